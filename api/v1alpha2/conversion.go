@@ -45,7 +45,10 @@ func (src *Cluster) ConvertTo(dstRaw conversion.Hub) error {
 	dst.Spec.ControlPlaneRef = restored.Spec.ControlPlaneRef
 	dst.Status.ControlPlaneReady = restored.Status.ControlPlaneReady
 	dst.Status.FailureDomains = restored.Status.FailureDomains
+	dst.Status.Conditions = restored.Status.Conditions
+	dst.Status.ReadyTime = restored.Status.ReadyTime
 	dst.Spec.Paused = restored.Spec.Paused
+	dst.Spec.Topology = restored.Spec.Topology
 
 	return nil
 }
@@ -110,6 +113,9 @@ func (src *Machine) ConvertTo(dstRaw conversion.Hub) error {
 		return err
 	}
 	restoreMachineSpec(&restored.Spec, &dst.Spec)
+	dst.Status.Conditions = restored.Status.Conditions
+	dst.Status.NodeReadyTime = restored.Status.NodeReadyTime
+	dst.Status.RemediationHistory = restored.Status.RemediationHistory
 
 	return nil
 }
@@ -120,6 +126,17 @@ func restoreMachineSpec(restored *v1alpha3.MachineSpec, dst *v1alpha3.MachineSpe
 	}
 	dst.Bootstrap.DataSecretName = restored.Bootstrap.DataSecretName
 	dst.FailureDomain = restored.FailureDomain
+	dst.SkipNodeDrain = restored.SkipNodeDrain
+	dst.NodeDrainTimeout = restored.NodeDrainTimeout
+	dst.NodeDrainGracePeriodSeconds = restored.NodeDrainGracePeriodSeconds
+	dst.NodeVolumeDetachTimeout = restored.NodeVolumeDetachTimeout
+	dst.PreDrainHookTimeout = restored.PreDrainHookTimeout
+	dst.PreTerminateHookTimeout = restored.PreTerminateHookTimeout
+	dst.PendingTimeout = restored.PendingTimeout
+	dst.ProvisioningTimeout = restored.ProvisioningTimeout
+	dst.ReplaceOnPhaseTimeout = restored.ReplaceOnPhaseTimeout
+	dst.Network = restored.Network
+	dst.NodeTaints = restored.NodeTaints
 }
 
 func (dst *Machine) ConvertFrom(srcRaw conversion.Hub) error {
@@ -177,6 +194,7 @@ func (src *MachineSet) ConvertTo(dstRaw conversion.Hub) error {
 		dst.Spec.ClusterName = restored.Spec.ClusterName
 	}
 	restoreMachineSpec(&restored.Spec.Template.Spec, &dst.Spec.Template.Spec)
+	dst.Status.RemediationHistory = restored.Status.RemediationHistory
 
 	return nil
 }
@@ -230,7 +248,11 @@ func (src *MachineDeployment) ConvertTo(dstRaw conversion.Hub) error {
 		dst.Spec.ClusterName = restored.Spec.ClusterName
 	}
 	dst.Spec.Paused = restored.Spec.Paused
+	dst.Spec.RolloutAfter = restored.Spec.RolloutAfter
 	dst.Status.Phase = restored.Status.Phase
+	dst.Status.PreflightCheckFailures = restored.Status.PreflightCheckFailures
+	dst.Status.Summary = restored.Status.Summary
+	dst.Status.Conditions = restored.Status.Conditions
 	restoreMachineSpec(&restored.Spec.Template.Spec, &dst.Spec.Template.Spec)
 
 	return nil
@@ -443,6 +443,7 @@ func autoConvert_v1alpha3_ClusterSpec_To_v1alpha2_ClusterSpec(in *v1alpha3.Clust
 	// WARNING: in.ControlPlaneEndpoint requires manual conversion: does not exist in peer-type
 	// WARNING: in.ControlPlaneRef requires manual conversion: does not exist in peer-type
 	out.InfrastructureRef = (*v1.ObjectReference)(unsafe.Pointer(in.InfrastructureRef))
+	// WARNING: in.Topology requires manual conversion: does not exist in peer-type
 	return nil
 }
 
@@ -464,6 +465,8 @@ func autoConvert_v1alpha3_ClusterStatus_To_v1alpha2_ClusterStatus(in *v1alpha3.C
 	out.InfrastructureReady = in.InfrastructureReady
 	out.ControlPlaneInitialized = in.ControlPlaneInitialized
 	// WARNING: in.ControlPlaneReady requires manual conversion: does not exist in peer-type
+	// WARNING: in.Conditions requires manual conversion: does not exist in peer-type
+	// WARNING: in.ReadyTime requires manual conversion: does not exist in peer-type
 	return nil
 }
 
@@ -626,6 +629,7 @@ func autoConvert_v1alpha3_MachineDeploymentSpec_To_v1alpha2_MachineDeploymentSpe
 	out.RevisionHistoryLimit = (*int32)(unsafe.Pointer(in.RevisionHistoryLimit))
 	out.Paused = in.Paused
 	out.ProgressDeadlineSeconds = (*int32)(unsafe.Pointer(in.ProgressDeadlineSeconds))
+	// WARNING: in.RolloutAfter requires manual conversion: does not exist in peer-type
 	return nil
 }
 
@@ -654,6 +658,9 @@ func autoConvert_v1alpha3_MachineDeploymentStatus_To_v1alpha2_MachineDeploymentS
 	out.AvailableReplicas = in.AvailableReplicas
 	out.UnavailableReplicas = in.UnavailableReplicas
 	// WARNING: in.Phase requires manual conversion: does not exist in peer-type
+	// WARNING: in.PreflightCheckFailures requires manual conversion: does not exist in peer-type
+	// WARNING: in.Summary requires manual conversion: does not exist in peer-type
+	// WARNING: in.Conditions requires manual conversion: does not exist in peer-type
 	return nil
 }
 
@@ -866,6 +873,7 @@ func autoConvert_v1alpha3_MachineSetStatus_To_v1alpha2_MachineSetStatus(in *v1al
 	out.ObservedGeneration = in.ObservedGeneration
 	// WARNING: in.FailureReason requires manual conversion: does not exist in peer-type
 	// WARNING: in.FailureMessage requires manual conversion: does not exist in peer-type
+	// WARNING: in.RemediationHistory requires manual conversion: does not exist in peer-type
 	return nil
 }
 
@@ -889,6 +897,17 @@ func autoConvert_v1alpha3_MachineSpec_To_v1alpha2_MachineSpec(in *v1alpha3.Machi
 	out.Version = (*string)(unsafe.Pointer(in.Version))
 	out.ProviderID = (*string)(unsafe.Pointer(in.ProviderID))
 	// WARNING: in.FailureDomain requires manual conversion: does not exist in peer-type
+	// WARNING: in.SkipNodeDrain requires manual conversion: does not exist in peer-type
+	// WARNING: in.NodeDrainTimeout requires manual conversion: does not exist in peer-type
+	// WARNING: in.NodeDrainGracePeriodSeconds requires manual conversion: does not exist in peer-type
+	// WARNING: in.NodeVolumeDetachTimeout requires manual conversion: does not exist in peer-type
+	// WARNING: in.PreDrainHookTimeout requires manual conversion: does not exist in peer-type
+	// WARNING: in.PreTerminateHookTimeout requires manual conversion: does not exist in peer-type
+	// WARNING: in.PendingTimeout requires manual conversion: does not exist in peer-type
+	// WARNING: in.ProvisioningTimeout requires manual conversion: does not exist in peer-type
+	// WARNING: in.ReplaceOnPhaseTimeout requires manual conversion: does not exist in peer-type
+	// WARNING: in.Network requires manual conversion: does not exist in peer-type
+	// WARNING: in.NodeTaints requires manual conversion: does not exist in peer-type
 	return nil
 }
 
@@ -915,6 +934,9 @@ func autoConvert_v1alpha3_MachineStatus_To_v1alpha2_MachineStatus(in *v1alpha3.M
 	out.Phase = in.Phase
 	out.BootstrapReady = in.BootstrapReady
 	out.InfrastructureReady = in.InfrastructureReady
+	// WARNING: in.Conditions requires manual conversion: does not exist in peer-type
+	// WARNING: in.NodeReadyTime requires manual conversion: does not exist in peer-type
+	// WARNING: in.RemediationHistory requires manual conversion: does not exist in peer-type
 	return nil
 }
 
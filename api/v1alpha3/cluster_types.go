@@ -53,10 +53,102 @@ type ClusterSpec struct {
 	// for provisioning infrastructure for a cluster in said provider.
 	// +optional
 	InfrastructureRef *corev1.ObjectReference `json:"infrastructureRef,omitempty"`
+
+	// Topology encapsulates the topology for the cluster.
+	// When set, the infrastructure cluster, the control plane and the MachineDeployments are created
+	// and kept up to date from the referenced ClusterClass.
+	// +optional
+	Topology *Topology `json:"topology,omitempty"`
 }
 
 // ANCHOR_END: ClusterSpec
 
+// ANCHOR: Topology
+
+// Topology encapsulates the information of the managed resources.
+type Topology struct {
+	// Class is the name of the ClusterClass object to create the topology from;
+	// the ClusterClass must be in the same namespace of the Cluster.
+	// +kubebuilder:validation:MinLength=1
+	Class string `json:"class"`
+
+	// Version is the Kubernetes version of the cluster.
+	// +kubebuilder:validation:MinLength=1
+	Version string `json:"version"`
+
+	// ControlPlane describes the cluster control plane.
+	// +optional
+	ControlPlane ControlPlaneTopology `json:"controlPlane,omitempty"`
+
+	// Workers encapsulates the different constructs that form the worker nodes
+	// for the cluster.
+	// +optional
+	Workers *WorkersTopology `json:"workers,omitempty"`
+
+	// Variables are the values of the variables defined in the ClusterClass.
+	// +optional
+	Variables []ClusterVariable `json:"variables,omitempty"`
+}
+
+// ControlPlaneTopology specifies the parameters for the control plane nodes in the cluster.
+type ControlPlaneTopology struct {
+	// Metadata is the metadata applied to the control plane object, in addition to the one defined in the ClusterClass.
+	// Only labels and annotations are used.
+	// +optional
+	Metadata ObjectMeta `json:"metadata,omitempty"`
+
+	// Replicas is the number of control plane nodes.
+	// If the value is nil, the control plane object is created without the number of Replicas
+	// and the provider default applies.
+	// +optional
+	Replicas *int32 `json:"replicas,omitempty"`
+}
+
+// WorkersTopology represents the different sets of worker nodes in the cluster.
+type WorkersTopology struct {
+	// MachineDeployments is a list of machine deployments in the cluster.
+	// +optional
+	MachineDeployments []MachineDeploymentTopology `json:"machineDeployments,omitempty"`
+}
+
+// MachineDeploymentTopology specifies the different parameters for a set of worker nodes in the topology.
+// This set of nodes is managed by a MachineDeployment object whose lifecycle is managed by the topology controller.
+type MachineDeploymentTopology struct {
+	// Metadata is the metadata applied to the Machines of the MachineDeployment, in addition to the one
+	// defined in the ClusterClass. Only labels and annotations are used.
+	// +optional
+	Metadata ObjectMeta `json:"metadata,omitempty"`
+
+	// Class is the name of the MachineDeploymentClass used to create the set of worker nodes.
+	// This should match one of the deployment classes defined in the ClusterClass object
+	// mentioned in the `Cluster.Spec.Topology.Class` field.
+	// +kubebuilder:validation:MinLength=1
+	Class string `json:"class"`
+
+	// Name is the unique identifier for this MachineDeploymentTopology; the MachineDeployment
+	// is named after the Cluster and this name, i.e. <cluster name>-<name>.
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+
+	// Replicas is the number of worker nodes belonging to this set.
+	// If the value is nil, the MachineDeployment is created without the number of Replicas (defaulting to 1)
+	// and it's assumed that an external entity (like cluster autoscaler) is responsible for the management
+	// of this value.
+	// +optional
+	Replicas *int32 `json:"replicas,omitempty"`
+}
+
+// ClusterVariable sets the value of a variable defined in the ClusterClass.
+type ClusterVariable struct {
+	// Name of the variable.
+	Name string `json:"name"`
+
+	// Value of the variable.
+	Value string `json:"value"`
+}
+
+// ANCHOR_END: Topology
+
 // ANCHOR: ClusterNetwork
 
 // ClusterNetwork specifies the different networking
@@ -124,6 +216,14 @@ type ClusterStatus struct {
 	// ControlPlaneReady defines if the control plane is ready.
 	// +optional
 	ControlPlaneReady bool `json:"controlPlaneReady,omitempty"`
+
+	// Conditions defines current service state of the Cluster.
+	// +optional
+	Conditions Conditions `json:"conditions,omitempty"`
+
+	// ReadyTime is the time the Cluster became Ready for the first time.
+	// +optional
+	ReadyTime *metav1.Time `json:"readyTime,omitempty"`
 }
 
 // ANCHOR_END: ClusterStatus
@@ -187,6 +287,14 @@ type Cluster struct {
 	Status ClusterStatus `json:"status,omitempty"`
 }
 
+func (in *Cluster) GetConditions() Conditions {
+	return in.Status.Conditions
+}
+
+func (in *Cluster) SetConditions(conditions Conditions) {
+	in.Status.Conditions = conditions
+}
+
 // +kubebuilder:object:root=true
 
 // ClusterList contains a list of Cluster
@@ -19,7 +19,10 @@ package v1alpha3
 import (
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/apimachinery/pkg/util/version"
+	utilversion "sigs.k8s.io/cluster-api/util/version"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
 )
@@ -48,12 +51,13 @@ func (c *Cluster) Default() {
 
 // ValidateCreate implements webhook.Validator so a webhook will be registered for the type
 func (c *Cluster) ValidateCreate() error {
-	return c.validate()
+	return c.validate(nil)
 }
 
 // ValidateUpdate implements webhook.Validator so a webhook will be registered for the type
 func (c *Cluster) ValidateUpdate(old runtime.Object) error {
-	return c.validate()
+	oldCluster, _ := old.(*Cluster)
+	return c.validate(oldCluster)
 }
 
 // ValidateDelete implements webhook.Validator so a webhook will be registered for the type
@@ -61,7 +65,7 @@ func (c *Cluster) ValidateDelete() error {
 	return nil
 }
 
-func (c *Cluster) validate() error {
+func (c *Cluster) validate(old *Cluster) error {
 	var allErrs field.ErrorList
 	if c.Spec.InfrastructureRef != nil && c.Spec.InfrastructureRef.Namespace != c.Namespace {
 		allErrs = append(
@@ -87,8 +91,95 @@ func (c *Cluster) validate() error {
 
 	}
 
+	allErrs = append(allErrs, c.validateTopology(old)...)
+
 	if len(allErrs) == 0 {
 		return nil
 	}
 	return apierrors.NewInvalid(GroupVersion.WithKind("Cluster").GroupKind(), c.Name, allErrs)
 }
+
+func (c *Cluster) validateTopology(old *Cluster) field.ErrorList {
+	var allErrs field.ErrorList
+	topologyPath := field.NewPath("spec", "topology")
+
+	if old != nil && (old.Spec.Topology == nil) != (c.Spec.Topology == nil) {
+		allErrs = append(
+			allErrs,
+			field.Forbidden(topologyPath, "can not be added to or removed from an existing Cluster"),
+		)
+	}
+
+	topology := c.Spec.Topology
+	if topology == nil {
+		return allErrs
+	}
+
+	if topology.Class == "" {
+		allErrs = append(
+			allErrs,
+			field.Required(topologyPath.Child("class"), "class must be set"),
+		)
+	}
+	if old != nil && old.Spec.Topology != nil && old.Spec.Topology.Class != topology.Class {
+		allErrs = append(
+			allErrs,
+			field.Forbidden(topologyPath.Child("class"), "field is immutable"),
+		)
+	}
+
+	if _, err := version.ParseSemantic(topology.Version); err != nil {
+		allErrs = append(
+			allErrs,
+			field.Invalid(topologyPath.Child("version"), topology.Version, "must be a valid semantic version"),
+		)
+	}
+	if old != nil && old.Spec.Topology != nil {
+		if err := utilversion.ValidateUpgrade(old.Spec.Topology.Version, topology.Version); err != nil {
+			allErrs = append(
+				allErrs,
+				field.Forbidden(topologyPath.Child("version"), err.Error()),
+			)
+		}
+	}
+
+	if topology.Workers != nil {
+		names := sets.NewString()
+		for i, md := range topology.Workers.MachineDeployments {
+			mdPath := topologyPath.Child("workers", "machineDeployments").Index(i)
+			if md.Class == "" {
+				allErrs = append(
+					allErrs,
+					field.Required(mdPath.Child("class"), "class must be set"),
+				)
+			}
+			if md.Name == "" {
+				allErrs = append(
+					allErrs,
+					field.Required(mdPath.Child("name"), "name must be set"),
+				)
+				continue
+			}
+			if names.Has(md.Name) {
+				allErrs = append(
+					allErrs,
+					field.Duplicate(mdPath.Child("name"), md.Name),
+				)
+			}
+			names.Insert(md.Name)
+		}
+	}
+
+	variables := sets.NewString()
+	for i, variable := range topology.Variables {
+		if variables.Has(variable.Name) {
+			allErrs = append(
+				allErrs,
+				field.Duplicate(topologyPath.Child("variables").Index(i).Child("name"), variable.Name),
+			)
+		}
+		variables.Insert(variable.Name)
+	}
+
+	return allErrs
+}
@@ -98,3 +98,122 @@ func TestClusterValidation(t *testing.T) {
 		})
 	}
 }
+
+func TestClusterTopologyValidation(t *testing.T) {
+	valid := &Cluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "foo",
+		},
+		Spec: ClusterSpec{
+			Topology: &Topology{
+				Class:   "class",
+				Version: "v1.18.2",
+				Workers: &WorkersTopology{
+					MachineDeployments: []MachineDeploymentTopology{
+						{Class: "default-worker", Name: "md-0"},
+						{Class: "default-worker", Name: "md-1"},
+					},
+				},
+			},
+		},
+	}
+
+	invalidVersion := valid.DeepCopy()
+	invalidVersion.Spec.Topology.Version = "latest"
+
+	missingClass := valid.DeepCopy()
+	missingClass.Spec.Topology.Class = ""
+
+	duplicateName := valid.DeepCopy()
+	duplicateName.Spec.Topology.Workers.MachineDeployments[1].Name = "md-0"
+
+	duplicateVariable := valid.DeepCopy()
+	duplicateVariable.Spec.Topology.Variables = []ClusterVariable{{Name: "IMAGE_ID", Value: "a"}, {Name: "IMAGE_ID", Value: "b"}}
+
+	changedClass := valid.DeepCopy()
+	changedClass.Spec.Topology.Class = "other-class"
+
+	removedTopology := valid.DeepCopy()
+	removedTopology.Spec.Topology = nil
+
+	upgraded := valid.DeepCopy()
+	upgraded.Spec.Topology.Version = "v1.19.1"
+
+	skippedMinor := valid.DeepCopy()
+	skippedMinor.Spec.Topology.Version = "v1.20.0"
+
+	tests := []struct {
+		name      string
+		c         *Cluster
+		old       *Cluster
+		expectErr bool
+	}{
+		{
+			name:      "should succeed with a valid topology",
+			c:         valid,
+			expectErr: false,
+		},
+		{
+			name:      "should return error with an invalid version",
+			c:         invalidVersion,
+			expectErr: true,
+		},
+		{
+			name:      "should return error without a class",
+			c:         missingClass,
+			expectErr: true,
+		},
+		{
+			name:      "should return error with duplicated machine deployment names",
+			c:         duplicateName,
+			expectErr: true,
+		},
+		{
+			name:      "should return error with duplicated variables",
+			c:         duplicateVariable,
+			expectErr: true,
+		},
+		{
+			name:      "should succeed when the version changes",
+			c:         upgraded,
+			old:       valid,
+			expectErr: false,
+		},
+		{
+			name:      "should return error when the version skips a minor version",
+			c:         skippedMinor,
+			old:       valid,
+			expectErr: true,
+		},
+		{
+			name:      "should return error when the class changes",
+			c:         changedClass,
+			old:       valid,
+			expectErr: true,
+		},
+		{
+			name:      "should return error when the topology is removed",
+			c:         removedTopology,
+			old:       valid,
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			var err error
+			if tt.old != nil {
+				err = tt.c.ValidateUpdate(tt.old)
+			} else {
+				err = tt.c.ValidateCreate()
+			}
+			if tt.expectErr {
+				g.Expect(err).To(HaveOccurred())
+			} else {
+				g.Expect(err).NotTo(HaveOccurred())
+			}
+		})
+	}
+}
@@ -0,0 +1,148 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha3
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ANCHOR: ClusterClassSpec
+
+// ClusterClassSpec describes the desired state of the ClusterClass.
+type ClusterClassSpec struct {
+	// Infrastructure is a reference to a provider-specific template that holds the details
+	// for provisioning the infrastructure cluster for the underlying provider.
+	// The template must follow the template contract, with the object to be created in spec.template.
+	// +optional
+	Infrastructure LocalObjectTemplate `json:"infrastructure,omitempty"`
+
+	// ControlPlane is a reference to a provider-specific template that holds the details
+	// for provisioning the control plane of the Cluster.
+	ControlPlane ControlPlaneClass `json:"controlPlane"`
+
+	// Workers describes the worker pools that can be used in the topology of a Cluster.
+	// +optional
+	Workers WorkersClass `json:"workers,omitempty"`
+
+	// Variables defines the variables that can be set in the topology of a Cluster,
+	// and that are substituted in the objects stamped from the templates of the class.
+	// +optional
+	Variables []ClusterClassVariable `json:"variables,omitempty"`
+}
+
+// ANCHOR_END: ClusterClassSpec
+
+// LocalObjectTemplate defines a reference to a template in the same namespace of the ClusterClass.
+type LocalObjectTemplate struct {
+	// Ref is a required reference to a custom resource
+	// offered by a provider.
+	Ref *corev1.ObjectReference `json:"ref"`
+}
+
+// ControlPlaneClass defines the class for the control plane.
+type ControlPlaneClass struct {
+	// Metadata is the metadata applied to the control plane object.
+	// Only labels and annotations are used.
+	// +optional
+	Metadata ObjectMeta `json:"metadata,omitempty"`
+
+	// LocalObjectTemplate contains the reference to the control plane template; the control plane object is
+	// created from spec.template, setting spec.version, spec.replicas and, if MachineInfrastructure is defined,
+	// spec.infrastructureTemplate.
+	LocalObjectTemplate `json:",inline"`
+
+	// MachineInfrastructure defines the infrastructure template used by the control plane Machines; it is
+	// required for control plane providers creating Machines (e.g. KubeadmControlPlane).
+	// +optional
+	MachineInfrastructure *LocalObjectTemplate `json:"machineInfrastructure,omitempty"`
+}
+
+// WorkersClass is a collection of classes for the worker pools.
+type WorkersClass struct {
+	// MachineDeployments is a list of classes for the MachineDeployments used in the topology of a Cluster.
+	// +optional
+	MachineDeployments []MachineDeploymentClass `json:"machineDeployments,omitempty"`
+}
+
+// MachineDeploymentClass serves as a template to define a set of worker nodes of the cluster.
+type MachineDeploymentClass struct {
+	// Class is the name of the class, used for referencing it in the topology of a Cluster.
+	// It must be unique within the ClusterClass.
+	Class string `json:"class"`
+
+	// Template is a local struct containing a collection of templates for the creation of
+	// the MachineDeployment objects.
+	Template MachineDeploymentClassTemplate `json:"template"`
+}
+
+// MachineDeploymentClassTemplate defines how a MachineDeployment generated from a MachineDeploymentClass should look like.
+type MachineDeploymentClassTemplate struct {
+	// Metadata is the metadata applied to the Machines of the MachineDeployment.
+	// Only labels and annotations are used.
+	// +optional
+	Metadata ObjectMeta `json:"metadata,omitempty"`
+
+	// Bootstrap contains the bootstrap template reference to be used
+	// for the creation of worker Machines.
+	Bootstrap LocalObjectTemplate `json:"bootstrap"`
+
+	// Infrastructure contains the infrastructure template reference to be used
+	// for the creation of worker Machines.
+	Infrastructure LocalObjectTemplate `json:"infrastructure"`
+}
+
+// ClusterClassVariable defines a variable that can be set in the topology of a Cluster.
+type ClusterClassVariable struct {
+	// Name of the variable; occurrences of ${Name} in the string values of the templates
+	// are replaced with the value of the variable.
+	// +kubebuilder:validation:Pattern=`^[A-Za-z_][A-Za-z0-9_]*$`
+	Name string `json:"name"`
+
+	// Required specifies if the variable must be set in the topology of a Cluster.
+	// +optional
+	Required bool `json:"required,omitempty"`
+
+	// Default is the value of the variable when it is not set in the topology of a Cluster.
+	// +optional
+	Default string `json:"default,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:path=clusterclasses,scope=Namespaced,categories=cluster-api
+// +kubebuilder:storageversion
+
+// ClusterClass is a template which can be used to create managed topologies.
+type ClusterClass struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec ClusterClassSpec `json:"spec,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// ClusterClassList contains a list of ClusterClass.
+type ClusterClassList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ClusterClass `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ClusterClass{}, &ClusterClassList{})
+}
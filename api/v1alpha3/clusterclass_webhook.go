@@ -0,0 +1,136 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha3
+
+import (
+	"regexp"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+)
+
+var variableNameRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+func (c *ClusterClass) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(c).
+		Complete()
+}
+
+// +kubebuilder:webhook:verbs=create;update,path=/validate-cluster-x-k8s-io-v1alpha3-clusterclass,mutating=false,failurePolicy=fail,groups=cluster.x-k8s.io,resources=clusterclasses,versions=v1alpha3,name=validation.clusterclass.cluster.x-k8s.io
+
+var _ webhook.Validator = &ClusterClass{}
+
+// ValidateCreate implements webhook.Validator so a webhook will be registered for the type
+func (c *ClusterClass) ValidateCreate() error {
+	return c.validate()
+}
+
+// ValidateUpdate implements webhook.Validator so a webhook will be registered for the type
+func (c *ClusterClass) ValidateUpdate(old runtime.Object) error {
+	return c.validate()
+}
+
+// ValidateDelete implements webhook.Validator so a webhook will be registered for the type
+func (c *ClusterClass) ValidateDelete() error {
+	return nil
+}
+
+func (c *ClusterClass) validate() error {
+	var allErrs field.ErrorList
+	specPath := field.NewPath("spec")
+
+	if c.Spec.Infrastructure.Ref != nil {
+		allErrs = append(allErrs, c.validateTemplate(c.Spec.Infrastructure, specPath.Child("infrastructure"))...)
+	}
+	allErrs = append(allErrs, c.validateTemplate(c.Spec.ControlPlane.LocalObjectTemplate, specPath.Child("controlPlane"))...)
+	if c.Spec.ControlPlane.MachineInfrastructure != nil {
+		allErrs = append(allErrs, c.validateTemplate(*c.Spec.ControlPlane.MachineInfrastructure, specPath.Child("controlPlane", "machineInfrastructure"))...)
+	}
+
+	classes := sets.NewString()
+	for i, md := range c.Spec.Workers.MachineDeployments {
+		mdPath := specPath.Child("workers", "machineDeployments").Index(i)
+		switch {
+		case md.Class == "":
+			allErrs = append(
+				allErrs,
+				field.Required(mdPath.Child("class"), "class must be set"),
+			)
+		case classes.Has(md.Class):
+			allErrs = append(
+				allErrs,
+				field.Duplicate(mdPath.Child("class"), md.Class),
+			)
+		}
+		classes.Insert(md.Class)
+
+		allErrs = append(allErrs, c.validateTemplate(md.Template.Bootstrap, mdPath.Child("template", "bootstrap"))...)
+		allErrs = append(allErrs, c.validateTemplate(md.Template.Infrastructure, mdPath.Child("template", "infrastructure"))...)
+	}
+
+	variables := sets.NewString()
+	for i, variable := range c.Spec.Variables {
+		namePath := specPath.Child("variables").Index(i).Child("name")
+		switch {
+		case !variableNameRegex.MatchString(variable.Name):
+			allErrs = append(
+				allErrs,
+				field.Invalid(namePath, variable.Name, "must start with a letter or an underscore, followed by letters, digits or underscores"),
+			)
+		case variables.Has(variable.Name):
+			allErrs = append(
+				allErrs,
+				field.Duplicate(namePath, variable.Name),
+			)
+		}
+		variables.Insert(variable.Name)
+	}
+
+	if len(allErrs) == 0 {
+		return nil
+	}
+	return apierrors.NewInvalid(GroupVersion.WithKind("ClusterClass").GroupKind(), c.Name, allErrs)
+}
+
+// validateTemplate checks that a template is referenced, in the same namespace of the ClusterClass.
+func (c *ClusterClass) validateTemplate(template LocalObjectTemplate, path *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	if template.Ref == nil {
+		return append(
+			allErrs,
+			field.Required(path.Child("ref"), "template reference must be set"),
+		)
+	}
+	if template.Ref.Name == "" {
+		allErrs = append(
+			allErrs,
+			field.Required(path.Child("ref", "name"), "template name must be set"),
+		)
+	}
+	if template.Ref.Namespace != "" && template.Ref.Namespace != c.Namespace {
+		allErrs = append(
+			allErrs,
+			field.Invalid(path.Child("ref", "namespace"), template.Ref.Namespace, "must match metadata.namespace"),
+		)
+	}
+	return allErrs
+}
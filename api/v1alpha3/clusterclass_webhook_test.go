@@ -0,0 +1,132 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha3
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestClusterClassValidation(t *testing.T) {
+	ref := func(kind, name string) LocalObjectTemplate {
+		return LocalObjectTemplate{Ref: &corev1.ObjectReference{Kind: kind, Name: name}}
+	}
+
+	valid := &ClusterClass{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "class",
+			Namespace: "foo",
+		},
+		Spec: ClusterClassSpec{
+			Infrastructure: ref("InfrastructureClusterTemplate", "infra"),
+			ControlPlane: ControlPlaneClass{
+				LocalObjectTemplate: ref("ControlPlaneTemplate", "cp"),
+			},
+			Workers: WorkersClass{
+				MachineDeployments: []MachineDeploymentClass{
+					{
+						Class: "default-worker",
+						Template: MachineDeploymentClassTemplate{
+							Bootstrap:      ref("BootstrapConfigTemplate", "bootstrap"),
+							Infrastructure: ref("InfrastructureMachineTemplate", "machine"),
+						},
+					},
+				},
+			},
+			Variables: []ClusterClassVariable{
+				{Name: "IMAGE_ID", Required: true},
+			},
+		},
+	}
+
+	missingControlPlane := valid.DeepCopy()
+	missingControlPlane.Spec.ControlPlane.Ref = nil
+
+	invalidNamespace := valid.DeepCopy()
+	invalidNamespace.Spec.Infrastructure.Ref.Namespace = "bar"
+
+	duplicateClass := valid.DeepCopy()
+	duplicateClass.Spec.Workers.MachineDeployments = append(duplicateClass.Spec.Workers.MachineDeployments, duplicateClass.Spec.Workers.MachineDeployments[0])
+
+	missingBootstrap := valid.DeepCopy()
+	missingBootstrap.Spec.Workers.MachineDeployments[0].Template.Bootstrap.Ref = nil
+
+	invalidVariable := valid.DeepCopy()
+	invalidVariable.Spec.Variables[0].Name = "IMAGE-ID"
+
+	duplicateVariable := valid.DeepCopy()
+	duplicateVariable.Spec.Variables = append(duplicateVariable.Spec.Variables, ClusterClassVariable{Name: "IMAGE_ID"})
+
+	tests := []struct {
+		name      string
+		expectErr bool
+		c         *ClusterClass
+	}{
+		{
+			name:      "should succeed with a valid class",
+			expectErr: false,
+			c:         valid,
+		},
+		{
+			name:      "should return error when the control plane template is missing",
+			expectErr: true,
+			c:         missingControlPlane,
+		},
+		{
+			name:      "should return error when a template is in a different namespace",
+			expectErr: true,
+			c:         invalidNamespace,
+		},
+		{
+			name:      "should return error when a machine deployment class is duplicated",
+			expectErr: true,
+			c:         duplicateClass,
+		},
+		{
+			name:      "should return error when the bootstrap template of a machine deployment class is missing",
+			expectErr: true,
+			c:         missingBootstrap,
+		},
+		{
+			name:      "should return error when a variable name is invalid",
+			expectErr: true,
+			c:         invalidVariable,
+		},
+		{
+			name:      "should return error when a variable is duplicated",
+			expectErr: true,
+			c:         duplicateVariable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			if tt.expectErr {
+				g.Expect(tt.c.ValidateCreate()).NotTo(Succeed())
+				g.Expect(tt.c.ValidateUpdate(valid)).NotTo(Succeed())
+			} else {
+				g.Expect(tt.c.ValidateCreate()).To(Succeed())
+				g.Expect(tt.c.ValidateUpdate(valid)).To(Succeed())
+			}
+		})
+	}
+}
@@ -0,0 +1,116 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha3
+
+import (
+	"fmt"
+	"reflect"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/cluster-api/feature"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+)
+
+func (m *ClusterResourceSet) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(m).
+		Complete()
+}
+
+// +kubebuilder:webhook:verbs=create;update,path=/validate-cluster-x-k8s-io-v1alpha3-clusterresourceset,mutating=false,failurePolicy=fail,groups=cluster.x-k8s.io,resources=clusterresourcesets,versions=v1alpha3,name=validation.clusterresourceset.cluster.x-k8s.io
+// +kubebuilder:webhook:verbs=create;update,path=/mutate-cluster-x-k8s-io-v1alpha3-clusterresourceset,mutating=true,failurePolicy=fail,groups=cluster.x-k8s.io,resources=clusterresourcesets,versions=v1alpha3,name=default.clusterresourceset.cluster.x-k8s.io
+
+var _ webhook.Defaulter = &ClusterResourceSet{}
+var _ webhook.Validator = &ClusterResourceSet{}
+
+// Default implements webhook.Defaulter so a webhook will be registered for the type
+func (m *ClusterResourceSet) Default() {
+	// ClusterResourceSet Strategy defaults to ApplyOnce.
+	if m.Spec.Strategy == "" {
+		m.Spec.Strategy = string(ClusterResourceSetStrategyApplyOnce)
+	}
+}
+
+// ValidateCreate implements webhook.Validator so a webhook will be registered for the type
+func (m *ClusterResourceSet) ValidateCreate() error {
+	// ClusterResourceSets can't be created while the ClusterResourceSet feature is disabled.
+	if !feature.Gates.Enabled(feature.ClusterResourceSet) {
+		return apierrors.NewInvalid(GroupVersion.WithKind("ClusterResourceSet").GroupKind(), m.Name, field.ErrorList{
+			field.Forbidden(field.NewPath("spec"), "can be set only if the ClusterResourceSet feature gate is enabled"),
+		})
+	}
+	return m.validate(nil)
+}
+
+// ValidateUpdate implements webhook.Validator so a webhook will be registered for the type
+func (m *ClusterResourceSet) ValidateUpdate(old runtime.Object) error {
+	crs, ok := old.(*ClusterResourceSet)
+	if !ok {
+		return apierrors.NewBadRequest(fmt.Sprintf("expected a ClusterResourceSet but got a %T", old))
+	}
+	return m.validate(crs)
+}
+
+// ValidateDelete implements webhook.Validator so a webhook will be registered for the type
+func (m *ClusterResourceSet) ValidateDelete() error {
+	return nil
+}
+
+func (m *ClusterResourceSet) validate(old *ClusterResourceSet) error {
+	var allErrs field.ErrorList
+
+	// Validate selector parses as Selector
+	selector, err := metav1.LabelSelectorAsSelector(&m.Spec.ClusterSelector)
+	if err != nil {
+		allErrs = append(
+			allErrs,
+			field.Invalid(field.NewPath("spec", "clusterSelector"), m.Spec.ClusterSelector, err.Error()),
+		)
+	}
+
+	// Validate that the selector isn't empty as null selectors do not select any objects.
+	if selector != nil && selector.Empty() {
+		allErrs = append(
+			allErrs,
+			field.Invalid(field.NewPath("spec", "clusterSelector"), m.Spec.ClusterSelector, "selector must not be empty"),
+		)
+	}
+
+	if old != nil && old.Spec.Strategy != "" && old.Spec.Strategy != m.Spec.Strategy {
+		allErrs = append(
+			allErrs,
+			field.Invalid(field.NewPath("spec", "strategy"), m.Spec.Strategy, "field is immutable"),
+		)
+	}
+
+	if old != nil && !reflect.DeepEqual(old.Spec.ClusterSelector, m.Spec.ClusterSelector) {
+		allErrs = append(
+			allErrs,
+			field.Invalid(field.NewPath("spec", "clusterSelector"), m.Spec.ClusterSelector, "field is immutable"),
+		)
+	}
+
+	if len(allErrs) == 0 {
+		return nil
+	}
+
+	return apierrors.NewInvalid(GroupVersion.WithKind("ClusterResourceSet").GroupKind(), m.Name, allErrs)
+}
@@ -0,0 +1,141 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha3
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/cluster-api/feature"
+)
+
+func TestClusterResourceSetDefault(t *testing.T) {
+	g := NewWithT(t)
+	crs := &ClusterResourceSet{}
+
+	crs.Default()
+
+	g.Expect(crs.Spec.Strategy).To(Equal(string(ClusterResourceSetStrategyApplyOnce)))
+}
+
+func TestClusterResourceSetLabelSelectorAsSelectorValidation(t *testing.T) {
+	defer feature.SetFeatureGateDuringTest(feature.Gates, feature.ClusterResourceSet, true)()
+
+	tests := []struct {
+		name      string
+		selectors map[string]string
+		expectErr bool
+	}{
+		{
+			name:      "should not return error for valid selector",
+			selectors: map[string]string{"foo": "bar"},
+			expectErr: false,
+		},
+		{
+			name:      "should return error for invalid selector",
+			selectors: map[string]string{"-123-foo": "bar"},
+			expectErr: true,
+		},
+		{
+			name:      "should return error for empty selector",
+			selectors: map[string]string{},
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+			crs := &ClusterResourceSet{
+				Spec: ClusterResourceSetSpec{
+					ClusterSelector: metav1.LabelSelector{
+						MatchLabels: tt.selectors,
+					},
+				},
+			}
+			if tt.expectErr {
+				g.Expect(crs.ValidateCreate()).NotTo(Succeed())
+				g.Expect(crs.ValidateUpdate(crs)).NotTo(Succeed())
+			} else {
+				g.Expect(crs.ValidateCreate()).To(Succeed())
+				g.Expect(crs.ValidateUpdate(crs)).To(Succeed())
+			}
+		})
+	}
+}
+
+func TestClusterResourceSetImmutableFields(t *testing.T) {
+	tests := []struct {
+		name        string
+		oldStrategy string
+		newStrategy string
+		oldSelector map[string]string
+		newSelector map[string]string
+		expectErr   bool
+	}{
+		{
+			name:        "when nothing has changed",
+			oldStrategy: string(ClusterResourceSetStrategyApplyOnce),
+			newStrategy: string(ClusterResourceSetStrategyApplyOnce),
+			oldSelector: map[string]string{"foo": "bar"},
+			newSelector: map[string]string{"foo": "bar"},
+			expectErr:   false,
+		},
+		{
+			name:        "when the strategy has changed",
+			oldStrategy: string(ClusterResourceSetStrategyApplyOnce),
+			newStrategy: string(ClusterResourceSetStrategyReconcile),
+			oldSelector: map[string]string{"foo": "bar"},
+			newSelector: map[string]string{"foo": "bar"},
+			expectErr:   true,
+		},
+		{
+			name:        "when the cluster selector has changed",
+			oldStrategy: string(ClusterResourceSetStrategyApplyOnce),
+			newStrategy: string(ClusterResourceSetStrategyApplyOnce),
+			oldSelector: map[string]string{"foo": "bar"},
+			newSelector: map[string]string{"foo": "baz"},
+			expectErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			newCRS := &ClusterResourceSet{
+				Spec: ClusterResourceSetSpec{
+					ClusterSelector: metav1.LabelSelector{MatchLabels: tt.newSelector},
+					Strategy:        tt.newStrategy,
+				},
+			}
+			oldCRS := &ClusterResourceSet{
+				Spec: ClusterResourceSetSpec{
+					ClusterSelector: metav1.LabelSelector{MatchLabels: tt.oldSelector},
+					Strategy:        tt.oldStrategy,
+				},
+			}
+
+			if tt.expectErr {
+				g.Expect(newCRS.ValidateUpdate(oldCRS)).NotTo(Succeed())
+			} else {
+				g.Expect(newCRS.ValidateUpdate(oldCRS)).To(Succeed())
+			}
+		})
+	}
+}
@@ -30,12 +30,132 @@ const (
 	// tool uses this label for implementing provider's lifecycle operations.
 	ProviderLabelName = "cluster.x-k8s.io/provider"
 
+	// ClusterTopologyOwnedLabel is the label set on all the objects stamped from a ClusterClass
+	// by the topology controller for a Cluster with a managed topology.
+	ClusterTopologyOwnedLabel = "topology.cluster.x-k8s.io/owned"
+
+	// ClusterTopologyMachineDeploymentLabelName is the label set on the MachineDeployments (and the referenced
+	// templates) stamped for a worker pool of a managed topology; the value is the name of the pool in the topology.
+	ClusterTopologyMachineDeploymentLabelName = "topology.cluster.x-k8s.io/deployment-name"
+
 	// PausedAnnotation is an annotation that can be applied to any Cluster API
 	// object to prevent a controller from processing a resource.
 	//
 	// Controllers working with Cluster API objects must check the existence of this annotation
 	// on the reconciled object.
+	//
+	// When a Cluster is paused, the core controllers add this annotation to the infrastructure, control plane and
+	// bootstrap objects referenced by the Cluster, Machines and MachinePools, so providers stop reconciling them too.
 	PausedAnnotation = "cluster.x-k8s.io/paused"
+
+	// PausePropagatedAnnotation is set together with PausedAnnotation by the core controllers when propagating
+	// the pause of a Cluster to a provider object; the annotations are removed when the Cluster is resumed, while
+	// a PausedAnnotation set by users is never removed.
+	PausePropagatedAnnotation = "cluster.x-k8s.io/pause-propagated"
+
+	// PropagateLabelsAnnotation is an annotation that can be applied to a Cluster for selecting, with a comma separated
+	// list of label keys, the Cluster labels to be propagated to all the objects belonging to the Cluster
+	// (e.g. MachineDeployments, MachineSets, Machines and the referenced infrastructure and bootstrap objects).
+	//
+	// Propagated labels are added or updated, but never removed from the objects belonging to the Cluster.
+	PropagateLabelsAnnotation = "cluster.x-k8s.io/propagate-labels"
+
+	// ManagedByAnnotation is an annotation that can be applied to the infrastructure cluster object referenced by a Cluster
+	// for marking the infrastructure as managed by an external system, e.g. a pre-existing VPC/network; the value of the
+	// annotation can be used for identifying the system managing the infrastructure.
+	//
+	// Infrastructure providers must not reconcile externally managed objects, whose status (e.g. ready) is set by the
+	// external system. The Cluster controller does not set a controller reference on externally managed objects, so they
+	// are not garbage collected, and it does not delete them when the Cluster is deleted.
+	ManagedByAnnotation = "cluster.x-k8s.io/managed-by"
+
+	// DeleteMachineAnnotation marks control plane and worker nodes that will be given priority for deletion
+	// when a MachineSet scales down. This annotation is given top priority on all delete policies.
+	DeleteMachineAnnotation = "cluster.x-k8s.io/delete-machine"
+
+	// DisableMachineCreateAnnotation is set by the MachineDeployment controller on the old MachineSets of a
+	// MachineDeployment using the OnDelete strategy, so the MachineSets do not replace the deleted Machines.
+	DisableMachineCreateAnnotation = "cluster.x-k8s.io/disable-machine-create"
+
+	// SkipRemediationAnnotation is an annotation that can be applied to a Machine to prevent MachineHealthChecks
+	// from remediating it, e.g. for keeping an unhealthy Machine around for troubleshooting.
+	// The Machine is still health checked and counted as unhealthy.
+	SkipRemediationAnnotation = "cluster.x-k8s.io/skip-remediation"
+
+	// SkipUpgradePreflightChecksAnnotation is an annotation that can be applied to a MachineDeployment or to a
+	// control plane to start a rollout even if the preflight checks against the workload cluster are failing.
+	SkipUpgradePreflightChecksAnnotation = "cluster.x-k8s.io/skip-upgrade-preflight-checks"
+
+	// ImageSupersededByAnnotation is an annotation set by image-builder pipelines on an infrastructure machine template
+	// referencing an image channel (spec.imageChannel) when a new OS image is available for the channel; the value is
+	// the name of the infrastructure machine template, of the same kind and in the same namespace, using the new image.
+	ImageSupersededByAnnotation = "cluster.x-k8s.io/image-superseded-by"
+
+	// ImageAutoRolloutAnnotation is an annotation that can be applied to a MachineDeployment for automatically rolling
+	// out new OS images, by replacing its infrastructure machine template with the one superseding it.
+	ImageAutoRolloutAnnotation = "cluster.x-k8s.io/image-auto-rollout"
+
+	// MaintenanceWindowAnnotation is an annotation that can be applied to a MachineDeployment for restricting the
+	// automatic rollouts of new OS images to a maintenance window, in the "[days ]HH:MM-HH:MM" format, in UTC,
+	// e.g. "Sat,Sun 02:00-06:00"; if days are not specified, the window applies to every day.
+	MaintenanceWindowAnnotation = "cluster.x-k8s.io/maintenance-window"
+
+	// ImageUpdateAvailableAnnotation is the annotation set by the MachineDeployment controller with the name of the
+	// infrastructure machine template using a new OS image, while the new image is not rolled out.
+	ImageUpdateAvailableAnnotation = "cluster.x-k8s.io/image-update-available"
+
+	// NodeMetadataDomain is the domain of the Machine labels and annotations that are synced to the Node of the
+	// Machine, together with the labels in the NodeRoleLabelDomain. Changes to labels and annotations in these
+	// domains on the template of a MachineDeployment or of a control plane are applied in place to the existing
+	// Machines and Nodes, without triggering a rollout.
+	NodeMetadataDomain = "node.cluster.x-k8s.io"
+
+	// NodeRoleLabelDomain is the domain of the Node role labels, e.g. node-role.kubernetes.io/worker.
+	NodeRoleLabelDomain = "node-role.kubernetes.io"
+
+	// ManagedNodeLabelsAnnotation is the annotation set on a Node with the comma separated list of the label keys
+	// synced from the Machine, so labels removed from the Machine can be removed from the Node.
+	ManagedNodeLabelsAnnotation = "cluster.x-k8s.io/managed-node-labels"
+
+	// ManagedNodeAnnotationsAnnotation is the annotation set on a Node with the comma separated list of the
+	// annotation keys synced from the Machine, so annotations removed from the Machine can be removed from the Node.
+	ManagedNodeAnnotationsAnnotation = "cluster.x-k8s.io/managed-node-annotations"
+
+	// NodeMetadataSyncedAnnotation is the annotation set on a Machine whose labels, annotations or taints have been
+	// synced to its Node, so they are removed from the Node once they are removed from the Machine.
+	NodeMetadataSyncedAnnotation = "cluster.x-k8s.io/node-metadata-synced"
+
+	// ManagedNodeTaintsAnnotation is the annotation set on a Node with the comma separated list of the taints,
+	// in the key:effect format, synced from the Machine, so taints removed from the Machine can be removed from the Node.
+	ManagedNodeTaintsAnnotation = "cluster.x-k8s.io/managed-node-taints"
+)
+
+// Annotations set on MachineDeployments and MachineSets for the cluster-autoscaler, describing the Nodes that would be
+// created when scaling up, so MachineDeployments and MachineSets can be scaled from zero replicas.
+const (
+	// AutoscalerCPUCapacityAnnotation is the CPU capacity of the Nodes.
+	AutoscalerCPUCapacityAnnotation = "capacity.cluster-autoscaler.kubernetes.io/cpu"
+
+	// AutoscalerMemoryCapacityAnnotation is the memory capacity of the Nodes.
+	AutoscalerMemoryCapacityAnnotation = "capacity.cluster-autoscaler.kubernetes.io/memory"
+
+	// AutoscalerEphemeralDiskCapacityAnnotation is the ephemeral storage capacity of the Nodes.
+	AutoscalerEphemeralDiskCapacityAnnotation = "capacity.cluster-autoscaler.kubernetes.io/ephemeral-disk"
+
+	// AutoscalerMaxPodsCapacityAnnotation is the maximum number of pods on the Nodes.
+	AutoscalerMaxPodsCapacityAnnotation = "capacity.cluster-autoscaler.kubernetes.io/maxPods"
+
+	// AutoscalerGPUTypeAnnotation is the name of the GPU resource of the Nodes, e.g. nvidia.com/gpu.
+	AutoscalerGPUTypeAnnotation = "capacity.cluster-autoscaler.kubernetes.io/gpu-type"
+
+	// AutoscalerGPUCountAnnotation is the number of GPUs of the Nodes.
+	AutoscalerGPUCountAnnotation = "capacity.cluster-autoscaler.kubernetes.io/gpu-count"
+
+	// AutoscalerLabelsAnnotation is the comma separated list of the labels of the Nodes, in the key=value format.
+	AutoscalerLabelsAnnotation = "capacity.cluster-autoscaler.kubernetes.io/labels"
+
+	// AutoscalerTaintsAnnotation is the comma separated list of the taints of the Nodes, in the key=value:effect format.
+	AutoscalerTaintsAnnotation = "capacity.cluster-autoscaler.kubernetes.io/taints"
 )
 
 // MachineAddressType describes a valid MachineAddress type.
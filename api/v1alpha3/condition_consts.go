@@ -0,0 +1,183 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha3
+
+// ANCHOR: CommonConditions
+
+// Common ConditionTypes used by Cluster API objects.
+const (
+	// ReadyCondition defines the Ready condition type that summarizes the operational state of a Cluster API object.
+	ReadyCondition ConditionType = "Ready"
+)
+
+// Common ConditionReason used by Cluster API objects.
+const (
+	// DeletingReason (Severity=Info) documents a condition not in Status=True because the underlying object it is currently being deleted.
+	DeletingReason = "Deleting"
+
+	// DeletionFailedReason (Severity=Warning) documents a condition not in Status=True because the underlying object
+	// encountered problems during deletion. This is a warning because the reconciler will retry deletion.
+	DeletionFailedReason = "DeletionFailed"
+)
+
+// ANCHOR_END: CommonConditions
+
+// Conditions and condition Reasons for the Cluster object
+
+const (
+	// InfrastructureReadyCondition reports a summary of current status of the infrastructure object defined for this cluster/machine.
+	InfrastructureReadyCondition ConditionType = "InfrastructureReady"
+
+	// WaitingForInfrastructureReason (Severity=Info) documents a cluster/machine waiting for the cluster/machine infrastructure
+	// to be available.
+	WaitingForInfrastructureReason = "WaitingForInfrastructure"
+
+	// WaitingForInfrastructureReleaseReason (Severity=Info) documents a deleted machine waiting for the infrastructure
+	// provider to release the machine before its infrastructure object is deleted.
+	WaitingForInfrastructureReleaseReason = "WaitingForInfrastructureRelease"
+
+	// InfrastructureNotFoundReason (Severity=Warning) documents a machine whose infrastructure object does not exist;
+	// the severity is Error if the infrastructure object was deleted after the machine infrastructure was ready.
+	InfrastructureNotFoundReason = "InfrastructureNotFound"
+)
+
+const (
+	// ControlPlaneReadyCondition reports the ready condition from the control plane object defined for this cluster.
+	// If a control plane object is not defined, it reports if the first control plane machine has been initialized.
+	ControlPlaneReadyCondition ConditionType = "ControlPlaneReady"
+
+	// WaitingForControlPlaneReason (Severity=Info) documents a cluster waiting for the control plane
+	// to be available.
+	WaitingForControlPlaneReason = "WaitingForControlPlane"
+)
+
+// Conditions and condition Reasons for the Machine object
+
+const (
+	// BootstrapReadyCondition reports a summary of current status of the bootstrap object defined for this machine.
+	BootstrapReadyCondition ConditionType = "BootstrapReady"
+
+	// WaitingForDataSecretReason (Severity=Info) documents a machine waiting for the bootstrap data secret
+	// to be available.
+	WaitingForDataSecretReason = "WaitingForDataSecret"
+
+	// BootstrapConfigNotFoundReason (Severity=Warning) documents a machine waiting for the bootstrap data secret
+	// whose bootstrap config object does not exist.
+	BootstrapConfigNotFoundReason = "BootstrapConfigNotFound"
+)
+
+const (
+	// RemediatingCondition is set to True on a Machine when a MachineHealthCheck detects the machine as unhealthy
+	// and triggers its remediation; it is set to False when the remediation is stopped by the MaxRemediationRetries
+	// of the MachineHealthCheck.
+	// NOTE: Unlike the other conditions, Status=True documents a problem, so this condition is not part of the Machine
+	// Ready summary.
+	RemediatingCondition ConditionType = "Remediating"
+
+	// UnhealthyReason documents a machine being remediated because it failed a MachineHealthCheck.
+	UnhealthyReason = "Unhealthy"
+
+	// RemediationRetriesExhaustedReason (Severity=Error) documents an unhealthy machine which is no longer remediated
+	// because the MaxRemediationRetries of its MachineHealthCheck have been reached; human intervention is required.
+	RemediationRetriesExhaustedReason = "RemediationRetriesExhausted"
+)
+
+const (
+	// MachineOwnerRemediatedCondition is set to False on a Machine by a MachineHealthCheck when the remediation of the
+	// unhealthy Machine is handed off to the controller owning it, e.g. a KubeadmControlPlane, which then deletes and
+	// replaces the Machine.
+	// NOTE: this condition is not part of the Machine Ready summary.
+	MachineOwnerRemediatedCondition ConditionType = "OwnerRemediated"
+
+	// WaitingForRemediationReason (Severity=Warning) documents a machine waiting for its owner to remediate it.
+	WaitingForRemediationReason = "WaitingForRemediation"
+)
+
+const (
+	// DrainingSucceededCondition reports the drain of the node of a deleted machine; the condition is not set if
+	// draining is skipped.
+	// NOTE: this condition is not part of the Machine Ready summary.
+	DrainingSucceededCondition ConditionType = "DrainingSucceeded"
+
+	// DrainingReason (Severity=Info) documents a machine whose node is being drained.
+	DrainingReason = "Draining"
+
+	// DrainingFailedReason (Severity=Warning) documents a machine whose node drain failed, e.g. because of Pods
+	// not evicted yet; the drain is retried.
+	DrainingFailedReason = "DrainingFailed"
+
+	// DrainingTimedOutReason (Severity=Warning) documents a machine whose node was not drained before the machine's
+	// NodeDrainTimeout expired; the deletion continues without draining the node.
+	DrainingTimedOutReason = "DrainingTimedOut"
+)
+
+const (
+	// PreDrainDeleteHookSucceededCondition reports a deleted machine waiting for its pre-drain delete hooks to be removed
+	// before draining the node; the condition is set only if the machine has pre-drain delete hooks, and its last
+	// transition time is used for enforcing the machine's PreDrainHookTimeout.
+	// NOTE: this condition is not part of the Machine Ready summary.
+	PreDrainDeleteHookSucceededCondition ConditionType = "PreDrainDeleteHookSucceeded"
+
+	// PreTerminateDeleteHookSucceededCondition reports a deleted machine waiting for its pre-terminate delete hooks to
+	// be removed before deleting the infrastructure; the condition is set only if the machine has pre-terminate
+	// delete hooks, and its last transition time is used for enforcing the machine's PreTerminateHookTimeout.
+	// NOTE: this condition is not part of the Machine Ready summary.
+	PreTerminateDeleteHookSucceededCondition ConditionType = "PreTerminateDeleteHookSucceeded"
+
+	// WaitingExternalHookReason (Severity=Info) documents a machine waiting for the delete hooks to be removed
+	// by the external controllers owning them.
+	WaitingExternalHookReason = "WaitingExternalHook"
+
+	// DeleteHookTimedOutReason (Severity=Warning) documents a machine whose delete hooks were not removed before the
+	// timeout expired; the deletion continues ignoring the remaining hooks.
+	DeleteHookTimedOutReason = "DeleteHookTimedOut"
+)
+
+// Conditions and condition Reasons for the MachineHealthCheck object
+
+const (
+	// RemediationAllowedCondition is set on MachineHealthChecks to show whether the MachineHealthCheck is allowed
+	// to remediate any Machines, or whether remediation is short-circuited by MaxUnhealthy or UnhealthyRange.
+	RemediationAllowedCondition ConditionType = "RemediationAllowed"
+
+	// TooManyUnhealthyReason (Severity=Warning) documents a MachineHealthCheck that is not remediating because the
+	// number of unhealthy machines is out of the bounds set by MaxUnhealthy or UnhealthyRange.
+	TooManyUnhealthyReason = "TooManyUnhealthy"
+)
+
+// Conditions and condition Reasons for the MachineDeployment object
+
+const (
+	// MachineDeploymentAvailableCondition means the MachineDeployment is available, that is, at least the minimum available
+	// machines required (i.e. Spec.Replicas-MaxUnavailable when MachineDeploymentStrategyType = RollingUpdate) are up and running for at least minReadySeconds.
+	MachineDeploymentAvailableCondition ConditionType = "Available"
+
+	// WaitingForAvailableMachinesReason (Severity=Warning) reflects the fact that the required minimum number of machines for a machinedeployment are not available.
+	WaitingForAvailableMachinesReason = "WaitingForAvailableMachines"
+)
+
+// Conditions and condition Reasons for the IPAddressClaim object
+
+const (
+	// AddressAllocatedCondition reports whether an IPAddress has been allocated for the IPAddressClaim
+	// by the IPAM provider.
+	AddressAllocatedCondition ConditionType = "AddressAllocated"
+
+	// WaitingForIPAddressReason (Severity=Info) documents an IPAddressClaim waiting for the IPAM provider
+	// to allocate an IPAddress.
+	WaitingForIPAddressReason = "WaitingForIPAddress"
+)
@@ -16,15 +16,27 @@ limitations under the License.
 
 package v1alpha3
 
-func (*Cluster) Hub()                {}
-func (*ClusterList) Hub()            {}
-func (*Machine) Hub()                {}
-func (*MachineList) Hub()            {}
-func (*MachineSet) Hub()             {}
-func (*MachineSetList) Hub()         {}
-func (*MachineDeployment) Hub()      {}
-func (*MachineDeploymentList) Hub()  {}
-func (*MachineHealthCheck) Hub()     {}
-func (*MachineHealthCheckList) Hub() {}
-func (*MachinePool) Hub()            {}
-func (*MachinePoolList) Hub()        {}
+func (*Cluster) Hub()                       {}
+func (*ClusterList) Hub()                   {}
+func (*Machine) Hub()                       {}
+func (*MachineList) Hub()                   {}
+func (*MachineSet) Hub()                    {}
+func (*MachineSetList) Hub()                {}
+func (*MachineDeployment) Hub()             {}
+func (*MachineDeploymentList) Hub()         {}
+func (*MachineHealthCheck) Hub()            {}
+func (*MachineHealthCheckList) Hub()        {}
+func (*MachinePool) Hub()                   {}
+func (*MachinePoolList) Hub()               {}
+func (*ClusterResourceSet) Hub()            {}
+func (*ClusterResourceSetList) Hub()        {}
+func (*ClusterResourceSetBinding) Hub()     {}
+func (*ClusterResourceSetBindingList) Hub() {}
+func (*Notifier) Hub()                      {}
+func (*NotifierList) Hub()                  {}
+func (*IPAddressClaim) Hub()                {}
+func (*IPAddressClaimList) Hub()            {}
+func (*IPAddress) Hub()                     {}
+func (*IPAddressList) Hub()                 {}
+func (*ClusterClass) Hub()                  {}
+func (*ClusterClassList) Hub()              {}
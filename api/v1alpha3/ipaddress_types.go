@@ -0,0 +1,76 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha3
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ANCHOR: IPAddressSpec
+
+// IPAddressSpec defines the desired state of IPAddress
+type IPAddressSpec struct {
+	// ClaimRef is a reference to the IPAddressClaim the address was allocated for.
+	ClaimRef corev1.LocalObjectReference `json:"claimRef"`
+
+	// PoolRef is a reference to the pool the address was allocated from.
+	PoolRef corev1.TypedLocalObjectReference `json:"poolRef"`
+
+	// Address is the IP address.
+	// +kubebuilder:validation:MinLength=1
+	Address string `json:"address"`
+
+	// Prefix is the prefix length of the network the address belongs to.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=128
+	Prefix int `json:"prefix"`
+
+	// Gateway is the IP address of the gateway of the network the address belongs to.
+	// +optional
+	Gateway string `json:"gateway,omitempty"`
+}
+
+// ANCHOR_END: IPAddressSpec
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:path=ipaddresses,scope=Namespaced,categories=cluster-api
+// +kubebuilder:storageversion
+// +kubebuilder:printcolumn:name="Address",type="string",JSONPath=".spec.address",description="IP address"
+// +kubebuilder:printcolumn:name="Pool Name",type="string",JSONPath=".spec.poolRef.name",description="Name of the pool the address was allocated from"
+// +kubebuilder:printcolumn:name="Pool Kind",type="string",JSONPath=".spec.poolRef.kind",description="Kind of the pool the address was allocated from"
+
+// IPAddress is the Schema for the ipaddresses API
+type IPAddress struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec IPAddressSpec `json:"spec,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// IPAddressList contains a list of IPAddress
+type IPAddressList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []IPAddress `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&IPAddress{}, &IPAddressList{})
+}
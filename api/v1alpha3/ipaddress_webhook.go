@@ -0,0 +1,110 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha3
+
+import (
+	"fmt"
+	"net"
+	"reflect"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+)
+
+func (m *IPAddress) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(m).
+		Complete()
+}
+
+// +kubebuilder:webhook:verbs=create;update,path=/validate-cluster-x-k8s-io-v1alpha3-ipaddress,mutating=false,failurePolicy=fail,groups=cluster.x-k8s.io,resources=ipaddresses,versions=v1alpha3,name=validation.ipaddress.cluster.x-k8s.io
+
+var _ webhook.Validator = &IPAddress{}
+
+// ValidateCreate implements webhook.Validator so a webhook will be registered for the type
+func (m *IPAddress) ValidateCreate() error {
+	return m.validate(nil)
+}
+
+// ValidateUpdate implements webhook.Validator so a webhook will be registered for the type
+func (m *IPAddress) ValidateUpdate(old runtime.Object) error {
+	oldAddress, ok := old.(*IPAddress)
+	if !ok {
+		return apierrors.NewBadRequest(fmt.Sprintf("expected an IPAddress but got a %T", old))
+	}
+	return m.validate(oldAddress)
+}
+
+// ValidateDelete implements webhook.Validator so a webhook will be registered for the type
+func (m *IPAddress) ValidateDelete() error {
+	return nil
+}
+
+func (m *IPAddress) validate(old *IPAddress) error {
+	var allErrs field.ErrorList
+
+	address := net.ParseIP(m.Spec.Address)
+	if address == nil {
+		allErrs = append(
+			allErrs,
+			field.Invalid(field.NewPath("spec", "address"), m.Spec.Address, "must be an IP address"),
+		)
+	}
+
+	maxPrefix := 128
+	if address != nil && address.To4() != nil {
+		maxPrefix = 32
+	}
+	if m.Spec.Prefix < 0 || m.Spec.Prefix > maxPrefix {
+		allErrs = append(
+			allErrs,
+			field.Invalid(field.NewPath("spec", "prefix"), m.Spec.Prefix, fmt.Sprintf("must be between 0 and %d", maxPrefix)),
+		)
+	}
+
+	if m.Spec.Gateway != "" {
+		gateway := net.ParseIP(m.Spec.Gateway)
+		switch {
+		case gateway == nil:
+			allErrs = append(
+				allErrs,
+				field.Invalid(field.NewPath("spec", "gateway"), m.Spec.Gateway, "must be an IP address"),
+			)
+		case address != nil && (gateway.To4() == nil) != (address.To4() == nil):
+			allErrs = append(
+				allErrs,
+				field.Invalid(field.NewPath("spec", "gateway"), m.Spec.Gateway, "must belong to the same IP family of spec.address"),
+			)
+		}
+	}
+
+	if old != nil && !reflect.DeepEqual(old.Spec, m.Spec) {
+		allErrs = append(
+			allErrs,
+			field.Forbidden(field.NewPath("spec"), "field is immutable"),
+		)
+	}
+
+	if len(allErrs) == 0 {
+		return nil
+	}
+
+	return apierrors.NewInvalid(GroupVersion.WithKind("IPAddress").GroupKind(), m.Name, allErrs)
+}
@@ -0,0 +1,107 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha3
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestIPAddressValidateCreate(t *testing.T) {
+	tests := []struct {
+		name      string
+		spec      IPAddressSpec
+		expectErr bool
+	}{
+		{
+			name:      "should accept a valid IPv4 address",
+			spec:      IPAddressSpec{Address: "10.0.0.10", Prefix: 24, Gateway: "10.0.0.1"},
+			expectErr: false,
+		},
+		{
+			name:      "should accept a valid IPv6 address",
+			spec:      IPAddressSpec{Address: "fd00::10", Prefix: 64, Gateway: "fd00::1"},
+			expectErr: false,
+		},
+		{
+			name:      "should accept an address without gateway",
+			spec:      IPAddressSpec{Address: "10.0.0.10", Prefix: 32},
+			expectErr: false,
+		},
+		{
+			name:      "should reject an invalid address",
+			spec:      IPAddressSpec{Address: "10.0.0.300", Prefix: 24},
+			expectErr: true,
+		},
+		{
+			name:      "should reject a prefix too long for an IPv4 address",
+			spec:      IPAddressSpec{Address: "10.0.0.10", Prefix: 33},
+			expectErr: true,
+		},
+		{
+			name:      "should reject an invalid gateway",
+			spec:      IPAddressSpec{Address: "10.0.0.10", Prefix: 24, Gateway: "gateway"},
+			expectErr: true,
+		},
+		{
+			name:      "should reject a gateway of a different IP family",
+			spec:      IPAddressSpec{Address: "10.0.0.10", Prefix: 24, Gateway: "fd00::1"},
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+			address := &IPAddress{
+				ObjectMeta: metav1.ObjectMeta{Name: "address", Namespace: "default"},
+				Spec:       tt.spec,
+			}
+			address.Spec.ClaimRef = corev1.LocalObjectReference{Name: "claim"}
+
+			if tt.expectErr {
+				g.Expect(address.ValidateCreate()).NotTo(Succeed())
+			} else {
+				g.Expect(address.ValidateCreate()).To(Succeed())
+			}
+		})
+	}
+}
+
+func TestIPAddressValidateUpdate(t *testing.T) {
+	g := NewWithT(t)
+
+	old := &IPAddress{
+		ObjectMeta: metav1.ObjectMeta{Name: "address", Namespace: "default"},
+		Spec: IPAddressSpec{
+			ClaimRef: corev1.LocalObjectReference{Name: "claim"},
+			Address:  "10.0.0.10",
+			Prefix:   24,
+		},
+	}
+
+	unchanged := old.DeepCopy()
+	unchanged.Labels = map[string]string{"foo": "bar"}
+	g.Expect(unchanged.ValidateUpdate(old)).To(Succeed())
+
+	changed := old.DeepCopy()
+	changed.Spec.Address = "10.0.0.11"
+	g.Expect(changed.ValidateUpdate(old)).NotTo(Succeed())
+}
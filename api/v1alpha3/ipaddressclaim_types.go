@@ -0,0 +1,93 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha3
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// IPAddressClaimFinalizer is added to the IPAddressClaim object so the IPAddress allocated for the claim
+	// is released before the claim is deleted.
+	IPAddressClaimFinalizer = "ipaddressclaim.cluster.x-k8s.io"
+)
+
+// ANCHOR: IPAddressClaimSpec
+
+// IPAddressClaimSpec defines the desired state of IPAddressClaim
+type IPAddressClaimSpec struct {
+	// PoolRef is a reference to the pool from which an IP address should be allocated.
+	// Pools are provided by IPAM providers. This field is immutable.
+	PoolRef corev1.TypedLocalObjectReference `json:"poolRef"`
+}
+
+// ANCHOR_END: IPAddressClaimSpec
+
+// ANCHOR: IPAddressClaimStatus
+
+// IPAddressClaimStatus defines the observed state of IPAddressClaim
+type IPAddressClaimStatus struct {
+	// AddressRef is a reference to the IPAddress allocated for the claim.
+	// It is set by the IPAM provider once the address has been allocated.
+	// +optional
+	AddressRef corev1.LocalObjectReference `json:"addressRef,omitempty"`
+
+	// Conditions defines current service state of the IPAddressClaim.
+	// +optional
+	Conditions Conditions `json:"conditions,omitempty"`
+}
+
+// ANCHOR_END: IPAddressClaimStatus
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:path=ipaddressclaims,scope=Namespaced,categories=cluster-api
+// +kubebuilder:subresource:status
+// +kubebuilder:storageversion
+// +kubebuilder:printcolumn:name="Pool Name",type="string",JSONPath=".spec.poolRef.name",description="Name of the pool to allocate an address from"
+// +kubebuilder:printcolumn:name="Pool Kind",type="string",JSONPath=".spec.poolRef.kind",description="Kind of the pool to allocate an address from"
+// +kubebuilder:printcolumn:name="Address",type="string",JSONPath=".status.addressRef.name",description="Name of the IPAddress allocated for the claim"
+
+// IPAddressClaim is the Schema for the ipaddressclaims API
+type IPAddressClaim struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   IPAddressClaimSpec   `json:"spec,omitempty"`
+	Status IPAddressClaimStatus `json:"status,omitempty"`
+}
+
+func (in *IPAddressClaim) GetConditions() Conditions {
+	return in.Status.Conditions
+}
+
+func (in *IPAddressClaim) SetConditions(conditions Conditions) {
+	in.Status.Conditions = conditions
+}
+
+// +kubebuilder:object:root=true
+
+// IPAddressClaimList contains a list of IPAddressClaim
+type IPAddressClaimList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []IPAddressClaim `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&IPAddressClaim{}, &IPAddressClaimList{})
+}
@@ -0,0 +1,88 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha3
+
+import (
+	"fmt"
+	"reflect"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+)
+
+func (m *IPAddressClaim) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(m).
+		Complete()
+}
+
+// +kubebuilder:webhook:verbs=create;update,path=/validate-cluster-x-k8s-io-v1alpha3-ipaddressclaim,mutating=false,failurePolicy=fail,groups=cluster.x-k8s.io,resources=ipaddressclaims,versions=v1alpha3,name=validation.ipaddressclaim.cluster.x-k8s.io
+
+var _ webhook.Validator = &IPAddressClaim{}
+
+// ValidateCreate implements webhook.Validator so a webhook will be registered for the type
+func (m *IPAddressClaim) ValidateCreate() error {
+	return m.validate(nil)
+}
+
+// ValidateUpdate implements webhook.Validator so a webhook will be registered for the type
+func (m *IPAddressClaim) ValidateUpdate(old runtime.Object) error {
+	oldClaim, ok := old.(*IPAddressClaim)
+	if !ok {
+		return apierrors.NewBadRequest(fmt.Sprintf("expected an IPAddressClaim but got a %T", old))
+	}
+	return m.validate(oldClaim)
+}
+
+// ValidateDelete implements webhook.Validator so a webhook will be registered for the type
+func (m *IPAddressClaim) ValidateDelete() error {
+	return nil
+}
+
+func (m *IPAddressClaim) validate(old *IPAddressClaim) error {
+	var allErrs field.ErrorList
+
+	if m.Spec.PoolRef.Name == "" {
+		allErrs = append(
+			allErrs,
+			field.Required(field.NewPath("spec", "poolRef", "name"), "must be set"),
+		)
+	}
+
+	if m.Spec.PoolRef.Kind == "" {
+		allErrs = append(
+			allErrs,
+			field.Required(field.NewPath("spec", "poolRef", "kind"), "must be set"),
+		)
+	}
+
+	if old != nil && !reflect.DeepEqual(old.Spec.PoolRef, m.Spec.PoolRef) {
+		allErrs = append(
+			allErrs,
+			field.Invalid(field.NewPath("spec", "poolRef"), m.Spec.PoolRef, "field is immutable"),
+		)
+	}
+
+	if len(allErrs) == 0 {
+		return nil
+	}
+
+	return apierrors.NewInvalid(GroupVersion.WithKind("IPAddressClaim").GroupKind(), m.Name, allErrs)
+}
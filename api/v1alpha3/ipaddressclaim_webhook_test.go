@@ -0,0 +1,85 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha3
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+)
+
+func TestIPAddressClaimValidateCreate(t *testing.T) {
+	tests := []struct {
+		name      string
+		poolRef   corev1.TypedLocalObjectReference
+		expectErr bool
+	}{
+		{
+			name:      "should accept a complete pool reference",
+			poolRef:   corev1.TypedLocalObjectReference{APIGroup: pointer.StringPtr("ipam.example.com"), Kind: "IPPool", Name: "pool"},
+			expectErr: false,
+		},
+		{
+			name:      "should reject a pool reference without name",
+			poolRef:   corev1.TypedLocalObjectReference{APIGroup: pointer.StringPtr("ipam.example.com"), Kind: "IPPool"},
+			expectErr: true,
+		},
+		{
+			name:      "should reject a pool reference without kind",
+			poolRef:   corev1.TypedLocalObjectReference{APIGroup: pointer.StringPtr("ipam.example.com"), Name: "pool"},
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+			claim := &IPAddressClaim{
+				ObjectMeta: metav1.ObjectMeta{Name: "claim", Namespace: "default"},
+				Spec:       IPAddressClaimSpec{PoolRef: tt.poolRef},
+			}
+
+			if tt.expectErr {
+				g.Expect(claim.ValidateCreate()).NotTo(Succeed())
+			} else {
+				g.Expect(claim.ValidateCreate()).To(Succeed())
+			}
+		})
+	}
+}
+
+func TestIPAddressClaimValidateUpdate(t *testing.T) {
+	g := NewWithT(t)
+
+	old := &IPAddressClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: "claim", Namespace: "default"},
+		Spec: IPAddressClaimSpec{
+			PoolRef: corev1.TypedLocalObjectReference{APIGroup: pointer.StringPtr("ipam.example.com"), Kind: "IPPool", Name: "pool"},
+		},
+	}
+
+	statusChanged := old.DeepCopy()
+	statusChanged.Status.AddressRef = corev1.LocalObjectReference{Name: "address"}
+	g.Expect(statusChanged.ValidateUpdate(old)).To(Succeed())
+
+	poolChanged := old.DeepCopy()
+	poolChanged.Spec.PoolRef.Name = "another-pool"
+	g.Expect(poolChanged.ValidateUpdate(old)).NotTo(Succeed())
+}
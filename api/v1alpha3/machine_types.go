@@ -32,6 +32,31 @@ const (
 	// ExcludeNodeDrainingAnnotation annotation explicitly skips node draining if set
 	ExcludeNodeDrainingAnnotation = "machine.cluster.x-k8s.io/exclude-node-draining"
 
+	// PreDrainDeleteHookAnnotationPrefix is the prefix of the annotations that block the draining of the Node
+	// of a deleted Machine, e.g. "pre-drain.delete.hook.machine.cluster.x-k8s.io/detach-storage: storage-controller".
+	// The owning controller removes its annotation once it has completed its tasks.
+	PreDrainDeleteHookAnnotationPrefix = "pre-drain.delete.hook.machine.cluster.x-k8s.io"
+
+	// PreTerminateDeleteHookAnnotationPrefix is the prefix of the annotations that block the deletion of the
+	// infrastructure of a deleted Machine, once its Node has been drained.
+	// The owning controller removes its annotation once it has completed its tasks.
+	PreTerminateDeleteHookAnnotationPrefix = "pre-terminate.delete.hook.machine.cluster.x-k8s.io"
+
+	// ActionAnnotation is the annotation requesting the infrastructure provider to perform an action on a machine
+	// without replacing it, e.g. "cluster.x-k8s.io/action: reboot".
+	// When set on a Machine, the Machine controller moves it to the InfrastructureMachine; infrastructure providers
+	// implementing the action remove the annotation from the InfrastructureMachine once the action has been performed.
+	ActionAnnotation = "cluster.x-k8s.io/action"
+
+	// RebootAction is the ActionAnnotation value requesting the infrastructure provider to reboot the machine.
+	RebootAction = "reboot"
+
+	// ReleaseAction is the ActionAnnotation value set by the Machine controller on the InfrastructureMachine of a
+	// deleted Machine, requesting the infrastructure provider to release the machine (e.g. stop billing, detach the
+	// networks) before the InfrastructureMachine is deleted. Only InfrastructureMachines with a status.released field
+	// are released; the provider sets the field to true once the machine has been released.
+	ReleaseAction = "release"
+
 	// MachineSetLabelName is the label set on machines if they're controlled by MachineSet
 	MachineSetLabelName = "cluster.x-k8s.io/set-name"
 
@@ -77,6 +102,80 @@ type MachineSpec struct {
 	// Must match a key in the FailureDomains map stored on the cluster object.
 	// +optional
 	FailureDomain *string `json:"failureDomain,omitempty"`
+
+	// SkipNodeDrain skips the draining of the Node when the Machine is deleted.
+	// It is equivalent to the "machine.cluster.x-k8s.io/exclude-node-draining" annotation.
+	// +optional
+	SkipNodeDrain bool `json:"skipNodeDrain,omitempty"`
+
+	// NodeDrainTimeout is the total amount of time that the controller will spend on draining the Node.
+	// The timeout is measured from the moment the Machine deletion started, so it includes the time spent waiting
+	// for the pre-drain delete hooks; once expired, the Node is deleted anyway, so that Pods blocked by
+	// PodDisruptionBudgets or unreachable kubelets can't block the deletion forever.
+	// If not set, the controller retries draining the Node until it succeeds.
+	// +optional
+	NodeDrainTimeout *metav1.Duration `json:"nodeDrainTimeout,omitempty"`
+
+	// NodeDrainGracePeriodSeconds is the period of time in seconds given to each Pod to terminate gracefully
+	// when draining the Node. If negative, the default value specified in the Pod will be used.
+	// Defaults to -1.
+	// +optional
+	NodeDrainGracePeriodSeconds *int32 `json:"nodeDrainGracePeriodSeconds,omitempty"`
+
+	// NodeVolumeDetachTimeout is the total amount of time that the controller will spend on waiting for all the
+	// volumes attached to the Node to be detached after draining it, before deleting the infrastructure machine.
+	// Like NodeDrainTimeout, it is measured from the moment the Machine deletion started, so it should be longer
+	// than NodeDrainTimeout for the controller to wait for the volumes after a slow drain.
+	// If not set, the controller does not wait for volumes to be detached.
+	// +optional
+	NodeVolumeDetachTimeout *metav1.Duration `json:"nodeVolumeDetachTimeout,omitempty"`
+
+	// PreDrainHookTimeout is the total amount of time that the controller will spend on waiting for the
+	// pre-drain delete hooks of the Machine to be removed, before draining the Node.
+	// The timeout is measured from the first time the controller found pre-drain delete hooks on the deleted Machine,
+	// as recorded by the PreDrainDeleteHookSucceeded condition.
+	// If not set, the controller waits until all the pre-drain delete hooks are removed.
+	// +optional
+	PreDrainHookTimeout *metav1.Duration `json:"preDrainHookTimeout,omitempty"`
+
+	// PreTerminateHookTimeout is the total amount of time that the controller will spend on waiting for the
+	// pre-terminate delete hooks of the Machine to be removed, before deleting the infrastructure machine.
+	// The timeout is measured from the moment the Node has been drained and deleted and the controller started
+	// waiting for the pre-terminate delete hooks, as recorded by the PreTerminateDeleteHookSucceeded condition;
+	// the time spent on draining the Node does not count.
+	// If not set, the controller waits until all the pre-terminate delete hooks are removed.
+	// +optional
+	PreTerminateHookTimeout *metav1.Duration `json:"preTerminateHookTimeout,omitempty"`
+
+	// PendingTimeout is the maximum amount of time the Machine can spend in the Pending phase, i.e. waiting for
+	// its bootstrap data, measured from its creation. Once expired, the Machine is marked as failed.
+	// If not set, the Machine can stay in the Pending phase forever.
+	// +optional
+	PendingTimeout *metav1.Duration `json:"pendingTimeout,omitempty"`
+
+	// ProvisioningTimeout is the maximum amount of time the Machine can spend in the Provisioning phase, i.e.
+	// waiting for its infrastructure to be ready, measured from the moment its bootstrap data was ready.
+	// Once expired, the Machine is marked as failed.
+	// If not set, the Machine can stay in the Provisioning phase forever.
+	// +optional
+	ProvisioningTimeout *metav1.Duration `json:"provisioningTimeout,omitempty"`
+
+	// ReplaceOnPhaseTimeout deletes the Machine once it is marked as failed because of PendingTimeout or
+	// ProvisioningTimeout, if it is controlled by a MachineSet, so that it gets replaced.
+	// +optional
+	ReplaceOnPhaseTimeout bool `json:"replaceOnPhaseTimeout,omitempty"`
+
+	// Network defines the network requirements of the Machine, e.g. static IP addresses or subnet hints.
+	// Infrastructure providers supporting the Machine network contract read it from the owner Machine
+	// when provisioning the infrastructure machine, and report the addresses assigned in status.addresses.
+	// +optional
+	Network *MachineNetwork `json:"network,omitempty"`
+
+	// NodeTaints are the taints applied to the Node of the Machine, in addition to the ones set at bootstrap.
+	// Changes are applied in place to the Node, without replacing the Machine; taints removed from this list
+	// are removed from the Node.
+	// +optional
+	NodeTaints []corev1.Taint `json:"nodeTaints,omitempty"`
 }
 
 // ANCHOR_END: MachineSpec
@@ -155,6 +254,19 @@ type MachineStatus struct {
 	// InfrastructureReady is the state of the infrastructure provider.
 	// +optional
 	InfrastructureReady bool `json:"infrastructureReady"`
+
+	// Conditions defines current service state of the Machine.
+	// +optional
+	Conditions Conditions `json:"conditions,omitempty"`
+
+	// NodeReadyTime is the time the Node of the Machine became Ready for the first time.
+	// +optional
+	NodeReadyTime *metav1.Time `json:"nodeReadyTime,omitempty"`
+
+	// RemediationHistory records the most recent remediations of the Machine performed by MachineHealthChecks,
+	// oldest first. The remediations of the Machines owned by a MachineSet are recorded on the MachineSet instead.
+	// +optional
+	RemediationHistory []RemediationRecord `json:"remediationHistory,omitempty"`
 }
 
 // ANCHOR_END: MachineStatus
@@ -210,6 +322,68 @@ type Bootstrap struct {
 
 // ANCHOR_END: Bootstrap
 
+// BootstrapDataFormat is the format of the bootstrap data stored in a bootstrap data secret.
+type BootstrapDataFormat string
+
+const (
+	// BootstrapDataFormatCloudConfig is the format of cloud-init cloud-config bootstrap data.
+	BootstrapDataFormatCloudConfig BootstrapDataFormat = "cloud-config"
+
+	// BootstrapDataFormatIgnition is the format of Ignition bootstrap data.
+	BootstrapDataFormatIgnition BootstrapDataFormat = "ignition"
+)
+
+const (
+	// BootstrapDataSecretValueKey is the key of the bootstrap data in a bootstrap data secret.
+	BootstrapDataSecretValueKey = "value"
+
+	// BootstrapDataSecretFormatKey is the key of the format of the bootstrap data in a bootstrap data secret.
+	// Secrets without this key hold cloud-config bootstrap data.
+	BootstrapDataSecretFormatKey = "format"
+
+	// BootstrapDataContractVersionAnnotation is set by bootstrap providers on the bootstrap data secrets to the
+	// version of the bootstrap data contract they implement, so infrastructure providers can detect secrets
+	// created by providers implementing an older contract.
+	BootstrapDataContractVersionAnnotation = "cluster.x-k8s.io/bootstrap-data-contract-version"
+
+	// BootstrapDataContractVersion is the current version of the bootstrap data contract: the bootstrap data is
+	// stored under BootstrapDataSecretValueKey and its format under BootstrapDataSecretFormatKey.
+	BootstrapDataContractVersion = "v1"
+)
+
+// ANCHOR: MachineNetwork
+
+// MachineNetwork defines the network requirements of a Machine.
+type MachineNetwork struct {
+	// Interfaces is the list of network interfaces requested for the Machine.
+	// +optional
+	Interfaces []MachineNetworkInterface `json:"interfaces,omitempty"`
+}
+
+// MachineNetworkInterface defines the requirements of a network interface of a Machine.
+type MachineNetworkInterface struct {
+	// Name identifies the interface within the Machine; it is not required to match the name
+	// of the interface in the operating system.
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+
+	// Addresses is a list of static IP addresses requested for the interface, in CIDR notation
+	// (e.g. 10.0.0.10/24). If empty, the addresses are assigned by the infrastructure, e.g. via DHCP.
+	// +optional
+	Addresses []string `json:"addresses,omitempty"`
+
+	// Gateway is the IP address of the gateway to be used by the interface.
+	// +optional
+	Gateway *string `json:"gateway,omitempty"`
+
+	// SubnetHint is a hint about the subnet the interface should be attached to; its format is
+	// provider specific, e.g. a subnet ID or name.
+	// +optional
+	SubnetHint *string `json:"subnetHint,omitempty"`
+}
+
+// ANCHOR_END: MachineNetwork
+
 // +kubebuilder:object:root=true
 // +kubebuilder:resource:path=machines,shortName=ma,scope=Namespaced,categories=cluster-api
 // +kubebuilder:subresource:status
@@ -227,6 +401,14 @@ type Machine struct {
 	Status MachineStatus `json:"status,omitempty"`
 }
 
+func (in *Machine) GetConditions() Conditions {
+	return in.Status.Conditions
+}
+
+func (in *Machine) SetConditions(conditions Conditions) {
+	in.Status.Conditions = conditions
+}
+
 // +kubebuilder:object:root=true
 
 // MachineList contains a list of Machine
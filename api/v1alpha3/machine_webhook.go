@@ -17,6 +17,8 @@ limitations under the License.
 package v1alpha3
 
 import (
+	"net"
+
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/validation/field"
@@ -96,8 +98,53 @@ func (m *Machine) validate() error {
 		)
 	}
 
+	if action, ok := m.Annotations[ActionAnnotation]; ok && action != RebootAction {
+		allErrs = append(
+			allErrs,
+			field.NotSupported(
+				field.NewPath("metadata", "annotations", ActionAnnotation),
+				action,
+				[]string{RebootAction},
+			),
+		)
+	}
+
+	allErrs = append(allErrs, validateMachineNetwork(m.Spec.Network, field.NewPath("spec", "network"), true)...)
+
 	if len(allErrs) == 0 {
 		return nil
 	}
 	return apierrors.NewInvalid(GroupVersion.WithKind("Machine").GroupKind(), m.Name, allErrs)
 }
+
+// validateMachineNetwork validates the network requirements of a Machine; static addresses are allowed only
+// if the requirements apply to a single Machine, because they can't be shared across the replicas of a template.
+func validateMachineNetwork(network *MachineNetwork, fldPath *field.Path, allowAddresses bool) field.ErrorList {
+	var allErrs field.ErrorList
+	if network == nil {
+		return allErrs
+	}
+
+	names := map[string]bool{}
+	for i, iface := range network.Interfaces {
+		ifacePath := fldPath.Child("interfaces").Index(i)
+		if names[iface.Name] {
+			allErrs = append(allErrs, field.Duplicate(ifacePath.Child("name"), iface.Name))
+		}
+		names[iface.Name] = true
+
+		if len(iface.Addresses) > 0 && !allowAddresses {
+			allErrs = append(allErrs, field.Forbidden(ifacePath.Child("addresses"), "static addresses can't be shared across the machines created from a template"))
+		}
+		for j, address := range iface.Addresses {
+			if _, _, err := net.ParseCIDR(address); err != nil {
+				allErrs = append(allErrs, field.Invalid(ifacePath.Child("addresses").Index(j), address, "must be an IP address in CIDR notation"))
+			}
+		}
+
+		if iface.Gateway != nil && net.ParseIP(*iface.Gateway) == nil {
+			allErrs = append(allErrs, field.Invalid(ifacePath.Child("gateway"), *iface.Gateway, "must be an IP address"))
+		}
+	}
+	return allErrs
+}
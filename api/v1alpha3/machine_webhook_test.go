@@ -83,6 +83,120 @@ func TestMachineBootstrapValidation(t *testing.T) {
 	}
 }
 
+func TestMachineActionValidation(t *testing.T) {
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		expectErr   bool
+	}{
+		{
+			name:        "should succeed without action annotation",
+			annotations: nil,
+			expectErr:   false,
+		},
+		{
+			name:        "should succeed with reboot action",
+			annotations: map[string]string{ActionAnnotation: RebootAction},
+			expectErr:   false,
+		},
+		{
+			name:        "should return error with unknown action",
+			annotations: map[string]string{ActionAnnotation: "poweroff"},
+			expectErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			m := &Machine{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "foobar", Annotations: tt.annotations},
+				Spec: MachineSpec{
+					Bootstrap:         Bootstrap{ConfigRef: &corev1.ObjectReference{Namespace: "foobar"}},
+					InfrastructureRef: corev1.ObjectReference{Namespace: "foobar"},
+				},
+			}
+
+			if tt.expectErr {
+				g.Expect(m.ValidateCreate()).NotTo(Succeed())
+				g.Expect(m.ValidateUpdate(nil)).NotTo(Succeed())
+			} else {
+				g.Expect(m.ValidateCreate()).To(Succeed())
+				g.Expect(m.ValidateUpdate(nil)).To(Succeed())
+			}
+		})
+	}
+}
+
+func TestMachineNetworkValidation(t *testing.T) {
+	tests := []struct {
+		name      string
+		network   *MachineNetwork
+		expectErr bool
+	}{
+		{
+			name:      "should succeed without network requirements",
+			network:   nil,
+			expectErr: false,
+		},
+		{
+			name: "should succeed with valid static addresses and gateway",
+			network: &MachineNetwork{
+				Interfaces: []MachineNetworkInterface{
+					{Name: "eth0", Addresses: []string{"10.0.0.10/24", "fd00::10/64"}, Gateway: pointer.StringPtr("10.0.0.1")},
+					{Name: "eth1", SubnetHint: pointer.StringPtr("subnet-1")},
+				},
+			},
+			expectErr: false,
+		},
+		{
+			name: "should return error with duplicated interface names",
+			network: &MachineNetwork{
+				Interfaces: []MachineNetworkInterface{{Name: "eth0"}, {Name: "eth0"}},
+			},
+			expectErr: true,
+		},
+		{
+			name: "should return error with addresses not in CIDR notation",
+			network: &MachineNetwork{
+				Interfaces: []MachineNetworkInterface{{Name: "eth0", Addresses: []string{"10.0.0.10"}}},
+			},
+			expectErr: true,
+		},
+		{
+			name: "should return error with an invalid gateway",
+			network: &MachineNetwork{
+				Interfaces: []MachineNetworkInterface{{Name: "eth0", Gateway: pointer.StringPtr("10.0.0")}},
+			},
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			m := &Machine{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "foobar"},
+				Spec: MachineSpec{
+					Bootstrap:         Bootstrap{ConfigRef: &corev1.ObjectReference{Namespace: "foobar"}},
+					InfrastructureRef: corev1.ObjectReference{Namespace: "foobar"},
+					Network:           tt.network,
+				},
+			}
+
+			if tt.expectErr {
+				g.Expect(m.ValidateCreate()).NotTo(Succeed())
+				g.Expect(m.ValidateUpdate(nil)).NotTo(Succeed())
+			} else {
+				g.Expect(m.ValidateCreate()).To(Succeed())
+				g.Expect(m.ValidateUpdate(nil)).To(Succeed())
+			}
+		})
+	}
+}
+
 func TestMachineNamespaceValidation(t *testing.T) {
 	tests := []struct {
 		name      string
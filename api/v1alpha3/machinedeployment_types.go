@@ -17,6 +17,7 @@ limitations under the License.
 package v1alpha3
 
 import (
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
 )
@@ -27,6 +28,10 @@ const (
 	// Replace the old MachineSet by new one using rolling update
 	// i.e. gradually scale down the old MachineSet and scale up the new one.
 	RollingUpdateMachineDeploymentStrategyType MachineDeploymentStrategyType = "RollingUpdate"
+
+	// Create a new MachineSet on changes, but only replace the Machines of the old MachineSets
+	// once they are deleted by the user, e.g. during a maintenance window.
+	OnDeleteMachineDeploymentStrategyType MachineDeploymentStrategyType = "OnDelete"
 )
 
 // ANCHOR: MachineDeploymentSpec
@@ -77,6 +82,12 @@ type MachineDeploymentSpec struct {
 	// reason will be surfaced in the deployment status. Note that progress will
 	// not be estimated during the time a deployment is paused. Defaults to 600s.
 	ProgressDeadlineSeconds *int32 `json:"progressDeadlineSeconds,omitempty"`
+
+	// RolloutAfter is a field to indicate a rollout should be performed
+	// after the specified time even if no changes have been made to the
+	// MachineDeployment. MachineSets created before this time are replaced.
+	// +optional
+	RolloutAfter *metav1.Time `json:"rolloutAfter,omitempty"`
 }
 
 // ANCHOR_END: MachineDeploymentSpec
@@ -86,9 +97,9 @@ type MachineDeploymentSpec struct {
 // MachineDeploymentStrategy describes how to replace existing machines
 // with new ones.
 type MachineDeploymentStrategy struct {
-	// Type of deployment. Currently the only supported strategy is
-	// "RollingUpdate".
+	// Type of deployment. Allowed values are RollingUpdate and OnDelete.
 	// Default is RollingUpdate.
+	// +kubebuilder:validation:Enum=RollingUpdate;OnDelete
 	// +optional
 	Type MachineDeploymentStrategyType `json:"type,omitempty"`
 
@@ -182,10 +193,56 @@ type MachineDeploymentStatus struct {
 	// Phase represents the current phase of a MachineDeployment (ScalingUp, ScalingDown, Running, Failed, or Unknown).
 	// +optional
 	Phase string `json:"phase,omitempty"`
+
+	// PreflightCheckFailures lists the failed checks against the workload
+	// cluster that are blocking the start of a rollout; the checks can be
+	// skipped with the cluster.x-k8s.io/skip-upgrade-preflight-checks annotation.
+	// +optional
+	PreflightCheckFailures []string `json:"preflightCheckFailures,omitempty"`
+
+	// Summary aggregates the Machines targeted by this deployment, so that
+	// clients don't need to list them.
+	// +optional
+	Summary *MachineDeploymentSummary `json:"summary,omitempty"`
+
+	// Conditions defines current service state of the MachineDeployment.
+	// +optional
+	Conditions Conditions `json:"conditions,omitempty"`
 }
 
 // ANCHOR_END: MachineDeploymentStatus
 
+// MachineDeploymentSummary aggregates the Machines targeted by a MachineDeployment.
+type MachineDeploymentSummary struct {
+	// FailureDomains is the number of Machines in each failure domain.
+	// Machines without a failure domain are not counted.
+	// +optional
+	FailureDomains map[string]int32 `json:"failureDomains,omitempty"`
+
+	// Phases is the number of Machines in each phase (Pending, Provisioning,
+	// Provisioned, Running, Deleting, Deleted, Failed, or Unknown).
+	// +optional
+	Phases map[string]int32 `json:"phases,omitempty"`
+
+	// OldestMachineCreationTimestamp is the creation timestamp of the oldest Machine.
+	// +optional
+	OldestMachineCreationTimestamp *metav1.Time `json:"oldestMachineCreationTimestamp,omitempty"`
+
+	// NewestMachineCreationTimestamp is the creation timestamp of the newest Machine.
+	// +optional
+	NewestMachineCreationTimestamp *metav1.Time `json:"newestMachineCreationTimestamp,omitempty"`
+
+	// InfrastructureRef is the reference to the infrastructure template
+	// new Machines are created from.
+	// +optional
+	InfrastructureRef *corev1.ObjectReference `json:"infrastructureRef,omitempty"`
+
+	// BootstrapConfigRef is the reference to the bootstrap configuration
+	// template new Machines are created from, if any.
+	// +optional
+	BootstrapConfigRef *corev1.ObjectReference `json:"bootstrapConfigRef,omitempty"`
+}
+
 // MachineDeploymentPhase indicates the progress of the machine deployment
 type MachineDeploymentPhase string
 
@@ -245,6 +302,14 @@ type MachineDeployment struct {
 	Status MachineDeploymentStatus `json:"status,omitempty"`
 }
 
+func (in *MachineDeployment) GetConditions() Conditions {
+	return in.Status.Conditions
+}
+
+func (in *MachineDeployment) SetConditions(conditions Conditions) {
+	in.Status.Conditions = conditions
+}
+
 // +kubebuilder:object:root=true
 
 // MachineDeploymentList contains a list of MachineDeployment
@@ -26,6 +26,7 @@ import (
 	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/apimachinery/pkg/util/validation/field"
 	"k8s.io/utils/pointer"
+	utilversion "sigs.k8s.io/cluster-api/util/version"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
 )
@@ -49,12 +50,13 @@ func (m *MachineDeployment) Default() {
 
 // ValidateCreate implements webhook.Validator so a webhook will be registered for the type
 func (m *MachineDeployment) ValidateCreate() error {
-	return m.validate()
+	return m.validate(nil)
 }
 
 // ValidateUpdate implements webhook.Validator so a webhook will be registered for the type
 func (m *MachineDeployment) ValidateUpdate(old runtime.Object) error {
-	return m.validate()
+	oldMD, _ := old.(*MachineDeployment)
+	return m.validate(oldMD)
 }
 
 // ValidateDelete implements webhook.Validator so a webhook will be registered for the type
@@ -62,7 +64,7 @@ func (m *MachineDeployment) ValidateDelete() error {
 	return nil
 }
 
-func (m *MachineDeployment) validate() error {
+func (m *MachineDeployment) validate(old *MachineDeployment) error {
 	var allErrs field.ErrorList
 	selector, err := metav1.LabelSelectorAsSelector(&m.Spec.Selector)
 	if err != nil {
@@ -81,6 +83,17 @@ func (m *MachineDeployment) validate() error {
 		)
 	}
 
+	allErrs = append(allErrs, validateMachineNetwork(m.Spec.Template.Spec.Network, field.NewPath("spec", "template", "spec", "network"), false)...)
+
+	if old != nil && old.Spec.Template.Spec.Version != nil && m.Spec.Template.Spec.Version != nil {
+		if err := utilversion.ValidateUpgrade(*old.Spec.Template.Spec.Version, *m.Spec.Template.Spec.Version); err != nil {
+			allErrs = append(
+				allErrs,
+				field.Forbidden(field.NewPath("spec", "template", "spec", "version"), err.Error()),
+			)
+		}
+	}
+
 	if len(allErrs) == 0 {
 		return nil
 	}
@@ -111,3 +111,111 @@ func TestMachineDeploymentValidation(t *testing.T) {
 		})
 	}
 }
+
+func TestMachineDeploymentNetworkValidation(t *testing.T) {
+	tests := []struct {
+		name      string
+		network   *MachineNetwork
+		expectErr bool
+	}{
+		{
+			name: "should succeed with subnet hints",
+			network: &MachineNetwork{
+				Interfaces: []MachineNetworkInterface{{Name: "eth0", SubnetHint: pointer.StringPtr("subnet-1")}},
+			},
+			expectErr: false,
+		},
+		{
+			name: "should return error with static addresses",
+			network: &MachineNetwork{
+				Interfaces: []MachineNetworkInterface{{Name: "eth0", Addresses: []string{"10.0.0.10/24"}}},
+			},
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+			md := &MachineDeployment{
+				Spec: MachineDeploymentSpec{
+					Selector: metav1.LabelSelector{
+						MatchLabels: map[string]string{"foo": "bar"},
+					},
+					Template: MachineTemplateSpec{
+						ObjectMeta: ObjectMeta{
+							Labels: map[string]string{"foo": "bar"},
+						},
+						Spec: MachineSpec{
+							Network: tt.network,
+						},
+					},
+				},
+			}
+			if tt.expectErr {
+				g.Expect(md.ValidateCreate()).NotTo(Succeed())
+				g.Expect(md.ValidateUpdate(nil)).NotTo(Succeed())
+			} else {
+				g.Expect(md.ValidateCreate()).To(Succeed())
+				g.Expect(md.ValidateUpdate(nil)).To(Succeed())
+			}
+		})
+	}
+}
+
+func TestMachineDeploymentVersionUpgradeValidation(t *testing.T) {
+	tests := []struct {
+		name       string
+		oldVersion string
+		newVersion string
+		expectErr  bool
+	}{
+		{
+			name:       "should succeed when upgrading to the next minor version",
+			oldVersion: "v1.17.3",
+			newVersion: "v1.18.2",
+			expectErr:  false,
+		},
+		{
+			name:       "should succeed when upgrading to a patch version",
+			oldVersion: "v1.17.3",
+			newVersion: "v1.17.4",
+			expectErr:  false,
+		},
+		{
+			name:       "should return error when skipping a minor version",
+			oldVersion: "v1.17.3",
+			newVersion: "v1.19.0",
+			expectErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+			md := func(version string) *MachineDeployment {
+				return &MachineDeployment{
+					Spec: MachineDeploymentSpec{
+						Selector: metav1.LabelSelector{
+							MatchLabels: map[string]string{"foo": "bar"},
+						},
+						Template: MachineTemplateSpec{
+							ObjectMeta: ObjectMeta{
+								Labels: map[string]string{"foo": "bar"},
+							},
+							Spec: MachineSpec{
+								Version: pointer.StringPtr(version),
+							},
+						},
+					},
+				}
+			}
+			err := md(tt.newVersion).ValidateUpdate(md(tt.oldVersion))
+			if tt.expectErr {
+				g.Expect(err).To(HaveOccurred())
+			} else {
+				g.Expect(err).NotTo(HaveOccurred())
+			}
+		})
+	}
+}
@@ -41,9 +41,56 @@ type MachineHealthCheckSpec struct {
 	UnhealthyConditions []UnhealthyCondition `json:"unhealthyConditions"`
 
 	// Any further remediation is only allowed if at most "MaxUnhealthy" machines selected by
-	// "selector" are not healthy.
+	// "selector" are not healthy. It can be an absolute number or a percentage of the selected machines.
 	// +optional
 	MaxUnhealthy *intstr.IntOrString `json:"maxUnhealthy,omitempty"`
+
+	// Any further remediation is only allowed if the number of machines selected by "selector" as not healthy
+	// is within the range of "UnhealthyRange". Takes precedence over MaxUnhealthy.
+	// Eg. "[3-5]" - This means that remediation will be allowed only when:
+	// (a) there are at least 3 unhealthy machines (and)
+	// (b) there are at most 5 unhealthy machines
+	// +optional
+	// +kubebuilder:validation:Pattern=`^\[[0-9]+-[0-9]+\]$`
+	UnhealthyRange *string `json:"unhealthyRange,omitempty"`
+
+	// Machines older than this duration without a node will be considered to have
+	// failed and will be remediated.
+	// Defaults to 10 minutes, set to 0 to disable.
+	// +optional
+	NodeStartupTimeout *metav1.Duration `json:"nodeStartupTimeout,omitempty"`
+
+	// RemediationTemplate is a reference to a remediation template
+	// provided by an infrastructure provider.
+	//
+	// This field is completely optional, when filled, the MachineHealthCheck controller
+	// creates a new object from the template referenced and hands off remediation of the machine to
+	// a controller that lives outside of Cluster API. When empty, unhealthy Machines owned
+	// by a MachineSet are deleted.
+	// +optional
+	RemediationTemplate *corev1.ObjectReference `json:"remediationTemplate,omitempty"`
+
+	// RemediationTimeout is the maximum amount of time an external remediation request is given
+	// to bring a Machine back to health. Once it has expired, the Machine is deleted if it is owned
+	// by a MachineSet. If not set, the controller waits for the external remediation to either
+	// succeed or report a failure.
+	// +optional
+	RemediationTimeout *metav1.Duration `json:"remediationTimeout,omitempty"`
+
+	// MaxRemediationRetries is the maximum number of remediations recorded in the remediation history of a
+	// MachineSet, or of a Machine not owned by a MachineSet, within the RemediationRetryPeriod. Once it is
+	// reached, the unhealthy Machines are no longer remediated and their Remediating condition is set to False
+	// with the RemediationRetriesExhausted reason, flagging the need for human intervention.
+	// If not set, unhealthy Machines are always remediated.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=10
+	MaxRemediationRetries *int32 `json:"maxRemediationRetries,omitempty"`
+
+	// RemediationRetryPeriod is the period over which remediations are counted against MaxRemediationRetries.
+	// If not set, all the remediations recorded in the remediation history are counted.
+	// +optional
+	RemediationRetryPeriod *metav1.Duration `json:"remediationRetryPeriod,omitempty"`
 }
 
 // ANCHOR_END: MachineHealthCHeckSpec
@@ -78,10 +125,76 @@ type MachineHealthCheckStatus struct {
 	// total number of healthy machines counted by this machine health check
 	// +kubebuilder:validation:Minimum=0
 	CurrentHealthy int32 `json:"currentHealthy"`
+
+	// total number of unhealthy machines currently handed off to an external remediation request
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	RemediationsInProgress int32 `json:"remediationsInProgress,omitempty"`
+
+	// RemediationStartTime is the time unhealthy machines were found, while there are machines not healthy yet;
+	// it is reset once all the machines are healthy again.
+	// +optional
+	RemediationStartTime *metav1.Time `json:"remediationStartTime,omitempty"`
+
+	// Conditions defines current service state of the MachineHealthCheck.
+	// +optional
+	Conditions Conditions `json:"conditions,omitempty"`
 }
 
 // ANCHOR_END: MachineHealthCheckStatus
 
+// MaxRemediationHistory is the maximum number of records kept in a remediation history.
+const MaxRemediationHistory = 10
+
+// RemediationType is the type of a remediation performed by a MachineHealthCheck.
+type RemediationType string
+
+const (
+	// DeleteRemediationType is the remediation of a Machine owned by a MachineSet by deleting it.
+	DeleteRemediationType RemediationType = "Delete"
+
+	// ExternalRemediationType is the remediation of a Machine by an external remediation request
+	// created from the RemediationTemplate of the MachineHealthCheck.
+	ExternalRemediationType RemediationType = "External"
+
+	// OwnerRemediationType is the remediation of a Machine handed off to the controller owning it.
+	OwnerRemediationType RemediationType = "Owner"
+)
+
+// RemediationOutcome is the outcome of a remediation performed by a MachineHealthCheck.
+type RemediationOutcome string
+
+const (
+	// RemediationStarted is the outcome of a remediation which has not completed yet.
+	RemediationStarted RemediationOutcome = "Started"
+
+	// RemediationSucceeded is the outcome of a remediation which completed, i.e. the Machine was deleted
+	// or became healthy again.
+	RemediationSucceeded RemediationOutcome = "Succeeded"
+
+	// RemediationFailed is the outcome of an external remediation which reported a failure or did not
+	// complete within the RemediationTimeout.
+	RemediationFailed RemediationOutcome = "Failed"
+)
+
+// RemediationRecord records a remediation performed by a MachineHealthCheck.
+type RemediationRecord struct {
+	// MachineName is the name of the remediated Machine.
+	MachineName string `json:"machineName"`
+
+	// Timestamp is the time the remediation started.
+	Timestamp metav1.Time `json:"timestamp"`
+
+	// Cause is the reason the Machine was found unhealthy.
+	Cause string `json:"cause"`
+
+	// Type is the type of the remediation.
+	Type RemediationType `json:"type"`
+
+	// Outcome is the outcome of the remediation.
+	Outcome RemediationOutcome `json:"outcome"`
+}
+
 // +kubebuilder:object:root=true
 // +kubebuilder:resource:path=machinehealthchecks,shortName=mhc;mhcs,scope=Namespaced,categories=cluster-api
 // +kubebuilder:storageversion
@@ -102,6 +215,14 @@ type MachineHealthCheck struct {
 	Status MachineHealthCheckStatus `json:"status,omitempty"`
 }
 
+func (m *MachineHealthCheck) GetConditions() Conditions {
+	return m.Status.Conditions
+}
+
+func (m *MachineHealthCheck) SetConditions(conditions Conditions) {
+	m.Status.Conditions = conditions
+}
+
 // +kubebuilder:object:root=true
 
 // MachineHealthCheckList contains a list of MachineHealthCheck
@@ -18,6 +18,7 @@ package v1alpha3
 
 import (
 	"fmt"
+	"time"
 
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -37,6 +38,16 @@ func (m *MachineHealthCheck) SetupWebhookWithManager(mgr ctrl.Manager) error {
 // +kubebuilder:webhook:verbs=create;update,path=/validate-cluster-x-k8s-io-v1alpha3-machinehealthcheck,mutating=false,failurePolicy=fail,groups=cluster.x-k8s.io,resources=machinehealthchecks,versions=v1alpha3,name=validation.machinehealthcheck.cluster.x-k8s.io
 // +kubebuilder:webhook:verbs=create;update,path=/mutate-cluster-x-k8s-io-v1alpha3-machinehealthcheck,mutating=true,failurePolicy=fail,groups=cluster.x-k8s.io,resources=machinehealthchecks,versions=v1alpha3,name=default.machinehealthcheck.cluster.x-k8s.io
 
+var (
+	// Default time allowed for a node to start up. Can be made longer as part of
+	// spec if required for particular provider.
+	// 10 minutes should allow the instance to start and the node to join the
+	// cluster on most providers.
+	defaultNodeStartupTimeout = metav1.Duration{Duration: 10 * time.Minute}
+	// Minimum time allowed for a node to start up.
+	minNodeStartupTimeout = metav1.Duration{Duration: 30 * time.Second}
+)
+
 var _ webhook.Defaulter = &MachineHealthCheck{}
 var _ webhook.Validator = &MachineHealthCheck{}
 
@@ -46,6 +57,15 @@ func (m *MachineHealthCheck) Default() {
 		defaultMaxUnhealthy := intstr.FromString("100%")
 		m.Spec.MaxUnhealthy = &defaultMaxUnhealthy
 	}
+
+	if m.Spec.NodeStartupTimeout == nil {
+		timeout := defaultNodeStartupTimeout
+		m.Spec.NodeStartupTimeout = &timeout
+	}
+
+	if m.Spec.RemediationTemplate != nil && len(m.Spec.RemediationTemplate.Namespace) == 0 {
+		m.Spec.RemediationTemplate.Namespace = m.Namespace
+	}
 }
 
 // ValidateCreate implements webhook.Validator so a webhook will be registered for the type
@@ -86,6 +106,85 @@ func (m *MachineHealthCheck) validate(old *MachineHealthCheck) error {
 		)
 	}
 
+	if m.Spec.NodeStartupTimeout != nil &&
+		m.Spec.NodeStartupTimeout.Seconds() != 0 &&
+		m.Spec.NodeStartupTimeout.Seconds() < minNodeStartupTimeout.Seconds() {
+		allErrs = append(
+			allErrs,
+			field.Invalid(field.NewPath("spec", "nodeStartupTimeout"), m.Spec.NodeStartupTimeout.Duration.String(),
+				fmt.Sprintf("must be 0 or at least %v", minNodeStartupTimeout.Duration)),
+		)
+	}
+
+	if m.Spec.MaxUnhealthy != nil {
+		if _, err := intstr.GetValueFromIntOrPercent(m.Spec.MaxUnhealthy, 0, false); err != nil {
+			allErrs = append(
+				allErrs,
+				field.Invalid(field.NewPath("spec", "maxUnhealthy"), m.Spec.MaxUnhealthy.String(), "must be an integer or a percentage"),
+			)
+		}
+	}
+
+	if m.Spec.UnhealthyRange != nil {
+		var min, max int
+		if _, err := fmt.Sscanf(*m.Spec.UnhealthyRange, "[%d-%d]", &min, &max); err != nil || min > max {
+			allErrs = append(
+				allErrs,
+				field.Invalid(field.NewPath("spec", "unhealthyRange"), *m.Spec.UnhealthyRange, "must be a range like [min-max], with min less than or equal to max"),
+			)
+		}
+	}
+
+	if m.Spec.RemediationTemplate != nil && m.Spec.RemediationTemplate.Namespace != m.Namespace {
+		allErrs = append(
+			allErrs,
+			field.Invalid(
+				field.NewPath("spec", "remediationTemplate", "namespace"),
+				m.Spec.RemediationTemplate.Namespace,
+				"must match metadata.namespace",
+			),
+		)
+	}
+
+	if m.Spec.RemediationTimeout != nil {
+		if m.Spec.RemediationTemplate == nil {
+			allErrs = append(
+				allErrs,
+				field.Forbidden(field.NewPath("spec", "remediationTimeout"), "can only be set together with spec.remediationTemplate"),
+			)
+		} else if m.Spec.RemediationTimeout.Duration < 0 {
+			allErrs = append(
+				allErrs,
+				field.Invalid(field.NewPath("spec", "remediationTimeout"), m.Spec.RemediationTimeout.Duration.String(), "must be greater than or equal to 0"),
+			)
+		}
+	}
+
+	if m.Spec.MaxRemediationRetries != nil && (*m.Spec.MaxRemediationRetries < 1 || *m.Spec.MaxRemediationRetries > MaxRemediationHistory) {
+		allErrs = append(
+			allErrs,
+			field.Invalid(
+				field.NewPath("spec", "maxRemediationRetries"),
+				*m.Spec.MaxRemediationRetries,
+				fmt.Sprintf("must be between 1 and %d", MaxRemediationHistory),
+			),
+		)
+	}
+
+	if m.Spec.RemediationRetryPeriod != nil {
+		if m.Spec.MaxRemediationRetries == nil {
+			allErrs = append(
+				allErrs,
+				field.Forbidden(field.NewPath("spec", "remediationRetryPeriod"), "can only be set together with spec.maxRemediationRetries"),
+			)
+		} else if m.Spec.RemediationRetryPeriod.Duration <= 0 {
+			allErrs = append(
+				allErrs,
+				field.Invalid(field.NewPath("spec", "remediationRetryPeriod"), m.Spec.RemediationRetryPeriod.Duration.String(), "must be greater than 0"),
+			)
+		}
+	}
+
 	if len(allErrs) == 0 {
 		return nil
 	}
@@ -18,9 +18,13 @@ package v1alpha3
 
 import (
 	"testing"
+	"time"
 
 	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/utils/pointer"
 )
 
 func TestMachineHealthCheckDefault(t *testing.T) {
@@ -30,6 +34,22 @@ func TestMachineHealthCheckDefault(t *testing.T) {
 	mhc.Default()
 
 	g.Expect(mhc.Spec.MaxUnhealthy.String()).To(Equal("100%"))
+	g.Expect(mhc.Spec.NodeStartupTimeout).To(Equal(&metav1.Duration{Duration: 10 * time.Minute}))
+	g.Expect(mhc.Spec.RemediationTemplate).To(BeNil())
+}
+
+func TestMachineHealthCheckDefaultRemediationTemplateNamespace(t *testing.T) {
+	g := NewWithT(t)
+	mhc := &MachineHealthCheck{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "foo"},
+		Spec: MachineHealthCheckSpec{
+			RemediationTemplate: &corev1.ObjectReference{Name: "remediation-template"},
+		},
+	}
+
+	mhc.Default()
+
+	g.Expect(mhc.Spec.RemediationTemplate.Namespace).To(Equal("foo"))
 }
 
 func TestMachineHealthCheckLabelSelectorAsSelectorValidation(t *testing.T) {
@@ -115,3 +135,251 @@ func TestMachineHealthCheckClusterNameImmutable(t *testing.T) {
 		})
 	}
 }
+
+func TestMachineHealthCheckNodeStartupTimeout(t *testing.T) {
+	zero := metav1.Duration{Duration: 0}
+	twentyNineSeconds := metav1.Duration{Duration: 29 * time.Second}
+	thirtySeconds := metav1.Duration{Duration: 30 * time.Second}
+	oneDay := metav1.Duration{Duration: 24 * time.Hour}
+
+	tests := []struct {
+		name      string
+		timeout   *metav1.Duration
+		expectErr bool
+	}{
+		{
+			name:      "when the nodeStartupTimeout is not given",
+			timeout:   nil,
+			expectErr: false,
+		},
+		{
+			name:      "when the nodeStartupTimeout is 0 (disabled)",
+			timeout:   &zero,
+			expectErr: false,
+		},
+		{
+			name:      "when the nodeStartupTimeout is below 30s",
+			timeout:   &twentyNineSeconds,
+			expectErr: true,
+		},
+		{
+			name:      "when the nodeStartupTimeout is 30s",
+			timeout:   &thirtySeconds,
+			expectErr: false,
+		},
+		{
+			name:      "when the nodeStartupTimeout is above 30s",
+			timeout:   &oneDay,
+			expectErr: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+			mhc := &MachineHealthCheck{
+				Spec: MachineHealthCheckSpec{
+					NodeStartupTimeout: tt.timeout,
+				},
+			}
+
+			if tt.expectErr {
+				g.Expect(mhc.ValidateCreate()).NotTo(Succeed())
+			} else {
+				g.Expect(mhc.ValidateCreate()).To(Succeed())
+			}
+		})
+	}
+}
+
+func TestMachineHealthCheckRemediationTemplateValidation(t *testing.T) {
+	tests := []struct {
+		name      string
+		template  *corev1.ObjectReference
+		timeout   *metav1.Duration
+		expectErr bool
+	}{
+		{
+			name:      "when no remediation template is given",
+			expectErr: false,
+		},
+		{
+			name:      "when the remediation template is in the same namespace",
+			template:  &corev1.ObjectReference{Namespace: "foo", Name: "remediation-template"},
+			expectErr: false,
+		},
+		{
+			name:      "when the remediation template is in another namespace",
+			template:  &corev1.ObjectReference{Namespace: "bar", Name: "remediation-template"},
+			expectErr: true,
+		},
+		{
+			name:      "when a remediation timeout is given with a remediation template",
+			template:  &corev1.ObjectReference{Namespace: "foo", Name: "remediation-template"},
+			timeout:   &metav1.Duration{Duration: time.Hour},
+			expectErr: false,
+		},
+		{
+			name:      "when a negative remediation timeout is given",
+			template:  &corev1.ObjectReference{Namespace: "foo", Name: "remediation-template"},
+			timeout:   &metav1.Duration{Duration: -time.Hour},
+			expectErr: true,
+		},
+		{
+			name:      "when a remediation timeout is given without a remediation template",
+			timeout:   &metav1.Duration{Duration: time.Hour},
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+			mhc := &MachineHealthCheck{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "foo"},
+				Spec: MachineHealthCheckSpec{
+					RemediationTemplate: tt.template,
+					RemediationTimeout:  tt.timeout,
+				},
+			}
+
+			if tt.expectErr {
+				g.Expect(mhc.ValidateCreate()).NotTo(Succeed())
+				g.Expect(mhc.ValidateUpdate(mhc)).NotTo(Succeed())
+			} else {
+				g.Expect(mhc.ValidateCreate()).To(Succeed())
+				g.Expect(mhc.ValidateUpdate(mhc)).To(Succeed())
+			}
+		})
+	}
+}
+
+func TestMachineHealthCheckRemediationRetriesValidation(t *testing.T) {
+	tests := []struct {
+		name       string
+		maxRetries *int32
+		period     *metav1.Duration
+		expectErr  bool
+	}{
+		{
+			name:      "when no retry limit is given",
+			expectErr: false,
+		},
+		{
+			name:       "when a retry limit is given with a retry period",
+			maxRetries: pointer.Int32Ptr(3),
+			period:     &metav1.Duration{Duration: time.Hour},
+			expectErr:  false,
+		},
+		{
+			name:       "when a retry limit is given without a retry period",
+			maxRetries: pointer.Int32Ptr(3),
+			expectErr:  false,
+		},
+		{
+			name:       "when the retry limit is 0",
+			maxRetries: pointer.Int32Ptr(0),
+			expectErr:  true,
+		},
+		{
+			name:       "when the retry limit exceeds the remediation history",
+			maxRetries: pointer.Int32Ptr(MaxRemediationHistory + 1),
+			expectErr:  true,
+		},
+		{
+			name:       "when the retry period is not positive",
+			maxRetries: pointer.Int32Ptr(3),
+			period:     &metav1.Duration{},
+			expectErr:  true,
+		},
+		{
+			name:      "when a retry period is given without a retry limit",
+			period:    &metav1.Duration{Duration: time.Hour},
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+			mhc := &MachineHealthCheck{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "foo"},
+				Spec: MachineHealthCheckSpec{
+					MaxRemediationRetries:  tt.maxRetries,
+					RemediationRetryPeriod: tt.period,
+				},
+			}
+
+			if tt.expectErr {
+				g.Expect(mhc.ValidateCreate()).NotTo(Succeed())
+				g.Expect(mhc.ValidateUpdate(mhc)).NotTo(Succeed())
+			} else {
+				g.Expect(mhc.ValidateCreate()).To(Succeed())
+				g.Expect(mhc.ValidateUpdate(mhc)).To(Succeed())
+			}
+		})
+	}
+}
+
+func TestMachineHealthCheckUnhealthyThresholdsValidation(t *testing.T) {
+	tests := []struct {
+		name           string
+		maxUnhealthy   *intstr.IntOrString
+		unhealthyRange *string
+		expectErr      bool
+	}{
+		{
+			name:         "when maxUnhealthy is an int",
+			maxUnhealthy: &intstr.IntOrString{Type: intstr.Int, IntVal: 3},
+			expectErr:    false,
+		},
+		{
+			name:         "when maxUnhealthy is a percentage",
+			maxUnhealthy: &intstr.IntOrString{Type: intstr.String, StrVal: "40%"},
+			expectErr:    false,
+		},
+		{
+			name:         "when maxUnhealthy is not an int or percentage",
+			maxUnhealthy: &intstr.IntOrString{Type: intstr.String, StrVal: "abcdef"},
+			expectErr:    true,
+		},
+		{
+			name:           "when unhealthyRange is valid",
+			unhealthyRange: pointer.StringPtr("[3-5]"),
+			expectErr:      false,
+		},
+		{
+			name:           "when unhealthyRange has a single value",
+			unhealthyRange: pointer.StringPtr("[3-3]"),
+			expectErr:      false,
+		},
+		{
+			name:           "when unhealthyRange has the lower bound greater than the upper bound",
+			unhealthyRange: pointer.StringPtr("[5-3]"),
+			expectErr:      true,
+		},
+		{
+			name:           "when unhealthyRange is not a range",
+			unhealthyRange: pointer.StringPtr("3"),
+			expectErr:      true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+			mhc := &MachineHealthCheck{
+				Spec: MachineHealthCheckSpec{
+					MaxUnhealthy:   tt.maxUnhealthy,
+					UnhealthyRange: tt.unhealthyRange,
+				},
+			}
+
+			if tt.expectErr {
+				g.Expect(mhc.ValidateCreate()).NotTo(Succeed())
+			} else {
+				g.Expect(mhc.ValidateCreate()).To(Succeed())
+			}
+		})
+	}
+}
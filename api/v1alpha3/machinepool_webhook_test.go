@@ -0,0 +1,141 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha3
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/cluster-api/feature"
+)
+
+func TestMachinePoolDefault(t *testing.T) {
+	g := NewWithT(t)
+
+	m := &MachinePool{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "foobar",
+		},
+		Spec: MachinePoolSpec{
+			ClusterName: "test-cluster",
+			Template: MachineTemplateSpec{
+				Spec: MachineSpec{
+					Bootstrap: Bootstrap{ConfigRef: &corev1.ObjectReference{}},
+					Version:   pointer.StringPtr("1.17.5"),
+				},
+			},
+		},
+	}
+
+	m.Default()
+
+	g.Expect(m.Labels[ClusterLabelName]).To(Equal(m.Spec.ClusterName))
+	g.Expect(m.Spec.Replicas).To(Equal(pointer.Int32Ptr(1)))
+	g.Expect(m.Spec.MinReadySeconds).To(Equal(pointer.Int32Ptr(0)))
+	g.Expect(m.Spec.Template.Spec.Bootstrap.ConfigRef.Namespace).To(Equal(m.Namespace))
+	g.Expect(m.Spec.Template.Spec.InfrastructureRef.Namespace).To(Equal(m.Namespace))
+	g.Expect(m.Spec.Template.Spec.Version).To(Equal(pointer.StringPtr("v1.17.5")))
+}
+
+func TestMachinePoolValidation(t *testing.T) {
+	defer feature.SetFeatureGateDuringTest(feature.Gates, feature.MachinePool, true)()
+
+	tests := []struct {
+		name      string
+		bootstrap Bootstrap
+		replicas  *int32
+		expectErr bool
+	}{
+		{
+			name:      "should return error if configref and data are nil",
+			bootstrap: Bootstrap{ConfigRef: nil, DataSecretName: nil},
+			expectErr: true,
+		},
+		{
+			name:      "should not return error if dataSecretName is set",
+			bootstrap: Bootstrap{ConfigRef: nil, DataSecretName: pointer.StringPtr("test")},
+			expectErr: false,
+		},
+		{
+			name:      "should not return error if config ref is set",
+			bootstrap: Bootstrap{ConfigRef: &corev1.ObjectReference{}},
+			expectErr: false,
+		},
+		{
+			name:      "should return error if replicas is negative",
+			bootstrap: Bootstrap{ConfigRef: &corev1.ObjectReference{}},
+			replicas:  pointer.Int32Ptr(-1),
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+			m := &MachinePool{
+				Spec: MachinePoolSpec{
+					Replicas: tt.replicas,
+					Template: MachineTemplateSpec{
+						Spec: MachineSpec{Bootstrap: tt.bootstrap},
+					},
+				},
+			}
+			if tt.expectErr {
+				g.Expect(m.ValidateCreate()).NotTo(Succeed())
+				g.Expect(m.ValidateUpdate(m.DeepCopy())).NotTo(Succeed())
+			} else {
+				g.Expect(m.ValidateCreate()).To(Succeed())
+				g.Expect(m.ValidateUpdate(m.DeepCopy())).To(Succeed())
+			}
+		})
+	}
+}
+
+func TestMachinePoolFeatureGate(t *testing.T) {
+	g := NewWithT(t)
+	defer feature.SetFeatureGateDuringTest(feature.Gates, feature.MachinePool, false)()
+
+	m := &MachinePool{
+		Spec: MachinePoolSpec{
+			Template: MachineTemplateSpec{
+				Spec: MachineSpec{Bootstrap: Bootstrap{DataSecretName: pointer.StringPtr("test")}},
+			},
+		},
+	}
+	g.Expect(m.ValidateCreate()).NotTo(Succeed())
+	g.Expect(m.ValidateUpdate(m.DeepCopy())).To(Succeed())
+}
+
+func TestMachinePoolClusterNameImmutable(t *testing.T) {
+	g := NewWithT(t)
+
+	newMP := &MachinePool{
+		Spec: MachinePoolSpec{
+			ClusterName: "bar",
+			Template: MachineTemplateSpec{
+				Spec: MachineSpec{Bootstrap: Bootstrap{ConfigRef: &corev1.ObjectReference{}}},
+			},
+		},
+	}
+	oldMP := newMP.DeepCopy()
+	oldMP.Spec.ClusterName = "foo"
+
+	g.Expect(newMP.ValidateUpdate(oldMP)).NotTo(Succeed())
+}
@@ -46,8 +46,8 @@ type MachineSetSpec struct {
 	MinReadySeconds int32 `json:"minReadySeconds,omitempty"`
 
 	// DeletePolicy defines the policy used to identify nodes to delete when downscaling.
-	// Defaults to "Random".  Valid values are "Random, "Newest", "Oldest"
-	// +kubebuilder:validation:Enum=Random;Newest;Oldest
+	// Defaults to "Random".  Valid values are "Random, "Newest", "Oldest", "LeastUtilized"
+	// +kubebuilder:validation:Enum=Random;Newest;Oldest;LeastUtilized
 	DeletePolicy string `json:"deletePolicy,omitempty"`
 
 	// Selector is a label query over machines that should match the replica count.
@@ -104,6 +104,14 @@ const (
 	// (Status.FailureReason or Status.FailureMessage are set to a non-empty value).
 	// It then prioritizes the oldest Machines for deletion based on the Machine's CreationTimestamp.
 	OldestMachineSetDeletePolicy MachineSetDeletePolicy = "Oldest"
+
+	// LeastUtilizedMachineSetDeletePolicy prioritizes both Machines that have the annotation
+	// "cluster.x-k8s.io/delete-machine=yes" and Machines that are unhealthy
+	// (Status.FailureReason or Status.FailureMessage are set to a non-empty value).
+	// It then prioritizes the Machines whose Nodes are running the fewest Pods, as observed in the
+	// workload cluster, using the resources requested on the Nodes to break ties.
+	// Falls back to "Random" when the workload cluster cannot be queried.
+	LeastUtilizedMachineSetDeletePolicy MachineSetDeletePolicy = "LeastUtilized"
 )
 
 // ANCHOR: MachineSetStatus
@@ -157,6 +165,11 @@ type MachineSetStatus struct {
 	FailureReason *capierrors.MachineSetStatusError `json:"failureReason,omitempty"`
 	// +optional
 	FailureMessage *string `json:"failureMessage,omitempty"`
+
+	// RemediationHistory records the most recent remediations of the Machines of the MachineSet performed by
+	// MachineHealthChecks, oldest first.
+	// +optional
+	RemediationHistory []RemediationRecord `json:"remediationHistory,omitempty"`
 }
 
 // ANCHOR_END: MachineSetStatus
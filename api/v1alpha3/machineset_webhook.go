@@ -72,6 +72,8 @@ func (m *MachineSet) validate() error {
 		)
 	}
 
+	allErrs = append(allErrs, validateMachineNetwork(m.Spec.Template.Spec.Network, field.NewPath("spec", "template", "spec", "network"), false)...)
+
 	if len(allErrs) == 0 {
 		return nil
 	}
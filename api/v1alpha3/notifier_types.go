@@ -0,0 +1,196 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha3
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// NotifierURLSecretKey is the key of the Secret data holding the URL notifications are posted to.
+	NotifierURLSecretKey = "url"
+)
+
+// ANCHOR: NotifierSpec
+
+// NotifierSpec defines the desired state of Notifier
+type NotifierSpec struct {
+	// Label selector for Clusters. The Clusters that are
+	// selected by this will be the ones whose lifecycle events are notified.
+	// It must match the Cluster labels.
+	ClusterSelector metav1.LabelSelector `json:"clusterSelector"`
+
+	// Events is the list of lifecycle events to notify.
+	// All the events are notified if empty.
+	// +optional
+	Events []NotifierEvent `json:"events,omitempty"`
+
+	// Webhook is the endpoint the notifications are posted to.
+	Webhook NotifierWebhook `json:"webhook"`
+}
+
+// ANCHOR_END: NotifierSpec
+
+// NotifierEvent is a Cluster lifecycle event that can be notified.
+// +kubebuilder:validation:Enum=ClusterCreated;ClusterReady;UpgradeStarted;UpgradeFinished;RemediationStarted;RemediationFinished
+type NotifierEvent string
+
+const (
+	// NotifierEventClusterCreated is notified when a Cluster is created.
+	NotifierEventClusterCreated NotifierEvent = "ClusterCreated"
+
+	// NotifierEventClusterReady is notified when a Cluster is provisioned and its control plane is initialized.
+	NotifierEventClusterReady NotifierEvent = "ClusterReady"
+
+	// NotifierEventUpgradeStarted is notified when the Machines of a Cluster start
+	// rolling out a new Kubernetes version.
+	NotifierEventUpgradeStarted NotifierEvent = "UpgradeStarted"
+
+	// NotifierEventUpgradeFinished is notified when all the Machines of a Cluster
+	// run the same Kubernetes version again.
+	NotifierEventUpgradeFinished NotifierEvent = "UpgradeFinished"
+
+	// NotifierEventRemediationStarted is notified when the MachineHealthChecks of a Cluster
+	// report unhealthy Machines.
+	NotifierEventRemediationStarted NotifierEvent = "RemediationStarted"
+
+	// NotifierEventRemediationFinished is notified when the MachineHealthChecks of a Cluster
+	// no longer report unhealthy Machines.
+	NotifierEventRemediationFinished NotifierEvent = "RemediationFinished"
+)
+
+// NotifierWebhook is an HTTP endpoint notifications are posted to.
+type NotifierWebhook struct {
+	// SecretName is the name of a Secret, in the same namespace as the Notifier,
+	// holding the URL notifications are posted to under the "url" key.
+	// The URL is stored in a Secret as webhook URLs usually embed credentials.
+	// +kubebuilder:validation:MinLength=1
+	SecretName string `json:"secretName"`
+
+	// Format is the format of the payload posted to the webhook. Defaults to JSON.
+	// +kubebuilder:validation:Enum=JSON;Slack
+	// +optional
+	Format NotifierFormat `json:"format,omitempty"`
+}
+
+// NotifierFormat is the format of the payload posted to a webhook.
+type NotifierFormat string
+
+const (
+	// NotifierFormatJSON posts a JSON document describing the event.
+	NotifierFormatJSON NotifierFormat = "JSON"
+
+	// NotifierFormatSlack posts a message compatible with Slack incoming webhooks.
+	NotifierFormatSlack NotifierFormat = "Slack"
+)
+
+// ANCHOR: NotifierStatus
+
+// NotifierStatus defines the observed state of Notifier
+type NotifierStatus struct {
+	// Clusters is the lifecycle state last notified for each of the selected Clusters.
+	// +optional
+	Clusters []NotifierClusterStatus `json:"clusters,omitempty"`
+
+	// LastNotificationTime is the time the last notification was successfully posted.
+	// +optional
+	LastNotificationTime *metav1.Time `json:"lastNotificationTime,omitempty"`
+
+	// FailureMessage is set when the last notification could not be posted.
+	// +optional
+	FailureMessage *string `json:"failureMessage,omitempty"`
+
+	// ObservedGeneration reflects the generation of the most recently observed Notifier.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+}
+
+// NotifierClusterStatus is the lifecycle state of a Cluster as last notified.
+type NotifierClusterStatus struct {
+	// Name of the Cluster.
+	Name string `json:"name"`
+
+	// Ready is true if the Cluster has been notified as ready.
+	// +optional
+	Ready bool `json:"ready,omitempty"`
+
+	// Version is the Kubernetes version all the Machines of the Cluster were last seen running.
+	// +optional
+	Version string `json:"version,omitempty"`
+
+	// Upgrading is true if the Cluster has been notified as being upgraded.
+	// +optional
+	Upgrading bool `json:"upgrading,omitempty"`
+
+	// Remediating is true if the Cluster has been notified as having unhealthy Machines.
+	// +optional
+	Remediating bool `json:"remediating,omitempty"`
+}
+
+// ANCHOR_END: NotifierStatus
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:path=notifiers,scope=Namespaced,categories=cluster-api
+// +kubebuilder:subresource:status
+// +kubebuilder:storageversion
+// +kubebuilder:printcolumn:name="Format",type="string",JSONPath=".spec.webhook.format",description="Format of the notifications"
+// +kubebuilder:printcolumn:name="LastNotification",type="date",JSONPath=".status.lastNotificationTime",description="Time the last notification was posted"
+
+// Notifier is the Schema for the notifiers API
+type Notifier struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   NotifierSpec   `json:"spec,omitempty"`
+	Status NotifierStatus `json:"status,omitempty"`
+}
+
+// GetClusterStatus returns the notified state of the named Cluster, if any.
+func (n *Notifier) GetClusterStatus(name string) *NotifierClusterStatus {
+	for i := range n.Status.Clusters {
+		if n.Status.Clusters[i].Name == name {
+			return &n.Status.Clusters[i]
+		}
+	}
+	return nil
+}
+
+// NotifiesEvent returns true if the given event is notified by the Notifier.
+func (n *Notifier) NotifiesEvent(event NotifierEvent) bool {
+	if len(n.Spec.Events) == 0 {
+		return true
+	}
+	for _, e := range n.Spec.Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// +kubebuilder:object:root=true
+
+// NotifierList contains a list of Notifier
+type NotifierList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []Notifier `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&Notifier{}, &NotifierList{})
+}
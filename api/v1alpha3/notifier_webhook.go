@@ -0,0 +1,100 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha3
+
+import (
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+)
+
+func (m *Notifier) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(m).
+		Complete()
+}
+
+// +kubebuilder:webhook:verbs=create;update,path=/validate-cluster-x-k8s-io-v1alpha3-notifier,mutating=false,failurePolicy=fail,groups=cluster.x-k8s.io,resources=notifiers,versions=v1alpha3,name=validation.notifier.cluster.x-k8s.io
+// +kubebuilder:webhook:verbs=create;update,path=/mutate-cluster-x-k8s-io-v1alpha3-notifier,mutating=true,failurePolicy=fail,groups=cluster.x-k8s.io,resources=notifiers,versions=v1alpha3,name=default.notifier.cluster.x-k8s.io
+
+var _ webhook.Defaulter = &Notifier{}
+var _ webhook.Validator = &Notifier{}
+
+// Default implements webhook.Defaulter so a webhook will be registered for the type
+func (m *Notifier) Default() {
+	// Notifier webhook format defaults to JSON.
+	if m.Spec.Webhook.Format == "" {
+		m.Spec.Webhook.Format = NotifierFormatJSON
+	}
+}
+
+// ValidateCreate implements webhook.Validator so a webhook will be registered for the type
+func (m *Notifier) ValidateCreate() error {
+	return m.validate()
+}
+
+// ValidateUpdate implements webhook.Validator so a webhook will be registered for the type
+func (m *Notifier) ValidateUpdate(old runtime.Object) error {
+	if _, ok := old.(*Notifier); !ok {
+		return apierrors.NewBadRequest(fmt.Sprintf("expected a Notifier but got a %T", old))
+	}
+	return m.validate()
+}
+
+// ValidateDelete implements webhook.Validator so a webhook will be registered for the type
+func (m *Notifier) ValidateDelete() error {
+	return nil
+}
+
+func (m *Notifier) validate() error {
+	var allErrs field.ErrorList
+
+	// Validate selector parses as Selector
+	selector, err := metav1.LabelSelectorAsSelector(&m.Spec.ClusterSelector)
+	if err != nil {
+		allErrs = append(
+			allErrs,
+			field.Invalid(field.NewPath("spec", "clusterSelector"), m.Spec.ClusterSelector, err.Error()),
+		)
+	}
+
+	// Validate that the selector isn't empty as null selectors do not select any objects.
+	if selector != nil && selector.Empty() {
+		allErrs = append(
+			allErrs,
+			field.Invalid(field.NewPath("spec", "clusterSelector"), m.Spec.ClusterSelector, "selector must not be empty"),
+		)
+	}
+
+	if m.Spec.Webhook.SecretName == "" {
+		allErrs = append(
+			allErrs,
+			field.Required(field.NewPath("spec", "webhook", "secretName"), "a Secret holding the webhook URL is required"),
+		)
+	}
+
+	if len(allErrs) == 0 {
+		return nil
+	}
+
+	return apierrors.NewInvalid(GroupVersion.WithKind("Notifier").GroupKind(), m.Name, allErrs)
+}
@@ -0,0 +1,102 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha3
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestNotifierDefault(t *testing.T) {
+	g := NewWithT(t)
+	n := &Notifier{}
+
+	n.Default()
+
+	g.Expect(n.Spec.Webhook.Format).To(Equal(NotifierFormatJSON))
+}
+
+func TestNotifierValidation(t *testing.T) {
+	tests := []struct {
+		name       string
+		selectors  map[string]string
+		secretName string
+		expectErr  bool
+	}{
+		{
+			name:       "should not return error for valid selector and secret",
+			selectors:  map[string]string{"foo": "bar"},
+			secretName: "webhook",
+			expectErr:  false,
+		},
+		{
+			name:       "should return error for invalid selector",
+			selectors:  map[string]string{"-123-foo": "bar"},
+			secretName: "webhook",
+			expectErr:  true,
+		},
+		{
+			name:       "should return error for empty selector",
+			selectors:  map[string]string{},
+			secretName: "webhook",
+			expectErr:  true,
+		},
+		{
+			name:       "should return error for missing secret name",
+			selectors:  map[string]string{"foo": "bar"},
+			secretName: "",
+			expectErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+			n := &Notifier{
+				Spec: NotifierSpec{
+					ClusterSelector: metav1.LabelSelector{
+						MatchLabels: tt.selectors,
+					},
+					Webhook: NotifierWebhook{
+						SecretName: tt.secretName,
+					},
+				},
+			}
+			if tt.expectErr {
+				g.Expect(n.ValidateCreate()).NotTo(Succeed())
+				g.Expect(n.ValidateUpdate(n)).NotTo(Succeed())
+			} else {
+				g.Expect(n.ValidateCreate()).To(Succeed())
+				g.Expect(n.ValidateUpdate(n)).To(Succeed())
+			}
+		})
+	}
+}
+
+func TestNotifierNotifiesEvent(t *testing.T) {
+	g := NewWithT(t)
+
+	n := &Notifier{}
+	g.Expect(n.NotifiesEvent(NotifierEventClusterReady)).To(BeTrue())
+	g.Expect(n.NotifiesEvent(NotifierEventUpgradeStarted)).To(BeTrue())
+
+	n.Spec.Events = []NotifierEvent{NotifierEventClusterReady}
+	g.Expect(n.NotifiesEvent(NotifierEventClusterReady)).To(BeTrue())
+	g.Expect(n.NotifiesEvent(NotifierEventUpgradeStarted)).To(BeFalse())
+}
@@ -38,6 +38,10 @@ func (src *KubeadmConfig) ConvertTo(dstRaw conversion.Hub) error {
 
 	dst.Status.DataSecretName = restored.Status.DataSecretName
 	dst.Spec.Verbosity = restored.Spec.Verbosity
+	dst.Spec.DiskSetup = restored.Spec.DiskSetup
+	dst.Spec.Mounts = restored.Spec.Mounts
+	dst.Spec.Kubelet = restored.Spec.Kubelet
+	dst.Spec.OS = restored.Spec.OS
 
 	return nil
 }
@@ -269,12 +269,16 @@ func autoConvert_v1alpha3_KubeadmConfigSpec_To_v1alpha2_KubeadmConfigSpec(in *v1
 	out.InitConfiguration = (*v1beta1.InitConfiguration)(unsafe.Pointer(in.InitConfiguration))
 	out.JoinConfiguration = (*v1beta1.JoinConfiguration)(unsafe.Pointer(in.JoinConfiguration))
 	out.Files = *(*[]File)(unsafe.Pointer(&in.Files))
+	// WARNING: in.DiskSetup requires manual conversion: does not exist in peer-type
+	// WARNING: in.Mounts requires manual conversion: does not exist in peer-type
 	out.PreKubeadmCommands = *(*[]string)(unsafe.Pointer(&in.PreKubeadmCommands))
 	out.PostKubeadmCommands = *(*[]string)(unsafe.Pointer(&in.PostKubeadmCommands))
 	out.Users = *(*[]User)(unsafe.Pointer(&in.Users))
 	out.NTP = (*NTP)(unsafe.Pointer(in.NTP))
 	out.Format = Format(in.Format)
+	// WARNING: in.OS requires manual conversion: does not exist in peer-type
 	// WARNING: in.Verbosity requires manual conversion: does not exist in peer-type
+	// WARNING: in.Kubelet requires manual conversion: does not exist in peer-type
 	return nil
 }
 
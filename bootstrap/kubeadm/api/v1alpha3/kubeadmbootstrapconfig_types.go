@@ -22,12 +22,29 @@ import (
 )
 
 // Format specifies the output format of the bootstrap data
-// +kubebuilder:validation:Enum=cloud-config
+// +kubebuilder:validation:Enum=cloud-config;ignition
 type Format string
 
 const (
 	// CloudConfig make the bootstrap data to be of cloud-config format
 	CloudConfig Format = "cloud-config"
+
+	// Ignition make the bootstrap data to be of Ignition format, e.g. for Flatcar Container Linux
+	// or Fedora CoreOS machine images
+	Ignition Format = "ignition"
+)
+
+// OperatingSystem specifies the operating system of the machines the bootstrap data is rendered for
+// +kubebuilder:validation:Enum=linux;windows
+type OperatingSystem string
+
+const (
+	// Linux make the bootstrap data to be rendered for Linux machines
+	Linux OperatingSystem = "linux"
+
+	// Windows make the bootstrap data to be rendered for Windows machines using cloudbase-init, with the kubeadm
+	// commands run by a PowerShell script
+	Windows OperatingSystem = "windows"
 )
 
 // KubeadmConfigSpec defines the desired state of KubeadmConfig.
@@ -49,6 +66,14 @@ type KubeadmConfigSpec struct {
 	// +optional
 	Files []File `json:"files,omitempty"`
 
+	// DiskSetup specifies options for the creation of partition tables and file systems on devices.
+	// +optional
+	DiskSetup *DiskSetup `json:"diskSetup,omitempty"`
+
+	// Mounts specifies a list of mount points to be setup.
+	// +optional
+	Mounts []MountPoints `json:"mounts,omitempty"`
+
 	// PreKubeadmCommands specifies extra commands to run before kubeadm runs
 	// +optional
 	PreKubeadmCommands []string `json:"preKubeadmCommands,omitempty"`
@@ -69,10 +94,49 @@ type KubeadmConfigSpec struct {
 	// +optional
 	Format Format `json:"format,omitempty"`
 
+	// OS specifies the operating system of the machine the bootstrap data is rendered for.
+	// Defaults to linux; windows is only supported for worker nodes, with the cloud-config format.
+	// +optional
+	OS OperatingSystem `json:"os,omitempty"`
+
 	// Verbosity is the number for the kubeadm log level verbosity.
 	// It overrides the `--v` flag in kubeadm commands.
 	// +optional
 	Verbosity *int32 `json:"verbosity,omitempty"`
+
+	// Kubelet specifies node-level settings of the kubelet, applied both when initializing and when joining a node.
+	// +optional
+	Kubelet *KubeletConfiguration `json:"kubelet,omitempty"`
+}
+
+// KubeletConfiguration defines node-level settings of the kubelet.
+// The settings are passed to the kubelet as command line flags via the kubeletExtraArgs of the kubeadm node
+// registration, so they override the cluster-wide kubelet configuration managed by kubeadm; flags explicitly
+// set in kubeletExtraArgs take precedence.
+type KubeletConfiguration struct {
+	// MaxPods is the maximum number of Pods that can run on the node.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	MaxPods *int32 `json:"maxPods,omitempty"`
+
+	// SystemReserved is the amount of resources reserved for the system daemons, e.g. cpu: 500m and memory: 1Gi.
+	// +optional
+	SystemReserved map[string]string `json:"systemReserved,omitempty"`
+
+	// KubeReserved is the amount of resources reserved for the Kubernetes system daemons, e.g. cpu: 500m and memory: 1Gi.
+	// +optional
+	KubeReserved map[string]string `json:"kubeReserved,omitempty"`
+
+	// EvictionHard is the set of eviction signals and thresholds triggering Pod evictions,
+	// e.g. memory.available: 100Mi and nodefs.available: 10%.
+	// +optional
+	EvictionHard map[string]string `json:"evictionHard,omitempty"`
+
+	// CgroupDriver is the driver the kubelet uses for manipulating the cgroups on the host;
+	// it must match the one of the container runtime.
+	// +kubebuilder:validation:Enum=cgroupfs;systemd
+	// +optional
+	CgroupDriver string `json:"cgroupDriver,omitempty"`
 }
 
 // KubeadmConfigStatus defines the observed state of KubeadmConfig
@@ -208,6 +272,73 @@ type User struct {
 	SSHAuthorizedKeys []string `json:"sshAuthorizedKeys,omitempty"`
 }
 
+// DiskSetup defines input for generated disk_setup and fs_setup in cloud-init.
+type DiskSetup struct {
+	// Partitions specifies the list of the partitions to setup.
+	// +optional
+	Partitions []Partition `json:"partitions,omitempty"`
+
+	// Filesystems specifies the list of file systems to setup.
+	// +optional
+	Filesystems []Filesystem `json:"filesystems,omitempty"`
+}
+
+// Partition defines how to create and layout a partition.
+type Partition struct {
+	// Device is the name of the device.
+	Device string `json:"device"`
+
+	// Layout specifies the device layout.
+	// If it is true, a single partition will be created for the entire device.
+	// When layout is false, it means don't partition or ignore existing partitioning.
+	Layout bool `json:"layout"`
+
+	// Overwrite describes whether to skip checks and create the partition if a partition or filesystem is found on the device.
+	// Use with caution. Default is 'false'.
+	// +optional
+	Overwrite *bool `json:"overwrite,omitempty"`
+
+	// TableType specifies the type of partition table. The following are supported:
+	// 'mbr': default and setups a MS-DOS partition table
+	// 'gpt': setups a GPT partition table
+	// +kubebuilder:validation:Enum=mbr;gpt
+	// +optional
+	TableType *string `json:"tableType,omitempty"`
+}
+
+// Filesystem defines the file systems to be created.
+type Filesystem struct {
+	// Device specifies the device name
+	Device string `json:"device"`
+
+	// Filesystem specifies the file system type.
+	Filesystem string `json:"filesystem"`
+
+	// Label specifies the file system label to be used. If set to None, no label is used.
+	Label string `json:"label"`
+
+	// Partition specifies the partition to use. The valid options are: "auto|any", "auto", "any", "none", and <NUM>, where NUM is the actual partition number.
+	// +optional
+	Partition *string `json:"partition,omitempty"`
+
+	// Overwrite defines whether or not to overwrite any existing filesystem.
+	// If true, any pre-existing file system will be destroyed. Use with Caution.
+	// +optional
+	Overwrite *bool `json:"overwrite,omitempty"`
+
+	// ReplaceFS is a special directive, used for Microsoft Azure that instructs cloud-init to replace a file system of <FS_TYPE>.
+	// NOTE: unless you define a label, this requires the use of the 'any' partition directive.
+	// +optional
+	ReplaceFS *string `json:"replaceFS,omitempty"`
+
+	// ExtraOpts defined extra options to add to the command for creating the file system.
+	// +optional
+	ExtraOpts []string `json:"extraOpts,omitempty"`
+}
+
+// MountPoints defines input for generated mounts in cloud-init.
+type MountPoints []string
+
 // NTP defines input for generated ntp in cloud-init
 type NTP struct {
 	// Servers specifies which NTP servers to use
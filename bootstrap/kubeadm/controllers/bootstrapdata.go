@@ -0,0 +1,118 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// DataSecretCleanupPolicy defines what happens to the bootstrap data secret of a KubeadmConfig once the node of its
+// Machine is Ready; the bootstrap data is no longer required at this stage, but it contains credentials like the bootstrap token.
+type DataSecretCleanupPolicy string
+
+const (
+	// DataSecretCleanupPolicyRetain keeps the bootstrap data secret as it is.
+	DataSecretCleanupPolicyRetain = DataSecretCleanupPolicy("Retain")
+
+	// DataSecretCleanupPolicyScrub removes the bootstrap data from the secret, keeping the secret referenced by the Machine.
+	DataSecretCleanupPolicyScrub = DataSecretCleanupPolicy("Scrub")
+
+	// DataSecretCleanupPolicyDelete deletes the bootstrap data secret.
+	DataSecretCleanupPolicyDelete = DataSecretCleanupPolicy("Delete")
+)
+
+const (
+	// dataSecretCleanupRequeueAfter is how long to wait before checking again if the node of a Machine is Ready.
+	dataSecretCleanupRequeueAfter = 1 * time.Minute
+)
+
+// cleanupBootstrapData scrubs or deletes the bootstrap data secret of a KubeadmConfig, according to the
+// DataSecretCleanupPolicy, once the node of its Machine has been Ready for longer than the DataSecretRetention.
+func (r *KubeadmConfigReconciler) cleanupBootstrapData(ctx context.Context, scope *Scope) (ctrl.Result, error) {
+	if r.DataSecretCleanupPolicy == "" || r.DataSecretCleanupPolicy == DataSecretCleanupPolicyRetain || scope.Config.Status.DataSecretName == nil {
+		return ctrl.Result{}, nil
+	}
+
+	// The Machine watch triggers a new reconcile when the node reference is set.
+	nodeName := scope.ConfigOwner.NodeRefName()
+	if nodeName == "" {
+		return ctrl.Result{}, nil
+	}
+
+	secret := &corev1.Secret{}
+	secretKey := client.ObjectKey{Namespace: scope.Config.Namespace, Name: *scope.Config.Status.DataSecretName}
+	if err := r.Client.Get(ctx, secretKey, secret); err != nil {
+		if apierrors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, errors.Wrapf(err, "failed to get bootstrap data secret for KubeadmConfig %s/%s", scope.Config.Namespace, scope.Config.Name)
+	}
+	if r.DataSecretCleanupPolicy == DataSecretCleanupPolicyScrub && len(secret.Data[clusterv1.BootstrapDataSecretValueKey]) == 0 {
+		return ctrl.Result{}, nil
+	}
+
+	remoteClient, err := r.remoteClientGetter(ctx, r.Client, scope.Cluster, r.scheme)
+	if err != nil {
+		scope.Error(err, "error creating remote cluster client")
+		return ctrl.Result{}, err
+	}
+
+	node := &corev1.Node{}
+	if err := remoteClient.Get(ctx, client.ObjectKey{Name: nodeName}, node); err != nil {
+		return ctrl.Result{}, errors.Wrapf(err, "failed to get node %s", nodeName)
+	}
+
+	var readySince *time.Time
+	for _, c := range node.Status.Conditions {
+		if c.Type == corev1.NodeReady && c.Status == corev1.ConditionTrue {
+			readySince = &c.LastTransitionTime.Time
+			break
+		}
+	}
+	if readySince == nil {
+		scope.V(4).Info("Node is not Ready yet, keeping the bootstrap data", "node", nodeName)
+		return ctrl.Result{RequeueAfter: dataSecretCleanupRequeueAfter}, nil
+	}
+	if remaining := r.DataSecretRetention - time.Since(*readySince); remaining > 0 {
+		return ctrl.Result{RequeueAfter: remaining}, nil
+	}
+
+	switch r.DataSecretCleanupPolicy {
+	case DataSecretCleanupPolicyScrub:
+		scope.Info("Scrubbing bootstrap data", "secret", secret.Name)
+		secret.Data[clusterv1.BootstrapDataSecretValueKey] = []byte{}
+		if err := r.Client.Update(ctx, secret); err != nil {
+			return ctrl.Result{}, errors.Wrapf(err, "failed to scrub bootstrap data secret for KubeadmConfig %s/%s", scope.Config.Namespace, scope.Config.Name)
+		}
+	case DataSecretCleanupPolicyDelete:
+		scope.Info("Deleting bootstrap data", "secret", secret.Name)
+		if err := r.Client.Delete(ctx, secret); err != nil && !apierrors.IsNotFound(err) {
+			return ctrl.Result{}, errors.Wrapf(err, "failed to delete bootstrap data secret for KubeadmConfig %s/%s", scope.Config.Namespace, scope.Config.Name)
+		}
+	default:
+		return ctrl.Result{}, errors.Errorf("unknown bootstrap data cleanup policy %q", r.DataSecretCleanupPolicy)
+	}
+	return ctrl.Result{}, nil
+}
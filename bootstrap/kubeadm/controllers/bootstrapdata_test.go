@@ -0,0 +1,173 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/pointer"
+	fakeremote "sigs.k8s.io/cluster-api/controllers/remote/fake"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func TestKubeadmConfigReconciler_Reconcile_CleanupBootstrapData(t *testing.T) {
+	tests := []struct {
+		name             string
+		policy           DataSecretCleanupPolicy
+		retention        time.Duration
+		nodeReady        bool
+		wantSecret       bool
+		wantData         bool
+		wantRequeueAfter bool
+	}{
+		{
+			name:       "Retains the bootstrap data by default",
+			nodeReady:  true,
+			wantSecret: true,
+			wantData:   true,
+		},
+		{
+			name:       "Retains the bootstrap data if the policy is Retain",
+			policy:     DataSecretCleanupPolicyRetain,
+			nodeReady:  true,
+			wantSecret: true,
+			wantData:   true,
+		},
+		{
+			name:       "Scrubs the bootstrap data if the policy is Scrub",
+			policy:     DataSecretCleanupPolicyScrub,
+			nodeReady:  true,
+			wantSecret: true,
+			wantData:   false,
+		},
+		{
+			name:       "Deletes the bootstrap data if the policy is Delete",
+			policy:     DataSecretCleanupPolicyDelete,
+			nodeReady:  true,
+			wantSecret: false,
+		},
+		{
+			name:             "Retains the bootstrap data until the node is Ready",
+			policy:           DataSecretCleanupPolicyDelete,
+			nodeReady:        false,
+			wantSecret:       true,
+			wantData:         true,
+			wantRequeueAfter: true,
+		},
+		{
+			name:             "Retains the bootstrap data until the retention expires",
+			policy:           DataSecretCleanupPolicyDelete,
+			retention:        time.Hour,
+			nodeReady:        true,
+			wantSecret:       true,
+			wantData:         true,
+			wantRequeueAfter: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cluster := newCluster("cluster")
+			cluster.Status.InfrastructureReady = true
+			cluster.Status.ControlPlaneInitialized = true
+
+			workerMachine := newWorkerMachine(cluster)
+			workerMachine.Spec.Bootstrap.DataSecretName = pointer.StringPtr("worker-join-cfg")
+			workerMachine.Status.InfrastructureReady = true
+			workerMachine.Status.NodeRef = &corev1.ObjectReference{Kind: "Node", Name: "worker-node"}
+
+			workerJoinConfig := newWorkerJoinKubeadmConfig(workerMachine)
+			workerJoinConfig.Status.Ready = true
+			workerJoinConfig.Status.DataSecretName = pointer.StringPtr("worker-join-cfg")
+
+			dataSecret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "default",
+					Name:      "worker-join-cfg",
+				},
+				Data: map[string][]byte{
+					"value": []byte("bootstrap data"),
+				},
+			}
+
+			nodeStatus := corev1.ConditionFalse
+			if tt.nodeReady {
+				nodeStatus = corev1.ConditionTrue
+			}
+			node := &corev1.Node{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "worker-node",
+				},
+				Status: corev1.NodeStatus{
+					Conditions: []corev1.NodeCondition{
+						{
+							Type:               corev1.NodeReady,
+							Status:             nodeStatus,
+							LastTransitionTime: metav1.NewTime(time.Now().Add(-time.Minute)),
+						},
+					},
+				},
+			}
+
+			myclient := fake.NewFakeClientWithScheme(setupScheme(), cluster, workerMachine, workerJoinConfig, dataSecret, node)
+			k := &KubeadmConfigReconciler{
+				Log:                     log.Log,
+				Client:                  myclient,
+				KubeadmInitLock:         &myInitLocker{},
+				DataSecretCleanupPolicy: tt.policy,
+				DataSecretRetention:     tt.retention,
+				remoteClientGetter:      fakeremote.NewClusterClient,
+			}
+			request := ctrl.Request{
+				NamespacedName: types.NamespacedName{
+					Namespace: "default",
+					Name:      "worker-join-cfg",
+				},
+			}
+			result, err := k.Reconcile(request)
+			if err != nil {
+				t.Fatalf("Failed to reconcile:\n %+v", err)
+			}
+			if (result.RequeueAfter > 0) != tt.wantRequeueAfter {
+				t.Fatalf("got RequeueAfter %s, want requeue after %t", result.RequeueAfter, tt.wantRequeueAfter)
+			}
+
+			secret := &corev1.Secret{}
+			err = myclient.Get(context.Background(), client.ObjectKey{Namespace: "default", Name: "worker-join-cfg"}, secret)
+			if !tt.wantSecret {
+				if !apierrors.IsNotFound(err) {
+					t.Fatalf("expected the bootstrap data secret to be deleted, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("failed to get the bootstrap data secret: %v", err)
+			}
+			if (len(secret.Data["value"]) > 0) != tt.wantData {
+				t.Fatalf("got bootstrap data %q, want bootstrap data %t", secret.Data["value"], tt.wantData)
+			}
+		})
+	}
+}
@@ -35,7 +35,9 @@ import (
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
 	bootstrapv1 "sigs.k8s.io/cluster-api/bootstrap/kubeadm/api/v1alpha3"
 	"sigs.k8s.io/cluster-api/bootstrap/kubeadm/internal/cloudinit"
+	"sigs.k8s.io/cluster-api/bootstrap/kubeadm/internal/ignition"
 	"sigs.k8s.io/cluster-api/bootstrap/kubeadm/internal/locking"
+	"sigs.k8s.io/cluster-api/bootstrap/kubeadm/internal/windows"
 	kubeadmv1beta1 "sigs.k8s.io/cluster-api/bootstrap/kubeadm/types/v1beta1"
 	bsutil "sigs.k8s.io/cluster-api/bootstrap/util"
 	"sigs.k8s.io/cluster-api/controllers/remote"
@@ -50,6 +52,10 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/source"
 )
 
+// errWindowsControlPlane is returned for the control plane machines with a KubeadmConfig for Windows,
+// given that only worker nodes can run Windows.
+var errWindowsControlPlane = errors.New("control plane machines cannot run Windows, only worker nodes are supported on Windows")
+
 // InitLocker is a lock that is used around kubeadm init
 type InitLocker interface {
 	Lock(ctx context.Context, cluster *clusterv1.Cluster, machine *clusterv1.Machine) bool
@@ -67,6 +73,18 @@ type KubeadmConfigReconciler struct {
 	KubeadmInitLock InitLocker
 	scheme          *runtime.Scheme
 
+	// TokenRotationPolicy defines what happens when the bootstrap token of a Machine that did not join the cluster yet
+	// expires; defaults to TokenRotationPolicyOnExpiry.
+	TokenRotationPolicy TokenRotationPolicy
+
+	// DataSecretCleanupPolicy defines what happens to the bootstrap data secret once the node of the Machine is Ready;
+	// defaults to DataSecretCleanupPolicyRetain.
+	DataSecretCleanupPolicy DataSecretCleanupPolicy
+
+	// DataSecretRetention is how long the bootstrap data secret is kept after the node of the Machine is Ready,
+	// before applying the DataSecretCleanupPolicy.
+	DataSecretRetention time.Duration
+
 	remoteClientGetter remote.ClusterClientGetter
 }
 
@@ -193,28 +211,11 @@ func (r *KubeadmConfigReconciler) Reconcile(req ctrl.Request) (_ ctrl.Result, re
 		// If the BootstrapToken has been generated for a join and the infrastructure is not ready.
 		// This indicates the token in the join config has not been consumed and it may need a refresh.
 		if (config.Spec.JoinConfiguration != nil && config.Spec.JoinConfiguration.Discovery.BootstrapToken != nil) && !configOwner.IsInfrastructureReady() {
-
-			token := config.Spec.JoinConfiguration.Discovery.BootstrapToken.Token
-
-			remoteClient, err := r.remoteClientGetter(ctx, r.Client, cluster, r.scheme)
-			if err != nil {
-				log.Error(err, "error creating remote cluster client")
-				return ctrl.Result{}, err
-			}
-
-			log.Info("refreshing token until the infrastructure has a chance to consume it")
-			err = refreshToken(remoteClient, token)
-			if err != nil {
-				// It would be nice to re-create the bootstrap token if the error was "not found", but we have no way to update the Machine's bootstrap data
-				return ctrl.Result{}, errors.Wrapf(err, "failed to refresh bootstrap token")
-			}
-			// NB: this may not be sufficient to keep the token live if we don't see it before it expires, but when we generate a config we will set the status to "ready" which should generate an update event
-			return ctrl.Result{
-				RequeueAfter: DefaultTokenTTL / 2,
-			}, nil
+			return r.refreshBootstrapToken(ctx, scope, patchHelper)
 		}
-		// In any other case just return as the config is already generated and need not be generated again.
-		return ctrl.Result{}, nil
+		// In any other case the config is already generated and need not be generated again; once the node
+		// is Ready, the bootstrap data can be cleaned up.
+		return r.cleanupBootstrapData(ctx, scope)
 	}
 
 	// Attempt to Patch the KubeadmConfig object and status after each reconciliation if no error occurs.
@@ -251,6 +252,57 @@ func (r *KubeadmConfigReconciler) Reconcile(req ctrl.Request) (_ ctrl.Result, re
 	return r.joinWorker(ctx, scope)
 }
 
+// refreshBootstrapToken extends the TTL of the bootstrap token of a Machine that did not join the cluster yet; if the token
+// already expired and was removed from the workload cluster, it is rotated according to the TokenRotationPolicy.
+func (r *KubeadmConfigReconciler) refreshBootstrapToken(ctx context.Context, scope *Scope, patchHelper *patch.Helper) (ctrl.Result, error) {
+	token := scope.Config.Spec.JoinConfiguration.Discovery.BootstrapToken.Token
+
+	remoteClient, err := r.remoteClientGetter(ctx, r.Client, scope.Cluster, r.scheme)
+	if err != nil {
+		scope.Error(err, "error creating remote cluster client")
+		return ctrl.Result{}, err
+	}
+
+	scope.Info("refreshing token until the infrastructure has a chance to consume it")
+	if err := refreshToken(remoteClient, token); err != nil {
+		if !apierrors.IsNotFound(err) || r.TokenRotationPolicy == TokenRotationPolicyNever {
+			return ctrl.Result{}, errors.Wrapf(err, "failed to refresh bootstrap token")
+		}
+		return r.rotateBootstrapToken(ctx, scope, patchHelper)
+	}
+	// NB: this may not be sufficient to keep the token live if we don't see it before it expires, but when we generate a config we will set the status to "ready" which should generate an update event
+	return ctrl.Result{
+		RequeueAfter: DefaultTokenTTL / 2,
+	}, nil
+}
+
+// rotateBootstrapToken creates a new bootstrap token for a Machine whose token expired before the infrastructure
+// had a chance to consume it, and regenerates the bootstrap data of the Machine using the new token.
+func (r *KubeadmConfigReconciler) rotateBootstrapToken(ctx context.Context, scope *Scope, patchHelper *patch.Helper) (ctrl.Result, error) {
+	scope.Info("bootstrap token expired before the infrastructure consumed it, creating a new one")
+
+	// Clearing the token forces reconcileDiscovery to create a new one.
+	scope.Config.Spec.JoinConfiguration.Discovery.BootstrapToken.Token = ""
+
+	var res ctrl.Result
+	var err error
+	if scope.ConfigOwner.IsControlPlaneMachine() {
+		res, err = r.joinControlplane(ctx, scope)
+	} else {
+		res, err = r.joinWorker(ctx, scope)
+	}
+	if err != nil || res != (ctrl.Result{}) {
+		return res, err
+	}
+
+	if err := patchHelper.Patch(ctx, scope.Config); err != nil {
+		return ctrl.Result{}, errors.Wrapf(err, "failed to patch config after rotating the bootstrap token")
+	}
+	return ctrl.Result{
+		RequeueAfter: DefaultTokenTTL / 2,
+	}, nil
+}
+
 func (r *KubeadmConfigReconciler) handleClusterNotInitialized(ctx context.Context, scope *Scope) (_ ctrl.Result, reterr error) {
 	// if it's NOT a control plane machine, requeue
 	if !scope.ConfigOwner.IsControlPlaneMachine() {
@@ -258,6 +310,10 @@ func (r *KubeadmConfigReconciler) handleClusterNotInitialized(ctx context.Contex
 		return ctrl.Result{RequeueAfter: 30 * time.Second}, nil
 	}
 
+	if scope.Config.Spec.OS == bootstrapv1.Windows {
+		return ctrl.Result{}, errWindowsControlPlane
+	}
+
 	// if the machine has not ClusterConfiguration and InitConfiguration, requeue
 	if scope.Config.Spec.InitConfiguration == nil && scope.Config.Spec.ClusterConfiguration == nil {
 		scope.Info("Control plane is not ready, requeing joining control planes until ready.")
@@ -301,7 +357,15 @@ func (r *KubeadmConfigReconciler) handleClusterNotInitialized(ctx context.Contex
 			},
 		}
 	}
-	initdata, err := kubeadmv1beta1.ConfigurationToYAML(scope.Config.Spec.InitConfiguration)
+	// the kubelet configuration is rendered into a copy, so it is not persisted into the kubeletExtraArgs
+	initConfiguration := scope.Config.Spec.InitConfiguration.DeepCopy()
+	kubeletArgs, err := kubeletExtraArgs(initConfiguration.NodeRegistration, scope.Config.Spec.Kubelet)
+	if err != nil {
+		scope.Error(err, "failed to render kubelet configuration")
+		return ctrl.Result{}, err
+	}
+	initConfiguration.NodeRegistration.KubeletExtraArgs = kubeletArgs
+	initdata, err := kubeadmv1beta1.ConfigurationToYAML(initConfiguration)
 	if err != nil {
 		scope.Error(err, "failed to marshal init configuration")
 		return ctrl.Result{}, err
@@ -342,10 +406,12 @@ func (r *KubeadmConfigReconciler) handleClusterNotInitialized(ctx context.Contex
 		verbosityFlag = fmt.Sprintf("--v %s", strconv.Itoa(int(*scope.Config.Spec.Verbosity)))
 	}
 
-	cloudInitData, err := cloudinit.NewInitControlPlane(&cloudinit.ControlPlaneInput{
+	controlPlaneInput := &cloudinit.ControlPlaneInput{
 		BaseUserData: cloudinit.BaseUserData{
 			AdditionalFiles:     scope.Config.Spec.Files,
 			NTP:                 scope.Config.Spec.NTP,
+			DiskSetup:           scope.Config.Spec.DiskSetup,
+			Mounts:              scope.Config.Spec.Mounts,
 			PreKubeadmCommands:  scope.Config.Spec.PreKubeadmCommands,
 			PostKubeadmCommands: scope.Config.Spec.PostKubeadmCommands,
 			Users:               scope.Config.Spec.Users,
@@ -354,13 +420,21 @@ func (r *KubeadmConfigReconciler) handleClusterNotInitialized(ctx context.Contex
 		InitConfiguration:    initdata,
 		ClusterConfiguration: clusterdata,
 		Certificates:         certificates,
-	})
+	}
+
+	var bootstrapData []byte
+	switch scope.Config.Spec.Format {
+	case bootstrapv1.Ignition:
+		bootstrapData, err = ignition.NewInitControlPlane(controlPlaneInput)
+	default:
+		bootstrapData, err = cloudinit.NewInitControlPlane(controlPlaneInput)
+	}
 	if err != nil {
-		scope.Error(err, "failed to generate cloud init for bootstrap control plane")
+		scope.Error(err, "failed to generate bootstrap data for bootstrap control plane")
 		return ctrl.Result{}, err
 	}
 
-	if err := r.storeBootstrapData(ctx, scope, cloudInitData); err != nil {
+	if err := r.storeBootstrapData(ctx, scope, bootstrapData); err != nil {
 		scope.Error(err, "failed to store bootstrap data")
 		return ctrl.Result{}, err
 	}
@@ -393,7 +467,18 @@ func (r *KubeadmConfigReconciler) joinWorker(ctx context.Context, scope *Scope)
 		return ctrl.Result{}, err
 	}
 
-	joinData, err := kubeadmv1beta1.ConfigurationToYAML(scope.Config.Spec.JoinConfiguration)
+	// the kubelet configuration is rendered into a copy, so it is not persisted into the kubeletExtraArgs
+	joinConfiguration := scope.Config.Spec.JoinConfiguration.DeepCopy()
+	joinConfiguration.NodeRegistration.KubeletExtraArgs, err = kubeletExtraArgs(joinConfiguration.NodeRegistration, scope.Config.Spec.Kubelet)
+	if err != nil {
+		scope.Error(err, "failed to render kubelet configuration")
+		return ctrl.Result{}, err
+	}
+	// Windows nodes use containerd, which has a different CRI socket than the default one assumed by kubeadm.
+	if scope.Config.Spec.OS == bootstrapv1.Windows && joinConfiguration.NodeRegistration.CRISocket == "" {
+		joinConfiguration.NodeRegistration.CRISocket = windows.ContainerdCRISocket
+	}
+	joinData, err := kubeadmv1beta1.ConfigurationToYAML(joinConfiguration)
 	if err != nil {
 		scope.Error(err, "failed to marshal join configuration")
 		return ctrl.Result{}, err
@@ -410,23 +495,38 @@ func (r *KubeadmConfigReconciler) joinWorker(ctx context.Context, scope *Scope)
 		verbosityFlag = fmt.Sprintf("--v %s", strconv.Itoa(int(*scope.Config.Spec.Verbosity)))
 	}
 
-	cloudJoinData, err := cloudinit.NewNode(&cloudinit.NodeInput{
+	nodeInput := &cloudinit.NodeInput{
 		BaseUserData: cloudinit.BaseUserData{
 			AdditionalFiles:     scope.Config.Spec.Files,
 			NTP:                 scope.Config.Spec.NTP,
+			DiskSetup:           scope.Config.Spec.DiskSetup,
+			Mounts:              scope.Config.Spec.Mounts,
 			PreKubeadmCommands:  scope.Config.Spec.PreKubeadmCommands,
 			PostKubeadmCommands: scope.Config.Spec.PostKubeadmCommands,
 			Users:               scope.Config.Spec.Users,
 			KubeadmVerbosity:    verbosityFlag,
 		},
 		JoinConfiguration: joinData,
-	})
+	}
+
+	var bootstrapData []byte
+	switch {
+	case scope.Config.Spec.OS == bootstrapv1.Windows:
+		if scope.Config.Spec.Format == bootstrapv1.Ignition {
+			return ctrl.Result{}, errors.New("the Ignition format is not supported on Windows")
+		}
+		bootstrapData, err = windows.NewNode(nodeInput)
+	case scope.Config.Spec.Format == bootstrapv1.Ignition:
+		bootstrapData, err = ignition.NewNode(nodeInput)
+	default:
+		bootstrapData, err = cloudinit.NewNode(nodeInput)
+	}
 	if err != nil {
 		scope.Error(err, "failed to create a worker join configuration")
 		return ctrl.Result{}, err
 	}
 
-	if err := r.storeBootstrapData(ctx, scope, cloudJoinData); err != nil {
+	if err := r.storeBootstrapData(ctx, scope, bootstrapData); err != nil {
 		scope.Error(err, "failed to store bootstrap data")
 		return ctrl.Result{}, err
 	}
@@ -438,6 +538,10 @@ func (r *KubeadmConfigReconciler) joinControlplane(ctx context.Context, scope *S
 		return ctrl.Result{}, fmt.Errorf("%s is not a valid control plane kind, only Machine is supported", scope.ConfigOwner.GetKind())
 	}
 
+	if scope.Config.Spec.OS == bootstrapv1.Windows {
+		return ctrl.Result{}, errWindowsControlPlane
+	}
+
 	if scope.Config.Spec.JoinConfiguration.ControlPlane == nil {
 		scope.Config.Spec.JoinConfiguration.ControlPlane = &kubeadmv1beta1.JoinControlPlane{}
 	}
@@ -465,7 +569,14 @@ func (r *KubeadmConfigReconciler) joinControlplane(ctx context.Context, scope *S
 		return ctrl.Result{}, err
 	}
 
-	joinData, err := kubeadmv1beta1.ConfigurationToYAML(scope.Config.Spec.JoinConfiguration)
+	// the kubelet configuration is rendered into a copy, so it is not persisted into the kubeletExtraArgs
+	joinConfiguration := scope.Config.Spec.JoinConfiguration.DeepCopy()
+	joinConfiguration.NodeRegistration.KubeletExtraArgs, err = kubeletExtraArgs(joinConfiguration.NodeRegistration, scope.Config.Spec.Kubelet)
+	if err != nil {
+		scope.Error(err, "failed to render kubelet configuration")
+		return ctrl.Result{}, err
+	}
+	joinData, err := kubeadmv1beta1.ConfigurationToYAML(joinConfiguration)
 	if err != nil {
 		scope.Error(err, "failed to marshal join configuration")
 		return ctrl.Result{}, err
@@ -478,24 +589,34 @@ func (r *KubeadmConfigReconciler) joinControlplane(ctx context.Context, scope *S
 		verbosityFlag = fmt.Sprintf("--v %s", strconv.Itoa(int(*scope.Config.Spec.Verbosity)))
 	}
 
-	cloudJoinData, err := cloudinit.NewJoinControlPlane(&cloudinit.ControlPlaneJoinInput{
+	controlPlaneJoinInput := &cloudinit.ControlPlaneJoinInput{
 		JoinConfiguration: joinData,
 		Certificates:      certificates,
 		BaseUserData: cloudinit.BaseUserData{
 			AdditionalFiles:     scope.Config.Spec.Files,
 			NTP:                 scope.Config.Spec.NTP,
+			DiskSetup:           scope.Config.Spec.DiskSetup,
+			Mounts:              scope.Config.Spec.Mounts,
 			PreKubeadmCommands:  scope.Config.Spec.PreKubeadmCommands,
 			PostKubeadmCommands: scope.Config.Spec.PostKubeadmCommands,
 			Users:               scope.Config.Spec.Users,
 			KubeadmVerbosity:    verbosityFlag,
 		},
-	})
+	}
+
+	var bootstrapData []byte
+	switch scope.Config.Spec.Format {
+	case bootstrapv1.Ignition:
+		bootstrapData, err = ignition.NewJoinControlPlane(controlPlaneJoinInput)
+	default:
+		bootstrapData, err = cloudinit.NewJoinControlPlane(controlPlaneJoinInput)
+	}
 	if err != nil {
 		scope.Error(err, "failed to create a control plane join configuration")
 		return ctrl.Result{}, err
 	}
 
-	if err := r.storeBootstrapData(ctx, scope, cloudJoinData); err != nil {
+	if err := r.storeBootstrapData(ctx, scope, bootstrapData); err != nil {
 		scope.Error(err, "failed to store bootstrap data")
 		return ctrl.Result{}, err
 	}
@@ -667,7 +788,7 @@ func (r *KubeadmConfigReconciler) reconcileTopLevelObjectSettings(cluster *clust
 // storeBootstrapData creates a new secret with the data passed in as input,
 // sets the reference in the configuration status and ready to true.
 func (r *KubeadmConfigReconciler) storeBootstrapData(ctx context.Context, scope *Scope, data []byte) error {
-	secret := &corev1.Secret{
+	dataSecret := &corev1.Secret{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      scope.Config.Name,
 			Namespace: scope.Config.Namespace,
@@ -684,16 +805,33 @@ func (r *KubeadmConfigReconciler) storeBootstrapData(ctx context.Context, scope
 				},
 			},
 		},
-		Data: map[string][]byte{
-			"value": data,
-		},
 	}
+	secret.SetBootstrapData(dataSecret, data, bootstrapDataFormat(scope.Config.Spec.Format))
 
-	if err := r.Client.Create(ctx, secret); err != nil {
-		return errors.Wrapf(err, "failed to create kubeconfig secret for KubeadmConfig %s/%s", scope.Config.Namespace, scope.Config.Name)
+	if err := r.Client.Create(ctx, dataSecret); err != nil {
+		if !apierrors.IsAlreadyExists(err) {
+			return errors.Wrapf(err, "failed to create kubeconfig secret for KubeadmConfig %s/%s", scope.Config.Namespace, scope.Config.Name)
+		}
+		// The bootstrap data is regenerated when the bootstrap token is rotated, so the existing secret must be updated.
+		existing := &corev1.Secret{}
+		if err := r.Client.Get(ctx, client.ObjectKey{Namespace: dataSecret.Namespace, Name: dataSecret.Name}, existing); err != nil {
+			return errors.Wrapf(err, "failed to get kubeconfig secret for KubeadmConfig %s/%s", scope.Config.Namespace, scope.Config.Name)
+		}
+		secret.SetBootstrapData(existing, data, bootstrapDataFormat(scope.Config.Spec.Format))
+		if err := r.Client.Update(ctx, existing); err != nil {
+			return errors.Wrapf(err, "failed to update kubeconfig secret for KubeadmConfig %s/%s", scope.Config.Namespace, scope.Config.Name)
+		}
 	}
 
-	scope.Config.Status.DataSecretName = pointer.StringPtr(secret.Name)
+	scope.Config.Status.DataSecretName = pointer.StringPtr(dataSecret.Name)
 	scope.Config.Status.Ready = true
 	return nil
 }
+
+// bootstrapDataFormat returns the format recorded in the bootstrap data secret for the given KubeadmConfig format.
+func bootstrapDataFormat(format bootstrapv1.Format) clusterv1.BootstrapDataFormat {
+	if format == bootstrapv1.Ignition {
+		return clusterv1.BootstrapDataFormatIgnition
+	}
+	return clusterv1.BootstrapDataFormatCloudConfig
+}
@@ -34,6 +34,7 @@ import (
 	"k8s.io/utils/pointer"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
 	bootstrapv1 "sigs.k8s.io/cluster-api/bootstrap/kubeadm/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/bootstrap/kubeadm/internal/windows"
 	kubeadmv1beta1 "sigs.k8s.io/cluster-api/bootstrap/kubeadm/types/v1beta1"
 	fakeremote "sigs.k8s.io/cluster-api/controllers/remote/fake"
 	"sigs.k8s.io/cluster-api/util/secret"
@@ -250,6 +251,14 @@ func TestKubeadmConfigReconciler_Reconcile_MigrateToSecret(t *testing.T) {
 		t.Fatal("expected bootstrap data secret value to match")
 	}
 
+	if format := string(secret.Data[clusterv1.BootstrapDataSecretFormatKey]); format != string(clusterv1.BootstrapDataFormatCloudConfig) {
+		t.Fatalf("expected bootstrap data secret format to be %s, got %s", clusterv1.BootstrapDataFormatCloudConfig, format)
+	}
+
+	if version := secret.Annotations[clusterv1.BootstrapDataContractVersionAnnotation]; version != clusterv1.BootstrapDataContractVersion {
+		t.Fatalf("expected bootstrap data secret contract version to be %s, got %s", clusterv1.BootstrapDataContractVersion, version)
+	}
+
 	if clusterName := secret.Labels[clusterv1.ClusterLabelName]; clusterName != "cluster" {
 		t.Fatalf("expected bootstrap data secret to have a cluster name label set to `cluster`, got %s", clusterName)
 	}
@@ -509,6 +518,57 @@ func TestKubeadmConfigReconciler_Reconcile_GenerateCloudConfigData(t *testing.T)
 	}
 }
 
+func TestKubeadmConfigReconciler_Reconcile_GenerateIgnitionData(t *testing.T) {
+	cluster := newCluster("cluster")
+	cluster.Status.InfrastructureReady = true
+
+	controlPlaneInitMachine := newControlPlaneMachine(cluster, "control-plane-init-machine")
+	controlPlaneInitConfig := newControlPlaneInitKubeadmConfig(controlPlaneInitMachine, "control-plane-init-cfg")
+	controlPlaneInitConfig.Spec.Format = bootstrapv1.Ignition
+
+	objects := []runtime.Object{
+		cluster,
+		controlPlaneInitMachine,
+		controlPlaneInitConfig,
+	}
+	objects = append(objects, createSecrets(t, cluster, controlPlaneInitConfig)...)
+
+	myclient := fake.NewFakeClientWithScheme(setupScheme(), objects...)
+
+	k := &KubeadmConfigReconciler{
+		Log:             log.Log,
+		Client:          myclient,
+		KubeadmInitLock: &myInitLocker{},
+	}
+
+	request := ctrl.Request{
+		NamespacedName: types.NamespacedName{
+			Namespace: "default",
+			Name:      "control-plane-init-cfg",
+		},
+	}
+	if _, err := k.Reconcile(request); err != nil {
+		t.Fatalf("Failed to reconcile:\n %+v", err)
+	}
+
+	cfg, err := getKubeadmConfig(myclient, "control-plane-init-cfg")
+	if err != nil {
+		t.Fatalf("Failed to reconcile:\n %+v", err)
+	}
+	if cfg.Status.DataSecretName == nil {
+		t.Fatal("Expected generated bootstrap data secret name")
+	}
+
+	secret := &corev1.Secret{}
+	if err := myclient.Get(context.Background(), types.NamespacedName{Namespace: cfg.Namespace, Name: *cfg.Status.DataSecretName}, secret); err != nil {
+		t.Fatalf("failed to get Secret bootstrap data for KubeadmConfig: %v", err)
+	}
+
+	if format := string(secret.Data[clusterv1.BootstrapDataSecretFormatKey]); format != string(clusterv1.BootstrapDataFormatIgnition) {
+		t.Fatalf("expected bootstrap data secret format to be %s, got %s", clusterv1.BootstrapDataFormatIgnition, format)
+	}
+}
+
 // If a control plane has no JoinConfiguration, then we will create a default and no error will occur
 func TestKubeadmConfigReconciler_Reconcile_ErrorIfJoiningControlPlaneHasInvalidConfiguration(t *testing.T) {
 	// TODO: extract this kind of code into a setup function that puts the state of objects into an initialized controlplane (implies secrets exist)
@@ -696,6 +756,95 @@ func TestReconcileIfJoinNodesAndControlPlaneIsReady(t *testing.T) {
 	}
 }
 
+func TestReconcileIfJoinWindowsWorkerNode(t *testing.T) {
+	cluster := newCluster("cluster")
+	cluster.Status.InfrastructureReady = true
+	cluster.Status.ControlPlaneInitialized = true
+	cluster.Spec.ControlPlaneEndpoint = clusterv1.APIEndpoint{Host: "100.105.150.1", Port: 6443}
+
+	machine := newWorkerMachine(cluster)
+	config := newWorkerJoinKubeadmConfig(machine)
+	config.Spec.OS = bootstrapv1.Windows
+
+	objects := []runtime.Object{
+		cluster,
+		machine,
+		config,
+	}
+	objects = append(objects, createSecrets(t, cluster, config)...)
+	myclient := fake.NewFakeClientWithScheme(setupScheme(), objects...)
+	k := &KubeadmConfigReconciler{
+		Log:                log.Log,
+		Client:             myclient,
+		KubeadmInitLock:    &myInitLocker{},
+		remoteClientGetter: fakeremote.NewClusterClient,
+	}
+
+	request := ctrl.Request{
+		NamespacedName: types.NamespacedName{
+			Namespace: config.GetNamespace(),
+			Name:      config.GetName(),
+		},
+	}
+	if _, err := k.Reconcile(request); err != nil {
+		t.Fatalf("Failed to reconcile:\n %+v", err)
+	}
+
+	cfg, err := getKubeadmConfig(myclient, config.GetName())
+	if err != nil {
+		t.Fatalf("Failed to reconcile:\n %+v", err)
+	}
+	if cfg.Status.DataSecretName == nil {
+		t.Fatal("Expected bootstrap data secret")
+	}
+
+	dataSecret := &corev1.Secret{}
+	if err := myclient.Get(context.Background(), client.ObjectKey{Namespace: "default", Name: *cfg.Status.DataSecretName}, dataSecret); err != nil {
+		t.Fatal(errors.Wrap(err, "failed to get bootstrap data secret"))
+	}
+	if !bytes.Contains(dataSecret.Data["value"], []byte(windows.ContainerdCRISocket)) {
+		t.Fatal("Expected the bootstrap data to use the containerd CRI socket of Windows")
+	}
+	if !bytes.Contains(dataSecret.Data["value"], []byte("powershell.exe")) {
+		t.Fatal("Expected the bootstrap data to run the kubeadm bootstrap script with PowerShell")
+	}
+}
+
+func TestReconcileIfJoinWindowsControlPlaneNode(t *testing.T) {
+	cluster := newCluster("cluster")
+	cluster.Status.InfrastructureReady = true
+	cluster.Status.ControlPlaneInitialized = true
+	cluster.Spec.ControlPlaneEndpoint = clusterv1.APIEndpoint{Host: "100.105.150.1", Port: 6443}
+
+	machine := newControlPlaneMachine(cluster, "control-plane-join-machine")
+	config := newControlPlaneJoinKubeadmConfig(machine, "control-plane-join-cfg")
+	config.Spec.OS = bootstrapv1.Windows
+
+	objects := []runtime.Object{
+		cluster,
+		machine,
+		config,
+	}
+	objects = append(objects, createSecrets(t, cluster, config)...)
+	myclient := fake.NewFakeClientWithScheme(setupScheme(), objects...)
+	k := &KubeadmConfigReconciler{
+		Log:                log.Log,
+		Client:             myclient,
+		KubeadmInitLock:    &myInitLocker{},
+		remoteClientGetter: fakeremote.NewClusterClient,
+	}
+
+	request := ctrl.Request{
+		NamespacedName: types.NamespacedName{
+			Namespace: config.GetNamespace(),
+			Name:      config.GetName(),
+		},
+	}
+	if _, err := k.Reconcile(request); errors.Cause(err) != errWindowsControlPlane {
+		t.Fatalf("Expected the Windows control plane to be rejected, got %v", err)
+	}
+}
+
 func TestBootstrapTokenTTLExtension(t *testing.T) {
 	cluster := newCluster("cluster")
 	cluster.Status.InfrastructureReady = true
@@ -893,6 +1042,121 @@ func TestBootstrapTokenTTLExtension(t *testing.T) {
 	}
 }
 
+func TestBootstrapTokenRotation(t *testing.T) {
+	tests := []struct {
+		name         string
+		policy       TokenRotationPolicy
+		expectRotate bool
+	}{
+		{
+			name:         "Rotates the bootstrap token by default",
+			expectRotate: true,
+		},
+		{
+			name:         "Rotates the bootstrap token if the policy is OnExpiry",
+			policy:       TokenRotationPolicyOnExpiry,
+			expectRotate: true,
+		},
+		{
+			name:         "Does not rotate the bootstrap token if the policy is Never",
+			policy:       TokenRotationPolicyNever,
+			expectRotate: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cluster := newCluster("cluster")
+			cluster.Status.InfrastructureReady = true
+			cluster.Status.ControlPlaneInitialized = true
+			cluster.Spec.ControlPlaneEndpoint = clusterv1.APIEndpoint{Host: "100.105.150.1", Port: 6443}
+
+			controlPlaneInitMachine := newControlPlaneMachine(cluster, "control-plane-init-machine")
+			initConfig := newControlPlaneInitKubeadmConfig(controlPlaneInitMachine, "control-plane-init-config")
+			workerMachine := newWorkerMachine(cluster)
+			workerJoinConfig := newWorkerJoinKubeadmConfig(workerMachine)
+			objects := []runtime.Object{
+				cluster,
+				workerMachine,
+				workerJoinConfig,
+			}
+
+			objects = append(objects, createSecrets(t, cluster, initConfig)...)
+			myclient := fake.NewFakeClientWithScheme(setupScheme(), objects...)
+			k := &KubeadmConfigReconciler{
+				Log:                 log.Log,
+				Client:              myclient,
+				KubeadmInitLock:     &myInitLocker{},
+				TokenRotationPolicy: tt.policy,
+				remoteClientGetter:  fakeremote.NewClusterClient,
+			}
+			request := ctrl.Request{
+				NamespacedName: types.NamespacedName{
+					Namespace: "default",
+					Name:      "worker-join-cfg",
+				},
+			}
+			if _, err := k.Reconcile(request); err != nil {
+				t.Fatalf("Failed to reconcile:\n %+v", err)
+			}
+			cfg, err := getKubeadmConfig(myclient, "worker-join-cfg")
+			if err != nil {
+				t.Fatalf("Failed to reconcile:\n %+v", err)
+			}
+			oldToken := cfg.Spec.JoinConfiguration.Discovery.BootstrapToken.Token
+
+			// Simulate the bootstrap token cleaner deleting the expired token.
+			l := &corev1.SecretList{}
+			if err := myclient.List(context.Background(), l, client.InNamespace(metav1.NamespaceSystem)); err != nil {
+				t.Fatal(errors.Wrap(err, "failed to get bootstrap tokens"))
+			}
+			for i := range l.Items {
+				if err := myclient.Delete(context.Background(), &l.Items[i]); err != nil {
+					t.Fatal(errors.Wrap(err, "failed to delete bootstrap token"))
+				}
+			}
+
+			result, err := k.Reconcile(request)
+			if !tt.expectRotate {
+				if err == nil {
+					t.Fatal("expected an error refreshing the expired bootstrap token")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Failed to reconcile:\n %+v", err)
+			}
+			if result.RequeueAfter >= DefaultTokenTTL || result.RequeueAfter == 0 {
+				t.Fatal("expected a requeue duration less than the token TTL")
+			}
+
+			cfg, err = getKubeadmConfig(myclient, "worker-join-cfg")
+			if err != nil {
+				t.Fatalf("Failed to reconcile:\n %+v", err)
+			}
+			newToken := cfg.Spec.JoinConfiguration.Discovery.BootstrapToken.Token
+			if newToken == "" || newToken == oldToken {
+				t.Fatal("Reconcile should have rotated the expired bootstrap token")
+			}
+
+			l = &corev1.SecretList{}
+			if err := myclient.List(context.Background(), l, client.InNamespace(metav1.NamespaceSystem)); err != nil {
+				t.Fatal(errors.Wrap(err, "failed to get bootstrap tokens"))
+			}
+			if len(l.Items) != 1 {
+				t.Fatalf("Expected one bootstrap token, saw:\n %+d", len(l.Items))
+			}
+
+			dataSecret := &corev1.Secret{}
+			if err := myclient.Get(context.Background(), client.ObjectKey{Namespace: "default", Name: *cfg.Status.DataSecretName}, dataSecret); err != nil {
+				t.Fatal(errors.Wrap(err, "failed to get bootstrap data secret"))
+			}
+			if !bytes.Contains(dataSecret.Data["value"], []byte(newToken)) {
+				t.Fatal("Expected the bootstrap data to be regenerated with the new bootstrap token")
+			}
+		})
+	}
+}
+
 // Ensure the discovery portion of the JoinConfiguration gets generated correctly.
 func TestKubeadmConfigReconciler_Reconcile_DisocveryReconcileBehaviors(t *testing.T) {
 	k := &KubeadmConfigReconciler{
@@ -0,0 +1,111 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	bootstrapv1 "sigs.k8s.io/cluster-api/bootstrap/kubeadm/api/v1alpha3"
+	kubeadmv1beta1 "sigs.k8s.io/cluster-api/bootstrap/kubeadm/types/v1beta1"
+)
+
+// kubeletExtraArgs returns the kubelet extra args of the node registration, with the flags rendering the
+// kubelet configuration added; the extra args explicitly set by the user take precedence.
+// The node registration is not modified.
+func kubeletExtraArgs(nodeRegistration kubeadmv1beta1.NodeRegistrationOptions, kubelet *bootstrapv1.KubeletConfiguration) (map[string]string, error) {
+	if kubelet == nil {
+		return nodeRegistration.KubeletExtraArgs, nil
+	}
+
+	args := map[string]string{}
+	if kubelet.MaxPods != nil {
+		args["max-pods"] = strconv.Itoa(int(*kubelet.MaxPods))
+	}
+	if len(kubelet.SystemReserved) > 0 {
+		value, err := reservedResourcesArg(kubelet.SystemReserved)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid kubelet systemReserved")
+		}
+		args["system-reserved"] = value
+	}
+	if len(kubelet.KubeReserved) > 0 {
+		value, err := reservedResourcesArg(kubelet.KubeReserved)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid kubelet kubeReserved")
+		}
+		args["kube-reserved"] = value
+	}
+	if len(kubelet.EvictionHard) > 0 {
+		value, err := evictionThresholdsArg(kubelet.EvictionHard)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid kubelet evictionHard")
+		}
+		args["eviction-hard"] = value
+	}
+	if kubelet.CgroupDriver != "" {
+		args["cgroup-driver"] = kubelet.CgroupDriver
+	}
+
+	for k, v := range nodeRegistration.KubeletExtraArgs {
+		args[k] = v
+	}
+	return args, nil
+}
+
+// reservedResourcesArg renders reserved resources as the value of a kubelet flag, e.g. cpu=500m,memory=1Gi.
+func reservedResourcesArg(resources map[string]string) (string, error) {
+	values := make([]string, 0, len(resources))
+	for _, name := range sortedKeys(resources) {
+		if _, err := resource.ParseQuantity(resources[name]); err != nil {
+			return "", errors.Wrapf(err, "invalid quantity %q for %s", resources[name], name)
+		}
+		values = append(values, fmt.Sprintf("%s=%s", name, resources[name]))
+	}
+	return strings.Join(values, ","), nil
+}
+
+// evictionThresholdsArg renders eviction thresholds as the value of a kubelet flag, e.g. memory.available<100Mi.
+func evictionThresholdsArg(thresholds map[string]string) (string, error) {
+	values := make([]string, 0, len(thresholds))
+	for _, signal := range sortedKeys(thresholds) {
+		threshold := thresholds[signal]
+		if strings.HasSuffix(threshold, "%") {
+			percentage, err := strconv.ParseFloat(strings.TrimSuffix(threshold, "%"), 64)
+			if err != nil || percentage < 0 || percentage > 100 {
+				return "", errors.Errorf("invalid percentage %q for %s", threshold, signal)
+			}
+		} else if _, err := resource.ParseQuantity(threshold); err != nil {
+			return "", errors.Wrapf(err, "invalid quantity %q for %s", threshold, signal)
+		}
+		values = append(values, fmt.Sprintf("%s<%s", signal, threshold))
+	}
+	return strings.Join(values, ","), nil
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,110 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/utils/pointer"
+	bootstrapv1 "sigs.k8s.io/cluster-api/bootstrap/kubeadm/api/v1alpha3"
+	kubeadmv1beta1 "sigs.k8s.io/cluster-api/bootstrap/kubeadm/types/v1beta1"
+)
+
+func TestKubeletExtraArgs(t *testing.T) {
+	tests := []struct {
+		name             string
+		nodeRegistration kubeadmv1beta1.NodeRegistrationOptions
+		kubelet          *bootstrapv1.KubeletConfiguration
+		want             map[string]string
+		wantErr          bool
+	}{
+		{
+			name: "Returns the extra args unchanged without a kubelet configuration",
+			nodeRegistration: kubeadmv1beta1.NodeRegistrationOptions{
+				KubeletExtraArgs: map[string]string{"node-labels": "foo=bar"},
+			},
+			want: map[string]string{"node-labels": "foo=bar"},
+		},
+		{
+			name: "Renders the kubelet configuration as flags",
+			kubelet: &bootstrapv1.KubeletConfiguration{
+				MaxPods:        pointer.Int32Ptr(250),
+				SystemReserved: map[string]string{"memory": "1Gi", "cpu": "500m"},
+				KubeReserved:   map[string]string{"cpu": "1"},
+				EvictionHard:   map[string]string{"nodefs.available": "10%", "memory.available": "100Mi"},
+				CgroupDriver:   "systemd",
+			},
+			want: map[string]string{
+				"max-pods":        "250",
+				"system-reserved": "cpu=500m,memory=1Gi",
+				"kube-reserved":   "cpu=1",
+				"eviction-hard":   "memory.available<100Mi,nodefs.available<10%",
+				"cgroup-driver":   "systemd",
+			},
+		},
+		{
+			name: "Gives precedence to the extra args",
+			nodeRegistration: kubeadmv1beta1.NodeRegistrationOptions{
+				KubeletExtraArgs: map[string]string{"max-pods": "110", "node-labels": "foo=bar"},
+			},
+			kubelet: &bootstrapv1.KubeletConfiguration{
+				MaxPods:      pointer.Int32Ptr(250),
+				CgroupDriver: "cgroupfs",
+			},
+			want: map[string]string{
+				"max-pods":      "110",
+				"node-labels":   "foo=bar",
+				"cgroup-driver": "cgroupfs",
+			},
+		},
+		{
+			name: "Fails with an invalid reserved quantity",
+			kubelet: &bootstrapv1.KubeletConfiguration{
+				SystemReserved: map[string]string{"memory": "lots"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Fails with an invalid eviction percentage",
+			kubelet: &bootstrapv1.KubeletConfiguration{
+				EvictionHard: map[string]string{"nodefs.available": "110%"},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			nodeRegistration := tt.nodeRegistration.DeepCopy()
+
+			got, err := kubeletExtraArgs(tt.nodeRegistration, tt.kubelet)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("kubeletExtraArgs() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("kubeletExtraArgs() = %v, want %v", got, tt.want)
+			}
+			if !reflect.DeepEqual(&tt.nodeRegistration, nodeRegistration) {
+				t.Errorf("kubeletExtraArgs() modified the node registration")
+			}
+		})
+	}
+}
@@ -33,6 +33,19 @@ var (
 	DefaultTokenTTL = 15 * time.Minute
 )
 
+// TokenRotationPolicy defines what happens when the bootstrap token of a Machine that did not join the cluster yet
+// can't be refreshed because it expired and was removed from the workload cluster.
+type TokenRotationPolicy string
+
+const (
+	// TokenRotationPolicyOnExpiry creates a new bootstrap token and regenerates the bootstrap data of the Machine,
+	// so a Machine whose infrastructure is not ready yet, e.g. waiting on slow infrastructure, can still join.
+	TokenRotationPolicyOnExpiry = TokenRotationPolicy("OnExpiry")
+
+	// TokenRotationPolicyNever reports an error; the Machine won't be able to join the cluster and has to be replaced.
+	TokenRotationPolicyNever = TokenRotationPolicy("Never")
+)
+
 // createToken attempts to create a token with the given ID.
 func createToken(c client.Client) (string, error) {
 	token, err := bootstraputil.GenerateBootstrapToken()
@@ -85,6 +98,9 @@ func refreshToken(c client.Client, token string) error {
 		return err
 	}
 
+	// Tokens already expired are rejected by the API server until refreshed, so a refresh is enough to revive them
+	// until the bootstrap token cleaner deletes their secret.
+
 	if secret.Data == nil {
 		return errors.Errorf("Invalid bootstrap secret %q, remove the token from the kubadm config to re-create", secretName)
 	}
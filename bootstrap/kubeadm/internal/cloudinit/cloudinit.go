@@ -39,6 +39,8 @@ type BaseUserData struct {
 	WriteFiles          []bootstrapv1.File
 	Users               []bootstrapv1.User
 	NTP                 *bootstrapv1.NTP
+	DiskSetup           *bootstrapv1.DiskSetup
+	Mounts              []bootstrapv1.MountPoints
 	KubeadmVerbosity    string
 }
 
@@ -60,6 +62,18 @@ func generate(kind string, tpl string, data interface{}) ([]byte, error) {
 		return nil, errors.Wrap(err, "failed to parse users template")
 	}
 
+	if _, err := tm.Parse(diskSetupTemplate); err != nil {
+		return nil, errors.Wrap(err, "failed to parse disk setup template")
+	}
+
+	if _, err := tm.Parse(fsSetupTemplate); err != nil {
+		return nil, errors.Wrap(err, "failed to parse fs setup template")
+	}
+
+	if _, err := tm.Parse(mountsTemplate); err != nil {
+		return nil, errors.Wrap(err, "failed to parse mounts template")
+	}
+
 	t, err := tm.Parse(tpl)
 	if err != nil {
 		return nil, errors.Wrapf(err, "failed to parse %s template", kind)
@@ -115,3 +115,67 @@ func TestNewInitControlPlaneCommands(t *testing.T) {
 		}
 	}
 }
+
+func TestNewNodeDiskSetupAndMounts(t *testing.T) {
+	gpt := "gpt"
+	partition := "auto"
+	overwrite := false
+
+	nodeinput := &NodeInput{
+		BaseUserData: BaseUserData{
+			Header: "test",
+			DiskSetup: &infrav1.DiskSetup{
+				Partitions: []infrav1.Partition{
+					{
+						Device:    "/dev/sdb",
+						Layout:    true,
+						Overwrite: &overwrite,
+						TableType: &gpt,
+					},
+				},
+				Filesystems: []infrav1.Filesystem{
+					{
+						Device:     "/dev/sdb",
+						Filesystem: "ext4",
+						Label:      "data_disk",
+						Partition:  &partition,
+						ExtraOpts:  []string{"-E", "lazy_itable_init=1"},
+					},
+				},
+			},
+			Mounts: []infrav1.MountPoints{
+				{"LABEL=data_disk", "/var/lib/data"},
+			},
+		},
+		JoinConfiguration: "my-join-config",
+	}
+
+	out, err := NewNode(nodeinput)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedSections := []string{
+		`disk_setup:
+  /dev/sdb:
+    table_type: gpt
+    layout: true
+    overwrite: false`,
+		`fs_setup:
+  - label: data_disk
+    filesystem: ext4
+    device: /dev/sdb
+    partition: auto
+    extra_opts:
+      - -E
+      - lazy_itable_init=1`,
+		`mounts:
+  -
+    - LABEL=data_disk
+    - /var/lib/data`,
+	}
+	for _, s := range expectedSections {
+		if !bytes.Contains(out, []byte(s)) {
+			t.Errorf("%s\ndid not contain\n%s", out, s)
+		}
+	}
+}
@@ -0,0 +1,223 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package ignition generates the bootstrap data in the Ignition format, for the machine images
+// using Ignition instead of cloud-init, e.g. Flatcar Container Linux and Fedora CoreOS.
+package ignition
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	bootstrapv1 "sigs.k8s.io/cluster-api/bootstrap/kubeadm/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/bootstrap/kubeadm/internal/cloudinit"
+)
+
+const (
+	ignitionVersion = "2.3.0"
+
+	kubeadmScriptPath = "/etc/kubeadm.sh"
+	kubeadmDonePath   = "/etc/kubeadm.done"
+	kubeadmUnitName   = "kubeadm.service"
+
+	timesyncdConfPath = "/etc/systemd/timesyncd.conf"
+	timesyncdUnitName = "systemd-timesyncd.service"
+
+	// kubeadmUnit runs the kubeadm script once, on the first boot of the machine.
+	kubeadmUnit = `[Unit]
+Description=kubeadm
+Wants=network-online.target
+After=network-online.target
+ConditionPathExists=!` + kubeadmDonePath + `
+
+[Service]
+Type=oneshot
+ExecStart=` + kubeadmScriptPath + `
+
+[Install]
+WantedBy=multi-user.target
+`
+)
+
+// NewInitControlPlane returns the Ignition config to be used on a controlplane instance.
+func NewInitControlPlane(input *cloudinit.ControlPlaneInput) ([]byte, error) {
+	files := input.Certificates.AsFiles()
+	files = append(files, input.AdditionalFiles...)
+	files = append(files, bootstrapv1.File{
+		Path:        "/etc/kubeadm.yaml",
+		Owner:       "root:root",
+		Permissions: "0640",
+		Content:     "---\n" + input.ClusterConfiguration + "\n---\n" + input.InitConfiguration,
+	})
+
+	config, err := render(&input.BaseUserData, files, "kubeadm init --config /etc/kubeadm.yaml")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to generate Ignition config for the init control plane")
+	}
+	return config, nil
+}
+
+// NewJoinControlPlane returns the Ignition config to be used on a new control plane instance.
+func NewJoinControlPlane(input *cloudinit.ControlPlaneJoinInput) ([]byte, error) {
+	files := input.Certificates.AsFiles()
+	files = append(files, input.AdditionalFiles...)
+	files = append(files, bootstrapv1.File{
+		Path:        "/etc/kubeadm-controlplane-join-config.yaml",
+		Owner:       "root:root",
+		Permissions: "0640",
+		Content:     input.JoinConfiguration,
+	})
+
+	config, err := render(&input.BaseUserData, files, "kubeadm join --config /etc/kubeadm-controlplane-join-config.yaml")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to generate Ignition config for machine joining control plane")
+	}
+	return config, nil
+}
+
+// NewNode returns the Ignition config to be used on a node instance.
+func NewNode(input *cloudinit.NodeInput) ([]byte, error) {
+	files := append([]bootstrapv1.File{}, input.AdditionalFiles...)
+	files = append(files, bootstrapv1.File{
+		Path:        "/etc/kubeadm-node.yaml",
+		Owner:       "root:root",
+		Permissions: "0640",
+		Content:     "---\n" + input.JoinConfiguration,
+	})
+
+	config, err := render(&input.BaseUserData, files, "kubeadm join --config /etc/kubeadm-node.yaml")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to generate Ignition config for the node")
+	}
+	return config, nil
+}
+
+// render generates an Ignition config writing the given files, creating the users and running the kubeadm command,
+// wrapped by the pre and post kubeadm commands, as a systemd unit on the first boot.
+func render(input *cloudinit.BaseUserData, files []bootstrapv1.File, kubeadmCommand string) ([]byte, error) {
+	// Disks and file systems are usually prepared in the machine image when using Ignition, so
+	// there is no translation of the cloud-init disk setup and mounts.
+	if input.DiskSetup != nil || len(input.Mounts) > 0 {
+		return nil, errors.New("diskSetup and mounts are not supported with the Ignition format")
+	}
+
+	cfg := config{
+		Ignition: ignition{Version: ignitionVersion},
+	}
+
+	for _, f := range files {
+		file, err := toIgnitionFile(f)
+		if err != nil {
+			return nil, err
+		}
+		cfg.Storage.Files = append(cfg.Storage.Files, file)
+	}
+
+	for _, u := range input.Users {
+		cfg.Passwd.Users = append(cfg.Passwd.Users, toIgnitionUser(u))
+		if u.Sudo != nil {
+			cfg.Storage.Files = append(cfg.Storage.Files, newFile(fmt.Sprintf("/etc/sudoers.d/%s", u.Name), 0440, fmt.Sprintf("%s %s\n", u.Name, *u.Sudo)))
+		}
+	}
+
+	if input.NTP != nil && input.NTP.Enabled != nil && *input.NTP.Enabled {
+		cfg.Storage.Files = append(cfg.Storage.Files, newFile(timesyncdConfPath, 0644, fmt.Sprintf("[Time]\nNTP=%s\n", strings.Join(input.NTP.Servers, " "))))
+		cfg.Systemd.Units = append(cfg.Systemd.Units, unit{Name: timesyncdUnitName, Enabled: true})
+	}
+
+	commands := append([]string{}, input.PreKubeadmCommands...)
+	commands = append(commands, strings.TrimSpace(kubeadmCommand+" "+input.KubeadmVerbosity))
+	commands = append(commands, input.PostKubeadmCommands...)
+	commands = append(commands, "touch "+kubeadmDonePath)
+	script := "#!/bin/bash\nset -e\n" + strings.Join(commands, "\n") + "\n"
+	cfg.Storage.Files = append(cfg.Storage.Files, newFile(kubeadmScriptPath, 0700, script))
+	cfg.Systemd.Units = append(cfg.Systemd.Units, unit{Name: kubeadmUnitName, Enabled: true, Contents: kubeadmUnit})
+
+	out, err := json.Marshal(cfg)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal Ignition config")
+	}
+	return out, nil
+}
+
+func newFile(path string, mode int, content string) file {
+	return file{
+		Filesystem: "root",
+		Path:       path,
+		Mode:       &mode,
+		Contents:   fileContents{Source: "data:," + url.PathEscape(content)},
+	}
+}
+
+func toIgnitionFile(f bootstrapv1.File) (file, error) {
+	out := file{
+		Filesystem: "root",
+		Path:       f.Path,
+	}
+
+	if f.Permissions != "" {
+		mode, err := strconv.ParseInt(f.Permissions, 8, 32)
+		if err != nil {
+			return file{}, errors.Wrapf(err, "invalid permissions %q for file %s", f.Permissions, f.Path)
+		}
+		m := int(mode)
+		out.Mode = &m
+	}
+
+	if f.Owner != "" {
+		owner := strings.SplitN(f.Owner, ":", 2)
+		out.User = &nodeUser{Name: owner[0]}
+		if len(owner) == 2 {
+			out.Group = &nodeGroup{Name: owner[1]}
+		}
+	}
+
+	switch f.Encoding {
+	case bootstrapv1.Base64:
+		out.Contents.Source = "data:;base64," + f.Content
+	case bootstrapv1.Gzip, bootstrapv1.GzipBase64:
+		out.Contents.Source = "data:;base64," + f.Content
+		out.Contents.Compression = "gzip"
+	default:
+		out.Contents.Source = "data:," + url.PathEscape(f.Content)
+	}
+
+	return out, nil
+}
+
+func toIgnitionUser(u bootstrapv1.User) user {
+	out := user{
+		Name:              u.Name,
+		Gecos:             u.Gecos,
+		HomeDir:           u.HomeDir,
+		Shell:             u.Shell,
+		PasswordHash:      u.Passwd,
+		PrimaryGroup:      u.PrimaryGroup,
+		SSHAuthorizedKeys: u.SSHAuthorizedKeys,
+	}
+	if u.Groups != nil {
+		for _, g := range strings.Split(*u.Groups, ",") {
+			if g = strings.TrimSpace(g); g != "" {
+				out.Groups = append(out.Groups, g)
+			}
+		}
+	}
+	return out
+}
@@ -0,0 +1,165 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ignition
+
+import (
+	"encoding/json"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"k8s.io/utils/pointer"
+	bootstrapv1 "sigs.k8s.io/cluster-api/bootstrap/kubeadm/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/bootstrap/kubeadm/internal/cloudinit"
+)
+
+func findFile(cfg *config, path string) *file {
+	for i := range cfg.Storage.Files {
+		if cfg.Storage.Files[i].Path == path {
+			return &cfg.Storage.Files[i]
+		}
+	}
+	return nil
+}
+
+func TestNewNode(t *testing.T) {
+	g := NewWithT(t)
+
+	input := &cloudinit.NodeInput{
+		BaseUserData: cloudinit.BaseUserData{
+			PreKubeadmCommands:  []string{"echo pre"},
+			PostKubeadmCommands: []string{"echo post"},
+			KubeadmVerbosity:    "--v 5",
+			AdditionalFiles: []bootstrapv1.File{
+				{
+					Path:        "/etc/my-file",
+					Owner:       "core:core",
+					Permissions: "0600",
+					Content:     "hello world",
+				},
+				{
+					Path:     "/etc/my-encoded-file",
+					Encoding: bootstrapv1.Base64,
+					Content:  "aGk=",
+				},
+			},
+			Users: []bootstrapv1.User{
+				{
+					Name:              "capi",
+					Groups:            pointer.StringPtr("docker, wheel"),
+					Sudo:              pointer.StringPtr("ALL=(ALL) NOPASSWD:ALL"),
+					SSHAuthorizedKeys: []string{"ssh-rsa AAAA"},
+				},
+			},
+			NTP: &bootstrapv1.NTP{
+				Enabled: pointer.BoolPtr(true),
+				Servers: []string{"0.pool.ntp.org", "1.pool.ntp.org"},
+			},
+		},
+		JoinConfiguration: "my-join-config",
+	}
+
+	out, err := NewNode(input)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	cfg := &config{}
+	g.Expect(json.Unmarshal(out, cfg)).To(Succeed())
+	g.Expect(cfg.Ignition.Version).To(Equal(ignitionVersion))
+
+	myFile := findFile(cfg, "/etc/my-file")
+	g.Expect(myFile).NotTo(BeNil())
+	g.Expect(*myFile.Mode).To(Equal(0600))
+	g.Expect(myFile.User.Name).To(Equal("core"))
+	g.Expect(myFile.Group.Name).To(Equal("core"))
+	g.Expect(myFile.Contents.Source).To(Equal("data:,hello%20world"))
+
+	encodedFile := findFile(cfg, "/etc/my-encoded-file")
+	g.Expect(encodedFile).NotTo(BeNil())
+	g.Expect(encodedFile.Contents.Source).To(Equal("data:;base64,aGk="))
+
+	g.Expect(findFile(cfg, "/etc/kubeadm-node.yaml")).NotTo(BeNil())
+	g.Expect(findFile(cfg, "/etc/sudoers.d/capi")).NotTo(BeNil())
+	g.Expect(findFile(cfg, timesyncdConfPath).Contents.Source).To(ContainSubstring("0.pool.ntp.org%201.pool.ntp.org"))
+
+	script := findFile(cfg, kubeadmScriptPath)
+	g.Expect(script).NotTo(BeNil())
+	g.Expect(script.Contents.Source).To(ContainSubstring("echo%20pre%0Akubeadm%20join%20--config%20%2Fetc%2Fkubeadm-node.yaml%20--v%205%0Aecho%20post"))
+
+	g.Expect(cfg.Passwd.Users).To(Equal([]user{
+		{
+			Name:              "capi",
+			Groups:            []string{"docker", "wheel"},
+			SSHAuthorizedKeys: []string{"ssh-rsa AAAA"},
+		},
+	}))
+	g.Expect(cfg.Systemd.Units).To(ConsistOf(
+		unit{Name: timesyncdUnitName, Enabled: true},
+		unit{Name: kubeadmUnitName, Enabled: true, Contents: kubeadmUnit},
+	))
+}
+
+func TestNewInitControlPlane(t *testing.T) {
+	g := NewWithT(t)
+
+	input := &cloudinit.ControlPlaneInput{
+		ClusterConfiguration: "my-cluster-config",
+		InitConfiguration:    "my-init-config",
+	}
+
+	out, err := NewInitControlPlane(input)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	cfg := &config{}
+	g.Expect(json.Unmarshal(out, cfg)).To(Succeed())
+
+	kubeadmConfig := findFile(cfg, "/etc/kubeadm.yaml")
+	g.Expect(kubeadmConfig).NotTo(BeNil())
+	g.Expect(kubeadmConfig.Contents.Source).To(ContainSubstring("my-cluster-config"))
+	g.Expect(kubeadmConfig.Contents.Source).To(ContainSubstring("my-init-config"))
+	g.Expect(findFile(cfg, kubeadmScriptPath).Contents.Source).To(ContainSubstring("kubeadm%20init%20--config%20%2Fetc%2Fkubeadm.yaml%0A"))
+}
+
+func TestInvalidFilePermissions(t *testing.T) {
+	g := NewWithT(t)
+
+	input := &cloudinit.NodeInput{
+		BaseUserData: cloudinit.BaseUserData{
+			AdditionalFiles: []bootstrapv1.File{
+				{
+					Path:        "/etc/my-file",
+					Permissions: "rw-r--r--",
+					Content:     "hello world",
+				},
+			},
+		},
+	}
+
+	_, err := NewNode(input)
+	g.Expect(err).To(HaveOccurred())
+}
+
+func TestDiskSetupNotSupported(t *testing.T) {
+	g := NewWithT(t)
+
+	input := &cloudinit.NodeInput{
+		BaseUserData: cloudinit.BaseUserData{
+			Mounts: []bootstrapv1.MountPoints{{"LABEL=data", "/data"}},
+		},
+	}
+
+	_, err := NewNode(input)
+	g.Expect(err).To(HaveOccurred())
+}
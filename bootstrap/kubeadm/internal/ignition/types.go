@@ -0,0 +1,82 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ignition
+
+// The following types are the subset of the Ignition config spec v2.3.0 used by the bootstrap provider.
+// See https://coreos.github.io/ignition/configuration-v2_3/ for the full specification.
+
+type config struct {
+	Ignition ignition `json:"ignition"`
+	Passwd   passwd   `json:"passwd,omitempty"`
+	Storage  storage  `json:"storage,omitempty"`
+	Systemd  systemd  `json:"systemd,omitempty"`
+}
+
+type ignition struct {
+	Version string `json:"version"`
+}
+
+type passwd struct {
+	Users []user `json:"users,omitempty"`
+}
+
+type user struct {
+	Name              string   `json:"name"`
+	Gecos             *string  `json:"gecos,omitempty"`
+	Groups            []string `json:"groups,omitempty"`
+	HomeDir           *string  `json:"homeDir,omitempty"`
+	PasswordHash      *string  `json:"passwordHash,omitempty"`
+	PrimaryGroup      *string  `json:"primaryGroup,omitempty"`
+	Shell             *string  `json:"shell,omitempty"`
+	SSHAuthorizedKeys []string `json:"sshAuthorizedKeys,omitempty"`
+}
+
+type storage struct {
+	Files []file `json:"files,omitempty"`
+}
+
+type file struct {
+	Filesystem string       `json:"filesystem"`
+	Path       string       `json:"path"`
+	Contents   fileContents `json:"contents"`
+	Mode       *int         `json:"mode,omitempty"`
+	User       *nodeUser    `json:"user,omitempty"`
+	Group      *nodeGroup   `json:"group,omitempty"`
+}
+
+type fileContents struct {
+	Compression string `json:"compression,omitempty"`
+	Source      string `json:"source"`
+}
+
+type nodeUser struct {
+	Name string `json:"name,omitempty"`
+}
+
+type nodeGroup struct {
+	Name string `json:"name,omitempty"`
+}
+
+type systemd struct {
+	Units []unit `json:"units,omitempty"`
+}
+
+type unit struct {
+	Name     string `json:"name"`
+	Enabled  bool   `json:"enabled,omitempty"`
+	Contents string `json:"contents,omitempty"`
+}
@@ -0,0 +1,41 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package windows
+
+// The following types are the subset of the cloud-config supported by cloudbase-init used by the bootstrap provider.
+// See https://cloudbase-init.readthedocs.io/en/latest/userdata.html#cloud-config for the full specification.
+
+type cloudConfig struct {
+	WriteFiles []writeFile `json:"write_files,omitempty"`
+	Users      []user      `json:"users,omitempty"`
+	RunCmd     []string    `json:"runcmd,omitempty"`
+}
+
+type writeFile struct {
+	Path        string `json:"path"`
+	Encoding    string `json:"encoding,omitempty"`
+	Permissions string `json:"permissions,omitempty"`
+	Content     string `json:"content"`
+}
+
+type user struct {
+	Name              string   `json:"name"`
+	Gecos             *string  `json:"gecos,omitempty"`
+	PrimaryGroup      *string  `json:"primary_group,omitempty"`
+	Groups            []string `json:"groups,omitempty"`
+	SSHAuthorizedKeys []string `json:"ssh_authorized_keys,omitempty"`
+}
@@ -0,0 +1,167 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package windows generates the bootstrap data for Windows machines, in the cloud-config format supported by
+// cloudbase-init; the kubeadm commands are run by a PowerShell script.
+package windows
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+	bootstrapv1 "sigs.k8s.io/cluster-api/bootstrap/kubeadm/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/bootstrap/kubeadm/internal/cloudinit"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	cloudConfigHeader = "#cloud-config\n"
+
+	kubeadmJoinConfigPath = `C:\k\kubeadm-node.yaml`
+	kubeadmScriptPath     = `C:\k\kubeadm-bootstrap.ps1`
+	kubeadmDonePath       = `C:\k\kubeadm.done`
+
+	containerdPath = `C:\Program Files\containerd\containerd.exe`
+
+	// ContainerdCRISocket is the CRI socket of containerd on Windows.
+	ContainerdCRISocket = "npipe:////./pipe/containerd-containerd"
+
+	// exitOnError stops the script if the previous native command failed; PowerShell does not stop on the
+	// failures of native commands, even with $ErrorActionPreference set to Stop.
+	exitOnError = "if ($LASTEXITCODE) { exit $LASTEXITCODE }"
+)
+
+// drivePathRegexp matches the paths starting with a drive letter, e.g. C:\k or c:/k.
+var drivePathRegexp = regexp.MustCompile(`^[A-Za-z]:`)
+
+// NewNode returns the cloudbase-init user data to be used on a Windows node instance.
+func NewNode(input *cloudinit.NodeInput) ([]byte, error) {
+	files := append([]bootstrapv1.File{}, input.AdditionalFiles...)
+	files = append(files, bootstrapv1.File{
+		Path:    kubeadmJoinConfigPath,
+		Content: "---\n" + input.JoinConfiguration,
+	})
+
+	userData, err := render(&input.BaseUserData, files, fmt.Sprintf("kubeadm join --config '%s'", kubeadmJoinConfigPath))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to generate cloudbase-init user data for the Windows node")
+	}
+	return userData, nil
+}
+
+// render generates a cloud-config writing the given files, creating the users and running a PowerShell script which
+// starts containerd and runs the kubeadm command, wrapped by the pre and post kubeadm commands, on the first boot.
+func render(input *cloudinit.BaseUserData, files []bootstrapv1.File, kubeadmCommand string) ([]byte, error) {
+	// Disks and file systems are prepared in the machine image on Windows, so there is no translation of the
+	// cloud-init disk setup and mounts.
+	if input.DiskSetup != nil || len(input.Mounts) > 0 {
+		return nil, errors.New("diskSetup and mounts are not supported on Windows")
+	}
+
+	cfg := cloudConfig{}
+	for _, f := range files {
+		cfg.WriteFiles = append(cfg.WriteFiles, toWriteFile(f))
+	}
+
+	for _, u := range input.Users {
+		cfg.Users = append(cfg.Users, toUser(u))
+	}
+
+	cfg.WriteFiles = append(cfg.WriteFiles, writeFile{
+		Path:    kubeadmScriptPath,
+		Content: script(input, kubeadmCommand),
+	})
+	cfg.RunCmd = []string{fmt.Sprintf(`powershell.exe -NoProfile -NonInteractive -ExecutionPolicy Bypass -File "%s"`, kubeadmScriptPath)}
+
+	out, err := yaml.Marshal(cfg)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal cloud-config")
+	}
+	return append([]byte(cloudConfigHeader), out...), nil
+}
+
+// script returns the PowerShell script registering and starting the containerd service, configuring the time
+// synchronization and running the kubeadm command, wrapped by the pre and post kubeadm commands; the script
+// does nothing if kubeadm already ran on the machine.
+func script(input *cloudinit.BaseUserData, kubeadmCommand string) string {
+	lines := []string{
+		"$ErrorActionPreference = 'Stop'",
+		fmt.Sprintf("if (Test-Path '%s') { exit 0 }", kubeadmDonePath),
+		"if (-not (Get-Service -Name containerd -ErrorAction SilentlyContinue)) {",
+		fmt.Sprintf("  & '%s' --register-service", containerdPath),
+		"  " + exitOnError,
+		"}",
+		"Start-Service -Name containerd",
+	}
+
+	if input.NTP != nil && input.NTP.Enabled != nil && *input.NTP.Enabled {
+		lines = append(lines,
+			fmt.Sprintf(`w32tm /config /manualpeerlist:"%s" /syncfromflags:manual /update`, strings.Join(input.NTP.Servers, " ")),
+			exitOnError,
+			"Restart-Service -Name w32time",
+		)
+	}
+
+	commands := append([]string{}, input.PreKubeadmCommands...)
+	commands = append(commands, strings.TrimSpace(kubeadmCommand+" "+input.KubeadmVerbosity))
+	commands = append(commands, input.PostKubeadmCommands...)
+	for _, c := range commands {
+		lines = append(lines, c, exitOnError)
+	}
+
+	lines = append(lines, fmt.Sprintf("New-Item -ItemType File -Force -Path '%s' | Out-Null", kubeadmDonePath))
+	return strings.Join(lines, "\r\n") + "\r\n"
+}
+
+// windowsPath converts a path to a Windows path, e.g. /etc/kubernetes/pki/ca.crt to C:\etc\kubernetes\pki\ca.crt,
+// which is the path used by kubeadm on Windows for the default paths of the kubeadm configuration.
+func windowsPath(path string) string {
+	path = strings.ReplaceAll(path, "/", `\`)
+	if strings.HasPrefix(path, `\`) && !drivePathRegexp.MatchString(path) {
+		path = "C:" + path
+	}
+	return path
+}
+
+func toWriteFile(f bootstrapv1.File) writeFile {
+	// The owner of the files is ignored, given that cloudbase-init does not support it.
+	return writeFile{
+		Path:        windowsPath(f.Path),
+		Encoding:    string(f.Encoding),
+		Permissions: f.Permissions,
+		Content:     f.Content,
+	}
+}
+
+func toUser(u bootstrapv1.User) user {
+	// The Linux specific settings, e.g. sudo and shell, are ignored.
+	out := user{
+		Name:              u.Name,
+		Gecos:             u.Gecos,
+		PrimaryGroup:      u.PrimaryGroup,
+		SSHAuthorizedKeys: u.SSHAuthorizedKeys,
+	}
+	if u.Groups != nil {
+		for _, g := range strings.Split(*u.Groups, ",") {
+			if g = strings.TrimSpace(g); g != "" {
+				out.Groups = append(out.Groups, g)
+			}
+		}
+	}
+	return out
+}
@@ -0,0 +1,146 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package windows
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"k8s.io/utils/pointer"
+	bootstrapv1 "sigs.k8s.io/cluster-api/bootstrap/kubeadm/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/bootstrap/kubeadm/internal/cloudinit"
+	"sigs.k8s.io/yaml"
+)
+
+func findFile(cfg *cloudConfig, path string) *writeFile {
+	for i := range cfg.WriteFiles {
+		if cfg.WriteFiles[i].Path == path {
+			return &cfg.WriteFiles[i]
+		}
+	}
+	return nil
+}
+
+func TestNewNode(t *testing.T) {
+	g := NewWithT(t)
+
+	input := &cloudinit.NodeInput{
+		BaseUserData: cloudinit.BaseUserData{
+			PreKubeadmCommands:  []string{"Write-Output pre"},
+			PostKubeadmCommands: []string{"Write-Output post"},
+			KubeadmVerbosity:    "--v 5",
+			AdditionalFiles: []bootstrapv1.File{
+				{
+					Path:        "/etc/my-file",
+					Owner:       "root:root",
+					Permissions: "0600",
+					Content:     "hello world",
+				},
+				{
+					Path:     "D:/data/my-encoded-file",
+					Encoding: bootstrapv1.Base64,
+					Content:  "aGk=",
+				},
+			},
+			Users: []bootstrapv1.User{
+				{
+					Name:              "capi",
+					Groups:            pointer.StringPtr("Administrators, Users"),
+					Sudo:              pointer.StringPtr("ALL=(ALL) NOPASSWD:ALL"),
+					SSHAuthorizedKeys: []string{"ssh-rsa AAAA"},
+				},
+			},
+			NTP: &bootstrapv1.NTP{
+				Enabled: pointer.BoolPtr(true),
+				Servers: []string{"0.pool.ntp.org", "1.pool.ntp.org"},
+			},
+		},
+		JoinConfiguration: "my-join-config",
+	}
+
+	out, err := NewNode(input)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(string(out)).To(HavePrefix(cloudConfigHeader))
+
+	cfg := &cloudConfig{}
+	g.Expect(yaml.Unmarshal(out, cfg)).To(Succeed())
+
+	myFile := findFile(cfg, `C:\etc\my-file`)
+	g.Expect(myFile).NotTo(BeNil())
+	g.Expect(myFile.Permissions).To(Equal("0600"))
+	g.Expect(myFile.Content).To(Equal("hello world"))
+
+	encodedFile := findFile(cfg, `D:\data\my-encoded-file`)
+	g.Expect(encodedFile).NotTo(BeNil())
+	g.Expect(encodedFile.Encoding).To(Equal("base64"))
+
+	g.Expect(findFile(cfg, kubeadmJoinConfigPath).Content).To(Equal("---\nmy-join-config"))
+
+	script := findFile(cfg, kubeadmScriptPath)
+	g.Expect(script).NotTo(BeNil())
+	g.Expect(script.Content).To(ContainSubstring("--register-service"))
+	g.Expect(script.Content).To(ContainSubstring("Start-Service -Name containerd"))
+	g.Expect(script.Content).To(ContainSubstring(`/manualpeerlist:"0.pool.ntp.org 1.pool.ntp.org"`))
+	g.Expect(script.Content).To(ContainSubstring(strings.Join([]string{
+		"Write-Output pre",
+		exitOnError,
+		`kubeadm join --config 'C:\k\kubeadm-node.yaml' --v 5`,
+		exitOnError,
+		"Write-Output post",
+		exitOnError,
+	}, "\r\n")))
+
+	g.Expect(cfg.RunCmd).To(ConsistOf(ContainSubstring(kubeadmScriptPath)))
+	g.Expect(cfg.Users).To(Equal([]user{
+		{
+			Name:              "capi",
+			Groups:            []string{"Administrators", "Users"},
+			SSHAuthorizedKeys: []string{"ssh-rsa AAAA"},
+		},
+	}))
+}
+
+func TestNewNodeDiskSetup(t *testing.T) {
+	g := NewWithT(t)
+
+	input := &cloudinit.NodeInput{
+		BaseUserData: cloudinit.BaseUserData{
+			Mounts: []bootstrapv1.MountPoints{{"/dev/sdb", "/data"}},
+		},
+	}
+
+	_, err := NewNode(input)
+	g.Expect(err).To(HaveOccurred())
+}
+
+func TestWindowsPath(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{path: "/etc/kubernetes/pki/ca.crt", want: `C:\etc\kubernetes\pki\ca.crt`},
+		{path: `C:\k\config`, want: `C:\k\config`},
+		{path: "d:/data/file", want: `d:\data\file`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			g := NewWithT(t)
+			g.Expect(windowsPath(tt.path)).To(Equal(tt.want))
+		})
+	}
+}
@@ -23,6 +23,7 @@ import (
 	"os"
 	"time"
 
+	"github.com/pkg/errors"
 	"k8s.io/apimachinery/pkg/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/rest"
@@ -64,6 +65,9 @@ func main() {
 		kubeadmConfigConcurrency int
 		syncPeriod               time.Duration
 		webhookPort              int
+		tokenRotationPolicy      string
+		dataSecretCleanupPolicy  string
+		dataSecretRetention      time.Duration
 	)
 
 	flag.StringVar(&metricsAddr, "metrics-addr", ":8080",
@@ -87,6 +91,15 @@ func main() {
 	flag.DurationVar(&kubeadmbootstrapcontrollers.DefaultTokenTTL, "bootstrap-token-ttl", 15*time.Minute,
 		"The amount of time the bootstrap token will be valid")
 
+	flag.StringVar(&tokenRotationPolicy, "bootstrap-token-rotation-policy", string(kubeadmbootstrapcontrollers.TokenRotationPolicyOnExpiry),
+		"What to do when the bootstrap token of a Machine not yet joined expires: OnExpiry creates a new token and regenerates the bootstrap data, Never fails")
+
+	flag.StringVar(&dataSecretCleanupPolicy, "bootstrap-data-cleanup-policy", string(kubeadmbootstrapcontrollers.DataSecretCleanupPolicyRetain),
+		"What to do with the bootstrap data secret once the node of a Machine is Ready: Retain keeps it, Scrub removes the bootstrap data from it, Delete deletes it")
+
+	flag.DurationVar(&dataSecretRetention, "bootstrap-data-retention", 0,
+		"The amount of time the bootstrap data secret is kept after the node of a Machine is Ready, before applying the bootstrap data cleanup policy")
+
 	flag.IntVar(&webhookPort, "webhook-port", 9443,
 		"Webhook Server port (set to 0 to disable)")
 
@@ -94,6 +107,20 @@ func main() {
 
 	ctrl.SetLogger(klogr.New())
 
+	switch kubeadmbootstrapcontrollers.TokenRotationPolicy(tokenRotationPolicy) {
+	case kubeadmbootstrapcontrollers.TokenRotationPolicyOnExpiry, kubeadmbootstrapcontrollers.TokenRotationPolicyNever:
+	default:
+		setupLog.Error(errors.Errorf("invalid bootstrap token rotation policy %q", tokenRotationPolicy), "unable to start manager")
+		os.Exit(1)
+	}
+
+	switch kubeadmbootstrapcontrollers.DataSecretCleanupPolicy(dataSecretCleanupPolicy) {
+	case kubeadmbootstrapcontrollers.DataSecretCleanupPolicyRetain, kubeadmbootstrapcontrollers.DataSecretCleanupPolicyScrub, kubeadmbootstrapcontrollers.DataSecretCleanupPolicyDelete:
+	default:
+		setupLog.Error(errors.Errorf("invalid bootstrap data cleanup policy %q", dataSecretCleanupPolicy), "unable to start manager")
+		os.Exit(1)
+	}
+
 	if profilerAddress != "" {
 		klog.Infof("Profiler listening for requests at %s", profilerAddress)
 		go func() {
@@ -118,8 +145,11 @@ func main() {
 
 	// Kubeadm controllers.
 	if err = (&kubeadmbootstrapcontrollers.KubeadmConfigReconciler{
-		Client: mgr.GetClient(),
-		Log:    ctrl.Log.WithName("controllers").WithName("KubeadmConfig"),
+		Client:                  mgr.GetClient(),
+		Log:                     ctrl.Log.WithName("controllers").WithName("KubeadmConfig"),
+		TokenRotationPolicy:     kubeadmbootstrapcontrollers.TokenRotationPolicy(tokenRotationPolicy),
+		DataSecretCleanupPolicy: kubeadmbootstrapcontrollers.DataSecretCleanupPolicy(dataSecretCleanupPolicy),
+		DataSecretRetention:     dataSecretRetention,
 	}).SetupWithManager(mgr, concurrency(kubeadmConfigConcurrency)); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "KubeadmConfig")
 		os.Exit(1)
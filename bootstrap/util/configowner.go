@@ -59,6 +59,15 @@ func (co ConfigOwner) DataSecretName() *string {
 	return &dataSecretName
 }
 
+// NodeRefName extracts status.nodeRef.name from the config owner.
+func (co ConfigOwner) NodeRefName() string {
+	nodeName, _, err := unstructured.NestedString(co.Object, "status", "nodeRef", "name")
+	if err != nil {
+		return ""
+	}
+	return nodeName
+}
+
 // IsControlPlaneMachine checks if an unstructured object is Machine with the control plane role.
 func (co ConfigOwner) IsControlPlaneMachine() bool {
 	if co.GetKind() != "Machine" {
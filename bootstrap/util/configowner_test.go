@@ -20,6 +20,7 @@ import (
 	"context"
 	"testing"
 
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/utils/pointer"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
@@ -50,6 +51,10 @@ func TestGetConfigOwnerSuccess(t *testing.T) {
 		},
 		Status: clusterv1.MachineStatus{
 			InfrastructureReady: true,
+			NodeRef: &corev1.ObjectReference{
+				Kind: "Node",
+				Name: "my-node",
+			},
 		},
 	}
 
@@ -81,6 +86,9 @@ func TestGetConfigOwnerSuccess(t *testing.T) {
 	if configOwner.DataSecretName() == nil {
 		t.Fatalf("did not expect DataSecretName: %v", configOwner.DataSecretName())
 	}
+	if configOwner.NodeRefName() != "my-node" {
+		t.Fatalf("did not expect NodeRefName: %q", configOwner.NodeRefName())
+	}
 	if !configOwner.IsControlPlaneMachine() {
 		t.Fatalf("did not expect IsControlPlane: %v", configOwner.IsControlPlaneMachine())
 	}
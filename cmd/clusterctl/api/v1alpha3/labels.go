@@ -22,4 +22,8 @@ const (
 
 	// ClusterctlCoreLabelName defines the label that is applied to all the core objects managed by clusterctl.
 	ClusterctlCoreLabelName = "clusterctl.cluster.x-k8s.io/core"
+
+	// ClusterctlMoveAnnotation can be set to "false" on an object to exclude it, and the objects it owns, from
+	// clusterctl move and backup, e.g. for Secrets managed by external systems in both the management clusters.
+	ClusterctlMoveAnnotation = "clusterctl.cluster.x-k8s.io/move"
 )
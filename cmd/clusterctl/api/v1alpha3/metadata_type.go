@@ -30,6 +30,11 @@ type Metadata struct {
 
 	// +optional
 	ReleaseSeries []ReleaseSeries `json:"releaseSeries"`
+
+	// Dependencies defines the providers that must be installed in the management cluster for the provider to work,
+	// e.g. an infrastructure provider requiring a specific IPAM provider.
+	// +optional
+	Dependencies []ProviderDependency `json:"dependencies,omitempty"`
 }
 
 // ReleaseSeries maps a provider release series (major/minor) with a API Version of Cluster API (contract).
@@ -44,6 +49,21 @@ type ReleaseSeries struct {
 	Contract string `json:"contract,omitempty"`
 }
 
+// ProviderDependency defines a provider required by another provider.
+type ProviderDependency struct {
+	// Name of the required provider.
+	Name string `json:"name"`
+
+	// Type of the required provider. If empty, a provider of any type with the given name satisfies the dependency.
+	// +optional
+	Type ProviderType `json:"type,omitempty"`
+}
+
+// IsSatisfiedBy returns true if the dependency is satisfied by a provider with the given name and type.
+func (d *ProviderDependency) IsSatisfiedBy(name string, providerType ProviderType) bool {
+	return d.Name == name && (d.Type == ProviderTypeUnknown || d.Type == providerType)
+}
+
 func init() {
 	SchemeBuilder.Register(&Metadata{})
 }
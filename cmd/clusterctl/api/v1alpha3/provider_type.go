@@ -17,16 +17,24 @@ limitations under the License.
 package v1alpha3
 
 import (
+	corev1 "k8s.io/api/core/v1"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
 )
 
 // +kubebuilder:resource:path=providers,scope=Namespaced,categories=cluster-api
 // +kubebuilder:storageversion
 // +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
 // +kubebuilder:printcolumn:name="Type",type="string",JSONPath=".type"
 // +kubebuilder:printcolumn:name="Version",type="string",JSONPath=".version"
 // +kubebuilder:printcolumn:name="Watch Namespace",type="string",JSONPath=".watchedNamespace"
+// +kubebuilder:printcolumn:name="Contract",type="string",JSONPath=".status.contract"
+// +kubebuilder:printcolumn:name="Health",type="string",JSONPath=".status.health"
+// +kubebuilder:printcolumn:name="Last Updated",type="date",JSONPath=".status.lastUpdated"
 
 // Provider is the Schema for the providers API
 type Provider struct {
@@ -43,9 +51,110 @@ type Provider struct {
 	Version string `json:"version,omitempty"`
 
 	// WatchedNamespace indicates the namespace where the provider controller is is watching.
-	// if empty the provider controller is watching for objects in all namespaces.
+	// if empty, and also WatchedNamespaces and WatchedNamespaceSelector are empty, the provider controller is watching
+	// for objects in all namespaces.
 	// +optional
 	WatchedNamespace string `json:"watchedNamespace,omitempty"`
+
+	// WatchedNamespaces indicates additional namespaces where the provider controller is watching, for
+	// providers watching a list of namespaces.
+	// +optional
+	WatchedNamespaces []string `json:"watchedNamespaces,omitempty"`
+
+	// WatchedNamespaceSelector, if set, indicates that the provider controller is watching also all the namespaces
+	// matching the label selector.
+	// +optional
+	WatchedNamespaceSelector *metav1.LabelSelector `json:"watchedNamespaceSelector,omitempty"`
+
+	// History records the install and upgrade operations performed on the provider instance,
+	// ordered from the oldest to the most recent.
+	// +optional
+	History []ProviderOperation `json:"history,omitempty"`
+
+	// Status reports the state of the provider instance, as last observed by clusterctl.
+	// +optional
+	Status ProviderStatus `json:"status,omitempty"`
+}
+
+// ProviderHealth defines the health of a provider instance.
+type ProviderHealth string
+
+const (
+	// ProviderHealthy is used when all the provider Deployments are available.
+	ProviderHealthy = ProviderHealth("Healthy")
+
+	// ProviderUnhealthy is used when at least one of the provider Deployments is not available.
+	ProviderUnhealthy = ProviderHealth("Unhealthy")
+
+	// ProviderHealthUnknown is used when the health of the provider has not been checked yet.
+	ProviderHealthUnknown = ProviderHealth("Unknown")
+)
+
+// ProviderStatus defines the state of a provider instance, as last observed by clusterctl.
+type ProviderStatus struct {
+	// Contract is the API Version of Cluster API (contract) implemented by the provider version
+	// reported in ObservedVersion.
+	// +optional
+	Contract string `json:"contract,omitempty"`
+
+	// ObservedVersion is the version of the provider the status refers to.
+	// +optional
+	ObservedVersion string `json:"observedVersion,omitempty"`
+
+	// Health reports if the provider Deployments are available.
+	// +optional
+	Health ProviderHealth `json:"health,omitempty"`
+
+	// Message explains why the provider is unhealthy, if it is.
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// LastUpdated is the time clusterctl last updated the status.
+	// +optional
+	LastUpdated *metav1.Time `json:"lastUpdated,omitempty"`
+
+	// ClusterctlVersion is the version of clusterctl which last updated the status.
+	// +optional
+	ClusterctlVersion string `json:"clusterctlVersion,omitempty"`
+}
+
+// ProviderOperationType defines the type of an operation performed on a provider instance.
+type ProviderOperationType string
+
+const (
+	// InstallProviderOperation is used when the provider instance is installed by clusterctl init.
+	InstallProviderOperation = ProviderOperationType("Install")
+
+	// UpgradeProviderOperation is used when the provider instance is upgraded by clusterctl upgrade.
+	UpgradeProviderOperation = ProviderOperationType("Upgrade")
+)
+
+// ProviderOperation records an operation performed on a provider instance.
+type ProviderOperation struct {
+	// Type of the operation.
+	Type ProviderOperationType `json:"type"`
+
+	// Version of the provider installed by the operation.
+	Version string `json:"version"`
+
+	// PreviousVersion of the provider, if any.
+	// +optional
+	PreviousVersion string `json:"previousVersion,omitempty"`
+
+	// Timestamp of the operation.
+	Timestamp metav1.Time `json:"timestamp"`
+
+	// User who performed the operation.
+	// +optional
+	User string `json:"user,omitempty"`
+
+	// ClusterctlVersion is the version of clusterctl used for performing the operation.
+	// +optional
+	ClusterctlVersion string `json:"clusterctlVersion,omitempty"`
+
+	// RepositoryURL is the URL of the repository the provider components were read from.
+	// +optional
+	RepositoryURL string `json:"repositoryURL,omitempty"`
 }
 
 // ProviderType is a string representation of a TaskGroup create policy.
@@ -90,9 +199,70 @@ func (p *Provider) InstanceName() string {
 	return types.NamespacedName{Namespace: p.Namespace, Name: p.Name}.String()
 }
 
+// WatchesAllNamespaces returns true if the provider controller is watching for objects in all namespaces.
+func (p *Provider) WatchesAllNamespaces() bool {
+	return p.WatchedNamespace == "" && len(p.WatchedNamespaces) == 0 && p.WatchedNamespaceSelector == nil
+}
+
+// GetWatchedNamespaces returns the sorted list of namespaces explicitly watched by the provider controller, that is
+// WatchedNamespace and WatchedNamespaces combined.
+func (p *Provider) GetWatchedNamespaces() []string {
+	namespaces := sets.NewString(p.WatchedNamespaces...)
+	if p.WatchedNamespace != "" {
+		namespaces.Insert(p.WatchedNamespace)
+	}
+	return namespaces.List()
+}
+
+// WatchesNamespace returns true if the provider controller is watching for objects in the given namespace.
+func (p *Provider) WatchesNamespace(namespace corev1.Namespace) bool {
+	if p.WatchesAllNamespaces() {
+		return true
+	}
+
+	for _, n := range p.GetWatchedNamespaces() {
+		if n == namespace.Name {
+			return true
+		}
+	}
+
+	if p.WatchedNamespaceSelector != nil {
+		selector, err := metav1.LabelSelectorAsSelector(p.WatchedNamespaceSelector)
+		if err != nil {
+			return false
+		}
+		return selector.Matches(labels.Set(namespace.Labels))
+	}
+	return false
+}
+
 // HasWatchingOverlapWith returns true if the provider has an overlapping watching namespace with another provider.
-func (p *Provider) HasWatchingOverlapWith(other Provider) bool {
-	return p.WatchedNamespace == "" || p.WatchedNamespace == other.WatchedNamespace || other.WatchedNamespace == ""
+// Given that the namespaces watched because of a WatchedNamespaceSelector depend on the namespace labels, the check
+// is performed against the namespaces existing in the cluster; additionally, providers with the same
+// WatchedNamespaceSelector are always considered overlapping.
+func (p *Provider) HasWatchingOverlapWith(other Provider, namespaces []corev1.Namespace) bool {
+	if p.WatchesAllNamespaces() || other.WatchesAllNamespaces() {
+		return true
+	}
+
+	otherNamespaces := sets.NewString(other.GetWatchedNamespaces()...)
+	for _, n := range p.GetWatchedNamespaces() {
+		if otherNamespaces.Has(n) {
+			return true
+		}
+	}
+
+	if p.WatchedNamespaceSelector != nil && other.WatchedNamespaceSelector != nil &&
+		apiequality.Semantic.DeepEqual(p.WatchedNamespaceSelector, other.WatchedNamespaceSelector) {
+		return true
+	}
+
+	for _, n := range namespaces {
+		if p.WatchesNamespace(n) && other.WatchesNamespace(n) {
+			return true
+		}
+	}
+	return false
 }
 
 // Equals returns true if two providers are exactly the same.
@@ -101,6 +271,8 @@ func (p *Provider) Equals(other Provider) bool {
 		p.Namespace == other.Namespace &&
 		p.Type == other.Type &&
 		p.WatchedNamespace == other.WatchedNamespace &&
+		apiequality.Semantic.DeepEqual(p.GetWatchedNamespaces(), other.GetWatchedNamespaces()) &&
+		apiequality.Semantic.DeepEqual(p.WatchedNamespaceSelector, other.WatchedNamespaceSelector) &&
 		p.Version == other.Version
 }
 
@@ -0,0 +1,108 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha3
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestProvider_HasWatchingOverlapWith(t *testing.T) {
+	tenantA := &metav1.LabelSelector{MatchLabels: map[string]string{"tenant": "a"}}
+	tenantB := &metav1.LabelSelector{MatchLabels: map[string]string{"tenant": "b"}}
+
+	namespaces := []corev1.Namespace{
+		{ObjectMeta: metav1.ObjectMeta{Name: "ns1", Labels: map[string]string{"tenant": "a"}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "ns2", Labels: map[string]string{"tenant": "b"}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "ns3"}},
+	}
+
+	tests := []struct {
+		name     string
+		provider Provider
+		other    Provider
+		want     bool
+	}{
+		{
+			name:     "providers watching all namespaces overlap",
+			provider: Provider{},
+			other:    Provider{WatchedNamespace: "ns1"},
+			want:     true,
+		},
+		{
+			name:     "providers watching the same namespace overlap",
+			provider: Provider{WatchedNamespace: "ns1"},
+			other:    Provider{WatchedNamespace: "ns1"},
+			want:     true,
+		},
+		{
+			name:     "providers watching different namespaces do not overlap",
+			provider: Provider{WatchedNamespace: "ns1"},
+			other:    Provider{WatchedNamespace: "ns2"},
+			want:     false,
+		},
+		{
+			name:     "providers watching lists of namespaces with a namespace in common overlap",
+			provider: Provider{WatchedNamespace: "ns1", WatchedNamespaces: []string{"ns2"}},
+			other:    Provider{WatchedNamespaces: []string{"ns2", "ns3"}},
+			want:     true,
+		},
+		{
+			name:     "providers watching lists of namespaces without namespaces in common do not overlap",
+			provider: Provider{WatchedNamespaces: []string{"ns1", "ns2"}},
+			other:    Provider{WatchedNamespaces: []string{"ns3", "ns4"}},
+			want:     false,
+		},
+		{
+			name:     "provider watching by label selector overlaps with a provider watching a matching namespace",
+			provider: Provider{WatchedNamespaceSelector: tenantA},
+			other:    Provider{WatchedNamespace: "ns1"},
+			want:     true,
+		},
+		{
+			name:     "provider watching by label selector does not overlap with a provider watching a namespace not matching",
+			provider: Provider{WatchedNamespaceSelector: tenantA},
+			other:    Provider{WatchedNamespaces: []string{"ns2", "ns3"}},
+			want:     false,
+		},
+		{
+			name:     "providers watching by the same label selector overlap",
+			provider: Provider{WatchedNamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"tenant": "c"}}},
+			other:    Provider{WatchedNamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"tenant": "c"}}},
+			want:     true,
+		},
+		{
+			name:     "providers watching by label selectors not matching the same namespaces do not overlap",
+			provider: Provider{WatchedNamespaceSelector: tenantA},
+			other:    Provider{WatchedNamespaceSelector: tenantB},
+			want:     false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.provider.HasWatchingOverlapWith(tt.other, namespaces); got != tt.want {
+				t.Errorf("HasWatchingOverlapWith() = %v, want %v", got, tt.want)
+			}
+			// the overlap check must be symmetric.
+			if got := tt.other.HasWatchingOverlapWith(tt.provider, namespaces); got != tt.want {
+				t.Errorf("HasWatchingOverlapWith() on the other provider = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
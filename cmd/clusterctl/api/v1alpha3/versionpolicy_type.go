@@ -0,0 +1,119 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha3
+
+import (
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/version"
+)
+
+// +kubebuilder:resource:path=versionpolicies,scope=Cluster,categories=cluster-api
+// +kubebuilder:storageversion
+// +kubebuilder:object:root=true
+
+// VersionPolicy defines the provider versions allowed in a management cluster.
+// Both clusterctl init and clusterctl upgrade respect the version ranges defined by
+// all the VersionPolicy objects existing in the management cluster.
+type VersionPolicy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// Providers defines the allowed version range for each provider.
+	// Providers without a version range are not restricted.
+	// +optional
+	Providers []ProviderVersionRange `json:"providers,omitempty"`
+}
+
+// ProviderVersionRange defines the range of versions allowed for a provider.
+type ProviderVersionRange struct {
+	// Name of the provider the version range applies to.
+	Name string `json:"name"`
+
+	// MinVersion defines the minimum allowed version, inclusive.
+	// If empty, there is no lower bound.
+	// +optional
+	MinVersion string `json:"minVersion,omitempty"`
+
+	// MaxVersion defines the maximum allowed version, inclusive.
+	// If empty, there is no upper bound.
+	// +optional
+	MaxVersion string `json:"maxVersion,omitempty"`
+
+	// AllowPreReleases allows pre-release versions (e.g. v0.3.0-rc.1) within the range.
+	// +optional
+	AllowPreReleases bool `json:"allowPreReleases,omitempty"`
+}
+
+// Allows returns true if the given version belongs to the version range.
+func (r *ProviderVersionRange) Allows(v *version.Version) (bool, error) {
+	if v.PreRelease() != "" && !r.AllowPreReleases {
+		return false, nil
+	}
+
+	if r.MinVersion != "" {
+		minVersion, err := version.ParseSemantic(r.MinVersion)
+		if err != nil {
+			return false, errors.Wrapf(err, "invalid minVersion %q for the %s provider", r.MinVersion, r.Name)
+		}
+		if v.LessThan(minVersion) {
+			return false, nil
+		}
+	}
+
+	if r.MaxVersion != "" {
+		maxVersion, err := version.ParseSemantic(r.MaxVersion)
+		if err != nil {
+			return false, errors.Wrapf(err, "invalid maxVersion %q for the %s provider", r.MaxVersion, r.Name)
+		}
+		if maxVersion.LessThan(v) {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+// IsVersionAllowed returns true if the given version is allowed for a provider, that is when the version
+// belongs to all the version ranges defined for the provider.
+func (p *VersionPolicy) IsVersionAllowed(provider string, v *version.Version) (bool, error) {
+	for i := range p.Providers {
+		r := &p.Providers[i]
+		if r.Name != provider {
+			continue
+		}
+
+		allowed, err := r.Allows(v)
+		if err != nil || !allowed {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// +kubebuilder:object:root=true
+
+// VersionPolicyList contains a list of VersionPolicy
+type VersionPolicyList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []VersionPolicy `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&VersionPolicy{}, &VersionPolicyList{})
+}
@@ -0,0 +1,80 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var alphaCmd = &cobra.Command{
+	Use:   "alpha",
+	Short: "Commands for features in alpha",
+	Long:  `Commands for features in alpha; these commands and their flags might change or be removed in future releases`,
+}
+
+var alphaSimulateCmd = &cobra.Command{
+	Use:   "simulate",
+	Short: "Simulate operations on a workload cluster without applying them",
+	Long:  `Simulate operations on a workload cluster without applying them`,
+}
+
+var alphaOrphansCmd = &cobra.Command{
+	Use:   "orphans",
+	Short: "Find and delete the infrastructure and bootstrap objects without a corresponding Machine/Cluster owner",
+	Long:  `Find and delete the infrastructure and bootstrap objects without a corresponding Machine/Cluster owner`,
+}
+
+var alphaMachineCmd = &cobra.Command{
+	Use:   "machine",
+	Short: "Request actions on Machines, e.g. reboot",
+	Long:  `Request actions on Machines, e.g. reboot`,
+}
+
+var alphaRolloutCmd = &cobra.Command{
+	Use:   "rollout",
+	Short: "Manage the rollout of MachineDeployments and KubeadmControlPlanes",
+	Long:  `Manage the rollout of MachineDeployments and KubeadmControlPlanes`,
+}
+
+var alphaClusterCmd = &cobra.Command{
+	Use:   "cluster",
+	Short: "Pause and resume the reconciliation of Clusters",
+	Long:  `Pause and resume the reconciliation of Clusters`,
+}
+
+var alphaTestCmd = &cobra.Command{
+	Use:   "test",
+	Short: "Run smoke tests of the providers against a management cluster",
+	Long:  `Run smoke tests of the providers against a management cluster`,
+}
+
+var alphaTopologyCmd = &cobra.Command{
+	Use:   "topology",
+	Short: "Work with the managed topologies of the Clusters defined by ClusterClasses",
+	Long:  `Work with the managed topologies of the Clusters defined by ClusterClasses`,
+}
+
+func init() {
+	alphaCmd.AddCommand(alphaSimulateCmd)
+	alphaCmd.AddCommand(alphaOrphansCmd)
+	alphaCmd.AddCommand(alphaMachineCmd)
+	alphaCmd.AddCommand(alphaRolloutCmd)
+	alphaCmd.AddCommand(alphaClusterCmd)
+	alphaCmd.AddCommand(alphaTestCmd)
+	alphaCmd.AddCommand(alphaTopologyCmd)
+	RootCmd.AddCommand(alphaCmd)
+}
@@ -0,0 +1,87 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/client"
+)
+
+type clusterPauseOptions struct {
+	kubeconfig      string
+	targetNamespace string
+	waitTimeout     time.Duration
+}
+
+var clpo = &clusterPauseOptions{}
+
+var clusterPauseCmd = &cobra.Command{
+	Use:   "pause CLUSTER",
+	Short: "Pause the reconciliation of a Cluster",
+	Long: LongDesc(`
+		Pause the reconciliation of a Cluster.
+
+		The Cluster is paused by setting spec.paused; the core controllers then stop reconciling the objects
+		belonging to the Cluster and propagate the pause to the infrastructure, control plane and bootstrap objects
+		referenced by the Cluster, its Machines and its MachinePools by adding the cluster.x-k8s.io/paused annotation,
+		so the providers stop reconciling them too.
+
+		The command waits for the controllers to acknowledge the pause, i.e. for the annotation to be added to all
+		the provider objects, unless --wait-timeout is 0.`),
+
+	Example: Examples(`
+		# Pauses the Cluster foo.
+		clusterctl alpha cluster pause foo
+
+		# Pauses the Cluster foo in the "bar" namespace, without waiting for the acknowledgement of the controllers.
+		clusterctl alpha cluster pause foo --namespace=bar --wait-timeout=0`),
+
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runClusterPause(args[0])
+	},
+}
+
+func init() {
+	clusterPauseCmd.Flags().StringVarP(&clpo.kubeconfig, "kubeconfig", "", "", "Path to the kubeconfig file to use for accessing the management cluster. If empty, default rules for kubeconfig discovery will be used")
+	clusterPauseCmd.Flags().StringVarP(&clpo.targetNamespace, "namespace", "n", "", "The namespace where the Cluster lives. If not specified, the current namespace will be used")
+	clusterPauseCmd.Flags().DurationVarP(&clpo.waitTimeout, "wait-timeout", "", 2*time.Minute, "The maximum duration to wait for the controllers to acknowledge the pause. Use 0 for not waiting")
+
+	alphaClusterCmd.AddCommand(clusterPauseCmd)
+}
+
+func runClusterPause(name string) error {
+	c, err := client.New(cfgFile)
+	if err != nil {
+		return err
+	}
+
+	if err := c.PauseCluster(ctx, client.PauseClusterOptions{
+		Kubeconfig:  clpo.kubeconfig,
+		Namespace:   clpo.targetNamespace,
+		ClusterName: name,
+		Timeout:     clpo.waitTimeout,
+	}); err != nil {
+		return err
+	}
+
+	fmt.Printf("Cluster %s paused\n", name)
+	return nil
+}
@@ -0,0 +1,81 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/client"
+)
+
+type clusterResumeOptions struct {
+	kubeconfig      string
+	targetNamespace string
+	waitTimeout     time.Duration
+}
+
+var clro = &clusterResumeOptions{}
+
+var clusterResumeCmd = &cobra.Command{
+	Use:   "resume CLUSTER",
+	Short: "Resume the reconciliation of a Cluster",
+	Long: LongDesc(`
+		Resume the reconciliation of a Cluster paused by "clusterctl alpha cluster pause".
+
+		The Cluster is resumed by unsetting spec.paused; the core controllers then remove the cluster.x-k8s.io/paused
+		annotations they propagated to the provider objects, while the annotations added by users are left in place.
+
+		The command waits for the controllers to acknowledge the resume, unless --wait-timeout is 0.`),
+
+	Example: Examples(`
+		# Resumes the Cluster foo.
+		clusterctl alpha cluster resume foo`),
+
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runClusterResume(args[0])
+	},
+}
+
+func init() {
+	clusterResumeCmd.Flags().StringVarP(&clro.kubeconfig, "kubeconfig", "", "", "Path to the kubeconfig file to use for accessing the management cluster. If empty, default rules for kubeconfig discovery will be used")
+	clusterResumeCmd.Flags().StringVarP(&clro.targetNamespace, "namespace", "n", "", "The namespace where the Cluster lives. If not specified, the current namespace will be used")
+	clusterResumeCmd.Flags().DurationVarP(&clro.waitTimeout, "wait-timeout", "", 2*time.Minute, "The maximum duration to wait for the controllers to acknowledge the resume. Use 0 for not waiting")
+
+	alphaClusterCmd.AddCommand(clusterResumeCmd)
+}
+
+func runClusterResume(name string) error {
+	c, err := client.New(cfgFile)
+	if err != nil {
+		return err
+	}
+
+	if err := c.ResumeCluster(ctx, client.PauseClusterOptions{
+		Kubeconfig:  clro.kubeconfig,
+		Namespace:   clro.targetNamespace,
+		ClusterName: name,
+		Timeout:     clro.waitTimeout,
+	}); err != nil {
+		return err
+	}
+
+	fmt.Printf("Cluster %s resumed\n", name)
+	return nil
+}
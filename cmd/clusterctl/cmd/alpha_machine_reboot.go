@@ -0,0 +1,79 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/client"
+)
+
+type rebootMachineOptions struct {
+	kubeconfig      string
+	targetNamespace string
+}
+
+var rmo = &rebootMachineOptions{}
+
+var rebootMachineCmd = &cobra.Command{
+	Use:   "reboot NAME",
+	Short: "Reboot a Machine without replacing it",
+	Long: LongDesc(`
+		Reboot a Machine without replacing it.
+
+		The reboot is requested by setting the "cluster.x-k8s.io/action: reboot" annotation on the Machine, that is
+		handed over to the infrastructure provider; the reboot is performed asynchronously, and only by the
+		infrastructure providers implementing the action.`),
+
+	Example: Examples(`
+		# Reboots the Machine foo-md-0-abcde.
+		clusterctl alpha machine reboot foo-md-0-abcde
+
+		# Reboots the Machine foo-md-0-abcde in the "foo" namespace.
+		clusterctl alpha machine reboot foo-md-0-abcde --namespace=foo`),
+
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runRebootMachine(args[0])
+	},
+}
+
+func init() {
+	rebootMachineCmd.Flags().StringVarP(&rmo.kubeconfig, "kubeconfig", "", "", "Path to the kubeconfig file to use for accessing the management cluster. If empty, default rules for kubeconfig discovery will be used")
+	rebootMachineCmd.Flags().StringVarP(&rmo.targetNamespace, "namespace", "n", "", "The namespace where the Machine lives. If not specified, the current namespace will be used")
+
+	alphaMachineCmd.AddCommand(rebootMachineCmd)
+}
+
+func runRebootMachine(name string) error {
+	c, err := client.New(cfgFile)
+	if err != nil {
+		return err
+	}
+
+	if err := c.RebootMachine(ctx, client.RebootMachineOptions{
+		Kubeconfig: rmo.kubeconfig,
+		Namespace:  rmo.targetNamespace,
+		Machine:    name,
+	}); err != nil {
+		return err
+	}
+
+	fmt.Printf("Machine %s reboot requested\n", name)
+	return nil
+}
@@ -0,0 +1,101 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/client"
+)
+
+type deleteOrphansOptions struct {
+	kubeconfig      string
+	targetNamespace string
+	kind            string
+	all             bool
+}
+
+var doo = &deleteOrphansOptions{}
+
+var deleteOrphansCmd = &cobra.Command{
+	Use:   "delete [NAME...]",
+	Short: "Delete the infrastructure and bootstrap objects without a corresponding Machine/Cluster owner",
+	Long: LongDesc(`
+		Delete the infrastructure and bootstrap objects without a corresponding Machine/Cluster owner.
+
+		Only objects reported by "clusterctl alpha orphans list" can be deleted; each object is checked
+		again before deleting it, so objects adopted in the meantime are preserved.
+
+		Deleting an infrastructure object makes the infrastructure provider to release the corresponding
+		infrastructure, if any.
+
+		Orphaned Machines are not deleted by --all, because deleting a Machine drains and deletes its Node;
+		they must be deleted by name.`),
+
+	Example: Examples(`
+		# Deletes the orphaned AWSMachine foo-md-0-abcde in the "foo" namespace.
+		clusterctl alpha orphans delete foo-md-0-abcde --kind AWSMachine --namespace=foo
+
+		# Deletes all the orphaned objects in all the namespaces, except Machines.
+		clusterctl alpha orphans delete --all`),
+
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if !doo.all {
+			if doo.kind == "" || len(args) == 0 {
+				return errors.New("please specify the kind and the names of the orphaned objects to be deleted, or use --all")
+			}
+		} else if doo.kind != "" || len(args) > 0 {
+			return errors.New("the kind and the names of the orphaned objects can't be specified together with --all")
+		}
+
+		return runDeleteOrphans(args)
+	},
+}
+
+func init() {
+	deleteOrphansCmd.Flags().StringVarP(&doo.kubeconfig, "kubeconfig", "", "", "Path to the kubeconfig file to use for accessing the management cluster. If empty, default rules for kubeconfig discovery will be used")
+	deleteOrphansCmd.Flags().StringVarP(&doo.targetNamespace, "namespace", "n", "", "The namespace where to look for orphaned objects. If not specified, all the namespaces are checked")
+	deleteOrphansCmd.Flags().StringVarP(&doo.kind, "kind", "", "", "The kind of the orphaned objects to be deleted, e.g. AWSMachine")
+	deleteOrphansCmd.Flags().BoolVarP(&doo.all, "all", "", false, "Delete all the orphaned objects")
+
+	alphaOrphansCmd.AddCommand(deleteOrphansCmd)
+}
+
+func runDeleteOrphans(names []string) error {
+	c, err := client.New(cfgFile)
+	if err != nil {
+		return err
+	}
+
+	deleted, err := c.DeleteOrphans(ctx, client.DeleteOrphansOptions{
+		Kubeconfig: doo.kubeconfig,
+		Namespace:  doo.targetNamespace,
+		Kind:       doo.kind,
+		Names:      names,
+		All:        doo.all,
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, o := range deleted {
+		fmt.Printf("%s %s/%s deleted\n", o.Kind, o.Namespace, o.Name)
+	}
+	return nil
+}
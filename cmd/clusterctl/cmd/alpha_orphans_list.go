@@ -0,0 +1,101 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/client"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/printer"
+)
+
+type listOrphansOptions struct {
+	kubeconfig      string
+	targetNamespace string
+	wide            bool
+}
+
+var loo = &listOrphansOptions{}
+
+var listOrphansCmd = &cobra.Command{
+	Use:   "list",
+	Args:  cobra.NoArgs,
+	Short: "List the infrastructure and bootstrap objects without a corresponding Machine/Cluster owner",
+	Long: LongDesc(`
+		List the infrastructure and bootstrap objects without a corresponding Machine/Cluster owner, e.g. objects
+		leaked by failed operations.
+
+		An infrastructure or bootstrap object, or a bootstrap data secret, is orphaned if none of its owners exists
+		anymore or, if it has no owners, if the Cluster it is linked to by the cluster name label does not exist anymore.
+
+		Machines whose infrastructure object, or whose bootstrap config before the bootstrap data is available,
+		does not exist anymore are reported too.`),
+
+	Example: Examples(`
+		# Lists the orphaned objects in all the namespaces.
+		clusterctl alpha orphans list
+
+		# Lists the orphaned objects in the "foo" namespace.
+		clusterctl alpha orphans list --namespace=foo`),
+
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runListOrphans()
+	},
+}
+
+func init() {
+	listOrphansCmd.Flags().StringVarP(&loo.kubeconfig, "kubeconfig", "", "", "Path to the kubeconfig file to use for accessing the management cluster. If empty, default rules for kubeconfig discovery will be used")
+	listOrphansCmd.Flags().StringVarP(&loo.targetNamespace, "namespace", "n", "", "The namespace where to look for orphaned objects. If not specified, all the namespaces are checked")
+	listOrphansCmd.Flags().BoolVarP(&loo.wide, "wide", "", false, "Print the API version and the provider of each orphaned object")
+
+	alphaOrphansCmd.AddCommand(listOrphansCmd)
+}
+
+func runListOrphans() error {
+	c, err := client.New(cfgFile)
+	if err != nil {
+		return err
+	}
+
+	orphans, err := c.ListOrphans(ctx, client.ListOrphansOptions{
+		Kubeconfig: loo.kubeconfig,
+		Namespace:  loo.targetNamespace,
+	})
+	if err != nil {
+		return err
+	}
+
+	if len(orphans) == 0 {
+		fmt.Println("No orphaned objects found")
+		return nil
+	}
+
+	t := printer.NewTable(
+		printer.Column{Name: "NAMESPACE"},
+		printer.Column{Name: "KIND"},
+		printer.Column{Name: "NAME"},
+		printer.Column{Name: "REASON"},
+		printer.Column{Name: "API VERSION", Wide: true},
+		printer.Column{Name: "PROVIDER", Wide: true},
+	)
+	for _, o := range orphans {
+		t.AddRow(o.Namespace, o.Kind, o.Name, o.Reason, o.APIVersion, o.Provider)
+	}
+	return t.Print(os.Stdout, printOptions(loo.wide))
+}
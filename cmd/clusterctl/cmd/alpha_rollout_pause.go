@@ -0,0 +1,87 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/client"
+)
+
+type rolloutPauseOptions struct {
+	kubeconfig      string
+	targetNamespace string
+}
+
+var rpo = &rolloutPauseOptions{}
+
+var rolloutPauseCmd = &cobra.Command{
+	Use:   "pause KIND/NAME",
+	Short: "Pause the rollout of a MachineDeployment or a KubeadmControlPlane",
+	Long: LongDesc(`
+		Pause the rollout of a MachineDeployment or a KubeadmControlPlane.
+
+		While paused, the changes to the spec of the object are not rolled out to its Machines; this allows to
+		apply several changes and roll them out at once with "clusterctl alpha rollout resume".
+
+		A MachineDeployment is paused by setting spec.paused, a KubeadmControlPlane by adding the
+		cluster.x-k8s.io/paused annotation.`),
+
+	Example: Examples(`
+		# Pauses the MachineDeployment foo-md-0.
+		clusterctl alpha rollout pause machinedeployment/foo-md-0
+
+		# Pauses the KubeadmControlPlane foo-control-plane in the "foo" namespace.
+		clusterctl alpha rollout pause kubeadmcontrolplane/foo-control-plane --namespace=foo`),
+
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runRolloutPause(args[0])
+	},
+}
+
+func init() {
+	rolloutPauseCmd.Flags().StringVarP(&rpo.kubeconfig, "kubeconfig", "", "", "Path to the kubeconfig file to use for accessing the management cluster. If empty, default rules for kubeconfig discovery will be used")
+	rolloutPauseCmd.Flags().StringVarP(&rpo.targetNamespace, "namespace", "n", "", "The namespace where the object lives. If not specified, the current namespace will be used")
+
+	alphaRolloutCmd.AddCommand(rolloutPauseCmd)
+}
+
+func runRolloutPause(ref string) error {
+	kind, name, err := parseRolloutRef(ref)
+	if err != nil {
+		return err
+	}
+
+	c, err := client.New(cfgFile)
+	if err != nil {
+		return err
+	}
+
+	if err := c.RolloutPause(ctx, client.RolloutPauseOptions{
+		Kubeconfig: rpo.kubeconfig,
+		Namespace:  rpo.targetNamespace,
+		Kind:       kind,
+		Name:       name,
+	}); err != nil {
+		return err
+	}
+
+	fmt.Printf("%s %s paused\n", kind, name)
+	return nil
+}
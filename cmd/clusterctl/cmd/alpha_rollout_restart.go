@@ -0,0 +1,107 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/client"
+)
+
+type rolloutRestartOptions struct {
+	kubeconfig      string
+	targetNamespace string
+}
+
+var rro = &rolloutRestartOptions{}
+
+var rolloutRestartCmd = &cobra.Command{
+	Use:   "restart KIND/NAME",
+	Short: "Restart a MachineDeployment or a KubeadmControlPlane",
+	Long: LongDesc(`
+		Restart a MachineDeployment or a KubeadmControlPlane.
+
+		All the Machines are replaced with a rolling update, even if the spec of the object did not change; this
+		allows to pick up e.g. rebuilt machine images or rotated bootstrap secrets.
+
+		The rollout is triggered by setting spec.rolloutAfter on a MachineDeployment, or spec.upgradeAfter on a
+		KubeadmControlPlane, to the current time; the Machines are replaced asynchronously by the controllers
+		following the rollout strategy of the object.`),
+
+	Example: Examples(`
+		# Restarts the MachineDeployment foo-md-0.
+		clusterctl alpha rollout restart machinedeployment/foo-md-0
+
+		# Restarts the KubeadmControlPlane foo-control-plane in the "foo" namespace.
+		clusterctl alpha rollout restart kubeadmcontrolplane/foo-control-plane --namespace=foo`),
+
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runRolloutRestart(args[0])
+	},
+}
+
+func init() {
+	rolloutRestartCmd.Flags().StringVarP(&rro.kubeconfig, "kubeconfig", "", "", "Path to the kubeconfig file to use for accessing the management cluster. If empty, default rules for kubeconfig discovery will be used")
+	rolloutRestartCmd.Flags().StringVarP(&rro.targetNamespace, "namespace", "n", "", "The namespace where the object lives. If not specified, the current namespace will be used")
+
+	alphaRolloutCmd.AddCommand(rolloutRestartCmd)
+}
+
+func runRolloutRestart(ref string) error {
+	kind, name, err := parseRolloutRef(ref)
+	if err != nil {
+		return err
+	}
+
+	c, err := client.New(cfgFile)
+	if err != nil {
+		return err
+	}
+
+	restartedAt, err := c.RolloutRestart(ctx, client.RolloutRestartOptions{
+		Kubeconfig: rro.kubeconfig,
+		Namespace:  rro.targetNamespace,
+		Kind:       kind,
+		Name:       name,
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%s %s restarted at %s\n", kind, name, restartedAt.Format(time.RFC3339))
+	return nil
+}
+
+// parseRolloutRef parses a KIND/NAME reference, where KIND is case insensitive.
+func parseRolloutRef(ref string) (string, string, error) {
+	parts := strings.Split(ref, "/")
+	if len(parts) != 2 || parts[1] == "" {
+		return "", "", errors.Errorf("invalid reference %q, it must be in the KIND/NAME format", ref)
+	}
+
+	for _, kind := range []string{client.MachineDeploymentKind, client.KubeadmControlPlaneKind} {
+		if strings.EqualFold(parts[0], kind) {
+			return kind, parts[1], nil
+		}
+	}
+	return "", "", errors.Errorf("invalid kind %q, only %s and %s objects are supported", parts[0], client.MachineDeploymentKind, client.KubeadmControlPlaneKind)
+}
@@ -0,0 +1,83 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/client"
+)
+
+type rolloutResumeOptions struct {
+	kubeconfig      string
+	targetNamespace string
+}
+
+var rreo = &rolloutResumeOptions{}
+
+var rolloutResumeCmd = &cobra.Command{
+	Use:   "resume KIND/NAME",
+	Short: "Resume the rollout of a paused MachineDeployment or KubeadmControlPlane",
+	Long: LongDesc(`
+		Resume the rollout of a MachineDeployment or a KubeadmControlPlane paused with
+		"clusterctl alpha rollout pause"; the changes to the spec of the object applied while it was paused are
+		rolled out to its Machines.`),
+
+	Example: Examples(`
+		# Resumes the MachineDeployment foo-md-0.
+		clusterctl alpha rollout resume machinedeployment/foo-md-0
+
+		# Resumes the KubeadmControlPlane foo-control-plane in the "foo" namespace.
+		clusterctl alpha rollout resume kubeadmcontrolplane/foo-control-plane --namespace=foo`),
+
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runRolloutResume(args[0])
+	},
+}
+
+func init() {
+	rolloutResumeCmd.Flags().StringVarP(&rreo.kubeconfig, "kubeconfig", "", "", "Path to the kubeconfig file to use for accessing the management cluster. If empty, default rules for kubeconfig discovery will be used")
+	rolloutResumeCmd.Flags().StringVarP(&rreo.targetNamespace, "namespace", "n", "", "The namespace where the object lives. If not specified, the current namespace will be used")
+
+	alphaRolloutCmd.AddCommand(rolloutResumeCmd)
+}
+
+func runRolloutResume(ref string) error {
+	kind, name, err := parseRolloutRef(ref)
+	if err != nil {
+		return err
+	}
+
+	c, err := client.New(cfgFile)
+	if err != nil {
+		return err
+	}
+
+	if err := c.RolloutResume(ctx, client.RolloutPauseOptions{
+		Kubeconfig: rreo.kubeconfig,
+		Namespace:  rreo.targetNamespace,
+		Kind:       kind,
+		Name:       name,
+	}); err != nil {
+		return err
+	}
+
+	fmt.Printf("%s %s resumed\n", kind, name)
+	return nil
+}
@@ -0,0 +1,92 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/client"
+)
+
+type rolloutUndoOptions struct {
+	kubeconfig      string
+	targetNamespace string
+	toRevision      int64
+}
+
+var ruo = &rolloutUndoOptions{}
+
+var rolloutUndoCmd = &cobra.Command{
+	Use:   "undo KIND/NAME",
+	Short: "Roll back a MachineDeployment to a previous revision",
+	Long: LongDesc(`
+		Roll back a MachineDeployment to a previous revision.
+
+		Each time the Machine template of a MachineDeployment changes, a new MachineSet is created with an
+		increasing revision number, and the old MachineSets are kept up to spec.revisionHistoryLimit; the Machine
+		template of the MachineSet with the given revision, or of the previous one by default, is copied back to
+		the MachineDeployment, which triggers a rollout.
+
+		KubeadmControlPlanes don't keep the history of their revisions, and can't be rolled back.`),
+
+	Example: Examples(`
+		# Rolls back the MachineDeployment foo-md-0 to the previous revision.
+		clusterctl alpha rollout undo machinedeployment/foo-md-0
+
+		# Rolls back the MachineDeployment foo-md-0 in the "foo" namespace to revision 2.
+		clusterctl alpha rollout undo machinedeployment/foo-md-0 --namespace=foo --to-revision=2`),
+
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runRolloutUndo(args[0])
+	},
+}
+
+func init() {
+	rolloutUndoCmd.Flags().StringVarP(&ruo.kubeconfig, "kubeconfig", "", "", "Path to the kubeconfig file to use for accessing the management cluster. If empty, default rules for kubeconfig discovery will be used")
+	rolloutUndoCmd.Flags().StringVarP(&ruo.targetNamespace, "namespace", "n", "", "The namespace where the object lives. If not specified, the current namespace will be used")
+	rolloutUndoCmd.Flags().Int64VarP(&ruo.toRevision, "to-revision", "", 0, "The revision to roll back to. If 0, the previous revision will be used")
+
+	alphaRolloutCmd.AddCommand(rolloutUndoCmd)
+}
+
+func runRolloutUndo(ref string) error {
+	kind, name, err := parseRolloutRef(ref)
+	if err != nil {
+		return err
+	}
+
+	c, err := client.New(cfgFile)
+	if err != nil {
+		return err
+	}
+
+	revision, err := c.RolloutUndo(ctx, client.RolloutUndoOptions{
+		Kubeconfig: ruo.kubeconfig,
+		Namespace:  ruo.targetNamespace,
+		Kind:       kind,
+		Name:       name,
+		ToRevision: ruo.toRevision,
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%s %s rolled back to revision %d\n", kind, name, revision)
+	return nil
+}
@@ -0,0 +1,134 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/client"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/printer"
+)
+
+type simulateScaleOptions struct {
+	kubeconfig        string
+	targetNamespace   string
+	machineDeployment string
+	replicas          int32
+}
+
+var sso = &simulateScaleOptions{}
+
+var simulateScaleCmd = &cobra.Command{
+	Use:   "scale",
+	Short: "Simulate scaling a MachineDeployment",
+	Long: LongDesc(`
+		Simulate scaling a MachineDeployment, without changing its replicas.
+
+		The simulation reports the machines to be created in each failure domain, and warns if the scale operation
+		exceeds the quota reported by the infrastructure provider or violates the bounds defined for the cluster autoscaler.`),
+
+	Example: Examples(`
+		# Simulates scaling the MachineDeployment md-0 to 5 replicas.
+		clusterctl alpha simulate scale --machinedeployment md-0 --replicas 5
+
+		# Simulates scaling the MachineDeployment md-0 in the "foo" namespace to 5 replicas.
+		clusterctl alpha simulate scale --machinedeployment md-0 --replicas 5 --namespace=foo`),
+
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if sso.machineDeployment == "" {
+			return errors.New("please specify the MachineDeployment to be scaled using --machinedeployment")
+		}
+		if sso.replicas < 0 {
+			return errors.New("please specify the number of replicas using --replicas")
+		}
+
+		return runSimulateScale()
+	},
+}
+
+func init() {
+	simulateScaleCmd.Flags().StringVarP(&sso.kubeconfig, "kubeconfig", "", "", "Path to the kubeconfig file to use for accessing the management cluster. If empty, default rules for kubeconfig discovery will be used")
+	simulateScaleCmd.Flags().StringVarP(&sso.targetNamespace, "namespace", "n", "", "The namespace where the MachineDeployment lives. If not specified, the current namespace will be used")
+	simulateScaleCmd.Flags().StringVarP(&sso.machineDeployment, "machinedeployment", "", "", "The name of the MachineDeployment to be scaled")
+	simulateScaleCmd.Flags().Int32VarP(&sso.replicas, "replicas", "", -1, "The number of replicas the MachineDeployment should be scaled to")
+
+	alphaSimulateCmd.AddCommand(simulateScaleCmd)
+}
+
+func runSimulateScale() error {
+	c, err := client.New(cfgFile)
+	if err != nil {
+		return err
+	}
+
+	simulation, err := c.SimulateScale(ctx, client.SimulateScaleOptions{
+		Kubeconfig:        sso.kubeconfig,
+		Namespace:         sso.targetNamespace,
+		MachineDeployment: sso.machineDeployment,
+		Replicas:          sso.replicas,
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Scaling MachineDeployment %s/%s from %d to %d replicas\n", simulation.Namespace, simulation.Name, simulation.CurrentReplicas, simulation.Replicas)
+	if simulation.AvailableMachines != nil {
+		fmt.Printf("Available machines reported by the infrastructure provider: %d\n", *simulation.AvailableMachines)
+	}
+	fmt.Println()
+
+	t := printer.NewTable(
+		printer.Column{Name: "FAILURE DOMAIN"},
+		printer.Column{Name: "MACHINES"},
+		printer.Column{Name: "NEW MACHINES", Color: newMachinesColor},
+	)
+	for _, f := range simulation.FailureDomains {
+		name := f.Name
+		if name == "" {
+			name = "<none>"
+		}
+		t.AddRow(name, strconv.Itoa(f.Machines), strconv.Itoa(f.NewMachines))
+	}
+	options := printOptions(false)
+	if err := t.Print(os.Stdout, options); err != nil {
+		return err
+	}
+
+	if len(simulation.Warnings) > 0 {
+		fmt.Println()
+		warningColor := printer.Yellow
+		if options.NoColor {
+			warningColor = printer.NoColor
+		}
+		for _, w := range simulation.Warnings {
+			fmt.Println(printer.Colorize("Warning: "+w, warningColor))
+		}
+	}
+	return nil
+}
+
+// newMachinesColor highlights the failure domains where new machines are going to be created.
+func newMachinesColor(value string) printer.Color {
+	if value == "0" {
+		return printer.NoColor
+	}
+	return printer.Green
+}
@@ -0,0 +1,103 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/client"
+)
+
+type supportBundleOptions struct {
+	kubeconfig      string
+	clusterName     string
+	targetNamespace string
+	since           time.Duration
+	output          string
+}
+
+var sbo = &supportBundleOptions{}
+
+var supportBundleCmd = &cobra.Command{
+	Use:   "support-bundle",
+	Args:  cobra.NoArgs,
+	Short: "Collect the information required for troubleshooting a workload cluster into a tarball",
+	Long: LongDesc(`
+		Collect the information required for troubleshooting a workload cluster into a gzipped tarball, that is
+		the objects belonging to the Cluster, the related events, the versions of the Cluster and of the providers
+		responsible for it, and the logs of the providers.
+
+		The objects are sanitized before being written, by removing the data of the Secrets and the content of the
+		files passed to the bootstrap providers; a manifest.yaml file in the tarball lists the collected files and
+		the errors that prevented to collect part of the information.`),
+
+	Example: Examples(`
+		# Collects a support bundle for the "my-cluster" Cluster in the current namespace.
+		clusterctl alpha support-bundle --cluster=my-cluster
+
+		# Collects a support bundle for the "my-cluster" Cluster in the "foo" namespace, with the logs of the last hour.
+		clusterctl alpha support-bundle --cluster=my-cluster --namespace=foo --since=1h --output=bundle.tar.gz`),
+
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runSupportBundle()
+	},
+}
+
+func init() {
+	supportBundleCmd.Flags().StringVarP(&sbo.kubeconfig, "kubeconfig", "", "", "Path to the kubeconfig file to use for accessing the management cluster. If empty, default rules for kubeconfig discovery will be used")
+	supportBundleCmd.Flags().StringVarP(&sbo.clusterName, "cluster", "", "", "The name of the Cluster to collect the support bundle for")
+	supportBundleCmd.Flags().StringVarP(&sbo.targetNamespace, "namespace", "n", "", "The namespace where the Cluster lives. If not specified, the current namespace will be used")
+	supportBundleCmd.Flags().DurationVarP(&sbo.since, "since", "", 0, "Only collect the provider logs newer than a relative duration like 5s, 2m, or 3h. Defaults to all logs")
+	supportBundleCmd.Flags().StringVarP(&sbo.output, "output", "o", "", "Path of the tarball to write the support bundle to. Defaults to <cluster>-support-bundle.tar.gz")
+	_ = supportBundleCmd.MarkFlagRequired("cluster")
+
+	alphaCmd.AddCommand(supportBundleCmd)
+}
+
+func runSupportBundle() error {
+	c, err := client.New(cfgFile)
+	if err != nil {
+		return err
+	}
+
+	output := sbo.output
+	if output == "" {
+		output = fmt.Sprintf("%s-support-bundle.tar.gz", sbo.clusterName)
+	}
+
+	manifest, err := c.SupportBundle(ctx, client.SupportBundleOptions{
+		Kubeconfig:  sbo.kubeconfig,
+		ClusterName: sbo.clusterName,
+		Namespace:   sbo.targetNamespace,
+		LogsSince:   sbo.since,
+		OutputFile:  output,
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Support bundle for Cluster %s/%s written to %s\n", manifest.Namespace, manifest.Name, output)
+	if len(manifest.Errors) > 0 {
+		fmt.Println("\nSome information could not be collected:")
+		for _, e := range manifest.Errors {
+			fmt.Printf("  - %s\n", e)
+		}
+	}
+	return nil
+}
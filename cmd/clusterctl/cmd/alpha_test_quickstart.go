@@ -0,0 +1,143 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/client"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/printer"
+)
+
+type testQuickstartOptions struct {
+	kubeconfig               string
+	infrastructureProvider   string
+	flavor                   string
+	targetNamespace          string
+	clusterName              string
+	kubernetesVersion        string
+	controlPlaneMachineCount int
+	workerMachineCount       int
+	upgradeToVersion         string
+	scaleWorkersBy           int32
+	stepTimeout              time.Duration
+	skipCleanup              bool
+}
+
+var tqo = &testQuickstartOptions{}
+
+var testQuickstartCmd = &cobra.Command{
+	Use:   "quickstart",
+	Args:  cobra.NoArgs,
+	Short: "Run a create-upgrade-scale-delete cycle of a workload cluster",
+	Long: LongDesc(`
+		Run a create-upgrade-scale-delete cycle of a workload cluster, as a smoke test for new provider releases.
+
+		The workload cluster is created from the cluster template of the infrastructure provider, like with
+		"clusterctl config cluster"; the command then waits for all the Machines to be running, optionally upgrades
+		the workload cluster to a new Kubernetes version and scales out its first MachineDeployment, and finally
+		deletes the workload cluster, reporting the duration and the outcome of each step.
+
+		The steps following a failed step are skipped, except for the deletion of the workload cluster; use
+		--skip-cleanup to keep the workload cluster for investigating failures.`),
+
+	Example: Examples(`
+		# Runs the quickstart test using the default cluster template of the aws provider.
+		clusterctl alpha test quickstart --infrastructure aws --kubernetes-version v1.17.3
+
+		# Runs the quickstart test including the upgrade of the workload cluster to Kubernetes v1.18.0.
+		clusterctl alpha test quickstart --infrastructure aws --kubernetes-version v1.17.3 --upgrade-to-version v1.18.0`),
+
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runTestQuickstart()
+	},
+}
+
+func init() {
+	testQuickstartCmd.Flags().StringVarP(&tqo.kubeconfig, "kubeconfig", "", "", "Path to the kubeconfig file to use for accessing the management cluster. If empty, default rules for kubeconfig discovery will be used")
+	testQuickstartCmd.Flags().StringVarP(&tqo.infrastructureProvider, "infrastructure", "i", "", "The infrastructure provider to be tested; its cluster template is used for creating the workload cluster")
+	testQuickstartCmd.Flags().StringVarP(&tqo.flavor, "flavor", "f", "", "The workload cluster template variant to be used. By default (empty), the default cluster template will be used")
+	testQuickstartCmd.Flags().StringVarP(&tqo.targetNamespace, "target-namespace", "n", "", "The namespace where the workload cluster should be created. If not specified, the current namespace will be used")
+	testQuickstartCmd.Flags().StringVarP(&tqo.clusterName, "cluster-name", "", "quickstart", "The name of the workload cluster")
+	testQuickstartCmd.Flags().StringVarP(&tqo.kubernetesVersion, "kubernetes-version", "", "", "The Kubernetes version to use for the workload cluster. By default (empty), the value from os env variables or the .cluster-api/clusterctl.yaml config file will be used")
+	testQuickstartCmd.Flags().IntVarP(&tqo.controlPlaneMachineCount, "control-plane-machine-count", "", 1, "The number of control plane machines of the workload cluster")
+	testQuickstartCmd.Flags().IntVarP(&tqo.workerMachineCount, "worker-machine-count", "", 1, "The number of worker machines of the workload cluster")
+	testQuickstartCmd.Flags().StringVarP(&tqo.upgradeToVersion, "upgrade-to-version", "", "", "The Kubernetes version the workload cluster is upgraded to. If empty, the upgrade step is skipped")
+	testQuickstartCmd.Flags().Int32VarP(&tqo.scaleWorkersBy, "scale-workers-by", "", 1, "The number of worker machines added to the workload cluster. If zero, the scale step is skipped")
+	testQuickstartCmd.Flags().DurationVarP(&tqo.stepTimeout, "step-timeout", "", 30*time.Minute, "The maximum duration of each step")
+	testQuickstartCmd.Flags().BoolVarP(&tqo.skipCleanup, "skip-cleanup", "", false, "Keep the workload cluster at the end of the test")
+
+	alphaTestCmd.AddCommand(testQuickstartCmd)
+}
+
+func runTestQuickstart() error {
+	if tqo.infrastructureProvider == "" {
+		return errors.New("please specify the infrastructure provider to be tested using --infrastructure")
+	}
+
+	c, err := client.New(cfgFile)
+	if err != nil {
+		return err
+	}
+
+	steps, err := c.TestQuickstart(ctx, client.TestQuickstartOptions{
+		Kubeconfig:               tqo.kubeconfig,
+		InfrastructureProvider:   tqo.infrastructureProvider,
+		Flavor:                   tqo.flavor,
+		TargetNamespace:          tqo.targetNamespace,
+		ClusterName:              tqo.clusterName,
+		KubernetesVersion:        tqo.kubernetesVersion,
+		ControlPlaneMachineCount: tqo.controlPlaneMachineCount,
+		WorkerMachineCount:       tqo.workerMachineCount,
+		UpgradeToVersion:         tqo.upgradeToVersion,
+		ScaleWorkersBy:           tqo.scaleWorkersBy,
+		StepTimeout:              tqo.stepTimeout,
+		SkipCleanup:              tqo.skipCleanup,
+	})
+	if err != nil {
+		return err
+	}
+
+	t := printer.NewTable(
+		printer.Column{Name: "STEP"},
+		printer.Column{Name: "DURATION"},
+		printer.Column{Name: "RESULT"},
+		printer.Column{Name: "ERROR"},
+	)
+	failures := 0
+	for _, s := range steps {
+		result, message := "Passed", ""
+		if s.Error != nil {
+			result, message = "Failed", s.Error.Error()
+			failures++
+		}
+		t.AddRow(s.Name, s.Duration.Round(time.Second).String(), result, message)
+	}
+	if err := t.Print(os.Stdout, printOptions(false)); err != nil {
+		return err
+	}
+
+	if failures > 0 {
+		return errors.Errorf("quickstart test of the %s provider failed", tqo.infrastructureProvider)
+	}
+	fmt.Printf("\nQuickstart test of the %s provider passed\n", tqo.infrastructureProvider)
+	return nil
+}
@@ -0,0 +1,123 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/util/diff"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/client"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/printer"
+)
+
+type topologyPlanOptions struct {
+	kubeconfig      string
+	targetNamespace string
+	files           []string
+}
+
+var tpo = &topologyPlanOptions{}
+
+var topologyPlanCmd = &cobra.Command{
+	Use:   "plan",
+	Args:  cobra.NoArgs,
+	Short: "Preview the changes to the managed topologies of the Clusters for a proposed Cluster or ClusterClass change",
+	Long: LongDesc(`
+		Preview the changes to the managed topologies of the Clusters for a proposed Cluster or ClusterClass change.
+
+		The objects the topology controller is going to create, update and delete are computed by running the
+		topology controller against an in-memory copy of the objects of the management cluster, so the management
+		cluster is not changed; this allows to review the changes, e.g. in CI, before applying them.
+
+		The files can contain Clusters, ClusterClasses and the templates they reference; when a ClusterClass is
+		provided, all the Clusters using it are planned.`),
+
+	Example: Examples(`
+		# Previews the changes to the topology of a Cluster.
+		clusterctl alpha topology plan -f my-cluster.yaml
+
+		# Previews the changes to the topologies of all the Clusters using a ClusterClass in the "foo" namespace.
+		clusterctl alpha topology plan -f my-cluster-class.yaml --namespace=foo`),
+
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runTopologyPlan()
+	},
+}
+
+func init() {
+	topologyPlanCmd.Flags().StringVarP(&tpo.kubeconfig, "kubeconfig", "", "", "Path to the kubeconfig file to use for accessing the management cluster. If empty, default rules for kubeconfig discovery will be used")
+	topologyPlanCmd.Flags().StringVarP(&tpo.targetNamespace, "namespace", "n", "", "The namespace used for the objects without a namespace. If not specified, the current namespace will be used")
+	topologyPlanCmd.Flags().StringSliceVarP(&tpo.files, "file", "f", nil, "Path to the files with the proposed Clusters and ClusterClasses")
+	_ = topologyPlanCmd.MarkFlagRequired("file")
+
+	alphaTopologyCmd.AddCommand(topologyPlanCmd)
+}
+
+func runTopologyPlan() error {
+	c, err := client.New(cfgFile)
+	if err != nil {
+		return err
+	}
+
+	plan, err := c.TopologyPlan(ctx, client.TopologyPlanOptions{
+		Kubeconfig: tpo.kubeconfig,
+		Namespace:  tpo.targetNamespace,
+		Files:      tpo.files,
+	})
+	if err != nil {
+		return err
+	}
+
+	if len(plan.Clusters) == 0 {
+		fmt.Println("No Clusters with a managed topology affected by the proposed change")
+		return nil
+	}
+
+	t := printer.NewTable(
+		printer.Column{Name: "CLUSTER"},
+		printer.Column{Name: "OPERATION"},
+		printer.Column{Name: "KIND"},
+		printer.Column{Name: "NAME"},
+	)
+	for _, cluster := range plan.Clusters {
+		clusterName := fmt.Sprintf("%s/%s", cluster.Namespace, cluster.Name)
+		if len(cluster.Created)+len(cluster.Updated)+len(cluster.Deleted) == 0 {
+			t.AddRow(clusterName, "none", "", "")
+		}
+		for _, o := range cluster.Created {
+			t.AddRow(clusterName, "create", o.GetKind(), o.GetName())
+		}
+		for _, u := range cluster.Updated {
+			t.AddRow(clusterName, "update", u.After.GetKind(), u.After.GetName())
+		}
+		for _, o := range cluster.Deleted {
+			t.AddRow(clusterName, "delete", o.GetKind(), o.GetName())
+		}
+	}
+	if err := t.Print(os.Stdout, printOptions(false)); err != nil {
+		return err
+	}
+
+	for _, cluster := range plan.Clusters {
+		for _, u := range cluster.Updated {
+			fmt.Printf("\n%s %s/%s:\n%s\n", u.After.GetKind(), u.After.GetNamespace(), u.After.GetName(), diff.ObjectReflectDiff(u.Before.Object, u.After.Object))
+		}
+	}
+	return nil
+}
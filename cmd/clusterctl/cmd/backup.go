@@ -0,0 +1,77 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/client"
+)
+
+type backupOptions struct {
+	kubeconfig string
+	namespace  string
+	directory  string
+}
+
+var bo = &backupOptions{}
+
+var backupCmd = &cobra.Command{
+	Use:   "backup",
+	Short: "Saves Cluster API objects (e.g. Cluster, Machines) from a management cluster to a directory",
+	Long: LongDesc(`
+		Saves Cluster API objects (e.g. Cluster, Machines) from a management cluster to a directory.
+
+		The backup includes all the objects of the types defined by the providers installed by clusterctl, and
+		the Secrets and ConfigMaps belonging to a Cluster. Clusters are paused while the backup is taken.`),
+
+	Example: Examples(`
+		# Saves the Cluster API objects existing in all the namespaces to the my-backup directory.
+		clusterctl backup --directory=my-backup
+
+		# Saves the Cluster API objects existing in the foo namespace to the my-backup directory.
+		clusterctl backup --directory=my-backup --namespace=foo`),
+
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if bo.directory == "" {
+			return errors.New("please specify a directory for the backup using the --directory flag")
+		}
+
+		return runBackup()
+	},
+}
+
+func init() {
+	backupCmd.Flags().StringVarP(&bo.kubeconfig, "kubeconfig", "", "", "Path to the kubeconfig file to use for accessing the management cluster. If empty, default rules for kubeconfig discovery will be used")
+	backupCmd.Flags().StringVarP(&bo.namespace, "namespace", "n", "", "The namespace where the objects to be saved exists. If not specified, the objects in all the namespaces are saved")
+	backupCmd.Flags().StringVarP(&bo.directory, "directory", "d", "", "The directory where the backup is written")
+
+	RootCmd.AddCommand(backupCmd)
+}
+
+func runBackup() error {
+	c, err := client.New(cfgFile)
+	if err != nil {
+		return err
+	}
+
+	return c.Backup(ctx, client.BackupOptions{
+		Kubeconfig: bo.kubeconfig,
+		Namespace:  bo.namespace,
+		Directory:  bo.directory,
+	})
+}
@@ -19,10 +19,12 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/client"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/printer"
 )
 
 type configClusterOptions struct {
@@ -41,6 +43,11 @@ type configClusterOptions struct {
 	configMapDataKey   string
 
 	listVariables bool
+	exportSchema  bool
+	listFlavors   bool
+
+	validate              bool
+	validateWithProviders []string
 }
 
 var cc = &configClusterOptions{}
@@ -84,10 +91,28 @@ var configClusterClusterCmd = &cobra.Command{
 		clusterctl config cluster my-cluster --from https://github.com/foo-org/foo-repository/blob/master/cluster-template.yaml
 
 		# Generates a yaml file for creating a Cluster API workload cluster using a template hosted on the local file system
-		clusterctl config cluster my-cluster --from ~/workspace/cluster-template.yaml`),
+		clusterctl config cluster my-cluster --from ~/workspace/cluster-template.yaml
+
+		# Generates a yaml file for creating a Cluster API workload cluster, validating the generated objects against
+		# the CustomResourceDefinitions of the providers, including the AWS infrastructure provider v0.5.0
+		clusterctl config cluster my-cluster --from ~/workspace/cluster-template.yaml --validate --validate-with=aws:v0.5.0
+
+		# Prints the JSON Schema of the variables of the "ha" flavor of the default infrastructure provider's templates.
+		clusterctl config cluster my-cluster --flavor ha --export-schema
+
+		# Lists the flavors of the templates of the AWS infrastructure provider v0.5.0, with the variables they require.
+		clusterctl config cluster --infrastructure=aws:v0.5.0 --list-flavors`),
 
-	Args: cobra.ExactArgs(1),
+	Args: func(cmd *cobra.Command, args []string) error {
+		if cc.listFlavors {
+			return cobra.MaximumNArgs(1)(cmd, args)
+		}
+		return cobra.ExactArgs(1)(cmd, args)
+	},
 	RunE: func(cmd *cobra.Command, args []string) error {
+		if cc.listFlavors {
+			return runListFlavors()
+		}
 		return runGetClusterTemplate(args[0])
 	},
 }
@@ -115,6 +140,10 @@ func init() {
 
 	// other flags
 	configClusterClusterCmd.Flags().BoolVarP(&cc.listVariables, "list-variables", "", false, "Returns the list of variables expected by the template instead of the template yaml")
+	configClusterClusterCmd.Flags().BoolVarP(&cc.exportSchema, "export-schema", "", false, "Returns the JSON Schema of the variables expected by the template, including their types, defaults and descriptions, instead of the template yaml")
+	configClusterClusterCmd.Flags().BoolVarP(&cc.listFlavors, "list-flavors", "", false, "Lists the flavors of the workload cluster templates published by the infrastructure provider, with the variables they require, instead of the template yaml")
+	configClusterClusterCmd.Flags().BoolVarP(&cc.validate, "validate", "", false, "Validates the generated objects against the CustomResourceDefinitions of the core, kubeadm and infrastructure providers, read from the provider repositories")
+	configClusterClusterCmd.Flags().StringSliceVarP(&cc.validateWithProviders, "validate-with", "", nil, "Additional providers and versions (e.g. aws:v0.5.0) whose CustomResourceDefinitions are used for validating the generated objects")
 
 	configCmd.AddCommand(configClusterClusterCmd)
 }
@@ -132,7 +161,9 @@ func runGetClusterTemplate(name string) error {
 		KubernetesVersion:        cc.kubernetesVersion,
 		ControlPlaneMachineCount: cc.controlPlaneMachineCount,
 		WorkerMachineCount:       cc.workerMachineCount,
-		ListVariablesOnly:        cc.listVariables,
+		ListVariablesOnly:        cc.listVariables || cc.exportSchema,
+		Validate:                 cc.validate,
+		ValidateWithProviders:    cc.validateWithProviders,
 	}
 
 	if cc.url != "" {
@@ -156,11 +187,15 @@ func runGetClusterTemplate(name string) error {
 		}
 	}
 
-	template, err := c.GetClusterTemplate(templateOptions)
+	template, err := c.GetClusterTemplate(ctx, templateOptions)
 	if err != nil {
 		return err
 	}
 
+	if cc.exportSchema {
+		return templateVariablesSchemaOutput(template)
+	}
+
 	if cc.listVariables {
 		return templateListVariablesOutput(template)
 	}
@@ -168,6 +203,34 @@ func runGetClusterTemplate(name string) error {
 	return templateYAMLOutput(template)
 }
 
+func runListFlavors() error {
+	c, err := client.New(cfgFile)
+	if err != nil {
+		return err
+	}
+
+	flavors, err := c.ListFlavors(ctx, client.ListFlavorsOptions{
+		Kubeconfig:             cc.kubeconfig,
+		InfrastructureProvider: cc.infrastructureProvider,
+	})
+	if err != nil {
+		return err
+	}
+
+	t := printer.NewTable(
+		printer.Column{Name: "FLAVOR"},
+		printer.Column{Name: "VARIABLES"},
+	)
+	for _, f := range flavors {
+		name := f.Name
+		if name == "" {
+			name = "<default>"
+		}
+		t.AddRow(name, strings.Join(f.Variables, ", "))
+	}
+	return t.Print(os.Stdout, printOptions(false))
+}
+
 func templateListVariablesOutput(template client.Template) error {
 	if len(template.Variables()) > 0 {
 		fmt.Println("Variables:")
@@ -179,6 +242,19 @@ func templateListVariablesOutput(template client.Template) error {
 	return nil
 }
 
+func templateVariablesSchemaOutput(template client.Template) error {
+	schema, err := template.VariablesSchema()
+	if err != nil {
+		return err
+	}
+	schema = append(schema, '\n')
+
+	if _, err := os.Stdout.Write(schema); err != nil {
+		return errors.Wrap(err, "failed to write the variables schema to Stdout")
+	}
+	return nil
+}
+
 func templateYAMLOutput(template client.Template) error {
 	yaml, err := template.Yaml()
 	if err != nil {
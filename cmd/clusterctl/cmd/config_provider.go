@@ -83,7 +83,7 @@ func runGetComponents(providerName, targetNamespace, watchingNamespace, output s
 		return err
 	}
 
-	components, err := c.GetProviderComponents(providerName, targetNamespace, watchingNamespace)
+	components, err := c.GetProviderComponents(ctx, providerName, targetNamespace, watchingNamespace)
 	if err != nil {
 		return err
 	}
@@ -17,12 +17,11 @@ limitations under the License.
 package cmd
 
 import (
-	"fmt"
 	"os"
-	"text/tabwriter"
 
 	"github.com/spf13/cobra"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/client"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/printer"
 )
 
 var configRepositoryCmd = &cobra.Command{
@@ -54,17 +53,18 @@ func runGetRepositories() error {
 		return err
 	}
 
-	repositoryList, err := c.GetProvidersConfig()
+	repositoryList, err := c.GetProvidersConfig(ctx)
 	if err != nil {
 		return err
 	}
 
-	w := tabwriter.NewWriter(os.Stdout, 10, 4, 3, ' ', 0)
-	fmt.Fprintln(w, "NAME\tTYPE\tURL")
+	t := printer.NewTable(
+		printer.Column{Name: "NAME"},
+		printer.Column{Name: "TYPE"},
+		printer.Column{Name: "URL"},
+	)
 	for _, r := range repositoryList {
-		fmt.Fprintf(w, "%s\t%s\t%s\n", r.Name(), r.Type(), r.URL())
+		t.AddRow(r.Name(), string(r.Type()), r.URL())
 	}
-	w.Flush()
-
-	return nil
+	return t.Print(os.Stdout, printOptions(false))
 }
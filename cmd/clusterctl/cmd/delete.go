@@ -17,9 +17,19 @@ limitations under the License.
 package cmd
 
 import (
+	"bufio"
+	"fmt"
+	goio "io"
+	"os"
+	"strings"
+
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/client"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/printer"
 )
 
 type deleteOptions struct {
@@ -27,7 +37,10 @@ type deleteOptions struct {
 	targetNamespace      string
 	forceDeleteNamespace bool
 	forceDeleteCRD       bool
+	deleteCertManager    bool
+	keepInventory        bool
 	deleteAll            bool
+	yes                  bool
 }
 
 var dd = &deleteOptions{}
@@ -36,7 +49,11 @@ var deleteCmd = &cobra.Command{
 	Use:   "delete [providers]",
 	Short: "Deletes one or more providers from the management cluster",
 	Long: LongDesc(`
-		Deletes one or more providers from the management cluster.`),
+		Deletes one or more providers from the management cluster.
+
+		When deleting the CRDs, the namespaces or all the providers, the list of the objects to be deleted is printed,
+		including the custom resources deleted together with the CRDs. When deleting all the providers,
+		an explicit confirmation is required before proceeding, unless the --yes flag is set.`),
 
 	Example: Examples(`
 		# Deletes the AWS provider
@@ -55,22 +72,29 @@ var deleteCmd = &cobra.Command{
 		# Cluster API Providers are orphaned and there might be ongoing costs incurred as a result of this.
 		clusterctl delete --all
 
+		# Deletes all the providers without asking for confirmation, while preserving the inventory,
+		# so the providers are still reported as installed and can be re-installed with the same configuration.
+		clusterctl delete --all --keep-inventory --yes
+
 		# Delete the AWS provider and related CRDs. Please note that this forces deletion of 
 		# all the related objects (e.g. AWSClusters, AWSMachines etc.).
 		# Important! As a consequence of this operation, all the corresponding resources managed by
 		# the AWS infrastructure provider are orphaned and there might be ongoing costs incurred as a result of this.
-		clusterctl delete aws --delete-crd
+		clusterctl delete aws --include-crd
 
 		# Delete the AWS provider and its hosting Namespace. Please note that this forces deletion of 
 		# all objects existing in the namespace. 
 		# Important! As a consequence of this operation, all the corresponding resources managed by
 		# Cluster API Providers are orphaned and there might be ongoing costs incurred as a result of this.
-		clusterctl delete aws --delete-namespace
+		clusterctl delete aws --include-namespace
 
 		# Reset the management cluster to its original state
 		# Important! As a consequence of this operation all the corresponding resources on target clouds
 		# are "orphaned" and thus there may be ongoing costs incurred as a result of this.
-		clusterctl delete --all --delete-crd  --delete-namespace`),
+		clusterctl delete --all --include-crd --include-namespace
+
+		# Reset the management cluster to its original state, also deleting cert-manager if it was installed by clusterctl.
+		clusterctl delete --all --include-crd --include-namespace --include-cert-manager`),
 
 	RunE: func(cmd *cobra.Command, args []string) error {
 		if dd.deleteAll && len(args) > 0 {
@@ -81,6 +105,14 @@ var deleteCmd = &cobra.Command{
 			return errors.New("At least one provider should be specified or the --all flag should be set")
 		}
 
+		if dd.keepInventory && dd.forceDeleteNamespace {
+			return errors.New("The --keep-inventory flag can't be used in combination with the --include-namespace flag")
+		}
+
+		if dd.deleteCertManager && !dd.deleteAll {
+			return errors.New("The --include-cert-manager flag can only be used in combination with the --all flag")
+		}
+
 		return runDelete(args)
 	},
 }
@@ -89,9 +121,18 @@ func init() {
 	deleteCmd.Flags().StringVarP(&dd.kubeconfig, "kubeconfig", "", "", "Path to the kubeconfig file to use for accessing the management cluster. If empty, default rules for kubeconfig discovery will be used")
 	deleteCmd.Flags().StringVarP(&dd.targetNamespace, "namespace", "", "", "The namespace where the provider to be deleted lives. If not specified, the namespace name will be inferred from the current configuration")
 
+	deleteCmd.Flags().BoolVarP(&dd.forceDeleteNamespace, "include-namespace", "", false, "Forces the deletion of the namespace where the providers are hosted (and of all the contained objects)")
+	deleteCmd.Flags().BoolVarP(&dd.forceDeleteCRD, "include-crd", "", false, "Forces the deletion of the provider's CRDs (and of all the related objects)")
+	deleteCmd.Flags().BoolVarP(&dd.deleteCertManager, "include-cert-manager", "", false, "Deletes cert-manager, if it was installed by clusterctl. It can only be used in combination with --all")
+	deleteCmd.Flags().BoolVarP(&dd.keepInventory, "keep-inventory", "", false, "Preserves the inventory items of the deleted providers. It can't be used in combination with --include-namespace")
+	deleteCmd.Flags().BoolVarP(&dd.deleteAll, "all", "", false, "Force deletion of all the providers")
+	deleteCmd.Flags().BoolVarP(&dd.yes, "yes", "y", false, "Skips the confirmation required before deleting all the providers")
+
+	// The --delete-namespace and --delete-crd flags are preserved for backward compatibility.
 	deleteCmd.Flags().BoolVarP(&dd.forceDeleteNamespace, "delete-namespace", "n", false, "Forces the deletion of the namespace where the providers are hosted (and of all the contained objects)")
 	deleteCmd.Flags().BoolVarP(&dd.forceDeleteCRD, "delete-crd", "c", false, "Forces the deletion of the provider's CRDs (and of all the related objects)")
-	deleteCmd.Flags().BoolVarP(&dd.deleteAll, "all", "", false, "Force deletion of all the providers")
+	_ = deleteCmd.Flags().MarkDeprecated("delete-namespace", "use --include-namespace instead")
+	_ = deleteCmd.Flags().MarkDeprecated("delete-crd", "use --include-crd instead")
 
 	RootCmd.AddCommand(deleteCmd)
 }
@@ -102,15 +143,104 @@ func runDelete(args []string) error {
 		return err
 	}
 
-	if err := c.Delete(client.DeleteOptions{
+	options := client.DeleteOptions{
 		Kubeconfig:           dd.kubeconfig,
 		ForceDeleteNamespace: dd.forceDeleteNamespace,
 		ForceDeleteCRD:       dd.forceDeleteCRD,
+		DeleteCertManager:    dd.deleteCertManager,
+		KeepInventory:        dd.keepInventory,
 		Namespace:            dd.targetNamespace,
 		Providers:            args,
-	}); err != nil {
+	}
+
+	// Deleting the CRDs or the namespaces destroys the objects of the users, so the objects to be deleted
+	// are shown before proceeding; deleting all the providers might destroy a shared management cluster,
+	// so an explicit confirmation is required too.
+	if dd.deleteAll || dd.forceDeleteCRD || dd.forceDeleteNamespace {
+		objs, err := c.PreviewDelete(ctx, options)
+		if err != nil {
+			return err
+		}
+
+		if err := printDeletePreview(os.Stdout, objs); err != nil {
+			return err
+		}
+
+		if dd.deleteAll && !dd.yes {
+			confirmed, err := confirm(os.Stdin, os.Stdout, "Do you want to delete all the objects listed above?")
+			if err != nil {
+				return err
+			}
+			if !confirmed {
+				return errors.New("Deletion aborted")
+			}
+		}
+	}
+
+	if err := c.Delete(ctx, options); err != nil {
 		return err
 	}
 
 	return nil
 }
+
+// printDeletePreview prints the objects that are going to be deleted.
+func printDeletePreview(w goio.Writer, objs []unstructured.Unstructured) error {
+	if len(objs) == 0 {
+		fmt.Fprintln(w, "No provider components to be deleted")
+		return nil
+	}
+
+	// The custom resources of the CRDs to be deleted are deleted too.
+	crdKinds := map[schema.GroupKind]string{}
+	for _, o := range objs {
+		if o.GetKind() != "CustomResourceDefinition" {
+			continue
+		}
+		group, _, _ := unstructured.NestedString(o.Object, "spec", "group")
+		kind, _, _ := unstructured.NestedString(o.Object, "spec", "names", "kind")
+		crdKinds[schema.GroupKind{Group: group, Kind: kind}] = o.GetName()
+	}
+
+	fmt.Fprintln(w, "The following objects are going to be deleted:")
+	t := printer.NewTable(
+		printer.Column{Name: "KIND"},
+		printer.Column{Name: "NAMESPACE"},
+		printer.Column{Name: "NAME"},
+		printer.Column{Name: "NOTES"},
+	)
+	for _, o := range objs {
+		notes := ""
+		switch o.GetKind() {
+		case "Namespace":
+			notes = "all the objects in the namespace are deleted"
+		case "CustomResourceDefinition":
+			notes = "all the objects of this kind are deleted"
+		}
+		if crd, ok := crdKinds[o.GroupVersionKind().GroupKind()]; ok {
+			notes = fmt.Sprintf("deleted together with the %s CustomResourceDefinition", crd)
+		}
+		if o.GetLabels()[clusterctlv1.ClusterctlCoreLabelName] == "cert-manager" {
+			notes = "cert-manager component"
+		}
+		t.AddRow(o.GetKind(), o.GetNamespace(), o.GetName(), notes)
+	}
+	return t.Print(w, printOptions(false))
+}
+
+// confirm asks a yes/no question, returning true only if the answer is yes.
+func confirm(in goio.Reader, out goio.Writer, question string) (bool, error) {
+	fmt.Fprintf(out, "%s [y/N]: ", question)
+
+	answer, err := bufio.NewReader(in).ReadString('\n')
+	if err != nil && err != goio.EOF {
+		return false, errors.Wrap(err, "failed to read the confirmation")
+	}
+
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true, nil
+	default:
+		return false, nil
+	}
+}
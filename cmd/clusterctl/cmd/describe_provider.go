@@ -0,0 +1,152 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/client"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/printer"
+	"sigs.k8s.io/yaml"
+)
+
+type describeProviderOptions struct {
+	kubeconfig      string
+	targetNamespace string
+	output          string
+	wide            bool
+}
+
+var dpo = &describeProviderOptions{}
+
+var describeProviderCmd = &cobra.Command{
+	Use:   "provider",
+	Args:  cobra.ExactArgs(1),
+	Short: "Describe a provider installed in the management cluster",
+	Long: LongDesc(`
+		Describe a provider installed in the management cluster, including the history of the
+		install and upgrade operations performed on each instance of the provider.
+
+		For each operation, clusterctl records the user who performed it, the clusterctl version used
+		and the URL of the repository the provider components were read from.`),
+
+	Example: Examples(`
+		# Describes all the instances of the AWS provider installed in the management cluster.
+		clusterctl describe provider aws
+
+		# Describes the instance of the AWS provider hosted in the "foo" namespace.
+		clusterctl describe provider aws --namespace=foo
+
+		# Displays also the user, the clusterctl version and the repository URL for each operation.
+		clusterctl describe provider aws --wide
+
+		# Displays the inventory items for the AWS provider in yaml format.
+		clusterctl describe provider aws -o yaml`),
+
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if !(dpo.output == "" || dpo.output == "yaml" || dpo.output == "text") {
+			return errors.New("please provide a valid output. Supported values are [ text, yaml ]")
+		}
+
+		return runDescribeProvider(args[0])
+	},
+}
+
+func init() {
+	describeProviderCmd.Flags().StringVarP(&dpo.kubeconfig, "kubeconfig", "", "", "Path to the kubeconfig file to use for accessing the management cluster. If empty, default rules for kubeconfig discovery will be used")
+	describeProviderCmd.Flags().StringVarP(&dpo.targetNamespace, "namespace", "", "", "The namespace where the provider to be described lives. If not specified, all the instances of the provider are described")
+	describeProviderCmd.Flags().StringVarP(&dpo.output, "output", "o", "text", "Output format. One of [yaml, text]")
+	describeProviderCmd.Flags().BoolVarP(&dpo.wide, "wide", "", false, "Print additional information for each operation in the history (text output only)")
+
+	describeCmd.AddCommand(describeProviderCmd)
+}
+
+func runDescribeProvider(provider string) error {
+	c, err := client.New(cfgFile)
+	if err != nil {
+		return err
+	}
+
+	providers, err := c.DescribeProvider(ctx, client.DescribeProviderOptions{
+		Kubeconfig: dpo.kubeconfig,
+		Provider:   provider,
+		Namespace:  dpo.targetNamespace,
+	})
+	if err != nil {
+		return err
+	}
+
+	if dpo.output == "yaml" {
+		return providersYAMLOutput(providers)
+	}
+	return providersDefaultOutput(providers)
+}
+
+func providersYAMLOutput(providers []clusterctlv1.Provider) error {
+	for i := range providers {
+		y, err := yaml.Marshal(&providers[i])
+		if err != nil {
+			return errors.Wrap(err, "failed to marshal provider to yaml")
+		}
+		fmt.Println("---")
+		fmt.Print(string(y))
+	}
+	return nil
+}
+
+func providersDefaultOutput(providers []clusterctlv1.Provider) error {
+	for _, p := range providers {
+		fmt.Printf("Name:               %s\n", p.Name)
+		fmt.Printf("Namespace:          %s\n", p.Namespace)
+		fmt.Printf("Type:               %s\n", p.Type)
+		fmt.Printf("Version:            %s\n", p.Version)
+		fmt.Printf("WatchedNamespace:   %s\n", p.WatchedNamespace)
+		if len(p.WatchedNamespaces) > 0 {
+			fmt.Printf("WatchedNamespaces:  %s\n", strings.Join(p.WatchedNamespaces, ", "))
+		}
+		if p.WatchedNamespaceSelector != nil {
+			fmt.Printf("WatchedNamespaceSelector: %s\n", metav1.FormatLabelSelector(p.WatchedNamespaceSelector))
+		}
+		if len(p.History) > 0 {
+			fmt.Println("History:")
+			t := printer.NewTable(
+				printer.Column{Name: "TIMESTAMP"},
+				printer.Column{Name: "OPERATION"},
+				printer.Column{Name: "VERSION"},
+				printer.Column{Name: "PREVIOUS VERSION"},
+				printer.Column{Name: "USER", Wide: true},
+				printer.Column{Name: "CLUSTERCTL VERSION", Wide: true},
+				printer.Column{Name: "REPOSITORY URL", Wide: true},
+			).WithIndent("  ")
+			for _, o := range p.History {
+				t.AddRow(o.Timestamp.Format(time.RFC3339), string(o.Type), o.Version, o.PreviousVersion, o.User, o.ClusterctlVersion, o.RepositoryURL)
+			}
+			if err := t.Print(os.Stdout, printOptions(dpo.wide)); err != nil {
+				return err
+			}
+		}
+		fmt.Println()
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/client"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/printer"
+)
+
+type doctorCertificatesOptions struct {
+	kubeconfig string
+	warnBefore time.Duration
+	fix        bool
+	wide       bool
+}
+
+var dco = &doctorCertificatesOptions{}
+
+var doctorCertificatesCmd = &cobra.Command{
+	Use:   "certificates",
+	Args:  cobra.NoArgs,
+	Short: "Check the certificates used by the webhooks of the providers",
+	Long: LongDesc(`
+		Check the cert-manager Certificates and Issuers installed by clusterctl, and the CA bundles
+		of the webhooks of the providers, reporting the certificates expired or approaching expiry.
+
+		If --fix is set, the renewal of the Certificates expired, not ready or approaching expiry is triggered
+		by deleting the Secret where the certificate is stored; cert-manager then issues a new certificate
+		and updates the CA bundles of the webhooks.`),
+
+	Example: Examples(`
+		# Check the certificates used by the webhooks of the providers.
+		clusterctl doctor certificates
+
+		# Check the certificates, reporting the ones expiring in the next 7 days.
+		clusterctl doctor certificates --warn-before=168h
+
+		# Check the certificates and trigger the renewal of the ones expired or approaching expiry.
+		clusterctl doctor certificates --fix`),
+
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runDoctorCertificates()
+	},
+}
+
+func init() {
+	doctorCertificatesCmd.Flags().StringVarP(&dco.kubeconfig, "kubeconfig", "", "", "Path to the kubeconfig file to use for accessing the management cluster. If empty, default rules for kubeconfig discovery will be used")
+	doctorCertificatesCmd.Flags().DurationVarP(&dco.warnBefore, "warn-before", "", 720*time.Hour, "How long before expiry a certificate is reported as approaching expiry")
+	doctorCertificatesCmd.Flags().BoolVarP(&dco.fix, "fix", "", false, "Trigger the renewal of the cert-manager Certificates expired, not ready or approaching expiry")
+	doctorCertificatesCmd.Flags().BoolVarP(&dco.wide, "wide", "", false, "Print the messages explaining the status of each certificate")
+
+	doctorCmd.AddCommand(doctorCertificatesCmd)
+}
+
+func runDoctorCertificates() error {
+	c, err := client.New(cfgFile)
+	if err != nil {
+		return err
+	}
+
+	reports, err := c.DoctorCertificates(ctx, client.DoctorCertificatesOptions{
+		Kubeconfig: dco.kubeconfig,
+		WarnBefore: dco.warnBefore,
+		Fix:        dco.fix,
+	})
+	if err != nil {
+		return err
+	}
+
+	if len(reports) == 0 {
+		fmt.Println("No certificates found")
+		return nil
+	}
+
+	t := printer.NewTable(
+		printer.Column{Name: "KIND"},
+		printer.Column{Name: "NAMESPACE"},
+		printer.Column{Name: "NAME"},
+		printer.Column{Name: "EXPIRES"},
+		printer.Column{Name: "STATUS"},
+		printer.Column{Name: "MESSAGE", Wide: true},
+	)
+	problems := 0
+	for _, r := range reports {
+		expires := ""
+		if r.NotAfter != nil {
+			expires = r.NotAfter.UTC().Format(time.RFC3339)
+		}
+		if r.Status != client.CertificateStatusOK {
+			problems++
+		}
+		t.AddRow(r.Kind, r.Namespace, r.Name, expires, r.Status, r.Message)
+	}
+	if err := t.Print(os.Stdout, printOptions(dco.wide)); err != nil {
+		return err
+	}
+
+	if problems > 0 && !dco.fix {
+		return errors.Errorf("found %d certificates expired, not ready or approaching expiry; use --fix to trigger their renewal", problems)
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/client"
+)
+
+type generateProviderRepoOptions struct {
+	name            string
+	providerType    string
+	contract        string
+	targetDirectory string
+}
+
+var gpro = &generateProviderRepoOptions{}
+
+var generateProviderRepoCmd = &cobra.Command{
+	Use:   "provider-repo",
+	Args:  cobra.NoArgs,
+	Short: "Scaffold a new provider repository",
+	Long: LongDesc(`
+		Scaffold a new provider repository conforming to the clusterctl provider contract.
+
+		The generated repository contains the metadata YAML, a kustomize layout for building the components YAML,
+		the cluster templates (for infrastructure providers only) and a script packaging the release assets
+		in a folder that can be used as a clusterctl local provider repository.
+
+		Existing files are never overwritten.`),
+
+	Example: Examples(`
+		# Scaffolds the repository of the foo infrastructure provider in the infrastructure-foo directory.
+		clusterctl generate provider-repo --name foo --type infrastructure
+
+		# Scaffolds the repository of the foo bootstrap provider in the current directory.
+		clusterctl generate provider-repo --name foo --type bootstrap --target-dir .`),
+
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runGenerateProviderRepo()
+	},
+}
+
+func init() {
+	generateProviderRepoCmd.Flags().StringVarP(&gpro.name, "name", "", "", "The name of the provider, e.g. aws")
+	generateProviderRepoCmd.Flags().StringVarP(&gpro.providerType, "type", "", "", "The type of the provider, one of infrastructure, bootstrap or control-plane")
+	generateProviderRepoCmd.Flags().StringVarP(&gpro.contract, "contract", "", "", "The API Version of Cluster API (contract) supported by the first release series of the provider. By default (empty), the contract of this version of clusterctl is used")
+	generateProviderRepoCmd.Flags().StringVarP(&gpro.targetDirectory, "target-dir", "", "", "The directory where the provider repository is generated. By default (empty), a directory named after the provider type and name is created in the current directory")
+
+	generateCmd.AddCommand(generateProviderRepoCmd)
+}
+
+func runGenerateProviderRepo() error {
+	if gpro.name == "" {
+		return errors.New("please specify the name of the provider using --name")
+	}
+	if gpro.providerType == "" {
+		return errors.New("please specify the type of the provider using --type")
+	}
+
+	c, err := client.New(cfgFile)
+	if err != nil {
+		return err
+	}
+
+	paths, err := c.GenerateProviderRepository(ctx, client.GenerateProviderRepositoryOptions{
+		Name:            gpro.name,
+		Type:            gpro.providerType,
+		Contract:        gpro.contract,
+		TargetDirectory: gpro.targetDirectory,
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, p := range paths {
+		fmt.Printf("Generated %s\n", p)
+	}
+	return nil
+}
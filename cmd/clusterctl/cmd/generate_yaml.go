@@ -0,0 +1,92 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/client"
+)
+
+type generateYAMLOptions struct {
+	from          string
+	listVariables bool
+}
+
+var gyo = &generateYAMLOptions{}
+
+var generateYAMLCmd = &cobra.Command{
+	Use:   "yaml",
+	Args:  cobra.NoArgs,
+	Short: "Process a YAML file with the clusterctl variable substitution",
+	Long: LongDesc(`
+		Process a YAML file, e.g. a custom manifest, replacing its variables with the values read from the
+		environment or from the clusterctl configuration file, like for workload cluster templates.
+
+		The objects are printed as they are defined in the YAML file, so they are not moved to a target namespace.`),
+
+	Example: Examples(`
+		# Processes a YAML file read from stdin.
+		cat ~/workspace/manifest.yaml | clusterctl generate yaml
+
+		# Processes a YAML file from the local file system.
+		clusterctl generate yaml --from ~/workspace/manifest.yaml
+
+		# Processes a YAML file from a GitHub repository.
+		clusterctl generate yaml --from https://github.com/foo-org/foo-repository/blob/master/manifest.yaml
+
+		# Prints the list of variables required by a YAML file.
+		clusterctl generate yaml --from ~/workspace/manifest.yaml --list-variables`),
+
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runGenerateYAML()
+	},
+}
+
+func init() {
+	generateYAMLCmd.Flags().StringVarP(&gyo.from, "from", "", "-", "The URL to read the YAML file from, e.g. a local file or a file in a GitHub repository; use '-' for reading from stdin")
+	generateYAMLCmd.Flags().BoolVarP(&gyo.listVariables, "list-variables", "", false, "Returns the list of variables expected by the YAML file")
+
+	generateCmd.AddCommand(generateYAMLCmd)
+}
+
+func runGenerateYAML() error {
+	c, err := client.New(cfgFile)
+	if err != nil {
+		return err
+	}
+
+	options := client.ProcessYAMLOptions{
+		ListVariablesOnly: gyo.listVariables,
+	}
+	if gyo.from == "-" {
+		options.ReaderSource = &client.ReaderSourceOptions{Reader: os.Stdin}
+	} else {
+		options.URLSource = &client.URLSourceOptions{URL: gyo.from}
+	}
+
+	template, err := c.ProcessYAML(ctx, options)
+	if err != nil {
+		return err
+	}
+
+	if gyo.listVariables {
+		return templateListVariablesOutput(template)
+	}
+	return templateYAMLOutput(template)
+}
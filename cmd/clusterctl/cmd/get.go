@@ -0,0 +1,73 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/util/duration"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/client"
+)
+
+var getCmd = &cobra.Command{
+	Use:   "get",
+	Short: "Get the status of the Cluster API objects in a management cluster",
+	Long:  `Get the status of the Cluster API objects in a management cluster`,
+}
+
+func init() {
+	RootCmd.AddCommand(getCmd)
+}
+
+// readyStatus returns the status of the Ready condition of an object, or an empty string if the condition is not set.
+func readyStatus(s client.ObjectStatus) string {
+	for _, c := range s.Conditions {
+		if c.Type == clusterv1.ReadyCondition {
+			return string(c.Status)
+		}
+	}
+	return ""
+}
+
+// conditionsSummary returns the key conditions of an object other than Ready, e.g. "InfrastructureReady=True
+// ControlPlaneReady=False(WaitingForControlPlane)".
+func conditionsSummary(s client.ObjectStatus) string {
+	var ret []string
+	for _, c := range s.Conditions {
+		if c.Type == clusterv1.ReadyCondition {
+			continue
+		}
+		v := fmt.Sprintf("%s=%s", c.Type, c.Status)
+		if c.Reason != "" {
+			v = fmt.Sprintf("%s(%s)", v, c.Reason)
+		}
+		ret = append(ret, v)
+	}
+	return strings.Join(ret, " ")
+}
+
+// age returns the age of an object in a human readable format, e.g. 5m.
+func age(s client.ObjectStatus) string {
+	if s.CreationTimestamp.IsZero() {
+		return ""
+	}
+	return duration.HumanDuration(time.Since(s.CreationTimestamp.Time))
+}
@@ -0,0 +1,133 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/client"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/printer"
+)
+
+type getClustersOptions struct {
+	kubeconfig      string
+	targetNamespace string
+	allNamespaces   bool
+	watch           bool
+	output          string
+	wide            bool
+}
+
+var gco = &getClustersOptions{}
+
+var getClustersCmd = &cobra.Command{
+	Use:   "clusters",
+	Args:  cobra.NoArgs,
+	Short: "Get the status of the Clusters",
+	Long: LongDesc(`
+		Get the phase and the key conditions of the Clusters in a management cluster.
+
+		With the --watch flag, the status is printed again every time it changes, so it is possible to
+		follow the provisioning of a Cluster in real time.`),
+
+	Example: Examples(`
+		# Gets the status of the Clusters in the current namespace.
+		clusterctl get clusters
+
+		# Gets the status of the Clusters in all the namespaces, including all the key conditions.
+		clusterctl get clusters --all-namespaces --wide
+
+		# Follows the status of the Clusters in the "foo" namespace.
+		clusterctl get clusters --namespace=foo --watch`),
+
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if !(gco.output == "" || gco.output == "text" || gco.output == "json") {
+			return errors.New("please provide a valid output. Supported values are [ text, json ]")
+		}
+
+		return runGetClusters()
+	},
+}
+
+func init() {
+	getClustersCmd.Flags().StringVarP(&gco.kubeconfig, "kubeconfig", "", "", "Path to the kubeconfig file to use for accessing the management cluster. If empty, default rules for kubeconfig discovery will be used")
+	getClustersCmd.Flags().StringVarP(&gco.targetNamespace, "namespace", "n", "", "The namespace where the Clusters live. If not specified, the current namespace will be used")
+	getClustersCmd.Flags().BoolVarP(&gco.allNamespaces, "all-namespaces", "A", false, "Get the Clusters in all the namespaces")
+	getClustersCmd.Flags().BoolVarP(&gco.watch, "watch", "w", false, "Print the status again every time it changes, until clusterctl is interrupted")
+	getClustersCmd.Flags().StringVarP(&gco.output, "output", "o", "text", "Output format. One of [text, json]")
+	getClustersCmd.Flags().BoolVarP(&gco.wide, "wide", "", false, "Print all the key conditions of the Clusters (text output only)")
+
+	getCmd.AddCommand(getClustersCmd)
+}
+
+func runGetClusters() error {
+	c, err := client.New(cfgFile)
+	if err != nil {
+		return err
+	}
+
+	options := client.GetClustersOptions{
+		Kubeconfig:    gco.kubeconfig,
+		Namespace:     gco.targetNamespace,
+		AllNamespaces: gco.allNamespaces,
+	}
+
+	if gco.watch {
+		first := true
+		return c.WatchClusters(ctx, options, func(clusters []client.ObjectStatus) error {
+			if !first && gco.output != "json" {
+				fmt.Println()
+			}
+			first = false
+			return printClusters(clusters)
+		})
+	}
+
+	clusters, err := c.GetClusters(ctx, options)
+	if err != nil {
+		return err
+	}
+	return printClusters(clusters)
+}
+
+func printClusters(clusters []client.ObjectStatus) error {
+	if gco.output == "json" {
+		y, err := json.MarshalIndent(clusters, "", "  ")
+		if err != nil {
+			return errors.Wrap(err, "failed to marshal the Clusters to json")
+		}
+		fmt.Println(string(y))
+		return nil
+	}
+
+	t := printer.NewTable(
+		printer.Column{Name: "NAMESPACE"},
+		printer.Column{Name: "NAME"},
+		printer.Column{Name: "PHASE"},
+		printer.Column{Name: "READY", Color: printer.ConditionStatusColor},
+		printer.Column{Name: "AGE"},
+		printer.Column{Name: "CONDITIONS", Wide: true},
+	)
+	for _, s := range clusters {
+		t.AddRow(s.Namespace, s.Name, s.Phase, readyStatus(s), age(s), conditionsSummary(s))
+	}
+	return t.Print(os.Stdout, printOptions(gco.wide))
+}
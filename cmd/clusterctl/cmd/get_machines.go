@@ -0,0 +1,141 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/client"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/printer"
+)
+
+type getMachinesOptions struct {
+	kubeconfig      string
+	targetNamespace string
+	allNamespaces   bool
+	clusterName     string
+	watch           bool
+	output          string
+	wide            bool
+}
+
+var gmo = &getMachinesOptions{}
+
+var getMachinesCmd = &cobra.Command{
+	Use:   "machines",
+	Args:  cobra.NoArgs,
+	Short: "Get the status of the Machines",
+	Long: LongDesc(`
+		Get the phase, the key conditions, the Kubernetes version and the Node of the Machines in a
+		management cluster.
+
+		With the --watch flag, the status is printed again every time it changes, so it is possible to
+		follow the provisioning of the Machines of a Cluster in real time.`),
+
+	Example: Examples(`
+		# Gets the status of the Machines in the current namespace.
+		clusterctl get machines
+
+		# Gets the status of the Machines of the Cluster "my-cluster", including the provider IDs and all the key conditions.
+		clusterctl get machines --cluster=my-cluster --wide
+
+		# Follows the status of the Machines of the Cluster "my-cluster".
+		clusterctl get machines --cluster=my-cluster --watch`),
+
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if !(gmo.output == "" || gmo.output == "text" || gmo.output == "json") {
+			return errors.New("please provide a valid output. Supported values are [ text, json ]")
+		}
+
+		return runGetMachines()
+	},
+}
+
+func init() {
+	getMachinesCmd.Flags().StringVarP(&gmo.kubeconfig, "kubeconfig", "", "", "Path to the kubeconfig file to use for accessing the management cluster. If empty, default rules for kubeconfig discovery will be used")
+	getMachinesCmd.Flags().StringVarP(&gmo.targetNamespace, "namespace", "n", "", "The namespace where the Machines live. If not specified, the current namespace will be used")
+	getMachinesCmd.Flags().BoolVarP(&gmo.allNamespaces, "all-namespaces", "A", false, "Get the Machines in all the namespaces")
+	getMachinesCmd.Flags().StringVarP(&gmo.clusterName, "cluster", "", "", "Get only the Machines belonging to the Cluster with this name")
+	getMachinesCmd.Flags().BoolVarP(&gmo.watch, "watch", "w", false, "Print the status again every time it changes, until clusterctl is interrupted")
+	getMachinesCmd.Flags().StringVarP(&gmo.output, "output", "o", "text", "Output format. One of [text, json]")
+	getMachinesCmd.Flags().BoolVarP(&gmo.wide, "wide", "", false, "Print the provider IDs and all the key conditions of the Machines (text output only)")
+
+	getCmd.AddCommand(getMachinesCmd)
+}
+
+func runGetMachines() error {
+	c, err := client.New(cfgFile)
+	if err != nil {
+		return err
+	}
+
+	options := client.GetMachinesOptions{
+		Kubeconfig:    gmo.kubeconfig,
+		Namespace:     gmo.targetNamespace,
+		AllNamespaces: gmo.allNamespaces,
+		ClusterName:   gmo.clusterName,
+	}
+
+	if gmo.watch {
+		first := true
+		return c.WatchMachines(ctx, options, func(machines []client.ObjectStatus) error {
+			if !first && gmo.output != "json" {
+				fmt.Println()
+			}
+			first = false
+			return printMachines(machines)
+		})
+	}
+
+	machines, err := c.GetMachines(ctx, options)
+	if err != nil {
+		return err
+	}
+	return printMachines(machines)
+}
+
+func printMachines(machines []client.ObjectStatus) error {
+	if gmo.output == "json" {
+		y, err := json.MarshalIndent(machines, "", "  ")
+		if err != nil {
+			return errors.Wrap(err, "failed to marshal the Machines to json")
+		}
+		fmt.Println(string(y))
+		return nil
+	}
+
+	t := printer.NewTable(
+		printer.Column{Name: "NAMESPACE"},
+		printer.Column{Name: "NAME"},
+		printer.Column{Name: "CLUSTER"},
+		printer.Column{Name: "PHASE"},
+		printer.Column{Name: "READY", Color: printer.ConditionStatusColor},
+		printer.Column{Name: "VERSION"},
+		printer.Column{Name: "NODE"},
+		printer.Column{Name: "AGE"},
+		printer.Column{Name: "PROVIDER ID", Wide: true},
+		printer.Column{Name: "CONDITIONS", Wide: true},
+	)
+	for _, s := range machines {
+		t.AddRow(s.Namespace, s.Name, s.ClusterName, s.Phase, readyStatus(s), s.Version, s.NodeName, age(s), s.ProviderID, conditionsSummary(s))
+	}
+	return t.Print(os.Stdout, printOptions(gmo.wide))
+}
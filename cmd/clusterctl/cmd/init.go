@@ -18,9 +18,14 @@ package cmd
 
 import (
 	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
 
+	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/client"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/printer"
 )
 
 type initOptions struct {
@@ -32,6 +37,12 @@ type initOptions struct {
 	targetNamespace         string
 	watchingNamespace       string
 	listImages              bool
+	listPermissions         bool
+	architectures           []string
+	validateArchitectures   bool
+	componentsFiles         []string
+	providerNamespaces      []string
+	inventoryNamespaces     []string
 }
 
 var io = &initOptions{}
@@ -74,12 +85,35 @@ var initCmd = &cobra.Command{
 		# Initialize a management cluster by installing the provider's components' in the "foo" namespace.
 		clusterctl init --infrastructure aws --target-namespace foo
 
+		# Initialize a management cluster by installing all the providers in the "capi-system" namespace, except
+		# the AWS infrastructure provider, installed in the "capa-system" namespace.
+		clusterctl init --infrastructure aws --target-namespace capi-system --provider-namespace aws=capa-system
+
 		# Initialize a management cluster and configures all the providers for watching Cluster API
 		# objects in the "foo" namespace only.
 		clusterctl init --infrastructure aws --watching-namespace=foo
 
 		# Lists the container images required for initializing the management cluster (without actually installing the providers).
-		clusterctl init --infrastructure aws --list-images`),
+		clusterctl init --infrastructure aws --list-images
+
+		# Lists the container images required for initializing the management cluster, resolved to the image
+		# of each architecture for multi-arch images, e.g. for creating an air-gap bundle for amd64 and arm64.
+		clusterctl init --infrastructure aws --list-images --architectures amd64,arm64
+
+		# Lists the permissions granted to the providers by the RBAC rules in their components (without actually
+		# installing the providers), e.g. for reviewing the access to secrets before installing a new provider.
+		clusterctl init --infrastructure aws --list-permissions
+
+		# Initialize a management cluster after checking that all the images are published for the architectures
+		# of the management cluster nodes.
+		clusterctl init --infrastructure aws --validate-image-architectures
+
+		# Initialize a management cluster by installing the AWS infrastructure provider from components YAML
+		# rendered by a separate pipeline step; the version of the provider must be specified.
+		clusterctl init --infrastructure aws:v0.5.0 --components-file aws=infrastructure-components.yaml
+
+		# Initialize a management cluster by installing the AWS infrastructure provider from rendered components YAML read from stdin.
+		cat infrastructure-components.yaml | clusterctl init --infrastructure aws:v0.5.0 --components-file aws=-`),
 
 	RunE: func(cmd *cobra.Command, args []string) error {
 		return runInit()
@@ -93,8 +127,15 @@ func init() {
 	initCmd.Flags().StringSliceVarP(&io.bootstrapProviders, "bootstrap", "b", nil, "Bootstrap providers and versions (e.g. kubeadm-bootstrap:v0.3.0) to add to the management cluster. By default (empty), the kubeadm bootstrap provider's latest release is used")
 	initCmd.Flags().StringSliceVarP(&io.controlPlaneProviders, "control-plane", "c", nil, "ControlPlane providers and versions (e.g. kubeadm-control-plane:v0.3.0) to add to the management cluster. By default (empty), the kubeadm control plane provider latest release is used")
 	initCmd.Flags().StringVarP(&io.targetNamespace, "target-namespace", "", "", "The target namespace where the providers should be deployed. If not specified, each provider will be installed in a provider's default namespace")
+	initCmd.Flags().StringSliceVarP(&io.providerNamespaces, "provider-namespace", "", nil, "Target namespaces for single providers, in the form provider=namespace (e.g. aws=capa-system), overriding --target-namespace")
 	initCmd.Flags().StringVarP(&io.watchingNamespace, "watching-namespace", "", "", "Namespace that the providers should watch to reconcile Cluster API objects. If unspecified, the providers watches for Cluster API objects across all namespaces")
 	initCmd.Flags().BoolVarP(&io.listImages, "list-images", "", false, "Lists the container images required for initializing the management cluster (without actually installing the providers)")
+	initCmd.Flags().StringSliceVarP(&io.architectures, "architectures", "", nil, "Architectures (e.g. amd64,arm64) the images listed by --list-images should be resolved for; images not published for all the architectures are reported as an error")
+	initCmd.Flags().BoolVarP(&io.listPermissions, "list-permissions", "", false, "Lists the permissions granted to the providers by the RBAC rules in their components (without actually installing the providers)")
+	initCmd.Flags().BoolVarP(&io.validateArchitectures, "validate-image-architectures", "", false, "Check that all the images are published for the architectures of the management cluster nodes before installing the providers")
+	initCmd.Flags().StringSliceVarP(&io.componentsFiles, "components-file", "", nil, "Already rendered components YAML files for providers, in the form provider=path (e.g. aws=infrastructure-components.yaml); use '-' as path for reading from stdin. Rendered components are validated and installed as-is, and the version of the corresponding providers must be specified")
+
+	initCmd.Flags().StringSliceVarP(&io.inventoryNamespaces, "inventory-namespace", "", nil, "Namespaces the clusterctl inventory should be scoped to, for sharing the management cluster with other users; providers installed in other namespaces are ignored, and the target and watching namespaces must be one of them")
 
 	RootCmd.AddCommand(initCmd)
 }
@@ -105,19 +146,34 @@ func runInit() error {
 		return err
 	}
 
+	renderedComponents, err := readRenderedComponents(io.componentsFiles)
+	if err != nil {
+		return err
+	}
+
+	providerNamespaces, err := parseProviderNamespaces(io.providerNamespaces)
+	if err != nil {
+		return err
+	}
+
 	options := client.InitOptions{
-		Kubeconfig:              io.kubeconfig,
-		CoreProvider:            io.coreProvider,
-		BootstrapProviders:      io.bootstrapProviders,
-		ControlPlaneProviders:   io.controlPlaneProviders,
-		InfrastructureProviders: io.infrastructureProviders,
-		TargetNamespace:         io.targetNamespace,
-		WatchingNamespace:       io.watchingNamespace,
-		LogUsageInstructions:    true,
+		Kubeconfig:                 io.kubeconfig,
+		CoreProvider:               io.coreProvider,
+		BootstrapProviders:         io.bootstrapProviders,
+		ControlPlaneProviders:      io.controlPlaneProviders,
+		InfrastructureProviders:    io.infrastructureProviders,
+		TargetNamespace:            io.targetNamespace,
+		WatchingNamespace:          io.watchingNamespace,
+		ProviderNamespaces:         providerNamespaces,
+		LogUsageInstructions:       true,
+		RenderedComponents:         renderedComponents,
+		Architectures:              io.architectures,
+		ValidateImageArchitectures: io.validateArchitectures,
+		InventoryNamespaces:        io.inventoryNamespaces,
 	}
 
 	if io.listImages {
-		images, err := c.InitImages(options)
+		images, err := c.InitImages(ctx, options)
 		if err != nil {
 			return err
 		}
@@ -128,8 +184,104 @@ func runInit() error {
 		return nil
 	}
 
-	if _, err := c.Init(options); err != nil {
+	if io.listPermissions {
+		permissions, err := c.InitPermissions(ctx, options)
+		if err != nil {
+			return err
+		}
+		return printPermissions(permissions)
+	}
+
+	if _, err := c.Init(ctx, options); err != nil {
 		return err
 	}
 	return nil
 }
+
+// printPermissions prints the permissions granted to the providers, highlighting the access to secrets and the wildcards.
+func printPermissions(permissions []client.ProviderPermissions) error {
+	t := printer.NewTable(
+		printer.Column{Name: "PROVIDER"},
+		printer.Column{Name: "SCOPE"},
+		printer.Column{Name: "API GROUP"},
+		printer.Column{Name: "RESOURCE"},
+		printer.Column{Name: "VERBS"},
+		printer.Column{Name: "NOTES", Color: func(string) printer.Color { return printer.Yellow }},
+	)
+	for _, p := range permissions {
+		for _, permission := range p.Permissions {
+			resource := permission.Resource
+			if len(permission.ResourceNames) > 0 {
+				resource = fmt.Sprintf("%s (%s)", resource, strings.Join(permission.ResourceNames, ","))
+			}
+			var notes []string
+			if permission.SecretsAccess() {
+				notes = append(notes, "secrets access")
+			}
+			if permission.Wildcard() {
+				notes = append(notes, "wildcard")
+			}
+			t.AddRow(p.Provider, permission.Scope, permission.APIGroup, resource, strings.Join(permission.Verbs, ","), strings.Join(notes, ", "))
+		}
+	}
+	return t.Print(os.Stdout, printOptions(false))
+}
+
+// readRenderedComponents reads the rendered components YAML files, in the form provider=path, where '-' stands for stdin.
+func readRenderedComponents(componentsFiles []string) (map[string][]byte, error) {
+	if len(componentsFiles) == 0 {
+		return nil, nil
+	}
+
+	renderedComponents := map[string][]byte{}
+	stdinUsed := false
+	for _, f := range componentsFiles {
+		t := strings.SplitN(f, "=", 2)
+		if len(t) != 2 || t[0] == "" || t[1] == "" {
+			return nil, errors.Errorf("invalid components file %q. Components files should be in the form provider=path", f)
+		}
+		name, path := strings.ToLower(t[0]), t[1]
+
+		if _, ok := renderedComponents[name]; ok {
+			return nil, errors.Errorf("invalid components file %q. Components files can be provided only once for each provider", f)
+		}
+
+		var data []byte
+		var err error
+		if path == "-" {
+			if stdinUsed {
+				return nil, errors.New("invalid components files. Only one components file can be read from stdin")
+			}
+			stdinUsed = true
+			data, err = ioutil.ReadAll(os.Stdin)
+		} else {
+			data, err = ioutil.ReadFile(path)
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read the components file for the %q provider", name)
+		}
+		renderedComponents[name] = data
+	}
+	return renderedComponents, nil
+}
+
+// parseProviderNamespaces parses the target namespaces for single providers, in the form provider=namespace.
+func parseProviderNamespaces(providerNamespaces []string) (map[string]string, error) {
+	if len(providerNamespaces) == 0 {
+		return nil, nil
+	}
+
+	ret := map[string]string{}
+	for _, n := range providerNamespaces {
+		t := strings.SplitN(n, "=", 2)
+		if len(t) != 2 || t[0] == "" || t[1] == "" {
+			return nil, errors.Errorf("invalid provider namespace %q. Provider namespaces should be in the form provider=namespace", n)
+		}
+		name := strings.ToLower(t[0])
+		if _, ok := ret[name]; ok {
+			return nil, errors.Errorf("invalid provider namespace %q. The namespace can be provided only once for each provider", n)
+		}
+		ret[name] = t[1]
+	}
+	return ret, nil
+}
@@ -0,0 +1,84 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/client"
+)
+
+type logsClusterOptions struct {
+	kubeconfig      string
+	targetNamespace string
+	since           time.Duration
+	previous        bool
+	tail            int64
+}
+
+var lco = &logsClusterOptions{}
+
+var logsClusterCmd = &cobra.Command{
+	Use:   "cluster",
+	Args:  cobra.ExactArgs(1),
+	Short: "Print the provider logs related to a workload cluster",
+	Long: LongDesc(`
+		Print the log lines mentioning a workload cluster, read from the controllers of all the providers
+		installed in the management cluster (core, bootstrap, control-plane and infrastructure providers).
+
+		Log lines are merged in a single chronological stream, and each line is prefixed with the pod and
+		the container name. This helps in debugging provisioning failures that involve many providers.`),
+
+	Example: Examples(`
+		# Prints the provider logs related to the cluster foo written in the last hour.
+		clusterctl logs cluster foo
+
+		# Prints the provider logs related to the cluster foo in the "bar" namespace, written in the last 10 minutes.
+		clusterctl logs cluster foo --namespace=bar --since=10m`),
+
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runLogsCluster(args[0])
+	},
+}
+
+func init() {
+	logsClusterCmd.Flags().StringVarP(&lco.kubeconfig, "kubeconfig", "", "", "Path to the kubeconfig file to use for accessing the management cluster. If empty, default rules for kubeconfig discovery will be used")
+	logsClusterCmd.Flags().StringVarP(&lco.targetNamespace, "namespace", "n", "", "The namespace where the workload cluster lives. If not specified, the current namespace will be used")
+	logsClusterCmd.Flags().DurationVarP(&lco.since, "since", "", time.Hour, "Only return logs newer than a relative duration like 5s, 2m, or 3h. Use 0 for all logs")
+	logsClusterCmd.Flags().BoolVarP(&lco.previous, "previous", "p", false, "Print the logs for the previous instance of the provider containers, if they exist")
+	logsClusterCmd.Flags().Int64VarP(&lco.tail, "tail", "", 0, "Number of most recent lines to read from each provider container. If 0, the last 10000 lines are read")
+
+	logsCmd.AddCommand(logsClusterCmd)
+}
+
+func runLogsCluster(name string) error {
+	c, err := client.New(cfgFile)
+	if err != nil {
+		return err
+	}
+
+	return c.ClusterLogs(ctx, client.ClusterLogsOptions{
+		Kubeconfig:  lco.kubeconfig,
+		ClusterName: name,
+		Namespace:   lco.targetNamespace,
+		Since:       lco.since,
+		Previous:    lco.previous,
+		TailLines:   lco.tail,
+	}, os.Stdout)
+}
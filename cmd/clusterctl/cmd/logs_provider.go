@@ -0,0 +1,86 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/client"
+)
+
+type logsProviderOptions struct {
+	kubeconfig      string
+	targetNamespace string
+	follow          bool
+	since           time.Duration
+	previous        bool
+}
+
+var lpo = &logsProviderOptions{}
+
+var logsProviderCmd = &cobra.Command{
+	Use:   "provider",
+	Args:  cobra.ExactArgs(1),
+	Short: "Print the logs of a provider installed in the management cluster",
+	Long: LongDesc(`
+		Print the logs of the controllers of a provider installed in the management cluster.
+
+		clusterctl reads the provider inventory to locate the pods of the provider controllers, and then
+		merges the logs of all the containers, prefixing each line with the pod and the container name.`),
+
+	Example: Examples(`
+		# Prints the logs of the AWS provider.
+		clusterctl logs provider aws
+
+		# Prints the logs of the instance of the AWS provider hosted in the "foo" namespace.
+		clusterctl logs provider aws --namespace=foo
+
+		# Streams the logs of the AWS provider, starting from the logs written in the last 10 minutes.
+		clusterctl logs provider aws --follow --since=10m`),
+
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runLogsProvider(args[0])
+	},
+}
+
+func init() {
+	logsProviderCmd.Flags().StringVarP(&lpo.kubeconfig, "kubeconfig", "", "", "Path to the kubeconfig file to use for accessing the management cluster. If empty, default rules for kubeconfig discovery will be used")
+	logsProviderCmd.Flags().StringVarP(&lpo.targetNamespace, "namespace", "", "", "The namespace where the provider lives. Required only if there are many instances of the provider in the management cluster")
+	logsProviderCmd.Flags().BoolVarP(&lpo.follow, "follow", "f", false, "Specify if the logs should be streamed")
+	logsProviderCmd.Flags().DurationVarP(&lpo.since, "since", "", 0, "Only return logs newer than a relative duration like 5s, 2m, or 3h. Defaults to all logs")
+	logsProviderCmd.Flags().BoolVarP(&lpo.previous, "previous", "p", false, "Print the logs for the previous instance of the provider containers, if they exist")
+
+	logsCmd.AddCommand(logsProviderCmd)
+}
+
+func runLogsProvider(provider string) error {
+	c, err := client.New(cfgFile)
+	if err != nil {
+		return err
+	}
+
+	return c.ProviderLogs(ctx, client.ProviderLogsOptions{
+		Kubeconfig: lpo.kubeconfig,
+		Provider:   provider,
+		Namespace:  lpo.targetNamespace,
+		Follow:     lpo.follow,
+		Since:      lpo.since,
+		Previous:   lpo.previous,
+	}, os.Stdout)
+}
@@ -17,15 +17,20 @@ limitations under the License.
 package cmd
 
 import (
+	"strings"
+
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/client"
 )
 
 type moveOptions struct {
-	fromKubeconfig string
-	namespace      string
-	toKubeconfig   string
+	fromKubeconfig        string
+	namespace             string
+	toKubeconfig          string
+	namespaceMappings     []string
+	targetNamespacePrefix string
+	targetNamespaceSuffix string
 }
 
 var mo = &moveOptions{}
@@ -40,7 +45,13 @@ var moveCmd = &cobra.Command{
 
 	Example: Examples(`
 		# Moves Cluster API objects from cluster to the target cluster.
-		clusterctl move --to-kubeconfig=target-kubeconfig.yaml`),
+		clusterctl move --to-kubeconfig=target-kubeconfig.yaml
+
+		# Moves Cluster API objects from the ns1 namespace to the site-a-ns1 namespace of the target cluster.
+		clusterctl move --to-kubeconfig=target-kubeconfig.yaml --namespace=ns1 --namespace-mapping=ns1=site-a-ns1
+
+		# Moves Cluster API objects from the ns1 namespace, prefixing the namespace in the target cluster with "site-a-".
+		clusterctl move --to-kubeconfig=target-kubeconfig.yaml --namespace=ns1 --target-namespace-prefix=site-a-`),
 
 	RunE: func(cmd *cobra.Command, args []string) error {
 		if mo.toKubeconfig == "" {
@@ -55,6 +66,9 @@ func init() {
 	moveCmd.Flags().StringVarP(&mo.fromKubeconfig, "kubeconfig", "", "", "Path to the kubeconfig file to use for accessing the originating management cluster. If empty, default rules for kubeconfig discovery will be used")
 	moveCmd.Flags().StringVarP(&mo.toKubeconfig, "to-kubeconfig", "", "", "Path to the kubeconfig file to use for accessing the target management cluster")
 	moveCmd.Flags().StringVarP(&mo.namespace, "namespace", "n", "", "The namespace where the objects describing the workload cluster exists. If not specified, the current namespace will be used")
+	moveCmd.Flags().StringSliceVarP(&mo.namespaceMappings, "namespace-mapping", "", nil, "Source and target namespaces (e.g. ns1=site-a-ns1) for moving the objects to a different namespace in the target cluster")
+	moveCmd.Flags().StringVarP(&mo.targetNamespacePrefix, "target-namespace-prefix", "", "", "The prefix added to the namespaces not listed in --namespace-mapping in the target cluster")
+	moveCmd.Flags().StringVarP(&mo.targetNamespaceSuffix, "target-namespace-suffix", "", "", "The suffix added to the namespaces not listed in --namespace-mapping in the target cluster")
 
 	RootCmd.AddCommand(moveCmd)
 }
@@ -65,12 +79,35 @@ func runMove() error {
 		return err
 	}
 
-	if err := c.Move(client.MoveOptions{
+	namespaces := map[string]string{}
+	for _, m := range mo.namespaceMappings {
+		source, target, ok := splitNamespaceMapping(m)
+		if !ok {
+			return errors.Errorf("invalid namespace mapping %q, it must be in the form source=target", m)
+		}
+		namespaces[source] = target
+	}
+
+	if err := c.Move(ctx, client.MoveOptions{
 		FromKubeconfig: mo.fromKubeconfig,
 		ToKubeconfig:   mo.toKubeconfig,
 		Namespace:      mo.namespace,
+		NamespaceMapping: client.NamespaceMapping{
+			Namespaces: namespaces,
+			Prefix:     mo.targetNamespacePrefix,
+			Suffix:     mo.targetNamespaceSuffix,
+		},
 	}); err != nil {
 		return err
 	}
 	return nil
 }
+
+// splitNamespaceMapping splits a namespace mapping in the form source=target.
+func splitNamespaceMapping(mapping string) (string, string, bool) {
+	parts := strings.Split(mapping, "=")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", false
+	}
+	return parts[0], parts[1], true
+}
@@ -0,0 +1,169 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/client"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/printer"
+)
+
+type reportVersionsOptions struct {
+	kubeconfig      string
+	targetNamespace string
+	allNamespaces   bool
+	output          string
+	wide            bool
+}
+
+var rvo = &reportVersionsOptions{}
+
+var reportVersionsCmd = &cobra.Command{
+	Use:   "versions",
+	Args:  cobra.NoArgs,
+	Short: "Report the versions of the workload clusters",
+	Long: LongDesc(`
+		Report, for each workload cluster, the Kubernetes version of the control plane, the range of
+		Kubernetes versions of the worker machines, the versions of the providers responsible for the
+		cluster and the provider upgrades available.
+
+		The csv and json outputs are meant for compliance reporting across the whole fleet.`),
+
+	Example: Examples(`
+		# Reports the versions of the workload clusters in the current namespace.
+		clusterctl report versions
+
+		# Reports the versions of all the workload clusters in csv format.
+		clusterctl report versions --all-namespaces -o csv
+
+		# Reports the versions of the workload clusters in the "foo" namespace in json format.
+		clusterctl report versions --namespace=foo -o json`),
+
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if !(rvo.output == "" || rvo.output == "text" || rvo.output == "csv" || rvo.output == "json") {
+			return errors.New("please provide a valid output. Supported values are [ text, csv, json ]")
+		}
+
+		return runReportVersions()
+	},
+}
+
+func init() {
+	reportVersionsCmd.Flags().StringVarP(&rvo.kubeconfig, "kubeconfig", "", "", "Path to the kubeconfig file to use for accessing the management cluster. If empty, default rules for kubeconfig discovery will be used")
+	reportVersionsCmd.Flags().StringVarP(&rvo.targetNamespace, "namespace", "n", "", "The namespace where the workload clusters live. If not specified, the current namespace will be used")
+	reportVersionsCmd.Flags().BoolVarP(&rvo.allNamespaces, "all-namespaces", "A", false, "Report the workload clusters in all the namespaces")
+	reportVersionsCmd.Flags().StringVarP(&rvo.output, "output", "o", "text", "Output format. One of [text, csv, json]")
+	reportVersionsCmd.Flags().BoolVarP(&rvo.wide, "wide", "", false, "Print the providers responsible for each cluster (text output only)")
+
+	reportCmd.AddCommand(reportVersionsCmd)
+}
+
+func runReportVersions() error {
+	c, err := client.New(cfgFile)
+	if err != nil {
+		return err
+	}
+
+	reports, err := c.ReportVersions(ctx, client.ReportVersionsOptions{
+		Kubeconfig:    rvo.kubeconfig,
+		Namespace:     rvo.targetNamespace,
+		AllNamespaces: rvo.allNamespaces,
+	})
+	if err != nil {
+		return err
+	}
+
+	switch rvo.output {
+	case "csv":
+		return reportVersionsCSVOutput(reports)
+	case "json":
+		y, err := json.MarshalIndent(reports, "", "  ")
+		if err != nil {
+			return errors.Wrap(err, "failed to marshal the report to json")
+		}
+		fmt.Println(string(y))
+		return nil
+	}
+	return reportVersionsDefaultOutput(reports)
+}
+
+func reportVersionsCSVOutput(reports []client.ClusterVersionReport) error {
+	w := csv.NewWriter(os.Stdout)
+	if err := w.Write([]string{"NAMESPACE", "NAME", "CONTROL PLANE VERSION", "MIN WORKER VERSION", "MAX WORKER VERSION", "PROVIDERS", "UPGRADES AVAILABLE"}); err != nil {
+		return err
+	}
+	for _, r := range reports {
+		if err := w.Write([]string{r.Namespace, r.Name, r.ControlPlaneVersion, r.MinWorkerVersion, r.MaxWorkerVersion, providerVersions(r), providerUpgrades(r)}); err != nil {
+			return err
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
+
+func reportVersionsDefaultOutput(reports []client.ClusterVersionReport) error {
+	t := printer.NewTable(
+		printer.Column{Name: "NAMESPACE"},
+		printer.Column{Name: "NAME"},
+		printer.Column{Name: "CONTROL PLANE"},
+		printer.Column{Name: "WORKERS"},
+		printer.Column{Name: "UPGRADE AVAILABLE"},
+		printer.Column{Name: "PROVIDERS", Wide: true},
+		printer.Column{Name: "UPGRADES", Wide: true},
+	)
+	for i := range reports {
+		r := &reports[i]
+		workers := r.MinWorkerVersion
+		if r.MinWorkerVersion != r.MaxWorkerVersion {
+			workers = fmt.Sprintf("%s - %s", r.MinWorkerVersion, r.MaxWorkerVersion)
+		}
+		upgradeAvailable := "No"
+		if r.UpgradeAvailable() {
+			upgradeAvailable = "Yes"
+		}
+		t.AddRow(r.Namespace, r.Name, r.ControlPlaneVersion, workers, upgradeAvailable, providerVersions(*r), providerUpgrades(*r))
+	}
+	return t.Print(os.Stdout, printOptions(rvo.wide))
+}
+
+// providerVersions returns the providers responsible for a cluster and their versions, e.g. "capi-system/cluster-api:v0.3.0".
+func providerVersions(r client.ClusterVersionReport) string {
+	var ret []string
+	for _, p := range r.Providers {
+		ret = append(ret, fmt.Sprintf("%s/%s:%s", p.Namespace, p.Name, p.Version))
+	}
+	return strings.Join(ret, " ")
+}
+
+// providerUpgrades returns the upgrades available for the providers responsible for a cluster, e.g. "capi-system/cluster-api:v0.3.1".
+func providerUpgrades(r client.ClusterVersionReport) string {
+	var ret []string
+	for _, p := range r.Providers {
+		if p.NextVersion == "" {
+			continue
+		}
+		ret = append(ret, fmt.Sprintf("%s/%s:%s", p.Namespace, p.Name, p.NextVersion))
+	}
+	return strings.Join(ret, " ")
+}
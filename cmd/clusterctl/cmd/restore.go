@@ -0,0 +1,72 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/client"
+)
+
+type restoreOptions struct {
+	kubeconfig string
+	directory  string
+}
+
+var ro = &restoreOptions{}
+
+var restoreCmd = &cobra.Command{
+	Use:   "restore",
+	Short: "Restores Cluster API objects (e.g. Cluster, Machines) saved with clusterctl backup to a management cluster",
+	Long: LongDesc(`
+		Restores Cluster API objects (e.g. Cluster, Machines) saved with clusterctl backup to a management cluster.
+
+		The management cluster must have all the required provider components already installed.
+		OwnerReferences between the restored objects are re-created, and Clusters are resumed only
+		after all the objects are restored.`),
+
+	Example: Examples(`
+		# Restores the Cluster API objects saved in the my-backup directory.
+		clusterctl restore --directory=my-backup`),
+
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if ro.directory == "" {
+			return errors.New("please specify the directory of the backup using the --directory flag")
+		}
+
+		return runRestore()
+	},
+}
+
+func init() {
+	restoreCmd.Flags().StringVarP(&ro.kubeconfig, "kubeconfig", "", "", "Path to the kubeconfig file to use for accessing the management cluster. If empty, default rules for kubeconfig discovery will be used")
+	restoreCmd.Flags().StringVarP(&ro.directory, "directory", "d", "", "The directory where the backup to be restored is stored")
+
+	RootCmd.AddCommand(restoreCmd)
+}
+
+func runRestore() error {
+	c, err := client.New(cfgFile)
+	if err != nil {
+		return err
+	}
+
+	return c.Restore(ctx, client.RestoreOptions{
+		Kubeconfig: ro.kubeconfig,
+		Directory:  ro.directory,
+	})
+}
@@ -17,17 +17,24 @@ limitations under the License.
 package cmd
 
 import (
+	"context"
 	"flag"
+	"fmt"
 	"os"
+	"os/signal"
 	"strings"
+	"syscall"
 
 	"github.com/MakeNowJust/heredoc"
 	"github.com/spf13/cobra"
 	logf "sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/log"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/printer"
 )
 
 var cfgFile string
 
+var noColor bool
+
 var RootCmd = &cobra.Command{
 	Use:          "clusterctl",
 	SilenceUsage: true,
@@ -37,7 +44,15 @@ var RootCmd = &cobra.Command{
 		Cluster API providers, and then use clusterctl for creating yaml templates for your workload clusters.`),
 }
 
+// ctx is the context of the operations run by the clusterctl commands; it is cancelled when clusterctl is interrupted,
+// so long running operations, e.g. downloads from GitHub or waits on the API server, can stop cleanly.
+var ctx = context.Background()
+
 func Execute() {
+	var cancel context.CancelFunc
+	ctx, cancel = signalContext()
+	defer cancel()
+
 	if err := RootCmd.Execute(); err != nil {
 		//TODO: print error stack if log v>0
 		//TODO: print cmd help if validation error
@@ -51,6 +66,34 @@ func init() {
 
 	RootCmd.PersistentFlags().AddGoFlagSet(flag.CommandLine)
 	RootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "Path to the the clusterctl config file (default is $HOME/.cluster-api/clusterctl.yaml)")
+	RootCmd.PersistentFlags().BoolVar(&noColor, "no-color", false, "Disable colors in the command output")
+}
+
+// signalContext returns a context that is cancelled when clusterctl receives an interrupt or a termination signal;
+// a second signal terminates clusterctl immediately, without waiting for the current operation to stop.
+func signalContext() (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	signals := make(chan os.Signal, 2)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-signals
+		fmt.Fprintln(os.Stderr, "Interrupted, stopping the current operation; interrupt again to exit immediately")
+		cancel()
+		<-signals
+		os.Exit(1)
+	}()
+
+	return ctx, cancel
+}
+
+// printOptions returns the options for rendering the command output; colors are disabled if requested
+// by the user or if not supported by the standard output.
+func printOptions(wide bool) printer.Options {
+	return printer.Options{
+		Wide:    wide,
+		NoColor: noColor || !printer.ColorSupported(os.Stdout),
+	}
 }
 
 const Indentation = `  `
@@ -17,13 +17,20 @@ limitations under the License.
 package cmd
 
 import (
+	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"os"
 	"sort"
-	"text/tabwriter"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/client"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/printer"
+	"sigs.k8s.io/yaml"
 )
 
 var upgradeCmd = &cobra.Command{
@@ -35,7 +42,12 @@ var upgradeCmd = &cobra.Command{
 }
 
 type upgradePlanOptions struct {
-	kubeconfig string
+	kubeconfig          string
+	wide                bool
+	inventoryNamespaces []string
+	planFile            string
+	managementGroup     string
+	contract            string
 }
 
 var up = &upgradePlanOptions{}
@@ -51,11 +63,24 @@ var upgradePlanCmd = &cobra.Command{
 
 		Then, for each provider in a management group, the following upgrade options are provided:
 		- The latest patch release for the current API Version of Cluster API (contract).
-		- The latest patch release for the next API Version of Cluster API (contract), if available.`),
+		- The latest patch release for the next API Version of Cluster API (contract), if available.
+
+		With --plan-file, the upgrade plan of a management group to an API Version of Cluster API (contract) is written
+		to a file in a machine-readable format, listing the current and the target version and contract of each provider
+		and the images of the target versions, so it can be reviewed and approved before being applied with
+		clusterctl upgrade apply --plan-file.`),
 
 	Example: Examples(`
 		# Gets the recommended target versions for upgrading Cluster API providers.
-		clusterctl upgrade plan`),
+		clusterctl upgrade plan
+
+		# Gets the recommended target versions for upgrading Cluster API providers, including the namespace
+		# each provider is watching.
+		clusterctl upgrade plan --wide
+
+		# Writes the upgrade plan of the capi-system/cluster-api management group to the v1alpha3 API Version
+		# of Cluster API (contract) to a file, for review.
+		clusterctl upgrade plan --management-group capi-system/cluster-api --contract v1alpha3 --plan-file upgrade-plan.yaml`),
 
 	RunE: func(cmd *cobra.Command, args []string) error {
 		return runUpgradePlan()
@@ -63,9 +88,14 @@ var upgradePlanCmd = &cobra.Command{
 }
 
 type upgradeApplyOptions struct {
-	kubeconfig      string
-	managementGroup string
-	contract        string
+	kubeconfig          string
+	managementGroup     string
+	contract            string
+	planFile            string
+	inventoryNamespaces []string
+	wait                bool
+	waitTimeout         time.Duration
+	output              string
 }
 
 var ua = &upgradeApplyOptions{}
@@ -77,12 +107,28 @@ var upgradeApplyCmd = &cobra.Command{
 		The upgrade apply command applies new versions of Cluster API providers as defined by clusterctl upgrade plan.
 		
 		New version should be applied for each management groups, ensuring all the providers on the same cluster API version
-		in order to guarantee the proper functioning of the management cluster.`),
+		in order to guarantee the proper functioning of the management cluster.
+
+		With --plan-file, the upgrade is executed exactly as defined in a plan file written by clusterctl upgrade plan;
+		the upgrade is refused if the management group has drifted from the plan, e.g. if a provider has been upgraded
+		or if the images of a target version have changed since the plan was written.
+
+		With --wait, the upgraded providers are verified by checking that all the provider deployments are ready, the
+		webhooks are serving, the conversion webhooks are converting objects and the controllers have reconciled all
+		the objects; the command fails if any of the checks does not pass within --wait-timeout, so automation can
+		gate subsequent steps on the verdict.`),
 
 	Example: Examples(`
 		# Upgrades all the providers in the capi-system/cluster-api to the latest version available which is compliant
 		# to the v1alpha3 API Version of Cluster API (contract).
-		clusterctl upgrade apply --management-group capi-system/cluster-api  --contract v1alpha3`),
+		clusterctl upgrade apply --management-group capi-system/cluster-api  --contract v1alpha3
+
+		# Upgrades the providers in the capi-system/cluster-api management group, then verifies they are working
+		# and prints the verdict in JSON format.
+		clusterctl upgrade apply --management-group capi-system/cluster-api  --contract v1alpha3 --wait -o json
+
+		# Upgrades the providers as defined in a plan file written by clusterctl upgrade plan.
+		clusterctl upgrade apply --plan-file upgrade-plan.yaml`),
 
 	RunE: func(cmd *cobra.Command, args []string) error {
 		return runUpgradeApply()
@@ -91,12 +137,22 @@ var upgradeApplyCmd = &cobra.Command{
 
 func init() {
 	upgradePlanCmd.Flags().StringVarP(&up.kubeconfig, "kubeconfig", "", "", "Path to the kubeconfig file to use for accessing the management cluster. If empty, default rules for kubeconfig discovery will be used")
+	upgradePlanCmd.Flags().BoolVarP(&up.wide, "wide", "", false, "Print additional information for each provider")
+	upgradePlanCmd.Flags().StringSliceVarP(&up.inventoryNamespaces, "inventory-namespace", "", nil, "Namespaces the clusterctl inventory should be scoped to; only the management groups with the core provider in one of them are planned")
+	upgradePlanCmd.Flags().StringVarP(&up.planFile, "plan-file", "", "", "Path of the file the upgrade plan of the management group to the contract is written to, in a machine-readable format")
+	upgradePlanCmd.Flags().StringVarP(&up.managementGroup, "management-group", "", "", "The management group the upgrade plan is written for; required with --plan-file")
+	upgradePlanCmd.Flags().StringVarP(&up.contract, "contract", "", "", "The API Version of Cluster API (contract) the upgrade plan is written for; required with --plan-file")
 
 	upgradeCmd.AddCommand(upgradePlanCmd)
 
 	upgradeApplyCmd.Flags().StringVarP(&ua.kubeconfig, "kubeconfig", "", "", "Path to the kubeconfig file to use for accessing the management cluster. If empty, default rules for kubeconfig discovery will be used")
 	upgradeApplyCmd.Flags().StringVarP(&ua.managementGroup, "management-group", "", "", "The management group that should be upgraded")
 	upgradeApplyCmd.Flags().StringVarP(&ua.contract, "contract", "", "", "The API Version of Cluster API (contract) the management group should upgrade to")
+	upgradeApplyCmd.Flags().StringVarP(&ua.planFile, "plan-file", "", "", "Path of an upgrade plan file written by clusterctl upgrade plan; the upgrade is executed exactly as defined in the plan")
+	upgradeApplyCmd.Flags().StringSliceVarP(&ua.inventoryNamespaces, "inventory-namespace", "", nil, "Namespaces the clusterctl inventory should be scoped to; the management group must have the core provider in one of them")
+	upgradeApplyCmd.Flags().BoolVarP(&ua.wait, "wait", "", false, "Verify the upgraded providers are working after applying the upgrade")
+	upgradeApplyCmd.Flags().DurationVarP(&ua.waitTimeout, "wait-timeout", "", 5*time.Minute, "The maximum duration of each check of the verification")
+	upgradeApplyCmd.Flags().StringVarP(&ua.output, "output", "o", "text", "Output format of the verification verdict. One of [text, json]")
 
 	upgradeCmd.AddCommand(upgradeApplyCmd)
 
@@ -109,8 +165,16 @@ func runUpgradePlan() error {
 		return err
 	}
 
-	upgradePlans, err := c.PlanUpgrade(client.PlanUpgradeOptions{
-		Kubeconfig: up.kubeconfig,
+	if up.planFile != "" {
+		return runUpgradePlanToFile(c)
+	}
+	if up.managementGroup != "" || up.contract != "" {
+		return errors.New("--management-group and --contract can only be used with --plan-file")
+	}
+
+	upgradePlans, err := c.PlanUpgrade(ctx, client.PlanUpgradeOptions{
+		Kubeconfig:          up.kubeconfig,
+		InventoryNamespaces: up.inventoryNamespaces,
 	})
 	if err != nil {
 		return err
@@ -133,17 +197,29 @@ func runUpgradePlan() error {
 		fmt.Println("")
 		fmt.Printf("Management group: %s, latest release available for the %s API Version of Cluster API (contract):\n", plan.CoreProvider.InstanceName(), plan.Contract)
 		fmt.Println("")
-		w := tabwriter.NewWriter(os.Stdout, 10, 4, 3, ' ', 0)
-		fmt.Fprintln(w, "NAME\tNAMESPACE\tTYPE\tCURRENT VERSION\tNEXT VERSION")
+		t := printer.NewTable(
+			printer.Column{Name: "NAME"},
+			printer.Column{Name: "NAMESPACE"},
+			printer.Column{Name: "TYPE"},
+			printer.Column{Name: "WATCHED NAMESPACE", Wide: true},
+			printer.Column{Name: "CURRENT VERSION"},
+			printer.Column{Name: "NEXT VERSION", Color: targetVersionColor},
+		)
 		for _, upgradeItem := range plan.Providers {
-			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", upgradeItem.Provider.Name, upgradeItem.Provider.Namespace, upgradeItem.Provider.Type, upgradeItem.Provider.Version, prettifyTargetVersion(upgradeItem.NextVersion))
+			t.AddRow(upgradeItem.Provider.Name, upgradeItem.Provider.Namespace, upgradeItem.Provider.Type, upgradeItem.Provider.WatchedNamespace, upgradeItem.Provider.Version, prettifyTargetVersion(upgradeItem.NextVersion))
 			if upgradeItem.NextVersion != "" {
 				upgradeAvailable = true
 			}
 		}
-		w.Flush()
+		if err := t.Print(os.Stdout, printOptions(up.wide)); err != nil {
+			return err
+		}
 		fmt.Println("")
 
+		if err := printCRDMigrations(plan); err != nil {
+			return err
+		}
+
 		if upgradeAvailable {
 			fmt.Println("You can now apply the upgrade by executing the following command:")
 			fmt.Println("")
@@ -158,6 +234,68 @@ func runUpgradePlan() error {
 	return nil
 }
 
+// runUpgradePlanToFile writes the upgrade plan of a management group to a plan file.
+func runUpgradePlanToFile(c client.Client) error {
+	if up.managementGroup == "" || up.contract == "" {
+		return errors.New("--management-group and --contract are required with --plan-file")
+	}
+
+	planFile, err := c.ExportUpgradePlan(ctx, client.ExportUpgradePlanOptions{
+		Kubeconfig:          up.kubeconfig,
+		ManagementGroup:     up.managementGroup,
+		Contract:            up.contract,
+		InventoryNamespaces: up.inventoryNamespaces,
+	})
+	if err != nil {
+		return err
+	}
+
+	out, err := yaml.Marshal(planFile)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal the upgrade plan to yaml")
+	}
+	if err := ioutil.WriteFile(up.planFile, out, 0600); err != nil {
+		return errors.Wrapf(err, "failed to write the upgrade plan to %s", up.planFile)
+	}
+
+	fmt.Printf("Upgrade plan for the %s management group written to %s\n", planFile.ManagementGroup, up.planFile)
+	fmt.Println("")
+	fmt.Println("After reviewing the plan, you can apply the upgrade by executing the following command:")
+	fmt.Println("")
+	fmt.Println(fmt.Sprintf("   upgrade apply --plan-file %s", up.planFile))
+	fmt.Println("")
+	return nil
+}
+
+// printCRDMigrations prints the storage version migrations executed before upgrading the providers, if any.
+func printCRDMigrations(plan client.UpgradePlan) error {
+	t := printer.NewTable(
+		printer.Column{Name: "PROVIDER"},
+		printer.Column{Name: "CUSTOM RESOURCE DEFINITION"},
+		printer.Column{Name: "DROPPED VERSIONS"},
+		printer.Column{Name: "STORAGE VERSION"},
+		printer.Column{Name: "OBJECTS"},
+	)
+	migrations := 0
+	for _, upgradeItem := range plan.Providers {
+		for _, m := range upgradeItem.CRDMigrations {
+			t.AddRow(upgradeItem.InstanceName(), m.CRD, strings.Join(m.DroppedVersions, ","), m.StorageVersion, strconv.Itoa(m.Objects))
+			migrations++
+		}
+	}
+	if migrations == 0 {
+		return nil
+	}
+
+	fmt.Println("The following objects are stored in API versions dropped by the next versions, and they will be migrated to the current storage version before upgrading:")
+	fmt.Println("")
+	if err := t.Print(os.Stdout, printOptions(up.wide)); err != nil {
+		return err
+	}
+	fmt.Println("")
+	return nil
+}
+
 func sortUpgradeItems(plan client.UpgradePlan) {
 	sort.Slice(plan.Providers, func(i, j int) bool {
 		return plan.Providers[i].Provider.Type < plan.Providers[j].Provider.Type ||
@@ -173,25 +311,159 @@ func sortUpgradePlans(upgradePlans []client.UpgradePlan) {
 	})
 }
 
+const alreadyUpToDate = "Already up to date"
+
 func prettifyTargetVersion(version string) string {
 	if version == "" {
-		return "Already up to date"
+		return alreadyUpToDate
 	}
 	return version
 }
 
+// targetVersionColor highlights the providers with an upgrade available.
+func targetVersionColor(version string) printer.Color {
+	if version == alreadyUpToDate {
+		return printer.Green
+	}
+	return printer.Yellow
+}
+
 func runUpgradeApply() error {
 	c, err := client.New(cfgFile)
 	if err != nil {
 		return err
 	}
 
-	if err := c.ApplyUpgrade(client.ApplyUpgradeOptions{
-		Kubeconfig:      ua.kubeconfig,
-		ManagementGroup: ua.managementGroup,
-		Contract:        ua.contract,
-	}); err != nil {
+	if ua.output != "text" && ua.output != "json" {
+		return errors.Errorf("invalid output format %q, please use one of [text, json]", ua.output)
+	}
+
+	options := client.ApplyUpgradeOptions{
+		Kubeconfig:          ua.kubeconfig,
+		ManagementGroup:     ua.managementGroup,
+		Contract:            ua.contract,
+		InventoryNamespaces: ua.inventoryNamespaces,
+	}
+	managementGroup := ua.managementGroup
+	if ua.planFile != "" {
+		planFile, err := readUpgradePlanFile(ua.planFile)
+		if err != nil {
+			return err
+		}
+		options.PlanFile = planFile
+		managementGroup = planFile.ManagementGroup
+	}
+
+	if err := c.ApplyUpgrade(ctx, options); err != nil {
+		return err
+	}
+
+	if !ua.wait {
+		return nil
+	}
+
+	checks, err := c.VerifyUpgrade(ctx, client.VerifyUpgradeOptions{
+		Kubeconfig:          ua.kubeconfig,
+		ManagementGroup:     managementGroup,
+		InventoryNamespaces: ua.inventoryNamespaces,
+		Timeout:             ua.waitTimeout,
+	})
+	if err != nil {
+		return err
+	}
+
+	verdict := newUpgradeVerdict(managementGroup, checks)
+	if err := printUpgradeVerdict(verdict, ua.output); err != nil {
+		return err
+	}
+	if !verdict.Passed {
+		return errors.Errorf("verification of the upgrade of the %s management group failed", managementGroup)
+	}
+	return nil
+}
+
+// readUpgradePlanFile reads an upgrade plan file written by upgrade plan.
+func readUpgradePlanFile(path string) (*client.UpgradePlanFile, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read the upgrade plan file %s", path)
+	}
+
+	planFile := &client.UpgradePlanFile{}
+	if err := yaml.UnmarshalStrict(data, planFile); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse the upgrade plan file %s", path)
+	}
+	return planFile, nil
+}
+
+// upgradeVerdict is the outcome of the verification of an upgrade.
+type upgradeVerdict struct {
+	ManagementGroup string                `json:"managementGroup"`
+	Passed          bool                  `json:"passed"`
+	Checks          []upgradeVerdictCheck `json:"checks"`
+}
+
+// upgradeVerdictCheck is the outcome of a check of the verification of an upgrade.
+type upgradeVerdictCheck struct {
+	Name     string `json:"name"`
+	Passed   bool   `json:"passed"`
+	Duration string `json:"duration"`
+	Error    string `json:"error,omitempty"`
+}
+
+// newUpgradeVerdict returns the verdict for the checks of the verification of an upgrade; the verification
+// passed only if all the checks were executed and passed.
+func newUpgradeVerdict(managementGroup string, checks []client.UpgradeCheck) upgradeVerdict {
+	verdict := upgradeVerdict{
+		ManagementGroup: managementGroup,
+		Passed:          len(checks) > 0,
+		Checks:          []upgradeVerdictCheck{},
+	}
+	for _, check := range checks {
+		c := upgradeVerdictCheck{
+			Name:     check.Name,
+			Passed:   check.Error == nil,
+			Duration: check.Duration.Round(time.Second).String(),
+		}
+		if check.Error != nil {
+			c.Error = check.Error.Error()
+			verdict.Passed = false
+		}
+		verdict.Checks = append(verdict.Checks, c)
+	}
+	return verdict
+}
+
+func printUpgradeVerdict(verdict upgradeVerdict, output string) error {
+	if output == "json" {
+		j, err := json.MarshalIndent(verdict, "", "  ")
+		if err != nil {
+			return errors.Wrap(err, "failed to marshal the verdict to json")
+		}
+		fmt.Println(string(j))
+		return nil
+	}
+
+	fmt.Println("")
+	t := printer.NewTable(
+		printer.Column{Name: "CHECK"},
+		printer.Column{Name: "DURATION"},
+		printer.Column{Name: "RESULT"},
+		printer.Column{Name: "ERROR"},
+	)
+	for _, c := range verdict.Checks {
+		result := "Passed"
+		if !c.Passed {
+			result = "Failed"
+		}
+		t.AddRow(c.Name, c.Duration, result, c.Error)
+	}
+	if err := t.Print(os.Stdout, printOptions(false)); err != nil {
 		return err
 	}
+
+	if verdict.Passed {
+		fmt.Printf("\nUpgrade of the %s management group verified\n", verdict.ManagementGroup)
+	}
 	return nil
 }
@@ -50,7 +50,7 @@ func cmd_clusterctl_config_manifest_cert_manager_yaml() ([]byte, error) {
 	)
 }
 
-var _cmd_clusterctl_config_manifest_clusterctl_api_yaml = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xb4\x55\x4d\x6f\xdc\x36\x10\xbd\xeb\x57\x0c\xd2\x43\x2e\x5d\x6d\xd3\xa0\x40\xa1\x5b\xe0\xf6\x60\xf4\x03\x46\x6c\x38\x87\xa2\x07\x2e\x39\x2b\x4d\x4c\x91\x2c\x67\xb8\x89\x5b\xf4\xbf\x17\x24\x25\xed\x4a\x6e\x5d\x5f\xa2\x93\xf8\x38\x5f\x7c\xc3\x37\x54\x81\xee\x31\x32\x79\xd7\x81\x0a\x84\x9f\x05\x5d\x5e\x71\xfb\xf0\x3d\xb7\xe4\xf7\xa7\x37\xcd\x03\x39\xd3\xc1\x55\x62\xf1\xe3\x7b\x64\x9f\xa2\xc6\x1f\xf0\x48\x8e\x84\xbc\x6b\x46\x14\x65\x94\xa8\xae\x01\x50\xce\x79\x51\x19\xe6\xbc\x04\xd0\xde\x49\xf4\xd6\x62\xdc\xf5\xe8\xda\x87\x74\xc0\x43\x22\x6b\x30\x96\xe0\x73\xea\xd3\x37\xed\xb7\xed\x77\x0d\x80\x8e\x58\xdc\xef\x68\x44\x16\x35\x86\x0e\x5c\xb2\xb6\x01\x70\x6a\xc4\x0e\x42\xf4\x27\x32\x18\xb9\xd5\x36\xb1\x60\xd4\x62\xe7\xdf\xf6\xf3\xae\x16\xdd\x70\x40\x9d\xf3\xf7\xd1\xa7\xd0\xc1\x73\xa6\x35\xf0\x5c\xad\x12\xec\x7d\xa4\x79\xbd\x9b\x5d\x77\x2a\x50\x41\x2a\x17\x37\x53\x15\x05\xb2\xc4\xf2\xd3\x0a\xfe\x99\x58\xca\x56\xb0\x29\x2a\x7b\x51\x75\x41\x99\x5c\x9f\xac\x8a\x67\xbc\x01\x60\xed\x03\x76\xf0\x6b\x2e\x26\x28\x8d\xa6\x01\x98\xe8\x29\xc5\xec\x40\x19\x53\x08\x57\xf6\x26\x92\x13\x8c\x57\xde\xa6\xd1\x2d\xa5\x7e\x64\xef\x6e\x94\x0c\x1d\xb4\xf2\x18\xb0\xa0\x33\x6d\x77\x67\x20\xef\x75\xc0\x12\xc9\xf5\x4f\x3d\xa7\x94\x2b\xe7\xfb\x15\xf6\xbc\xff\x27\x25\x7a\x40\xb3\x1c\x63\x15\xe8\x43\xde\x84\xed\xde\x93\x80\xd5\xf8\xf4\x46\xd9\x30\xa8\xb7\x95\x32\x3d\xe0\xa8\xba\xc9\xc3\x07\x74\xef\x6e\xae\xef\xdf\xde\xae\x60\x00\x83\xac\x23\x05\x29\x77\x6a\xee\x06\x10\x83\x0c\x08\xd5\x18\x8e\x3e\x96\xe5\xd2\x13\x78\x77\x73\xbd\x44\x08\xd1\x07\x8c\xb2\xdc\x80\xfa\x5d\xa8\xe4\x02\xdd\xe4\x7b\x9d\x4b\xaa\x56\x60\xb2\x3c\xb0\xe6\x9d\x28\x45\x33\x9d\x02\xfc\x11\x64\x20\x86\x88\x21\x22\xa3\xab\x82\x59\x05\x86\x6c\xa4\x1c\xf8\xc3\x47\xd4\xd2\xc2\x2d\xc6\x1c\x06\x78\xf0\xc9\x9a\xac\xaa\x13\x46\x81\x88\xda\xf7\x8e\xfe\x5c\x62\x33\x88\x2f\x49\xad\x12\x9c\x2e\xe1\xf9\x2b\xb7\xc6\x29\x0b\x27\x65\x13\x7e\x0d\xca\x19\x18\xd5\x23\x44\xcc\x59\x20\xb9\x8b\x78\xc5\x84\x5b\xf8\xc5\x47\x04\x72\x47\xdf\xc1\x20\x12\xb8\xdb\xef\x7b\x92\x79\x3a\x68\x3f\x8e\xc9\x91\x3c\xee\x8b\xd0\xe9\x90\xc4\x47\xde\x1b\x3c\xa1\xdd\x33\xf5\x3b\x15\xf5\x40\x82\x5a\x52\xc4\xbd\x0a\xb4\x2b\xa5\xbb\x32\x21\xda\xd1\x7c\x15\xa7\x79\xc2\xaf\x57\xb5\x3e\xb9\x13\xf5\x2b\xea\x7b\xa6\x03\x59\x86\xb9\xdd\x6a\x72\xad\xa7\x38\x13\x9d\xa1\xcc\xce\xfb\x1f\x6f\xef\x60\x4e\x5d\x9a\xb1\x65\xbf\xf0\x7e\x76\xe4\x73\x0b\x32\x61\xe4\x8e\x18\x6b\x13\x8f\xd1\x8f\x25\x26\x3a\x13\x3c\x39\x29\x0b\x6d\x09\xdd\x96\x7e\x4e\x87\x91\x24\xf7\xfd\x8f\x84\x2c\xb9\x57\x2d\x5c\x95\x91\x09\x07\x84\x14\x8c\x12\x34\x2d\x5c\x3b\xb8\x52\x23\xda\x2b\xc5\xf8\xc5\x1b\x90\x99\xe6\x5d\x26\xf6\x65\x2d\xb8\x9c\xf6\x5b\xe3\xca\x5a\xb3\x81\xff\xbb\x5f\x79\x2a\x01\x39\x43\x79\xee\x56\xb1\x64\x8f\x2a\x90\xb3\x42\x33\xf7\xb8\xc8\xf9\x62\x94\xcd\x5f\x96\xb4\x2a\x73\x38\xbb\x72\x0a\xc1\x47\x59\xee\xf0\x4b\x4e\x75\xfa\x5f\x75\xcf\xca\x5e\x97\xab\xfd\x18\xbc\x43\x27\x73\x84\xf6\x25\xd9\xb6\x43\xf2\x99\xb4\x1f\x36\xa6\x9b\xfc\x6e\xc1\x3f\x0d\x18\x71\x45\xdb\x86\xa4\xf3\x4b\x9c\x15\x42\x5c\xab\x20\xd7\xb7\x40\x47\xc0\x31\xc8\xe3\xca\x7d\xe3\x30\x5b\xff\x0b\xf5\xb5\xed\x0c\xe4\x40\x59\x7b\xae\x89\x5f\x40\xc6\x93\x8b\xc3\x59\x66\xa6\x03\x89\xa9\xb6\x99\xc5\x47\xd5\xe3\x25\x92\x0e\xcb\xd8\xe8\xe0\xaf\xbf\x1b\x16\x25\xa9\x0c\x6c\xa5\x35\x06\x99\xf8\xea\x2e\x5e\xec\x57\xaf\x56\x0f\x72\x59\x6a\xef\xea\x8b\xca\x1d\xfc\xf6\x7b\x53\x53\xa1\xb9\x9f\x5f\xdd\x0c\xfe\x13\x00\x00\xff\xff\xaf\x43\x9c\x79\x20\x09\x00\x00")
+var _cmd_clusterctl_config_manifest_clusterctl_api_yaml = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\x03\xed\x19\xdb\x6e\x1c\xb7\xf5\x7d\xbf\x82\x50\x1f\x9c\x00\xda\x51\x5c\xa3\x40\xb1\x6f\xae\x6c\x20\x42\x6d\x47\x90\x64\xe7\xa1\xe8\x03\x77\x86\xda\x65\xcc\x21\xa7\x24\x67\xa5\x6d\x90\x7f\xef\x39\xe4\x90\x33\x9c\xdb\xce\xda\x09\x0a\x03\x59\x18\xb0\xe6\x90\x3c\x3c\xf7\x1b\x69\xc5\x3f\x31\x6d\xb8\x92\x1b\x42\x2b\xce\x9e\x2d\x93\xf8\x65\xb2\xcf\x7f\x37\x19\x57\x57\x87\x97\xab\xcf\x5c\x16\x1b\x72\x5d\x1b\xab\xca\x3b\x66\x54\xad\x73\xf6\x86\x3d\x72\xc9\x2d\xec\x5c\x95\xcc\xd2\x82\x5a\xba\x59\x11\x42\xa5\x54\x96\x22\xd8\xe0\x27\x21\xb9\x92\x56\x2b\x21\x98\x5e\xef\x98\xcc\x3e\xd7\x5b\xb6\xad\xb9\x28\x98\x76\xc8\xc3\xd5\x87\x1f\xb2\xbf\x66\x7f\x83\x13\xb9\x66\xee\xf8\x03\x2f\x99\xb1\xb4\xac\x36\x44\xd6\x42\xc0\x8a\xa4\x25\xdb\x90\x4a\xab\x03\x87\xd3\x26\xcb\x05\x10\xc4\x74\x6e\x45\xf8\x33\x7b\x5e\x7b\xa2\x57\xa6\x62\x39\xde\xbf\xd3\xaa\x06\x0c\x73\x5b\x3d\xe2\x40\x2d\xb5\x6c\xa7\x34\x0f\xdf\xeb\x70\x74\x0d\xb2\x71\x10\x2f\x8b\xdb\x86\x0a\x07\x12\xdc\xd8\x7f\x26\xe0\x77\x00\x71\x4b\x95\xa8\x35\x15\x1d\xaa\x1d\xd4\x70\xb9\xab\x05\xd5\x2d\x1c\xc0\x26\x57\x15\xf0\xf7\x01\x89\xa9\x68\xce\x0a\x80\x35\xe2\x71\xc4\xac\x09\x2d\x0a\x27\x70\x2a\x6e\x35\x97\x40\xd4\xb5\x12\x75\x29\x23\xa9\xbf\x18\x25\x6f\xa9\xdd\x6f\x48\x66\x8f\x15\x73\xd0\x20\xb6\x87\x16\x80\x6b\x1b\x62\x2c\xe0\xd8\x0d\x4f\x36\x57\x26\x87\x3f\x25\xb0\xf9\xf3\x4f\xd4\xe6\x7b\x56\x44\x36\x12\x44\x3f\xe3\x22\xe9\xaf\xcd\x23\x04\x23\xb0\x35\x68\x1b\xed\x88\xe6\x36\xc1\x77\x9d\x02\x17\x21\xda\x33\x2a\xec\x3e\x41\xf3\x63\x17\xb4\x08\x89\xa0\xc6\x7e\xac\xc0\xe8\x9d\x9a\x5a\x4c\xef\x00\x4e\xd2\x05\x8f\x0f\x21\xab\x76\xdf\xe1\x25\x15\xd5\x9e\xbe\xf2\xe6\x00\x02\x2b\xe9\xa6\xd9\x0f\x56\x20\x5f\xdf\xde\x7c\x7a\x75\x9f\x80\x09\x29\x98\xc9\x35\xaf\xac\xf3\x97\x60\x69\x84\x1b\x62\xf7\x8c\xf8\xcd\xe4\x51\x69\xf7\x19\xed\x8d\x00\xaa\x88\x01\xa0\x15\xd3\x36\x5a\xb7\xff\xd1\x36\x02\x74\xa0\xbd\xfb\x5e\x20\x49\x7e\x17\x2c\x80\xeb\x33\x7f\x6f\x63\x2e\xac\x68\xb8\x20\xea\x11\xe0\x40\x94\x66\x95\x66\x86\x49\x1f\x0c\x12\xc4\x04\x37\x51\x49\xd4\xf6\x17\x96\xdb\x8c\xdc\x33\x8d\x68\x88\xd9\xab\x5a\x14\x18\x31\xe0\xd3\x02\x86\x5c\xed\x24\xff\x6f\xc4\x0d\x37\x2a\x77\xa9\x00\x61\x1a\xdb\xc3\xe9\x3c\x02\x7c\x83\x1c\xa8\xa8\xd9\x25\x5c\x50\x90\x92\x1e\x01\x0d\xde\x42\x6a\xd9\xc1\xe7\xb6\x98\x8c\xbc\x57\x9a\xc1\xc1\x47\xb5\x21\x7b\x6b\x2b\xb3\xb9\xba\xda\x71\x1b\x22\x5f\xae\xca\xb2\x86\x18\x77\xbc\x72\xc6\xc7\xb7\xb5\x55\xda\x5c\x15\xec\xc0\xc4\x95\xe1\xbb\x35\xd5\xf9\x9e\x5b\xc0\x5e\x6b\x76\x05\x62\x5c\x3b\xd2\xa5\x8b\x7e\x59\x59\xfc\x45\x37\xb1\xd2\xbc\x48\x68\x1d\x58\x98\xff\x81\xd4\x00\xff\x71\x46\x09\x3f\xfa\x1d\x4e\x32\xba\xf0\x0a\xe0\x12\x4c\x52\x08\xc7\x6e\x5d\xed\x34\x2d\x18\x9a\x90\xf6\x31\x98\xc0\x5f\x60\x13\x65\xb4\xc6\xa8\x01\x99\xd8\x89\x47\x23\x73\x90\x1b\x60\x66\x1a\x64\xf4\xa8\x55\xe9\xf6\x28\x08\xd6\x60\xd5\x8d\xec\x4b\x65\x9c\x6a\x80\xcd\x2c\xc1\x09\x82\x28\xcd\xa6\x77\xcd\xa8\xcd\xfe\x14\xc8\x8b\x8c\xa0\x31\x44\x60\x24\x19\x89\xa4\x3d\x84\x64\x48\x72\xb6\x1a\x6c\x18\xb1\x72\xff\x6b\x13\xc1\xa8\xc9\x8f\x10\x7d\xdd\x3f\x11\x3c\xae\xb1\x7c\xb4\xe5\x16\x2b\xa9\xcd\x40\xd4\xfe\x87\x9e\xd9\x70\x06\x6a\xf7\x82\x0d\x2c\x67\x23\x27\x26\x8c\x24\xb0\xc8\x0e\x5c\xd5\x66\x29\x13\xb7\xe9\x7e\xef\xa4\xad\xf6\x2f\x09\x47\x87\x3c\x9e\x4d\x07\x38\xb9\x32\x1c\x6d\xf2\xe3\xdd\xbb\x93\x54\xdc\x75\x77\x07\x31\xe2\x9f\x0d\x39\x2d\xb6\x84\xba\x51\x71\x82\x6f\x56\x10\x76\xa4\x35\xe4\x09\xcc\x15\xce\x52\x6f\xb2\x67\xf3\x60\x63\xa1\x71\x8a\xfe\x58\x92\x04\x82\x67\x15\x88\x9a\xa6\xd6\x47\xfe\x35\x5e\x72\x36\x61\xb8\x78\x92\x26\xd8\xb4\x88\x9c\xd9\xab\xc0\x6a\xf5\xc9\xab\x3e\xc2\x26\xf2\xb4\x57\x1d\x07\xfd\xba\x5b\x0f\x0b\xad\x77\xc2\x6a\x43\xe8\x03\x3a\xb6\xc7\xaf\x22\x45\xb3\xff\xd4\x1c\x62\x5e\x9f\x92\x75\x6b\x1c\xc3\x95\xb6\xa0\x6a\x81\x69\xf1\x94\xde\xec\xb3\xdd\x48\x22\xa0\x5a\xd3\x63\x07\xee\x2a\xcc\x99\x4c\x8c\xa5\x26\x7a\x0f\x6d\x98\xf1\xd9\xac\x4d\xb8\x21\xbc\xdc\xbd\xbd\x7f\x20\x21\x05\xb9\xa4\xdc\xcf\x01\x8e\xa2\xf6\xa0\x69\x53\x31\x26\x4e\xc8\x8b\x4c\xfb\x64\x1e\x73\x01\x93\x45\xa5\x20\xd3\xba\x8f\x5c\x70\x38\xd5\x43\x6a\xea\x6d\xc9\xad\x71\x32\x05\xd1\x61\xce\xce\xc8\xb5\x6b\x0b\xc8\x96\x41\x8a\x72\xb5\x51\x46\x6e\x24\x40\x4b\x26\xae\xa9\x61\x7f\x78\x22\x46\x41\x9b\x35\x0a\x76\x59\x2a\xee\x76\x34\x27\xf5\xe8\x4b\xc2\x19\x8d\xdd\xbb\x0d\x2e\xbc\x69\xeb\xa3\x1e\x9e\x61\xe3\x06\xed\x92\x30\xd4\x3a\x58\x62\x0e\x14\x66\x50\x3f\xce\xde\x3b\x2d\xcd\x6a\x59\xf6\x5b\x90\xfb\xbe\x26\xf3\x3d\xed\x79\xbe\x1f\xf1\x3b\xe4\x23\xa8\x3d\xf2\x0e\x25\xf4\xea\x0c\x07\x0d\xc5\xff\x29\x8a\x9b\x6d\x81\x50\xa8\x59\xbb\xc1\xa3\x61\xc8\x81\xbf\x0b\x28\xbf\x1f\x21\x99\x97\x95\x60\x25\xd8\x4f\x1b\x5a\xa2\x8e\x02\xf3\x5e\x9b\xb0\x81\x4b\xf2\x53\xa3\x97\xe6\xae\xb3\x58\xf3\xed\xc8\x09\xc6\x7c\x83\x12\x2d\x88\xf7\xec\xe6\x0d\xab\x84\x3a\x96\x2e\x1b\x52\x70\x24\x7a\xa0\x5c\xd0\xad\x60\x67\x51\xd2\xe9\x69\x4e\x90\xf3\xae\xdd\x19\x44\x8d\x81\xb2\x6b\x0d\x7d\xad\x8f\x48\x79\xca\x0e\x4e\x27\xce\x19\x26\x20\x5c\x1b\xba\x63\x27\x18\x78\xef\x77\x11\xf6\x5c\x09\x0a\x5e\x07\xc6\xdb\x53\x33\xb0\x55\x4b\xaf\x9b\xa3\x2b\x8f\xb8\x1d\x33\x94\xf3\xcc\x58\xa5\x76\x72\x82\xc8\x9e\x55\x8d\x78\x5f\x42\x71\xeb\x5a\x23\x84\x6a\xf6\x88\x61\x1d\x62\xf1\x72\x7a\x27\xc2\xdd\xb0\x2c\x19\x16\x24\x10\x68\x39\x8e\x52\x1a\xdb\xe8\xd4\x28\x81\x5c\x4c\x35\x2c\x76\x04\x0f\xc3\x64\x8a\xf5\x32\x75\xa3\x15\x3c\x6a\xea\xaa\x71\x37\xdf\xba\x2d\x09\xe2\x87\x93\x4d\x6d\x94\x6c\x42\x6e\x2c\x2d\x03\x86\x6c\xc9\x6d\xfd\xb9\xc7\xcc\xb5\x3f\xf7\xb6\xf6\xee\x97\x11\xfe\xb4\xc7\xd2\x76\xa6\x16\x6e\x87\x6b\x68\x1d\xf0\xcf\x51\x01\x84\x65\x68\xb2\xac\xac\xec\xd1\x37\xc2\x54\x18\x35\xb8\x17\xfb\xae\x7e\xb3\xd2\xdf\x73\xcf\x04\xa8\x1f\x75\x01\x94\x34\x08\x13\xb3\x4b\x49\x08\xf7\x8f\x28\xd3\x1b\x92\xc1\x78\x89\xfd\x6a\xe4\xd2\x7c\x91\x78\x03\x5d\x67\x88\x39\x1c\x71\xee\x6c\x98\xbd\x4c\xe4\x4e\xed\x72\x41\x07\x2e\xbd\x58\x91\x9b\x44\x6f\x58\x3e\x35\x1b\xfc\xb4\x62\xcb\x04\x5c\xe8\x6f\x5f\x9a\xad\x1d\x8a\xb7\xcf\x58\x9a\x99\x76\x9a\x3a\xc9\x6c\x7f\xbb\x2f\x10\x83\xfb\xa4\x24\x84\x7a\xd7\x25\x8c\xb1\x52\xf9\xc1\x35\x63\xed\x1e\xa7\xfc\xd7\x1f\xde\x40\xdd\x36\xd8\x3d\xda\xf6\x0f\xc8\x7b\x3d\x43\x42\x53\xcb\x86\x15\xa7\x0a\x94\x37\x06\xe6\xd1\xde\xcf\x87\x00\x30\x6c\xf2\x99\x05\xfb\x0e\x03\x84\x80\x40\x33\x11\x1d\x0a\x76\xb9\x4d\xcd\xd4\x67\x04\xe7\xdc\xc8\xc0\x95\xe4\xec\x38\xbe\xd0\x63\x13\x6f\x6a\xc2\xb4\xe7\x17\x01\xd1\xb6\x22\x8b\xb4\xaa\xa0\x74\x36\x13\x18\xc9\x68\x98\x5e\xd0\x4f\x35\xd3\x43\x27\x85\x45\xe4\x46\x91\xb5\x2d\x80\x17\xea\x0b\xe3\x05\x88\x96\xb4\xe7\x15\xce\x7e\x50\x43\x76\x92\x62\xb0\xb1\x30\x53\xfb\x44\x05\x2f\x22\x6a\x6f\x3b\x37\xf2\x92\x7c\x50\x16\xff\x7b\xfb\xcc\xb1\x2b\x40\x7d\xbc\x51\xcc\x00\xd4\x41\xbe\x98\x61\x7f\xed\x22\x76\xfd\x56\x67\x6e\xd2\x77\x5d\x2e\xb5\x74\x9a\x28\x20\xff\xa6\xdb\x4b\x2b\x3d\xc9\x31\x60\x81\x16\x06\x64\xd7\xf0\xe5\x52\xb3\xbf\xc0\xa3\x2e\xa1\x16\xc2\x8e\x47\x2a\xb9\x76\x81\x33\xe0\x9e\x16\x62\x50\x07\xe0\x6e\xc4\x04\x1f\x5d\x29\x4d\x5c\x33\x89\x71\xdb\xc4\xec\x0c\x5c\x9a\x87\x13\x7e\x38\x2b\xf0\x89\x81\x14\xb5\x63\xde\xb5\x92\xf8\xf4\xc1\x73\xa8\xa0\xf4\x6e\x9a\xc6\x0a\xe3\xcc\x94\xb2\x26\x63\xc1\x42\x5d\x4e\x75\xc4\xa7\xfa\x74\xdf\x7c\x83\xdd\x8e\xc2\x67\x54\x39\xd9\x99\xcf\xd3\xe2\x82\xed\x3b\x74\xf0\x11\x6e\xbb\xaf\x34\x73\x51\x65\x56\x1a\xc3\xd8\xee\xaf\xf3\xb1\xb2\xa4\x6e\x04\xf5\x2b\x86\x3f\x67\x0c\xbf\x81\x5e\xb8\x06\xeb\x7d\xed\xde\x96\x04\xeb\xae\x8d\xd5\xad\x7e\xfa\xdb\x45\x8c\x38\x01\x39\x4a\x18\x8e\x61\x58\x46\xa7\x97\x84\xf9\x4e\x08\xef\xeb\xe7\x98\xcb\x11\xcc\x4f\x7b\x65\x7c\xc4\x7d\xe4\x4c\xb8\xf6\xe0\x02\xbe\x2e\x2e\x13\xaf\x72\xe0\x1b\x79\xe1\x83\x77\xdf\xa6\x57\x63\xe3\x3e\x9f\x0f\x88\x92\xe2\x48\x2e\xdc\xee\x8b\xec\xac\x44\x75\x62\x0a\x33\x58\xe8\x57\x1c\xe6\x8c\x52\xc3\x74\x4a\x8b\xd6\x1e\xba\x15\x42\x2c\xed\x56\x13\xd3\xed\xf1\x8a\xe3\xd2\xcf\x91\xe3\xeb\x4e\x5b\x89\xc4\x5c\x3f\x55\x58\x8d\x3a\xe7\x89\xe2\x3f\x55\xc7\x40\x50\xbe\x3f\xd9\x10\xab\x6b\xcf\x07\x3e\x4f\x60\xf7\xd5\x81\xd4\xdb\xf8\x0a\x12\x2e\x6f\x06\x26\xe4\xd7\xdf\x56\xed\xec\x84\xe6\x39\xab\x6c\x23\xc3\x4d\xe7\xa9\xf5\xe2\x22\x79\x49\x75\x9f\x20\x1c\x2f\x54\xc0\xf2\xaf\x7f\xaf\xfc\xc5\xb1\x53\xf2\xc0\xf5\x7a\xbd\xa2\xdf\xd8\xe3\x76\x73\xa2\x52\x82\xe7\x10\x36\xfe\xaf\x4f\xdc\x8d\xe0\x6e\x91\x96\x63\xef\x9d\x3b\x59\x1b\x3c\x76\xf7\xb8\xe8\x3d\x79\x77\x57\x8f\xed\xbb\x77\x33\x9c\x19\x3c\x7a\xff\x4e\xef\xa5\x09\xc5\xc9\x0b\x66\x7f\xb2\x63\xb0\xa0\x57\x4f\x7e\xb2\x83\xb1\x56\x82\x45\x97\xe9\xa0\x33\x48\x98\xfc\x43\xd9\x7d\x77\xec\x81\x56\xe3\x62\x5a\xf7\x49\xa8\x79\x95\x03\x3f\xa8\x70\xde\xda\x69\xe2\x3b\x28\x35\x95\x3b\xa0\xc8\x53\xe6\xc6\x4e\xa1\xaf\x48\x49\x0f\x6d\x14\xc3\x7a\x00\x3d\x3f\xc6\xf2\x19\x3a\xff\x7c\x01\xfe\x26\x5e\x80\xff\x9c\xfc\x7f\x5b\x93\xff\x98\x87\x67\x94\x76\x1b\x73\x75\xd7\x69\x42\x88\x89\xd3\x64\xf4\x7e\x97\xdb\x19\xcd\xf7\xed\x94\xac\x27\xe2\x16\xd9\x13\xb7\xe0\x2b\x10\x6b\x7a\x28\xb0\x00\x42\x81\x83\x5e\x81\xb5\xdc\xf6\x2b\xa1\xe5\xaf\xf4\x8d\xaf\xdf\x39\xb4\x5d\xda\xfd\x45\xd8\xf1\xf5\x02\xe6\xd8\x24\x17\x08\x9c\x62\x66\xae\xe9\x76\x18\x6f\x35\xbb\x83\xf2\x13\xcc\x64\xc1\x80\xa1\x77\xc0\x63\x30\xf8\x52\xbe\xd6\x1e\xd6\xd2\xfb\x1d\xcb\x76\xe3\x3d\x0c\x64\xea\x57\xd9\x0f\x6b\x9d\x67\x2f\xbf\x77\x32\x6e\xa2\xab\xe3\x79\xfa\x55\x71\xab\x14\x5c\x21\x07\xeb\x25\x7d\x5e\xfa\x44\xff\x3e\x6e\x4d\x84\x0d\x18\x78\x59\x97\x7d\x83\xc1\xd9\x15\x06\x77\x7e\x60\xe3\x8c\xdc\x3c\x76\xa6\x75\xe8\x61\x06\xcc\x02\x7c\x10\x24\x0e\xd4\xd6\xb2\x38\xfb\xb5\xb6\xe4\x72\x31\x2f\x71\x6b\xca\x0b\x64\xc7\xdf\x8f\x17\xc4\xf1\xa5\xbc\xb8\xa2\xe2\x14\x17\x58\x89\x8e\x4e\xd9\x7b\x1e\xe7\x87\x39\x13\x43\x9b\x2f\x7a\x75\x46\xf2\xbe\xe6\x0d\xf9\xdc\x42\xfd\x0f\xac\xc2\xff\x07\xa3\xa9\xfd\xa9\x5f\x2a\x00\x00")
 
 func cmd_clusterctl_config_manifest_clusterctl_api_yaml() ([]byte, error) {
 	return bindata_read(
@@ -36,3 +36,61 @@ type Template repository.Template
 
 // Template wraps a YAML file that defines the cluster objects (Cluster, Machines etc.).
 type UpgradePlan cluster.UpgradePlan
+
+// UpgradePlanFile is a machine-readable upgrade plan for a management group, which can be reviewed before being applied.
+type UpgradePlanFile cluster.UpgradePlanFile
+
+// ScaleSimulation is the outcome of a simulated scale operation.
+type ScaleSimulation cluster.ScaleSimulation
+
+// OrphanedObject is an infrastructure or bootstrap object without a corresponding Machine/Cluster owner, or a Machine
+// whose infrastructure or bootstrap objects do not exist anymore.
+type OrphanedObject cluster.OrphanedObject
+
+// CertificateReport reports the state of a certificate used by the webhooks of the providers installed by clusterctl.
+type CertificateReport cluster.CertificateReport
+
+// QuickstartStep reports the outcome of a step of the quickstart test.
+type QuickstartStep cluster.QuickstartStep
+
+// Flavor describes a cluster template published in a provider repository.
+type Flavor repository.Flavor
+
+// UpgradeCheck reports the outcome of a check of the post-upgrade verification.
+type UpgradeCheck cluster.UpgradeCheck
+
+// ProviderPermissions reports the permissions granted to a provider by the RBAC rules in its components.
+type ProviderPermissions cluster.ProviderPermissions
+
+// PermissionScopeCluster is the scope of the permissions granted on the whole cluster.
+const PermissionScopeCluster = cluster.PermissionScopeCluster
+
+// TopologyPlanOutput reports the changes to the managed topologies of the Clusters affected by a proposed change.
+type TopologyPlanOutput cluster.TopologyPlanOutput
+
+// SupportBundleManifest describes the content of a support bundle.
+type SupportBundleManifest cluster.SupportBundleManifest
+
+// ObjectStatus reports the status of a Cluster or of a Machine.
+type ObjectStatus cluster.ObjectStatus
+
+// NamespaceMapping defines how the namespaces of the objects moved are remapped in the target management cluster.
+type NamespaceMapping cluster.NamespaceMapping
+
+// ProgressEvent is a structured event emitted by long-running operations, e.g. init or move.
+// NB. progress types are type aliases, so reporters can be passed along to the low-level libraries.
+type ProgressEvent = cluster.ProgressEvent
+
+// ProgressReporter receives the ProgressEvents emitted by long-running operations.
+type ProgressReporter = cluster.ProgressReporter
+
+// ProgressReporterFunc is a function implementing ProgressReporter.
+type ProgressReporterFunc = cluster.ProgressReporterFunc
+
+// Types of ProgressEvent.
+const (
+	ProgressStarted    = cluster.ProgressStarted
+	ProgressInProgress = cluster.ProgressInProgress
+	ProgressSucceeded  = cluster.ProgressSucceeded
+	ProgressFailed     = cluster.ProgressFailed
+)
@@ -0,0 +1,70 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import "context"
+
+// BackupOptions carries the options supported by Backup.
+type BackupOptions struct {
+	// Kubeconfig file to use for accessing the management cluster. If empty, default rules for kubeconfig
+	// discovery will be used.
+	Kubeconfig string
+
+	// Namespace where the objects to be saved exists. If not specified, the objects in all the namespaces are saved.
+	Namespace string
+
+	// Directory where the backup is written.
+	Directory string
+}
+
+func (c *clusterctlClient) Backup(ctx context.Context, options BackupOptions) error {
+	clusterClient, err := c.clusterClientFactory(options.Kubeconfig, "")
+	if err != nil {
+		return err
+	}
+
+	// Ensures the custom resource definitions required by clusterctl are in place.
+	if err := clusterClient.ProviderInventory().EnsureCustomResourceDefinitions(ctx); err != nil {
+		return err
+	}
+
+	return clusterClient.ObjectMover().Backup(ctx, options.Namespace, options.Directory)
+}
+
+// RestoreOptions carries the options supported by Restore.
+type RestoreOptions struct {
+	// Kubeconfig file to use for accessing the management cluster. If empty, default rules for kubeconfig
+	// discovery will be used.
+	Kubeconfig string
+
+	// Directory where the backup to be restored is stored.
+	Directory string
+}
+
+func (c *clusterctlClient) Restore(ctx context.Context, options RestoreOptions) error {
+	clusterClient, err := c.clusterClientFactory(options.Kubeconfig, "")
+	if err != nil {
+		return err
+	}
+
+	// Ensures the custom resource definitions required by clusterctl are in place.
+	if err := clusterClient.ProviderInventory().EnsureCustomResourceDefinitions(ctx); err != nil {
+		return err
+	}
+
+	return clusterClient.ObjectMover().Restore(ctx, clusterClient, options.Directory)
+}
@@ -17,9 +17,18 @@ limitations under the License.
 package client
 
 import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/client/cluster"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/client/config"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/client/image"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/client/repository"
+	logf "sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/log"
 )
 
 // InitOptions carries the options supported by Init.
@@ -51,8 +60,32 @@ type InitOptions struct {
 	// If unspecified, the providers watches for Cluster API objects across all namespaces.
 	WatchingNamespace string
 
+	// ProviderNamespaces maps provider names to the namespace where each provider should be deployed, overriding
+	// TargetNamespace; this allows to choose the namespace layout of the management cluster, e.g. installing
+	// all the providers in a single namespace.
+	ProviderNamespaces map[string]string
+
+	// InventoryNamespaces scopes the inventory to the given namespaces, so independent users can install their own
+	// providers in the same management cluster; TargetNamespace and WatchingNamespace must be one of these namespaces.
+	// If empty, the inventory includes all the providers in the management cluster.
+	InventoryNamespaces []string
+
 	// LogUsageInstructions instructs the init command to print the usage instructions in case of first run.
 	LogUsageInstructions bool
+
+	// RenderedComponents maps provider names to already rendered components YAML, e.g. generated by a separate
+	// pipeline step; those components are validated and installed as-is instead of being read from the provider repository.
+	// The version of these providers must be explicitly set (e.g. aws:v0.5.0).
+	RenderedComponents map[string][]byte
+
+	// Architectures filters the images returned by InitImages to the given architectures (e.g. amd64, arm64);
+	// multi-arch images are resolved to the digest of the image for each architecture, e.g. for creating an air-gap bundle.
+	// If empty, the images are returned as defined in the components YAML.
+	Architectures []string
+
+	// ValidateImageArchitectures instructs Init to check, before installing the providers, that all the images are
+	// published for the architectures of the nodes of the management cluster.
+	ValidateImageArchitectures bool
 }
 
 // DeleteOptions carries the options supported by Delete.
@@ -68,6 +101,14 @@ type DeleteOptions struct {
 	// ForceDeleteCRD forces the deletion of the provider's CRDs (and of all the related objects)".
 	ForceDeleteCRD bool
 
+	// DeleteCertManager deletes cert-manager, if it was installed by clusterctl; it can only be used when
+	// deleting all the providers, because cert-manager is required by the providers.
+	DeleteCertManager bool
+
+	// KeepInventory preserves the inventory items of the deleted providers; it can't be used in combination
+	// with ForceDeleteNamespace, because the inventory items are hosted in the provider's namespace.
+	KeepInventory bool
+
 	// Namespace where the provider to be deleted lives. If not specified, the namespace name will be inferred
 	// from the current configuration.
 	Namespace string
@@ -88,40 +129,170 @@ type MoveOptions struct {
 	// Namespace where the objects describing the workload cluster exists. If not specified, the current
 	// namespace will be used.
 	Namespace string
+
+	// NamespaceMapping defines how the namespaces of the objects moved are remapped in the target management cluster.
+	// By default (empty), the objects are moved to the same namespaces.
+	NamespaceMapping NamespaceMapping
 }
 
 // Client is exposes the clusterctl high-level client library.
 type Client interface {
 	// GetProvidersConfig returns the list of providers configured for this instance of clusterctl.
-	GetProvidersConfig() ([]Provider, error)
+	GetProvidersConfig(ctx context.Context) ([]Provider, error)
 
 	// GetProviderComponents returns the provider components for a given provider, targetNamespace, watchingNamespace.
-	GetProviderComponents(provider, targetNameSpace, watchingNamespace string) (Components, error)
+	GetProviderComponents(ctx context.Context, provider, targetNameSpace, watchingNamespace string) (Components, error)
 
 	// Init initializes a management cluster by adding the requested list of providers.
-	Init(options InitOptions) ([]Components, error)
+	Init(ctx context.Context, options InitOptions) ([]Components, error)
 
 	// InitImages returns the list of images required for executing the init command.
-	InitImages(options InitOptions) ([]string, error)
+	InitImages(ctx context.Context, options InitOptions) ([]string, error)
+
+	// InitPermissions returns the permissions granted to the providers installed by the init command, as defined
+	// by the RBAC rules in their components.
+	InitPermissions(ctx context.Context, options InitOptions) ([]ProviderPermissions, error)
 
 	// GetClusterTemplate returns a workload cluster template.
-	GetClusterTemplate(options GetClusterTemplateOptions) (Template, error)
+	GetClusterTemplate(ctx context.Context, options GetClusterTemplateOptions) (Template, error)
+
+	// ListFlavors returns the flavors of the workload cluster templates published by an infrastructure provider,
+	// together with the variables required by each template.
+	ListFlavors(ctx context.Context, options ListFlavorsOptions) ([]Flavor, error)
+
+	// ProcessYAML reads a template, e.g. a custom manifest, and replaces its variables with the values read from the
+	// environment or from the clusterctl configuration file, like for workload cluster templates.
+	ProcessYAML(ctx context.Context, options ProcessYAMLOptions) (Template, error)
 
 	// Delete deletes providers from a management cluster.
-	Delete(options DeleteOptions) error
+	Delete(ctx context.Context, options DeleteOptions) error
+
+	// PreviewDelete returns the objects that Delete removes from a management cluster, without deleting them,
+	// including the custom resources deleted together with the CRDs.
+	PreviewDelete(ctx context.Context, options DeleteOptions) ([]unstructured.Unstructured, error)
 
 	// Move moves all the Cluster API objects existing in a namespace (or from all the namespaces if empty) to a target management cluster.
-	Move(options MoveOptions) error
+	Move(ctx context.Context, options MoveOptions) error
+
+	// Backup saves all the Cluster API objects existing in a namespace (or in all the namespaces if empty) to a directory.
+	Backup(ctx context.Context, options BackupOptions) error
+
+	// Restore restores all the Cluster API objects saved in a directory to a management cluster.
+	Restore(ctx context.Context, options RestoreOptions) error
 
 	// PlanUpgrade returns a set of suggested Upgrade plans for the cluster, and more specifically:
 	// - Each management group gets separated upgrade plans.
 	// - For each management group, an upgrade plan is generated for each API Version of Cluster API (contract) available, e.g.
 	//   - Upgrade to the latest version in the the v1alpha2 series: ....
 	//   - Upgrade to the latest version in the the v1alpha3 series: ....
-	PlanUpgrade(options PlanUpgradeOptions) ([]UpgradePlan, error)
+	PlanUpgrade(ctx context.Context, options PlanUpgradeOptions) ([]UpgradePlan, error)
+
+	// ExportUpgradePlan returns the upgrade plan of a management group to an API Version of Cluster API (contract),
+	// in a machine-readable format which can be written to a plan file and applied later by ApplyUpgrade.
+	ExportUpgradePlan(ctx context.Context, options ExportUpgradePlanOptions) (*UpgradePlanFile, error)
 
 	// ApplyUpgrade executes an upgrade plan.
-	ApplyUpgrade(options ApplyUpgradeOptions) error
+	ApplyUpgrade(ctx context.Context, options ApplyUpgradeOptions) error
+
+	// VerifyUpgrade verifies the providers of a management group are working after an upgrade, reporting
+	// the outcome of each check.
+	VerifyUpgrade(ctx context.Context, options VerifyUpgradeOptions) ([]UpgradeCheck, error)
+
+	// DescribeProvider returns the inventory items for the instances of a provider installed in a management cluster,
+	// including the history of the install and upgrade operations performed on each instance.
+	DescribeProvider(ctx context.Context, options DescribeProviderOptions) ([]clusterctlv1.Provider, error)
+
+	// ListProvidersInClusters returns, for each management cluster, the list of installed providers.
+	ListProvidersInClusters(ctx context.Context, options MultiClusterOptions) ([]ClusterProviders, error)
+
+	// PlanUpgradeInClusters returns, for each management cluster, the set of suggested Upgrade plans.
+	PlanUpgradeInClusters(ctx context.Context, options MultiClusterOptions) ([]ClusterUpgradePlans, error)
+
+	// CheckHealthInClusters checks, for each management cluster, if the controllers of the installed providers are running.
+	CheckHealthInClusters(ctx context.Context, options MultiClusterOptions) ([]ClusterHealth, error)
+
+	// ProviderLogs writes to out the logs of the controllers of a provider instance installed in a management cluster.
+	ProviderLogs(ctx context.Context, options ProviderLogsOptions, out io.Writer) error
+
+	// ClusterLogs writes to out, in chronological order, the log lines mentioning a workload cluster read from the
+	// controllers of all the providers installed in a management cluster.
+	ClusterLogs(ctx context.Context, options ClusterLogsOptions, out io.Writer) error
+
+	// SimulateScale reports what would happen by scaling a MachineDeployment (new machines per failure domain,
+	// quota and cluster autoscaler bounds violations), without changing it.
+	SimulateScale(ctx context.Context, options SimulateScaleOptions) (*ScaleSimulation, error)
+
+	// ReportVersions returns, for each workload cluster, the Kubernetes versions of the control plane and of the workers,
+	// the providers responsible for the cluster and the upgrades available for them.
+	ReportVersions(ctx context.Context, options ReportVersionsOptions) ([]ClusterVersionReport, error)
+
+	// GetClusters returns the status of the Clusters in a management cluster, including their phase and key conditions.
+	GetClusters(ctx context.Context, options GetClustersOptions) ([]ObjectStatus, error)
+
+	// GetMachines returns the status of the Machines in a management cluster, including their phase and key conditions.
+	GetMachines(ctx context.Context, options GetMachinesOptions) ([]ObjectStatus, error)
+
+	// WatchClusters calls handler with the status of the Clusters in a management cluster, and then again every time
+	// the status changes, until the context is cancelled or handler returns an error.
+	WatchClusters(ctx context.Context, options GetClustersOptions, handler ObjectStatusHandler) error
+
+	// WatchMachines calls handler with the status of the Machines in a management cluster, and then again every time
+	// the status changes, until the context is cancelled or handler returns an error.
+	WatchMachines(ctx context.Context, options GetMachinesOptions, handler ObjectStatusHandler) error
+
+	// ListOrphans returns the infrastructure and bootstrap objects without a corresponding Machine/Cluster owner,
+	// and the Machines whose infrastructure or bootstrap objects do not exist anymore.
+	ListOrphans(ctx context.Context, options ListOrphansOptions) ([]OrphanedObject, error)
+
+	// DeleteOrphans deletes the selected orphaned objects,
+	// and returns the objects deleted.
+	DeleteOrphans(ctx context.Context, options DeleteOrphansOptions) ([]OrphanedObject, error)
+
+	// DoctorCertificates checks the certificates used by the webhooks of the providers installed by clusterctl,
+	// optionally triggering the renewal of the certificates expired or approaching expiry.
+	DoctorCertificates(ctx context.Context, options DoctorCertificatesOptions) ([]CertificateReport, error)
+
+	// RebootMachine requests the infrastructure provider to reboot a Machine, without replacing it.
+	RebootMachine(ctx context.Context, options RebootMachineOptions) error
+
+	// RolloutRestart triggers a rolling replacement of the Machines controlled by a MachineDeployment or a
+	// KubeadmControlPlane, even if its spec did not change.
+	RolloutRestart(ctx context.Context, options RolloutRestartOptions) (time.Time, error)
+
+	// RolloutPause pauses the rollout of the changes to the spec of a MachineDeployment or a KubeadmControlPlane.
+	RolloutPause(ctx context.Context, options RolloutPauseOptions) error
+
+	// RolloutResume resumes the rollout of a MachineDeployment or a KubeadmControlPlane paused by RolloutPause.
+	RolloutResume(ctx context.Context, options RolloutPauseOptions) error
+
+	// RolloutUndo rolls back a MachineDeployment to the Machine template of a previous revision, and returns the
+	// revision rolled back to.
+	RolloutUndo(ctx context.Context, options RolloutUndoOptions) (int64, error)
+
+	// PauseCluster pauses the reconciliation of a Cluster, optionally waiting for the controllers to acknowledge the pause
+	// by propagating it to the provider objects belonging to the Cluster.
+	PauseCluster(ctx context.Context, options PauseClusterOptions) error
+
+	// ResumeCluster resumes the reconciliation of a Cluster paused by PauseCluster, optionally waiting for the controllers
+	// to acknowledge the resume.
+	ResumeCluster(ctx context.Context, options PauseClusterOptions) error
+
+	// TestQuickstart runs a create-upgrade-scale-delete cycle of a workload cluster created from the template of an
+	// infrastructure provider, reporting the duration and the outcome of each step, as a smoke test of the providers.
+	TestQuickstart(ctx context.Context, options TestQuickstartOptions) ([]QuickstartStep, error)
+
+	// TopologyPlan computes the objects the topology controller is going to create, update and delete for the
+	// Clusters with a managed topology affected by a proposed change to Clusters or ClusterClasses, without
+	// changing the management cluster.
+	TopologyPlan(ctx context.Context, options TopologyPlanOptions) (*TopologyPlanOutput, error)
+
+	// GenerateProviderRepository scaffolds a new provider repository conforming to the clusterctl provider contract,
+	// and returns the paths of the generated files.
+	GenerateProviderRepository(ctx context.Context, options GenerateProviderRepositoryOptions) ([]string, error)
+
+	// SupportBundle collects into a gzipped tarball the sanitized objects belonging to a Cluster, the related events,
+	// the versions and the logs of the providers responsible for it, and returns the manifest describing the tarball.
+	SupportBundle(ctx context.Context, options SupportBundleOptions) (*SupportBundleManifest, error)
 }
 
 // clusterctlClient implements Client.
@@ -129,10 +300,13 @@ type clusterctlClient struct {
 	configClient            config.Client
 	repositoryClientFactory RepositoryClientFactory
 	clusterClientFactory    ClusterClientFactory
+	imageResolver           image.Resolver
+	progressReporter        ProgressReporter
+	log                     logr.Logger
 }
 
 type RepositoryClientFactory func(config.Provider) (repository.Client, error)
-type ClusterClientFactory func(string) (cluster.Client, error)
+type ClusterClientFactory func(kubeconfig, context string) (cluster.Client, error)
 
 // Ensure clusterctlClient implements Client.
 var _ Client = &clusterctlClient{}
@@ -163,13 +337,41 @@ func InjectClusterClientFactory(factory ClusterClientFactory) Option {
 	}
 }
 
+// InjectImageResolver allows to override the default resolver used for reading the platforms of the images
+// from their registries.
+func InjectImageResolver(resolver image.Resolver) Option {
+	return func(c *clusterctlClient) {
+		c.imageResolver = resolver
+	}
+}
+
+// InjectProgressReporter allows to receive the events emitted by long-running operations, like init, move, upgrade
+// and delete, e.g. for showing the progress of the operations to the users.
+// NB. the reporter is ignored if a ClusterClientFactory is injected.
+func InjectProgressReporter(reporter ProgressReporter) Option {
+	return func(c *clusterctlClient) {
+		c.progressReporter = reporter
+	}
+}
+
+// InjectLogger allows to route the logs of the operations into the logging stack of the program embedding clusterctl,
+// using the verbosity levels and the structured key/values of logr; by default the clusterctl logger is used.
+// NB. the logger is ignored by an injected config client, RepositoryClientFactory or ClusterClientFactory.
+func InjectLogger(log logr.Logger) Option {
+	return func(c *clusterctlClient) {
+		c.log = log
+	}
+}
+
 // New returns a configClient.
 func New(path string, options ...Option) (Client, error) {
 	return newClusterctlClient(path, options...)
 }
 
 func newClusterctlClient(path string, options ...Option) (*clusterctlClient, error) {
-	client := &clusterctlClient{}
+	client := &clusterctlClient{
+		log: logf.Log,
+	}
 	for _, o := range options {
 		o(client)
 	}
@@ -177,7 +379,7 @@ func newClusterctlClient(path string, options ...Option) (*clusterctlClient, err
 	// if there is an injected config, use it, otherwise use the default one
 	// provided by the config low level library.
 	if client.configClient == nil {
-		c, err := config.New(path)
+		c, err := config.New(path, config.InjectLogger(client.log))
 		if err != nil {
 			return nil, err
 		}
@@ -186,27 +388,32 @@ func newClusterctlClient(path string, options ...Option) (*clusterctlClient, err
 
 	// if there is an injected RepositoryFactory, use it, otherwise use a default one.
 	if client.repositoryClientFactory == nil {
-		client.repositoryClientFactory = defaultRepositoryFactory(client.configClient)
+		client.repositoryClientFactory = defaultRepositoryFactory(client.configClient, client.log)
 	}
 
 	// if there is an injected ClusterFactory, use it, otherwise use a default one.
 	if client.clusterClientFactory == nil {
-		client.clusterClientFactory = defaultClusterFactory(client.configClient)
+		client.clusterClientFactory = defaultClusterFactory(client.configClient, client.progressReporter, client.log)
+	}
+
+	// if there is an injected ImageResolver, use it, otherwise use the default one.
+	if client.imageResolver == nil {
+		client.imageResolver = image.NewResolver()
 	}
 
 	return client, nil
 }
 
 // defaultClusterFactory is a ClusterClientFactory func the uses the default client provided by the cluster low level library.
-func defaultClusterFactory(configClient config.Client) func(kubeconfig string) (cluster.Client, error) {
-	return func(kubeconfig string) (cluster.Client, error) {
-		return cluster.New(kubeconfig, configClient), nil
+func defaultClusterFactory(configClient config.Client, progressReporter ProgressReporter, log logr.Logger) func(kubeconfig, context string) (cluster.Client, error) {
+	return func(kubeconfig, context string) (cluster.Client, error) {
+		return cluster.New(kubeconfig, configClient, cluster.InjectKubeconfigContext(context), cluster.InjectProgressReporter(progressReporter), cluster.InjectLogger(log)), nil
 	}
 }
 
 // defaultRepositoryFactory is a RepositoryClientFactory func the uses the default client provided by the repository low level library.
-func defaultRepositoryFactory(configClient config.Client) func(providerConfig config.Provider) (repository.Client, error) {
+func defaultRepositoryFactory(configClient config.Client, log logr.Logger) func(providerConfig config.Provider) (repository.Client, error) {
 	return func(providerConfig config.Provider) (repository.Client, error) {
-		return repository.New(providerConfig, configClient.Variables())
+		return repository.New(providerConfig, configClient.Variables(), repository.InjectLogger(log))
 	}
 }
@@ -17,20 +17,26 @@ limitations under the License.
 package client
 
 import (
+	"context"
 	"fmt"
+	"io"
+	"sort"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/runtime/serializer"
-	"k8s.io/apimachinery/pkg/util/wait"
 	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/client/cluster"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/client/config"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/client/repository"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/internal/scheme"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/internal/test"
+	crclient "sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 // dummy test to document fakeClient usage
@@ -67,40 +73,176 @@ type fakeClient struct {
 
 var _ Client = &fakeClient{}
 
-func (f fakeClient) GetProvidersConfig() ([]Provider, error) {
-	return f.internalClient.GetProvidersConfig()
+func (f fakeClient) GetProvidersConfig(ctx context.Context) ([]Provider, error) {
+	return f.internalClient.GetProvidersConfig(ctx)
 }
 
-func (f fakeClient) GetProviderComponents(provider, targetNameSpace, watchingNamespace string) (Components, error) {
-	return f.internalClient.GetProviderComponents(provider, targetNameSpace, watchingNamespace)
+func (f fakeClient) GetProviderComponents(ctx context.Context, provider, targetNameSpace, watchingNamespace string) (Components, error) {
+	return f.internalClient.GetProviderComponents(ctx, provider, targetNameSpace, watchingNamespace)
 }
 
-func (f fakeClient) GetClusterTemplate(options GetClusterTemplateOptions) (Template, error) {
-	return f.internalClient.GetClusterTemplate(options)
+func (f fakeClient) GetClusterTemplate(ctx context.Context, options GetClusterTemplateOptions) (Template, error) {
+	return f.internalClient.GetClusterTemplate(ctx, options)
 }
 
-func (f fakeClient) Init(options InitOptions) ([]Components, error) {
-	return f.internalClient.Init(options)
+func (f fakeClient) ListFlavors(ctx context.Context, options ListFlavorsOptions) ([]Flavor, error) {
+	return f.internalClient.ListFlavors(ctx, options)
 }
 
-func (f fakeClient) InitImages(options InitOptions) ([]string, error) {
-	return f.internalClient.InitImages(options)
+func (f fakeClient) ProcessYAML(ctx context.Context, options ProcessYAMLOptions) (Template, error) {
+	return f.internalClient.ProcessYAML(ctx, options)
 }
 
-func (f fakeClient) Delete(options DeleteOptions) error {
-	return f.internalClient.Delete(options)
+func (f fakeClient) Init(ctx context.Context, options InitOptions) ([]Components, error) {
+	return f.internalClient.Init(ctx, options)
 }
 
-func (f fakeClient) Move(options MoveOptions) error {
-	return f.internalClient.Move(options)
+func (f fakeClient) InitImages(ctx context.Context, options InitOptions) ([]string, error) {
+	return f.internalClient.InitImages(ctx, options)
 }
 
-func (f fakeClient) PlanUpgrade(options PlanUpgradeOptions) ([]UpgradePlan, error) {
-	return f.internalClient.PlanUpgrade(options)
+func (f fakeClient) InitPermissions(ctx context.Context, options InitOptions) ([]ProviderPermissions, error) {
+	return f.internalClient.InitPermissions(ctx, options)
 }
 
-func (f fakeClient) ApplyUpgrade(options ApplyUpgradeOptions) error {
-	return f.internalClient.ApplyUpgrade(options)
+func (f fakeClient) Delete(ctx context.Context, options DeleteOptions) error {
+	return f.internalClient.Delete(ctx, options)
+}
+
+func (f fakeClient) PreviewDelete(ctx context.Context, options DeleteOptions) ([]unstructured.Unstructured, error) {
+	return f.internalClient.PreviewDelete(ctx, options)
+}
+
+func (f fakeClient) Move(ctx context.Context, options MoveOptions) error {
+	return f.internalClient.Move(ctx, options)
+}
+
+func (f fakeClient) Backup(ctx context.Context, options BackupOptions) error {
+	return f.internalClient.Backup(ctx, options)
+}
+
+func (f fakeClient) Restore(ctx context.Context, options RestoreOptions) error {
+	return f.internalClient.Restore(ctx, options)
+}
+
+func (f fakeClient) PlanUpgrade(ctx context.Context, options PlanUpgradeOptions) ([]UpgradePlan, error) {
+	return f.internalClient.PlanUpgrade(ctx, options)
+}
+
+func (f fakeClient) ExportUpgradePlan(ctx context.Context, options ExportUpgradePlanOptions) (*UpgradePlanFile, error) {
+	return f.internalClient.ExportUpgradePlan(ctx, options)
+}
+
+func (f fakeClient) ApplyUpgrade(ctx context.Context, options ApplyUpgradeOptions) error {
+	return f.internalClient.ApplyUpgrade(ctx, options)
+}
+
+func (f fakeClient) VerifyUpgrade(ctx context.Context, options VerifyUpgradeOptions) ([]UpgradeCheck, error) {
+	return f.internalClient.VerifyUpgrade(ctx, options)
+}
+
+func (f fakeClient) DescribeProvider(ctx context.Context, options DescribeProviderOptions) ([]clusterctlv1.Provider, error) {
+	return f.internalClient.DescribeProvider(ctx, options)
+}
+
+func (f fakeClient) ListProvidersInClusters(ctx context.Context, options MultiClusterOptions) ([]ClusterProviders, error) {
+	return f.internalClient.ListProvidersInClusters(ctx, options)
+}
+
+func (f fakeClient) PlanUpgradeInClusters(ctx context.Context, options MultiClusterOptions) ([]ClusterUpgradePlans, error) {
+	return f.internalClient.PlanUpgradeInClusters(ctx, options)
+}
+
+func (f fakeClient) CheckHealthInClusters(ctx context.Context, options MultiClusterOptions) ([]ClusterHealth, error) {
+	return f.internalClient.CheckHealthInClusters(ctx, options)
+}
+
+func (f fakeClient) ProviderLogs(ctx context.Context, options ProviderLogsOptions, out io.Writer) error {
+	return f.internalClient.ProviderLogs(ctx, options, out)
+}
+
+func (f fakeClient) ClusterLogs(ctx context.Context, options ClusterLogsOptions, out io.Writer) error {
+	return f.internalClient.ClusterLogs(ctx, options, out)
+}
+
+func (f fakeClient) SimulateScale(ctx context.Context, options SimulateScaleOptions) (*ScaleSimulation, error) {
+	return f.internalClient.SimulateScale(ctx, options)
+}
+
+func (f fakeClient) ReportVersions(ctx context.Context, options ReportVersionsOptions) ([]ClusterVersionReport, error) {
+	return f.internalClient.ReportVersions(ctx, options)
+}
+
+func (f fakeClient) GetClusters(ctx context.Context, options GetClustersOptions) ([]ObjectStatus, error) {
+	return f.internalClient.GetClusters(ctx, options)
+}
+
+func (f fakeClient) GetMachines(ctx context.Context, options GetMachinesOptions) ([]ObjectStatus, error) {
+	return f.internalClient.GetMachines(ctx, options)
+}
+
+func (f fakeClient) WatchClusters(ctx context.Context, options GetClustersOptions, handler ObjectStatusHandler) error {
+	return f.internalClient.WatchClusters(ctx, options, handler)
+}
+
+func (f fakeClient) WatchMachines(ctx context.Context, options GetMachinesOptions, handler ObjectStatusHandler) error {
+	return f.internalClient.WatchMachines(ctx, options, handler)
+}
+
+func (f fakeClient) ListOrphans(ctx context.Context, options ListOrphansOptions) ([]OrphanedObject, error) {
+	return f.internalClient.ListOrphans(ctx, options)
+}
+
+func (f fakeClient) DeleteOrphans(ctx context.Context, options DeleteOrphansOptions) ([]OrphanedObject, error) {
+	return f.internalClient.DeleteOrphans(ctx, options)
+}
+
+func (f fakeClient) DoctorCertificates(ctx context.Context, options DoctorCertificatesOptions) ([]CertificateReport, error) {
+	return f.internalClient.DoctorCertificates(ctx, options)
+}
+
+func (f fakeClient) RebootMachine(ctx context.Context, options RebootMachineOptions) error {
+	return f.internalClient.RebootMachine(ctx, options)
+}
+
+func (f fakeClient) RolloutRestart(ctx context.Context, options RolloutRestartOptions) (time.Time, error) {
+	return f.internalClient.RolloutRestart(ctx, options)
+}
+
+func (f fakeClient) RolloutPause(ctx context.Context, options RolloutPauseOptions) error {
+	return f.internalClient.RolloutPause(ctx, options)
+}
+
+func (f fakeClient) RolloutResume(ctx context.Context, options RolloutPauseOptions) error {
+	return f.internalClient.RolloutResume(ctx, options)
+}
+
+func (f fakeClient) PauseCluster(ctx context.Context, options PauseClusterOptions) error {
+	return f.internalClient.PauseCluster(ctx, options)
+}
+
+func (f fakeClient) ResumeCluster(ctx context.Context, options PauseClusterOptions) error {
+	return f.internalClient.ResumeCluster(ctx, options)
+}
+
+func (f fakeClient) RolloutUndo(ctx context.Context, options RolloutUndoOptions) (int64, error) {
+	return f.internalClient.RolloutUndo(ctx, options)
+}
+
+func (f fakeClient) TestQuickstart(ctx context.Context, options TestQuickstartOptions) ([]QuickstartStep, error) {
+	return f.internalClient.TestQuickstart(ctx, options)
+}
+
+func (f fakeClient) TopologyPlan(ctx context.Context, options TopologyPlanOptions) (*TopologyPlanOutput, error) {
+	return f.internalClient.TopologyPlan(ctx, options)
+}
+
+func (f fakeClient) GenerateProviderRepository(ctx context.Context, options GenerateProviderRepositoryOptions) ([]string, error) {
+	return f.internalClient.GenerateProviderRepository(ctx, options)
+}
+
+func (f fakeClient) SupportBundle(ctx context.Context, options SupportBundleOptions) (*SupportBundleManifest, error) {
+	return f.internalClient.SupportBundle(ctx, options)
 }
 
 // newFakeClient returns a clusterctl client that allows to execute tests on a set of fake config, fake repositories and fake clusters.
@@ -117,11 +259,12 @@ func newFakeClient(configClient config.Client) *fakeClient {
 		fake.configClient = newFakeConfig()
 	}
 
-	var clusterClientFactory = func(kubeconfig string) (cluster.Client, error) {
-		if _, ok := fake.clusters[kubeconfig]; !ok {
-			return nil, errors.Errorf("Cluster for kubeconfig %q does not exists.", kubeconfig)
+	var clusterClientFactory = func(kubeconfig, context string) (cluster.Client, error) {
+		key := fakeClusterKey(kubeconfig, context)
+		if _, ok := fake.clusters[key]; !ok {
+			return nil, errors.Errorf("Cluster for kubeconfig %q does not exists.", key)
 		}
-		return fake.clusters[kubeconfig], nil
+		return fake.clusters[key], nil
 	}
 
 	fake.internalClient, _ = newClusterctlClient("fake-config",
@@ -139,10 +282,19 @@ func newFakeClient(configClient config.Client) *fakeClient {
 }
 
 func (f *fakeClient) WithCluster(clusterClient cluster.Client) *fakeClient {
-	f.clusters[clusterClient.Kubeconfig()] = clusterClient
+	f.clusters[fakeClusterKey(clusterClient.Kubeconfig(), clusterClient.KubeconfigContext())] = clusterClient
 	return f
 }
 
+// fakeClusterKey returns the key identifying a fake cluster; fake clusters accessed using a kubeconfig context
+// are identified by kubeconfig/context.
+func fakeClusterKey(kubeconfig, context string) string {
+	if context == "" {
+		return kubeconfig
+	}
+	return fmt.Sprintf("%s/%s", kubeconfig, context)
+}
+
 func (f *fakeClient) WithRepository(repositoryClient repository.Client) *fakeClient {
 	if fc, ok := f.configClient.(fakeConfigClient); ok {
 		fc.WithProvider(repositoryClient)
@@ -161,13 +313,13 @@ func newFakeCluster(kubeconfig string, configClient config.Client) *fakeClusterC
 	}
 
 	fake.fakeProxy = test.NewFakeProxy()
-	pollImmediateWaiter := func(interval, timeout time.Duration, condition wait.ConditionFunc) error {
+	objectWaiter := func(ctx context.Context, gvk schema.GroupVersionKind, key crclient.ObjectKey, timeout time.Duration, condition cluster.ObjectConditionFunc) error {
 		return nil
 	}
 
 	fake.internalclient = cluster.New("", configClient,
 		cluster.InjectProxy(fake.fakeProxy),
-		cluster.InjectPollImmediateWaiter(pollImmediateWaiter),
+		cluster.InjectObjectWaiter(objectWaiter),
 		cluster.InjectRepositoryFactory(func(provider config.Provider, configVariablesClient config.VariablesClient, options ...repository.Option) (repository.Client, error) {
 			if _, ok := fake.repositories[provider.Name()]; !ok {
 				return nil, errors.Errorf("Repository for kubeconfig %q does not exists.", provider.Name())
@@ -184,21 +336,32 @@ type fakeCertManagerClient struct {
 
 var _ cluster.CertManagerClient = &fakeCertManagerClient{}
 
-func (p *fakeCertManagerClient) EnsureWebhook() error {
+func (p *fakeCertManagerClient) EnsureWebhook(ctx context.Context) error {
 	// For unit test, we are not installing the cert-manager Webhook so we always return no error without doing additional steps.
 	return nil
 }
 
-func (p *fakeCertManagerClient) Images() ([]string, error) {
+func (p *fakeCertManagerClient) Images(_ context.Context) ([]string, error) {
 	// For unit test, we are not installing the cert-manager.
 	return nil, nil
 }
 
+func (p *fakeCertManagerClient) ObjectsToDelete(ctx context.Context) ([]unstructured.Unstructured, error) {
+	// For unit test, we are not installing the cert-manager.
+	return nil, nil
+}
+
+func (p *fakeCertManagerClient) Delete(ctx context.Context) error {
+	// For unit test, we are not installing the cert-manager.
+	return nil
+}
+
 type fakeClusterClient struct {
-	kubeconfig     string
-	fakeProxy      *test.FakeProxy
-	repositories   map[string]repository.Client
-	internalclient cluster.Client
+	kubeconfig        string
+	kubeconfigContext string
+	fakeProxy         *test.FakeProxy
+	repositories      map[string]repository.Client
+	internalclient    cluster.Client
 }
 
 var _ cluster.Client = &fakeClusterClient{}
@@ -207,10 +370,18 @@ func (f fakeClusterClient) Kubeconfig() string {
 	return f.kubeconfig
 }
 
+func (f fakeClusterClient) KubeconfigContext() string {
+	return f.kubeconfigContext
+}
+
 func (f fakeClusterClient) Proxy() cluster.Proxy {
 	return f.fakeProxy
 }
 
+func (f fakeClusterClient) ProgressReporter() cluster.ProgressReporter {
+	return f.internalclient.ProgressReporter()
+}
+
 func (f *fakeClusterClient) CertManager() cluster.CertManagerClient {
 	return &fakeCertManagerClient{}
 }
@@ -223,6 +394,12 @@ func (f fakeClusterClient) ProviderInventory() cluster.InventoryClient {
 	return f.internalclient.ProviderInventory()
 }
 
+func (f fakeClusterClient) WithInventoryNamespaces(namespaces ...string) cluster.Client {
+	scoped := f
+	scoped.internalclient = f.internalclient.WithInventoryNamespaces(namespaces...)
+	return &scoped
+}
+
 func (f fakeClusterClient) ProviderInstaller() cluster.ProviderInstaller {
 	return f.internalclient.ProviderInstaller()
 }
@@ -235,10 +412,70 @@ func (f *fakeClusterClient) ProviderUpgrader() cluster.ProviderUpgrader {
 	return f.internalclient.ProviderUpgrader()
 }
 
+func (f *fakeClusterClient) UpgradeVerifier() cluster.UpgradeVerifier {
+	return f.internalclient.UpgradeVerifier()
+}
+
 func (f *fakeClusterClient) Template() cluster.TemplateClient {
 	return f.internalclient.Template()
 }
 
+func (f *fakeClusterClient) ProviderLogs() cluster.LogsClient {
+	return f.internalclient.ProviderLogs()
+}
+
+func (f *fakeClusterClient) ScaleSimulator() cluster.ScaleSimulator {
+	return f.internalclient.ScaleSimulator()
+}
+
+func (f *fakeClusterClient) ConversionWebhooks() cluster.ConversionWebhookClient {
+	return f.internalclient.ConversionWebhooks()
+}
+
+func (f *fakeClusterClient) VersionReporter() cluster.VersionReporter {
+	return f.internalclient.VersionReporter()
+}
+
+func (f *fakeClusterClient) StatusLister() cluster.StatusLister {
+	return f.internalclient.StatusLister()
+}
+
+func (f *fakeClusterClient) OrphanFinder() cluster.OrphanFinder {
+	return f.internalclient.OrphanFinder()
+}
+
+func (f *fakeClusterClient) CertificateChecker() cluster.CertificateChecker {
+	return f.internalclient.CertificateChecker()
+}
+
+func (f *fakeClusterClient) MachineActions() cluster.MachineActionClient {
+	return f.internalclient.MachineActions()
+}
+
+func (f *fakeClusterClient) Rollout() cluster.RolloutClient {
+	return f.internalclient.Rollout()
+}
+
+func (f *fakeClusterClient) Pause() cluster.PauseClient {
+	return f.internalclient.Pause()
+}
+
+func (f *fakeClusterClient) Quickstart() cluster.QuickstartRunner {
+	return f.internalclient.Quickstart()
+}
+
+func (f *fakeClusterClient) Topology() cluster.TopologyClient {
+	return f.internalclient.Topology()
+}
+
+func (f *fakeClusterClient) SupportBundleCollector() cluster.SupportBundleCollector {
+	return f.internalclient.SupportBundleCollector()
+}
+
+func (f *fakeClusterClient) OperationLock() cluster.OperationLock {
+	return f.internalclient.OperationLock()
+}
+
 func (f *fakeClusterClient) WithObjs(objs ...runtime.Object) *fakeClusterClient {
 	f.fakeProxy.WithObjs(objs...)
 	return f
@@ -249,6 +486,11 @@ func (f *fakeClusterClient) WithProviderInventory(name string, providerType clus
 	return f
 }
 
+func (f *fakeClusterClient) WithKubeconfigContext(context string) *fakeClusterClient {
+	f.kubeconfigContext = context
+	return f
+}
+
 func (f *fakeClusterClient) WithRepository(repositoryClient repository.Client) *fakeClusterClient {
 	f.repositories[repositoryClient.Name()] = repositoryClient
 	return f
@@ -279,10 +521,18 @@ func (f fakeConfigClient) Providers() config.ProvidersClient {
 	return f.internalclient.Providers()
 }
 
+func (f fakeConfigClient) CertManager() config.CertManagerClient {
+	return f.internalclient.CertManager()
+}
+
 func (f fakeConfigClient) Variables() config.VariablesClient {
 	return f.internalclient.Variables()
 }
 
+func (f fakeConfigClient) Deployments() config.DeploymentsClient {
+	return f.internalclient.Deployments()
+}
+
 func (f *fakeConfigClient) WithVar(key, value string) *fakeConfigClient {
 	f.fakeReader.WithVar(key, value)
 	return f
@@ -322,8 +572,8 @@ func (f fakeRepositoryClient) DefaultVersion() string {
 	return f.fakeRepository.DefaultVersion()
 }
 
-func (f fakeRepositoryClient) GetVersions() ([]string, error) {
-	return f.fakeRepository.GetVersions()
+func (f fakeRepositoryClient) GetVersions(ctx context.Context) ([]string, error) {
+	return f.fakeRepository.GetVersions(ctx)
 }
 
 func (f fakeRepositoryClient) Components() repository.ComponentsClient {
@@ -384,28 +634,57 @@ type fakeTemplateClient struct {
 	configVariablesClient config.VariablesClient
 }
 
-func (f *fakeTemplateClient) Get(flavor, targetNamespace string, listVariablesOnly bool) (repository.Template, error) {
+func (f *fakeTemplateClient) Get(ctx context.Context, flavor, targetNamespace string, listVariablesOnly bool) (repository.Template, error) {
 	name := "cluster-template"
 	if flavor != "" {
 		name = fmt.Sprintf("%s-%s", name, flavor)
 	}
 	name = fmt.Sprintf("%s.yaml", name)
 
-	content, err := f.fakeRepository.GetFile(f.version, name)
+	content, err := f.fakeRepository.GetFile(ctx, f.version, name)
 	if err != nil {
 		return nil, err
 	}
 	return repository.NewTemplate(content, f.configVariablesClient, targetNamespace, listVariablesOnly)
 }
 
+func (f *fakeTemplateClient) ListFlavors(ctx context.Context) ([]repository.Flavor, error) {
+	files, err := f.fakeRepository.ListFiles(ctx, f.version)
+	if err != nil {
+		return nil, err
+	}
+
+	flavors := []repository.Flavor{}
+	for _, name := range files {
+		if !strings.HasPrefix(name, "cluster-template") || !strings.HasSuffix(name, ".yaml") {
+			continue
+		}
+		flavor := strings.TrimPrefix(strings.TrimSuffix(strings.TrimPrefix(name, "cluster-template"), ".yaml"), "-")
+
+		content, err := f.fakeRepository.GetFile(ctx, f.version, name)
+		if err != nil {
+			return nil, err
+		}
+		template, err := repository.NewTemplate(content, f.configVariablesClient, "", true)
+		if err != nil {
+			return nil, err
+		}
+		flavors = append(flavors, repository.Flavor{Name: flavor, Variables: template.Variables()})
+	}
+	sort.Slice(flavors, func(i, j int) bool {
+		return flavors[i].Name < flavors[j].Name
+	})
+	return flavors, nil
+}
+
 // fakeMetadataClient provides a super simple MetadataClient (e.g. without support for local overrides/embedded metadata)
 type fakeMetadataClient struct {
 	version        string
 	fakeRepository *test.FakeRepository
 }
 
-func (f *fakeMetadataClient) Get() (*clusterctlv1.Metadata, error) {
-	content, err := f.fakeRepository.GetFile(f.version, "metadata.yaml")
+func (f *fakeMetadataClient) Get(ctx context.Context) (*clusterctlv1.Metadata, error) {
+	content, err := f.fakeRepository.GetFile(ctx, f.version, "metadata.yaml")
 	if err != nil {
 		return nil, err
 	}
@@ -426,16 +705,30 @@ type fakeComponentClient struct {
 	configVariablesClient config.VariablesClient
 }
 
-func (f *fakeComponentClient) Get(version, targetNamespace, watchingNamespace string) (repository.Components, error) {
+func (f *fakeComponentClient) Get(ctx context.Context, version, targetNamespace, watchingNamespace string) (repository.Components, error) {
 	if version == "" {
 		version = f.fakeRepository.DefaultVersion()
 	}
 	path := f.fakeRepository.ComponentsPath()
 
-	content, err := f.fakeRepository.GetFile(version, path)
+	content, err := f.fakeRepository.GetFile(ctx, version, path)
 	if err != nil {
 		return nil, err
 	}
 
 	return repository.NewComponents(f.provider, version, content, f.configVariablesClient, targetNamespace, watchingNamespace)
 }
+
+func (f *fakeComponentClient) CustomResourceDefinitions(ctx context.Context, version string) ([]unstructured.Unstructured, error) {
+	if version == "" {
+		version = f.fakeRepository.DefaultVersion()
+	}
+	path := f.fakeRepository.ComponentsPath()
+
+	content, err := f.fakeRepository.GetFile(ctx, version, path)
+	if err != nil {
+		return nil, err
+	}
+
+	return repository.InspectCustomResourceDefinitions(content)
+}
@@ -0,0 +1,73 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/version"
+	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// newestServedVersion returns the newest version served by a CRD, according to the Kubernetes
+// version priority rules (e.g. v1 > v1beta1 > v1alpha3 > v1alpha2); if the CRD does not serve
+// any version, an empty string is returned.
+func newestServedVersion(crd apiextensionsv1.CustomResourceDefinition) string {
+	newest := ""
+	for _, v := range crd.Spec.Versions {
+		if !v.Served {
+			continue
+		}
+		if newest == "" || version.CompareKubeAwareVersionStrings(v.Name, newest) > 0 {
+			newest = v.Name
+		}
+	}
+	return newest
+}
+
+// negotiateGroupVersionKind returns the GroupVersionKind to be used for reading objects of the given GroupKind
+// from the management cluster, using the newest version served by the corresponding CRD; this allows
+// to work with management clusters at different contract levels.
+// In case the CRD is not installed by clusterctl, the given default version is returned.
+func negotiateGroupVersionKind(ctx context.Context, proxy Proxy, gk schema.GroupKind, defaultVersion string) (schema.GroupVersionKind, error) {
+	c, err := proxy.NewClient()
+	if err != nil {
+		return schema.GroupVersionKind{}, err
+	}
+
+	crdList := &apiextensionsv1.CustomResourceDefinitionList{}
+	if err := c.List(ctx, crdList, client.MatchingLabels{clusterctlv1.ClusterctlLabelName: ""}); err != nil {
+		return schema.GroupVersionKind{}, errors.Wrap(err, "failed to get the list of CRDs required for API version negotiation")
+	}
+
+	for _, crd := range crdList.Items {
+		if crd.Spec.Group != gk.Group || crd.Spec.Names.Kind != gk.Kind {
+			continue
+		}
+		v := newestServedVersion(crd)
+		if v == "" {
+			return schema.GroupVersionKind{}, errors.Errorf("the %q CustomResourceDefinition does not serve any version", crd.Name)
+		}
+		return gk.WithVersion(v), nil
+	}
+
+	return gk.WithVersion(defaultVersion), nil
+}
@@ -0,0 +1,119 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"testing"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/internal/test"
+)
+
+func Test_newestServedVersion(t *testing.T) {
+	tests := []struct {
+		name     string
+		versions []apiextensionsv1.CustomResourceDefinitionVersion
+		want     string
+	}{
+		{
+			name: "Returns the newest served version",
+			versions: []apiextensionsv1.CustomResourceDefinitionVersion{
+				{Name: "v1alpha2", Served: true, Storage: true},
+				{Name: "v1alpha3", Served: true},
+				{Name: "v1alpha10", Served: true},
+			},
+			want: "v1alpha10",
+		},
+		{
+			name: "Ignores versions not served",
+			versions: []apiextensionsv1.CustomResourceDefinitionVersion{
+				{Name: "v1alpha3", Served: true, Storage: true},
+				{Name: "v1beta1", Served: false},
+			},
+			want: "v1alpha3",
+		},
+		{
+			name: "Returns empty if no version is served",
+			versions: []apiextensionsv1.CustomResourceDefinitionVersion{
+				{Name: "v1alpha3", Served: false, Storage: true},
+			},
+			want: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			crd := apiextensionsv1.CustomResourceDefinition{
+				Spec: apiextensionsv1.CustomResourceDefinitionSpec{
+					Versions: tt.versions,
+				},
+			}
+			if got := newestServedVersion(crd); got != tt.want {
+				t.Errorf("got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_negotiateGroupVersionKind(t *testing.T) {
+	tests := []struct {
+		name           string
+		proxy          Proxy
+		gk             schema.GroupKind
+		defaultVersion string
+		want           schema.GroupVersionKind
+		wantErr        bool
+	}{
+		{
+			name: "Returns the newest version served by the CRD",
+			proxy: test.NewFakeProxy().
+				WithObjs(test.FakeCustomResourceDefinition("cluster.x-k8s.io", "Cluster", "v1alpha2", "v1alpha3")),
+			gk:             schema.GroupKind{Group: "cluster.x-k8s.io", Kind: "Cluster"},
+			defaultVersion: "v1alpha3",
+			want:           schema.GroupVersionKind{Group: "cluster.x-k8s.io", Version: "v1alpha3", Kind: "Cluster"},
+			wantErr:        false,
+		},
+		{
+			name: "Returns an older version if it is the only one served by the CRD",
+			proxy: test.NewFakeProxy().
+				WithObjs(test.FakeCustomResourceDefinition("cluster.x-k8s.io", "Cluster", "v1alpha2")),
+			gk:             schema.GroupKind{Group: "cluster.x-k8s.io", Kind: "Cluster"},
+			defaultVersion: "v1alpha3",
+			want:           schema.GroupVersionKind{Group: "cluster.x-k8s.io", Version: "v1alpha2", Kind: "Cluster"},
+			wantErr:        false,
+		},
+		{
+			name:           "Returns the default version if the CRD is not installed by clusterctl",
+			proxy:          test.NewFakeProxy(),
+			gk:             schema.GroupKind{Group: "cluster.x-k8s.io", Kind: "Cluster"},
+			defaultVersion: "v1alpha3",
+			want:           schema.GroupVersionKind{Group: "cluster.x-k8s.io", Version: "v1alpha3", Kind: "Cluster"},
+			wantErr:        false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := negotiateGroupVersionKind(ctx, tt.proxy, tt.gk, tt.defaultVersion)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,322 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	kerrors "k8s.io/apimachinery/pkg/util/errors"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	utilyaml "sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/internal/util"
+	"sigs.k8s.io/cluster-api/cmd/version"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// backupFormatVersion is the version of the format used for writing backups; it should be changed
+	// every time the format is changed in a non backward compatible way.
+	backupFormatVersion = "v1"
+
+	// backupManifestFile is the name of the file describing the content of a backup.
+	backupManifestFile = "clusterctl-backup.yaml"
+)
+
+// backupManifest describes the content of a backup.
+type backupManifest struct {
+	// FormatVersion is the version of the format used for writing the backup.
+	FormatVersion string `json:"formatVersion"`
+
+	// ClusterctlVersion is the version of clusterctl used for writing the backup.
+	ClusterctlVersion string `json:"clusterctlVersion"`
+
+	// Timestamp when the backup was written.
+	Timestamp metav1.Time `json:"timestamp"`
+
+	// Namespace the objects were read from; empty if objects were read from all the namespaces.
+	Namespace string `json:"namespace,omitempty"`
+
+	// Files contains the name of the files, one for each object in the backup.
+	Files []string `json:"files"`
+}
+
+func (o *objectMover) Backup(ctx context.Context, namespace string, directory string) error {
+	log := o.log
+	log.Info("Performing backup...")
+
+	objectGraph := newObjectGraph(o.fromProxy)
+	objectGraph.log = o.log
+
+	// Gets all the types defines by the CRDs installed by clusterctl plus the ConfigMap/Secret core types.
+	types, err := objectGraph.getDiscoveryTypes(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Discovery the object graph for the selected types.
+	if err := objectGraph.Discovery(ctx, namespace, types); err != nil {
+		return err
+	}
+	logExcludedNodes(objectGraph, "backup")
+
+	// Pauses the Clusters, so the objects are not changed by the controllers while the backup is taken;
+	// Clusters that are already paused are left untouched.
+	clustersToPause, err := getUnpausedClusters(ctx, o.fromProxy, objectGraph.getClusters())
+	if err != nil {
+		return err
+	}
+
+	log.V(1).Info("Pausing the source cluster")
+	if err := setClusterPause(ctx, o.log, o.fromProxy, clustersToPause, true); err != nil {
+		return err
+	}
+
+	backupErr := o.backup(ctx, objectGraph, clustersToPause, namespace, directory)
+
+	// Resumes the Clusters paused by the backup, no matter of the backup succeeded or not.
+	log.V(1).Info("Resuming the source cluster")
+	if err := setClusterPause(ctx, o.log, o.fromProxy, clustersToPause, false); err != nil {
+		return kerrors.NewAggregate([]error{backupErr, err})
+	}
+	return backupErr
+}
+
+// backup writes to a directory the objects in the graph, and a manifest describing the backup.
+// Clusters paused by the backup operation are saved with the pause field unset, so they are going to be resumed after restore.
+func (o *objectMover) backup(ctx context.Context, graph *objectGraph, pausedClusters []*node, namespace string, directory string) error {
+	log := o.log
+
+	if err := os.MkdirAll(directory, 0755); err != nil {
+		return errors.Wrapf(err, "failed to create the backup directory %q", directory)
+	}
+
+	c, err := o.fromProxy.NewClient()
+	if err != nil {
+		return err
+	}
+
+	manifest := backupManifest{
+		FormatVersion:     backupFormatVersion,
+		ClusterctlVersion: version.Get().GitVersion,
+		Timestamp:         metav1.Now(),
+		Namespace:         namespace,
+	}
+
+	nodes := graph.getNodesForBackup()
+	log.Info("Saving Cluster API objects", "Objects", len(nodes))
+	for _, n := range nodes {
+		obj := &unstructured.Unstructured{}
+		obj.SetAPIVersion(n.identity.APIVersion)
+		obj.SetKind(n.identity.Kind)
+		objKey := client.ObjectKey{
+			Namespace: n.identity.Namespace,
+			Name:      n.identity.Name,
+		}
+
+		if err := c.Get(ctx, objKey, obj); err != nil {
+			return errors.Wrapf(err, "error reading %q %s/%s",
+				obj.GroupVersionKind(), obj.GetNamespace(), obj.GetName())
+		}
+
+		for _, cluster := range pausedClusters {
+			if cluster == n {
+				unstructured.RemoveNestedField(obj.Object, "spec", "paused")
+			}
+		}
+
+		content, err := utilyaml.FromUnstructured([]unstructured.Unstructured{*obj})
+		if err != nil {
+			return err
+		}
+
+		file := backupFileName(n)
+		log.V(1).Info("Saving", n.identity.Kind, n.identity.Name, "Namespace", n.identity.Namespace)
+		if err := ioutil.WriteFile(filepath.Join(directory, file), content, 0600); err != nil {
+			return errors.Wrapf(err, "failed to write %q", file)
+		}
+		manifest.Files = append(manifest.Files, file)
+	}
+
+	content, err := yaml.Marshal(manifest)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal the backup manifest")
+	}
+	if err := ioutil.WriteFile(filepath.Join(directory, backupManifestFile), content, 0600); err != nil {
+		return errors.Wrapf(err, "failed to write %q", backupManifestFile)
+	}
+
+	return nil
+}
+
+// backupFileName returns the name of the file where the object corresponding to a node is saved.
+func backupFileName(n *node) string {
+	return strings.ToLower(fmt.Sprintf("%s_%s_%s_%s.yaml", n.identity.GroupVersionKind().Group, n.identity.Kind, n.identity.Namespace, n.identity.Name))
+}
+
+func (o *objectMover) Restore(ctx context.Context, toCluster Client, directory string) error {
+	log := o.log
+	log.Info("Performing restore...")
+
+	objs, err := readBackup(directory)
+	if err != nil {
+		return err
+	}
+
+	// Rebuilds the object graph from the saved objects, using the OwnerReferences recorded at backup time.
+	// Owners that are not part of the backup are dropped, given that it is not possible to restore the link to them.
+	objectGraph := newObjectGraph(toCluster.Proxy())
+	objectGraph.log = o.log
+	for i := range objs {
+		objectGraph.addObj(&objs[i])
+	}
+	objectGraph.removeVirtualNodes()
+	objectGraph.setSoftOwnership()
+	objectGraph.setClusterTenants()
+
+	objsByNode := map[*node]*unstructured.Unstructured{}
+	nodes := []*node{}
+	for i := range objs {
+		n := objectGraph.uidToNode[objs[i].GetUID()]
+		objsByNode[n] = &objs[i]
+		nodes = append(nodes, n)
+	}
+
+	// Clusters are restored paused, so the controllers don't start reconciling objects before the restore is completed;
+	// Clusters that were not paused at backup time are resumed at the end of the restore.
+	clustersToResume := []*node{}
+	for _, cluster := range objectGraph.getClusters() {
+		obj := objsByNode[cluster]
+		paused, _, err := unstructured.NestedBool(obj.Object, "spec", "paused")
+		if err != nil {
+			return errors.Wrapf(err, "failed to read spec.paused from %q %s/%s", obj.GroupVersionKind(), obj.GetNamespace(), obj.GetName())
+		}
+		if !paused {
+			clustersToResume = append(clustersToResume, cluster)
+		}
+		if err := unstructured.SetNestedField(obj.Object, true, "spec", "paused"); err != nil {
+			return errors.Wrapf(err, "failed to set spec.paused on %q %s/%s", obj.GroupVersionKind(), obj.GetNamespace(), obj.GetName())
+		}
+	}
+
+	// Ensure all the expected target namespaces are in place before creating objects.
+	log.V(1).Info("Creating target namespaces, if missing")
+	if err := o.ensureNamespaces(ctx, nodes, toCluster.Proxy()); err != nil {
+		return err
+	}
+
+	cTo, err := toCluster.Proxy().NewClient()
+	if err != nil {
+		return err
+	}
+
+	// Create all objects group by group, ensuring all the ownerReferences are re-created using the UIDs of the restored owners.
+	restoreSequence := newMoveSequence(nodes)
+	log.Info("Restoring Cluster API objects", "Objects", len(nodes))
+	for groupIndex := 0; groupIndex < len(restoreSequence.groups); groupIndex++ {
+		errList := []error{}
+		for _, nodeToCreate := range restoreSequence.getGroup(groupIndex) {
+			obj := objsByNode[nodeToCreate]
+			log.V(1).Info("Creating", nodeToCreate.identity.Kind, nodeToCreate.identity.Name, "Namespace", nodeToCreate.identity.Namespace)
+
+			// Nb. The operation is wrapped in a retry loop to make restore more resilient to unexpected conditions.
+			err := retry(o.log, retryCreateTargetObject, retryIntervalCreateTargetObject, func() error {
+				return createObject(ctx, o.log, nodeToCreate, obj.DeepCopy(), cTo)
+			})
+			if err != nil {
+				errList = append(errList, err)
+			}
+		}
+		if len(errList) > 0 {
+			return kerrors.NewAggregate(errList)
+		}
+	}
+
+	// Reset the pause field on the Cluster objects, so the controllers start reconciling them.
+	log.V(1).Info("Resuming the target cluster")
+	if err := setClusterPause(ctx, o.log, toCluster.Proxy(), clustersToResume, false); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// readBackup reads the objects saved in a backup directory.
+func readBackup(directory string) ([]unstructured.Unstructured, error) {
+	content, err := ioutil.ReadFile(filepath.Join(directory, backupManifestFile))
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read the backup manifest from %q", directory)
+	}
+
+	manifest := &backupManifest{}
+	if err := yaml.Unmarshal(content, manifest); err != nil {
+		return nil, errors.Wrapf(err, "failed to unmarshal the backup manifest from %q", directory)
+	}
+
+	if manifest.FormatVersion != backupFormatVersion {
+		return nil, errors.Errorf("the backup in %q uses the %q format, while this version of clusterctl supports only the %q format", directory, manifest.FormatVersion, backupFormatVersion)
+	}
+
+	objs := []unstructured.Unstructured{}
+	for _, file := range manifest.Files {
+		content, err := ioutil.ReadFile(filepath.Join(directory, file))
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read %q from the backup", file)
+		}
+
+		fileObjs, err := utilyaml.ToUnstructured(content)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to parse %q from the backup", file)
+		}
+		objs = append(objs, fileObjs...)
+	}
+	return objs, nil
+}
+
+// getUnpausedClusters returns the Clusters that are not paused.
+func getUnpausedClusters(ctx context.Context, proxy Proxy, clusters []*node) ([]*node, error) {
+	c, err := proxy.NewClient()
+	if err != nil {
+		return nil, err
+	}
+
+	ret := []*node{}
+	for _, cluster := range clusters {
+		clusterObj := &clusterv1.Cluster{}
+		clusterObjKey := client.ObjectKey{
+			Namespace: cluster.identity.Namespace,
+			Name:      cluster.identity.Name,
+		}
+
+		if err := c.Get(ctx, clusterObjKey, clusterObj); err != nil {
+			return nil, errors.Wrapf(err, "error reading %q %s/%s",
+				clusterObj.GroupVersionKind(), clusterObj.GetNamespace(), clusterObj.GetName())
+		}
+
+		if !clusterObj.Spec.Paused {
+			ret = append(ret, cluster)
+		}
+	}
+	return ret, nil
+}
@@ -0,0 +1,184 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func Test_objectMover_backupAndRestore(t *testing.T) {
+	// NB. we are testing backup and restore using the same set of moveTests used for testing move.
+	for _, tt := range moveTests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "clusterctl-backup")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(dir)
+
+			// Create an objectGraph bound a source cluster with all the CRDs for the types involved in the test.
+			graph := getObjectGraphWithObjs(tt.fields.objs)
+
+			// Get all the types to be considered for discovery
+			discoveryTypes, err := getFakeDiscoveryTypes(graph)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// trigger discovery the content of the source cluster
+			if err := graph.Discovery(ctx, "ns1", discoveryTypes); err != nil {
+				t.Fatal(err)
+			}
+
+			mover := objectMover{
+				fromProxy: graph.proxy,
+			}
+
+			// Pause the source clusters, as it happens during backup, and save the objects
+			clustersToPause, err := getUnpausedClusters(ctx, graph.proxy, graph.getClusters())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := setClusterPause(ctx, graph.log, graph.proxy, clustersToPause, true); err != nil {
+				t.Fatal(err)
+			}
+			if err := mover.backup(ctx, graph, clustersToPause, "ns1", dir); err != nil {
+				t.Fatalf("error = %v, want nil", err)
+			}
+
+			// check that there is a file for each object
+			nodes := graph.getNodesForBackup()
+			for _, node := range nodes {
+				if _, err := os.Stat(filepath.Join(dir, backupFileName(node))); err != nil {
+					t.Errorf("error = %v when checking for %s %s/%s saved in the backup", err, node.identity.Kind, node.identity.Namespace, node.identity.Name)
+				}
+			}
+
+			// gets a fakeProxy to an empty cluster with all the required CRDs and restore the objects
+			toProxy := getFakeProxyWithCRDs()
+			toCluster := newClusterClient("", nil, InjectProxy(toProxy))
+
+			if err := mover.Restore(ctx, toCluster, dir); err != nil {
+				t.Fatalf("error = %v, want nil", err)
+			}
+
+			// check that the objects are created in the target cluster and that clusters are not paused
+			csTo, err := toProxy.NewClient()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, node := range nodes {
+				key := client.ObjectKey{
+					Namespace: node.identity.Namespace,
+					Name:      node.identity.Name,
+				}
+
+				oTo := &unstructured.Unstructured{}
+				oTo.SetAPIVersion(node.identity.APIVersion)
+				oTo.SetKind(node.identity.Kind)
+
+				if err := csTo.Get(ctx, key, oTo); err != nil {
+					t.Errorf("error = %v when checking for %v created in target cluster", err, key)
+					continue
+				}
+
+				if node.identity.GroupVersionKind().GroupKind() == clusterv1.GroupVersion.WithKind("Cluster").GroupKind() {
+					paused, _, _ := unstructured.NestedBool(oTo.Object, "spec", "paused")
+					if paused {
+						t.Errorf("%v is still paused in target cluster", key)
+					}
+				}
+			}
+		})
+	}
+}
+
+func Test_readBackup(t *testing.T) {
+	tests := []struct {
+		name     string
+		manifest string
+		files    map[string]string
+		wantObjs int
+		wantErr  bool
+	}{
+		{
+			name:     "fails without a manifest",
+			manifest: "",
+			wantErr:  true,
+		},
+		{
+			name:     "fails with a manifest using an unknown format",
+			manifest: "formatVersion: v0\nfiles: []\n",
+			wantErr:  true,
+		},
+		{
+			name:     "fails if a file is missing",
+			manifest: "formatVersion: v1\nfiles:\n- foo.yaml\n",
+			wantErr:  true,
+		},
+		{
+			name:     "reads all the files listed in the manifest",
+			manifest: "formatVersion: v1\nfiles:\n- foo.yaml\n- bar.yaml\n",
+			files: map[string]string{
+				"foo.yaml": "apiVersion: v1\nkind: Secret\nmetadata:\n  name: foo\n  namespace: ns1\n",
+				"bar.yaml": "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: bar\n  namespace: ns1\n",
+			},
+			wantObjs: 2,
+			wantErr:  false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "clusterctl-backup")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(dir)
+
+			if tt.manifest != "" {
+				if err := ioutil.WriteFile(filepath.Join(dir, backupManifestFile), []byte(tt.manifest), 0600); err != nil {
+					t.Fatal(err)
+				}
+			}
+			for name, content := range tt.files {
+				if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0600); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			got, err := readBackup(dir)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			if len(got) != tt.wantObjs {
+				t.Errorf("got %d objects, want %d", len(got), tt.wantObjs)
+			}
+		})
+	}
+}
@@ -17,13 +17,21 @@ limitations under the License.
 package cluster
 
 import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/url"
 	"time"
 
+	"github.com/go-logr/logr"
 	"github.com/pkg/errors"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	kerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
 	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
-	"sigs.k8s.io/cluster-api/cmd/clusterctl/config"
+	manifests "sigs.k8s.io/cluster-api/cmd/clusterctl/config"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/client/config"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/internal/util"
 	logf "sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -32,57 +40,74 @@ import (
 const (
 	embeddedCertManagerManifestPath = "cmd/clusterctl/config/manifest/cert-manager.yaml"
 
-	waitCertManagerInterval = 1 * time.Second
-	waitCertManagerTimeout  = 10 * time.Minute
+	waitCertManagerTimeout = 10 * time.Minute
+
+	getCertManagerManifestTimeout = 30 * time.Second
 )
 
 // CertManagerClient has methods to work with cert-manager components in the cluster.
 type CertManagerClient interface {
 	// EnsureWebhook makes sure the cert-manager Webhook is Available in a cluster:
 	// this is a requirement to install a new provider
-	EnsureWebhook() error
+	EnsureWebhook(ctx context.Context) error
 
 	// Images return the list of images required for installing the cert-manager.
-	Images() ([]string, error)
+	Images(ctx context.Context) ([]string, error)
+
+	// ObjectsToDelete returns the cert-manager components that Delete removes; only the components installed
+	// by clusterctl are deleted.
+	ObjectsToDelete(ctx context.Context) ([]unstructured.Unstructured, error)
+
+	// Delete deletes the cert-manager components installed by clusterctl.
+	Delete(ctx context.Context) error
 }
 
 // certManagerClient implements CertManagerClient .
 type certManagerClient struct {
-	proxy               Proxy
-	pollImmediateWaiter PollImmediateWaiter
+	configClient config.Client
+	proxy        Proxy
+	objectWaiter ObjectWaiter
+	log          logr.Logger
 }
 
 // Ensure certManagerClient implements the CertManagerClient interface.
 var _ CertManagerClient = &certManagerClient{}
 
 // newCertMangerClient returns a certManagerClient.
-func newCertMangerClient(proxy Proxy, pollImmediateWaiter PollImmediateWaiter) *certManagerClient {
+func newCertMangerClient(configClient config.Client, proxy Proxy, objectWaiter ObjectWaiter) *certManagerClient {
 	return &certManagerClient{
-		proxy:               proxy,
-		pollImmediateWaiter: pollImmediateWaiter,
+		configClient: configClient,
+		proxy:        proxy,
+		objectWaiter: objectWaiter,
+		log:          logf.Log,
 	}
 }
 
 // Images return the list of images required for installing the cert-manager.
-func (cm *certManagerClient) Images() ([]string, error) {
+func (cm *certManagerClient) Images(ctx context.Context) ([]string, error) {
+	certManagerConfig, err := cm.configClient.CertManager().Get()
+	if err != nil {
+		return nil, err
+	}
+
+	// If the cert-manager installation is skipped, no additional images are required.
+	if certManagerConfig.Skip() {
+		return []string{}, nil
+	}
+
 	// Checks if the cert-manager web-hook already exists, if yes, no additional images are required for the web-hook.
 	// Nb. we are ignoring the error so this operation can support listing images even if there is no an existing management cluster;
 	// in case there is no an existing management cluster, we assume there is no web-hook installed in the cluster.
-	hasWebhook, _ := cm.hasWebhook()
+	hasWebhook, _ := cm.hasWebhook(ctx)
 	if hasWebhook {
 		return []string{}, nil
 	}
 
-	yaml, err := config.Asset(embeddedCertManagerManifestPath)
+	objs, err := cm.getManifestObjs(certManagerConfig)
 	if err != nil {
 		return nil, err
 	}
 
-	objs, err := util.ToUnstructured(yaml)
-	if err != nil {
-		return nil, errors.Wrap(err, "failed to parse yaml for cert-manager manifest")
-	}
-
 	images, err := util.InspectImages(objs)
 	if err != nil {
 		return nil, err
@@ -93,12 +118,24 @@ func (cm *certManagerClient) Images() ([]string, error) {
 // EnsureWebhook makes sure the cert-manager Web-hook is Available in a cluster:
 // this is a requirement to install a new provider
 // Nb. In order to provide a simpler out-of-the box experience, the cert-manager manifest
-// is embedded in the clusterctl binary.
-func (cm *certManagerClient) EnsureWebhook() error {
-	log := logf.Log
+// is embedded in the clusterctl binary; the user can override this by pinning a version or by
+// providing the URL of a custom manifest, or skip the installation entirely.
+func (cm *certManagerClient) EnsureWebhook(ctx context.Context) error {
+	log := cm.log
+
+	certManagerConfig, err := cm.configClient.CertManager().Get()
+	if err != nil {
+		return err
+	}
+
+	// If the cert-manager installation is skipped, exit immediately; cert-manager is expected to be managed outside of clusterctl.
+	if certManagerConfig.Skip() {
+		log.V(1).Info("Skipping cert-manager installation")
+		return nil
+	}
 
 	// Checks if the cert-manager web-hook already exists, if yes, exit immediately
-	hasWebhook, err := cm.hasWebhook()
+	hasWebhook, err := cm.hasWebhook(ctx)
 	if err != nil {
 		return err
 	}
@@ -109,17 +146,12 @@ func (cm *certManagerClient) EnsureWebhook() error {
 	// Otherwise install cert-manager
 	log.Info("Installing cert-manager")
 
-	// Gets the cert-manager manifest from the embedded assets and apply it.
-	yaml, err := config.Asset(embeddedCertManagerManifestPath)
+	// Gets the cert-manager manifest and apply it.
+	objs, err := cm.getManifestObjs(certManagerConfig)
 	if err != nil {
 		return err
 	}
 
-	objs, err := util.ToUnstructured(yaml)
-	if err != nil {
-		return errors.Wrap(err, "failed to parse yaml for cert-manager manifest")
-	}
-
 	// installs the web-hook
 	c, err := cm.proxy.NewClient()
 	if err != nil {
@@ -148,11 +180,9 @@ func (cm *certManagerClient) EnsureWebhook() error {
 
 	// Waits for for the cert-manager web-hook to be available.
 	log.Info("Waiting for cert-manager to be available...")
-	if err := cm.pollImmediateWaiter(waitCertManagerInterval, waitCertManagerTimeout, func() (bool, error) {
-		webhook, err := cm.getWebhook(c)
-		if err != nil {
-			return false, errors.Wrap(err, "failed to get cert-manager web-hook")
-		}
+	webhookRef := newWebhook()
+	webhookKey := client.ObjectKey{Name: webhookRef.GetName()}
+	if err := cm.objectWaiter(ctx, webhookRef.GroupVersionKind(), webhookKey, waitCertManagerTimeout, func(webhook *unstructured.Unstructured) (bool, error) {
 		if webhook == nil {
 			return false, nil
 		}
@@ -170,12 +200,119 @@ func (cm *certManagerClient) EnsureWebhook() error {
 	return nil
 }
 
+func (cm *certManagerClient) ObjectsToDelete(ctx context.Context) ([]unstructured.Unstructured, error) {
+	// The cert-manager components installed by clusterctl are identified by the core label.
+	return cm.proxy.ListResources(ctx, "", map[string]string{clusterctlv1.ClusterctlCoreLabelName: "cert-manager"})
+}
+
+func (cm *certManagerClient) Delete(ctx context.Context) error {
+	log := cm.log
+
+	objs, err := cm.ObjectsToDelete(ctx)
+	if err != nil {
+		return err
+	}
+	if len(objs) == 0 {
+		log.V(1).Info("Skipping cert-manager deletion, cert-manager was not installed by clusterctl")
+		return nil
+	}
+
+	log.Info("Deleting cert-manager")
+
+	// Keep track of the namespaces we are deleting.
+	namespacesToDelete := sets.NewString()
+	for _, obj := range objs {
+		if obj.GroupVersionKind().Kind == "Namespace" {
+			namespacesToDelete.Insert(obj.GetName())
+		}
+	}
+
+	c, err := cm.proxy.NewClient()
+	if err != nil {
+		return err
+	}
+
+	errList := []error{}
+	for i := range objs {
+		obj := objs[i]
+
+		// Objects in a namespace that is going to be deleted are deleted by the Namespace controller.
+		if namespacesToDelete.Has(obj.GetNamespace()) {
+			continue
+		}
+
+		log.V(5).Info("Deleting", logf.UnstructuredToValues(obj)...)
+		if err := c.Delete(ctx, &obj); err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			errList = append(errList, errors.Wrapf(err, "failed to delete cert-manager component: %s, %s/%s", obj.GroupVersionKind(), obj.GetNamespace(), obj.GetName()))
+		}
+	}
+
+	return kerrors.NewAggregate(errList)
+}
+
+// getManifestObjs returns the cert-manager manifest, either embedded in the clusterctl binary or read from the configured URL,
+// converted into a list of objects.
+func (cm *certManagerClient) getManifestObjs(certManagerConfig config.CertManager) ([]unstructured.Unstructured, error) {
+	var yaml []byte
+	var err error
+	if certManagerConfig.URL() == "" {
+		yaml, err = manifests.Asset(embeddedCertManagerManifestPath)
+	} else {
+		yaml, err = getCertManagerManifest(certManagerConfig.URL())
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	objs, err := util.ToUnstructured(yaml)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse yaml for cert-manager manifest")
+	}
+	return objs, nil
+}
+
+// getCertManagerManifest reads the cert-manager manifest from a local file or from an http(s) URL.
+func getCertManagerManifest(manifestURL string) ([]byte, error) {
+	u, err := url.Parse(manifestURL)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid cert-manager manifest URL %q", manifestURL)
+	}
+
+	switch u.Scheme {
+	case "", "file":
+		content, err := ioutil.ReadFile(u.Path)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read cert-manager manifest %q", manifestURL)
+		}
+		return content, nil
+	case "http", "https":
+		client := &http.Client{Timeout: getCertManagerManifestTimeout}
+		resp, err := client.Get(manifestURL)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get cert-manager manifest %q", manifestURL)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			return nil, errors.Errorf("failed to get cert-manager manifest %q: %s", manifestURL, resp.Status)
+		}
+
+		content, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read cert-manager manifest %q", manifestURL)
+		}
+		return content, nil
+	default:
+		return nil, errors.Errorf("invalid cert-manager manifest URL %q: only file, http and https schemes are supported", manifestURL)
+	}
+}
+
 // getWebhook returns the cert-manager Webhook or nil if it does not exists.
-func (cm *certManagerClient) getWebhook(c client.Client) (*unstructured.Unstructured, error) {
-	webhook := &unstructured.Unstructured{}
-	webhook.SetAPIVersion("apiregistration.k8s.io/v1beta1")
-	webhook.SetKind("APIService")
-	webhook.SetName("v1beta1.webhook.cert-manager.io")
+func (cm *certManagerClient) getWebhook(ctx context.Context, c client.Client) (*unstructured.Unstructured, error) {
+	webhook := newWebhook()
 
 	key, err := client.ObjectKeyFromObject(webhook)
 	if err != nil {
@@ -193,15 +330,24 @@ func (cm *certManagerClient) getWebhook(c client.Client) (*unstructured.Unstruct
 	return webhook, nil
 }
 
+// newWebhook returns an empty object identifying the cert-manager web-hook.
+func newWebhook() *unstructured.Unstructured {
+	webhook := &unstructured.Unstructured{}
+	webhook.SetAPIVersion("apiregistration.k8s.io/v1beta1")
+	webhook.SetKind("APIService")
+	webhook.SetName("v1beta1.webhook.cert-manager.io")
+	return webhook
+}
+
 // hasWebhook returns true if there is already a web-hook in the cluster
-func (cm *certManagerClient) hasWebhook() (bool, error) {
+func (cm *certManagerClient) hasWebhook(ctx context.Context) (bool, error) {
 	c, err := cm.proxy.NewClient()
 	if err != nil {
 		return false, err
 	}
 
 	// Checks if the cert-manager web-hook already exists, if yes, no additional images are required
-	webhook, err := cm.getWebhook(c)
+	webhook, err := cm.getWebhook(ctx, c)
 	if err != nil {
 		return false, errors.Wrap(err, "failed to check if the cert-manager web-hook exists")
 	}
@@ -0,0 +1,168 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/client/config"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/internal/test"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+var customCertManagerManifest = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: cert-manager-webhook
+  namespace: cert-manager
+spec:
+  template:
+    spec:
+      containers:
+      - name: webhook
+        image: registry.example.com/cert-manager-webhook:v0.11.1
+`
+
+func Test_certManagerClient_Images(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "cc")
+	if err != nil {
+		t.Fatalf("ioutil.TempDir() error = %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	manifestPath := filepath.Join(tmpDir, "cert-manager.yaml")
+	if err := ioutil.WriteFile(manifestPath, []byte(customCertManagerManifest), 0600); err != nil {
+		t.Fatalf("ioutil.WriteFile() error = %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		reader  config.Reader
+		want    []string
+		wantErr bool
+	}{
+		{
+			name:    "returns images from a custom manifest",
+			reader:  test.NewFakeReader().WithVar(config.CertManagerConfigKey, "url: "+manifestPath),
+			want:    []string{"registry.example.com/cert-manager-webhook:v0.11.1"},
+			wantErr: false,
+		},
+		{
+			name:    "returns no images if the cert-manager installation is skipped",
+			reader:  test.NewFakeReader().WithVar(config.CertManagerConfigKey, "skip: true"),
+			want:    []string{},
+			wantErr: false,
+		},
+		{
+			name:    "fails if the custom manifest does not exist",
+			reader:  test.NewFakeReader().WithVar(config.CertManagerConfigKey, "url: "+filepath.Join(tmpDir, "foo.yaml")),
+			want:    nil,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configClient, _ := config.New("", config.InjectReader(tt.reader))
+
+			cm := newCertMangerClient(configClient, test.NewFakeProxy(), fakeObjectWaiter)
+			got, err := cm.Images(ctx)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("got = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func Test_certManagerClient_Delete(t *testing.T) {
+	certManagerLabels := map[string]string{clusterctlv1.ClusterctlCoreLabelName: "cert-manager"}
+
+	proxy := test.NewFakeProxy().WithObjs(
+		&corev1.Namespace{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Namespace"},
+			ObjectMeta: metav1.ObjectMeta{Name: "cert-manager", Labels: certManagerLabels},
+		},
+		&corev1.ServiceAccount{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "ServiceAccount"},
+			ObjectMeta: metav1.ObjectMeta{Namespace: "cert-manager", Name: "cert-manager", Labels: certManagerLabels},
+		},
+		&rbacv1.ClusterRole{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "rbac.authorization.k8s.io/v1", Kind: "ClusterRole"},
+			ObjectMeta: metav1.ObjectMeta{Name: "cert-manager-controller", Labels: certManagerLabels},
+		},
+		// An object not installed by clusterctl (should never be deleted)
+		&rbacv1.ClusterRole{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "rbac.authorization.k8s.io/v1", Kind: "ClusterRole"},
+			ObjectMeta: metav1.ObjectMeta{Name: "other"},
+		},
+	)
+	cm := newCertMangerClient(nil, proxy, fakeObjectWaiter)
+
+	objs, err := cm.ObjectsToDelete(ctx)
+	if err != nil {
+		t.Fatalf("ObjectsToDelete() error = %v", err)
+	}
+	if len(objs) != 3 {
+		t.Errorf("got %d objects to delete, want 3", len(objs))
+	}
+
+	if err := cm.Delete(ctx); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+
+	c, err := proxy.NewClient()
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	tests := []struct {
+		obj     runtime.Object
+		key     client.ObjectKey
+		deleted bool
+	}{
+		{obj: &corev1.Namespace{}, key: client.ObjectKey{Name: "cert-manager"}, deleted: true},
+		{obj: &rbacv1.ClusterRole{}, key: client.ObjectKey{Name: "cert-manager-controller"}, deleted: true},
+		{obj: &rbacv1.ClusterRole{}, key: client.ObjectKey{Name: "other"}, deleted: false},
+	}
+	for _, tt := range tests {
+		err := c.Get(ctx, tt.key, tt.obj)
+		if err != nil && !apierrors.IsNotFound(err) {
+			t.Fatalf("Failed to get %v from the cluster: %v", tt.key, err)
+		}
+		if deleted := apierrors.IsNotFound(err); deleted != tt.deleted {
+			t.Errorf("%v deleted = %v, want %v", tt.key, deleted, tt.deleted)
+		}
+	}
+}
@@ -0,0 +1,383 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/pkg/errors"
+	admissionregistrationv1beta1 "k8s.io/api/admissionregistration/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
+	logf "sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// CertificateStatusOK is the status of a certificate that is valid and not approaching its expiry.
+	CertificateStatusOK = "OK"
+
+	// CertificateStatusWarning is the status of a certificate approaching its expiry.
+	CertificateStatusWarning = "Warning"
+
+	// CertificateStatusError is the status of a certificate that is expired, not ready or missing.
+	CertificateStatusError = "Error"
+
+	certManagerAPIVersion = "cert-manager.io/v1alpha2"
+)
+
+// CertificateReport reports the state of a certificate used by the providers installed by clusterctl, that is
+// a cert-manager Certificate or Issuer, or the CA bundle of a webhook.
+type CertificateReport struct {
+	// Kind, Namespace and Name of the object the certificate belongs to, e.g. a Certificate or a CustomResourceDefinition.
+	Kind      string
+	Namespace string
+	Name      string
+
+	// NotAfter is the expiry time of the certificate; it is nil if unknown, e.g. for Issuers.
+	NotAfter *time.Time
+
+	// Status of the certificate, one of OK, Warning or Error.
+	Status string
+
+	// Message explains the status of the certificate if not OK.
+	Message string
+}
+
+// String returns a string identifying the object the certificate belongs to, e.g. Certificate capi-system/capi-serving-cert.
+func (r CertificateReport) String() string {
+	if r.Namespace == "" {
+		return fmt.Sprintf("%s %s", r.Kind, r.Name)
+	}
+	return fmt.Sprintf("%s %s/%s", r.Kind, r.Namespace, r.Name)
+}
+
+// CanRenew returns true if the certificate can be renewed by cert-manager.
+func (r CertificateReport) CanRenew() bool {
+	return r.Kind == "Certificate"
+}
+
+// CertificateChecker has methods to check the certificates used by the webhooks of the providers installed by clusterctl.
+type CertificateChecker interface {
+	// Check returns the state of the cert-manager Certificates and Issuers installed by clusterctl, and of the
+	// CA bundles of the webhooks of the providers installed by clusterctl; certificates expiring
+	// within warnBefore are reported with a Warning status.
+	Check(ctx context.Context, warnBefore time.Duration) ([]CertificateReport, error)
+
+	// Renew triggers the renewal of a cert-manager Certificate by deleting the Secret where the certificate is stored,
+	// so cert-manager issues a new one; the CA bundles of the webhooks are then updated by the cert-manager CA injector.
+	Renew(ctx context.Context, report CertificateReport) error
+}
+
+// certificateChecker implements CertificateChecker.
+type certificateChecker struct {
+	proxy Proxy
+	log   logr.Logger
+}
+
+// ensure certificateChecker implements CertificateChecker.
+var _ CertificateChecker = &certificateChecker{}
+
+func newCertificateChecker(proxy Proxy) *certificateChecker {
+	return &certificateChecker{
+		proxy: proxy,
+		log:   logf.Log,
+	}
+}
+
+func (c *certificateChecker) Check(ctx context.Context, warnBefore time.Duration) ([]CertificateReport, error) {
+	log := c.log
+	log.V(1).Info("Checking certificates")
+
+	cs, err := c.proxy.NewClient()
+	if err != nil {
+		return nil, err
+	}
+
+	var reports []CertificateReport
+	for _, check := range []func(context.Context, client.Client, time.Time) ([]CertificateReport, error){
+		checkCertManagerCertificates,
+		checkCertManagerIssuers,
+		checkCRDCABundles,
+		checkWebhookConfigurationCABundles,
+	} {
+		r, err := check(ctx, cs, time.Now().Add(warnBefore))
+		if err != nil {
+			return nil, err
+		}
+		reports = append(reports, r...)
+	}
+
+	sort.Slice(reports, func(i, j int) bool {
+		if reports[i].Kind != reports[j].Kind {
+			return reports[i].Kind < reports[j].Kind
+		}
+		if reports[i].Namespace != reports[j].Namespace {
+			return reports[i].Namespace < reports[j].Namespace
+		}
+		return reports[i].Name < reports[j].Name
+	})
+	return reports, nil
+}
+
+func (c *certificateChecker) Renew(ctx context.Context, report CertificateReport) error {
+	if !report.CanRenew() {
+		return errors.Errorf("%s can't be renewed, only cert-manager Certificates can be renewed", report)
+	}
+
+	cs, err := c.proxy.NewClient()
+	if err != nil {
+		return err
+	}
+
+	certificate := &unstructured.Unstructured{}
+	certificate.SetAPIVersion(certManagerAPIVersion)
+	certificate.SetKind("Certificate")
+	if err := cs.Get(ctx, client.ObjectKey{Namespace: report.Namespace, Name: report.Name}, certificate); err != nil {
+		return errors.Wrapf(err, "failed to get %s", report)
+	}
+
+	secretName, _, err := unstructured.NestedString(certificate.Object, "spec", "secretName")
+	if err != nil || secretName == "" {
+		return errors.Errorf("failed to get the secret name of %s", report)
+	}
+
+	c.log.Info("Triggering the renewal of the certificate", "Certificate", report.String(), "Secret", secretName)
+	secret := &corev1.Secret{}
+	secret.SetNamespace(report.Namespace)
+	secret.SetName(secretName)
+	if err := cs.Delete(ctx, secret); err != nil && !apierrors.IsNotFound(err) {
+		return errors.Wrapf(err, "failed to delete the secret %s/%s of %s", report.Namespace, secretName, report)
+	}
+	return nil
+}
+
+// listCertManagerObjects lists the cert-manager objects of the given kind installed by clusterctl; if cert-manager is
+// not installed, an empty list is returned.
+func listCertManagerObjects(ctx context.Context, c client.Client, kind string) ([]unstructured.Unstructured, error) {
+	objList := &unstructured.UnstructuredList{}
+	objList.SetAPIVersion(certManagerAPIVersion)
+	objList.SetKind(kind + "List")
+	if err := c.List(ctx, objList, client.MatchingLabels{clusterctlv1.ClusterctlLabelName: ""}); err != nil {
+		if meta.IsNoMatchError(err) {
+			return nil, nil
+		}
+		return nil, errors.Wrapf(err, "failed to list cert-manager %s objects", kind)
+	}
+	return objList.Items, nil
+}
+
+// readyCondition returns the status and the message of the Ready condition of a cert-manager object.
+func readyCondition(obj *unstructured.Unstructured) (string, string) {
+	conditions, _, _ := unstructured.NestedSlice(obj.Object, "status", "conditions")
+	for _, c := range conditions {
+		condition, ok := c.(map[string]interface{})
+		if !ok || condition["type"] != "Ready" {
+			continue
+		}
+		status, _ := condition["status"].(string)
+		message, _ := condition["message"].(string)
+		return status, message
+	}
+	return "Unknown", "the Ready condition is not reported"
+}
+
+func checkCertManagerCertificates(ctx context.Context, c client.Client, warnAfter time.Time) ([]CertificateReport, error) {
+	certificates, err := listCertManagerObjects(ctx, c, "Certificate")
+	if err != nil {
+		return nil, err
+	}
+
+	var reports []CertificateReport
+	for i := range certificates {
+		reports = append(reports, certificateReport(&certificates[i], warnAfter))
+	}
+	return reports, nil
+}
+
+// certificateReport returns the report for a cert-manager Certificate.
+func certificateReport(certificate *unstructured.Unstructured, warnAfter time.Time) CertificateReport {
+	report := CertificateReport{
+		Kind:      "Certificate",
+		Namespace: certificate.GetNamespace(),
+		Name:      certificate.GetName(),
+		Status:    CertificateStatusOK,
+	}
+
+	if notAfter, ok, _ := unstructured.NestedString(certificate.Object, "status", "notAfter"); ok {
+		t, err := time.Parse(time.RFC3339, notAfter)
+		if err != nil {
+			report.Status = CertificateStatusError
+			report.Message = fmt.Sprintf("failed to parse the expiry time of the certificate: %v", err)
+			return report
+		}
+		report.NotAfter = &t
+	}
+
+	if status, message := readyCondition(certificate); status != "True" {
+		report.Status = CertificateStatusError
+		report.Message = fmt.Sprintf("the certificate is not ready: %s", message)
+		return report
+	}
+
+	setExpiryStatus(&report, warnAfter)
+	return report
+}
+
+func checkCertManagerIssuers(ctx context.Context, c client.Client, _ time.Time) ([]CertificateReport, error) {
+	issuers, err := listCertManagerObjects(ctx, c, "Issuer")
+	if err != nil {
+		return nil, err
+	}
+
+	var reports []CertificateReport
+	for i := range issuers {
+		reports = append(reports, issuerReport(&issuers[i]))
+	}
+	return reports, nil
+}
+
+// issuerReport returns the report for a cert-manager Issuer.
+func issuerReport(issuer *unstructured.Unstructured) CertificateReport {
+	report := CertificateReport{
+		Kind:      "Issuer",
+		Namespace: issuer.GetNamespace(),
+		Name:      issuer.GetName(),
+		Status:    CertificateStatusOK,
+	}
+	if status, message := readyCondition(issuer); status != "True" {
+		report.Status = CertificateStatusError
+		report.Message = fmt.Sprintf("the issuer is not ready: %s", message)
+	}
+	return report
+}
+
+func checkCRDCABundles(ctx context.Context, c client.Client, warnAfter time.Time) ([]CertificateReport, error) {
+	crdList := &apiextensionsv1.CustomResourceDefinitionList{}
+	if err := c.List(ctx, crdList, client.MatchingLabels{clusterctlv1.ClusterctlLabelName: ""}); err != nil {
+		return nil, errors.Wrap(err, "failed to get the list of CRDs installed by clusterctl")
+	}
+
+	var reports []CertificateReport
+	for i := range crdList.Items {
+		crd := &crdList.Items[i]
+		if crd.Spec.Conversion == nil || crd.Spec.Conversion.Strategy != apiextensionsv1.WebhookConverter ||
+			crd.Spec.Conversion.WebhookClientConfig == nil || crd.Spec.Conversion.WebhookClientConfig.Service == nil {
+			continue
+		}
+		reports = append(reports, caBundleReport("CustomResourceDefinition", "", crd.Name, crd.Spec.Conversion.WebhookClientConfig.CABundle, warnAfter))
+	}
+	return reports, nil
+}
+
+func checkWebhookConfigurationCABundles(ctx context.Context, c client.Client, warnAfter time.Time) ([]CertificateReport, error) {
+	var reports []CertificateReport
+
+	validatingList := &admissionregistrationv1beta1.ValidatingWebhookConfigurationList{}
+	if err := c.List(ctx, validatingList, client.MatchingLabels{clusterctlv1.ClusterctlLabelName: ""}); err != nil {
+		return nil, errors.Wrap(err, "failed to get the list of ValidatingWebhookConfigurations installed by clusterctl")
+	}
+	for _, config := range validatingList.Items {
+		for _, webhook := range config.Webhooks {
+			reports = append(reports, caBundleReport("ValidatingWebhookConfiguration", "", fmt.Sprintf("%s/%s", config.Name, webhook.Name), webhook.ClientConfig.CABundle, warnAfter))
+		}
+	}
+
+	mutatingList := &admissionregistrationv1beta1.MutatingWebhookConfigurationList{}
+	if err := c.List(ctx, mutatingList, client.MatchingLabels{clusterctlv1.ClusterctlLabelName: ""}); err != nil {
+		return nil, errors.Wrap(err, "failed to get the list of MutatingWebhookConfigurations installed by clusterctl")
+	}
+	for _, config := range mutatingList.Items {
+		for _, webhook := range config.Webhooks {
+			reports = append(reports, caBundleReport("MutatingWebhookConfiguration", "", fmt.Sprintf("%s/%s", config.Name, webhook.Name), webhook.ClientConfig.CABundle, warnAfter))
+		}
+	}
+
+	return reports, nil
+}
+
+// caBundleReport returns the report for a webhook CA bundle, using the earliest expiry time of the certificates in the bundle.
+func caBundleReport(kind, namespace, name string, caBundle []byte, warnAfter time.Time) CertificateReport {
+	report := CertificateReport{
+		Kind:      kind,
+		Namespace: namespace,
+		Name:      name,
+		Status:    CertificateStatusOK,
+	}
+
+	if len(caBundle) == 0 {
+		report.Status = CertificateStatusError
+		report.Message = "the webhook client configuration does not have a caBundle; check if the CA injection by cert-manager is working"
+		return report
+	}
+
+	rest := caBundle
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			report.Status = CertificateStatusError
+			report.Message = fmt.Sprintf("the caBundle contains an invalid certificate: %v", err)
+			return report
+		}
+		if report.NotAfter == nil || cert.NotAfter.Before(*report.NotAfter) {
+			notAfter := cert.NotAfter
+			report.NotAfter = &notAfter
+		}
+	}
+
+	if report.NotAfter == nil {
+		report.Status = CertificateStatusError
+		report.Message = "the caBundle does not contain any certificate"
+		return report
+	}
+
+	setExpiryStatus(&report, warnAfter)
+	return report
+}
+
+// setExpiryStatus sets the status of a report according to the expiry time of the certificate.
+func setExpiryStatus(report *CertificateReport, warnAfter time.Time) {
+	if report.NotAfter == nil {
+		return
+	}
+	switch {
+	case report.NotAfter.Before(time.Now()):
+		report.Status = CertificateStatusError
+		report.Message = "the certificate is expired"
+	case report.NotAfter.Before(warnAfter):
+		report.Status = CertificateStatusWarning
+		report.Message = fmt.Sprintf("the certificate expires in %s", time.Until(*report.NotAfter).Round(time.Hour))
+	}
+}
@@ -0,0 +1,311 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	admissionregistrationv1beta1 "k8s.io/api/admissionregistration/v1beta1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/internal/test"
+)
+
+// caBundle returns a PEM encoded self-signed CA certificate expiring at notAfter.
+func caBundle(t *testing.T, notAfter time.Time) []byte {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate the key: %v", err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test-ca"},
+		NotBefore:             notAfter.Add(-365 * 24 * time.Hour),
+		NotAfter:              notAfter,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("failed to create the certificate: %v", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+func readyConditions(status, message string) []interface{} {
+	return []interface{}{
+		map[string]interface{}{
+			"type":    "Ready",
+			"status":  status,
+			"message": message,
+		},
+	}
+}
+
+func certManagerObject(kind, name string, status map[string]interface{}) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{}}
+	obj.SetAPIVersion(certManagerAPIVersion)
+	obj.SetKind(kind)
+	obj.SetNamespace("capi-system")
+	obj.SetName(name)
+	if status != nil {
+		obj.Object["status"] = status
+	}
+	return obj
+}
+
+func Test_certificateReport(t *testing.T) {
+	now := time.Now()
+	warnAfter := now.Add(30 * 24 * time.Hour)
+
+	tests := []struct {
+		name        string
+		certificate *unstructured.Unstructured
+		wantStatus  string
+	}{
+		{
+			name: "Ready certificate not approaching expiry",
+			certificate: certManagerObject("Certificate", "capi-serving-cert", map[string]interface{}{
+				"notAfter":   now.Add(60 * 24 * time.Hour).UTC().Format(time.RFC3339),
+				"conditions": readyConditions("True", ""),
+			}),
+			wantStatus: CertificateStatusOK,
+		},
+		{
+			name: "Ready certificate approaching expiry",
+			certificate: certManagerObject("Certificate", "capi-serving-cert", map[string]interface{}{
+				"notAfter":   now.Add(10 * 24 * time.Hour).UTC().Format(time.RFC3339),
+				"conditions": readyConditions("True", ""),
+			}),
+			wantStatus: CertificateStatusWarning,
+		},
+		{
+			name: "Expired certificate",
+			certificate: certManagerObject("Certificate", "capi-serving-cert", map[string]interface{}{
+				"notAfter":   now.Add(-time.Hour).UTC().Format(time.RFC3339),
+				"conditions": readyConditions("True", ""),
+			}),
+			wantStatus: CertificateStatusError,
+		},
+		{
+			name: "Certificate not ready",
+			certificate: certManagerObject("Certificate", "capi-serving-cert", map[string]interface{}{
+				"conditions": readyConditions("False", "issuer not found"),
+			}),
+			wantStatus: CertificateStatusError,
+		},
+		{
+			name:        "Certificate without status",
+			certificate: certManagerObject("Certificate", "capi-serving-cert", nil),
+			wantStatus:  CertificateStatusError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := certificateReport(tt.certificate, warnAfter)
+			if got.Status != tt.wantStatus {
+				t.Errorf("got status %s (%s), want %s", got.Status, got.Message, tt.wantStatus)
+			}
+			if got.Namespace != "capi-system" || got.Name != "capi-serving-cert" || !got.CanRenew() {
+				t.Errorf("got %s, want a renewable report for Certificate capi-system/capi-serving-cert", got)
+			}
+		})
+	}
+}
+
+func Test_issuerReport(t *testing.T) {
+	tests := []struct {
+		name       string
+		issuer     *unstructured.Unstructured
+		wantStatus string
+	}{
+		{
+			name:       "Ready issuer",
+			issuer:     certManagerObject("Issuer", "capi-selfsigned-issuer", map[string]interface{}{"conditions": readyConditions("True", "")}),
+			wantStatus: CertificateStatusOK,
+		},
+		{
+			name:       "Issuer not ready",
+			issuer:     certManagerObject("Issuer", "capi-selfsigned-issuer", map[string]interface{}{"conditions": readyConditions("False", "secret not found")}),
+			wantStatus: CertificateStatusError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := issuerReport(tt.issuer)
+			if got.Status != tt.wantStatus {
+				t.Errorf("got status %s (%s), want %s", got.Status, got.Message, tt.wantStatus)
+			}
+			if got.CanRenew() {
+				t.Errorf("got a renewable report for %s, want not renewable", got)
+			}
+		})
+	}
+}
+
+func Test_caBundleReport(t *testing.T) {
+	now := time.Now()
+	warnAfter := now.Add(30 * 24 * time.Hour)
+
+	tests := []struct {
+		name         string
+		caBundle     func(t *testing.T) []byte
+		wantStatus   string
+		wantNotAfter bool
+	}{
+		{
+			name:         "Valid caBundle",
+			caBundle:     func(t *testing.T) []byte { return caBundle(t, now.Add(365*24*time.Hour)) },
+			wantStatus:   CertificateStatusOK,
+			wantNotAfter: true,
+		},
+		{
+			name: "caBundle with a certificate approaching expiry",
+			caBundle: func(t *testing.T) []byte {
+				return append(caBundle(t, now.Add(365*24*time.Hour)), caBundle(t, now.Add(24*time.Hour))...)
+			},
+			wantStatus:   CertificateStatusWarning,
+			wantNotAfter: true,
+		},
+		{
+			name:         "Expired caBundle",
+			caBundle:     func(t *testing.T) []byte { return caBundle(t, now.Add(-time.Hour)) },
+			wantStatus:   CertificateStatusError,
+			wantNotAfter: true,
+		},
+		{
+			name:       "Empty caBundle",
+			caBundle:   func(t *testing.T) []byte { return nil },
+			wantStatus: CertificateStatusError,
+		},
+		{
+			name:       "caBundle without certificates",
+			caBundle:   func(t *testing.T) []byte { return []byte("not a certificate") },
+			wantStatus: CertificateStatusError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := caBundleReport("CustomResourceDefinition", "", "clusters.cluster.x-k8s.io", tt.caBundle(t), warnAfter)
+			if got.Status != tt.wantStatus {
+				t.Errorf("got status %s (%s), want %s", got.Status, got.Message, tt.wantStatus)
+			}
+			if (got.NotAfter != nil) != tt.wantNotAfter {
+				t.Errorf("got NotAfter %v, want NotAfter set %t", got.NotAfter, tt.wantNotAfter)
+			}
+		})
+	}
+}
+
+func Test_checkWebhookCABundles(t *testing.T) {
+	now := time.Now()
+	warnAfter := now.Add(30 * 24 * time.Hour)
+
+	crd := test.FakeCustomResourceDefinition("cluster.x-k8s.io", "Cluster", "v1alpha3")
+	crd.Spec.Conversion = &apiextensionsv1.CustomResourceConversion{
+		Strategy: apiextensionsv1.WebhookConverter,
+		WebhookClientConfig: &apiextensionsv1.WebhookClientConfig{
+			Service: &apiextensionsv1.ServiceReference{
+				Namespace: "capi-system",
+				Name:      "capi-webhook-service",
+			},
+			CABundle: caBundle(t, now.Add(24*time.Hour)),
+		},
+	}
+	crdWithoutWebhook := test.FakeCustomResourceDefinition("cluster.x-k8s.io", "Machine", "v1alpha3")
+
+	validating := &admissionregistrationv1beta1.ValidatingWebhookConfiguration{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: admissionregistrationv1beta1.SchemeGroupVersion.String(),
+			Kind:       "ValidatingWebhookConfiguration",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   "capi-validating-webhook-configuration",
+			Labels: map[string]string{clusterctlv1.ClusterctlLabelName: ""},
+		},
+		Webhooks: []admissionregistrationv1beta1.ValidatingWebhook{
+			{
+				Name:         "validation.cluster.cluster.x-k8s.io",
+				ClientConfig: admissionregistrationv1beta1.WebhookClientConfig{CABundle: caBundle(t, now.Add(365*24*time.Hour))},
+			},
+		},
+	}
+	mutating := &admissionregistrationv1beta1.MutatingWebhookConfiguration{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: admissionregistrationv1beta1.SchemeGroupVersion.String(),
+			Kind:       "MutatingWebhookConfiguration",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   "capi-mutating-webhook-configuration",
+			Labels: map[string]string{clusterctlv1.ClusterctlLabelName: ""},
+		},
+		Webhooks: []admissionregistrationv1beta1.MutatingWebhook{
+			{
+				Name: "default.cluster.cluster.x-k8s.io",
+			},
+		},
+	}
+
+	proxy := test.NewFakeProxy().WithObjs([]runtime.Object{crd, crdWithoutWebhook, validating, mutating}...)
+	c, err := proxy.NewClient()
+	if err != nil {
+		t.Fatalf("failed to create the client: %v", err)
+	}
+
+	crdReports, err := checkCRDCABundles(ctx, c, warnAfter)
+	if err != nil {
+		t.Fatalf("checkCRDCABundles() error = %v", err)
+	}
+	if len(crdReports) != 1 || crdReports[0].Name != crd.Name || crdReports[0].Status != CertificateStatusWarning {
+		t.Errorf("got %v, want a Warning report for the %s CRD", crdReports, crd.Name)
+	}
+
+	webhookReports, err := checkWebhookConfigurationCABundles(ctx, c, warnAfter)
+	if err != nil {
+		t.Fatalf("checkWebhookConfigurationCABundles() error = %v", err)
+	}
+	want := map[string]string{
+		"ValidatingWebhookConfiguration capi-validating-webhook-configuration/validation.cluster.cluster.x-k8s.io": CertificateStatusOK,
+		"MutatingWebhookConfiguration capi-mutating-webhook-configuration/default.cluster.cluster.x-k8s.io":        CertificateStatusError,
+	}
+	if len(webhookReports) != len(want) {
+		t.Fatalf("got %d reports, want %d", len(webhookReports), len(want))
+	}
+	for _, r := range webhookReports {
+		if want[r.String()] != r.Status {
+			t.Errorf("got status %s for %s, want %s", r.Status, r, want[r.String()])
+		}
+	}
+}
+
+func Test_certificateChecker_Renew(t *testing.T) {
+	checker := newCertificateChecker(test.NewFakeProxy())
+	if err := checker.Renew(ctx, CertificateReport{Kind: "Issuer", Namespace: "capi-system", Name: "capi-selfsigned-issuer"}); err == nil {
+		t.Error("expected an error renewing an Issuer, got nil")
+	}
+}
@@ -18,20 +18,18 @@ package cluster
 
 import (
 	"context"
-	"time"
 
+	"github.com/go-logr/logr"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
-	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/client/config"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/client/repository"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/internal/test"
+	logf "sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
-var (
-	ctx = context.TODO()
-)
-
 // Client is used to interact with a management cluster.
 // A management cluster contains following categories of objects:
 // - provider components (e.g. the CRDs, controllers, RBAC)
@@ -41,9 +39,16 @@ type Client interface {
 	// Kubeconfig return the path to kubeconfig used to access to a management cluster.
 	Kubeconfig() string
 
+	// KubeconfigContext return the kubeconfig context used to access to a management cluster.
+	// If empty, the current context of the kubeconfig file is used.
+	KubeconfigContext() string
+
 	// Proxy return the Proxy used for operating objects in the management cluster.
 	Proxy() Proxy
 
+	// ProgressReporter returns the ProgressReporter receiving the events emitted by long-running operations, if any.
+	ProgressReporter() ProgressReporter
+
 	// CertManager returns a CertManagerClient that can be user for
 	// operating the cert-manager components in the cluster.
 	CertManager() CertManagerClient
@@ -56,6 +61,10 @@ type Client interface {
 	// operating provider inventory stored in the management cluster (e.g. the list of installed providers/versions).
 	ProviderInventory() InventoryClient
 
+	// WithInventoryNamespaces returns a Client whose provider inventory, installer and upgrader are scoped to the
+	// given namespaces, so independent users can manage their own providers in the same management cluster.
+	WithInventoryNamespaces(namespaces ...string) Client
+
 	// ProviderInstaller returns a ProviderInstaller that enforces consistency rules for provider installation,
 	// trying to prevent e.g. controllers fighting for objects, inconsistent versions, etc.
 	ProviderInstaller() ProviderInstaller
@@ -67,20 +76,74 @@ type Client interface {
 	// ProviderUpgrader returns a ProviderUpgrader that supports upgrading Cluster API providers.
 	ProviderUpgrader() ProviderUpgrader
 
+	// UpgradeVerifier returns an UpgradeVerifier that can be used for verifying the providers are working after an upgrade.
+	UpgradeVerifier() UpgradeVerifier
+
 	// Template has methods to work with templates stored in the cluster.
 	Template() TemplateClient
-}
 
-// PollImmediateWaiter tries a condition func until it returns true, an error, or the timeout is reached.
-type PollImmediateWaiter func(interval, timeout time.Duration, condition wait.ConditionFunc) error
+	// ProviderLogs returns a LogsClient that can be used for reading the logs of the provider controllers.
+	ProviderLogs() LogsClient
+
+	// ScaleSimulator returns a ScaleSimulator that can be used for simulating scale operations on workload clusters.
+	ScaleSimulator() ScaleSimulator
+
+	// ConversionWebhooks returns a ConversionWebhookClient that can be used for verifying the conversion webhooks
+	// of the CRDs installed by clusterctl.
+	ConversionWebhooks() ConversionWebhookClient
+
+	// VersionReporter returns a VersionReporter that can be used for reporting the versions of the workload clusters.
+	VersionReporter() VersionReporter
+
+	// StatusLister returns a StatusLister that can be used for listing the status of the Clusters and of the Machines.
+	StatusLister() StatusLister
+
+	// OrphanFinder returns an OrphanFinder that can be used for finding and deleting the infrastructure objects
+	// without a corresponding Machine/Cluster owner.
+	OrphanFinder() OrphanFinder
+
+	// CertificateChecker returns a CertificateChecker that can be used for checking and renewing the certificates
+	// used by the webhooks of the providers installed by clusterctl.
+	CertificateChecker() CertificateChecker
+
+	// MachineActions returns a MachineActionClient that can be used for requesting actions, e.g. reboot, on Machines.
+	MachineActions() MachineActionClient
+
+	// Rollout returns a RolloutClient that can be used for triggering the rollout of the Machines controlled by
+	// MachineDeployments and KubeadmControlPlanes.
+	Rollout() RolloutClient
+
+	// Pause returns a PauseClient that can be used for pausing and resuming the reconciliation of Clusters.
+	Pause() PauseClient
+
+	// Quickstart returns a QuickstartRunner that can be used for running a create-upgrade-scale-delete cycle of a
+	// workload cluster, as a smoke test of the providers.
+	Quickstart() QuickstartRunner
+
+	// Topology returns a TopologyClient that can be used for working with the managed topologies of the Clusters.
+	Topology() TopologyClient
+
+	// SupportBundleCollector returns a SupportBundleCollector that can be used for collecting the information
+	// required for troubleshooting a workload cluster.
+	SupportBundleCollector() SupportBundleCollector
+
+	// OperationLock returns an OperationLock that can be used for preventing concurrent clusterctl operations
+	// on the management cluster (or on the inventory namespaces the client is scoped to).
+	OperationLock() OperationLock
+}
 
 // clusterClient implements Client.
 type clusterClient struct {
 	configClient            config.Client
 	kubeconfig              string
+	kubeconfigContext       string
 	proxy                   Proxy
+	proxyConfig             *ProxyConfig
 	repositoryClientFactory RepositoryClientFactory
-	pollImmediateWaiter     PollImmediateWaiter
+	objectWaiter            ObjectWaiter
+	inventoryNamespaces     []string
+	progressReporter        ProgressReporter
+	log                     logr.Logger
 }
 
 type RepositoryClientFactory func(provider config.Provider, configVariablesClient config.VariablesClient, options ...repository.Option) (repository.Client, error)
@@ -92,38 +155,143 @@ func (c *clusterClient) Kubeconfig() string {
 	return c.kubeconfig
 }
 
+func (c *clusterClient) KubeconfigContext() string {
+	return c.kubeconfigContext
+}
+
 func (c *clusterClient) Proxy() Proxy {
 	return c.proxy
 }
 
+func (c *clusterClient) ProgressReporter() ProgressReporter {
+	return c.progressReporter
+}
+
 func (c *clusterClient) CertManager() CertManagerClient {
-	return newCertMangerClient(c.proxy, c.pollImmediateWaiter)
+	certManager := newCertMangerClient(c.configClient, c.proxy, c.objectWaiter)
+	certManager.log = c.log
+	return certManager
 }
 
 func (c *clusterClient) ProviderComponents() ComponentsClient {
-	return newComponentsClient(c.proxy)
+	components := newComponentsClient(c.proxy, c.objectWaiter)
+	if c.configClient != nil {
+		components.deployments = c.configClient.Deployments()
+	}
+	components.log = c.log
+	return components
 }
 
 func (c *clusterClient) ProviderInventory() InventoryClient {
-	return newInventoryClient(c.proxy, c.pollImmediateWaiter)
+	inventory := newScopedInventoryClient(c.proxy, c.objectWaiter, c.inventoryNamespaces)
+	inventory.log = c.log
+	return inventory
+}
+
+func (c *clusterClient) WithInventoryNamespaces(namespaces ...string) Client {
+	scoped := *c
+	scoped.inventoryNamespaces = namespaces
+	return &scoped
 }
 
 func (c *clusterClient) ProviderInstaller() ProviderInstaller {
-	return newProviderInstaller(c.configClient, c.repositoryClientFactory, c.proxy, c.ProviderInventory(), c.ProviderComponents())
+	installer := newProviderInstaller(c.configClient, c.repositoryClientFactory, c.proxy, c.ProviderInventory(), c.ProviderComponents(), c.progressReporter)
+	installer.log = c.log
+	return installer
 }
 
 func (c *clusterClient) ObjectMover() ObjectMover {
-	return newObjectMover(c.proxy)
+	mover := newObjectMover(c.proxy, c.progressReporter)
+	mover.log = c.log
+	return mover
 }
 
 func (c *clusterClient) ProviderUpgrader() ProviderUpgrader {
-	return newProviderUpgrader(c.configClient, c.repositoryClientFactory, c.ProviderInventory(), c.ProviderComponents())
+	upgrader := newProviderUpgrader(c.configClient, c.repositoryClientFactory, c.proxy, c.ProviderInventory(), c.ProviderComponents(), c.progressReporter)
+	upgrader.log = c.log
+	return upgrader
+}
+
+func (c *clusterClient) UpgradeVerifier() UpgradeVerifier {
+	verifier := newUpgradeVerifier(c.proxy, c.ProviderComponents())
+	verifier.log = c.log
+	return verifier
 }
 
 func (c *clusterClient) Template() TemplateClient {
 	return newTemplateClient(c.proxy, c.configClient)
 }
 
+func (c *clusterClient) ProviderLogs() LogsClient {
+	return newLogsClient(c.proxy)
+}
+
+func (c *clusterClient) ScaleSimulator() ScaleSimulator {
+	return newScaleSimulator(c.proxy)
+}
+
+func (c *clusterClient) ConversionWebhooks() ConversionWebhookClient {
+	conversionWebhooks := newConversionWebhookClient(c.proxy)
+	conversionWebhooks.log = c.log
+	return conversionWebhooks
+}
+
+func (c *clusterClient) VersionReporter() VersionReporter {
+	return newVersionReporter(c.proxy, c.ProviderInventory())
+}
+
+func (c *clusterClient) StatusLister() StatusLister {
+	return newStatusLister(c.proxy)
+}
+
+func (c *clusterClient) OrphanFinder() OrphanFinder {
+	orphanFinder := newOrphanFinder(c.proxy, c.ProviderInventory())
+	orphanFinder.log = c.log
+	return orphanFinder
+}
+
+func (c *clusterClient) CertificateChecker() CertificateChecker {
+	certificateChecker := newCertificateChecker(c.proxy)
+	certificateChecker.log = c.log
+	return certificateChecker
+}
+
+func (c *clusterClient) MachineActions() MachineActionClient {
+	return newMachineActionClient(c.proxy)
+}
+
+func (c *clusterClient) Rollout() RolloutClient {
+	return newRolloutClient(c.proxy)
+}
+
+func (c *clusterClient) Pause() PauseClient {
+	pause := newPauseClient(c.proxy)
+	pause.log = c.log
+	return pause
+}
+
+func (c *clusterClient) Quickstart() QuickstartRunner {
+	quickstart := newQuickstartRunner(c.proxy, c.objectWaiter)
+	quickstart.log = c.log
+	return quickstart
+}
+
+func (c *clusterClient) Topology() TopologyClient {
+	return newTopologyClient(c.proxy)
+}
+
+func (c *clusterClient) SupportBundleCollector() SupportBundleCollector {
+	collector := newSupportBundleCollector(c.proxy, c.ProviderInventory())
+	collector.log = c.log
+	return collector
+}
+
+func (c *clusterClient) OperationLock() OperationLock {
+	lock := newOperationLock(c.proxy, c.inventoryNamespaces)
+	lock.log = c.log
+	return lock
+}
+
 // Option is a configuration option supplied to New
 type Option func(*clusterClient)
 
@@ -134,6 +302,23 @@ func InjectProxy(proxy Proxy) Option {
 	}
 }
 
+// InjectProxyConfig allows to override the default configuration of the clients used by the proxy for accessing
+// the management cluster, e.g. for increasing the number of retries when working with a busy API server.
+// NB. the configuration is ignored if a proxy is injected.
+func InjectProxyConfig(config ProxyConfig) Option {
+	return func(c *clusterClient) {
+		c.proxyConfig = &config
+	}
+}
+
+// InjectKubeconfigContext allows to override the kubeconfig context used for accessing the management cluster;
+// by default the current context of the kubeconfig file is used.
+func InjectKubeconfigContext(context string) Option {
+	return func(c *clusterClient) {
+		c.kubeconfigContext = context
+	}
+}
+
 // InjectRepositoryFactory allows to override the default factory used for creating
 // RepositoryClient objects.
 func InjectRepositoryFactory(factory RepositoryClientFactory) Option {
@@ -142,10 +327,28 @@ func InjectRepositoryFactory(factory RepositoryClientFactory) Option {
 	}
 }
 
-// InjectPollImmediateWaiter allows to override the default PollImmediateWaiter used by clusterctl.
-func InjectPollImmediateWaiter(pollImmediateWaiter PollImmediateWaiter) Option {
+// InjectObjectWaiter allows to override the default ObjectWaiter used by clusterctl, which watches objects
+// until they reach the desired state.
+func InjectObjectWaiter(objectWaiter ObjectWaiter) Option {
 	return func(c *clusterClient) {
-		c.pollImmediateWaiter = pollImmediateWaiter
+		c.objectWaiter = objectWaiter
+	}
+}
+
+// InjectProgressReporter allows to receive the events emitted by long-running operations, like install, move and upgrade,
+// e.g. for showing the progress of the operations to the users.
+func InjectProgressReporter(reporter ProgressReporter) Option {
+	return func(c *clusterClient) {
+		c.progressReporter = reporter
+	}
+}
+
+// InjectLogger allows to override the logger used by the client, e.g. for routing the logs of the operations into
+// the logging stack of the program embedding clusterctl; by default the clusterctl logger is used.
+// NB. the logger is not used by an injected proxy or repositoryClientFactory.
+func InjectLogger(log logr.Logger) Option {
+	return func(c *clusterClient) {
+		c.log = log
 	}
 }
 
@@ -158,6 +361,7 @@ func newClusterClient(kubeconfig string, configClient config.Client, options ...
 	client := &clusterClient{
 		configClient: configClient,
 		kubeconfig:   kubeconfig,
+		log:          logf.Log,
 	}
 	for _, o := range options {
 		o(client)
@@ -165,17 +369,24 @@ func newClusterClient(kubeconfig string, configClient config.Client, options ...
 
 	// if there is an injected proxy, use it, otherwise use a default one
 	if client.proxy == nil {
-		client.proxy = newProxy(kubeconfig)
+		proxyConfig := DefaultProxyConfig()
+		if client.proxyConfig != nil {
+			proxyConfig = *client.proxyConfig
+		}
+		client.proxy = newProxy(kubeconfig, client.kubeconfigContext, proxyConfig, client.log)
 	}
 
 	// if there is an injected repositoryClientFactory, use it, otherwise use the default one
 	if client.repositoryClientFactory == nil {
-		client.repositoryClientFactory = repository.New
+		log := client.log
+		client.repositoryClientFactory = func(provider config.Provider, configVariablesClient config.VariablesClient, options ...repository.Option) (repository.Client, error) {
+			return repository.New(provider, configVariablesClient, append([]repository.Option{repository.InjectLogger(log)}, options...)...)
+		}
 	}
 
-	// if there is an injected PollImmediateWaiter, use it, otherwise use the default one
-	if client.pollImmediateWaiter == nil {
-		client.pollImmediateWaiter = wait.PollImmediate
+	// if there is an injected ObjectWaiter, use it, otherwise use the default one
+	if client.objectWaiter == nil {
+		client.objectWaiter = newWatchObjectWaiter(client.proxy)
 	}
 
 	return client
@@ -185,12 +396,23 @@ type Proxy interface {
 	// CurrentNamespace returns the namespace from the current context in the kubeconfig file
 	CurrentNamespace() (string, error)
 
+	// GetContexts returns the names of all the contexts defined in the kubeconfig file.
+	GetContexts() ([]string, error)
+
 	// NewClient returns a new controller runtime Client object for working on the management cluster
 	NewClient() (client.Client, error)
 
+	// NewClientSet returns a new client-go Clientset for working on the management cluster; it should be used
+	// only for operations not supported by the controller runtime Client, e.g. reading pod logs.
+	NewClientSet() (kubernetes.Interface, error)
+
+	// NewDynamicClient returns a new client-go dynamic client for working on the management cluster; it should be used
+	// only for operations not supported by the controller runtime Client, e.g. watching objects.
+	NewDynamicClient() (dynamic.Interface, error)
+
 	// ListResources returns all the Kubernetes objects existing in a namespace (or in all namespaces if empty)
 	// with the given labels.
-	ListResources(namespace string, labels map[string]string) ([]unstructured.Unstructured, error)
+	ListResources(ctx context.Context, namespace string, labels map[string]string) ([]unstructured.Unstructured, error)
 }
 
 var _ Proxy = &test.FakeProxy{}
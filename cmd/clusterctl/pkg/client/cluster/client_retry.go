@@ -0,0 +1,169 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"context"
+	"net"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilnet "k8s.io/apimachinery/pkg/util/net"
+	"k8s.io/apimachinery/pkg/util/wait"
+	logf "sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// retryingClient wraps a controller runtime Client, retrying with an exponential backoff the requests failing
+// with a transient error, e.g. because the API server is busy or the connection is flaky.
+type retryingClient struct {
+	client.Client
+	backoff   wait.Backoff
+	retriable func(error) bool
+	log       logr.Logger
+}
+
+// ensure retryingClient implements client.Client.
+var _ client.Client = &retryingClient{}
+
+// newRetryingClient returns a client retrying the requests failing with a transient error; if refreshableCredentials is
+// true, also the requests failing with 401 Unauthorized are retried, see isTransientOrUnauthorizedError.
+func newRetryingClient(c client.Client, retries int, interval time.Duration, refreshableCredentials bool) *retryingClient {
+	retriable := isTransientError
+	if refreshableCredentials {
+		retriable = isTransientOrUnauthorizedError
+	}
+	return &retryingClient{
+		Client:    c,
+		retriable: retriable,
+		backoff: wait.Backoff{
+			Steps:    retries + 1,
+			Duration: interval,
+			Factor:   2,
+			Jitter:   0.1,
+		},
+		log: logf.Log,
+	}
+}
+
+func (r *retryingClient) Get(ctx context.Context, key client.ObjectKey, obj runtime.Object) error {
+	return retryOnError(r.log, r.backoff, r.retriable, func() error {
+		return r.Client.Get(ctx, key, obj)
+	})
+}
+
+func (r *retryingClient) List(ctx context.Context, list runtime.Object, opts ...client.ListOption) error {
+	return retryOnError(r.log, r.backoff, r.retriable, func() error {
+		return r.Client.List(ctx, list, opts...)
+	})
+}
+
+func (r *retryingClient) Create(ctx context.Context, obj runtime.Object, opts ...client.CreateOption) error {
+	return retryOnError(r.log, r.backoff, r.retriable, func() error {
+		return r.Client.Create(ctx, obj, opts...)
+	})
+}
+
+func (r *retryingClient) Delete(ctx context.Context, obj runtime.Object, opts ...client.DeleteOption) error {
+	return retryOnError(r.log, r.backoff, r.retriable, func() error {
+		return r.Client.Delete(ctx, obj, opts...)
+	})
+}
+
+func (r *retryingClient) Update(ctx context.Context, obj runtime.Object, opts ...client.UpdateOption) error {
+	return retryOnError(r.log, r.backoff, r.retriable, func() error {
+		return r.Client.Update(ctx, obj, opts...)
+	})
+}
+
+func (r *retryingClient) Patch(ctx context.Context, obj runtime.Object, patch client.Patch, opts ...client.PatchOption) error {
+	return retryOnError(r.log, r.backoff, r.retriable, func() error {
+		return r.Client.Patch(ctx, obj, patch, opts...)
+	})
+}
+
+func (r *retryingClient) DeleteAllOf(ctx context.Context, obj runtime.Object, opts ...client.DeleteAllOfOption) error {
+	return retryOnError(r.log, r.backoff, r.retriable, func() error {
+		return r.Client.DeleteAllOf(ctx, obj, opts...)
+	})
+}
+
+// retryOnTransientError executes an action, retrying it with backoff if it fails with a transient error.
+func retryOnTransientError(log logr.Logger, backoff wait.Backoff, action func() error) error {
+	return retryOnError(log, backoff, isTransientError, action)
+}
+
+// isTransientOrUnauthorizedError returns true if an error is transient or if it is a 401 Unauthorized error; when the
+// credentials are provided by an exec or an auth provider plugin, e.g. for EKS, GKE or OIDC, short-lived tokens
+// expiring during long operations are refreshed by the plugin after the first request failing with 401 Unauthorized,
+// so the request can succeed when retried.
+func isTransientOrUnauthorizedError(err error) bool {
+	return isTransientError(err) || apierrors.IsUnauthorized(errors.Cause(err))
+}
+
+// retryOnConflict executes an action, retrying it with backoff if it fails with a conflict error, e.g. when
+// updating an object that was changed concurrently; the action should read the object again before updating it.
+func retryOnConflict(log logr.Logger, backoff wait.Backoff, action func() error) error {
+	return retryOnError(log, backoff, func(err error) bool {
+		return apierrors.IsConflict(errors.Cause(err))
+	}, action)
+}
+
+// retryOnError executes an action, retrying it with backoff if it fails with an error matching the retriable func.
+func retryOnError(log logr.Logger, backoff wait.Backoff, retriable func(error) bool, action func() error) error {
+	var lastErr error
+	err := wait.ExponentialBackoff(backoff, func() (bool, error) {
+		lastErr = action()
+		if lastErr == nil {
+			return true, nil
+		}
+		if retriable(lastErr) {
+			log.V(5).Info("Operation failed, retry", "Error", lastErr.Error())
+			return false, nil
+		}
+		return false, lastErr
+	})
+	if err == wait.ErrWaitTimeout {
+		return lastErr
+	}
+	return err
+}
+
+// isTransientError returns true if an error is likely to disappear when retrying the same request, e.g. because
+// the API server is throttling requests or because the connection was interrupted.
+func isTransientError(err error) bool {
+	err = errors.Cause(err)
+
+	if apierrors.IsTooManyRequests(err) ||
+		apierrors.IsServerTimeout(err) ||
+		apierrors.IsTimeout(err) ||
+		apierrors.IsServiceUnavailable(err) {
+		return true
+	}
+
+	if utilnet.IsProbableEOF(err) || utilnet.IsConnectionReset(err) || utilnet.IsConnectionRefused(err) {
+		return true
+	}
+
+	if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+		return true
+	}
+	return false
+}
@@ -0,0 +1,150 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"io"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	logf "sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/log"
+)
+
+func Test_isTransientError(t *testing.T) {
+	gr := schema.GroupResource{Group: "cluster.x-k8s.io", Resource: "clusters"}
+
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{
+			name: "too many requests",
+			err:  apierrors.NewTooManyRequests("busy", 1),
+			want: true,
+		},
+		{
+			name: "server timeout",
+			err:  apierrors.NewServerTimeout(gr, "get", 1),
+			want: true,
+		},
+		{
+			name: "service unavailable",
+			err:  apierrors.NewServiceUnavailable("unavailable"),
+			want: true,
+		},
+		{
+			name: "EOF",
+			err:  io.EOF,
+			want: true,
+		},
+		{
+			name: "wrapped EOF",
+			err:  errors.Wrap(io.EOF, "failed to get"),
+			want: true,
+		},
+		{
+			name: "not found",
+			err:  apierrors.NewNotFound(gr, "foo"),
+			want: false,
+		},
+		{
+			name: "conflict",
+			err:  apierrors.NewConflict(gr, "foo", errors.New("conflict")),
+			want: false,
+		},
+		{
+			name: "generic error",
+			err:  errors.New("generic error"),
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isTransientError(tt.err); got != tt.want {
+				t.Errorf("got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_retryOnError(t *testing.T) {
+	gr := schema.GroupResource{Group: "cluster.x-k8s.io", Resource: "clusters"}
+	backoff := wait.Backoff{
+		Steps:    3,
+		Duration: time.Millisecond,
+		Factor:   1,
+	}
+
+	tests := []struct {
+		name         string
+		retry        func(logr.Logger, wait.Backoff, func() error) error
+		errs         []error
+		wantAttempts int
+		wantErr      bool
+	}{
+		{
+			name:         "transient errors are retried",
+			retry:        retryOnTransientError,
+			errs:         []error{apierrors.NewTooManyRequests("busy", 1), io.EOF, nil},
+			wantAttempts: 3,
+			wantErr:      false,
+		},
+		{
+			name:         "other errors are not retried",
+			retry:        retryOnTransientError,
+			errs:         []error{apierrors.NewNotFound(gr, "foo"), nil},
+			wantAttempts: 1,
+			wantErr:      true,
+		},
+		{
+			name:         "fails when retries are exhausted",
+			retry:        retryOnTransientError,
+			errs:         []error{io.EOF, io.EOF, io.EOF, nil},
+			wantAttempts: 3,
+			wantErr:      true,
+		},
+		{
+			name:         "wrapped conflict errors are retried",
+			retry:        retryOnConflict,
+			errs:         []error{errors.Wrap(apierrors.NewConflict(gr, "foo", errors.New("conflict")), "failed to update"), nil},
+			wantAttempts: 2,
+			wantErr:      false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attempts := 0
+			err := tt.retry(logf.Log, backoff, func() error {
+				err := tt.errs[attempts]
+				attempts++
+				return err
+			})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if attempts != tt.wantAttempts {
+				t.Errorf("attempts = %d, want %d", attempts, tt.wantAttempts)
+			}
+		})
+	}
+}
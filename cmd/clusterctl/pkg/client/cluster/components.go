@@ -17,107 +17,170 @@ limitations under the License.
 package cluster
 
 import (
+	"context"
+	"time"
+
+	"github.com/go-logr/logr"
 	"github.com/pkg/errors"
+	appsv1 "k8s.io/api/apps/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
 	kerrors "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/apimachinery/pkg/util/sets"
+	clientretry "k8s.io/client-go/util/retry"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
 	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/client/config"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/client/repository"
 	logf "sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
+const (
+	waitComponentsDeletedTimeout = 5 * time.Minute
+)
+
+// DeleteOptions carries the options supported by ComponentsClient.Delete.
 type DeleteOptions struct {
 	Provider             clusterctlv1.Provider
 	ForceDeleteNamespace bool
 	ForceDeleteCRD       bool
+
+	// KeepInventory preserves the inventory item of the provider, so the provider is still reported
+	// as installed after its components are deleted.
+	KeepInventory bool
 }
 
 // ComponentsClient has methods to work with provider components in the cluster.
 type ComponentsClient interface {
 	// Create creates the provider components in the management cluster.
-	Create(components repository.Components) error
+	Create(ctx context.Context, components repository.Components) error
 
 	// Delete deletes the provider components from the management cluster.
 	// The operation is designed to prevent accidental deletion of user created objects, so
 	// it is required to explicitly opt-in for the deletion of the namespace where the provider components are hosted
 	// and for the deletion of the provider's CRDs.
-	Delete(options DeleteOptions) error
+	Delete(ctx context.Context, options DeleteOptions) error
+
+	// ObjectsToDelete returns the provider components that Delete removes with the given options.
+	// NB. If the namespace where the provider components are hosted is deleted, all the objects contained
+	// in the namespace are deleted too, even if they are not included in the list.
+	ObjectsToDelete(ctx context.Context, options DeleteOptions) ([]unstructured.Unstructured, error)
+
+	// CustomResourcesToDelete returns the custom resources that are deleted together with the
+	// CustomResourceDefinitions included in the given objects.
+	CustomResourcesToDelete(ctx context.Context, objs []unstructured.Unstructured) ([]unstructured.Unstructured, error)
+
+	// CheckHealth checks if the controllers of a provider instance are running, that is if all the Deployments
+	// belonging to the provider instance have all their replicas available.
+	CheckHealth(ctx context.Context, provider clusterctlv1.Provider) error
 }
 
 // providerComponents implements ComponentsClient.
 type providerComponents struct {
-	proxy Proxy
+	proxy        Proxy
+	objectWaiter ObjectWaiter
+	deployments  config.DeploymentsClient
+	log          logr.Logger
 }
 
 // Create provider components defined in the yaml file.
-func (p *providerComponents) Create(components repository.Components) error {
-	log := logf.Log
+func (p *providerComponents) Create(ctx context.Context, components repository.Components) error {
+	log := p.log
 	log.Info("Installing", "Provider", components.Name(), "Version", components.Version(), "TargetNamespace", components.TargetNamespace())
 	c, err := p.proxy.NewClient()
 	if err != nil {
 		return err
 	}
 
+	// applies the customizations of the provider Deployments defined in the clusterctl configuration, if any
+	objs := components.Objs()
+	if p.deployments != nil {
+		overrides, err := p.deployments.Get(components.Name())
+		if err != nil {
+			return err
+		}
+		if objs, err = applyDeploymentOverrides(objs, overrides); err != nil {
+			return errors.Wrapf(err, "failed to customize the deployments of the %s provider", components.Name())
+		}
+		secrets, err := imagePullSecretObjs(components.Name(), components.TargetNamespace(), overrides.ImagePullSecrets)
+		if err != nil {
+			return errors.Wrapf(err, "failed to create the image pull secrets of the %s provider", components.Name())
+		}
+		objs = append(objs, secrets...)
+	}
+
 	// sort provider components for creation according to relation across objects (e.g. Namespace before everything namespaced)
-	resources := sortResourcesForCreate(components.Objs())
+	resources := sortResourcesForCreate(objs)
 
 	// creates (or updates) provider components
 	for i := range resources {
+		if err := ctx.Err(); err != nil {
+			return errors.Wrapf(err, "installation of the %s provider interrupted", components.Name())
+		}
 		obj := resources[i]
 
-		// check if the component already exists, and eventually update it
-		currentR := &unstructured.Unstructured{}
-		currentR.SetGroupVersionKind(obj.GroupVersionKind())
-
-		key := client.ObjectKey{
-			Namespace: obj.GetNamespace(),
-			Name:      obj.GetName(),
+		// Nb. The operation is wrapped in a retry on conflict loop, so it does not fail if the component is changed
+		// concurrently, e.g. by a controller, between reading its current version and updating it.
+		if err := retryOnConflict(p.log, clientretry.DefaultRetry, func() error {
+			return createOrUpdateObj(ctx, p.log, c, obj)
+		}); err != nil {
+			return err
 		}
-		if err := c.Get(ctx, key, currentR); err != nil {
-			if !apierrors.IsNotFound(err) {
-				return errors.Wrapf(err, "failed to get current provider object")
-			}
+	}
 
-			//if it does not exists, create the component
-			log.V(5).Info("Creating", logf.UnstructuredToValues(obj)...)
-			if err := c.Create(ctx, &obj); err != nil {
-				return errors.Wrapf(err, "failed to create provider object %s, %s/%s", obj.GroupVersionKind(), obj.GetNamespace(), obj.GetName())
-			}
+	return nil
+}
 
-			continue
+// createOrUpdateObj creates a provider component, or updates it if it already exists.
+func createOrUpdateObj(ctx context.Context, log logr.Logger, c client.Client, obj unstructured.Unstructured) error {
+	// check if the component already exists, and eventually update it
+	currentR := &unstructured.Unstructured{}
+	currentR.SetGroupVersionKind(obj.GroupVersionKind())
+
+	key := client.ObjectKey{
+		Namespace: obj.GetNamespace(),
+		Name:      obj.GetName(),
+	}
+	if err := c.Get(ctx, key, currentR); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return errors.Wrapf(err, "failed to get current provider object")
 		}
 
-		// otherwise update the component
-		// if upgrading an existing component, then use the current resourceVersion for the optimistic lock
-		log.V(5).Info("Upgrading", logf.UnstructuredToValues(obj)...)
-		obj.SetResourceVersion(currentR.GetResourceVersion())
-		if err := c.Update(ctx, &obj); err != nil {
-			return errors.Wrapf(err, "failed to update provider object %s, %s/%s", obj.GroupVersionKind(), obj.GetNamespace(), obj.GetName())
+		//if it does not exists, create the component
+		log.V(5).Info("Creating", logf.UnstructuredToValues(obj)...)
+		if err := c.Create(ctx, &obj); err != nil {
+			return errors.Wrapf(err, "failed to create provider object %s, %s/%s", obj.GroupVersionKind(), obj.GetNamespace(), obj.GetName())
 		}
+
+		return nil
+	}
+
+	// otherwise update the component
+	// if upgrading an existing component, then use the current resourceVersion for the optimistic lock
+	log.V(5).Info("Upgrading", logf.UnstructuredToValues(obj)...)
+	obj.SetResourceVersion(currentR.GetResourceVersion())
+	if err := c.Update(ctx, &obj); err != nil {
+		return errors.Wrapf(err, "failed to update provider object %s, %s/%s", obj.GroupVersionKind(), obj.GetNamespace(), obj.GetName())
 	}
 
 	return nil
 }
 
-func (p *providerComponents) Delete(options DeleteOptions) error {
-	log := logf.Log
-	log.Info("Deleting", "Provider", options.Provider.Name, "Version", options.Provider.Version, "TargetNamespace", options.Provider.Namespace)
-
+func (p *providerComponents) ObjectsToDelete(ctx context.Context, options DeleteOptions) ([]unstructured.Unstructured, error) {
 	// Fetch all the components belonging to a provider.
 	labels := map[string]string{
 		clusterctlv1.ClusterctlLabelName: "",
 		clusterv1.ProviderLabelName:      options.Provider.Name,
 	}
-	resources, err := p.proxy.ListResources(options.Provider.Namespace, labels)
+	resources, err := p.proxy.ListResources(ctx, options.Provider.Namespace, labels)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	resourcesToDelete := []unstructured.Unstructured{}
-	namespacesToDelete := sets.NewString()
 	for _, obj := range resources {
 		// If the CRDs should NOT be deleted, skip it;
 		// NB. Skipping CRDs deletion ensures that also the objects of Kind defined in the CRDs Kind are not deleted.
@@ -125,16 +188,75 @@ func (p *providerComponents) Delete(options DeleteOptions) error {
 			continue
 		}
 
-		// If the Namespace should NOT be deleted, skip it, otherwise keep track of the namespaces we are deleting;
+		// If the Namespace should NOT be deleted, skip it;
 		// NB. Skipping Namespaces deletion ensures that also the objects hosted in the namespace but without the "clusterctl.cluster.x-k8s.io" and the "cluster.x-k8s.io/provider" label are not deleted.
-		if obj.GroupVersionKind().Kind == "Namespace" {
-			if !options.ForceDeleteNamespace {
+		if obj.GroupVersionKind().Kind == "Namespace" && !options.ForceDeleteNamespace {
+			continue
+		}
+
+		// If the inventory should be preserved, skip the provider's inventory item.
+		if obj.GetLabels()[clusterctlv1.ClusterctlCoreLabelName] == "inventory" && options.KeepInventory {
+			continue
+		}
+
+		resourcesToDelete = append(resourcesToDelete, obj)
+	}
+
+	return resourcesToDelete, nil
+}
+
+func (p *providerComponents) CustomResourcesToDelete(ctx context.Context, objs []unstructured.Unstructured) ([]unstructured.Unstructured, error) {
+	c, err := p.proxy.NewClient()
+	if err != nil {
+		return nil, err
+	}
+
+	var instances []unstructured.Unstructured
+	for i := range objs {
+		if objs[i].GroupVersionKind().Kind != "CustomResourceDefinition" {
+			continue
+		}
+
+		crd := &apiextensionsv1.CustomResourceDefinition{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(objs[i].Object, crd); err != nil {
+			return nil, errors.Wrapf(err, "failed to convert %q to a CustomResourceDefinition", objs[i].GetName())
+		}
+
+		version := newestServedVersion(*crd)
+		if version == "" {
+			version = crd.Spec.Version
+		}
+
+		list := &unstructured.UnstructuredList{}
+		list.SetAPIVersion(crd.Spec.Group + "/" + version)
+		list.SetKind(crd.Spec.Names.ListKind)
+		if err := c.List(ctx, list); err != nil {
+			if apierrors.IsNotFound(err) {
 				continue
 			}
-			namespacesToDelete.Insert(obj.GetName())
+			return nil, errors.Wrapf(err, "failed to list the objects of the CustomResourceDefinition %q", crd.Name)
 		}
+		instances = append(instances, list.Items...)
+	}
 
-		resourcesToDelete = append(resourcesToDelete, obj)
+	return instances, nil
+}
+
+func (p *providerComponents) Delete(ctx context.Context, options DeleteOptions) error {
+	log := p.log
+	log.Info("Deleting", "Provider", options.Provider.Name, "Version", options.Provider.Version, "TargetNamespace", options.Provider.Namespace)
+
+	resourcesToDelete, err := p.ObjectsToDelete(ctx, options)
+	if err != nil {
+		return err
+	}
+
+	// Keep track of the namespaces we are deleting.
+	namespacesToDelete := sets.NewString()
+	for _, obj := range resourcesToDelete {
+		if obj.GroupVersionKind().Kind == "Namespace" {
+			namespacesToDelete.Insert(obj.GetName())
+		}
 	}
 
 	// Delete all the provider components.
@@ -144,6 +266,7 @@ func (p *providerComponents) Delete(options DeleteOptions) error {
 	}
 
 	errList := []error{}
+	deploymentsToWait := []unstructured.Unstructured{}
 	for i := range resourcesToDelete {
 		obj := resourcesToDelete[i]
 
@@ -162,6 +285,59 @@ func (p *providerComponents) Delete(options DeleteOptions) error {
 				continue
 			}
 			errList = append(errList, errors.Wrapf(err, "Error deleting object %s, %s/%s", obj.GroupVersionKind(), obj.GetNamespace(), obj.GetName()))
+			continue
+		}
+
+		if obj.GroupVersionKind().Kind == "Deployment" {
+			deploymentsToWait = append(deploymentsToWait, obj)
+		}
+	}
+	if len(errList) > 0 {
+		return kerrors.NewAggregate(errList)
+	}
+
+	// Waits for the provider controllers to be gone, so a new version of the provider installed right after
+	// (e.g. during upgrades) does not run side by side with the controllers being deleted.
+	for i := range deploymentsToWait {
+		obj := deploymentsToWait[i]
+		key := client.ObjectKey{Namespace: obj.GetNamespace(), Name: obj.GetName()}
+		if err := p.objectWaiter(ctx, obj.GroupVersionKind(), key, waitComponentsDeletedTimeout, objectDeleted); err != nil {
+			errList = append(errList, errors.Wrapf(err, "failed to wait for the deletion of %s, %s/%s", obj.GroupVersionKind(), obj.GetNamespace(), obj.GetName()))
+		}
+	}
+
+	return kerrors.NewAggregate(errList)
+}
+
+func (p *providerComponents) CheckHealth(ctx context.Context, provider clusterctlv1.Provider) error {
+	c, err := p.proxy.NewClient()
+	if err != nil {
+		return err
+	}
+
+	deploymentList := &appsv1.DeploymentList{}
+	if err := c.List(ctx, deploymentList,
+		client.InNamespace(provider.Namespace),
+		client.MatchingLabels{
+			clusterctlv1.ClusterctlLabelName: "",
+			clusterv1.ProviderLabelName:      provider.Name,
+		},
+	); err != nil {
+		return errors.Wrapf(err, "failed to list deployments for the %s provider", provider.InstanceName())
+	}
+
+	if len(deploymentList.Items) == 0 {
+		return errors.Errorf("failed to find deployments for the %s provider", provider.InstanceName())
+	}
+
+	errList := []error{}
+	for _, d := range deploymentList.Items {
+		replicas := int32(1)
+		if d.Spec.Replicas != nil {
+			replicas = *d.Spec.Replicas
+		}
+		if d.Status.AvailableReplicas < replicas {
+			errList = append(errList, errors.Errorf("deployment %s/%s has %d available replicas, %d desired", d.Namespace, d.Name, d.Status.AvailableReplicas, replicas))
 		}
 	}
 
@@ -169,9 +345,11 @@ func (p *providerComponents) Delete(options DeleteOptions) error {
 }
 
 // newComponentsClient returns a providerComponents.
-func newComponentsClient(proxy Proxy) *providerComponents {
+func newComponentsClient(proxy Proxy, objectWaiter ObjectWaiter) *providerComponents {
 	return &providerComponents{
-		proxy: proxy,
+		proxy:        proxy,
+		objectWaiter: objectWaiter,
+		log:          logf.Log,
 	}
 }
 
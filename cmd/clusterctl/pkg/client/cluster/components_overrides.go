@@ -0,0 +1,189 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"encoding/json"
+	"io/ioutil"
+
+	"github.com/pkg/errors"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/client/config"
+)
+
+// managerContainerName is the name of the container running the provider controllers in the kubebuilder scaffolding.
+const managerContainerName = "manager"
+
+// applyDeploymentOverrides returns the provider components with the customizations applied to the Deployments;
+// image pull secrets are added to the ServiceAccounts too.
+func applyDeploymentOverrides(objs []unstructured.Unstructured, overrides config.DeploymentOverrides) ([]unstructured.Unstructured, error) {
+	if overrides.IsEmpty() {
+		return objs, nil
+	}
+
+	ret := make([]unstructured.Unstructured, 0, len(objs))
+	for _, o := range objs {
+		if o.GroupVersionKind().GroupKind() == corev1.SchemeGroupVersion.WithKind("ServiceAccount").GroupKind() && len(overrides.ImagePullSecrets) > 0 {
+			u, err := overrideServiceAccount(o, overrides.ImagePullSecrets)
+			if err != nil {
+				return nil, err
+			}
+			ret = append(ret, u)
+			continue
+		}
+
+		if o.GroupVersionKind().GroupKind() != appsv1.SchemeGroupVersion.WithKind("Deployment").GroupKind() {
+			ret = append(ret, o)
+			continue
+		}
+
+		deployment := &appsv1.Deployment{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(o.Object, deployment); err != nil {
+			return nil, errors.Wrapf(err, "failed to convert %s/%s to a Deployment", o.GetNamespace(), o.GetName())
+		}
+
+		overrideDeployment(deployment, overrides)
+
+		content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(deployment)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to convert Deployment %s/%s to unstructured", o.GetNamespace(), o.GetName())
+		}
+		u := unstructured.Unstructured{Object: content}
+		u.SetGroupVersionKind(o.GroupVersionKind())
+		ret = append(ret, u)
+	}
+	return ret, nil
+}
+
+// overrideDeployment applies the customizations to a Deployment; the resources are applied to the manager container,
+// or to the first container if there is no container named manager.
+func overrideDeployment(deployment *appsv1.Deployment, overrides config.DeploymentOverrides) {
+	if overrides.Replicas != nil {
+		replicas := *overrides.Replicas
+		deployment.Spec.Replicas = &replicas
+	}
+
+	podSpec := &deployment.Spec.Template.Spec
+	if overrides.Resources != nil && len(podSpec.Containers) > 0 {
+		container := &podSpec.Containers[0]
+		for i := range podSpec.Containers {
+			if podSpec.Containers[i].Name == managerContainerName {
+				container = &podSpec.Containers[i]
+				break
+			}
+		}
+		container.Resources = *overrides.Resources.DeepCopy()
+	}
+	if len(overrides.NodeSelector) > 0 {
+		podSpec.NodeSelector = map[string]string{}
+		for k, v := range overrides.NodeSelector {
+			podSpec.NodeSelector[k] = v
+		}
+	}
+	if len(overrides.Tolerations) > 0 {
+		podSpec.Tolerations = append([]corev1.Toleration{}, overrides.Tolerations...)
+	}
+	if overrides.PriorityClassName != "" {
+		podSpec.PriorityClassName = overrides.PriorityClassName
+	}
+	podSpec.ImagePullSecrets = addImagePullSecrets(podSpec.ImagePullSecrets, overrides.ImagePullSecrets)
+}
+
+// overrideServiceAccount adds the image pull secrets to a ServiceAccount.
+func overrideServiceAccount(o unstructured.Unstructured, secrets []config.ImagePullSecret) (unstructured.Unstructured, error) {
+	serviceAccount := &corev1.ServiceAccount{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(o.Object, serviceAccount); err != nil {
+		return unstructured.Unstructured{}, errors.Wrapf(err, "failed to convert %s/%s to a ServiceAccount", o.GetNamespace(), o.GetName())
+	}
+
+	serviceAccount.ImagePullSecrets = addImagePullSecrets(serviceAccount.ImagePullSecrets, secrets)
+
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(serviceAccount)
+	if err != nil {
+		return unstructured.Unstructured{}, errors.Wrapf(err, "failed to convert ServiceAccount %s/%s to unstructured", o.GetNamespace(), o.GetName())
+	}
+	u := unstructured.Unstructured{Object: content}
+	u.SetGroupVersionKind(o.GroupVersionKind())
+	return u, nil
+}
+
+// addImagePullSecrets adds the image pull secrets to a list of references, skipping the ones already referenced.
+func addImagePullSecrets(refs []corev1.LocalObjectReference, secrets []config.ImagePullSecret) []corev1.LocalObjectReference {
+	for _, secret := range secrets {
+		found := false
+		for _, ref := range refs {
+			if ref.Name == secret.Name {
+				found = true
+				break
+			}
+		}
+		if !found {
+			refs = append(refs, corev1.LocalObjectReference{Name: secret.Name})
+		}
+	}
+	return refs
+}
+
+// imagePullSecretObjs returns the Secrets to be created in the provider namespace for the image pull secrets defined
+// with a docker config file; the Secrets are labeled as the other provider components.
+func imagePullSecretObjs(provider, namespace string, secrets []config.ImagePullSecret) ([]unstructured.Unstructured, error) {
+	ret := []unstructured.Unstructured{}
+	for _, s := range secrets {
+		if s.DockerConfigFile == "" {
+			continue
+		}
+
+		data, err := ioutil.ReadFile(s.DockerConfigFile)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read the docker config file for the %q image pull secret", s.Name)
+		}
+		if !json.Valid(data) {
+			return nil, errors.Errorf("invalid docker config file %q for the %q image pull secret: the file must be in json format", s.DockerConfigFile, s.Name)
+		}
+
+		secret := &corev1.Secret{
+			TypeMeta: metav1.TypeMeta{
+				APIVersion: corev1.SchemeGroupVersion.String(),
+				Kind:       "Secret",
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: namespace,
+				Name:      s.Name,
+				Labels: map[string]string{
+					clusterctlv1.ClusterctlLabelName: "",
+					clusterv1.ProviderLabelName:      provider,
+				},
+			},
+			Type: corev1.SecretTypeDockerConfigJson,
+			Data: map[string][]byte{
+				corev1.DockerConfigJsonKey: data,
+			},
+		}
+		content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(secret)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to convert Secret %s/%s to unstructured", namespace, s.Name)
+		}
+		ret = append(ret, unstructured.Unstructured{Object: content})
+	}
+	return ret, nil
+}
@@ -0,0 +1,234 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/utils/pointer"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/client/config"
+)
+
+func Test_applyDeploymentOverrides(t *testing.T) {
+	deployment := unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "apps/v1",
+			"kind":       "Deployment",
+			"metadata": map[string]interface{}{
+				"name":      "capi-controller-manager",
+				"namespace": "capi-system",
+			},
+			"spec": map[string]interface{}{
+				"replicas": int64(1),
+				"template": map[string]interface{}{
+					"spec": map[string]interface{}{
+						"containers": []interface{}{
+							map[string]interface{}{"name": "kube-rbac-proxy", "image": "proxy"},
+							map[string]interface{}{"name": "manager", "image": "manager"},
+						},
+					},
+				},
+			},
+		},
+	}
+	namespace := unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "Namespace",
+			"metadata": map[string]interface{}{
+				"name": "capi-system",
+			},
+		},
+	}
+
+	overrides := config.DeploymentOverrides{
+		Replicas: pointer.Int32Ptr(3),
+		Resources: &corev1.ResourceRequirements{
+			Limits: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("512Mi")},
+		},
+		NodeSelector: map[string]string{"node-role.kubernetes.io/infra": ""},
+		Tolerations: []corev1.Toleration{
+			{Key: "node-role.kubernetes.io/infra", Operator: corev1.TolerationOpExists},
+		},
+		PriorityClassName: "system-cluster-critical",
+	}
+
+	got, err := applyDeploymentOverrides([]unstructured.Unstructured{namespace, deployment}, overrides)
+	if err != nil {
+		t.Fatalf("applyDeploymentOverrides() error = %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("got %d objects, want 2", len(got))
+	}
+	if !reflect.DeepEqual(got[0], namespace) {
+		t.Errorf("got %v, want the Namespace unchanged", got[0])
+	}
+
+	d := got[1]
+	if d.GetKind() != "Deployment" || d.GetAPIVersion() != "apps/v1" {
+		t.Errorf("got %s %s, want apps/v1 Deployment", d.GetAPIVersion(), d.GetKind())
+	}
+	if replicas, _, _ := unstructured.NestedInt64(d.Object, "spec", "replicas"); replicas != 3 {
+		t.Errorf("got %d replicas, want 3", replicas)
+	}
+	containers, _, _ := unstructured.NestedSlice(d.Object, "spec", "template", "spec", "containers")
+	if len(containers) != 2 {
+		t.Fatalf("got %d containers, want 2", len(containers))
+	}
+	if _, ok, _ := unstructured.NestedMap(containers[0].(map[string]interface{}), "resources", "limits"); ok {
+		t.Errorf("got resources on the kube-rbac-proxy container, want them on the manager container only")
+	}
+	if memory, _, _ := unstructured.NestedString(containers[1].(map[string]interface{}), "resources", "limits", "memory"); memory != "512Mi" {
+		t.Errorf("got memory limit %q on the manager container, want 512Mi", memory)
+	}
+	if nodeSelector, _, _ := unstructured.NestedStringMap(d.Object, "spec", "template", "spec", "nodeSelector"); !reflect.DeepEqual(nodeSelector, overrides.NodeSelector) {
+		t.Errorf("got nodeSelector %v, want %v", nodeSelector, overrides.NodeSelector)
+	}
+	if tolerations, _, _ := unstructured.NestedSlice(d.Object, "spec", "template", "spec", "tolerations"); len(tolerations) != 1 {
+		t.Errorf("got %d tolerations, want 1", len(tolerations))
+	}
+	if priorityClassName, _, _ := unstructured.NestedString(d.Object, "spec", "template", "spec", "priorityClassName"); priorityClassName != "system-cluster-critical" {
+		t.Errorf("got priorityClassName %q, want system-cluster-critical", priorityClassName)
+	}
+}
+
+func Test_applyDeploymentOverrides_ImagePullSecrets(t *testing.T) {
+	deployment := unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "apps/v1",
+			"kind":       "Deployment",
+			"metadata": map[string]interface{}{
+				"name":      "capi-controller-manager",
+				"namespace": "capi-system",
+			},
+			"spec": map[string]interface{}{
+				"template": map[string]interface{}{
+					"spec": map[string]interface{}{
+						"imagePullSecrets": []interface{}{
+							map[string]interface{}{"name": "existing"},
+						},
+					},
+				},
+			},
+		},
+	}
+	serviceAccount := unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "ServiceAccount",
+			"metadata": map[string]interface{}{
+				"name":      "capi-manager",
+				"namespace": "capi-system",
+			},
+		},
+	}
+
+	overrides := config.DeploymentOverrides{
+		ImagePullSecrets: []config.ImagePullSecret{{Name: "existing"}, {Name: "mirror"}},
+	}
+
+	got, err := applyDeploymentOverrides([]unstructured.Unstructured{deployment, serviceAccount}, overrides)
+	if err != nil {
+		t.Fatalf("applyDeploymentOverrides() error = %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("got %d objects, want 2", len(got))
+	}
+
+	wantRefs := []interface{}{
+		map[string]interface{}{"name": "existing"},
+		map[string]interface{}{"name": "mirror"},
+	}
+	if refs, _, _ := unstructured.NestedSlice(got[0].Object, "spec", "template", "spec", "imagePullSecrets"); !reflect.DeepEqual(refs, wantRefs) {
+		t.Errorf("got imagePullSecrets %v on the Deployment, want %v", refs, wantRefs)
+	}
+	if got[1].GetKind() != "ServiceAccount" || got[1].GetAPIVersion() != "v1" {
+		t.Errorf("got %s %s, want v1 ServiceAccount", got[1].GetAPIVersion(), got[1].GetKind())
+	}
+	if refs, _, _ := unstructured.NestedSlice(got[1].Object, "imagePullSecrets"); !reflect.DeepEqual(refs, wantRefs) {
+		t.Errorf("got imagePullSecrets %v on the ServiceAccount, want %v", refs, wantRefs)
+	}
+}
+
+func Test_imagePullSecretObjs(t *testing.T) {
+	dir, err := ioutil.TempDir("", "clusterctl")
+	if err != nil {
+		t.Fatalf("failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	dockerConfig := filepath.Join(dir, "config.json")
+	if err := ioutil.WriteFile(dockerConfig, []byte(`{"auths":{"mirror.example.com":{"auth":"dXNlcjpwYXNz"}}}`), 0600); err != nil {
+		t.Fatalf("failed to write the docker config file: %v", err)
+	}
+	invalidConfig := filepath.Join(dir, "invalid.json")
+	if err := ioutil.WriteFile(invalidConfig, []byte("auths: {}"), 0600); err != nil {
+		t.Fatalf("failed to write the docker config file: %v", err)
+	}
+
+	got, err := imagePullSecretObjs("cluster-api", "capi-system", []config.ImagePullSecret{
+		{Name: "existing"},
+		{Name: "mirror", DockerConfigFile: dockerConfig},
+	})
+	if err != nil {
+		t.Fatalf("imagePullSecretObjs() error = %v", err)
+	}
+	if len(got) != 1 {
+		t.Fatalf("got %d secrets, want 1 for the secret defined with a docker config file", len(got))
+	}
+
+	secret := got[0]
+	if secret.GetKind() != "Secret" || secret.GetNamespace() != "capi-system" || secret.GetName() != "mirror" {
+		t.Errorf("got %s %s/%s, want Secret capi-system/mirror", secret.GetKind(), secret.GetNamespace(), secret.GetName())
+	}
+	if secretType, _, _ := unstructured.NestedString(secret.Object, "type"); secretType != string(corev1.SecretTypeDockerConfigJson) {
+		t.Errorf("got type %q, want %q", secretType, corev1.SecretTypeDockerConfigJson)
+	}
+	if _, ok, _ := unstructured.NestedString(secret.Object, "data", corev1.DockerConfigJsonKey); !ok {
+		t.Errorf("got no %s data, want the content of the docker config file", corev1.DockerConfigJsonKey)
+	}
+	if secret.GetLabels()[clusterv1.ProviderLabelName] != "cluster-api" {
+		t.Errorf("got labels %v, want the secret labeled as a cluster-api provider component", secret.GetLabels())
+	}
+
+	if _, err := imagePullSecretObjs("cluster-api", "capi-system", []config.ImagePullSecret{{Name: "mirror", DockerConfigFile: invalidConfig}}); err == nil {
+		t.Errorf("imagePullSecretObjs() error = nil, want an error for an invalid docker config file")
+	}
+	if _, err := imagePullSecretObjs("cluster-api", "capi-system", []config.ImagePullSecret{{Name: "mirror", DockerConfigFile: filepath.Join(dir, "missing.json")}}); err == nil {
+		t.Errorf("imagePullSecretObjs() error = nil, want an error for a missing docker config file")
+	}
+}
+
+func Test_applyDeploymentOverrides_NoOverrides(t *testing.T) {
+	objs := []unstructured.Unstructured{{Object: map[string]interface{}{"apiVersion": "apps/v1", "kind": "Deployment"}}}
+
+	got, err := applyDeploymentOverrides(objs, config.DeploymentOverrides{})
+	if err != nil {
+		t.Fatalf("applyDeploymentOverrides() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, objs) {
+		t.Errorf("got %v, want the objects unchanged", got)
+	}
+}
@@ -17,8 +17,11 @@ limitations under the License.
 package cluster
 
 import (
+	"reflect"
+	"sort"
 	"testing"
 
+	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -75,6 +78,21 @@ func Test_providerComponents_Delete(t *testing.T) {
 		},
 		// CRDs (should be deleted only if forceDeleteCRD)
 		&crd,
+		// The provider's inventory item (should be deleted unless keepInventory)
+		&clusterctlv1.Provider{
+			TypeMeta: metav1.TypeMeta{
+				APIVersion: clusterctlv1.GroupVersion.String(),
+				Kind:       "Provider",
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "ns1",
+				Name:      "infra",
+				Labels: map[string]string{
+					clusterv1.ProviderLabelName:          "infra",
+					clusterctlv1.ClusterctlCoreLabelName: "inventory",
+				},
+			},
+		},
 		// Another object out of the provider namespace (should never be deleted)
 		&corev1.Pod{
 			TypeMeta: metav1.TypeMeta{
@@ -92,6 +110,7 @@ func Test_providerComponents_Delete(t *testing.T) {
 		provider             clusterctlv1.Provider
 		forceDeleteNamespace bool
 		forceDeleteCRD       bool
+		keepInventory        bool
 	}
 	type wantDiff struct {
 		object  corev1.ObjectReference
@@ -170,15 +189,30 @@ func Test_providerComponents_Delete(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "Delete provider while preserving the inventory",
+			args: args{
+				provider:             clusterctlv1.Provider{ObjectMeta: metav1.ObjectMeta{Name: "infra", Namespace: "ns1"}},
+				forceDeleteNamespace: false,
+				forceDeleteCRD:       false,
+				keepInventory:        true,
+			},
+			wantDiff: []wantDiff{
+				{object: corev1.ObjectReference{APIVersion: clusterctlv1.GroupVersion.String(), Kind: "Provider", Namespace: "ns1", Name: "infra"}, deleted: false}, // inventory should be preserved
+				{object: corev1.ObjectReference{APIVersion: "v1", Kind: "Pod", Namespace: "ns1", Name: "pod1"}, deleted: true},                                      // provider components should be deleted
+			},
+			wantErr: false,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			proxy := test.NewFakeProxy().WithObjs(initObjs...)
-			c := newComponentsClient(proxy)
-			err := c.Delete(DeleteOptions{
+			c := newComponentsClient(proxy, fakeObjectWaiter)
+			err := c.Delete(ctx, DeleteOptions{
 				Provider:             tt.args.provider,
 				ForceDeleteNamespace: tt.args.forceDeleteNamespace,
 				ForceDeleteCRD:       tt.args.forceDeleteCRD,
+				KeepInventory:        tt.args.keepInventory,
 			})
 			if (err != nil) != tt.wantErr {
 				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
@@ -220,3 +254,131 @@ func Test_providerComponents_Delete(t *testing.T) {
 		})
 	}
 }
+
+func Test_providerComponents_CheckHealth(t *testing.T) {
+	deployment := func(namespace, name string, replicas, availableReplicas int32) *appsv1.Deployment {
+		return &appsv1.Deployment{
+			TypeMeta: metav1.TypeMeta{
+				Kind:       "Deployment",
+				APIVersion: "apps/v1",
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: namespace,
+				Name:      name,
+				Labels: map[string]string{
+					clusterctlv1.ClusterctlLabelName: "",
+					clusterv1.ProviderLabelName:      "infra",
+				},
+			},
+			Spec: appsv1.DeploymentSpec{
+				Replicas: &replicas,
+			},
+			Status: appsv1.DeploymentStatus{
+				AvailableReplicas: availableReplicas,
+			},
+		}
+	}
+
+	provider := clusterctlv1.Provider{ObjectMeta: metav1.ObjectMeta{Name: "infra", Namespace: "ns1"}}
+
+	tests := []struct {
+		name    string
+		objs    []runtime.Object
+		wantErr bool
+	}{
+		{
+			name:    "All the deployments are available",
+			objs:    []runtime.Object{deployment("ns1", "controller-manager", 1, 1)},
+			wantErr: false,
+		},
+		{
+			name:    "A deployment is not available",
+			objs:    []runtime.Object{deployment("ns1", "controller-manager", 2, 1)},
+			wantErr: true,
+		},
+		{
+			name:    "No deployments for the provider instance",
+			objs:    []runtime.Object{deployment("ns2", "controller-manager", 1, 1)},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			proxy := test.NewFakeProxy().WithObjs(tt.objs...)
+			c := newComponentsClient(proxy, fakeObjectWaiter)
+			err := c.CheckHealth(ctx, provider)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_providerComponents_CustomResourcesToDelete(t *testing.T) {
+	crd := unstructured.Unstructured{}
+	crd.SetAPIVersion("apiextensions.k8s.io/v1beta1")
+	crd.SetKind("CustomResourceDefinition")
+	crd.SetName("clusters.cluster.x-k8s.io")
+	crd.Object["spec"] = map[string]interface{}{
+		"group": clusterv1.GroupVersion.Group,
+		"names": map[string]interface{}{
+			"kind":     "Cluster",
+			"listKind": "ClusterList",
+		},
+		"versions": []interface{}{
+			map[string]interface{}{"name": clusterv1.GroupVersion.Version, "served": true, "storage": true},
+		},
+	}
+
+	pod := unstructured.Unstructured{}
+	pod.SetAPIVersion("v1")
+	pod.SetKind("Pod")
+	pod.SetNamespace("ns1")
+	pod.SetName("pod1")
+
+	proxy := test.NewFakeProxy().WithObjs(
+		&clusterv1.Cluster{
+			TypeMeta:   metav1.TypeMeta{APIVersion: clusterv1.GroupVersion.String(), Kind: "Cluster"},
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: "cluster1"},
+		},
+		&clusterv1.Cluster{
+			TypeMeta:   metav1.TypeMeta{APIVersion: clusterv1.GroupVersion.String(), Kind: "Cluster"},
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ns2", Name: "cluster2"},
+		},
+	)
+	c := newComponentsClient(proxy, fakeObjectWaiter)
+
+	tests := []struct {
+		name      string
+		objs      []unstructured.Unstructured
+		wantNames []string
+	}{
+		{
+			name:      "Returns the instances of the CRDs to be deleted, in all the namespaces",
+			objs:      []unstructured.Unstructured{crd, pod},
+			wantNames: []string{"ns1/cluster1", "ns2/cluster2"},
+		},
+		{
+			name:      "Returns nothing if no CRD is deleted",
+			objs:      []unstructured.Unstructured{pod},
+			wantNames: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := c.CustomResourcesToDelete(ctx, tt.objs)
+			if err != nil {
+				t.Fatalf("error = %v, want nil", err)
+			}
+
+			var gotNames []string
+			for _, o := range got {
+				gotNames = append(gotNames, o.GetNamespace()+"/"+o.GetName())
+			}
+			sort.Strings(gotNames)
+			if !reflect.DeepEqual(gotNames, tt.wantNames) {
+				t.Errorf("got = %v, want %v", gotNames, tt.wantNames)
+			}
+		})
+	}
+}
@@ -0,0 +1,161 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	kerrors "k8s.io/apimachinery/pkg/util/errors"
+	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
+	logf "sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ConversionWebhookClient has methods to verify the conversion webhooks of the CRDs installed by clusterctl.
+type ConversionWebhookClient interface {
+	// Check verifies that the conversion webhooks of all the CRDs installed by clusterctl are working, and
+	// returns an error reporting the broken ones.
+	// A conversion webhook can be verified only if there are objects to convert; if this is not the case, the check
+	// is reported as inconclusive in the logs and it does not fail.
+	// Operations like move or upgrade should run this check first, so they don't fail halfway with opaque conversion errors.
+	Check(ctx context.Context) error
+}
+
+// conversionWebhookClient implements ConversionWebhookClient.
+type conversionWebhookClient struct {
+	proxy Proxy
+	log   logr.Logger
+}
+
+// ensure conversionWebhookClient implements ConversionWebhookClient.
+var _ ConversionWebhookClient = &conversionWebhookClient{}
+
+func (w *conversionWebhookClient) Check(ctx context.Context) error {
+	log := w.log
+	log.V(1).Info("Checking conversion webhooks")
+
+	c, err := w.proxy.NewClient()
+	if err != nil {
+		return err
+	}
+
+	crdList := &apiextensionsv1.CustomResourceDefinitionList{}
+	if err := c.List(ctx, crdList, client.MatchingLabels{clusterctlv1.ClusterctlLabelName: ""}); err != nil {
+		return errors.Wrap(err, "failed to get the list of CRDs installed by clusterctl")
+	}
+
+	errList := []error{}
+	for i := range crdList.Items {
+		crd := &crdList.Items[i]
+		if crd.Spec.Conversion == nil || crd.Spec.Conversion.Strategy != apiextensionsv1.WebhookConverter {
+			continue
+		}
+
+		log.V(5).Info("Checking conversion webhook", "CRD", crd.Name)
+		verified, err := checkConversionWebhook(ctx, c, crd)
+		if err != nil {
+			errList = append(errList, errors.Wrapf(err, "the conversion webhook for the %s CRD is not working", crd.Name))
+			continue
+		}
+		if !verified {
+			log.Info("The conversion webhook check is inconclusive: there are no objects to convert", "CRD", crd.Name)
+		}
+	}
+	return kerrors.NewAggregate(errList)
+}
+
+// checkConversionWebhook checks the conversion webhook of a CRD by checking the webhook configuration, the webhook service,
+// and by issuing a test conversion request for each served version different from the storage version.
+// The returned value is false if no conversion request was actually sent to the webhook, because there are no objects
+// to convert; in this case the webhook is not verified.
+func checkConversionWebhook(ctx context.Context, c client.Client, crd *apiextensionsv1.CustomResourceDefinition) (bool, error) {
+	config := crd.Spec.Conversion.WebhookClientConfig
+	if config == nil || (config.Service == nil && config.URL == nil) {
+		return false, errors.New("the webhook client configuration is missing")
+	}
+
+	if config.Service != nil {
+		if err := checkWebhookService(ctx, c, config.Service.Namespace, config.Service.Name, config.CABundle); err != nil {
+			return false, err
+		}
+	}
+
+	// Reads an object for each served version different from the storage version; given that objects are stored in
+	// the storage version, this forces the API server to send a conversion request to the webhook.
+	// If there are no served versions other than the storage version, conversions never happen, and so there is
+	// nothing else to verify.
+	verified := true
+	for _, version := range crd.Spec.Versions {
+		if !version.Served || version.Storage {
+			continue
+		}
+
+		objList := new(unstructured.UnstructuredList)
+		objList.SetAPIVersion(metav1.GroupVersion{Group: crd.Spec.Group, Version: version.Name}.String())
+		objList.SetKind(crd.Spec.Names.Kind + "List")
+		if err := c.List(ctx, objList, client.Limit(1)); err != nil {
+			return false, errors.Wrapf(err, "the conversion request for the %s version failed", version.Name)
+		}
+		// An empty list does not require any conversion, so the webhook is not called.
+		if len(objList.Items) == 0 {
+			verified = false
+		}
+	}
+
+	return verified, nil
+}
+
+// checkWebhookService checks that the service backing a webhook has ready endpoints, and that the webhook
+// client configuration has the caBundle for verifying the certificate of the service.
+func checkWebhookService(ctx context.Context, c client.Client, namespace, name string, caBundle []byte) error {
+	if len(caBundle) == 0 {
+		return errors.New("the webhook client configuration does not have a caBundle; check if the CA injection by cert-manager is working")
+	}
+
+	endpoints := &corev1.Endpoints{}
+	endpointsKey := client.ObjectKey{
+		Namespace: namespace,
+		Name:      name,
+	}
+	if err := c.Get(ctx, endpointsKey, endpoints); err != nil {
+		if apierrors.IsNotFound(err) {
+			return errors.Errorf("the webhook service %s/%s does not exist", namespace, name)
+		}
+		return errors.Wrapf(err, "failed to get the endpoints of the webhook service %s/%s", namespace, name)
+	}
+
+	for _, subset := range endpoints.Subsets {
+		if len(subset.Addresses) > 0 {
+			return nil
+		}
+	}
+	return errors.Errorf("the webhook service %s/%s does not have ready endpoints", namespace, name)
+}
+
+func newConversionWebhookClient(proxy Proxy) *conversionWebhookClient {
+	return &conversionWebhookClient{
+		proxy: proxy,
+		log:   logf.Log,
+	}
+}
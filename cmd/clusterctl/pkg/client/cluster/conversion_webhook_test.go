@@ -0,0 +1,195 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/internal/test"
+)
+
+func Test_conversionWebhookClient_Check(t *testing.T) {
+	// Returns a CRD using the webhook service capi-system/capi-webhook-service for conversions.
+	crdWithConversionWebhook := func(caBundle []byte) *apiextensionsv1.CustomResourceDefinition {
+		crd := test.FakeCustomResourceDefinition("cluster.x-k8s.io", "Cluster", "v1alpha3")
+		crd.Spec.Conversion = &apiextensionsv1.CustomResourceConversion{
+			Strategy: apiextensionsv1.WebhookConverter,
+			WebhookClientConfig: &apiextensionsv1.WebhookClientConfig{
+				Service: &apiextensionsv1.ServiceReference{
+					Namespace: "capi-system",
+					Name:      "capi-webhook-service",
+				},
+				CABundle: caBundle,
+			},
+		}
+		return crd
+	}
+
+	// Returns the endpoints for the webhook service capi-system/capi-webhook-service.
+	webhookEndpoints := func(addresses ...string) *corev1.Endpoints {
+		endpoints := &corev1.Endpoints{
+			TypeMeta: metav1.TypeMeta{
+				APIVersion: "v1",
+				Kind:       "Endpoints",
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "capi-system",
+				Name:      "capi-webhook-service",
+			},
+		}
+		if len(addresses) > 0 {
+			subset := corev1.EndpointSubset{}
+			for _, a := range addresses {
+				subset.Addresses = append(subset.Addresses, corev1.EndpointAddress{IP: a})
+			}
+			endpoints.Subsets = append(endpoints.Subsets, subset)
+		}
+		return endpoints
+	}
+
+	tests := []struct {
+		name    string
+		objs    []runtime.Object
+		wantErr bool
+	}{
+		{
+			name: "pass with CRDs without conversion webhooks",
+			objs: []runtime.Object{
+				test.FakeCustomResourceDefinition("cluster.x-k8s.io", "Cluster", "v1alpha3"),
+			},
+			wantErr: false,
+		},
+		{
+			name: "pass with a conversion webhook service with ready endpoints",
+			objs: []runtime.Object{
+				crdWithConversionWebhook([]byte("ca")),
+				webhookEndpoints("10.0.0.1"),
+			},
+			wantErr: false,
+		},
+		{
+			name: "fails if the conversion webhook configuration does not have a caBundle",
+			objs: []runtime.Object{
+				crdWithConversionWebhook(nil),
+				webhookEndpoints("10.0.0.1"),
+			},
+			wantErr: true,
+		},
+		{
+			name: "fails if the conversion webhook service does not exist",
+			objs: []runtime.Object{
+				crdWithConversionWebhook([]byte("ca")),
+			},
+			wantErr: true,
+		},
+		{
+			name: "fails if the conversion webhook service does not have ready endpoints",
+			objs: []runtime.Object{
+				crdWithConversionWebhook([]byte("ca")),
+				webhookEndpoints(),
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := newConversionWebhookClient(test.NewFakeProxy().WithObjs(tt.objs...))
+			err := w.Check(ctx)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_checkConversionWebhook(t *testing.T) {
+	// Returns a CRD storing v1alpha2 objects, and using the webhook service capi-system/capi-webhook-service for converting to v1alpha3.
+	crd := test.FakeCustomResourceDefinition("cluster.x-k8s.io", "Cluster", "v1alpha2", "v1alpha3")
+	crd.Spec.Conversion = &apiextensionsv1.CustomResourceConversion{
+		Strategy: apiextensionsv1.WebhookConverter,
+		WebhookClientConfig: &apiextensionsv1.WebhookClientConfig{
+			Service: &apiextensionsv1.ServiceReference{
+				Namespace: "capi-system",
+				Name:      "capi-webhook-service",
+			},
+			CABundle: []byte("ca"),
+		},
+	}
+
+	endpoints := &corev1.Endpoints{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "Endpoints",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "capi-system",
+			Name:      "capi-webhook-service",
+		},
+		Subsets: []corev1.EndpointSubset{
+			{Addresses: []corev1.EndpointAddress{{IP: "10.0.0.1"}}},
+		},
+	}
+
+	cluster := &clusterv1.Cluster{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: clusterv1.GroupVersion.String(),
+			Kind:       "Cluster",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "ns1",
+			Name:      "cluster1",
+		},
+	}
+
+	tests := []struct {
+		name         string
+		objs         []runtime.Object
+		wantVerified bool
+	}{
+		{
+			name:         "inconclusive if there are no objects to convert",
+			objs:         []runtime.Object{crd, endpoints},
+			wantVerified: false,
+		},
+		{
+			name:         "verified if there are objects to convert",
+			objs:         []runtime.Object{crd, endpoints, cluster},
+			wantVerified: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := test.NewFakeProxy().WithObjs(tt.objs...).NewClient()
+			if err != nil {
+				t.Fatalf("NewClient() error = %v", err)
+			}
+
+			verified, err := checkConversionWebhook(ctx, c, crd)
+			if err != nil {
+				t.Fatalf("error = %v, want nil", err)
+			}
+			if verified != tt.wantVerified {
+				t.Errorf("verified = %v, want %v", verified, tt.wantVerified)
+			}
+		})
+	}
+}
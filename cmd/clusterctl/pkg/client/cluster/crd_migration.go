@@ -0,0 +1,329 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/go-logr/logr"
+	"github.com/pkg/errors"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
+	clientretry "k8s.io/client-go/util/retry"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/internal/util"
+	logf "sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// CRDMigration defines the storage version migration required for a CustomResourceDefinition before a provider
+// upgrade, because the new version of the CRD drops API versions objects are still stored in.
+type CRDMigration struct {
+	// CRD is the name of the CustomResourceDefinition.
+	CRD string
+
+	// StorageVersion is the version the objects are migrated to; this is the storage version of the current CRD,
+	// and it is served also by the new CRD.
+	StorageVersion string
+
+	// DroppedVersions are the versions objects are stored in, which are not defined anymore by the new CRD.
+	DroppedVersions []string
+
+	// Objects is the number of objects to be migrated.
+	Objects int
+}
+
+// crdMigrator migrates the objects stored in API versions dropped by new CustomResourceDefinitions; without this
+// step, applying the new CRDs fails because the API server does not allow to drop versions listed in status.storedVersions.
+type crdMigrator struct {
+	proxy Proxy
+	log   logr.Logger
+}
+
+func newCRDMigrator(proxy Proxy) *crdMigrator {
+	return &crdMigrator{
+		proxy: proxy,
+		log:   logf.Log,
+	}
+}
+
+// plan returns the migrations required before installing the given objects; only the CustomResourceDefinitions
+// already existing in the cluster and dropping versions objects are stored in require a migration.
+func (m *crdMigrator) plan(ctx context.Context, objs []unstructured.Unstructured) ([]CRDMigration, error) {
+	c, err := m.proxy.NewClient()
+	if err != nil {
+		return nil, err
+	}
+
+	var migrations []CRDMigration
+	for _, o := range objs {
+		if !util.IsCustomResourceDefinition(o) {
+			continue
+		}
+
+		newCRD := &apiextensionsv1.CustomResourceDefinition{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(o.Object, newCRD); err != nil {
+			return nil, errors.Wrapf(err, "failed to convert %q to a CustomResourceDefinition", o.GetName())
+		}
+		newVersions := crdVersions(newCRD)
+
+		currentCRD := &apiextensionsv1.CustomResourceDefinition{}
+		if err := c.Get(ctx, client.ObjectKey{Name: o.GetName()}, currentCRD); err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return nil, errors.Wrapf(err, "failed to get the CustomResourceDefinition %q", o.GetName())
+		}
+
+		if err := m.checkStoredVersions(ctx, c, currentCRD, newCRD); err != nil {
+			return nil, err
+		}
+
+		var dropped []string
+		for _, v := range currentCRD.Status.StoredVersions {
+			if !newVersions.Has(v) {
+				dropped = append(dropped, v)
+			}
+		}
+		if len(dropped) == 0 {
+			continue
+		}
+
+		storageVersion := crdStorageVersion(currentCRD)
+		if !newVersions.Has(storageVersion) {
+			return nil, errors.Errorf("unable to migrate the objects of the CustomResourceDefinition %q: the new CRD does not serve the current storage version %q", currentCRD.Name, storageVersion)
+		}
+
+		list, err := m.listObjects(ctx, c, currentCRD, storageVersion)
+		if err != nil {
+			return nil, err
+		}
+
+		migrations = append(migrations, CRDMigration{
+			CRD:             currentCRD.Name,
+			StorageVersion:  storageVersion,
+			DroppedVersions: dropped,
+			Objects:         len(list.Items),
+		})
+	}
+	return migrations, nil
+}
+
+// crdUpgradeError reports a CustomResourceDefinition whose upgrade would leave existing objects unreadable, because
+// the new CRD neither serves nor can convert the versions the objects are stored in.
+type crdUpgradeError struct {
+	// CRD is the name of the CustomResourceDefinition.
+	CRD string
+
+	// Reasons lists why the objects could not be read after the upgrade.
+	Reasons []string
+
+	// Objects is the number of existing objects.
+	Objects int
+}
+
+func (e *crdUpgradeError) Error() string {
+	return fmt.Sprintf("unable to upgrade the CustomResourceDefinition %q: %s, so its %d objects would not be readable after the upgrade; "+
+		"upgrade first to a provider version serving the stored versions, or fix the conversion webhook configuration of the new version",
+		e.CRD, strings.Join(e.Reasons, "; "), e.Objects)
+}
+
+// checkStoredVersions verifies that the new CustomResourceDefinition can read the objects stored by the current one,
+// otherwise the API server rejects the reads after the upgrade. Once the migrations are completed, objects are stored
+// in the current storage version or in the stored versions still defined by the new CRD; each of them must be served by
+// the new CRD, or converted by a configured conversion webhook.
+// NB. CustomResourceDefinitions without objects can't be broken by the upgrade, so they are not blocked.
+func (m *crdMigrator) checkStoredVersions(ctx context.Context, c client.Client, currentCRD, newCRD *apiextensionsv1.CustomResourceDefinition) error {
+	newVersions := crdVersions(newCRD)
+	servedVersions := crdServedVersions(newCRD)
+	webhook := newCRD.Spec.Conversion != nil && newCRD.Spec.Conversion.Strategy == apiextensionsv1.WebhookConverter
+	webhookConfigured := webhook && isConversionWebhookConfigured(newCRD)
+
+	var reasons []string
+	if webhook && !webhookConfigured {
+		reasons = append(reasons, "the new CRD uses a conversion webhook, but it does not define the webhook service and the CA bundle (or a CA injection annotation)")
+	}
+
+	storageVersion := crdStorageVersion(currentCRD)
+	storedVersions := sets.NewString(storageVersion)
+	for _, v := range currentCRD.Status.StoredVersions {
+		if newVersions.Has(v) {
+			storedVersions.Insert(v)
+		}
+	}
+	for _, v := range storedVersions.List() {
+		switch {
+		case servedVersions.Has(v):
+		case webhookConfigured && newVersions.Has(v):
+		case newVersions.Has(v):
+			reasons = append(reasons, fmt.Sprintf("version %s is stored but not served by the new CRD, and there is no conversion webhook", v))
+		default:
+			reasons = append(reasons, fmt.Sprintf("version %s is stored but not defined by the new CRD", v))
+		}
+	}
+	if len(reasons) == 0 {
+		return nil
+	}
+
+	list, err := m.listObjects(ctx, c, currentCRD, storageVersion)
+	if err != nil {
+		return err
+	}
+	if len(list.Items) == 0 {
+		m.log.V(1).Info("CustomResourceDefinition without objects, skipping the stored versions check", "CRD", currentCRD.Name, "Reasons", reasons)
+		return nil
+	}
+	return &crdUpgradeError{
+		CRD:     currentCRD.Name,
+		Reasons: reasons,
+		Objects: len(list.Items),
+	}
+}
+
+// isConversionWebhookConfigured returns true if the conversion webhook of a CustomResourceDefinition defines where to
+// send the conversion requests and the CA for trusting it, either directly or via the cert-manager CA injection.
+func isConversionWebhookConfigured(crd *apiextensionsv1.CustomResourceDefinition) bool {
+	config := crd.Spec.Conversion.WebhookClientConfig
+	if config == nil || (config.Service == nil && config.URL == nil) {
+		return false
+	}
+	if len(config.CABundle) > 0 {
+		return true
+	}
+	for k := range crd.GetAnnotations() {
+		if strings.HasSuffix(k, "/inject-ca-from") {
+			return true
+		}
+	}
+	return false
+}
+
+// run executes the migrations, by re-writing all the objects of each CustomResourceDefinition so they get stored
+// in the current storage version, and then by removing the dropped versions from the CRD status.storedVersions.
+func (m *crdMigrator) run(ctx context.Context, migrations []CRDMigration) error {
+	log := m.log
+
+	c, err := m.proxy.NewClient()
+	if err != nil {
+		return err
+	}
+
+	for _, migration := range migrations {
+		log.Info("Migrating CustomResourceDefinition objects", "CRD", migration.CRD, "StorageVersion", migration.StorageVersion, "DroppedVersions", migration.DroppedVersions)
+
+		crd := &apiextensionsv1.CustomResourceDefinition{}
+		if err := c.Get(ctx, client.ObjectKey{Name: migration.CRD}, crd); err != nil {
+			return errors.Wrapf(err, "failed to get the CustomResourceDefinition %q", migration.CRD)
+		}
+
+		list, err := m.listObjects(ctx, c, crd, migration.StorageVersion)
+		if err != nil {
+			return err
+		}
+
+		// A no-op update is enough for the API server to store the object in the current storage version.
+		for i := range list.Items {
+			obj := &list.Items[i]
+			if err := retryOnConflict(m.log, clientretry.DefaultRetry, func() error {
+				current := &unstructured.Unstructured{}
+				current.SetGroupVersionKind(obj.GroupVersionKind())
+				if err := c.Get(ctx, client.ObjectKey{Namespace: obj.GetNamespace(), Name: obj.GetName()}, current); err != nil {
+					if apierrors.IsNotFound(err) {
+						return nil
+					}
+					return err
+				}
+				return c.Update(ctx, current)
+			}); err != nil {
+				return errors.Wrapf(err, "failed to migrate %s %s/%s to version %s", obj.GetKind(), obj.GetNamespace(), obj.GetName(), migration.StorageVersion)
+			}
+		}
+
+		dropped := sets.NewString(migration.DroppedVersions...)
+		if err := retryOnConflict(m.log, clientretry.DefaultRetry, func() error {
+			if err := c.Get(ctx, client.ObjectKey{Name: migration.CRD}, crd); err != nil {
+				return err
+			}
+			storedVersions := []string{}
+			for _, v := range crd.Status.StoredVersions {
+				if !dropped.Has(v) {
+					storedVersions = append(storedVersions, v)
+				}
+			}
+			if !sets.NewString(storedVersions...).Has(migration.StorageVersion) {
+				storedVersions = append(storedVersions, migration.StorageVersion)
+			}
+			crd.Status.StoredVersions = storedVersions
+			return c.Status().Update(ctx, crd)
+		}); err != nil {
+			return errors.Wrapf(err, "failed to update the stored versions of the CustomResourceDefinition %q", migration.CRD)
+		}
+	}
+	return nil
+}
+
+// listObjects lists all the objects of a CustomResourceDefinition using the given version.
+func (m *crdMigrator) listObjects(ctx context.Context, c client.Client, crd *apiextensionsv1.CustomResourceDefinition, version string) (*unstructured.UnstructuredList, error) {
+	list := &unstructured.UnstructuredList{}
+	list.SetAPIVersion(crd.Spec.Group + "/" + version)
+	list.SetKind(crd.Spec.Names.ListKind)
+	if err := c.List(ctx, list); err != nil {
+		return nil, errors.Wrapf(err, "failed to list the objects of the CustomResourceDefinition %q", crd.Name)
+	}
+	return list, nil
+}
+
+// crdVersions returns the versions defined by a CustomResourceDefinition.
+func crdVersions(crd *apiextensionsv1.CustomResourceDefinition) sets.String {
+	versions := sets.NewString()
+	if crd.Spec.Version != "" {
+		versions.Insert(crd.Spec.Version)
+	}
+	for _, v := range crd.Spec.Versions {
+		versions.Insert(v.Name)
+	}
+	return versions
+}
+
+// crdServedVersions returns the versions served by a CustomResourceDefinition; the version of a CRD
+// not using the versions list is always served.
+func crdServedVersions(crd *apiextensionsv1.CustomResourceDefinition) sets.String {
+	if len(crd.Spec.Versions) == 0 {
+		return crdVersions(crd)
+	}
+	versions := sets.NewString()
+	for _, v := range crd.Spec.Versions {
+		if v.Served {
+			versions.Insert(v.Name)
+		}
+	}
+	return versions
+}
+
+// crdStorageVersion returns the version used for storing the objects of a CustomResourceDefinition.
+func crdStorageVersion(crd *apiextensionsv1.CustomResourceDefinition) string {
+	for _, v := range crd.Spec.Versions {
+		if v.Storage {
+			return v.Name
+		}
+	}
+	return crd.Spec.Version
+}
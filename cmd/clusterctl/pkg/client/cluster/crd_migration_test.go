@@ -0,0 +1,253 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"reflect"
+	"testing"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/internal/test"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func fakeMachineCRD(storageVersion string, storedVersions []string, versions ...string) *apiextensionsv1.CustomResourceDefinition {
+	crd := &apiextensionsv1.CustomResourceDefinition{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: apiextensionsv1.SchemeGroupVersion.String(),
+			Kind:       "CustomResourceDefinition",
+		},
+		ObjectMeta: metav1.ObjectMeta{Name: "machines.cluster.x-k8s.io"},
+		Spec: apiextensionsv1.CustomResourceDefinitionSpec{
+			Group: clusterv1.GroupVersion.Group,
+			Names: apiextensionsv1.CustomResourceDefinitionNames{Kind: "Machine", ListKind: "MachineList"},
+		},
+		Status: apiextensionsv1.CustomResourceDefinitionStatus{StoredVersions: storedVersions},
+	}
+	for _, v := range versions {
+		crd.Spec.Versions = append(crd.Spec.Versions, apiextensionsv1.CustomResourceDefinitionVersion{Name: v, Served: true, Storage: v == storageVersion})
+	}
+	return crd
+}
+
+func Test_crdMigrator(t *testing.T) {
+	machine := func(name string) *clusterv1.Machine {
+		return &clusterv1.Machine{
+			TypeMeta:   metav1.TypeMeta{APIVersion: clusterv1.GroupVersion.String(), Kind: "Machine"},
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: name},
+		}
+	}
+
+	tests := []struct {
+		name               string
+		currentCRD         *apiextensionsv1.CustomResourceDefinition
+		newCRD             *apiextensionsv1.CustomResourceDefinition
+		want               []CRDMigration
+		wantStoredVersions []string
+		wantErr            bool
+	}{
+		{
+			name:   "No migration if the CRD does not exist",
+			newCRD: fakeMachineCRD("v1alpha3", nil, "v1alpha3"),
+			want:   nil,
+		},
+		{
+			name:               "No migration if the new CRD defines all the stored versions",
+			currentCRD:         fakeMachineCRD("v1alpha3", []string{"v1alpha2", "v1alpha3"}, "v1alpha2", "v1alpha3"),
+			newCRD:             fakeMachineCRD("v1alpha4", nil, "v1alpha2", "v1alpha3", "v1alpha4"),
+			want:               nil,
+			wantStoredVersions: []string{"v1alpha2", "v1alpha3"},
+		},
+		{
+			name:       "Migrates the objects stored in versions dropped by the new CRD",
+			currentCRD: fakeMachineCRD("v1alpha3", []string{"v1alpha2", "v1alpha3"}, "v1alpha2", "v1alpha3"),
+			newCRD:     fakeMachineCRD("v1alpha3", nil, "v1alpha3", "v1alpha4"),
+			want: []CRDMigration{
+				{
+					CRD:             "machines.cluster.x-k8s.io",
+					StorageVersion:  "v1alpha3",
+					DroppedVersions: []string{"v1alpha2"},
+					Objects:         2,
+				},
+			},
+			wantStoredVersions: []string{"v1alpha3"},
+		},
+		{
+			name:       "Fails if the new CRD drops the current storage version",
+			currentCRD: fakeMachineCRD("v1alpha3", []string{"v1alpha2", "v1alpha3"}, "v1alpha2", "v1alpha3"),
+			newCRD:     fakeMachineCRD("v1alpha4", nil, "v1alpha4"),
+			wantErr:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			objs := []runtime.Object{machine("m1"), machine("m2")}
+			if tt.currentCRD != nil {
+				objs = append(objs, tt.currentCRD)
+			}
+			proxy := test.NewFakeProxy().WithObjs(objs...)
+
+			newCRD, err := runtime.DefaultUnstructuredConverter.ToUnstructured(tt.newCRD)
+			if err != nil {
+				t.Fatalf("failed to convert the new CRD: %v", err)
+			}
+
+			m := newCRDMigrator(proxy)
+			got, err := m.plan(ctx, []unstructured.Unstructured{{Object: newCRD}})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got = %v, want %v", got, tt.want)
+			}
+
+			if err := m.run(ctx, got); err != nil {
+				t.Fatalf("run() error = %v", err)
+			}
+			if tt.currentCRD == nil {
+				return
+			}
+
+			c, err := proxy.NewClient()
+			if err != nil {
+				t.Fatalf("failed to create the client: %v", err)
+			}
+			crd := &apiextensionsv1.CustomResourceDefinition{}
+			if err := c.Get(ctx, client.ObjectKey{Name: tt.currentCRD.Name}, crd); err != nil {
+				t.Fatalf("failed to get the CRD: %v", err)
+			}
+			if !reflect.DeepEqual(crd.Status.StoredVersions, tt.wantStoredVersions) {
+				t.Errorf("storedVersions = %v, want %v", crd.Status.StoredVersions, tt.wantStoredVersions)
+			}
+
+			machines := &clusterv1.MachineList{}
+			if err := c.List(ctx, machines); err != nil {
+				t.Fatalf("failed to list Machines: %v", err)
+			}
+			if len(machines.Items) != 2 {
+				t.Errorf("got %d Machines after the migration, want 2", len(machines.Items))
+			}
+		})
+	}
+}
+
+func Test_crdMigrator_checkStoredVersions(t *testing.T) {
+	machine := &clusterv1.Machine{
+		TypeMeta:   metav1.TypeMeta{APIVersion: clusterv1.GroupVersion.String(), Kind: "Machine"},
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: "m1"},
+	}
+	notServed := func(crd *apiextensionsv1.CustomResourceDefinition, version string) *apiextensionsv1.CustomResourceDefinition {
+		for i := range crd.Spec.Versions {
+			if crd.Spec.Versions[i].Name == version {
+				crd.Spec.Versions[i].Served = false
+			}
+		}
+		return crd
+	}
+	withWebhook := func(crd *apiextensionsv1.CustomResourceDefinition, caBundle []byte) *apiextensionsv1.CustomResourceDefinition {
+		crd.Spec.Conversion = &apiextensionsv1.CustomResourceConversion{
+			Strategy: apiextensionsv1.WebhookConverter,
+			WebhookClientConfig: &apiextensionsv1.WebhookClientConfig{
+				Service:  &apiextensionsv1.ServiceReference{Namespace: "capi-webhook-system", Name: "capi-webhook-service"},
+				CABundle: caBundle,
+			},
+		}
+		return crd
+	}
+
+	tests := []struct {
+		name       string
+		currentCRD *apiextensionsv1.CustomResourceDefinition
+		newCRD     *apiextensionsv1.CustomResourceDefinition
+		objs       []runtime.Object
+		wantErr    bool
+	}{
+		{
+			name:       "Pass if the new CRD serves all the stored versions",
+			currentCRD: fakeMachineCRD("v1alpha3", []string{"v1alpha2", "v1alpha3"}, "v1alpha2", "v1alpha3"),
+			newCRD:     fakeMachineCRD("v1alpha4", nil, "v1alpha2", "v1alpha3", "v1alpha4"),
+			objs:       []runtime.Object{machine},
+			wantErr:    false,
+		},
+		{
+			name:       "Pass if the versions dropped by the new CRD are migrated to the current storage version",
+			currentCRD: fakeMachineCRD("v1alpha3", []string{"v1alpha2", "v1alpha3"}, "v1alpha2", "v1alpha3"),
+			newCRD:     fakeMachineCRD("v1alpha4", nil, "v1alpha3", "v1alpha4"),
+			objs:       []runtime.Object{machine},
+			wantErr:    false,
+		},
+		{
+			name:       "Fails if a stored version is not served by the new CRD and there is no conversion webhook",
+			currentCRD: fakeMachineCRD("v1alpha3", []string{"v1alpha2", "v1alpha3"}, "v1alpha2", "v1alpha3"),
+			newCRD:     notServed(fakeMachineCRD("v1alpha4", nil, "v1alpha2", "v1alpha3", "v1alpha4"), "v1alpha2"),
+			objs:       []runtime.Object{machine},
+			wantErr:    true,
+		},
+		{
+			name:       "Pass if a stored version is not served by the new CRD but it is converted by the conversion webhook",
+			currentCRD: fakeMachineCRD("v1alpha3", []string{"v1alpha2", "v1alpha3"}, "v1alpha2", "v1alpha3"),
+			newCRD:     withWebhook(notServed(fakeMachineCRD("v1alpha4", nil, "v1alpha2", "v1alpha3", "v1alpha4"), "v1alpha2"), []byte("ca")),
+			objs:       []runtime.Object{machine},
+			wantErr:    false,
+		},
+		{
+			name:       "Fails if the conversion webhook of the new CRD has no CA",
+			currentCRD: fakeMachineCRD("v1alpha3", []string{"v1alpha3"}, "v1alpha3"),
+			newCRD:     withWebhook(fakeMachineCRD("v1alpha4", nil, "v1alpha3", "v1alpha4"), nil),
+			objs:       []runtime.Object{machine},
+			wantErr:    true,
+		},
+		{
+			name:       "Fails if the new CRD drops the current storage version",
+			currentCRD: fakeMachineCRD("v1alpha3", []string{"v1alpha3"}, "v1alpha3"),
+			newCRD:     fakeMachineCRD("v1alpha4", nil, "v1alpha4"),
+			objs:       []runtime.Object{machine},
+			wantErr:    true,
+		},
+		{
+			name:       "Pass if there are no objects",
+			currentCRD: fakeMachineCRD("v1alpha3", []string{"v1alpha3"}, "v1alpha3"),
+			newCRD:     fakeMachineCRD("v1alpha4", nil, "v1alpha4"),
+			objs:       nil,
+			wantErr:    false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			proxy := test.NewFakeProxy().WithObjs(tt.objs...)
+			c, err := proxy.NewClient()
+			if err != nil {
+				t.Fatalf("failed to create the client: %v", err)
+			}
+
+			err = newCRDMigrator(proxy).checkStoredVersions(ctx, c, tt.currentCRD, tt.newCRD)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if _, ok := err.(*crdUpgradeError); tt.wantErr && !ok {
+				t.Errorf("got error %T, want *crdUpgradeError", err)
+			}
+		})
+	}
+}
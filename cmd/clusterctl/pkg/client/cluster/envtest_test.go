@@ -0,0 +1,219 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"fmt"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/client/config"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/client/repository"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/internal/test"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	envtestCoreProvider  = "cluster-api"
+	envtestInfraProvider = "infra-envtest"
+)
+
+// envtestComponents returns rendered components for a provider, with a Namespace and a ConfigMap.
+func envtestComponents(provider config.Provider, namespace string) repository.Components {
+	yaml := fmt.Sprintf(`apiVersion: v1
+kind: Namespace
+metadata:
+  name: %[1]s
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: %[2]s-config
+  namespace: %[1]s
+data:
+  provider: %[2]s
+`, namespace, provider.Name())
+
+	components, err := repository.NewRenderedComponents(provider, "v1.0.0", []byte(yaml), namespace, "")
+	Expect(err).ToNot(HaveOccurred())
+	return components
+}
+
+var _ = Describe("clusterctl cluster library", func() {
+	var (
+		clusterClient Client
+		c             client.Client
+		coreProvider  config.Provider
+		infraProvider config.Provider
+	)
+
+	BeforeEach(func() {
+		coreProvider = config.NewProvider(envtestCoreProvider, "https://somewhere.com", clusterctlv1.CoreProviderType)
+		infraProvider = config.NewProvider(envtestInfraProvider, "https://somewhere.com", clusterctlv1.InfrastructureProviderType)
+
+		reader := test.NewFakeReader().
+			WithProvider(coreProvider.Name(), coreProvider.Type(), coreProvider.URL()).
+			WithProvider(infraProvider.Name(), infraProvider.Type(), infraProvider.URL())
+		configClient, err := config.New("", config.InjectReader(reader))
+		Expect(err).ToNot(HaveOccurred())
+
+		repositoryFactory := func(provider config.Provider, configVariablesClient config.VariablesClient, options ...repository.Option) (repository.Client, error) {
+			repo := test.NewFakeRepository().
+				WithVersions("v1.0.0").
+				WithMetadata("v1.0.0", &clusterctlv1.Metadata{
+					ReleaseSeries: []clusterctlv1.ReleaseSeries{
+						{Major: 1, Minor: 0, Contract: "v1alpha3"},
+					},
+				})
+			return repository.New(provider, configVariablesClient, repository.InjectRepository(repo))
+		}
+
+		clusterClient = New(testKubeconfig, configClient, InjectRepositoryFactory(repositoryFactory))
+
+		c, err = clusterClient.Proxy().NewClient()
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("installs the inventory CRDs", func() {
+		Expect(clusterClient.ProviderInventory().EnsureCustomResourceDefinitions(ctx)).To(Succeed())
+
+		By("being idempotent")
+		Expect(clusterClient.ProviderInventory().EnsureCustomResourceDefinitions(ctx)).To(Succeed())
+
+		Expect(c.List(ctx, &clusterctlv1.ProviderList{})).To(Succeed())
+		Expect(c.List(ctx, &clusterctlv1.VersionPolicyList{})).To(Succeed())
+	})
+
+	It("installs, lists and deletes providers", func() {
+		Expect(clusterClient.ProviderInventory().EnsureCustomResourceDefinitions(ctx)).To(Succeed())
+
+		By("installing the providers")
+		installer := clusterClient.ProviderInstaller()
+		installer.Add(envtestComponents(coreProvider, "capi-envtest-system"))
+		installer.Add(envtestComponents(infraProvider, "infra-envtest-system"))
+		Expect(installer.Validate(ctx)).To(Succeed())
+		installed, err := installer.Install(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(installed).To(HaveLen(2))
+
+		By("recording the providers in the inventory")
+		providers, err := clusterClient.ProviderInventory().List(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(providers.Items).To(HaveLen(2))
+
+		name, err := clusterClient.ProviderInventory().GetDefaultProviderName(ctx, clusterctlv1.InfrastructureProviderType)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(name).To(Equal(envtestInfraProvider))
+
+		namespace, err := clusterClient.ProviderInventory().GetDefaultProviderNamespace(ctx, envtestInfraProvider)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(namespace).To(Equal("infra-envtest-system"))
+
+		By("labeling the provider components")
+		configMap := &corev1.ConfigMap{}
+		Expect(c.Get(ctx, client.ObjectKey{Namespace: "infra-envtest-system", Name: envtestInfraProvider + "-config"}, configMap)).To(Succeed())
+		Expect(configMap.Labels).To(HaveKeyWithValue(clusterv1.ProviderLabelName, envtestInfraProvider))
+		Expect(configMap.Labels).To(HaveKey(clusterctlv1.ClusterctlLabelName))
+
+		By("deleting the infrastructure provider, preserving its namespace")
+		var infraInventory clusterctlv1.Provider
+		for _, p := range providers.Items {
+			if p.Name == envtestInfraProvider {
+				infraInventory = p
+			}
+		}
+		Expect(clusterClient.ProviderComponents().Delete(ctx, DeleteOptions{Provider: infraInventory})).To(Succeed())
+
+		err = c.Get(ctx, client.ObjectKey{Namespace: "infra-envtest-system", Name: envtestInfraProvider + "-config"}, &corev1.ConfigMap{})
+		Expect(apierrors.IsNotFound(err)).To(BeTrue())
+		Expect(c.Get(ctx, client.ObjectKey{Name: "infra-envtest-system"}, &corev1.Namespace{})).To(Succeed())
+
+		providers, err = clusterClient.ProviderInventory().List(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(providers.Items).To(HaveLen(1))
+		Expect(providers.Items[0].Name).To(Equal(envtestCoreProvider))
+	})
+
+	It("reports conversion webhooks without ready endpoints", func() {
+		// Simulates a provider whose webhook server is not running, by using a webhook service without endpoints.
+		crd := &apiextensionsv1.CustomResourceDefinition{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "envtestmachines.infrastructure.cluster.x-k8s.io",
+				Labels: map[string]string{
+					clusterctlv1.ClusterctlLabelName: "",
+				},
+			},
+			Spec: apiextensionsv1.CustomResourceDefinitionSpec{
+				Group: "infrastructure.cluster.x-k8s.io",
+				Names: apiextensionsv1.CustomResourceDefinitionNames{
+					Kind:     "EnvtestMachine",
+					ListKind: "EnvtestMachineList",
+					Plural:   "envtestmachines",
+					Singular: "envtestmachine",
+				},
+				Scope: apiextensionsv1.NamespaceScoped,
+				Versions: []apiextensionsv1.CustomResourceDefinitionVersion{
+					{Name: "v1alpha3", Served: true, Storage: true},
+				},
+				Validation: &apiextensionsv1.CustomResourceValidation{
+					OpenAPIV3Schema: &apiextensionsv1.JSONSchemaProps{
+						Type:                   "object",
+						XPreserveUnknownFields: pointer.BoolPtr(true),
+					},
+				},
+				PreserveUnknownFields: pointer.BoolPtr(false),
+				Conversion: &apiextensionsv1.CustomResourceConversion{
+					Strategy: apiextensionsv1.WebhookConverter,
+					WebhookClientConfig: &apiextensionsv1.WebhookClientConfig{
+						Service: &apiextensionsv1.ServiceReference{
+							Namespace: "default",
+							Name:      "envtest-webhook-service",
+						},
+						CABundle: []byte("Cg=="),
+					},
+					ConversionReviewVersions: []string{"v1beta1"},
+				},
+			},
+		}
+		Expect(c.Create(ctx, crd)).To(Succeed())
+		defer func() {
+			Expect(c.Delete(ctx, crd)).To(Succeed())
+		}()
+
+		endpoints := &corev1.Endpoints{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "default",
+				Name:      "envtest-webhook-service",
+			},
+		}
+		Expect(c.Create(ctx, endpoints)).To(Succeed())
+		defer func() {
+			Expect(c.Delete(ctx, endpoints)).To(Succeed())
+		}()
+
+		err := clusterClient.ConversionWebhooks().Check(ctx)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("does not have ready endpoints"))
+	})
+})
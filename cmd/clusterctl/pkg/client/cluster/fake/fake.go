@@ -0,0 +1,60 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package fake implements fakes for the clusterctl cluster library, so tools integrating with clusterctl can unit
+// test their integrations without a management cluster.
+package fake
+
+import (
+	"context"
+	"time"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/client/cluster"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/client/config"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/internal/test"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+var (
+	// Scheme is the scheme used by the fake Proxy; it includes the client-go types, the Cluster API and clusterctl
+	// types, the CRD types and the types of a set of fake providers.
+	Scheme = test.FakeScheme
+)
+
+// Proxy is a cluster.Proxy backed by a fake controller-runtime client, that can be initialized with a set of objects
+// using WithObjs, or with an already initialized management cluster using WithProviderInventory.
+type Proxy = test.FakeProxy
+
+// NewProxy returns a new fake Proxy for an empty management cluster.
+func NewProxy() *Proxy {
+	return test.NewFakeProxy()
+}
+
+// NewClient returns a cluster.Client working on the given proxy; the client does not wait for objects to reach the
+// desired state, because there are no controllers acting on the objects of a fake Proxy.
+func NewClient(proxy cluster.Proxy, configClient config.Client, options ...cluster.Option) cluster.Client {
+	options = append([]cluster.Option{
+		cluster.InjectProxy(proxy),
+		cluster.InjectObjectWaiter(NoopObjectWaiter),
+	}, options...)
+	return cluster.New("", configClient, options...)
+}
+
+// NoopObjectWaiter is a cluster.ObjectWaiter that returns immediately.
+func NoopObjectWaiter(_ context.Context, _ schema.GroupVersionKind, _ client.ObjectKey, _ time.Duration, _ cluster.ObjectConditionFunc) error {
+	return nil
+}
@@ -0,0 +1,57 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fake
+
+import (
+	"context"
+	"testing"
+
+	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/client/config"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/internal/test"
+)
+
+func TestNewClient(t *testing.T) {
+	proxy := NewProxy().
+		WithProviderInventory("cluster-api", clusterctlv1.CoreProviderType, "v1.0.0", "capi-system", "")
+
+	configClient, err := config.New("", config.InjectReader(test.NewFakeReader()))
+	if err != nil {
+		t.Fatalf("failed to create the config client: %v", err)
+	}
+
+	c := NewClient(proxy, configClient)
+	if err := c.ProviderInventory().EnsureCustomResourceDefinitions(context.Background()); err != nil {
+		t.Fatalf("EnsureCustomResourceDefinitions() error = %v", err)
+	}
+
+	providers, err := c.ProviderInventory().List(context.Background())
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(providers.Items) != 1 || providers.Items[0].Name != "cluster-api" {
+		t.Errorf("got %v, want the cluster-api provider", providers.Items)
+	}
+
+	name, err := c.ProviderInventory().GetDefaultProviderName(context.Background(), clusterctlv1.CoreProviderType)
+	if err != nil {
+		t.Fatalf("GetDefaultProviderName() error = %v", err)
+	}
+	if name != "cluster-api" {
+		t.Errorf("got default core provider %q, want %q", name, "cluster-api")
+	}
+}
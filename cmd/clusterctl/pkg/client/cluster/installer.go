@@ -17,12 +17,24 @@ limitations under the License.
 package cluster
 
 import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
 	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apimachinery/pkg/util/version"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
 	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/client/config"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/client/repository"
+	logf "sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/log"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/metrics"
 )
 
 // ProviderInstaller defines methods for enforcing consistency rules for provider installation.
@@ -32,8 +44,9 @@ type ProviderInstaller interface {
 	// before actually starting the installation of new providers.
 	Add(repository.Components)
 
-	// Install performs the installation of the providers ready in the install queue.
-	Install() ([]repository.Components, error)
+	// Install performs the installation of the providers ready in the install queue; providers are installed
+	// after the providers they depend on, as declared in the provider metadata.
+	Install(ctx context.Context) ([]repository.Components, error)
 
 	// Validate performs steps to validate a management cluster by looking at the current state and the providers in the queue.
 	// The following checks are performed in order to ensure a fully operational cluster:
@@ -42,10 +55,16 @@ type ProviderInstaller interface {
 	// - Providers must combine in valid management groups
 	//   - All the providers must belong to one/only one management groups
 	//   - All the providers in a management group must support the same API Version of Cluster API (contract)
-	Validate() error
+	// - The version of the providers must be allowed by the version policy of the management cluster
+	// - The providers required by the providers in the queue must be already installed or part of the queue
+	Validate(ctx context.Context) error
 
 	// Images returns the list of images required for installing the providers ready in the install queue.
 	Images() []string
+
+	// Permissions returns the permissions granted to the providers ready in the install queue by the RBAC rules
+	// in their components, so they can be reviewed before the installation.
+	Permissions() ([]ProviderPermissions, error)
 }
 
 // providerInstaller implements ProviderInstaller
@@ -56,6 +75,9 @@ type providerInstaller struct {
 	providerComponents      ComponentsClient
 	providerInventory       InventoryClient
 	installQueue            []repository.Components
+	contracts               map[string]string
+	progress                progress
+	log                     logr.Logger
 }
 
 var _ ProviderInstaller = &providerInstaller{}
@@ -64,33 +86,126 @@ func (i *providerInstaller) Add(components repository.Components) {
 	i.installQueue = append(i.installQueue, components)
 }
 
-func (i *providerInstaller) Install() ([]repository.Components, error) {
-	ret := make([]repository.Components, 0, len(i.installQueue))
-	for _, components := range i.installQueue {
-		if err := installComponentsAndUpdateInventory(components, i.providerComponents, i.providerInventory); err != nil {
+func (i *providerInstaller) Install(ctx context.Context) (_ []repository.Components, reterr error) {
+	i.progress.started("", "", len(i.installQueue))
+	defer func() {
+		i.progress.completed("", "", 0, 0, reterr)
+	}()
+
+	// Sorts the install queue, so the providers required by other providers are installed first.
+	installQueue, err := i.sortInstallQueue(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := make([]repository.Components, 0, len(installQueue))
+	for idx, components := range installQueue {
+		// Stops before installing the next provider if the operation has been cancelled, so no provider is left half installed.
+		if err := ctx.Err(); err != nil {
+			return nil, errors.Wrap(err, "installation of the providers interrupted")
+		}
+		provider := components.InventoryObject()
+		i.progress.started(ProgressStepInstallProvider, provider.InstanceName(), 0)
+
+		contract, err := i.getProviderContract(ctx, provider)
+		if err != nil {
+			i.progress.completed(ProgressStepInstallProvider, provider.InstanceName(), idx, len(installQueue), err)
+			return nil, err
+		}
+
+		start := time.Now()
+		if err := installComponentsAndUpdateInventory(ctx, components, contract, i.providerComponents, i.providerInventory, nil); err != nil {
+			i.progress.completed(ProgressStepInstallProvider, provider.InstanceName(), idx, len(installQueue), err)
 			return nil, err
 		}
+		metrics.ProviderInstallDuration.WithLabelValues(components.Name(), string(components.Type())).Observe(time.Since(start).Seconds())
+		i.progress.completed(ProgressStepInstallProvider, provider.InstanceName(), idx+1, len(installQueue), nil)
 
 		ret = append(ret, components)
 	}
 	return ret, nil
 }
 
-func installComponentsAndUpdateInventory(components repository.Components, providerComponents ComponentsClient, providerInventory InventoryClient) error {
-	if err := providerComponents.Create(components); err != nil {
+// installComponentsAndUpdateInventory installs the provider components and records the operation, together with the
+// API Version of Cluster API (contract) supported by the provider, in the inventory; previous is the inventory item
+// of the provider instance being upgraded, if any, and it is used for preserving the provider history.
+func installComponentsAndUpdateInventory(ctx context.Context, components repository.Components, contract string, providerComponents ComponentsClient, providerInventory InventoryClient, previous *clusterctlv1.Provider) error {
+	if err := providerComponents.Create(ctx, components); err != nil {
 		return err
 	}
 
-	if err := providerInventory.Create(components.InventoryObject()); err != nil {
+	inventoryObject := components.InventoryObject()
+	inventoryObject.History = providerHistory(previous, newProviderOperation(components, previous))
+	inventoryObject.Status = newProviderStatus(inventoryObject.Version, contract)
+
+	if err := providerInventory.Create(ctx, inventoryObject); err != nil {
 		return err
 	}
 
 	return nil
 }
 
-func (i *providerInstaller) Validate() error {
+func (i *providerInstaller) Validate(ctx context.Context) error {
+	log := i.log
+
 	// Get the list of providers currently in the cluster.
-	providerList, err := i.providerInventory.List()
+	providerList, err := i.providerInventory.List(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Checks if the version of the providers in the installQueue are allowed by the version policy of the management cluster.
+	versionPolicy, err := i.providerInventory.GetVersionPolicy(ctx)
+	if err != nil {
+		return err
+	}
+	for _, components := range i.installQueue {
+		provider := components.InventoryObject()
+		providerVersion, err := version.ParseSemantic(provider.Version)
+		if err != nil {
+			return errors.Wrapf(err, "failed to parse version for the %s provider", provider.InstanceName())
+		}
+
+		allowed, err := versionPolicy.IsVersionAllowed(provider.Name, providerVersion)
+		if err != nil {
+			return err
+		}
+		if !allowed {
+			return errors.Errorf("installing provider %q is not allowed: version %s is not permitted by the version policy of the management cluster", components.Name(), provider.Version)
+		}
+		log.V(5).Info("Version allowed by the version policy", "Provider", provider.InstanceName(), "Version", provider.Version)
+	}
+
+	// Gets the namespaces existing in the cluster, used for checking overlaps between providers watching namespaces by label selector.
+	namespaces, err := listNamespaces(ctx, i.proxy)
+	if err != nil {
+		return err
+	}
+
+	// If the inventory is scoped to a set of namespaces, checks the providers in the installQueue are not going to
+	// interfere with the providers of other users of the management cluster.
+	if scope := i.providerInventory.Namespaces(); len(scope) > 0 {
+		allProviders, err := i.providerInventory.ListAll(ctx)
+		if err != nil {
+			return err
+		}
+		var outOfScope []clusterctlv1.Provider
+		for _, provider := range allProviders.Items {
+			if !sets.NewString(scope...).Has(provider.Namespace) {
+				outOfScope = append(outOfScope, provider)
+			}
+		}
+		for _, components := range i.installQueue {
+			if err := checkInventoryScope(components.InventoryObject(), scope, outOfScope, namespaces); err != nil {
+				return errors.Wrapf(err, "installing provider %q can interfere with providers outside of the inventory namespaces", components.Name())
+			}
+			log.V(5).Info("Provider does not interfere with providers outside of the inventory namespaces", "Provider", components.Name(), "InventoryNamespaces", scope)
+		}
+	}
+
+	// Gets the objects of the providers already installed in the namespaces shared with the providers in the installQueue,
+	// used for checking name collisions.
+	objects, err := i.getSharedNamespaceObjects(ctx, providerList)
 	if err != nil {
 		return err
 	}
@@ -99,67 +214,85 @@ func (i *providerInstaller) Validate() error {
 	// During this operation following checks are performed:
 	// - There must be only one instance of the same provider per namespace
 	// - Instances of the same provider must not be fighting for objects (no watching overlap)
+	// - Providers must not have objects with the same name of the objects of other providers
 	for _, components := range i.installQueue {
-		if providerList, err = simulateInstall(providerList, components); err != nil {
+		if providerList, err = simulateInstall(providerList, components, namespaces, objects); err != nil {
 			return errors.Wrapf(err, "installing provider %q can lead to a non functioning management cluster", components.Name())
 		}
+		log.V(5).Info("Provider does not overlap with other instances", "Provider", components.Name(), "WatchingNamespace", components.WatchingNamespace())
+	}
+
+	// Checks if the dependencies of the providers in the installQueue are satisfied by the providers already installed
+	// in the cluster or by other providers in the installQueue.
+	for _, components := range i.installQueue {
+		if err := i.checkDependencies(ctx, components, providerList); err != nil {
+			return err
+		}
+		log.V(5).Info("Provider dependencies satisfied", "Provider", components.Name())
+	}
+
+	// Checks there are no circular dependencies among the providers in the installQueue, otherwise it is not possible
+	// to define the order in which they should be installed.
+	if _, err := i.sortInstallQueue(ctx); err != nil {
+		return err
 	}
 
 	// Now that the provider list contains all the providers that are scheduled for install, gets the resulting management groups.
 	// During this operation following check is performed:
 	// - Providers must combine in valid management groups
 	//   - All the providers must belong to one/only one management group
-	managementGroups, err := deriveManagementGroups(providerList)
+	managementGroups, err := deriveManagementGroups(providerList, namespaces)
 	if err != nil {
 		return err
 	}
 
 	// Checks if all the providers supports the same API Version of Cluster API (contract) of the corresponding management group.
-	providerInstanceContracts := map[string]string{}
 	for _, components := range i.installQueue {
 		provider := components.InventoryObject()
 
 		// Gets the management group the providers belongs to, and then retrieve the API Version of Cluster API (contract)
 		// all the providers in the management group must support.
 		managementGroup := managementGroups.FindManagementGroupByProviderInstanceName(provider.InstanceName())
-		managementGroupContract, err := i.getProviderContract(providerInstanceContracts, managementGroup.CoreProvider)
+		managementGroupContract, err := i.getProviderContract(ctx, managementGroup.CoreProvider)
 		if err != nil {
 			return err
 		}
 
 		// Gets the API Version of Cluster API (contract) the provider support and compare it with the  management group contract.
-		providerContract, err := i.getProviderContract(providerInstanceContracts, provider)
+		providerContract, err := i.getProviderContract(ctx, provider)
 		if err != nil {
 			return err
 		}
 		if providerContract != managementGroupContract {
 			return errors.Errorf("installing provider %q can lead to a non functioning management cluster: the target version for the provider supports the %s API Version of Cluster API (contract), while the management group is using %s", components.Name(), providerContract, managementGroupContract)
 		}
+		log.V(5).Info("Provider contract matches the management group", "Provider", provider.InstanceName(), "ManagementGroup", managementGroup.CoreProvider.InstanceName(), "Contract", providerContract)
 	}
 	return nil
 }
 
 // getProviderContract returns the API Version of Cluster API (contract) for a provider instance.
-func (i *providerInstaller) getProviderContract(providerInstanceContracts map[string]string, provider clusterctlv1.Provider) (string, error) {
+func (i *providerInstaller) getProviderContract(ctx context.Context, provider clusterctlv1.Provider) (string, error) {
+	if i.contracts == nil {
+		i.contracts = map[string]string{}
+	}
+
 	// If the contract for the provider instance is already known, return it.
-	if contract, ok := providerInstanceContracts[provider.InstanceName()]; ok {
+	if contract, ok := i.contracts[provider.InstanceName()]; ok {
 		return contract, nil
 	}
 
-	// Otherwise get the contract for the providers instance.
-
-	// Gets the providers metadata.
-	configRepository, err := i.configClient.Providers().Get(provider.Name)
-	if err != nil {
-		return "", err
+	// If the contract for the provider version is already recorded in the inventory, return it without fetching
+	// the provider metadata.
+	if provider.Status.Contract != "" && provider.Status.ObservedVersion == provider.Version {
+		i.contracts[provider.InstanceName()] = provider.Status.Contract
+		return provider.Status.Contract, nil
 	}
 
-	providerRepository, err := i.repositoryClientFactory(configRepository, i.configClient.Variables())
-	if err != nil {
-		return "", err
-	}
+	// Otherwise get the contract for the providers instance.
 
-	latestMetadata, err := providerRepository.Metadata(provider.Version).Get()
+	// Gets the providers metadata.
+	latestMetadata, err := i.getProviderMetadata(ctx, provider)
 	if err != nil {
 		return "", err
 	}
@@ -175,12 +308,141 @@ func (i *providerInstaller) getProviderContract(providerInstanceContracts map[st
 		return "", errors.Errorf("invalid provider metadata: version %s for the provider %s does not match any release series", provider.Version, provider.InstanceName())
 	}
 
-	providerInstanceContracts[provider.InstanceName()] = releaseSeries.Contract
+	i.contracts[provider.InstanceName()] = releaseSeries.Contract
 	return releaseSeries.Contract, nil
 }
 
-// simulateInstall adds a provider to the list of providers in a cluster (without installing it).
-func simulateInstall(providerList *clusterctlv1.ProviderList, components repository.Components) (*clusterctlv1.ProviderList, error) {
+// getProviderMetadata returns the metadata for a provider instance.
+func (i *providerInstaller) getProviderMetadata(ctx context.Context, provider clusterctlv1.Provider) (*clusterctlv1.Metadata, error) {
+	configRepository, err := i.configClient.Providers().Get(provider.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	providerRepository, err := i.repositoryClientFactory(configRepository, i.configClient.Variables())
+	if err != nil {
+		return nil, err
+	}
+
+	return providerRepository.Metadata(provider.Version).Get(ctx)
+}
+
+// checkDependencies checks if the dependencies declared in the metadata of a provider are satisfied by
+// the providers in the list.
+func (i *providerInstaller) checkDependencies(ctx context.Context, components repository.Components, providerList *clusterctlv1.ProviderList) error {
+	metadata, err := i.getProviderMetadata(ctx, components.InventoryObject())
+	if err != nil {
+		return err
+	}
+
+	for _, dependency := range metadata.Dependencies {
+		satisfied := false
+		for _, provider := range providerList.Items {
+			if dependency.IsSatisfiedBy(provider.Name, provider.GetProviderType()) {
+				satisfied = true
+				break
+			}
+		}
+		if !satisfied {
+			return errors.Errorf("installing provider %q requires the %q provider, that is neither installed in the management cluster nor going to be installed", components.Name(), dependency.Name)
+		}
+	}
+	return nil
+}
+
+// sortInstallQueue sorts the install queue so each provider is installed after the providers it depends on;
+// the order of the providers without dependencies among each other is preserved.
+func (i *providerInstaller) sortInstallQueue(ctx context.Context) ([]repository.Components, error) {
+	dependencies := make([][]clusterctlv1.ProviderDependency, len(i.installQueue))
+	for n, components := range i.installQueue {
+		metadata, err := i.getProviderMetadata(ctx, components.InventoryObject())
+		if err != nil {
+			return nil, err
+		}
+		dependencies[n] = metadata.Dependencies
+	}
+
+	// dependsOnQueued returns true if the provider in the install queue at position n depends on a provider still in the queue.
+	queued := make([]bool, len(i.installQueue))
+	for n := range queued {
+		queued[n] = true
+	}
+	dependsOnQueued := func(n int) bool {
+		for _, dependency := range dependencies[n] {
+			for m, components := range i.installQueue {
+				if m != n && queued[m] && dependency.IsSatisfiedBy(components.Name(), components.Type()) {
+					return true
+				}
+			}
+		}
+		return false
+	}
+
+	ret := make([]repository.Components, 0, len(i.installQueue))
+	for len(ret) < len(i.installQueue) {
+		next := -1
+		for n := range i.installQueue {
+			if queued[n] && !dependsOnQueued(n) {
+				next = n
+				break
+			}
+		}
+		if next == -1 {
+			var names []string
+			for n, components := range i.installQueue {
+				if queued[n] {
+					names = append(names, components.Name())
+				}
+			}
+			return nil, errors.Errorf("failed to sort the providers to be installed: there is a circular dependency among the %s providers", strings.Join(names, ", "))
+		}
+		queued[next] = false
+		ret = append(ret, i.installQueue[next])
+	}
+	return ret, nil
+}
+
+// componentObjects maps the objects of the providers, identified by group kind, namespace and name, to the name
+// of the provider they belong to.
+type componentObjects map[string]string
+
+func componentObjectKey(obj unstructured.Unstructured) string {
+	return fmt.Sprintf("%s %s/%s", obj.GroupVersionKind().GroupKind(), obj.GetNamespace(), obj.GetName())
+}
+
+// getSharedNamespaceObjects returns the objects of the providers already installed in the namespaces where the providers
+// in the installQueue are going to be installed together with other providers, e.g. when installing all the providers
+// in a single namespace; the cluster-wide objects of the providers are returned as well.
+func (i *providerInstaller) getSharedNamespaceObjects(ctx context.Context, providerList *clusterctlv1.ProviderList) (componentObjects, error) {
+	objects := componentObjects{}
+
+	sharedNamespaces := sets.NewString()
+	for _, components := range i.installQueue {
+		provider := components.InventoryObject()
+		for _, p := range providerList.Items {
+			if p.Namespace == provider.Namespace && p.Name != provider.Name {
+				sharedNamespaces.Insert(provider.Namespace)
+			}
+		}
+	}
+
+	for _, namespace := range sharedNamespaces.List() {
+		objs, err := i.proxy.ListResources(ctx, namespace, map[string]string{clusterctlv1.ClusterctlLabelName: ""})
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to list the objects of the providers installed in the %q namespace", namespace)
+		}
+		for _, obj := range objs {
+			if name, ok := obj.GetLabels()[clusterv1.ProviderLabelName]; ok {
+				objects[componentObjectKey(obj)] = name
+			}
+		}
+	}
+	return objects, nil
+}
+
+// simulateInstall adds a provider to the list of providers in a cluster (without installing it); the objects of the
+// provider are added to the objects of the providers in the cluster.
+func simulateInstall(providerList *clusterctlv1.ProviderList, components repository.Components, namespaces []corev1.Namespace, objects componentObjects) (*clusterctlv1.ProviderList, error) {
 	provider := components.InventoryObject()
 
 	existingInstances := providerList.FilterByName(provider.Name)
@@ -197,8 +459,27 @@ func simulateInstall(providerList *clusterctlv1.ProviderList, components reposit
 	// If we are going to install an instance of a provider watching objects in namespaces already controlled by other providers
 	// then there will be providers fighting for objects...
 	for _, i := range existingInstances {
-		if i.HasWatchingOverlapWith(provider) {
-			return providerList, errors.Errorf("the new instance of the %q provider is going to watch for objects in the namespace %q that is already controlled by other providers", provider.Name, provider.WatchedNamespace)
+		if i.HasWatchingOverlapWith(provider, namespaces) {
+			return providerList, errors.Errorf("the new instance of the %q provider is going to watch for objects in namespaces that are already controlled by the %s provider", provider.Name, i.InstanceName())
+		}
+	}
+
+	// Name collision check:
+	// If the provider has objects with the same name of the objects of another provider, e.g. because they are installed in
+	// the same namespace, installing it is going to overwrite the objects of the other provider.
+	// NB. Instances of the same provider share the cluster-wide objects, e.g. the CRDs.
+	for _, obj := range components.Objs() {
+		if obj.GetKind() == "Namespace" {
+			continue
+		}
+		key := componentObjectKey(obj)
+		if owner, ok := objects[key]; ok && owner != provider.Name {
+			return providerList, errors.Errorf("the %s %q is already defined by the %q provider", obj.GetKind(), obj.GetName(), owner)
+		}
+	}
+	for _, obj := range components.Objs() {
+		if obj.GetKind() != "Namespace" {
+			objects[componentObjectKey(obj)] = provider.Name
 		}
 	}
 
@@ -207,6 +488,49 @@ func simulateInstall(providerList *clusterctlv1.ProviderList, components reposit
 	return providerList, nil
 }
 
+// checkInventoryScope checks that a provider installed in an inventory scoped to a set of namespaces does not interfere
+// with the providers outside of the scope, e.g. installed by other users of the management cluster: the provider must
+// be installed in, and watch only, namespaces of the scope, and the providers outside of the scope must not watch any
+// namespace of the scope.
+func checkInventoryScope(provider clusterctlv1.Provider, scope []string, outOfScope []clusterctlv1.Provider, namespaces []corev1.Namespace) error {
+	scopeSet := sets.NewString(scope...)
+	if !scopeSet.Has(provider.Namespace) {
+		return errors.Errorf("the provider is going to be installed in the %q namespace", provider.Namespace)
+	}
+
+	if provider.WatchesAllNamespaces() {
+		return errors.New("the provider is going to watch for objects in all the namespaces")
+	}
+	for _, n := range provider.GetWatchedNamespaces() {
+		if !scopeSet.Has(n) {
+			return errors.Errorf("the provider is going to watch for objects in the %q namespace", n)
+		}
+	}
+	for _, n := range namespaces {
+		if !scopeSet.Has(n.Name) && provider.WatchesNamespace(n) {
+			return errors.Errorf("the provider is going to watch for objects in the %q namespace", n.Name)
+		}
+	}
+
+	// Namespaces of the scope not existing yet are checked by name only.
+	existing := map[string]corev1.Namespace{}
+	for _, n := range namespaces {
+		existing[n.Name] = n
+	}
+	for _, name := range scope {
+		n, ok := existing[name]
+		if !ok {
+			n = corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name}}
+		}
+		for _, other := range outOfScope {
+			if other.WatchesNamespace(n) {
+				return errors.Errorf("the %q namespace is already watched by the %s provider", name, other.InstanceName())
+			}
+		}
+	}
+	return nil
+}
+
 func (i *providerInstaller) Images() []string {
 	ret := sets.NewString()
 	for _, components := range i.installQueue {
@@ -215,12 +539,26 @@ func (i *providerInstaller) Images() []string {
 	return ret.List()
 }
 
-func newProviderInstaller(configClient config.Client, repositoryClientFactory RepositoryClientFactory, proxy Proxy, providerMetadata InventoryClient, providerComponents ComponentsClient) *providerInstaller {
+func (i *providerInstaller) Permissions() ([]ProviderPermissions, error) {
+	ret := make([]ProviderPermissions, 0, len(i.installQueue))
+	for _, components := range i.installQueue {
+		permissions, err := componentsPermissions(components)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get the permissions of the %q provider", components.Name())
+		}
+		ret = append(ret, permissions)
+	}
+	return ret, nil
+}
+
+func newProviderInstaller(configClient config.Client, repositoryClientFactory RepositoryClientFactory, proxy Proxy, providerMetadata InventoryClient, providerComponents ComponentsClient, progressReporter ProgressReporter) *providerInstaller {
 	return &providerInstaller{
 		configClient:            configClient,
 		repositoryClientFactory: repositoryClientFactory,
 		proxy:                   proxy,
 		providerComponents:      providerComponents,
 		providerInventory:       providerMetadata,
+		progress:                newProgress(progressReporter, ProgressOperationInstall),
+		log:                     logf.Log,
 	}
 }
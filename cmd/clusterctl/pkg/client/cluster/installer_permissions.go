@@ -0,0 +1,236 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/client/repository"
+)
+
+// PermissionScopeCluster is the scope of the permissions granted on the cluster-scoped resources and on the
+// namespaced resources in all the namespaces.
+const PermissionScopeCluster = "cluster"
+
+// ProviderPermissions reports the permissions granted to a provider by the RBAC rules in its components.
+type ProviderPermissions struct {
+	// Provider is the instance name of the provider, e.g. capi-system/cluster-api.
+	Provider string
+
+	// Permissions are the permissions granted to the provider, sorted by scope, API group and resource.
+	Permissions []Permission
+}
+
+// Permission reports the verbs granted on a resource.
+type Permission struct {
+	// Scope is PermissionScopeCluster for the permissions granted in all the namespaces, or the namespace
+	// the permissions are granted in.
+	Scope string
+
+	// APIGroup is the API group of the resource; it is empty for the core API group and for the non resource URLs.
+	APIGroup string
+
+	// Resource is the resource, or the non resource URL, the permission is granted on.
+	Resource string
+
+	// ResourceNames restricts the permission to the given objects, if any.
+	ResourceNames []string
+
+	// Verbs are the verbs granted on the resource.
+	Verbs []string
+
+	// Roles are the ClusterRoles and Roles granting the permission, e.g. ClusterRole/capi-manager-role.
+	Roles []string
+}
+
+// SecretsAccess returns true if the permission grants access to the Secrets.
+func (p Permission) SecretsAccess() bool {
+	return (p.APIGroup == "" || p.APIGroup == rbacv1.APIGroupAll) && (p.Resource == "secrets" || p.Resource == rbacv1.ResourceAll)
+}
+
+// Wildcard returns true if the permission is granted with wildcards on the API groups, the resources or the verbs.
+func (p Permission) Wildcard() bool {
+	return p.APIGroup == rbacv1.APIGroupAll || p.Resource == rbacv1.ResourceAll || sets.NewString(p.Verbs...).Has(rbacv1.VerbAll)
+}
+
+// componentsPermissions reports the permissions granted by the ClusterRoles and the Roles in the provider components.
+// The scope of the permissions depends on how roles are bound: ClusterRoles bound by a ClusterRoleBinding, or not
+// bound in the components at all, grant permissions on the whole cluster, while ClusterRoles bound by RoleBindings
+// and Roles grant permissions only in the namespace of the bindings.
+func componentsPermissions(components repository.Components) (ProviderPermissions, error) {
+	clusterRoles := map[string][]rbacv1.PolicyRule{}
+	roles := map[string][]rbacv1.PolicyRule{}
+	clusterBound := sets.NewString()
+	namespaceBound := map[string]sets.String{}
+
+	for _, obj := range components.Objs() {
+		switch obj.GetKind() {
+		case "ClusterRole":
+			role := &rbacv1.ClusterRole{}
+			if err := convertRBACObject(obj, role); err != nil {
+				return ProviderPermissions{}, err
+			}
+			clusterRoles[role.Name] = role.Rules
+		case "Role":
+			role := &rbacv1.Role{}
+			if err := convertRBACObject(obj, role); err != nil {
+				return ProviderPermissions{}, err
+			}
+			roles[role.Namespace+"/"+role.Name] = role.Rules
+		case "ClusterRoleBinding":
+			binding := &rbacv1.ClusterRoleBinding{}
+			if err := convertRBACObject(obj, binding); err != nil {
+				return ProviderPermissions{}, err
+			}
+			clusterBound.Insert(binding.RoleRef.Name)
+		case "RoleBinding":
+			binding := &rbacv1.RoleBinding{}
+			if err := convertRBACObject(obj, binding); err != nil {
+				return ProviderPermissions{}, err
+			}
+			// RoleBindings to Roles are not tracked, given that Roles can only grant permissions in their own namespace.
+			if binding.RoleRef.Kind == "ClusterRole" {
+				if _, ok := namespaceBound[binding.RoleRef.Name]; !ok {
+					namespaceBound[binding.RoleRef.Name] = sets.NewString()
+				}
+				namespaceBound[binding.RoleRef.Name].Insert(binding.Namespace)
+			}
+		}
+	}
+
+	permissions := newPermissionsAggregator()
+	for name, rules := range clusterRoles {
+		source := "ClusterRole/" + name
+		namespaces, ok := namespaceBound[name]
+		if clusterBound.Has(name) || !ok {
+			permissions.add(PermissionScopeCluster, source, rules)
+			continue
+		}
+		for _, namespace := range namespaces.List() {
+			permissions.add(namespace, source, rules)
+		}
+	}
+	for key, rules := range roles {
+		namespace := strings.SplitN(key, "/", 2)[0]
+		permissions.add(namespace, "Role/"+key, rules)
+	}
+
+	provider := components.InventoryObject()
+	return ProviderPermissions{
+		Provider:    provider.InstanceName(),
+		Permissions: permissions.list(),
+	}, nil
+}
+
+func convertRBACObject(obj unstructured.Unstructured, into interface{}) error {
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, into); err != nil {
+		return errors.Wrapf(err, "failed to convert %s %q", obj.GetKind(), obj.GetName())
+	}
+	return nil
+}
+
+// permissionsAggregator merges the verbs granted on the same resource by different rules and roles.
+type permissionsAggregator struct {
+	permissions map[string]*permissionEntry
+}
+
+type permissionEntry struct {
+	permission Permission
+	verbs      sets.String
+	roles      sets.String
+}
+
+func newPermissionsAggregator() *permissionsAggregator {
+	return &permissionsAggregator{
+		permissions: map[string]*permissionEntry{},
+	}
+}
+
+// add expands the rules into a permission for each API group and resource, or non resource URL.
+func (a *permissionsAggregator) add(scope, source string, rules []rbacv1.PolicyRule) {
+	for _, rule := range rules {
+		for _, group := range rule.APIGroups {
+			for _, resource := range rule.Resources {
+				a.addPermission(scope, source, group, resource, rule.ResourceNames, rule.Verbs)
+			}
+		}
+		// Non resource URLs are meaningful only for cluster-wide permissions.
+		if scope == PermissionScopeCluster {
+			for _, url := range rule.NonResourceURLs {
+				a.addPermission(scope, source, "", url, nil, rule.Verbs)
+			}
+		}
+	}
+}
+
+func (a *permissionsAggregator) addPermission(scope, source, group, resource string, resourceNames, verbs []string) {
+	var names []string
+	if len(resourceNames) > 0 {
+		names = sets.NewString(resourceNames...).List()
+	}
+	key := fmt.Sprintf("%s|%s|%s|%s", scope, group, resource, strings.Join(names, ","))
+	entry, ok := a.permissions[key]
+	if !ok {
+		entry = &permissionEntry{
+			permission: Permission{
+				Scope:         scope,
+				APIGroup:      group,
+				Resource:      resource,
+				ResourceNames: names,
+			},
+			verbs: sets.NewString(),
+			roles: sets.NewString(),
+		}
+		a.permissions[key] = entry
+	}
+	entry.verbs.Insert(verbs...)
+	entry.roles.Insert(source)
+}
+
+// list returns the permissions sorted by scope, with cluster-wide permissions first, API group and resource.
+func (a *permissionsAggregator) list() []Permission {
+	ret := make([]Permission, 0, len(a.permissions))
+	for _, entry := range a.permissions {
+		p := entry.permission
+		p.Verbs = entry.verbs.List()
+		p.Roles = entry.roles.List()
+		ret = append(ret, p)
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		if ret[i].Scope != ret[j].Scope {
+			if ret[i].Scope == PermissionScopeCluster || ret[j].Scope == PermissionScopeCluster {
+				return ret[i].Scope == PermissionScopeCluster
+			}
+			return ret[i].Scope < ret[j].Scope
+		}
+		if ret[i].APIGroup != ret[j].APIGroup {
+			return ret[i].APIGroup < ret[j].APIGroup
+		}
+		if ret[i].Resource != ret[j].Resource {
+			return ret[i].Resource < ret[j].Resource
+		}
+		return strings.Join(ret[i].ResourceNames, ",") < strings.Join(ret[j].ResourceNames, ",")
+	})
+	return ret
+}
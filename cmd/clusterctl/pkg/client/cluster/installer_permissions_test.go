@@ -0,0 +1,109 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"reflect"
+	"testing"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
+)
+
+func Test_componentsPermissions(t *testing.T) {
+	managerRules := []rbacv1.PolicyRule{
+		{APIGroups: []string{""}, Resources: []string{"secrets", "events"}, Verbs: []string{"get", "list"}},
+		{APIGroups: []string{""}, Resources: []string{"events"}, Verbs: []string{"create"}},
+		{NonResourceURLs: []string{"/metrics"}, Verbs: []string{"get"}},
+	}
+	leaderRules := []rbacv1.PolicyRule{
+		{APIGroups: []string{"*"}, Resources: []string{"configmaps"}, Verbs: []string{"*"}},
+	}
+
+	components := withObjs(newFakeComponents("infra", clusterctlv1.InfrastructureProviderType, "v1.0.0", "ns1", ""),
+		toRBACUnstructured(t, &rbacv1.ClusterRole{
+			TypeMeta:   rbacTypeMeta("ClusterRole"),
+			ObjectMeta: rbacObjectMeta("", "manager-role"),
+			Rules:      managerRules,
+		}),
+		toRBACUnstructured(t, &rbacv1.ClusterRoleBinding{
+			TypeMeta:   rbacTypeMeta("ClusterRoleBinding"),
+			ObjectMeta: rbacObjectMeta("", "manager-rolebinding"),
+			RoleRef:    rbacv1.RoleRef{Kind: "ClusterRole", Name: "manager-role"},
+		}),
+		toRBACUnstructured(t, &rbacv1.ClusterRole{
+			TypeMeta:   rbacTypeMeta("ClusterRole"),
+			ObjectMeta: rbacObjectMeta("", "viewer-role"),
+			Rules:      []rbacv1.PolicyRule{{APIGroups: []string{"apps"}, Resources: []string{"deployments"}, ResourceNames: []string{"b", "a"}, Verbs: []string{"get"}}},
+		}),
+		toRBACUnstructured(t, &rbacv1.RoleBinding{
+			TypeMeta:   rbacTypeMeta("RoleBinding"),
+			ObjectMeta: rbacObjectMeta("ns1", "viewer-rolebinding"),
+			RoleRef:    rbacv1.RoleRef{Kind: "ClusterRole", Name: "viewer-role"},
+		}),
+		toRBACUnstructured(t, &rbacv1.Role{
+			TypeMeta:   rbacTypeMeta("Role"),
+			ObjectMeta: rbacObjectMeta("ns1", "leader-election-role"),
+			Rules:      leaderRules,
+		}),
+	)
+
+	got, err := componentsPermissions(components)
+	if err != nil {
+		t.Fatalf("error = %v, want nil", err)
+	}
+
+	want := ProviderPermissions{
+		Provider: "ns1/infra",
+		Permissions: []Permission{
+			{Scope: PermissionScopeCluster, APIGroup: "", Resource: "/metrics", Verbs: []string{"get"}, Roles: []string{"ClusterRole/manager-role"}},
+			{Scope: PermissionScopeCluster, APIGroup: "", Resource: "events", Verbs: []string{"create", "get", "list"}, Roles: []string{"ClusterRole/manager-role"}},
+			{Scope: PermissionScopeCluster, APIGroup: "", Resource: "secrets", Verbs: []string{"get", "list"}, Roles: []string{"ClusterRole/manager-role"}},
+			{Scope: "ns1", APIGroup: "*", Resource: "configmaps", Verbs: []string{"*"}, Roles: []string{"Role/ns1/leader-election-role"}},
+			{Scope: "ns1", APIGroup: "apps", Resource: "deployments", ResourceNames: []string{"a", "b"}, Verbs: []string{"get"}, Roles: []string{"ClusterRole/viewer-role"}},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got = %+v, want %+v", got, want)
+	}
+
+	if !got.Permissions[2].SecretsAccess() || got.Permissions[1].SecretsAccess() {
+		t.Errorf("SecretsAccess() should be true only for the permissions on secrets")
+	}
+	if !got.Permissions[3].Wildcard() || got.Permissions[2].Wildcard() {
+		t.Errorf("Wildcard() should be true only for the permissions granted with wildcards")
+	}
+}
+
+func rbacTypeMeta(kind string) metav1.TypeMeta {
+	return metav1.TypeMeta{APIVersion: rbacv1.SchemeGroupVersion.String(), Kind: kind}
+}
+
+func rbacObjectMeta(namespace, name string) metav1.ObjectMeta {
+	return metav1.ObjectMeta{Namespace: namespace, Name: name}
+}
+
+func toRBACUnstructured(t *testing.T, obj runtime.Object) unstructured.Unstructured {
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		t.Fatalf("failed to convert %v to unstructured: %v", obj, err)
+	}
+	return unstructured.Unstructured{Object: content}
+}
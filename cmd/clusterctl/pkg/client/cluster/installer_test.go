@@ -17,9 +17,15 @@ limitations under the License.
 package cluster
 
 import (
+	"context"
+	"reflect"
 	"testing"
 
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
 	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/client/config"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/client/repository"
@@ -30,7 +36,10 @@ func Test_providerInstaller_Validate(t *testing.T) {
 	fakeReader := test.NewFakeReader().
 		WithProvider("core", clusterctlv1.CoreProviderType, "https://somewhere.com").
 		WithProvider("infra1", clusterctlv1.InfrastructureProviderType, "https://somewhere.com").
-		WithProvider("infra2", clusterctlv1.InfrastructureProviderType, "https://somewhere.com")
+		WithProvider("infra2", clusterctlv1.InfrastructureProviderType, "https://somewhere.com").
+		WithProvider("infra3", clusterctlv1.InfrastructureProviderType, "https://somewhere.com").
+		WithProvider("infra4", clusterctlv1.InfrastructureProviderType, "https://somewhere.com").
+		WithProvider("infra5", clusterctlv1.InfrastructureProviderType, "https://somewhere.com")
 
 	repositoryMap := map[string]repository.Repository{
 		"core": test.NewFakeRepository().
@@ -72,11 +81,42 @@ func Test_providerInstaller_Validate(t *testing.T) {
 					{Major: 2, Minor: 0, Contract: "v1alpha4"},
 				},
 			}),
+		"infra3": test.NewFakeRepository().
+			WithVersions("v1.0.0").
+			WithMetadata("v1.0.0", &clusterctlv1.Metadata{
+				ReleaseSeries: []clusterctlv1.ReleaseSeries{
+					{Major: 1, Minor: 0, Contract: "v1alpha3"},
+				},
+				Dependencies: []clusterctlv1.ProviderDependency{
+					{Name: "infra1", Type: clusterctlv1.InfrastructureProviderType},
+				},
+			}),
+		"infra4": test.NewFakeRepository().
+			WithVersions("v1.0.0").
+			WithMetadata("v1.0.0", &clusterctlv1.Metadata{
+				ReleaseSeries: []clusterctlv1.ReleaseSeries{
+					{Major: 1, Minor: 0, Contract: "v1alpha3"},
+				},
+				Dependencies: []clusterctlv1.ProviderDependency{
+					{Name: "infra5", Type: clusterctlv1.InfrastructureProviderType},
+				},
+			}),
+		"infra5": test.NewFakeRepository().
+			WithVersions("v1.0.0").
+			WithMetadata("v1.0.0", &clusterctlv1.Metadata{
+				ReleaseSeries: []clusterctlv1.ReleaseSeries{
+					{Major: 1, Minor: 0, Contract: "v1alpha3"},
+				},
+				Dependencies: []clusterctlv1.ProviderDependency{
+					{Name: "infra4", Type: clusterctlv1.InfrastructureProviderType},
+				},
+			}),
 	}
 
 	type fields struct {
-		proxy        Proxy
-		installQueue []repository.Components
+		proxy               Proxy
+		installQueue        []repository.Components
+		inventoryNamespaces []string
 	}
 	tests := []struct {
 		name    string
@@ -142,6 +182,32 @@ func Test_providerInstaller_Validate(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "install another instance of infra1 on a cluster already initialized with core + infra1 watching namespaces by label selector, no overlaps",
+			fields: fields{
+				proxy: test.NewFakeProxy(). // cluster with core + infra1 watching the namespaces with the tenant=a label, v1alpha3 contract
+								WithProviderInventory("core", clusterctlv1.CoreProviderType, "v1.0.0", "core-system", "").
+								WithObjs(fakeProviderWatchingSelector("infra1", clusterctlv1.InfrastructureProviderType, "v1.0.0", "ns1", map[string]string{"tenant": "a"})).
+								WithObjs(fakeNamespace("ns2", map[string]string{"tenant": "b"})),
+				installQueue: []repository.Components{ // install infra1 watching ns2, v1alpha3 contract
+					newFakeComponents("infra1", clusterctlv1.InfrastructureProviderType, "v1.0.0", "ns2", "ns2"),
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "install another instance of infra1 on a cluster already initialized with core + infra1 watching namespaces by label selector, watching overlap with the existing infra1",
+			fields: fields{
+				proxy: test.NewFakeProxy(). // cluster with core + infra1 watching the namespaces with the tenant=a label, v1alpha3 contract
+								WithProviderInventory("core", clusterctlv1.CoreProviderType, "v1.0.0", "core-system", "").
+								WithObjs(fakeProviderWatchingSelector("infra1", clusterctlv1.InfrastructureProviderType, "v1.0.0", "ns1", map[string]string{"tenant": "a"})).
+								WithObjs(fakeNamespace("ns2", map[string]string{"tenant": "a"})),
+				installQueue: []repository.Components{ // install infra1 watching ns2, v1alpha3 contract
+					newFakeComponents("infra1", clusterctlv1.InfrastructureProviderType, "v1.0.0", "ns2", "ns2"),
+				},
+			},
+			wantErr: true,
+		},
 		{
 			name: "install another instance of infra1 on a cluster already initialized with core + infra1, not part of the existing management group",
 			fields: fields{
@@ -188,6 +254,162 @@ func Test_providerInstaller_Validate(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "install core + infra1 + infra3 (depending on infra1) on an empty cluster",
+			fields: fields{
+				proxy: test.NewFakeProxy(), //empty cluster
+				installQueue: []repository.Components{ // install core + infra1 + infra3, v1alpha3 contract
+					newFakeComponents("core", clusterctlv1.CoreProviderType, "v1.0.0", "core-system", ""),
+					newFakeComponents("infra3", clusterctlv1.InfrastructureProviderType, "v1.0.0", "infra3-system", ""),
+					newFakeComponents("infra1", clusterctlv1.InfrastructureProviderType, "v1.0.0", "infra1-system", ""),
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "install infra3 (depending on infra1) on a cluster already initialized with core + infra1",
+			fields: fields{
+				proxy: test.NewFakeProxy(). // cluster with core + infra1, v1alpha3 contract
+								WithProviderInventory("core", clusterctlv1.CoreProviderType, "v1.0.0", "core-system", "").
+								WithProviderInventory("infra1", clusterctlv1.InfrastructureProviderType, "v1.0.0", "infra1-system", ""),
+				installQueue: []repository.Components{ // install infra3, v1alpha3 contract
+					newFakeComponents("infra3", clusterctlv1.InfrastructureProviderType, "v1.0.0", "infra3-system", ""),
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "install infra3 (depending on infra1) on a cluster without infra1",
+			fields: fields{
+				proxy: test.NewFakeProxy(). // cluster with core + infra2, v1alpha3 contract
+								WithProviderInventory("core", clusterctlv1.CoreProviderType, "v1.0.0", "core-system", "").
+								WithProviderInventory("infra2", clusterctlv1.InfrastructureProviderType, "v1.0.0", "infra2-system", ""),
+				installQueue: []repository.Components{ // install infra3, v1alpha3 contract
+					newFakeComponents("infra3", clusterctlv1.InfrastructureProviderType, "v1.0.0", "infra3-system", ""),
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "install core + infra4 + infra5 (depending on each other) on an empty cluster",
+			fields: fields{
+				proxy: test.NewFakeProxy(), //empty cluster
+				installQueue: []repository.Components{ // install core + infra4 + infra5, v1alpha3 contract
+					newFakeComponents("core", clusterctlv1.CoreProviderType, "v1.0.0", "core-system", ""),
+					newFakeComponents("infra4", clusterctlv1.InfrastructureProviderType, "v1.0.0", "infra4-system", ""),
+					newFakeComponents("infra5", clusterctlv1.InfrastructureProviderType, "v1.0.0", "infra5-system", ""),
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "install core@v1.0.0 on an empty cluster with a version policy not allowing it",
+			fields: fields{
+				proxy: test.NewFakeProxy(). //empty cluster with a version policy
+								WithVersionPolicy("policy", clusterctlv1.ProviderVersionRange{Name: "core", MinVersion: "v1.0.1"}),
+				installQueue: []repository.Components{ // install core, v1alpha3 contract
+					newFakeComponents("core", clusterctlv1.CoreProviderType, "v1.0.0", "core-system", ""),
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "install core + infra1 in a scoped inventory on a cluster already initialized with core + infra1 in another namespace",
+			fields: fields{
+				proxy: test.NewFakeProxy(). // cluster with core + infra1 watching ns1, v1alpha3 contract
+								WithProviderInventory("core", clusterctlv1.CoreProviderType, "v1.0.0", "ns1", "ns1").
+								WithProviderInventory("infra1", clusterctlv1.InfrastructureProviderType, "v1.0.0", "ns1", "ns1"),
+				installQueue: []repository.Components{ // install core + infra1 watching ns2, v1alpha3 contract
+					newFakeComponents("core", clusterctlv1.CoreProviderType, "v1.0.0", "ns2", "ns2"),
+					newFakeComponents("infra1", clusterctlv1.InfrastructureProviderType, "v1.0.0", "ns2", "ns2"),
+				},
+				inventoryNamespaces: []string{"ns2"},
+			},
+			wantErr: false,
+		},
+		{
+			name: "install core + infra1 in a scoped inventory on a cluster already initialized with core + infra1 watching all the namespaces",
+			fields: fields{
+				proxy: test.NewFakeProxy(). // cluster with core + infra1 watching all the namespaces, v1alpha3 contract
+								WithProviderInventory("core", clusterctlv1.CoreProviderType, "v1.0.0", "core-system", "").
+								WithProviderInventory("infra1", clusterctlv1.InfrastructureProviderType, "v1.0.0", "infra1-system", ""),
+				installQueue: []repository.Components{ // install core + infra1 watching ns2, v1alpha3 contract
+					newFakeComponents("core", clusterctlv1.CoreProviderType, "v1.0.0", "ns2", "ns2"),
+					newFakeComponents("infra1", clusterctlv1.InfrastructureProviderType, "v1.0.0", "ns2", "ns2"),
+				},
+				inventoryNamespaces: []string{"ns2"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "install core in a scoped inventory watching all the namespaces",
+			fields: fields{
+				proxy: test.NewFakeProxy(), //empty cluster
+				installQueue: []repository.Components{ // install core watching all the namespaces, v1alpha3 contract
+					newFakeComponents("core", clusterctlv1.CoreProviderType, "v1.0.0", "ns2", ""),
+				},
+				inventoryNamespaces: []string{"ns2"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "install core in a scoped inventory outside of the inventory namespaces",
+			fields: fields{
+				proxy: test.NewFakeProxy(), //empty cluster
+				installQueue: []repository.Components{ // install core in core-system watching ns2, v1alpha3 contract
+					newFakeComponents("core", clusterctlv1.CoreProviderType, "v1.0.0", "core-system", "ns2"),
+				},
+				inventoryNamespaces: []string{"ns2"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "install core + infra1 in the same namespace",
+			fields: fields{
+				proxy: test.NewFakeProxy(), //empty cluster
+				installQueue: []repository.Components{ // install core + infra1 in ns1, v1alpha3 contract
+					withObjs(newFakeComponents("core", clusterctlv1.CoreProviderType, "v1.0.0", "ns1", ""), fakeComponentObj("Service", "ns1", "core-webhook-service")),
+					withObjs(newFakeComponents("infra1", clusterctlv1.InfrastructureProviderType, "v1.0.0", "ns1", ""), fakeComponentObj("Service", "ns1", "infra1-webhook-service")),
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "install core + infra1 in the same namespace, with objects with the same name",
+			fields: fields{
+				proxy: test.NewFakeProxy(), //empty cluster
+				installQueue: []repository.Components{ // install core + infra1 in ns1, v1alpha3 contract
+					withObjs(newFakeComponents("core", clusterctlv1.CoreProviderType, "v1.0.0", "ns1", ""), fakeComponentObj("Service", "ns1", "webhook-service")),
+					withObjs(newFakeComponents("infra1", clusterctlv1.InfrastructureProviderType, "v1.0.0", "ns1", ""), fakeComponentObj("Service", "ns1", "webhook-service")),
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "install infra2 in the namespace of core + infra1, with objects with the same name of an installed provider",
+			fields: fields{
+				proxy: test.NewFakeProxy(). // cluster with core + infra1 in ns1, v1alpha3 contract
+								WithProviderInventory("core", clusterctlv1.CoreProviderType, "v1.0.0", "ns1", "").
+								WithProviderInventory("infra1", clusterctlv1.InfrastructureProviderType, "v1.0.0", "ns1", "").
+								WithObjs(fakeProviderService("ns1", "webhook-service", "infra1")),
+				installQueue: []repository.Components{ // install infra2 in ns1, v1alpha3 contract
+					withObjs(newFakeComponents("infra2", clusterctlv1.InfrastructureProviderType, "v1.0.0", "ns1", ""), fakeComponentObj("Service", "ns1", "webhook-service")),
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "install another instance of infra1 sharing the cluster-wide objects",
+			fields: fields{
+				proxy: test.NewFakeProxy(), //empty cluster
+				installQueue: []repository.Components{ // install core + two instances of infra1, v1alpha3 contract
+					newFakeComponents("core", clusterctlv1.CoreProviderType, "v1.0.0", "core-system", ""),
+					withObjs(newFakeComponents("infra1", clusterctlv1.InfrastructureProviderType, "v1.0.0", "ns1", "ns1"), fakeComponentObj("CustomResourceDefinition", "", "infra1s.infrastructure.cluster.x-k8s.io")),
+					withObjs(newFakeComponents("infra1", clusterctlv1.InfrastructureProviderType, "v1.0.0", "ns2", "ns2"), fakeComponentObj("CustomResourceDefinition", "", "infra1s.infrastructure.cluster.x-k8s.io")),
+				},
+			},
+			wantErr: false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -197,13 +419,13 @@ func Test_providerInstaller_Validate(t *testing.T) {
 			i := &providerInstaller{
 				configClient:      configClient,
 				proxy:             tt.fields.proxy,
-				providerInventory: newInventoryClient(tt.fields.proxy, nil),
+				providerInventory: newScopedInventoryClient(tt.fields.proxy, nil, tt.fields.inventoryNamespaces),
 				repositoryClientFactory: func(provider config.Provider, configVariablesClient config.VariablesClient, options ...repository.Option) (repository.Client, error) {
 					return repository.New(provider, configVariablesClient, repository.InjectRepository(repositoryMap[provider.Name()]))
 				},
 				installQueue: tt.fields.installQueue,
 			}
-			if err := i.Validate(); (err != nil) != tt.wantErr {
+			if err := i.Validate(ctx); (err != nil) != tt.wantErr {
 				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
 			}
 
@@ -211,13 +433,163 @@ func Test_providerInstaller_Validate(t *testing.T) {
 	}
 }
 
+func Test_providerInstaller_sortInstallQueue(t *testing.T) {
+	fakeReader := test.NewFakeReader().
+		WithProvider("core", clusterctlv1.CoreProviderType, "https://somewhere.com").
+		WithProvider("bootstrap1", clusterctlv1.BootstrapProviderType, "https://somewhere.com").
+		WithProvider("infra1", clusterctlv1.InfrastructureProviderType, "https://somewhere.com").
+		WithProvider("infra2", clusterctlv1.InfrastructureProviderType, "https://somewhere.com")
+
+	// Returns a repository with metadata declaring the given dependencies.
+	newRepository := func(dependencies ...string) repository.Repository {
+		metadata := &clusterctlv1.Metadata{
+			ReleaseSeries: []clusterctlv1.ReleaseSeries{
+				{Major: 1, Minor: 0, Contract: "v1alpha3"},
+			},
+		}
+		for _, d := range dependencies {
+			metadata.Dependencies = append(metadata.Dependencies, clusterctlv1.ProviderDependency{Name: d})
+		}
+		return test.NewFakeRepository().
+			WithVersions("v1.0.0").
+			WithMetadata("v1.0.0", metadata)
+	}
+
+	tests := []struct {
+		name          string
+		repositoryMap map[string]repository.Repository
+		installQueue  []string
+		want          []string
+		wantErr       bool
+	}{
+		{
+			name: "the order is preserved if there are no dependencies",
+			repositoryMap: map[string]repository.Repository{
+				"core":       newRepository(),
+				"bootstrap1": newRepository(),
+				"infra1":     newRepository(),
+			},
+			installQueue: []string{"core", "bootstrap1", "infra1"},
+			want:         []string{"core", "bootstrap1", "infra1"},
+			wantErr:      false,
+		},
+		{
+			name: "providers are installed after their dependencies",
+			repositoryMap: map[string]repository.Repository{
+				"core":       newRepository(),
+				"bootstrap1": newRepository(),
+				"infra1":     newRepository("infra2", "bootstrap1"),
+				"infra2":     newRepository("core"),
+			},
+			installQueue: []string{"infra1", "bootstrap1", "infra2", "core"},
+			want:         []string{"bootstrap1", "core", "infra2", "infra1"},
+			wantErr:      false,
+		},
+		{
+			name: "dependencies not in the install queue are ignored",
+			repositoryMap: map[string]repository.Repository{
+				"core":   newRepository(),
+				"infra1": newRepository("infra2"),
+			},
+			installQueue: []string{"infra1", "core"},
+			want:         []string{"infra1", "core"},
+			wantErr:      false,
+		},
+		{
+			name: "fails with circular dependencies",
+			repositoryMap: map[string]repository.Repository{
+				"core":   newRepository(),
+				"infra1": newRepository("infra2"),
+				"infra2": newRepository("infra1"),
+			},
+			installQueue: []string{"core", "infra1", "infra2"},
+			wantErr:      true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configClient, _ := config.New("", config.InjectReader(fakeReader))
+
+			i := &providerInstaller{
+				configClient: configClient,
+				repositoryClientFactory: func(provider config.Provider, configVariablesClient config.VariablesClient, options ...repository.Option) (repository.Client, error) {
+					return repository.New(provider, configVariablesClient, repository.InjectRepository(tt.repositoryMap[provider.Name()]))
+				},
+			}
+			for _, name := range tt.installQueue {
+				provider, err := configClient.Providers().Get(name)
+				if err != nil {
+					t.Fatalf("error = %v, want nil", err)
+				}
+				i.Add(newFakeComponents(name, provider.Type(), "v1.0.0", name+"-system", ""))
+			}
+
+			got, err := i.sortInstallQueue(ctx)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			var gotNames []string
+			for _, components := range got {
+				gotNames = append(gotNames, components.Name())
+			}
+			if !reflect.DeepEqual(gotNames, tt.want) {
+				t.Errorf("got = %v, want %v", gotNames, tt.want)
+			}
+		})
+	}
+}
+
+func Test_providerInstaller_InstallCancelled(t *testing.T) {
+	fakeReader := test.NewFakeReader().
+		WithProvider("core", clusterctlv1.CoreProviderType, "https://somewhere.com")
+
+	repositoryMap := map[string]repository.Repository{
+		"core": test.NewFakeRepository().
+			WithVersions("v1.0.0").
+			WithMetadata("v1.0.0", &clusterctlv1.Metadata{
+				ReleaseSeries: []clusterctlv1.ReleaseSeries{
+					{Major: 1, Minor: 0, Contract: "v1alpha3"},
+				},
+			}),
+	}
+
+	configClient, _ := config.New("", config.InjectReader(fakeReader))
+	proxy := test.NewFakeProxy()
+	repositoryClientFactory := func(provider config.Provider, configVariablesClient config.VariablesClient, options ...repository.Option) (repository.Client, error) {
+		return repository.New(provider, configVariablesClient, repository.InjectRepository(repositoryMap[provider.Name()]))
+	}
+	inventory := newInventoryClient(proxy, fakeObjectWaiter)
+	i := newProviderInstaller(configClient, repositoryClientFactory, proxy, inventory, newComponentsClient(proxy, fakeObjectWaiter), nil)
+	i.Add(newFakeComponents("core", clusterctlv1.CoreProviderType, "v1.0.0", "core-system", ""))
+
+	cancelledCtx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := i.Install(cancelledCtx); err == nil {
+		t.Fatalf("error = nil, want an error for the cancelled installation")
+	}
+
+	providerList, err := inventory.List(ctx)
+	if err != nil {
+		t.Fatalf("error = %v, want nil", err)
+	}
+	if len(providerList.Items) != 0 {
+		t.Errorf("got %d providers in the inventory, want 0", len(providerList.Items))
+	}
+}
+
 type fakeComponents struct {
 	config.Provider
 	inventoryObject clusterctlv1.Provider
+	objs            []unstructured.Unstructured
 }
 
 func (c *fakeComponents) Version() string {
-	panic("not implemented")
+	return c.inventoryObject.Version
 }
 
 func (c *fakeComponents) Variables() []string {
@@ -241,13 +613,60 @@ func (c *fakeComponents) InventoryObject() clusterctlv1.Provider {
 }
 
 func (c *fakeComponents) Objs() []unstructured.Unstructured {
-	panic("not implemented")
+	return c.objs
 }
 
 func (c *fakeComponents) Yaml() ([]byte, error) {
 	panic("not implemented")
 }
 
+// withObjs sets the objects of fake components.
+func withObjs(components repository.Components, objs ...unstructured.Unstructured) repository.Components {
+	components.(*fakeComponents).objs = objs
+	return components
+}
+
+func fakeComponentObj(kind, namespace, name string) unstructured.Unstructured {
+	obj := unstructured.Unstructured{}
+	obj.SetAPIVersion("v1")
+	obj.SetKind(kind)
+	obj.SetNamespace(namespace)
+	obj.SetName(name)
+	return obj
+}
+
+func fakeProviderService(namespace, name, provider string) *corev1.Service {
+	return &corev1.Service{
+		TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "Service"},
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: namespace,
+			Name:      name,
+			Labels: map[string]string{
+				clusterctlv1.ClusterctlLabelName: "",
+				clusterv1.ProviderLabelName:      provider,
+			},
+		},
+	}
+}
+
+func fakeProviderWatchingSelector(name string, providerType clusterctlv1.ProviderType, version, targetNamespace string, namespaceLabels map[string]string) *clusterctlv1.Provider {
+	provider := fakeProvider(name, providerType, version, targetNamespace, "")
+	provider.WatchedNamespaceSelector = &metav1.LabelSelector{MatchLabels: namespaceLabels}
+	return &provider
+}
+
+func fakeNamespace(name string, labels map[string]string) *corev1.Namespace {
+	return &corev1.Namespace{
+		TypeMeta: metav1.TypeMeta{
+			Kind: "Namespace",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   name,
+			Labels: labels,
+		},
+	}
+}
+
 func newFakeComponents(name string, providerType clusterctlv1.ProviderType, version, targetNamespace, watchingNamespace string) repository.Components {
 	inventoryObject := fakeProvider(name, providerType, version, targetNamespace, watchingNamespace)
 	return &fakeComponents{
@@ -255,3 +674,40 @@ func newFakeComponents(name string, providerType clusterctlv1.ProviderType, vers
 		inventoryObject: inventoryObject,
 	}
 }
+
+func Test_providerInstaller_getProviderContract(t *testing.T) {
+	fakeReader := test.NewFakeReader().
+		WithProvider("core", clusterctlv1.CoreProviderType, "https://somewhere.com")
+	configClient, _ := config.New("", config.InjectReader(fakeReader))
+
+	i := &providerInstaller{
+		configClient: configClient,
+		repositoryClientFactory: func(provider config.Provider, configVariablesClient config.VariablesClient, options ...repository.Option) (repository.Client, error) {
+			return nil, errors.New("the provider metadata should not be fetched")
+		},
+	}
+
+	// The contract recorded in the inventory for the provider version is used without fetching the provider metadata.
+	core := clusterctlv1.Provider{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "core-system", Name: "core"},
+		Version:    "v1.0.0",
+		Status:     newProviderStatus("v1.0.0", "v1alpha3"),
+	}
+	got, err := i.getProviderContract(ctx, core)
+	if err != nil {
+		t.Fatalf("getProviderContract() error = %v", err)
+	}
+	if got != "v1alpha3" {
+		t.Errorf("getProviderContract() = %q, want %q", got, "v1alpha3")
+	}
+
+	// The contract recorded for another version of the provider is ignored.
+	infra := clusterctlv1.Provider{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "infra-system", Name: "infra"},
+		Version:    "v1.1.0",
+		Status:     newProviderStatus("v1.0.0", "v1alpha3"),
+	}
+	if _, err := i.getProviderContract(ctx, infra); err == nil {
+		t.Error("getProviderContract() error = nil, want the provider metadata to be fetched for a stale status")
+	}
+}
@@ -17,13 +17,18 @@ limitations under the License.
 package cluster
 
 import (
+	"context"
+	"strings"
 	"time"
 
+	"github.com/go-logr/logr"
 	"github.com/pkg/errors"
 	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	apimeta "k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/util/sets"
+	clientretry "k8s.io/client-go/util/retry"
 	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/config"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/internal/util"
@@ -34,8 +39,7 @@ import (
 const (
 	embeddedCustomResourceDefinitionPath = "cmd/clusterctl/config/manifest/clusterctl-api.yaml"
 
-	waitInventoryCRDInterval = 250 * time.Millisecond
-	waitInventoryCRDTimeout  = 1 * time.Minute
+	waitInventoryCRDTimeout = 1 * time.Minute
 )
 
 // InventoryClient exposes methods to interface with a cluster's provider inventory.
@@ -43,52 +47,89 @@ type InventoryClient interface {
 	// EnsureCustomResourceDefinitions installs the CRD required for creating inventory items, if necessary.
 	// Nb. In order to provide a simpler out-of-the box experience, the inventory CRD
 	// is embedded in the clusterctl binary.
-	EnsureCustomResourceDefinitions() error
+	EnsureCustomResourceDefinitions(ctx context.Context) error
 
-	// Create an inventory item for a provider instance installed in the cluster.
-	Create(clusterctlv1.Provider) error
+	// HasCustomResourceDefinitions returns true if the CRD required for reading the inventory items is installed.
+	// Unlike EnsureCustomResourceDefinitions, it never changes the cluster, so it can be used by read-only operations.
+	HasCustomResourceDefinitions(ctx context.Context) (bool, error)
 
-	// List returns the inventory items for all the provider instances installed in the cluster.
-	List() (*clusterctlv1.ProviderList, error)
+	// Create an inventory item for a provider instance installed in the cluster, including its status.
+	Create(ctx context.Context, provider clusterctlv1.Provider) error
+
+	// UpdateStatus records the status of a provider instance in its inventory item.
+	UpdateStatus(ctx context.Context, provider clusterctlv1.Provider) error
+
+	// List returns the inventory items for all the provider instances installed in the cluster; if the inventory is
+	// scoped to a set of namespaces, only the provider instances installed in those namespaces are returned.
+	List(ctx context.Context) (*clusterctlv1.ProviderList, error)
+
+	// ListAll returns the inventory items for all the provider instances installed in the cluster, including the
+	// ones installed outside of the namespaces the inventory is scoped to.
+	ListAll(ctx context.Context) (*clusterctlv1.ProviderList, error)
+
+	// Namespaces returns the namespaces the inventory is scoped to, e.g. for allowing independent users to manage
+	// their own providers in the same management cluster. If empty, the inventory is not scoped.
+	Namespaces() []string
 
 	// GetDefaultProviderName returns the default provider for a given ProviderType.
 	// In case there is only a single provider for a given type, e.g. only the AWS infrastructure Provider, it returns
 	// this as the default provider; In case there are more provider of the same type, there is no default provider.
-	GetDefaultProviderName(providerType clusterctlv1.ProviderType) (string, error)
+	GetDefaultProviderName(ctx context.Context, providerType clusterctlv1.ProviderType) (string, error)
 
 	// GetDefaultProviderVersion returns the default version for a given provider.
 	// In case there is only a single version installed for a given provider, e.g. only the v0.4.1 version for the AWS provider, it returns
 	// this as the default version; In case there are more version installed for the same provider, there is no default provider version.
-	GetDefaultProviderVersion(provider string) (string, error)
+	GetDefaultProviderVersion(ctx context.Context, provider string) (string, error)
 
 	// GetDefaultProviderNamespace returns the default namespace for a given provider.
 	// In case there is only a single instance for a given provider, e.g. only the AWS provider in the capa-system namespace, it returns
 	// this as the default namespace; In case there are more instances for the same provider installed in different namespaces, there is no default provider namespace.
-	GetDefaultProviderNamespace(provider string) (string, error)
+	GetDefaultProviderNamespace(ctx context.Context, provider string) (string, error)
 
 	// GetManagementGroups returns the list of management groups defined in the management cluster.
-	GetManagementGroups() (ManagementGroupList, error)
+	GetManagementGroups(ctx context.Context) (ManagementGroupList, error)
+
+	// GetVersionPolicy returns the version policy for the management cluster, that is the intersection of the version
+	// ranges defined by all the VersionPolicy objects existing in the cluster, so a version is allowed only if it belongs
+	// to all the ranges defined for a provider. If there are no VersionPolicy objects, an empty policy not restricting
+	// any provider is returned.
+	GetVersionPolicy(ctx context.Context) (*clusterctlv1.VersionPolicy, error)
 }
 
 // inventoryClient implements InventoryClient.
 type inventoryClient struct {
-	proxy               Proxy
-	pollImmediateWaiter PollImmediateWaiter
+	proxy        Proxy
+	objectWaiter ObjectWaiter
+	namespaces   []string
+	log          logr.Logger
 }
 
 // ensure inventoryClient implements InventoryClient.
 var _ InventoryClient = &inventoryClient{}
 
 // newInventoryClient returns a inventoryClient.
-func newInventoryClient(proxy Proxy, pollImmediateWaiter PollImmediateWaiter) *inventoryClient {
+func newInventoryClient(proxy Proxy, objectWaiter ObjectWaiter) *inventoryClient {
 	return &inventoryClient{
-		proxy:               proxy,
-		pollImmediateWaiter: pollImmediateWaiter,
+		proxy:        proxy,
+		objectWaiter: objectWaiter,
+		log:          logf.Log,
 	}
 }
 
-func (p *inventoryClient) EnsureCustomResourceDefinitions() error {
-	log := logf.Log
+// newScopedInventoryClient returns a inventoryClient scoped to the given namespaces.
+func newScopedInventoryClient(proxy Proxy, objectWaiter ObjectWaiter, namespaces []string) *inventoryClient {
+	inventory := newInventoryClient(proxy, objectWaiter)
+	inventory.namespaces = namespaces
+	return inventory
+}
+
+// inScope returns true if the namespace is one of the namespaces the inventory is scoped to, or if the inventory is not scoped.
+func (p *inventoryClient) inScope(namespace string) bool {
+	return len(p.namespaces) == 0 || sets.NewString(p.namespaces...).Has(namespace)
+}
+
+func (p *inventoryClient) EnsureCustomResourceDefinitions(ctx context.Context) error {
+	log := p.log
 
 	c, err := p.proxy.NewClient()
 	if err != nil {
@@ -96,8 +137,11 @@ func (p *inventoryClient) EnsureCustomResourceDefinitions() error {
 	}
 
 	// Check the CRDs already exists, if yes, exit immediately.
-	l := &clusterctlv1.ProviderList{}
-	if err = c.List(ctx, l); err == nil {
+	err = c.List(ctx, &clusterctlv1.ProviderList{})
+	if err == nil {
+		err = c.List(ctx, &clusterctlv1.VersionPolicyList{})
+	}
+	if err == nil {
 		return nil
 	}
 	if !apimeta.IsNoMatchError(err) {
@@ -144,20 +188,8 @@ func (p *inventoryClient) EnsureCustomResourceDefinitions() error {
 				return nil
 			}
 
-			if err := p.pollImmediateWaiter(waitInventoryCRDInterval, waitInventoryCRDTimeout, func() (bool, error) {
-				crd := &apiextensionsv1.CustomResourceDefinition{}
-				if err := c.Get(ctx, crdKey, crd); err != nil {
-					return false, err
-				}
-
-				for _, c := range crd.Status.Conditions {
-					if c.Type == apiextensionsv1.Established && c.Status == apiextensionsv1.ConditionTrue {
-						return true, nil
-					}
-				}
-				return false, nil
-			}); err != nil {
-				return errors.Wrapf(err, "failed to scale deployment")
+			if err := p.objectWaiter(ctx, o.GroupVersionKind(), crdKey, waitInventoryCRDTimeout, crdEstablished); err != nil {
+				return errors.Wrapf(err, "failed to wait for the %q CustomResourceDefinition to be established", crdKey.Name)
 			}
 		}
 	}
@@ -165,40 +197,126 @@ func (p *inventoryClient) EnsureCustomResourceDefinitions() error {
 	return nil
 }
 
-func (p *inventoryClient) Create(m clusterctlv1.Provider) error {
+func (p *inventoryClient) HasCustomResourceDefinitions(ctx context.Context) (bool, error) {
+	c, err := p.proxy.NewClient()
+	if err != nil {
+		return false, err
+	}
+
+	if err := c.List(ctx, &clusterctlv1.ProviderList{}, client.Limit(1)); err != nil {
+		if apimeta.IsNoMatchError(err) {
+			return false, nil
+		}
+		return false, errors.Wrap(err, "failed to check if the clusterctl inventory CRD exists")
+	}
+	return true, nil
+}
+
+func (p *inventoryClient) Create(ctx context.Context, m clusterctlv1.Provider) error {
+	// Providers outside of the scope of the inventory belong to other users of the management cluster.
+	if !p.inScope(m.Namespace) {
+		return errors.Errorf("cannot create the inventory item for the %s provider: namespace %q is outside of the inventory namespaces %s", m.InstanceName(), m.Namespace, strings.Join(p.namespaces, ", "))
+	}
+
 	cl, err := p.proxy.NewClient()
 	if err != nil {
 		return err
 	}
 
-	currentProvider := &clusterctlv1.Provider{}
-	key := client.ObjectKey{
-		Namespace: m.Namespace,
-		Name:      m.Name,
-	}
-	if err := cl.Get(ctx, key, currentProvider); err != nil {
-		if !apierrors.IsNotFound(err) {
-			return errors.Wrapf(err, "failed to get current provider object")
+	// Nb. The operation is wrapped in a retry on conflict loop, so it does not fail if the inventory item is changed
+	// concurrently between reading its current version and updating it.
+	return retryOnConflict(p.log, clientretry.DefaultRetry, func() error {
+		currentProvider := &clusterctlv1.Provider{}
+		key := client.ObjectKey{
+			Namespace: m.Namespace,
+			Name:      m.Name,
+		}
+		if err := cl.Get(ctx, key, currentProvider); err != nil {
+			if !apierrors.IsNotFound(err) {
+				return errors.Wrapf(err, "failed to get current provider object")
+			}
+			currentProvider = nil
 		}
-		currentProvider = nil
-	}
 
-	c := m.DeepCopyObject()
-	if currentProvider == nil {
-		if err := cl.Create(ctx, c); err != nil {
-			return errors.Wrapf(err, "failed to create provider object")
+		c := m.DeepCopy()
+		if currentProvider == nil {
+			if err := cl.Create(ctx, c); err != nil {
+				return errors.Wrapf(err, "failed to create provider object")
+			}
+		} else {
+			c.ResourceVersion = currentProvider.ResourceVersion
+			if err := cl.Update(ctx, c); err != nil {
+				return errors.Wrapf(err, "failed to update provider object")
+			}
 		}
-	} else {
-		m.ResourceVersion = currentProvider.ResourceVersion
-		if err := cl.Update(ctx, c); err != nil {
-			return errors.Wrapf(err, "failed to update provider object")
+
+		// The status is ignored when creating or updating the provider object, so it is written separately.
+		if apiequality.Semantic.DeepEqual(m.Status, clusterctlv1.ProviderStatus{}) {
+			return nil
 		}
+		c.Status = m.Status
+		return p.updateStatus(ctx, cl, c)
+	})
+}
+
+func (p *inventoryClient) UpdateStatus(ctx context.Context, m clusterctlv1.Provider) error {
+	if !p.inScope(m.Namespace) {
+		return errors.Errorf("cannot update the inventory item for the %s provider: namespace %q is outside of the inventory namespaces %s", m.InstanceName(), m.Namespace, strings.Join(p.namespaces, ", "))
+	}
+
+	cl, err := p.proxy.NewClient()
+	if err != nil {
+		return err
 	}
 
+	return retryOnConflict(p.log, clientretry.DefaultRetry, func() error {
+		currentProvider := &clusterctlv1.Provider{}
+		key := client.ObjectKey{
+			Namespace: m.Namespace,
+			Name:      m.Name,
+		}
+		if err := cl.Get(ctx, key, currentProvider); err != nil {
+			return errors.Wrapf(err, "failed to get current provider object")
+		}
+
+		currentProvider.Status = m.Status
+		return p.updateStatus(ctx, cl, currentProvider)
+	})
+}
+
+// updateStatus writes the status of a provider object using the status subresource.
+func (p *inventoryClient) updateStatus(ctx context.Context, cl client.Client, provider *clusterctlv1.Provider) error {
+	if err := cl.Status().Update(ctx, provider); err != nil {
+		// The inventory CRD installed by previous versions of clusterctl has no status subresource; in this case the
+		// status is not recorded, without failing the operation.
+		if apierrors.IsNotFound(err) {
+			p.log.V(1).Info("The inventory does not support the provider status, skipping", "Provider", provider.InstanceName())
+			return nil
+		}
+		return errors.Wrapf(err, "failed to update provider object status")
+	}
 	return nil
 }
 
-func (p *inventoryClient) List() (*clusterctlv1.ProviderList, error) {
+func (p *inventoryClient) List(ctx context.Context) (*clusterctlv1.ProviderList, error) {
+	providerList, err := p.ListAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(p.namespaces) == 0 {
+		return providerList, nil
+	}
+	scoped := &clusterctlv1.ProviderList{}
+	for _, provider := range providerList.Items {
+		if p.inScope(provider.Namespace) {
+			scoped.Items = append(scoped.Items, provider)
+		}
+	}
+	return scoped, nil
+}
+
+func (p *inventoryClient) ListAll(ctx context.Context) (*clusterctlv1.ProviderList, error) {
 	cl, err := p.proxy.NewClient()
 	if err != nil {
 		return nil, err
@@ -211,8 +329,12 @@ func (p *inventoryClient) List() (*clusterctlv1.ProviderList, error) {
 	return providerList, nil
 }
 
-func (p *inventoryClient) GetDefaultProviderName(providerType clusterctlv1.ProviderType) (string, error) {
-	providerList, err := p.List()
+func (p *inventoryClient) Namespaces() []string {
+	return p.namespaces
+}
+
+func (p *inventoryClient) GetDefaultProviderName(ctx context.Context, providerType clusterctlv1.ProviderType) (string, error) {
+	providerList, err := p.List(ctx)
 	if err != nil {
 		return "", err
 	}
@@ -232,8 +354,8 @@ func (p *inventoryClient) GetDefaultProviderName(providerType clusterctlv1.Provi
 	return "", nil
 }
 
-func (p *inventoryClient) GetDefaultProviderVersion(provider string) (string, error) {
-	providerList, err := p.List()
+func (p *inventoryClient) GetDefaultProviderVersion(ctx context.Context, provider string) (string, error) {
+	providerList, err := p.List(ctx)
 	if err != nil {
 		return "", err
 	}
@@ -252,8 +374,8 @@ func (p *inventoryClient) GetDefaultProviderVersion(provider string) (string, er
 	return "", nil
 }
 
-func (p *inventoryClient) GetDefaultProviderNamespace(provider string) (string, error) {
-	providerList, err := p.List()
+func (p *inventoryClient) GetDefaultProviderNamespace(ctx context.Context, provider string) (string, error) {
+	providerList, err := p.List(ctx)
 	if err != nil {
 		return "", err
 	}
@@ -271,3 +393,26 @@ func (p *inventoryClient) GetDefaultProviderNamespace(provider string) (string,
 	// There is no provider or more than one namespace for this provider; in both cases, a default provider namespace cannot be decided.
 	return "", nil
 }
+
+func (p *inventoryClient) GetVersionPolicy(ctx context.Context) (*clusterctlv1.VersionPolicy, error) {
+	cl, err := p.proxy.NewClient()
+	if err != nil {
+		return nil, err
+	}
+
+	policy := &clusterctlv1.VersionPolicy{}
+
+	policyList := &clusterctlv1.VersionPolicyList{}
+	if err := cl.List(ctx, policyList); err != nil {
+		// If the VersionPolicy CRD is not installed, there are no restrictions.
+		if apimeta.IsNoMatchError(err) {
+			return policy, nil
+		}
+		return nil, errors.Wrap(err, "failed get version policies")
+	}
+
+	for _, p := range policyList.Items {
+		policy.Providers = append(policy.Providers, p.Providers...)
+	}
+	return policy, nil
+}
@@ -0,0 +1,64 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"os/user"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/client/repository"
+	"sigs.k8s.io/cluster-api/cmd/version"
+)
+
+// currentUser returns the name of the user running clusterctl; it is a variable so it can be overridden in tests.
+var currentUser = func() string {
+	u, err := user.Current()
+	if err != nil {
+		return ""
+	}
+	return u.Username
+}
+
+// newProviderOperation returns the record of an operation installing the given provider components;
+// if a previous instance of the provider exists, the operation is recorded as an upgrade.
+func newProviderOperation(components repository.Components, previous *clusterctlv1.Provider) clusterctlv1.ProviderOperation {
+	operation := clusterctlv1.ProviderOperation{
+		Type:              clusterctlv1.InstallProviderOperation,
+		Version:           components.Version(),
+		Timestamp:         metav1.Now(),
+		User:              currentUser(),
+		ClusterctlVersion: version.Get().GitVersion,
+		RepositoryURL:     components.URL(),
+	}
+
+	if previous != nil {
+		operation.Type = clusterctlv1.UpgradeProviderOperation
+		operation.PreviousVersion = previous.Version
+	}
+
+	return operation
+}
+
+// providerHistory returns the history of a provider instance, extended with the given operation.
+func providerHistory(previous *clusterctlv1.Provider, operation clusterctlv1.ProviderOperation) []clusterctlv1.ProviderOperation {
+	var history []clusterctlv1.ProviderOperation
+	if previous != nil {
+		history = append(history, previous.History...)
+	}
+	return append(history, operation)
+}
@@ -0,0 +1,134 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"reflect"
+	"testing"
+
+	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/client/config"
+)
+
+func Test_newProviderOperation(t *testing.T) {
+	currentUser = func() string { return "admin" }
+
+	components := &fakeComponents{
+		Provider:        config.NewProvider("infra", "https://somewhere.com/infra/components.yaml", clusterctlv1.InfrastructureProviderType),
+		inventoryObject: fakeProvider("infra", clusterctlv1.InfrastructureProviderType, "v1.1.0", "infra-system", ""),
+	}
+
+	type args struct {
+		previous *clusterctlv1.Provider
+	}
+	tests := []struct {
+		name string
+		args args
+		want clusterctlv1.ProviderOperation
+	}{
+		{
+			name: "install",
+			args: args{
+				previous: nil,
+			},
+			want: clusterctlv1.ProviderOperation{
+				Type:          clusterctlv1.InstallProviderOperation,
+				Version:       "v1.1.0",
+				User:          "admin",
+				RepositoryURL: "https://somewhere.com/infra/components.yaml",
+			},
+		},
+		{
+			name: "upgrade",
+			args: args{
+				previous: &clusterctlv1.Provider{Version: "v1.0.0"},
+			},
+			want: clusterctlv1.ProviderOperation{
+				Type:            clusterctlv1.UpgradeProviderOperation,
+				Version:         "v1.1.0",
+				PreviousVersion: "v1.0.0",
+				User:            "admin",
+				RepositoryURL:   "https://somewhere.com/infra/components.yaml",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := newProviderOperation(components, tt.args.previous)
+
+			if got.Timestamp.IsZero() {
+				t.Error("got.Timestamp is zero, want the time of the operation")
+			}
+
+			// Ignore fields depending on the test environment.
+			got.Timestamp = tt.want.Timestamp
+			got.ClusterctlVersion = tt.want.ClusterctlVersion
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_providerHistory(t *testing.T) {
+	install := clusterctlv1.ProviderOperation{Type: clusterctlv1.InstallProviderOperation, Version: "v1.0.0"}
+	upgrade := clusterctlv1.ProviderOperation{Type: clusterctlv1.UpgradeProviderOperation, Version: "v1.1.0", PreviousVersion: "v1.0.0"}
+
+	type args struct {
+		previous  *clusterctlv1.Provider
+		operation clusterctlv1.ProviderOperation
+	}
+	tests := []struct {
+		name string
+		args args
+		want []clusterctlv1.ProviderOperation
+	}{
+		{
+			name: "no previous provider",
+			args: args{
+				previous:  nil,
+				operation: install,
+			},
+			want: []clusterctlv1.ProviderOperation{install},
+		},
+		{
+			name: "previous provider without history",
+			args: args{
+				previous:  &clusterctlv1.Provider{Version: "v1.0.0"},
+				operation: upgrade,
+			},
+			want: []clusterctlv1.ProviderOperation{upgrade},
+		},
+		{
+			name: "previous provider with history",
+			args: args{
+				previous:  &clusterctlv1.Provider{Version: "v1.0.0", History: []clusterctlv1.ProviderOperation{install}},
+				operation: upgrade,
+			},
+			want: []clusterctlv1.ProviderOperation{install, upgrade},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := providerHistory(tt.args.previous, tt.args.operation)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
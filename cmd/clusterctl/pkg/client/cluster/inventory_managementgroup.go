@@ -17,9 +17,11 @@ limitations under the License.
 package cluster
 
 import (
+	"context"
 	"strings"
 
 	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
 	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
 )
 
@@ -59,17 +61,18 @@ func (ml *ManagementGroupList) FindManagementGroupByProviderInstanceName(instanc
 	return nil
 }
 
-// deriveManagementGroups derives the management groups from a list of providers.
-func deriveManagementGroups(providerList *clusterctlv1.ProviderList) (ManagementGroupList, error) {
+// deriveManagementGroups derives the management groups from a list of providers; the list of namespaces existing in
+// the cluster is used for checking overlaps between providers watching namespaces by label selector.
+func deriveManagementGroups(providerList *clusterctlv1.ProviderList, namespaces []corev1.Namespace) (ManagementGroupList, error) {
 	// If any of the core providers watch the same namespace, we cannot define the management group.
-	if err := checkOverlappingCoreProviders(providerList); err != nil {
+	if err := checkOverlappingCoreProviders(providerList, namespaces); err != nil {
 		return nil, err
 	}
 
 	// If any of the  Bootstrap/ControlPlane/Infrastructure providers can't be combined with a core provider,
 	// or if any of the Bootstrap/ControlPlane/Infrastructure providers is watching objects controlled by more than one core provider
 	// we can't define a management group.
-	if err := checkOverlappingProviders(providerList); err != nil {
+	if err := checkOverlappingProviders(providerList, namespaces); err != nil {
 		return nil, err
 	}
 
@@ -78,7 +81,7 @@ func deriveManagementGroups(providerList *clusterctlv1.ProviderList) (Management
 	for _, coreProvider := range providerList.FilterCore() {
 		group := ManagementGroup{CoreProvider: coreProvider}
 		for _, provider := range providerList.Items {
-			if coreProvider.HasWatchingOverlapWith(provider) {
+			if coreProvider.HasWatchingOverlapWith(provider, namespaces) {
 				group.Providers = append(group.Providers, provider)
 			}
 		}
@@ -91,7 +94,7 @@ func deriveManagementGroups(providerList *clusterctlv1.ProviderList) (Management
 // checkOverlappingCoreProviders checks if there are core providers with overlapping watching namespaces, if yes, return error e.g.
 // cluster-api in capi-system watching all namespaces and another cluster-api in capi-system2 watching capi-system2 (both are watching capi-system2)
 // NB. This should not happen because init prevent the users to do so, but nevertheless we are double checking this before upgrades.
-func checkOverlappingCoreProviders(providerList *clusterctlv1.ProviderList) error {
+func checkOverlappingCoreProviders(providerList *clusterctlv1.ProviderList, namespaces []corev1.Namespace) error {
 	for _, provider := range providerList.FilterCore() {
 		for _, other := range providerList.FilterCore() {
 			// if the provider to compare is the same of the other provider, skip it
@@ -100,7 +103,7 @@ func checkOverlappingCoreProviders(providerList *clusterctlv1.ProviderList) erro
 			}
 
 			// check for overlapping namespaces
-			if provider.HasWatchingOverlapWith(other) {
+			if provider.HasWatchingOverlapWith(other, namespaces) {
 				return errors.Errorf("Unable to identify management groups: core providers %s and %s have overlapping watching namespaces",
 					provider.InstanceName(),
 					other.InstanceName(),
@@ -116,12 +119,12 @@ func checkOverlappingCoreProviders(providerList *clusterctlv1.ProviderList) erro
 //    e.g. cluster-api in capi-system watching capi-system and aws in capa-system watching capa-system (they are watching different namespaces)
 // 2) can be combined with more than one core provider
 //    e.g. cluster-api in capi-system1 watching all capi-system1, cluster-api in capi-system2 watching all capi-system2,  aws in capa-system watching all namespaces (aws is working with both CAPI instances, but this is not a configuration supported by clusterctl)
-func checkOverlappingProviders(providerList *clusterctlv1.ProviderList) error {
+func checkOverlappingProviders(providerList *clusterctlv1.ProviderList, namespaces []corev1.Namespace) error {
 	for _, provider := range providerList.FilterNonCore() {
 		// check for the core providers watching objects in the same namespace of the provider
 		var overlappingCoreProviders []string
 		for _, coreProvider := range providerList.FilterCore() {
-			if provider.HasWatchingOverlapWith(coreProvider) {
+			if provider.HasWatchingOverlapWith(coreProvider, namespaces) {
 				overlappingCoreProviders = append(overlappingCoreProviders, coreProvider.InstanceName())
 			}
 		}
@@ -144,11 +147,30 @@ func checkOverlappingProviders(providerList *clusterctlv1.ProviderList) error {
 	return nil
 }
 
-func (p *inventoryClient) GetManagementGroups() (ManagementGroupList, error) {
-	providerList, err := p.List()
+func (p *inventoryClient) GetManagementGroups(ctx context.Context) (ManagementGroupList, error) {
+	providerList, err := p.List(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	return deriveManagementGroups(providerList)
+	namespaces, err := listNamespaces(ctx, p.proxy)
+	if err != nil {
+		return nil, err
+	}
+
+	return deriveManagementGroups(providerList, namespaces)
+}
+
+// listNamespaces returns the namespaces existing in the cluster.
+func listNamespaces(ctx context.Context, proxy Proxy) ([]corev1.Namespace, error) {
+	c, err := proxy.NewClient()
+	if err != nil {
+		return nil, err
+	}
+
+	namespaceList := &corev1.NamespaceList{}
+	if err := c.List(ctx, namespaceList); err != nil {
+		return nil, errors.Wrap(err, "failed to list namespaces")
+	}
+	return namespaceList.Items, nil
 }
@@ -146,7 +146,7 @@ func Test_inventoryClient_GetManagementGroups(t *testing.T) {
 			p := &inventoryClient{
 				proxy: tt.fields.proxy,
 			}
-			got, err := p.GetManagementGroups()
+			got, err := p.GetManagementGroups(ctx)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -0,0 +1,54 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/cmd/version"
+)
+
+// newProviderStatus returns the status of a provider instance just installed or upgraded to the given version,
+// supporting the given API Version of Cluster API (contract); the health of the provider is not known until checked.
+func newProviderStatus(providerVersion, contract string) clusterctlv1.ProviderStatus {
+	now := metav1.Now()
+	return clusterctlv1.ProviderStatus{
+		Contract:          contract,
+		ObservedVersion:   providerVersion,
+		Health:            clusterctlv1.ProviderHealthUnknown,
+		LastUpdated:       &now,
+		ClusterctlVersion: version.Get().GitVersion,
+	}
+}
+
+// ProviderHealthStatus returns the status of a provider instance updated with the result of a health check,
+// as returned by ComponentsClient.CheckHealth; the contract recorded in the status is preserved.
+func ProviderHealthStatus(provider clusterctlv1.Provider, healthErr error) clusterctlv1.ProviderStatus {
+	status := *provider.Status.DeepCopy()
+
+	status.Health = clusterctlv1.ProviderHealthy
+	status.Message = ""
+	if healthErr != nil {
+		status.Health = clusterctlv1.ProviderUnhealthy
+		status.Message = healthErr.Error()
+	}
+
+	now := metav1.Now()
+	status.LastUpdated = &now
+	status.ClusterctlVersion = version.Get().GitVersion
+	return status
+}
@@ -0,0 +1,65 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
+)
+
+func Test_ProviderHealthStatus(t *testing.T) {
+	provider := clusterctlv1.Provider{
+		Version: "v1.0.0",
+		Status:  newProviderStatus("v1.0.0", "v1alpha3"),
+	}
+
+	tests := []struct {
+		name        string
+		healthErr   error
+		wantHealth  clusterctlv1.ProviderHealth
+		wantMessage string
+	}{
+		{
+			name:        "healthy",
+			healthErr:   nil,
+			wantHealth:  clusterctlv1.ProviderHealthy,
+			wantMessage: "",
+		},
+		{
+			name:        "unhealthy",
+			healthErr:   errors.New("deployment ns1/foo has 0 available replicas, 1 desired"),
+			wantHealth:  clusterctlv1.ProviderUnhealthy,
+			wantMessage: "deployment ns1/foo has 0 available replicas, 1 desired",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ProviderHealthStatus(provider, tt.healthErr)
+			if got.Health != tt.wantHealth || got.Message != tt.wantMessage {
+				t.Errorf("got Health = %q, Message = %q, want %q, %q", got.Health, got.Message, tt.wantHealth, tt.wantMessage)
+			}
+			if got.Contract != "v1alpha3" || got.ObservedVersion != "v1.0.0" {
+				t.Errorf("got Contract = %q, ObservedVersion = %q, want the values of the previous status preserved", got.Contract, got.ObservedVersion)
+			}
+			if got.LastUpdated == nil {
+				t.Error("got LastUpdated = nil, want the time of the check")
+			}
+		})
+	}
+}
@@ -17,18 +17,20 @@ limitations under the License.
 package cluster
 
 import (
+	"context"
 	"reflect"
 	"testing"
 	"time"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
-	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/internal/test"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
-func fakePollImmediateWaiter(interval, timeout time.Duration, condition wait.ConditionFunc) error {
+func fakeObjectWaiter(ctx context.Context, gvk schema.GroupVersionKind, key client.ObjectKey, timeout time.Duration, condition ObjectConditionFunc) error {
 	return nil
 }
 
@@ -58,15 +60,15 @@ func Test_inventoryClient_EnsureCustomResourceDefinitions(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			p := newInventoryClient(test.NewFakeProxy(), fakePollImmediateWaiter)
+			p := newInventoryClient(test.NewFakeProxy(), fakeObjectWaiter)
 			if tt.fields.alreadyHasCRD {
 				//forcing creation of metadata before test
-				if err := p.EnsureCustomResourceDefinitions(); err != nil {
+				if err := p.EnsureCustomResourceDefinitions(ctx); err != nil {
 					t.Fatal(err)
 				}
 			}
 
-			err := p.EnsureCustomResourceDefinitions()
+			err := p.EnsureCustomResourceDefinitions(ctx)
 			if (err != nil) != tt.wantErr {
 				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
 			}
@@ -74,11 +76,45 @@ func Test_inventoryClient_EnsureCustomResourceDefinitions(t *testing.T) {
 	}
 }
 
+func Test_inventoryClient_HasCustomResourceDefinitions(t *testing.T) {
+	tests := []struct {
+		name  string
+		proxy *test.FakeProxy
+		want  bool
+	}{
+		{
+			name:  "Has CRD",
+			proxy: test.NewFakeProxy(),
+			want:  true,
+		},
+		{
+			name:  "Has not CRD",
+			proxy: test.NewFakeProxy().WithoutInventoryCRD(),
+			want:  false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newInventoryClient(tt.proxy, fakeObjectWaiter)
+
+			got, err := p.HasCustomResourceDefinitions(ctx)
+			if err != nil {
+				t.Fatalf("error = %v, want nil", err)
+			}
+			if got != tt.want {
+				t.Errorf("got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 var fooProvider = clusterctlv1.Provider{ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "ns1"}}
+var barProvider = clusterctlv1.Provider{ObjectMeta: metav1.ObjectMeta{Name: "bar", Namespace: "ns2"}}
 
 func Test_inventoryClient_List(t *testing.T) {
 	type fields struct {
-		initObjs []runtime.Object
+		initObjs   []runtime.Object
+		namespaces []string
 	}
 	tests := []struct {
 		name    string
@@ -98,11 +134,25 @@ func Test_inventoryClient_List(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "Get list scoped to a namespace",
+			fields: fields{
+				initObjs: []runtime.Object{
+					&fooProvider,
+					&barProvider,
+				},
+				namespaces: []string{"ns2"},
+			},
+			want: []clusterctlv1.Provider{
+				barProvider,
+			},
+			wantErr: false,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			p := newInventoryClient(test.NewFakeProxy().WithObjs(tt.fields.initObjs...), fakePollImmediateWaiter)
-			got, err := p.List()
+			p := newScopedInventoryClient(test.NewFakeProxy().WithObjs(tt.fields.initObjs...), fakeObjectWaiter, tt.fields.namespaces)
+			got, err := p.List(ctx)
 			if (err != nil) != tt.wantErr {
 				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
 			}
@@ -116,3 +166,45 @@ func Test_inventoryClient_List(t *testing.T) {
 		})
 	}
 }
+
+func Test_inventoryClient_CreateOutOfScope(t *testing.T) {
+	p := newScopedInventoryClient(test.NewFakeProxy(), fakeObjectWaiter, []string{"ns2"})
+
+	if err := p.Create(ctx, fooProvider); err == nil {
+		t.Fatal("expected error creating a provider outside of the inventory namespaces, got nil")
+	}
+	if err := p.Create(ctx, barProvider); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+}
+
+func Test_inventoryClient_Status(t *testing.T) {
+	p := newInventoryClient(test.NewFakeProxy(), fakeObjectWaiter)
+
+	provider := *barProvider.DeepCopy()
+	provider.Status = newProviderStatus("v1.0.0", "v1alpha3")
+	if err := p.Create(ctx, provider); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	got, err := p.List(ctx)
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(got.Items) != 1 || got.Items[0].Status.Contract != "v1alpha3" || got.Items[0].Status.Health != clusterctlv1.ProviderHealthUnknown {
+		t.Fatalf("got = %v, want the provider with the status recorded on create", got.Items)
+	}
+
+	provider.Status = ProviderHealthStatus(got.Items[0], nil)
+	if err := p.UpdateStatus(ctx, provider); err != nil {
+		t.Fatalf("UpdateStatus() error = %v", err)
+	}
+
+	got, err = p.List(ctx)
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(got.Items) != 1 || got.Items[0].Status.Contract != "v1alpha3" || got.Items[0].Status.Health != clusterctlv1.ProviderHealthy {
+		t.Errorf("got = %v, want the provider healthy, with the contract preserved", got.Items)
+	}
+}
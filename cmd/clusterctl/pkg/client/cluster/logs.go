@@ -0,0 +1,304 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kerrors "k8s.io/apimachinery/pkg/util/errors"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// LogsOptions carries the options supported by LogsClient.
+type LogsOptions struct {
+	// Follow the logs of the provider controllers until the operation is interrupted.
+	Follow bool
+
+	// Since, if not zero, limits the logs to the ones written after the given duration relative to the current time.
+	Since time.Duration
+
+	// Previous returns the logs of the previous instance of the controller containers, if any.
+	Previous bool
+
+	// TailLines, if not zero, limits the logs to the given number of most recent lines of each container.
+	TailLines int64
+}
+
+const (
+	// maxLogLineSize is the size after which a log line is split, so a very long line, e.g. an object dumped
+	// in the logs, does not fail reading the logs.
+	maxLogLineSize = 1024 * 1024
+
+	// defaultReadTailLines is the number of most recent lines of each container returned by Read when
+	// options.TailLines is not set, bounding the memory used for holding the logs.
+	defaultReadTailLines = 10000
+)
+
+// LogLine is a line read from the logs of a provider controller.
+type LogLine struct {
+	// Timestamp when the line was written; it is zero if the timestamp can't be determined.
+	Timestamp time.Time
+
+	// Pod and Container the line comes from.
+	Pod       string
+	Container string
+
+	// Text of the line, without the timestamp.
+	Text string
+}
+
+// String returns the line prefixed with the name of the pod and of the container it comes from, and with its timestamp.
+func (l LogLine) String() string {
+	if l.Timestamp.IsZero() {
+		return fmt.Sprintf("[%s/%s] %s", l.Pod, l.Container, l.Text)
+	}
+	return fmt.Sprintf("[%s/%s] %s %s", l.Pod, l.Container, l.Timestamp.Format(time.RFC3339Nano), l.Text)
+}
+
+// LogsClient has methods to read the logs of the provider controllers.
+type LogsClient interface {
+	// Stream writes the logs of all the containers in the pods of a provider instance to out.
+	// Logs from different pods and containers are merged line by line, and each line is prefixed with
+	// the name of the pod and of the container it comes from.
+	Stream(ctx context.Context, provider clusterctlv1.Provider, options LogsOptions, out io.Writer) error
+
+	// Read returns the logs of all the containers in the pods of a provider instance, including the timestamp
+	// of each line; options.Follow is ignored, and if options.TailLines is not set only the most recent
+	// lines of each container are returned.
+	Read(ctx context.Context, provider clusterctlv1.Provider, options LogsOptions) ([]LogLine, error)
+}
+
+// providerLogs implements LogsClient.
+type providerLogs struct {
+	proxy Proxy
+}
+
+// ensure providerLogs implements LogsClient.
+var _ LogsClient = &providerLogs{}
+
+func (p *providerLogs) Stream(ctx context.Context, provider clusterctlv1.Provider, options LogsOptions, out io.Writer) error {
+	pods, err := p.getPods(ctx, provider)
+	if err != nil {
+		return err
+	}
+
+	cs, err := p.proxy.NewClientSet()
+	if err != nil {
+		return err
+	}
+
+	// Open a log stream for each container, and then copy all the streams to out concurrently;
+	// the mutex ensures lines coming from different streams are not interleaved.
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	errList := []error{}
+	addErr := func(err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		errList = append(errList, err)
+	}
+
+	for i := range pods {
+		pod := pods[i]
+		for _, container := range pod.Spec.Containers {
+			stream, err := cs.CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, podLogOptions(container.Name, options, false)).Stream()
+			if err != nil {
+				addErr(errors.Wrapf(err, "failed to get logs for container %s of pod %s/%s", container.Name, pod.Namespace, pod.Name))
+				continue
+			}
+
+			prefix := fmt.Sprintf("[%s/%s] ", pod.Name, container.Name)
+			wg.Add(1)
+			go func(stream io.ReadCloser) {
+				defer wg.Done()
+				defer stream.Close()
+
+				scanner := newLogScanner(stream)
+				for scanner.Scan() {
+					mu.Lock()
+					_, err := fmt.Fprintf(out, "%s%s\n", prefix, scanner.Text())
+					mu.Unlock()
+					if err != nil {
+						addErr(errors.Wrap(err, "failed to write logs"))
+						return
+					}
+				}
+				if err := scanner.Err(); err != nil {
+					addErr(errors.Wrapf(err, "failed to read logs from %s", prefix))
+				}
+			}(stream)
+		}
+	}
+	wg.Wait()
+
+	return kerrors.NewAggregate(errList)
+}
+
+func (p *providerLogs) Read(ctx context.Context, provider clusterctlv1.Provider, options LogsOptions) ([]LogLine, error) {
+	pods, err := p.getPods(ctx, provider)
+	if err != nil {
+		return nil, err
+	}
+
+	cs, err := p.proxy.NewClientSet()
+	if err != nil {
+		return nil, err
+	}
+
+	options.Follow = false
+	if options.TailLines == 0 {
+		options.TailLines = defaultReadTailLines
+	}
+
+	var lines []LogLine
+	for _, pod := range pods {
+		for _, container := range pod.Spec.Containers {
+			stream, err := cs.CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, podLogOptions(container.Name, options, true)).Stream()
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to get logs for container %s of pod %s/%s", container.Name, pod.Namespace, pod.Name)
+			}
+
+			scanner := newLogScanner(stream)
+			for scanner.Scan() {
+				lines = append(lines, parseLogLine(pod.Name, container.Name, scanner.Text()))
+			}
+			err = scanner.Err()
+			stream.Close()
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to read logs for container %s of pod %s/%s", container.Name, pod.Namespace, pod.Name)
+			}
+		}
+	}
+	return lines, nil
+}
+
+// podLogOptions returns the PodLogOptions for reading the logs of a container.
+func podLogOptions(container string, options LogsOptions, timestamps bool) *corev1.PodLogOptions {
+	logOptions := &corev1.PodLogOptions{
+		Container:  container,
+		Follow:     options.Follow,
+		Previous:   options.Previous,
+		Timestamps: timestamps,
+	}
+	if options.Since > 0 {
+		sinceSeconds := int64(options.Since.Seconds())
+		logOptions.SinceSeconds = &sinceSeconds
+	}
+	if options.TailLines > 0 {
+		tailLines := options.TailLines
+		logOptions.TailLines = &tailLines
+	}
+	return logOptions
+}
+
+// newLogScanner returns a scanner reading a log stream line by line; lines longer than maxLogLineSize are split.
+func newLogScanner(r io.Reader) *bufio.Scanner {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, bufio.MaxScanTokenSize), maxLogLineSize)
+	scanner.Split(scanLogLines)
+	return scanner
+}
+
+// scanLogLines is a bufio.SplitFunc like bufio.ScanLines, but returning the lines longer than maxLogLineSize in
+// chunks instead of failing with bufio.ErrTooLong.
+func scanLogLines(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	if atEOF && len(data) == 0 {
+		return 0, nil, nil
+	}
+	if i := bytes.IndexByte(data, '\n'); i >= 0 {
+		return i + 1, bytes.TrimSuffix(data[:i], []byte{'\r'}), nil
+	}
+	if atEOF || len(data) >= maxLogLineSize {
+		return len(data), bytes.TrimSuffix(data, []byte{'\r'}), nil
+	}
+	return 0, nil, nil
+}
+
+// parseLogLine parses a log line prefixed with the RFC3339 timestamp added by Kubernetes; if the line does not
+// start with a valid timestamp, the entire line is used as a text.
+func parseLogLine(pod, container, line string) LogLine {
+	ret := LogLine{Pod: pod, Container: container, Text: line}
+	if i := strings.Index(line, " "); i > 0 {
+		if timestamp, err := time.Parse(time.RFC3339Nano, line[:i]); err == nil {
+			ret.Timestamp = timestamp
+			ret.Text = line[i+1:]
+		}
+	}
+	return ret
+}
+
+// getPods returns the pods of the Deployments belonging to a provider instance.
+func (p *providerLogs) getPods(ctx context.Context, provider clusterctlv1.Provider) ([]corev1.Pod, error) {
+	c, err := p.proxy.NewClient()
+	if err != nil {
+		return nil, err
+	}
+
+	deploymentList := &appsv1.DeploymentList{}
+	if err := c.List(ctx, deploymentList,
+		client.InNamespace(provider.Namespace),
+		client.MatchingLabels{
+			clusterctlv1.ClusterctlLabelName: "",
+			clusterv1.ProviderLabelName:      provider.Name,
+		},
+	); err != nil {
+		return nil, errors.Wrapf(err, "failed to list deployments for the %s provider", provider.InstanceName())
+	}
+
+	var pods []corev1.Pod
+	for _, d := range deploymentList.Items {
+		selector, err := metav1.LabelSelectorAsSelector(d.Spec.Selector)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get the pod selector for deployment %s/%s", d.Namespace, d.Name)
+		}
+
+		podList := &corev1.PodList{}
+		if err := c.List(ctx, podList,
+			client.InNamespace(d.Namespace),
+			client.MatchingLabelsSelector{Selector: selector},
+		); err != nil {
+			return nil, errors.Wrapf(err, "failed to list pods for deployment %s/%s", d.Namespace, d.Name)
+		}
+		pods = append(pods, podList.Items...)
+	}
+
+	if len(pods) == 0 {
+		return nil, errors.Errorf("failed to find pods for the %s provider", provider.InstanceName())
+	}
+
+	return pods, nil
+}
+
+// newLogsClient returns a providerLogs.
+func newLogsClient(proxy Proxy) *providerLogs {
+	return &providerLogs{
+		proxy: proxy,
+	}
+}
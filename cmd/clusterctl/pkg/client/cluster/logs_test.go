@@ -0,0 +1,235 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"bytes"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/internal/test"
+)
+
+func Test_providerLogs_Stream(t *testing.T) {
+	deployment := &appsv1.Deployment{
+		TypeMeta: metav1.TypeMeta{
+			Kind: "Deployment",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "ns1",
+			Name:      "controller-manager",
+			Labels: map[string]string{
+				clusterctlv1.ClusterctlLabelName: "",
+				clusterv1.ProviderLabelName:      "infra",
+			},
+		},
+		Spec: appsv1.DeploymentSpec{
+			Selector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{"control-plane": "controller-manager"},
+			},
+		},
+	}
+
+	newPod := func(namespace, name string, labels map[string]string, containers ...string) *corev1.Pod {
+		pod := &corev1.Pod{
+			TypeMeta: metav1.TypeMeta{
+				Kind: "Pod",
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: namespace,
+				Name:      name,
+				Labels:    labels,
+			},
+		}
+		for _, c := range containers {
+			pod.Spec.Containers = append(pod.Spec.Containers, corev1.Container{Name: c})
+		}
+		return pod
+	}
+
+	type args struct {
+		provider clusterctlv1.Provider
+	}
+	tests := []struct {
+		name     string
+		initObjs []runtime.Object
+		args     args
+		want     []string
+		wantErr  bool
+	}{
+		{
+			name: "streams the logs of all the containers of all the provider pods",
+			initObjs: []runtime.Object{
+				deployment,
+				newPod("ns1", "pod1", deployment.Spec.Selector.MatchLabels, "manager", "kube-rbac-proxy"),
+				newPod("ns1", "pod2", deployment.Spec.Selector.MatchLabels, "manager", "kube-rbac-proxy"),
+				newPod("ns1", "pod3", nil, "other"),                                    // a pod not belonging to the provider deployment (should be ignored)
+				newPod("ns2", "pod4", deployment.Spec.Selector.MatchLabels, "manager"), // a pod in another namespace (should be ignored)
+			},
+			args: args{
+				provider: clusterctlv1.Provider{ObjectMeta: metav1.ObjectMeta{Name: "infra", Namespace: "ns1"}},
+			},
+			want: []string{
+				"[pod1/kube-rbac-proxy] fake logs",
+				"[pod1/manager] fake logs",
+				"[pod2/kube-rbac-proxy] fake logs",
+				"[pod2/manager] fake logs",
+			},
+			wantErr: false,
+		},
+		{
+			name: "fails if the provider has no pods",
+			initObjs: []runtime.Object{
+				deployment,
+			},
+			args: args{
+				provider: clusterctlv1.Provider{ObjectMeta: metav1.ObjectMeta{Name: "infra", Namespace: "ns1"}},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newLogsClient(test.NewFakeProxy().WithObjs(tt.initObjs...))
+
+			out := &bytes.Buffer{}
+			err := p.Stream(ctx, tt.args.provider, LogsOptions{}, out)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			// logs from different containers are streamed concurrently, so the order of the lines is not predictable.
+			got := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+			sort.Strings(got)
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_providerLogs_Read(t *testing.T) {
+	deployment := &appsv1.Deployment{
+		TypeMeta: metav1.TypeMeta{
+			Kind: "Deployment",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "ns1",
+			Name:      "controller-manager",
+			Labels: map[string]string{
+				clusterctlv1.ClusterctlLabelName: "",
+				clusterv1.ProviderLabelName:      "infra",
+			},
+		},
+		Spec: appsv1.DeploymentSpec{
+			Selector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{"control-plane": "controller-manager"},
+			},
+		},
+	}
+	pod := &corev1.Pod{
+		TypeMeta: metav1.TypeMeta{
+			Kind: "Pod",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "ns1",
+			Name:      "pod1",
+			Labels:    deployment.Spec.Selector.MatchLabels,
+		},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{Name: "manager"}},
+		},
+	}
+
+	p := newLogsClient(test.NewFakeProxy().WithObjs(deployment, pod))
+
+	got, err := p.Read(ctx, clusterctlv1.Provider{ObjectMeta: metav1.ObjectMeta{Name: "infra", Namespace: "ns1"}}, LogsOptions{Follow: true})
+	if err != nil {
+		t.Fatalf("error = %v, want nil", err)
+	}
+
+	want := []LogLine{{Pod: "pod1", Container: "manager", Text: "fake logs"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got = %v, want %v", got, want)
+	}
+}
+
+func Test_parseLogLine(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		want LogLine
+	}{
+		{
+			name: "line with timestamp",
+			line: "2020-03-12T10:21:13.412337Z I0312 10:21:13.412337 1 controller.go:164] Starting Controller",
+			want: LogLine{
+				Timestamp: time.Date(2020, 3, 12, 10, 21, 13, 412337000, time.UTC),
+				Pod:       "pod1",
+				Container: "manager",
+				Text:      "I0312 10:21:13.412337 1 controller.go:164] Starting Controller",
+			},
+		},
+		{
+			name: "line without timestamp",
+			line: "I0312 10:21:13.412337 1 controller.go:164] Starting Controller",
+			want: LogLine{
+				Pod:       "pod1",
+				Container: "manager",
+				Text:      "I0312 10:21:13.412337 1 controller.go:164] Starting Controller",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseLogLine("pod1", "manager", tt.line)
+			if !got.Timestamp.Equal(tt.want.Timestamp) || got.Pod != tt.want.Pod || got.Container != tt.want.Container || got.Text != tt.want.Text {
+				t.Errorf("got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_newLogScanner(t *testing.T) {
+	longLine := strings.Repeat("x", maxLogLineSize+10)
+	scanner := newLogScanner(strings.NewReader("first\r\n" + longLine + "\nlast"))
+
+	var got []string
+	for scanner.Scan() {
+		got = append(got, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatalf("error = %v, want nil", err)
+	}
+
+	want := []string{"first", longLine[:maxLogLineSize], longLine[maxLogLineSize:], "last"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %d lines, want %d lines split at %d bytes", len(got), len(want), maxLogLineSize)
+	}
+}
@@ -0,0 +1,76 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// MachineActionClient has methods to request actions on Machines to the infrastructure providers.
+type MachineActionClient interface {
+	// Request requests an action, e.g. reboot, on a Machine; the action is performed asynchronously by the
+	// infrastructure provider.
+	Request(ctx context.Context, namespace, name, action string) error
+}
+
+// machineActionClient implements MachineActionClient.
+type machineActionClient struct {
+	proxy Proxy
+}
+
+// ensure machineActionClient implements MachineActionClient.
+var _ MachineActionClient = &machineActionClient{}
+
+func newMachineActionClient(proxy Proxy) *machineActionClient {
+	return &machineActionClient{
+		proxy: proxy,
+	}
+}
+
+func (m *machineActionClient) Request(ctx context.Context, namespace, name, action string) error {
+	c, err := m.proxy.NewClient()
+	if err != nil {
+		return err
+	}
+
+	machine := &clusterv1.Machine{}
+	machineKey := client.ObjectKey{Namespace: namespace, Name: name}
+	if err := c.Get(ctx, machineKey, machine); err != nil {
+		return errors.Wrapf(err, "failed to get Machine %s/%s", namespace, name)
+	}
+
+	if !machine.DeletionTimestamp.IsZero() {
+		return errors.Errorf("Machine %s/%s is being deleted", namespace, name)
+	}
+	if pending, ok := machine.Annotations[clusterv1.ActionAnnotation]; ok {
+		return errors.Errorf("Machine %s/%s has already a pending %q action", namespace, name, pending)
+	}
+
+	patch := client.MergeFrom(machine.DeepCopy())
+	if machine.Annotations == nil {
+		machine.Annotations = map[string]string{}
+	}
+	machine.Annotations[clusterv1.ActionAnnotation] = action
+	if err := c.Patch(ctx, machine, patch); err != nil {
+		return errors.Wrapf(err, "failed to request %q action on Machine %s/%s", action, namespace, name)
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/internal/test"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func Test_machineActionClient_Request(t *testing.T) {
+	now := metav1.Now()
+
+	tests := []struct {
+		name    string
+		objs    []runtime.Object
+		wantErr bool
+	}{
+		{
+			name: "request action",
+			objs: []runtime.Object{
+				&clusterv1.Machine{ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: "m1"}},
+			},
+			wantErr: false,
+		},
+		{
+			name:    "fails if the Machine does not exist",
+			objs:    []runtime.Object{},
+			wantErr: true,
+		},
+		{
+			name: "fails if the Machine has already a pending action",
+			objs: []runtime.Object{
+				&clusterv1.Machine{ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: "m1", Annotations: map[string]string{clusterv1.ActionAnnotation: clusterv1.RebootAction}}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "fails if the Machine is being deleted",
+			objs: []runtime.Object{
+				&clusterv1.Machine{ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: "m1", DeletionTimestamp: &now}},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			proxy := test.NewFakeProxy().WithObjs(tt.objs...)
+			m := newMachineActionClient(proxy)
+
+			err := m.Request(ctx, "ns1", "m1", clusterv1.RebootAction)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			c, err := proxy.NewClient()
+			if err != nil {
+				t.Fatalf("error = %v", err)
+			}
+			machine := &clusterv1.Machine{}
+			if err := c.Get(ctx, client.ObjectKey{Namespace: "ns1", Name: "m1"}, machine); err != nil {
+				t.Fatalf("error = %v", err)
+			}
+			if got := machine.Annotations[clusterv1.ActionAnnotation]; got != clusterv1.RebootAction {
+				t.Errorf("got action annotation %q, want %q", got, clusterv1.RebootAction)
+			}
+		})
+	}
+}
@@ -17,9 +17,13 @@ limitations under the License.
 package cluster
 
 import (
+	"context"
 	"fmt"
+	"sort"
+	"sync"
 	"time"
 
+	"github.com/go-logr/logr"
 	"github.com/pkg/errors"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
@@ -30,34 +34,57 @@ import (
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apimachinery/pkg/util/wait"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
 	logf "sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 // ObjectMover defines methods for moving Cluster API objects to another management cluster.
 type ObjectMover interface {
-	// Move moves all the Cluster API objects existing in a namespace (or from all the namespaces if empty) to a target management cluster.
-	Move(namespace string, toCluster Client) error
+	// Move moves all the Cluster API objects existing in a namespace (or from all the namespaces if empty) to a target management cluster,
+	// eventually remapping the namespaces of the objects in the target management cluster.
+	Move(ctx context.Context, namespace string, toCluster Client, namespaceMapping NamespaceMapping) error
+
+	// Backup saves all the Cluster API objects existing in a namespace (or in all the namespaces if empty) to a directory.
+	Backup(ctx context.Context, namespace string, directory string) error
+
+	// Restore restores all the Cluster API objects saved in a directory to a target management cluster.
+	Restore(ctx context.Context, toCluster Client, directory string) error
 }
 
+// defaultMoveConcurrency is the number of objects created or deleted in parallel by move, within each move group.
+const defaultMoveConcurrency = 10
+
 // objectMover implements the ObjectMover interface.
 type objectMover struct {
 	fromProxy Proxy
+
+	// concurrency is the number of objects in a move group processed in parallel; values lower than 1 are treated as 1.
+	concurrency int
+
+	progress progress
+	log      logr.Logger
 }
 
 // ensure objectMover implements the ObjectMover interface.
 var _ ObjectMover = &objectMover{}
 
-func (o *objectMover) Move(namespace string, toCluster Client) error {
-	log := logf.Log
+func (o *objectMover) Move(ctx context.Context, namespace string, toCluster Client, namespaceMapping NamespaceMapping) (reterr error) {
+	log := o.log
 	log.Info("Performing move...")
 
+	o.progress.started("", "", 0)
+	defer func() {
+		o.progress.completed("", "", 0, 0, reterr)
+	}()
+
 	objectGraph := newObjectGraph(o.fromProxy)
+	objectGraph.log = o.log
 
 	//TODO: implement preflight checks ensuring the target cluster has all the required providers in place
 
 	// Gets all the types defines by the CRDs installed by clusterctl plus the ConfigMap/Secret core types.
-	types, err := objectGraph.getDiscoveryTypes()
+	types, err := objectGraph.getDiscoveryTypes(ctx)
 	if err != nil {
 		return err
 	}
@@ -65,44 +92,90 @@ func (o *objectMover) Move(namespace string, toCluster Client) error {
 	// Discovery the object graph for the selected types:
 	// - Nodes are defined the Kubernetes objects (Clusters, Machines etc.) identified during the discovery process.
 	// - Edges are derived by the OwnerReferences between nodes.
-	if err := objectGraph.Discovery(namespace, types); err != nil {
+	o.progress.started(ProgressStepDiscovery, "", 0)
+	if err := objectGraph.Discovery(ctx, namespace, types); err != nil {
+		o.progress.completed(ProgressStepDiscovery, "", 0, 0, err)
 		return err
 	}
+	discovered := len(objectGraph.getNodesWithClusterTenants())
+	o.progress.completed(ProgressStepDiscovery, "", discovered, discovered, nil)
+	logExcludedNodes(objectGraph, "move")
 
 	// Checks if Cluster API has already completed the provisioning of the infrastructure for the objects involved in the move operation.
 	// This is required because if the infrastructure is provisioned, then we can reasonably assume that the objects we are moving are
 	// not currently waiting for long-running reconciliation loops, and so we can safely rely on the pause field on the Cluster object
 	// for blocking any further object reconciliation on the source objects.
-	if err := o.checkProvisioningCompleted(objectGraph); err != nil {
+	if err := o.checkProvisioningCompleted(ctx, objectGraph); err != nil {
 		return err
 	}
 	//TODO: consider if to add additional preflight checks ensuring the object graph is complete (no virtual nodes left)
 
 	// Move the objects to the target cluster.
-	if err := o.move(objectGraph, toCluster.Proxy()); err != nil {
+	if err := o.move(ctx, objectGraph, toCluster.Proxy(), namespaceMapping); err != nil {
 		return err
 	}
 
 	return nil
 }
 
-func newObjectMover(fromProxy Proxy) *objectMover {
+// logExcludedNodes reports the objects excluded from move or backup by the clusterctl.cluster.x-k8s.io/move annotation,
+// either directly or because they are owned by an excluded object.
+func logExcludedNodes(graph *objectGraph, operation string) {
+	log := graph.log
+
+	excluded := graph.getExcludedNodes()
+	if len(excluded) == 0 {
+		return
+	}
+	sort.Slice(excluded, func(i, j int) bool {
+		a, b := excluded[i].identity, excluded[j].identity
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		return a.Name < b.Name
+	})
+
+	log.Info(fmt.Sprintf("Excluding objects from %s", operation), "Annotation", fmt.Sprintf("%s=false", clusterctlv1.ClusterctlMoveAnnotation), "Count", len(excluded))
+	for _, n := range excluded {
+		log.Info("Excluded", n.identity.Kind, n.identity.Name, "Namespace", n.identity.Namespace)
+	}
+}
+
+func newObjectMover(fromProxy Proxy, progressReporter ProgressReporter) *objectMover {
 	return &objectMover{
-		fromProxy: fromProxy,
+		fromProxy:   fromProxy,
+		concurrency: defaultMoveConcurrency,
+		progress:    newProgress(progressReporter, ProgressOperationMove),
+		log:         logf.Log,
 	}
 }
 
 // checkProvisioningCompleted checks if Cluster API has already completed the provisioning of the infrastructure for the objects involved in the move operation.
-func (o *objectMover) checkProvisioningCompleted(graph *objectGraph) error {
+func (o *objectMover) checkProvisioningCompleted(ctx context.Context, graph *objectGraph) error {
 	errList := []error{}
 	cFrom, err := o.fromProxy.NewClient()
 	if err != nil {
 		return err
 	}
 
+	// Reading Cluster and Machine objects using the newest version served by the management cluster, so
+	// the checks work across clusters at different contract levels.
+	clusterGVK, err := negotiateGroupVersionKind(ctx, o.fromProxy, clusterv1.GroupVersion.WithKind("Cluster").GroupKind(), clusterv1.GroupVersion.Version)
+	if err != nil {
+		return err
+	}
+	machineGVK, err := negotiateGroupVersionKind(ctx, o.fromProxy, clusterv1.GroupVersion.WithKind("Machine").GroupKind(), clusterv1.GroupVersion.Version)
+	if err != nil {
+		return err
+	}
+
 	// Checking all the clusters have infrastructure is ready
 	for _, cluster := range graph.getClusters() {
-		clusterObj := &clusterv1.Cluster{}
+		clusterObj := &unstructured.Unstructured{}
+		clusterObj.SetGroupVersionKind(clusterGVK)
 		clusterObjKey := client.ObjectKey{
 			Namespace: cluster.identity.Namespace,
 			Name:      cluster.identity.Name,
@@ -110,20 +183,22 @@ func (o *objectMover) checkProvisioningCompleted(graph *objectGraph) error {
 
 		if err := cFrom.Get(ctx, clusterObjKey, clusterObj); err != nil {
 			return errors.Wrapf(err, "error reading %q %s/%s",
-				clusterObj.GroupVersionKind(), clusterObj.GetNamespace(), clusterObj.GetName())
+				clusterObj.GroupVersionKind(), clusterObjKey.Namespace, clusterObjKey.Name)
 		}
 
-		if !clusterObj.Status.InfrastructureReady {
+		// NB. status fields not defined in older API versions are considered not set.
+		if infrastructureReady, _, _ := unstructured.NestedBool(clusterObj.Object, "status", "infrastructureReady"); !infrastructureReady {
 			errList = append(errList, errors.Errorf("cannot start the move operation while %q %s/%s is still provisioning the infrastructure", clusterObj.GroupVersionKind(), clusterObj.GetNamespace(), clusterObj.GetName()))
 			continue
 		}
 
-		if !clusterObj.Status.ControlPlaneInitialized {
+		if controlPlaneInitialized, _, _ := unstructured.NestedBool(clusterObj.Object, "status", "controlPlaneInitialized"); !controlPlaneInitialized {
 			errList = append(errList, errors.Errorf("cannot start the move operation while the control plane for %q %s/%s is not yet initialized", clusterObj.GroupVersionKind(), clusterObj.GetNamespace(), clusterObj.GetName()))
 			continue
 		}
 
-		if clusterObj.Spec.ControlPlaneRef != nil && !clusterObj.Status.ControlPlaneReady {
+		_, hasControlPlaneRef, _ := unstructured.NestedMap(clusterObj.Object, "spec", "controlPlaneRef")
+		if controlPlaneReady, _, _ := unstructured.NestedBool(clusterObj.Object, "status", "controlPlaneReady"); hasControlPlaneRef && !controlPlaneReady {
 			errList = append(errList, errors.Errorf("cannot start the move operation while the control plane for %q %s/%s is not yet ready", clusterObj.GroupVersionKind(), clusterObj.GetNamespace(), clusterObj.GetName()))
 			continue
 		}
@@ -132,7 +207,8 @@ func (o *objectMover) checkProvisioningCompleted(graph *objectGraph) error {
 	// Checking all the machine have a NodeRef
 	// Nb. NodeRef is considered a better signal than InfrastructureReady, because it ensures the node in the workload cluster is up and running.
 	for _, machine := range graph.getMachines() {
-		machineObj := &clusterv1.Machine{}
+		machineObj := &unstructured.Unstructured{}
+		machineObj.SetGroupVersionKind(machineGVK)
 		machineObjKey := client.ObjectKey{
 			Namespace: machine.identity.Namespace,
 			Name:      machine.identity.Name,
@@ -140,10 +216,10 @@ func (o *objectMover) checkProvisioningCompleted(graph *objectGraph) error {
 
 		if err := cFrom.Get(ctx, machineObjKey, machineObj); err != nil {
 			return errors.Wrapf(err, "error reading %q %s/%s",
-				machineObj.GroupVersionKind(), machineObj.GetNamespace(), machineObj.GetName())
+				machineObj.GroupVersionKind(), machineObjKey.Namespace, machineObjKey.Name)
 		}
 
-		if machineObj.Status.NodeRef == nil {
+		if _, hasNodeRef, _ := unstructured.NestedMap(machineObj.Object, "status", "nodeRef"); !hasNodeRef {
 			errList = append(errList, errors.Errorf("cannot start the move operation while %q %s/%s is still provisioning the node", machineObj.GroupVersionKind(), machineObj.GetNamespace(), machineObj.GetName()))
 		}
 	}
@@ -152,21 +228,38 @@ func (o *objectMover) checkProvisioningCompleted(graph *objectGraph) error {
 }
 
 // Move moves all the Cluster API objects existing in a namespace (or from all the namespaces if empty) to a target management cluster
-func (o *objectMover) move(graph *objectGraph, toProxy Proxy) error {
-	log := logf.Log
+func (o *objectMover) move(ctx context.Context, graph *objectGraph, toProxy Proxy, namespaceMapping NamespaceMapping) error {
+	log := o.log
 
 	clusters := graph.getClusters()
 	log.Info("Moving Cluster API objects", "Clusters", len(clusters))
 
+	// Resolves the target namespaces, failing before changing anything if two namespaces collide in the target management cluster.
+	namespaces := sets.NewString()
+	for _, n := range graph.getNodesWithClusterTenants() {
+		if n.identity.Namespace != "" {
+			namespaces.Insert(n.identity.Namespace)
+		}
+	}
+	remapper, err := namespaceMapping.resolve(namespaces)
+	if err != nil {
+		return err
+	}
+	for _, namespace := range namespaces.List() {
+		if target := remapper.namespace(namespace); target != namespace {
+			log.Info("Remapping namespace", "Namespace", namespace, "TargetNamespace", target)
+		}
+	}
+
 	// Sets the pause field on the Cluster object in the source management cluster, so the controllers stop reconciling it.
 	log.V(1).Info("Pausing the source cluster")
-	if err := setClusterPause(o.fromProxy, clusters, true); err != nil {
+	if err := setClusterPause(ctx, o.log, o.fromProxy, clusters, true); err != nil {
 		return err
 	}
 
 	// Ensure all the expected target namespaces are in place before creating objects.
 	log.V(1).Info("Creating target namespaces, if missing")
-	if err := o.ensureNamespaces(graph, toProxy); err != nil {
+	if err := o.ensureNamespaces(ctx, remapper.nodes(graph.getNodesWithClusterTenants()), toProxy); err != nil {
 		return err
 	}
 
@@ -176,26 +269,59 @@ func (o *objectMover) move(graph *objectGraph, toProxy Proxy) error {
 	// - All the MachineDeployments should be moved second (group 1, processed in parallel)
 	// - then all the MachineSets, then all the Machines, etc.
 	moveSequence := getMoveSequence(graph)
+	total := len(moveSequence.nodesMap)
+
+	cFrom, err := o.fromProxy.NewClient()
+	if err != nil {
+		return err
+	}
+	cTo, err := toProxy.NewClient()
+	if err != nil {
+		return err
+	}
+
+	// Read all the objects to be moved in bulk, now that the Clusters are paused.
+	log.V(1).Info("Reading objects from the source cluster")
+	sourceObjs, err := readObjects(ctx, cFrom, graph.getNodesWithClusterTenants())
+	if err != nil {
+		return err
+	}
 
 	// Create all objects group by group, ensuring all the ownerReferences are re-created.
-	log.Info("Creating objects in the target cluster")
+	log.Info("Creating objects in the target cluster", "Objects", total)
+	o.progress.started(ProgressStepCreateObjects, "", total)
+	created := 0
 	for groupIndex := 0; groupIndex < len(moveSequence.groups); groupIndex++ {
-		if err := o.createGroup(moveSequence.getGroup(groupIndex), toProxy); err != nil {
+		group := moveSequence.getGroup(groupIndex)
+		if err := o.createGroup(ctx, group, cFrom, cTo, sourceObjs, remapper); err != nil {
+			o.progress.completed(ProgressStepCreateObjects, "", created, total, err)
 			return err
 		}
+		created += len(group)
+		log.Info("Created objects", "Group", fmt.Sprintf("%d/%d", groupIndex+1, len(moveSequence.groups)), "Progress", fmt.Sprintf("%d/%d", created, total))
+		o.progress.updated(ProgressStepCreateObjects, "", created, total)
 	}
+	o.progress.completed(ProgressStepCreateObjects, "", created, total, nil)
 
 	// Delete all objects group by group in reverse order.
-	log.Info("Deleting objects from the source cluster")
+	log.Info("Deleting objects from the source cluster", "Objects", total)
+	o.progress.started(ProgressStepDeleteObjects, "", total)
+	deleted := 0
 	for groupIndex := len(moveSequence.groups) - 1; groupIndex >= 0; groupIndex-- {
-		if err := o.deleteGroup(moveSequence.getGroup(groupIndex)); err != nil {
+		group := moveSequence.getGroup(groupIndex)
+		if err := o.deleteGroup(ctx, group, cFrom); err != nil {
+			o.progress.completed(ProgressStepDeleteObjects, "", deleted, total, err)
 			return err
 		}
+		deleted += len(group)
+		log.Info("Deleted objects", "Group", fmt.Sprintf("%d/%d", len(moveSequence.groups)-groupIndex, len(moveSequence.groups)), "Progress", fmt.Sprintf("%d/%d", deleted, total))
+		o.progress.updated(ProgressStepDeleteObjects, "", deleted, total)
 	}
+	o.progress.completed(ProgressStepDeleteObjects, "", deleted, total, nil)
 
 	// Reset the pause field on the Cluster object in the target management cluster, so the controllers start reconciling it.
 	log.V(1).Info("Resuming the target cluster")
-	if err := setClusterPause(toProxy, clusters, false); err != nil {
+	if err := setClusterPause(ctx, o.log, toProxy, remapper.nodes(clusters), false); err != nil {
 		return err
 	}
 
@@ -231,17 +357,23 @@ func (s *moveSequence) getGroup(i int) moveGroup {
 
 // Define the move sequence by processing the ownerReference chain.
 func getMoveSequence(graph *objectGraph) *moveSequence {
+	// Process all the nodes in the graph that belong to a Cluster.
+	// NB. it is necessary to filter out nodes not belonging to a cluster because e.g. discovery reads all the secrets,
+	// but only few of them are related to Clusters/Machines etc.
+	return newMoveSequence(graph.getNodesWithClusterTenants())
+}
+
+// newMoveSequence defines a move sequence for the given nodes by processing the ownerReference chain.
+func newMoveSequence(nodes []*node) *moveSequence {
 	moveSequence := &moveSequence{
 		groups:   []moveGroup{},
 		nodesMap: make(map[*node]empty),
 	}
 
 	for {
-		// Determine the next move group by processing all the nodes in the graph that belong to a Cluster.
-		// NB. it is necessary to filter out nodes not belonging to a cluster because e.g. discovery reads all the secrets,
-		// but only few of them are related to Clusters/Machines etc.
+		// Determine the next move group by processing all the nodes.
 		moveGroup := moveGroup{}
-		for _, n := range graph.getNodesWithClusterTenants() {
+		for _, n := range nodes {
 			// If the node was already included in the moveSequence, skip it.
 			if moveSequence.hasNode(n) {
 				continue
@@ -276,42 +408,20 @@ func getMoveSequence(graph *objectGraph) *moveSequence {
 	return moveSequence
 }
 
-// setClusterPause sets the paused field on a Cluster object.
-func setClusterPause(proxy Proxy, clusters []*node, value bool) error {
-	log := logf.Log
-	patch := client.RawPatch(types.MergePatchType, []byte(fmt.Sprintf("{\"spec\":{\"paused\":%t}}", value)))
-
+// setClusterPause sets the paused field on the Cluster objects.
+func setClusterPause(ctx context.Context, log logr.Logger, proxy Proxy, clusters []*node, value bool) error {
 	for _, cluster := range clusters {
 		log.V(5).Info("Set Cluster.Spec.Paused", "Cluster", cluster.identity.Name, "Namespace", cluster.identity.Namespace)
-
-		cFrom, err := proxy.NewClient()
-		if err != nil {
+		if err := patchClusterPaused(ctx, proxy, cluster.identity.Namespace, cluster.identity.Name, value); err != nil {
 			return err
 		}
-
-		clusterObj := &clusterv1.Cluster{}
-		clusterObjKey := client.ObjectKey{
-			Namespace: cluster.identity.Namespace,
-			Name:      cluster.identity.Name,
-		}
-
-		if err := cFrom.Get(ctx, clusterObjKey, clusterObj); err != nil {
-			return errors.Wrapf(err, "error reading %q %s/%s",
-				clusterObj.GroupVersionKind(), clusterObj.GetNamespace(), clusterObj.GetName())
-		}
-
-		if err := cFrom.Patch(ctx, clusterObj, patch); err != nil {
-			return errors.Wrapf(err, "error pausing reconciliation for %q %s/%s",
-				clusterObj.GroupVersionKind(), clusterObj.GetNamespace(), clusterObj.GetName())
-		}
-
 	}
 	return nil
 }
 
-// ensureNamespaces ensures all the expected target namespaces are in place before creating objects.
-func (o *objectMover) ensureNamespaces(graph *objectGraph, toProxy Proxy) error {
-	log := logf.Log
+// ensureNamespaces ensures all the expected target namespaces are in place before creating the objects corresponding to the nodes.
+func (o *objectMover) ensureNamespaces(ctx context.Context, nodes []*node, toProxy Proxy) error {
+	log := o.log
 
 	cs, err := toProxy.NewClient()
 	if err != nil {
@@ -319,7 +429,7 @@ func (o *objectMover) ensureNamespaces(graph *objectGraph, toProxy Proxy) error
 	}
 
 	namespaces := sets.NewString()
-	for _, node := range graph.getNodesWithClusterTenants() {
+	for _, node := range nodes {
 		namespace := node.identity.Namespace
 
 		// If the namespace was already processed, skip it.
@@ -388,53 +498,113 @@ const (
 	retryIntervalCreateTargetObject = 1 * time.Second
 )
 
-// createGroup creates all the Kubernetes objects into the target management cluster corresponding to the object graph nodes in a moveGroup.
-func (o *objectMover) createGroup(group moveGroup, toProxy Proxy) error {
+// runConcurrently runs action for all the nodes in a moveGroup, processing up to o.concurrency nodes in parallel,
+// and returns the aggregate of the errors.
+// Nb. Nodes in the same group have no ownership relation with each other, so they can be processed in any order.
+func (o *objectMover) runConcurrently(group moveGroup, action func(n *node) error) error {
+	concurrency := o.concurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
 	errList := []error{}
+	sem := make(chan empty, concurrency)
 	for i := range group {
-		nodeToCreate := group[i]
+		n := group[i]
+		sem <- empty{}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			if err := action(n); err != nil {
+				mu.Lock()
+				defer mu.Unlock()
+				errList = append(errList, err)
+			}
+		}()
+	}
+	wg.Wait()
 
-		// Creates the Kubernetes object corresponding to the nodeToCreate.
-		// Nb. The operation is wrapped in a retry loop to make move more resilient to unexpected conditions.
-		err := retry(retryCreateTargetObject, retryIntervalCreateTargetObject, func() error {
-			return o.createTargetObject(nodeToCreate, toProxy)
-		})
-		if err != nil {
-			errList = append(errList, err)
-		}
+	return kerrors.NewAggregate(errList)
+}
+
+// readObjects reads the Kubernetes objects corresponding to the object graph nodes with one List for each type and namespace,
+// instead of one Get for each object; the objects are returned indexed by UID.
+func readObjects(ctx context.Context, c client.Client, nodes []*node) (map[types.UID]*unstructured.Unstructured, error) {
+	type listKey struct {
+		apiVersion string
+		kind       string
+		namespace  string
 	}
 
-	if len(errList) > 0 {
-		return kerrors.NewAggregate(errList)
+	uids := map[types.UID]empty{}
+	keys := map[listKey]empty{}
+	for _, n := range nodes {
+		uids[n.identity.UID] = empty{}
+		keys[listKey{apiVersion: n.identity.APIVersion, kind: n.identity.Kind, namespace: n.identity.Namespace}] = empty{}
 	}
 
-	return nil
+	objs := map[types.UID]*unstructured.Unstructured{}
+	for key := range keys {
+		items, err := listObjects(ctx, c, metav1.TypeMeta{APIVersion: key.apiVersion, Kind: key.kind}, key.namespace)
+		if err != nil {
+			return nil, err
+		}
+		for i := range items {
+			if _, ok := uids[items[i].GetUID()]; ok {
+				objs[items[i].GetUID()] = &items[i]
+			}
+		}
+	}
+	return objs, nil
 }
 
-// createTargetObject creates the Kubernetes object in the target Management cluster corresponding to the object graph node, taking care of restoring the OwnerReference with the owner nodes, if any.
-func (o *objectMover) createTargetObject(nodeToCreate *node, toProxy Proxy) error {
-	log := logf.Log
+// createGroup creates all the Kubernetes objects into the target management cluster corresponding to the object graph nodes in a moveGroup.
+func (o *objectMover) createGroup(ctx context.Context, group moveGroup, cFrom, cTo client.Client, sourceObjs map[types.UID]*unstructured.Unstructured, remapper namespaceRemapper) error {
+	return o.runConcurrently(group, func(nodeToCreate *node) error {
+		// Creates the Kubernetes object corresponding to the nodeToCreate.
+		// Nb. The operation is wrapped in a retry loop to make move more resilient to unexpected conditions.
+		return retry(o.log, retryCreateTargetObject, retryIntervalCreateTargetObject, func() error {
+			return o.createTargetObject(ctx, nodeToCreate, cFrom, cTo, sourceObjs[nodeToCreate.identity.UID], remapper)
+		})
+	})
+}
+
+// createTargetObject creates the Kubernetes object in the target Management cluster corresponding to the object graph node, taking care of restoring the OwnerReference with the owner nodes, if any,
+// and of moving the object to its target namespace.
+// If the source object was not read in bulk, e.g. because it was created after the bulk read, it is read from the source management cluster.
+func (o *objectMover) createTargetObject(ctx context.Context, nodeToCreate *node, cFrom, cTo client.Client, sourceObj *unstructured.Unstructured, remapper namespaceRemapper) error {
+	log := o.log
 	log.V(1).Info("Creating", nodeToCreate.identity.Kind, nodeToCreate.identity.Name, "Namespace", nodeToCreate.identity.Namespace)
 
-	cFrom, err := o.fromProxy.NewClient()
-	if err != nil {
-		return err
-	}
+	var obj *unstructured.Unstructured
+	if sourceObj != nil {
+		obj = sourceObj.DeepCopy()
+	} else {
+		// Get the source object
+		obj = &unstructured.Unstructured{}
+		obj.SetAPIVersion(nodeToCreate.identity.APIVersion)
+		obj.SetKind(nodeToCreate.identity.Kind)
+		objKey := client.ObjectKey{
+			Namespace: nodeToCreate.identity.Namespace,
+			Name:      nodeToCreate.identity.Name,
+		}
 
-	// Get the source object
-	obj := &unstructured.Unstructured{}
-	obj.SetAPIVersion(nodeToCreate.identity.APIVersion)
-	obj.SetKind(nodeToCreate.identity.Kind)
-	objKey := client.ObjectKey{
-		Namespace: nodeToCreate.identity.Namespace,
-		Name:      nodeToCreate.identity.Name,
+		if err := cFrom.Get(ctx, objKey, obj); err != nil {
+			return errors.Wrapf(err, "error reading %q %s/%s",
+				obj.GroupVersionKind(), obj.GetNamespace(), obj.GetName())
+		}
 	}
 
-	if err := cFrom.Get(ctx, objKey, obj); err != nil {
-		return errors.Wrapf(err, "error reading %q %s/%s",
-			obj.GroupVersionKind(), obj.GetNamespace(), obj.GetName())
-	}
+	remapper.object(obj)
+
+	return createObject(ctx, o.log, nodeToCreate, obj, cTo)
+}
 
+// createObject creates a Kubernetes object in a management cluster, taking care of restoring the OwnerReference with the owner nodes, if any.
+func createObject(ctx context.Context, log logr.Logger, nodeToCreate *node, obj *unstructured.Unstructured, cTo client.Client) error {
 	// New objects cannot have a specified resource version. Clear it out.
 	obj.SetResourceVersion("")
 
@@ -465,11 +635,6 @@ func (o *objectMover) createTargetObject(nodeToCreate *node, toProxy Proxy) erro
 	}
 
 	// Creates the targetObj into the target management cluster.
-	cTo, err := toProxy.NewClient()
-	if err != nil {
-		return err
-	}
-
 	if err := cTo.Create(ctx, obj); err != nil {
 		if !apierrors.IsAlreadyExists(err) {
 			return errors.Wrapf(err, "error creating %q %s/%s",
@@ -484,6 +649,10 @@ func (o *objectMover) createTargetObject(nodeToCreate *node, toProxy Proxy) erro
 		existingTargetObj := &unstructured.Unstructured{}
 		existingTargetObj.SetAPIVersion(obj.GetAPIVersion())
 		existingTargetObj.SetKind(obj.GetKind())
+		objKey := client.ObjectKey{
+			Namespace: obj.GetNamespace(),
+			Name:      obj.GetName(),
+		}
 		if err := cTo.Get(ctx, objKey, existingTargetObj); err != nil {
 			return errors.Wrapf(err, "error reading resource for %q %s/%s",
 				existingTargetObj.GroupVersionKind(), existingTargetObj.GetNamespace(), existingTargetObj.GetName())
@@ -509,23 +678,14 @@ const (
 )
 
 // deleteGroup deletes all the Kubernetes objects from the source management cluster corresponding to the object graph nodes in a moveGroup.
-func (o *objectMover) deleteGroup(group moveGroup) error {
-	errList := []error{}
-	for i := range group {
-		nodeToDelete := group[i]
-
+func (o *objectMover) deleteGroup(ctx context.Context, group moveGroup, cFrom client.Client) error {
+	return o.runConcurrently(group, func(nodeToDelete *node) error {
 		// Delete the Kubernetes object corresponding to the current node.
 		// Nb. The operation is wrapped in a retry loop to make move more resilient to unexpected conditions.
-		err := retry(retryDeleteSourceObject, retryIntervalDeleteSourceObject, func() error {
-			return o.deleteSourceObject(nodeToDelete)
+		return retry(o.log, retryDeleteSourceObject, retryIntervalDeleteSourceObject, func() error {
+			return o.deleteSourceObject(ctx, nodeToDelete, cFrom)
 		})
-
-		if err != nil {
-			errList = append(errList, err)
-		}
-	}
-
-	return kerrors.NewAggregate(errList)
+	})
 }
 
 var (
@@ -534,15 +694,10 @@ var (
 
 // deleteSourceObject deletes the Kubernetes object corresponding to the node from the source management cluster, taking care of removing all the finalizers so
 // the objects gets immediately deleted (force delete).
-func (o *objectMover) deleteSourceObject(nodeToDelete *node) error {
-	log := logf.Log
+func (o *objectMover) deleteSourceObject(ctx context.Context, nodeToDelete *node, cFrom client.Client) error {
+	log := o.log
 	log.V(1).Info("Deleting", nodeToDelete.identity.Kind, nodeToDelete.identity.Name, "Namespace", nodeToDelete.identity.Namespace)
 
-	cFrom, err := o.fromProxy.NewClient()
-	if err != nil {
-		return err
-	}
-
 	// Get the source object
 	sourceObj := &unstructured.Unstructured{}
 	sourceObj.SetAPIVersion(nodeToDelete.identity.APIVersion)
@@ -577,9 +732,7 @@ func (o *objectMover) deleteSourceObject(nodeToDelete *node) error {
 	return nil
 }
 
-func retry(attempts int, interval time.Duration, action func() error) error {
-	log := logf.Log
-
+func retry(log logr.Logger, attempts int, interval time.Duration, action func() error) error {
 	var errorToReturn error
 	for i := 0; i < attempts; i++ {
 		if err := action(); err != nil {
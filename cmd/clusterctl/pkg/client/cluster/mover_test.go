@@ -17,15 +17,20 @@ limitations under the License.
 package cluster
 
 import (
+	"fmt"
 	"reflect"
 	"sort"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/pkg/errors"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/internal/test"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -372,7 +377,7 @@ func Test_getMoveSequence(t *testing.T) {
 			}
 
 			// trigger discovery the content of the source cluster
-			if err := graph.Discovery("ns1", discoveryTypes); err != nil {
+			if err := graph.Discovery(ctx, "ns1", discoveryTypes); err != nil {
 				t.Fatal(err)
 			}
 
@@ -412,7 +417,7 @@ func Test_objectMover_move(t *testing.T) {
 			}
 
 			// trigger discovery the content of the source cluster
-			if err := graph.Discovery("ns1", discoveryTypes); err != nil {
+			if err := graph.Discovery(ctx, "ns1", discoveryTypes); err != nil {
 				t.Fatal(err)
 			}
 
@@ -421,10 +426,11 @@ func Test_objectMover_move(t *testing.T) {
 
 			// Run move
 			mover := objectMover{
-				fromProxy: graph.proxy,
+				fromProxy:   graph.proxy,
+				concurrency: defaultMoveConcurrency,
 			}
 
-			err = mover.move(graph, toProxy)
+			err = mover.move(ctx, graph, toProxy, NamespaceMapping{})
 			if (err != nil) != tt.wantErr {
 				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
 			}
@@ -477,6 +483,187 @@ func Test_objectMover_move(t *testing.T) {
 	}
 }
 
+func Test_objectMover_move_ReportsProgress(t *testing.T) {
+	graph := getObjectGraphWithObjs(test.NewFakeCluster("ns1", "foo").Objs())
+
+	discoveryTypes, err := getFakeDiscoveryTypes(graph)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := graph.Discovery(ctx, "ns1", discoveryTypes); err != nil {
+		t.Fatal(err)
+	}
+
+	recorder := &progressRecorder{}
+	mover := objectMover{
+		fromProxy: graph.proxy,
+		progress:  newProgress(recorder, ProgressOperationMove),
+	}
+	if err := mover.move(ctx, graph, getFakeProxyWithCRDs(), NamespaceMapping{}); err != nil {
+		t.Fatalf("move() error = %v", err)
+	}
+
+	moveSequence := getMoveSequence(graph)
+	total := len(moveSequence.nodesMap)
+	for _, step := range []string{ProgressStepCreateObjects, ProgressStepDeleteObjects} {
+		events := recorder.steps(step)
+		if len(events) != len(moveSequence.groups)+2 {
+			t.Fatalf("got %d %s events, want %d", len(events), step, len(moveSequence.groups)+2)
+		}
+		if events[0].Type != ProgressStarted || events[0].Total != total {
+			t.Errorf("got %v, want a Started event with Total %d", events[0], total)
+		}
+		for _, e := range events[1 : len(events)-1] {
+			if e.Type != ProgressInProgress || e.Current == 0 || e.Current > total {
+				t.Errorf("got %v, want an InProgress event with Current between 1 and %d", e, total)
+			}
+		}
+		want := ProgressEvent{Operation: ProgressOperationMove, Step: step, Type: ProgressSucceeded, Current: total, Total: total}
+		if last := events[len(events)-1]; !reflect.DeepEqual(last, want) {
+			t.Errorf("got %v, want %v", last, want)
+		}
+	}
+}
+
+func Test_readObjects(t *testing.T) {
+	objs := test.NewFakeCluster("ns1", "foo").Objs()
+	objs = append(objs, test.NewFakeCluster("ns2", "bar").Objs()...)
+	graph := getObjectGraphWithObjs(objs)
+
+	discoveryTypes, err := getFakeDiscoveryTypes(graph)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := graph.Discovery(ctx, "", discoveryTypes); err != nil {
+		t.Fatal(err)
+	}
+
+	// Read only the objects in ns1.
+	nodes := []*node{}
+	for _, n := range graph.getNodesWithClusterTenants() {
+		if n.identity.Namespace == "ns1" {
+			nodes = append(nodes, n)
+		}
+	}
+	if len(nodes) == 0 {
+		t.Fatal("expected objects in ns1")
+	}
+
+	c, err := graph.proxy.NewClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := readObjects(ctx, c, nodes)
+	if err != nil {
+		t.Fatalf("readObjects() error = %v", err)
+	}
+
+	if len(got) != len(nodes) {
+		t.Errorf("readObjects() returned %d objects, want %d", len(got), len(nodes))
+	}
+	for _, n := range nodes {
+		obj, ok := got[n.identity.UID]
+		if !ok {
+			t.Errorf("readObjects() did not return %s %s/%s", n.identity.Kind, n.identity.Namespace, n.identity.Name)
+			continue
+		}
+		if obj.GetKind() != n.identity.Kind || obj.GetNamespace() != n.identity.Namespace || obj.GetName() != n.identity.Name {
+			t.Errorf("readObjects() returned %s %s/%s for %s %s/%s", obj.GetKind(), obj.GetNamespace(), obj.GetName(), n.identity.Kind, n.identity.Namespace, n.identity.Name)
+		}
+	}
+}
+
+func Test_objectMover_runConcurrently(t *testing.T) {
+	group := moveGroup{}
+	for i := 0; i < 20; i++ {
+		group = append(group, &node{identity: corev1.ObjectReference{Name: fmt.Sprintf("obj-%d", i)}})
+	}
+
+	mover := objectMover{concurrency: 3}
+
+	var mu sync.Mutex
+	running, maxRunning := 0, 0
+	processed := sets.NewString()
+	err := mover.runConcurrently(group, func(n *node) error {
+		mu.Lock()
+		running++
+		if running > maxRunning {
+			maxRunning = running
+		}
+		processed.Insert(n.identity.Name)
+		mu.Unlock()
+
+		time.Sleep(5 * time.Millisecond)
+
+		mu.Lock()
+		running--
+		mu.Unlock()
+
+		if n.identity.Name == "obj-7" {
+			return errors.New("failed")
+		}
+		return nil
+	})
+
+	if err == nil {
+		t.Error("runConcurrently() expected an error")
+	}
+	if processed.Len() != len(group) {
+		t.Errorf("runConcurrently() processed %d nodes, want %d", processed.Len(), len(group))
+	}
+	if maxRunning > mover.concurrency {
+		t.Errorf("runConcurrently() processed %d nodes in parallel, want at most %d", maxRunning, mover.concurrency)
+	}
+}
+
+func Test_objectMover_move_WithNamespaceMapping(t *testing.T) {
+	graph := getObjectGraphWithObjs(test.NewFakeCluster("ns1", "foo").Objs())
+
+	discoveryTypes, err := getFakeDiscoveryTypes(graph)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := graph.Discovery(ctx, "ns1", discoveryTypes); err != nil {
+		t.Fatal(err)
+	}
+
+	toProxy := getFakeProxyWithCRDs()
+	mover := objectMover{
+		fromProxy: graph.proxy,
+	}
+	if err := mover.move(ctx, graph, toProxy, NamespaceMapping{Prefix: "site-a-"}); err != nil {
+		t.Fatalf("move() error = %v", err)
+	}
+
+	csTo, err := toProxy.NewClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// objects are created in the target namespace.
+	for _, node := range graph.uidToNode {
+		oTo := &unstructured.Unstructured{}
+		oTo.SetAPIVersion(node.identity.APIVersion)
+		oTo.SetKind(node.identity.Kind)
+		key := client.ObjectKey{Namespace: "site-a-ns1", Name: node.identity.Name}
+		if err := csTo.Get(ctx, key, oTo); err != nil {
+			t.Errorf("error = %v when checking for %v created in target cluster", err, key)
+		}
+	}
+
+	// references to the moved objects are rewritten, and the Cluster is resumed.
+	cluster := &clusterv1.Cluster{}
+	if err := csTo.Get(ctx, client.ObjectKey{Namespace: "site-a-ns1", Name: "foo"}, cluster); err != nil {
+		t.Fatal(err)
+	}
+	if cluster.Spec.InfrastructureRef.Namespace != "site-a-ns1" {
+		t.Errorf("spec.infrastructureRef.namespace = %q, want %q", cluster.Spec.InfrastructureRef.Namespace, "site-a-ns1")
+	}
+	if cluster.Spec.Paused {
+		t.Errorf("spec.paused = true, want the Cluster to be resumed in the target namespace")
+	}
+}
+
 func Test_objectMover_checkProvisioningCompleted(t *testing.T) {
 	type fields struct {
 		objs []runtime.Object
@@ -657,14 +844,14 @@ func Test_objectMover_checkProvisioningCompleted(t *testing.T) {
 			}
 
 			// trigger discovery the content of the source cluster
-			if err := graph.Discovery("ns1", discoveryTypes); err != nil {
+			if err := graph.Discovery(ctx, "ns1", discoveryTypes); err != nil {
 				t.Fatal(err)
 			}
 
 			o := &objectMover{
 				fromProxy: graph.proxy,
 			}
-			if err := o.checkProvisioningCompleted(graph); (err != nil) != tt.wantErr {
+			if err := o.checkProvisioningCompleted(ctx, graph); (err != nil) != tt.wantErr {
 				t.Errorf("error = %v, wantErr %v", err, tt.wantErr)
 			}
 		})
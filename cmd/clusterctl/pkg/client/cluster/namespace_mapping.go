@@ -0,0 +1,138 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// NamespaceMapping defines how the namespaces of the objects moved are remapped in the target management cluster,
+// so objects from different management clusters can be consolidated without namespace collisions.
+type NamespaceMapping struct {
+	// Namespaces maps source namespaces to target namespaces.
+	Namespaces map[string]string
+
+	// Prefix is added to the source namespaces not listed in Namespaces.
+	Prefix string
+
+	// Suffix is added to the source namespaces not listed in Namespaces.
+	Suffix string
+}
+
+// IsEmpty returns true if the mapping does not change any namespace.
+func (m NamespaceMapping) IsEmpty() bool {
+	return len(m.Namespaces) == 0 && m.Prefix == "" && m.Suffix == ""
+}
+
+// target returns the target namespace for a source namespace.
+func (m NamespaceMapping) target(namespace string) string {
+	if target, ok := m.Namespaces[namespace]; ok {
+		return target
+	}
+	return m.Prefix + namespace + m.Suffix
+}
+
+// resolve returns the namespaceRemapper for the given source namespaces, failing if a target namespace is invalid
+// or if two source namespaces are mapped to the same target namespace.
+func (m NamespaceMapping) resolve(namespaces sets.String) (namespaceRemapper, error) {
+	remapper := namespaceRemapper{}
+	if m.IsEmpty() {
+		return remapper, nil
+	}
+
+	sources := map[string]string{}
+	for _, namespace := range namespaces.List() {
+		target := m.target(namespace)
+		if errs := validation.IsDNS1123Label(target); len(errs) != 0 {
+			return nil, errors.Errorf("invalid target namespace %q for namespace %q: %s", target, namespace, strings.Join(errs, "; "))
+		}
+		if other, ok := sources[target]; ok {
+			return nil, errors.Errorf("namespaces %q and %q can't be both moved to the %q namespace", other, namespace, target)
+		}
+		sources[target] = namespace
+		remapper[namespace] = target
+	}
+	return remapper, nil
+}
+
+// namespaceRemapper maps the namespaces of the objects moved to the target namespaces.
+type namespaceRemapper map[string]string
+
+// namespace returns the target namespace for a source namespace.
+func (r namespaceRemapper) namespace(namespace string) string {
+	if target, ok := r[namespace]; ok {
+		return target
+	}
+	return namespace
+}
+
+// nodes returns a copy of the nodes with the identity in the target namespaces.
+func (r namespaceRemapper) nodes(nodes []*node) []*node {
+	if len(r) == 0 {
+		return nodes
+	}
+
+	ret := make([]*node, 0, len(nodes))
+	for _, n := range nodes {
+		remapped := *n
+		remapped.identity.Namespace = r.namespace(n.identity.Namespace)
+		ret = append(ret, &remapped)
+	}
+	return ret
+}
+
+// object moves an object to its target namespace, rewriting the namespace of the references to objects in the
+// remapped namespaces, e.g. the infrastructureRef of a Cluster or the secret holding the credentials of an
+// infrastructure cluster. References are detected as any field with both a name and a namespace.
+func (r namespaceRemapper) object(obj *unstructured.Unstructured) {
+	if len(r) == 0 {
+		return
+	}
+
+	if namespace := obj.GetNamespace(); namespace != "" {
+		obj.SetNamespace(r.namespace(namespace))
+	}
+	for field, value := range obj.Object {
+		if field == "metadata" {
+			continue
+		}
+		r.references(value)
+	}
+}
+
+func (r namespaceRemapper) references(value interface{}) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if namespace, ok := v["namespace"].(string); ok {
+			if _, hasName := v["name"]; hasName {
+				v["namespace"] = r.namespace(namespace)
+			}
+		}
+		for _, child := range v {
+			r.references(child)
+		}
+	case []interface{}:
+		for _, child := range v {
+			r.references(child)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+func Test_NamespaceMapping_resolve(t *testing.T) {
+	tests := []struct {
+		name       string
+		mapping    NamespaceMapping
+		namespaces []string
+		want       namespaceRemapper
+		wantErr    bool
+	}{
+		{
+			name:       "Empty mapping",
+			mapping:    NamespaceMapping{},
+			namespaces: []string{"ns1"},
+			want:       namespaceRemapper{},
+		},
+		{
+			name:       "Explicit mapping, with prefix and suffix for the other namespaces",
+			mapping:    NamespaceMapping{Namespaces: map[string]string{"ns1": "foo"}, Prefix: "a-", Suffix: "-b"},
+			namespaces: []string{"ns1", "ns2"},
+			want:       namespaceRemapper{"ns1": "foo", "ns2": "a-ns2-b"},
+		},
+		{
+			name:       "Fails if two namespaces are mapped to the same target namespace",
+			mapping:    NamespaceMapping{Namespaces: map[string]string{"ns1": "a-ns2"}, Prefix: "a-"},
+			namespaces: []string{"ns1", "ns2"},
+			wantErr:    true,
+		},
+		{
+			name:       "Fails for invalid target namespaces",
+			mapping:    NamespaceMapping{Prefix: "A_"},
+			namespaces: []string{"ns1"},
+			wantErr:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.mapping.resolve(sets.NewString(tt.namespaces...))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_namespaceRemapper_object(t *testing.T) {
+	obj := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "cluster.x-k8s.io/v1alpha3",
+			"kind":       "Cluster",
+			"metadata": map[string]interface{}{
+				"name":      "foo",
+				"namespace": "ns1",
+			},
+			"spec": map[string]interface{}{
+				"infrastructureRef": map[string]interface{}{
+					"kind":      "DummyInfrastructureCluster",
+					"name":      "foo",
+					"namespace": "ns1",
+				},
+				"secretRefs": []interface{}{
+					map[string]interface{}{"name": "credentials", "namespace": "shared"},
+				},
+				"selector": map[string]interface{}{
+					"namespace": "ns1",
+				},
+			},
+		},
+	}
+
+	namespaceRemapper{"ns1": "foo"}.object(obj)
+
+	if obj.GetNamespace() != "foo" {
+		t.Errorf("namespace = %q, want %q", obj.GetNamespace(), "foo")
+	}
+	if got, _, _ := unstructured.NestedString(obj.Object, "spec", "infrastructureRef", "namespace"); got != "foo" {
+		t.Errorf("spec.infrastructureRef.namespace = %q, want %q", got, "foo")
+	}
+	refs, _, _ := unstructured.NestedSlice(obj.Object, "spec", "secretRefs")
+	if got := refs[0].(map[string]interface{})["namespace"]; got != "shared" {
+		t.Errorf("spec.secretRefs[0].namespace = %q, want the namespace not moved to be preserved", got)
+	}
+	if got, _, _ := unstructured.NestedString(obj.Object, "spec", "selector", "namespace"); got != "ns1" {
+		t.Errorf("spec.selector.namespace = %q, want fields not referencing objects to be preserved", got)
+	}
+}
@@ -0,0 +1,192 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"context"
+	"sort"
+
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+var (
+	// clusterKeyConditions are the conditions reported for the Clusters, in the order they are reported.
+	clusterKeyConditions = []clusterv1.ConditionType{
+		clusterv1.ReadyCondition,
+		clusterv1.InfrastructureReadyCondition,
+		clusterv1.ControlPlaneReadyCondition,
+	}
+
+	// machineKeyConditions are the conditions reported for the Machines, in the order they are reported.
+	machineKeyConditions = []clusterv1.ConditionType{
+		clusterv1.ReadyCondition,
+		clusterv1.BootstrapReadyCondition,
+		clusterv1.InfrastructureReadyCondition,
+	}
+)
+
+// ObjectStatus reports the status of a Cluster or of a Machine.
+type ObjectStatus struct {
+	// Kind, Namespace and Name of the object.
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+
+	// ClusterName is the name of the Cluster the object belongs to.
+	ClusterName string `json:"clusterName"`
+
+	// Phase of the object, e.g. Provisioning.
+	Phase string `json:"phase"`
+
+	// Version is the Kubernetes version of a Machine; it is empty for Clusters.
+	Version string `json:"version,omitempty"`
+
+	// NodeName and ProviderID identify the Node of a Machine; they are empty for Clusters, or if the Node does not exist yet.
+	NodeName   string `json:"nodeName,omitempty"`
+	ProviderID string `json:"providerID,omitempty"`
+
+	// Conditions are the key conditions of the object, e.g. Ready, limited to the conditions already set on the object.
+	Conditions clusterv1.Conditions `json:"conditions,omitempty"`
+
+	// CreationTimestamp of the object.
+	CreationTimestamp metav1.Time `json:"creationTimestamp"`
+}
+
+// StatusLister has methods to list the status of the Clusters and of the Machines in a management cluster.
+type StatusLister interface {
+	// ListClusters returns the status of the Clusters in a namespace, or in all the namespaces if empty.
+	ListClusters(ctx context.Context, namespace string) ([]ObjectStatus, error)
+
+	// ListMachines returns the status of the Machines in a namespace, or in all the namespaces if empty;
+	// if clusterName is not empty, only the Machines belonging to that Cluster are returned.
+	ListMachines(ctx context.Context, namespace, clusterName string) ([]ObjectStatus, error)
+}
+
+// statusLister implements StatusLister.
+type statusLister struct {
+	proxy Proxy
+}
+
+// ensure statusLister implements StatusLister.
+var _ StatusLister = &statusLister{}
+
+func newStatusLister(proxy Proxy) *statusLister {
+	return &statusLister{
+		proxy: proxy,
+	}
+}
+
+func (l *statusLister) ListClusters(ctx context.Context, namespace string) ([]ObjectStatus, error) {
+	c, err := l.proxy.NewClient()
+	if err != nil {
+		return nil, err
+	}
+
+	clusterList := &clusterv1.ClusterList{}
+	if err := c.List(ctx, clusterList, client.InNamespace(namespace)); err != nil {
+		return nil, errors.Wrap(err, "failed to list Clusters")
+	}
+
+	ret := make([]ObjectStatus, 0, len(clusterList.Items))
+	for i := range clusterList.Items {
+		cluster := &clusterList.Items[i]
+		ret = append(ret, ObjectStatus{
+			Kind:              "Cluster",
+			Namespace:         cluster.Namespace,
+			Name:              cluster.Name,
+			ClusterName:       cluster.Name,
+			Phase:             cluster.Status.Phase,
+			Conditions:        keyConditions(cluster.Status.Conditions, clusterKeyConditions),
+			CreationTimestamp: cluster.CreationTimestamp,
+		})
+	}
+	sortObjectStatus(ret)
+	return ret, nil
+}
+
+func (l *statusLister) ListMachines(ctx context.Context, namespace, clusterName string) ([]ObjectStatus, error) {
+	c, err := l.proxy.NewClient()
+	if err != nil {
+		return nil, err
+	}
+
+	machineList := &clusterv1.MachineList{}
+	if err := c.List(ctx, machineList, client.InNamespace(namespace)); err != nil {
+		return nil, errors.Wrap(err, "failed to list Machines")
+	}
+
+	ret := make([]ObjectStatus, 0, len(machineList.Items))
+	for i := range machineList.Items {
+		machine := &machineList.Items[i]
+		// Filters on the spec instead of the cluster name label, because the label is set by the Machine controller
+		// and it can be missing on Machines just created.
+		if clusterName != "" && machine.Spec.ClusterName != clusterName {
+			continue
+		}
+		status := ObjectStatus{
+			Kind:              "Machine",
+			Namespace:         machine.Namespace,
+			Name:              machine.Name,
+			ClusterName:       machine.Spec.ClusterName,
+			Phase:             machine.Status.Phase,
+			Conditions:        keyConditions(machine.Status.Conditions, machineKeyConditions),
+			CreationTimestamp: machine.CreationTimestamp,
+		}
+		if machine.Spec.Version != nil {
+			status.Version = *machine.Spec.Version
+		}
+		if machine.Status.NodeRef != nil {
+			status.NodeName = machine.Status.NodeRef.Name
+		}
+		if machine.Spec.ProviderID != nil {
+			status.ProviderID = *machine.Spec.ProviderID
+		}
+		ret = append(ret, status)
+	}
+	sortObjectStatus(ret)
+	return ret, nil
+}
+
+// keyConditions returns the conditions with the given types, in the order of the types; conditions not set are skipped.
+func keyConditions(conditions clusterv1.Conditions, types []clusterv1.ConditionType) clusterv1.Conditions {
+	var ret clusterv1.Conditions
+	for _, t := range types {
+		for _, c := range conditions {
+			if c.Type == t {
+				ret = append(ret, c)
+				break
+			}
+		}
+	}
+	return ret
+}
+
+// sortObjectStatus sorts a list of ObjectStatus by namespace, cluster name and name.
+func sortObjectStatus(objs []ObjectStatus) {
+	sort.Slice(objs, func(i, j int) bool {
+		if objs[i].Namespace != objs[j].Namespace {
+			return objs[i].Namespace < objs[j].Namespace
+		}
+		if objs[i].ClusterName != objs[j].ClusterName {
+			return objs[i].ClusterName < objs[j].ClusterName
+		}
+		return objs[i].Name < objs[j].Name
+	})
+}
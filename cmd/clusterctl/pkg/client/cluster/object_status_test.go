@@ -0,0 +1,175 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/pointer"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/internal/test"
+)
+
+func Test_statusLister_ListClusters(t *testing.T) {
+	proxy := test.NewFakeProxy().WithObjs([]runtime.Object{
+		&clusterv1.Cluster{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ns2", Name: "cluster2"},
+			Status:     clusterv1.ClusterStatus{Phase: string(clusterv1.ClusterPhaseProvisioned)},
+		},
+		&clusterv1.Cluster{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: "cluster1"},
+			Status: clusterv1.ClusterStatus{
+				Phase: string(clusterv1.ClusterPhaseProvisioning),
+				Conditions: clusterv1.Conditions{
+					{Type: clusterv1.ControlPlaneReadyCondition, Status: "False", Reason: "WaitingForControlPlane"},
+					{Type: "Other", Status: "True"},
+					{Type: clusterv1.ReadyCondition, Status: "False"},
+				},
+			},
+		},
+	}...)
+
+	tests := []struct {
+		name      string
+		namespace string
+		want      []string
+	}{
+		{
+			name:      "List the Clusters in a namespace",
+			namespace: "ns1",
+			want:      []string{"ns1/cluster1"},
+		},
+		{
+			name:      "List the Clusters in all the namespaces",
+			namespace: "",
+			want:      []string{"ns1/cluster1", "ns2/cluster2"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newStatusLister(proxy).ListClusters(ctx, tt.namespace)
+			if err != nil {
+				t.Fatalf("ListClusters() error = %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d Clusters, want %d", len(got), len(tt.want))
+			}
+			for i := range got {
+				if name := got[i].Namespace + "/" + got[i].Name; name != tt.want[i] {
+					t.Errorf("got Cluster %s at position %d, want %s", name, i, tt.want[i])
+				}
+			}
+		})
+	}
+
+	got, err := newStatusLister(proxy).ListClusters(ctx, "ns1")
+	if err != nil {
+		t.Fatalf("ListClusters() error = %v", err)
+	}
+	if got[0].Phase != string(clusterv1.ClusterPhaseProvisioning) {
+		t.Errorf("got phase %q, want %q", got[0].Phase, clusterv1.ClusterPhaseProvisioning)
+	}
+	// Only the key conditions are reported, in a fixed order.
+	conditions := got[0].Conditions
+	if len(conditions) != 2 || conditions[0].Type != clusterv1.ReadyCondition || conditions[1].Type != clusterv1.ControlPlaneReadyCondition {
+		t.Errorf("got conditions %v, want Ready and ControlPlaneReady", conditions)
+	}
+}
+
+func Test_statusLister_ListMachines(t *testing.T) {
+	machine := func(namespace, name, clusterName string) *clusterv1.Machine {
+		return &clusterv1.Machine{
+			ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+			Spec:       clusterv1.MachineSpec{ClusterName: clusterName},
+		}
+	}
+
+	running := machine("ns1", "m1", "cluster1")
+	running.Spec.Version = pointer.StringPtr("v1.17.3")
+	running.Spec.ProviderID = pointer.StringPtr("aws:///us-east-1a/i-1234")
+	running.Status.Phase = string(clusterv1.MachinePhaseRunning)
+	running.Status.NodeRef = &corev1.ObjectReference{Kind: "Node", Name: "node1"}
+	running.Status.Conditions = clusterv1.Conditions{
+		{Type: clusterv1.InfrastructureReadyCondition, Status: "True"},
+		{Type: clusterv1.BootstrapReadyCondition, Status: "True"},
+		{Type: clusterv1.ReadyCondition, Status: "True"},
+	}
+
+	proxy := test.NewFakeProxy().WithObjs([]runtime.Object{
+		running,
+		machine("ns1", "m2", "cluster2"),
+		machine("ns1", "m0", "cluster2"),
+		machine("ns2", "m3", "cluster1"),
+	}...)
+
+	tests := []struct {
+		name        string
+		namespace   string
+		clusterName string
+		want        []string
+	}{
+		{
+			name:      "List the Machines in a namespace",
+			namespace: "ns1",
+			want:      []string{"ns1/m1", "ns1/m0", "ns1/m2"},
+		},
+		{
+			name:        "List the Machines of a Cluster",
+			namespace:   "ns1",
+			clusterName: "cluster2",
+			want:        []string{"ns1/m0", "ns1/m2"},
+		},
+		{
+			name:        "List the Machines of the Clusters with a name in all the namespaces",
+			namespace:   "",
+			clusterName: "cluster1",
+			want:        []string{"ns1/m1", "ns2/m3"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newStatusLister(proxy).ListMachines(ctx, tt.namespace, tt.clusterName)
+			if err != nil {
+				t.Fatalf("ListMachines() error = %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d Machines, want %d", len(got), len(tt.want))
+			}
+			for i := range got {
+				if name := got[i].Namespace + "/" + got[i].Name; name != tt.want[i] {
+					t.Errorf("got Machine %s at position %d, want %s", name, i, tt.want[i])
+				}
+			}
+		})
+	}
+
+	got, err := newStatusLister(proxy).ListMachines(ctx, "ns1", "cluster1")
+	if err != nil {
+		t.Fatalf("ListMachines() error = %v", err)
+	}
+	m := got[0]
+	if m.Phase != string(clusterv1.MachinePhaseRunning) || m.Version != "v1.17.3" || m.NodeName != "node1" || m.ProviderID != "aws:///us-east-1a/i-1234" {
+		t.Errorf("got %+v, want a running Machine with version, node and provider ID", m)
+	}
+	conditions := m.Conditions
+	if len(conditions) != 3 || conditions[0].Type != clusterv1.ReadyCondition || conditions[1].Type != clusterv1.BootstrapReadyCondition || conditions[2].Type != clusterv1.InfrastructureReadyCondition {
+		t.Errorf("got conditions %v, want Ready, BootstrapReady and InfrastructureReady", conditions)
+	}
+}
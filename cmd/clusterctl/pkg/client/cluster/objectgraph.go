@@ -17,8 +17,10 @@ limitations under the License.
 package cluster
 
 import (
+	"context"
 	"strings"
 
+	"github.com/go-logr/logr"
 	"github.com/pkg/errors"
 	corev1 "k8s.io/api/core/v1"
 	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
@@ -53,6 +55,10 @@ type node struct {
 	// virtual records if this node was discovered indirectly, e.g. by processing an OwnerRef, but not yet observed as a concrete object.
 	virtual bool
 
+	// excluded records if this node is excluded from move and backup, because the object is annotated with
+	// clusterctl.cluster.x-k8s.io/move: "false" or it is owned by an excluded object.
+	excluded bool
+
 	//newID stores the new UID the objects gets once created in the target cluster.
 	newUID types.UID
 
@@ -88,12 +94,14 @@ func (n *node) isSoftOwnedBy(other *node) bool {
 type objectGraph struct {
 	proxy     Proxy
 	uidToNode map[types.UID]*node
+	log       logr.Logger
 }
 
 func newObjectGraph(proxy Proxy) *objectGraph {
 	return &objectGraph{
 		proxy:     proxy,
 		uidToNode: map[types.UID]*node{},
+		log:       logf.Log,
 	}
 }
 
@@ -142,9 +150,12 @@ func (o *objectGraph) ownerToVirtualNode(owner metav1.OwnerReference, namespace
 // If the node corresponding to the Kubernetes object already exists as a virtual node detected when processing OwnerReferences,
 // the node is marked as Observed.
 func (o *objectGraph) objToNode(obj *unstructured.Unstructured) *node {
+	excluded := obj.GetAnnotations()[clusterctlv1.ClusterctlMoveAnnotation] == "false"
+
 	existingNode, found := o.uidToNode[obj.GetUID()]
 	if found {
 		existingNode.markObserved()
+		existingNode.excluded = excluded
 		return existingNode
 	}
 
@@ -160,6 +171,7 @@ func (o *objectGraph) objToNode(obj *unstructured.Unstructured) *node {
 		softOwners:     make(map[*node]empty),
 		tenantClusters: make(map[*node]empty),
 		virtual:        false,
+		excluded:       excluded,
 	}
 
 	o.uidToNode[newNode.identity.UID] = newNode
@@ -168,7 +180,7 @@ func (o *objectGraph) objToNode(obj *unstructured.Unstructured) *node {
 
 // getDiscoveryTypes returns the list of TypeMeta to be considered for the the move discovery phase.
 // This list includes all the types defines by the CRDs installed by clusterctl and the ConfigMap/Secret core types.
-func (o *objectGraph) getDiscoveryTypes() ([]metav1.TypeMeta, error) {
+func (o *objectGraph) getDiscoveryTypes(ctx context.Context) ([]metav1.TypeMeta, error) {
 	discoveredTypes := []metav1.TypeMeta{}
 
 	c, err := o.proxy.NewClient()
@@ -182,19 +194,19 @@ func (o *objectGraph) getDiscoveryTypes() ([]metav1.TypeMeta, error) {
 	}
 
 	for _, crd := range crdList.Items {
-		for _, version := range crd.Spec.Versions {
-			if !version.Storage {
-				continue
-			}
-
-			discoveredTypes = append(discoveredTypes, metav1.TypeMeta{
-				Kind: crd.Spec.Names.Kind,
-				APIVersion: metav1.GroupVersion{
-					Group:   crd.Spec.Group,
-					Version: version.Name,
-				}.String(),
-			})
+		// Use the newest version served by the management cluster, so the discovery works across clusters at different contract levels.
+		version := newestServedVersion(crd)
+		if version == "" {
+			continue
 		}
+
+		discoveredTypes = append(discoveredTypes, metav1.TypeMeta{
+			Kind: crd.Spec.Names.Kind,
+			APIVersion: metav1.GroupVersion{
+				Group:   crd.Spec.Group,
+				Version: version,
+			}.String(),
+		})
 	}
 
 	discoveredTypes = append(discoveredTypes, metav1.TypeMeta{Kind: "Secret", APIVersion: "v1"})
@@ -205,8 +217,8 @@ func (o *objectGraph) getDiscoveryTypes() ([]metav1.TypeMeta, error) {
 
 // Discovery reads all the Kubernetes objects existing in a namespace (or in all namespaces if empty) for the types received in input, and then adds
 // everything to the objects graph.
-func (o *objectGraph) Discovery(namespace string, types []metav1.TypeMeta) error {
-	log := logf.Log
+func (o *objectGraph) Discovery(ctx context.Context, namespace string, types []metav1.TypeMeta) error {
+	log := o.log
 	log.Info("Discovering Cluster API objects")
 
 	c, err := o.proxy.NewClient()
@@ -214,26 +226,15 @@ func (o *objectGraph) Discovery(namespace string, types []metav1.TypeMeta) error
 		return err
 	}
 
-	selectors := []client.ListOption{}
-	if namespace != "" {
-		selectors = append(selectors, client.InNamespace(namespace))
-	}
-
 	for _, typeMeta := range types {
-		objList := new(unstructured.UnstructuredList)
-		objList.SetAPIVersion(typeMeta.APIVersion)
-		objList.SetKind(typeMeta.Kind)
-
-		if err := c.List(ctx, objList, selectors...); err != nil {
-			if apierrors.IsNotFound(err) {
-				continue
-			}
-			return errors.Wrapf(err, "failed to list %q resources", objList.GroupVersionKind())
+		objs, err := listObjects(ctx, c, typeMeta, namespace)
+		if err != nil {
+			return err
 		}
 
-		log.V(5).Info(typeMeta.Kind, "Count", len(objList.Items))
-		for i := range objList.Items {
-			obj := objList.Items[i]
+		log.V(5).Info(typeMeta.Kind, "Count", len(objs))
+		for i := range objs {
+			obj := objs[i]
 			o.addObj(&obj)
 		}
 	}
@@ -244,14 +245,65 @@ func (o *objectGraph) Discovery(namespace string, types []metav1.TypeMeta) error
 	// by a naming convention (without any explicit OwnerReference).
 	o.setSoftOwnership()
 
+	// Excludes from move and backup the objects owned by the excluded ones.
+	o.setExcluded()
+
 	// Completes the graph by setting for each node the list of Clusters the node belong to.
 	o.setClusterTenants()
 
 	return nil
 }
 
-// getClusters returns the list of Clusters existing in the object graph.
+// listPageSize is the number of objects requested for each page when listing objects for move.
+const listPageSize = 500
+
+// listObjects lists all the objects of a type existing in a namespace (or in all namespaces if empty), one page at a time;
+// types not served by the cluster are ignored.
+func listObjects(ctx context.Context, c client.Client, typeMeta metav1.TypeMeta, namespace string) ([]unstructured.Unstructured, error) {
+	selectors := []client.ListOption{client.Limit(listPageSize)}
+	if namespace != "" {
+		selectors = append(selectors, client.InNamespace(namespace))
+	}
+
+	// Nb. the list kind is always used, given that it is required by the fake client, while the real client accepts both.
+	listKind := typeMeta.Kind
+	if !strings.HasSuffix(listKind, "List") {
+		listKind += "List"
+	}
+
+	objs := []unstructured.Unstructured{}
+	objList := new(unstructured.UnstructuredList)
+	for {
+		objList.SetAPIVersion(typeMeta.APIVersion)
+		objList.SetKind(listKind)
+
+		if err := c.List(ctx, objList, append(selectors, client.Continue(objList.GetContinue()))...); err != nil {
+			if apierrors.IsNotFound(err) {
+				return nil, nil
+			}
+			return nil, errors.Wrapf(err, "failed to list %q resources", objList.GroupVersionKind())
+		}
+		objs = append(objs, objList.Items...)
+
+		if objList.GetContinue() == "" {
+			return objs, nil
+		}
+	}
+}
+
+// getClusters returns the list of Clusters existing in the object graph, except the excluded ones.
 func (o *objectGraph) getClusters() []*node {
+	clusters := []*node{}
+	for _, node := range o.getAllClusters() {
+		if !node.excluded {
+			clusters = append(clusters, node)
+		}
+	}
+	return clusters
+}
+
+// getAllClusters returns the list of Clusters existing in the object graph, including the excluded ones.
+func (o *objectGraph) getAllClusters() []*node {
 	clusters := []*node{}
 	for _, node := range o.uidToNode {
 		if node.identity.GroupVersionKind().GroupKind() == clusterv1.GroupVersion.WithKind("Cluster").GroupKind() {
@@ -261,6 +313,17 @@ func (o *objectGraph) getClusters() []*node {
 	return clusters
 }
 
+// getExcludedNodes returns the list of nodes existing in the object graph that are excluded from move and backup.
+func (o *objectGraph) getExcludedNodes() []*node {
+	nodes := []*node{}
+	for _, node := range o.uidToNode {
+		if node.excluded && !node.virtual {
+			nodes = append(nodes, node)
+		}
+	}
+	return nodes
+}
+
 // getClusters returns the list of Secrets existing in the object graph.
 func (o *objectGraph) getSecrets() []*node {
 	secrets := []*node{}
@@ -292,10 +355,45 @@ func (o *objectGraph) getNodesWithClusterTenants() []*node {
 	return nodes
 }
 
-// getMachines returns the list of Machine existing in the object graph.
+// getNodesForBackup returns the list of nodes existing in the object graph to be included in a backup, that is
+// all the objects of the types defined by the CRDs installed by clusterctl and the Secrets/ConfigMaps belonging at least to one Cluster.
+func (o *objectGraph) getNodesForBackup() []*node {
+	nodes := []*node{}
+	for _, node := range o.uidToNode {
+		if node.virtual || node.excluded {
+			continue
+		}
+		if node.identity.APIVersion == "v1" && len(node.tenantClusters) == 0 {
+			continue
+		}
+		nodes = append(nodes, node)
+	}
+	return nodes
+}
+
+// removeVirtualNodes removes the nodes that were never observed as a concrete object, and the ownership relations with them,
+// e.g. when restoring objects whose owners are not part of the backup.
+func (o *objectGraph) removeVirtualNodes() {
+	for uid, node := range o.uidToNode {
+		if node.virtual {
+			delete(o.uidToNode, uid)
+			continue
+		}
+		for owner := range node.owners {
+			if owner.virtual {
+				delete(node.owners, owner)
+			}
+		}
+	}
+}
+
+// getMachines returns the list of Machine existing in the object graph, except the excluded ones.
 func (o *objectGraph) getMachines() []*node {
 	machines := []*node{}
 	for _, node := range o.uidToNode {
+		if node.excluded {
+			continue
+		}
 		if node.identity.GroupVersionKind().GroupKind() == clusterv1.GroupVersion.WithKind("Machine").GroupKind() {
 			machines = append(machines, node)
 		}
@@ -305,7 +403,7 @@ func (o *objectGraph) getMachines() []*node {
 
 // setSoftOwnership searches for soft ownership relations such as secrets linked to the cluster by a naming convention (without any explicit OwnerReference).
 func (o *objectGraph) setSoftOwnership() {
-	clusters := o.getClusters()
+	clusters := o.getAllClusters()
 	for _, secret := range o.getSecrets() {
 		// If the secret has at least one OwnerReference ignore it.
 		// NB. Cluster API generated secrets have an explicit OwnerReference to the ControlPlane or the KubeadmConfig object while user provided secrets might not have one.
@@ -328,7 +426,26 @@ func (o *objectGraph) setSoftOwnership() {
 	}
 }
 
+// setExcluded excludes from move and backup the dependents/softDependents of the excluded nodes.
+func (o *objectGraph) setExcluded() {
+	for _, node := range o.getNodes() {
+		if node.excluded {
+			o.setNodeExcluded(node)
+		}
+	}
+}
+
+func (o *objectGraph) setNodeExcluded(node *node) {
+	node.excluded = true
+	for _, other := range o.getNodes() {
+		if !other.excluded && (other.isOwnedBy(node) || other.isSoftOwnedBy(node)) {
+			o.setNodeExcluded(other)
+		}
+	}
+}
+
 // setClusterTenants sets the cluster tenants for the clusters itself and all their dependent object tree.
+// Excluded nodes, and so their dependent object tree, do not belong to any Cluster.
 func (o *objectGraph) setClusterTenants() {
 	for _, cluster := range o.getClusters() {
 		o.setClusterTenant(cluster, cluster)
@@ -337,6 +454,9 @@ func (o *objectGraph) setClusterTenants() {
 
 // setNodeTenant sets a tenant for a node and for its own dependents/sofDependents.
 func (o *objectGraph) setClusterTenant(node, tenant *node) {
+	if node.excluded {
+		return
+	}
 	node.tenantClusters[tenant] = empty{}
 	for _, other := range o.getNodes() {
 		if other.isOwnedBy(node) || other.isSoftOwnedBy(node) {
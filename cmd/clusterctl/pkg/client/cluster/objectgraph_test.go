@@ -23,11 +23,13 @@ import (
 	"testing"
 
 	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/runtime"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/types"
+	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/internal/test"
 )
 
@@ -46,7 +48,7 @@ func TestObjectGraph_getDiscoveryTypeMetaList(t *testing.T) {
 			fields: fields{
 				proxy: test.NewFakeProxy().
 					WithObjs(
-						test.FakeCustomResourceDefinition("foo", "Bar", "v2", "v1"), // NB. foo/v1 Bar is not a storage version, so it should be ignored
+						test.FakeCustomResourceDefinition("foo", "Bar", "v2", "v1"), // NB. foo/v1 Bar is older than foo/v2, so it should be ignored
 						test.FakeCustomResourceDefinition("foo", "Baz", "v1"),
 					),
 			},
@@ -58,11 +60,26 @@ func TestObjectGraph_getDiscoveryTypeMetaList(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "Return the newest served version of CRDs",
+			fields: fields{
+				proxy: test.NewFakeProxy().
+					WithObjs(
+						test.FakeCustomResourceDefinition("foo", "Bar", "v1alpha2", "v1alpha3"), // NB. foo/v1alpha3 Bar is not a storage version, but it is the newest served version
+					),
+			},
+			want: []metav1.TypeMeta{
+				{APIVersion: "foo/v1alpha3", Kind: "Bar"},
+				{APIVersion: "v1", Kind: "Secret"},
+				{APIVersion: "v1", Kind: "ConfigMap"},
+			},
+			wantErr: false,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			graph := newObjectGraph(tt.fields.proxy)
-			got, err := graph.getDiscoveryTypes()
+			got, err := graph.getDiscoveryTypes(ctx)
 			if (err != nil) != tt.wantErr {
 				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
 			}
@@ -920,7 +937,7 @@ func getFakeProxyWithCRDs() *test.FakeProxy {
 }
 
 func getFakeDiscoveryTypes(graph *objectGraph) ([]metav1.TypeMeta, error) {
-	discoveryTypes, err := graph.getDiscoveryTypes()
+	discoveryTypes, err := graph.getDiscoveryTypes(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -946,7 +963,7 @@ func TestObjectGraph_Discovery(t *testing.T) {
 			}
 
 			// finally test discovery
-			err = graph.Discovery("ns1", discoveryTypes)
+			err = graph.Discovery(ctx, "ns1", discoveryTypes)
 			if (err != nil) != tt.wantErr {
 				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
 			}
@@ -1064,7 +1081,7 @@ func TestObjectGraph_DiscoveryByNamespace(t *testing.T) {
 			}
 
 			// finally test discovery
-			err = graph.Discovery(tt.args.namespace, discoveryTypes)
+			err = graph.Discovery(ctx, tt.args.namespace, discoveryTypes)
 			if (err != nil) != tt.wantErr {
 				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
 			}
@@ -1310,3 +1327,74 @@ func Test_objectGraph_setClusterTenants(t *testing.T) {
 		})
 	}
 }
+
+func Test_objectGraph_setExcluded(t *testing.T) {
+	// excludeObj adds the clusterctl.cluster.x-k8s.io/move: "false" annotation to the object with the given kind and name.
+	excludeObj := func(objs []runtime.Object, kind, name string) []runtime.Object {
+		for _, o := range objs {
+			accessor, err := meta.Accessor(o)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if o.GetObjectKind().GroupVersionKind().Kind == kind && accessor.GetName() == name {
+				accessor.SetAnnotations(map[string]string{clusterctlv1.ClusterctlMoveAnnotation: "false"})
+			}
+		}
+		return objs
+	}
+
+	objs := []runtime.Object{}
+	objs = append(objs, test.NewFakeCluster("ns1", "cluster1").
+		WithMachineSets(
+			test.NewFakeMachineSet("cluster1-ms1").
+				WithMachines(
+					test.NewFakeMachine("cluster1-m1"),
+				),
+		).Objs()...)
+	objs = append(objs, test.NewFakeCluster("ns1", "cluster2").Objs()...)
+	objs = excludeObj(objs, "MachineSet", "cluster1-ms1")
+	objs = excludeObj(objs, "Cluster", "cluster2")
+
+	graph, err := getDetachedObjectGraphWihObjs(objs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	graph.setSoftOwnership()
+	graph.setExcluded()
+	graph.setClusterTenants()
+
+	wantExcluded := []string{
+		"/v1, Kind=Secret, ns1/cluster1-m1",
+		"/v1, Kind=Secret, ns1/cluster2-ca",
+		"/v1, Kind=Secret, ns1/cluster2-kubeconfig",
+		"bootstrap.cluster.x-k8s.io/v1alpha3, Kind=DummyBootstrapConfig, ns1/cluster1-m1",
+		"cluster.x-k8s.io/v1alpha3, Kind=Cluster, ns1/cluster2",
+		"cluster.x-k8s.io/v1alpha3, Kind=Machine, ns1/cluster1-m1",
+		"cluster.x-k8s.io/v1alpha3, Kind=MachineSet, ns1/cluster1-ms1",
+		"infrastructure.cluster.x-k8s.io/v1alpha3, Kind=DummyInfrastructureCluster, ns1/cluster2",
+		"infrastructure.cluster.x-k8s.io/v1alpha3, Kind=DummyInfrastructureMachine, ns1/cluster1-m1",
+	}
+	gotExcluded := []string{}
+	for _, node := range graph.getExcludedNodes() {
+		gotExcluded = append(gotExcluded, string(node.identity.UID))
+	}
+	sort.Strings(gotExcluded)
+	if !reflect.DeepEqual(gotExcluded, wantExcluded) {
+		t.Errorf("got excluded = %s, expected = %s", gotExcluded, wantExcluded)
+	}
+
+	// Excluded objects are neither moved nor backed up.
+	for _, node := range graph.getNodesWithClusterTenants() {
+		if node.excluded {
+			t.Errorf("excluded %s is a tenant of a Cluster", node.identity.UID)
+		}
+	}
+	for _, node := range graph.getNodesForBackup() {
+		if node.excluded {
+			t.Errorf("excluded %s is included in the backup", node.identity.UID)
+		}
+	}
+	if clusters := graph.getClusters(); len(clusters) != 1 || clusters[0].identity.Name != "cluster1" {
+		t.Errorf("got clusters = %v, expected only cluster1", clusters)
+	}
+}
@@ -0,0 +1,370 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/pkg/errors"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/rand"
+	"k8s.io/apimachinery/pkg/util/sets"
+	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
+	logf "sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// operationLockName is the name of the Lease recording the clusterctl operation in progress on a management cluster.
+	operationLockName = "clusterctl-lock"
+
+	// operationLockNamespace is the namespace of the Leases recording the clusterctl operations in progress.
+	operationLockNamespace = "kube-system"
+
+	// operationLockAnnotation is the annotation recording the operation holding the lock.
+	operationLockAnnotation = "clusterctl.cluster.x-k8s.io/operation"
+)
+
+var (
+	// operationLockDuration is the duration of an operation lock; the lock is renewed while the operation is in
+	// progress, so a lock left behind by a clusterctl process that did not release it (e.g. because it was killed)
+	// can be taken over shortly after the process stops renewing it.
+	operationLockDuration = 1 * time.Minute
+
+	// operationLockRenewPeriod is how often the operation locks are renewed while the operation is in progress.
+	operationLockRenewPeriod = 20 * time.Second
+)
+
+// heldOperationLocks records the holder identities of the operation locks held by this clusterctl process, so
+// acquiring a lock already held by this process (e.g. when moving within the same management cluster) is a no-op.
+var heldOperationLocks = struct {
+	sync.Mutex
+	holders sets.String
+}{holders: sets.NewString()}
+
+// OperationLock serializes the clusterctl operations changing a management cluster, so two users or CI jobs running
+// clusterctl concurrently against the same management cluster do not corrupt its state.
+type OperationLock interface {
+	// Acquire records in the management cluster that the given operation (e.g. init, upgrade, delete, move) is in
+	// progress, failing fast if another clusterctl operation is already in progress. The returned function
+	// releases the lock, and it should be deferred by the callers.
+	Acquire(ctx context.Context, operation string) (release func(), err error)
+}
+
+// operationLock implements OperationLock using a Lease in the kube-system namespace; when the client is scoped
+// to some inventory namespaces, there is a Lease for each of them, so independent users of the management cluster
+// do not block each other, while they are still blocked by the operations on the whole management cluster.
+type operationLock struct {
+	proxy      Proxy
+	namespaces []string
+	identity   string
+	log        logr.Logger
+}
+
+// ensure operationLock implements OperationLock.
+var _ OperationLock = &operationLock{}
+
+func newOperationLock(proxy Proxy, namespaces []string) *operationLock {
+	return &operationLock{
+		proxy:      proxy,
+		namespaces: namespaces,
+		identity:   operationLockIdentity(),
+		log:        logf.Log,
+	}
+}
+
+// operationLockIdentity returns the identity of the clusterctl process acquiring the locks.
+func operationLockIdentity() string {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "unknown"
+	}
+	return fmt.Sprintf("%s@%s-%d", currentUser(), hostname, os.Getpid())
+}
+
+// operationInProgressError is returned when a lock is held by another clusterctl operation.
+type operationInProgressError struct {
+	name      string
+	operation string
+	holder    string
+	since     time.Time
+}
+
+func (e *operationInProgressError) Error() string {
+	return fmt.Sprintf("operation in progress: clusterctl %s was started by %s at %s; wait for it to complete, "+
+		"or, if it was interrupted, delete the Lease %s/%s or wait until it expires",
+		e.operation, e.holder, e.since.Format(time.RFC3339), operationLockNamespace, e.name)
+}
+
+func (l *operationLock) Acquire(ctx context.Context, operation string) (func(), error) {
+	c, err := l.proxy.NewClient()
+	if err != nil {
+		return nil, err
+	}
+
+	// The random suffix makes the holder unique to this acquisition, so it is not mistaken for another clusterctl
+	// process with the same identity, e.g. running in a container with the same hostname and pid.
+	holder := fmt.Sprintf("%s-%s", l.identity, rand.String(5))
+
+	var acquired []string
+	releaseLocks := func() {
+		// Releases the locks in reverse order.
+		for i := len(acquired) - 1; i >= 0; i-- {
+			l.release(c, holder, acquired[i])
+		}
+	}
+
+	for _, name := range l.lockNames() {
+		ok, err := l.acquire(ctx, c, holder, name, operation)
+		if err != nil {
+			releaseLocks()
+			return nil, err
+		}
+		if ok {
+			acquired = append(acquired, name)
+		}
+	}
+
+	// Operations scoped to some inventory namespaces and operations on the whole management cluster acquire
+	// different locks, so each of them checks the locks of the other kind after acquiring its own.
+	if err := l.checkOtherLocks(ctx, c); err != nil {
+		releaseLocks()
+		return nil, err
+	}
+
+	heldOperationLocks.Lock()
+	heldOperationLocks.holders.Insert(holder)
+	heldOperationLocks.Unlock()
+
+	// Renews the locks while the operation is in progress, so they do not expire during long operations.
+	period := operationLockRenewPeriod
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		l.renew(c, holder, acquired, period, stop)
+	}()
+
+	return func() {
+		close(stop)
+		<-done
+		releaseLocks()
+
+		heldOperationLocks.Lock()
+		heldOperationLocks.holders.Delete(holder)
+		heldOperationLocks.Unlock()
+	}, nil
+}
+
+// lockNames returns the names of the Leases to be acquired, sorted so concurrent operations acquire them in the same order.
+func (l *operationLock) lockNames() []string {
+	if len(l.namespaces) == 0 {
+		return []string{operationLockName}
+	}
+
+	names := make([]string, 0, len(l.namespaces))
+	for _, ns := range l.namespaces {
+		names = append(names, fmt.Sprintf("%s-%s", operationLockName, ns))
+	}
+	sort.Strings(names)
+	return names
+}
+
+// acquire acquires a Lease for the given holder; it returns false if the Lease is already held by this clusterctl process.
+func (l *operationLock) acquire(ctx context.Context, c client.Client, holder, name, operation string) (bool, error) {
+	now := metav1.NewMicroTime(time.Now())
+	duration := int32(operationLockDuration.Seconds())
+	lease := &coordinationv1.Lease{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Namespace:   operationLockNamespace,
+			Labels:      map[string]string{clusterctlv1.ClusterctlLabelName: ""},
+			Annotations: map[string]string{operationLockAnnotation: operation},
+		},
+		Spec: coordinationv1.LeaseSpec{
+			HolderIdentity:       &holder,
+			LeaseDurationSeconds: &duration,
+			AcquireTime:          &now,
+			RenewTime:            &now,
+		},
+	}
+
+	err := c.Create(ctx, lease)
+	if err == nil {
+		l.log.V(5).Info("Operation lock acquired", "Lease", name, "Operation", operation)
+		return true, nil
+	}
+	if !apierrors.IsAlreadyExists(err) {
+		return false, errors.Wrapf(err, "failed to acquire the operation lock %s/%s", operationLockNamespace, name)
+	}
+
+	existing := &coordinationv1.Lease{}
+	key := client.ObjectKey{Namespace: operationLockNamespace, Name: name}
+	if err := c.Get(ctx, key, existing); err != nil {
+		return false, errors.Wrapf(err, "failed to read the operation lock %s/%s", operationLockNamespace, name)
+	}
+
+	// If the lock is already held by this clusterctl process (e.g. when moving within the same management cluster),
+	// there is nothing to do; the lock is renewed and released by the caller who acquired it first.
+	if heldByThisProcess(existing) {
+		return false, nil
+	}
+
+	if !leaseExpired(existing, now.Time) {
+		return false, newOperationInProgressError(existing)
+	}
+
+	// The lock was left behind by a clusterctl operation that did not complete, so it can be taken over; if the
+	// update conflicts, another clusterctl process took it over in the meantime.
+	l.log.Info("Taking over an expired operation lock", "Lease", name, "Operation", existing.Annotations[operationLockAnnotation])
+	existing.Annotations = lease.Annotations
+	existing.Labels = lease.Labels
+	existing.Spec = lease.Spec
+	if err := c.Update(ctx, existing); err != nil {
+		if apierrors.IsConflict(err) {
+			return false, errors.Errorf("operation in progress: another clusterctl operation acquired the operation lock %s/%s", operationLockNamespace, name)
+		}
+		return false, errors.Wrapf(err, "failed to acquire the operation lock %s/%s", operationLockNamespace, name)
+	}
+	return true, nil
+}
+
+// checkOtherLocks returns an error if an operation on the whole management cluster is in progress when acquiring
+// the locks of some inventory namespaces, or if an operation scoped to some inventory namespaces is in progress when
+// acquiring the lock of the whole management cluster.
+func (l *operationLock) checkOtherLocks(ctx context.Context, c client.Client) error {
+	leaseList := &coordinationv1.LeaseList{}
+	if err := c.List(ctx, leaseList, client.InNamespace(operationLockNamespace), client.MatchingLabels{clusterctlv1.ClusterctlLabelName: ""}); err != nil {
+		return errors.Wrapf(err, "failed to read the operation locks in the %s namespace", operationLockNamespace)
+	}
+
+	now := time.Now()
+	for i := range leaseList.Items {
+		lease := &leaseList.Items[i]
+		scoped := strings.HasPrefix(lease.Name, operationLockName+"-")
+		if lease.Name != operationLockName && !scoped {
+			continue
+		}
+		if scoped == (len(l.namespaces) > 0) {
+			continue
+		}
+		if heldByThisProcess(lease) || leaseExpired(lease, now) {
+			continue
+		}
+		return newOperationInProgressError(lease)
+	}
+	return nil
+}
+
+// renew periodically renews the Leases acquired by the given holder, until stop is closed.
+func (l *operationLock) renew(c client.Client, holder string, names []string, period time.Duration, stop <-chan struct{}) {
+	if len(names) == 0 {
+		return
+	}
+
+	ticker := time.NewTicker(period)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			for _, name := range names {
+				if err := l.renewLease(c, holder, name); err != nil {
+					l.log.Error(err, "Failed to renew the operation lock", "Lease", name)
+				}
+			}
+		}
+	}
+}
+
+// renewLease updates the renew time of a Lease, if it is still held by the given holder.
+func (l *operationLock) renewLease(c client.Client, holder, name string) error {
+	ctx := context.Background()
+	lease := &coordinationv1.Lease{}
+	key := client.ObjectKey{Namespace: operationLockNamespace, Name: name}
+	if err := c.Get(ctx, key, lease); err != nil {
+		return err
+	}
+	if h := lease.Spec.HolderIdentity; h == nil || *h != holder {
+		return errors.Errorf("the operation lock %s/%s is no longer held by this clusterctl operation", operationLockNamespace, name)
+	}
+	now := metav1.NewMicroTime(time.Now())
+	lease.Spec.RenewTime = &now
+	return c.Update(ctx, lease)
+}
+
+// release deletes a Lease, if it is still held by the given holder.
+func (l *operationLock) release(c client.Client, holder, name string) {
+	ctx := context.Background()
+	lease := &coordinationv1.Lease{}
+	key := client.ObjectKey{Namespace: operationLockNamespace, Name: name}
+	if err := c.Get(ctx, key, lease); err != nil {
+		if !apierrors.IsNotFound(err) {
+			l.log.Error(err, "Failed to release the operation lock", "Lease", name)
+		}
+		return
+	}
+	if h := lease.Spec.HolderIdentity; h == nil || *h != holder {
+		return
+	}
+	if err := c.Delete(ctx, lease); err != nil && !apierrors.IsNotFound(err) {
+		l.log.Error(err, "Failed to release the operation lock", "Lease", name)
+	}
+}
+
+// heldByThisProcess returns true if the Lease is held by an operation lock acquired by this clusterctl process.
+func heldByThisProcess(lease *coordinationv1.Lease) bool {
+	if lease.Spec.HolderIdentity == nil {
+		return false
+	}
+	heldOperationLocks.Lock()
+	defer heldOperationLocks.Unlock()
+	return heldOperationLocks.holders.Has(*lease.Spec.HolderIdentity)
+}
+
+// leaseExpired returns true if the Lease was not renewed within its duration.
+func leaseExpired(lease *coordinationv1.Lease, now time.Time) bool {
+	if lease.Spec.RenewTime == nil || lease.Spec.LeaseDurationSeconds == nil {
+		return true
+	}
+	expiry := lease.Spec.RenewTime.Add(time.Duration(*lease.Spec.LeaseDurationSeconds) * time.Second)
+	return now.After(expiry)
+}
+
+func newOperationInProgressError(lease *coordinationv1.Lease) error {
+	e := &operationInProgressError{
+		name:      lease.Name,
+		operation: lease.Annotations[operationLockAnnotation],
+	}
+	if lease.Spec.HolderIdentity != nil {
+		e.holder = *lease.Spec.HolderIdentity
+	}
+	if lease.Spec.AcquireTime != nil {
+		e.since = lease.Spec.AcquireTime.Time
+	}
+	return e
+}
@@ -0,0 +1,246 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	coordinationv1 "k8s.io/api/coordination/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/internal/test"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func fakeOperationLease(name, holder, operation string, renewed time.Time) *coordinationv1.Lease {
+	renewTime := metav1.NewMicroTime(renewed)
+	duration := int32(operationLockDuration.Seconds())
+	return &coordinationv1.Lease{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: coordinationv1.SchemeGroupVersion.String(),
+			Kind:       "Lease",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Namespace:   operationLockNamespace,
+			Labels:      map[string]string{clusterctlv1.ClusterctlLabelName: ""},
+			Annotations: map[string]string{operationLockAnnotation: operation},
+		},
+		Spec: coordinationv1.LeaseSpec{
+			HolderIdentity:       &holder,
+			LeaseDurationSeconds: &duration,
+			AcquireTime:          &renewTime,
+			RenewTime:            &renewTime,
+		},
+	}
+}
+
+func getOperationLease(t *testing.T, proxy Proxy, name string) (*coordinationv1.Lease, bool) {
+	c, err := proxy.NewClient()
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	lease := &coordinationv1.Lease{}
+	if err := c.Get(ctx, client.ObjectKey{Namespace: operationLockNamespace, Name: name}, lease); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, false
+		}
+		t.Fatalf("Get() error = %v", err)
+	}
+	return lease, true
+}
+
+func Test_operationLock_Acquire(t *testing.T) {
+	proxy := test.NewFakeProxy()
+	lock := newOperationLock(proxy, nil)
+
+	release, err := lock.Acquire(ctx, "upgrade")
+	if err != nil {
+		t.Fatalf("Acquire() error = %v", err)
+	}
+	lease, ok := getOperationLease(t, proxy, operationLockName)
+	if !ok {
+		t.Fatalf("the Lease %s was not created", operationLockName)
+	}
+	holder := *lease.Spec.HolderIdentity
+	if !strings.HasPrefix(holder, lock.identity+"-") {
+		t.Errorf("got holder %q, want it to start with %q", holder, lock.identity+"-")
+	}
+	if got := lease.Annotations[operationLockAnnotation]; got != "upgrade" {
+		t.Errorf("got operation %q, want upgrade", got)
+	}
+
+	// Acquiring the lock again from the same clusterctl process is a no-op.
+	releaseAgain, err := lock.Acquire(ctx, "move")
+	if err != nil {
+		t.Fatalf("Acquire() error = %v, want the lock to be reentrant", err)
+	}
+	releaseAgain()
+	if _, ok := getOperationLease(t, proxy, operationLockName); !ok {
+		t.Fatalf("the Lease %s was deleted by a nested release", operationLockName)
+	}
+
+	release()
+	if _, ok := getOperationLease(t, proxy, operationLockName); ok {
+		t.Fatalf("the Lease %s was not deleted on release", operationLockName)
+	}
+
+	// Once released, the lock can be acquired again, with a different holder.
+	releaseNext, err := lock.Acquire(ctx, "delete")
+	if err != nil {
+		t.Fatalf("Acquire() error = %v", err)
+	}
+	defer releaseNext()
+	lease, _ = getOperationLease(t, proxy, operationLockName)
+	if got := *lease.Spec.HolderIdentity; got == holder {
+		t.Errorf("got holder %q, want a different holder for each acquisition", got)
+	}
+}
+
+func Test_operationLock_AcquireRenews(t *testing.T) {
+	defer func(period time.Duration) { operationLockRenewPeriod = period }(operationLockRenewPeriod)
+	operationLockRenewPeriod = 10 * time.Millisecond
+
+	proxy := test.NewFakeProxy()
+	release, err := newOperationLock(proxy, nil).Acquire(ctx, "upgrade")
+	if err != nil {
+		t.Fatalf("Acquire() error = %v", err)
+	}
+	defer release()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		lease, ok := getOperationLease(t, proxy, operationLockName)
+		if !ok {
+			t.Fatalf("the Lease %s was not created", operationLockName)
+		}
+		if lease.Spec.RenewTime.After(lease.Spec.AcquireTime.Time) {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("the Lease %s was not renewed", operationLockName)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func Test_operationLock_AcquireHeld(t *testing.T) {
+	tests := []struct {
+		name    string
+		renewed time.Time
+		wantErr bool
+	}{
+		{
+			name:    "fails if the lock is held by another clusterctl process",
+			renewed: time.Now(),
+			wantErr: true,
+		},
+		{
+			name:    "takes over the lock if it expired",
+			renewed: time.Now().Add(-2 * operationLockDuration),
+			wantErr: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			proxy := test.NewFakeProxy().WithObjs(fakeOperationLease(operationLockName, "someone@somewhere-1", "init", tt.renewed))
+			lock := newOperationLock(proxy, nil)
+
+			release, err := lock.Acquire(ctx, "upgrade")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Acquire() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			lease, _ := getOperationLease(t, proxy, operationLockName)
+			if tt.wantErr {
+				for _, s := range []string{"operation in progress", "clusterctl init", "someone@somewhere-1", "kube-system/clusterctl-lock"} {
+					if !strings.Contains(err.Error(), s) {
+						t.Errorf("got error %q, want it to contain %q", err.Error(), s)
+					}
+				}
+				if got := *lease.Spec.HolderIdentity; got != "someone@somewhere-1" {
+					t.Errorf("got holder %q, want the lock to be left unchanged", got)
+				}
+				return
+			}
+
+			if got := *lease.Spec.HolderIdentity; !strings.HasPrefix(got, lock.identity+"-") {
+				t.Errorf("got holder %q, want it to start with %q", got, lock.identity+"-")
+			}
+			release()
+			if _, ok := getOperationLease(t, proxy, operationLockName); ok {
+				t.Errorf("the Lease %s was not deleted on release", operationLockName)
+			}
+		})
+	}
+}
+
+func Test_operationLock_AcquireScoped(t *testing.T) {
+	proxy := test.NewFakeProxy().WithObjs(fakeOperationLease(operationLockName+"-ns2", "someone@somewhere-1", "init", time.Now()))
+
+	// Users scoped to other inventory namespaces are not blocked.
+	release, err := newOperationLock(proxy, []string{"ns1"}).Acquire(ctx, "upgrade")
+	if err != nil {
+		t.Fatalf("Acquire() error = %v", err)
+	}
+	release()
+
+	// If one of the locks is held, the locks already acquired are released.
+	if _, err := newOperationLock(proxy, []string{"ns2", "ns1"}).Acquire(ctx, "upgrade"); err == nil {
+		t.Fatalf("Acquire() error = nil, want an operation in progress error")
+	}
+	if _, ok := getOperationLease(t, proxy, operationLockName+"-ns1"); ok {
+		t.Errorf("the Lease %s-ns1 was not released", operationLockName)
+	}
+}
+
+func Test_operationLock_AcquireScopedAndGlobal(t *testing.T) {
+	tests := []struct {
+		name       string
+		lease      string
+		namespaces []string
+	}{
+		{
+			name:       "scoped operations are blocked by an operation on the whole management cluster",
+			lease:      operationLockName,
+			namespaces: []string{"ns1"},
+		},
+		{
+			name:       "operations on the whole management cluster are blocked by a scoped operation",
+			lease:      operationLockName + "-ns1",
+			namespaces: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			proxy := test.NewFakeProxy().WithObjs(fakeOperationLease(tt.lease, "someone@somewhere-1", "init", time.Now()))
+			lock := newOperationLock(proxy, tt.namespaces)
+
+			if _, err := lock.Acquire(ctx, "upgrade"); err == nil || !strings.Contains(err.Error(), "operation in progress") {
+				t.Fatalf("Acquire() error = %v, want an operation in progress error", err)
+			}
+			for _, name := range lock.lockNames() {
+				if _, ok := getOperationLease(t, proxy, name); ok {
+					t.Errorf("the Lease %s was not released", name)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,386 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/go-logr/logr"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	kerrors "k8s.io/apimachinery/pkg/util/errors"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
+	logf "sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// OrphanedOwnersNotFound is the reason for an infrastructure object whose owners do not exist anymore.
+	OrphanedOwnersNotFound = "OwnersNotFound"
+
+	// OrphanedClusterNotFound is the reason for an infrastructure object without owners, linked by the
+	// cluster name label to a Cluster that does not exist anymore.
+	OrphanedClusterNotFound = "ClusterNotFound"
+
+	// OrphanedInfrastructureNotFound is the reason for a Machine whose infrastructure object does not exist anymore.
+	OrphanedInfrastructureNotFound = "InfrastructureNotFound"
+
+	// OrphanedBootstrapConfigNotFound is the reason for a Machine without bootstrap data, whose bootstrap config
+	// object does not exist anymore.
+	OrphanedBootstrapConfigNotFound = "BootstrapConfigNotFound"
+)
+
+// OrphanedObject is an infrastructure or bootstrap object without a corresponding Machine/Cluster owner, e.g.
+// an object leaked by a failed operation, or a Machine whose infrastructure or bootstrap object does not exist anymore.
+type OrphanedObject struct {
+	// APIVersion, Kind, Namespace and Name of the object.
+	APIVersion string
+	Kind       string
+	Namespace  string
+	Name       string
+
+	// Provider is the name of the infrastructure or bootstrap provider owning the object type; it is empty
+	// for Machines and bootstrap data secrets.
+	Provider string
+
+	// Reason why the object is considered orphaned.
+	Reason string
+}
+
+// String returns a string identifying the orphaned object, e.g. AWSMachine default/foo.
+func (o OrphanedObject) String() string {
+	return fmt.Sprintf("%s %s/%s", o.Kind, o.Namespace, o.Name)
+}
+
+// OrphanFinder has methods to find and delete the orphaned objects.
+type OrphanFinder interface {
+	// List returns the orphaned objects in a namespace, or in all the namespaces if empty.
+	List(ctx context.Context, namespace string) ([]OrphanedObject, error)
+
+	// Delete deletes the given orphaned objects; before deleting, each object is checked again,
+	// and objects that are not orphaned anymore are not deleted.
+	Delete(ctx context.Context, orphans []OrphanedObject) error
+}
+
+// orphanFinder implements OrphanFinder.
+type orphanFinder struct {
+	proxy             Proxy
+	providerInventory InventoryClient
+	log               logr.Logger
+}
+
+// ensure orphanFinder implements OrphanFinder.
+var _ OrphanFinder = &orphanFinder{}
+
+func newOrphanFinder(proxy Proxy, providerInventory InventoryClient) *orphanFinder {
+	return &orphanFinder{
+		proxy:             proxy,
+		providerInventory: providerInventory,
+		log:               logf.Log,
+	}
+}
+
+func (f *orphanFinder) List(ctx context.Context, namespace string) ([]OrphanedObject, error) {
+	log := f.log
+
+	c, err := f.proxy.NewClient()
+	if err != nil {
+		return nil, err
+	}
+
+	providerList, err := f.providerInventory.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	providers := map[string]bool{}
+	for _, p := range providerList.FilterByType(clusterctlv1.InfrastructureProviderType) {
+		providers[p.Name] = true
+	}
+	for _, p := range providerList.FilterByType(clusterctlv1.BootstrapProviderType) {
+		providers[p.Name] = true
+	}
+
+	crdList := &apiextensionsv1.CustomResourceDefinitionList{}
+	if err := c.List(ctx, crdList, client.MatchingLabels{clusterctlv1.ClusterctlLabelName: ""}); err != nil {
+		return nil, errors.Wrap(err, "failed to get the list of CRDs installed by clusterctl")
+	}
+
+	selectors := []client.ListOption{}
+	if namespace != "" {
+		selectors = append(selectors, client.InNamespace(namespace))
+	}
+
+	// existingOwners caches the result of the owner lookups, given that many objects usually share the same owner.
+	existingOwners := map[types.UID]bool{}
+
+	ret := []OrphanedObject{}
+	appendOrphans := func(kind string, objs []unstructured.Unstructured, provider string) error {
+		log.V(5).Info("Checking for orphans", "Kind", kind, "Count", len(objs))
+		for i := range objs {
+			obj := &objs[i]
+			reason, err := orphanedReason(ctx, c, obj, existingOwners)
+			if err != nil {
+				return err
+			}
+			if reason == "" {
+				continue
+			}
+			ret = append(ret, OrphanedObject{
+				APIVersion: obj.GetAPIVersion(),
+				Kind:       obj.GetKind(),
+				Namespace:  obj.GetNamespace(),
+				Name:       obj.GetName(),
+				Provider:   provider,
+				Reason:     reason,
+			})
+		}
+		return nil
+	}
+
+	for _, crd := range crdList.Items {
+		provider := crd.Labels[clusterv1.ProviderLabelName]
+		if !providers[provider] {
+			continue
+		}
+
+		for _, version := range crd.Spec.Versions {
+			if !version.Storage {
+				continue
+			}
+
+			objList := new(unstructured.UnstructuredList)
+			objList.SetAPIVersion(metav1.GroupVersion{Group: crd.Spec.Group, Version: version.Name}.String())
+			objList.SetKind(crd.Spec.Names.Kind)
+			if err := c.List(ctx, objList, selectors...); err != nil {
+				if apierrors.IsNotFound(err) {
+					continue
+				}
+				return nil, errors.Wrapf(err, "failed to list %q resources", objList.GroupVersionKind())
+			}
+			if err := appendOrphans(crd.Spec.Names.Kind, objList.Items, provider); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// Checks the bootstrap data secrets, which are not cleaned up by the deletion of a bootstrap config
+	// when their owner references are missing or wrong, e.g. after a failed move.
+	secretList := new(unstructured.UnstructuredList)
+	secretList.SetAPIVersion("v1")
+	secretList.SetKind("SecretList")
+	if err := c.List(ctx, secretList, selectors...); err != nil {
+		return nil, errors.Wrap(err, "failed to list Secrets")
+	}
+	bootstrapDataSecrets := []unstructured.Unstructured{}
+	for _, s := range secretList.Items {
+		if _, ok := s.GetAnnotations()[clusterv1.BootstrapDataContractVersionAnnotation]; ok {
+			bootstrapDataSecrets = append(bootstrapDataSecrets, s)
+		}
+	}
+	if err := appendOrphans("Secret", bootstrapDataSecrets, ""); err != nil {
+		return nil, err
+	}
+
+	// Checks the Machines, which are inconsistent if their infrastructure or bootstrap objects do not exist anymore.
+	machineList := new(unstructured.UnstructuredList)
+	machineList.SetAPIVersion(clusterv1.GroupVersion.String())
+	machineList.SetKind("MachineList")
+	if err := c.List(ctx, machineList, selectors...); err != nil {
+		return nil, errors.Wrap(err, "failed to list Machines")
+	}
+	if err := appendOrphans("Machine", machineList.Items, ""); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(ret, func(i, j int) bool {
+		if ret[i].Namespace != ret[j].Namespace {
+			return ret[i].Namespace < ret[j].Namespace
+		}
+		if ret[i].Kind != ret[j].Kind {
+			return ret[i].Kind < ret[j].Kind
+		}
+		return ret[i].Name < ret[j].Name
+	})
+	return ret, nil
+}
+
+// orphanedReason returns the reason why an infrastructure object is orphaned, or an empty string if it is not.
+// An object is orphaned if:
+// - it has OwnerReferences, but none of the owners exists anymore.
+// - it has no OwnerReferences, and the Cluster it is linked to by the cluster name label does not exist anymore.
+// Objects without OwnerReferences and without the cluster name label, e.g. user provided templates, are never
+// considered orphaned. Machines are checked by machineOrphanedReason instead.
+func orphanedReason(ctx context.Context, c client.Client, obj *unstructured.Unstructured, existingOwners map[types.UID]bool) (string, error) {
+	if obj.GroupVersionKind().GroupKind() == clusterv1.GroupVersion.WithKind("Machine").GroupKind() {
+		return machineOrphanedReason(ctx, c, obj)
+	}
+
+	if ownerReferences := obj.GetOwnerReferences(); len(ownerReferences) > 0 {
+		for _, ownerReference := range ownerReferences {
+			exists, err := ownerExists(ctx, c, obj.GetNamespace(), ownerReference, existingOwners)
+			if err != nil {
+				return "", err
+			}
+			if exists {
+				return "", nil
+			}
+		}
+		return OrphanedOwnersNotFound, nil
+	}
+
+	clusterName, ok := obj.GetLabels()[clusterv1.ClusterLabelName]
+	if !ok || clusterName == "" {
+		return "", nil
+	}
+	cluster := &clusterv1.Cluster{}
+	clusterKey := client.ObjectKey{Namespace: obj.GetNamespace(), Name: clusterName}
+	if err := c.Get(ctx, clusterKey, cluster); err != nil {
+		if apierrors.IsNotFound(err) {
+			return OrphanedClusterNotFound, nil
+		}
+		return "", errors.Wrapf(err, "failed to get Cluster %s/%s", obj.GetNamespace(), clusterName)
+	}
+	return "", nil
+}
+
+// machineOrphanedReason returns the reason why a Machine is orphaned, or an empty string if it is not.
+// A Machine is orphaned if:
+// - its infrastructure object does not exist anymore.
+// - it has no bootstrap data yet, and its bootstrap config object does not exist anymore.
+// Machines being deleted are never considered orphaned.
+func machineOrphanedReason(ctx context.Context, c client.Client, obj *unstructured.Unstructured) (string, error) {
+	if obj.GetDeletionTimestamp() != nil {
+		return "", nil
+	}
+
+	machine := &clusterv1.Machine{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.UnstructuredContent(), machine); err != nil {
+		return "", errors.Wrapf(err, "failed to convert Machine %s/%s", obj.GetNamespace(), obj.GetName())
+	}
+
+	if machine.Spec.InfrastructureRef.Name != "" {
+		exists, err := referenceExists(ctx, c, machine.Namespace, &machine.Spec.InfrastructureRef)
+		if err != nil {
+			return "", err
+		}
+		if !exists {
+			return OrphanedInfrastructureNotFound, nil
+		}
+	}
+
+	bootstrap := machine.Spec.Bootstrap
+	if bootstrap.ConfigRef != nil && bootstrap.Data == nil && bootstrap.DataSecretName == nil {
+		exists, err := referenceExists(ctx, c, machine.Namespace, bootstrap.ConfigRef)
+		if err != nil {
+			return "", err
+		}
+		if !exists {
+			return OrphanedBootstrapConfigNotFound, nil
+		}
+	}
+	return "", nil
+}
+
+// referenceExists checks if the object referenced by a Machine exists.
+func referenceExists(ctx context.Context, c client.Client, namespace string, ref *corev1.ObjectReference) (bool, error) {
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion(ref.APIVersion)
+	obj.SetKind(ref.Kind)
+	key := client.ObjectKey{Namespace: namespace, Name: ref.Name}
+	if err := c.Get(ctx, key, obj); err != nil {
+		if apierrors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, errors.Wrapf(err, "failed to get %s %s/%s", ref.Kind, namespace, ref.Name)
+	}
+	return true, nil
+}
+
+// ownerExists checks if the owner object exists; an object with the same name but a different UID is a different
+// object, so it is not considered the owner.
+func ownerExists(ctx context.Context, c client.Client, namespace string, ownerReference metav1.OwnerReference, existingOwners map[types.UID]bool) (bool, error) {
+	if exists, ok := existingOwners[ownerReference.UID]; ok {
+		return exists, nil
+	}
+
+	owner := &unstructured.Unstructured{}
+	owner.SetAPIVersion(ownerReference.APIVersion)
+	owner.SetKind(ownerReference.Kind)
+	ownerKey := client.ObjectKey{Namespace: namespace, Name: ownerReference.Name}
+	if err := c.Get(ctx, ownerKey, owner); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return false, errors.Wrapf(err, "failed to get %s %s/%s", ownerReference.Kind, namespace, ownerReference.Name)
+		}
+		existingOwners[ownerReference.UID] = false
+		return false, nil
+	}
+
+	exists := owner.GetUID() == ownerReference.UID
+	existingOwners[ownerReference.UID] = exists
+	return exists, nil
+}
+
+func (f *orphanFinder) Delete(ctx context.Context, orphans []OrphanedObject) error {
+	log := f.log
+
+	c, err := f.proxy.NewClient()
+	if err != nil {
+		return err
+	}
+
+	// Checks again the objects to be deleted, so objects adopted in the meantime are preserved.
+	existingOwners := map[types.UID]bool{}
+	errList := []error{}
+	for _, o := range orphans {
+		obj := &unstructured.Unstructured{}
+		obj.SetAPIVersion(o.APIVersion)
+		obj.SetKind(o.Kind)
+		objKey := client.ObjectKey{Namespace: o.Namespace, Name: o.Name}
+		if err := c.Get(ctx, objKey, obj); err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			errList = append(errList, errors.Wrapf(err, "failed to get %s", o))
+			continue
+		}
+
+		reason, err := orphanedReason(ctx, c, obj, existingOwners)
+		if err != nil {
+			errList = append(errList, err)
+			continue
+		}
+		if reason == "" {
+			errList = append(errList, errors.Errorf("%s is not orphaned anymore, skipping deletion", o))
+			continue
+		}
+
+		log.Info("Deleting orphaned object", "Kind", o.Kind, "Namespace", o.Namespace, "Name", o.Name)
+		if err := c.Delete(ctx, obj); err != nil && !apierrors.IsNotFound(err) {
+			errList = append(errList, errors.Wrapf(err, "failed to delete %s", o))
+		}
+	}
+	return kerrors.NewAggregate(errList)
+}
@@ -0,0 +1,307 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/pointer"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/internal/test"
+	fakebootstrap "sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/internal/test/providers/bootstrap"
+	fakeinfrastructure "sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/internal/test/providers/infrastructure"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+var (
+	existingMachine = &clusterv1.Machine{
+		TypeMeta:   metav1.TypeMeta{APIVersion: clusterv1.GroupVersion.String(), Kind: "Machine"},
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: "m1", UID: "m1-uid"},
+	}
+
+	existingCluster = &clusterv1.Cluster{
+		TypeMeta:   metav1.TypeMeta{APIVersion: clusterv1.GroupVersion.String(), Kind: "Cluster"},
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: "cluster1", UID: "cluster1-uid"},
+	}
+)
+
+func machineOwnerReference(name string, uid types.UID) metav1.OwnerReference {
+	return metav1.OwnerReference{
+		APIVersion: clusterv1.GroupVersion.String(),
+		Kind:       "Machine",
+		Name:       name,
+		UID:        uid,
+	}
+}
+
+func infrastructureMachine(namespace, name string, labels map[string]string, ownerReferences ...metav1.OwnerReference) *fakeinfrastructure.DummyInfrastructureMachine {
+	return &fakeinfrastructure.DummyInfrastructureMachine{
+		TypeMeta: metav1.TypeMeta{APIVersion: fakeinfrastructure.GroupVersion.String(), Kind: "DummyInfrastructureMachine"},
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:       namespace,
+			Name:            name,
+			Labels:          labels,
+			OwnerReferences: ownerReferences,
+		},
+	}
+}
+
+func bootstrapConfig(namespace, name string, ownerReferences ...metav1.OwnerReference) *fakebootstrap.DummyBootstrapConfig {
+	return &fakebootstrap.DummyBootstrapConfig{
+		TypeMeta: metav1.TypeMeta{APIVersion: fakebootstrap.GroupVersion.String(), Kind: "DummyBootstrapConfig"},
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:       namespace,
+			Name:            name,
+			OwnerReferences: ownerReferences,
+		},
+	}
+}
+
+func bootstrapDataSecret(namespace, name string, ownerReferences ...metav1.OwnerReference) *corev1.Secret {
+	return &corev1.Secret{
+		TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "Secret"},
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:       namespace,
+			Name:            name,
+			Annotations:     map[string]string{clusterv1.BootstrapDataContractVersionAnnotation: clusterv1.BootstrapDataContractVersion},
+			OwnerReferences: ownerReferences,
+		},
+	}
+}
+
+func machineWithReferences(namespace, name, infrastructureName, bootstrapName string, dataSecretName *string) *clusterv1.Machine {
+	return &clusterv1.Machine{
+		TypeMeta:   metav1.TypeMeta{APIVersion: clusterv1.GroupVersion.String(), Kind: "Machine"},
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+		Spec: clusterv1.MachineSpec{
+			ClusterName: "cluster1",
+			Bootstrap: clusterv1.Bootstrap{
+				ConfigRef:      &corev1.ObjectReference{APIVersion: fakebootstrap.GroupVersion.String(), Kind: "DummyBootstrapConfig", Name: bootstrapName},
+				DataSecretName: dataSecretName,
+			},
+			InfrastructureRef: corev1.ObjectReference{APIVersion: fakeinfrastructure.GroupVersion.String(), Kind: "DummyInfrastructureMachine", Name: infrastructureName},
+		},
+	}
+}
+
+func orphansProxy(objs ...runtime.Object) Proxy {
+	// The infrastructure provider infrastructure-infra owns the DummyInfrastructureMachine type.
+	crd := test.FakeCustomResourceDefinition(fakeinfrastructure.GroupVersion.Group, "DummyInfrastructureMachine", fakeinfrastructure.GroupVersion.Version)
+	crd.Labels[clusterv1.ProviderLabelName] = "infrastructure-infra"
+
+	// The bootstrap provider bootstrap-config owns the DummyBootstrapConfig type.
+	bootstrapCRD := test.FakeCustomResourceDefinition(fakebootstrap.GroupVersion.Group, "DummyBootstrapConfig", fakebootstrap.GroupVersion.Version)
+	bootstrapCRD.Labels[clusterv1.ProviderLabelName] = "bootstrap-config"
+
+	return test.NewFakeProxy().
+		WithProviderInventory("infrastructure-infra", clusterctlv1.InfrastructureProviderType, "v1.0.0", "infra-system", "").
+		WithProviderInventory("bootstrap-config", clusterctlv1.BootstrapProviderType, "v1.0.0", "bootstrap-system", "").
+		WithObjs(append([]runtime.Object{crd, bootstrapCRD, existingMachine, existingCluster}, objs...)...)
+}
+
+func Test_orphanFinder_List(t *testing.T) {
+	tests := []struct {
+		name      string
+		objs      []runtime.Object
+		namespace string
+		want      []OrphanedObject
+	}{
+		{
+			name: "objects owned by existing objects are not orphaned",
+			objs: []runtime.Object{
+				infrastructureMachine("ns1", "im1", nil, machineOwnerReference("m1", "m1-uid")),
+			},
+			want: []OrphanedObject{},
+		},
+		{
+			name: "objects without owners and without the cluster name label are not orphaned",
+			objs: []runtime.Object{
+				infrastructureMachine("ns1", "im1", nil),
+			},
+			want: []OrphanedObject{},
+		},
+		{
+			name: "objects without owners linked to an existing cluster are not orphaned",
+			objs: []runtime.Object{
+				infrastructureMachine("ns1", "im1", map[string]string{clusterv1.ClusterLabelName: "cluster1"}),
+			},
+			want: []OrphanedObject{},
+		},
+		{
+			name: "objects owned by objects that do not exist are orphaned",
+			objs: []runtime.Object{
+				infrastructureMachine("ns1", "im1", nil, machineOwnerReference("m2", "m2-uid")),
+				// An object with the same name but a different UID is not the owner.
+				infrastructureMachine("ns1", "im2", nil, machineOwnerReference("m1", "old-m1-uid")),
+			},
+			want: []OrphanedObject{
+				{APIVersion: fakeinfrastructure.GroupVersion.String(), Kind: "DummyInfrastructureMachine", Namespace: "ns1", Name: "im1", Provider: "infrastructure-infra", Reason: OrphanedOwnersNotFound},
+				{APIVersion: fakeinfrastructure.GroupVersion.String(), Kind: "DummyInfrastructureMachine", Namespace: "ns1", Name: "im2", Provider: "infrastructure-infra", Reason: OrphanedOwnersNotFound},
+			},
+		},
+		{
+			name: "objects without owners linked to a cluster that does not exist are orphaned",
+			objs: []runtime.Object{
+				infrastructureMachine("ns1", "im1", map[string]string{clusterv1.ClusterLabelName: "cluster2"}),
+			},
+			want: []OrphanedObject{
+				{APIVersion: fakeinfrastructure.GroupVersion.String(), Kind: "DummyInfrastructureMachine", Namespace: "ns1", Name: "im1", Provider: "infrastructure-infra", Reason: OrphanedClusterNotFound},
+			},
+		},
+		{
+			name: "only objects in the namespace are checked",
+			objs: []runtime.Object{
+				infrastructureMachine("ns1", "im1", nil, machineOwnerReference("m2", "m2-uid")),
+				infrastructureMachine("ns2", "im2", nil, machineOwnerReference("m2", "m2-uid")),
+			},
+			namespace: "ns2",
+			want: []OrphanedObject{
+				{APIVersion: fakeinfrastructure.GroupVersion.String(), Kind: "DummyInfrastructureMachine", Namespace: "ns2", Name: "im2", Provider: "infrastructure-infra", Reason: OrphanedOwnersNotFound},
+			},
+		},
+		{
+			name: "bootstrap objects and bootstrap data secrets owned by objects that do not exist are orphaned",
+			objs: []runtime.Object{
+				bootstrapConfig("ns1", "bc1", machineOwnerReference("m1", "m1-uid")),
+				bootstrapConfig("ns1", "bc2", machineOwnerReference("m2", "m2-uid")),
+				bootstrapDataSecret("ns1", "bc2", metav1.OwnerReference{APIVersion: fakebootstrap.GroupVersion.String(), Kind: "DummyBootstrapConfig", Name: "bc3", UID: "bc3-uid"}),
+				// Secrets which are not bootstrap data secrets are not checked.
+				&corev1.Secret{
+					TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Secret"},
+					ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: "other", OwnerReferences: []metav1.OwnerReference{machineOwnerReference("m2", "m2-uid")}},
+				},
+			},
+			want: []OrphanedObject{
+				{APIVersion: fakebootstrap.GroupVersion.String(), Kind: "DummyBootstrapConfig", Namespace: "ns1", Name: "bc2", Provider: "bootstrap-config", Reason: OrphanedOwnersNotFound},
+				{APIVersion: "v1", Kind: "Secret", Namespace: "ns1", Name: "bc2", Provider: "", Reason: OrphanedOwnersNotFound},
+			},
+		},
+		{
+			name: "machines whose infrastructure or bootstrap objects do not exist are orphaned",
+			objs: []runtime.Object{
+				infrastructureMachine("ns1", "im1", nil, machineOwnerReference("m1", "m1-uid")),
+				bootstrapConfig("ns1", "bc1", machineOwnerReference("m1", "m1-uid")),
+				machineWithReferences("ns1", "m2", "im1", "bc1", nil),
+				machineWithReferences("ns1", "m3", "im3", "bc1", nil),
+				machineWithReferences("ns1", "m4", "im1", "bc4", nil),
+				// The bootstrap config is not required anymore once the bootstrap data is available.
+				machineWithReferences("ns1", "m5", "im1", "bc5", pointer.StringPtr("bootstrap-data")),
+			},
+			want: []OrphanedObject{
+				{APIVersion: clusterv1.GroupVersion.String(), Kind: "Machine", Namespace: "ns1", Name: "m3", Provider: "", Reason: OrphanedInfrastructureNotFound},
+				{APIVersion: clusterv1.GroupVersion.String(), Kind: "Machine", Namespace: "ns1", Name: "m4", Provider: "", Reason: OrphanedBootstrapConfigNotFound},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := orphansProxy(tt.objs...)
+			f := newOrphanFinder(p, newInventoryClient(p, fakeObjectWaiter))
+
+			got, err := f.List(ctx, tt.namespace)
+			if err != nil {
+				t.Fatalf("error = %v, want nil", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_orphanFinder_Delete(t *testing.T) {
+	orphan := func(name string) OrphanedObject {
+		return OrphanedObject{APIVersion: fakeinfrastructure.GroupVersion.String(), Kind: "DummyInfrastructureMachine", Namespace: "ns1", Name: name}
+	}
+
+	tests := []struct {
+		name        string
+		objs        []runtime.Object
+		orphans     []OrphanedObject
+		wantDeleted []string
+		wantErr     bool
+	}{
+		{
+			name: "delete orphaned objects",
+			objs: []runtime.Object{
+				infrastructureMachine("ns1", "im1", nil, machineOwnerReference("m2", "m2-uid")),
+				infrastructureMachine("ns1", "im2", map[string]string{clusterv1.ClusterLabelName: "cluster2"}),
+			},
+			orphans:     []OrphanedObject{orphan("im1"), orphan("im2")},
+			wantDeleted: []string{"im1", "im2"},
+			wantErr:     false,
+		},
+		{
+			name:        "objects already deleted are ignored",
+			orphans:     []OrphanedObject{orphan("im1")},
+			wantDeleted: []string{"im1"},
+			wantErr:     false,
+		},
+		{
+			name: "objects not orphaned anymore are not deleted",
+			objs: []runtime.Object{
+				infrastructureMachine("ns1", "im1", nil, machineOwnerReference("m2", "m2-uid")),
+				infrastructureMachine("ns1", "im2", nil, machineOwnerReference("m1", "m1-uid")),
+			},
+			orphans:     []OrphanedObject{orphan("im1"), orphan("im2")},
+			wantDeleted: []string{"im1"},
+			wantErr:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := orphansProxy(tt.objs...)
+			f := newOrphanFinder(p, newInventoryClient(p, fakeObjectWaiter))
+
+			err := f.Delete(ctx, tt.orphans)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			c, err := p.NewClient()
+			if err != nil {
+				t.Fatalf("error = %v, want nil", err)
+			}
+			for _, o := range tt.orphans {
+				obj := &unstructured.Unstructured{}
+				obj.SetAPIVersion(o.APIVersion)
+				obj.SetKind(o.Kind)
+				err := c.Get(ctx, client.ObjectKey{Namespace: o.Namespace, Name: o.Name}, obj)
+
+				deleted := false
+				for _, d := range tt.wantDeleted {
+					if d == o.Name {
+						deleted = true
+					}
+				}
+				if deleted && err == nil {
+					t.Errorf("%s should be deleted", o)
+				}
+				if !deleted && err != nil {
+					t.Errorf("%s should not be deleted, got error %v", o, err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,211 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	logf "sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const pausePollInterval = 2 * time.Second
+
+// PauseClient has methods to pause and resume the reconciliation of Clusters.
+type PauseClient interface {
+	// Pause pauses the reconciliation of the Cluster with the given namespace and name by setting spec.paused, then
+	// waits, until the timeout expires, for the core controllers to acknowledge the pause by propagating the
+	// cluster.x-k8s.io/paused annotation to the infrastructure, control plane and bootstrap objects referenced by
+	// the Cluster, its Machines and its MachinePools, so the providers stop reconciling them too.
+	// If the timeout is 0, the acknowledgement is not verified.
+	Pause(ctx context.Context, namespace, name string, timeout time.Duration) error
+
+	// Resume resumes the reconciliation of the Cluster with the given namespace and name by unsetting spec.paused,
+	// then waits, until the timeout expires, for the core controllers to remove the cluster.x-k8s.io/paused
+	// annotations they propagated. If the timeout is 0, the acknowledgement is not verified.
+	Resume(ctx context.Context, namespace, name string, timeout time.Duration) error
+}
+
+// pauseClient implements PauseClient.
+type pauseClient struct {
+	proxy        Proxy
+	pollInterval time.Duration
+	log          logr.Logger
+}
+
+// ensure pauseClient implements PauseClient.
+var _ PauseClient = &pauseClient{}
+
+func newPauseClient(proxy Proxy) *pauseClient {
+	return &pauseClient{
+		proxy:        proxy,
+		pollInterval: pausePollInterval,
+		log:          logf.Log,
+	}
+}
+
+func (p *pauseClient) Pause(ctx context.Context, namespace, name string, timeout time.Duration) error {
+	return p.setPaused(ctx, namespace, name, true, timeout)
+}
+
+func (p *pauseClient) Resume(ctx context.Context, namespace, name string, timeout time.Duration) error {
+	return p.setPaused(ctx, namespace, name, false, timeout)
+}
+
+func (p *pauseClient) setPaused(ctx context.Context, namespace, name string, paused bool, timeout time.Duration) error {
+	log := p.log
+
+	log.V(1).Info("Set Cluster.Spec.Paused", "Cluster", name, "Namespace", namespace, "Paused", paused)
+	if err := patchClusterPaused(ctx, p.proxy, namespace, name, paused); err != nil {
+		return err
+	}
+	if timeout == 0 {
+		return nil
+	}
+
+	c, err := p.proxy.NewClient()
+	if err != nil {
+		return err
+	}
+
+	log.Info("Waiting for the controllers to acknowledge the pause", "Cluster", name, "Namespace", namespace, "Paused", paused)
+	var lastErr error
+	err = wait.PollImmediate(p.pollInterval, timeout, func() (bool, error) {
+		if lastErr = checkPauseAcknowledged(ctx, c, namespace, name, paused); lastErr != nil {
+			log.V(5).Info("Pause not acknowledged yet", "Cluster", name, "Namespace", namespace, "Reason", lastErr.Error())
+			return false, nil
+		}
+		return true, nil
+	})
+	if err != nil && lastErr != nil {
+		err = lastErr
+	}
+	return err
+}
+
+// patchClusterPaused sets the paused field on a Cluster object.
+func patchClusterPaused(ctx context.Context, proxy Proxy, namespace, name string, paused bool) error {
+	patch := client.RawPatch(types.MergePatchType, []byte(fmt.Sprintf("{\"spec\":{\"paused\":%t}}", paused)))
+
+	// Using the newest version of Cluster served by the management cluster.
+	clusterGVK, err := negotiateGroupVersionKind(ctx, proxy, clusterv1.GroupVersion.WithKind("Cluster").GroupKind(), clusterv1.GroupVersion.Version)
+	if err != nil {
+		return err
+	}
+
+	c, err := proxy.NewClient()
+	if err != nil {
+		return err
+	}
+
+	clusterObj := &unstructured.Unstructured{}
+	clusterObj.SetGroupVersionKind(clusterGVK)
+	clusterObjKey := client.ObjectKey{
+		Namespace: namespace,
+		Name:      name,
+	}
+
+	if err := c.Get(ctx, clusterObjKey, clusterObj); err != nil {
+		return errors.Wrapf(err, "error reading %q %s/%s",
+			clusterObj.GroupVersionKind(), clusterObjKey.Namespace, clusterObjKey.Name)
+	}
+
+	if err := c.Patch(ctx, clusterObj, patch); err != nil {
+		return errors.Wrapf(err, "error pausing reconciliation for %q %s/%s",
+			clusterObj.GroupVersionKind(), clusterObj.GetNamespace(), clusterObj.GetName())
+	}
+	return nil
+}
+
+// checkPauseAcknowledged checks that the pause, or the resume, of a Cluster has been propagated by the core
+// controllers to the provider objects referenced by the Cluster, its Machines and its MachinePools.
+func checkPauseAcknowledged(ctx context.Context, c client.Client, namespace, name string, paused bool) error {
+	cluster := &clusterv1.Cluster{}
+	if err := c.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, cluster); err != nil {
+		return errors.Wrapf(err, "failed to get Cluster %s/%s", namespace, name)
+	}
+
+	refs := []*corev1.ObjectReference{cluster.Spec.InfrastructureRef, cluster.Spec.ControlPlaneRef}
+
+	machineList := &clusterv1.MachineList{}
+	if err := c.List(ctx, machineList, client.InNamespace(namespace), client.MatchingLabels{clusterv1.ClusterLabelName: name}); err != nil {
+		return errors.Wrapf(err, "failed to list the Machines of Cluster %s/%s", namespace, name)
+	}
+	for i := range machineList.Items {
+		spec := &machineList.Items[i].Spec
+		refs = append(refs, &spec.InfrastructureRef, spec.Bootstrap.ConfigRef)
+	}
+
+	machinePoolList := &clusterv1.MachinePoolList{}
+	if err := c.List(ctx, machinePoolList, client.InNamespace(namespace), client.MatchingLabels{clusterv1.ClusterLabelName: name}); err != nil {
+		return errors.Wrapf(err, "failed to list the MachinePools of Cluster %s/%s", namespace, name)
+	}
+	for i := range machinePoolList.Items {
+		spec := &machinePoolList.Items[i].Spec.Template.Spec
+		refs = append(refs, &spec.InfrastructureRef, spec.Bootstrap.ConfigRef)
+	}
+
+	lagging := []string{}
+	for _, ref := range refs {
+		if ref == nil || ref.Name == "" {
+			continue
+		}
+		obj := &unstructured.Unstructured{}
+		obj.SetAPIVersion(ref.APIVersion)
+		obj.SetKind(ref.Kind)
+		key := client.ObjectKey{Namespace: ref.Namespace, Name: ref.Name}
+		if key.Namespace == "" {
+			key.Namespace = namespace
+		}
+		if err := c.Get(ctx, key, obj); err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return errors.Wrapf(err, "failed to get %s %s/%s", ref.Kind, key.Namespace, key.Name)
+		}
+
+		annotations := obj.GetAnnotations()
+		_, hasPaused := annotations[clusterv1.PausedAnnotation]
+		_, propagated := annotations[clusterv1.PausePropagatedAnnotation]
+		if (paused && !hasPaused) || (!paused && propagated) {
+			lagging = append(lagging, fmt.Sprintf("%s %s", ref.Kind, ref.Name))
+		}
+	}
+
+	if len(lagging) == 0 {
+		return nil
+	}
+	if len(lagging) > maxLaggingObjects {
+		lagging = append(lagging[:maxLaggingObjects], fmt.Sprintf("%d more", len(lagging)-maxLaggingObjects))
+	}
+	action := "pause"
+	if !paused {
+		action = "resume"
+	}
+	return errors.Errorf("the %s of Cluster %s/%s has not been acknowledged yet for the following objects: %s", action, namespace, name, strings.Join(lagging, ", "))
+}
@@ -0,0 +1,156 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/internal/test"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// annotateProviderObjects adds the given annotations to the provider objects, simulating the propagation of the pause
+// of a Cluster by the core controllers.
+func annotateProviderObjects(objs []runtime.Object, annotations map[string]string) []runtime.Object {
+	for _, obj := range objs {
+		group := obj.GetObjectKind().GroupVersionKind().Group
+		if group == clusterv1.GroupVersion.Group || !strings.HasSuffix(group, clusterv1.GroupVersion.Group) {
+			continue
+		}
+		accessor, err := meta.Accessor(obj)
+		if err != nil {
+			continue
+		}
+		accessor.SetAnnotations(annotations)
+	}
+	return objs
+}
+
+func Test_pauseClient_Pause(t *testing.T) {
+	tests := []struct {
+		name    string
+		objs    []runtime.Object
+		timeout time.Duration
+		wantErr bool
+	}{
+		{
+			name: "pauses without verifying the acknowledgement",
+			objs: test.NewFakeCluster("ns1", "cluster1").
+				WithMachines(test.NewFakeMachine("m1")).
+				Objs(),
+			timeout: 0,
+			wantErr: false,
+		},
+		{
+			name: "pauses when the pause is acknowledged",
+			objs: annotateProviderObjects(test.NewFakeCluster("ns1", "cluster1").
+				WithMachines(test.NewFakeMachine("m1")).
+				Objs(), map[string]string{clusterv1.PausedAnnotation: "true", clusterv1.PausePropagatedAnnotation: "cluster1"}),
+			timeout: time.Second,
+			wantErr: false,
+		},
+		{
+			name: "fails if the pause is not acknowledged",
+			objs: test.NewFakeCluster("ns1", "cluster1").
+				WithMachines(test.NewFakeMachine("m1")).
+				Objs(),
+			timeout: 50 * time.Millisecond,
+			wantErr: true,
+		},
+		{
+			name:    "fails if the Cluster does not exist",
+			objs:    []runtime.Object{},
+			timeout: 0,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			proxy := test.NewFakeProxy().WithObjs(tt.objs...)
+			p := newPauseClient(proxy)
+			p.pollInterval = 10 * time.Millisecond
+
+			err := p.Pause(context.Background(), "ns1", "cluster1", tt.timeout)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			c, err := proxy.NewClient()
+			if err != nil {
+				t.Fatalf("NewClient() error = %v", err)
+			}
+			cluster := &clusterv1.Cluster{}
+			if err := c.Get(context.Background(), client.ObjectKey{Namespace: "ns1", Name: "cluster1"}, cluster); err != nil {
+				t.Fatalf("Get() error = %v", err)
+			}
+			if !cluster.Spec.Paused {
+				t.Errorf("got spec.paused = false, want true")
+			}
+		})
+	}
+}
+
+func Test_pauseClient_Resume(t *testing.T) {
+	tests := []struct {
+		name    string
+		objs    []runtime.Object
+		wantErr bool
+	}{
+		{
+			name: "resumes when the resume is acknowledged",
+			objs: test.NewFakeCluster("ns1", "cluster1").
+				WithMachines(test.NewFakeMachine("m1")).
+				Objs(),
+			wantErr: false,
+		},
+		{
+			name: "resumes when the paused annotations set by users are left",
+			objs: annotateProviderObjects(test.NewFakeCluster("ns1", "cluster1").
+				WithMachines(test.NewFakeMachine("m1")).
+				Objs(), map[string]string{clusterv1.PausedAnnotation: "true"}),
+			wantErr: false,
+		},
+		{
+			name: "fails if the resume is not acknowledged",
+			objs: annotateProviderObjects(test.NewFakeCluster("ns1", "cluster1").
+				WithMachines(test.NewFakeMachine("m1")).
+				Objs(), map[string]string{clusterv1.PausedAnnotation: "true", clusterv1.PausePropagatedAnnotation: "cluster1"}),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			proxy := test.NewFakeProxy().WithObjs(tt.objs...)
+			p := newPauseClient(proxy)
+			p.pollInterval = 10 * time.Millisecond
+
+			err := p.Resume(context.Background(), "ns1", "cluster1", 50*time.Millisecond)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,144 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+// ProgressEventType defines the type of a ProgressEvent.
+type ProgressEventType string
+
+const (
+	// ProgressStarted is emitted when an operation, or a step of an operation, starts.
+	ProgressStarted ProgressEventType = "Started"
+
+	// ProgressInProgress is emitted while a step is running, reporting the number of objects processed so far.
+	ProgressInProgress ProgressEventType = "InProgress"
+
+	// ProgressSucceeded is emitted when an operation, or a step of an operation, completes successfully.
+	ProgressSucceeded ProgressEventType = "Succeeded"
+
+	// ProgressFailed is emitted when an operation, or a step of an operation, fails.
+	ProgressFailed ProgressEventType = "Failed"
+)
+
+// Operations reporting progress.
+const (
+	ProgressOperationInstall = "Install"
+	ProgressOperationMove    = "Move"
+	ProgressOperationUpgrade = "Upgrade"
+	ProgressOperationDelete  = "Delete"
+)
+
+// Steps of the operations reporting progress.
+const (
+	// ProgressStepDiscovery is the discovery of the objects to be moved; Total is the number of objects discovered.
+	ProgressStepDiscovery = "Discovery"
+
+	// ProgressStepCreateObjects is the creation of the objects in the target management cluster during move.
+	ProgressStepCreateObjects = "CreateObjects"
+
+	// ProgressStepDeleteObjects is the deletion of the moved objects from the source management cluster.
+	ProgressStepDeleteObjects = "DeleteObjects"
+
+	// ProgressStepInstallProvider is the installation of a provider; Target is the provider instance name.
+	ProgressStepInstallProvider = "InstallProvider"
+
+	// ProgressStepMigrateCRDs is the migration of the objects stored in API versions dropped by an upgrade;
+	// Target is the provider instance name.
+	ProgressStepMigrateCRDs = "MigrateCRDs"
+
+	// ProgressStepUpgradeProvider is the upgrade of a provider; Target is the provider instance name.
+	ProgressStepUpgradeProvider = "UpgradeProvider"
+
+	// ProgressStepDeleteProvider is the deletion of a provider; Target is the provider instance name.
+	ProgressStepDeleteProvider = "DeleteProvider"
+)
+
+// ProgressEvent is a structured event emitted by long-running operations, e.g. install or move.
+type ProgressEvent struct {
+	// Operation is the operation emitting the event, e.g. Move.
+	Operation string
+
+	// Step is the step of the operation the event refers to, e.g. CreateObjects; it is empty for the events
+	// about the operation as a whole.
+	Step string
+
+	// Target is the object the step is working on, e.g. the instance name of a provider, if any.
+	Target string
+
+	// Type is the type of the event.
+	Type ProgressEventType
+
+	// Current is the number of items processed so far; it is meaningful only when Total is greater than zero.
+	Current int
+
+	// Total is the number of items to be processed by the step, if known.
+	Total int
+
+	// Err is the error that caused the failure, for ProgressFailed events.
+	Err error
+}
+
+// ProgressReporter receives the ProgressEvents emitted by long-running operations, so the caller can report the progress
+// to the users, e.g. with a spinner or a progress bar.
+// NB. Events are emitted sequentially, by the goroutine running the operation.
+type ProgressReporter interface {
+	Report(event ProgressEvent)
+}
+
+// ProgressReporterFunc is a function implementing ProgressReporter.
+type ProgressReporterFunc func(event ProgressEvent)
+
+// Report calls f(event).
+func (f ProgressReporterFunc) Report(event ProgressEvent) {
+	f(event)
+}
+
+// progress emits the ProgressEvents for an operation; it is a no-op if there is no reporter.
+type progress struct {
+	reporter  ProgressReporter
+	operation string
+}
+
+func newProgress(reporter ProgressReporter, operation string) progress {
+	return progress{reporter: reporter, operation: operation}
+}
+
+func (p progress) report(event ProgressEvent) {
+	if p.reporter == nil {
+		return
+	}
+	event.Operation = p.operation
+	p.reporter.Report(event)
+}
+
+// started reports that a step (or the whole operation, if step is empty) started.
+func (p progress) started(step, target string, total int) {
+	p.report(ProgressEvent{Step: step, Target: target, Type: ProgressStarted, Total: total})
+}
+
+// updated reports the number of items processed so far by a step.
+func (p progress) updated(step, target string, current, total int) {
+	p.report(ProgressEvent{Step: step, Target: target, Type: ProgressInProgress, Current: current, Total: total})
+}
+
+// completed reports that a step (or the whole operation, if step is empty) succeeded or failed, depending on err.
+func (p progress) completed(step, target string, current, total int, err error) {
+	eventType := ProgressSucceeded
+	if err != nil {
+		eventType = ProgressFailed
+	}
+	p.report(ProgressEvent{Step: step, Target: target, Type: eventType, Current: current, Total: total, Err: err})
+}
@@ -0,0 +1,85 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+// progressRecorder is a ProgressReporter recording all the events received.
+type progressRecorder struct {
+	events []ProgressEvent
+}
+
+func (r *progressRecorder) Report(event ProgressEvent) {
+	r.events = append(r.events, event)
+}
+
+// steps returns the events for a step, without errors, so they can be compared.
+func (r *progressRecorder) steps(step string) []ProgressEvent {
+	ret := []ProgressEvent{}
+	for _, e := range r.events {
+		if e.Step == step {
+			e.Err = nil
+			ret = append(ret, e)
+		}
+	}
+	return ret
+}
+
+func Test_progress(t *testing.T) {
+	recorder := &progressRecorder{}
+	p := newProgress(recorder, ProgressOperationMove)
+
+	p.started(ProgressStepCreateObjects, "", 2)
+	p.updated(ProgressStepCreateObjects, "", 1, 2)
+	p.completed(ProgressStepCreateObjects, "", 1, 2, errors.New("failed"))
+	p.completed("", "", 0, 0, nil)
+
+	want := []ProgressEvent{
+		{Operation: ProgressOperationMove, Step: ProgressStepCreateObjects, Type: ProgressStarted, Total: 2},
+		{Operation: ProgressOperationMove, Step: ProgressStepCreateObjects, Type: ProgressInProgress, Current: 1, Total: 2},
+		{Operation: ProgressOperationMove, Step: ProgressStepCreateObjects, Type: ProgressFailed, Current: 1, Total: 2},
+		{Operation: ProgressOperationMove, Type: ProgressSucceeded},
+	}
+	if recorder.events[2].Err == nil {
+		t.Error("expected the error to be reported with the Failed event")
+	}
+	recorder.events[2].Err = nil
+	if !reflect.DeepEqual(recorder.events, want) {
+		t.Errorf("got %v, want %v", recorder.events, want)
+	}
+
+	// A progress without a reporter is a no-op.
+	newProgress(nil, ProgressOperationMove).started("", "", 0)
+}
+
+func Test_ProgressReporterFunc(t *testing.T) {
+	var got ProgressEvent
+	var reporter ProgressReporter = ProgressReporterFunc(func(event ProgressEvent) {
+		got = event
+	})
+
+	want := ProgressEvent{Operation: ProgressOperationInstall, Type: ProgressStarted}
+	reporter.Report(want)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
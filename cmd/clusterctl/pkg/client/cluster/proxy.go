@@ -17,13 +17,23 @@ limitations under the License.
 package cluster
 
 import (
+	"context"
 	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+	"time"
 
+	"github.com/go-logr/logr"
 	"github.com/pkg/errors"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
+	// Exec credential plugins are supported by client-go out of the box, while auth provider plugins must be registered.
+	_ "k8s.io/client-go/plugin/pkg/client/auth/oidc"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/internal/scheme"
@@ -34,27 +44,81 @@ import (
 
 var (
 	Scheme = scheme.Scheme
+
+	// inClusterNamespaceFile is the file with the namespace of the pod, mounted with the service account token.
+	inClusterNamespaceFile = "/var/run/secrets/kubernetes.io/serviceaccount/namespace"
 )
 
+// ProxyConfig defines the configuration of the clients used by a Proxy for accessing the management cluster.
+type ProxyConfig struct {
+	// QPS and Burst define the client side rate limiting for the requests to the API server.
+	QPS   float32
+	Burst int
+
+	// Timeout for each request to the API server; if zero, no timeout is set.
+	Timeout time.Duration
+
+	// Retries defines how many times a request failing with a transient error (e.g. a 429 response or an EOF) is retried;
+	// if zero, failed requests are not retried.
+	Retries int
+
+	// RetryInterval defines the interval before the first retry; the interval doubles for each subsequent retry.
+	RetryInterval time.Duration
+}
+
+// DefaultProxyConfig returns the default configuration for the clients used by a Proxy.
+func DefaultProxyConfig() ProxyConfig {
+	return ProxyConfig{
+		// Set QPS and Burst to a threshold that ensures the controller runtime client/client go does't generate throttling log messages
+		QPS:           20,
+		Burst:         100,
+		Retries:       3,
+		RetryInterval: 500 * time.Millisecond,
+	}
+}
+
 type proxy struct {
 	kubeconfig string
+	context    string
+	config     ProxyConfig
+
+	// inCluster is true when clusterctl runs inside a pod without a kubeconfig file; in this case the
+	// service account of the pod is used for accessing the cluster where the pod is running.
+	inCluster bool
+	log       logr.Logger
 }
 
 var _ Proxy = &proxy{}
 
 func (k *proxy) CurrentNamespace() (string, error) {
+	if k.inCluster {
+		namespace, err := ioutil.ReadFile(inClusterNamespaceFile)
+		if err != nil {
+			return "", errors.Wrapf(err, "failed to read the pod namespace from %q", inClusterNamespaceFile)
+		}
+		if ns := strings.TrimSpace(string(namespace)); ns != "" {
+			return ns, nil
+		}
+		return "default", nil
+	}
+
 	config, err := clientcmd.LoadFromFile(k.kubeconfig)
 	if err != nil {
 		return "", errors.Wrapf(err, "failed to load Kubeconfig file from %q", k.kubeconfig)
 	}
 
-	if config.CurrentContext == "" {
+	contextName := config.CurrentContext
+	if k.context != "" {
+		contextName = k.context
+	}
+
+	if contextName == "" {
 		return "", errors.Wrapf(err, "failed to get current-context from %q", k.kubeconfig)
 	}
 
-	v, ok := config.Contexts[config.CurrentContext]
+	v, ok := config.Contexts[contextName]
 	if !ok {
-		return "", errors.Wrapf(err, "failed to get context %q from %q", config.CurrentContext, k.kubeconfig)
+		return "", errors.Wrapf(err, "failed to get context %q from %q", contextName, k.kubeconfig)
 	}
 
 	if v.Namespace != "" {
@@ -64,6 +128,26 @@ func (k *proxy) CurrentNamespace() (string, error) {
 	return "default", nil
 }
 
+func (k *proxy) GetContexts() ([]string, error) {
+	// There are no contexts when using the in-cluster configuration.
+	if k.inCluster {
+		return []string{}, nil
+	}
+
+	config, err := clientcmd.LoadFromFile(k.kubeconfig)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to load Kubeconfig file from %q", k.kubeconfig)
+	}
+
+	contexts := make([]string, 0, len(config.Contexts))
+	for name := range config.Contexts {
+		contexts = append(contexts, name)
+	}
+	sort.Strings(contexts)
+
+	return contexts, nil
+}
+
 func (k *proxy) NewClient() (client.Client, error) {
 	config, err := k.getConfig()
 	if err != nil {
@@ -80,11 +164,16 @@ func (k *proxy) NewClient() (client.Client, error) {
 		return nil, errors.Wrap(err, "failed to create controller-runtime client")
 	}
 
+	if k.config.Retries > 0 {
+		retryingClient := newRetryingClient(c, k.config.Retries, k.config.RetryInterval, hasRefreshableCredentials(config))
+		retryingClient.log = k.log
+		return retryingClient, nil
+	}
 	return c, nil
 }
 
-func (k *proxy) ListResources(namespace string, labels map[string]string) ([]unstructured.Unstructured, error) {
-	cs, err := k.newClientSet()
+func (k *proxy) ListResources(ctx context.Context, namespace string, labels map[string]string) ([]unstructured.Unstructured, error) {
+	cs, err := k.NewClientSet()
 	if err != nil {
 		return nil, err
 	}
@@ -139,36 +228,66 @@ func (k *proxy) ListResources(namespace string, labels map[string]string) ([]uns
 	return ret, nil
 }
 
-func newProxy(kubeconfig string) Proxy {
-	// If a kubeconfig file isn't provided, find one in the standard locations.
+func newProxy(kubeconfig, context string, config ProxyConfig, log logr.Logger) Proxy {
+	// If a kubeconfig file isn't provided, find one in the standard locations; if there is none and clusterctl
+	// is running inside a pod, use the in-cluster configuration.
+	inCluster := false
 	if kubeconfig == "" {
 		kubeconfig = clientcmd.NewDefaultClientConfigLoadingRules().GetDefaultFilename()
+		if _, err := os.Stat(kubeconfig); os.IsNotExist(err) && isRunningInCluster() {
+			inCluster = true
+		}
 	}
 	return &proxy{
 		kubeconfig: kubeconfig,
+		context:    context,
+		config:     config,
+		inCluster:  inCluster,
+		log:        log,
 	}
 }
 
+// isRunningInCluster returns true if clusterctl is running inside a pod, with the environment variables
+// set by Kubernetes for accessing the API server.
+func isRunningInCluster() bool {
+	return os.Getenv("KUBERNETES_SERVICE_HOST") != "" && os.Getenv("KUBERNETES_SERVICE_PORT") != ""
+}
+
+// hasRefreshableCredentials returns true if the credentials are provided by an exec or an auth provider plugin,
+// which can refresh expired tokens, or by the service account of the pod, whose token is rotated on disk.
+func hasRefreshableCredentials(config *rest.Config) bool {
+	return config.ExecProvider != nil || config.AuthProvider != nil || config.BearerTokenFile != ""
+}
+
 func (k *proxy) getConfig() (*rest.Config, error) {
-	config, err := clientcmd.LoadFromFile(k.kubeconfig)
-	if err != nil {
-		return nil, errors.Wrapf(err, "failed to load Kubeconfig file from %q", k.kubeconfig)
-	}
+	var restConfig *rest.Config
+	if k.inCluster {
+		var err error
+		restConfig, err = rest.InClusterConfig()
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to load the in-cluster configuration")
+		}
+	} else {
+		config, err := clientcmd.LoadFromFile(k.kubeconfig)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to load Kubeconfig file from %q", k.kubeconfig)
+		}
 
-	restConfig, err := clientcmd.NewDefaultClientConfig(*config, &clientcmd.ConfigOverrides{}).ClientConfig()
-	if err != nil {
-		return nil, errors.Wrap(err, "failed to rest client")
+		restConfig, err = clientcmd.NewDefaultClientConfig(*config, &clientcmd.ConfigOverrides{CurrentContext: k.context}).ClientConfig()
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to rest client")
+		}
 	}
 	restConfig.UserAgent = fmt.Sprintf("clusterctl/%s (%s)", version.Get().GitVersion, version.Get().Platform)
 
-	// Set QPS and Burst to a threshold that ensures the controller runtime client/client go does't generate throttling log messages
-	restConfig.QPS = 20
-	restConfig.Burst = 100
+	restConfig.QPS = k.config.QPS
+	restConfig.Burst = k.config.Burst
+	restConfig.Timeout = k.config.Timeout
 
 	return restConfig, nil
 }
 
-func (k *proxy) newClientSet() (*kubernetes.Clientset, error) {
+func (k *proxy) NewClientSet() (kubernetes.Interface, error) {
 	config, err := k.getConfig()
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to create the client-go client")
@@ -181,3 +300,17 @@ func (k *proxy) newClientSet() (*kubernetes.Clientset, error) {
 
 	return cs, nil
 }
+
+func (k *proxy) NewDynamicClient() (dynamic.Interface, error) {
+	config, err := k.getConfig()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create the client-go dynamic client")
+	}
+
+	dc, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create the client-go dynamic client")
+	}
+
+	return dc, nil
+}
@@ -0,0 +1,108 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/rest"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+func Test_proxy_inCluster(t *testing.T) {
+	dir, err := ioutil.TempDir("", "clusterctl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	namespaceFile := filepath.Join(dir, "namespace")
+	if err := ioutil.WriteFile(namespaceFile, []byte("capi-system\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	defer func(f string) { inClusterNamespaceFile = f }(inClusterNamespaceFile)
+	inClusterNamespaceFile = namespaceFile
+
+	p := &proxy{inCluster: true}
+
+	namespace, err := p.CurrentNamespace()
+	if err != nil {
+		t.Fatalf("CurrentNamespace() error = %v", err)
+	}
+	if namespace != "capi-system" {
+		t.Errorf("CurrentNamespace() = %q, want %q", namespace, "capi-system")
+	}
+
+	contexts, err := p.GetContexts()
+	if err != nil {
+		t.Fatalf("GetContexts() error = %v", err)
+	}
+	if len(contexts) != 0 {
+		t.Errorf("GetContexts() = %v, want no contexts", contexts)
+	}
+}
+
+func Test_hasRefreshableCredentials(t *testing.T) {
+	tests := []struct {
+		name   string
+		config *rest.Config
+		want   bool
+	}{
+		{
+			name:   "static token",
+			config: &rest.Config{BearerToken: "token"},
+			want:   false,
+		},
+		{
+			name:   "exec plugin",
+			config: &rest.Config{ExecProvider: &clientcmdapi.ExecConfig{Command: "aws-iam-authenticator"}},
+			want:   true,
+		},
+		{
+			name:   "auth provider plugin",
+			config: &rest.Config{AuthProvider: &clientcmdapi.AuthProviderConfig{Name: "oidc"}},
+			want:   true,
+		},
+		{
+			name:   "service account token file",
+			config: &rest.Config{BearerTokenFile: "/var/run/secrets/kubernetes.io/serviceaccount/token"},
+			want:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := hasRefreshableCredentials(tt.config); got != tt.want {
+				t.Errorf("got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_isTransientOrUnauthorizedError(t *testing.T) {
+	unauthorized := errors.Wrap(apierrors.NewUnauthorized("token expired"), "failed to get")
+	if isTransientError(unauthorized) {
+		t.Errorf("expected unauthorized errors not to be transient")
+	}
+	if !isTransientOrUnauthorizedError(unauthorized) {
+		t.Errorf("expected unauthorized errors to be retried with refreshable credentials")
+	}
+}
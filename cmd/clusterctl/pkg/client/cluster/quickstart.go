@@ -0,0 +1,401 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/wait"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	logf "sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	quickstartPollInterval = 10 * time.Second
+)
+
+// The steps of the quickstart test, in the order they are executed.
+const (
+	QuickstartStepCreate    = "create"
+	QuickstartStepProvision = "provision"
+	QuickstartStepUpgrade   = "upgrade"
+	QuickstartStepScale     = "scale"
+	QuickstartStepDelete    = "delete"
+)
+
+// QuickstartStep reports the outcome of a step of the quickstart test.
+type QuickstartStep struct {
+	// Name of the step.
+	Name string
+
+	// Duration of the step.
+	Duration time.Duration
+
+	// Error is the reason why the step failed, nil if the step succeeded.
+	Error error
+}
+
+// QuickstartOptions carries the options supported by QuickstartRunner.Run.
+type QuickstartOptions struct {
+	// UpgradeToVersion is the Kubernetes version the workload cluster is upgraded to. If empty, the upgrade
+	// step is skipped.
+	UpgradeToVersion string
+
+	// ScaleWorkersBy is the number of Machines added to the first MachineDeployment of the workload cluster.
+	// If zero, the scale step is skipped.
+	ScaleWorkersBy int32
+
+	// StepTimeout is the maximum duration of each step.
+	StepTimeout time.Duration
+
+	// SkipCleanup skips the deletion of the workload cluster at the end of the test.
+	SkipCleanup bool
+}
+
+// QuickstartRunner runs a create-upgrade-scale-delete cycle of a workload cluster, as a smoke test
+// of the providers installed in the management cluster.
+type QuickstartRunner interface {
+	// Run creates the workload cluster defined by the template objects, waits for all its Machines to be running,
+	// optionally upgrades and scales it, and finally deletes it, reporting the outcome of each step.
+	// The steps following a failed step are not executed, except for the deletion of the workload cluster, which
+	// is always executed if the Cluster object was created by the test.
+	Run(ctx context.Context, objs []unstructured.Unstructured, options QuickstartOptions) ([]QuickstartStep, error)
+}
+
+// quickstartRunner implements QuickstartRunner.
+type quickstartRunner struct {
+	proxy        Proxy
+	objectWaiter ObjectWaiter
+	pollInterval time.Duration
+	log          logr.Logger
+}
+
+// ensure quickstartRunner implements QuickstartRunner.
+var _ QuickstartRunner = &quickstartRunner{}
+
+func newQuickstartRunner(proxy Proxy, objectWaiter ObjectWaiter) *quickstartRunner {
+	return &quickstartRunner{
+		proxy:        proxy,
+		objectWaiter: objectWaiter,
+		pollInterval: quickstartPollInterval,
+		log:          logf.Log,
+	}
+}
+
+func (q *quickstartRunner) Run(ctx context.Context, objs []unstructured.Unstructured, options QuickstartOptions) ([]QuickstartStep, error) {
+	clusterObj, err := quickstartCluster(objs)
+	if err != nil {
+		return nil, err
+	}
+	clusterKey := client.ObjectKey{Namespace: clusterObj.GetNamespace(), Name: clusterObj.GetName()}
+
+	c, err := q.proxy.NewClient()
+	if err != nil {
+		return nil, err
+	}
+
+	created := false
+	steps := []struct {
+		name string
+		skip bool
+		run  func() error
+	}{
+		{
+			name: QuickstartStepCreate,
+			run: func() error {
+				return q.create(ctx, c, objs, clusterObj, &created)
+			},
+		},
+		{
+			name: QuickstartStepProvision,
+			run: func() error {
+				return q.waitForMachines(ctx, c, clusterKey, "", options.StepTimeout)
+			},
+		},
+		{
+			name: QuickstartStepUpgrade,
+			skip: options.UpgradeToVersion == "",
+			run: func() error {
+				if err := q.upgrade(ctx, c, clusterKey, options.UpgradeToVersion); err != nil {
+					return err
+				}
+				return q.waitForMachines(ctx, c, clusterKey, options.UpgradeToVersion, options.StepTimeout)
+			},
+		},
+		{
+			name: QuickstartStepScale,
+			skip: options.ScaleWorkersBy == 0,
+			run: func() error {
+				if err := q.scale(ctx, c, clusterKey, options.ScaleWorkersBy); err != nil {
+					return err
+				}
+				return q.waitForMachines(ctx, c, clusterKey, options.UpgradeToVersion, options.StepTimeout)
+			},
+		},
+	}
+
+	results := []QuickstartStep{}
+	for _, s := range steps {
+		if s.skip {
+			continue
+		}
+		result := runQuickstartStep(q.log, s.name, s.run)
+		results = append(results, result)
+		if result.Error != nil {
+			break
+		}
+	}
+
+	// Never delete a Cluster that was not created by the test, e.g. if the create step failed because
+	// a Cluster with the same name already exists.
+	if created && !options.SkipCleanup {
+		results = append(results, runQuickstartStep(q.log, QuickstartStepDelete, func() error {
+			return q.delete(ctx, c, clusterObj, options.StepTimeout)
+		}))
+	}
+	return results, nil
+}
+
+// runQuickstartStep runs a step of the quickstart test, measuring its duration.
+func runQuickstartStep(log logr.Logger, name string, run func() error) QuickstartStep {
+	log.Info("Running quickstart step", "Step", name)
+
+	start := time.Now()
+	err := run()
+	return QuickstartStep{
+		Name:     name,
+		Duration: time.Since(start),
+		Error:    err,
+	}
+}
+
+// quickstartCluster returns the Cluster object defined by the template objects.
+func quickstartCluster(objs []unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	var clusterObj *unstructured.Unstructured
+	for i := range objs {
+		obj := &objs[i]
+		if obj.GroupVersionKind().GroupKind() != clusterv1.GroupVersion.WithKind("Cluster").GroupKind() {
+			continue
+		}
+		if clusterObj != nil {
+			return nil, errors.New("invalid cluster template: it must define exactly one Cluster object")
+		}
+		clusterObj = obj
+	}
+	if clusterObj == nil {
+		return nil, errors.New("invalid cluster template: it must define exactly one Cluster object")
+	}
+	return clusterObj, nil
+}
+
+func (q *quickstartRunner) create(ctx context.Context, c client.Client, objs []unstructured.Unstructured, clusterObj *unstructured.Unstructured, created *bool) error {
+	for i := range objs {
+		obj := objs[i].DeepCopy()
+		if err := c.Create(ctx, obj); err != nil {
+			return errors.Wrapf(err, "failed to create %s %s/%s", obj.GetKind(), obj.GetNamespace(), obj.GetName())
+		}
+		if obj.GroupVersionKind() == clusterObj.GroupVersionKind() && obj.GetName() == clusterObj.GetName() {
+			*created = true
+		}
+	}
+	return nil
+}
+
+// waitForMachines waits until the number of Machines of the workload cluster matches the desired replicas of its
+// control plane and of its MachineDeployments, and all the Machines are running with the given Kubernetes version,
+// if any.
+func (q *quickstartRunner) waitForMachines(ctx context.Context, c client.Client, clusterKey client.ObjectKey, version string, timeout time.Duration) error {
+	status := "no Machines observed yet"
+	err := wait.PollImmediate(q.pollInterval, timeout, func() (bool, error) {
+		desired, err := q.desiredMachines(ctx, c, clusterKey)
+		if err != nil {
+			return false, err
+		}
+
+		machines := &clusterv1.MachineList{}
+		if err := c.List(ctx, machines, client.InNamespace(clusterKey.Namespace), client.MatchingLabels{clusterv1.ClusterLabelName: clusterKey.Name}); err != nil {
+			return false, errors.Wrapf(err, "failed to list Machines for Cluster %s", clusterKey)
+		}
+
+		ready := 0
+		for _, m := range machines.Items {
+			if m.Status.GetTypedPhase() != clusterv1.MachinePhaseRunning || m.Status.NodeRef == nil {
+				continue
+			}
+			if version != "" && (m.Spec.Version == nil || *m.Spec.Version != version) {
+				continue
+			}
+			ready++
+		}
+		status = fmt.Sprintf("%d/%d Machines running, %d desired", ready, len(machines.Items), desired)
+		return ready == desired && len(machines.Items) == desired, nil
+	})
+	if err == wait.ErrWaitTimeout {
+		return errors.Errorf("timed out after %s waiting for the Machines of Cluster %s: %s", timeout, clusterKey, status)
+	}
+	return err
+}
+
+// desiredMachines returns the sum of the desired replicas of the control plane and of the MachineDeployments of a
+// workload cluster.
+func (q *quickstartRunner) desiredMachines(ctx context.Context, c client.Client, clusterKey client.ObjectKey) (int, error) {
+	desired := 0
+
+	controlPlane, err := getControlPlane(ctx, c, clusterKey)
+	if err != nil {
+		return 0, err
+	}
+	if controlPlane != nil {
+		replicas, found, err := unstructured.NestedInt64(controlPlane.Object, "spec", "replicas")
+		if err != nil {
+			return 0, errors.Wrapf(err, "failed to get the replicas of %s %s", controlPlane.GetKind(), clusterKey)
+		}
+		if !found {
+			replicas = 1
+		}
+		desired += int(replicas)
+	}
+
+	mds, err := getMachineDeployments(ctx, c, clusterKey)
+	if err != nil {
+		return 0, err
+	}
+	for _, md := range mds {
+		replicas := int32(1)
+		if md.Spec.Replicas != nil {
+			replicas = *md.Spec.Replicas
+		}
+		desired += int(replicas)
+	}
+	return desired, nil
+}
+
+// upgrade sets the Kubernetes version of the control plane and of the MachineDeployments of a workload cluster.
+func (q *quickstartRunner) upgrade(ctx context.Context, c client.Client, clusterKey client.ObjectKey, version string) error {
+	controlPlane, err := getControlPlane(ctx, c, clusterKey)
+	if err != nil {
+		return err
+	}
+	if controlPlane != nil {
+		patch := client.MergeFrom(controlPlane.DeepCopy())
+		if err := unstructured.SetNestedField(controlPlane.Object, version, "spec", "version"); err != nil {
+			return errors.Wrapf(err, "failed to set the version of %s %s/%s", controlPlane.GetKind(), controlPlane.GetNamespace(), controlPlane.GetName())
+		}
+		if err := c.Patch(ctx, controlPlane, patch); err != nil {
+			return errors.Wrapf(err, "failed to upgrade %s %s/%s", controlPlane.GetKind(), controlPlane.GetNamespace(), controlPlane.GetName())
+		}
+	}
+
+	mds, err := getMachineDeployments(ctx, c, clusterKey)
+	if err != nil {
+		return err
+	}
+	for i := range mds {
+		md := &mds[i]
+		patch := client.MergeFrom(md.DeepCopy())
+		md.Spec.Template.Spec.Version = &version
+		if err := c.Patch(ctx, md, patch); err != nil {
+			return errors.Wrapf(err, "failed to upgrade MachineDeployment %s/%s", md.Namespace, md.Name)
+		}
+	}
+	return nil
+}
+
+// scale adds Machines to the first MachineDeployment, by name, of a workload cluster.
+func (q *quickstartRunner) scale(ctx context.Context, c client.Client, clusterKey client.ObjectKey, by int32) error {
+	mds, err := getMachineDeployments(ctx, c, clusterKey)
+	if err != nil {
+		return err
+	}
+	if len(mds) == 0 {
+		return errors.Errorf("failed to scale Cluster %s: no MachineDeployments found", clusterKey)
+	}
+
+	md := &mds[0]
+	patch := client.MergeFrom(md.DeepCopy())
+	replicas := int32(1)
+	if md.Spec.Replicas != nil {
+		replicas = *md.Spec.Replicas
+	}
+	replicas += by
+	md.Spec.Replicas = &replicas
+	if err := c.Patch(ctx, md, patch); err != nil {
+		return errors.Wrapf(err, "failed to scale MachineDeployment %s/%s", md.Namespace, md.Name)
+	}
+	return nil
+}
+
+func (q *quickstartRunner) delete(ctx context.Context, c client.Client, clusterObj *unstructured.Unstructured, timeout time.Duration) error {
+	if err := c.Delete(ctx, clusterObj.DeepCopy()); err != nil {
+		return errors.Wrapf(err, "failed to delete Cluster %s/%s", clusterObj.GetNamespace(), clusterObj.GetName())
+	}
+
+	clusterKey := client.ObjectKey{Namespace: clusterObj.GetNamespace(), Name: clusterObj.GetName()}
+	return q.objectWaiter(ctx, clusterObj.GroupVersionKind(), clusterKey, timeout, objectDeleted)
+}
+
+// getControlPlane returns the control plane object of a workload cluster, or nil if the cluster does not
+// have a control plane object.
+func getControlPlane(ctx context.Context, c client.Client, clusterKey client.ObjectKey) (*unstructured.Unstructured, error) {
+	cluster := &clusterv1.Cluster{}
+	if err := c.Get(ctx, clusterKey, cluster); err != nil {
+		return nil, errors.Wrapf(err, "failed to get Cluster %s", clusterKey)
+	}
+
+	ref := cluster.Spec.ControlPlaneRef
+	if ref == nil {
+		return nil, nil
+	}
+
+	controlPlane := &unstructured.Unstructured{}
+	controlPlane.SetAPIVersion(ref.APIVersion)
+	controlPlane.SetKind(ref.Kind)
+	controlPlaneKey := client.ObjectKey{Namespace: ref.Namespace, Name: ref.Name}
+	if controlPlaneKey.Namespace == "" {
+		controlPlaneKey.Namespace = clusterKey.Namespace
+	}
+	if err := c.Get(ctx, controlPlaneKey, controlPlane); err != nil {
+		return nil, errors.Wrapf(err, "failed to get %s %s", ref.Kind, controlPlaneKey)
+	}
+	return controlPlane, nil
+}
+
+// getMachineDeployments returns the MachineDeployments of a workload cluster, sorted by name.
+func getMachineDeployments(ctx context.Context, c client.Client, clusterKey client.ObjectKey) ([]clusterv1.MachineDeployment, error) {
+	mdList := &clusterv1.MachineDeploymentList{}
+	if err := c.List(ctx, mdList, client.InNamespace(clusterKey.Namespace)); err != nil {
+		return nil, errors.Wrapf(err, "failed to list MachineDeployments for Cluster %s", clusterKey)
+	}
+
+	mds := []clusterv1.MachineDeployment{}
+	for _, md := range mdList.Items {
+		if md.Spec.ClusterName == clusterKey.Name {
+			mds = append(mds, md)
+		}
+	}
+	sort.Slice(mds, func(i, j int) bool {
+		return mds[i].Name < mds[j].Name
+	})
+	return mds, nil
+}
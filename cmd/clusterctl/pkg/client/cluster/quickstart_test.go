@@ -0,0 +1,186 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/utils/pointer"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/internal/test"
+	fakecontrolplane "sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/internal/test/providers/controlplane"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func Test_quickstartRunner_Run(t *testing.T) {
+	cluster := &clusterv1.Cluster{
+		TypeMeta:   metav1.TypeMeta{APIVersion: clusterv1.GroupVersion.String(), Kind: "Cluster"},
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: "quickstart"},
+		Spec: clusterv1.ClusterSpec{
+			ControlPlaneRef: &corev1.ObjectReference{APIVersion: fakecontrolplane.GroupVersion.String(), Kind: "DummyControlPlane", Name: "quickstart-control-plane"},
+		},
+	}
+	controlPlane := &fakecontrolplane.DummyControlPlane{
+		TypeMeta:   metav1.TypeMeta{APIVersion: fakecontrolplane.GroupVersion.String(), Kind: "DummyControlPlane"},
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: "quickstart-control-plane"},
+	}
+	md := &clusterv1.MachineDeployment{
+		TypeMeta:   metav1.TypeMeta{APIVersion: clusterv1.GroupVersion.String(), Kind: "MachineDeployment"},
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: "quickstart-md-0"},
+		Spec:       clusterv1.MachineDeploymentSpec{ClusterName: "quickstart", Replicas: pointer.Int32Ptr(1)},
+	}
+
+	tests := []struct {
+		name      string
+		objs      []runtime.Object
+		options   QuickstartOptions
+		wantSteps []string
+		wantFail  string
+		wantErr   bool
+	}{
+		{
+			name: "all the steps pass",
+			objs: []runtime.Object{
+				runningMachine("m1", "v1.17.3"),
+				runningMachine("m2", "v1.17.3"),
+			},
+			options:   QuickstartOptions{},
+			wantSteps: []string{QuickstartStepCreate, QuickstartStepProvision, QuickstartStepDelete},
+		},
+		{
+			name: "the delete step is skipped if cleanup is disabled",
+			objs: []runtime.Object{
+				runningMachine("m1", "v1.17.3"),
+				runningMachine("m2", "v1.17.3"),
+			},
+			options:   QuickstartOptions{SkipCleanup: true},
+			wantSteps: []string{QuickstartStepCreate, QuickstartStepProvision},
+		},
+		{
+			name: "the Cluster is deleted if the provision step fails",
+			objs: []runtime.Object{
+				runningMachine("m1", "v1.17.3"),
+			},
+			options:   QuickstartOptions{},
+			wantSteps: []string{QuickstartStepCreate, QuickstartStepProvision, QuickstartStepDelete},
+			wantFail:  QuickstartStepProvision,
+		},
+		{
+			name: "the upgrade step fails if the Machines do not get the new version",
+			objs: []runtime.Object{
+				runningMachine("m1", "v1.17.3"),
+				runningMachine("m2", "v1.17.3"),
+			},
+			options:   QuickstartOptions{UpgradeToVersion: "v1.18.0"},
+			wantSteps: []string{QuickstartStepCreate, QuickstartStepProvision, QuickstartStepUpgrade, QuickstartStepDelete},
+			wantFail:  QuickstartStepUpgrade,
+		},
+		{
+			name: "the scale step fails if no Machines are added",
+			objs: []runtime.Object{
+				runningMachine("m1", "v1.17.3"),
+				runningMachine("m2", "v1.17.3"),
+			},
+			options:   QuickstartOptions{ScaleWorkersBy: 1},
+			wantSteps: []string{QuickstartStepCreate, QuickstartStepProvision, QuickstartStepScale, QuickstartStepDelete},
+			wantFail:  QuickstartStepScale,
+		},
+		{
+			name: "a Cluster not created by the test is not deleted",
+			objs: []runtime.Object{
+				cluster.DeepCopy(),
+			},
+			options:   QuickstartOptions{},
+			wantSteps: []string{QuickstartStepCreate},
+			wantFail:  QuickstartStepCreate,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			proxy := test.NewFakeProxy().WithObjs(tt.objs...)
+			q := newQuickstartRunner(proxy, func(_ context.Context, _ schema.GroupVersionKind, _ client.ObjectKey, _ time.Duration, _ ObjectConditionFunc) error {
+				return nil
+			})
+			q.pollInterval = 10 * time.Millisecond
+			tt.options.StepTimeout = 50 * time.Millisecond
+
+			steps, err := q.Run(ctx, toUnstructured(t, cluster, controlPlane, md), tt.options)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			if len(steps) != len(tt.wantSteps) {
+				t.Fatalf("got %d steps %v, want %v", len(steps), steps, tt.wantSteps)
+			}
+			for i, s := range steps {
+				if s.Name != tt.wantSteps[i] {
+					t.Errorf("got step %q, want %q", s.Name, tt.wantSteps[i])
+				}
+				if failed := s.Error != nil; failed != (s.Name == tt.wantFail) {
+					t.Errorf("step %q: error = %v, wantFail %q", s.Name, s.Error, tt.wantFail)
+				}
+			}
+		})
+	}
+}
+
+func Test_quickstartRunner_Run_invalidTemplate(t *testing.T) {
+	q := newQuickstartRunner(test.NewFakeProxy(), nil)
+	if _, err := q.Run(ctx, nil, QuickstartOptions{}); err == nil {
+		t.Error("expected an error for a template without a Cluster object")
+	}
+}
+
+func runningMachine(name, version string) *clusterv1.Machine {
+	return &clusterv1.Machine{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "ns1",
+			Name:      name,
+			Labels:    map[string]string{clusterv1.ClusterLabelName: "quickstart"},
+		},
+		Spec: clusterv1.MachineSpec{
+			ClusterName: "quickstart",
+			Version:     &version,
+		},
+		Status: clusterv1.MachineStatus{
+			Phase:   string(clusterv1.MachinePhaseRunning),
+			NodeRef: &corev1.ObjectReference{Kind: "Node", Name: name},
+		},
+	}
+}
+
+func toUnstructured(t *testing.T, objs ...runtime.Object) []unstructured.Unstructured {
+	ret := []unstructured.Unstructured{}
+	for _, o := range objs {
+		u, err := runtime.DefaultUnstructuredConverter.ToUnstructured(o)
+		if err != nil {
+			t.Fatalf("failed to convert %v to unstructured: %v", o, err)
+		}
+		ret = append(ret, unstructured.Unstructured{Object: u})
+	}
+	return ret
+}
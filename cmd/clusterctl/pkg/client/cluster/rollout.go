@@ -0,0 +1,286 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/controllers/mdutil"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// MachineDeploymentKind is the kind of the MachineDeployment objects that can be restarted.
+	MachineDeploymentKind = "MachineDeployment"
+
+	// KubeadmControlPlaneKind is the kind of the KubeadmControlPlane objects that can be restarted.
+	KubeadmControlPlaneKind = "KubeadmControlPlane"
+)
+
+// rolloutTarget defines how to trigger the rollout of an object of a given kind.
+type rolloutTarget struct {
+	apiVersion string
+	// field is the spec field forcing the replacement of the Machines created before the time it is set to.
+	field string
+	// pausedField is the boolean spec field pausing the rollout; if empty, the rollout is paused with the
+	// cluster.x-k8s.io/paused annotation.
+	pausedField string
+}
+
+var rolloutTargets = map[string]rolloutTarget{
+	MachineDeploymentKind:   {apiVersion: clusterv1.GroupVersion.String(), field: "rolloutAfter", pausedField: "paused"},
+	KubeadmControlPlaneKind: {apiVersion: "controlplane.cluster.x-k8s.io/v1alpha3", field: "upgradeAfter"},
+}
+
+// RolloutClient has methods to trigger the rollout of the Machines controlled by MachineDeployments and KubeadmControlPlanes.
+type RolloutClient interface {
+	// Restart triggers a rolling replacement of all the Machines controlled by the object with the given kind,
+	// namespace and name, even if its spec did not change; the Machines are replaced asynchronously by the
+	// controller of the object. It returns the time set on the object.
+	Restart(ctx context.Context, kind, namespace, name string) (time.Time, error)
+
+	// Pause stops the controller of the object with the given kind, namespace and name from rolling out the
+	// changes to its spec, until Resume is called.
+	Pause(ctx context.Context, kind, namespace, name string) error
+
+	// Resume resumes the rollout of the object with the given kind, namespace and name paused by Pause.
+	Resume(ctx context.Context, kind, namespace, name string) error
+
+	// Undo rolls back a MachineDeployment to the Machine template of the MachineSet with the given revision, or
+	// of the previous revision if toRevision is 0. It returns the revision rolled back to.
+	Undo(ctx context.Context, kind, namespace, name string, toRevision int64) (int64, error)
+}
+
+// rolloutClient implements RolloutClient.
+type rolloutClient struct {
+	proxy Proxy
+}
+
+// ensure rolloutClient implements RolloutClient.
+var _ RolloutClient = &rolloutClient{}
+
+func newRolloutClient(proxy Proxy) *rolloutClient {
+	return &rolloutClient{
+		proxy: proxy,
+	}
+}
+
+func (r *rolloutClient) Restart(ctx context.Context, kind, namespace, name string) (time.Time, error) {
+	c, err := r.proxy.NewClient()
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	obj, target, err := getRolloutObject(ctx, c, kind, namespace, name)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	// The time is truncated to seconds, as it is serialized in RFC3339 format.
+	now := time.Now().UTC().Truncate(time.Second)
+	patch := client.MergeFrom(obj.DeepCopy())
+	if err := unstructured.SetNestedField(obj.Object, now.Format(time.RFC3339), "spec", target.field); err != nil {
+		return time.Time{}, errors.Wrapf(err, "failed to set spec.%s on %s %s/%s", target.field, kind, namespace, name)
+	}
+	if err := c.Patch(ctx, obj, patch); err != nil {
+		return time.Time{}, errors.Wrapf(err, "failed to restart %s %s/%s", kind, namespace, name)
+	}
+	return now, nil
+}
+
+func (r *rolloutClient) Pause(ctx context.Context, kind, namespace, name string) error {
+	return r.setPaused(ctx, kind, namespace, name, true)
+}
+
+func (r *rolloutClient) Resume(ctx context.Context, kind, namespace, name string) error {
+	return r.setPaused(ctx, kind, namespace, name, false)
+}
+
+func (r *rolloutClient) setPaused(ctx context.Context, kind, namespace, name string, paused bool) error {
+	c, err := r.proxy.NewClient()
+	if err != nil {
+		return err
+	}
+
+	obj, target, err := getRolloutObject(ctx, c, kind, namespace, name)
+	if err != nil {
+		return err
+	}
+
+	patch := client.MergeFrom(obj.DeepCopy())
+	if target.pausedField != "" {
+		isPaused, _, err := unstructured.NestedBool(obj.Object, "spec", target.pausedField)
+		if err != nil {
+			return errors.Wrapf(err, "failed to read spec.%s from %s %s/%s", target.pausedField, kind, namespace, name)
+		}
+		if isPaused == paused {
+			return pausedStateError(kind, namespace, name, paused)
+		}
+		if err := unstructured.SetNestedField(obj.Object, paused, "spec", target.pausedField); err != nil {
+			return errors.Wrapf(err, "failed to set spec.%s on %s %s/%s", target.pausedField, kind, namespace, name)
+		}
+	} else {
+		annotations := obj.GetAnnotations()
+		if _, isPaused := annotations[clusterv1.PausedAnnotation]; isPaused == paused {
+			return pausedStateError(kind, namespace, name, paused)
+		}
+		if paused {
+			if annotations == nil {
+				annotations = map[string]string{}
+			}
+			annotations[clusterv1.PausedAnnotation] = "true"
+		} else {
+			delete(annotations, clusterv1.PausedAnnotation)
+		}
+		obj.SetAnnotations(annotations)
+	}
+
+	if err := c.Patch(ctx, obj, patch); err != nil {
+		if paused {
+			return errors.Wrapf(err, "failed to pause %s %s/%s", kind, namespace, name)
+		}
+		return errors.Wrapf(err, "failed to resume %s %s/%s", kind, namespace, name)
+	}
+	return nil
+}
+
+func pausedStateError(kind, namespace, name string, paused bool) error {
+	if paused {
+		return errors.Errorf("%s %s/%s is already paused", kind, namespace, name)
+	}
+	return errors.Errorf("%s %s/%s is not paused", kind, namespace, name)
+}
+
+func (r *rolloutClient) Undo(ctx context.Context, kind, namespace, name string, toRevision int64) (int64, error) {
+	// The KubeadmControlPlane does not keep the history of its previous specs.
+	if kind != MachineDeploymentKind {
+		return 0, errors.Errorf("invalid kind %q, only %s objects can be rolled back", kind, MachineDeploymentKind)
+	}
+
+	c, err := r.proxy.NewClient()
+	if err != nil {
+		return 0, err
+	}
+
+	d := &clusterv1.MachineDeployment{}
+	if err := c.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, d); err != nil {
+		return 0, errors.Wrapf(err, "failed to get %s %s/%s", kind, namespace, name)
+	}
+	if !d.DeletionTimestamp.IsZero() {
+		return 0, errors.Errorf("%s %s/%s is being deleted", kind, namespace, name)
+	}
+	if d.Spec.Paused {
+		return 0, errors.Errorf("%s %s/%s is paused, it must be resumed before rolling it back", kind, namespace, name)
+	}
+
+	ms, err := machineSetForRevision(ctx, c, d, toRevision)
+	if err != nil {
+		return 0, err
+	}
+	revision, _ := mdutil.Revision(ms)
+
+	// The MachineSet template carries the hash label added by the MachineDeployment controller, which must not be
+	// copied back to the MachineDeployment template.
+	template := ms.Spec.Template.DeepCopy()
+	delete(template.Labels, mdutil.DefaultMachineDeploymentUniqueLabelKey)
+	if mdutil.EqualMachineTemplate(template, &d.Spec.Template) {
+		return 0, errors.Errorf("%s %s/%s is already using the Machine template of revision %d", kind, namespace, name, revision)
+	}
+
+	patch := client.MergeFrom(d.DeepCopy())
+	d.Spec.Template = *template
+	if err := c.Patch(ctx, d, patch); err != nil {
+		return 0, errors.Wrapf(err, "failed to roll back %s %s/%s to revision %d", kind, namespace, name, revision)
+	}
+	return revision, nil
+}
+
+// machineSetForRevision returns the MachineSet controlled by the MachineDeployment with the given revision, or the one
+// with the highest revision before the current one if toRevision is 0.
+func machineSetForRevision(ctx context.Context, c client.Client, d *clusterv1.MachineDeployment, toRevision int64) (*clusterv1.MachineSet, error) {
+	selector, err := metav1.LabelSelectorAsSelector(&d.Spec.Selector)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to convert the label selector of MachineDeployment %s/%s", d.Namespace, d.Name)
+	}
+	msList := &clusterv1.MachineSetList{}
+	if err := c.List(ctx, msList, client.InNamespace(d.Namespace), client.MatchingLabelsSelector{Selector: selector}); err != nil {
+		return nil, errors.Wrapf(err, "failed to list MachineSets for MachineDeployment %s/%s", d.Namespace, d.Name)
+	}
+
+	// The MachineSet with the highest revision is the current one.
+	revisions := map[int64]*clusterv1.MachineSet{}
+	var currentRevision int64
+	for i := range msList.Items {
+		ms := &msList.Items[i]
+		if !metav1.IsControlledBy(ms, d) {
+			continue
+		}
+		revision, err := mdutil.Revision(ms)
+		if err != nil || revision == 0 {
+			continue
+		}
+		revisions[revision] = ms
+		if revision > currentRevision {
+			currentRevision = revision
+		}
+	}
+
+	if toRevision != 0 {
+		ms, ok := revisions[toRevision]
+		if !ok {
+			return nil, errors.Errorf("revision %d not found for MachineDeployment %s/%s", toRevision, d.Namespace, d.Name)
+		}
+		return ms, nil
+	}
+
+	var previousRevision int64
+	for revision := range revisions {
+		if revision < currentRevision && revision > previousRevision {
+			previousRevision = revision
+		}
+	}
+	if previousRevision == 0 {
+		return nil, errors.Errorf("no previous revision found for MachineDeployment %s/%s", d.Namespace, d.Name)
+	}
+	return revisions[previousRevision], nil
+}
+
+// getRolloutObject gets an object supported by the rollout commands, failing if it is being deleted.
+func getRolloutObject(ctx context.Context, c client.Client, kind, namespace, name string) (*unstructured.Unstructured, rolloutTarget, error) {
+	target, ok := rolloutTargets[kind]
+	if !ok {
+		return nil, rolloutTarget{}, errors.Errorf("invalid kind %q, only %s and %s objects are supported", kind, MachineDeploymentKind, KubeadmControlPlaneKind)
+	}
+
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion(target.apiVersion)
+	obj.SetKind(kind)
+	objKey := client.ObjectKey{Namespace: namespace, Name: name}
+	if err := c.Get(ctx, objKey, obj); err != nil {
+		return nil, rolloutTarget{}, errors.Wrapf(err, "failed to get %s %s/%s", kind, namespace, name)
+	}
+
+	if !obj.GetDeletionTimestamp().IsZero() {
+		return nil, rolloutTarget{}, errors.Errorf("%s %s/%s is being deleted", kind, namespace, name)
+	}
+	return obj, target, nil
+}
@@ -0,0 +1,339 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/pointer"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/internal/test"
+	"sigs.k8s.io/cluster-api/controllers/mdutil"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func Test_rolloutClient_Restart(t *testing.T) {
+	now := metav1.Now()
+
+	tests := []struct {
+		name    string
+		kind    string
+		objs    []runtime.Object
+		wantErr bool
+	}{
+		{
+			name: "restart MachineDeployment",
+			kind: MachineDeploymentKind,
+			objs: []runtime.Object{
+				&clusterv1.MachineDeployment{ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: "md1"}},
+			},
+			wantErr: false,
+		},
+		{
+			name: "restart MachineDeployment with a previous rolloutAfter",
+			kind: MachineDeploymentKind,
+			objs: []runtime.Object{
+				&clusterv1.MachineDeployment{
+					ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: "md1"},
+					Spec:       clusterv1.MachineDeploymentSpec{RolloutAfter: &metav1.Time{Time: now.Add(-time.Hour)}},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name:    "fails if the kind is not supported",
+			kind:    "MachineSet",
+			objs:    []runtime.Object{},
+			wantErr: true,
+		},
+		{
+			name:    "fails if the MachineDeployment does not exist",
+			kind:    MachineDeploymentKind,
+			objs:    []runtime.Object{},
+			wantErr: true,
+		},
+		{
+			name: "fails if the MachineDeployment is being deleted",
+			kind: MachineDeploymentKind,
+			objs: []runtime.Object{
+				&clusterv1.MachineDeployment{ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: "md1", DeletionTimestamp: &now}},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			proxy := test.NewFakeProxy().WithObjs(tt.objs...)
+			r := newRolloutClient(proxy)
+
+			restartedAt, err := r.Restart(ctx, tt.kind, "ns1", "md1")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			c, err := proxy.NewClient()
+			if err != nil {
+				t.Fatalf("error = %v", err)
+			}
+			md := &clusterv1.MachineDeployment{}
+			if err := c.Get(ctx, client.ObjectKey{Namespace: "ns1", Name: "md1"}, md); err != nil {
+				t.Fatalf("error = %v", err)
+			}
+			if md.Spec.RolloutAfter == nil || !md.Spec.RolloutAfter.Time.Equal(restartedAt) {
+				t.Errorf("got rolloutAfter %v, want %v", md.Spec.RolloutAfter, restartedAt)
+			}
+		})
+	}
+}
+
+func Test_rolloutClient_PauseResume(t *testing.T) {
+	tests := []struct {
+		name       string
+		paused     bool
+		pause      bool
+		wantErr    bool
+		wantPaused bool
+	}{
+		{
+			name:       "pause MachineDeployment",
+			paused:     false,
+			pause:      true,
+			wantErr:    false,
+			wantPaused: true,
+		},
+		{
+			name:    "fails to pause a paused MachineDeployment",
+			paused:  true,
+			pause:   true,
+			wantErr: true,
+		},
+		{
+			name:       "resume MachineDeployment",
+			paused:     true,
+			pause:      false,
+			wantErr:    false,
+			wantPaused: false,
+		},
+		{
+			name:    "fails to resume a MachineDeployment not paused",
+			paused:  false,
+			pause:   false,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			proxy := test.NewFakeProxy().WithObjs(&clusterv1.MachineDeployment{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: "md1"},
+				Spec:       clusterv1.MachineDeploymentSpec{Paused: tt.paused},
+			})
+			r := newRolloutClient(proxy)
+
+			var err error
+			if tt.pause {
+				err = r.Pause(ctx, MachineDeploymentKind, "ns1", "md1")
+			} else {
+				err = r.Resume(ctx, MachineDeploymentKind, "ns1", "md1")
+			}
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			c, err := proxy.NewClient()
+			if err != nil {
+				t.Fatalf("error = %v", err)
+			}
+			md := &clusterv1.MachineDeployment{}
+			if err := c.Get(ctx, client.ObjectKey{Namespace: "ns1", Name: "md1"}, md); err != nil {
+				t.Fatalf("error = %v", err)
+			}
+			if md.Spec.Paused != tt.wantPaused {
+				t.Errorf("got paused %v, want %v", md.Spec.Paused, tt.wantPaused)
+			}
+		})
+	}
+}
+
+func Test_rolloutClient_Undo(t *testing.T) {
+	md := &clusterv1.MachineDeployment{
+		TypeMeta: metav1.TypeMeta{APIVersion: clusterv1.GroupVersion.String(), Kind: MachineDeploymentKind},
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:   "ns1",
+			Name:        "md1",
+			UID:         "md1-uid",
+			Annotations: map[string]string{mdutil.RevisionAnnotation: "3"},
+		},
+		Spec: clusterv1.MachineDeploymentSpec{
+			Selector: metav1.LabelSelector{MatchLabels: map[string]string{"md": "md1"}},
+			Template: clusterv1.MachineTemplateSpec{
+				ObjectMeta: clusterv1.ObjectMeta{Labels: map[string]string{"md": "md1"}},
+				Spec:       clusterv1.MachineSpec{Version: pointer.StringPtr("v1.17.3")},
+			},
+		},
+	}
+	machineSet := func(name, revision, version string, owned bool) *clusterv1.MachineSet {
+		ms := &clusterv1.MachineSet{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace:   "ns1",
+				Name:        name,
+				Labels:      map[string]string{"md": "md1"},
+				Annotations: map[string]string{mdutil.RevisionAnnotation: revision},
+			},
+			Spec: clusterv1.MachineSetSpec{
+				Template: clusterv1.MachineTemplateSpec{
+					ObjectMeta: clusterv1.ObjectMeta{Labels: map[string]string{"md": "md1", mdutil.DefaultMachineDeploymentUniqueLabelKey: name}},
+					Spec:       clusterv1.MachineSpec{Version: pointer.StringPtr(version)},
+				},
+			},
+		}
+		if owned {
+			ms.OwnerReferences = []metav1.OwnerReference{*metav1.NewControllerRef(md, md.GroupVersionKind())}
+		}
+		return ms
+	}
+
+	tests := []struct {
+		name         string
+		kind         string
+		toRevision   int64
+		objs         []runtime.Object
+		wantRevision int64
+		wantVersion  string
+		wantErr      bool
+	}{
+		{
+			name:       "roll back to the previous revision",
+			kind:       MachineDeploymentKind,
+			toRevision: 0,
+			objs: []runtime.Object{
+				md.DeepCopy(),
+				machineSet("ms1", "1", "v1.16.7", true),
+				machineSet("ms2", "2", "v1.17.0", true),
+				machineSet("ms3", "3", "v1.17.3", true),
+			},
+			wantRevision: 2,
+			wantVersion:  "v1.17.0",
+			wantErr:      false,
+		},
+		{
+			name:       "roll back to a given revision",
+			kind:       MachineDeploymentKind,
+			toRevision: 1,
+			objs: []runtime.Object{
+				md.DeepCopy(),
+				machineSet("ms1", "1", "v1.16.7", true),
+				machineSet("ms2", "2", "v1.17.0", true),
+				machineSet("ms3", "3", "v1.17.3", true),
+			},
+			wantRevision: 1,
+			wantVersion:  "v1.16.7",
+			wantErr:      false,
+		},
+		{
+			name:       "ignores the MachineSets not controlled by the MachineDeployment",
+			kind:       MachineDeploymentKind,
+			toRevision: 0,
+			objs: []runtime.Object{
+				md.DeepCopy(),
+				machineSet("ms1", "1", "v1.16.7", true),
+				machineSet("ms2", "2", "v1.17.0", false),
+				machineSet("ms3", "3", "v1.17.3", true),
+			},
+			wantRevision: 1,
+			wantVersion:  "v1.16.7",
+			wantErr:      false,
+		},
+		{
+			name:       "fails if there is no previous revision",
+			kind:       MachineDeploymentKind,
+			toRevision: 0,
+			objs: []runtime.Object{
+				md.DeepCopy(),
+				machineSet("ms3", "3", "v1.17.3", true),
+			},
+			wantErr: true,
+		},
+		{
+			name:       "fails if the revision does not exist",
+			kind:       MachineDeploymentKind,
+			toRevision: 5,
+			objs: []runtime.Object{
+				md.DeepCopy(),
+				machineSet("ms3", "3", "v1.17.3", true),
+			},
+			wantErr: true,
+		},
+		{
+			name:       "fails if the revision is the current template",
+			kind:       MachineDeploymentKind,
+			toRevision: 3,
+			objs: []runtime.Object{
+				md.DeepCopy(),
+				machineSet("ms3", "3", "v1.17.3", true),
+			},
+			wantErr: true,
+		},
+		{
+			name:       "fails for KubeadmControlPlanes",
+			kind:       KubeadmControlPlaneKind,
+			toRevision: 0,
+			objs:       []runtime.Object{},
+			wantErr:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			proxy := test.NewFakeProxy().WithObjs(tt.objs...)
+			r := newRolloutClient(proxy)
+
+			revision, err := r.Undo(ctx, tt.kind, "ns1", "md1", tt.toRevision)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if revision != tt.wantRevision {
+				t.Errorf("got revision %d, want %d", revision, tt.wantRevision)
+			}
+
+			c, err := proxy.NewClient()
+			if err != nil {
+				t.Fatalf("error = %v", err)
+			}
+			got := &clusterv1.MachineDeployment{}
+			if err := c.Get(ctx, client.ObjectKey{Namespace: "ns1", Name: "md1"}, got); err != nil {
+				t.Fatalf("error = %v", err)
+			}
+			if got.Spec.Template.Spec.Version == nil || *got.Spec.Template.Spec.Version != tt.wantVersion {
+				t.Errorf("got version %v, want %s", got.Spec.Template.Spec.Version, tt.wantVersion)
+			}
+			if _, ok := got.Spec.Template.Labels[mdutil.DefaultMachineDeploymentUniqueLabelKey]; ok {
+				t.Errorf("got the %s label in the MachineDeployment template", mdutil.DefaultMachineDeploymentUniqueLabelKey)
+			}
+		})
+	}
+}
@@ -0,0 +1,233 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// autoscalerMinSizeAnnotation and autoscalerMaxSizeAnnotation are the annotations used by the cluster autoscaler
+	// for defining the bounds of a MachineDeployment.
+	autoscalerMinSizeAnnotation = "cluster.k8s.io/cluster-api-autoscaler-node-group-min-size"
+	autoscalerMaxSizeAnnotation = "cluster.k8s.io/cluster-api-autoscaler-node-group-max-size"
+
+	// availableMachinesField is the optional field of the InfrastructureCluster status where infrastructure providers
+	// report how many machines can still be created with the current quota.
+	availableMachinesField = "availableMachines"
+)
+
+// FailureDomainScale reports how a scale operation affects a failure domain.
+type FailureDomainScale struct {
+	// Name of the failure domain; it is empty for the machines without a failure domain,
+	// that are placed by the infrastructure provider.
+	Name string
+
+	// Machines is the number of machines of the MachineDeployment currently in the failure domain.
+	Machines int
+
+	// NewMachines is the number of machines that the scale operation is going to create in the failure domain.
+	NewMachines int
+}
+
+// ScaleSimulation is the outcome of a simulated scale operation.
+type ScaleSimulation struct {
+	// Namespace and Name of the MachineDeployment.
+	Namespace string
+	Name      string
+
+	// CurrentReplicas of the MachineDeployment and the Replicas requested by the scale operation.
+	CurrentReplicas int32
+	Replicas        int32
+
+	// FailureDomains lists, for each failure domain, the machines existing and the machines to be created.
+	FailureDomains []FailureDomainScale
+
+	// AvailableMachines is the number of machines that can still be created, as reported by the infrastructure provider;
+	// it is nil if the infrastructure provider does not report it.
+	AvailableMachines *int64
+
+	// Warnings lists the problems the scale operation is going to face, e.g. the quota exceeded.
+	Warnings []string
+}
+
+// ScaleSimulator has methods to simulate scale operations on a workload cluster.
+type ScaleSimulator interface {
+	// SimulateScale reports what would happen by scaling a MachineDeployment to the given number of replicas,
+	// without changing it.
+	SimulateScale(ctx context.Context, namespace, name string, replicas int32) (*ScaleSimulation, error)
+}
+
+// scaleSimulator implements ScaleSimulator.
+type scaleSimulator struct {
+	proxy Proxy
+}
+
+// ensure scaleSimulator implements ScaleSimulator.
+var _ ScaleSimulator = &scaleSimulator{}
+
+func (s *scaleSimulator) SimulateScale(ctx context.Context, namespace, name string, replicas int32) (*ScaleSimulation, error) {
+	if replicas < 0 {
+		return nil, errors.Errorf("invalid number of replicas %d: it must be greater or equal to zero", replicas)
+	}
+
+	c, err := s.proxy.NewClient()
+	if err != nil {
+		return nil, err
+	}
+
+	machineDeployment := &clusterv1.MachineDeployment{}
+	machineDeploymentKey := client.ObjectKey{Namespace: namespace, Name: name}
+	if err := c.Get(ctx, machineDeploymentKey, machineDeployment); err != nil {
+		return nil, errors.Wrapf(err, "failed to get MachineDeployment %s/%s", namespace, name)
+	}
+
+	cluster := &clusterv1.Cluster{}
+	clusterKey := client.ObjectKey{Namespace: namespace, Name: machineDeployment.Spec.ClusterName}
+	if err := c.Get(ctx, clusterKey, cluster); err != nil {
+		return nil, errors.Wrapf(err, "failed to get Cluster %s/%s", namespace, machineDeployment.Spec.ClusterName)
+	}
+
+	machineList := &clusterv1.MachineList{}
+	if err := c.List(ctx, machineList, client.InNamespace(namespace), client.MatchingLabels{clusterv1.MachineDeploymentLabelName: name}); err != nil {
+		return nil, errors.Wrapf(err, "failed to list Machines for MachineDeployment %s/%s", namespace, name)
+	}
+
+	// Gets the InfrastructureCluster, if any, for checking the quota reported by the infrastructure provider.
+	var infrastructureCluster *unstructured.Unstructured
+	if ref := cluster.Spec.InfrastructureRef; ref != nil {
+		infrastructureCluster = &unstructured.Unstructured{}
+		infrastructureCluster.SetAPIVersion(ref.APIVersion)
+		infrastructureCluster.SetKind(ref.Kind)
+		infrastructureClusterKey := client.ObjectKey{Namespace: namespace, Name: ref.Name}
+		if err := c.Get(ctx, infrastructureClusterKey, infrastructureCluster); err != nil {
+			return nil, errors.Wrapf(err, "failed to get %s %s/%s", ref.Kind, namespace, ref.Name)
+		}
+	}
+
+	return simulateScale(machineDeployment, cluster, machineList.Items, infrastructureCluster, replicas)
+}
+
+// simulateScale computes the outcome of scaling a MachineDeployment, given the Cluster it belongs to,
+// its Machines and the InfrastructureCluster (that can be nil).
+func simulateScale(machineDeployment *clusterv1.MachineDeployment, cluster *clusterv1.Cluster, machines []clusterv1.Machine, infrastructureCluster *unstructured.Unstructured, replicas int32) (*ScaleSimulation, error) {
+	currentReplicas := int32(1)
+	if machineDeployment.Spec.Replicas != nil {
+		currentReplicas = *machineDeployment.Spec.Replicas
+	}
+
+	simulation := &ScaleSimulation{
+		Namespace:       machineDeployment.Namespace,
+		Name:            machineDeployment.Name,
+		CurrentReplicas: currentReplicas,
+		Replicas:        replicas,
+	}
+
+	// Counts the machines in each failure domain; all the failure domains defined in the cluster are reported,
+	// even if they don't host any machine of the MachineDeployment.
+	failureDomains := map[string]*FailureDomainScale{}
+	for name := range cluster.Status.FailureDomains {
+		failureDomains[name] = &FailureDomainScale{Name: name}
+	}
+	failureDomainFor := func(name string) *FailureDomainScale {
+		if _, ok := failureDomains[name]; !ok {
+			failureDomains[name] = &FailureDomainScale{Name: name}
+		}
+		return failureDomains[name]
+	}
+	for _, m := range machines {
+		name := ""
+		if m.Spec.FailureDomain != nil {
+			name = *m.Spec.FailureDomain
+		}
+		failureDomainFor(name).Machines++
+	}
+
+	// New machines are created in the failure domain defined in the MachineDeployment template, if any; otherwise
+	// the infrastructure provider is in charge of placing them.
+	newMachines := int(replicas - currentReplicas)
+	if newMachines > 0 {
+		name := ""
+		if machineDeployment.Spec.Template.Spec.FailureDomain != nil {
+			name = *machineDeployment.Spec.Template.Spec.FailureDomain
+			if _, ok := cluster.Status.FailureDomains[name]; !ok {
+				simulation.Warnings = append(simulation.Warnings, fmt.Sprintf("the failure domain %q defined in the MachineDeployment template does not exist in Cluster %s/%s", name, cluster.Namespace, cluster.Name))
+			}
+		}
+		failureDomainFor(name).NewMachines = newMachines
+	}
+
+	for _, f := range failureDomains {
+		simulation.FailureDomains = append(simulation.FailureDomains, *f)
+	}
+	sort.Slice(simulation.FailureDomains, func(i, j int) bool {
+		return simulation.FailureDomains[i].Name < simulation.FailureDomains[j].Name
+	})
+
+	// Checks the quota, if reported by the infrastructure provider.
+	if infrastructureCluster != nil {
+		availableMachines, found, err := unstructured.NestedInt64(infrastructureCluster.Object, "status", availableMachinesField)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read status.%s from %s %s/%s", availableMachinesField, infrastructureCluster.GetKind(), infrastructureCluster.GetNamespace(), infrastructureCluster.GetName())
+		}
+		if found {
+			simulation.AvailableMachines = &availableMachines
+			if int64(newMachines) > availableMachines {
+				simulation.Warnings = append(simulation.Warnings, fmt.Sprintf("the scale operation requires %d new machines, but %s %s/%s reports only %d available machines", newMachines, infrastructureCluster.GetKind(), infrastructureCluster.GetNamespace(), infrastructureCluster.GetName(), availableMachines))
+			}
+		}
+	}
+
+	// Checks the bounds defined for the cluster autoscaler, if any.
+	for _, bound := range []struct {
+		annotation string
+		violated   func(size int32) bool
+		message    string
+	}{
+		{annotation: autoscalerMinSizeAnnotation, violated: func(size int32) bool { return replicas < size }, message: "lower than the cluster autoscaler min size"},
+		{annotation: autoscalerMaxSizeAnnotation, violated: func(size int32) bool { return replicas > size }, message: "greater than the cluster autoscaler max size"},
+	} {
+		value, ok := machineDeployment.Annotations[bound.annotation]
+		if !ok {
+			continue
+		}
+		size, err := strconv.ParseInt(value, 10, 32)
+		if err != nil {
+			simulation.Warnings = append(simulation.Warnings, fmt.Sprintf("invalid value %q for the %s annotation", value, bound.annotation))
+			continue
+		}
+		if bound.violated(int32(size)) {
+			simulation.Warnings = append(simulation.Warnings, fmt.Sprintf("%d replicas is %s (%d); the cluster autoscaler might revert the scale operation", replicas, bound.message, size))
+		}
+	}
+
+	return simulation, nil
+}
+
+func newScaleSimulator(proxy Proxy) *scaleSimulator {
+	return &scaleSimulator{
+		proxy: proxy,
+	}
+}
@@ -0,0 +1,221 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"reflect"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/utils/pointer"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+)
+
+func Test_simulateScale(t *testing.T) {
+	cluster := &clusterv1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: "cluster1"},
+		Status: clusterv1.ClusterStatus{
+			FailureDomains: clusterv1.FailureDomains{
+				"fd1": clusterv1.FailureDomainSpec{},
+				"fd2": clusterv1.FailureDomainSpec{},
+			},
+		},
+	}
+
+	machineDeployment := func(replicas int32, failureDomain *string, annotations map[string]string) *clusterv1.MachineDeployment {
+		md := &clusterv1.MachineDeployment{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: "md1", Annotations: annotations},
+			Spec: clusterv1.MachineDeploymentSpec{
+				ClusterName: "cluster1",
+				Replicas:    pointer.Int32Ptr(replicas),
+			},
+		}
+		md.Spec.Template.Spec.FailureDomain = failureDomain
+		return md
+	}
+
+	machine := func(name string, failureDomain *string) clusterv1.Machine {
+		return clusterv1.Machine{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: name},
+			Spec:       clusterv1.MachineSpec{FailureDomain: failureDomain},
+		}
+	}
+
+	infrastructureCluster := func(availableMachines int64) *unstructured.Unstructured {
+		u := &unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"status": map[string]interface{}{
+					"availableMachines": availableMachines,
+				},
+			},
+		}
+		u.SetKind("InfrastructureCluster")
+		u.SetNamespace("ns1")
+		u.SetName("cluster1")
+		return u
+	}
+
+	type args struct {
+		machineDeployment     *clusterv1.MachineDeployment
+		machines              []clusterv1.Machine
+		infrastructureCluster *unstructured.Unstructured
+		replicas              int32
+	}
+	tests := []struct {
+		name                  string
+		args                  args
+		wantFailureDomains    []FailureDomainScale
+		wantAvailableMachines *int64
+		wantWarnings          int
+	}{
+		{
+			name: "scale up in the failure domain of the template",
+			args: args{
+				machineDeployment: machineDeployment(2, pointer.StringPtr("fd1"), nil),
+				machines:          []clusterv1.Machine{machine("m1", pointer.StringPtr("fd1")), machine("m2", pointer.StringPtr("fd1"))},
+				replicas:          5,
+			},
+			wantFailureDomains: []FailureDomainScale{
+				{Name: "fd1", Machines: 2, NewMachines: 3},
+				{Name: "fd2", Machines: 0, NewMachines: 0},
+			},
+			wantWarnings: 0,
+		},
+		{
+			name: "scale up without a failure domain in the template",
+			args: args{
+				machineDeployment: machineDeployment(1, nil, nil),
+				machines:          []clusterv1.Machine{machine("m1", pointer.StringPtr("fd2"))},
+				replicas:          3,
+			},
+			wantFailureDomains: []FailureDomainScale{
+				{Name: "", Machines: 0, NewMachines: 2},
+				{Name: "fd1", Machines: 0, NewMachines: 0},
+				{Name: "fd2", Machines: 1, NewMachines: 0},
+			},
+			wantWarnings: 0,
+		},
+		{
+			name: "scale up in a failure domain not existing in the cluster",
+			args: args{
+				machineDeployment: machineDeployment(0, pointer.StringPtr("fd3"), nil),
+				replicas:          1,
+			},
+			wantFailureDomains: []FailureDomainScale{
+				{Name: "fd1", Machines: 0, NewMachines: 0},
+				{Name: "fd2", Machines: 0, NewMachines: 0},
+				{Name: "fd3", Machines: 0, NewMachines: 1},
+			},
+			wantWarnings: 1,
+		},
+		{
+			name: "scale down does not create machines",
+			args: args{
+				machineDeployment: machineDeployment(2, pointer.StringPtr("fd1"), nil),
+				machines:          []clusterv1.Machine{machine("m1", pointer.StringPtr("fd1")), machine("m2", pointer.StringPtr("fd1"))},
+				replicas:          1,
+			},
+			wantFailureDomains: []FailureDomainScale{
+				{Name: "fd1", Machines: 2, NewMachines: 0},
+				{Name: "fd2", Machines: 0, NewMachines: 0},
+			},
+			wantWarnings: 0,
+		},
+		{
+			name: "scale up within the quota",
+			args: args{
+				machineDeployment:     machineDeployment(1, pointer.StringPtr("fd1"), nil),
+				infrastructureCluster: infrastructureCluster(2),
+				replicas:              3,
+			},
+			wantFailureDomains: []FailureDomainScale{
+				{Name: "fd1", Machines: 0, NewMachines: 2},
+				{Name: "fd2", Machines: 0, NewMachines: 0},
+			},
+			wantAvailableMachines: pointer.Int64Ptr(2),
+			wantWarnings:          0,
+		},
+		{
+			name: "scale up exceeding the quota",
+			args: args{
+				machineDeployment:     machineDeployment(1, pointer.StringPtr("fd1"), nil),
+				infrastructureCluster: infrastructureCluster(1),
+				replicas:              3,
+			},
+			wantFailureDomains: []FailureDomainScale{
+				{Name: "fd1", Machines: 0, NewMachines: 2},
+				{Name: "fd2", Machines: 0, NewMachines: 0},
+			},
+			wantAvailableMachines: pointer.Int64Ptr(1),
+			wantWarnings:          1,
+		},
+		{
+			name: "scale outside of the cluster autoscaler bounds",
+			args: args{
+				machineDeployment: machineDeployment(3, pointer.StringPtr("fd1"), map[string]string{
+					autoscalerMinSizeAnnotation: "2",
+					autoscalerMaxSizeAnnotation: "5",
+				}),
+				replicas: 1,
+			},
+			wantFailureDomains: []FailureDomainScale{
+				{Name: "fd1", Machines: 0, NewMachines: 0},
+				{Name: "fd2", Machines: 0, NewMachines: 0},
+			},
+			wantWarnings: 1,
+		},
+		{
+			name: "invalid cluster autoscaler bounds",
+			args: args{
+				machineDeployment: machineDeployment(3, pointer.StringPtr("fd1"), map[string]string{
+					autoscalerMaxSizeAnnotation: "many",
+				}),
+				replicas: 3,
+			},
+			wantFailureDomains: []FailureDomainScale{
+				{Name: "fd1", Machines: 0, NewMachines: 0},
+				{Name: "fd2", Machines: 0, NewMachines: 0},
+			},
+			wantWarnings: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := simulateScale(tt.args.machineDeployment, cluster, tt.args.machines, tt.args.infrastructureCluster, tt.args.replicas)
+			if err != nil {
+				t.Fatalf("error = %v, want nil", err)
+			}
+
+			if got.Replicas != tt.args.replicas {
+				t.Errorf("Replicas = %d, want %d", got.Replicas, tt.args.replicas)
+			}
+			if got.CurrentReplicas != *tt.args.machineDeployment.Spec.Replicas {
+				t.Errorf("CurrentReplicas = %d, want %d", got.CurrentReplicas, *tt.args.machineDeployment.Spec.Replicas)
+			}
+			if !reflect.DeepEqual(got.FailureDomains, tt.wantFailureDomains) {
+				t.Errorf("FailureDomains = %v, want %v", got.FailureDomains, tt.wantFailureDomains)
+			}
+			if !reflect.DeepEqual(got.AvailableMachines, tt.wantAvailableMachines) {
+				t.Errorf("AvailableMachines = %v, want %v", got.AvailableMachines, tt.wantAvailableMachines)
+			}
+			if len(got.Warnings) != tt.wantWarnings {
+				t.Errorf("Warnings = %v, want %d warnings", got.Warnings, tt.wantWarnings)
+			}
+		})
+	}
+}
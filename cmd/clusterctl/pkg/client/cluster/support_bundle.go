@@ -0,0 +1,369 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
+	logf "sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/log"
+	"sigs.k8s.io/cluster-api/cmd/version"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// supportBundleFormatVersion is the version of the format used for writing support bundles; it should be changed
+	// every time the format is changed in a non backward compatible way.
+	supportBundleFormatVersion = "v1"
+
+	// supportBundleManifestFile is the name of the file describing the content of a support bundle.
+	supportBundleManifestFile = "manifest.yaml"
+
+	// redactedValue replaces the sensitive values in the objects included in a support bundle.
+	redactedValue = "REDACTED"
+)
+
+// SupportBundleOptions carries the options supported by SupportBundleCollector.
+type SupportBundleOptions struct {
+	// LogsSince, if not zero, limits the provider logs to the ones written after the given duration relative to the current time.
+	LogsSince time.Duration
+}
+
+// SupportBundleManifest describes the content of a support bundle.
+type SupportBundleManifest struct {
+	// FormatVersion is the version of the format used for writing the support bundle.
+	FormatVersion string `json:"formatVersion"`
+
+	// ClusterctlVersion is the version of clusterctl used for writing the support bundle.
+	ClusterctlVersion string `json:"clusterctlVersion"`
+
+	// Timestamp when the support bundle was written.
+	Timestamp metav1.Time `json:"timestamp"`
+
+	// Namespace and Name of the Cluster the support bundle was collected for.
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+
+	// Files contains the name of the files in the support bundle, except the manifest itself.
+	Files []string `json:"files"`
+
+	// Errors contains the errors that prevented to collect part of the information, e.g. the logs of a provider;
+	// the support bundle is written anyway, with the information that could be collected.
+	Errors []string `json:"errors,omitempty"`
+}
+
+// SupportBundleCollector has methods to collect the information required for troubleshooting a workload cluster.
+type SupportBundleCollector interface {
+	// Collect writes to out a gzipped tarball with the sanitized objects belonging to a Cluster, the events related
+	// to those objects, the versions of the cluster and of the providers responsible for it, the logs of the providers
+	// and a manifest describing the content of the tarball.
+	Collect(ctx context.Context, namespace, name string, options SupportBundleOptions, out io.Writer) (*SupportBundleManifest, error)
+}
+
+// supportBundleCollector implements SupportBundleCollector.
+type supportBundleCollector struct {
+	proxy             Proxy
+	providerInventory InventoryClient
+	log               logr.Logger
+}
+
+// ensure supportBundleCollector implements SupportBundleCollector.
+var _ SupportBundleCollector = &supportBundleCollector{}
+
+func newSupportBundleCollector(proxy Proxy, providerInventory InventoryClient) *supportBundleCollector {
+	return &supportBundleCollector{
+		proxy:             proxy,
+		providerInventory: providerInventory,
+		log:               logf.Log,
+	}
+}
+
+func (s *supportBundleCollector) Collect(ctx context.Context, namespace, name string, options SupportBundleOptions, out io.Writer) (*SupportBundleManifest, error) {
+	log := s.log
+
+	// Discovery the object graph for the namespace, so it is possible to identify the objects belonging to the Cluster.
+	objectGraph := newObjectGraph(s.proxy)
+	objectGraph.log = s.log
+	discoveryTypes, err := objectGraph.getDiscoveryTypes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := objectGraph.Discovery(ctx, namespace, discoveryTypes); err != nil {
+		return nil, err
+	}
+
+	var clusterNode *node
+	for _, n := range objectGraph.getAllClusters() {
+		if n.identity.Namespace == namespace && n.identity.Name == name && !n.virtual {
+			clusterNode = n
+			break
+		}
+	}
+	if clusterNode == nil {
+		return nil, errors.Errorf("failed to find Cluster %s/%s", namespace, name)
+	}
+
+	nodes := []*node{clusterNode}
+	for _, n := range objectGraph.getNodesWithClusterTenants() {
+		if _, ok := n.tenantClusters[clusterNode]; ok && n != clusterNode && !n.virtual {
+			nodes = append(nodes, n)
+		}
+	}
+
+	c, err := s.proxy.NewClient()
+	if err != nil {
+		return nil, err
+	}
+
+	manifest := &SupportBundleManifest{
+		FormatVersion:     supportBundleFormatVersion,
+		ClusterctlVersion: version.Get().GitVersion,
+		Timestamp:         metav1.Now(),
+		Namespace:         namespace,
+		Name:              name,
+	}
+	w := newSupportBundleWriter(out, manifest)
+
+	// Writes the sanitized objects belonging to the Cluster.
+	log.Info("Collecting Cluster API objects", "Objects", len(nodes))
+	objs, err := readObjects(ctx, c, nodes)
+	if err != nil {
+		return nil, err
+	}
+	uids := map[types.UID]empty{}
+	files := map[string]*unstructured.Unstructured{}
+	for _, obj := range objs {
+		uids[obj.GetUID()] = empty{}
+		files[path.Join("objects", obj.GetKind(), fmt.Sprintf("%s.yaml", obj.GetName()))] = sanitizeObject(obj)
+	}
+	fileNames := make([]string, 0, len(files))
+	for fileName := range files {
+		fileNames = append(fileNames, fileName)
+	}
+	sort.Strings(fileNames)
+	for _, fileName := range fileNames {
+		if err := w.writeYAML(fileName, files[fileName].Object); err != nil {
+			return nil, err
+		}
+	}
+
+	// Writes the events related to the objects belonging to the Cluster.
+	log.Info("Collecting events")
+	eventList := &corev1.EventList{}
+	if err := c.List(ctx, eventList, client.InNamespace(namespace)); err != nil {
+		manifest.Errors = append(manifest.Errors, errors.Wrap(err, "failed to list Events").Error())
+	}
+	events := []corev1.Event{}
+	for _, e := range eventList.Items {
+		if _, ok := uids[e.InvolvedObject.UID]; ok {
+			events = append(events, e)
+		}
+	}
+	sort.Slice(events, func(i, j int) bool {
+		return events[i].LastTimestamp.Before(&events[j].LastTimestamp)
+	})
+	if err := w.writeYAML("events.yaml", events); err != nil {
+		return nil, err
+	}
+
+	// Writes the versions of the Cluster and of the providers responsible for it.
+	log.Info("Collecting versions")
+	var providers []clusterctlv1.Provider
+	clusterVersions, err := newVersionReporter(s.proxy, s.providerInventory).Report(ctx, namespace)
+	if err != nil {
+		manifest.Errors = append(manifest.Errors, errors.Wrap(err, "failed to report versions").Error())
+	}
+	for _, v := range clusterVersions {
+		if v.Name == name {
+			providers = v.Providers
+			if err := w.writeYAML("versions.yaml", v); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// If it was not possible to identify the providers responsible for the Cluster, collects the logs of all the providers.
+	if providers == nil {
+		providerList, err := s.providerInventory.List(ctx)
+		if err != nil {
+			manifest.Errors = append(manifest.Errors, errors.Wrap(err, "failed to get the list of providers").Error())
+		} else {
+			providers = providerList.Items
+		}
+	}
+
+	// Writes the logs of the providers.
+	logsClient := newLogsClient(s.proxy)
+	for _, p := range providers {
+		log.Info("Collecting logs", "Provider", p.Name, "Namespace", p.Namespace)
+		lines, err := logsClient.Read(ctx, p, LogsOptions{Since: options.LogsSince})
+		if err != nil {
+			manifest.Errors = append(manifest.Errors, errors.Wrapf(err, "failed to read the logs of provider %s/%s", p.Namespace, p.Name).Error())
+			continue
+		}
+		buf := &bytes.Buffer{}
+		for _, l := range lines {
+			fmt.Fprintln(buf, l.String())
+		}
+		if err := w.writeFile(path.Join("logs", p.Namespace, fmt.Sprintf("%s.log", p.Name)), buf.Bytes()); err != nil {
+			return nil, err
+		}
+	}
+
+	// Writes the manifest as a last file, so it lists all the other files.
+	if err := w.close(); err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+// supportBundleWriter writes files to a gzipped tarball, recording their names into a SupportBundleManifest.
+type supportBundleWriter struct {
+	gz       *gzip.Writer
+	tw       *tar.Writer
+	manifest *SupportBundleManifest
+}
+
+func newSupportBundleWriter(out io.Writer, manifest *SupportBundleManifest) *supportBundleWriter {
+	gz := gzip.NewWriter(out)
+	return &supportBundleWriter{
+		gz:       gz,
+		tw:       tar.NewWriter(gz),
+		manifest: manifest,
+	}
+}
+
+func (w *supportBundleWriter) writeYAML(name string, obj interface{}) error {
+	data, err := yaml.Marshal(obj)
+	if err != nil {
+		return errors.Wrapf(err, "failed to marshal %s", name)
+	}
+	return w.writeFile(name, data)
+}
+
+func (w *supportBundleWriter) writeFile(name string, data []byte) error {
+	if err := w.writeEntry(name, data); err != nil {
+		return err
+	}
+	w.manifest.Files = append(w.manifest.Files, name)
+	return nil
+}
+
+func (w *supportBundleWriter) writeEntry(name string, data []byte) error {
+	header := &tar.Header{
+		Name:    name,
+		Mode:    0644,
+		Size:    int64(len(data)),
+		ModTime: w.manifest.Timestamp.Time,
+	}
+	if err := w.tw.WriteHeader(header); err != nil {
+		return errors.Wrapf(err, "failed to write %s to the support bundle", name)
+	}
+	if _, err := w.tw.Write(data); err != nil {
+		return errors.Wrapf(err, "failed to write %s to the support bundle", name)
+	}
+	return nil
+}
+
+// close writes the manifest and flushes the tarball.
+func (w *supportBundleWriter) close() error {
+	data, err := yaml.Marshal(w.manifest)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal the support bundle manifest")
+	}
+	if err := w.writeEntry(supportBundleManifestFile, data); err != nil {
+		return err
+	}
+	if err := w.tw.Close(); err != nil {
+		return errors.Wrap(err, "failed to close the support bundle")
+	}
+	if err := w.gz.Close(); err != nil {
+		return errors.Wrap(err, "failed to close the support bundle")
+	}
+	return nil
+}
+
+// sanitizeObject returns a copy of an object without the values that could leak credentials or that are not
+// relevant for troubleshooting, that are the data of Secrets, the content of the files passed to the bootstrap
+// providers, password and token fields, the last applied configuration and the managed fields.
+func sanitizeObject(obj *unstructured.Unstructured) *unstructured.Unstructured {
+	sanitized := obj.DeepCopy()
+
+	annotations := sanitized.GetAnnotations()
+	if _, ok := annotations[corev1.LastAppliedConfigAnnotation]; ok {
+		delete(annotations, corev1.LastAppliedConfigAnnotation)
+		sanitized.SetAnnotations(annotations)
+	}
+	unstructured.RemoveNestedField(sanitized.Object, "metadata", "managedFields")
+
+	if sanitized.GetAPIVersion() == "v1" && sanitized.GetKind() == "Secret" {
+		for _, field := range []string{"data", "stringData"} {
+			data, ok := sanitized.Object[field].(map[string]interface{})
+			if !ok {
+				continue
+			}
+			for k := range data {
+				data[k] = redactedValue
+			}
+		}
+		return sanitized
+	}
+
+	sanitizeFields(sanitized.Object)
+	return sanitized
+}
+
+// sanitizeFields redacts, recursively, the password and token fields and the content of the entries in files lists.
+func sanitizeFields(obj map[string]interface{}) {
+	for k, v := range obj {
+		switch value := v.(type) {
+		case string:
+			switch k {
+			case "passwd", "password", "token":
+				obj[k] = redactedValue
+			}
+		case map[string]interface{}:
+			sanitizeFields(value)
+		case []interface{}:
+			for _, item := range value {
+				m, ok := item.(map[string]interface{})
+				if !ok {
+					continue
+				}
+				if _, ok := m["content"].(string); ok && k == "files" {
+					m["content"] = redactedValue
+				}
+				sanitizeFields(m)
+			}
+		}
+	}
+}
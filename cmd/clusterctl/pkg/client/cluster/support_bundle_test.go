@@ -0,0 +1,249 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"reflect"
+	"sort"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/internal/test"
+	"sigs.k8s.io/yaml"
+)
+
+func Test_sanitizeObject(t *testing.T) {
+	tests := []struct {
+		name string
+		obj  map[string]interface{}
+		want map[string]interface{}
+	}{
+		{
+			name: "Secret data is redacted",
+			obj: map[string]interface{}{
+				"apiVersion": "v1",
+				"kind":       "Secret",
+				"metadata":   map[string]interface{}{"name": "foo-kubeconfig"},
+				"data":       map[string]interface{}{"value": "c2VjcmV0"},
+				"stringData": map[string]interface{}{"other": "secret"},
+			},
+			want: map[string]interface{}{
+				"apiVersion": "v1",
+				"kind":       "Secret",
+				"metadata":   map[string]interface{}{"name": "foo-kubeconfig"},
+				"data":       map[string]interface{}{"value": redactedValue},
+				"stringData": map[string]interface{}{"other": redactedValue},
+			},
+		},
+		{
+			name: "Bootstrap files content, passwords and tokens are redacted",
+			obj: map[string]interface{}{
+				"apiVersion": "bootstrap.cluster.x-k8s.io/v1alpha3",
+				"kind":       "KubeadmConfig",
+				"metadata":   map[string]interface{}{"name": "foo"},
+				"spec": map[string]interface{}{
+					"files": []interface{}{
+						map[string]interface{}{"path": "/etc/foo", "content": "secret"},
+					},
+					"users": []interface{}{
+						map[string]interface{}{"name": "admin", "passwd": "secret"},
+					},
+					"joinConfiguration": map[string]interface{}{
+						"discovery": map[string]interface{}{
+							"bootstrapToken": map[string]interface{}{"token": "secret", "apiServerEndpoint": "1.2.3.4"},
+						},
+					},
+				},
+			},
+			want: map[string]interface{}{
+				"apiVersion": "bootstrap.cluster.x-k8s.io/v1alpha3",
+				"kind":       "KubeadmConfig",
+				"metadata":   map[string]interface{}{"name": "foo"},
+				"spec": map[string]interface{}{
+					"files": []interface{}{
+						map[string]interface{}{"path": "/etc/foo", "content": redactedValue},
+					},
+					"users": []interface{}{
+						map[string]interface{}{"name": "admin", "passwd": redactedValue},
+					},
+					"joinConfiguration": map[string]interface{}{
+						"discovery": map[string]interface{}{
+							"bootstrapToken": map[string]interface{}{"token": redactedValue, "apiServerEndpoint": "1.2.3.4"},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "Last applied configuration and managed fields are removed",
+			obj: map[string]interface{}{
+				"apiVersion": "cluster.x-k8s.io/v1alpha3",
+				"kind":       "Cluster",
+				"metadata": map[string]interface{}{
+					"name": "foo",
+					"annotations": map[string]interface{}{
+						corev1.LastAppliedConfigAnnotation: "{}",
+						"foo":                              "bar",
+					},
+					"managedFields": []interface{}{
+						map[string]interface{}{"manager": "kubectl"},
+					},
+				},
+			},
+			want: map[string]interface{}{
+				"apiVersion": "cluster.x-k8s.io/v1alpha3",
+				"kind":       "Cluster",
+				"metadata": map[string]interface{}{
+					"name": "foo",
+					"annotations": map[string]interface{}{
+						"foo": "bar",
+					},
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			obj := &unstructured.Unstructured{Object: tt.obj}
+			original := obj.DeepCopy()
+
+			got := sanitizeObject(obj)
+			if !reflect.DeepEqual(got.Object, tt.want) {
+				t.Errorf("got = %v, want %v", got.Object, tt.want)
+			}
+			if !reflect.DeepEqual(obj, original) {
+				t.Errorf("sanitizeObject changed the original object")
+			}
+		})
+	}
+}
+
+func Test_supportBundleCollector_Collect(t *testing.T) {
+	fooObjs := test.NewFakeCluster("ns1", "foo").Objs()
+	barObjs := test.NewFakeCluster("ns1", "bar").Objs()
+
+	fooUID, barUID := fooObjs[0].(*clusterv1.Cluster).UID, barObjs[0].(*clusterv1.Cluster).UID
+	for _, o := range fooObjs {
+		if s, ok := o.(*corev1.Secret); ok && s.Name == "foo-kubeconfig" {
+			s.Data = map[string][]byte{"value": []byte("secret")}
+		}
+	}
+
+	objs := []runtime.Object{
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "ns1"}},
+		&corev1.Event{
+			ObjectMeta:     metav1.ObjectMeta{Namespace: "ns1", Name: "foo-event"},
+			InvolvedObject: corev1.ObjectReference{Kind: "Cluster", Namespace: "ns1", Name: "foo", UID: fooUID},
+			Message:        "foo event",
+		},
+		&corev1.Event{
+			ObjectMeta:     metav1.ObjectMeta{Namespace: "ns1", Name: "bar-event"},
+			InvolvedObject: corev1.ObjectReference{Kind: "Cluster", Namespace: "ns1", Name: "bar", UID: barUID},
+			Message:        "bar event",
+		},
+	}
+	objs = append(objs, fooObjs...)
+	objs = append(objs, barObjs...)
+
+	proxy := getFakeProxyWithCRDs()
+	for _, o := range objs {
+		proxy.WithObjs(o)
+	}
+	s := newSupportBundleCollector(proxy, newInventoryClient(proxy, fakeObjectWaiter))
+
+	out := &bytes.Buffer{}
+	manifest, err := s.Collect(ctx, "ns1", "foo", SupportBundleOptions{}, out)
+	if err != nil {
+		t.Fatalf("error = %v, want nil", err)
+	}
+
+	files := readSupportBundle(t, out)
+
+	wantFiles := []string{
+		"events.yaml",
+		"objects/Cluster/foo.yaml",
+		"objects/DummyInfrastructureCluster/foo.yaml",
+		"objects/Secret/foo-ca.yaml",
+		"objects/Secret/foo-kubeconfig.yaml",
+		"versions.yaml",
+	}
+	gotFiles := append([]string{}, manifest.Files...)
+	sort.Strings(gotFiles)
+	if !reflect.DeepEqual(gotFiles, wantFiles) {
+		t.Errorf("manifest.Files = %v, want %v", gotFiles, wantFiles)
+	}
+	for _, f := range append(wantFiles, supportBundleManifestFile) {
+		if _, ok := files[f]; !ok {
+			t.Errorf("%s is missing from the support bundle", f)
+		}
+	}
+
+	secret := &unstructured.Unstructured{}
+	if err := yaml.Unmarshal(files["objects/Secret/foo-kubeconfig.yaml"], &secret.Object); err != nil {
+		t.Fatal(err)
+	}
+	if value, _, _ := unstructured.NestedString(secret.Object, "data", "value"); value != redactedValue {
+		t.Errorf("Secret data = %q, want %q", value, redactedValue)
+	}
+
+	events := []corev1.Event{}
+	if err := yaml.Unmarshal(files["events.yaml"], &events); err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 1 || events[0].Name != "foo-event" {
+		t.Errorf("events = %v, want only foo-event", events)
+	}
+
+	if _, err := s.Collect(ctx, "ns1", "baz", SupportBundleOptions{}, ioutil.Discard); err == nil {
+		t.Errorf("error = nil, want an error for a Cluster that does not exist")
+	}
+}
+
+// readSupportBundle returns the content of the files in a support bundle, indexed by name.
+func readSupportBundle(t *testing.T, in io.Reader) map[string][]byte {
+	gz, err := gzip.NewReader(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr := tar.NewReader(gz)
+
+	files := map[string][]byte{}
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := ioutil.ReadAll(tr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[header.Name] = data
+	}
+	return files
+}
@@ -29,18 +29,27 @@ import (
 	"github.com/pkg/errors"
 	"golang.org/x/oauth2"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/client/config"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/client/repository"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
+// TemplateVariablesConfigMapName is the name of the ConfigMap storing the default values for the template variables
+// to be used for the workload clusters in a namespace.
+const TemplateVariablesConfigMapName = "clusterctl-variables"
+
 // TemplateClient has methods to work with templates stored in the cluster/out of the provider repository.
 type TemplateClient interface {
 	// GetFromConfigMap returns a workload cluster template from the given ConfigMap.
-	GetFromConfigMap(namespace, name, dataKey, targetNamespace string, listVariablesOnly bool) (repository.Template, error)
+	GetFromConfigMap(ctx context.Context, namespace, name, dataKey, targetNamespace string, listVariablesOnly bool) (repository.Template, error)
 
 	// GetFromURL returns a workload cluster template from the given URL.
 	GetFromURL(templateURL, targetNamespace string, listVariablesOnly bool) (repository.Template, error)
+
+	// GetNamespaceVariables returns the default values for the template variables stored in the
+	// TemplateVariablesConfigMapName ConfigMap of the given namespace; if the ConfigMap does not exist, no values are returned.
+	GetNamespaceVariables(ctx context.Context, namespace string) (map[string]string, error)
 }
 
 // templateClient implements TemplateClient.
@@ -62,7 +71,7 @@ func newTemplateClient(proxy Proxy, configClient config.Client) *templateClient
 	}
 }
 
-func (t *templateClient) GetFromConfigMap(configMapNamespace, configMapName, configMapDataKey, targetNamespace string, listVariablesOnly bool) (repository.Template, error) {
+func (t *templateClient) GetFromConfigMap(ctx context.Context, configMapNamespace, configMapName, configMapDataKey, targetNamespace string, listVariablesOnly bool) (repository.Template, error) {
 	if configMapNamespace == "" {
 		return nil, errors.New("invalid GetFromConfigMap operation: missing configMapNamespace value")
 	}
@@ -106,6 +115,28 @@ func (t *templateClient) GetFromURL(templateURL, targetNamespace string, listVar
 	return repository.NewTemplate(content, t.configClient.Variables(), targetNamespace, listVariablesOnly)
 }
 
+func (t *templateClient) GetNamespaceVariables(ctx context.Context, namespace string) (map[string]string, error) {
+	c, err := t.proxy.NewClient()
+	if err != nil {
+		return nil, err
+	}
+
+	configMap := &corev1.ConfigMap{}
+	key := client.ObjectKey{
+		Namespace: namespace,
+		Name:      TemplateVariablesConfigMapName,
+	}
+
+	if err := c.Get(ctx, key, configMap); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, errors.Wrapf(err, "error reading ConfigMap %s/%s", namespace, TemplateVariablesConfigMapName)
+	}
+
+	return configMap.Data, nil
+}
+
 func (t *templateClient) getURLContent(templateURL string) ([]byte, error) {
 	rURL, err := url.Parse(templateURL)
 	if err != nil {
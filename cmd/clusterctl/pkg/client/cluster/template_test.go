@@ -134,7 +134,7 @@ func Test_templateClient_GetFromConfigMap(t *testing.T) {
 				proxy:        tt.fields.proxy,
 				configClient: tt.fields.configClient,
 			}
-			got, err := tc.GetFromConfigMap(tt.args.configMapNamespace, tt.args.configMapName, tt.args.configMapDataKey, tt.args.targetNamespace, tt.args.listVariablesOnly)
+			got, err := tc.GetFromConfigMap(ctx, tt.args.configMapNamespace, tt.args.configMapName, tt.args.configMapDataKey, tt.args.targetNamespace, tt.args.listVariablesOnly)
 			if (err != nil) != tt.wantErr {
 				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
 			}
@@ -153,6 +153,62 @@ func Test_templateClient_GetFromConfigMap(t *testing.T) {
 	}
 }
 
+func Test_templateClient_GetNamespaceVariables(t *testing.T) {
+	configMap := &corev1.ConfigMap{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "ConfigMap",
+			APIVersion: "v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "ns1",
+			Name:      TemplateVariablesConfigMapName,
+		},
+		Data: map[string]string{
+			"POD_CIDR": "10.0.0.0/16",
+		},
+	}
+
+	tests := []struct {
+		name      string
+		namespace string
+		want      map[string]string
+		wantErr   bool
+	}{
+		{
+			name:      "Return variables",
+			namespace: "ns1",
+			want: map[string]string{
+				"POD_CIDR": "10.0.0.0/16",
+			},
+			wantErr: false,
+		},
+		{
+			name:      "Return no variables if the ConfigMap does not exist",
+			namespace: "ns2",
+			want:      nil,
+			wantErr:   false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tc := &templateClient{
+				proxy: test.NewFakeProxy().WithObjs(configMap),
+			}
+			got, err := tc.GetNamespaceVariables(ctx, tt.namespace)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 func Test_templateClient_getGitHubFileContent(t *testing.T) {
 	client, mux, teardown := test.NewFakeGitHub()
 	defer teardown()
@@ -0,0 +1,474 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"context"
+	"reflect"
+	"sort"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/internal/scheme"
+	"sigs.k8s.io/cluster-api/controllers"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// TopologyPlanInput defines the input for TopologyClient.Plan.
+type TopologyPlanInput struct {
+	// Objs are the proposed Clusters and ClusterClasses, and the templates they reference, if not existing yet
+	// in the management cluster; the objects replace the ones with the same kind, namespace and name existing
+	// in the management cluster.
+	Objs []unstructured.Unstructured
+
+	// Namespace used for the objects without a namespace.
+	Namespace string
+}
+
+// TopologyPlanOutput reports the changes to the managed topologies of the Clusters affected by a proposed change.
+type TopologyPlanOutput struct {
+	// Clusters lists, for each Cluster affected by the change, the changes to its managed topology.
+	Clusters []TopologyClusterPlan
+}
+
+// TopologyClusterPlan reports the changes to the managed topology of a Cluster.
+type TopologyClusterPlan struct {
+	// Namespace and Name of the Cluster.
+	Namespace string
+	Name      string
+
+	// Created lists the objects the topology controller is going to create.
+	Created []unstructured.Unstructured
+
+	// Updated lists the objects the topology controller is going to update.
+	Updated []TopologyObjectUpdate
+
+	// Deleted lists the objects the topology controller is going to delete.
+	Deleted []unstructured.Unstructured
+}
+
+// TopologyObjectUpdate reports an object before and after being updated by the topology controller.
+type TopologyObjectUpdate struct {
+	Before unstructured.Unstructured
+	After  unstructured.Unstructured
+}
+
+// TopologyClient has methods to work with the managed topologies of the Clusters.
+type TopologyClient interface {
+	// Plan computes the objects the topology controller is going to create, update and delete for the
+	// Clusters affected by a proposed change to Clusters or ClusterClasses, without changing the management cluster.
+	Plan(ctx context.Context, in *TopologyPlanInput) (*TopologyPlanOutput, error)
+}
+
+// topologyClient implements TopologyClient.
+type topologyClient struct {
+	proxy Proxy
+}
+
+// ensure topologyClient implements TopologyClient.
+var _ TopologyClient = &topologyClient{}
+
+// newTopologyClient returns a topologyClient.
+func newTopologyClient(proxy Proxy) *topologyClient {
+	return &topologyClient{
+		proxy: proxy,
+	}
+}
+
+func (t *topologyClient) Plan(ctx context.Context, in *TopologyPlanInput) (*TopologyPlanOutput, error) {
+	c, err := t.proxy.NewClient()
+	if err != nil {
+		return nil, err
+	}
+
+	// Index the proposed objects, setting the default namespace on the ones without a namespace.
+	proposed := newTopologyObjects()
+	for i := range in.Objs {
+		obj := in.Objs[i].DeepCopy()
+		if obj.GetNamespace() == "" {
+			obj.SetNamespace(in.Namespace)
+		}
+		proposed.add(obj)
+	}
+
+	clusters, err := t.affectedClusters(ctx, c, proposed)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := &TopologyPlanOutput{}
+	for _, cluster := range clusters {
+		plan, err := t.planCluster(ctx, c, proposed, cluster)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to plan the topology of Cluster %s/%s", cluster.Namespace, cluster.Name)
+		}
+		ret.Clusters = append(ret.Clusters, *plan)
+	}
+	return ret, nil
+}
+
+// affectedClusters returns the Clusters with a managed topology affected by the proposed objects, that are
+// the proposed Clusters and the Clusters using the proposed ClusterClasses.
+func (t *topologyClient) affectedClusters(ctx context.Context, c client.Client, proposed *topologyObjects) ([]*clusterv1.Cluster, error) {
+	clusterGVK := clusterv1.GroupVersion.WithKind("Cluster")
+	classGVK := clusterv1.GroupVersion.WithKind("ClusterClass")
+
+	clusters := map[client.ObjectKey]*clusterv1.Cluster{}
+	for _, obj := range proposed.list(clusterGVK) {
+		cluster := &clusterv1.Cluster{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, cluster); err != nil {
+			return nil, errors.Wrapf(err, "failed to convert Cluster %s/%s", obj.GetNamespace(), obj.GetName())
+		}
+		if cluster.Spec.Topology == nil {
+			continue
+		}
+		clusters[client.ObjectKey{Namespace: cluster.Namespace, Name: cluster.Name}] = cluster
+	}
+
+	for _, class := range proposed.list(classGVK) {
+		clusterList := &clusterv1.ClusterList{}
+		if err := c.List(ctx, clusterList, client.InNamespace(class.GetNamespace())); err != nil {
+			return nil, errors.Wrapf(err, "failed to list Clusters in namespace %q", class.GetNamespace())
+		}
+		for i := range clusterList.Items {
+			cluster := &clusterList.Items[i]
+			key := client.ObjectKey{Namespace: cluster.Namespace, Name: cluster.Name}
+			if _, ok := clusters[key]; ok {
+				continue
+			}
+			if cluster.Spec.Topology != nil && cluster.Spec.Topology.Class == class.GetName() {
+				clusters[key] = cluster
+			}
+		}
+	}
+
+	ret := make([]*clusterv1.Cluster, 0, len(clusters))
+	for _, cluster := range clusters {
+		ret = append(ret, cluster)
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		if ret[i].Namespace != ret[j].Namespace {
+			return ret[i].Namespace < ret[j].Namespace
+		}
+		return ret[i].Name < ret[j].Name
+	})
+	return ret, nil
+}
+
+// planCluster computes the changes to the managed topology of a Cluster, by running the topology controller against
+// an in-memory copy of the objects of the topology.
+func (t *topologyClient) planCluster(ctx context.Context, c client.Client, proposed *topologyObjects, cluster *clusterv1.Cluster) (*TopologyClusterPlan, error) {
+	cluster = cluster.DeepCopy()
+
+	// The references to the objects of the topology are set by the topology controller, so they are usually not
+	// included in the proposed Cluster; in this case, the ones of the existing Cluster are used.
+	current := &clusterv1.Cluster{}
+	if err := c.Get(ctx, client.ObjectKey{Namespace: cluster.Namespace, Name: cluster.Name}, current); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return nil, err
+		}
+		current = nil
+	}
+	if current != nil {
+		cluster.UID = current.UID
+		if cluster.Spec.InfrastructureRef == nil {
+			cluster.Spec.InfrastructureRef = current.Spec.InfrastructureRef
+		}
+		if cluster.Spec.ControlPlaneRef == nil {
+			cluster.Spec.ControlPlaneRef = current.Spec.ControlPlaneRef
+		}
+	}
+
+	objs, err := t.topologyObjects(ctx, c, proposed, cluster)
+	if err != nil {
+		return nil, err
+	}
+
+	// Run the topology controller against a fake client initialized with the objects of the topology,
+	// tracking the objects changed.
+	initObjs := []runtime.Object{}
+	for _, obj := range objs.items {
+		typed, err := toTyped(obj)
+		if err != nil {
+			return nil, err
+		}
+		initObjs = append(initObjs, typed)
+	}
+	planClient := &topologyPlanClient{
+		Client:  fake.NewFakeClientWithScheme(scheme.Scheme, initObjs...),
+		changed: newTopologyObjects(),
+	}
+
+	// Read back the objects of the topology, so they can be compared with the objects changed by the topology
+	// controller regardless of how they are serialized.
+	snapshot := newTopologyObjects()
+	for _, obj := range objs.items {
+		u := &unstructured.Unstructured{}
+		u.SetGroupVersionKind(obj.GroupVersionKind())
+		if err := planClient.Client.Get(ctx, client.ObjectKey{Namespace: obj.GetNamespace(), Name: obj.GetName()}, u); err != nil {
+			return nil, err
+		}
+		snapshot.add(u)
+	}
+
+	if err := controllers.ReconcileTopology(ctx, planClient, cluster); err != nil {
+		return nil, err
+	}
+
+	ret := &TopologyClusterPlan{
+		Namespace: cluster.Namespace,
+		Name:      cluster.Name,
+	}
+	for _, changed := range planClient.changed.items {
+		after := &unstructured.Unstructured{}
+		after.SetGroupVersionKind(changed.GroupVersionKind())
+		if err := planClient.Client.Get(ctx, client.ObjectKey{Namespace: changed.GetNamespace(), Name: changed.GetName()}, after); err != nil {
+			if !apierrors.IsNotFound(err) {
+				return nil, err
+			}
+			after = nil
+		}
+		before := snapshot.get(changed.GroupVersionKind(), changed.GetNamespace(), changed.GetName())
+
+		switch {
+		case before == nil && after != nil:
+			ret.Created = append(ret.Created, *after)
+		case before != nil && after == nil:
+			ret.Deleted = append(ret.Deleted, *before)
+		case before != nil && after != nil:
+			// The resource version is changed by the fake client on every write.
+			after.SetResourceVersion(before.GetResourceVersion())
+			if !reflect.DeepEqual(before.Object, after.Object) {
+				ret.Updated = append(ret.Updated, TopologyObjectUpdate{Before: *before, After: *after})
+			}
+		}
+	}
+	return ret, nil
+}
+
+// topologyObjects returns the objects read by the topology controller for reconciling the managed topology of
+// a Cluster, that are the ClusterClass, its templates and the objects of the current topology; the proposed
+// objects take precedence over the ones existing in the management cluster.
+func (t *topologyClient) topologyObjects(ctx context.Context, c client.Client, proposed *topologyObjects, cluster *clusterv1.Cluster) (*topologyObjects, error) {
+	objs := newTopologyObjects()
+	get := func(ref *corev1.ObjectReference) (*unstructured.Unstructured, error) {
+		if ref == nil || ref.Name == "" {
+			return nil, nil
+		}
+		namespace := ref.Namespace
+		if namespace == "" {
+			namespace = cluster.Namespace
+		}
+		gvk := schema.FromAPIVersionAndKind(ref.APIVersion, ref.Kind)
+		if obj := objs.get(gvk, namespace, ref.Name); obj != nil {
+			return obj, nil
+		}
+		obj := proposed.get(gvk, namespace, ref.Name)
+		if obj == nil {
+			obj = &unstructured.Unstructured{}
+			obj.SetGroupVersionKind(gvk)
+			if err := c.Get(ctx, client.ObjectKey{Namespace: namespace, Name: ref.Name}, obj); err != nil {
+				if apierrors.IsNotFound(err) {
+					// Missing objects are reported by the topology controller.
+					return nil, nil
+				}
+				return nil, errors.Wrapf(err, "failed to get %s %s/%s", ref.Kind, namespace, ref.Name)
+			}
+		}
+		objs.add(obj)
+		return obj, nil
+	}
+
+	// The ClusterClass and its templates.
+	classObj, err := get(&corev1.ObjectReference{
+		APIVersion: clusterv1.GroupVersion.String(),
+		Kind:       "ClusterClass",
+		Name:       cluster.Spec.Topology.Class,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if classObj != nil {
+		class := &clusterv1.ClusterClass{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(classObj.Object, class); err != nil {
+			return nil, errors.Wrapf(err, "failed to convert ClusterClass %s/%s", classObj.GetNamespace(), classObj.GetName())
+		}
+		refs := []*corev1.ObjectReference{class.Spec.Infrastructure.Ref, class.Spec.ControlPlane.Ref}
+		if class.Spec.ControlPlane.MachineInfrastructure != nil {
+			refs = append(refs, class.Spec.ControlPlane.MachineInfrastructure.Ref)
+		}
+		for _, md := range class.Spec.Workers.MachineDeployments {
+			refs = append(refs, md.Template.Bootstrap.Ref, md.Template.Infrastructure.Ref)
+		}
+		for _, ref := range refs {
+			if _, err := get(ref); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// The objects of the current topology.
+	if _, err := get(cluster.Spec.InfrastructureRef); err != nil {
+		return nil, err
+	}
+	controlPlane, err := get(cluster.Spec.ControlPlaneRef)
+	if err != nil {
+		return nil, err
+	}
+	if controlPlane != nil {
+		ref := &corev1.ObjectReference{}
+		infrastructureTemplate, ok, _ := unstructured.NestedMap(controlPlane.Object, "spec", "infrastructureTemplate")
+		if ok {
+			if err := runtime.DefaultUnstructuredConverter.FromUnstructured(infrastructureTemplate, ref); err != nil {
+				return nil, errors.Wrapf(err, "failed to get the infrastructure template of %s %s/%s", controlPlane.GetKind(), controlPlane.GetNamespace(), controlPlane.GetName())
+			}
+			if _, err := get(ref); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	mdList := &clusterv1.MachineDeploymentList{}
+	if err := c.List(ctx, mdList,
+		client.InNamespace(cluster.Namespace),
+		client.MatchingLabels{clusterv1.ClusterLabelName: cluster.Name},
+		client.HasLabels{clusterv1.ClusterTopologyOwnedLabel},
+	); err != nil {
+		return nil, errors.Wrapf(err, "failed to list MachineDeployments for Cluster %q", cluster.Name)
+	}
+	for i := range mdList.Items {
+		md := &mdList.Items[i]
+		u := &unstructured.Unstructured{}
+		if err := scheme.Scheme.Convert(md, u, nil); err != nil {
+			return nil, errors.Wrapf(err, "failed to convert MachineDeployment %s/%s", md.Namespace, md.Name)
+		}
+		objs.add(u)
+
+		if _, err := get(md.Spec.Template.Spec.Bootstrap.ConfigRef); err != nil {
+			return nil, err
+		}
+		if _, err := get(&md.Spec.Template.Spec.InfrastructureRef); err != nil {
+			return nil, err
+		}
+	}
+
+	return objs, nil
+}
+
+// toTyped converts an object to the corresponding typed object, if its kind is known by the clusterctl scheme,
+// so it can be read by the fake client using typed lists.
+func toTyped(obj *unstructured.Unstructured) (runtime.Object, error) {
+	typed, err := scheme.Scheme.New(obj.GroupVersionKind())
+	if err != nil {
+		return obj.DeepCopy(), nil
+	}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, typed); err != nil {
+		return nil, errors.Wrapf(err, "failed to convert %s %s/%s", obj.GetKind(), obj.GetNamespace(), obj.GetName())
+	}
+	return typed, nil
+}
+
+// topologyObjects is a set of objects, indexed by kind, namespace and name, preserving the insertion order.
+type topologyObjects struct {
+	items []*unstructured.Unstructured
+	index map[string]*unstructured.Unstructured
+}
+
+func newTopologyObjects() *topologyObjects {
+	return &topologyObjects{
+		index: map[string]*unstructured.Unstructured{},
+	}
+}
+
+func topologyObjectKey(gvk schema.GroupVersionKind, namespace, name string) string {
+	return gvk.GroupKind().String() + "/" + namespace + "/" + name
+}
+
+func (o *topologyObjects) add(obj *unstructured.Unstructured) {
+	key := topologyObjectKey(obj.GroupVersionKind(), obj.GetNamespace(), obj.GetName())
+	if _, ok := o.index[key]; ok {
+		return
+	}
+	o.items = append(o.items, obj)
+	o.index[key] = obj
+}
+
+func (o *topologyObjects) get(gvk schema.GroupVersionKind, namespace, name string) *unstructured.Unstructured {
+	return o.index[topologyObjectKey(gvk, namespace, name)]
+}
+
+func (o *topologyObjects) list(gvk schema.GroupVersionKind) []*unstructured.Unstructured {
+	var ret []*unstructured.Unstructured
+	for _, obj := range o.items {
+		if obj.GroupVersionKind().GroupKind() == gvk.GroupKind() {
+			ret = append(ret, obj)
+		}
+	}
+	return ret
+}
+
+// topologyPlanClient is a client.Client tracking the objects created, updated and deleted.
+type topologyPlanClient struct {
+	client.Client
+	changed *topologyObjects
+}
+
+func (c *topologyPlanClient) track(obj runtime.Object) {
+	u := &unstructured.Unstructured{}
+	if err := scheme.Scheme.Convert(obj, u, nil); err != nil {
+		return
+	}
+	c.changed.add(u)
+}
+
+func (c *topologyPlanClient) Create(ctx context.Context, obj runtime.Object, opts ...client.CreateOption) error {
+	if err := c.Client.Create(ctx, obj, opts...); err != nil {
+		return err
+	}
+	c.track(obj)
+	return nil
+}
+
+func (c *topologyPlanClient) Update(ctx context.Context, obj runtime.Object, opts ...client.UpdateOption) error {
+	if err := c.Client.Update(ctx, obj, opts...); err != nil {
+		return err
+	}
+	c.track(obj)
+	return nil
+}
+
+func (c *topologyPlanClient) Patch(ctx context.Context, obj runtime.Object, patch client.Patch, opts ...client.PatchOption) error {
+	if err := c.Client.Patch(ctx, obj, patch, opts...); err != nil {
+		return err
+	}
+	c.track(obj)
+	return nil
+}
+
+func (c *topologyPlanClient) Delete(ctx context.Context, obj runtime.Object, opts ...client.DeleteOption) error {
+	if err := c.Client.Delete(ctx, obj, opts...); err != nil {
+		return err
+	}
+	c.track(obj)
+	return nil
+}
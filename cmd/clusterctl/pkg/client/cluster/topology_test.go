@@ -0,0 +1,255 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/utils/pointer"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/internal/test"
+	"sigs.k8s.io/cluster-api/controllers"
+)
+
+func Test_topologyClient_Plan(t *testing.T) {
+	template := func(apiVersion, kind, name string, spec map[string]interface{}) *unstructured.Unstructured {
+		return &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": apiVersion,
+			"kind":       kind,
+			"metadata": map[string]interface{}{
+				"name":      name,
+				"namespace": "ns1",
+			},
+			"spec": map[string]interface{}{
+				"template": map[string]interface{}{
+					"spec": spec,
+				},
+			},
+		}}
+	}
+	ref := func(obj *unstructured.Unstructured) *corev1.ObjectReference {
+		return &corev1.ObjectReference{APIVersion: obj.GetAPIVersion(), Kind: obj.GetKind(), Name: obj.GetName()}
+	}
+	infrastructureTemplate := template("infrastructure.cluster.x-k8s.io/v1alpha3", "InfrastructureClusterTemplate", "infra", map[string]interface{}{})
+	controlPlaneTemplate := template("controlplane.cluster.x-k8s.io/v1alpha3", "ControlPlaneTemplate", "cp", map[string]interface{}{})
+	workerMachineTemplate := template("infrastructure.cluster.x-k8s.io/v1alpha3", "InfrastructureMachineTemplate", "worker-machine", map[string]interface{}{"image": "${IMAGE_ID}"})
+	workerBootstrapTemplate := template("bootstrap.cluster.x-k8s.io/v1alpha3", "BootstrapConfigTemplate", "worker-bootstrap", map[string]interface{}{})
+
+	class := &clusterv1.ClusterClass{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: clusterv1.GroupVersion.String(),
+			Kind:       "ClusterClass",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "class",
+			Namespace: "ns1",
+		},
+		Spec: clusterv1.ClusterClassSpec{
+			Infrastructure: clusterv1.LocalObjectTemplate{Ref: ref(infrastructureTemplate)},
+			ControlPlane: clusterv1.ControlPlaneClass{
+				LocalObjectTemplate: clusterv1.LocalObjectTemplate{Ref: ref(controlPlaneTemplate)},
+			},
+			Workers: clusterv1.WorkersClass{
+				MachineDeployments: []clusterv1.MachineDeploymentClass{
+					{
+						Class: "default-worker",
+						Template: clusterv1.MachineDeploymentClassTemplate{
+							Bootstrap:      clusterv1.LocalObjectTemplate{Ref: ref(workerBootstrapTemplate)},
+							Infrastructure: clusterv1.LocalObjectTemplate{Ref: ref(workerMachineTemplate)},
+						},
+					},
+				},
+			},
+			Variables: []clusterv1.ClusterClassVariable{
+				{Name: "IMAGE_ID", Default: "image-1"},
+			},
+		},
+	}
+
+	newCluster := func(name, version string) *clusterv1.Cluster {
+		return &clusterv1.Cluster{
+			TypeMeta: metav1.TypeMeta{
+				APIVersion: clusterv1.GroupVersion.String(),
+				Kind:       "Cluster",
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "ns1",
+			},
+			Spec: clusterv1.ClusterSpec{
+				Topology: &clusterv1.Topology{
+					Class:   "class",
+					Version: version,
+					Workers: &clusterv1.WorkersTopology{
+						MachineDeployments: []clusterv1.MachineDeploymentTopology{
+							{Class: "default-worker", Name: "md-0", Replicas: pointer.Int32Ptr(1)},
+						},
+					},
+				},
+			},
+		}
+	}
+	toUnstructured := func(obj runtime.Object) unstructured.Unstructured {
+		u := unstructured.Unstructured{}
+		if err := test.FakeScheme.Convert(obj, &u, nil); err != nil {
+			t.Fatalf("failed to convert %v: %v", obj, err)
+		}
+		return u
+	}
+	newProxy := func() *test.FakeProxy {
+		proxy := test.NewFakeProxy().WithObjs(class, infrastructureTemplate, controlPlaneTemplate, workerMachineTemplate, workerBootstrapTemplate)
+
+		// Create the topology of an existing Cluster.
+		c, err := proxy.NewClient()
+		if err != nil {
+			t.Fatalf("NewClient() error = %v", err)
+		}
+		existing := newCluster("existing", "v1.18.2")
+		if err := controllers.ReconcileTopology(ctx, c, existing); err != nil {
+			t.Fatalf("failed to create the topology of the existing cluster: %v", err)
+		}
+		if err := c.Create(ctx, existing); err != nil {
+			t.Fatalf("failed to create the existing cluster: %v", err)
+		}
+		return proxy
+	}
+
+	updatedClass := class.DeepCopy()
+	updatedClass.Spec.Variables[0].Default = "image-2"
+
+	type want struct {
+		created sets.String
+		updated sets.String
+		deleted sets.String
+	}
+	tests := []struct {
+		name string
+		objs []unstructured.Unstructured
+		want map[string]want
+	}{
+		{
+			name: "a new Cluster creates all the objects of the topology",
+			objs: []unstructured.Unstructured{toUnstructured(newCluster("new", "v1.18.2"))},
+			want: map[string]want{
+				"new": {
+					created: sets.NewString("InfrastructureCluster", "ControlPlane", "BootstrapConfigTemplate", "InfrastructureMachineTemplate", "MachineDeployment"),
+					updated: sets.NewString(),
+					deleted: sets.NewString(),
+				},
+			},
+		},
+		{
+			name: "a new version of an existing Cluster updates the control plane and the MachineDeployments",
+			objs: []unstructured.Unstructured{toUnstructured(newCluster("existing", "v1.19.1"))},
+			want: map[string]want{
+				"existing": {
+					created: sets.NewString(),
+					updated: sets.NewString("ControlPlane", "MachineDeployment"),
+					deleted: sets.NewString(),
+				},
+			},
+		},
+		{
+			name: "removing a worker pool of an existing Cluster deletes its MachineDeployment",
+			objs: func() []unstructured.Unstructured {
+				cluster := newCluster("existing", "v1.18.2")
+				cluster.Spec.Topology.Workers = nil
+				return []unstructured.Unstructured{toUnstructured(cluster)}
+			}(),
+			want: map[string]want{
+				"existing": {
+					created: sets.NewString(),
+					updated: sets.NewString(),
+					deleted: sets.NewString("MachineDeployment"),
+				},
+			},
+		},
+		{
+			name: "a change to a ClusterClass rotates the templates of the Clusters using it",
+			objs: []unstructured.Unstructured{toUnstructured(updatedClass)},
+			want: map[string]want{
+				"existing": {
+					created: sets.NewString("InfrastructureMachineTemplate"),
+					updated: sets.NewString("MachineDeployment"),
+					deleted: sets.NewString(),
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			proxy := newProxy()
+			c, err := proxy.NewClient()
+			if err != nil {
+				t.Fatalf("NewClient() error = %v", err)
+			}
+			before := &clusterv1.MachineDeploymentList{}
+			if err := c.List(ctx, before); err != nil {
+				t.Fatalf("failed to list MachineDeployments: %v", err)
+			}
+
+			got, err := newTopologyClient(proxy).Plan(ctx, &TopologyPlanInput{Objs: tt.objs, Namespace: "ns1"})
+			if err != nil {
+				t.Fatalf("Plan() error = %v", err)
+			}
+
+			if len(got.Clusters) != len(tt.want) {
+				t.Fatalf("got %d clusters, want %d", len(got.Clusters), len(tt.want))
+			}
+			for _, cluster := range got.Clusters {
+				want, ok := tt.want[cluster.Name]
+				if !ok {
+					t.Fatalf("unexpected cluster %q", cluster.Name)
+				}
+				kinds := func(objs []unstructured.Unstructured) sets.String {
+					ret := sets.NewString()
+					for _, o := range objs {
+						ret.Insert(o.GetKind())
+					}
+					return ret
+				}
+				updated := sets.NewString()
+				for _, u := range cluster.Updated {
+					updated.Insert(u.After.GetKind())
+				}
+				if !kinds(cluster.Created).Equal(want.created) {
+					t.Errorf("created = %v, want %v", kinds(cluster.Created).List(), want.created.List())
+				}
+				if !updated.Equal(want.updated) {
+					t.Errorf("updated = %v, want %v", updated.List(), want.updated.List())
+				}
+				if !kinds(cluster.Deleted).Equal(want.deleted) {
+					t.Errorf("deleted = %v, want %v", kinds(cluster.Deleted).List(), want.deleted.List())
+				}
+			}
+
+			// The management cluster should not be changed.
+			after := &clusterv1.MachineDeploymentList{}
+			if err := c.List(ctx, after); err != nil {
+				t.Fatalf("failed to list MachineDeployments: %v", err)
+			}
+			if len(after.Items) != len(before.Items) || after.Items[0].ResourceVersion != before.Items[0].ResourceVersion {
+				t.Errorf("MachineDeployments changed by Plan()")
+			}
+		})
+	}
+}
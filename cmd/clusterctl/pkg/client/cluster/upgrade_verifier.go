@@ -0,0 +1,323 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/pkg/errors"
+	admissionregistrationv1beta1 "k8s.io/api/admissionregistration/v1beta1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	kerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/wait"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
+	logf "sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	upgradeVerifyPollInterval = 5 * time.Second
+
+	// maxLaggingObjects is the maximum number of objects not yet reconciled reported by the reconcile check.
+	maxLaggingObjects = 5
+)
+
+// The checks of the post-upgrade verification, in the order they are executed.
+const (
+	UpgradeCheckProvidersReady = "providers-ready"
+	UpgradeCheckWebhooks       = "webhooks-serving"
+	UpgradeCheckConversion     = "conversion"
+	UpgradeCheckReconcile      = "reconcile"
+)
+
+// UpgradeCheck reports the outcome of a check of the post-upgrade verification.
+type UpgradeCheck struct {
+	// Name of the check.
+	Name string
+
+	// Duration of the check, including the time spent waiting for the check to pass.
+	Duration time.Duration
+
+	// Error is the reason why the check failed, nil if the check passed.
+	Error error
+}
+
+// UpgradeVerifier verifies that the providers of a management group are working after an upgrade.
+type UpgradeVerifier interface {
+	// Verify waits for the providers to be working after an upgrade, running in order the following checks:
+	// - all the provider Deployments are ready;
+	// - all the webhooks of the providers are serving;
+	// - the conversion webhooks of the provider CRDs are converting objects;
+	// - the provider controllers have reconciled the current generation of all the objects.
+	// Each check is retried until it passes or the timeout expires; the checks following a failed check are not executed.
+	Verify(ctx context.Context, providers []clusterctlv1.Provider, timeout time.Duration) ([]UpgradeCheck, error)
+}
+
+// upgradeVerifier implements UpgradeVerifier.
+type upgradeVerifier struct {
+	proxy              Proxy
+	providerComponents ComponentsClient
+	pollInterval       time.Duration
+	log                logr.Logger
+}
+
+// ensure upgradeVerifier implements UpgradeVerifier.
+var _ UpgradeVerifier = &upgradeVerifier{}
+
+func newUpgradeVerifier(proxy Proxy, providerComponents ComponentsClient) *upgradeVerifier {
+	return &upgradeVerifier{
+		proxy:              proxy,
+		providerComponents: providerComponents,
+		pollInterval:       upgradeVerifyPollInterval,
+		log:                logf.Log,
+	}
+}
+
+func (v *upgradeVerifier) Verify(ctx context.Context, providers []clusterctlv1.Provider, timeout time.Duration) ([]UpgradeCheck, error) {
+	c, err := v.proxy.NewClient()
+	if err != nil {
+		return nil, err
+	}
+
+	providerNames := sets.NewString()
+	for _, p := range providers {
+		providerNames.Insert(p.Name)
+	}
+
+	checks := []struct {
+		name string
+		run  func() error
+	}{
+		{
+			name: UpgradeCheckProvidersReady,
+			run: func() error {
+				errList := []error{}
+				for _, p := range providers {
+					if err := v.providerComponents.CheckHealth(ctx, p); err != nil {
+						errList = append(errList, err)
+					}
+				}
+				return kerrors.NewAggregate(errList)
+			},
+		},
+		{
+			name: UpgradeCheckWebhooks,
+			run: func() error {
+				return checkWebhooksServing(ctx, c, providerNames)
+			},
+		},
+		{
+			name: UpgradeCheckConversion,
+			run: func() error {
+				return checkProviderConversionWebhooks(ctx, c, providerNames, v.log)
+			},
+		},
+		{
+			name: UpgradeCheckReconcile,
+			run: func() error {
+				return checkObjectsReconciled(ctx, c, providerNames)
+			},
+		},
+	}
+
+	results := []UpgradeCheck{}
+	for _, check := range checks {
+		result := v.runCheck(check.name, timeout, check.run)
+		results = append(results, result)
+		if result.Error != nil {
+			break
+		}
+	}
+	return results, nil
+}
+
+// runCheck runs a check until it passes or the timeout expires, measuring its duration.
+func (v *upgradeVerifier) runCheck(name string, timeout time.Duration, run func() error) UpgradeCheck {
+	log := v.log
+	log.Info("Verifying the upgrade", "Check", name)
+
+	start := time.Now()
+	var lastErr error
+	err := wait.PollImmediate(v.pollInterval, timeout, func() (bool, error) {
+		if lastErr = run(); lastErr != nil {
+			log.V(5).Info("Upgrade check not passed yet", "Check", name, "Reason", lastErr.Error())
+			return false, nil
+		}
+		return true, nil
+	})
+	if err != nil && lastErr != nil {
+		err = lastErr
+	}
+	return UpgradeCheck{
+		Name:     name,
+		Duration: time.Since(start),
+		Error:    err,
+	}
+}
+
+// isProviderObject returns true if an object installed by clusterctl belongs to one of the given providers.
+func isProviderObject(obj metav1.Object, providerNames sets.String) bool {
+	return providerNames.Has(obj.GetLabels()[clusterv1.ProviderLabelName])
+}
+
+// checkWebhooksServing checks that the services backing the admission webhooks of the providers have ready endpoints.
+func checkWebhooksServing(ctx context.Context, c client.Client, providerNames sets.String) error {
+	errList := []error{}
+
+	validatingList := &admissionregistrationv1beta1.ValidatingWebhookConfigurationList{}
+	if err := c.List(ctx, validatingList, client.MatchingLabels{clusterctlv1.ClusterctlLabelName: ""}); err != nil {
+		return errors.Wrap(err, "failed to get the list of ValidatingWebhookConfigurations installed by clusterctl")
+	}
+	for i := range validatingList.Items {
+		config := &validatingList.Items[i]
+		if !isProviderObject(config, providerNames) {
+			continue
+		}
+		for _, webhook := range config.Webhooks {
+			if webhook.ClientConfig.Service == nil {
+				continue
+			}
+			if err := checkWebhookService(ctx, c, webhook.ClientConfig.Service.Namespace, webhook.ClientConfig.Service.Name, webhook.ClientConfig.CABundle); err != nil {
+				errList = append(errList, errors.Wrapf(err, "the %s/%s webhook is not serving", config.Name, webhook.Name))
+			}
+		}
+	}
+
+	mutatingList := &admissionregistrationv1beta1.MutatingWebhookConfigurationList{}
+	if err := c.List(ctx, mutatingList, client.MatchingLabels{clusterctlv1.ClusterctlLabelName: ""}); err != nil {
+		return errors.Wrap(err, "failed to get the list of MutatingWebhookConfigurations installed by clusterctl")
+	}
+	for i := range mutatingList.Items {
+		config := &mutatingList.Items[i]
+		if !isProviderObject(config, providerNames) {
+			continue
+		}
+		for _, webhook := range config.Webhooks {
+			if webhook.ClientConfig.Service == nil {
+				continue
+			}
+			if err := checkWebhookService(ctx, c, webhook.ClientConfig.Service.Namespace, webhook.ClientConfig.Service.Name, webhook.ClientConfig.CABundle); err != nil {
+				errList = append(errList, errors.Wrapf(err, "the %s/%s webhook is not serving", config.Name, webhook.Name))
+			}
+		}
+	}
+
+	return kerrors.NewAggregate(errList)
+}
+
+// listProviderCRDs returns the CRDs installed by clusterctl for the given providers.
+func listProviderCRDs(ctx context.Context, c client.Client, providerNames sets.String) ([]apiextensionsv1.CustomResourceDefinition, error) {
+	crdList := &apiextensionsv1.CustomResourceDefinitionList{}
+	if err := c.List(ctx, crdList, client.MatchingLabels{clusterctlv1.ClusterctlLabelName: ""}); err != nil {
+		return nil, errors.Wrap(err, "failed to get the list of CRDs installed by clusterctl")
+	}
+
+	crds := []apiextensionsv1.CustomResourceDefinition{}
+	for i := range crdList.Items {
+		if isProviderObject(&crdList.Items[i], providerNames) {
+			crds = append(crds, crdList.Items[i])
+		}
+	}
+	return crds, nil
+}
+
+// checkProviderConversionWebhooks checks the conversion webhooks of the CRDs of the providers; the webhooks that
+// can't be verified because there are no objects to convert are reported in the logs.
+func checkProviderConversionWebhooks(ctx context.Context, c client.Client, providerNames sets.String, log logr.Logger) error {
+	crds, err := listProviderCRDs(ctx, c, providerNames)
+	if err != nil {
+		return err
+	}
+
+	errList := []error{}
+	for i := range crds {
+		crd := &crds[i]
+		if crd.Spec.Conversion == nil || crd.Spec.Conversion.Strategy != apiextensionsv1.WebhookConverter {
+			continue
+		}
+		verified, err := checkConversionWebhook(ctx, c, crd)
+		if err != nil {
+			errList = append(errList, errors.Wrapf(err, "the conversion webhook for the %s CRD is not working", crd.Name))
+			continue
+		}
+		if !verified {
+			log.Info("The conversion webhook check is inconclusive: there are no objects to convert", "CRD", crd.Name)
+		}
+	}
+	return kerrors.NewAggregate(errList)
+}
+
+// checkObjectsReconciled checks that the provider controllers have observed the current generation of all the
+// objects of the provider CRDs; objects without status.observedGeneration are ignored.
+func checkObjectsReconciled(ctx context.Context, c client.Client, providerNames sets.String) error {
+	crds, err := listProviderCRDs(ctx, c, providerNames)
+	if err != nil {
+		return err
+	}
+
+	lagging := []string{}
+	for i := range crds {
+		crd := &crds[i]
+		version := storageVersion(crd)
+		if version == "" {
+			continue
+		}
+
+		objList := new(unstructured.UnstructuredList)
+		objList.SetAPIVersion(metav1.GroupVersion{Group: crd.Spec.Group, Version: version}.String())
+		objList.SetKind(crd.Spec.Names.Kind + "List")
+		if err := c.List(ctx, objList); err != nil {
+			return errors.Wrapf(err, "failed to list the %s objects", crd.Spec.Names.Kind)
+		}
+
+		for _, obj := range objList.Items {
+			observedGeneration, found, err := unstructured.NestedInt64(obj.Object, "status", "observedGeneration")
+			if err != nil || !found {
+				continue
+			}
+			if observedGeneration < obj.GetGeneration() {
+				lagging = append(lagging, fmt.Sprintf("%s %s/%s", crd.Spec.Names.Kind, obj.GetNamespace(), obj.GetName()))
+			}
+		}
+	}
+
+	if len(lagging) == 0 {
+		return nil
+	}
+	if len(lagging) > maxLaggingObjects {
+		lagging = append(lagging[:maxLaggingObjects], fmt.Sprintf("%d more", len(lagging)-maxLaggingObjects))
+	}
+	return errors.Errorf("the current generation of the following objects has not been reconciled yet: %s", strings.Join(lagging, ", "))
+}
+
+// storageVersion returns the storage version of a CRD.
+func storageVersion(crd *apiextensionsv1.CustomResourceDefinition) string {
+	for _, v := range crd.Spec.Versions {
+		if v.Storage {
+			return v.Name
+		}
+	}
+	return crd.Spec.Version
+}
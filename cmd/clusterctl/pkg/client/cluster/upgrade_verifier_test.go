@@ -0,0 +1,133 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"testing"
+	"time"
+
+	admissionregistrationv1beta1 "k8s.io/api/admissionregistration/v1beta1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/pointer"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/internal/test"
+)
+
+func Test_upgradeVerifier_Verify(t *testing.T) {
+	providerLabels := map[string]string{
+		clusterctlv1.ClusterctlLabelName: "",
+		clusterv1.ProviderLabelName:      "cluster-api",
+	}
+
+	deployment := func(availableReplicas int32) *appsv1.Deployment {
+		return &appsv1.Deployment{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "apps/v1", Kind: "Deployment"},
+			ObjectMeta: metav1.ObjectMeta{Namespace: "capi-system", Name: "capi-controller-manager", Labels: providerLabels},
+			Spec:       appsv1.DeploymentSpec{Replicas: pointer.Int32Ptr(1)},
+			Status:     appsv1.DeploymentStatus{AvailableReplicas: availableReplicas},
+		}
+	}
+	webhookConfiguration := &admissionregistrationv1beta1.ValidatingWebhookConfiguration{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "admissionregistration.k8s.io/v1beta1", Kind: "ValidatingWebhookConfiguration"},
+		ObjectMeta: metav1.ObjectMeta{Name: "capi-validating-webhook-configuration", Labels: providerLabels},
+		Webhooks: []admissionregistrationv1beta1.ValidatingWebhook{
+			{
+				Name: "validation.cluster.cluster.x-k8s.io",
+				ClientConfig: admissionregistrationv1beta1.WebhookClientConfig{
+					Service:  &admissionregistrationv1beta1.ServiceReference{Namespace: "capi-webhook-system", Name: "capi-webhook-service"},
+					CABundle: []byte("ca"),
+				},
+			},
+		},
+	}
+	webhookEndpoints := &corev1.Endpoints{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Endpoints"},
+		ObjectMeta: metav1.ObjectMeta{Namespace: "capi-webhook-system", Name: "capi-webhook-service"},
+		Subsets:    []corev1.EndpointSubset{{Addresses: []corev1.EndpointAddress{{IP: "10.0.0.1"}}}},
+	}
+	crd := test.FakeCustomResourceDefinition("cluster.x-k8s.io", "MachineDeployment", "v1alpha3")
+	crd.Labels = providerLabels
+	machineDeployment := func(generation, observedGeneration int64) *clusterv1.MachineDeployment {
+		return &clusterv1.MachineDeployment{
+			TypeMeta:   metav1.TypeMeta{APIVersion: clusterv1.GroupVersion.String(), Kind: "MachineDeployment"},
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: "md", Generation: generation},
+			Status:     clusterv1.MachineDeploymentStatus{ObservedGeneration: observedGeneration},
+		}
+	}
+
+	provider := clusterctlv1.Provider{ObjectMeta: metav1.ObjectMeta{Namespace: "capi-system", Name: "cluster-api"}}
+	allChecks := []string{UpgradeCheckProvidersReady, UpgradeCheckWebhooks, UpgradeCheckConversion, UpgradeCheckReconcile}
+
+	tests := []struct {
+		name       string
+		objs       []runtime.Object
+		wantChecks []string
+		wantFail   string
+	}{
+		{
+			name:       "all the checks pass",
+			objs:       []runtime.Object{deployment(1), webhookConfiguration, webhookEndpoints, crd, machineDeployment(2, 2)},
+			wantChecks: allChecks,
+		},
+		{
+			name:       "fails if the provider deployments are not ready",
+			objs:       []runtime.Object{deployment(0), webhookConfiguration, webhookEndpoints, crd, machineDeployment(2, 2)},
+			wantChecks: []string{UpgradeCheckProvidersReady},
+			wantFail:   UpgradeCheckProvidersReady,
+		},
+		{
+			name:       "fails if the webhooks are not serving",
+			objs:       []runtime.Object{deployment(1), webhookConfiguration, crd, machineDeployment(2, 2)},
+			wantChecks: []string{UpgradeCheckProvidersReady, UpgradeCheckWebhooks},
+			wantFail:   UpgradeCheckWebhooks,
+		},
+		{
+			name:       "fails if the objects are not reconciled",
+			objs:       []runtime.Object{deployment(1), webhookConfiguration, webhookEndpoints, crd, machineDeployment(2, 1)},
+			wantChecks: allChecks,
+			wantFail:   UpgradeCheckReconcile,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			proxy := test.NewFakeProxy().WithObjs(tt.objs...)
+			v := newUpgradeVerifier(proxy, newComponentsClient(proxy, fakeObjectWaiter))
+			v.pollInterval = 10 * time.Millisecond
+
+			checks, err := v.Verify(ctx, []clusterctlv1.Provider{provider}, 50*time.Millisecond)
+			if err != nil {
+				t.Fatalf("Verify() error = %v", err)
+			}
+
+			if len(checks) != len(tt.wantChecks) {
+				t.Fatalf("got %d checks, want %v", len(checks), tt.wantChecks)
+			}
+			for i, check := range checks {
+				if check.Name != tt.wantChecks[i] {
+					t.Errorf("got check %q, want %q", check.Name, tt.wantChecks[i])
+				}
+				if wantFail := check.Name == tt.wantFail; (check.Error != nil) != wantFail {
+					t.Errorf("check %q error = %v, want failed %t", check.Name, check.Error, wantFail)
+				}
+			}
+		})
+	}
+}
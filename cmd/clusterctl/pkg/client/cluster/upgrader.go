@@ -17,11 +17,19 @@ limitations under the License.
 package cluster
 
 import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
 	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/sets"
 	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/client/config"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/client/repository"
 	logf "sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/log"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/metrics"
 )
 
 // ProviderUpgrader defines methods for supporting provider upgrade.
@@ -31,10 +39,18 @@ type ProviderUpgrader interface {
 	// - For each management group, an upgrade plan will be generated for each API Version of Cluster API (contract) available, e.g.
 	//   - Upgrade to the latest version in the the v1alpha2 series: ....
 	//   - Upgrade to the latest version in the the v1alpha3 series: ....
-	Plan() ([]UpgradePlan, error)
+	Plan(ctx context.Context) ([]UpgradePlan, error)
 
 	// ApplyPlan executes an upgrade following an UpgradePlan generated by clusterctl.
-	ApplyPlan(coreProvider clusterctlv1.Provider, clusterAPIVersion string) error
+	ApplyPlan(ctx context.Context, coreProvider clusterctlv1.Provider, clusterAPIVersion string) error
+
+	// ExportPlan returns the upgrade plan of a management group to the given API Version of Cluster API (contract),
+	// in a format which can be written to a file, reviewed, and then applied by ApplyPlanFile.
+	ExportPlan(ctx context.Context, coreProvider clusterctlv1.Provider, contract string) (*UpgradePlanFile, error)
+
+	// ApplyPlanFile executes exactly the upgrade defined by an upgrade plan file, refusing to proceed if the
+	// management group has drifted from the state the plan was computed for.
+	ApplyPlanFile(ctx context.Context, planFile UpgradePlanFile) error
 }
 
 // UpgradePlan defines a list of possible upgrade targets for a management group.
@@ -54,6 +70,10 @@ func (u *UpgradePlan) UpgradeRef() string {
 type UpgradeItem struct {
 	clusterctlv1.Provider
 	NextVersion string
+
+	// CRDMigrations lists the CustomResourceDefinitions whose objects are migrated to a new storage version
+	// before upgrading the provider, because the next version drops API versions objects are stored in.
+	CRDMigrations []CRDMigration
 }
 
 // UpgradeRef returns a string identifying the upgrade item; this string is derived by the provider.
@@ -64,17 +84,20 @@ func (u *UpgradeItem) UpgradeRef() string {
 type providerUpgrader struct {
 	configClient            config.Client
 	repositoryClientFactory RepositoryClientFactory
+	proxy                   Proxy
 	providerInventory       InventoryClient
 	providerComponents      ComponentsClient
+	progress                progress
+	log                     logr.Logger
 }
 
 var _ ProviderUpgrader = &providerUpgrader{}
 
-func (u *providerUpgrader) Plan() ([]UpgradePlan, error) {
-	log := logf.Log
+func (u *providerUpgrader) Plan(ctx context.Context) ([]UpgradePlan, error) {
+	log := u.log
 	log.Info("Checking new release availability...")
 
-	managementGroups, err := u.providerInventory.GetManagementGroups()
+	managementGroups, err := u.providerInventory.GetManagementGroups(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -88,7 +111,7 @@ func (u *providerUpgrader) Plan() ([]UpgradePlan, error) {
 		// or if available, all the providers in the management group can upgrade to the latest release supporting v1alpha4.
 
 		// Gets the upgrade info for the core provider.
-		coreUpgradeInfo, err := u.getUpgradeInfo(managementGroup.CoreProvider)
+		coreUpgradeInfo, err := u.getUpgradeInfo(ctx, managementGroup.CoreProvider)
 		if err != nil {
 			return nil, err
 		}
@@ -105,10 +128,15 @@ func (u *providerUpgrader) Plan() ([]UpgradePlan, error) {
 		// e.g. v1alpha3, cluster-api --> v0.3.2, kubeadm bootstrap --> v0.3.2, aws --> v0.5.4
 		// e.g. v1alpha4, cluster-api --> v0.4.1, kubeadm bootstrap --> v0.4.1, aws --> v0.6.2
 		for _, apiVersion := range contractsForUpgrade {
-			upgradePlan, err := u.getUpgradePlan(managementGroup, apiVersion)
+			upgradePlan, err := u.getUpgradePlan(ctx, managementGroup, apiVersion)
 			if err != nil {
 				return nil, err
 			}
+			for i := range upgradePlan.Providers {
+				if err := u.planCRDMigrations(ctx, &upgradePlan.Providers[i]); err != nil {
+					return nil, err
+				}
+			}
 			ret = append(ret, *upgradePlan)
 		}
 	}
@@ -116,37 +144,61 @@ func (u *providerUpgrader) Plan() ([]UpgradePlan, error) {
 	return ret, nil
 }
 
-func (u *providerUpgrader) ApplyPlan(coreProvider clusterctlv1.Provider, contract string) error {
-	log := logf.Log
+func (u *providerUpgrader) ApplyPlan(ctx context.Context, coreProvider clusterctlv1.Provider, contract string) error {
+	log := u.log
 	log.Info("Performing upgrade...")
 
+	if err := u.checkInventoryScope(coreProvider); err != nil {
+		return err
+	}
+
 	// Retrieves the management group.
-	managementGroup, err := u.getManagementGroup(coreProvider)
+	managementGroup, err := u.getManagementGroup(ctx, coreProvider)
 	if err != nil {
 		return err
 	}
 
 	// Gets the upgrade plan for the selected management group/API Version of Cluster API (contract).
-	upgradePlan, err := u.getUpgradePlan(*managementGroup, contract)
+	upgradePlan, err := u.getUpgradePlan(ctx, *managementGroup, contract)
 	if err != nil {
 		return err
 	}
 
 	// Do the upgrade
-	return u.doUpgrade(upgradePlan)
+	return u.doUpgrade(ctx, upgradePlan)
+}
+
+// checkInventoryScope checks the management group of a core provider can be upgraded: if the inventory is scoped to
+// a set of namespaces, only the management groups in those namespaces can be upgraded.
+func (u *providerUpgrader) checkInventoryScope(coreProvider clusterctlv1.Provider) error {
+	if scope := u.providerInventory.Namespaces(); len(scope) > 0 && !sets.NewString(scope...).Has(coreProvider.Namespace) {
+		return errors.Errorf("unable to upgrade the %s management group: namespace %q is outside of the inventory namespaces %s", coreProvider.InstanceName(), coreProvider.Namespace, strings.Join(scope, ", "))
+	}
+	return nil
 }
 
 // getUpgradePlan returns the upgrade plan for a specific managementGroup/contract
 // NB. this function is used both for upgrade plan and upgrade apply.
-func (u *providerUpgrader) getUpgradePlan(managementGroup ManagementGroup, contract string) (*UpgradePlan, error) {
+func (u *providerUpgrader) getUpgradePlan(ctx context.Context, managementGroup ManagementGroup, contract string) (*UpgradePlan, error) {
+	// Gets the version policy for the management cluster, defining the provider versions allowed for upgrades.
+	versionPolicy, err := u.providerInventory.GetVersionPolicy(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	upgradeItems := []UpgradeItem{}
 	for _, provider := range managementGroup.Providers {
 		// Gets the upgrade info for the provider.
-		providerUpgradeInfo, err := u.getUpgradeInfo(provider)
+		providerUpgradeInfo, err := u.getUpgradeInfo(ctx, provider)
 		if err != nil {
 			return nil, err
 		}
 
+		// Drops the versions not allowed by the version policy.
+		if err := providerUpgradeInfo.filterNextVersions(versionPolicy, provider.Name); err != nil {
+			return nil, err
+		}
+
 		// Identifies the next available version with the target contract for the provider, if available.
 		nextVersion := providerUpgradeInfo.getLatestNextVersion(contract)
 
@@ -164,9 +216,35 @@ func (u *providerUpgrader) getUpgradePlan(managementGroup ManagementGroup, contr
 	}, nil
 }
 
+// planCRDMigrations adds to an upgrade item the storage version migrations required before upgrading the provider.
+// NB. Only the CustomResourceDefinitions are read from the repository, so the plan does not require the values
+// of the variables of the provider components; in case the CRDs can't be read, the migrations are computed during
+// the upgrade.
+// An error is returned only if the new CRDs can't read the objects already existing in the cluster, because the upgrade
+// would break the provider.
+func (u *providerUpgrader) planCRDMigrations(ctx context.Context, upgradeItem *UpgradeItem) error {
+	log := u.log
+
+	if upgradeItem.NextVersion == "" {
+		return nil
+	}
+
+	crds, err := u.getUpgradeCustomResourceDefinitions(ctx, *upgradeItem)
+	if err == nil {
+		upgradeItem.CRDMigrations, err = newCRDMigrator(u.proxy).plan(ctx, crds)
+	}
+	if err != nil {
+		if _, ok := errors.Cause(err).(*crdUpgradeError); ok {
+			return errors.Wrapf(err, "unable to upgrade %s to %s", upgradeItem.InstanceName(), upgradeItem.NextVersion)
+		}
+		log.Info("Unable to check the CustomResourceDefinitions storage version migrations", "Provider", upgradeItem.InstanceName(), "TargetVersion", upgradeItem.NextVersion, "Error", err.Error())
+	}
+	return nil
+}
+
 // getManagementGroup returns the management group for a core provider.
-func (u *providerUpgrader) getManagementGroup(coreProvider clusterctlv1.Provider) (*ManagementGroup, error) {
-	managementGroups, err := u.providerInventory.GetManagementGroups()
+func (u *providerUpgrader) getManagementGroup(ctx context.Context, coreProvider clusterctlv1.Provider) (*ManagementGroup, error) {
+	managementGroups, err := u.providerInventory.GetManagementGroups(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -180,7 +258,7 @@ func (u *providerUpgrader) getManagementGroup(coreProvider clusterctlv1.Provider
 }
 
 // getUpgradeComponents returns the provider components for the selected target version.
-func (u *providerUpgrader) getUpgradeComponents(provider UpgradeItem) (repository.Components, error) {
+func (u *providerUpgrader) getUpgradeComponents(ctx context.Context, provider UpgradeItem) (repository.Components, error) {
 	configRepository, err := u.configClient.Providers().Get(provider.Name)
 	if err != nil {
 		return nil, err
@@ -191,53 +269,128 @@ func (u *providerUpgrader) getUpgradeComponents(provider UpgradeItem) (repositor
 		return nil, err
 	}
 
-	components, err := providerRepository.Components().Get(provider.NextVersion, provider.Namespace, provider.WatchedNamespace)
+	components, err := providerRepository.Components().Get(ctx, provider.NextVersion, provider.Namespace, provider.WatchedNamespace)
 	if err != nil {
 		return nil, err
 	}
 	return components, nil
 }
 
-func (u *providerUpgrader) doUpgrade(upgradePlan *UpgradePlan) error {
-	log := logf.Log
+// getUpgradeCustomResourceDefinitions returns the CustomResourceDefinitions of the provider for the selected target version.
+func (u *providerUpgrader) getUpgradeCustomResourceDefinitions(ctx context.Context, provider UpgradeItem) ([]unstructured.Unstructured, error) {
+	configRepository, err := u.configClient.Providers().Get(provider.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	providerRepository, err := u.repositoryClientFactory(configRepository, u.configClient.Variables())
+	if err != nil {
+		return nil, err
+	}
+
+	return providerRepository.Components().CustomResourceDefinitions(ctx, provider.NextVersion)
+}
+
+func (u *providerUpgrader) doUpgrade(ctx context.Context, upgradePlan *UpgradePlan) (reterr error) {
+	log := u.log
 	log.Info("Performing upgrade...")
 
+	toUpgrade := []UpgradeItem{}
 	for _, upgradeItem := range upgradePlan.Providers {
 		// If there is not a specified next version, skip it (we are already up-to-date).
 		if upgradeItem.NextVersion == "" {
 			continue
 		}
+		toUpgrade = append(toUpgrade, upgradeItem)
+	}
 
-		log.Info("Upgrading", "Provider", upgradeItem.InstanceName(), "CurrentVersion", upgradeItem.Version, "TargetVersion", upgradeItem.NextVersion)
-
-		// Gets the provider components for the target version.
-		components, err := u.getUpgradeComponents(upgradeItem)
-		if err != nil {
+	// Checks the new CRDs of all the providers before starting, so the management group is not left partially upgraded
+	// when the CRDs of one of the providers can't read the existing objects.
+	for i := range toUpgrade {
+		if err := u.planCRDMigrations(ctx, &toUpgrade[i]); err != nil {
 			return err
 		}
+	}
+
+	u.progress.started("", "", len(toUpgrade))
+	defer func() {
+		u.progress.completed("", "", 0, 0, reterr)
+	}()
 
-		// Delete the provider, preserving CRD and namespace.
-		if err := u.providerComponents.Delete(DeleteOptions{
-			Provider:             upgradeItem.Provider,
-			ForceDeleteNamespace: false,
-			ForceDeleteCRD:       false,
-		}); err != nil {
+	for i, upgradeItem := range toUpgrade {
+		// Stops before upgrading the next provider if the operation has been cancelled, so no provider is left half upgraded.
+		if err := ctx.Err(); err != nil {
+			return errors.Wrap(err, "upgrade of the providers interrupted")
+		}
+		log.Info("Upgrading", "Provider", upgradeItem.InstanceName(), "CurrentVersion", upgradeItem.Version, "TargetVersion", upgradeItem.NextVersion)
+		u.progress.started(ProgressStepUpgradeProvider, upgradeItem.InstanceName(), 0)
+		if err := u.upgradeProvider(ctx, upgradeItem, upgradePlan.Contract); err != nil {
+			u.progress.completed(ProgressStepUpgradeProvider, upgradeItem.InstanceName(), i, len(toUpgrade), err)
 			return err
 		}
+		u.progress.completed(ProgressStepUpgradeProvider, upgradeItem.InstanceName(), i+1, len(toUpgrade), nil)
+	}
+	return nil
+}
+
+// upgradeProvider upgrades a provider to the next version defined in the upgrade item, supporting the given
+// API Version of Cluster API (contract).
+func (u *providerUpgrader) upgradeProvider(ctx context.Context, upgradeItem UpgradeItem, contract string) error {
+	start := time.Now()
+
+	// Gets the provider components for the target version.
+	components, err := u.getUpgradeComponents(ctx, upgradeItem)
+	if err != nil {
+		return err
+	}
 
-		// Install the new version of the provider components.
-		if err := installComponentsAndUpdateInventory(components, u.providerComponents, u.providerInventory); err != nil {
+	// Migrates the objects stored in API versions dropped by the new CRDs, otherwise the API server rejects the CRDs update.
+	migrator := newCRDMigrator(u.proxy)
+	migrator.log = u.log
+	migrations, err := migrator.plan(ctx, components.Objs())
+	if err != nil {
+		return err
+	}
+	if len(migrations) > 0 {
+		objects := 0
+		for _, migration := range migrations {
+			objects += migration.Objects
+		}
+		u.progress.started(ProgressStepMigrateCRDs, upgradeItem.InstanceName(), objects)
+		if err := migrator.run(ctx, migrations); err != nil {
+			u.progress.completed(ProgressStepMigrateCRDs, upgradeItem.InstanceName(), 0, objects, err)
 			return err
 		}
+		u.progress.completed(ProgressStepMigrateCRDs, upgradeItem.InstanceName(), objects, objects, nil)
+	}
+
+	// Delete the provider, preserving CRD and namespace.
+	if err := u.providerComponents.Delete(ctx, DeleteOptions{
+		Provider:             upgradeItem.Provider,
+		ForceDeleteNamespace: false,
+		ForceDeleteCRD:       false,
+	}); err != nil {
+		return err
+	}
+
+	// Install the new version of the provider components.
+	// NB. The inventory item is deleted together with the other provider components, so the previous
+	// provider instance is passed along in order to preserve its history.
+	if err := installComponentsAndUpdateInventory(ctx, components, contract, u.providerComponents, u.providerInventory, &upgradeItem.Provider); err != nil {
+		return err
 	}
+	metrics.ProviderUpgradeDuration.WithLabelValues(upgradeItem.Name, upgradeItem.Type).Observe(time.Since(start).Seconds())
 	return nil
 }
 
-func newProviderUpgrader(configClient config.Client, repositoryClientFactory RepositoryClientFactory, providerInventory InventoryClient, providerComponents ComponentsClient) *providerUpgrader {
+func newProviderUpgrader(configClient config.Client, repositoryClientFactory RepositoryClientFactory, proxy Proxy, providerInventory InventoryClient, providerComponents ComponentsClient, progressReporter ProgressReporter) *providerUpgrader {
 	return &providerUpgrader{
 		configClient:            configClient,
 		repositoryClientFactory: repositoryClientFactory,
+		proxy:                   proxy,
 		providerInventory:       providerInventory,
 		providerComponents:      providerComponents,
+		progress:                newProgress(progressReporter, ProgressOperationUpgrade),
+		log:                     logf.Log,
 	}
 }
@@ -17,6 +17,7 @@ limitations under the License.
 package cluster
 
 import (
+	"context"
 	"fmt"
 	"sort"
 
@@ -41,7 +42,7 @@ type upgradeInfo struct {
 }
 
 // getUpgradeInfo returns all the info required for taking upgrade decisions for a provider.
-func (u *providerUpgrader) getUpgradeInfo(provider clusterctlv1.Provider) (*upgradeInfo, error) {
+func (u *providerUpgrader) getUpgradeInfo(ctx context.Context, provider clusterctlv1.Provider) (*upgradeInfo, error) {
 	// Gets the list of versions available in the provider repository.
 	configRepository, err := u.configClient.Providers().Get(provider.Name)
 	if err != nil {
@@ -53,7 +54,7 @@ func (u *providerUpgrader) getUpgradeInfo(provider clusterctlv1.Provider) (*upgr
 		return nil, err
 	}
 
-	repositoryVersions, err := providerRepository.GetVersions()
+	repositoryVersions, err := providerRepository.GetVersions(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -74,7 +75,7 @@ func (u *providerUpgrader) getUpgradeInfo(provider clusterctlv1.Provider) (*upgr
 		}
 	}
 
-	latestMetadata, err := providerRepository.Metadata(versionTag(latestVersion)).Get()
+	latestMetadata, err := providerRepository.Metadata(versionTag(latestVersion)).Get(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -130,6 +131,22 @@ func newUpgradeInfo(metadata *clusterctlv1.Metadata, currentVersion *version.Ver
 	}
 }
 
+// filterNextVersions drops the next versions not allowed by the version policy of the management cluster.
+func (i *upgradeInfo) filterNextVersions(versionPolicy *clusterctlv1.VersionPolicy, provider string) error {
+	nextVersions := []version.Version{}
+	for j := range i.nextVersions {
+		allowed, err := versionPolicy.IsVersionAllowed(provider, &i.nextVersions[j])
+		if err != nil {
+			return err
+		}
+		if allowed {
+			nextVersions = append(nextVersions, i.nextVersions[j])
+		}
+	}
+	i.nextVersions = nextVersions
+	return nil
+}
+
 // getContractsForUpgrade return the list of API Version of Cluster API (contract) version available for a provider upgrade. e.g.
 // - If the current version of the provider support v1alpha3 contract (the latest), it returns v1alpha3
 // - If the current version of the provider support v1alpha3 contract but there is also the v1alpha4 contract available, it returns v1alpha3, v1alpha4
@@ -146,6 +163,15 @@ func (i *upgradeInfo) getContractsForUpgrade() []string {
 	return contractsForUpgrade.List()
 }
 
+// getCurrentContract returns the API Version of Cluster API (contract) supported by the current version of the provider.
+func (i *upgradeInfo) getCurrentContract() string {
+	releaseSeries := i.metadata.GetReleaseSeriesForVersion(i.currentVersion)
+	if releaseSeries == nil {
+		return ""
+	}
+	return releaseSeries.Contract
+}
+
 // getLatestNextVersion returns the next available version for a provider within the target API Version of Cluster API (contract).
 // the next available version is tha latest version available in the for the target contract version.
 func (i *upgradeInfo) getLatestNextVersion(contract string) *version.Version {
@@ -155,7 +155,7 @@ func Test_providerUpgrader_getUpgradeInfo(t *testing.T) {
 					return repository.New(provider, configVariablesClient, repository.InjectRepository(tt.fields.repository))
 				},
 			}
-			got, err := u.getUpgradeInfo(tt.args.provider)
+			got, err := u.getUpgradeInfo(ctx, tt.args.provider)
 			if (err != nil) != tt.wantErr {
 				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
 			}
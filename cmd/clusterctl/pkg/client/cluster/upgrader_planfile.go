@@ -0,0 +1,234 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
+)
+
+// UpgradePlanFileKind is the kind of the upgrade plan files.
+const UpgradePlanFileKind = "UpgradePlan"
+
+// UpgradePlanFile is a machine-readable upgrade plan for a management group, written by upgrade plan and
+// applied by upgrade apply; it records the state of the management group the plan is computed for, so
+// the upgrade can be reviewed before being applied, and applied exactly as reviewed.
+type UpgradePlanFile struct {
+	metav1.TypeMeta `json:",inline"`
+
+	// ManagementGroup is the name of the management group to upgrade, derived from the core provider.
+	ManagementGroup string `json:"managementGroup"`
+
+	// Contract is the API Version of Cluster API (contract) the management group is upgraded to.
+	Contract string `json:"contract"`
+
+	// Providers lists all the providers in the management group, including the ones already up to date.
+	Providers []UpgradePlanFileProvider `json:"providers"`
+}
+
+// UpgradePlanFileProvider defines the upgrade of a provider in an upgrade plan file.
+type UpgradePlanFileProvider struct {
+	Name             string `json:"name"`
+	Namespace        string `json:"namespace"`
+	Type             string `json:"type"`
+	WatchedNamespace string `json:"watchedNamespace,omitempty"`
+
+	// CurrentVersion and CurrentContract are the version of the provider installed in the management cluster
+	// when the plan was computed, and the API Version of Cluster API (contract) it supports.
+	CurrentVersion  string `json:"currentVersion"`
+	CurrentContract string `json:"currentContract,omitempty"`
+
+	// TargetVersion and TargetContract are the version the provider is upgraded to, and the API Version of
+	// Cluster API (contract) it supports; they are empty if the provider is already up to date.
+	TargetVersion  string `json:"targetVersion,omitempty"`
+	TargetContract string `json:"targetContract,omitempty"`
+
+	// Images are the images used by the components of the target version.
+	Images []string `json:"images,omitempty"`
+}
+
+// InstanceName returns the instance name of the provider.
+func (p *UpgradePlanFileProvider) InstanceName() string {
+	return types.NamespacedName{Namespace: p.Namespace, Name: p.Name}.String()
+}
+
+func (u *providerUpgrader) ExportPlan(ctx context.Context, coreProvider clusterctlv1.Provider, contract string) (*UpgradePlanFile, error) {
+	if err := u.checkInventoryScope(coreProvider); err != nil {
+		return nil, err
+	}
+
+	managementGroup, err := u.getManagementGroup(ctx, coreProvider)
+	if err != nil {
+		return nil, err
+	}
+
+	upgradePlan, err := u.getUpgradePlan(ctx, *managementGroup, contract)
+	if err != nil {
+		return nil, err
+	}
+
+	planFile := &UpgradePlanFile{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: clusterctlv1.GroupVersion.String(),
+			Kind:       UpgradePlanFileKind,
+		},
+		ManagementGroup: managementGroup.CoreProvider.InstanceName(),
+		Contract:        contract,
+		Providers:       []UpgradePlanFileProvider{},
+	}
+	for _, upgradeItem := range upgradePlan.Providers {
+		providerUpgradeInfo, err := u.getUpgradeInfo(ctx, upgradeItem.Provider)
+		if err != nil {
+			return nil, err
+		}
+
+		provider := UpgradePlanFileProvider{
+			Name:             upgradeItem.Name,
+			Namespace:        upgradeItem.Namespace,
+			Type:             upgradeItem.Type,
+			WatchedNamespace: upgradeItem.WatchedNamespace,
+			CurrentVersion:   upgradeItem.Version,
+			CurrentContract:  providerUpgradeInfo.getCurrentContract(),
+		}
+		if upgradeItem.NextVersion != "" {
+			// Gets the components of the target version, so the plan records the images which are going to be deployed.
+			components, err := u.getUpgradeComponents(ctx, upgradeItem)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to get the components of %s %s", upgradeItem.InstanceName(), upgradeItem.NextVersion)
+			}
+			provider.TargetVersion = upgradeItem.NextVersion
+			provider.TargetContract = contract
+			provider.Images = sortedImages(components.Images())
+		}
+		planFile.Providers = append(planFile.Providers, provider)
+	}
+
+	sort.Slice(planFile.Providers, func(i, j int) bool {
+		return planFile.Providers[i].Type < planFile.Providers[j].Type ||
+			(planFile.Providers[i].Type == planFile.Providers[j].Type && planFile.Providers[i].InstanceName() < planFile.Providers[j].InstanceName())
+	})
+	return planFile, nil
+}
+
+func (u *providerUpgrader) ApplyPlanFile(ctx context.Context, planFile UpgradePlanFile) error {
+	log := u.log
+	log.Info("Performing upgrade from the plan file...")
+
+	if planFile.APIVersion != clusterctlv1.GroupVersion.String() || planFile.Kind != UpgradePlanFileKind {
+		return errors.Errorf("invalid upgrade plan file: expected %s %s, got %s %s", clusterctlv1.GroupVersion.String(), UpgradePlanFileKind, planFile.APIVersion, planFile.Kind)
+	}
+
+	var coreProvider *clusterctlv1.Provider
+	for _, p := range planFile.Providers {
+		if p.Type == string(clusterctlv1.CoreProviderType) {
+			coreProvider = &clusterctlv1.Provider{ObjectMeta: metav1.ObjectMeta{Namespace: p.Namespace, Name: p.Name}}
+		}
+	}
+	if coreProvider == nil {
+		return errors.New("invalid upgrade plan file: the core provider is missing")
+	}
+
+	if err := u.checkInventoryScope(*coreProvider); err != nil {
+		return err
+	}
+
+	managementGroup, err := u.getManagementGroup(ctx, *coreProvider)
+	if err != nil {
+		return err
+	}
+
+	upgradePlan, err := u.getUpgradePlanFromFile(ctx, *managementGroup, planFile)
+	if err != nil {
+		return err
+	}
+
+	return u.doUpgrade(ctx, upgradePlan)
+}
+
+// getUpgradePlanFromFile returns the upgrade plan defined by an upgrade plan file; an error is returned if the
+// management group has drifted from the plan, e.g. a provider has been upgraded or the components of a target
+// version have been modified since the plan was computed.
+func (u *providerUpgrader) getUpgradePlanFromFile(ctx context.Context, managementGroup ManagementGroup, planFile UpgradePlanFile) (*UpgradePlan, error) {
+	planned := map[string]UpgradePlanFileProvider{}
+	for _, p := range planFile.Providers {
+		planned[p.InstanceName()] = p
+	}
+
+	drifts := []string{}
+	upgradeItems := []UpgradeItem{}
+	for _, provider := range managementGroup.Providers {
+		p, ok := planned[provider.InstanceName()]
+		if !ok {
+			drifts = append(drifts, fmt.Sprintf("%s is not in the plan", provider.InstanceName()))
+			continue
+		}
+		delete(planned, provider.InstanceName())
+
+		if provider.Type != p.Type || provider.Version != p.CurrentVersion {
+			drifts = append(drifts, fmt.Sprintf("%s is %s %s, the plan is for %s %s", provider.InstanceName(), provider.Type, provider.Version, p.Type, p.CurrentVersion))
+			continue
+		}
+
+		upgradeItem := UpgradeItem{
+			Provider:    provider,
+			NextVersion: p.TargetVersion,
+		}
+		if upgradeItem.NextVersion != "" {
+			components, err := u.getUpgradeComponents(ctx, upgradeItem)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to get the components of %s %s", upgradeItem.InstanceName(), upgradeItem.NextVersion)
+			}
+			if images := sortedImages(components.Images()); strings.Join(images, ",") != strings.Join(p.Images, ",") {
+				drifts = append(drifts, fmt.Sprintf("the components of %s %s use the images %v, the plan is for %v", provider.InstanceName(), p.TargetVersion, images, p.Images))
+			}
+		}
+		upgradeItems = append(upgradeItems, upgradeItem)
+	}
+
+	missing := []string{}
+	for instanceName := range planned {
+		missing = append(missing, instanceName)
+	}
+	sort.Strings(missing)
+	for _, instanceName := range missing {
+		drifts = append(drifts, fmt.Sprintf("%s is in the plan but not in the management group", instanceName))
+	}
+
+	if len(drifts) > 0 {
+		return nil, errors.Errorf("the %s management group has drifted from the upgrade plan, please plan the upgrade again: %s", managementGroup.CoreProvider.InstanceName(), strings.Join(drifts, "; "))
+	}
+
+	return &UpgradePlan{
+		Contract:     planFile.Contract,
+		CoreProvider: managementGroup.CoreProvider,
+		Providers:    upgradeItems,
+	}, nil
+}
+
+// sortedImages returns a sorted copy of a list of images.
+func sortedImages(images []string) []string {
+	ret := append([]string{}, images...)
+	sort.Strings(ret)
+	return ret
+}
@@ -0,0 +1,248 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/client/config"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/client/repository"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/internal/test"
+	logf "sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/log"
+)
+
+// managerComponentsYAML returns the components YAML of a provider, with a manager using the given image.
+func managerComponentsYAML(image string) []byte {
+	return []byte(fmt.Sprintf(`apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: manager
+spec:
+  template:
+    spec:
+      containers:
+      - name: manager
+        image: %s
+`, image))
+}
+
+// newUpgradePlanFileTestUpgrader returns a providerUpgrader for a management group with core v1.0.0, which can be
+// upgraded to v1.0.1 with the given image, and infra v2.0.0, which is already up to date.
+func newUpgradePlanFileTestUpgrader(coreImage string, proxy Proxy) *providerUpgrader {
+	reader := test.NewFakeReader().
+		WithProvider("core", clusterctlv1.CoreProviderType, "https://somewhere.com").
+		WithProvider("infra", clusterctlv1.InfrastructureProviderType, "https://somewhere.com")
+	repositories := map[string]repository.Repository{
+		"core": test.NewFakeRepository().
+			WithPaths("root", "components.yaml").
+			WithDefaultVersion("v1.0.1").
+			WithVersions("v1.0.0", "v1.0.1").
+			WithFile("v1.0.1", "components.yaml", managerComponentsYAML(coreImage)).
+			WithMetadata("v1.0.1", &clusterctlv1.Metadata{
+				ReleaseSeries: []clusterctlv1.ReleaseSeries{
+					{Major: 1, Minor: 0, Contract: "v1alpha3"},
+				},
+			}),
+		"infra": test.NewFakeRepository().
+			WithVersions("v2.0.0").
+			WithMetadata("v2.0.0", &clusterctlv1.Metadata{
+				ReleaseSeries: []clusterctlv1.ReleaseSeries{
+					{Major: 2, Minor: 0, Contract: "v1alpha3"},
+				},
+			}),
+	}
+	configClient, _ := config.New("", config.InjectReader(reader))
+
+	return &providerUpgrader{
+		configClient: configClient,
+		repositoryClientFactory: func(provider config.Provider, configVariablesClient config.VariablesClient, options ...repository.Option) (repository.Client, error) {
+			return repository.New(provider, configVariablesClient, repository.InjectRepository(repositories[provider.Name()]))
+		},
+		proxy:             proxy,
+		providerInventory: newInventoryClient(proxy, nil),
+		log:               logf.Log,
+	}
+}
+
+func Test_providerUpgrader_ExportPlan(t *testing.T) {
+	proxy := test.NewFakeProxy().
+		WithProviderInventory("core", clusterctlv1.CoreProviderType, "v1.0.0", "core-system", "").
+		WithProviderInventory("infra", clusterctlv1.InfrastructureProviderType, "v2.0.0", "infra-system", "")
+	u := newUpgradePlanFileTestUpgrader("registry.io/core:v1.0.1", proxy)
+
+	got, err := u.ExportPlan(ctx, fakeProvider("core", clusterctlv1.CoreProviderType, "v1.0.0", "core-system", ""), "v1alpha3")
+	if err != nil {
+		t.Fatalf("ExportPlan() error = %v", err)
+	}
+
+	want := &UpgradePlanFile{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: clusterctlv1.GroupVersion.String(),
+			Kind:       UpgradePlanFileKind,
+		},
+		ManagementGroup: "core-system/core",
+		Contract:        "v1alpha3",
+		Providers: []UpgradePlanFileProvider{
+			{
+				Name:            "core",
+				Namespace:       "core-system",
+				Type:            string(clusterctlv1.CoreProviderType),
+				CurrentVersion:  "v1.0.0",
+				CurrentContract: "v1alpha3",
+				TargetVersion:   "v1.0.1",
+				TargetContract:  "v1alpha3",
+				Images:          []string{"registry.io/core:v1.0.1"},
+			},
+			{
+				Name:            "infra",
+				Namespace:       "infra-system",
+				Type:            string(clusterctlv1.InfrastructureProviderType),
+				CurrentVersion:  "v2.0.0",
+				CurrentContract: "v1alpha3",
+			},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ExportPlan() = %v, want %v", got, want)
+	}
+}
+
+func Test_providerUpgrader_getUpgradePlanFromFile(t *testing.T) {
+	planFile := UpgradePlanFile{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: clusterctlv1.GroupVersion.String(),
+			Kind:       UpgradePlanFileKind,
+		},
+		ManagementGroup: "core-system/core",
+		Contract:        "v1alpha3",
+		Providers: []UpgradePlanFileProvider{
+			{Name: "core", Namespace: "core-system", Type: string(clusterctlv1.CoreProviderType), CurrentVersion: "v1.0.0", TargetVersion: "v1.0.1", Images: []string{"registry.io/core:v1.0.1"}},
+			{Name: "infra", Namespace: "infra-system", Type: string(clusterctlv1.InfrastructureProviderType), CurrentVersion: "v2.0.0"},
+		},
+	}
+
+	tests := []struct {
+		name      string
+		coreImage string
+		proxy     Proxy
+		want      *UpgradePlan
+		wantErr   string
+	}{
+		{
+			name:      "the management group is in the state the plan was computed for",
+			coreImage: "registry.io/core:v1.0.1",
+			proxy: test.NewFakeProxy().
+				WithProviderInventory("core", clusterctlv1.CoreProviderType, "v1.0.0", "core-system", "").
+				WithProviderInventory("infra", clusterctlv1.InfrastructureProviderType, "v2.0.0", "infra-system", ""),
+			want: &UpgradePlan{
+				Contract:     "v1alpha3",
+				CoreProvider: fakeProvider("core", clusterctlv1.CoreProviderType, "v1.0.0", "core-system", ""),
+				Providers: []UpgradeItem{
+					{
+						Provider:    fakeProvider("core", clusterctlv1.CoreProviderType, "v1.0.0", "core-system", ""),
+						NextVersion: "v1.0.1",
+					},
+					{
+						Provider: fakeProvider("infra", clusterctlv1.InfrastructureProviderType, "v2.0.0", "infra-system", ""),
+					},
+				},
+			},
+		},
+		{
+			name:      "a provider has been upgraded since the plan was computed",
+			coreImage: "registry.io/core:v1.0.1",
+			proxy: test.NewFakeProxy().
+				WithProviderInventory("core", clusterctlv1.CoreProviderType, "v1.0.1", "core-system", "").
+				WithProviderInventory("infra", clusterctlv1.InfrastructureProviderType, "v2.0.0", "infra-system", ""),
+			wantErr: "core-system/core is CoreProvider v1.0.1, the plan is for CoreProvider v1.0.0",
+		},
+		{
+			name:      "the images of a target version have changed since the plan was computed",
+			coreImage: "registry.io/core:v1.0.1-patched",
+			proxy: test.NewFakeProxy().
+				WithProviderInventory("core", clusterctlv1.CoreProviderType, "v1.0.0", "core-system", "").
+				WithProviderInventory("infra", clusterctlv1.InfrastructureProviderType, "v2.0.0", "infra-system", ""),
+			wantErr: "the components of core-system/core v1.0.1 use the images [registry.io/core:v1.0.1-patched]",
+		},
+		{
+			name:      "a provider has been added since the plan was computed",
+			coreImage: "registry.io/core:v1.0.1",
+			proxy: test.NewFakeProxy().
+				WithProviderInventory("core", clusterctlv1.CoreProviderType, "v1.0.0", "core-system", "").
+				WithProviderInventory("infra", clusterctlv1.InfrastructureProviderType, "v2.0.0", "infra-system", "").
+				WithProviderInventory("bootstrap", clusterctlv1.BootstrapProviderType, "v3.0.0", "bootstrap-system", ""),
+			wantErr: "bootstrap-system/bootstrap is not in the plan",
+		},
+		{
+			name:      "a provider has been deleted since the plan was computed",
+			coreImage: "registry.io/core:v1.0.1",
+			proxy: test.NewFakeProxy().
+				WithProviderInventory("core", clusterctlv1.CoreProviderType, "v1.0.0", "core-system", ""),
+			wantErr: "infra-system/infra is in the plan but not in the management group",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u := newUpgradePlanFileTestUpgrader(tt.coreImage, tt.proxy)
+
+			managementGroup, err := u.getManagementGroup(ctx, fakeProvider("core", clusterctlv1.CoreProviderType, "v1.0.0", "core-system", ""))
+			if err != nil {
+				t.Fatalf("getManagementGroup() error = %v", err)
+			}
+
+			got, err := u.getUpgradePlanFromFile(ctx, *managementGroup, planFile)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want an error containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_providerUpgrader_ApplyPlanFile_InvalidFile(t *testing.T) {
+	proxy := test.NewFakeProxy().
+		WithProviderInventory("core", clusterctlv1.CoreProviderType, "v1.0.0", "core-system", "")
+	u := newUpgradePlanFileTestUpgrader("registry.io/core:v1.0.1", proxy)
+
+	if err := u.ApplyPlanFile(ctx, UpgradePlanFile{TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"}}); err == nil {
+		t.Error("ApplyPlanFile() succeeded, want an error for a file which is not an upgrade plan")
+	}
+
+	planFile := UpgradePlanFile{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: clusterctlv1.GroupVersion.String(),
+			Kind:       UpgradePlanFileKind,
+		},
+		Contract: "v1alpha3",
+	}
+	if err := u.ApplyPlanFile(ctx, planFile); err == nil {
+		t.Error("ApplyPlanFile() succeeded, want an error for a plan without core provider")
+	}
+}
@@ -85,6 +85,57 @@ func Test_providerUpgrader_Plan(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "Single Management group, no multi-tenancy, upgrade within the versions allowed by the version policy",
+			fields: fields{
+				// config for two providers
+				reader: test.NewFakeReader().
+					WithProvider("core", clusterctlv1.CoreProviderType, "https://somewhere.com").
+					WithProvider("infra", clusterctlv1.InfrastructureProviderType, "https://somewhere.com"),
+				// two provider repositories, each with new versions in the v1alpha3 contract
+				repository: map[string]repository.Repository{
+					"core": test.NewFakeRepository().
+						WithVersions("v1.0.0", "v1.0.1", "v1.0.2").
+						WithMetadata("v1.0.2", &clusterctlv1.Metadata{
+							ReleaseSeries: []clusterctlv1.ReleaseSeries{
+								{Major: 1, Minor: 0, Contract: "v1alpha3"},
+							},
+						}),
+					"infra": test.NewFakeRepository().
+						WithVersions("v2.0.0", "v2.0.1-beta.0").
+						WithMetadata("v2.0.1-beta.0", &clusterctlv1.Metadata{
+							ReleaseSeries: []clusterctlv1.ReleaseSeries{
+								{Major: 2, Minor: 0, Contract: "v1alpha3"},
+							},
+						}),
+				},
+				// two providers existing in the cluster, with a version policy pinning core to v1.0.1 and allowing pre-releases for infra
+				proxy: test.NewFakeProxy().
+					WithProviderInventory("core", clusterctlv1.CoreProviderType, "v1.0.0", "core-system", "").
+					WithProviderInventory("infra", clusterctlv1.InfrastructureProviderType, "v2.0.0", "infra-system", "").
+					WithVersionPolicy("policy",
+						clusterctlv1.ProviderVersionRange{Name: "core", MaxVersion: "v1.0.1"},
+						clusterctlv1.ProviderVersionRange{Name: "infra", AllowPreReleases: true},
+					),
+			},
+			want: []UpgradePlan{
+				{ // one upgrade plan with the latest releases allowed by the version policy
+					Contract:     "v1alpha3",
+					CoreProvider: fakeProvider("core", clusterctlv1.CoreProviderType, "v1.0.0", "core-system", ""),
+					Providers: []UpgradeItem{
+						{
+							Provider:    fakeProvider("core", clusterctlv1.CoreProviderType, "v1.0.0", "core-system", ""),
+							NextVersion: "v1.0.1",
+						},
+						{
+							Provider:    fakeProvider("infra", clusterctlv1.InfrastructureProviderType, "v2.0.0", "infra-system", ""),
+							NextVersion: "v2.0.1-beta.0",
+						},
+					},
+				},
+			},
+			wantErr: false,
+		},
 		{
 			name: "Single Management group, no multi-tenancy, upgrade for two contacts",
 			fields: fields{
@@ -438,9 +489,10 @@ func Test_providerUpgrader_Plan(t *testing.T) {
 				repositoryClientFactory: func(provider config.Provider, configVariablesClient config.VariablesClient, options ...repository.Option) (repository.Client, error) {
 					return repository.New(provider, configVariablesClient, repository.InjectRepository(tt.fields.repository[provider.Name()]))
 				},
+				proxy:             tt.fields.proxy,
 				providerInventory: newInventoryClient(tt.fields.proxy, nil),
 			}
-			got, err := u.Plan()
+			got, err := u.Plan(ctx)
 			if (err != nil) != tt.wantErr {
 				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
 			}
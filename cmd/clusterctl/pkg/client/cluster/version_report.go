@@ -0,0 +1,231 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"context"
+	"sort"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/version"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ClusterVersions reports the Kubernetes versions of a workload cluster and the providers responsible for it.
+type ClusterVersions struct {
+	// Namespace and Name of the Cluster.
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+
+	// ControlPlaneVersion is the Kubernetes version of the control plane; it is empty if the version can't be
+	// determined, e.g. because the control plane machines are not yet created.
+	ControlPlaneVersion string `json:"controlPlaneVersion,omitempty"`
+
+	// MinWorkerVersion and MaxWorkerVersion are the range of Kubernetes versions of the worker machines;
+	// they are empty if the cluster has no worker machines.
+	MinWorkerVersion string `json:"minWorkerVersion,omitempty"`
+	MaxWorkerVersion string `json:"maxWorkerVersion,omitempty"`
+
+	// Providers lists the provider instances responsible for the cluster, that are the core provider and the
+	// providers of the infrastructure, control plane and bootstrap objects, watching the cluster namespace.
+	Providers []clusterctlv1.Provider `json:"providers"`
+}
+
+// VersionReporter has methods to report the versions of the workload clusters managed by a management cluster.
+type VersionReporter interface {
+	// Report returns the versions of all the workload clusters in a namespace, or in all the namespaces if empty.
+	Report(ctx context.Context, namespace string) ([]ClusterVersions, error)
+}
+
+// versionReporter implements VersionReporter.
+type versionReporter struct {
+	proxy             Proxy
+	providerInventory InventoryClient
+}
+
+// ensure versionReporter implements VersionReporter.
+var _ VersionReporter = &versionReporter{}
+
+func newVersionReporter(proxy Proxy, providerInventory InventoryClient) *versionReporter {
+	return &versionReporter{
+		proxy:             proxy,
+		providerInventory: providerInventory,
+	}
+}
+
+func (r *versionReporter) Report(ctx context.Context, namespace string) ([]ClusterVersions, error) {
+	c, err := r.proxy.NewClient()
+	if err != nil {
+		return nil, err
+	}
+
+	providerList, err := r.providerInventory.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Gets the provider owning each API group, by looking at the CRDs installed by clusterctl.
+	crdList := &apiextensionsv1.CustomResourceDefinitionList{}
+	if err := c.List(ctx, crdList, client.MatchingLabels{clusterctlv1.ClusterctlLabelName: ""}); err != nil {
+		return nil, errors.Wrap(err, "failed to get the list of CRDs installed by clusterctl")
+	}
+	providerByGroup := map[string]string{}
+	for _, crd := range crdList.Items {
+		if name, ok := crd.Labels[clusterv1.ProviderLabelName]; ok {
+			providerByGroup[crd.Spec.Group] = name
+		}
+	}
+
+	clusterList := &clusterv1.ClusterList{}
+	if err := c.List(ctx, clusterList, client.InNamespace(namespace)); err != nil {
+		return nil, errors.Wrap(err, "failed to list Clusters")
+	}
+
+	machineList := &clusterv1.MachineList{}
+	if err := c.List(ctx, machineList, client.InNamespace(namespace)); err != nil {
+		return nil, errors.Wrap(err, "failed to list Machines")
+	}
+
+	ret := []ClusterVersions{}
+	for i := range clusterList.Items {
+		cluster := &clusterList.Items[i]
+
+		ns := &corev1.Namespace{}
+		if err := c.Get(ctx, client.ObjectKey{Name: cluster.Namespace}, ns); err != nil {
+			return nil, errors.Wrapf(err, "failed to get Namespace %s", cluster.Namespace)
+		}
+
+		var machines []clusterv1.Machine
+		for _, m := range machineList.Items {
+			if m.Namespace == cluster.Namespace && m.Spec.ClusterName == cluster.Name {
+				machines = append(machines, m)
+			}
+		}
+
+		versions, err := reportClusterVersions(ctx, c, cluster, machines)
+		if err != nil {
+			return nil, err
+		}
+
+		// Gets the provider instances responsible for the cluster; the core provider is always responsible, while other
+		// providers are responsible only if they own the objects referenced by the cluster or by its machines.
+		names := map[string]bool{}
+		refs := []*corev1.ObjectReference{cluster.Spec.InfrastructureRef, cluster.Spec.ControlPlaneRef}
+		for j := range machines {
+			refs = append(refs, machines[j].Spec.Bootstrap.ConfigRef)
+		}
+		for _, ref := range refs {
+			if ref == nil {
+				continue
+			}
+			gv, err := schema.ParseGroupVersion(ref.APIVersion)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to parse the API version of %s %s/%s", ref.Kind, ref.Namespace, ref.Name)
+			}
+			if name, ok := providerByGroup[gv.Group]; ok {
+				names[name] = true
+			}
+		}
+		for _, p := range providerList.Items {
+			if p.GetProviderType() != clusterctlv1.CoreProviderType && !names[p.Name] {
+				continue
+			}
+			if !p.WatchesNamespace(*ns) {
+				continue
+			}
+			versions.Providers = append(versions.Providers, p)
+		}
+
+		ret = append(ret, *versions)
+	}
+
+	sort.Slice(ret, func(i, j int) bool {
+		if ret[i].Namespace != ret[j].Namespace {
+			return ret[i].Namespace < ret[j].Namespace
+		}
+		return ret[i].Name < ret[j].Name
+	})
+	return ret, nil
+}
+
+// reportClusterVersions computes the Kubernetes versions of a cluster, given its machines.
+func reportClusterVersions(ctx context.Context, c client.Client, cluster *clusterv1.Cluster, machines []clusterv1.Machine) (*ClusterVersions, error) {
+	versions := &ClusterVersions{
+		Namespace: cluster.Namespace,
+		Name:      cluster.Name,
+	}
+
+	// The control plane version is read from the control plane object, if any; otherwise it is derived by the
+	// control plane machines.
+	if ref := cluster.Spec.ControlPlaneRef; ref != nil {
+		controlPlane := &unstructured.Unstructured{}
+		controlPlane.SetAPIVersion(ref.APIVersion)
+		controlPlane.SetKind(ref.Kind)
+		controlPlaneKey := client.ObjectKey{Namespace: cluster.Namespace, Name: ref.Name}
+		if err := c.Get(ctx, controlPlaneKey, controlPlane); err != nil {
+			return nil, errors.Wrapf(err, "failed to get %s %s/%s", ref.Kind, cluster.Namespace, ref.Name)
+		}
+		v, _, err := unstructured.NestedString(controlPlane.Object, "spec", "version")
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read spec.version from %s %s/%s", ref.Kind, cluster.Namespace, ref.Name)
+		}
+		versions.ControlPlaneVersion = v
+	}
+
+	var controlPlaneVersions, workerVersions []string
+	for _, m := range machines {
+		if m.Spec.Version == nil || *m.Spec.Version == "" {
+			continue
+		}
+		if _, ok := m.Labels[clusterv1.MachineControlPlaneLabelName]; ok {
+			controlPlaneVersions = append(controlPlaneVersions, *m.Spec.Version)
+			continue
+		}
+		workerVersions = append(workerVersions, *m.Spec.Version)
+	}
+
+	if versions.ControlPlaneVersion == "" {
+		_, versions.ControlPlaneVersion = versionRange(controlPlaneVersions)
+	}
+	versions.MinWorkerVersion, versions.MaxWorkerVersion = versionRange(workerVersions)
+
+	return versions, nil
+}
+
+// versionRange returns the min and the max of a list of Kubernetes versions; versions that are not
+// semantic versions are compared as strings.
+func versionRange(versions []string) (string, string) {
+	if len(versions) == 0 {
+		return "", ""
+	}
+	sorted := append([]string{}, versions...)
+	sort.Slice(sorted, func(i, j int) bool {
+		vi, erri := version.ParseSemantic(sorted[i])
+		vj, errj := version.ParseSemantic(sorted[j])
+		if erri != nil || errj != nil {
+			return sorted[i] < sorted[j]
+		}
+		return vi.LessThan(vj)
+	})
+	return sorted[0], sorted[len(sorted)-1]
+}
@@ -0,0 +1,166 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/pointer"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/internal/test"
+)
+
+func Test_versionReporter_Report(t *testing.T) {
+	namespace := func(name string) *corev1.Namespace {
+		return &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name}}
+	}
+
+	cluster := func(namespace, name string) *clusterv1.Cluster {
+		return &clusterv1.Cluster{
+			ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+			Spec: clusterv1.ClusterSpec{
+				InfrastructureRef: &corev1.ObjectReference{
+					APIVersion: "infrastructure.cluster.x-k8s.io/v1alpha3",
+					Kind:       "InfrastructureCluster",
+					Name:       name,
+				},
+			},
+		}
+	}
+
+	machine := func(namespace, name, clusterName, version string, controlPlane bool) *clusterv1.Machine {
+		m := &clusterv1.Machine{
+			ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name, Labels: map[string]string{}},
+			Spec: clusterv1.MachineSpec{
+				ClusterName: clusterName,
+				Version:     pointer.StringPtr(version),
+			},
+		}
+		if controlPlane {
+			m.Labels[clusterv1.MachineControlPlaneLabelName] = ""
+		}
+		return m
+	}
+
+	// The infrastructure provider infra-foo owns the infrastructure.cluster.x-k8s.io API group.
+	infrastructureCRD := test.FakeCustomResourceDefinition("infrastructure.cluster.x-k8s.io", "InfrastructureCluster", "v1alpha3")
+	infrastructureCRD.Labels[clusterv1.ProviderLabelName] = "infrastructure-foo"
+
+	proxy := func() Proxy {
+		return test.NewFakeProxy().
+			WithProviderInventory("cluster-api", clusterctlv1.CoreProviderType, "v1.0.0", "capi-system", "").
+			WithProviderInventory("infrastructure-foo", clusterctlv1.InfrastructureProviderType, "v2.0.0", "foo-system", "").
+			WithProviderInventory("infrastructure-bar", clusterctlv1.InfrastructureProviderType, "v3.0.0", "bar-system", "").
+			WithObjs([]runtime.Object{
+				infrastructureCRD,
+				namespace("ns1"),
+				namespace("ns2"),
+				cluster("ns1", "cluster1"),
+				machine("ns1", "cp1", "cluster1", "v1.17.3", true),
+				machine("ns1", "m1", "cluster1", "v1.16.10", false),
+				machine("ns1", "m2", "cluster1", "v1.16.2", false),
+				cluster("ns2", "cluster2"),
+				machine("ns2", "cp2", "cluster2", "v1.18.0", true),
+			}...)
+	}
+
+	tests := []struct {
+		name      string
+		namespace string
+		want      []ClusterVersions
+		wantErr   bool
+	}{
+		{
+			name:      "report the clusters in a namespace",
+			namespace: "ns1",
+			want: []ClusterVersions{
+				{
+					Namespace:           "ns1",
+					Name:                "cluster1",
+					ControlPlaneVersion: "v1.17.3",
+					MinWorkerVersion:    "v1.16.2",
+					MaxWorkerVersion:    "v1.16.10",
+					Providers:           []clusterctlv1.Provider{{Name: "cluster-api"}, {Name: "infrastructure-foo"}},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name:      "report the clusters in all the namespaces",
+			namespace: "",
+			want: []ClusterVersions{
+				{
+					Namespace:           "ns1",
+					Name:                "cluster1",
+					ControlPlaneVersion: "v1.17.3",
+					MinWorkerVersion:    "v1.16.2",
+					MaxWorkerVersion:    "v1.16.10",
+					Providers:           []clusterctlv1.Provider{{Name: "cluster-api"}, {Name: "infrastructure-foo"}},
+				},
+				{
+					Namespace:           "ns2",
+					Name:                "cluster2",
+					ControlPlaneVersion: "v1.18.0",
+					Providers:           []clusterctlv1.Provider{{Name: "cluster-api"}, {Name: "infrastructure-foo"}},
+				},
+			},
+			wantErr: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := proxy()
+			r := newVersionReporter(p, newInventoryClient(p, fakeObjectWaiter))
+
+			got, err := r.Report(ctx, tt.namespace)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d clusters, want %d", len(got), len(tt.want))
+			}
+			for i := range got {
+				g, w := got[i], tt.want[i]
+				if g.Namespace != w.Namespace || g.Name != w.Name {
+					t.Errorf("got cluster %s/%s, want %s/%s", g.Namespace, g.Name, w.Namespace, w.Name)
+				}
+				if g.ControlPlaneVersion != w.ControlPlaneVersion {
+					t.Errorf("got ControlPlaneVersion %q, want %q", g.ControlPlaneVersion, w.ControlPlaneVersion)
+				}
+				if g.MinWorkerVersion != w.MinWorkerVersion || g.MaxWorkerVersion != w.MaxWorkerVersion {
+					t.Errorf("got worker versions %q - %q, want %q - %q", g.MinWorkerVersion, g.MaxWorkerVersion, w.MinWorkerVersion, w.MaxWorkerVersion)
+				}
+				if len(g.Providers) != len(w.Providers) {
+					t.Fatalf("got %d providers, want %d", len(g.Providers), len(w.Providers))
+				}
+				for j := range g.Providers {
+					if g.Providers[j].Name != w.Providers[j].Name {
+						t.Errorf("got provider %q, want %q", g.Providers[j].Name, w.Providers[j].Name)
+					}
+				}
+			}
+		})
+	}
+}
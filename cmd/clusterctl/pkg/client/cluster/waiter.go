@@ -0,0 +1,161 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/restmapper"
+	"k8s.io/client-go/tools/cache"
+	watchtools "k8s.io/client-go/tools/watch"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ObjectConditionFunc returns true if an object satisfies the condition being waited for; obj is nil
+// if the object does not exist.
+type ObjectConditionFunc func(obj *unstructured.Unstructured) (bool, error)
+
+// ObjectWaiter waits until the object with the given GroupVersionKind and key satisfies a condition,
+// an error occurs, the timeout is reached or the context is cancelled.
+type ObjectWaiter func(ctx context.Context, gvk schema.GroupVersionKind, key client.ObjectKey, timeout time.Duration, condition ObjectConditionFunc) error
+
+// newWatchObjectWaiter returns an ObjectWaiter that watches the object instead of polling it, so changes are
+// detected as soon as they happen without querying the API server at fixed intervals.
+func newWatchObjectWaiter(proxy Proxy) ObjectWaiter {
+	return func(ctx context.Context, gvk schema.GroupVersionKind, key client.ObjectKey, timeout time.Duration, condition ObjectConditionFunc) error {
+		resourceClient, err := resourceClientFor(proxy, gvk, key.Namespace)
+		if err != nil {
+			return err
+		}
+
+		// Restrict the list and the watch to the object we are waiting for.
+		fieldSelector := fields.OneTermEqualSelector("metadata.name", key.Name).String()
+		lw := &cache.ListWatch{
+			ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+				options.FieldSelector = fieldSelector
+				return resourceClient.List(options)
+			},
+			WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+				options.FieldSelector = fieldSelector
+				return resourceClient.Watch(options)
+			},
+		}
+
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+
+		_, err = watchtools.UntilWithSync(ctx, lw, &unstructured.Unstructured{},
+			// Check the current state of the object before waiting for changes.
+			func(store cache.Store) (bool, error) {
+				items := store.List()
+				if len(items) == 0 {
+					return condition(nil)
+				}
+				obj, ok := items[0].(*unstructured.Unstructured)
+				if !ok {
+					return false, errors.Errorf("unexpected object type %T", items[0])
+				}
+				return condition(obj)
+			},
+			func(event watch.Event) (bool, error) {
+				switch event.Type {
+				case watch.Deleted:
+					return condition(nil)
+				case watch.Added, watch.Modified:
+					obj, ok := event.Object.(*unstructured.Unstructured)
+					if !ok {
+						return false, errors.Errorf("unexpected object type %T", event.Object)
+					}
+					return condition(obj)
+				}
+				return false, nil
+			},
+		)
+		if err == wait.ErrWaitTimeout {
+			// The wait is interrupted either by the timeout or by the cancellation of the parent context.
+			if ctx.Err() == context.Canceled {
+				return errors.Wrapf(ctx.Err(), "interrupted while waiting for %s %s", gvk.Kind, key)
+			}
+			return errors.Errorf("timed out after %s waiting for %s %s", timeout, gvk.Kind, key)
+		}
+		return err
+	}
+}
+
+// resourceClientFor returns a dynamic client for the resource corresponding to the given GroupVersionKind.
+func resourceClientFor(proxy Proxy, gvk schema.GroupVersionKind, namespace string) (dynamic.ResourceInterface, error) {
+	cs, err := proxy.NewClientSet()
+	if err != nil {
+		return nil, err
+	}
+
+	groupResources, err := restmapper.GetAPIGroupResources(cs.Discovery())
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get api group resources")
+	}
+
+	mapping, err := restmapper.NewDiscoveryRESTMapper(groupResources).RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get the resource for the %q GroupVersionKind", gvk)
+	}
+
+	dc, err := proxy.NewDynamicClient()
+	if err != nil {
+		return nil, err
+	}
+
+	if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+		return dc.Resource(mapping.Resource).Namespace(namespace), nil
+	}
+	return dc.Resource(mapping.Resource), nil
+}
+
+// crdEstablished is an ObjectConditionFunc returning true when a CustomResourceDefinition is Established.
+func crdEstablished(obj *unstructured.Unstructured) (bool, error) {
+	if obj == nil {
+		return false, nil
+	}
+
+	crd := &apiextensionsv1.CustomResourceDefinition{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, crd); err != nil {
+		return false, errors.Wrapf(err, "failed to convert %q to a CustomResourceDefinition", obj.GetName())
+	}
+
+	for _, c := range crd.Status.Conditions {
+		if c.Type == apiextensionsv1.Established && c.Status == apiextensionsv1.ConditionTrue {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// objectDeleted is an ObjectConditionFunc returning true when an object does not exist anymore.
+func objectDeleted(obj *unstructured.Unstructured) (bool, error) {
+	return obj == nil, nil
+}
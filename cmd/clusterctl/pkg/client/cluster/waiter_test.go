@@ -0,0 +1,97 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"testing"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func Test_crdEstablished(t *testing.T) {
+	tests := []struct {
+		name       string
+		conditions []apiextensionsv1.CustomResourceDefinitionCondition
+		noObject   bool
+		want       bool
+	}{
+		{
+			name:     "Return false if the CRD does not exist",
+			noObject: true,
+			want:     false,
+		},
+		{
+			name:       "Return false if the CRD has no conditions",
+			conditions: nil,
+			want:       false,
+		},
+		{
+			name: "Return false if the CRD is not established",
+			conditions: []apiextensionsv1.CustomResourceDefinitionCondition{
+				{Type: apiextensionsv1.NamesAccepted, Status: apiextensionsv1.ConditionTrue},
+				{Type: apiextensionsv1.Established, Status: apiextensionsv1.ConditionFalse},
+			},
+			want: false,
+		},
+		{
+			name: "Return true if the CRD is established",
+			conditions: []apiextensionsv1.CustomResourceDefinitionCondition{
+				{Type: apiextensionsv1.NamesAccepted, Status: apiextensionsv1.ConditionTrue},
+				{Type: apiextensionsv1.Established, Status: apiextensionsv1.ConditionTrue},
+			},
+			want: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var obj *unstructured.Unstructured
+			if !tt.noObject {
+				crd := &apiextensionsv1.CustomResourceDefinition{}
+				crd.SetName("foos.bar.io")
+				crd.Status.Conditions = tt.conditions
+
+				u, err := runtime.DefaultUnstructuredConverter.ToUnstructured(crd)
+				if err != nil {
+					t.Fatalf("ToUnstructured() error = %v", err)
+				}
+				obj = &unstructured.Unstructured{Object: u}
+			}
+
+			got, err := crdEstablished(obj)
+			if err != nil {
+				t.Fatalf("crdEstablished() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("crdEstablished() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_objectDeleted(t *testing.T) {
+	got, err := objectDeleted(nil)
+	if err != nil || !got {
+		t.Errorf("objectDeleted(nil) = %v, %v, want true, nil", got, err)
+	}
+
+	got, err = objectDeleted(&unstructured.Unstructured{})
+	if err != nil || got {
+		t.Errorf("objectDeleted(obj) = %v, %v, want false, nil", got, err)
+	}
+}
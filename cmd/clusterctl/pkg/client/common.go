@@ -17,6 +17,7 @@ limitations under the License.
 package client
 
 import (
+	"context"
 	"strings"
 
 	"github.com/pkg/errors"
@@ -25,7 +26,7 @@ import (
 )
 
 // getComponentsByName is a utility method that returns components for a given provider, targetNamespace, and watchingNamespace.
-func (c *clusterctlClient) getComponentsByName(provider string, targetNamespace string, watchingNamespace string) (repository.Components, error) {
+func (c *clusterctlClient) getComponentsByName(ctx context.Context, provider string, targetNamespace string, watchingNamespace string) (repository.Components, error) {
 
 	// parse the abbreviated syntax for name[:version]
 	name, version, err := parseProviderName(provider)
@@ -48,7 +49,7 @@ func (c *clusterctlClient) getComponentsByName(provider string, targetNamespace
 		return nil, err
 	}
 
-	components, err := repository.Components().Get(version, targetNamespace, watchingNamespace)
+	components, err := repository.Components().Get(ctx, version, targetNamespace, watchingNamespace)
 	if err != nil {
 		return nil, err
 	}
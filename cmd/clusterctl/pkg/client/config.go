@@ -17,15 +17,20 @@ limitations under the License.
 package client
 
 import (
+	"context"
+	"fmt"
 	"strconv"
 
 	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/util/version"
 	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/client/cluster"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/client/config"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/internal/util"
 )
 
-func (c *clusterctlClient) GetProvidersConfig() ([]Provider, error) {
+func (c *clusterctlClient) GetProvidersConfig(ctx context.Context) ([]Provider, error) {
 	r, err := c.configClient.Providers().List()
 	if err != nil {
 		return nil, err
@@ -40,8 +45,8 @@ func (c *clusterctlClient) GetProvidersConfig() ([]Provider, error) {
 	return rr, nil
 }
 
-func (c *clusterctlClient) GetProviderComponents(provider, targetNameSpace, watchingNamespace string) (Components, error) {
-	components, err := c.getComponentsByName(provider, targetNameSpace, watchingNamespace)
+func (c *clusterctlClient) GetProviderComponents(ctx context.Context, provider, targetNameSpace, watchingNamespace string) (Components, error) {
+	components, err := c.getComponentsByName(ctx, provider, targetNameSpace, watchingNamespace)
 	if err != nil {
 		return nil, err
 	}
@@ -85,6 +90,17 @@ type GetClusterTemplateOptions struct {
 	// listVariablesOnly sets the GetClusterTemplate method to return the list of variables expected by the template
 	// without executing any further processing.
 	ListVariablesOnly bool
+
+	// Validate sets the GetClusterTemplate method to validate the workload cluster template objects against the
+	// schemas of the CustomResourceDefinitions of the cluster-api, kubeadm-bootstrap and kubeadm-control-plane
+	// providers, of the infrastructure provider the template is read from and of the ValidateWithProviders.
+	// The CustomResourceDefinitions are read from the provider repositories, not from the management cluster.
+	Validate bool
+
+	// ValidateWithProviders lists additional providers, in the form name[:version], whose CustomResourceDefinitions
+	// are used for validating the workload cluster template objects, e.g. the infrastructure provider of a template
+	// read from an URL. A version set here takes precedence over the default version of the provider.
+	ValidateWithProviders []string
 }
 
 // numSources return the number of template sources currently set on a GetClusterTemplateOptions.
@@ -136,7 +152,7 @@ type ConfigMapSourceOptions struct {
 	DataKey string
 }
 
-func (c *clusterctlClient) GetClusterTemplate(options GetClusterTemplateOptions) (Template, error) {
+func (c *clusterctlClient) GetClusterTemplate(ctx context.Context, options GetClusterTemplateOptions) (Template, error) {
 	// Checks that no more than on source is set
 	numsSource := options.numSources()
 	if numsSource > 1 {
@@ -150,7 +166,7 @@ func (c *clusterctlClient) GetClusterTemplate(options GetClusterTemplateOptions)
 	}
 
 	// Gets  the client for the current management cluster
-	cluster, err := c.clusterClientFactory(options.Kubeconfig)
+	cluster, err := c.clusterClientFactory(options.Kubeconfig, "")
 	if err != nil {
 		return nil, err
 	}
@@ -169,37 +185,135 @@ func (c *clusterctlClient) GetClusterTemplate(options GetClusterTemplateOptions)
 		return nil, err
 	}
 
+	// Inject the default variables for the target namespace, if any, into the configClient; they are used only for
+	// the variables not defined by the templateOptions, the os env variables or the clusterctl config file.
+	if err := c.namespaceVariablesToVariables(ctx, cluster, options.TargetNamespace); err != nil {
+		return nil, err
+	}
+
 	// Gets the workload cluster template from the selected source
-	if options.ProviderRepositorySource != nil {
-		return c.getTemplateFromRepository(cluster, *options.ProviderRepositorySource, options.TargetNamespace, options.ListVariablesOnly)
+	var template Template
+	switch {
+	case options.ProviderRepositorySource != nil:
+		template, err = c.getTemplateFromRepository(ctx, cluster, *options.ProviderRepositorySource, options.TargetNamespace, options.ListVariablesOnly)
+	case options.ConfigMapSource != nil:
+		template, err = c.getTemplateFromConfigMap(ctx, cluster, *options.ConfigMapSource, options.TargetNamespace, options.ListVariablesOnly)
+	case options.URLSource != nil:
+		template, err = c.getTemplateFromURL(cluster, *options.URLSource, options.TargetNamespace, options.ListVariablesOnly)
+	default:
+		return nil, errors.New("unable to read custom template. Please specify a template source")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if options.Validate && !options.ListVariablesOnly {
+		providers, err := c.templateValidationProviders(ctx, cluster, options)
+		if err != nil {
+			return nil, err
+		}
+		if err := c.validateTemplate(ctx, template, providers); err != nil {
+			return nil, err
+		}
+	}
+
+	return template, nil
+}
+
+// ListFlavorsOptions carries the options supported by ListFlavors.
+type ListFlavorsOptions struct {
+	// Kubeconfig file to use for accessing the management cluster. If empty, default rules for kubeconfig
+	// discovery will be used.
+	Kubeconfig string
+
+	// InfrastructureProvider, in the form name[:version], to list the cluster template flavors of. By default (empty),
+	// the default infrastructure provider installed in the management cluster is used; by default the version
+	// installed in the management cluster is used.
+	InfrastructureProvider string
+}
+
+func (c *clusterctlClient) ListFlavors(ctx context.Context, options ListFlavorsOptions) ([]Flavor, error) {
+	cluster, err := c.clusterClientFactory(options.Kubeconfig, "")
+	if err != nil {
+		return nil, err
+	}
+
+	// ensure the custom resource definitions required by clusterctl are in place
+	if err := cluster.ProviderInventory().EnsureCustomResourceDefinitions(ctx); err != nil {
+		return nil, err
 	}
-	if options.ConfigMapSource != nil {
-		return c.getTemplateFromConfigMap(cluster, *options.ConfigMapSource, options.TargetNamespace, options.ListVariablesOnly)
+
+	name, version, err := getTemplateInfrastructureProvider(ctx, cluster, ProviderRepositorySourceOptions{InfrastructureProvider: options.InfrastructureProvider})
+	if err != nil {
+		return nil, err
 	}
-	if options.URLSource != nil {
-		return c.getTemplateFromURL(cluster, *options.URLSource, options.TargetNamespace, options.ListVariablesOnly)
+
+	providerConfig, err := c.configClient.Providers().Get(name)
+	if err != nil {
+		return nil, err
 	}
 
-	return nil, errors.New("unable to read custom template. Please specify a template source")
+	repo, err := c.repositoryClientFactory(providerConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	flavors, err := repo.Templates(version).ListFlavors(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Flavor is an alias for repository.Flavor; this makes the conversion
+	ret := make([]Flavor, len(flavors))
+	for i, f := range flavors {
+		ret[i] = Flavor(f)
+	}
+	return ret, nil
 }
 
 // getTemplateFromRepository returns a workload cluster template from a provider repository.
-func (c *clusterctlClient) getTemplateFromRepository(cluster cluster.Client, source ProviderRepositorySourceOptions, targetNamespace string, listVariablesOnly bool) (Template, error) {
+func (c *clusterctlClient) getTemplateFromRepository(ctx context.Context, cluster cluster.Client, source ProviderRepositorySourceOptions, targetNamespace string, listVariablesOnly bool) (Template, error) {
 	// ensure the custom resource definitions required by clusterctl are in place
-	if err := cluster.ProviderInventory().EnsureCustomResourceDefinitions(); err != nil {
+	if err := cluster.ProviderInventory().EnsureCustomResourceDefinitions(ctx); err != nil {
 		return nil, err
 	}
 
+	name, version, err := getTemplateInfrastructureProvider(ctx, cluster, source)
+	if err != nil {
+		return nil, err
+	}
+
+	// Get the template from the template repository.
+	providerConfig, err := c.configClient.Providers().Get(name)
+	if err != nil {
+		return nil, err
+	}
+
+	repo, err := c.repositoryClientFactory(providerConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	template, err := repo.Templates(version).Get(ctx, source.Flavor, targetNamespace, listVariablesOnly)
+	if err != nil {
+		return nil, err
+	}
+	return template, nil
+}
+
+// getTemplateInfrastructureProvider returns the name and the version of the infrastructure provider to read the workload
+// cluster template from; if they are not specified, the default ones are detected from the provider inventory.
+func getTemplateInfrastructureProvider(ctx context.Context, cluster cluster.Client, source ProviderRepositorySourceOptions) (string, string, error) {
 	// If the option specifying the name of the infrastructure provider to get templates from is empty, try to detect it.
 	provider := source.InfrastructureProvider
 	if provider == "" {
-		defaultProviderName, err := cluster.ProviderInventory().GetDefaultProviderName(clusterctlv1.InfrastructureProviderType)
+		defaultProviderName, err := cluster.ProviderInventory().GetDefaultProviderName(ctx, clusterctlv1.InfrastructureProviderType)
 		if err != nil {
-			return nil, err
+			return "", "", err
 		}
 
 		if defaultProviderName == "" {
-			return nil, errors.New("failed to identify the default infrastructure provider. Please specify an infrastructure provider")
+			return "", "", errors.New("failed to identify the default infrastructure provider. Please specify an infrastructure provider")
 		}
 		provider = defaultProviderName
 	}
@@ -207,42 +321,27 @@ func (c *clusterctlClient) getTemplateFromRepository(cluster cluster.Client, sou
 	// parse the abbreviated syntax for name[:version]
 	name, version, err := parseProviderName(provider)
 	if err != nil {
-		return nil, err
+		return "", "", err
 	}
 
 	// If the version of the infrastructure provider to get templates from is empty, try to detect it.
 	if version == "" {
-		defaultProviderVersion, err := cluster.ProviderInventory().GetDefaultProviderVersion(name)
+		defaultProviderVersion, err := cluster.ProviderInventory().GetDefaultProviderVersion(ctx, name)
 		if err != nil {
-			return nil, err
+			return "", "", err
 		}
 
 		if defaultProviderVersion == "" {
-			return nil, errors.Errorf("failed to identify the default version for the provider %q. Please specify a version", name)
+			return "", "", errors.Errorf("failed to identify the default version for the provider %q. Please specify a version", name)
 		}
 		version = defaultProviderVersion
 	}
 
-	// Get the template from the template repository.
-	providerConfig, err := c.configClient.Providers().Get(name)
-	if err != nil {
-		return nil, err
-	}
-
-	repo, err := c.repositoryClientFactory(providerConfig)
-	if err != nil {
-		return nil, err
-	}
-
-	template, err := repo.Templates(version).Get(source.Flavor, targetNamespace, listVariablesOnly)
-	if err != nil {
-		return nil, err
-	}
-	return template, nil
+	return name, version, nil
 }
 
 // getTemplateFromConfigMap returns a workload cluster template from a ConfigMap.
-func (c *clusterctlClient) getTemplateFromConfigMap(cluster cluster.Client, source ConfigMapSourceOptions, targetNamespace string, listVariablesOnly bool) (Template, error) {
+func (c *clusterctlClient) getTemplateFromConfigMap(ctx context.Context, cluster cluster.Client, source ConfigMapSourceOptions, targetNamespace string, listVariablesOnly bool) (Template, error) {
 	// If the option specifying the configMapNamespace is empty, default it to the current namespace.
 	if source.Namespace == "" {
 		currentNamespace, err := cluster.Proxy().CurrentNamespace()
@@ -257,7 +356,7 @@ func (c *clusterctlClient) getTemplateFromConfigMap(cluster cluster.Client, sour
 		source.DataKey = DefaultCustomTemplateConfigMapKey
 	}
 
-	return cluster.Template().GetFromConfigMap(source.Namespace, source.Name, source.DataKey, targetNamespace, listVariablesOnly)
+	return cluster.Template().GetFromConfigMap(ctx, source.Namespace, source.Name, source.DataKey, targetNamespace, listVariablesOnly)
 }
 
 // getTemplateFromURL returns a workload cluster template from an URL.
@@ -265,6 +364,100 @@ func (c *clusterctlClient) getTemplateFromURL(cluster cluster.Client, source URL
 	return cluster.Template().GetFromURL(source.URL, targetNamespace, listVariablesOnly)
 }
 
+// templateValidationProviders returns the providers, in the form name[:version], whose CustomResourceDefinitions are
+// used for validating a workload cluster template.
+func (c *clusterctlClient) templateValidationProviders(ctx context.Context, cluster cluster.Client, options GetClusterTemplateOptions) ([]string, error) {
+	providers := []string{
+		config.ClusterAPIProviderName,
+		config.KubeadmBootstrapProviderName,
+		config.KubeadmControlPlaneProviderName,
+	}
+
+	if options.ProviderRepositorySource != nil {
+		name, version, err := getTemplateInfrastructureProvider(ctx, cluster, *options.ProviderRepositorySource)
+		if err != nil {
+			return nil, err
+		}
+		providers = append(providers, fmt.Sprintf("%s:%s", name, version))
+	}
+
+	// Providers explicitly requested replace the default ones with the same name.
+	for _, provider := range options.ValidateWithProviders {
+		name, _, err := parseProviderName(provider)
+		if err != nil {
+			return nil, err
+		}
+		replaced := false
+		for i := range providers {
+			if n, _, _ := parseProviderName(providers[i]); n == name {
+				providers[i] = provider
+				replaced = true
+			}
+		}
+		if !replaced {
+			providers = append(providers, provider)
+		}
+	}
+
+	return providers, nil
+}
+
+// validateTemplate validates the workload cluster template objects against the schemas of the CustomResourceDefinitions
+// of the given providers, read from the provider repositories.
+func (c *clusterctlClient) validateTemplate(ctx context.Context, template Template, providers []string) error {
+	log := c.log
+
+	var crds []unstructured.Unstructured
+	for _, provider := range providers {
+		name, version, err := parseProviderName(provider)
+		if err != nil {
+			return err
+		}
+
+		providerConfig, err := c.configClient.Providers().Get(name)
+		if err != nil {
+			return err
+		}
+
+		repo, err := c.repositoryClientFactory(providerConfig)
+		if err != nil {
+			return err
+		}
+
+		providerCRDs, err := repo.Components().CustomResourceDefinitions(ctx, version)
+		if err != nil {
+			return errors.Wrapf(err, "failed to read the CustomResourceDefinitions of the %q provider", name)
+		}
+		crds = append(crds, providerCRDs...)
+	}
+
+	skipped, err := util.ValidateObjects(template.Objs(), crds)
+	if err != nil {
+		return errors.Wrap(err, "invalid workload cluster template")
+	}
+	for _, o := range skipped {
+		log.V(1).Info("Skipping validation, no CustomResourceDefinition found", "APIVersion", o.GetAPIVersion(), "Kind", o.GetKind(), "Name", o.GetName())
+	}
+	return nil
+}
+
+// namespaceVariablesToVariables injects the default variables stored in the target namespace to the configClient,
+// without overriding variables already defined.
+func (c *clusterctlClient) namespaceVariablesToVariables(ctx context.Context, cluster cluster.Client, targetNamespace string) error {
+	variables, err := cluster.Template().GetNamespaceVariables(ctx, targetNamespace)
+	if err != nil {
+		return err
+	}
+
+	for key, value := range variables {
+		if _, err := c.configClient.Variables().Get(key); err == nil {
+			continue
+		}
+		c.configClient.Variables().Set(key, value)
+	}
+	return nil
+}
+
 // templateOptionsToVariables injects some of the templateOptions to the configClient so they can be consumed as a variables from the template.
 func (c *clusterctlClient) templateOptionsToVariables(options GetClusterTemplateOptions) error {
 
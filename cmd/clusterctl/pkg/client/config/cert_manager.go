@@ -0,0 +1,62 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+// CertManager defines the cert-manager configuration.
+type CertManager interface {
+	// URL returns the location of the cert-manager manifest; if empty, the cert-manager manifest
+	// embedded in the clusterctl binary is used.
+	URL() string
+
+	// Version returns the cert-manager version; if empty, the version of the cert-manager manifest
+	// embedded in the clusterctl binary is used.
+	Version() string
+
+	// Skip returns true if clusterctl should not install cert-manager, e.g. because cert-manager
+	// is already managed outside of clusterctl.
+	Skip() bool
+}
+
+// certManager implements CertManager.
+type certManager struct {
+	url     string
+	version string
+	skip    bool
+}
+
+// ensure certManager implements CertManager.
+var _ CertManager = &certManager{}
+
+func (p *certManager) URL() string {
+	return p.url
+}
+
+func (p *certManager) Version() string {
+	return p.version
+}
+
+func (p *certManager) Skip() bool {
+	return p.skip
+}
+
+func NewCertManager(url, version string, skip bool) CertManager {
+	return &certManager{
+		url:     url,
+		version: version,
+		skip:    skip,
+	}
+}
@@ -0,0 +1,86 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/util/version"
+)
+
+const (
+	// CertManagerConfigKey defines the name of the top level config key for cert-manager configuration.
+	CertManagerConfigKey = "cert-manager"
+
+	// certManagerReleaseURL defines the location of the cert-manager manifest for a given cert-manager version.
+	certManagerReleaseURL = "https://github.com/jetstack/cert-manager/releases/download/%s/cert-manager.yaml"
+)
+
+// CertManagerClient has methods to work with cert-manager configurations.
+type CertManagerClient interface {
+	// Get returns the cert-manager configuration.
+	Get() (CertManager, error)
+}
+
+// certManagerClient implements CertManagerClient.
+type certManagerClient struct {
+	reader Reader
+}
+
+// ensure certManagerClient implements CertManagerClient.
+var _ CertManagerClient = &certManagerClient{}
+
+func newCertManagerClient(reader Reader) *certManagerClient {
+	return &certManagerClient{
+		reader: reader,
+	}
+}
+
+// configCertManager mirrors config.CertManager interface and allows serialization of the corresponding info.
+type configCertManager struct {
+	URL     string `json:"url,omitempty"`
+	Version string `json:"version,omitempty"`
+	Skip    bool   `json:"skip,omitempty"`
+}
+
+func (p *certManagerClient) Get() (CertManager, error) {
+	userCertManager := configCertManager{}
+	if err := p.reader.UnmarshalKey(CertManagerConfigKey, &userCertManager); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal cert-manager from the clusterctl configuration file")
+	}
+
+	// If a version is defined without an explicit URL, derive the URL from the cert-manager release.
+	certManagerURL := userCertManager.URL
+	if userCertManager.Version != "" {
+		if _, err := version.ParseSemantic(userCertManager.Version); err != nil {
+			return nil, errors.Wrapf(err, "invalid cert-manager version %q. Please fix the cert-manager value in clusterctl configuration file", userCertManager.Version)
+		}
+		if certManagerURL == "" {
+			certManagerURL = fmt.Sprintf(certManagerReleaseURL, userCertManager.Version)
+		}
+	}
+
+	if certManagerURL != "" {
+		if _, err := url.Parse(certManagerURL); err != nil {
+			return nil, errors.Wrap(err, "error parsing cert-manager URL. Please fix the cert-manager value in clusterctl configuration file")
+		}
+	}
+
+	return NewCertManager(certManagerURL, userCertManager.Version, userCertManager.Skip), nil
+}
@@ -0,0 +1,92 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"reflect"
+	"testing"
+
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/internal/test"
+)
+
+func Test_certManagerClient_Get(t *testing.T) {
+	type fields struct {
+		reader Reader
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		want    CertManager
+		wantErr bool
+	}{
+		{
+			name: "Defaults to the embedded cert-manager manifest",
+			fields: fields{
+				reader: test.NewFakeReader(),
+			},
+			want:    NewCertManager("", "", false),
+			wantErr: false,
+		},
+		{
+			name: "Derives the URL from the cert-manager version",
+			fields: fields{
+				reader: test.NewFakeReader().WithVar(CertManagerConfigKey, "version: v0.11.1"),
+			},
+			want:    NewCertManager("https://github.com/jetstack/cert-manager/releases/download/v0.11.1/cert-manager.yaml", "v0.11.1", false),
+			wantErr: false,
+		},
+		{
+			name: "Uses the custom URL",
+			fields: fields{
+				reader: test.NewFakeReader().WithVar(CertManagerConfigKey, "url: https://internal.example.com/cert-manager.yaml\nversion: v0.11.1"),
+			},
+			want:    NewCertManager("https://internal.example.com/cert-manager.yaml", "v0.11.1", false),
+			wantErr: false,
+		},
+		{
+			name: "Skips cert-manager installation",
+			fields: fields{
+				reader: test.NewFakeReader().WithVar(CertManagerConfigKey, "skip: true"),
+			},
+			want:    NewCertManager("", "", true),
+			wantErr: false,
+		},
+		{
+			name: "Fails for invalid version",
+			fields: fields{
+				reader: test.NewFakeReader().WithVar(CertManagerConfigKey, "version: foo"),
+			},
+			want:    nil,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newCertManagerClient(tt.fields.reader)
+			got, err := p.Get()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
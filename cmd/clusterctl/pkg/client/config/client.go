@@ -17,25 +17,38 @@ limitations under the License.
 package config
 
 import (
+	"github.com/go-logr/logr"
 	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/internal/test"
+	logf "sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/log"
 )
 
 // Client is used to interact with the clusterctl configurations.
-// Clusterctl v2 handles two types of configs:
+// Clusterctl v2 handles three types of configs:
 // 1. The configuration of the providers (name, type and URL of the provider repository)
 // 2. Variables used when installing providers/creating clusters. Variables can be read from the environment or from the config file
+// 3. The configuration of cert-manager (version, URL of the manifest or skip)
+// 4. The customizations of the provider Deployments (replicas, resources, node selector, tolerations and priority class)
+// Configurations can be read from the local environment/config file and from a configuration shared in the management cluster.
 type Client interface {
 	// Providers provide access to provider configurations.
 	Providers() ProvidersClient
 
+	// CertManager provide access to the cert-manager configuration.
+	CertManager() CertManagerClient
+
 	// Variables provide access to environment variables and/or variables defined in the clusterctl configuration file.
 	Variables() VariablesClient
+
+	// Deployments provide access to the customizations of the provider Deployments.
+	Deployments() DeploymentsClient
 }
 
 // configClient implements Client.
 type configClient struct {
 	reader Reader
+	log    logr.Logger
 }
 
 // ensure configClient implements Client.
@@ -45,10 +58,18 @@ func (c *configClient) Providers() ProvidersClient {
 	return newProvidersClient(c.reader)
 }
 
+func (c *configClient) CertManager() CertManagerClient {
+	return newCertManagerClient(c.reader)
+}
+
 func (c *configClient) Variables() VariablesClient {
 	return newVariablesClient(c.reader)
 }
 
+func (c *configClient) Deployments() DeploymentsClient {
+	return newDeploymentsClient(c.reader)
+}
+
 // Option is a configuration option supplied to New
 type Option func(*configClient)
 
@@ -59,23 +80,48 @@ func InjectReader(reader Reader) Option {
 	}
 }
 
+// InjectLogger allows to override the logger used by the client, e.g. for routing the logs of the configuration
+// reads into the logging stack of the program embedding clusterctl; by default the clusterctl logger is used.
+// NB. the logger is not used by an injected reader.
+func InjectLogger(log logr.Logger) Option {
+	return func(c *configClient) {
+		c.log = log
+	}
+}
+
 // New returns a Client for interacting with the clusterctl configuration.
 func New(path string, options ...Option) (Client, error) {
 	return newConfigClient(path, options...)
 }
 
 func newConfigClient(path string, options ...Option) (*configClient, error) {
-	client := &configClient{}
+	client := &configClient{
+		log: logf.Log,
+	}
 	for _, o := range options {
 		o(client)
 	}
 
 	// if there is an injected reader, use it, otherwise use a default one
 	if client.reader == nil {
-		client.reader = newViperReader()
-		if err := client.reader.Init(path); err != nil {
+		localReader := newViperReader(client.log)
+		if err := localReader.Init(path); err != nil {
 			return nil, errors.Wrap(err, "failed to initialize the configuration reader")
 		}
+		client.reader = localReader
+
+		// if the local configuration points to a configuration shared in a management cluster, read it and
+		// layer it below the local configuration, so flags, environment variables and the config file take precedence.
+		loader := func(kubeconfig, context, namespace string) (*corev1.ConfigMap, *corev1.Secret, error) {
+			return loadSharedConfig(client.log, kubeconfig, context, namespace)
+		}
+		sharedReader, err := newSharedConfigReader(localReader, loader)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to read the clusterctl shared configuration")
+		}
+		if sharedReader != nil {
+			client.reader = newLayeredReader(localReader, sharedReader)
+		}
 	}
 
 	return client, nil
@@ -0,0 +1,187 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// DeploymentsConfigKey defines the name of the top level config key for the customization of the provider Deployments.
+	DeploymentsConfigKey = "provider-deployments"
+
+	// AllProvidersDeploymentsKey defines the key for the customizations applied to the Deployments of all the providers;
+	// the customizations defined for a specific provider take precedence.
+	AllProvidersDeploymentsKey = "all"
+)
+
+// DeploymentOverrides defines the customizations applied to the controller Deployments of a provider when it is
+// installed or upgraded, e.g. for running the controllers on infrastructure nodes or sizing them for large fleets.
+type DeploymentOverrides struct {
+	// Replicas of the Deployments.
+	Replicas *int32 `json:"replicas,omitempty"`
+
+	// Resources of the manager container of the Deployments.
+	Resources *corev1.ResourceRequirements `json:"resources,omitempty"`
+
+	// NodeSelector of the pods of the Deployments.
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+
+	// Tolerations of the pods of the Deployments.
+	Tolerations []corev1.Toleration `json:"tolerations,omitempty"`
+
+	// PriorityClassName of the pods of the Deployments.
+	PriorityClassName string `json:"priorityClassName,omitempty"`
+
+	// ImagePullSecrets added to the pods of the Deployments and to the ServiceAccounts, e.g. for pulling the
+	// provider images from a private mirror.
+	ImagePullSecrets []ImagePullSecret `json:"imagePullSecrets,omitempty"`
+}
+
+// ImagePullSecret defines a secret used for pulling the provider images.
+type ImagePullSecret struct {
+	// Name of the secret.
+	Name string `json:"name"`
+
+	// DockerConfigFile is the path of a docker config file, e.g. $HOME/.docker/config.json; if set, the secret
+	// is created from the file in the provider namespace, otherwise the secret must already exist.
+	DockerConfigFile string `json:"dockerConfigFile,omitempty"`
+}
+
+// IsEmpty returns true if the overrides do not customize the Deployments.
+func (o DeploymentOverrides) IsEmpty() bool {
+	return o.Replicas == nil && o.Resources == nil && len(o.NodeSelector) == 0 && len(o.Tolerations) == 0 && o.PriorityClassName == "" && len(o.ImagePullSecrets) == 0
+}
+
+// merge returns the overrides with the fields not set replaced by the defaults.
+func (o DeploymentOverrides) merge(defaults DeploymentOverrides) DeploymentOverrides {
+	if o.Replicas == nil {
+		o.Replicas = defaults.Replicas
+	}
+	if o.Resources == nil {
+		o.Resources = defaults.Resources
+	}
+	if o.NodeSelector == nil {
+		o.NodeSelector = defaults.NodeSelector
+	}
+	if o.Tolerations == nil {
+		o.Tolerations = defaults.Tolerations
+	}
+	if o.PriorityClassName == "" {
+		o.PriorityClassName = defaults.PriorityClassName
+	}
+	if o.ImagePullSecrets == nil {
+		o.ImagePullSecrets = defaults.ImagePullSecrets
+	}
+	return o
+}
+
+// DeploymentsClient has methods to work with the customizations of the provider Deployments.
+type DeploymentsClient interface {
+	// Get returns the customizations of the Deployments of a provider, that is the ones defined for the provider
+	// combined with the ones defined for all the providers.
+	Get(provider string) (DeploymentOverrides, error)
+}
+
+// deploymentsClient implements DeploymentsClient.
+type deploymentsClient struct {
+	reader Reader
+}
+
+// ensure deploymentsClient implements DeploymentsClient.
+var _ DeploymentsClient = &deploymentsClient{}
+
+func newDeploymentsClient(reader Reader) *deploymentsClient {
+	return &deploymentsClient{
+		reader: reader,
+	}
+}
+
+func (p *deploymentsClient) Get(provider string) (DeploymentOverrides, error) {
+	var raw map[string]interface{}
+	if err := p.reader.UnmarshalKey(DeploymentsConfigKey, &raw); err != nil {
+		return DeploymentOverrides{}, errors.Wrap(err, "failed to unmarshal provider-deployments from the clusterctl configuration file")
+	}
+
+	all, err := decodeDeploymentOverrides(raw[AllProvidersDeploymentsKey])
+	if err != nil {
+		return DeploymentOverrides{}, errors.Wrapf(err, "invalid %s value. Please fix the provider-deployments value in clusterctl configuration file", AllProvidersDeploymentsKey)
+	}
+	overrides, err := decodeDeploymentOverrides(raw[provider])
+	if err != nil {
+		return DeploymentOverrides{}, errors.Wrapf(err, "invalid %s value. Please fix the provider-deployments value in clusterctl configuration file", provider)
+	}
+	return overrides.merge(all), nil
+}
+
+// decodeDeploymentOverrides decodes the customizations read from the configuration file.
+// Nb. The configuration file is decoded using the field names in the json tags, matched case insensitively, because
+// the configuration reader does not preserve the case of the keys.
+func decodeDeploymentOverrides(raw interface{}) (DeploymentOverrides, error) {
+	overrides := DeploymentOverrides{}
+	if raw == nil {
+		return overrides, nil
+	}
+
+	data, err := json.Marshal(stringKeys(raw))
+	if err != nil {
+		return overrides, err
+	}
+	if err := json.Unmarshal(data, &overrides); err != nil {
+		return overrides, err
+	}
+
+	if overrides.Replicas != nil && *overrides.Replicas < 0 {
+		return overrides, errors.Errorf("replicas must be greater than or equal to 0, got %d", *overrides.Replicas)
+	}
+	for _, secret := range overrides.ImagePullSecrets {
+		if secret.Name == "" {
+			return overrides, errors.New("imagePullSecrets must have a name")
+		}
+	}
+	return overrides, nil
+}
+
+// stringKeys converts the maps with interface keys, as returned by the yaml parser, into maps with string keys
+// that can be serialized to json.
+func stringKeys(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for key, value := range v {
+			m[fmt.Sprint(key)] = stringKeys(value)
+		}
+		return m
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for key, value := range v {
+			m[key] = stringKeys(value)
+		}
+		return m
+	case []interface{}:
+		l := make([]interface{}, len(v))
+		for i, value := range v {
+			l[i] = stringKeys(value)
+		}
+		return l
+	}
+	return v
+}
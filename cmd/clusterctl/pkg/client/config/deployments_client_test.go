@@ -0,0 +1,147 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/internal/test"
+)
+
+func Test_deploymentsClient_Get(t *testing.T) {
+	config := `
+all:
+  nodeSelector:
+    node-role.kubernetes.io/infra: ""
+  tolerations:
+  - key: node-role.kubernetes.io/infra
+    operator: Exists
+    effect: NoSchedule
+  priorityClassName: system-cluster-critical
+  imagePullSecrets:
+  - name: mirror-pull-secret
+    dockerConfigFile: /home/user/.docker/config.json
+cluster-api:
+  replicas: 2
+  resources:
+    requests:
+      cpu: 200m
+      memory: 256Mi
+  priorityClassName: capi-critical
+`
+	infraNodeSelector := map[string]string{"node-role.kubernetes.io/infra": ""}
+	infraTolerations := []corev1.Toleration{
+		{Key: "node-role.kubernetes.io/infra", Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoSchedule},
+	}
+	mirrorPullSecrets := []ImagePullSecret{
+		{Name: "mirror-pull-secret", DockerConfigFile: "/home/user/.docker/config.json"},
+	}
+
+	tests := []struct {
+		name     string
+		reader   Reader
+		provider string
+		want     DeploymentOverrides
+		wantErr  bool
+	}{
+		{
+			name:     "No customizations",
+			reader:   test.NewFakeReader(),
+			provider: "cluster-api",
+			want:     DeploymentOverrides{},
+			wantErr:  false,
+		},
+		{
+			name:     "Customizations for all the providers",
+			reader:   test.NewFakeReader().WithVar(DeploymentsConfigKey, config),
+			provider: "aws",
+			want: DeploymentOverrides{
+				NodeSelector:      infraNodeSelector,
+				Tolerations:       infraTolerations,
+				PriorityClassName: "system-cluster-critical",
+				ImagePullSecrets:  mirrorPullSecrets,
+			},
+			wantErr: false,
+		},
+		{
+			name:     "Customizations for a provider take precedence",
+			reader:   test.NewFakeReader().WithVar(DeploymentsConfigKey, config),
+			provider: "cluster-api",
+			want: DeploymentOverrides{
+				Replicas: pointer.Int32Ptr(2),
+				Resources: &corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{
+						corev1.ResourceCPU:    resource.MustParse("200m"),
+						corev1.ResourceMemory: resource.MustParse("256Mi"),
+					},
+				},
+				NodeSelector:      infraNodeSelector,
+				Tolerations:       infraTolerations,
+				PriorityClassName: "capi-critical",
+				ImagePullSecrets:  mirrorPullSecrets,
+			},
+			wantErr: false,
+		},
+		{
+			name:     "Fails for negative replicas",
+			reader:   test.NewFakeReader().WithVar(DeploymentsConfigKey, "cluster-api:\n  replicas: -1"),
+			provider: "cluster-api",
+			wantErr:  true,
+		},
+		{
+			name:     "Fails for image pull secrets without a name",
+			reader:   test.NewFakeReader().WithVar(DeploymentsConfigKey, "all:\n  imagePullSecrets:\n  - dockerConfigFile: config.json"),
+			provider: "cluster-api",
+			wantErr:  true,
+		},
+		{
+			name:     "Fails for invalid resources",
+			reader:   test.NewFakeReader().WithVar(DeploymentsConfigKey, "all:\n  resources:\n    limits:\n      cpu: lots"),
+			provider: "cluster-api",
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newDeploymentsClient(tt.reader)
+			got, err := p.Get(tt.provider)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			if got.Resources != nil && tt.want.Resources != nil {
+				for name, want := range tt.want.Resources.Requests {
+					got := got.Resources.Requests[name]
+					if got.Cmp(want) != 0 {
+						t.Errorf("got %s request %s, want %s", name, got.String(), want.String())
+					}
+				}
+				got.Resources, tt.want.Resources = nil, nil
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
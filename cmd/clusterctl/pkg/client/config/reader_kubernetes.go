@@ -0,0 +1,177 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"bytes"
+
+	"github.com/go-logr/logr"
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+const (
+	// SharedConfigNamespaceKey is the configuration key defining the namespace in the management cluster
+	// hosting the shared clusterctl configuration; if not set, the shared configuration is not used.
+	SharedConfigNamespaceKey = "shared-config-namespace"
+
+	// SharedConfigKubeconfigKey is the configuration key defining the kubeconfig file to be used for reading
+	// the shared clusterctl configuration; if not set, the default kubeconfig discovery rules apply.
+	SharedConfigKubeconfigKey = "shared-config-kubeconfig"
+
+	// SharedConfigKubeconfigContextKey is the configuration key defining the kubeconfig context to be used for reading
+	// the shared clusterctl configuration; if not set, the current context is used.
+	SharedConfigKubeconfigContextKey = "shared-config-kubeconfig-context"
+
+	// SharedConfigName is the name of the ConfigMap and of the Secret hosting the shared clusterctl configuration.
+	SharedConfigName = "clusterctl-config"
+
+	// SharedConfigDataKey is the key in the ConfigMap data hosting the shared clusterctl configuration,
+	// using the same format of the clusterctl config file.
+	SharedConfigDataKey = "clusterctl.yaml"
+)
+
+// sharedConfigLoader defines a function that reads the ConfigMap and the Secret hosting
+// the shared clusterctl configuration; both objects are optional, so nil is returned if not found.
+type sharedConfigLoader func(kubeconfig, context, namespace string) (*corev1.ConfigMap, *corev1.Secret, error)
+
+// kubernetesReader implements Reader reading the clusterctl configuration shared across
+// the users of a management cluster. The configuration is read from the SharedConfigDataKey of the SharedConfigName
+// ConfigMap, using the same format of the clusterctl config file, and from the SharedConfigName Secret, where each key
+// defines a variable; the Secret is intended for sensitive values like credentials, and it takes precedence on the ConfigMap.
+type kubernetesReader struct {
+	viper     *viper.Viper
+	variables map[string]string
+}
+
+// newKubernetesReader returns a kubernetesReader for the given ConfigMap and Secret; both of them are optional.
+func newKubernetesReader(configMap *corev1.ConfigMap, secret *corev1.Secret) (*kubernetesReader, error) {
+	r := &kubernetesReader{
+		viper:     viper.New(),
+		variables: map[string]string{},
+	}
+
+	if configMap != nil {
+		if data, ok := configMap.Data[SharedConfigDataKey]; ok {
+			r.viper.SetConfigType("yaml")
+			if err := r.viper.ReadConfig(bytes.NewBufferString(data)); err != nil {
+				return nil, errors.Wrapf(err, "failed to read %q from the %s/%s ConfigMap", SharedConfigDataKey, configMap.Namespace, configMap.Name)
+			}
+		}
+	}
+
+	if secret != nil {
+		for k, v := range secret.Data {
+			r.variables[k] = string(v)
+		}
+		for k, v := range secret.StringData {
+			r.variables[k] = v
+		}
+	}
+
+	return r, nil
+}
+
+// Init is a no-op, given that the shared configuration is read when creating the kubernetesReader.
+func (k *kubernetesReader) Init(path string) error {
+	return nil
+}
+
+func (k *kubernetesReader) Get(key string) (string, error) {
+	if v, ok := k.variables[key]; ok {
+		return v, nil
+	}
+	if k.viper.Get(key) == nil {
+		return "", errors.Errorf("Failed to get value for variable %q from the clusterctl shared configuration", key)
+	}
+	return k.viper.GetString(key), nil
+}
+
+func (k *kubernetesReader) Set(key, value string) {
+	k.variables[key] = value
+}
+
+func (k *kubernetesReader) UnmarshalKey(key string, rawval interface{}) error {
+	return k.viper.UnmarshalKey(key, rawval)
+}
+
+// newSharedConfigReader returns a Reader for the clusterctl configuration shared in the management cluster,
+// if the local configuration defines the SharedConfigNamespaceKey; otherwise nil is returned.
+func newSharedConfigReader(local Reader, load sharedConfigLoader) (Reader, error) {
+	namespace, err := local.Get(SharedConfigNamespaceKey)
+	if err != nil || namespace == "" {
+		return nil, nil
+	}
+	// Kubeconfig and context are optional, so errors are ignored and defaults apply.
+	kubeconfig, _ := local.Get(SharedConfigKubeconfigKey)
+	context, _ := local.Get(SharedConfigKubeconfigContextKey)
+
+	configMap, secret, err := load(kubeconfig, context, namespace)
+	if err != nil {
+		return nil, err
+	}
+	return newKubernetesReader(configMap, secret)
+}
+
+// loadSharedConfig reads the ConfigMap and the Secret hosting the shared clusterctl configuration
+// from the management cluster.
+func loadSharedConfig(log logr.Logger, kubeconfig, context, namespace string) (*corev1.ConfigMap, *corev1.Secret, error) {
+	// If a kubeconfig file isn't provided, find one in the standard locations.
+	if kubeconfig == "" {
+		kubeconfig = clientcmd.NewDefaultClientConfigLoadingRules().GetDefaultFilename()
+	}
+
+	config, err := clientcmd.LoadFromFile(kubeconfig)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "failed to load Kubeconfig file from %q", kubeconfig)
+	}
+
+	restConfig, err := clientcmd.NewDefaultClientConfig(*config, &clientcmd.ConfigOverrides{CurrentContext: context}).ClientConfig()
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to rest client")
+	}
+
+	cs, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to create the client-go client")
+	}
+
+	log.V(5).Info("Reading shared configuration", "Namespace", namespace, "Name", SharedConfigName)
+
+	configMap, err := cs.CoreV1().ConfigMaps(namespace).Get(SharedConfigName, metav1.GetOptions{})
+	if err != nil {
+		if !apierrors.IsNotFound(err) {
+			return nil, nil, errors.Wrapf(err, "failed to read the %s/%s ConfigMap", namespace, SharedConfigName)
+		}
+		configMap = nil
+	}
+
+	secret, err := cs.CoreV1().Secrets(namespace).Get(SharedConfigName, metav1.GetOptions{})
+	if err != nil {
+		if !apierrors.IsNotFound(err) {
+			return nil, nil, errors.Wrapf(err, "failed to read the %s/%s Secret", namespace, SharedConfigName)
+		}
+		secret = nil
+	}
+
+	return configMap, secret, nil
+}
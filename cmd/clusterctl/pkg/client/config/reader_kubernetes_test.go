@@ -0,0 +1,210 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/internal/test"
+)
+
+func Test_kubernetesReader_Get(t *testing.T) {
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: SharedConfigName},
+		Data: map[string]string{
+			SharedConfigDataKey: "foo: foo\nbar: bar",
+		},
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: SharedConfigName},
+		Data: map[string][]byte{
+			"bar":    []byte("bar-from-secret"),
+			"SECRET": []byte("secret"),
+		},
+	}
+
+	type args struct {
+		key string
+	}
+	tests := []struct {
+		name      string
+		configMap *corev1.ConfigMap
+		secret    *corev1.Secret
+		args      args
+		want      string
+		wantErr   bool
+	}{
+		{
+			name:      "Read from the ConfigMap",
+			configMap: configMap,
+			secret:    secret,
+			args: args{
+				key: "foo",
+			},
+			want:    "foo",
+			wantErr: false,
+		},
+		{
+			name:      "Read from the Secret",
+			configMap: configMap,
+			secret:    secret,
+			args: args{
+				key: "SECRET",
+			},
+			want:    "secret",
+			wantErr: false,
+		},
+		{
+			name:      "Secret takes precedence on the ConfigMap",
+			configMap: configMap,
+			secret:    secret,
+			args: args{
+				key: "bar",
+			},
+			want:    "bar-from-secret",
+			wantErr: false,
+		},
+		{
+			name:      "Fails if the variable is not defined",
+			configMap: configMap,
+			secret:    secret,
+			args: args{
+				key: "baz",
+			},
+			want:    "",
+			wantErr: true,
+		},
+		{
+			name:      "Fails if ConfigMap and Secret do not exist",
+			configMap: nil,
+			secret:    nil,
+			args: args{
+				key: "foo",
+			},
+			want:    "",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := newKubernetesReader(tt.configMap, tt.secret)
+			if err != nil {
+				t.Fatalf("newKubernetesReader() error = %v", err)
+			}
+
+			got, err := r.Get(tt.args.key)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Get() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Get() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_kubernetesReader_UnmarshalKey(t *testing.T) {
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: SharedConfigName},
+		Data: map[string]string{
+			SharedConfigDataKey: "providers:\n- name: foo\n  url: https://foo.bar\n  type: InfrastructureProvider",
+		},
+	}
+
+	r, err := newKubernetesReader(configMap, nil)
+	if err != nil {
+		t.Fatalf("newKubernetesReader() error = %v", err)
+	}
+
+	got := []configProvider{}
+	if err := r.UnmarshalKey(ProvidersConfigKey, &got); err != nil {
+		t.Fatalf("UnmarshalKey() error = %v", err)
+	}
+
+	want := []configProvider{
+		{Name: "foo", URL: "https://foo.bar", Type: "InfrastructureProvider"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("UnmarshalKey() got = %v, want %v", got, want)
+	}
+}
+
+func Test_newSharedConfigReader(t *testing.T) {
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: SharedConfigName},
+		Data: map[string]string{
+			SharedConfigDataKey: "foo: foo",
+		},
+	}
+
+	tests := []struct {
+		name       string
+		local      Reader
+		load       sharedConfigLoader
+		wantReader bool
+		wantErr    bool
+	}{
+		{
+			name:  "Returns nil if the shared configuration namespace is not set",
+			local: test.NewFakeReader(),
+			load: func(kubeconfig, context, namespace string) (*corev1.ConfigMap, *corev1.Secret, error) {
+				return nil, nil, errors.New("should not be called")
+			},
+			wantReader: false,
+			wantErr:    false,
+		},
+		{
+			name: "Returns a reader if the shared configuration namespace is set",
+			local: test.NewFakeReader().
+				WithVar(SharedConfigNamespaceKey, "ns1").
+				WithVar(SharedConfigKubeconfigKey, "kubeconfig").
+				WithVar(SharedConfigKubeconfigContextKey, "context"),
+			load: func(kubeconfig, context, namespace string) (*corev1.ConfigMap, *corev1.Secret, error) {
+				if kubeconfig != "kubeconfig" || context != "context" || namespace != "ns1" {
+					return nil, nil, errors.Errorf("unexpected arguments %q, %q, %q", kubeconfig, context, namespace)
+				}
+				return configMap, nil, nil
+			},
+			wantReader: true,
+			wantErr:    false,
+		},
+		{
+			name:  "Fails if the shared configuration cannot be read",
+			local: test.NewFakeReader().WithVar(SharedConfigNamespaceKey, "ns1"),
+			load: func(kubeconfig, context, namespace string) (*corev1.ConfigMap, *corev1.Secret, error) {
+				return nil, nil, errors.New("failed to connect")
+			},
+			wantReader: false,
+			wantErr:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newSharedConfigReader(tt.local, tt.load)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("newSharedConfigReader() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if (got != nil) != tt.wantReader {
+				t.Errorf("newSharedConfigReader() got = %v, wantReader %v", got, tt.wantReader)
+			}
+		})
+	}
+}
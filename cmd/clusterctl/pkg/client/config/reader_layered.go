@@ -0,0 +1,85 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"github.com/pkg/errors"
+)
+
+// layeredReader implements Reader on top of a list of readers, where readers earlier in the list
+// take precedence over the following ones.
+type layeredReader struct {
+	readers []Reader
+}
+
+// newLayeredReader returns a layeredReader; readers are listed in order of precedence.
+func newLayeredReader(readers ...Reader) Reader {
+	return &layeredReader{
+		readers: readers,
+	}
+}
+
+// Init initialize all the readers.
+func (l *layeredReader) Init(path string) error {
+	for _, r := range l.readers {
+		if err := r.Init(path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Get returns the value from the first reader defining the key; if no reader defines the key,
+// the error from the reader with the highest precedence is returned.
+func (l *layeredReader) Get(key string) (string, error) {
+	var firstErr error
+	for _, r := range l.readers {
+		v, err := r.Get(key)
+		if err == nil {
+			return v, nil
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+	if firstErr == nil {
+		firstErr = errors.Errorf("Failed to get value for variable %q", key)
+	}
+	return "", firstErr
+}
+
+// Set sets the override on the reader with the highest precedence.
+func (l *layeredReader) Set(key, value string) {
+	if len(l.readers) > 0 {
+		l.readers[0].Set(key, value)
+	}
+}
+
+// UnmarshalKey unmarshals the value from the first reader defining the key; values are not merged
+// across readers, so e.g. a list of providers defined in a reader replaces the lists defined in the following ones.
+func (l *layeredReader) UnmarshalKey(key string, rawval interface{}) error {
+	for _, r := range l.readers {
+		var raw interface{}
+		if err := r.UnmarshalKey(key, &raw); err != nil {
+			return err
+		}
+		if raw != nil {
+			return r.UnmarshalKey(key, rawval)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,143 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"reflect"
+	"testing"
+
+	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/internal/test"
+)
+
+func Test_layeredReader_Get(t *testing.T) {
+	local := test.NewFakeReader().
+		WithVar("foo", "foo-local")
+	shared := test.NewFakeReader().
+		WithVar("foo", "foo-shared").
+		WithVar("bar", "bar-shared")
+
+	type args struct {
+		key string
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "Local value takes precedence",
+			args: args{
+				key: "foo",
+			},
+			want:    "foo-local",
+			wantErr: false,
+		},
+		{
+			name: "Falls back to the shared value",
+			args: args{
+				key: "bar",
+			},
+			want:    "bar-shared",
+			wantErr: false,
+		},
+		{
+			name: "Fails if no reader defines the value",
+			args: args{
+				key: "baz",
+			},
+			want:    "",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newLayeredReader(local, shared)
+
+			got, err := r.Get(tt.args.key)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Get() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Get() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_layeredReader_Set(t *testing.T) {
+	local := test.NewFakeReader()
+	shared := test.NewFakeReader().
+		WithVar("foo", "foo-shared")
+
+	r := newLayeredReader(local, shared)
+	r.Set("foo", "foo-override")
+
+	got, err := r.Get("foo")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if got != "foo-override" {
+		t.Errorf("Get() got = %v, want %v", got, "foo-override")
+	}
+}
+
+func Test_layeredReader_UnmarshalKey(t *testing.T) {
+	tests := []struct {
+		name   string
+		local  Reader
+		shared Reader
+		want   []configProvider
+	}{
+		{
+			name:   "Reads from the shared reader if not defined locally",
+			local:  test.NewFakeReader(),
+			shared: test.NewFakeReader().WithProvider("foo", clusterctlv1.InfrastructureProviderType, "https://foo.shared"),
+			want: []configProvider{
+				{Name: "foo", URL: "https://foo.shared", Type: clusterctlv1.InfrastructureProviderType},
+			},
+		},
+		{
+			name:   "Local definition replaces the shared one",
+			local:  test.NewFakeReader().WithProvider("bar", clusterctlv1.InfrastructureProviderType, "https://bar.local"),
+			shared: test.NewFakeReader().WithProvider("foo", clusterctlv1.InfrastructureProviderType, "https://foo.shared"),
+			want: []configProvider{
+				{Name: "bar", URL: "https://bar.local", Type: clusterctlv1.InfrastructureProviderType},
+			},
+		},
+		{
+			name:   "Not defined in any reader",
+			local:  test.NewFakeReader(),
+			shared: test.NewFakeReader(),
+			want:   []configProvider{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newLayeredReader(tt.local, tt.shared)
+
+			got := []configProvider{}
+			if err := r.UnmarshalKey(ProvidersConfigKey, &got); err != nil {
+				t.Fatalf("UnmarshalKey() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("UnmarshalKey() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
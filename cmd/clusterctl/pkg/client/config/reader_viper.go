@@ -20,10 +20,10 @@ import (
 	"path/filepath"
 	"strings"
 
+	"github.com/go-logr/logr"
 	"github.com/pkg/errors"
 	"github.com/spf13/viper"
 	"k8s.io/client-go/util/homedir"
-	logf "sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/log"
 )
 
 // ConfigFolder defines the name of the config folder under $home
@@ -32,16 +32,19 @@ const ConfigFolder = ".cluster-api"
 // viperReader implements Reader using viper as backend for reading from environment variables
 // and from a clusterctl config file.
 type viperReader struct {
+	log logr.Logger
 }
 
 // newViperReader returns a viperReader.
-func newViperReader() Reader {
-	return &viperReader{}
+func newViperReader(log logr.Logger) Reader {
+	return &viperReader{
+		log: log,
+	}
 }
 
 // Init initialize the viperReader.
 func (v *viperReader) Init(path string) error {
-	log := logf.Log
+	log := v.log
 
 	if path != "" {
 		// Use path file from the flag.
@@ -21,6 +21,8 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+
+	logf "sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/log"
 )
 
 func Test_viperReader_Get(t *testing.T) {
@@ -74,7 +76,7 @@ func Test_viperReader_Get(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			v := &viperReader{}
+			v := &viperReader{log: logf.Log}
 
 			err := v.Init(configFile)
 			if err != nil {
@@ -131,7 +133,7 @@ func Test_viperReader_Set(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			v := &viperReader{}
+			v := &viperReader{log: logf.Log}
 
 			err := v.Init(configFile)
 			if err != nil {
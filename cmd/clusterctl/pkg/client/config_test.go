@@ -17,6 +17,7 @@ limitations under the License.
 package client
 
 import (
+	"context"
 	"fmt"
 	"io/ioutil"
 	"os"
@@ -27,6 +28,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/client/cluster"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/client/config"
 )
 
@@ -78,7 +80,7 @@ func Test_clusterctlClient_GetProvidersConfig(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := tt.field.client.GetProvidersConfig()
+			got, err := tt.field.client.GetProvidersConfig(context.Background())
 			if tt.wantErr != (err != nil) {
 				t.Fatalf("error = %v, wantErr = %v", err, tt.wantErr)
 			}
@@ -154,7 +156,7 @@ func Test_clusterctlClient_GetProviderComponents(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := client.GetProviderComponents(tt.args.provider, tt.args.targetNameSpace, tt.args.watchingNamespace)
+			got, err := client.GetProviderComponents(context.Background(), tt.args.provider, tt.args.targetNameSpace, tt.args.watchingNamespace)
 			if tt.wantErr != (err != nil) {
 				t.Fatalf("error = %v, wantErr = %v", err, tt.wantErr)
 			}
@@ -308,6 +310,71 @@ func Test_clusterctlClient_templateOptionsToVariables(t *testing.T) {
 	}
 }
 
+func Test_clusterctlClient_namespaceVariablesToVariables(t *testing.T) {
+	configMap := &corev1.ConfigMap{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "ConfigMap",
+			APIVersion: "v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "ns1",
+			Name:      cluster.TemplateVariablesConfigMapName,
+		},
+		Data: map[string]string{
+			"POD_CIDR":           "10.0.0.0/16",
+			"KUBERNETES_VERSION": "v1.2.3",
+		},
+	}
+
+	tests := []struct {
+		name            string
+		targetNamespace string
+		wantVars        map[string]string
+	}{
+		{
+			name:            "namespace variables are used if not already defined",
+			targetNamespace: "ns1",
+			wantVars: map[string]string{
+				"POD_CIDR":           "10.0.0.0/16",
+				"KUBERNETES_VERSION": "v3.4.5",
+			},
+		},
+		{
+			name:            "no namespace variables",
+			targetNamespace: "ns2",
+			wantVars: map[string]string{
+				"KUBERNETES_VERSION": "v3.4.5",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := newFakeConfig().
+				WithVar("KUBERNETES_VERSION", "v3.4.5") // with this line we are simulating an env var
+
+			cluster1 := newFakeCluster("kubeconfig", config).
+				WithObjs(configMap)
+
+			c := &clusterctlClient{
+				configClient: config,
+			}
+			if err := c.namespaceVariablesToVariables(context.Background(), cluster1, tt.targetNamespace); err != nil {
+				t.Fatalf("error = %v", err)
+			}
+
+			for name, wantValue := range tt.wantVars {
+				gotValue, err := config.Variables().Get(name)
+				if err != nil {
+					t.Fatalf("variable %s is not defined in config variables", name)
+				}
+				if gotValue != wantValue {
+					t.Errorf("variable %s, got = %v, want %v", name, gotValue, wantValue)
+				}
+			}
+		})
+	}
+}
+
 func Test_clusterctlClient_GetClusterTemplate(t *testing.T) {
 	rawTemplate := templateYAML("ns3", "${ CLUSTER_NAME }")
 
@@ -473,7 +540,7 @@ func Test_clusterctlClient_GetClusterTemplate(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := client.GetClusterTemplate(tt.args.options)
+			got, err := client.GetClusterTemplate(context.Background(), tt.args.options)
 			if tt.wantErr != (err != nil) {
 				t.Fatalf("error = %v, wantErr = %v", err, tt.wantErr)
 			}
@@ -17,28 +17,136 @@ limitations under the License.
 package client
 
 import (
+	"context"
+
 	"github.com/pkg/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/client/cluster"
 )
 
-func (c *clusterctlClient) Delete(options DeleteOptions) error {
-	clusterClient, err := c.clusterClientFactory(options.Kubeconfig)
+func (c *clusterctlClient) Delete(ctx context.Context, options DeleteOptions) error {
+	clusterClient, err := c.clusterClientFactory(options.Kubeconfig, "")
 	if err != nil {
 		return err
 	}
 
-	if err := clusterClient.ProviderInventory().EnsureCustomResourceDefinitions(); err != nil {
+	// Prevents other clusterctl operations from changing the management cluster concurrently.
+	release, err := clusterClient.OperationLock().Acquire(ctx, "delete")
+	if err != nil {
 		return err
 	}
+	defer release()
 
-	// Get the list of installed providers.
-	installedProviders, err := clusterClient.ProviderInventory().List()
+	providers, err := providersToDelete(ctx, clusterClient, options)
 	if err != nil {
 		return err
 	}
 
+	// Delete the selected providers
+	reporter := clusterClient.ProgressReporter()
+	reportDeleteProgress(reporter, cluster.ProgressEvent{Type: cluster.ProgressStarted, Total: len(providers)})
+	for i := range providers {
+		provider := providers[i]
+		reportDeleteProgress(reporter, cluster.ProgressEvent{Step: cluster.ProgressStepDeleteProvider, Target: provider.InstanceName(), Type: cluster.ProgressStarted})
+		if err := clusterClient.ProviderComponents().Delete(ctx, componentsDeleteOptions(provider, options)); err != nil {
+			reportDeleteProgress(reporter, cluster.ProgressEvent{Step: cluster.ProgressStepDeleteProvider, Target: provider.InstanceName(), Type: cluster.ProgressFailed, Current: i, Total: len(providers), Err: err})
+			reportDeleteProgress(reporter, cluster.ProgressEvent{Type: cluster.ProgressFailed, Err: err})
+			return err
+		}
+		reportDeleteProgress(reporter, cluster.ProgressEvent{Step: cluster.ProgressStepDeleteProvider, Target: provider.InstanceName(), Type: cluster.ProgressSucceeded, Current: i + 1, Total: len(providers)})
+	}
+
+	// Delete cert-manager, once there are no providers left depending on it.
+	if options.DeleteCertManager {
+		if err := clusterClient.CertManager().Delete(ctx); err != nil {
+			reportDeleteProgress(reporter, cluster.ProgressEvent{Type: cluster.ProgressFailed, Err: err})
+			return err
+		}
+	}
+	reportDeleteProgress(reporter, cluster.ProgressEvent{Type: cluster.ProgressSucceeded})
+
+	return nil
+}
+
+// reportDeleteProgress reports a progress event of the delete operation, if there is a reporter.
+func reportDeleteProgress(reporter cluster.ProgressReporter, event cluster.ProgressEvent) {
+	if reporter == nil {
+		return
+	}
+	event.Operation = cluster.ProgressOperationDelete
+	reporter.Report(event)
+}
+
+func (c *clusterctlClient) PreviewDelete(ctx context.Context, options DeleteOptions) ([]unstructured.Unstructured, error) {
+	clusterClient, err := c.clusterClientFactory(options.Kubeconfig, "")
+	if err != nil {
+		return nil, err
+	}
+
+	providers, err := providersToDelete(ctx, clusterClient, options)
+	if err != nil {
+		return nil, err
+	}
+
+	var objs []unstructured.Unstructured
+	for _, provider := range providers {
+		providerObjs, err := clusterClient.ProviderComponents().ObjectsToDelete(ctx, componentsDeleteOptions(provider, options))
+		if err != nil {
+			return nil, err
+		}
+		objs = append(objs, providerObjs...)
+	}
+
+	// Deleting the CRDs deletes all the custom resources of these kinds too.
+	instances, err := clusterClient.ProviderComponents().CustomResourcesToDelete(ctx, objs)
+	if err != nil {
+		return nil, err
+	}
+	objs = append(objs, instances...)
+
+	if options.DeleteCertManager {
+		certManagerObjs, err := clusterClient.CertManager().ObjectsToDelete(ctx)
+		if err != nil {
+			return nil, err
+		}
+		objs = append(objs, certManagerObjs...)
+	}
+
+	return objs, nil
+}
+
+// componentsDeleteOptions returns the options for deleting the components of a provider.
+func componentsDeleteOptions(provider clusterctlv1.Provider, options DeleteOptions) cluster.DeleteOptions {
+	return cluster.DeleteOptions{
+		Provider:             provider,
+		ForceDeleteNamespace: options.ForceDeleteNamespace,
+		ForceDeleteCRD:       options.ForceDeleteCRD,
+		KeepInventory:        options.KeepInventory,
+	}
+}
+
+// providersToDelete returns the providers selected for deletion by options.
+func providersToDelete(ctx context.Context, clusterClient cluster.Client, options DeleteOptions) ([]clusterctlv1.Provider, error) {
+	if options.KeepInventory && options.ForceDeleteNamespace {
+		return nil, errors.New("the inventory can't be preserved when deleting the namespace where the providers are hosted")
+	}
+
+	if options.DeleteCertManager && len(options.Providers) > 0 {
+		return nil, errors.New("cert-manager can only be deleted together with all the providers")
+	}
+
+	if err := clusterClient.ProviderInventory().EnsureCustomResourceDefinitions(ctx); err != nil {
+		return nil, err
+	}
+
+	// Get the list of installed providers.
+	installedProviders, err := clusterClient.ProviderInventory().List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	// If the list of providers to delete is empty, delete all the providers.
 	// Prepare the list of providers to delete.
 	var providers []clusterctlv1.Provider
@@ -51,21 +159,21 @@ func (c *clusterctlClient) Delete(options DeleteOptions) error {
 			// Parse the abbreviated syntax for name[:version]
 			name, _, err := parseProviderName(provider)
 			if err != nil {
-				return err
+				return nil, err
 			}
 
 			// If the namespace where the provider is installed is not provided, try to detect it
 			namespace := options.Namespace
 			if namespace == "" {
-				namespace, err = clusterClient.ProviderInventory().GetDefaultProviderNamespace(name)
+				namespace, err = clusterClient.ProviderInventory().GetDefaultProviderNamespace(ctx, name)
 				if err != nil {
-					return err
+					return nil, err
 				}
 
 				// if there are more instance of a providers, it is not possible to get a default namespace for the provider,
 				// so we should return and ask for it.
 				if namespace == "" {
-					return errors.Errorf("Unable to find default namespace for the %q provider. Please specify the provider's namespace", name)
+					return nil, errors.Errorf("Unable to find default namespace for the %q provider. Please specify the provider's namespace", name)
 				}
 			}
 
@@ -79,11 +187,11 @@ func (c *clusterctlClient) Delete(options DeleteOptions) error {
 				}
 			}
 			if found {
-				break
+				continue
 			}
 
 			// In case the provider does not match any installed providers, we still force deletion
-			// so the user can do 'delete' without removing CRD and after some time 'delete --delete-crd' (same for the namespace).
+			// so the user can do 'delete' without removing CRD and after some time 'delete --include-crd' (same for the namespace).
 			providers = append(providers, clusterctlv1.Provider{ObjectMeta: metav1.ObjectMeta{
 				Name:      name,
 				Namespace: namespace,
@@ -91,12 +199,5 @@ func (c *clusterctlClient) Delete(options DeleteOptions) error {
 		}
 	}
 
-	// Delete the selected providers
-	for _, provider := range providers {
-		if err := clusterClient.ProviderComponents().Delete(cluster.DeleteOptions{Provider: provider, ForceDeleteNamespace: options.ForceDeleteNamespace, ForceDeleteCRD: options.ForceDeleteCRD}); err != nil {
-			return err
-		}
-	}
-
-	return nil
+	return providers, nil
 }
@@ -90,10 +90,70 @@ func Test_clusterctlClient_Delete(t *testing.T) {
 			wantProviders: sets.NewString(capiProviderConfig.Name()),
 			wantErr:       false,
 		},
+		{
+			name: "Delete all the providers while preserving the inventory",
+			fields: fields{
+				client: fakeClusterForDelete(),
+			},
+			args: args{
+				options: DeleteOptions{
+					Kubeconfig:    "kubeconfig",
+					KeepInventory: true,
+					Providers:     nil, // nil means all the providers
+				},
+			},
+			wantProviders: sets.NewString(capiProviderConfig.Name(), bootstrapProviderConfig.Name()),
+			wantErr:       false,
+		},
+		{
+			name: "Fails if preserving the inventory while deleting the namespace",
+			fields: fields{
+				client: fakeClusterForDelete(),
+			},
+			args: args{
+				options: DeleteOptions{
+					Kubeconfig:           "kubeconfig",
+					ForceDeleteNamespace: true,
+					KeepInventory:        true,
+					Providers:            nil, // nil means all the providers
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Delete all the providers and cert-manager",
+			fields: fields{
+				client: fakeClusterForDelete(),
+			},
+			args: args{
+				options: DeleteOptions{
+					Kubeconfig:        "kubeconfig",
+					DeleteCertManager: true,
+					Providers:         nil, // nil means all the providers
+				},
+			},
+			wantProviders: sets.NewString(),
+			wantErr:       false,
+		},
+		{
+			name: "Fails if deleting cert-manager together with a subset of the providers",
+			fields: fields{
+				client: fakeClusterForDelete(),
+			},
+			args: args{
+				options: DeleteOptions{
+					Kubeconfig:        "kubeconfig",
+					DeleteCertManager: true,
+					Namespace:         "capbpk-system",
+					Providers:         []string{bootstrapProviderConfig.Name()},
+				},
+			},
+			wantErr: true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if err := tt.fields.client.Delete(tt.args.options); (err != nil) != tt.wantErr {
+			if err := tt.fields.client.Delete(context.Background(), tt.args.options); (err != nil) != tt.wantErr {
 				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
 			}
 			if tt.wantErr {
@@ -124,6 +184,59 @@ func Test_clusterctlClient_Delete(t *testing.T) {
 	}
 }
 
+func Test_clusterctlClient_PreviewDelete(t *testing.T) {
+	tests := []struct {
+		name      string
+		options   DeleteOptions
+		wantNames []string
+	}{
+		{
+			name: "Preview the deletion of all the providers",
+			options: DeleteOptions{
+				Kubeconfig: "kubeconfig",
+				Providers:  nil, // nil means all the providers
+			},
+			wantNames: []string{capiProviderConfig.Name(), bootstrapProviderConfig.Name()},
+		},
+		{
+			name: "Preview the deletion of all the providers while preserving the inventory",
+			options: DeleteOptions{
+				Kubeconfig:    "kubeconfig",
+				KeepInventory: true,
+				Providers:     nil, // nil means all the providers
+			},
+			wantNames: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := fakeClusterForDelete()
+
+			objs, err := client.PreviewDelete(context.Background(), tt.options)
+			if err != nil {
+				t.Fatalf("error = %v, want nil", err)
+			}
+
+			gotNames := sets.NewString()
+			for _, o := range objs {
+				gotNames.Insert(o.GetName())
+			}
+			if !gotNames.Equal(sets.NewString(tt.wantNames...)) {
+				t.Errorf("got = %v, want %v", gotNames.List(), tt.wantNames)
+			}
+
+			// Previewing the deletion should not delete anything.
+			providers, err := client.clusters["kubeconfig"].ProviderInventory().List(context.Background())
+			if err != nil {
+				t.Fatalf("failed to read providers %v", err)
+			}
+			if len(providers.Items) != 2 {
+				t.Errorf("got %d providers, want 2", len(providers.Items))
+			}
+		})
+	}
+}
+
 // clusterctl client for a management cluster with capi and bootstrap provider
 func fakeClusterForDelete() *fakeClient {
 	config1 := newFakeConfig().
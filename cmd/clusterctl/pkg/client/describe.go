@@ -0,0 +1,77 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/client/cluster"
+)
+
+// DescribeProviderOptions carries the options supported by DescribeProvider.
+type DescribeProviderOptions struct {
+	// Kubeconfig file to use for accessing the management cluster. If empty, default rules for kubeconfig
+	// discovery will be used.
+	Kubeconfig string
+
+	// Provider to describe.
+	Provider string
+
+	// Namespace where the provider to describe lives. If not specified, all the instances of the provider are described.
+	Namespace string
+}
+
+func (c *clusterctlClient) DescribeProvider(ctx context.Context, options DescribeProviderOptions) ([]clusterctlv1.Provider, error) {
+	clusterClient, err := c.clusterClientFactory(options.Kubeconfig, "")
+	if err != nil {
+		return nil, err
+	}
+
+	return getProviderInstances(ctx, clusterClient, options.Provider, options.Namespace)
+}
+
+// getProviderInstances returns the instances of a provider installed in a management cluster, optionally
+// limited to the one hosted in the given namespace.
+func getProviderInstances(ctx context.Context, clusterClient cluster.Client, provider, namespace string) ([]clusterctlv1.Provider, error) {
+	if err := clusterClient.ProviderInventory().EnsureCustomResourceDefinitions(ctx); err != nil {
+		return nil, err
+	}
+
+	installedProviders, err := clusterClient.ProviderInventory().List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var providers []clusterctlv1.Provider
+	for _, p := range installedProviders.FilterByName(provider) {
+		if namespace != "" && p.Namespace != namespace {
+			continue
+		}
+		providers = append(providers, p)
+	}
+
+	if len(providers) == 0 {
+		if namespace != "" {
+			return nil, errors.Errorf("failed to find the %q provider in the %q namespace", provider, namespace)
+		}
+		return nil, errors.Errorf("failed to find the %q provider", provider)
+	}
+
+	return providers, nil
+}
@@ -0,0 +1,136 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+func Test_clusterctlClient_DescribeProvider(t *testing.T) {
+	type fields struct {
+		client *fakeClient
+	}
+	type args struct {
+		options DescribeProviderOptions
+	}
+	tests := []struct {
+		name           string
+		fields         fields
+		args           args
+		wantNamespaces sets.String
+		wantErr        bool
+	}{
+		{
+			name: "Describe all the instances of a provider",
+			fields: fields{
+				client: fakeClusterForDescribe(),
+			},
+			args: args{
+				options: DescribeProviderOptions{
+					Kubeconfig: "kubeconfig",
+					Provider:   capiProviderConfig.Name(),
+				},
+			},
+			wantNamespaces: sets.NewString("capi-system1", "capi-system2"),
+			wantErr:        false,
+		},
+		{
+			name: "Describe the instance of a provider in a namespace",
+			fields: fields{
+				client: fakeClusterForDescribe(),
+			},
+			args: args{
+				options: DescribeProviderOptions{
+					Kubeconfig: "kubeconfig",
+					Provider:   capiProviderConfig.Name(),
+					Namespace:  "capi-system2",
+				},
+			},
+			wantNamespaces: sets.NewString("capi-system2"),
+			wantErr:        false,
+		},
+		{
+			name: "Fails if the provider is not installed",
+			fields: fields{
+				client: fakeClusterForDescribe(),
+			},
+			args: args{
+				options: DescribeProviderOptions{
+					Kubeconfig: "kubeconfig",
+					Provider:   bootstrapProviderConfig.Name(),
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Fails if the provider is not installed in the namespace",
+			fields: fields{
+				client: fakeClusterForDescribe(),
+			},
+			args: args{
+				options: DescribeProviderOptions{
+					Kubeconfig: "kubeconfig",
+					Provider:   capiProviderConfig.Name(),
+					Namespace:  "foo",
+				},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.fields.client.DescribeProvider(context.Background(), tt.args.options)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			gotNamespaces := sets.NewString()
+			for _, p := range got {
+				if p.Name != tt.args.options.Provider {
+					t.Errorf("got provider %v, want %v", p.Name, tt.args.options.Provider)
+				}
+				gotNamespaces.Insert(p.Namespace)
+			}
+			if !reflect.DeepEqual(gotNamespaces, tt.wantNamespaces) {
+				t.Errorf("got = %v, want %v", gotNamespaces.List(), tt.wantNamespaces.List())
+			}
+		})
+	}
+}
+
+// clusterctl client for a management cluster with two instances of the capi provider
+func fakeClusterForDescribe() *fakeClient {
+	config1 := newFakeConfig().
+		WithProvider(capiProviderConfig).
+		WithProvider(bootstrapProviderConfig)
+
+	cluster1 := newFakeCluster("kubeconfig", config1)
+	cluster1.fakeProxy.WithProviderInventory(capiProviderConfig.Name(), capiProviderConfig.Type(), "v1.0.0", "capi-system1", "ns1")
+	cluster1.fakeProxy.WithProviderInventory(capiProviderConfig.Name(), capiProviderConfig.Type(), "v1.0.0", "capi-system2", "ns2")
+
+	client := newFakeClient(config1).
+		WithCluster(cluster1)
+
+	return client
+}
@@ -0,0 +1,81 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/client/cluster"
+)
+
+// The statuses of a certificate reported by DoctorCertificates.
+const (
+	CertificateStatusOK      = cluster.CertificateStatusOK
+	CertificateStatusWarning = cluster.CertificateStatusWarning
+	CertificateStatusError   = cluster.CertificateStatusError
+)
+
+// DoctorCertificatesOptions carries the options supported by DoctorCertificates.
+type DoctorCertificatesOptions struct {
+	// Kubeconfig file to use for accessing the management cluster. If empty, default rules for kubeconfig
+	// discovery will be used.
+	Kubeconfig string
+
+	// WarnBefore defines how long before expiry a certificate is reported as approaching expiry.
+	WarnBefore time.Duration
+
+	// Fix triggers the renewal of the cert-manager Certificates expired, not ready or approaching expiry.
+	Fix bool
+}
+
+func (c *clusterctlClient) DoctorCertificates(ctx context.Context, options DoctorCertificatesOptions) ([]CertificateReport, error) {
+	if options.WarnBefore < 0 {
+		return nil, errors.New("the time before expiry for reporting certificates as approaching expiry can't be negative")
+	}
+
+	clusterClient, err := c.clusterClientFactory(options.Kubeconfig, "")
+	if err != nil {
+		return nil, err
+	}
+
+	checker := clusterClient.CertificateChecker()
+	reports, err := checker.Check(ctx, options.WarnBefore)
+	if err != nil {
+		return nil, err
+	}
+
+	if options.Fix {
+		for i, r := range reports {
+			if r.Status == cluster.CertificateStatusOK || !r.CanRenew() {
+				continue
+			}
+			if err := checker.Renew(ctx, r); err != nil {
+				return nil, err
+			}
+			reports[i].Message = r.Message + "; renewal triggered"
+		}
+	}
+
+	// CertificateReport is an alias for cluster.CertificateReport; this makes the conversion
+	ret := make([]CertificateReport, len(reports))
+	for i, r := range reports {
+		ret[i] = CertificateReport(r)
+	}
+	return ret, nil
+}
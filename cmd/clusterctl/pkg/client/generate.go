@@ -0,0 +1,139 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/client/repository"
+)
+
+// providerRepoTypes maps the values accepted for GenerateProviderRepositoryOptions.Type to the provider types.
+var providerRepoTypes = map[string]clusterctlv1.ProviderType{
+	"infrastructure": clusterctlv1.InfrastructureProviderType,
+	"bootstrap":      clusterctlv1.BootstrapProviderType,
+	"control-plane":  clusterctlv1.ControlPlaneProviderType,
+}
+
+// GenerateProviderRepositoryOptions carries the options supported by GenerateProviderRepository.
+type GenerateProviderRepositoryOptions struct {
+	// Name of the provider, e.g. aws.
+	Name string
+
+	// Type of the provider, one of infrastructure, bootstrap or control-plane.
+	Type string
+
+	// Contract is the API Version of Cluster API (contract) supported by the first release series of the provider.
+	// By default (empty), the contract of this version of clusterctl is used.
+	Contract string
+
+	// TargetDirectory where the provider repository is generated. By default (empty), a directory named after the
+	// provider type and name (e.g. infrastructure-aws) is created in the current directory.
+	TargetDirectory string
+}
+
+func (c *clusterctlClient) GenerateProviderRepository(ctx context.Context, options GenerateProviderRepositoryOptions) ([]string, error) {
+	providerType, ok := providerRepoTypes[options.Type]
+	if !ok {
+		return nil, errors.Errorf("invalid provider type %q, it must be one of infrastructure, bootstrap or control-plane", options.Type)
+	}
+
+	files, err := repository.Scaffold(repository.ScaffoldOptions{
+		Name:     options.Name,
+		Type:     providerType,
+		Contract: options.Contract,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	targetDirectory := options.TargetDirectory
+	if targetDirectory == "" {
+		targetDirectory = options.Type + "-" + options.Name
+	}
+
+	// Existing files are never overwritten, so the command can't be used by mistake on an existing repository.
+	for _, f := range files {
+		path := filepath.Join(targetDirectory, filepath.FromSlash(f.Path))
+		if _, err := os.Stat(path); err == nil {
+			return nil, errors.Errorf("failed to generate the provider repository: %q already exists", path)
+		}
+	}
+
+	var paths []string
+	for _, f := range files {
+		path := filepath.Join(targetDirectory, filepath.FromSlash(f.Path))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return nil, errors.Wrapf(err, "failed to create the %q directory", filepath.Dir(path))
+		}
+		mode := os.FileMode(0644)
+		if f.Executable {
+			mode = 0755
+		}
+		if err := ioutil.WriteFile(path, f.Content, mode); err != nil {
+			return nil, errors.Wrapf(err, "failed to write %q", path)
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
+
+// ProcessYAMLOptions carries the options supported by ProcessYAML.
+type ProcessYAMLOptions struct {
+	// ReaderSource to be used for reading the template; only one template source can be used at time.
+	ReaderSource *ReaderSourceOptions
+
+	// URLSource to be used for reading the template; only one template source can be used at time.
+	URLSource *URLSourceOptions
+
+	// ListVariablesOnly return the list of variables expected by the template without executing any further processing.
+	ListVariablesOnly bool
+}
+
+// ReaderSourceOptions defines the options to be used when reading a template from a reader, e.g. stdin.
+type ReaderSourceOptions struct {
+	// Reader to read the template from.
+	Reader io.Reader
+}
+
+func (c *clusterctlClient) ProcessYAML(ctx context.Context, options ProcessYAMLOptions) (Template, error) {
+	if (options.ReaderSource == nil) == (options.URLSource == nil) {
+		return nil, errors.New("invalid ProcessYAML operation: exactly one template source must be set")
+	}
+
+	// Templates are processed as they are, so the objects are not moved to a target namespace.
+	if options.ReaderSource != nil {
+		content, err := ioutil.ReadAll(options.ReaderSource.Reader)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to read the template")
+		}
+		return repository.NewTemplate(content, c.configClient.Variables(), "", options.ListVariablesOnly)
+	}
+
+	// NB. The management cluster is not accessed for reading templates from an URL.
+	cluster, err := c.clusterClientFactory("", "")
+	if err != nil {
+		return nil, err
+	}
+	return cluster.Template().GetFromURL(options.URLSource.URL, "", options.ListVariablesOnly)
+}
@@ -0,0 +1,122 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func Test_clusterctlClient_GenerateProviderRepository(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "cc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	c := &clusterctlClient{}
+	options := GenerateProviderRepositoryOptions{
+		Name:            "foo",
+		Type:            "infrastructure",
+		TargetDirectory: filepath.Join(tmpDir, "infrastructure-foo"),
+	}
+
+	paths, err := c.GenerateProviderRepository(context.Background(), options)
+	if err != nil {
+		t.Fatalf("GenerateProviderRepository() error = %v", err)
+	}
+	if len(paths) == 0 {
+		t.Fatalf("GenerateProviderRepository() returned no files")
+	}
+	for _, p := range paths {
+		if _, err := os.Stat(p); err != nil {
+			t.Errorf("%s was not generated: %v", p, err)
+		}
+	}
+
+	info, err := os.Stat(filepath.Join(options.TargetDirectory, "hack", "release-manifests.sh"))
+	if err != nil {
+		t.Fatalf("the release script was not generated: %v", err)
+	}
+	if info.Mode()&0100 == 0 {
+		t.Errorf("the release script is not executable, mode = %v", info.Mode())
+	}
+
+	// Existing repositories are not overwritten.
+	if _, err := c.GenerateProviderRepository(context.Background(), options); err == nil {
+		t.Errorf("GenerateProviderRepository() on an existing repository, error = nil, want an error")
+	}
+
+	options.Type = "core"
+	options.TargetDirectory = filepath.Join(tmpDir, "core-foo")
+	if _, err := c.GenerateProviderRepository(context.Background(), options); err == nil {
+		t.Errorf("GenerateProviderRepository() with type core, error = nil, want an error")
+	}
+}
+
+func Test_clusterctlClient_ProcessYAML(t *testing.T) {
+	template := `# clusterctl:variables
+# - name: VALUE
+#   default: default
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: ${CONFIGMAP_NAME}
+  namespace: custom
+data:
+  value: ${VALUE}`
+
+	c := &clusterctlClient{
+		configClient: newFakeConfig().WithVar("CONFIGMAP_NAME", "foo"),
+	}
+
+	got, err := c.ProcessYAML(context.Background(), ProcessYAMLOptions{
+		ReaderSource: &ReaderSourceOptions{Reader: strings.NewReader(template)},
+	})
+	if err != nil {
+		t.Fatalf("ProcessYAML() error = %v", err)
+	}
+	objs := got.Objs()
+	if len(objs) != 1 {
+		t.Fatalf("got %d objects, want 1", len(objs))
+	}
+	if objs[0].GetName() != "foo" || objs[0].GetNamespace() != "custom" {
+		t.Errorf("got %s/%s, want custom/foo", objs[0].GetNamespace(), objs[0].GetName())
+	}
+	if value := objs[0].Object["data"].(map[string]interface{})["value"]; value != "default" {
+		t.Errorf("got value %v, want the default value of the variable", value)
+	}
+
+	got, err = c.ProcessYAML(context.Background(), ProcessYAMLOptions{
+		ReaderSource:      &ReaderSourceOptions{Reader: strings.NewReader(template)},
+		ListVariablesOnly: true,
+	})
+	if err != nil {
+		t.Fatalf("ProcessYAML() error = %v", err)
+	}
+	if vars := strings.Join(got.Variables(), ","); vars != "CONFIGMAP_NAME,VALUE" {
+		t.Errorf("got variables %s, want CONFIGMAP_NAME,VALUE", vars)
+	}
+
+	if _, err := c.ProcessYAML(context.Background(), ProcessYAMLOptions{}); err == nil {
+		t.Errorf("ProcessYAML() error = nil, want an error when no template source is set")
+	}
+}
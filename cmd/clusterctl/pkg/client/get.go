@@ -0,0 +1,172 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"reflect"
+	"time"
+
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/client/cluster"
+)
+
+// defaultWatchInterval is the interval between two reads of the status of the objects being watched.
+const defaultWatchInterval = 2 * time.Second
+
+// GetClustersOptions carries the options supported by GetClusters and WatchClusters.
+type GetClustersOptions struct {
+	// Kubeconfig file to use for accessing the management cluster. If empty, default rules for kubeconfig
+	// discovery will be used.
+	Kubeconfig string
+
+	// Namespace where the Clusters live. If not specified, the current namespace will be used.
+	Namespace string
+
+	// AllNamespaces gets the Clusters in all the namespaces; if set, Namespace is ignored.
+	AllNamespaces bool
+
+	// WatchInterval is the interval between two reads of the status of the Clusters by WatchClusters.
+	// If not specified, the status is read every 2 seconds.
+	WatchInterval time.Duration
+}
+
+// GetMachinesOptions carries the options supported by GetMachines and WatchMachines.
+type GetMachinesOptions struct {
+	// Kubeconfig file to use for accessing the management cluster. If empty, default rules for kubeconfig
+	// discovery will be used.
+	Kubeconfig string
+
+	// Namespace where the Machines live. If not specified, the current namespace will be used.
+	Namespace string
+
+	// AllNamespaces gets the Machines in all the namespaces; if set, Namespace is ignored.
+	AllNamespaces bool
+
+	// ClusterName restricts the Machines to the ones belonging to a Cluster. By default (empty), the Machines
+	// of all the Clusters are returned.
+	ClusterName string
+
+	// WatchInterval is the interval between two reads of the status of the Machines by WatchMachines.
+	// If not specified, the status is read every 2 seconds.
+	WatchInterval time.Duration
+}
+
+// ObjectStatusHandler receives the status of the objects being watched; if it returns an error, the watch is stopped.
+type ObjectStatusHandler func(objs []ObjectStatus) error
+
+func (c *clusterctlClient) GetClusters(ctx context.Context, options GetClustersOptions) ([]ObjectStatus, error) {
+	lister, namespace, err := c.statusLister(options.Kubeconfig, options.Namespace, options.AllNamespaces)
+	if err != nil {
+		return nil, err
+	}
+	return toObjectStatus(lister.ListClusters(ctx, namespace))
+}
+
+func (c *clusterctlClient) GetMachines(ctx context.Context, options GetMachinesOptions) ([]ObjectStatus, error) {
+	lister, namespace, err := c.statusLister(options.Kubeconfig, options.Namespace, options.AllNamespaces)
+	if err != nil {
+		return nil, err
+	}
+	return toObjectStatus(lister.ListMachines(ctx, namespace, options.ClusterName))
+}
+
+func (c *clusterctlClient) WatchClusters(ctx context.Context, options GetClustersOptions, handler ObjectStatusHandler) error {
+	lister, namespace, err := c.statusLister(options.Kubeconfig, options.Namespace, options.AllNamespaces)
+	if err != nil {
+		return err
+	}
+	return watchObjectStatus(ctx, options.WatchInterval, handler, func() ([]ObjectStatus, error) {
+		return toObjectStatus(lister.ListClusters(ctx, namespace))
+	})
+}
+
+func (c *clusterctlClient) WatchMachines(ctx context.Context, options GetMachinesOptions, handler ObjectStatusHandler) error {
+	lister, namespace, err := c.statusLister(options.Kubeconfig, options.Namespace, options.AllNamespaces)
+	if err != nil {
+		return err
+	}
+	return watchObjectStatus(ctx, options.WatchInterval, handler, func() ([]ObjectStatus, error) {
+		return toObjectStatus(lister.ListMachines(ctx, namespace, options.ClusterName))
+	})
+}
+
+// statusLister returns the StatusLister for a management cluster, and the namespace to list the objects from.
+func (c *clusterctlClient) statusLister(kubeconfig, namespace string, allNamespaces bool) (cluster.StatusLister, string, error) {
+	clusterClient, err := c.clusterClientFactory(kubeconfig, "")
+	if err != nil {
+		return nil, "", err
+	}
+
+	// If the option specifying the Namespace is empty, default it to the current namespace of the kubeconfig;
+	// if listing all the namespaces, the empty Namespace makes the lister to look in all the namespaces.
+	if allNamespaces {
+		namespace = ""
+	} else if namespace == "" {
+		currentNamespace, err := clusterClient.Proxy().CurrentNamespace()
+		if err != nil {
+			return nil, "", err
+		}
+		namespace = currentNamespace
+	}
+
+	return clusterClient.StatusLister(), namespace, nil
+}
+
+// watchObjectStatus reads the status of the objects every interval, and calls handler with the first status read and
+// then every time the status changes, until the context is cancelled or an error occurs.
+// NB. the status is polled because the management cluster proxy does not support watches.
+func watchObjectStatus(ctx context.Context, interval time.Duration, handler ObjectStatusHandler, list func() ([]ObjectStatus, error)) error {
+	if interval <= 0 {
+		interval = defaultWatchInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var last []ObjectStatus
+	first := true
+	for {
+		objs, err := list()
+		if err != nil {
+			return err
+		}
+		if first || !reflect.DeepEqual(objs, last) {
+			if err := handler(objs); err != nil {
+				return err
+			}
+			first = false
+			last = objs
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func toObjectStatus(objs []cluster.ObjectStatus, err error) ([]ObjectStatus, error) {
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]ObjectStatus, 0, len(objs))
+	for _, o := range objs {
+		ret = append(ret, ObjectStatus(o))
+	}
+	return ret, nil
+}
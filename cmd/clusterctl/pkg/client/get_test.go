@@ -0,0 +1,131 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+)
+
+func Test_clusterctlClient_GetMachines(t *testing.T) {
+	machine := func(namespace, name, clusterName string) *clusterv1.Machine {
+		return &clusterv1.Machine{
+			ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+			Spec:       clusterv1.MachineSpec{ClusterName: clusterName},
+		}
+	}
+
+	cluster1 := newFakeCluster("kubeconfig", nil).
+		WithObjs(machine("default", "m1", "foo"), machine("default", "m2", "bar"), machine("ns1", "m3", "foo"))
+	client := newFakeClient(nil).WithCluster(cluster1)
+
+	tests := []struct {
+		name    string
+		options GetMachinesOptions
+		want    []string
+	}{
+		{
+			name:    "Get the Machines in the current namespace",
+			options: GetMachinesOptions{Kubeconfig: "kubeconfig"},
+			want:    []string{"default/m2", "default/m1"},
+		},
+		{
+			name:    "Get the Machines of a Cluster in all the namespaces",
+			options: GetMachinesOptions{Kubeconfig: "kubeconfig", AllNamespaces: true, ClusterName: "foo"},
+			want:    []string{"default/m1", "ns1/m3"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := client.GetMachines(context.Background(), tt.options)
+			if err != nil {
+				t.Fatalf("GetMachines() error = %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d Machines, want %d", len(got), len(tt.want))
+			}
+			for i := range got {
+				if name := got[i].Namespace + "/" + got[i].Name; name != tt.want[i] {
+					t.Errorf("got Machine %s at position %d, want %s", name, i, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func Test_clusterctlClient_WatchClusters(t *testing.T) {
+	cluster := &clusterv1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "foo"},
+		Status:     clusterv1.ClusterStatus{Phase: string(clusterv1.ClusterPhaseProvisioning)},
+	}
+	cluster1 := newFakeCluster("kubeconfig", nil).WithObjs(cluster)
+	client := newFakeClient(nil).WithCluster(cluster1)
+
+	c, err := cluster1.Proxy().NewClient()
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var phases []string
+	err = client.WatchClusters(ctx, GetClustersOptions{Kubeconfig: "kubeconfig", WatchInterval: 10 * time.Millisecond}, func(objs []ObjectStatus) error {
+		if len(objs) != 1 {
+			return errors.Errorf("got %d Clusters, want 1", len(objs))
+		}
+		phases = append(phases, objs[0].Phase)
+
+		// Changes the phase after the first read, and stops watching once the change is observed.
+		if len(phases) == 1 {
+			updated := cluster.DeepCopy()
+			if err := c.Get(ctx, types.NamespacedName{Namespace: updated.Namespace, Name: updated.Name}, updated); err != nil {
+				return err
+			}
+			updated.Status.Phase = string(clusterv1.ClusterPhaseProvisioned)
+			return c.Update(ctx, updated)
+		}
+		cancel()
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("WatchClusters() error = %v", err)
+	}
+
+	want := []string{string(clusterv1.ClusterPhaseProvisioning), string(clusterv1.ClusterPhaseProvisioned)}
+	if len(phases) != len(want) || phases[0] != want[0] || phases[1] != want[1] {
+		t.Errorf("got phases %v, want %v", phases, want)
+	}
+}
+
+func Test_clusterctlClient_WatchClusters_StopsOnHandlerError(t *testing.T) {
+	cluster1 := newFakeCluster("kubeconfig", nil)
+	client := newFakeClient(nil).WithCluster(cluster1)
+
+	err := client.WatchClusters(context.Background(), GetClustersOptions{Kubeconfig: "kubeconfig", WatchInterval: 10 * time.Millisecond}, func(objs []ObjectStatus) error {
+		return errors.New("stop")
+	})
+	if err == nil || err.Error() != "stop" {
+		t.Errorf("WatchClusters() error = %v, want the error returned by the handler", err)
+	}
+}
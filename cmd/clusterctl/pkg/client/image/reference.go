@@ -0,0 +1,117 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package image
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// dockerHubDomain is the domain of the images without an explicit registry.
+	dockerHubDomain = "docker.io"
+
+	// dockerHubRegistry is the host serving the registry API for Docker Hub.
+	dockerHubRegistry = "registry-1.docker.io"
+
+	defaultTag = "latest"
+)
+
+// Reference is a parsed container image reference, e.g. k8s.gcr.io/cluster-api/cluster-api-controller:v0.3.0.
+type Reference struct {
+	// Image is the image as defined in the provider components.
+	Image string
+
+	// Registry is the host serving the registry API, e.g. k8s.gcr.io.
+	Registry string
+
+	// Repository is the path of the image in the registry, e.g. cluster-api/cluster-api-controller.
+	Repository string
+
+	// Tag is the image tag; it defaults to latest if neither the tag nor the digest are set.
+	Tag string
+
+	// Digest is the image digest, e.g. sha256:...
+	Digest string
+}
+
+// ParseReference parses a container image reference, applying the same defaults of the container runtimes
+// for images without a registry or a tag.
+func ParseReference(image string) (Reference, error) {
+	ref := Reference{Image: image}
+
+	name := image
+	if i := strings.Index(name, "@"); i >= 0 {
+		ref.Digest = name[i+1:]
+		name = name[:i]
+		if !strings.Contains(ref.Digest, ":") {
+			return Reference{}, errors.Errorf("invalid image %q: invalid digest", image)
+		}
+	}
+	// A colon after the last slash separates the tag; a colon before it is the port of the registry.
+	if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+		ref.Tag = name[i+1:]
+		name = name[:i]
+	}
+	if name == "" || ref.Tag == "" && strings.HasSuffix(image, ":") {
+		return Reference{}, errors.Errorf("invalid image %q", image)
+	}
+
+	// The first component is a registry only if it looks like a host, otherwise the image is on Docker Hub.
+	ref.Registry = dockerHubDomain
+	ref.Repository = name
+	if i := strings.Index(name, "/"); i >= 0 {
+		host := name[:i]
+		if strings.ContainsAny(host, ".:") || host == "localhost" {
+			ref.Registry = host
+			ref.Repository = name[i+1:]
+		}
+	}
+	if ref.Registry == dockerHubDomain {
+		ref.Registry = dockerHubRegistry
+		if !strings.Contains(ref.Repository, "/") {
+			ref.Repository = "library/" + ref.Repository
+		}
+	}
+
+	if ref.Tag == "" && ref.Digest == "" {
+		ref.Tag = defaultTag
+	}
+	return ref, nil
+}
+
+// manifestReference returns the tag or the digest used for reading the image manifest.
+func (r Reference) manifestReference() string {
+	if r.Digest != "" {
+		return r.Digest
+	}
+	return r.Tag
+}
+
+// WithDigest returns the image pinned to the given digest, e.g. for referencing the image of a single platform
+// of a multi-arch image.
+func (r Reference) WithDigest(digest string) string {
+	name := r.Image
+	if i := strings.Index(name, "@"); i >= 0 {
+		name = name[:i]
+	}
+	if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+		name = name[:i]
+	}
+	return name + "@" + digest
+}
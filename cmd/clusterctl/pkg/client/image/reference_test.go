@@ -0,0 +1,93 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package image
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestParseReference(t *testing.T) {
+	tests := []struct {
+		name    string
+		image   string
+		want    Reference
+		wantErr bool
+	}{
+		{
+			name:  "image with registry and tag",
+			image: "gcr.io/k8s-staging-cluster-api/cluster-api-controller:v0.3.0",
+			want:  Reference{Registry: "gcr.io", Repository: "k8s-staging-cluster-api/cluster-api-controller", Tag: "v0.3.0"},
+		},
+		{
+			name:  "image with registry port and without tag",
+			image: "localhost:5000/controller",
+			want:  Reference{Registry: "localhost:5000", Repository: "controller", Tag: "latest"},
+		},
+		{
+			name:  "docker hub official image",
+			image: "nginx:1.17",
+			want:  Reference{Registry: "registry-1.docker.io", Repository: "library/nginx", Tag: "1.17"},
+		},
+		{
+			name:  "docker hub image with organization",
+			image: "docker.io/jetstack/cert-manager-controller:v0.11.0",
+			want:  Reference{Registry: "registry-1.docker.io", Repository: "jetstack/cert-manager-controller", Tag: "v0.11.0"},
+		},
+		{
+			name:  "image with digest",
+			image: "quay.io/jetstack/cert-manager-webhook@sha256:abc",
+			want:  Reference{Registry: "quay.io", Repository: "jetstack/cert-manager-webhook", Digest: "sha256:abc"},
+		},
+		{
+			name:    "invalid digest",
+			image:   "quay.io/jetstack/cert-manager-webhook@abc",
+			wantErr: true,
+		},
+		{
+			name:    "empty tag",
+			image:   "quay.io/jetstack/cert-manager-webhook:",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			got, err := ParseReference(tt.image)
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+
+			tt.want.Image = tt.image
+			g.Expect(got).To(Equal(tt.want))
+		})
+	}
+}
+
+func TestReference_WithDigest(t *testing.T) {
+	g := NewWithT(t)
+
+	for _, image := range []string{"localhost:5000/controller:v1", "localhost:5000/controller@sha256:abc", "localhost:5000/controller"} {
+		ref, err := ParseReference(image)
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(ref.WithDigest("sha256:def")).To(Equal("localhost:5000/controller@sha256:def"))
+	}
+}
@@ -0,0 +1,340 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package image
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	kerrors "k8s.io/apimachinery/pkg/util/errors"
+)
+
+// Media types of the manifests supported by the Resolver.
+const (
+	mediaTypeDockerManifestList = "application/vnd.docker.distribution.manifest.list.v2+json"
+	mediaTypeDockerManifest     = "application/vnd.docker.distribution.manifest.v2+json"
+	mediaTypeOCIIndex           = "application/vnd.oci.image.index.v1+json"
+	mediaTypeOCIManifest        = "application/vnd.oci.image.manifest.v1+json"
+)
+
+// Platform is a platform an image is published for.
+type Platform struct {
+	// OS of the platform, e.g. linux.
+	OS string
+
+	// Architecture of the platform, e.g. amd64 or arm64.
+	Architecture string
+
+	// Variant of the architecture, e.g. v7 for arm.
+	Variant string
+
+	// Digest of the image manifest for the platform.
+	Digest string
+}
+
+// Resolver reads the platforms container images are published for from their registry.
+type Resolver interface {
+	// Platforms returns the platforms an image is published for; for a multi-arch image, i.e. a manifest list,
+	// one platform is returned for each image in the list.
+	Platforms(image string) ([]Platform, error)
+}
+
+// Option is a configuration option supplied to NewResolver.
+type Option func(*registryResolver)
+
+// InjectHTTPClient allows to override the HTTP client used for accessing the registries.
+func InjectHTTPClient(client *http.Client) Option {
+	return func(r *registryResolver) {
+		r.client = client
+	}
+}
+
+// InjectScheme allows to override the scheme used for accessing the registries, e.g. http for a local registry.
+func InjectScheme(scheme string) Option {
+	return func(r *registryResolver) {
+		r.scheme = scheme
+	}
+}
+
+// NewResolver returns a Resolver reading the image manifests with the registry HTTP API V2; only anonymous access
+// to the registries is supported.
+func NewResolver(options ...Option) Resolver {
+	r := &registryResolver{
+		client: &http.Client{Timeout: 30 * time.Second},
+		scheme: "https",
+	}
+	for _, o := range options {
+		o(r)
+	}
+	return r
+}
+
+type registryResolver struct {
+	client *http.Client
+	scheme string
+}
+
+var _ Resolver = &registryResolver{}
+
+// manifest contains the fields of the manifests and of the manifest lists used by the Resolver.
+type manifest struct {
+	MediaType string `json:"mediaType"`
+	Manifests []struct {
+		Digest   string `json:"digest"`
+		Platform struct {
+			OS           string `json:"os"`
+			Architecture string `json:"architecture"`
+			Variant      string `json:"variant"`
+		} `json:"platform"`
+	} `json:"manifests"`
+	Config struct {
+		Digest string `json:"digest"`
+	} `json:"config"`
+}
+
+func (r *registryResolver) Platforms(image string) ([]Platform, error) {
+	ref, err := ParseReference(image)
+	if err != nil {
+		return nil, err
+	}
+
+	accept := strings.Join([]string{mediaTypeDockerManifestList, mediaTypeOCIIndex, mediaTypeDockerManifest, mediaTypeOCIManifest}, ", ")
+	body, header, err := r.get(ref, fmt.Sprintf("/v2/%s/manifests/%s", ref.Repository, ref.manifestReference()), accept)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read the manifest of image %q", image)
+	}
+
+	m := &manifest{}
+	if err := json.Unmarshal(body, m); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse the manifest of image %q", image)
+	}
+	mediaType := m.MediaType
+	if mediaType == "" {
+		mediaType = strings.Split(header.Get("Content-Type"), ";")[0]
+	}
+
+	switch mediaType {
+	case mediaTypeDockerManifestList, mediaTypeOCIIndex:
+		var platforms []Platform
+		for _, p := range m.Manifests {
+			platforms = append(platforms, Platform{
+				OS:           p.Platform.OS,
+				Architecture: p.Platform.Architecture,
+				Variant:      p.Platform.Variant,
+				Digest:       p.Digest,
+			})
+		}
+		return platforms, nil
+	case mediaTypeDockerManifest, mediaTypeOCIManifest:
+		// The platform of a single-arch image is defined in the image configuration.
+		configBody, _, err := r.get(ref, fmt.Sprintf("/v2/%s/blobs/%s", ref.Repository, m.Config.Digest), "*/*")
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read the configuration of image %q", image)
+		}
+		config := &struct {
+			OS           string `json:"os"`
+			Architecture string `json:"architecture"`
+			Variant      string `json:"variant"`
+		}{}
+		if err := json.Unmarshal(configBody, config); err != nil {
+			return nil, errors.Wrapf(err, "failed to parse the configuration of image %q", image)
+		}
+		return []Platform{{
+			OS:           config.OS,
+			Architecture: config.Architecture,
+			Variant:      config.Variant,
+			Digest:       header.Get("Docker-Content-Digest"),
+		}}, nil
+	default:
+		return nil, errors.Errorf("unsupported manifest media type %q for image %q", mediaType, image)
+	}
+}
+
+// get reads a registry API path; if the registry requires a token, an anonymous token is requested
+// to the authorization server and the request is retried.
+func (r *registryResolver) get(ref Reference, path, accept string) ([]byte, http.Header, error) {
+	u := fmt.Sprintf("%s://%s%s", r.scheme, ref.Registry, path)
+
+	resp, err := r.do(u, accept, "")
+	if err != nil {
+		return nil, nil, err
+	}
+	if resp.StatusCode == http.StatusUnauthorized {
+		challenge := resp.Header.Get("WWW-Authenticate")
+		resp.Body.Close()
+
+		token, err := r.token(challenge, ref.Repository)
+		if err != nil {
+			return nil, nil, err
+		}
+		if resp, err = r.do(u, accept, token); err != nil {
+			return nil, nil, err
+		}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, nil, errors.Errorf("unexpected response from %q: %s", u, resp.Status)
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "failed to read the response from %q", u)
+	}
+	return body, resp.Header, nil
+}
+
+func (r *registryResolver) do(u, accept, token string) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to create a request for %q", u)
+	}
+	req.Header.Set("Accept", accept)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get %q", u)
+	}
+	return resp, nil
+}
+
+// token requests an anonymous token with pull access to a repository, as defined by the Bearer challenge
+// returned by the registry.
+func (r *registryResolver) token(challenge, repository string) (string, error) {
+	if !strings.HasPrefix(challenge, "Bearer ") {
+		return "", errors.Errorf("unsupported authentication challenge %q, only anonymous access to the registries is supported", challenge)
+	}
+	params := parseChallenge(strings.TrimPrefix(challenge, "Bearer "))
+	if params["realm"] == "" {
+		return "", errors.Errorf("invalid authentication challenge %q: missing realm", challenge)
+	}
+
+	query := url.Values{}
+	if params["service"] != "" {
+		query.Set("service", params["service"])
+	}
+	scope := params["scope"]
+	if scope == "" {
+		scope = fmt.Sprintf("repository:%s:pull", repository)
+	}
+	query.Set("scope", scope)
+
+	u := params["realm"] + "?" + query.Encode()
+	resp, err := r.do(u, "application/json", "")
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", errors.Errorf("failed to get a token from %q: %s", params["realm"], resp.Status)
+	}
+
+	t := &struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}{}
+	if err := json.NewDecoder(resp.Body).Decode(t); err != nil {
+		return "", errors.Wrapf(err, "failed to parse the token from %q", params["realm"])
+	}
+	if t.Token != "" {
+		return t.Token, nil
+	}
+	return t.AccessToken, nil
+}
+
+// parseChallenge parses the comma separated key="value" parameters of an authentication challenge.
+func parseChallenge(s string) map[string]string {
+	params := map[string]string{}
+	for s != "" {
+		i := strings.Index(s, "=")
+		if i < 0 {
+			break
+		}
+		key := strings.TrimSpace(s[:i])
+		s = s[i+1:]
+
+		var value string
+		if strings.HasPrefix(s, `"`) {
+			end := strings.Index(s[1:], `"`)
+			if end < 0 {
+				value, s = s[1:], ""
+			} else {
+				value, s = s[1:end+1], s[end+2:]
+			}
+		} else if end := strings.Index(s, ","); end >= 0 {
+			value, s = s[:end], s[end:]
+		} else {
+			value, s = s, ""
+		}
+		params[key] = value
+		s = strings.TrimLeft(s, ", ")
+	}
+	return params
+}
+
+// ForArchitectures resolves the images for the given architectures, returning for each image and architecture the
+// image pinned to the digest of the linux platform, e.g. for mirroring only the required platforms of multi-arch
+// images. An error is returned if any image is not published for all the architectures.
+func ForArchitectures(resolver Resolver, images []string, architectures []string) ([]string, error) {
+	var ret []string
+	var errs []error
+	for _, image := range images {
+		ref, err := ParseReference(image)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		platforms, err := resolver.Platforms(image)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		var missing []string
+		for _, arch := range architectures {
+			digest := ""
+			for _, p := range platforms {
+				if p.OS == "linux" && p.Architecture == arch {
+					digest = p.Digest
+					break
+				}
+			}
+			if digest == "" {
+				missing = append(missing, arch)
+				continue
+			}
+			ret = append(ret, ref.WithDigest(digest))
+		}
+		if len(missing) > 0 {
+			errs = append(errs, errors.Errorf("image %q is not published for the %s architectures", image, strings.Join(missing, ", ")))
+		}
+	}
+	if len(errs) > 0 {
+		return nil, kerrors.NewAggregate(errs)
+	}
+
+	sort.Strings(ret)
+	return ret, nil
+}
@@ -0,0 +1,208 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package image
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+const (
+	testManifestList = `{
+  "mediaType": "application/vnd.docker.distribution.manifest.list.v2+json",
+  "manifests": [
+    {"digest": "sha256:amd64", "platform": {"os": "linux", "architecture": "amd64"}},
+    {"digest": "sha256:arm64", "platform": {"os": "linux", "architecture": "arm64", "variant": "v8"}}
+  ]
+}`
+	testManifest = `{
+  "mediaType": "application/vnd.docker.distribution.manifest.v2+json",
+  "config": {"digest": "sha256:config"}
+}`
+	testConfig = `{"os": "linux", "architecture": "amd64"}`
+)
+
+// newTestRegistry returns a registry serving a multi-arch image (multi:v1) and a single-arch image (single:v1);
+// if withToken is set, the registry requires an anonymous bearer token.
+func newTestRegistry(withToken bool) *httptest.Server {
+	mux := http.NewServeMux()
+	var server *httptest.Server
+	authorized := func(w http.ResponseWriter, r *http.Request) bool {
+		if withToken && r.Header.Get("Authorization") != "Bearer test-token" {
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="test"`, server.URL))
+			w.WriteHeader(http.StatusUnauthorized)
+			return false
+		}
+		return true
+	}
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("service") != "test" || !strings.HasSuffix(r.URL.Query().Get("scope"), ":pull") {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		fmt.Fprint(w, `{"token": "test-token"}`)
+	})
+	mux.HandleFunc("/v2/multi/manifests/v1", func(w http.ResponseWriter, r *http.Request) {
+		if authorized(w, r) {
+			fmt.Fprint(w, testManifestList)
+		}
+	})
+	mux.HandleFunc("/v2/single/manifests/v1", func(w http.ResponseWriter, r *http.Request) {
+		if authorized(w, r) {
+			w.Header().Set("Docker-Content-Digest", "sha256:single")
+			fmt.Fprint(w, testManifest)
+		}
+	})
+	mux.HandleFunc("/v2/single/blobs/sha256:config", func(w http.ResponseWriter, r *http.Request) {
+		if authorized(w, r) {
+			fmt.Fprint(w, testConfig)
+		}
+	})
+	server = httptest.NewServer(mux)
+	return server
+}
+
+func TestResolver_Platforms(t *testing.T) {
+	tests := []struct {
+		name      string
+		image     string
+		withToken bool
+		want      []Platform
+		wantErr   bool
+	}{
+		{
+			name:  "multi-arch image",
+			image: "multi:v1",
+			want: []Platform{
+				{OS: "linux", Architecture: "amd64", Digest: "sha256:amd64"},
+				{OS: "linux", Architecture: "arm64", Variant: "v8", Digest: "sha256:arm64"},
+			},
+		},
+		{
+			name:      "multi-arch image on a registry requiring a token",
+			image:     "multi:v1",
+			withToken: true,
+			want: []Platform{
+				{OS: "linux", Architecture: "amd64", Digest: "sha256:amd64"},
+				{OS: "linux", Architecture: "arm64", Variant: "v8", Digest: "sha256:arm64"},
+			},
+		},
+		{
+			name:  "single-arch image",
+			image: "single:v1",
+			want: []Platform{
+				{OS: "linux", Architecture: "amd64", Digest: "sha256:single"},
+			},
+		},
+		{
+			name:    "image not found",
+			image:   "missing:v1",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			server := newTestRegistry(tt.withToken)
+			defer server.Close()
+
+			r := NewResolver(InjectScheme("http"))
+			got, err := r.Platforms(strings.TrimPrefix(server.URL, "http://") + "/" + tt.image)
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(got).To(Equal(tt.want))
+		})
+	}
+}
+
+type fakeResolver map[string][]Platform
+
+func (f fakeResolver) Platforms(image string) ([]Platform, error) {
+	return f[image], nil
+}
+
+func TestForArchitectures(t *testing.T) {
+	resolver := fakeResolver{
+		"example.com/multi:v1": {
+			{OS: "linux", Architecture: "amd64", Digest: "sha256:amd64"},
+			{OS: "linux", Architecture: "arm64", Digest: "sha256:arm64"},
+		},
+		"example.com/single:v1": {
+			{OS: "linux", Architecture: "amd64", Digest: "sha256:single"},
+		},
+	}
+
+	tests := []struct {
+		name          string
+		images        []string
+		architectures []string
+		want          []string
+		wantErr       bool
+	}{
+		{
+			name:          "resolves the images for all the architectures",
+			images:        []string{"example.com/multi:v1"},
+			architectures: []string{"amd64", "arm64"},
+			want:          []string{"example.com/multi@sha256:amd64", "example.com/multi@sha256:arm64"},
+		},
+		{
+			name:          "resolves the images for one architecture",
+			images:        []string{"example.com/multi:v1", "example.com/single:v1"},
+			architectures: []string{"amd64"},
+			want:          []string{"example.com/multi@sha256:amd64", "example.com/single@sha256:single"},
+		},
+		{
+			name:          "fails if an image is not published for an architecture",
+			images:        []string{"example.com/multi:v1", "example.com/single:v1"},
+			architectures: []string{"arm64"},
+			wantErr:       true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			got, err := ForArchitectures(resolver, tt.images, tt.architectures)
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(got).To(Equal(tt.want))
+		})
+	}
+}
+
+func Test_parseChallenge(t *testing.T) {
+	g := NewWithT(t)
+
+	got := parseChallenge(`realm="https://auth.docker.io/token",service="registry.docker.io",scope="repository:library/nginx:pull"`)
+	g.Expect(got).To(Equal(map[string]string{
+		"realm":   "https://auth.docker.io/token",
+		"service": "registry.docker.io",
+		"scope":   "repository:library/nginx:pull",
+	}))
+}
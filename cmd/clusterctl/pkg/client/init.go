@@ -17,29 +17,67 @@ limitations under the License.
 package client
 
 import (
+	"context"
 	"sort"
+	"strings"
 
 	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
 	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/client/cluster"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/client/config"
-	logf "sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/log"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/client/image"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/client/repository"
 )
 
 const NoopProvider = "-"
 
+// validateInventoryNamespaces checks that the providers are installed in, and watch, one of the namespaces the
+// inventory is scoped to.
+func validateInventoryNamespaces(options InitOptions) error {
+	inventoryNamespaces := sets.NewString(options.InventoryNamespaces...)
+	if !inventoryNamespaces.Has(options.TargetNamespace) {
+		return errors.Errorf("the target namespace %q must be one of the inventory namespaces %s", options.TargetNamespace, strings.Join(options.InventoryNamespaces, ", "))
+	}
+	if !inventoryNamespaces.Has(options.WatchingNamespace) {
+		return errors.Errorf("the watching namespace %q must be one of the inventory namespaces %s", options.WatchingNamespace, strings.Join(options.InventoryNamespaces, ", "))
+	}
+	for name, namespace := range options.ProviderNamespaces {
+		if !inventoryNamespaces.Has(namespace) {
+			return errors.Errorf("the target namespace %q of the %q provider must be one of the inventory namespaces %s", namespace, name, strings.Join(options.InventoryNamespaces, ", "))
+		}
+	}
+	return nil
+}
+
 // Init initializes a management cluster by adding the requested list of providers.
-func (c *clusterctlClient) Init(options InitOptions) ([]Components, error) {
-	log := logf.Log
+func (c *clusterctlClient) Init(ctx context.Context, options InitOptions) ([]Components, error) {
+	log := c.log
 
 	// gets access to the management cluster
-	cluster, err := c.clusterClientFactory(options.Kubeconfig)
+	cluster, err := c.clusterClientFactory(options.Kubeconfig, "")
 	if err != nil {
 		return nil, err
 	}
 
+	// if requested, scopes the inventory to the given namespaces, ignoring the providers of other users of the management cluster
+	if len(options.InventoryNamespaces) > 0 {
+		if err := validateInventoryNamespaces(options); err != nil {
+			return nil, err
+		}
+		cluster = cluster.WithInventoryNamespaces(options.InventoryNamespaces...)
+	}
+
+	// prevents other clusterctl operations from changing the management cluster concurrently
+	release, err := cluster.OperationLock().Acquire(ctx, "init")
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
 	// ensure the custom resource definitions required by clusterctl are in place
-	if err := cluster.ProviderInventory().EnsureCustomResourceDefinitions(); err != nil {
+	if err := cluster.ProviderInventory().EnsureCustomResourceDefinitions(ctx); err != nil {
 		return nil, err
 	}
 
@@ -47,11 +85,11 @@ func (c *clusterctlClient) Init(options InitOptions) ([]Components, error) {
 	// if not we consider this the first time init is executed, and thus we enforce the installation of a core provider,
 	// a bootstrap provider and a control-plane provider (if not already explicitly requested by the user)
 	log.Info("Fetching providers")
-	firstRun := c.addDefaultProviders(cluster, &options)
+	firstRun := c.addDefaultProviders(ctx, cluster, &options)
 
 	// create an installer service, add the requested providers to the install queue and then perform validation
 	// of the target state of the management cluster before starting the installation.
-	installer, err := c.setupInstaller(cluster, options)
+	installer, err := c.setupInstaller(ctx, cluster, options)
 	if err != nil {
 		return nil, err
 	}
@@ -62,16 +100,24 @@ func (c *clusterctlClient) Init(options InitOptions) ([]Components, error) {
 	// - Providers combines in valid management groups
 	//   - All the providers should belong to one/only one management groups
 	//   - All the providers in a management group must support the same API Version of Cluster API (contract)
-	if err := installer.Validate(); err != nil {
+	if err := installer.Validate(ctx); err != nil {
 		return nil, err
 	}
 
+	// If requested, ensure all the images are published for the architectures of the management cluster nodes, so
+	// there are no pods failing with exec format errors after the installation.
+	if options.ValidateImageArchitectures {
+		if err := c.validateImageArchitectures(ctx, cluster, installer); err != nil {
+			return nil, err
+		}
+	}
+
 	// Before installing the providers, ensure the cert-manager Webhook is in place.
-	if err := cluster.CertManager().EnsureWebhook(); err != nil {
+	if err := cluster.CertManager().EnsureWebhook(ctx); err != nil {
 		return nil, err
 	}
 
-	components, err := installer.Install()
+	components, err := installer.Install(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -96,27 +142,35 @@ func (c *clusterctlClient) Init(options InitOptions) ([]Components, error) {
 }
 
 // Init returns the list of images required for init.
-func (c *clusterctlClient) InitImages(options InitOptions) ([]string, error) {
+func (c *clusterctlClient) InitImages(ctx context.Context, options InitOptions) ([]string, error) {
 	// gets access to the management cluster
-	cluster, err := c.clusterClientFactory(options.Kubeconfig)
+	cluster, err := c.clusterClientFactory(options.Kubeconfig, "")
 	if err != nil {
 		return nil, err
 	}
 
+	// if requested, scopes the inventory to the given namespaces, ignoring the providers of other users of the management cluster
+	if len(options.InventoryNamespaces) > 0 {
+		if err := validateInventoryNamespaces(options); err != nil {
+			return nil, err
+		}
+		cluster = cluster.WithInventoryNamespaces(options.InventoryNamespaces...)
+	}
+
 	// checks if the cluster already contains a Core provider.
 	// if not we consider this the first time init is executed, and thus we enforce the installation of a core provider,
 	// a bootstrap provider and a control-plane provider (if not already explicitly requested by the user)
-	c.addDefaultProviders(cluster, &options)
+	c.addDefaultProviders(ctx, cluster, &options)
 
 	// create an installer service, add the requested providers to the install queue and then perform validation
 	// of the target state of the management cluster before starting the installation.
-	installer, err := c.setupInstaller(cluster, options)
+	installer, err := c.setupInstaller(ctx, cluster, options)
 	if err != nil {
 		return nil, err
 	}
 
 	// Gets the list of container images required for the cert-manager (if not already installed).
-	images, err := cluster.CertManager().Images()
+	images, err := cluster.CertManager().Images(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -124,46 +178,148 @@ func (c *clusterctlClient) InitImages(options InitOptions) ([]string, error) {
 	// Appends the list of container images required for the selected providers.
 	images = append(images, installer.Images()...)
 
+	// If requested, resolves the images for the target architectures.
+	if len(options.Architectures) > 0 {
+		return image.ForArchitectures(c.imageResolver, images, options.Architectures)
+	}
+
 	sort.Strings(images)
 	return images, nil
 }
 
-func (c *clusterctlClient) setupInstaller(cluster cluster.Client, options InitOptions) (cluster.ProviderInstaller, error) {
+// InitPermissions returns the permissions granted to the providers installed by init.
+func (c *clusterctlClient) InitPermissions(ctx context.Context, options InitOptions) ([]ProviderPermissions, error) {
+	// gets access to the management cluster
+	cluster, err := c.clusterClientFactory(options.Kubeconfig, "")
+	if err != nil {
+		return nil, err
+	}
+
+	// if requested, scopes the inventory to the given namespaces, ignoring the providers of other users of the management cluster
+	if len(options.InventoryNamespaces) > 0 {
+		if err := validateInventoryNamespaces(options); err != nil {
+			return nil, err
+		}
+		cluster = cluster.WithInventoryNamespaces(options.InventoryNamespaces...)
+	}
+
+	// checks if the cluster already contains a Core provider, and if not adds the default providers like init does.
+	c.addDefaultProviders(ctx, cluster, &options)
+
+	// create an installer service and add the requested providers to the install queue.
+	installer, err := c.setupInstaller(ctx, cluster, options)
+	if err != nil {
+		return nil, err
+	}
+
+	permissions, err := installer.Permissions()
+	if err != nil {
+		return nil, err
+	}
+
+	// ProviderPermissions is an alias for cluster.ProviderPermissions; this makes the conversion
+	ret := make([]ProviderPermissions, len(permissions))
+	for i, p := range permissions {
+		ret[i] = ProviderPermissions(p)
+	}
+	return ret, nil
+}
+
+// validateImageArchitectures checks that the images required by the cert-manager and by the providers being installed
+// are published for all the architectures of the management cluster nodes.
+func (c *clusterctlClient) validateImageArchitectures(ctx context.Context, cluster cluster.Client, installer cluster.ProviderInstaller) error {
+	architectures, err := nodeArchitectures(ctx, cluster)
+	if err != nil {
+		return err
+	}
+
+	images, err := cluster.CertManager().Images(ctx)
+	if err != nil {
+		return err
+	}
+	images = append(images, installer.Images()...)
+
+	if _, err := image.ForArchitectures(c.imageResolver, images, architectures); err != nil {
+		return errors.Wrap(err, "failed to validate the images for the architectures of the management cluster nodes")
+	}
+	return nil
+}
+
+// nodeArchitectures returns the architectures of the management cluster nodes.
+func nodeArchitectures(ctx context.Context, cluster cluster.Client) ([]string, error) {
+	c, err := cluster.Proxy().NewClient()
+	if err != nil {
+		return nil, err
+	}
+
+	nodes := &corev1.NodeList{}
+	if err := c.List(ctx, nodes); err != nil {
+		return nil, errors.Wrap(err, "failed to list the management cluster nodes")
+	}
+
+	architectures := sets.NewString()
+	for _, node := range nodes.Items {
+		if node.Status.NodeInfo.Architecture != "" {
+			architectures.Insert(node.Status.NodeInfo.Architecture)
+		}
+	}
+	return architectures.List(), nil
+}
+
+func (c *clusterctlClient) setupInstaller(ctx context.Context, cluster cluster.Client, options InitOptions) (cluster.ProviderInstaller, error) {
 	installer := cluster.ProviderInstaller()
 
 	addOptions := addToInstallerOptions{
-		installer:         installer,
-		targetNamespace:   options.TargetNamespace,
-		watchingNamespace: options.WatchingNamespace,
+		installer:          installer,
+		targetNamespace:    options.TargetNamespace,
+		watchingNamespace:  options.WatchingNamespace,
+		providerNamespaces: options.ProviderNamespaces,
+		renderedComponents: options.RenderedComponents,
+		usedComponents:     sets.NewString(),
+		usedNamespaces:     sets.NewString(),
 	}
 
 	if options.CoreProvider != "" {
-		if err := c.addToInstaller(addOptions, clusterctlv1.CoreProviderType, options.CoreProvider); err != nil {
+		if err := c.addToInstaller(ctx, addOptions, clusterctlv1.CoreProviderType, options.CoreProvider); err != nil {
 			return nil, err
 		}
 	}
 
-	if err := c.addToInstaller(addOptions, clusterctlv1.BootstrapProviderType, options.BootstrapProviders...); err != nil {
+	if err := c.addToInstaller(ctx, addOptions, clusterctlv1.BootstrapProviderType, options.BootstrapProviders...); err != nil {
 		return nil, err
 	}
 
-	if err := c.addToInstaller(addOptions, clusterctlv1.ControlPlaneProviderType, options.ControlPlaneProviders...); err != nil {
+	if err := c.addToInstaller(ctx, addOptions, clusterctlv1.ControlPlaneProviderType, options.ControlPlaneProviders...); err != nil {
 		return nil, err
 	}
 
-	if err := c.addToInstaller(addOptions, clusterctlv1.InfrastructureProviderType, options.InfrastructureProviders...); err != nil {
+	if err := c.addToInstaller(ctx, addOptions, clusterctlv1.InfrastructureProviderType, options.InfrastructureProviders...); err != nil {
 		return nil, err
 	}
 
+	// Rendered components must be used by one of the providers being installed.
+	for name := range options.RenderedComponents {
+		if !addOptions.usedComponents.Has(name) {
+			return nil, errors.Errorf("rendered components are provided for the %q provider, which is not being installed", name)
+		}
+	}
+
+	// Provider namespaces must be used by one of the providers being installed.
+	for name := range options.ProviderNamespaces {
+		if !addOptions.usedNamespaces.Has(name) {
+			return nil, errors.Errorf("a target namespace is provided for the %q provider, which is not being installed", name)
+		}
+	}
+
 	return installer, nil
 }
 
-func (c *clusterctlClient) addDefaultProviders(cluster cluster.Client, options *InitOptions) bool {
+func (c *clusterctlClient) addDefaultProviders(ctx context.Context, cluster cluster.Client, options *InitOptions) bool {
 	firstRun := false
 	// Check if there is already a core provider installed in the cluster
 	// Nb. we are ignoring the error so this operation can support listing images even if there is no an existing management cluster;
 	// in case there is no an existing management cluster, we assume there are no core providers installed in the cluster.
-	currentCoreProvider, _ := cluster.ProviderInventory().GetDefaultProviderName(clusterctlv1.CoreProviderType)
+	currentCoreProvider, _ := cluster.ProviderInventory().GetDefaultProviderName(ctx, clusterctlv1.CoreProviderType)
 
 	// If there are no core providers installed in the cluster, consider this a first run and add default providers to the list
 	// of providers to be installed.
@@ -183,13 +339,17 @@ func (c *clusterctlClient) addDefaultProviders(cluster cluster.Client, options *
 }
 
 type addToInstallerOptions struct {
-	installer         cluster.ProviderInstaller
-	targetNamespace   string
-	watchingNamespace string
+	installer          cluster.ProviderInstaller
+	targetNamespace    string
+	watchingNamespace  string
+	providerNamespaces map[string]string
+	renderedComponents map[string][]byte
+	usedComponents     sets.String
+	usedNamespaces     sets.String
 }
 
 // addToInstaller adds the components to the install queue and checks that the actual provider type match the target group
-func (c *clusterctlClient) addToInstaller(options addToInstallerOptions, targetGroup clusterctlv1.ProviderType, providers ...string) error {
+func (c *clusterctlClient) addToInstaller(ctx context.Context, options addToInstallerOptions, targetGroup clusterctlv1.ProviderType, providers ...string) error {
 	for _, provider := range providers {
 		// It is possible to opt-out from automatic installation of bootstrap/controlPlane providers using '-' as a provider name (NoopProvider).
 		if provider == NoopProvider {
@@ -199,7 +359,7 @@ func (c *clusterctlClient) addToInstaller(options addToInstallerOptions, targetG
 			continue
 		}
 
-		components, err := c.getComponentsByName(provider, options.targetNamespace, options.watchingNamespace)
+		components, err := c.getComponents(ctx, options, provider)
 		if err != nil {
 			return errors.Wrapf(err, "failed to get provider components for the %q provider", provider)
 		}
@@ -212,3 +372,38 @@ func (c *clusterctlClient) addToInstaller(options addToInstallerOptions, targetG
 	}
 	return nil
 }
+
+// getComponents returns the components for a provider, using the rendered components if provided for the provider.
+func (c *clusterctlClient) getComponents(ctx context.Context, options addToInstallerOptions, provider string) (repository.Components, error) {
+	name, version, err := parseProviderName(provider)
+	if err != nil {
+		return nil, err
+	}
+
+	targetNamespace := options.targetNamespace
+	if namespace, ok := options.providerNamespaces[name]; ok {
+		targetNamespace = namespace
+		options.usedNamespaces.Insert(name)
+	}
+
+	rawyaml, ok := options.renderedComponents[name]
+	if !ok {
+		return c.getComponentsByName(ctx, provider, targetNamespace, options.watchingNamespace)
+	}
+	options.usedComponents.Insert(name)
+
+	// The version of rendered components can't be derived from the provider repository.
+	if version == "" {
+		return nil, errors.Errorf("the version of the %q provider must be specified when using rendered components, e.g. %s:v1.0.0", name, name)
+	}
+	if repository.IsVersionSelector(version) {
+		return nil, errors.Errorf("the version of the %q provider must be a release when using rendered components, e.g. %s:v1.0.0, got %q", name, name, version)
+	}
+
+	providerConfig, err := c.configClient.Providers().Get(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return repository.NewRenderedComponents(providerConfig, version, rawyaml, targetNamespace, options.watchingNamespace)
+}
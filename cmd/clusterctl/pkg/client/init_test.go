@@ -17,6 +17,7 @@ limitations under the License.
 package client
 
 import (
+	"context"
 	"fmt"
 	"testing"
 
@@ -39,6 +40,8 @@ func Test_clusterctlClient_Init(t *testing.T) {
 		infrastructureProvider []string
 		targetNameSpace        string
 		watchingNamespace      string
+		providerNamespaces     map[string]string
+		renderedComponents     map[string][]byte
 	}
 	type want struct {
 		provider          Provider
@@ -318,17 +321,130 @@ func Test_clusterctlClient_Init(t *testing.T) {
 			want:    nil,
 			wantErr: true,
 		},
+		{
+			name: "Init (with an empty cluster) with rendered components",
+			field: field{
+				client: fakeEmptyCluster(), // clusterctl client for an empty management cluster (with repository setup for capi, bootstrap, control plane and infra provider)
+				hasCRD: false,
+			},
+			args: args{
+				coreProvider:           "",
+				bootstrapProvider:      []string{"-"},
+				controlPlaneProvider:   []string{"-"},
+				infrastructureProvider: []string{"infra:v3.0.0"},
+				targetNameSpace:        "",
+				watchingNamespace:      "",
+				renderedComponents:     map[string][]byte{"infra": renderedComponentsYAML("ns5")},
+			},
+			want: []want{
+				{
+					provider:          capiProviderConfig,
+					version:           "v1.0.0",
+					targetNamespace:   "ns1",
+					watchingNamespace: "",
+				},
+				{
+					provider:          infraProviderConfig,
+					version:           "v3.0.0",
+					targetNamespace:   "ns5",
+					watchingNamespace: "",
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "Fails when the version of a provider with rendered components is not set",
+			field: field{
+				client: fakeEmptyCluster(), // clusterctl client for an empty management cluster (with repository setup for capi, bootstrap, control plane and infra provider)
+			},
+			args: args{
+				coreProvider:           "",
+				bootstrapProvider:      nil,
+				controlPlaneProvider:   nil,
+				infrastructureProvider: []string{"infra"}, // version is missing
+				targetNameSpace:        "",
+				watchingNamespace:      "",
+				renderedComponents:     map[string][]byte{"infra": renderedComponentsYAML("ns5")},
+			},
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name: "Fails when rendered components are provided for a provider not being installed",
+			field: field{
+				client: fakeEmptyCluster(), // clusterctl client for an empty management cluster (with repository setup for capi, bootstrap, control plane and infra provider)
+			},
+			args: args{
+				coreProvider:           "",
+				bootstrapProvider:      nil,
+				controlPlaneProvider:   nil,
+				infrastructureProvider: nil,
+				targetNameSpace:        "",
+				watchingNamespace:      "",
+				renderedComponents:     map[string][]byte{"infra": renderedComponentsYAML("ns5")}, // infra is not installed
+			},
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name: "Init (with an empty cluster) with a target namespace for a provider",
+			field: field{
+				client: fakeEmptyCluster(), // clusterctl client for an empty management cluster (with repository setup for capi, bootstrap, control plane and infra provider)
+				hasCRD: false,
+			},
+			args: args{
+				coreProvider:           "",
+				bootstrapProvider:      []string{"-"},
+				controlPlaneProvider:   []string{"-"},
+				infrastructureProvider: []string{"infra"},
+				targetNameSpace:        "",
+				watchingNamespace:      "",
+				providerNamespaces:     map[string]string{"infra": "ns9"},
+			},
+			want: []want{
+				{
+					provider:          capiProviderConfig,
+					version:           "v1.0.0",
+					targetNamespace:   "ns1",
+					watchingNamespace: "",
+				},
+				{
+					provider:          infraProviderConfig,
+					version:           "v3.0.0",
+					targetNamespace:   "ns9",
+					watchingNamespace: "",
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "Fails when a target namespace is provided for a provider not being installed",
+			field: field{
+				client: fakeEmptyCluster(), // clusterctl client for an empty management cluster (with repository setup for capi, bootstrap, control plane and infra provider)
+			},
+			args: args{
+				coreProvider:           "",
+				bootstrapProvider:      nil,
+				controlPlaneProvider:   nil,
+				infrastructureProvider: nil,
+				targetNameSpace:        "",
+				watchingNamespace:      "",
+				providerNamespaces:     map[string]string{"infra": "ns9"}, // infra is not installed
+			},
+			want:    nil,
+			wantErr: true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 
 			if tt.field.hasCRD {
-				if err := tt.field.client.clusters["kubeconfig"].ProviderInventory().EnsureCustomResourceDefinitions(); err != nil {
+				if err := tt.field.client.clusters["kubeconfig"].ProviderInventory().EnsureCustomResourceDefinitions(context.Background()); err != nil {
 					t.Fatalf("EnsureMetadata() error = %v", err)
 				}
 			}
 
-			got, err := tt.field.client.Init(InitOptions{
+			got, err := tt.field.client.Init(context.Background(), InitOptions{
 				Kubeconfig:              "kubeconfig",
 				CoreProvider:            tt.args.coreProvider,
 				BootstrapProviders:      tt.args.bootstrapProvider,
@@ -336,6 +452,8 @@ func Test_clusterctlClient_Init(t *testing.T) {
 				InfrastructureProviders: tt.args.infrastructureProvider,
 				TargetNamespace:         tt.args.targetNameSpace,
 				WatchingNamespace:       tt.args.watchingNamespace,
+				ProviderNamespaces:      tt.args.providerNamespaces,
+				RenderedComponents:      tt.args.renderedComponents,
 			})
 
 			if (err != nil) != tt.wantErr {
@@ -500,6 +618,22 @@ func componentsYAML(ns string) []byte {
 	return util.JoinYaml(namespaceYaml, podYaml)
 }
 
+// renderedComponentsYAML returns components YAML as rendered by a separate pipeline step, with all the objects in the target namespace.
+func renderedComponentsYAML(ns string) []byte {
+	var namespaceYaml = []byte("apiVersion: v1\n" +
+		"kind: Namespace\n" +
+		"metadata:\n" +
+		fmt.Sprintf("  name: %s", ns))
+
+	var podYaml = []byte("apiVersion: v1\n" +
+		"kind: Pod\n" +
+		"metadata:\n" +
+		"  name: manager\n" +
+		fmt.Sprintf("  namespace: %s", ns))
+
+	return util.JoinYaml(namespaceYaml, podYaml)
+}
+
 func templateYAML(ns string, clusterName string) []byte {
 	var podYaml = []byte("apiVersion: v1\n" +
 		"kind: Cluster\n" +
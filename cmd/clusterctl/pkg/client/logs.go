@@ -0,0 +1,164 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	kerrors "k8s.io/apimachinery/pkg/util/errors"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/client/cluster"
+)
+
+// ProviderLogsOptions carries the options supported by ProviderLogs.
+type ProviderLogsOptions struct {
+	// Kubeconfig file to use for accessing the management cluster. If empty, default rules for kubeconfig
+	// discovery will be used.
+	Kubeconfig string
+
+	// Provider to read the logs from.
+	Provider string
+
+	// Namespace where the provider lives. It is required only if there are many instances of the provider
+	// installed in the management cluster.
+	Namespace string
+
+	// Follow the logs until the operation is interrupted.
+	Follow bool
+
+	// Since, if not zero, limits the logs to the ones written after the given duration relative to the current time.
+	Since time.Duration
+
+	// Previous returns the logs of the previous instance of the provider controllers, if any.
+	Previous bool
+}
+
+func (c *clusterctlClient) ProviderLogs(ctx context.Context, options ProviderLogsOptions, out io.Writer) error {
+	clusterClient, err := c.clusterClientFactory(options.Kubeconfig, "")
+	if err != nil {
+		return err
+	}
+
+	providers, err := getProviderInstances(ctx, clusterClient, options.Provider, options.Namespace)
+	if err != nil {
+		return err
+	}
+
+	if len(providers) > 1 {
+		return errors.Errorf("there are %d instances of the %q provider, please specify the namespace of the instance to read the logs from", len(providers), options.Provider)
+	}
+
+	return clusterClient.ProviderLogs().Stream(ctx, providers[0], cluster.LogsOptions{
+		Follow:   options.Follow,
+		Since:    options.Since,
+		Previous: options.Previous,
+	}, out)
+}
+
+// ClusterLogsOptions carries the options supported by ClusterLogs.
+type ClusterLogsOptions struct {
+	// Kubeconfig file to use for accessing the management cluster. If empty, default rules for kubeconfig
+	// discovery will be used.
+	Kubeconfig string
+
+	// ClusterName of the workload cluster to read the logs for.
+	ClusterName string
+
+	// Namespace where the workload cluster lives. If not specified, the current namespace will be used.
+	Namespace string
+
+	// Since, if not zero, limits the logs to the ones written after the given duration relative to the current time.
+	Since time.Duration
+
+	// Previous returns the logs of the previous instance of the provider controllers, if any.
+	Previous bool
+
+	// TailLines, if not zero, limits the logs read from each provider controller to the given number of most recent
+	// lines; by default, only the most recent lines are read, bounding the memory used for merging the logs.
+	TailLines int64
+}
+
+func (c *clusterctlClient) ClusterLogs(ctx context.Context, options ClusterLogsOptions, out io.Writer) error {
+	clusterClient, err := c.clusterClientFactory(options.Kubeconfig, "")
+	if err != nil {
+		return err
+	}
+
+	// If the option specifying the Namespace is empty, default it to the current namespace of the kubeconfig.
+	if options.Namespace == "" {
+		currentNamespace, err := clusterClient.Proxy().CurrentNamespace()
+		if err != nil {
+			return err
+		}
+		options.Namespace = currentNamespace
+	}
+
+	if err := clusterClient.ProviderInventory().EnsureCustomResourceDefinitions(ctx); err != nil {
+		return err
+	}
+
+	providerList, err := clusterClient.ProviderInventory().List(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Reads the logs of all the providers; if reading the logs from a provider fails, the logs of the other providers
+	// are printed anyway, given that they can still be useful for debugging.
+	errList := []error{}
+	var lines []cluster.LogLine
+	for _, provider := range providerList.Items {
+		providerLines, err := clusterClient.ProviderLogs().Read(ctx, provider, cluster.LogsOptions{
+			Since:     options.Since,
+			Previous:  options.Previous,
+			TailLines: options.TailLines,
+		})
+		if err != nil {
+			errList = append(errList, err)
+			continue
+		}
+		lines = append(lines, providerLines...)
+	}
+
+	for _, l := range filterClusterLogLines(lines, options.ClusterName, options.Namespace) {
+		if _, err := fmt.Fprintln(out, l.String()); err != nil {
+			return errors.Wrap(err, "failed to write logs")
+		}
+	}
+
+	return kerrors.NewAggregate(errList)
+}
+
+// filterClusterLogLines returns the log lines mentioning both the name and the namespace of a cluster,
+// sorted in chronological order.
+func filterClusterLogLines(lines []cluster.LogLine, name, namespace string) []cluster.LogLine {
+	var ret []cluster.LogLine
+	for _, l := range lines {
+		if strings.Contains(l.Text, name) && strings.Contains(l.Text, namespace) {
+			ret = append(ret, l)
+		}
+	}
+
+	sort.SliceStable(ret, func(i, j int) bool {
+		return ret[i].Timestamp.Before(ret[j].Timestamp)
+	})
+	return ret
+}
@@ -0,0 +1,197 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/client/cluster"
+)
+
+func Test_clusterctlClient_ProviderLogs(t *testing.T) {
+	type args struct {
+		options ProviderLogsOptions
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "Read the logs of the instance of a provider in a namespace",
+			args: args{
+				options: ProviderLogsOptions{
+					Kubeconfig: "kubeconfig",
+					Provider:   capiProviderConfig.Name(),
+					Namespace:  "capi-system2",
+				},
+			},
+			want:    "[pod1/manager] fake logs\n",
+			wantErr: false,
+		},
+		{
+			name: "Fails if there are many instances of the provider and the namespace is not specified",
+			args: args{
+				options: ProviderLogsOptions{
+					Kubeconfig: "kubeconfig",
+					Provider:   capiProviderConfig.Name(),
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Fails if the provider is not installed",
+			args: args{
+				options: ProviderLogsOptions{
+					Kubeconfig: "kubeconfig",
+					Provider:   bootstrapProviderConfig.Name(),
+				},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := fakeClusterForLogs()
+
+			out := &bytes.Buffer{}
+			err := client.ProviderLogs(context.Background(), tt.args.options, out)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			if out.String() != tt.want {
+				t.Errorf("got = %q, want %q", out.String(), tt.want)
+			}
+		})
+	}
+}
+
+func Test_clusterctlClient_ClusterLogs(t *testing.T) {
+	client := fakeClusterForLogs()
+
+	out := &bytes.Buffer{}
+	err := client.ClusterLogs(context.Background(), ClusterLogsOptions{
+		Kubeconfig:  "kubeconfig",
+		ClusterName: "foo",
+	}, out)
+
+	// The instance of the capi provider in the capi-system1 namespace does not have a running controller, so reading
+	// logs fails; nevertheless the logs for the other instance should be processed.
+	if err == nil {
+		t.Fatal("error = nil, want an error for the capi provider in the capi-system1 namespace")
+	}
+
+	// None of the log lines in the capi-system2 namespace mention the cluster.
+	if out.String() != "" {
+		t.Errorf("got = %q, want empty", out.String())
+	}
+}
+
+func Test_filterClusterLogLines(t *testing.T) {
+	t1 := time.Date(2020, 3, 12, 10, 0, 0, 0, time.UTC)
+	t2 := t1.Add(time.Second)
+	t3 := t2.Add(time.Second)
+
+	lines := []cluster.LogLine{
+		{Timestamp: t3, Pod: "capa", Container: "manager", Text: `"msg"="Reconciling AWSCluster" "cluster"="foo" "namespace"="ns1"`},
+		{Timestamp: t1, Pod: "capi", Container: "manager", Text: `"msg"="Reconciling Cluster" "cluster"="foo" "namespace"="ns1"`},
+		{Timestamp: t2, Pod: "capi", Container: "manager", Text: `"msg"="Reconciling Cluster" "cluster"="bar" "namespace"="ns1"`}, // another cluster
+		{Timestamp: t2, Pod: "capi", Container: "manager", Text: `"msg"="Reconciling Cluster" "cluster"="foo" "namespace"="ns2"`}, // a cluster with the same name in another namespace
+		{Timestamp: t2, Pod: "cabpk", Container: "manager", Text: `"msg"="Reconciling KubeadmConfig" "kubeadmconfig"="ns1/foo-abcde"`},
+	}
+
+	got := filterClusterLogLines(lines, "foo", "ns1")
+
+	want := []string{
+		`[capi/manager] 2020-03-12T10:00:00Z "msg"="Reconciling Cluster" "cluster"="foo" "namespace"="ns1"`,
+		`[cabpk/manager] 2020-03-12T10:00:01Z "msg"="Reconciling KubeadmConfig" "kubeadmconfig"="ns1/foo-abcde"`,
+		`[capa/manager] 2020-03-12T10:00:02Z "msg"="Reconciling AWSCluster" "cluster"="foo" "namespace"="ns1"`,
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got = %v lines, want %v", len(got), len(want))
+	}
+	for i := range got {
+		if got[i].String() != want[i] {
+			t.Errorf("got[%d] = %q, want %q", i, got[i].String(), want[i])
+		}
+	}
+}
+
+// clusterctl client for a management cluster with two instances of the capi provider, with a running controller
+// for the instance in the capi-system2 namespace.
+func fakeClusterForLogs() *fakeClient {
+	config1 := newFakeConfig().
+		WithProvider(capiProviderConfig).
+		WithProvider(bootstrapProviderConfig)
+
+	selector := map[string]string{"control-plane": "controller-manager"}
+
+	cluster1 := newFakeCluster("kubeconfig", config1).
+		WithProviderInventory(capiProviderConfig.Name(), capiProviderConfig.Type(), "v1.0.0", "capi-system1", "ns1").
+		WithProviderInventory(capiProviderConfig.Name(), capiProviderConfig.Type(), "v1.0.0", "capi-system2", "ns2").
+		WithObjs(
+			&appsv1.Deployment{
+				TypeMeta: metav1.TypeMeta{
+					Kind: "Deployment",
+				},
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "capi-system2",
+					Name:      "controller-manager",
+					Labels: map[string]string{
+						clusterctlv1.ClusterctlLabelName: "",
+						clusterv1.ProviderLabelName:      capiProviderConfig.Name(),
+					},
+				},
+				Spec: appsv1.DeploymentSpec{
+					Selector: &metav1.LabelSelector{
+						MatchLabels: selector,
+					},
+				},
+			},
+			&corev1.Pod{
+				TypeMeta: metav1.TypeMeta{
+					Kind: "Pod",
+				},
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "capi-system2",
+					Name:      "pod1",
+					Labels:    selector,
+				},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{Name: "manager"}},
+				},
+			},
+		)
+
+	client := newFakeClient(config1).
+		WithCluster(cluster1)
+
+	return client
+}
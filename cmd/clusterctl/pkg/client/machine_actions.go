@@ -0,0 +1,54 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+)
+
+// RebootMachineOptions carries the options supported by RebootMachine.
+type RebootMachineOptions struct {
+	// Kubeconfig file to use for accessing the management cluster. If empty, default rules for kubeconfig
+	// discovery will be used.
+	Kubeconfig string
+
+	// Namespace where the Machine lives. If not specified, the current namespace will be used.
+	Namespace string
+
+	// Machine to be rebooted.
+	Machine string
+}
+
+func (c *clusterctlClient) RebootMachine(ctx context.Context, options RebootMachineOptions) error {
+	clusterClient, err := c.clusterClientFactory(options.Kubeconfig, "")
+	if err != nil {
+		return err
+	}
+
+	// If the option specifying the Namespace is empty, default it to the current namespace of the kubeconfig.
+	if options.Namespace == "" {
+		currentNamespace, err := clusterClient.Proxy().CurrentNamespace()
+		if err != nil {
+			return err
+		}
+		options.Namespace = currentNamespace
+	}
+
+	return clusterClient.MachineActions().Request(ctx, options.Namespace, options.Machine, clusterv1.RebootAction)
+}
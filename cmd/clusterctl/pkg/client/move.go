@@ -16,26 +16,47 @@ limitations under the License.
 
 package client
 
-func (c *clusterctlClient) Move(options MoveOptions) error {
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/client/cluster"
+)
+
+func (c *clusterctlClient) Move(ctx context.Context, options MoveOptions) error {
 	// Get the client for interacting with the source management cluster.
-	fromCluster, err := c.clusterClientFactory(options.FromKubeconfig)
+	fromCluster, err := c.clusterClientFactory(options.FromKubeconfig, "")
 	if err != nil {
 		return err
 	}
 
+	// Prevents other clusterctl operations from changing the source management cluster concurrently.
+	releaseFrom, err := fromCluster.OperationLock().Acquire(ctx, "move")
+	if err != nil {
+		return err
+	}
+	defer releaseFrom()
+
 	// Ensures the custom resource definitions required by clusterctl are in place.
-	if err := fromCluster.ProviderInventory().EnsureCustomResourceDefinitions(); err != nil {
+	if err := fromCluster.ProviderInventory().EnsureCustomResourceDefinitions(ctx); err != nil {
 		return err
 	}
 
 	// Get the client for interacting with the target management cluster.
-	toCluster, err := c.clusterClientFactory(options.ToKubeconfig)
+	toCluster, err := c.clusterClientFactory(options.ToKubeconfig, "")
 	if err != nil {
 		return err
 	}
 
+	// Prevents other clusterctl operations from changing the target management cluster concurrently.
+	releaseTo, err := toCluster.OperationLock().Acquire(ctx, "move")
+	if err != nil {
+		return err
+	}
+	defer releaseTo()
+
 	// Ensures the custom resource definitions required by clusterctl are in place
-	if err := toCluster.ProviderInventory().EnsureCustomResourceDefinitions(); err != nil {
+	if err := toCluster.ProviderInventory().EnsureCustomResourceDefinitions(ctx); err != nil {
 		return err
 	}
 
@@ -48,7 +69,16 @@ func (c *clusterctlClient) Move(options MoveOptions) error {
 		options.Namespace = currentNamespace
 	}
 
-	if err := fromCluster.ObjectMover().Move(options.Namespace, toCluster); err != nil {
+	// Checks the conversion webhooks are working in both the management clusters, given that objects are read from the
+	// source management cluster and created in the target management cluster using the storage version of the source CRDs.
+	if err := fromCluster.ConversionWebhooks().Check(ctx); err != nil {
+		return errors.Wrap(err, "cannot start the move operation")
+	}
+	if err := toCluster.ConversionWebhooks().Check(ctx); err != nil {
+		return errors.Wrap(err, "cannot start the move operation")
+	}
+
+	if err := fromCluster.ObjectMover().Move(ctx, options.Namespace, toCluster, cluster.NamespaceMapping(options.NamespaceMapping)); err != nil {
 		return err
 	}
 
@@ -0,0 +1,202 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	kerrors "k8s.io/apimachinery/pkg/util/errors"
+	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/client/cluster"
+)
+
+// MultiClusterOptions carries the options supported by the operations running across many management clusters.
+type MultiClusterOptions struct {
+	// Kubeconfig file to use for accessing the management clusters. If empty, default rules for kubeconfig
+	// discovery will be used.
+	Kubeconfig string
+
+	// Contexts in the kubeconfig file to use for accessing the management clusters. By default (empty),
+	// all the contexts defined in the kubeconfig file are used; the clusters without the clusterctl inventory are skipped.
+	Contexts []string
+}
+
+// ClusterProviders defines the providers installed in a management cluster.
+type ClusterProviders struct {
+	// Context used for accessing the management cluster.
+	Context string
+
+	// Providers installed in the management cluster.
+	Providers []clusterctlv1.Provider
+
+	// Error occurred while getting the providers from the management cluster, if any.
+	Error error
+}
+
+// ClusterUpgradePlans defines the upgrade plans for a management cluster.
+type ClusterUpgradePlans struct {
+	// Context used for accessing the management cluster.
+	Context string
+
+	// UpgradePlans for the management cluster.
+	UpgradePlans []UpgradePlan
+
+	// Error occurred while computing the upgrade plans for the management cluster, if any.
+	Error error
+}
+
+// ClusterHealth defines the health of the providers installed in a management cluster.
+type ClusterHealth struct {
+	// Context used for accessing the management cluster.
+	Context string
+
+	// Providers reports the health of each provider instance installed in the management cluster.
+	Providers []ProviderHealth
+
+	// Error occurred while checking the health of the management cluster, if any; an error is reported also
+	// when one of the provider instances is not healthy.
+	Error error
+}
+
+// ProviderHealth defines the health of a provider instance.
+type ProviderHealth struct {
+	// Provider instance.
+	Provider clusterctlv1.Provider
+
+	// Error reporting why the provider instance is not healthy; nil means the provider instance is healthy.
+	Error error
+}
+
+func (c *clusterctlClient) ListProvidersInClusters(ctx context.Context, options MultiClusterOptions) ([]ClusterProviders, error) {
+	var ret []ClusterProviders
+	err := c.forEachCluster(ctx, options, func(context string, clusterClient cluster.Client) error {
+		result := ClusterProviders{Context: context}
+		result.Error = func() error {
+			providerList, err := clusterClient.ProviderInventory().List(ctx)
+			if err != nil {
+				return err
+			}
+			result.Providers = providerList.Items
+			return nil
+		}()
+		ret = append(ret, result)
+		return result.Error
+	})
+	return ret, err
+}
+
+func (c *clusterctlClient) PlanUpgradeInClusters(ctx context.Context, options MultiClusterOptions) ([]ClusterUpgradePlans, error) {
+	var ret []ClusterUpgradePlans
+	err := c.forEachCluster(ctx, options, func(context string, clusterClient cluster.Client) error {
+		result := ClusterUpgradePlans{Context: context}
+		result.UpgradePlans, result.Error = planUpgrade(ctx, clusterClient)
+		ret = append(ret, result)
+		return result.Error
+	})
+	return ret, err
+}
+
+func (c *clusterctlClient) CheckHealthInClusters(ctx context.Context, options MultiClusterOptions) ([]ClusterHealth, error) {
+	var ret []ClusterHealth
+	err := c.forEachCluster(ctx, options, func(context string, clusterClient cluster.Client) error {
+		result := ClusterHealth{Context: context}
+		result.Error = func() error {
+			providerList, err := clusterClient.ProviderInventory().List(ctx)
+			if err != nil {
+				return err
+			}
+
+			errList := []error{}
+			for _, provider := range providerList.Items {
+				healthErr := clusterClient.ProviderComponents().CheckHealth(ctx, provider)
+				if healthErr != nil {
+					errList = append(errList, healthErr)
+				}
+
+				// Records the result of the check in the inventory, so it is reported by kubectl get providers; failing to
+				// record it, e.g. because of missing permissions, does not affect the result of the check.
+				provider.Status = cluster.ProviderHealthStatus(provider, healthErr)
+				if err := clusterClient.ProviderInventory().UpdateStatus(ctx, provider); err != nil {
+					c.log.V(1).Info("Failed to record the provider health in the inventory", "Provider", provider.InstanceName(), "Error", err.Error())
+				}
+
+				result.Providers = append(result.Providers, ProviderHealth{
+					Provider: provider,
+					Error:    healthErr,
+				})
+			}
+			return kerrors.NewAggregate(errList)
+		}()
+		ret = append(ret, result)
+		return result.Error
+	})
+	return ret, err
+}
+
+// forEachCluster runs a read-only operation on each management cluster selected by the given options.
+// The operation is run on all the management clusters, even if it fails on some of them; in this case the errors
+// returned for each management cluster are aggregated.
+// The clusters without the clusterctl inventory are skipped: installing the inventory CRD would change every cluster
+// in the kubeconfig file, including the ones that are not management clusters.
+func (c *clusterctlClient) forEachCluster(ctx context.Context, options MultiClusterOptions, operation func(context string, clusterClient cluster.Client) error) error {
+	contexts := options.Contexts
+	if len(contexts) == 0 {
+		clusterClient, err := c.clusterClientFactory(options.Kubeconfig, "")
+		if err != nil {
+			return err
+		}
+
+		contexts, err = clusterClient.Proxy().GetContexts()
+		if err != nil {
+			return err
+		}
+	}
+
+	errList := []error{}
+	for _, context := range contexts {
+		// If the operation has been cancelled, skips the remaining management clusters.
+		if err := ctx.Err(); err != nil {
+			errList = append(errList, errors.Wrap(err, "operation interrupted"))
+			break
+		}
+
+		if err := c.runOnCluster(ctx, options.Kubeconfig, context, operation); err != nil {
+			errList = append(errList, errors.Wrapf(err, "failed to run the operation on the management cluster for the %q context", context))
+		}
+	}
+	return kerrors.NewAggregate(errList)
+}
+
+// runOnCluster runs an operation on the management cluster for a context, if the clusterctl inventory is installed.
+func (c *clusterctlClient) runOnCluster(ctx context.Context, kubeconfig, context string, operation func(context string, clusterClient cluster.Client) error) error {
+	clusterClient, err := c.clusterClientFactory(kubeconfig, context)
+	if err != nil {
+		return err
+	}
+
+	installed, err := clusterClient.ProviderInventory().HasCustomResourceDefinitions(ctx)
+	if err != nil {
+		return err
+	}
+	if !installed {
+		c.log.Info("Skipping the cluster without the clusterctl inventory", "Context", context)
+		return nil
+	}
+
+	return operation(context, clusterClient)
+}
@@ -0,0 +1,238 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/client/config"
+)
+
+func Test_clusterctlClient_ListProvidersInClusters(t *testing.T) {
+	type args struct {
+		options MultiClusterOptions
+	}
+	tests := []struct {
+		name         string
+		args         args
+		wantContexts []string
+		wantErr      bool
+	}{
+		{
+			name: "list providers in the given contexts",
+			args: args{
+				options: MultiClusterOptions{
+					Kubeconfig: "kubeconfig",
+					Contexts:   []string{"ctx2"},
+				},
+			},
+			wantContexts: []string{"ctx2"},
+			wantErr:      false,
+		},
+		{
+			name: "list providers in all the contexts of the kubeconfig file",
+			args: args{
+				options: MultiClusterOptions{
+					Kubeconfig: "kubeconfig",
+				},
+			},
+			wantContexts: []string{"ctx1", "ctx2"},
+			wantErr:      false,
+		},
+		{
+			name: "returns results for the reachable clusters and an error for the others",
+			args: args{
+				options: MultiClusterOptions{
+					Kubeconfig: "kubeconfig",
+					Contexts:   []string{"ctx1", "ctx3"},
+				},
+			},
+			wantContexts: []string{"ctx1"},
+			wantErr:      true,
+		},
+		{
+			name: "skips the clusters without the clusterctl inventory",
+			args: args{
+				options: MultiClusterOptions{
+					Kubeconfig: "kubeconfig",
+					Contexts:   []string{"ctx1", "ctx4"},
+				},
+			},
+			wantContexts: []string{"ctx1"},
+			wantErr:      false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := fakeClientForMultiCluster()
+
+			got, err := client.ListProvidersInClusters(context.Background(), tt.args.options)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if len(got) != len(tt.wantContexts) {
+				t.Fatalf("got = %v results, want %v", len(got), len(tt.wantContexts))
+			}
+			for i := range got {
+				if got[i].Context != tt.wantContexts[i] {
+					t.Errorf("got[%d].Context = %v, want %v", i, got[i].Context, tt.wantContexts[i])
+				}
+				if got[i].Error != nil {
+					t.Errorf("got[%d].Error = %v, want nil", i, got[i].Error)
+				}
+				if len(got[i].Providers) != 1 {
+					t.Errorf("got[%d].Providers = %v providers, want 1", i, len(got[i].Providers))
+				}
+			}
+		})
+	}
+}
+
+func Test_clusterctlClient_PlanUpgradeInClusters(t *testing.T) {
+	client := fakeClientForMultiCluster()
+
+	got, err := client.PlanUpgradeInClusters(context.Background(), MultiClusterOptions{
+		Kubeconfig: "kubeconfig",
+		Contexts:   []string{"ctx1", "ctx2"},
+	})
+	if err != nil {
+		t.Fatalf("error = %v, want nil", err)
+	}
+
+	if len(got) != 2 {
+		t.Fatalf("got = %v results, want 2", len(got))
+	}
+	for i := range got {
+		if len(got[i].UpgradePlans) != 1 {
+			t.Fatalf("got[%d].UpgradePlans = %v plans, want 1", i, len(got[i].UpgradePlans))
+		}
+		plan := got[i].UpgradePlans[0]
+		if plan.Contract != "v1alpha3" || len(plan.Providers) != 1 || plan.Providers[0].NextVersion != "v1.0.1" {
+			t.Errorf("got[%d].UpgradePlans = %v, want an upgrade plan to v1.0.1", i, got[i].UpgradePlans)
+		}
+	}
+}
+
+func Test_clusterctlClient_CheckHealthInClusters(t *testing.T) {
+	client := fakeClientForMultiCluster()
+
+	got, err := client.CheckHealthInClusters(context.Background(), MultiClusterOptions{
+		Kubeconfig: "kubeconfig",
+		Contexts:   []string{"ctx1", "ctx2"},
+	})
+	if err == nil {
+		t.Fatal("error = nil, want the health check failure in ctx2 to be reported")
+	}
+
+	if len(got) != 2 {
+		t.Fatalf("got = %v results, want 2", len(got))
+	}
+
+	// ctx1 hosts a healthy core provider.
+	if got[0].Context != "ctx1" || got[0].Error != nil || len(got[0].Providers) != 1 || got[0].Providers[0].Error != nil {
+		t.Errorf("got[0] = %v, want ctx1 to be healthy", got[0])
+	}
+
+	// ctx2 hosts a core provider without a running controller.
+	if got[1].Context != "ctx2" || got[1].Error == nil || len(got[1].Providers) != 1 || got[1].Providers[0].Error == nil {
+		t.Errorf("got[1] = %v, want ctx2 to be unhealthy", got[1])
+	}
+
+	// The result of the check is recorded in the status of the providers.
+	if len(got[0].Providers) == 1 && got[0].Providers[0].Provider.Status.Health != clusterctlv1.ProviderHealthy {
+		t.Errorf("got[0].Providers[0].Provider.Status.Health = %q, want %q", got[0].Providers[0].Provider.Status.Health, clusterctlv1.ProviderHealthy)
+	}
+	if len(got[1].Providers) == 1 && got[1].Providers[0].Provider.Status.Health != clusterctlv1.ProviderUnhealthy {
+		t.Errorf("got[1].Providers[0].Provider.Status.Health = %q, want %q", got[1].Providers[0].Provider.Status.Health, clusterctlv1.ProviderUnhealthy)
+	}
+}
+
+// clusterctl client for a kubeconfig file with two contexts, each one pointing to a management cluster with the core provider;
+// the core provider controller is running only in the management cluster for ctx1. The ctx4 context, not listed in the
+// kubeconfig file, points to a cluster without the clusterctl inventory.
+func fakeClientForMultiCluster() *fakeClient {
+	core := config.NewProvider("core", "https://somewhere.com", clusterctlv1.CoreProviderType)
+
+	config1 := newFakeConfig().
+		WithProvider(core)
+
+	repository1 := newFakeRepository(core, config1.Variables()).
+		WithPaths("root", "components.yaml").
+		WithDefaultVersion("v1.0.1").
+		WithFile("v1.0.1", "components.yaml", componentsYAML("ns1")).
+		WithVersions("v1.0.0", "v1.0.1").
+		WithMetadata("v1.0.1", &clusterctlv1.Metadata{
+			ReleaseSeries: []clusterctlv1.ReleaseSeries{
+				{Major: 1, Minor: 0, Contract: "v1alpha3"},
+			},
+		})
+
+	// the cluster client for the kubeconfig file, used for reading the list of contexts.
+	cluster0 := newFakeCluster("kubeconfig", config1)
+	cluster0.fakeProxy.WithContexts("ctx1", "ctx2")
+
+	replicas := int32(1)
+	cluster1 := newFakeCluster("kubeconfig", config1).
+		WithKubeconfigContext("ctx1").
+		WithRepository(repository1).
+		WithProviderInventory(core.Name(), core.Type(), "v1.0.0", "core-system", "").
+		WithObjs(&appsv1.Deployment{
+			TypeMeta: metav1.TypeMeta{
+				Kind:       "Deployment",
+				APIVersion: "apps/v1",
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "core-system",
+				Name:      "controller-manager",
+				Labels: map[string]string{
+					clusterctlv1.ClusterctlLabelName: "",
+					clusterv1.ProviderLabelName:      core.Name(),
+				},
+			},
+			Spec: appsv1.DeploymentSpec{
+				Replicas: &replicas,
+			},
+			Status: appsv1.DeploymentStatus{
+				AvailableReplicas: 1,
+			},
+		})
+
+	cluster2 := newFakeCluster("kubeconfig", config1).
+		WithKubeconfigContext("ctx2").
+		WithRepository(repository1).
+		WithProviderInventory(core.Name(), core.Type(), "v1.0.0", "core-system", "")
+
+	// a cluster which is not a management cluster.
+	cluster4 := newFakeCluster("kubeconfig", config1).
+		WithKubeconfigContext("ctx4")
+	cluster4.fakeProxy.WithoutInventoryCRD()
+
+	client := newFakeClient(config1).
+		WithRepository(repository1).
+		WithCluster(cluster0).
+		WithCluster(cluster1).
+		WithCluster(cluster2).
+		WithCluster(cluster4)
+
+	return client
+}
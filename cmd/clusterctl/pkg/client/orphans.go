@@ -0,0 +1,123 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"sort"
+
+	"github.com/pkg/errors"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/client/cluster"
+)
+
+// ListOrphansOptions carries the options supported by ListOrphans.
+type ListOrphansOptions struct {
+	// Kubeconfig file to use for accessing the management cluster. If empty, default rules for kubeconfig
+	// discovery will be used.
+	Kubeconfig string
+
+	// Namespace where to look for orphaned objects. If not specified, all the namespaces are checked.
+	Namespace string
+}
+
+// DeleteOrphansOptions carries the options supported by DeleteOrphans.
+type DeleteOrphansOptions struct {
+	// Kubeconfig file to use for accessing the management cluster. If empty, default rules for kubeconfig
+	// discovery will be used.
+	Kubeconfig string
+
+	// Namespace where to look for orphaned objects. If not specified, all the namespaces are checked.
+	Namespace string
+
+	// Kind and Names of the orphaned objects to be deleted.
+	Kind  string
+	Names []string
+
+	// All deletes all the orphaned objects except Machines, which can only be deleted by name because deleting
+	// a Machine drains and deletes its Node; if set, Kind and Names are ignored.
+	All bool
+}
+
+func (c *clusterctlClient) ListOrphans(ctx context.Context, options ListOrphansOptions) ([]OrphanedObject, error) {
+	clusterClient, err := c.clusterClientFactory(options.Kubeconfig, "")
+	if err != nil {
+		return nil, err
+	}
+
+	orphans, err := clusterClient.OrphanFinder().List(ctx, options.Namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	// OrphanedObject is an alias for cluster.OrphanedObject; this makes the conversion
+	ret := make([]OrphanedObject, len(orphans))
+	for i, o := range orphans {
+		ret[i] = OrphanedObject(o)
+	}
+	return ret, nil
+}
+
+func (c *clusterctlClient) DeleteOrphans(ctx context.Context, options DeleteOrphansOptions) ([]OrphanedObject, error) {
+	if !options.All && (options.Kind == "" || len(options.Names) == 0) {
+		return nil, errors.New("at least one orphaned object to be deleted should be specified, or all the orphaned objects should be selected")
+	}
+
+	clusterClient, err := c.clusterClientFactory(options.Kubeconfig, "")
+	if err != nil {
+		return nil, err
+	}
+
+	orphans, err := clusterClient.OrphanFinder().List(ctx, options.Namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	// Selects the orphaned objects to be deleted; objects that are not orphaned can't be selected.
+	names := map[string]bool{}
+	for _, n := range options.Names {
+		names[n] = true
+	}
+	var selected []cluster.OrphanedObject
+	for _, o := range orphans {
+		if options.All && o.APIVersion == clusterv1.GroupVersion.String() && o.Kind == "Machine" {
+			continue
+		}
+		if options.All || (o.Kind == options.Kind && names[o.Name]) {
+			selected = append(selected, o)
+			delete(names, o.Name)
+		}
+	}
+	if !options.All && len(names) > 0 {
+		var missing []string
+		for n := range names {
+			missing = append(missing, n)
+		}
+		sort.Strings(missing)
+		return nil, errors.Errorf("failed to find orphaned %s objects with name %v", options.Kind, missing)
+	}
+
+	if err := clusterClient.OrphanFinder().Delete(ctx, selected); err != nil {
+		return nil, err
+	}
+
+	ret := make([]OrphanedObject, len(selected))
+	for i, o := range selected {
+		ret[i] = OrphanedObject(o)
+	}
+	return ret, nil
+}
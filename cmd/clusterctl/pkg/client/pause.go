@@ -0,0 +1,68 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"time"
+)
+
+// PauseClusterOptions carries the options supported by PauseCluster and ResumeCluster.
+type PauseClusterOptions struct {
+	// Kubeconfig file to use for accessing the management cluster. If empty, default rules for kubeconfig
+	// discovery will be used.
+	Kubeconfig string
+
+	// Namespace where the Cluster lives. If not specified, the current namespace will be used.
+	Namespace string
+
+	// ClusterName is the name of the Cluster to be paused or resumed.
+	ClusterName string
+
+	// Timeout is the time to wait for the controllers to acknowledge the pause or the resume of the Cluster;
+	// if 0, the acknowledgement is not verified.
+	Timeout time.Duration
+}
+
+func (c *clusterctlClient) PauseCluster(ctx context.Context, options PauseClusterOptions) error {
+	return c.setClusterPaused(ctx, options, true)
+}
+
+func (c *clusterctlClient) ResumeCluster(ctx context.Context, options PauseClusterOptions) error {
+	return c.setClusterPaused(ctx, options, false)
+}
+
+func (c *clusterctlClient) setClusterPaused(ctx context.Context, options PauseClusterOptions, paused bool) error {
+	clusterClient, err := c.clusterClientFactory(options.Kubeconfig, "")
+	if err != nil {
+		return err
+	}
+
+	namespace := options.Namespace
+	if namespace == "" {
+		currentNamespace, err := clusterClient.Proxy().CurrentNamespace()
+		if err != nil {
+			return err
+		}
+		namespace = currentNamespace
+	}
+
+	if paused {
+		return clusterClient.Pause().Pause(ctx, namespace, options.ClusterName, options.Timeout)
+	}
+	return clusterClient.Pause().Resume(ctx, namespace, options.ClusterName, options.Timeout)
+}
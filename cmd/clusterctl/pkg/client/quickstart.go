@@ -0,0 +1,117 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/client/cluster"
+)
+
+// TestQuickstartOptions carries the options supported by TestQuickstart.
+type TestQuickstartOptions struct {
+	// Kubeconfig file to use for accessing the management cluster. If empty, default rules for kubeconfig
+	// discovery will be used.
+	Kubeconfig string
+
+	// InfrastructureProvider to read the workload cluster template from; the provider must be installed
+	// in the management cluster.
+	InfrastructureProvider string
+
+	// Flavor defines the workload cluster template variant to be used. By default (empty), the default
+	// cluster template of the infrastructure provider will be used.
+	Flavor string
+
+	// TargetNamespace where the workload cluster is created. If not specified, the current namespace will be used.
+	TargetNamespace string
+
+	// ClusterName to be used for the workload cluster.
+	ClusterName string
+
+	// KubernetesVersion to use for the workload cluster. By default (empty), the value from os env variables
+	// or the .cluster-api/clusterctl.yaml config file will be used.
+	KubernetesVersion string
+
+	// ControlPlaneMachineCount defines the number of control plane machines of the workload cluster.
+	ControlPlaneMachineCount int
+
+	// WorkerMachineCount defines the number of worker machines of the workload cluster.
+	WorkerMachineCount int
+
+	// UpgradeToVersion is the Kubernetes version the workload cluster is upgraded to. If empty, the upgrade
+	// step is skipped.
+	UpgradeToVersion string
+
+	// ScaleWorkersBy is the number of worker machines added to the workload cluster. If zero, the scale step
+	// is skipped.
+	ScaleWorkersBy int32
+
+	// StepTimeout is the maximum duration of each step.
+	StepTimeout time.Duration
+
+	// SkipCleanup skips the deletion of the workload cluster at the end of the test.
+	SkipCleanup bool
+}
+
+func (c *clusterctlClient) TestQuickstart(ctx context.Context, options TestQuickstartOptions) ([]QuickstartStep, error) {
+	if options.InfrastructureProvider == "" {
+		return nil, errors.New("the infrastructure provider to be tested must be specified")
+	}
+	if options.ClusterName == "" {
+		return nil, errors.New("the name of the workload cluster must be specified")
+	}
+
+	template, err := c.GetClusterTemplate(ctx, GetClusterTemplateOptions{
+		Kubeconfig: options.Kubeconfig,
+		ProviderRepositorySource: &ProviderRepositorySourceOptions{
+			InfrastructureProvider: options.InfrastructureProvider,
+			Flavor:                 options.Flavor,
+		},
+		TargetNamespace:          options.TargetNamespace,
+		ClusterName:              options.ClusterName,
+		KubernetesVersion:        options.KubernetesVersion,
+		ControlPlaneMachineCount: options.ControlPlaneMachineCount,
+		WorkerMachineCount:       options.WorkerMachineCount,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	clusterClient, err := c.clusterClientFactory(options.Kubeconfig, "")
+	if err != nil {
+		return nil, err
+	}
+
+	steps, err := clusterClient.Quickstart().Run(ctx, template.Objs(), cluster.QuickstartOptions{
+		UpgradeToVersion: options.UpgradeToVersion,
+		ScaleWorkersBy:   options.ScaleWorkersBy,
+		StepTimeout:      options.StepTimeout,
+		SkipCleanup:      options.SkipCleanup,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// QuickstartStep is an alias for cluster.QuickstartStep; this makes the conversion
+	ret := make([]QuickstartStep, len(steps))
+	for i, s := range steps {
+		ret[i] = QuickstartStep(s)
+	}
+	return ret, nil
+}
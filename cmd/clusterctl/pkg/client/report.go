@@ -0,0 +1,160 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+
+	"k8s.io/apimachinery/pkg/util/version"
+)
+
+// ReportVersionsOptions carries the options supported by ReportVersions.
+type ReportVersionsOptions struct {
+	// Kubeconfig file to use for accessing the management cluster. If empty, default rules for kubeconfig
+	// discovery will be used.
+	Kubeconfig string
+
+	// Namespace where the workload clusters live. If not specified, the current namespace will be used.
+	Namespace string
+
+	// AllNamespaces reports the workload clusters in all the namespaces; if set, Namespace is ignored.
+	AllNamespaces bool
+}
+
+// ProviderVersionReport reports the version of a provider instance responsible for a workload cluster.
+type ProviderVersionReport struct {
+	// Name, Namespace and Type of the provider instance.
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+	Type      string `json:"type"`
+
+	// Version of the provider instance.
+	Version string `json:"version"`
+
+	// NextVersion is the latest version the provider instance can be upgraded to; it is empty if no upgrade is available.
+	NextVersion string `json:"nextVersion"`
+}
+
+// ClusterVersionReport reports the versions of a workload cluster.
+type ClusterVersionReport struct {
+	// Namespace and Name of the Cluster.
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+
+	// ControlPlaneVersion is the Kubernetes version of the control plane.
+	ControlPlaneVersion string `json:"controlPlaneVersion"`
+
+	// MinWorkerVersion and MaxWorkerVersion are the range of Kubernetes versions of the worker machines.
+	MinWorkerVersion string `json:"minWorkerVersion"`
+	MaxWorkerVersion string `json:"maxWorkerVersion"`
+
+	// Providers lists the provider instances responsible for the cluster.
+	Providers []ProviderVersionReport `json:"providers"`
+}
+
+// UpgradeAvailable returns true if any of the providers responsible for the cluster can be upgraded.
+func (r *ClusterVersionReport) UpgradeAvailable() bool {
+	for _, p := range r.Providers {
+		if p.NextVersion != "" {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *clusterctlClient) ReportVersions(ctx context.Context, options ReportVersionsOptions) ([]ClusterVersionReport, error) {
+	clusterClient, err := c.clusterClientFactory(options.Kubeconfig, "")
+	if err != nil {
+		return nil, err
+	}
+
+	// Ensures the custom resource definitions required by clusterctl are in place.
+	if err := clusterClient.ProviderInventory().EnsureCustomResourceDefinitions(ctx); err != nil {
+		return nil, err
+	}
+
+	// If the option specifying the Namespace is empty, default it to the current namespace of the kubeconfig;
+	// if reporting on all the namespaces, the empty Namespace makes the reporter to look in all the namespaces.
+	namespace := ""
+	if !options.AllNamespaces {
+		namespace = options.Namespace
+		if namespace == "" {
+			currentNamespace, err := clusterClient.Proxy().CurrentNamespace()
+			if err != nil {
+				return nil, err
+			}
+			namespace = currentNamespace
+		}
+	}
+
+	clusterVersions, err := clusterClient.VersionReporter().Report(ctx, namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	upgradePlans, err := planUpgrade(ctx, clusterClient)
+	if err != nil {
+		return nil, err
+	}
+
+	// Gets, for each provider instance, the latest version available across all the upgrade plans.
+	nextVersions := map[string]string{}
+	for _, plan := range upgradePlans {
+		for _, item := range plan.Providers {
+			if item.NextVersion == "" {
+				continue
+			}
+			current, ok := nextVersions[item.InstanceName()]
+			if !ok || isNewerVersion(item.NextVersion, current) {
+				nextVersions[item.InstanceName()] = item.NextVersion
+			}
+		}
+	}
+
+	ret := make([]ClusterVersionReport, 0, len(clusterVersions))
+	for _, v := range clusterVersions {
+		report := ClusterVersionReport{
+			Namespace:           v.Namespace,
+			Name:                v.Name,
+			ControlPlaneVersion: v.ControlPlaneVersion,
+			MinWorkerVersion:    v.MinWorkerVersion,
+			MaxWorkerVersion:    v.MaxWorkerVersion,
+		}
+		for _, p := range v.Providers {
+			report.Providers = append(report.Providers, ProviderVersionReport{
+				Name:        p.Name,
+				Namespace:   p.Namespace,
+				Type:        p.Type,
+				Version:     p.Version,
+				NextVersion: nextVersions[p.InstanceName()],
+			})
+		}
+		ret = append(ret, report)
+	}
+	return ret, nil
+}
+
+// isNewerVersion returns true if a is a version newer than b; versions that are not
+// semantic versions are compared as strings.
+func isNewerVersion(a, b string) bool {
+	va, erra := version.ParseSemantic(a)
+	vb, errb := version.ParseSemantic(b)
+	if erra != nil || errb != nil {
+		return a > b
+	}
+	return vb.LessThan(va)
+}
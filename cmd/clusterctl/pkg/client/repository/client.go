@@ -17,15 +17,18 @@ limitations under the License.
 package repository
 
 import (
+	"context"
 	"io/ioutil"
 	"net/url"
 	"os"
 	"path/filepath"
 
+	"github.com/go-logr/logr"
 	"github.com/pkg/errors"
 	"k8s.io/client-go/util/homedir"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/client/config"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/internal/test"
+	logf "sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/log"
 )
 
 // Client is used to interact with provider repositories.
@@ -36,7 +39,7 @@ type Client interface {
 	config.Provider
 
 	// GetVersion return the list of versions that are available in a provider repository
-	GetVersions() ([]string, error)
+	GetVersions(ctx context.Context) ([]string, error)
 
 	// Components provide access to YAML file for creating provider components.
 	Components() ComponentsClient
@@ -54,25 +57,32 @@ type repositoryClient struct {
 	config.Provider
 	configVariablesClient config.VariablesClient
 	repository            Repository
+	log                   logr.Logger
 }
 
 // ensure repositoryClient implements Client.
 var _ Client = &repositoryClient{}
 
-func (c *repositoryClient) GetVersions() ([]string, error) {
-	return c.repository.GetVersions()
+func (c *repositoryClient) GetVersions(ctx context.Context) ([]string, error) {
+	return c.repository.GetVersions(ctx)
 }
 
 func (c *repositoryClient) Components() ComponentsClient {
-	return newComponentsClient(c.Provider, c.repository, c.configVariablesClient)
+	components := newComponentsClient(c.Provider, c.repository, c.configVariablesClient)
+	components.log = c.log
+	return components
 }
 
 func (c *repositoryClient) Templates(version string) TemplateClient {
-	return newTemplateClient(c.Provider, version, c.repository, c.configVariablesClient)
+	templates := newTemplateClient(c.Provider, version, c.repository, c.configVariablesClient)
+	templates.log = c.log
+	return templates
 }
 
 func (c *repositoryClient) Metadata(version string) MetadataClient {
-	return newMetadataClient(c.Provider, version, c.repository)
+	metadata := newMetadataClient(c.Provider, version, c.repository)
+	metadata.log = c.log
+	return metadata
 }
 
 // Option is a configuration option supplied to New
@@ -87,6 +97,14 @@ func InjectRepository(repository Repository) Option {
 	}
 }
 
+// InjectLogger allows to override the logger used by the client, e.g. for routing the logs of the repository
+// fetches into the logging stack of the program embedding clusterctl; by default the clusterctl logger is used.
+func InjectLogger(log logr.Logger) Option {
+	return func(c *repositoryClient) {
+		c.log = log
+	}
+}
+
 // New returns a Client.
 func New(provider config.Provider, configVariablesClient config.VariablesClient, options ...Option) (Client, error) {
 	return newRepositoryClient(provider, configVariablesClient, options...)
@@ -96,6 +114,7 @@ func newRepositoryClient(provider config.Provider, configVariablesClient config.
 	client := &repositoryClient{
 		Provider:              provider,
 		configVariablesClient: configVariablesClient,
+		log:                   logf.Log,
 	}
 	for _, o := range options {
 		o(client)
@@ -103,12 +122,13 @@ func newRepositoryClient(provider config.Provider, configVariablesClient config.
 
 	// if there is an injected repository, use it, otherwise use a default one
 	if client.repository == nil {
-		r, err := repositoryFactory(provider, configVariablesClient)
+		r, err := repositoryFactory(provider, configVariablesClient, client.log)
 		if err != nil {
 			return nil, errors.Wrapf(err, "failed to get repository client for %q", provider.Name())
 		}
 		client.repository = r
 	}
+	client.repository = newInstrumentedRepository(client.repository, provider.Name())
 
 	return client, nil
 }
@@ -132,16 +152,19 @@ type Repository interface {
 	ComponentsPath() string
 
 	// GetFile return a file for a given provider version.
-	GetFile(version string, path string) ([]byte, error)
+	GetFile(ctx context.Context, version string, path string) ([]byte, error)
 
 	// GetVersion return the list of versions that are available in a provider repository
-	GetVersions() ([]string, error)
+	GetVersions(ctx context.Context) ([]string, error)
+
+	// ListFiles returns the names of the files available for a given provider version, relative to RootPath.
+	ListFiles(ctx context.Context, version string) ([]string, error)
 }
 
 var _ Repository = &test.FakeRepository{}
 
 //repositoryFactory returns the repository implementation corresponding to the provider URL.
-func repositoryFactory(providerConfig config.Provider, configVariablesClient config.VariablesClient) (Repository, error) {
+func repositoryFactory(providerConfig config.Provider, configVariablesClient config.VariablesClient, log logr.Logger) (Repository, error) {
 	// parse the repository url
 	rURL, err := url.Parse(providerConfig.URL())
 	if err != nil {
@@ -150,16 +173,25 @@ func repositoryFactory(providerConfig config.Provider, configVariablesClient con
 
 	// if the url is a github repository
 	if rURL.Scheme == httpsScheme && rURL.Host == githubDomain {
-		repo, err := newGitHubRepository(providerConfig, configVariablesClient)
+		repo, err := newGitHubRepository(providerConfig, configVariablesClient, log)
 		if err != nil {
 			return nil, errors.Wrap(err, "error creating the GitHub repository client")
 		}
 		return repo, err
 	}
 
+	// if the url scheme has a repository type registered
+	if constructor, ok := getRepositoryType(rURL.Scheme); ok {
+		repo, err := constructor(providerConfig, configVariablesClient, log)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error creating the %q repository client", rURL.Scheme)
+		}
+		return repo, nil
+	}
+
 	// if the url is a local filesystem repository
 	if rURL.Scheme == "file" || rURL.Scheme == "" {
-		repo, err := newLocalRepository(providerConfig, configVariablesClient)
+		repo, err := newLocalRepository(providerConfig, configVariablesClient, log)
 		if err != nil {
 			return nil, errors.Wrap(err, "error creating the local filesystem repository client")
 		}
@@ -17,9 +17,12 @@ limitations under the License.
 package repository
 
 import (
+	"context"
 	"os"
+	"reflect"
 	"testing"
 
+	"github.com/go-logr/logr"
 	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/client/config"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/internal/test"
@@ -58,9 +61,80 @@ func Test_newRepositoryClient_LocalFileSystemRepository(t *testing.T) {
 			if err != nil {
 				t.Fatalf("got error %v when none was expected", err)
 			}
-			if _, ok := repoClient.repository.(*localRepository); !ok {
-				t.Fatalf("got repository of type %T when *repository.localRepository was expected", repoClient.repository)
+			instrumented, ok := repoClient.repository.(*instrumentedRepository)
+			if !ok {
+				t.Fatalf("got repository of type %T when *repository.instrumentedRepository was expected", repoClient.repository)
+			}
+			if _, ok := instrumented.Repository.(*localRepository); !ok {
+				t.Fatalf("got repository of type %T when *repository.localRepository was expected", instrumented.Repository)
 			}
 		})
 	}
 }
+
+func Test_newRepositoryClient_InjectLogger(t *testing.T) {
+	log := &recordingLogger{}
+
+	repositoryClient, err := newRepositoryClient(
+		config.NewProvider("p1", "", clusterctlv1.CoreProviderType),
+		test.NewFakeVariableClient(),
+		InjectRepository(test.NewFakeRepository().
+			WithPaths("root", "").
+			WithDefaultVersion("v1.0.0").
+			WithFile("v1.0.0", "metadata.yaml", metadataYaml)),
+		InjectLogger(log),
+	)
+	if err != nil {
+		t.Fatalf("newRepositoryClient() error = %v", err)
+	}
+
+	if _, err := repositoryClient.Metadata("v1.0.0").Get(context.Background()); err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+
+	want := recordedLogEntry{level: 1, msg: "Fetching", values: []interface{}{"File", "metadata.yaml", "Provider", "p1", "Version", "v1.0.0"}}
+	if len(log.entries) != 1 || !reflect.DeepEqual(log.entries[0], want) {
+		t.Errorf("got log entries %v, want [%v]", log.entries, want)
+	}
+}
+
+// recordingLogger is a logr.Logger recording the entries logged by the clients, used for checking an injected logger is used.
+type recordingLogger struct {
+	level   int
+	entries []recordedLogEntry
+	parent  *recordingLogger
+}
+
+type recordedLogEntry struct {
+	level  int
+	msg    string
+	values []interface{}
+}
+
+var _ logr.Logger = &recordingLogger{}
+
+func (l *recordingLogger) root() *recordingLogger {
+	if l.parent != nil {
+		return l.parent.root()
+	}
+	return l
+}
+
+func (l *recordingLogger) Enabled() bool { return true }
+
+func (l *recordingLogger) Info(msg string, kvs ...interface{}) {
+	root := l.root()
+	root.entries = append(root.entries, recordedLogEntry{level: l.level, msg: msg, values: kvs})
+}
+
+func (l *recordingLogger) Error(err error, msg string, kvs ...interface{}) {
+	l.Info(msg, append(kvs, "error", err)...)
+}
+
+func (l *recordingLogger) V(level int) logr.InfoLogger {
+	return &recordingLogger{level: level, parent: l.root()}
+}
+
+func (l *recordingLogger) WithName(name string) logr.Logger { return l }
+
+func (l *recordingLogger) WithValues(kvs ...interface{}) logr.Logger { return l }
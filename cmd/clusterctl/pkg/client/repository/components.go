@@ -35,8 +35,9 @@ import (
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/internal/util"
 )
 
-// variableRegEx defines the regexp used for searching variables inside a YAML
-var variableRegEx = regexp.MustCompile(`\${\s*([A-Z0-9_]+)\s*}`)
+// variableRegEx defines the regexp used for searching variables inside a YAML; variables can define an inline
+// default value, used when the variable is not set, e.g. ${VAR:=value}; spaces around the default value are ignored.
+var variableRegEx = regexp.MustCompile(`\${\s*([A-Z0-9_]+)\s*(:=([^}]*))?}`)
 
 // Components wraps a YAML file that defines the provider components
 // to be installed in a management cluster (CRD, Controller, RBAC etc.)
@@ -44,9 +45,10 @@ var variableRegEx = regexp.MustCompile(`\${\s*([A-Z0-9_]+)\s*}`)
 // from the provider repositories:
 // 1. Checks for all the variables in the component YAML file and replace with corresponding config values
 // 2. Ensure all the provider components are deployed in the target namespace (apply only to namespaced objects)
-// 3. Ensure all the ClusterRoleBinding which are referencing namespaced objects have the name prefixed with the namespace name
-// 4. Set the watching namespace for the provider controller
-// 5. Adds labels to all the components in order to allow easy identification of the provider objects
+// 3. Ensure the webhook service references, the certificates and the leader election namespace refer to the target namespace
+// 4. Ensure all the ClusterRoleBinding which are referencing namespaced objects have the name prefixed with the namespace name
+// 5. Set the watching namespace for the provider controller
+// 6. Adds labels to all the components in order to allow easy identification of the provider objects
 type Components interface {
 	// configuration of the provider the provider components belongs to.
 	config.Provider
@@ -91,6 +93,7 @@ type components struct {
 	images            []string
 	targetNamespace   string
 	watchingNamespace string
+	watchScope        watchScope
 	objs              []unstructured.Unstructured
 }
 
@@ -131,9 +134,11 @@ func (c *components) InventoryObject() clusterctlv1.Provider {
 			Name:      c.Name(),
 			Labels:    labels,
 		},
-		Type:             string(c.Type()),
-		Version:          c.version,
-		WatchedNamespace: c.watchingNamespace,
+		Type:                     string(c.Type()),
+		Version:                  c.version,
+		WatchedNamespace:         c.watchingNamespace,
+		WatchedNamespaces:        c.watchScope.namespaces,
+		WatchedNamespaceSelector: c.watchScope.selector,
 	}
 }
 
@@ -151,9 +156,10 @@ func (c *components) Yaml() ([]byte, error) {
 // from the provider repositories:
 // 1. Checks for all the variables in the component YAML file and replace with corresponding config values
 // 2. Ensure all the provider components are deployed in the target namespace (apply only to namespaced objects)
-// 3. Ensure all the ClusterRoleBinding which are referencing namespaced objects have the name prefixed with the namespace name
-// 4. Set the watching namespace for the provider controller
-// 5. Adds labels to all the components in order to allow easy identification of the provider objects
+// 3. Ensure the webhook service references, the certificates and the leader election namespace refer to the target namespace
+// 4. Ensure all the ClusterRoleBinding which are referencing namespaced objects have the name prefixed with the namespace name
+// 5. Set the watching namespace for the provider controller
+// 6. Adds labels to all the components in order to allow easy identification of the provider objects
 func NewComponents(provider config.Provider, version string, rawyaml []byte, configVariablesClient config.VariablesClient, targetNamespace, watchingNamespace string) (*components, error) {
 	// inspect the yaml read from the repository for variables
 	variables := inspectVariables(rawyaml)
@@ -201,6 +207,13 @@ func NewComponents(provider config.Provider, version string, rawyaml []byte, con
 	// fix Namespace name in all the objects
 	objs = fixTargetNamespace(objs, targetNamespace)
 
+	// ensures the references to the namespace of the provider components, e.g. in the webhook service references,
+	// refer to targetNamespace
+	objs, err = fixNamespaceReferences(objs, targetNamespace)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to fix references to the target namespace")
+	}
+
 	// ensures all the ClusterRole and ClusterRoleBinding have the name prefixed with the namespace name and that
 	// all the clusterRole/clusterRoleBinding namespaced subjects refers to targetNamespace
 	// Nb. Making all the RBAC rules "namespaced" is required for supporting multi-tenancy
@@ -224,6 +237,13 @@ func NewComponents(provider config.Provider, version string, rawyaml []byte, con
 		}
 	}
 
+	// inspect the list of objects for the additional namespaces and the namespace label selector the controller is
+	// set for watching, if any
+	scope, err := inspectWatchScope(objs)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to detect the watched namespaces")
+	}
+
 	objs = addLabels(objs, provider.Name())
 
 	return &components{
@@ -233,10 +253,98 @@ func NewComponents(provider config.Provider, version string, rawyaml []byte, con
 		images:            images,
 		targetNamespace:   targetNamespace,
 		watchingNamespace: watchingNamespace,
+		watchScope:        scope,
+		objs:              objs,
+	}, nil
+}
+
+// NewRenderedComponents returns a new objects embedding an already rendered component YAML file, e.g. generated by
+// a separate and audited pipeline step.
+//
+// Rendered components are installed as-is, so instead of applying the processing steps described in NewComponents,
+// clusterctl only validates the component YAML:
+// 1. There must be no variables left in the component YAML file
+// 2. All the provider components must be deployed in the target namespace, defined by the Namespace object
+// 3. The provider controller must be watching the expected namespace
+// The only change applied to the provider components are the labels required for identifying the provider objects.
+func NewRenderedComponents(provider config.Provider, version string, rawyaml []byte, targetNamespace, watchingNamespace string) (*components, error) {
+	// inspect the yaml for variables not yet replaced
+	if variables := inspectVariables(rawyaml); len(variables) > 0 {
+		return nil, errors.Errorf("rendered components should not contain variables, found [%s]", strings.Join(variables, ", "))
+	}
+
+	// transform the yaml in a list of objects, so following validation can work on typed objects (instead of working on a string/slice of bytes)
+	objs, err := util.ToUnstructured(rawyaml)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse yaml")
+	}
+
+	// inspect the list of objects for the images required by the provider component
+	images, err := util.InspectImages(objs)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to detect required images")
+	}
+
+	// rendered components must define the Namespace object the provider components are deployed in
+	renderedTargetNamespace, err := inspectTargetNamespace(objs)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to detect target namespace")
+	}
+	if renderedTargetNamespace == "" {
+		return nil, errors.New("rendered components should contain the Namespace object the provider components are deployed in")
+	}
+	if targetNamespace != "" && targetNamespace != renderedTargetNamespace {
+		return nil, errors.Errorf("rendered components are deployed in the %q namespace, while the %q target namespace was requested", renderedTargetNamespace, targetNamespace)
+	}
+
+	for _, o := range objs {
+		if isResourceNamespaced(o.GetKind()) && o.GetNamespace() != renderedTargetNamespace {
+			return nil, errors.Errorf("rendered components should be deployed in the %q namespace, %s %q is in the %q namespace", renderedTargetNamespace, o.GetKind(), o.GetName(), o.GetNamespace())
+		}
+	}
+
+	renderedWatchingNamespace, err := inspectWatchNamespace(objs)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to detect watching namespace")
+	}
+	if renderedWatchingNamespace != watchingNamespace {
+		return nil, errors.Errorf("rendered components are watching the %q namespace, while the %q watching namespace was requested", renderedWatchingNamespace, watchingNamespace)
+	}
+
+	scope, err := inspectWatchScope(objs)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to detect the watched namespaces")
+	}
+
+	objs = addLabels(objs, provider.Name())
+
+	return &components{
+		Provider:          provider,
+		version:           version,
+		images:            images,
+		targetNamespace:   renderedTargetNamespace,
+		watchingNamespace: renderedWatchingNamespace,
+		watchScope:        scope,
 		objs:              objs,
 	}, nil
 }
 
+// InspectCustomResourceDefinitions returns the CustomResourceDefinitions defined in the component YAML.
+func InspectCustomResourceDefinitions(rawyaml []byte) ([]unstructured.Unstructured, error) {
+	objs, err := util.ToUnstructured(rawyaml)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse yaml")
+	}
+
+	var crds []unstructured.Unstructured
+	for _, o := range objs {
+		if util.IsCustomResourceDefinition(o) {
+			crds = append(crds, o)
+		}
+	}
+	return crds, nil
+}
+
 func inspectVariables(data []byte) []string {
 	variables := sets.NewString()
 	match := variableRegEx.FindAllStringSubmatch(string(data), -1)
@@ -253,17 +361,55 @@ func inspectVariables(data []byte) []string {
 	return ret
 }
 
+// inspectInlineDefaults returns the inline default values of the variables inside a YAML, e.g. ${VAR:=value};
+// if a variable defines many inline default values, the first one is returned.
+func inspectInlineDefaults(data []byte) map[string]string {
+	defaults := map[string]string{}
+	for _, m := range variableRegEx.FindAllStringSubmatch(string(data), -1) {
+		if m[2] == "" {
+			continue
+		}
+		if _, ok := defaults[m[1]]; !ok {
+			defaults[m[1]] = strings.TrimSpace(m[3])
+		}
+	}
+	return defaults
+}
+
 func replaceVariables(yaml []byte, variables []string, configVariablesClient config.VariablesClient) ([]byte, error) {
-	tmp := string(yaml)
-	var missingVariables []string
+	values := map[string]string{}
+	unset := sets.NewString()
 	for _, key := range variables {
 		val, err := configVariablesClient.Get(key)
 		if err != nil {
-			missingVariables = append(missingVariables, key)
+			unset.Insert(key)
 			continue
 		}
-		exp := regexp.MustCompile(`\$\{\s*` + key + `\s*\}`)
-		tmp = exp.ReplaceAllString(tmp, val)
+		values[key] = val
+	}
+
+	// Variables not set are replaced with their inline default value, if any; otherwise they are missing.
+	missing := sets.NewString()
+	tmp := variableRegEx.ReplaceAllStringFunc(string(yaml), func(s string) string {
+		m := variableRegEx.FindStringSubmatch(s)
+		if val, ok := values[m[1]]; ok {
+			return val
+		}
+		if !unset.Has(m[1]) {
+			return s
+		}
+		if m[2] != "" {
+			return strings.TrimSpace(m[3])
+		}
+		missing.Insert(m[1])
+		return s
+	})
+
+	var missingVariables []string
+	for _, key := range variables {
+		if missing.Has(key) {
+			missingVariables = append(missingVariables, key)
+		}
 	}
 	if len(missingVariables) > 0 {
 		return nil, errors.Errorf("value for variables [%s] is not set. Please set the value using os environment variables or the clusterctl config file", strings.Join(missingVariables, ", "))
@@ -435,10 +581,170 @@ func fixRBAC(objs []unstructured.Unstructured, targetNamespace string) ([]unstru
 	return objs, nil
 }
 
+const (
+	serviceKind          = "Service"
+	certificateKind      = "Certificate"
+	crdKind              = "CustomResourceDefinition"
+	injectCAFromSuffix   = "/inject-ca-from"
+	leaderElectionNSArg  = "--leader-election-namespace="
+	serviceDNSNameSuffix = "svc"
+)
+
+// fixNamespaceReferences ensures the references to the services and to the certificates of the provider components
+// refer to the target namespace, so the provider works when it is installed in a namespace different from its default
+// one, e.g. in a namespace shared with other providers:
+// - the service references of the webhook configurations and of the CRD conversion webhooks;
+// - the cert-manager CA injection annotations;
+// - the DNS names of the cert-manager certificates for the services, e.g. webhook-service.default-namespace.svc;
+// - the leader election namespace of the provider controller, if set.
+func fixNamespaceReferences(objs []unstructured.Unstructured, targetNamespace string) ([]unstructured.Unstructured, error) {
+	services := sets.NewString()
+	certificates := sets.NewString()
+	for _, o := range objs {
+		switch o.GetKind() {
+		case serviceKind:
+			services.Insert(o.GetName())
+		case certificateKind:
+			certificates.Insert(o.GetName())
+		}
+	}
+
+	for i := range objs {
+		o := &objs[i]
+
+		fixInjectCAFromAnnotations(o, certificates, targetNamespace)
+
+		var err error
+		switch o.GetKind() {
+		case "ValidatingWebhookConfiguration", "MutatingWebhookConfiguration":
+			err = fixWebhookServiceReferences(o, services, targetNamespace)
+		case crdKind:
+			// v1beta1 and v1 CRDs define the conversion webhook client configuration in different fields.
+			if err = fixServiceReference(o.Object, services, targetNamespace, "spec", "conversion", "webhookClientConfig", "service"); err == nil {
+				err = fixServiceReference(o.Object, services, targetNamespace, "spec", "conversion", "webhook", "clientConfig", "service")
+			}
+		case certificateKind:
+			err = fixCertificateDNSNames(o, services, targetNamespace)
+		case deploymentKind:
+			err = fixLeaderElectionNamespace(o, targetNamespace)
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to fix references to the target namespace in %s %q", o.GetKind(), o.GetName())
+		}
+	}
+	return objs, nil
+}
+
+// fixInjectCAFromAnnotations ensures the cert-manager CA injection annotations refer to the certificates in the target namespace.
+func fixInjectCAFromAnnotations(o *unstructured.Unstructured, certificates sets.String, targetNamespace string) {
+	annotations := o.GetAnnotations()
+	changed := false
+	for k, v := range annotations {
+		if !strings.HasSuffix(k, injectCAFromSuffix) {
+			continue
+		}
+		parts := strings.Split(v, "/")
+		if len(parts) != 2 || !certificates.Has(parts[1]) || parts[0] == targetNamespace {
+			continue
+		}
+		annotations[k] = fmt.Sprintf("%s/%s", targetNamespace, parts[1])
+		changed = true
+	}
+	if changed {
+		o.SetAnnotations(annotations)
+	}
+}
+
+// fixWebhookServiceReferences ensures the webhooks of a webhook configuration refer to the services in the target namespace.
+func fixWebhookServiceReferences(o *unstructured.Unstructured, services sets.String, targetNamespace string) error {
+	webhooks, found, err := unstructured.NestedSlice(o.Object, "webhooks")
+	if err != nil || !found {
+		return err
+	}
+	for _, w := range webhooks {
+		webhook, ok := w.(map[string]interface{})
+		if !ok {
+			return errors.New("invalid webhook definition")
+		}
+		if err := fixServiceReference(webhook, services, targetNamespace, "clientConfig", "service"); err != nil {
+			return err
+		}
+	}
+	return unstructured.SetNestedSlice(o.Object, webhooks, "webhooks")
+}
+
+// fixServiceReference ensures the service reference at the given path, if any, refers to the target namespace
+// when the service is one of the provider components.
+func fixServiceReference(obj map[string]interface{}, services sets.String, targetNamespace string, fields ...string) error {
+	name, found, err := unstructured.NestedString(obj, append(fields, "name")...)
+	if err != nil || !found || !services.Has(name) {
+		return err
+	}
+	return unstructured.SetNestedField(obj, targetNamespace, append(fields, "namespace")...)
+}
+
+// fixCertificateDNSNames ensures the DNS names of a certificate for the services of the provider components,
+// e.g. webhook-service.default-namespace.svc or webhook-service.default-namespace.svc.cluster.local, refer to the
+// target namespace.
+func fixCertificateDNSNames(o *unstructured.Unstructured, services sets.String, targetNamespace string) error {
+	dnsNames, found, err := unstructured.NestedStringSlice(o.Object, "spec", "dnsNames")
+	if err != nil || !found {
+		return err
+	}
+	for i, dnsName := range dnsNames {
+		parts := strings.Split(dnsName, ".")
+		if len(parts) < 3 || !services.Has(parts[0]) || parts[2] != serviceDNSNameSuffix {
+			continue
+		}
+		parts[1] = targetNamespace
+		dnsNames[i] = strings.Join(parts, ".")
+	}
+	return unstructured.SetNestedStringSlice(o.Object, dnsNames, "spec", "dnsNames")
+}
+
+// fixLeaderElectionNamespace ensures the provider controller, if configured with a leader election namespace, uses
+// the target namespace for leader election.
+func fixLeaderElectionNamespace(o *unstructured.Unstructured, targetNamespace string) error {
+	// Convert Unstructured into a typed object
+	d := &appsv1.Deployment{}
+	if err := scheme.Scheme.Convert(o, d, nil); err != nil {
+		return err
+	}
+
+	changed := false
+	for j := range d.Spec.Template.Spec.Containers {
+		c := &d.Spec.Template.Spec.Containers[j]
+		if c.Name != controllerContainerName {
+			continue
+		}
+		for k, a := range c.Args {
+			if strings.HasPrefix(a, leaderElectionNSArg) && a != leaderElectionNSArg+targetNamespace {
+				c.Args[k] = leaderElectionNSArg + targetNamespace
+				changed = true
+			}
+		}
+	}
+	if !changed {
+		return nil
+	}
+
+	// Convert Deployment back to Unstructured
+	return scheme.Scheme.Convert(d, o, nil)
+}
+
 const namespaceArgPrefix = "--namespace="
+const watchNamespacesArgPrefix = "--watch-namespaces="
+const watchNamespaceSelectorArgPrefix = "--watch-namespace-selector="
 const deploymentKind = "Deployment"
 const controllerContainerName = "manager"
 
+// watchScope defines the namespaces a controller is watching in addition to the namespace set by the --namespace
+// command arg, for providers watching a list of namespaces or the namespaces matching a label selector.
+type watchScope struct {
+	namespaces []string
+	selector   *metav1.LabelSelector
+}
+
 // inspectWatchNamespace inspects the list of components objects for the default watching namespace
 // the default watching namespace is the namespace the controller is set for watching in the component yaml read from the repository, if any
 func inspectWatchNamespace(objs []unstructured.Unstructured) (string, error) {
@@ -478,6 +784,62 @@ func inspectWatchNamespace(objs []unstructured.Unstructured) (string, error) {
 	return namespace, nil
 }
 
+// inspectWatchScope inspects the list of components objects for the --watch-namespaces and --watch-namespace-selector
+// command args, defining the additional namespaces and the namespace label selector the controllers are watching.
+func inspectWatchScope(objs []unstructured.Unstructured) (watchScope, error) {
+	scope := watchScope{}
+	namespaces, selector := "", ""
+	for i := range objs {
+		o := objs[i]
+		if o.GetKind() != deploymentKind {
+			continue
+		}
+
+		// Convert Unstructured into a typed object
+		d := &appsv1.Deployment{}
+		if err := scheme.Scheme.Convert(&o, d, nil); err != nil {
+			return scope, err
+		}
+
+		for _, c := range d.Spec.Template.Spec.Containers {
+			if c.Name != controllerContainerName {
+				continue
+			}
+
+			for _, a := range c.Args {
+				switch {
+				case strings.HasPrefix(a, watchNamespacesArgPrefix):
+					n := strings.TrimPrefix(a, watchNamespacesArgPrefix)
+					if namespaces != "" && n != namespaces {
+						return scope, errors.New("Invalid manifest. All the controllers should have the same --watch-namespaces command arg in the provider components yaml")
+					}
+					namespaces = n
+				case strings.HasPrefix(a, watchNamespaceSelectorArgPrefix):
+					s := strings.TrimPrefix(a, watchNamespaceSelectorArgPrefix)
+					if selector != "" && s != selector {
+						return scope, errors.New("Invalid manifest. All the controllers should have the same --watch-namespace-selector command arg in the provider components yaml")
+					}
+					selector = s
+				}
+			}
+		}
+	}
+
+	for _, n := range strings.Split(namespaces, ",") {
+		if n = strings.TrimSpace(n); n != "" {
+			scope.namespaces = append(scope.namespaces, n)
+		}
+	}
+	if selector != "" {
+		labelSelector, err := metav1.ParseToLabelSelector(selector)
+		if err != nil {
+			return scope, errors.Wrapf(err, "invalid --watch-namespace-selector command arg %q", selector)
+		}
+		scope.selector = labelSelector
+	}
+	return scope, nil
+}
+
 func fixWatchNamespace(objs []unstructured.Unstructured, watchingNamespace string) ([]unstructured.Unstructured, error) {
 	// look for resources of kind Deployment
 	for i := range objs {
@@ -17,7 +17,11 @@ limitations under the License.
 package repository
 
 import (
+	"context"
+
+	"github.com/go-logr/logr"
 	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/client/config"
 	logf "sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/log"
 )
@@ -25,7 +29,11 @@ import (
 // ComponentsClient has methods to work with yaml file for generating provider components.
 // Assets are yaml files to be used for deploying a provider into a management cluster.
 type ComponentsClient interface {
-	Get(version, targetNamespace, watchingNamespace string) (Components, error)
+	Get(ctx context.Context, version, targetNamespace, watchingNamespace string) (Components, error)
+
+	// CustomResourceDefinitions returns the CustomResourceDefinitions included in the provider components.
+	// No variable substitution is performed, so they can be read without the values required for installing the provider.
+	CustomResourceDefinitions(ctx context.Context, version string) ([]unstructured.Unstructured, error)
 }
 
 // componentsClient implements ComponentsClient.
@@ -33,6 +41,7 @@ type componentsClient struct {
 	provider              config.Provider
 	repository            Repository
 	configVariablesClient config.VariablesClient
+	log                   logr.Logger
 }
 
 // ensure componentsClient implements ComponentsClient.
@@ -44,35 +53,66 @@ func newComponentsClient(provider config.Provider, repository Repository, config
 		provider:              provider,
 		repository:            repository,
 		configVariablesClient: configVariablesClient,
+		log:                   logf.Log,
 	}
 }
 
-func (f *componentsClient) Get(version, targetNamespace, watchingNamespace string) (Components, error) {
-	log := logf.Log
+func (f *componentsClient) Get(ctx context.Context, version, targetNamespace, watchingNamespace string) (Components, error) {
+	version, file, err := f.getRawBytes(ctx, version)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewComponents(f.provider, version, file, f.configVariablesClient, targetNamespace, watchingNamespace)
+}
+
+func (f *componentsClient) CustomResourceDefinitions(ctx context.Context, version string) ([]unstructured.Unstructured, error) {
+	_, file, err := f.getRawBytes(ctx, version)
+	if err != nil {
+		return nil, err
+	}
+
+	return InspectCustomResourceDefinitions(file)
+}
+
+// getRawBytes returns the component YAML for the given version, together with the version actually read.
+func (f *componentsClient) getRawBytes(ctx context.Context, version string) (string, []byte, error) {
+	log := f.log
 
 	// if the request does not target a specific version, read from the default repository version that is derived from the repository URL, e.g. latest.
 	if version == "" {
 		version = f.repository.DefaultVersion()
 	}
 
+	// if the request targets a channel or a version constraint, resolve it to the matching release, so the
+	// version actually installed is recorded in the inventory.
+	if IsVersionSelector(version) {
+		resolved, err := resolveVersion(ctx, f.repository, version)
+		if err != nil {
+			return "", nil, errors.Wrapf(err, "failed to resolve version %q for the %q provider", version, f.provider.Name())
+		}
+		log.V(1).Info("Resolved", "Provider", f.provider.Name(), "Version", version, "ResolvedVersion", resolved)
+		version = resolved
+	}
+
 	// retrieve the path where the path is stored
 	path := f.repository.ComponentsPath()
 
 	// read the component YAML, reading the local override file if it exists, otherwise read from the provider repository
 	file, err := getLocalOverride(f.provider, version, path)
 	if err != nil {
-		return nil, err
+		return "", nil, err
 	}
 
 	if file == nil {
 		log.V(1).Info("Fetching", "File", path, "Provider", f.provider.Name(), "Version", version)
-		file, err = f.repository.GetFile(version, path)
+		file, err = f.repository.GetFile(ctx, version, path)
 		if err != nil {
-			return nil, errors.Wrapf(err, "failed to read %q from provider's repository %q", path, f.provider.Name())
+			return "", nil, errors.Wrapf(err, "failed to read %q from provider's repository %q", path, f.provider.Name())
 		}
 	} else {
 		log.V(1).Info("Using", "Override", path, "Provider", f.provider.Name(), "Version", version)
 	}
 
-	return NewComponents(f.provider, version, file, f.configVariablesClient, targetNamespace, watchingNamespace)
+	return version, file, nil
 }
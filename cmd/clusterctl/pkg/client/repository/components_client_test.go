@@ -18,6 +18,7 @@ package repository
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"reflect"
 	"testing"
@@ -157,6 +158,49 @@ func Test_componentsClient_Get(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "Version constraint resolved to the matching release",
+			fields: fields{
+				provider: p1,
+				repository: test.NewFakeRepository().
+					WithPaths("root", "components.yaml").
+					WithDefaultVersion("v1.1.0").
+					WithFile("v1.0.0", "components.yaml", util.JoinYaml(namespaceYaml, controllerYaml, configMapYaml)).
+					WithFile("v1.0.1", "components.yaml", util.JoinYaml(namespaceYaml, controllerYaml, configMapYaml)).
+					WithFile("v1.1.0", "components.yaml", util.JoinYaml(namespaceYaml, controllerYaml, configMapYaml)),
+				configVariablesClient: test.NewFakeVariableClient().WithVar(variableName, variableValue),
+			},
+			args: args{
+				version:           ">=1.0 <1.1",
+				targetNamespace:   "",
+				watchingNamespace: "",
+			},
+			want: want{
+				provider:          p1,
+				version:           "v1.0.1", // version resolved
+				targetNamespace:   namespaceName,
+				watchingNamespace: "",
+				variables:         []string{variableName},
+			},
+			wantErr: false,
+		},
+		{
+			name: "Fails if no release matches the version constraint",
+			fields: fields{
+				provider: p1,
+				repository: test.NewFakeRepository().
+					WithPaths("root", "components.yaml").
+					WithDefaultVersion("v1.0.0").
+					WithFile("v1.0.0", "components.yaml", util.JoinYaml(namespaceYaml, controllerYaml, configMapYaml)),
+				configVariablesClient: test.NewFakeVariableClient().WithVar(variableName, variableValue),
+			},
+			args: args{
+				version:           ">=2.0",
+				targetNamespace:   "",
+				watchingNamespace: "",
+			},
+			wantErr: true,
+		},
 		{
 			name: "Fails if components file does not exists",
 			fields: fields{
@@ -235,7 +279,7 @@ func Test_componentsClient_Get(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			f := newComponentsClient(tt.fields.provider, tt.fields.repository, tt.fields.configVariablesClient)
-			got, err := f.Get(tt.args.version, tt.args.targetNamespace, tt.args.watchingNamespace)
+			got, err := f.Get(context.Background(), tt.args.version, tt.args.targetNamespace, tt.args.watchingNamespace)
 			if (err != nil) != tt.wantErr {
 				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
 			}
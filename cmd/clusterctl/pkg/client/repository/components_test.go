@@ -19,13 +19,16 @@ package repository
 import (
 	"fmt"
 	"reflect"
+	"strings"
 	"testing"
 
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
 	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/client/config"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/internal/test"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/internal/util"
 )
 
 func Test_inspectVariables(t *testing.T) {
@@ -58,6 +61,13 @@ func Test_inspectVariables(t *testing.T) {
 			},
 			want: []string{"A", "B", "C"},
 		},
+		{
+			name: "variables with inline default values are processed",
+			args: args{
+				data: "yaml with ${A:=a} ${ B := b } ${C:=}",
+			},
+			want: []string{"A", "B", "C"},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -68,6 +78,42 @@ func Test_inspectVariables(t *testing.T) {
 	}
 }
 
+func Test_inspectInlineDefaults(t *testing.T) {
+	got := inspectInlineDefaults([]byte("yaml with ${A} ${B:=b} ${ C := c } ${B:=other} ${D:=}"))
+	want := map[string]string{"B": "b", "C": "c", "D": ""}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("inspectInlineDefaults() = %v, want %v", got, want)
+	}
+}
+
+func Test_InspectCustomResourceDefinitions(t *testing.T) {
+	rawyaml := []byte("apiVersion: v1\n" +
+		"kind: Namespace\n" +
+		"metadata:\n" +
+		"  name: ns1\n" +
+		"---\n" +
+		"apiVersion: apiextensions.k8s.io/v1\n" +
+		"kind: CustomResourceDefinition\n" +
+		"metadata:\n" +
+		"  name: foos.test.cluster.x-k8s.io\n" +
+		"---\n" +
+		"apiVersion: v1\n" +
+		"kind: Secret\n" +
+		"metadata:\n" +
+		"  name: credentials\n" +
+		"  namespace: ns1\n" +
+		"data:\n" +
+		"  credentials: ${CREDENTIALS}\n")
+
+	got, err := InspectCustomResourceDefinitions(rawyaml)
+	if err != nil {
+		t.Fatalf("error = %v, wantErr nil", err)
+	}
+	if len(got) != 1 || got[0].GetName() != "foos.test.cluster.x-k8s.io" {
+		t.Errorf("InspectCustomResourceDefinitions() = %v, want the foos.test.cluster.x-k8s.io CustomResourceDefinition", got)
+	}
+}
+
 func Test_replaceVariables(t *testing.T) {
 	type args struct {
 		yaml                  []byte
@@ -91,6 +137,27 @@ func Test_replaceVariables(t *testing.T) {
 			want:    []byte("foo bar"),
 			wantErr: false,
 		},
+		{
+			name: "pass and replaces variables not set with their inline default values",
+			args: args{
+				yaml:      []byte("foo ${ BAR:=bar } ${BAZ:=baz} ${BAZ:=} ${QUX:=}"),
+				variables: []string{"BAR", "BAZ", "QUX"},
+				configVariablesClient: test.NewFakeVariableClient().
+					WithVar("BAZ", "baz2"),
+			},
+			want:    []byte("foo bar baz2 baz2 "),
+			wantErr: false,
+		},
+		{
+			name: "fails for missing variables without an inline default value",
+			args: args{
+				yaml:                  []byte("foo ${ BAR:=bar } ${ BAR }"),
+				variables:             []string{"BAR"},
+				configVariablesClient: test.NewFakeVariableClient(),
+			},
+			want:    nil,
+			wantErr: true,
+		},
 		{
 			name: "fails for missing variables",
 			args: args{
@@ -603,6 +670,10 @@ func fakeDeployment(watchNamespace string) unstructured.Unstructured {
 	if watchNamespace != "" {
 		args = append(args, fmt.Sprintf("%s%s", namespaceArgPrefix, watchNamespace))
 	}
+	return fakeDeploymentWithArgs(args...)
+}
+
+func fakeDeploymentWithArgs(args ...string) unstructured.Unstructured {
 	return unstructured.Unstructured{
 		Object: map[string]interface{}{
 			"apiVersion": "apps/v1",
@@ -623,6 +694,114 @@ func fakeDeployment(watchNamespace string) unstructured.Unstructured {
 	}
 }
 
+func Test_fixNamespaceReferences(t *testing.T) {
+	componentsYAML := `apiVersion: v1
+kind: Service
+metadata:
+  name: webhook-service
+  namespace: target
+---
+apiVersion: cert-manager.io/v1alpha2
+kind: Certificate
+metadata:
+  name: serving-cert
+  namespace: target
+spec:
+  dnsNames:
+  - webhook-service.default-system.svc
+  - webhook-service.default-system.svc.cluster.local
+  - external.example.com
+---
+apiVersion: admissionregistration.k8s.io/v1beta1
+kind: ValidatingWebhookConfiguration
+metadata:
+  name: validating-webhook-configuration
+  annotations:
+    cert-manager.io/inject-ca-from: default-system/serving-cert
+webhooks:
+- name: validation.foo.x-k8s.io
+  clientConfig:
+    service:
+      name: webhook-service
+      namespace: default-system
+- name: validation.external.x-k8s.io
+  clientConfig:
+    service:
+      name: external-service
+      namespace: external-system
+---
+apiVersion: apiextensions.k8s.io/v1beta1
+kind: CustomResourceDefinition
+metadata:
+  name: foos.foo.x-k8s.io
+  annotations:
+    cert-manager.io/inject-ca-from: default-system/serving-cert
+spec:
+  conversion:
+    strategy: Webhook
+    webhookClientConfig:
+      service:
+        name: webhook-service
+        namespace: default-system
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: controller-manager
+  namespace: target
+spec:
+  template:
+    spec:
+      containers:
+      - name: manager
+        image: foo
+        args:
+        - --enable-leader-election
+        - --leader-election-namespace=default-system
+`
+	objs, err := util.ToUnstructured([]byte(componentsYAML))
+	if err != nil {
+		t.Fatalf("failed to parse the components YAML: %v", err)
+	}
+
+	got, err := fixNamespaceReferences(objs, "target")
+	if err != nil {
+		t.Fatalf("fixNamespaceReferences() error = %v", err)
+	}
+
+	dnsNames, _, _ := unstructured.NestedStringSlice(got[1].Object, "spec", "dnsNames")
+	wantDNSNames := []string{"webhook-service.target.svc", "webhook-service.target.svc.cluster.local", "external.example.com"}
+	if !reflect.DeepEqual(dnsNames, wantDNSNames) {
+		t.Errorf("got Certificate dnsNames %v, want %v", dnsNames, wantDNSNames)
+	}
+
+	if got := got[2].GetAnnotations()["cert-manager.io/inject-ca-from"]; got != "target/serving-cert" {
+		t.Errorf("got ValidatingWebhookConfiguration inject-ca-from %q, want target/serving-cert", got)
+	}
+	webhooks, _, _ := unstructured.NestedSlice(got[2].Object, "webhooks")
+	wantNamespaces := []string{"target", "external-system"}
+	for i, w := range webhooks {
+		namespace, _, _ := unstructured.NestedString(w.(map[string]interface{}), "clientConfig", "service", "namespace")
+		if namespace != wantNamespaces[i] {
+			t.Errorf("got webhook %d service namespace %q, want %q", i, namespace, wantNamespaces[i])
+		}
+	}
+
+	if got := got[3].GetAnnotations()["cert-manager.io/inject-ca-from"]; got != "target/serving-cert" {
+		t.Errorf("got CustomResourceDefinition inject-ca-from %q, want target/serving-cert", got)
+	}
+	if namespace, _, _ := unstructured.NestedString(got[3].Object, "spec", "conversion", "webhookClientConfig", "service", "namespace"); namespace != "target" {
+		t.Errorf("got conversion webhook service namespace %q, want target", namespace)
+	}
+
+	containers, _, _ := unstructured.NestedSlice(got[4].Object, "spec", "template", "spec", "containers")
+	args, _, _ := unstructured.NestedStringSlice(containers[0].(map[string]interface{}), "args")
+	wantArgs := []string{"--enable-leader-election", "--leader-election-namespace=target"}
+	if !reflect.DeepEqual(args, wantArgs) {
+		t.Errorf("got manager args %v, want %v", args, wantArgs)
+	}
+}
+
 func Test_inspectWatchNamespace(t *testing.T) {
 	type args struct {
 		objs []unstructured.Unstructured
@@ -688,6 +867,67 @@ func Test_inspectWatchNamespace(t *testing.T) {
 	}
 }
 
+func Test_inspectWatchScope(t *testing.T) {
+	tests := []struct {
+		name    string
+		objs    []unstructured.Unstructured
+		want    watchScope
+		wantErr bool
+	}{
+		{
+			name: "return empty if there are no additional watches",
+			objs: []unstructured.Unstructured{
+				fakeDeployment("foo"),
+			},
+			want: watchScope{},
+		},
+		{
+			name: "get the watched namespaces and the namespace selector",
+			objs: []unstructured.Unstructured{
+				fakeDeploymentWithArgs("--namespace=foo", "--watch-namespaces=bar, baz", "--watch-namespace-selector=team=a"),
+				fakeDeploymentWithArgs("--watch-namespaces=bar, baz"),
+			},
+			want: watchScope{
+				namespaces: []string{"bar", "baz"},
+				selector: &metav1.LabelSelector{
+					MatchLabels:      map[string]string{"team": "a"},
+					MatchExpressions: []metav1.LabelSelectorRequirement{},
+				},
+			},
+		},
+		{
+			name: "fails if inconsistent watched namespaces",
+			objs: []unstructured.Unstructured{
+				fakeDeploymentWithArgs("--watch-namespaces=bar"),
+				fakeDeploymentWithArgs("--watch-namespaces=baz"),
+			},
+			wantErr: true,
+		},
+		{
+			name: "fails if invalid namespace selector",
+			objs: []unstructured.Unstructured{
+				fakeDeploymentWithArgs("--watch-namespace-selector=team in (a"),
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := inspectWatchScope(tt.objs)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 func Test_fixWatchNamespace(t *testing.T) {
 	type args struct {
 		objs              []unstructured.Unstructured
@@ -796,3 +1036,109 @@ func Test_addLabels(t *testing.T) {
 		})
 	}
 }
+
+func Test_NewRenderedComponents(t *testing.T) {
+	namespace := "apiVersion: v1\nkind: Namespace\nmetadata:\n  name: ns1\n"
+	deployment := func(namespace string, args ...string) string {
+		return fmt.Sprintf("apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: manager\n  namespace: %s\nspec:\n  template:\n    spec:\n      containers:\n      - name: %s\n        image: example.com/manager:v1.0.0\n        args: [%s]\n", namespace, controllerContainerName, strings.Join(args, ", "))
+	}
+
+	type args struct {
+		rawyaml           string
+		targetNamespace   string
+		watchingNamespace string
+	}
+	tests := []struct {
+		name                  string
+		args                  args
+		wantTargetNamespace   string
+		wantWatchingNamespace string
+		wantImages            []string
+		wantErr               bool
+	}{
+		{
+			name: "rendered components",
+			args: args{
+				rawyaml: namespace + "---\n" + deployment("ns1", namespaceArgPrefix+"foo"),
+			},
+			wantTargetNamespace:   "ns1",
+			wantWatchingNamespace: "foo",
+			wantImages:            []string{"example.com/manager:v1.0.0"},
+		},
+		{
+			name: "rendered components matching the requested namespaces",
+			args: args{
+				rawyaml:         namespace + "---\n" + deployment("ns1"),
+				targetNamespace: "ns1",
+			},
+			wantTargetNamespace:   "ns1",
+			wantWatchingNamespace: "",
+			wantImages:            []string{"example.com/manager:v1.0.0"},
+		},
+		{
+			name: "fails if variables are left",
+			args: args{
+				rawyaml: namespace + "---\n" + deployment("${NAMESPACE}"),
+			},
+			wantErr: true,
+		},
+		{
+			name: "fails if there is no Namespace object",
+			args: args{
+				rawyaml: deployment("ns1"),
+			},
+			wantErr: true,
+		},
+		{
+			name: "fails if objects are in a different namespace",
+			args: args{
+				rawyaml: namespace + "---\n" + deployment("ns2"),
+			},
+			wantErr: true,
+		},
+		{
+			name: "fails if the target namespace does not match",
+			args: args{
+				rawyaml:         namespace + "---\n" + deployment("ns1"),
+				targetNamespace: "ns2",
+			},
+			wantErr: true,
+		},
+		{
+			name: "fails if the watching namespace does not match",
+			args: args{
+				rawyaml:           namespace + "---\n" + deployment("ns1"),
+				watchingNamespace: "foo",
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := config.NewProvider("p1", "", clusterctlv1.CoreProviderType)
+
+			got, err := NewRenderedComponents(provider, "v1.0.0", []byte(tt.args.rawyaml), tt.args.targetNamespace, tt.args.watchingNamespace)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			if got.TargetNamespace() != tt.wantTargetNamespace {
+				t.Errorf("got.TargetNamespace() = %v, want %v", got.TargetNamespace(), tt.wantTargetNamespace)
+			}
+			if got.WatchingNamespace() != tt.wantWatchingNamespace {
+				t.Errorf("got.WatchingNamespace() = %v, want %v", got.WatchingNamespace(), tt.wantWatchingNamespace)
+			}
+			if !reflect.DeepEqual(got.Images(), tt.wantImages) {
+				t.Errorf("got.Images() = %v, want %v", got.Images(), tt.wantImages)
+			}
+			for _, o := range got.Objs() {
+				if o.GetLabels()[clusterv1.ProviderLabelName] != "p1" {
+					t.Errorf("%s %q is missing the provider label", o.GetKind(), o.GetName())
+				}
+			}
+		})
+	}
+}
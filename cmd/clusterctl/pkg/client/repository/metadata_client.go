@@ -17,6 +17,9 @@ limitations under the License.
 package repository
 
 import (
+	"context"
+
+	"github.com/go-logr/logr"
 	"github.com/pkg/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -31,7 +34,7 @@ import (
 // Metadata are yaml files providing additional information about provider's assets like e.g the version compatibility Matrix.
 type MetadataClient interface {
 	// Get returns the provider's metadata.
-	Get() (*clusterctlv1.Metadata, error)
+	Get(ctx context.Context) (*clusterctlv1.Metadata, error)
 }
 
 // metadataClient implements MetadataClient.
@@ -39,6 +42,7 @@ type metadataClient struct {
 	provider   config.Provider
 	version    string
 	repository Repository
+	log        logr.Logger
 }
 
 // ensure metadataClient implements MetadataClient.
@@ -50,11 +54,12 @@ func newMetadataClient(provider config.Provider, version string, repository Repo
 		provider:   provider,
 		version:    version,
 		repository: repository,
+		log:        logf.Log,
 	}
 }
 
-func (f *metadataClient) Get() (*clusterctlv1.Metadata, error) {
-	log := logf.Log
+func (f *metadataClient) Get(ctx context.Context) (*clusterctlv1.Metadata, error) {
+	log := f.log
 
 	// gets the metadata file from the repository
 	version := f.version
@@ -66,7 +71,7 @@ func (f *metadataClient) Get() (*clusterctlv1.Metadata, error) {
 	}
 	if file == nil {
 		log.V(1).Info("Fetching", "File", name, "Provider", f.provider.Name(), "Version", version)
-		file, err = f.repository.GetFile(version, name)
+		file, err = f.repository.GetFile(ctx, version, name)
 		if err != nil {
 			// if there are problems in reading the metadata file from the repository, check if there are embedded metadata for the provider, if yes use them
 			if obj := f.getEmbeddedMetadata(); obj != nil {
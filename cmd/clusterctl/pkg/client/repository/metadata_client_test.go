@@ -17,6 +17,7 @@ limitations under the License.
 package repository
 
 import (
+	"context"
 	"reflect"
 	"testing"
 
@@ -138,7 +139,7 @@ func Test_metadataClient_Get(t *testing.T) {
 				version:    tt.fields.version,
 				repository: tt.fields.repository,
 			}
-			got, err := f.Get()
+			got, err := f.Get(context.Background())
 			if (err != nil) != tt.wantErr {
 				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
 			}
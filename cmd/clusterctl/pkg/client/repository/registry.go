@@ -0,0 +1,87 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package repository
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/go-logr/logr"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/client/config"
+)
+
+// RepositoryConstructor creates the Repository implementation for a provider; it is called for the providers
+// with a URL whose scheme the constructor has been registered for.
+type RepositoryConstructor func(providerConfig config.Provider, configVariablesClient config.VariablesClient, log logr.Logger) (Repository, error)
+
+var (
+	repositoryTypesMu sync.RWMutex
+	repositoryTypes   = map[string]RepositoryConstructor{}
+)
+
+// RegisterRepositoryType makes a custom Repository implementation, e.g. backed by an internal artifact store or by
+// a proprietary catalog, available for the provider URLs with the given scheme. It is meant to be called from the
+// init function of a package compiled into a distribution of clusterctl, e.g.
+//
+//	func init() {
+//		repository.RegisterRepositoryType("artifacts", newArtifactsRepository)
+//	}
+//
+// The "file" scheme, as well as URLs without a scheme, are reserved to the local filesystem repositories, and the
+// https URLs on github.com are always served by the GitHub repositories.
+// RegisterRepositoryType panics if the scheme is reserved, if it is already registered or if constructor is nil.
+func RegisterRepositoryType(scheme string, constructor RepositoryConstructor) {
+	scheme = strings.ToLower(scheme)
+	if scheme == "" || scheme == "file" {
+		panic(fmt.Sprintf("repository: the %q scheme is reserved to the local filesystem repositories", scheme))
+	}
+	if constructor == nil {
+		panic(fmt.Sprintf("repository: nil constructor registered for the %q scheme", scheme))
+	}
+
+	repositoryTypesMu.Lock()
+	defer repositoryTypesMu.Unlock()
+
+	if _, ok := repositoryTypes[scheme]; ok {
+		panic(fmt.Sprintf("repository: a repository type is already registered for the %q scheme", scheme))
+	}
+	repositoryTypes[scheme] = constructor
+}
+
+// RegisteredRepositorySchemes returns the URL schemes with a custom Repository implementation registered.
+func RegisteredRepositorySchemes() []string {
+	repositoryTypesMu.RLock()
+	defer repositoryTypesMu.RUnlock()
+
+	schemes := make([]string, 0, len(repositoryTypes))
+	for scheme := range repositoryTypes {
+		schemes = append(schemes, scheme)
+	}
+	sort.Strings(schemes)
+	return schemes
+}
+
+// getRepositoryType returns the constructor registered for a URL scheme, if any.
+func getRepositoryType(scheme string) (RepositoryConstructor, bool) {
+	repositoryTypesMu.RLock()
+	defer repositoryTypesMu.RUnlock()
+
+	constructor, ok := repositoryTypes[strings.ToLower(scheme)]
+	return constructor, ok
+}
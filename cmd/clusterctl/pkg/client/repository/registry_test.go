@@ -0,0 +1,121 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package repository
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/pkg/errors"
+	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/client/config"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/internal/test"
+)
+
+func Test_newRepositoryClient_RegisteredRepositoryType(t *testing.T) {
+	fake := test.NewFakeRepository()
+	RegisterRepositoryType("artifacts", func(providerConfig config.Provider, configVariablesClient config.VariablesClient, log logr.Logger) (Repository, error) {
+		if providerConfig.Name() == "broken" {
+			return nil, errors.New("broken")
+		}
+		return fake, nil
+	})
+	defer unregisterRepositoryType("artifacts")
+
+	repoClient, err := newRepositoryClient(config.NewProvider("p1", "artifacts://store/p1", clusterctlv1.InfrastructureProviderType), test.NewFakeVariableClient())
+	if err != nil {
+		t.Fatalf("got error %v when none was expected", err)
+	}
+	instrumented, ok := repoClient.repository.(*instrumentedRepository)
+	if !ok {
+		t.Fatalf("got repository of type %T when *repository.instrumentedRepository was expected", repoClient.repository)
+	}
+	if instrumented.Repository != fake {
+		t.Fatalf("got repository of type %T when the registered repository was expected", instrumented.Repository)
+	}
+
+	// The scheme is case insensitive.
+	if _, err := newRepositoryClient(config.NewProvider("p1", "ARTIFACTS://store/p1", clusterctlv1.InfrastructureProviderType), test.NewFakeVariableClient()); err != nil {
+		t.Fatalf("got error %v when none was expected", err)
+	}
+
+	if _, err := newRepositoryClient(config.NewProvider("broken", "artifacts://store/broken", clusterctlv1.InfrastructureProviderType), test.NewFakeVariableClient()); err == nil {
+		t.Fatalf("got no error when the registered constructor fails")
+	}
+
+	if _, err := newRepositoryClient(config.NewProvider("p2", "unknown://store/p2", clusterctlv1.InfrastructureProviderType), test.NewFakeVariableClient()); err == nil {
+		t.Fatalf("got no error for a scheme without a registered repository type")
+	}
+}
+
+func TestRegisterRepositoryType(t *testing.T) {
+	constructor := func(config.Provider, config.VariablesClient, logr.Logger) (Repository, error) {
+		return test.NewFakeRepository(), nil
+	}
+
+	RegisterRepositoryType("registered", constructor)
+	defer unregisterRepositoryType("registered")
+
+	if got, want := RegisteredRepositorySchemes(), []string{"registered"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("RegisteredRepositorySchemes() = %v, want %v", got, want)
+	}
+
+	tests := []struct {
+		name        string
+		scheme      string
+		constructor RepositoryConstructor
+	}{
+		{
+			name:        "the empty scheme is reserved",
+			scheme:      "",
+			constructor: constructor,
+		},
+		{
+			name:        "the file scheme is reserved",
+			scheme:      "File",
+			constructor: constructor,
+		},
+		{
+			name:        "a scheme can't be registered twice",
+			scheme:      "Registered",
+			constructor: constructor,
+		},
+		{
+			name:        "the constructor is required",
+			scheme:      "other",
+			constructor: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("RegisterRepositoryType() did not panic")
+				}
+			}()
+			RegisterRepositoryType(tt.scheme, tt.constructor)
+		})
+	}
+}
+
+// unregisterRepositoryType removes a repository type registered by a test.
+func unregisterRepositoryType(scheme string) {
+	repositoryTypesMu.Lock()
+	defer repositoryTypesMu.Unlock()
+	delete(repositoryTypes, scheme)
+}
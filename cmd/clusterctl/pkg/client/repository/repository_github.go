@@ -24,6 +24,7 @@ import (
 	"path/filepath"
 	"strings"
 
+	"github.com/go-logr/logr"
 	"github.com/google/go-github/github"
 	"github.com/pkg/errors"
 	"golang.org/x/oauth2"
@@ -53,6 +54,7 @@ type gitHubRepository struct {
 	rootPath                 string
 	componentsPath           string
 	injectClient             *github.Client
+	log                      logr.Logger
 }
 
 var _ Repository = &gitHubRepository{}
@@ -63,8 +65,8 @@ func (g *gitHubRepository) DefaultVersion() string {
 }
 
 // GetVersion returns the list of versions that are available in a provider repository
-func (g *gitHubRepository) GetVersions() ([]string, error) {
-	versions, err := g.getVersions()
+func (g *gitHubRepository) GetVersions(ctx context.Context) ([]string, error) {
+	versions, err := g.getVersions(ctx)
 	if err != nil {
 		return nil, errors.Wrapf(err, "failed to get repository versions")
 	}
@@ -82,14 +84,14 @@ func (g *gitHubRepository) ComponentsPath() string {
 }
 
 // GetFile returns a file for a given provider version
-func (g *gitHubRepository) GetFile(version, path string) ([]byte, error) {
-	release, err := g.getReleaseByTag(version)
+func (g *gitHubRepository) GetFile(ctx context.Context, version, path string) ([]byte, error) {
+	release, err := g.getReleaseByTag(ctx, version)
 	if err != nil {
 		return nil, errors.Wrapf(err, "failed to get GitHub release %s", version)
 	}
 
 	// download files from the release
-	files, err := g.downloadFilesFromRelease(release, path)
+	files, err := g.downloadFilesFromRelease(ctx, release, path)
 	if err != nil {
 		return nil, errors.Wrapf(err, "failed to download files from GitHub release %s", version)
 	}
@@ -97,8 +99,29 @@ func (g *gitHubRepository) GetFile(version, path string) ([]byte, error) {
 	return files, nil
 }
 
+// ListFiles returns the names of the assets of the release for a given provider version, relative to the root path.
+func (g *gitHubRepository) ListFiles(ctx context.Context, version string) ([]string, error) {
+	release, err := g.getReleaseByTag(ctx, version)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get GitHub release %s", version)
+	}
+
+	files := []string{}
+	for _, a := range release.Assets {
+		if a.Name == nil {
+			continue
+		}
+		name, err := filepath.Rel(g.rootPath, *a.Name)
+		if err != nil || strings.HasPrefix(name, "..") {
+			continue
+		}
+		files = append(files, name)
+	}
+	return files, nil
+}
+
 // newGitHubRepository returns a gitHubRepository implementation
-func newGitHubRepository(providerConfig config.Provider, configVariablesClient config.VariablesClient) (*gitHubRepository, error) {
+func newGitHubRepository(providerConfig config.Provider, configVariablesClient config.VariablesClient, log logr.Logger) (*gitHubRepository, error) {
 	if configVariablesClient == nil {
 		return nil, errors.New("invalid arguments: configVariablesClient can't be nil")
 	}
@@ -142,6 +165,7 @@ func newGitHubRepository(providerConfig config.Provider, configVariablesClient c
 		defaultVersion:        defaultVersion,
 		rootPath:              rootPath,
 		componentsPath:        componentsPath,
+		log:                   log,
 	}
 
 	if token, err := configVariablesClient.Get(config.GitHubTokenVariable); err == nil {
@@ -149,7 +173,7 @@ func newGitHubRepository(providerConfig config.Provider, configVariablesClient c
 	}
 
 	if defaultVersion == githubLatestReleaseLabel {
-		repo.defaultVersion, err = repo.getLatestRelease()
+		repo.defaultVersion, err = repo.getLatestRelease(context.TODO())
 		if err != nil {
 			return nil, errors.Wrap(err, "failed to get GitHub latest version")
 		}
@@ -184,12 +208,13 @@ func (g *gitHubRepository) setClientToken(token string) {
 }
 
 // getVersions returns all the release versions for a github repository
-func (g *gitHubRepository) getVersions() ([]string, error) {
+func (g *gitHubRepository) getVersions(ctx context.Context) ([]string, error) {
 	client := g.getClient()
 
+	g.log.V(5).Info("Listing GitHub releases", "Owner", g.owner, "Repository", g.repository)
 	// get all the releases
 	// NB. currently Github API does not support result ordering, so it not possible to limit results
-	releases, _, err := client.Repositories.ListReleases(context.TODO(), g.owner, g.repository, nil)
+	releases, _, err := client.Repositories.ListReleases(ctx, g.owner, g.repository, nil)
 	if err != nil {
 		return nil, g.handleGithubErr(err, "failed to get the list of releases")
 	}
@@ -211,8 +236,8 @@ func (g *gitHubRepository) getVersions() ([]string, error) {
 
 // getLatestRelease returns the latest release for a github repository, according to
 // semantic version order of the release tag name.
-func (g *gitHubRepository) getLatestRelease() (string, error) {
-	versions, err := g.getVersions()
+func (g *gitHubRepository) getLatestRelease(ctx context.Context) (string, error) {
+	versions, err := g.getVersions(ctx)
 	if err != nil {
 		return "", g.handleGithubErr(err, "failed to get the list of versions")
 	}
@@ -240,10 +265,11 @@ func (g *gitHubRepository) getLatestRelease() (string, error) {
 }
 
 // getReleaseByTag returns the github repository release with a specific tag name.
-func (g *gitHubRepository) getReleaseByTag(tag string) (*github.RepositoryRelease, error) {
+func (g *gitHubRepository) getReleaseByTag(ctx context.Context, tag string) (*github.RepositoryRelease, error) {
 	client := g.getClient()
 
-	release, _, err := client.Repositories.GetReleaseByTag(context.TODO(), g.owner, g.repository, tag)
+	g.log.V(5).Info("Getting GitHub release", "Owner", g.owner, "Repository", g.repository, "Tag", tag)
+	release, _, err := client.Repositories.GetReleaseByTag(ctx, g.owner, g.repository, tag)
 	if err != nil {
 		return nil, g.handleGithubErr(err, "failed to read release %q", tag)
 	}
@@ -256,7 +282,7 @@ func (g *gitHubRepository) getReleaseByTag(tag string) (*github.RepositoryReleas
 }
 
 // downloadFilesFromRelease download a file from release.
-func (g *gitHubRepository) downloadFilesFromRelease(release *github.RepositoryRelease, fileName string) ([]byte, error) {
+func (g *gitHubRepository) downloadFilesFromRelease(ctx context.Context, release *github.RepositoryRelease, fileName string) ([]byte, error) {
 	client := g.getClient()
 	absoluteFileName := filepath.Join(g.rootPath, fileName)
 
@@ -272,12 +298,18 @@ func (g *gitHubRepository) downloadFilesFromRelease(release *github.RepositoryRe
 		return nil, errors.Errorf("failed to get file %q from %q release", fileName, *release.TagName)
 	}
 
-	reader, redirect, err := client.Repositories.DownloadReleaseAsset(context.TODO(), g.owner, g.repository, *assetID)
+	g.log.V(5).Info("Downloading GitHub release asset", "Owner", g.owner, "Repository", g.repository, "Tag", *release.TagName, "File", absoluteFileName)
+	reader, redirect, err := client.Repositories.DownloadReleaseAsset(ctx, g.owner, g.repository, *assetID)
 	if err != nil {
 		return nil, g.handleGithubErr(err, "failed to download file %q from %q release", *release.TagName, fileName)
 	}
 	if redirect != "" {
-		response, err := http.Get(redirect) //nolint:bodyclose (NB: The reader is actually closed in a defer)
+		g.log.V(5).Info("Following GitHub release asset redirect", "File", absoluteFileName, "Location", redirect)
+		request, err := http.NewRequestWithContext(ctx, http.MethodGet, redirect, nil)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to download file %q from %q release via redirect location %q", *release.TagName, fileName, redirect)
+		}
+		response, err := http.DefaultClient.Do(request) //nolint:bodyclose (NB: The reader is actually closed in a defer)
 		if err != nil {
 			return nil, errors.Wrapf(err, "failed to download file %q from %q release via redirect location %q", *release.TagName, fileName, redirect)
 		}
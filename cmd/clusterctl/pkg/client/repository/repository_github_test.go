@@ -17,6 +17,7 @@ limitations under the License.
 package repository
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"reflect"
@@ -26,6 +27,7 @@ import (
 	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/client/config"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/internal/test"
+	logf "sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/log"
 )
 
 //TODO: test newGitHubRepository
@@ -72,13 +74,13 @@ func Test_gitHubRepository_getVersions(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			g, err := newGitHubRepository(tt.field.providerConfig, configVariablesClient)
+			g, err := newGitHubRepository(tt.field.providerConfig, configVariablesClient, logf.Log)
 			if err != nil {
 				t.Fatal(err)
 			}
 			g.injectClient = client
 
-			got, err := g.getVersions()
+			got, err := g.getVersions(context.Background())
 			if (err != nil) != tt.wantErr {
 				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
 			}
@@ -144,13 +146,13 @@ func Test_gitHubRepository_getLatestRelease(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			g, err := newGitHubRepository(tt.field.providerConfig, configVariablesClient)
+			g, err := newGitHubRepository(tt.field.providerConfig, configVariablesClient, logf.Log)
 			if err != nil {
 				t.Fatal(err)
 			}
 			g.injectClient = client
 
-			got, err := g.getLatestRelease()
+			got, err := g.getLatestRelease(context.Background())
 			if (err != nil) != tt.wantErr {
 				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
 			}
@@ -207,13 +209,13 @@ func Test_gitHubRepository_getReleaseByTag(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			g, err := newGitHubRepository(providerConfig, configVariablesClient)
+			g, err := newGitHubRepository(providerConfig, configVariablesClient, logf.Log)
 			if err != nil {
 				t.Fatal(err)
 			}
 			g.injectClient = client
 
-			got, err := g.getReleaseByTag(tt.args.tag)
+			got, err := g.getReleaseByTag(context.Background(), tt.args.tag)
 			if (err != nil) != tt.wantErr {
 				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
 			}
@@ -320,13 +322,13 @@ func Test_gitHubRepository_downloadFilesFromRelease(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			g, err := newGitHubRepository(providerConfig, configVariablesClient)
+			g, err := newGitHubRepository(providerConfig, configVariablesClient, logf.Log)
 			if err != nil {
 				t.Fatal(err)
 			}
 			g.injectClient = client
 
-			got, err := g.downloadFilesFromRelease(tt.args.release, tt.args.fileName)
+			got, err := g.downloadFilesFromRelease(context.Background(), tt.args.release, tt.args.fileName)
 			if (err != nil) != tt.wantErr {
 				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
 			}
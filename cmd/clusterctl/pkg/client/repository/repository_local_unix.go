@@ -17,12 +17,14 @@ limitations under the License.
 package repository
 
 import (
+	"context"
 	"io/ioutil"
 	"net/url"
 	"os"
 	"path/filepath"
 	"strings"
 
+	"github.com/go-logr/logr"
 	"github.com/pkg/errors"
 	"k8s.io/apimachinery/pkg/util/version"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/client/config"
@@ -52,6 +54,7 @@ type localRepository struct {
 	providerName          string
 	defaultVersion        string
 	componentsPath        string
+	log                   logr.Logger
 }
 
 var _ Repository = &localRepository{}
@@ -72,11 +75,11 @@ func (r *localRepository) ComponentsPath() string {
 }
 
 // GetFile returns a file for a given provider version.
-func (r *localRepository) GetFile(version, fileName string) ([]byte, error) {
+func (r *localRepository) GetFile(ctx context.Context, version, fileName string) ([]byte, error) {
 	var err error
 
 	if version == "latest" {
-		version, err = r.getLatestRelease()
+		version, err = r.getLatestRelease(ctx)
 		if err != nil {
 			return nil, errors.Wrapf(err, "failed to get the latest release")
 		}
@@ -85,6 +88,7 @@ func (r *localRepository) GetFile(version, fileName string) ([]byte, error) {
 	}
 
 	absolutePath := filepath.Join(r.basepath, r.providerName, version, r.RootPath(), fileName)
+	r.log.V(5).Info("Reading file from local repository", "Path", absolutePath)
 
 	f, err := os.Stat(absolutePath)
 	if err != nil {
@@ -101,8 +105,37 @@ func (r *localRepository) GetFile(version, fileName string) ([]byte, error) {
 
 }
 
+// ListFiles returns the names of the files available for a given provider version in the local repository.
+func (r *localRepository) ListFiles(ctx context.Context, version string) ([]string, error) {
+	var err error
+
+	if version == "latest" {
+		version, err = r.getLatestRelease(ctx)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get the latest release")
+		}
+	} else if version == "" {
+		version = r.defaultVersion
+	}
+
+	releasePath := filepath.Join(r.basepath, r.providerName, version, r.RootPath())
+	infos, err := ioutil.ReadDir(releasePath)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list the files of local release %s", version)
+	}
+
+	files := []string{}
+	for _, f := range infos {
+		if f.IsDir() {
+			continue
+		}
+		files = append(files, f.Name())
+	}
+	return files, nil
+}
+
 // GetVersions returns the list of versions that are available for a local repository.
-func (r *localRepository) GetVersions() ([]string, error) {
+func (r *localRepository) GetVersions(ctx context.Context) ([]string, error) {
 	// get all the sub-directories under {basepath}/{provider-name}/
 	releasesPath := filepath.Join(r.basepath, r.providerName)
 	files, err := ioutil.ReadDir(releasesPath)
@@ -126,7 +159,7 @@ func (r *localRepository) GetVersions() ([]string, error) {
 }
 
 // newLocalRepository returns a new localRepository.
-func newLocalRepository(providerConfig config.Provider, configVariablesClient config.VariablesClient) (*localRepository, error) {
+func newLocalRepository(providerConfig config.Provider, configVariablesClient config.VariablesClient, log logr.Logger) (*localRepository, error) {
 	url, err := url.Parse(providerConfig.URL())
 	if err != nil {
 		return nil, errors.Wrap(err, "invalid url")
@@ -167,10 +200,11 @@ func newLocalRepository(providerConfig config.Provider, configVariablesClient co
 		providerName:          providerName,
 		defaultVersion:        defaultVersion,
 		componentsPath:        componentsPath,
+		log:                   log,
 	}
 
 	if defaultVersion == "latest" {
-		repo.defaultVersion, err = repo.getLatestRelease()
+		repo.defaultVersion, err = repo.getLatestRelease(context.TODO())
 		if err != nil {
 			return nil, errors.Wrap(err, "failed to get latest version")
 		}
@@ -179,8 +213,8 @@ func newLocalRepository(providerConfig config.Provider, configVariablesClient co
 }
 
 // getLatestRelease returns the latest release for the local repository.
-func (r *localRepository) getLatestRelease() (string, error) {
-	versions, err := r.GetVersions()
+func (r *localRepository) getLatestRelease(ctx context.Context) (string, error) {
+	versions, err := r.GetVersions(ctx)
 	if err != nil {
 		return "", errors.Wrapf(err, "failed to get local repository versions")
 	}
@@ -17,6 +17,7 @@ limitations under the License.
 package repository
 
 import (
+	"context"
 	"io/ioutil"
 	"os"
 	"path/filepath"
@@ -26,6 +27,7 @@ import (
 	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/client/config"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/internal/test"
+	logf "sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/log"
 )
 
 func Test_localRepository_newLocalRepository(t *testing.T) {
@@ -106,7 +108,7 @@ func Test_localRepository_newLocalRepository(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := newLocalRepository(tt.fields.provider, tt.fields.configVariablesClient)
+			got, err := newLocalRepository(tt.fields.provider, tt.fields.configVariablesClient, logf.Log)
 			if (err != nil) != tt.wantErr {
 				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
 			}
@@ -169,7 +171,7 @@ func Test_localRepository_newLocalRepository_Latest(t *testing.T) {
 	p2URLLatestAbs := filepath.Join(tmpDir, p2URLLatest)
 	p2 := config.NewProvider("provider-2", p2URLLatestAbs, clusterctlv1.BootstrapProviderType)
 
-	got, err := newLocalRepository(p2, test.NewFakeVariableClient())
+	got, err := newLocalRepository(p2, test.NewFakeVariableClient(), logf.Log)
 	if err != nil {
 		t.Fatalf("got error %v when none was expected", err)
 	}
@@ -275,11 +277,11 @@ func Test_localRepository_GetFile(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			r, err := newLocalRepository(tt.fields.provider, tt.fields.configVariablesClient)
+			r, err := newLocalRepository(tt.fields.provider, tt.fields.configVariablesClient, logf.Log)
 			if err != nil {
 				t.Fatalf("unexpected error: %v", err)
 			}
-			got, err := r.GetFile(tt.args.version, tt.args.fileName)
+			got, err := r.GetFile(context.Background(), tt.args.version, tt.args.fileName)
 			if (err != nil) != tt.wantErr {
 				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
 			}
@@ -351,12 +353,12 @@ func Test_localRepository_GetVersions(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			r, err := newLocalRepository(tt.fields.provider, tt.fields.configVariablesClient)
+			r, err := newLocalRepository(tt.fields.provider, tt.fields.configVariablesClient, logf.Log)
 			if err != nil {
 				t.Fatalf("unexpected error: %v", err)
 				return
 			}
-			got, err := r.GetVersions()
+			got, err := r.GetVersions(context.Background())
 			if (err != nil) != tt.wantErr {
 				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
 			}
@@ -0,0 +1,62 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package repository
+
+import (
+	"context"
+
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/metrics"
+)
+
+// instrumentedRepository wraps a Repository, counting the errors occurred while fetching from it.
+type instrumentedRepository struct {
+	Repository
+	providerName string
+}
+
+var _ Repository = &instrumentedRepository{}
+
+func newInstrumentedRepository(repository Repository, providerName string) *instrumentedRepository {
+	return &instrumentedRepository{
+		Repository:   repository,
+		providerName: providerName,
+	}
+}
+
+func (r *instrumentedRepository) GetFile(ctx context.Context, version string, path string) ([]byte, error) {
+	file, err := r.Repository.GetFile(ctx, version, path)
+	if err != nil {
+		metrics.RepositoryFetchErrors.WithLabelValues(r.providerName).Inc()
+	}
+	return file, err
+}
+
+func (r *instrumentedRepository) ListFiles(ctx context.Context, version string) ([]string, error) {
+	files, err := r.Repository.ListFiles(ctx, version)
+	if err != nil {
+		metrics.RepositoryFetchErrors.WithLabelValues(r.providerName).Inc()
+	}
+	return files, err
+}
+
+func (r *instrumentedRepository) GetVersions(ctx context.Context) ([]string, error) {
+	versions, err := r.Repository.GetVersions(ctx)
+	if err != nil {
+		metrics.RepositoryFetchErrors.WithLabelValues(r.providerName).Inc()
+	}
+	return versions, err
+}
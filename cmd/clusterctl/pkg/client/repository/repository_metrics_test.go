@@ -0,0 +1,49 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/internal/test"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/metrics"
+)
+
+func Test_instrumentedRepository(t *testing.T) {
+	repository := test.NewFakeRepository().
+		WithPaths("root", "components.yaml").
+		WithDefaultVersion("v1.0").
+		WithFile("v1.0", "components.yaml", []byte("content"))
+	r := newInstrumentedRepository(repository, "instrumented-provider")
+	fetchErrors := metrics.RepositoryFetchErrors.WithLabelValues("instrumented-provider")
+
+	if _, err := r.GetFile(context.Background(), "v1.0", "components.yaml"); err != nil {
+		t.Fatalf("GetFile() error = %v", err)
+	}
+	if got := testutil.ToFloat64(fetchErrors); got != 0 {
+		t.Errorf("got %v fetch errors after a successful fetch, want 0", got)
+	}
+
+	if _, err := r.GetFile(context.Background(), "v1.0", "missing.yaml"); err == nil {
+		t.Fatalf("GetFile() expected an error for a missing file")
+	}
+	if got := testutil.ToFloat64(fetchErrors); got != 1 {
+		t.Errorf("got %v fetch errors after a failed fetch, want 1", got)
+	}
+}
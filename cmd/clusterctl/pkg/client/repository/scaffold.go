@@ -0,0 +1,472 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package repository
+
+import (
+	"bytes"
+	"path/filepath"
+	"sort"
+	"strings"
+	texttemplate "text/template"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/util/validation"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
+)
+
+// ScaffoldOptions carries the options supported by Scaffold.
+type ScaffoldOptions struct {
+	// Name of the provider, e.g. aws; it must be a valid DNS label.
+	Name string
+
+	// Type of the provider; the core provider type is not supported.
+	Type clusterctlv1.ProviderType
+
+	// Contract is the API Version of Cluster API (contract) supported by the first release series of the provider.
+	// By default (empty), the contract of this version of clusterctl is used.
+	Contract string
+}
+
+// ScaffoldFile is a file generated by Scaffold.
+type ScaffoldFile struct {
+	// Path of the file, relative to the root of the provider repository.
+	Path string
+
+	// Content of the file.
+	Content []byte
+
+	// Executable is true for the scripts.
+	Executable bool
+}
+
+// scaffoldProviderType defines the provider specific parts of the scaffolding.
+type scaffoldProviderType struct {
+	// prefix of the provider label and of the components YAML, e.g. infrastructure.
+	prefix string
+
+	// group of the provider API.
+	group string
+
+	// kinds defined by the provider API, without the provider specific prefix, e.g. Cluster for AWSCluster.
+	kinds []string
+}
+
+var scaffoldProviderTypes = map[clusterctlv1.ProviderType]scaffoldProviderType{
+	clusterctlv1.InfrastructureProviderType: {
+		prefix: "infrastructure",
+		group:  "infrastructure.cluster.x-k8s.io",
+		kinds:  []string{"Cluster", "Machine", "MachineTemplate"},
+	},
+	clusterctlv1.BootstrapProviderType: {
+		prefix: "bootstrap",
+		group:  "bootstrap.cluster.x-k8s.io",
+		kinds:  []string{"Config", "ConfigTemplate"},
+	},
+	clusterctlv1.ControlPlaneProviderType: {
+		prefix: "control-plane",
+		group:  "controlplane.cluster.x-k8s.io",
+		kinds:  []string{"ControlPlane"},
+	},
+}
+
+// scaffoldData is the data the scaffolding templates are executed with.
+type scaffoldData struct {
+	Name           string
+	Type           clusterctlv1.ProviderType
+	Label          string
+	Namespace      string
+	Group          string
+	KindPrefix     string
+	Kinds          []string
+	Contract       string
+	ComponentsFile string
+	Infrastructure bool
+}
+
+// Scaffold returns the files of a new provider repository conforming to the clusterctl provider contract: the
+// metadata YAML, a kustomize layout for building the components YAML, the cluster templates (for infrastructure
+// providers only) and a script packaging the release assets.
+func Scaffold(options ScaffoldOptions) ([]ScaffoldFile, error) {
+	if errs := validation.IsDNS1123Label(options.Name); len(errs) != 0 {
+		return nil, errors.Errorf("invalid provider name %q: %s", options.Name, strings.Join(errs, "; "))
+	}
+
+	providerType, ok := scaffoldProviderTypes[options.Type]
+	if !ok {
+		return nil, errors.Errorf("unable to scaffold a provider repository for providers of type %q", options.Type)
+	}
+
+	contract := options.Contract
+	if contract == "" {
+		contract = clusterv1.GroupVersion.Version
+	}
+
+	label := providerType.prefix + "-" + options.Name
+	data := scaffoldData{
+		Name:           options.Name,
+		Type:           options.Type,
+		Label:          label,
+		Namespace:      label + "-system",
+		Group:          providerType.group,
+		KindPrefix:     kindPrefix(options.Name),
+		Kinds:          providerType.kinds,
+		Contract:       contract,
+		ComponentsFile: providerType.prefix + "-components.yaml",
+		Infrastructure: options.Type == clusterctlv1.InfrastructureProviderType,
+	}
+
+	templates := map[string]string{
+		"README.md":                         scaffoldReadme,
+		"metadata.yaml":                     scaffoldMetadata,
+		"config/kustomization.yaml":         scaffoldKustomization,
+		"config/namespace.yaml":             scaffoldNamespace,
+		"config/crd/kustomization.yaml":     scaffoldCRDKustomization,
+		"config/rbac/kustomization.yaml":    scaffoldRBACKustomization,
+		"config/rbac/role.yaml":             scaffoldRole,
+		"config/rbac/role_binding.yaml":     scaffoldRoleBinding,
+		"config/manager/kustomization.yaml": scaffoldManagerKustomization,
+		"config/manager/manager.yaml":       scaffoldManager,
+		"hack/release-manifests.sh":         scaffoldReleaseScript,
+	}
+	if data.Infrastructure {
+		templates["templates/cluster-template.yaml"] = scaffoldClusterTemplate
+	}
+
+	var files []ScaffoldFile
+	for path, text := range templates {
+		t, err := texttemplate.New(path).Funcs(texttemplate.FuncMap{"lower": strings.ToLower}).Parse(text)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to parse the template of %q", path)
+		}
+		var b bytes.Buffer
+		if err := t.Execute(&b, data); err != nil {
+			return nil, errors.Wrapf(err, "failed to generate %q", path)
+		}
+		files = append(files, ScaffoldFile{
+			Path:       path,
+			Content:    b.Bytes(),
+			Executable: filepath.Ext(path) == ".sh",
+		})
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].Path < files[j].Path
+	})
+	return files, nil
+}
+
+// kindPrefix returns the prefix of the kinds of a provider API, e.g. MyCloud for my-cloud.
+func kindPrefix(name string) string {
+	var b strings.Builder
+	for _, part := range strings.Split(name, "-") {
+		if part == "" {
+			continue
+		}
+		b.WriteString(strings.ToUpper(part[:1]) + part[1:])
+	}
+	return b.String()
+}
+
+const scaffoldReadme = `# Cluster API provider {{ .Name }}
+
+This repository has been scaffolded by ` + "`clusterctl generate provider-repo`" + `; the layout conforms to the
+clusterctl provider contract, see https://cluster-api.sigs.k8s.io/clusterctl/provider-contract.html.
+
+Next steps:
+
+* Generate the CustomResourceDefinitions of the {{ range $i, $k := .Kinds }}{{ if $i }}, {{ end }}{{ $.KindPrefix }}{{ $k }}{{ end }} types into
+  ` + "`config/crd/bases`" + `, e.g. using controller-gen.
+* Set the image of the controller in ` + "`config/manager/manager.yaml`" + `.
+* Add a release series to ` + "`metadata.yaml`" + ` for each new minor release.
+{{- if .Infrastructure }}
+* Complete the spec of the {{ .KindPrefix }}Cluster and {{ .KindPrefix }}MachineTemplate objects in ` + "`templates/cluster-template.yaml`" + `.
+{{- end }}
+
+Run ` + "`hack/release-manifests.sh <version>`" + ` to build the release assets; the ` + "`out`" + ` folder can be
+used as a local provider repository in the clusterctl configuration, e.g.
+
+` + "```yaml" + `
+providers:
+- name: {{ .Name }}
+  url: file://<path>/out/{{ .Label }}/latest/{{ .ComponentsFile }}
+  type: {{ .Type }}
+` + "```" + `
+`
+
+const scaffoldMetadata = `# The metadata YAML maps each release series of the provider to the API Version of Cluster API (contract) it supports.
+apiVersion: clusterctl.cluster.x-k8s.io/v1alpha3
+kind: Metadata
+releaseSeries:
+- major: 0
+  minor: 1
+  contract: {{ .Contract }}
+`
+
+const scaffoldKustomization = `namespace: {{ .Namespace }}
+namePrefix: {{ .Label }}-
+
+commonLabels:
+  cluster.x-k8s.io/provider: {{ .Label }}
+
+resources:
+- namespace.yaml
+- crd
+- rbac
+- manager
+`
+
+const scaffoldNamespace = `apiVersion: v1
+kind: Namespace
+metadata:
+  name: system
+`
+
+const scaffoldCRDKustomization = `# The CustomResourceDefinitions are labeled with the API Version of Cluster API (contract) they implement.
+commonLabels:
+  cluster.x-k8s.io/{{ .Contract }}: {{ .Contract }}
+
+resources:
+{{- range .Kinds }}
+- bases/{{ $.Group }}_{{ lower $.KindPrefix }}{{ lower . }}s.yaml
+{{- end }}
+`
+
+const scaffoldRBACKustomization = `resources:
+- role.yaml
+- role_binding.yaml
+`
+
+const scaffoldRole = `apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRole
+metadata:
+  name: manager-role
+rules:
+- apiGroups:
+  - {{ .Group }}
+  resources:
+{{- range .Kinds }}
+  - {{ lower $.KindPrefix }}{{ lower . }}s
+  - {{ lower $.KindPrefix }}{{ lower . }}s/status
+{{- end }}
+  verbs:
+  - create
+  - delete
+  - get
+  - list
+  - patch
+  - update
+  - watch
+- apiGroups:
+  - cluster.x-k8s.io
+  resources:
+  - clusters
+  - clusters/status
+  - machines
+  - machines/status
+  verbs:
+  - get
+  - list
+  - watch
+- apiGroups:
+  - ""
+  resources:
+  - secrets
+  - events
+  verbs:
+  - create
+  - get
+  - list
+  - patch
+  - update
+  - watch
+`
+
+const scaffoldRoleBinding = `apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRoleBinding
+metadata:
+  name: manager-rolebinding
+roleRef:
+  apiGroup: rbac.authorization.k8s.io
+  kind: ClusterRole
+  name: manager-role
+subjects:
+- kind: ServiceAccount
+  name: default
+  namespace: system
+`
+
+const scaffoldManagerKustomization = `resources:
+- manager.yaml
+`
+
+const scaffoldManager = `# The container running the controller MUST be named manager, and it MUST support the --namespace flag,
+# which is set by clusterctl for limiting the namespace the controller watches.
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: controller-manager
+  namespace: system
+  labels:
+    control-plane: controller-manager
+spec:
+  selector:
+    matchLabels:
+      control-plane: controller-manager
+  replicas: 1
+  template:
+    metadata:
+      labels:
+        control-plane: controller-manager
+    spec:
+      containers:
+      - name: manager
+        image: controller:latest
+        args:
+        - --enable-leader-election
+      terminationGracePeriodSeconds: 10
+`
+
+const scaffoldReleaseScript = `#!/usr/bin/env bash
+
+# Builds the release assets of the provider into out/{{ .Label }}/<version>, following the layout of a clusterctl
+# local provider repository; the assets MUST be attached to the GitHub release of the same version.
+
+set -o errexit
+set -o nounset
+set -o pipefail
+
+VERSION=${1:?usage: $0 <version>}
+ROOT=$(dirname "${BASH_SOURCE[0]}")/..
+RELEASE_DIR=${ROOT}/out/{{ .Label }}/${VERSION}
+
+mkdir -p "${RELEASE_DIR}"
+kustomize build "${ROOT}/config" > "${RELEASE_DIR}/{{ .ComponentsFile }}"
+cp "${ROOT}/metadata.yaml" "${RELEASE_DIR}/metadata.yaml"
+{{- if .Infrastructure }}
+cp "${ROOT}"/templates/cluster-template*.yaml "${RELEASE_DIR}/"
+{{- end }}
+
+echo "Release assets available in ${RELEASE_DIR}"
+`
+
+const scaffoldClusterTemplate = `# clusterctl:variables
+# - name: CLUSTER_NAME
+#   description: The name of the workload cluster.
+# - name: KUBERNETES_VERSION
+#   description: The Kubernetes version of the workload cluster.
+# - name: CONTROL_PLANE_MACHINE_COUNT
+#   type: integer
+#   default: 1
+#   description: The number of control plane machines.
+# - name: WORKER_MACHINE_COUNT
+#   type: integer
+#   default: 1
+#   description: The number of worker machines.
+---
+apiVersion: cluster.x-k8s.io/{{ .Contract }}
+kind: Cluster
+metadata:
+  name: "${CLUSTER_NAME}"
+spec:
+  clusterNetwork:
+    pods:
+      cidrBlocks: ["192.168.0.0/16"]
+  infrastructureRef:
+    apiVersion: {{ .Group }}/{{ .Contract }}
+    kind: {{ .KindPrefix }}Cluster
+    name: "${CLUSTER_NAME}"
+  controlPlaneRef:
+    apiVersion: controlplane.cluster.x-k8s.io/{{ .Contract }}
+    kind: KubeadmControlPlane
+    name: "${CLUSTER_NAME}-control-plane"
+---
+apiVersion: {{ .Group }}/{{ .Contract }}
+kind: {{ .KindPrefix }}Cluster
+metadata:
+  name: "${CLUSTER_NAME}"
+spec: {}
+---
+apiVersion: controlplane.cluster.x-k8s.io/{{ .Contract }}
+kind: KubeadmControlPlane
+metadata:
+  name: "${CLUSTER_NAME}-control-plane"
+spec:
+  replicas: ${CONTROL_PLANE_MACHINE_COUNT}
+  version: "${KUBERNETES_VERSION}"
+  infrastructureTemplate:
+    apiVersion: {{ .Group }}/{{ .Contract }}
+    kind: {{ .KindPrefix }}MachineTemplate
+    name: "${CLUSTER_NAME}-control-plane"
+  kubeadmConfigSpec:
+    initConfiguration:
+      nodeRegistration:
+        kubeletExtraArgs: {}
+    joinConfiguration:
+      nodeRegistration:
+        kubeletExtraArgs: {}
+---
+apiVersion: {{ .Group }}/{{ .Contract }}
+kind: {{ .KindPrefix }}MachineTemplate
+metadata:
+  name: "${CLUSTER_NAME}-control-plane"
+spec:
+  template:
+    spec: {}
+---
+apiVersion: cluster.x-k8s.io/{{ .Contract }}
+kind: MachineDeployment
+metadata:
+  name: "${CLUSTER_NAME}-md-0"
+spec:
+  clusterName: "${CLUSTER_NAME}"
+  replicas: ${WORKER_MACHINE_COUNT}
+  selector:
+    matchLabels: {}
+  template:
+    spec:
+      clusterName: "${CLUSTER_NAME}"
+      version: "${KUBERNETES_VERSION}"
+      bootstrap:
+        configRef:
+          apiVersion: bootstrap.cluster.x-k8s.io/{{ .Contract }}
+          kind: KubeadmConfigTemplate
+          name: "${CLUSTER_NAME}-md-0"
+      infrastructureRef:
+        apiVersion: {{ .Group }}/{{ .Contract }}
+        kind: {{ .KindPrefix }}MachineTemplate
+        name: "${CLUSTER_NAME}-md-0"
+---
+apiVersion: {{ .Group }}/{{ .Contract }}
+kind: {{ .KindPrefix }}MachineTemplate
+metadata:
+  name: "${CLUSTER_NAME}-md-0"
+spec:
+  template:
+    spec: {}
+---
+apiVersion: bootstrap.cluster.x-k8s.io/{{ .Contract }}
+kind: KubeadmConfigTemplate
+metadata:
+  name: "${CLUSTER_NAME}-md-0"
+spec:
+  template:
+    spec:
+      joinConfiguration:
+        nodeRegistration:
+          kubeletExtraArgs: {}
+`
@@ -0,0 +1,151 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package repository
+
+import (
+	"reflect"
+	"testing"
+
+	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/internal/util"
+	"sigs.k8s.io/yaml"
+)
+
+func Test_Scaffold(t *testing.T) {
+	tests := []struct {
+		name      string
+		options   ScaffoldOptions
+		wantPaths []string
+		wantErr   bool
+	}{
+		{
+			name:    "Infrastructure provider",
+			options: ScaffoldOptions{Name: "my-cloud", Type: clusterctlv1.InfrastructureProviderType},
+			wantPaths: []string{
+				"README.md",
+				"config/crd/kustomization.yaml",
+				"config/kustomization.yaml",
+				"config/manager/kustomization.yaml",
+				"config/manager/manager.yaml",
+				"config/namespace.yaml",
+				"config/rbac/kustomization.yaml",
+				"config/rbac/role.yaml",
+				"config/rbac/role_binding.yaml",
+				"hack/release-manifests.sh",
+				"metadata.yaml",
+				"templates/cluster-template.yaml",
+			},
+		},
+		{
+			name:    "Bootstrap provider, without cluster templates",
+			options: ScaffoldOptions{Name: "foo", Type: clusterctlv1.BootstrapProviderType, Contract: "v1alpha4"},
+			wantPaths: []string{
+				"README.md",
+				"config/crd/kustomization.yaml",
+				"config/kustomization.yaml",
+				"config/manager/kustomization.yaml",
+				"config/manager/manager.yaml",
+				"config/namespace.yaml",
+				"config/rbac/kustomization.yaml",
+				"config/rbac/role.yaml",
+				"config/rbac/role_binding.yaml",
+				"hack/release-manifests.sh",
+				"metadata.yaml",
+			},
+		},
+		{
+			name:    "Fails for the core provider",
+			options: ScaffoldOptions{Name: "foo", Type: clusterctlv1.CoreProviderType},
+			wantErr: true,
+		},
+		{
+			name:    "Fails for invalid names",
+			options: ScaffoldOptions{Name: "Foo_Bar", Type: clusterctlv1.InfrastructureProviderType},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Scaffold(tt.options)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			var paths []string
+			for _, f := range got {
+				paths = append(paths, f.Path)
+				if f.Executable != (f.Path == "hack/release-manifests.sh") {
+					t.Errorf("%s: Executable = %v", f.Path, f.Executable)
+				}
+				if f.Path == "README.md" || f.Path == "hack/release-manifests.sh" {
+					continue
+				}
+				if _, err := util.ToUnstructured(f.Content); err != nil {
+					t.Errorf("%s is not valid YAML: %v", f.Path, err)
+				}
+			}
+			if !reflect.DeepEqual(paths, tt.wantPaths) {
+				t.Errorf("paths = %v, want %v", paths, tt.wantPaths)
+			}
+
+			for _, f := range got {
+				switch f.Path {
+				case "metadata.yaml":
+					metadata := &clusterctlv1.Metadata{}
+					if err := yaml.Unmarshal(f.Content, metadata); err != nil {
+						t.Fatalf("failed to parse metadata.yaml: %v", err)
+					}
+					wantContract := tt.options.Contract
+					if wantContract == "" {
+						wantContract = "v1alpha3"
+					}
+					if len(metadata.ReleaseSeries) != 1 || metadata.ReleaseSeries[0].Contract != wantContract {
+						t.Errorf("releaseSeries = %v, want a release series with contract %s", metadata.ReleaseSeries, wantContract)
+					}
+				case "templates/cluster-template.yaml":
+					definitions, err := inspectVariableDefinitions(f.Content)
+					if err != nil {
+						t.Fatalf("failed to parse the variable definitions of the cluster template: %v", err)
+					}
+					if len(definitions) != 4 {
+						t.Errorf("got %d variable definitions, want 4", len(definitions))
+					}
+				}
+			}
+		})
+	}
+}
+
+func Test_kindPrefix(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{name: "aws", want: "Aws"},
+		{name: "my-cloud", want: "MyCloud"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := kindPrefix(tt.name); got != tt.want {
+				t.Errorf("kindPrefix() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
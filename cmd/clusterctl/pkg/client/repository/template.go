@@ -33,6 +33,10 @@ type Template interface {
 	// This value is derived by the template YAML.
 	Variables() []string
 
+	// VariablesSchema returns the JSON Schema of the variables required by the template, including the types,
+	// default values and descriptions defined in the template, if any.
+	VariablesSchema() ([]byte, error)
+
 	// TargetNamespace where the template objects will be installed.
 	TargetNamespace() string
 
@@ -46,6 +50,8 @@ type Template interface {
 // template implements Template.
 type template struct {
 	variables       []string
+	definitions     []VariableDefinition
+	inlineDefaults  map[string]string
 	targetNamespace string
 	objs            []unstructured.Unstructured
 }
@@ -57,6 +63,10 @@ func (t *template) Variables() []string {
 	return t.variables
 }
 
+func (t *template) VariablesSchema() ([]byte, error) {
+	return variablesJSONSchema(t.variables, t.definitions, t.inlineDefaults)
+}
+
 func (t *template) TargetNamespace() string {
 	return t.targetNamespace
 }
@@ -73,14 +83,30 @@ func (t *template) Yaml() ([]byte, error) {
 func NewTemplate(rawYaml []byte, configVariablesClient config.VariablesClient, targetNamespace string, listVariablesOnly bool) (*template, error) {
 	// Inspect variables and replace with values from the configuration.
 	variables := inspectVariables(rawYaml)
+	inlineDefaults := inspectInlineDefaults(rawYaml)
+	definitions, err := inspectVariableDefinitions(rawYaml)
+	if err != nil {
+		return nil, err
+	}
 	if listVariablesOnly {
 		return &template{
 			variables:       variables,
+			definitions:     definitions,
+			inlineDefaults:  inlineDefaults,
 			targetNamespace: targetNamespace,
 		}, nil
 	}
 
-	yaml, err := replaceVariables(rawYaml, variables, configVariablesClient)
+	// Variables not set in the configuration get the default value defined in the template definitions, if any,
+	// or the inline default value, e.g. ${VAR:=value}.
+	variablesClient := newDefaultedVariablesClient(configVariablesClient, definitions)
+
+	// Checks the values match the type and the allowed values of the variables before rendering the template.
+	if err := validateVariables(variables, definitions, variablesClient, inlineDefaults); err != nil {
+		return nil, err
+	}
+
+	yaml, err := replaceVariables(rawYaml, variables, variablesClient)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to perform variable substitution")
 	}
@@ -94,10 +120,15 @@ func NewTemplate(rawYaml []byte, configVariablesClient config.VariablesClient, t
 	// Ensures all the template components are deployed in the target namespace (applies only to namespaced objects)
 	// This is required in order to ensure a cluster and all the related objects are in a single namespace, that is a requirement for
 	// the clusterctl move operation (and also for many controller reconciliation loops).
-	objs = fixTargetNamespace(objs, targetNamespace)
+	// If the target namespace is empty, e.g. for templates not defining workload clusters, the objects are left untouched.
+	if targetNamespace != "" {
+		objs = fixTargetNamespace(objs, targetNamespace)
+	}
 
 	return &template{
 		variables:       variables,
+		definitions:     definitions,
+		inlineDefaults:  inlineDefaults,
 		targetNamespace: targetNamespace,
 		objs:            objs,
 	}, nil
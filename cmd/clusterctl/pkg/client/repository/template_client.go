@@ -17,8 +17,12 @@ limitations under the License.
 package repository
 
 import (
+	"context"
 	"fmt"
+	"regexp"
+	"sort"
 
+	"github.com/go-logr/logr"
 	"github.com/pkg/errors"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/client/config"
 	logf "sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/log"
@@ -39,15 +43,32 @@ type TemplateOptions struct {
 // TemplateClient has methods to work with cluster templates hosted on a provider repository.
 // Templates are yaml files to be used for creating a guest cluster.
 type TemplateClient interface {
-	Get(flavor, targetNamespace string, listVariablesOnly bool) (Template, error)
+	Get(ctx context.Context, flavor, targetNamespace string, listVariablesOnly bool) (Template, error)
+
+	// ListFlavors returns the flavors of the cluster templates published in the provider repository, together
+	// with the variables required by each template.
+	ListFlavors(ctx context.Context) ([]Flavor, error)
 }
 
+// Flavor describes a cluster template published in a provider repository.
+type Flavor struct {
+	// Name of the flavor; it is empty for the default cluster template.
+	Name string
+
+	// Variables required by the cluster template.
+	Variables []string
+}
+
+// templateFileRegEx matches the names of the cluster templates, capturing the flavor name.
+var templateFileRegEx = regexp.MustCompile(`^cluster-template(?:-(.+))?\.yaml$`)
+
 // templateClient implements TemplateClient.
 type templateClient struct {
 	provider              config.Provider
 	version               string
 	repository            Repository
 	configVariablesClient config.VariablesClient
+	log                   logr.Logger
 }
 
 // Ensure templateClient implements the TemplateClient interface.
@@ -60,23 +81,18 @@ func newTemplateClient(provider config.Provider, version string, repository Repo
 		version:               version,
 		repository:            repository,
 		configVariablesClient: configVariablesClient,
+		log:                   logf.Log,
 	}
 }
 
 // Get return the template for the flavor specified.
 // In case the template does not exists, an error is returned.
 // Get assumes the following naming convention for templates: cluster-template[-<flavor_name>].yaml
-func (c *templateClient) Get(flavor, targetNamespace string, listVariablesOnly bool) (Template, error) {
-	log := logf.Log
-
+func (c *templateClient) Get(ctx context.Context, flavor, targetNamespace string, listVariablesOnly bool) (Template, error) {
 	if targetNamespace == "" {
 		return nil, errors.New("invalid arguments: please provide a targetNamespace")
 	}
 
-	// we are always reading templateClient for a well know version, that usually is
-	// the version of the provider installed in the management cluster.
-	version := c.version
-
 	// building template name according with the naming convention
 	name := "cluster-template"
 	if flavor != "" {
@@ -84,6 +100,22 @@ func (c *templateClient) Get(flavor, targetNamespace string, listVariablesOnly b
 	}
 	name = fmt.Sprintf("%s.yaml", name)
 
+	rawYaml, err := c.getRawYaml(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewTemplate(rawYaml, c.configVariablesClient, targetNamespace, listVariablesOnly)
+}
+
+// getRawYaml returns the template file with the given name.
+func (c *templateClient) getRawYaml(ctx context.Context, name string) ([]byte, error) {
+	log := c.log
+
+	// we are always reading templateClient for a well know version, that usually is
+	// the version of the provider installed in the management cluster.
+	version := c.version
+
 	// read the component YAML, reading the local override file if it exists, otherwise read from the provider repository
 	rawYaml, err := getLocalOverride(c.provider, version, name)
 	if err != nil {
@@ -92,13 +124,44 @@ func (c *templateClient) Get(flavor, targetNamespace string, listVariablesOnly b
 
 	if rawYaml == nil {
 		log.V(1).Info("Fetching", "File", name, "Provider", c.provider.Name(), "Version", version)
-		rawYaml, err = c.repository.GetFile(version, name)
+		rawYaml, err = c.repository.GetFile(ctx, version, name)
 		if err != nil {
 			return nil, errors.Wrapf(err, "failed to read %q from provider's repository %q", name, c.provider.Name())
 		}
 	} else {
 		log.V(1).Info("Using", "Override", name, "Provider", c.provider.Name(), "Version", version)
 	}
+	return rawYaml, nil
+}
 
-	return NewTemplate(rawYaml, c.configVariablesClient, targetNamespace, listVariablesOnly)
+// ListFlavors returns the flavors of the cluster templates published for the version, according to the
+// naming convention of the templates: cluster-template[-<flavor_name>].yaml.
+func (c *templateClient) ListFlavors(ctx context.Context) ([]Flavor, error) {
+	files, err := c.repository.ListFiles(ctx, c.version)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list the files of provider's repository %q", c.provider.Name())
+	}
+
+	flavors := []Flavor{}
+	for _, f := range files {
+		m := templateFileRegEx.FindStringSubmatch(f)
+		if m == nil {
+			continue
+		}
+
+		rawYaml, err := c.getRawYaml(ctx, f)
+		if err != nil {
+			return nil, err
+		}
+		flavors = append(flavors, Flavor{
+			Name:      m[1],
+			Variables: inspectVariables(rawYaml),
+		})
+	}
+
+	// The default cluster template goes first.
+	sort.Slice(flavors, func(i, j int) bool {
+		return flavors[i].Name < flavors[j].Name
+	})
+	return flavors, nil
 }
@@ -18,6 +18,7 @@ package repository
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"reflect"
 	"testing"
@@ -157,7 +158,7 @@ func Test_templates_Get(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			f := newTemplateClient(tt.fields.provider, tt.fields.version, tt.fields.repository, tt.fields.configVariablesClient)
-			got, err := f.Get(tt.args.flavor, tt.args.targetNamespace, tt.args.listVariablesOnly)
+			got, err := f.Get(context.Background(), tt.args.flavor, tt.args.targetNamespace, tt.args.listVariablesOnly)
 			if (err != nil) != tt.wantErr {
 				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
 			}
@@ -192,3 +193,36 @@ func Test_templates_Get(t *testing.T) {
 		})
 	}
 }
+
+func Test_templates_ListFlavors(t *testing.T) {
+	p1 := config.NewProvider("p1", "", clusterctlv1.InfrastructureProviderType)
+
+	repository := test.NewFakeRepository().
+		WithPaths("root", "").
+		WithDefaultVersion("v1.0.0").
+		WithFile("v1.0.0", "infrastructure-components.yaml", []byte("")).
+		WithFile("v1.0.0", "metadata.yaml", []byte("")).
+		WithFile("v1.0.0", "cluster-template.yaml", configMapYaml).
+		WithFile("v1.0.0", "cluster-template-ipv6.yaml", []byte("name: ${CLUSTER_NAME}\nipv6: ${IPV6_CIDR}")).
+		WithFile("v1.0.0", "cluster-template-external-cloud-provider.yaml", []byte("name: ${CLUSTER_NAME}"))
+
+	f := newTemplateClient(p1, "v1.0.0", repository, test.NewFakeVariableClient())
+	got, err := f.ListFlavors(context.Background())
+	if err != nil {
+		t.Fatalf("ListFlavors() error = %v", err)
+	}
+
+	want := []Flavor{
+		{Name: "", Variables: []string{variableName}},
+		{Name: "external-cloud-provider", Variables: []string{"CLUSTER_NAME"}},
+		{Name: "ipv6", Variables: []string{"CLUSTER_NAME", "IPV6_CIDR"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got = %v, want %v", got, want)
+	}
+
+	f = newTemplateClient(p1, "v2.0.0", repository, test.NewFakeVariableClient())
+	if _, err := f.ListFlavors(context.Background()); err == nil {
+		t.Errorf("ListFlavors() error = nil, want an error for a version not in the repository")
+	}
+}
@@ -0,0 +1,287 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package repository
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/client/config"
+	"sigs.k8s.io/yaml"
+)
+
+// variableDefinitionsMarker is the comment line starting the block of comments defining the template variables.
+const variableDefinitionsMarker = "# clusterctl:variables"
+
+// variableTypes are the supported types of the template variables.
+var variableTypes = sets.NewString("string", "integer", "number", "boolean")
+
+// jsonSchemaDraft is the JSON Schema version of the variables schema.
+const jsonSchemaDraft = "http://json-schema.org/draft-07/schema#"
+
+// VariableDefinition describes a variable of a cluster template. Definitions are optional and are read from a block
+// of comments in the template starting with "# clusterctl:variables", e.g.
+//
+//	# clusterctl:variables
+//	# - name: WORKER_MACHINE_COUNT
+//	#   type: integer
+//	#   default: 3
+//	#   description: The number of worker machines.
+//	# - name: CONTROL_PLANE_MACHINE_COUNT
+//	#   type: integer
+//	#   enum: [1, 3, 5]
+type VariableDefinition struct {
+	// Name of the variable.
+	Name string `json:"name"`
+
+	// Type of the variable value, one of string (default), integer, number or boolean.
+	Type string `json:"type,omitempty"`
+
+	// Default is the value used when the variable is not set.
+	Default *string `json:"-"`
+
+	// Enum lists the allowed values of the variable, if restricted.
+	Enum []string `json:"-"`
+
+	// Description of the variable.
+	Description string `json:"description,omitempty"`
+}
+
+// variablesSchema is the JSON Schema of the variables of a template.
+type variablesSchema struct {
+	Schema     string                    `json:"$schema"`
+	Type       string                    `json:"type"`
+	Properties map[string]variableSchema `json:"properties"`
+	Required   []string                  `json:"required,omitempty"`
+}
+
+type variableSchema struct {
+	Type        string        `json:"type"`
+	Default     interface{}   `json:"default,omitempty"`
+	Enum        []interface{} `json:"enum,omitempty"`
+	Description string        `json:"description,omitempty"`
+}
+
+// inspectVariableDefinitions reads the variable definitions from the template, if any.
+func inspectVariableDefinitions(rawYaml []byte) ([]VariableDefinition, error) {
+	var block []string
+	inBlock := false
+	for _, line := range strings.Split(string(rawYaml), "\n") {
+		line = strings.TrimRight(line, "\r")
+		if !inBlock {
+			inBlock = strings.TrimSpace(line) == variableDefinitionsMarker
+			continue
+		}
+		if !strings.HasPrefix(line, "#") {
+			break
+		}
+		block = append(block, strings.TrimPrefix(strings.TrimPrefix(line, "#"), " "))
+	}
+	if len(block) == 0 {
+		return nil, nil
+	}
+
+	// Default and enum values are parsed as any YAML scalar, so they don't need to be quoted.
+	var rawDefinitions []struct {
+		VariableDefinition `json:",inline"`
+		Default            interface{}   `json:"default,omitempty"`
+		Enum               []interface{} `json:"enum,omitempty"`
+	}
+	if err := yaml.Unmarshal([]byte(strings.Join(block, "\n")), &rawDefinitions); err != nil {
+		return nil, errors.Wrap(err, "failed to parse the variable definitions")
+	}
+	definitions := make([]VariableDefinition, len(rawDefinitions))
+	for i, raw := range rawDefinitions {
+		d := &definitions[i]
+		*d = raw.VariableDefinition
+		if raw.Default != nil {
+			s := scalarString(raw.Default)
+			d.Default = &s
+		}
+		for _, v := range raw.Enum {
+			d.Enum = append(d.Enum, scalarString(v))
+		}
+
+		if d.Name == "" {
+			return nil, errors.Errorf("invalid variable definition %d: the name is required", i)
+		}
+		if d.Type == "" {
+			d.Type = "string"
+		}
+		if !variableTypes.Has(d.Type) {
+			return nil, errors.Errorf("invalid type %q for variable %s, it must be one of %s", d.Type, d.Name, strings.Join(variableTypes.List(), ", "))
+		}
+		for _, v := range d.Enum {
+			if _, err := typedValue(d.Type, v); err != nil {
+				return nil, errors.Wrapf(err, "invalid enum value for variable %s", d.Name)
+			}
+		}
+		if d.Default != nil {
+			if err := validateVariableValue(*d, *d.Default); err != nil {
+				return nil, errors.Wrapf(err, "invalid default value for variable %s", d.Name)
+			}
+		}
+	}
+	return definitions, nil
+}
+
+// scalarString returns the string representation of a YAML scalar; numbers are formatted without exponent.
+func scalarString(v interface{}) string {
+	if f, ok := v.(float64); ok {
+		return strconv.FormatFloat(f, 'f', -1, 64)
+	}
+	return fmt.Sprint(v)
+}
+
+// validateVariableValue checks a value matches the type and the allowed values of a variable.
+func validateVariableValue(d VariableDefinition, value string) error {
+	if _, err := typedValue(d.Type, value); err != nil {
+		return errors.Errorf("%q is not a valid %s", value, d.Type)
+	}
+	if len(d.Enum) > 0 && !sets.NewString(d.Enum...).Has(value) {
+		return errors.Errorf("%q is not one of the allowed values [%s]", value, strings.Join(d.Enum, ", "))
+	}
+	return nil
+}
+
+// validateVariables checks the values of the variables used in a template match their definitions, so errors
+// are reported before rendering the template; values are read from the configuration, or from the inline
+// default values if not set. Variables without a value are not checked.
+func validateVariables(variables []string, definitions []VariableDefinition, configVariablesClient config.VariablesClient, inlineDefaults map[string]string) error {
+	byName := map[string]VariableDefinition{}
+	for _, d := range definitions {
+		byName[d.Name] = d
+	}
+
+	var errs []string
+	for _, v := range variables {
+		d, ok := byName[v]
+		if !ok {
+			continue
+		}
+		value, err := configVariablesClient.Get(v)
+		if err != nil {
+			if value, ok = inlineDefaults[v]; !ok {
+				continue
+			}
+		}
+		if err := validateVariableValue(d, value); err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", v, err))
+		}
+	}
+	if len(errs) > 0 {
+		return errors.Errorf("invalid values for variables: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// typedValue converts the value of a variable to its type.
+func typedValue(variableType, value string) (interface{}, error) {
+	switch variableType {
+	case "string":
+		return value, nil
+	case "integer":
+		return strconv.ParseInt(value, 10, 64)
+	case "number":
+		return strconv.ParseFloat(value, 64)
+	case "boolean":
+		return strconv.ParseBool(value)
+	}
+	return nil, errors.Errorf("unknown type %q", variableType)
+}
+
+// variablesJSONSchema returns the JSON Schema of the given variables; the variables without a definition are strings,
+// and the variables without a default value, defined either in the definitions or inline, are required.
+func variablesJSONSchema(variables []string, definitions []VariableDefinition, inlineDefaults map[string]string) ([]byte, error) {
+	byName := map[string]VariableDefinition{}
+	for _, d := range definitions {
+		byName[d.Name] = d
+	}
+
+	schema := variablesSchema{
+		Schema:     jsonSchemaDraft,
+		Type:       "object",
+		Properties: map[string]variableSchema{},
+	}
+	for _, v := range variables {
+		d, ok := byName[v]
+		if !ok {
+			d = VariableDefinition{Name: v, Type: "string"}
+		}
+
+		property := variableSchema{Type: d.Type, Description: d.Description}
+		for _, e := range d.Enum {
+			value, err := typedValue(d.Type, e)
+			if err != nil {
+				return nil, errors.Wrapf(err, "invalid enum value for variable %s", d.Name)
+			}
+			property.Enum = append(property.Enum, value)
+		}
+		defaultValue := d.Default
+		if defaultValue == nil {
+			if inline, ok := inlineDefaults[v]; ok {
+				defaultValue = &inline
+			}
+		}
+		if defaultValue != nil {
+			value, err := typedValue(d.Type, *defaultValue)
+			if err != nil {
+				return nil, errors.Wrapf(err, "invalid default value for variable %s", d.Name)
+			}
+			property.Default = value
+		} else {
+			schema.Required = append(schema.Required, v)
+		}
+		schema.Properties[v] = property
+	}
+
+	return json.MarshalIndent(schema, "", "  ")
+}
+
+// defaultedVariablesClient returns the default value of the variables not set in the wrapped VariablesClient.
+type defaultedVariablesClient struct {
+	config.VariablesClient
+	defaults map[string]string
+}
+
+func newDefaultedVariablesClient(c config.VariablesClient, definitions []VariableDefinition) config.VariablesClient {
+	defaults := map[string]string{}
+	for _, d := range definitions {
+		if d.Default != nil {
+			defaults[d.Name] = *d.Default
+		}
+	}
+	if len(defaults) == 0 {
+		return c
+	}
+	return &defaultedVariablesClient{VariablesClient: c, defaults: defaults}
+}
+
+func (c *defaultedVariablesClient) Get(key string) (string, error) {
+	value, err := c.VariablesClient.Get(key)
+	if err != nil {
+		if d, ok := c.defaults[key]; ok {
+			return d, nil
+		}
+		return "", err
+	}
+	return value, nil
+}
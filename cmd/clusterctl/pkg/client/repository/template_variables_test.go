@@ -0,0 +1,256 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package repository
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/internal/test"
+)
+
+var templateWithDefinitionsYaml = []byte("# clusterctl:variables\n" +
+	"# - name: WORKER_MACHINE_COUNT\n" +
+	"#   type: integer\n" +
+	"#   default: 3\n" +
+	"#   description: The number of worker machines.\n" +
+	"# - name: SSH_KEY\n" +
+	"#   description: The SSH key.\n" +
+	"apiVersion: v1\n" +
+	"data:\n" +
+	"  count: \"${WORKER_MACHINE_COUNT}\"\n" +
+	"  key: ${SSH_KEY}\n" +
+	"  region: ${REGION}\n" +
+	"kind: ConfigMap\n" +
+	"metadata:\n" +
+	"  name: manager")
+
+var templateWithEnumsYaml = []byte("# clusterctl:variables\n" +
+	"# - name: CONTROL_PLANE_MACHINE_COUNT\n" +
+	"#   type: integer\n" +
+	"#   enum: [1, 3, 5]\n" +
+	"# - name: WORKER_MACHINE_COUNT\n" +
+	"#   type: integer\n" +
+	"# - name: CNI\n" +
+	"#   enum: [calico, cilium]\n" +
+	"apiVersion: v1\n" +
+	"data:\n" +
+	"  controlPlane: \"${CONTROL_PLANE_MACHINE_COUNT}\"\n" +
+	"  workers: \"${WORKER_MACHINE_COUNT:=2}\"\n" +
+	"  cni: ${CNI:=calico}\n" +
+	"kind: ConfigMap\n" +
+	"metadata:\n" +
+	"  name: manager")
+
+func Test_inspectVariableDefinitions(t *testing.T) {
+	tests := []struct {
+		name    string
+		rawYaml []byte
+		want    []VariableDefinition
+		wantErr bool
+	}{
+		{
+			name:    "no definitions",
+			rawYaml: templateMapYaml,
+			want:    nil,
+		},
+		{
+			name:    "definitions with defaults",
+			rawYaml: templateWithDefinitionsYaml,
+			want: []VariableDefinition{
+				{Name: "WORKER_MACHINE_COUNT", Type: "integer", Default: pointer.StringPtr("3"), Description: "The number of worker machines."},
+				{Name: "SSH_KEY", Type: "string", Description: "The SSH key."},
+			},
+		},
+		{
+			name:    "definitions with enums",
+			rawYaml: []byte("# clusterctl:variables\n# - name: FOO\n#   type: integer\n#   enum: [1, 3]\n#   default: 3\n"),
+			want: []VariableDefinition{
+				{Name: "FOO", Type: "integer", Default: pointer.StringPtr("3"), Enum: []string{"1", "3"}},
+			},
+		},
+		{
+			name:    "enum not matching the type",
+			rawYaml: []byte("# clusterctl:variables\n# - name: FOO\n#   type: integer\n#   enum: [1, many]\n"),
+			wantErr: true,
+		},
+		{
+			name:    "default not in the enum",
+			rawYaml: []byte("# clusterctl:variables\n# - name: FOO\n#   enum: [foo, bar]\n#   default: baz\n"),
+			wantErr: true,
+		},
+		{
+			name:    "unknown type",
+			rawYaml: []byte("# clusterctl:variables\n# - name: FOO\n#   type: list\n"),
+			wantErr: true,
+		},
+		{
+			name:    "default not matching the type",
+			rawYaml: []byte("# clusterctl:variables\n# - name: FOO\n#   type: boolean\n#   default: maybe\n"),
+			wantErr: true,
+		},
+		{
+			name:    "missing name",
+			rawYaml: []byte("# clusterctl:variables\n# - type: string\n"),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := inspectVariableDefinitions(tt.rawYaml)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got = %v, want = %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_template_VariablesSchema(t *testing.T) {
+	got, err := NewTemplate(templateWithDefinitionsYaml, test.NewFakeVariableClient(), "ns1", true)
+	if err != nil {
+		t.Fatalf("error = %v", err)
+	}
+
+	schema, err := got.VariablesSchema()
+	if err != nil {
+		t.Fatalf("error = %v", err)
+	}
+
+	want := map[string]interface{}{
+		"$schema": jsonSchemaDraft,
+		"type":    "object",
+		"properties": map[string]interface{}{
+			"REGION":               map[string]interface{}{"type": "string"},
+			"SSH_KEY":              map[string]interface{}{"type": "string", "description": "The SSH key."},
+			"WORKER_MACHINE_COUNT": map[string]interface{}{"type": "integer", "default": float64(3), "description": "The number of worker machines."},
+		},
+		"required": []interface{}{"REGION", "SSH_KEY"},
+	}
+	var gotSchema map[string]interface{}
+	if err := json.Unmarshal(schema, &gotSchema); err != nil {
+		t.Fatalf("failed to unmarshal the schema: %v", err)
+	}
+	if !reflect.DeepEqual(gotSchema, want) {
+		t.Errorf("got = %v, want = %v", gotSchema, want)
+	}
+}
+
+func Test_newTemplate_defaultValues(t *testing.T) {
+	got, err := NewTemplate(templateWithDefinitionsYaml, test.NewFakeVariableClient().WithVar("SSH_KEY", "key").WithVar("REGION", "eu"), "ns1", false)
+	if err != nil {
+		t.Fatalf("error = %v", err)
+	}
+
+	yaml, err := got.Yaml()
+	if err != nil {
+		t.Fatalf("got.Yaml error = %v", err)
+	}
+	if !bytes.Contains(yaml, []byte(`count: "3"`)) {
+		t.Errorf("got.Yaml without the default value:\n%s", yaml)
+	}
+}
+
+func Test_template_VariablesSchema_enumsAndInlineDefaults(t *testing.T) {
+	got, err := NewTemplate(templateWithEnumsYaml, test.NewFakeVariableClient(), "ns1", true)
+	if err != nil {
+		t.Fatalf("error = %v", err)
+	}
+
+	schema, err := got.VariablesSchema()
+	if err != nil {
+		t.Fatalf("error = %v", err)
+	}
+
+	want := map[string]interface{}{
+		"$schema": jsonSchemaDraft,
+		"type":    "object",
+		"properties": map[string]interface{}{
+			"CNI":                         map[string]interface{}{"type": "string", "default": "calico", "enum": []interface{}{"calico", "cilium"}},
+			"CONTROL_PLANE_MACHINE_COUNT": map[string]interface{}{"type": "integer", "enum": []interface{}{float64(1), float64(3), float64(5)}},
+			"WORKER_MACHINE_COUNT":        map[string]interface{}{"type": "integer", "default": float64(2)},
+		},
+		"required": []interface{}{"CONTROL_PLANE_MACHINE_COUNT"},
+	}
+	var gotSchema map[string]interface{}
+	if err := json.Unmarshal(schema, &gotSchema); err != nil {
+		t.Fatalf("failed to unmarshal the schema: %v", err)
+	}
+	if !reflect.DeepEqual(gotSchema, want) {
+		t.Errorf("got = %v, want = %v", gotSchema, want)
+	}
+}
+
+func Test_newTemplate_validation(t *testing.T) {
+	tests := []struct {
+		name            string
+		variablesClient *test.FakeVariableClient
+		wantYaml        []string
+		wantErr         []string
+	}{
+		{
+			name:            "valid values and inline defaults",
+			variablesClient: test.NewFakeVariableClient().WithVar("CONTROL_PLANE_MACHINE_COUNT", "3"),
+			wantYaml:        []string{`controlPlane: "3"`, `workers: "2"`, `cni: calico`},
+		},
+		{
+			name: "invalid values are reported before rendering",
+			variablesClient: test.NewFakeVariableClient().
+				WithVar("CONTROL_PLANE_MACHINE_COUNT", "2").
+				WithVar("WORKER_MACHINE_COUNT", "two").
+				WithVar("CNI", "flannel"),
+			wantErr: []string{"CONTROL_PLANE_MACHINE_COUNT", "WORKER_MACHINE_COUNT", "CNI"},
+		},
+		{
+			name:            "missing variables are reported",
+			variablesClient: test.NewFakeVariableClient(),
+			wantErr:         []string{"CONTROL_PLANE_MACHINE_COUNT"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewTemplate(templateWithEnumsYaml, tt.variablesClient, "ns1", false)
+			if (err != nil) != (len(tt.wantErr) > 0) {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			for _, e := range tt.wantErr {
+				if !strings.Contains(err.Error(), e) {
+					t.Errorf("error = %v, want it to report %s", err, e)
+				}
+			}
+			if err != nil {
+				return
+			}
+
+			yaml, err := got.Yaml()
+			if err != nil {
+				t.Fatalf("got.Yaml error = %v", err)
+			}
+			for _, w := range tt.wantYaml {
+				if !bytes.Contains(yaml, []byte(w)) {
+					t.Errorf("got.Yaml without %q:\n%s", w, yaml)
+				}
+			}
+		})
+	}
+}
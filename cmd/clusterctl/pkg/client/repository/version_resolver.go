@@ -0,0 +1,172 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package repository
+
+import (
+	"context"
+	"strings"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/util/version"
+)
+
+const (
+	// VersionLatest selects the highest release of a provider, pre-releases included.
+	VersionLatest = "latest"
+
+	// VersionStable selects the highest release of a provider, pre-releases excluded.
+	VersionStable = "stable"
+
+	// VersionLatestStable is an alias of VersionStable.
+	VersionLatestStable = "latest-stable"
+)
+
+// constraintOperators are the operators supported in version constraints; longer operators go first, so they are
+// matched before their prefixes.
+var constraintOperators = []string{">=", "<=", "!=", ">", "<", "="}
+
+// IsVersionSelector returns true if the version is a channel, e.g. stable, or a version constraint, e.g. ">=0.6 <0.7",
+// that should be resolved against the releases available in the provider repository.
+func IsVersionSelector(v string) bool {
+	switch v {
+	case VersionLatest, VersionStable, VersionLatestStable:
+		return true
+	}
+	return strings.ContainsAny(v, "<>=! ")
+}
+
+// resolveVersion resolves a channel or a version constraint against the releases available in a repository.
+func resolveVersion(ctx context.Context, repository Repository, selector string) (string, error) {
+	versions, err := repository.GetVersions(ctx)
+	if err != nil {
+		return "", err
+	}
+	return selectVersion(versions, selector)
+}
+
+// selectVersion returns the highest version matching a channel or a version constraint. Versions with tag names
+// that are not valid semantic versions are ignored; pre-releases are selected only by the latest channel.
+func selectVersion(versions []string, selector string) (string, error) {
+	var match func(v *version.Version) bool
+	switch selector {
+	case VersionLatest:
+		match = func(*version.Version) bool { return true }
+	case VersionStable, VersionLatestStable:
+		match = func(v *version.Version) bool { return v.PreRelease() == "" }
+	default:
+		constraints, err := parseVersionConstraints(selector)
+		if err != nil {
+			return "", err
+		}
+		match = func(v *version.Version) bool {
+			if v.PreRelease() != "" {
+				return false
+			}
+			for _, c := range constraints {
+				if !c.match(v) {
+					return false
+				}
+			}
+			return true
+		}
+	}
+
+	var selectedTag string
+	var selectedVersion *version.Version
+	for _, tag := range versions {
+		sv, err := version.ParseSemantic(tag)
+		if err != nil || !match(sv) {
+			continue
+		}
+		if selectedVersion == nil || selectedVersion.LessThan(sv) {
+			selectedTag = tag
+			selectedVersion = sv
+		}
+	}
+
+	if selectedTag == "" {
+		return "", errors.Errorf("failed to find a release matching %q", selector)
+	}
+	return selectedTag, nil
+}
+
+// versionConstraint is a comparison between a version and a bound, e.g. >=0.6.
+type versionConstraint struct {
+	operator string
+	bound    *version.Version
+}
+
+// parseVersionConstraints parses a list of version constraints separated by spaces, that must all be satisfied.
+// Bounds can omit the patch or the minor version, e.g. >=0.6 is equivalent to >=0.6.0.
+func parseVersionConstraints(s string) ([]versionConstraint, error) {
+	fields := strings.Fields(s)
+	if len(fields) == 0 {
+		return nil, errors.Errorf("invalid version constraint %q", s)
+	}
+
+	ret := make([]versionConstraint, 0, len(fields))
+	for _, f := range fields {
+		operator := ""
+		for _, o := range constraintOperators {
+			if strings.HasPrefix(f, o) {
+				operator = o
+				break
+			}
+		}
+		if operator == "" {
+			return nil, errors.Errorf("invalid version constraint %q: %q must start with one of %s", s, f, strings.Join(constraintOperators, ", "))
+		}
+
+		bound, err := parseVersionBound(strings.TrimPrefix(f, operator))
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid version constraint %q", s)
+		}
+		ret = append(ret, versionConstraint{operator: operator, bound: bound})
+	}
+	return ret, nil
+}
+
+// parseVersionBound parses the version in a constraint, adding the missing minor and patch versions.
+func parseVersionBound(s string) (*version.Version, error) {
+	v := strings.TrimPrefix(s, "v")
+	for strings.Count(v, ".") < 2 {
+		v += ".0"
+	}
+	bound, err := version.ParseSemantic(v)
+	if err != nil {
+		return nil, errors.Errorf("%q is not a valid version", s)
+	}
+	return bound, nil
+}
+
+func (c versionConstraint) match(v *version.Version) bool {
+	switch c.operator {
+	case ">=":
+		return v.AtLeast(c.bound)
+	case "<=":
+		return !c.bound.LessThan(v)
+	case ">":
+		return c.bound.LessThan(v)
+	case "<":
+		return v.LessThan(c.bound)
+	case "=":
+		return v.AtLeast(c.bound) && !c.bound.LessThan(v)
+	case "!=":
+		return v.LessThan(c.bound) || c.bound.LessThan(v)
+	}
+	return false
+}
@@ -0,0 +1,110 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package repository
+
+import (
+	"testing"
+)
+
+func Test_selectVersion(t *testing.T) {
+	versions := []string{"v0.5.3", "v0.6.0", "v0.6.2", "v0.7.0-rc.1", "v0.7.0-beta.0", "not-a-version"}
+
+	tests := []struct {
+		name     string
+		selector string
+		want     string
+		wantErr  bool
+	}{
+		{
+			name:     "latest selects pre-releases",
+			selector: VersionLatest,
+			want:     "v0.7.0-rc.1",
+		},
+		{
+			name:     "stable ignores pre-releases",
+			selector: VersionStable,
+			want:     "v0.6.2",
+		},
+		{
+			name:     "latest-stable is an alias of stable",
+			selector: VersionLatestStable,
+			want:     "v0.6.2",
+		},
+		{
+			name:     "range constraint",
+			selector: ">=0.5 <0.6",
+			want:     "v0.5.3",
+		},
+		{
+			name:     "constraints ignore pre-releases",
+			selector: "<0.7",
+			want:     "v0.6.2",
+		},
+		{
+			name:     "exact version with the v prefix",
+			selector: "=v0.6.0",
+			want:     "v0.6.0",
+		},
+		{
+			name:     "excluded version",
+			selector: ">=0.6 !=0.6.2",
+			want:     "v0.6.0",
+		},
+		{
+			name:     "Fails if no version matches",
+			selector: ">0.6.2 <=0.7",
+			wantErr:  true,
+		},
+		{
+			name:     "Fails for an invalid operator",
+			selector: "~0.6",
+			wantErr:  true,
+		},
+		{
+			name:     "Fails for an invalid bound",
+			selector: ">=zero",
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := selectVersion(versions, tt.selector)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsVersionSelector(t *testing.T) {
+	for v, want := range map[string]bool{
+		"v0.6.0":        false,
+		"":              false,
+		"latest":        true,
+		"stable":        true,
+		"latest-stable": true,
+		">=0.6 <0.7":    true,
+		"!=0.6.1":       true,
+	} {
+		if got := IsVersionSelector(v); got != want {
+			t.Errorf("IsVersionSelector(%q) = %v, want %v", v, got, want)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"time"
+
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/client/cluster"
+)
+
+// The kinds of the objects supported by the rollout commands.
+const (
+	MachineDeploymentKind   = cluster.MachineDeploymentKind
+	KubeadmControlPlaneKind = cluster.KubeadmControlPlaneKind
+)
+
+// RolloutRestartOptions carries the options supported by RolloutRestart.
+type RolloutRestartOptions struct {
+	// Kubeconfig file to use for accessing the management cluster. If empty, default rules for kubeconfig
+	// discovery will be used.
+	Kubeconfig string
+
+	// Namespace where the object lives. If not specified, the current namespace will be used.
+	Namespace string
+
+	// Kind of the object to be restarted, either MachineDeployment or KubeadmControlPlane.
+	Kind string
+
+	// Name of the object to be restarted.
+	Name string
+}
+
+func (c *clusterctlClient) RolloutRestart(ctx context.Context, options RolloutRestartOptions) (time.Time, error) {
+	rolloutClient, namespace, err := c.getRolloutClient(options.Kubeconfig, options.Namespace)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return rolloutClient.Restart(ctx, options.Kind, namespace, options.Name)
+}
+
+// RolloutPauseOptions carries the options supported by RolloutPause and RolloutResume.
+type RolloutPauseOptions struct {
+	// Kubeconfig file to use for accessing the management cluster. If empty, default rules for kubeconfig
+	// discovery will be used.
+	Kubeconfig string
+
+	// Namespace where the object lives. If not specified, the current namespace will be used.
+	Namespace string
+
+	// Kind of the object to be paused or resumed, either MachineDeployment or KubeadmControlPlane.
+	Kind string
+
+	// Name of the object to be paused or resumed.
+	Name string
+}
+
+func (c *clusterctlClient) RolloutPause(ctx context.Context, options RolloutPauseOptions) error {
+	rolloutClient, namespace, err := c.getRolloutClient(options.Kubeconfig, options.Namespace)
+	if err != nil {
+		return err
+	}
+	return rolloutClient.Pause(ctx, options.Kind, namespace, options.Name)
+}
+
+func (c *clusterctlClient) RolloutResume(ctx context.Context, options RolloutPauseOptions) error {
+	rolloutClient, namespace, err := c.getRolloutClient(options.Kubeconfig, options.Namespace)
+	if err != nil {
+		return err
+	}
+	return rolloutClient.Resume(ctx, options.Kind, namespace, options.Name)
+}
+
+// RolloutUndoOptions carries the options supported by RolloutUndo.
+type RolloutUndoOptions struct {
+	// Kubeconfig file to use for accessing the management cluster. If empty, default rules for kubeconfig
+	// discovery will be used.
+	Kubeconfig string
+
+	// Namespace where the object lives. If not specified, the current namespace will be used.
+	Namespace string
+
+	// Kind of the object to be rolled back; only MachineDeployment is supported.
+	Kind string
+
+	// Name of the object to be rolled back.
+	Name string
+
+	// ToRevision is the revision to roll back to; if 0, the previous revision is used.
+	ToRevision int64
+}
+
+func (c *clusterctlClient) RolloutUndo(ctx context.Context, options RolloutUndoOptions) (int64, error) {
+	rolloutClient, namespace, err := c.getRolloutClient(options.Kubeconfig, options.Namespace)
+	if err != nil {
+		return 0, err
+	}
+	return rolloutClient.Undo(ctx, options.Kind, namespace, options.Name, options.ToRevision)
+}
+
+// getRolloutClient returns the RolloutClient for the management cluster, together with the namespace of the object,
+// defaulted to the current namespace of the kubeconfig if empty.
+func (c *clusterctlClient) getRolloutClient(kubeconfig, namespace string) (cluster.RolloutClient, string, error) {
+	clusterClient, err := c.clusterClientFactory(kubeconfig, "")
+	if err != nil {
+		return nil, "", err
+	}
+
+	if namespace == "" {
+		currentNamespace, err := clusterClient.Proxy().CurrentNamespace()
+		if err != nil {
+			return nil, "", err
+		}
+		namespace = currentNamespace
+	}
+	return clusterClient.Rollout(), namespace, nil
+}
@@ -0,0 +1,59 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import "context"
+
+// SimulateScaleOptions carries the options supported by SimulateScale.
+type SimulateScaleOptions struct {
+	// Kubeconfig file to use for accessing the management cluster. If empty, default rules for kubeconfig
+	// discovery will be used.
+	Kubeconfig string
+
+	// Namespace where the MachineDeployment lives. If not specified, the current namespace will be used.
+	Namespace string
+
+	// MachineDeployment to be scaled.
+	MachineDeployment string
+
+	// Replicas the MachineDeployment should be scaled to.
+	Replicas int32
+}
+
+func (c *clusterctlClient) SimulateScale(ctx context.Context, options SimulateScaleOptions) (*ScaleSimulation, error) {
+	clusterClient, err := c.clusterClientFactory(options.Kubeconfig, "")
+	if err != nil {
+		return nil, err
+	}
+
+	// If the option specifying the Namespace is empty, default it to the current namespace of the kubeconfig.
+	if options.Namespace == "" {
+		currentNamespace, err := clusterClient.Proxy().CurrentNamespace()
+		if err != nil {
+			return nil, err
+		}
+		options.Namespace = currentNamespace
+	}
+
+	simulation, err := clusterClient.ScaleSimulator().SimulateScale(ctx, options.Namespace, options.MachineDeployment, options.Replicas)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := ScaleSimulation(*simulation)
+	return &ret, nil
+}
@@ -0,0 +1,87 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/client/cluster"
+)
+
+// SupportBundleOptions carries the options supported by SupportBundle.
+type SupportBundleOptions struct {
+	// Kubeconfig file to use for accessing the management cluster. If empty, default rules for kubeconfig
+	// discovery will be used.
+	Kubeconfig string
+
+	// ClusterName is the name of the Cluster to collect the support bundle for.
+	ClusterName string
+
+	// Namespace where the Cluster lives. If not specified, the current namespace will be used.
+	Namespace string
+
+	// LogsSince, if not zero, limits the provider logs to the ones written after the given duration relative to the current time.
+	LogsSince time.Duration
+
+	// OutputFile is the path of the gzipped tarball to write the support bundle to.
+	OutputFile string
+}
+
+func (c *clusterctlClient) SupportBundle(ctx context.Context, options SupportBundleOptions) (*SupportBundleManifest, error) {
+	if options.ClusterName == "" {
+		return nil, errors.New("the name of the Cluster to collect the support bundle for is required")
+	}
+	if options.OutputFile == "" {
+		return nil, errors.New("the output file for the support bundle is required")
+	}
+
+	clusterClient, err := c.clusterClientFactory(options.Kubeconfig, "")
+	if err != nil {
+		return nil, err
+	}
+
+	// If the option specifying the Namespace is empty, default it to the current namespace of the kubeconfig.
+	if options.Namespace == "" {
+		currentNamespace, err := clusterClient.Proxy().CurrentNamespace()
+		if err != nil {
+			return nil, err
+		}
+		options.Namespace = currentNamespace
+	}
+
+	f, err := os.Create(options.OutputFile)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to create %q", options.OutputFile)
+	}
+	defer f.Close()
+
+	manifest, err := clusterClient.SupportBundleCollector().Collect(ctx, options.Namespace, options.ClusterName, cluster.SupportBundleOptions{
+		LogsSince: options.LogsSince,
+	}, f)
+	if err != nil {
+		return nil, err
+	}
+	if err := f.Close(); err != nil {
+		return nil, errors.Wrapf(err, "failed to write %q", options.OutputFile)
+	}
+
+	ret := SupportBundleManifest(*manifest)
+	return &ret, nil
+}
@@ -0,0 +1,87 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"io/ioutil"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/client/cluster"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/internal/util"
+)
+
+// TopologyPlanOptions carries the options supported by TopologyPlan.
+type TopologyPlanOptions struct {
+	// Kubeconfig file to use for accessing the management cluster. If empty, default rules for kubeconfig
+	// discovery will be used.
+	Kubeconfig string
+
+	// Files with the proposed Clusters and ClusterClasses, and the templates they reference, if not existing yet
+	// in the management cluster.
+	Files []string
+
+	// Objs are the proposed objects, in addition to the ones read from Files.
+	Objs []unstructured.Unstructured
+
+	// Namespace used for the proposed objects without a namespace. If not specified, the current namespace will be used.
+	Namespace string
+}
+
+func (c *clusterctlClient) TopologyPlan(ctx context.Context, options TopologyPlanOptions) (*TopologyPlanOutput, error) {
+	objs := append([]unstructured.Unstructured{}, options.Objs...)
+	for _, file := range options.Files {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read %q", file)
+		}
+		fileObjs, err := util.ToUnstructured(data)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to parse %q", file)
+		}
+		objs = append(objs, fileObjs...)
+	}
+	if len(objs) == 0 {
+		return nil, errors.New("at least one Cluster or ClusterClass should be provided")
+	}
+
+	clusterClient, err := c.clusterClientFactory(options.Kubeconfig, "")
+	if err != nil {
+		return nil, err
+	}
+
+	// If the option specifying the Namespace is empty, default it to the current namespace of the kubeconfig.
+	if options.Namespace == "" {
+		currentNamespace, err := clusterClient.Proxy().CurrentNamespace()
+		if err != nil {
+			return nil, err
+		}
+		options.Namespace = currentNamespace
+	}
+
+	plan, err := clusterClient.Topology().Plan(ctx, &cluster.TopologyPlanInput{
+		Objs:      objs,
+		Namespace: options.Namespace,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ret := TopologyPlanOutput(*plan)
+	return &ret, nil
+}
@@ -17,7 +17,9 @@ limitations under the License.
 package client
 
 import (
+	"context"
 	"strings"
+	"time"
 
 	"github.com/pkg/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -29,21 +31,34 @@ import (
 type PlanUpgradeOptions struct {
 	// Kubeconfig file to use for accessing the management cluster. If empty, default rules for kubeconfig discovery will be used.
 	Kubeconfig string
+
+	// InventoryNamespaces scopes the upgrade plans to the management groups installed in the given namespaces.
+	// If empty, upgrade plans are returned for all the management groups.
+	InventoryNamespaces []string
 }
 
-func (c *clusterctlClient) PlanUpgrade(options PlanUpgradeOptions) ([]UpgradePlan, error) {
+func (c *clusterctlClient) PlanUpgrade(ctx context.Context, options PlanUpgradeOptions) ([]UpgradePlan, error) {
 	// Get the client for interacting with the management cluster.
-	cluster, err := c.clusterClientFactory(options.Kubeconfig)
+	clusterClient, err := c.clusterClientFactory(options.Kubeconfig, "")
 	if err != nil {
 		return nil, err
 	}
+	if len(options.InventoryNamespaces) > 0 {
+		clusterClient = clusterClient.WithInventoryNamespaces(options.InventoryNamespaces...)
+	}
 
 	// Ensures the custom resource definitions required by clusterctl are in place.
-	if err := cluster.ProviderInventory().EnsureCustomResourceDefinitions(); err != nil {
+	if err := clusterClient.ProviderInventory().EnsureCustomResourceDefinitions(ctx); err != nil {
 		return nil, err
 	}
 
-	upgradePlan, err := cluster.ProviderUpgrader().Plan()
+	return planUpgrade(ctx, clusterClient)
+}
+
+// planUpgrade returns the upgrade plans for a management cluster; the custom resource definitions required by
+// clusterctl are expected to be in place.
+func planUpgrade(ctx context.Context, clusterClient cluster.Client) ([]UpgradePlan, error) {
+	upgradePlan, err := clusterClient.ProviderUpgrader().Plan(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -61,6 +76,53 @@ func (c *clusterctlClient) PlanUpgrade(options PlanUpgradeOptions) ([]UpgradePla
 	return aliasUpgradePlan, nil
 }
 
+// ExportUpgradePlanOptions carries the options supported by upgrade plan --plan-file.
+type ExportUpgradePlanOptions struct {
+	// Kubeconfig file to use for accessing the management cluster. If empty, default rules for kubeconfig discovery will be used.
+	Kubeconfig string
+
+	// ManagementGroup the upgrade plan is computed for.
+	ManagementGroup string
+
+	// Contract defines the API Version of Cluster API (contract) the management group should upgrade to.
+	Contract string
+
+	// InventoryNamespaces scopes the inventory to the management groups installed in the given namespaces.
+	// If empty, the upgrade plan can be computed for any management group.
+	InventoryNamespaces []string
+}
+
+func (c *clusterctlClient) ExportUpgradePlan(ctx context.Context, options ExportUpgradePlanOptions) (*UpgradePlanFile, error) {
+	// Get the client for interacting with the management cluster.
+	clusterClient, err := c.clusterClientFactory(options.Kubeconfig, "")
+	if err != nil {
+		return nil, err
+	}
+	if len(options.InventoryNamespaces) > 0 {
+		clusterClient = clusterClient.WithInventoryNamespaces(options.InventoryNamespaces...)
+	}
+
+	// Ensures the custom resource definitions required by clusterctl are in place.
+	if err := clusterClient.ProviderInventory().EnsureCustomResourceDefinitions(ctx); err != nil {
+		return nil, err
+	}
+
+	if options.Contract == "" {
+		return nil, errors.New("the API Version of Cluster API (contract) to upgrade to is required for exporting an upgrade plan")
+	}
+
+	coreUpgradeItem, err := parseUpgradeItem(options.ManagementGroup)
+	if err != nil {
+		return nil, err
+	}
+
+	planFile, err := clusterClient.ProviderUpgrader().ExportPlan(ctx, coreUpgradeItem.Provider, options.Contract)
+	if err != nil {
+		return nil, err
+	}
+	return (*UpgradePlanFile)(planFile), nil
+}
+
 // ApplyUpgradeOptions carries the options supported by upgrade apply.
 type ApplyUpgradeOptions struct {
 	// Kubeconfig file to use for accessing the management cluster. If empty, default rules for kubeconfig discovery will be used.
@@ -71,36 +133,124 @@ type ApplyUpgradeOptions struct {
 
 	// Contract defines the API Version of Cluster API (contract) the management group should upgrade to.
 	Contract string
+
+	// InventoryNamespaces scopes the upgrade to the management groups installed in the given namespaces.
+	// If empty, any management group can be upgraded.
+	InventoryNamespaces []string
+
+	// PlanFile, if set, defines exactly the upgrade to be executed, which is refused if the management group has
+	// drifted from the plan; ManagementGroup and Contract must be empty, because they are read from the plan file.
+	PlanFile *UpgradePlanFile
 }
 
-func (c *clusterctlClient) ApplyUpgrade(options ApplyUpgradeOptions) error {
+func (c *clusterctlClient) ApplyUpgrade(ctx context.Context, options ApplyUpgradeOptions) error {
 	// Get the client for interacting with the management cluster.
-	clusterClient, err := c.clusterClientFactory(options.Kubeconfig)
+	clusterClient, err := c.clusterClientFactory(options.Kubeconfig, "")
+	if err != nil {
+		return err
+	}
+	if len(options.InventoryNamespaces) > 0 {
+		clusterClient = clusterClient.WithInventoryNamespaces(options.InventoryNamespaces...)
+	}
+
+	// Prevents other clusterctl operations from changing the management cluster concurrently.
+	release, err := clusterClient.OperationLock().Acquire(ctx, "upgrade")
 	if err != nil {
 		return err
 	}
+	defer release()
 
 	// Ensures the custom resource definitions required by clusterctl are in place.
-	if err := clusterClient.ProviderInventory().EnsureCustomResourceDefinitions(); err != nil {
+	if err := clusterClient.ProviderInventory().EnsureCustomResourceDefinitions(ctx); err != nil {
 		return err
 	}
 
+	if options.PlanFile != nil && (options.ManagementGroup != "" || options.Contract != "") {
+		return errors.New("the management group and the API Version of Cluster API (contract) cannot be set when applying an upgrade plan file")
+	}
+
 	// The management group name is derived from the core provider name, so now
 	// convert the reference back into a coreProvider.
-	coreUpgradeItem, err := parseUpgradeItem(options.ManagementGroup)
-	if err != nil {
-		return err
+	var coreProvider clusterctlv1.Provider
+	if options.PlanFile == nil {
+		coreUpgradeItem, err := parseUpgradeItem(options.ManagementGroup)
+		if err != nil {
+			return err
+		}
+		coreProvider = coreUpgradeItem.Provider
+	}
+
+	// Checks the conversion webhooks are working, given that the upgrade might require converting existing objects.
+	if err := clusterClient.ConversionWebhooks().Check(ctx); err != nil {
+		return errors.Wrap(err, "cannot start the upgrade operation")
+	}
+
+	// If there is a plan file, we are upgrading a whole management group exactly as defined in the plan.
+	if options.PlanFile != nil {
+		return clusterClient.ProviderUpgrader().ApplyPlanFile(ctx, cluster.UpgradePlanFile(*options.PlanFile))
 	}
-	coreProvider := coreUpgradeItem.Provider
 
 	// Otherwise we are upgrading a whole management group according to a clusterctl generated upgrade plan.
-	if err := clusterClient.ProviderUpgrader().ApplyPlan(coreProvider, options.Contract); err != nil {
+	if err := clusterClient.ProviderUpgrader().ApplyPlan(ctx, coreProvider, options.Contract); err != nil {
 		return err
 	}
 
 	return nil
 }
 
+// VerifyUpgradeOptions carries the options supported by upgrade verify.
+type VerifyUpgradeOptions struct {
+	// Kubeconfig file to use for accessing the management cluster. If empty, default rules for kubeconfig discovery will be used.
+	Kubeconfig string
+
+	// ManagementGroup that should be verified.
+	ManagementGroup string
+
+	// InventoryNamespaces scopes the inventory to the management groups installed in the given namespaces.
+	// If empty, any management group can be verified.
+	InventoryNamespaces []string
+
+	// Timeout is the maximum duration of each check.
+	Timeout time.Duration
+}
+
+func (c *clusterctlClient) VerifyUpgrade(ctx context.Context, options VerifyUpgradeOptions) ([]UpgradeCheck, error) {
+	// Get the client for interacting with the management cluster.
+	clusterClient, err := c.clusterClientFactory(options.Kubeconfig, "")
+	if err != nil {
+		return nil, err
+	}
+	if len(options.InventoryNamespaces) > 0 {
+		clusterClient = clusterClient.WithInventoryNamespaces(options.InventoryNamespaces...)
+	}
+
+	coreUpgradeItem, err := parseUpgradeItem(options.ManagementGroup)
+	if err != nil {
+		return nil, err
+	}
+
+	managementGroups, err := clusterClient.ProviderInventory().GetManagementGroups(ctx)
+	if err != nil {
+		return nil, err
+	}
+	managementGroup := managementGroups.FindManagementGroupByProviderInstanceName(coreUpgradeItem.Provider.InstanceName())
+	if managementGroup == nil {
+		return nil, errors.Errorf("unable to identify the %s management group", coreUpgradeItem.Provider.InstanceName())
+	}
+
+	checks, err := clusterClient.UpgradeVerifier().Verify(ctx, managementGroup.Providers, options.Timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	// UpgradeCheck is an alias for cluster.UpgradeCheck; this makes the conversion
+	ret := make([]UpgradeCheck, len(checks))
+	for i, check := range checks {
+		ret[i] = UpgradeCheck(check)
+	}
+	return ret, nil
+}
+
 func parseUpgradeItem(ref string) (*cluster.UpgradeItem, error) {
 	refSplit := strings.Split(strings.ToLower(ref), "/")
 	if len(refSplit) != 2 {
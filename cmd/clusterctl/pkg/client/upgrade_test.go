@@ -71,7 +71,7 @@ func Test_clusterctlClient_ApplyUpgrade(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if err := tt.fields.client.ApplyUpgrade(tt.args.options); (err != nil) != tt.wantErr {
+			if err := tt.fields.client.ApplyUpgrade(context.Background(), tt.args.options); (err != nil) != tt.wantErr {
 				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
 			}
 			if tt.wantErr {
@@ -98,6 +98,14 @@ func Test_clusterctlClient_ApplyUpgrade(t *testing.T) {
 			})
 			for i := range gotProviders.Items {
 				tt.wantProviders.Items[i].ResourceVersion = gotProviders.Items[i].ResourceVersion
+
+				// The upgrade should be recorded in the provider history; the history is then ignored
+				// in the comparison, because it depends on the test environment (e.g. timestamp, user).
+				history := gotProviders.Items[i].History
+				if len(history) == 0 || history[len(history)-1].Type != clusterctlv1.UpgradeProviderOperation {
+					t.Errorf("got history = %v, want the upgrade operation to be recorded", history)
+				}
+				gotProviders.Items[i].History = nil
 			}
 
 			if !reflect.DeepEqual(gotProviders, tt.wantProviders) {
@@ -107,6 +115,64 @@ func Test_clusterctlClient_ApplyUpgrade(t *testing.T) {
 	}
 }
 
+func Test_clusterctlClient_ApplyUpgradePlanFile(t *testing.T) {
+	client := fakeClientFoUpgrade() // core v1.0.0 (v1.0.1 available), infra v2.0.0 (v2.0.1 available)
+
+	planFile, err := client.ExportUpgradePlan(context.Background(), ExportUpgradePlanOptions{
+		Kubeconfig:      "kubeconfig",
+		ManagementGroup: "core-system/core",
+		Contract:        "v1alpha3",
+	})
+	if err != nil {
+		t.Fatalf("ExportUpgradePlan() error = %v", err)
+	}
+	targetVersions := map[string]string{}
+	for _, p := range planFile.Providers {
+		targetVersions[p.InstanceName()] = p.TargetVersion
+	}
+	if want := map[string]string{"core-system/core": "v1.0.1", "infra-system/infra": "v2.0.1"}; !reflect.DeepEqual(targetVersions, want) {
+		t.Fatalf("got target versions %v, want %v", targetVersions, want)
+	}
+
+	// The management group and the contract are read from the plan file.
+	if err := client.ApplyUpgrade(context.Background(), ApplyUpgradeOptions{
+		Kubeconfig:      "kubeconfig",
+		ManagementGroup: "core-system/core",
+		PlanFile:        planFile,
+	}); err == nil {
+		t.Fatal("ApplyUpgrade() succeeded, want an error when both the management group and a plan file are set")
+	}
+
+	if err := client.ApplyUpgrade(context.Background(), ApplyUpgradeOptions{
+		Kubeconfig: "kubeconfig",
+		PlanFile:   planFile,
+	}); err != nil {
+		t.Fatalf("ApplyUpgrade() error = %v", err)
+	}
+
+	c, err := client.clusters["kubeconfig"].Proxy().NewClient()
+	if err != nil {
+		t.Fatalf("failed to create client %v", err)
+	}
+	gotProviders := &clusterctlv1.ProviderList{}
+	if err := c.List(context.TODO(), gotProviders); err != nil {
+		t.Fatalf("failed to read providers %v", err)
+	}
+	for _, p := range gotProviders.Items {
+		if want := targetVersions[p.InstanceName()]; p.Version != want {
+			t.Errorf("got %s at version %s, want %s", p.InstanceName(), p.Version, want)
+		}
+	}
+
+	// The plan can't be applied again, because the management group has drifted from it.
+	if err := client.ApplyUpgrade(context.Background(), ApplyUpgradeOptions{
+		Kubeconfig: "kubeconfig",
+		PlanFile:   planFile,
+	}); err == nil {
+		t.Error("ApplyUpgrade() succeeded, want an error when the management group has drifted from the plan")
+	}
+}
+
 func fakeClientFoUpgrade() *fakeClient {
 	core := config.NewProvider("core", "https://somewhere.com", clusterctlv1.CoreProviderType)
 	infra := config.NewProvider("infra", "https://somewhere.com", clusterctlv1.InfrastructureProviderType)
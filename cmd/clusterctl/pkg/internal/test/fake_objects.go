@@ -935,8 +935,8 @@ func FakeCustomResourceDefinition(group string, kind string, versions ...string)
 	}
 
 	for i, version := range versions {
-		// set the first version as a storage version
-		versionObj := apiextensionslv1.CustomResourceDefinitionVersion{Name: version}
+		// set the first version as a storage version; all the versions are served
+		versionObj := apiextensionslv1.CustomResourceDefinitionVersion{Name: version, Served: true}
 		if i == 0 {
 			versionObj.Storage = true
 		}
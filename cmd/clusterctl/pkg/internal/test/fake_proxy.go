@@ -17,10 +17,17 @@ limitations under the License.
 package test
 
 import (
+	"context"
+
 	apiextensionslv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
 	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
@@ -28,12 +35,15 @@ import (
 	fakecontrolplane "sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/internal/test/providers/controlplane"
 	fakeinfrastructure "sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/internal/test/providers/infrastructure"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 )
 
 type FakeProxy struct {
-	cs   client.Client
-	objs []runtime.Object
+	cs             client.Client
+	objs           []runtime.Object
+	contexts       []string
+	noInventoryCRD bool
 }
 
 var (
@@ -55,17 +65,41 @@ func (f *FakeProxy) CurrentNamespace() (string, error) {
 	return "default", nil
 }
 
+func (f *FakeProxy) GetContexts() ([]string, error) {
+	return f.contexts, nil
+}
+
 func (f *FakeProxy) NewClient() (client.Client, error) {
 	if f.cs != nil {
 		return f.cs, nil
 	}
 	f.cs = fake.NewFakeClientWithScheme(FakeScheme, f.objs...)
+	if f.noInventoryCRD {
+		f.cs = &noInventoryCRDClient{Client: f.cs}
+	}
 
 	return f.cs, nil
 }
 
+// NewClientSet returns a fake client-go Clientset; only the objects known by the client-go scheme are added to the Clientset.
+func (f *FakeProxy) NewClientSet() (kubernetes.Interface, error) {
+	var objs []runtime.Object
+	for _, o := range f.objs {
+		if _, _, err := clientgoscheme.Scheme.ObjectKinds(o); err != nil {
+			continue
+		}
+		objs = append(objs, o)
+	}
+	return k8sfake.NewSimpleClientset(objs...), nil
+}
+
+// NewDynamicClient returns a fake client-go dynamic client initialized with the objects known by the FakeProxy.
+func (f *FakeProxy) NewDynamicClient() (dynamic.Interface, error) {
+	return dynamicfake.NewSimpleDynamicClient(FakeScheme, f.objs...), nil
+}
+
 // ListResources returns all the resources known by the FakeProxy
-func (f *FakeProxy) ListResources(namespace string, labels map[string]string) ([]unstructured.Unstructured, error) {
+func (f *FakeProxy) ListResources(_ context.Context, namespace string, labels map[string]string) ([]unstructured.Unstructured, error) {
 	var ret []unstructured.Unstructured //nolint
 	for _, o := range f.objs {
 		u := unstructured.Unstructured{}
@@ -107,6 +141,19 @@ func (f *FakeProxy) WithObjs(objs ...runtime.Object) *FakeProxy {
 	return f
 }
 
+// WithContexts can be used for setting up test scenarios requiring a kubeconfig file with many contexts.
+func (f *FakeProxy) WithContexts(contexts ...string) *FakeProxy {
+	f.contexts = append(f.contexts, contexts...)
+	return f
+}
+
+// WithoutInventoryCRD can be used for setting up test scenarios requiring a cluster where the clusterctl CRDs are not
+// installed, e.g. a cluster that is not a management cluster.
+func (f *FakeProxy) WithoutInventoryCRD() *FakeProxy {
+	f.noInventoryCRD = true
+	return f
+}
+
 // WithProviderInventory can be used as a fast track for setting up test scenarios requiring an already initialized management cluster.
 // NB. this method adds an items to the Provider inventory, but it doesn't install the corresponding provider; if the
 // test case requires the actual provider to be installed, use the the fake client to install both the provider
@@ -133,3 +180,47 @@ func (f *FakeProxy) WithProviderInventory(name string, providerType clusterctlv1
 
 	return f
 }
+
+// WithVersionPolicy can be used for setting up test scenarios requiring a management cluster with a version policy.
+func (f *FakeProxy) WithVersionPolicy(name string, ranges ...clusterctlv1.ProviderVersionRange) *FakeProxy {
+	f.objs = append(f.objs, &clusterctlv1.VersionPolicy{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: clusterctlv1.GroupVersion.String(),
+			Kind:       "VersionPolicy",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+		},
+		Providers: ranges,
+	})
+
+	return f
+}
+
+// noInventoryCRDClient is a client failing to read the clusterctl types, like the API server does when the clusterctl
+// CRDs are not installed.
+type noInventoryCRDClient struct {
+	client.Client
+}
+
+func (c *noInventoryCRDClient) Get(ctx context.Context, key client.ObjectKey, obj runtime.Object) error {
+	if err := noInventoryCRDError(obj); err != nil {
+		return err
+	}
+	return c.Client.Get(ctx, key, obj)
+}
+
+func (c *noInventoryCRDClient) List(ctx context.Context, list runtime.Object, opts ...client.ListOption) error {
+	if err := noInventoryCRDError(list); err != nil {
+		return err
+	}
+	return c.Client.List(ctx, list, opts...)
+}
+
+func noInventoryCRDError(obj runtime.Object) error {
+	gvk, err := apiutil.GVKForObject(obj, FakeScheme)
+	if err != nil || gvk.Group != clusterctlv1.GroupVersion.Group {
+		return nil
+	}
+	return &apimeta.NoKindMatchError{GroupKind: gvk.GroupKind(), SearchedVersions: []string{gvk.Version}}
+}
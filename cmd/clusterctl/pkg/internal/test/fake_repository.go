@@ -17,7 +17,9 @@ limitations under the License.
 package test
 
 import (
+	"context"
 	"fmt"
+	"strings"
 
 	"github.com/pkg/errors"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -45,7 +47,7 @@ func (f *FakeRepository) ComponentsPath() string {
 	return f.componentsPath
 }
 
-func (f FakeRepository) GetFile(version string, path string) ([]byte, error) {
+func (f FakeRepository) GetFile(ctx context.Context, version string, path string) ([]byte, error) {
 	if _, ok := f.versions[version]; !ok {
 		return nil, errors.Errorf("unable to get files for version %s", version)
 	}
@@ -58,7 +60,7 @@ func (f FakeRepository) GetFile(version string, path string) ([]byte, error) {
 	return nil, errors.Errorf("unable to get file %s for version %s", path, version)
 }
 
-func (f *FakeRepository) GetVersions() ([]string, error) {
+func (f *FakeRepository) GetVersions(ctx context.Context) ([]string, error) {
 	v := make([]string, 0, len(f.versions))
 	for k := range f.versions {
 		v = append(v, k)
@@ -66,6 +68,20 @@ func (f *FakeRepository) GetVersions() ([]string, error) {
 	return v, nil
 }
 
+func (f *FakeRepository) ListFiles(ctx context.Context, version string) ([]string, error) {
+	if _, ok := f.versions[version]; !ok {
+		return nil, errors.Errorf("unable to get files for version %s", version)
+	}
+
+	files := []string{}
+	for p := range f.files {
+		if strings.HasPrefix(p, vpath(version, "")) {
+			files = append(files, strings.TrimPrefix(p, vpath(version, "")))
+		}
+	}
+	return files, nil
+}
+
 func NewFakeRepository() *FakeRepository {
 	return &FakeRepository{
 		versions: map[string]bool{},
@@ -0,0 +1,155 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"sort"
+
+	"github.com/pkg/errors"
+	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions"
+	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/install"
+	"k8s.io/apiextensions-apiserver/pkg/apiserver/validation"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	kerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+const customResourceDefinitionKind = "CustomResourceDefinition"
+
+var crdScheme = runtime.NewScheme()
+
+func init() {
+	install.Install(crdScheme)
+}
+
+// IsCustomResourceDefinition returns true if the object is a CustomResourceDefinition.
+func IsCustomResourceDefinition(obj unstructured.Unstructured) bool {
+	return obj.GroupVersionKind().GroupKind() == schema.GroupKind{Group: apiextensions.GroupName, Kind: customResourceDefinitionKind}
+}
+
+// ValidateObjects validates the objects against the OpenAPI v3 schemas defined in the CustomResourceDefinitions,
+// without requiring access to a cluster. Besides the errors reported by the API server, fields not defined in the
+// schemas are reported as errors, because they are usually caused by typos and they would be silently dropped.
+// The objects whose version and kind are not defined by any of the CustomResourceDefinitions are returned as skipped.
+func ValidateObjects(objs []unstructured.Unstructured, crds []unstructured.Unstructured) ([]unstructured.Unstructured, error) {
+	schemas := map[schema.GroupVersionKind]*apiextensions.CustomResourceValidation{}
+	for _, o := range crds {
+		crd, err := toInternalCustomResourceDefinition(o)
+		if err != nil {
+			return nil, err
+		}
+		for _, v := range crd.Spec.Versions {
+			crValidation := crd.Spec.Validation
+			if v.Schema != nil {
+				crValidation = v.Schema
+			}
+			if crValidation == nil || crValidation.OpenAPIV3Schema == nil {
+				continue
+			}
+			schemas[schema.GroupVersionKind{Group: crd.Spec.Group, Version: v.Name, Kind: crd.Spec.Names.Kind}] = crValidation
+		}
+	}
+
+	var skipped []unstructured.Unstructured
+	var errs []error
+	for _, o := range objs {
+		crValidation, ok := schemas[o.GroupVersionKind()]
+		if !ok {
+			skipped = append(skipped, o)
+			continue
+		}
+
+		validator, _, err := validation.NewSchemaValidator(crValidation)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to create the schema validator for %s", o.GroupVersionKind())
+		}
+		allErrs := validation.ValidateCustomResource(nil, o.UnstructuredContent(), validator)
+		allErrs = append(allErrs, unknownFields(nil, o.UnstructuredContent(), crValidation.OpenAPIV3Schema, true)...)
+		if len(allErrs) > 0 {
+			errs = append(errs, errors.Errorf("%s %q is not valid: %s", o.GetKind(), o.GetName(), allErrs.ToAggregate().Error()))
+		}
+	}
+
+	return skipped, kerrors.NewAggregate(errs)
+}
+
+// toInternalCustomResourceDefinition converts a CustomResourceDefinition of any of the supported versions to the
+// internal version, so schemas are read in the same way independently of the version used by the provider.
+func toInternalCustomResourceDefinition(obj unstructured.Unstructured) (*apiextensions.CustomResourceDefinition, error) {
+	versioned, err := crdScheme.New(obj.GroupVersionKind())
+	if err != nil {
+		return nil, errors.Wrapf(err, "unsupported CustomResourceDefinition %q", obj.GetName())
+	}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.UnstructuredContent(), versioned); err != nil {
+		return nil, errors.Wrapf(err, "failed to read CustomResourceDefinition %q", obj.GetName())
+	}
+
+	crd := &apiextensions.CustomResourceDefinition{}
+	if err := crdScheme.Convert(versioned, crd, nil); err != nil {
+		return nil, errors.Wrapf(err, "failed to convert CustomResourceDefinition %q", obj.GetName())
+	}
+	return crd, nil
+}
+
+// unknownFields returns an error for every field of the value that is not defined in the schema.
+// Objects without properties in the schema, or that preserve unknown fields, accept any field.
+func unknownFields(fldPath *field.Path, value interface{}, s *apiextensions.JSONSchemaProps, isResourceRoot bool) field.ErrorList {
+	if s == nil || (s.XPreserveUnknownFields != nil && *s.XPreserveUnknownFields) {
+		return nil
+	}
+
+	var allErrs field.ErrorList
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if len(s.Properties) == 0 {
+			if s.AdditionalProperties != nil && s.AdditionalProperties.Schema != nil {
+				for key, item := range v {
+					allErrs = append(allErrs, unknownFields(fldPath.Key(key), item, s.AdditionalProperties.Schema, false)...)
+				}
+			}
+			return allErrs
+		}
+
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			// apiVersion, kind and metadata are validated by the API server independently of the schema.
+			if isResourceRoot && (key == "apiVersion" || key == "kind" || key == "metadata") {
+				continue
+			}
+			property, ok := s.Properties[key]
+			if !ok {
+				allErrs = append(allErrs, field.Forbidden(fldPath.Child(key), "field not defined in the schema"))
+				continue
+			}
+			allErrs = append(allErrs, unknownFields(fldPath.Child(key), v[key], &property, false)...)
+		}
+	case []interface{}:
+		if s.Items == nil || s.Items.Schema == nil {
+			return nil
+		}
+		for i, item := range v {
+			allErrs = append(allErrs, unknownFields(fldPath.Index(i), item, s.Items.Schema, false)...)
+		}
+	}
+	return allErrs
+}
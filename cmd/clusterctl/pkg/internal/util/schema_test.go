@@ -0,0 +1,168 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"testing"
+)
+
+var testCRDYaml = []byte(`apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: foos.test.cluster.x-k8s.io
+spec:
+  group: test.cluster.x-k8s.io
+  names:
+    kind: Foo
+    listKind: FooList
+    plural: foos
+    singular: foo
+  scope: Namespaced
+  versions:
+  - name: v1alpha3
+    served: true
+    storage: true
+    schema:
+      openAPIV3Schema:
+        type: object
+        properties:
+          apiVersion:
+            type: string
+          kind:
+            type: string
+          metadata:
+            type: object
+          spec:
+            type: object
+            properties:
+              replicas:
+                type: integer
+              labels:
+                type: object
+                additionalProperties:
+                  type: string
+              items:
+                type: array
+                items:
+                  type: object
+                  properties:
+                    name:
+                      type: string
+            required:
+            - replicas`)
+
+func TestValidateObjects(t *testing.T) {
+	crds, err := ToUnstructured(testCRDYaml)
+	if err != nil {
+		t.Fatalf("ToUnstructured() error = %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		yaml        string
+		wantSkipped int
+		wantErr     bool
+	}{
+		{
+			name: "valid object",
+			yaml: `apiVersion: test.cluster.x-k8s.io/v1alpha3
+kind: Foo
+metadata:
+  name: foo
+  namespace: bar
+spec:
+  replicas: 1
+  labels:
+    foo: bar
+  items:
+  - name: foo`,
+			wantSkipped: 0,
+			wantErr:     false,
+		},
+		{
+			name: "field with the wrong type",
+			yaml: `apiVersion: test.cluster.x-k8s.io/v1alpha3
+kind: Foo
+metadata:
+  name: foo
+spec:
+  replicas: one`,
+			wantErr: true,
+		},
+		{
+			name: "missing required field",
+			yaml: `apiVersion: test.cluster.x-k8s.io/v1alpha3
+kind: Foo
+metadata:
+  name: foo
+spec: {}`,
+			wantErr: true,
+		},
+		{
+			name: "unknown field",
+			yaml: `apiVersion: test.cluster.x-k8s.io/v1alpha3
+kind: Foo
+metadata:
+  name: foo
+spec:
+  replicas: 1
+  items:
+  - name: foo
+    nmae: bar`,
+			wantErr: true,
+		},
+		{
+			name: "object without a CustomResourceDefinition is skipped",
+			yaml: `apiVersion: v1
+kind: Secret
+metadata:
+  name: foo
+data:
+  foo: YmFy`,
+			wantSkipped: 1,
+			wantErr:     false,
+		},
+		{
+			name: "object with a version not defined in the CustomResourceDefinition is skipped",
+			yaml: `apiVersion: test.cluster.x-k8s.io/v1alpha2
+kind: Foo
+metadata:
+  name: foo`,
+			wantSkipped: 1,
+			wantErr:     false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			objs, err := ToUnstructured([]byte(tt.yaml))
+			if err != nil {
+				t.Fatalf("ToUnstructured() error = %v", err)
+			}
+
+			skipped, err := ValidateObjects(objs, crds)
+			if tt.wantErr != (err != nil) {
+				t.Fatalf("error = %v, wantErr = %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if len(skipped) != tt.wantSkipped {
+				t.Errorf("got %d skipped objects, want %d", len(skipped), tt.wantSkipped)
+			}
+		})
+	}
+}
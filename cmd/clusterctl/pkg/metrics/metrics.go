@@ -0,0 +1,70 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package metrics defines the metrics exported by the clusterctl library.
+//
+// The metrics are registered in a dedicated Registry, so applications using clusterctl as a library
+// can gather them directly or register them into their own registry.
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	// Registry is the registry where the clusterctl metrics are registered.
+	Registry = prometheus.NewRegistry()
+
+	// ProviderInstallDuration is a metric observing the time required for installing
+	// the components of a provider, including the update of the provider inventory.
+	ProviderInstallDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "clusterctl_provider_install_duration_seconds",
+			Help:    "Time required for installing the components of a provider.",
+			Buckets: prometheus.ExponentialBuckets(1, 2, 10),
+		},
+		[]string{"provider", "type"},
+	)
+
+	// ProviderUpgradeDuration is a metric observing the time required for upgrading
+	// a provider, including the deletion of the components of the previous version.
+	ProviderUpgradeDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "clusterctl_provider_upgrade_duration_seconds",
+			Help:    "Time required for upgrading a provider to a new version.",
+			Buckets: prometheus.ExponentialBuckets(1, 2, 10),
+		},
+		[]string{"provider", "type"},
+	)
+
+	// RepositoryFetchErrors is a metric counting the errors occurred while fetching
+	// files or versions from the repository of a provider.
+	RepositoryFetchErrors = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "clusterctl_repository_fetch_errors_total",
+			Help: "Number of errors occurred while fetching files or versions from a provider repository.",
+		},
+		[]string{"provider"},
+	)
+)
+
+func init() {
+	Registry.MustRegister(
+		ProviderInstallDuration,
+		ProviderUpgradeDuration,
+		RepositoryFetchErrors,
+	)
+}
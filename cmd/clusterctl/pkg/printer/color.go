@@ -0,0 +1,78 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package printer
+
+import (
+	"fmt"
+	"os"
+)
+
+// Color is an ANSI color code.
+type Color string
+
+const (
+	// NoColor is used for values that should not be colorized.
+	NoColor = Color("")
+
+	// Red is used for errors and failure states.
+	Red = Color("31")
+
+	// Green is used for success states.
+	Green = Color("32")
+
+	// Yellow is used for warnings and unknown states.
+	Yellow = Color("33")
+)
+
+// ColorFunc returns the color to be used for a value.
+type ColorFunc func(value string) Color
+
+// Colorize returns the text wrapped in the ANSI escape sequences for the given color.
+func Colorize(text string, color Color) string {
+	if color == NoColor || text == "" {
+		return text
+	}
+	return fmt.Sprintf("\x1b[%sm%s\x1b[0m", color, text)
+}
+
+// ConditionStatusColor returns the color for a condition status: green for True, red for False and yellow for Unknown.
+func ConditionStatusColor(status string) Color {
+	switch status {
+	case "True":
+		return Green
+	case "False":
+		return Red
+	case "Unknown":
+		return Yellow
+	default:
+		return NoColor
+	}
+}
+
+// ColorSupported returns true if colors can be used when writing to f, that is if f is a terminal and
+// the NO_COLOR environment variable is not set (see https://no-color.org).
+func ColorSupported(f *os.File) bool {
+	if _, ok := os.LookupEnv("NO_COLOR"); ok {
+		return false
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
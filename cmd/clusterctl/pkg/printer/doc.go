@@ -0,0 +1,28 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+/*
+Package printer implements a shared output rendering layer for the clusterctl commands, so the CLI presents
+consistent and readable results.
+
+Tables are rendered with aligned columns; columns can be marked as wide, and in this case they are printed only
+when the wide output is requested (e.g. with the --wide flag).
+
+Table values can be colorized, e.g. for highlighting the status of conditions; colors are automatically disabled
+when the output is not a terminal, when the NO_COLOR environment variable is set, or when the user opts out
+(e.g. with the --no-color flag).
+*/
+package printer
@@ -0,0 +1,150 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package printer
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// minColumnWidth is the minimum width of a table column, padding included.
+	minColumnWidth = 10
+
+	// columnPadding is the number of spaces added after the values in a column.
+	columnPadding = 3
+)
+
+// Options defines how the output should be rendered.
+type Options struct {
+	// Wide enables printing wide columns.
+	Wide bool
+
+	// NoColor disables colors.
+	NoColor bool
+}
+
+// Column defines a table column.
+type Column struct {
+	// Name of the column, used as a header.
+	Name string
+
+	// Wide columns are printed only if the wide output is requested.
+	Wide bool
+
+	// Color, if set, is used for colorizing the values in the column.
+	Color ColorFunc
+}
+
+// Table renders a list of rows with aligned columns.
+type Table struct {
+	columns []Column
+	rows    [][]string
+	indent  string
+}
+
+// NewTable returns a Table with the given columns.
+func NewTable(columns ...Column) *Table {
+	return &Table{
+		columns: columns,
+	}
+}
+
+// WithIndent sets a prefix for all the lines of the table, e.g. for nesting a table in a structured output.
+func (t *Table) WithIndent(indent string) *Table {
+	t.indent = indent
+	return t
+}
+
+// AddRow adds a row to the table; values are assigned to columns in order, missing values are considered empty.
+func (t *Table) AddRow(values ...string) *Table {
+	t.rows = append(t.rows, values)
+	return t
+}
+
+// Print writes the table to w.
+func (t *Table) Print(w io.Writer, options Options) error {
+	// Selects the columns to print.
+	var columns []int
+	for i, c := range t.columns {
+		if c.Wide && !options.Wide {
+			continue
+		}
+		columns = append(columns, i)
+	}
+
+	// Computes the width of each column; widths are computed before colorizing values, because the ANSI
+	// escape sequences are not visible.
+	widths := make([]int, len(t.columns))
+	for _, i := range columns {
+		widths[i] = utf8.RuneCountInString(t.columns[i].Name)
+		for _, row := range t.rows {
+			if n := utf8.RuneCountInString(value(row, i)); n > widths[i] {
+				widths[i] = n
+			}
+		}
+		widths[i] += columnPadding
+		if widths[i] < minColumnWidth {
+			widths[i] = minColumnWidth
+		}
+	}
+
+	printLine := func(values func(i int) (string, Color)) error {
+		var b strings.Builder
+		b.WriteString(t.indent)
+		for n, i := range columns {
+			v, color := values(i)
+			if options.NoColor {
+				color = NoColor
+			}
+			b.WriteString(Colorize(v, color))
+			if n < len(columns)-1 {
+				b.WriteString(strings.Repeat(" ", widths[i]-utf8.RuneCountInString(v)))
+			}
+		}
+		_, err := fmt.Fprintln(w, strings.TrimRight(b.String(), " "))
+		return err
+	}
+
+	if err := printLine(func(i int) (string, Color) {
+		return t.columns[i].Name, NoColor
+	}); err != nil {
+		return err
+	}
+
+	for _, row := range t.rows {
+		if err := printLine(func(i int) (string, Color) {
+			v := value(row, i)
+			if t.columns[i].Color == nil {
+				return v, NoColor
+			}
+			return v, t.columns[i].Color(v)
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func value(row []string, i int) string {
+	if i < len(row) {
+		return row[i]
+	}
+	return ""
+}
@@ -0,0 +1,91 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package printer
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestTable_Print(t *testing.T) {
+	newTable := func() *Table {
+		return NewTable(
+			Column{Name: "NAME"},
+			Column{Name: "STATUS", Color: ConditionStatusColor},
+			Column{Name: "MESSAGE", Wide: true},
+		).
+			AddRow("cluster-api", "True", "").
+			AddRow("infrastructure-aws", "False", "controller not running")
+	}
+
+	tests := []struct {
+		name    string
+		table   *Table
+		options Options
+		want    string
+	}{
+		{
+			name:    "print table",
+			table:   newTable(),
+			options: Options{NoColor: true},
+			want: "NAME                 STATUS\n" +
+				"cluster-api          True\n" +
+				"infrastructure-aws   False\n",
+		},
+		{
+			name:    "print table with wide columns",
+			table:   newTable(),
+			options: Options{NoColor: true, Wide: true},
+			want: "NAME                 STATUS    MESSAGE\n" +
+				"cluster-api          True\n" +
+				"infrastructure-aws   False     controller not running\n",
+		},
+		{
+			name:    "print table with colors",
+			table:   newTable(),
+			options: Options{},
+			want: "NAME                 STATUS\n" +
+				"cluster-api          \x1b[32mTrue\x1b[0m\n" +
+				"infrastructure-aws   \x1b[31mFalse\x1b[0m\n",
+		},
+		{
+			name:    "print table with indent",
+			table:   newTable().WithIndent("  "),
+			options: Options{NoColor: true},
+			want: "  NAME                 STATUS\n" +
+				"  cluster-api          True\n" +
+				"  infrastructure-aws   False\n",
+		},
+		{
+			name:    "print empty table",
+			table:   NewTable(Column{Name: "NAME"}, Column{Name: "TYPE"}),
+			options: Options{NoColor: true},
+			want:    "NAME      TYPE\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := &bytes.Buffer{}
+			if err := tt.table.Print(out, tt.options); err != nil {
+				t.Fatalf("error = %v, want nil", err)
+			}
+			if out.String() != tt.want {
+				t.Errorf("got = %q, want %q", out.String(), tt.want)
+			}
+		})
+	}
+}
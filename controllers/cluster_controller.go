@@ -36,6 +36,7 @@ import (
 	"sigs.k8s.io/cluster-api/controllers/metrics"
 	capierrors "sigs.k8s.io/cluster-api/errors"
 	"sigs.k8s.io/cluster-api/util"
+	"sigs.k8s.io/cluster-api/util/conditions"
 	"sigs.k8s.io/cluster-api/util/patch"
 	"sigs.k8s.io/cluster-api/util/secret"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -108,8 +109,11 @@ func (r *ClusterReconciler) Reconcile(req ctrl.Request) (_ ctrl.Result, reterr e
 		return ctrl.Result{}, err
 	}
 
-	// Return early if the object or Cluster is paused.
+	// Return early if the object or Cluster is paused, after propagating the pause to the referenced provider objects.
 	if util.IsPaused(cluster, cluster) {
+		if err := reconcilePausePropagation(ctx, r.Client, cluster, cluster.Namespace, cluster.Spec.InfrastructureRef, cluster.Spec.ControlPlaneRef); err != nil {
+			return ctrl.Result{}, err
+		}
 		logger.V(3).Info("reconciliation is paused for this object")
 		return ctrl.Result{}, nil
 	}
@@ -125,6 +129,18 @@ func (r *ClusterReconciler) Reconcile(req ctrl.Request) (_ ctrl.Result, reterr e
 		r.reconcilePhase(ctx, cluster)
 		r.reconcileMetrics(ctx, cluster)
 
+		// Always update the Ready condition with the summary of the Cluster conditions; while the Cluster
+		// is being deleted, the Ready condition is set by reconcileDelete.
+		if cluster.ObjectMeta.DeletionTimestamp.IsZero() {
+			conditions.SetSummary(cluster,
+				conditions.WithConditions(
+					clusterv1.ControlPlaneReadyCondition,
+					clusterv1.InfrastructureReadyCondition,
+				),
+			)
+			r.reconcileReadyTime(ctx, cluster)
+		}
+
 		// Always attempt to Patch the Cluster object and status after each reconciliation.
 		if err := patchHelper.Patch(ctx, cluster); err != nil {
 			reterr = kerrors.NewAggregate([]error{reterr, err})
@@ -202,77 +218,90 @@ func (r *ClusterReconciler) reconcileMetrics(_ context.Context, cluster *cluster
 	}
 }
 
+// reconcileReadyTime records the time the Cluster became Ready for the first time, observing the time elapsed
+// since the creation of the Cluster.
+func (r *ClusterReconciler) reconcileReadyTime(_ context.Context, cluster *clusterv1.Cluster) {
+	if cluster.Status.ReadyTime != nil || !conditions.IsTrue(cluster, clusterv1.ReadyCondition) {
+		return
+	}
+
+	readyTime := conditions.Get(cluster, clusterv1.ReadyCondition).LastTransitionTime
+	cluster.Status.ReadyTime = &readyTime
+	metrics.ObserveDuration(metrics.ClusterReadyDuration.WithLabelValues(cluster.Namespace), cluster.CreationTimestamp.Time, readyTime.Time)
+}
+
 // reconcileDelete handles cluster deletion.
+// The descendants of the cluster are deleted in order: first the workers (MachineDeployments, MachineSets, MachinePools
+// and the other worker Machines) so they can be drained while the control plane is still available, then the control
+// plane, and finally the cluster infrastructure, which can be deleted only once all the Machines are gone.
 func (r *ClusterReconciler) reconcileDelete(ctx context.Context, cluster *clusterv1.Cluster) (reconcile.Result, error) {
 	logger := r.Log.WithValues("cluster", cluster.Name, "namespace", cluster.Namespace)
 
-	// First handle the control plane
+	descendants, err := r.listDescendants(ctx, cluster)
+	if err != nil {
+		logger.Error(err, "Failed to list descendants")
+		return reconcile.Result{}, err
+	}
+
+	// First handle the workers.
+	if workers := descendants.workersLength(); workers > 0 {
+		conditions.MarkFalse(cluster, clusterv1.ReadyCondition, clusterv1.DeletingReason, clusterv1.ConditionSeverityInfo, "Deleting workers")
+
+		children, err := descendants.filterOwnedWorkers(cluster)
+		if err != nil {
+			logger.Error(err, "Failed to extract direct worker descendants")
+			return reconcile.Result{}, err
+		}
+		if err := r.deleteChildren(ctx, cluster, children); err != nil {
+			return ctrl.Result{}, err
+		}
+
+		logger.Info("Cluster still has workers - need to requeue", "descendants", descendants.descendantNames(), "indirect descendants count", workers-len(children))
+		// Requeue so we can check the next time to see if there are still any workers left.
+		return ctrl.Result{RequeueAfter: deleteRequeueAfter}, nil
+	}
+
+	// Then handle the control plane.
+	conditions.MarkFalse(cluster, clusterv1.ReadyCondition, clusterv1.DeletingReason, clusterv1.ConditionSeverityInfo, "Deleting control plane")
+
+	controlPlaneExists := false
 	if cluster.Spec.ControlPlaneRef != nil {
 		obj, err := external.Get(ctx, r.Client, cluster.Spec.ControlPlaneRef, cluster.Namespace)
 		switch {
 		case apierrors.IsNotFound(errors.Cause(err)):
+			// All good - the control plane has been deleted
 		case err != nil:
 			return reconcile.Result{}, err
-		case obj.GetDeletionTimestamp().IsZero():
-			if err := r.Client.Delete(ctx, obj); err != nil {
-				return ctrl.Result{}, errors.Wrapf(err,
-					"failed to delete %v %q for Cluster %q in namespace %q",
-					obj.GroupVersionKind(), obj.GetName(), cluster.Name, cluster.Namespace)
+		default:
+			controlPlaneExists = true
+			if obj.GetDeletionTimestamp().IsZero() {
+				if err := r.Client.Delete(ctx, obj); err != nil {
+					return ctrl.Result{}, errors.Wrapf(err,
+						"failed to delete %v %q for Cluster %q in namespace %q",
+						obj.GroupVersionKind(), obj.GetName(), cluster.Name, cluster.Namespace)
+				}
 			}
 		}
 	}
 
-	descendants, err := r.listDescendants(ctx, cluster)
-	if err != nil {
-		logger.Error(err, "Failed to list descendants")
-		return reconcile.Result{}, err
-	}
-
 	children, err := descendants.filterOwnedDescendants(cluster)
 	if err != nil {
 		logger.Error(err, "Failed to extract direct descendants")
 		return reconcile.Result{}, err
 	}
-
-	if len(children) > 0 {
-		logger.Info("Cluster still has children - deleting them first", "count", len(children))
-
-		var errs []error
-
-		for _, child := range children {
-			accessor, err := meta.Accessor(child)
-			if err != nil {
-				logger.Error(err, "Couldn't create accessor", "type", fmt.Sprintf("%T", child))
-				continue
-			}
-
-			if !accessor.GetDeletionTimestamp().IsZero() {
-				// Don't handle deleted child
-				continue
-			}
-
-			gvk := child.GetObjectKind().GroupVersionKind().String()
-
-			logger.Info("Deleting child", "gvk", gvk, "name", accessor.GetName())
-			if err := r.Client.Delete(context.Background(), child); err != nil {
-				err = errors.Wrapf(err, "error deleting cluster %s/%s: failed to delete %s %s", cluster.Namespace, cluster.Name, gvk, accessor.GetName())
-				logger.Error(err, "Error deleting resource", "gvk", gvk, "name", accessor.GetName())
-				errs = append(errs, err)
-			}
-		}
-
-		if len(errs) > 0 {
-			return ctrl.Result{}, kerrors.NewAggregate(errs)
-		}
+	if err := r.deleteChildren(ctx, cluster, children); err != nil {
+		return ctrl.Result{}, err
 	}
 
-	if descendantCount := descendants.length(); descendantCount > 0 {
-		indirect := descendantCount - len(children)
-		logger.Info("Cluster still has descendants - need to requeue", "descendants", descendants.descendantNames(), "indirect descendants count", indirect)
-		// Requeue so we can check the next time to see if there are still any descendants left.
+	if controlPlaneExists || descendants.length() > 0 {
+		logger.Info("Cluster still has a control plane - need to requeue", "descendants", descendants.descendantNames())
+		// Requeue so we can check the next time to see if the control plane is gone.
 		return ctrl.Result{RequeueAfter: deleteRequeueAfter}, nil
 	}
 
+	// Finally handle the cluster infrastructure.
+	conditions.MarkFalse(cluster, clusterv1.ReadyCondition, clusterv1.DeletingReason, clusterv1.ConditionSeverityInfo, "Deleting infrastructure")
+
 	if cluster.Spec.InfrastructureRef != nil {
 		obj, err := external.Get(ctx, r.Client, cluster.Spec.InfrastructureRef, cluster.Namespace)
 		switch {
@@ -282,13 +311,18 @@ func (r *ClusterReconciler) reconcileDelete(ctx context.Context, cluster *cluste
 			return ctrl.Result{}, errors.Wrapf(err, "failed to get %s %q for Cluster %s/%s",
 				path.Join(cluster.Spec.InfrastructureRef.APIVersion, cluster.Spec.InfrastructureRef.Kind),
 				cluster.Spec.InfrastructureRef.Name, cluster.Namespace, cluster.Name)
+		case util.IsExternallyManaged(obj):
+			// Externally managed infrastructure outlives the Cluster.
+			logger.Info("Skipping deletion of externally managed infrastructure", "gvk", obj.GroupVersionKind().String(), "name", obj.GetName())
 		default:
 			// Issue a deletion request for the infrastructure object.
 			// Once it's been deleted, the cluster will get processed again.
-			if err := r.Client.Delete(ctx, obj); err != nil {
-				return ctrl.Result{}, errors.Wrapf(err,
-					"failed to delete %v %q for Cluster %q in namespace %q",
-					obj.GroupVersionKind(), obj.GetName(), cluster.Name, cluster.Namespace)
+			if obj.GetDeletionTimestamp().IsZero() {
+				if err := r.Client.Delete(ctx, obj); err != nil {
+					return ctrl.Result{}, errors.Wrapf(err,
+						"failed to delete %v %q for Cluster %q in namespace %q",
+						obj.GroupVersionKind(), obj.GetName(), cluster.Name, cluster.Namespace)
+				}
 			}
 
 			// Return here so we don't remove the finalizer yet.
@@ -300,18 +334,54 @@ func (r *ClusterReconciler) reconcileDelete(ctx context.Context, cluster *cluste
 	return ctrl.Result{}, nil
 }
 
+// deleteChildren issues a deletion request for the given children of the cluster not already being deleted.
+func (r *ClusterReconciler) deleteChildren(ctx context.Context, cluster *clusterv1.Cluster, children []runtime.Object) error {
+	logger := r.Log.WithValues("cluster", cluster.Name, "namespace", cluster.Namespace)
+
+	var errs []error
+	for _, child := range children {
+		accessor, err := meta.Accessor(child)
+		if err != nil {
+			logger.Error(err, "Couldn't create accessor", "type", fmt.Sprintf("%T", child))
+			continue
+		}
+
+		if !accessor.GetDeletionTimestamp().IsZero() {
+			// Don't handle deleted child
+			continue
+		}
+
+		gvk := child.GetObjectKind().GroupVersionKind().String()
+
+		logger.Info("Deleting child", "gvk", gvk, "name", accessor.GetName())
+		if err := r.Client.Delete(ctx, child); err != nil {
+			err = errors.Wrapf(err, "error deleting cluster %s/%s: failed to delete %s %s", cluster.Namespace, cluster.Name, gvk, accessor.GetName())
+			logger.Error(err, "Error deleting resource", "gvk", gvk, "name", accessor.GetName())
+			errs = append(errs, err)
+		}
+	}
+	return kerrors.NewAggregate(errs)
+}
+
 type clusterDescendants struct {
 	machineDeployments   clusterv1.MachineDeploymentList
 	machineSets          clusterv1.MachineSetList
+	machinePools         clusterv1.MachinePoolList
 	controlPlaneMachines clusterv1.MachineList
 	workerMachines       clusterv1.MachineList
 }
 
 // length returns the number of descendants
 func (c *clusterDescendants) length() int {
+	return c.workersLength() +
+		len(c.controlPlaneMachines.Items)
+}
+
+// workersLength returns the number of descendants, excluding the control plane machines
+func (c *clusterDescendants) workersLength() int {
 	return len(c.machineDeployments.Items) +
 		len(c.machineSets.Items) +
-		len(c.controlPlaneMachines.Items) +
+		len(c.machinePools.Items) +
 		len(c.workerMachines.Items)
 }
 
@@ -338,6 +408,13 @@ func (c *clusterDescendants) descendantNames() string {
 	if len(machineSetNames) > 0 {
 		descendants = append(descendants, "Machine sets: "+strings.Join(machineSetNames, ","))
 	}
+	machinePoolNames := make([]string, len(c.machinePools.Items))
+	for i, machinePool := range c.machinePools.Items {
+		machinePoolNames[i] = machinePool.Name
+	}
+	if len(machinePoolNames) > 0 {
+		descendants = append(descendants, "Machine pools: "+strings.Join(machinePoolNames, ","))
+	}
 	workerMachineNames := make([]string, len(c.workerMachines.Items))
 	for i, workerMachine := range c.workerMachines.Items {
 		workerMachineNames[i] = workerMachine.Name
@@ -348,7 +425,7 @@ func (c *clusterDescendants) descendantNames() string {
 	return strings.Join(descendants, ";")
 }
 
-// listDescendants returns a list of all MachineDeployments, MachineSets, and Machines for the cluster.
+// listDescendants returns a list of all MachineDeployments, MachineSets, MachinePools and Machines for the cluster.
 func (r *ClusterReconciler) listDescendants(ctx context.Context, cluster *clusterv1.Cluster) (clusterDescendants, error) {
 	var descendants clusterDescendants
 
@@ -365,6 +442,10 @@ func (r *ClusterReconciler) listDescendants(ctx context.Context, cluster *cluste
 		return descendants, errors.Wrapf(err, "failed to list MachineSets for cluster %s/%s", cluster.Namespace, cluster.Name)
 	}
 
+	if err := r.Client.List(ctx, &descendants.machinePools, listOptions...); err != nil {
+		return descendants, errors.Wrapf(err, "failed to list MachinePools for cluster %s/%s", cluster.Namespace, cluster.Name)
+	}
+
 	var machines clusterv1.MachineList
 	if err := r.Client.List(ctx, &machines, listOptions...); err != nil {
 		return descendants, errors.Wrapf(err, "failed to list Machines for cluster %s/%s", cluster.Namespace, cluster.Name)
@@ -381,6 +462,28 @@ func (r *ClusterReconciler) listDescendants(ctx context.Context, cluster *cluste
 // filterOwnedDescendants returns an array of runtime.Objects containing only those descendants that have the cluster
 // as an owner reference, with control plane machines sorted last.
 func (c clusterDescendants) filterOwnedDescendants(cluster *clusterv1.Cluster) ([]runtime.Object, error) {
+	return filterOwned(cluster,
+		&c.machineDeployments,
+		&c.machineSets,
+		&c.machinePools,
+		&c.workerMachines,
+		&c.controlPlaneMachines,
+	)
+}
+
+// filterOwnedWorkers returns an array of runtime.Objects containing only those descendants that have the cluster
+// as an owner reference, excluding the control plane machines.
+func (c clusterDescendants) filterOwnedWorkers(cluster *clusterv1.Cluster) ([]runtime.Object, error) {
+	return filterOwned(cluster,
+		&c.machineDeployments,
+		&c.machineSets,
+		&c.machinePools,
+		&c.workerMachines,
+	)
+}
+
+// filterOwned returns the items of the given lists that have the cluster as an owner reference.
+func filterOwned(cluster *clusterv1.Cluster, lists ...runtime.Object) ([]runtime.Object, error) {
 	var ownedDescendants []runtime.Object
 	eachFunc := func(o runtime.Object) error {
 		acc, err := meta.Accessor(o)
@@ -395,12 +498,6 @@ func (c clusterDescendants) filterOwnedDescendants(cluster *clusterv1.Cluster) (
 		return nil
 	}
 
-	lists := []runtime.Object{
-		&c.machineDeployments,
-		&c.machineSets,
-		&c.workerMachines,
-		&c.controlPlaneMachines,
-	}
 	for _, list := range lists {
 		if err := meta.EachListItem(list, eachFunc); err != nil {
 			return nil, errors.Wrapf(err, "error finding owned descendants of cluster %s/%s", cluster.Namespace, cluster.Name)
@@ -434,6 +531,7 @@ func (r *ClusterReconciler) reconcileControlPlaneInitialized(ctx context.Context
 	}
 
 	if cluster.Status.ControlPlaneInitialized {
+		conditions.MarkTrue(cluster, clusterv1.ControlPlaneReadyCondition)
 		return nil
 	}
 
@@ -446,10 +544,12 @@ func (r *ClusterReconciler) reconcileControlPlaneInitialized(ctx context.Context
 	for _, m := range machines {
 		if util.IsControlPlaneMachine(m) && m.Status.NodeRef != nil {
 			cluster.Status.ControlPlaneInitialized = true
+			conditions.MarkTrue(cluster, clusterv1.ControlPlaneReadyCondition)
 			return nil
 		}
 	}
 
+	conditions.MarkFalse(cluster, clusterv1.ControlPlaneReadyCondition, clusterv1.WaitingForControlPlaneReason, clusterv1.ConditionSeverityInfo, "Waiting for the first control plane machine to have its Status.NodeRef set")
 	return nil
 }
 
@@ -29,6 +29,7 @@ import (
 	"sigs.k8s.io/cluster-api/controllers/external"
 	capierrors "sigs.k8s.io/cluster-api/errors"
 	"sigs.k8s.io/cluster-api/util"
+	"sigs.k8s.io/cluster-api/util/conditions"
 	"sigs.k8s.io/cluster-api/util/kubeconfig"
 	"sigs.k8s.io/cluster-api/util/patch"
 	"sigs.k8s.io/cluster-api/util/secret"
@@ -72,6 +73,11 @@ func (r *ClusterReconciler) reconcileExternal(ctx context.Context, cluster *clus
 		return external.ReconcileOutput{}, err
 	}
 
+	// Remove the pause propagated to the external object, if the Cluster is not paused anymore.
+	if err := propagatePause(ctx, r.Client, cluster, obj); err != nil {
+		return external.ReconcileOutput{}, err
+	}
+
 	// if external ref is paused, return error.
 	if util.IsPaused(cluster, obj) {
 		logger.V(3).Info("External object referenced is paused")
@@ -84,9 +90,12 @@ func (r *ClusterReconciler) reconcileExternal(ctx context.Context, cluster *clus
 		return external.ReconcileOutput{}, err
 	}
 
-	// Set external object ControllerReference to the Cluster.
-	if err := controllerutil.SetControllerReference(cluster, obj, r.scheme); err != nil {
-		return external.ReconcileOutput{}, err
+	// Set external object ControllerReference to the Cluster, unless the object is externally managed and thus
+	// it must not be garbage collected together with the Cluster.
+	if !util.IsExternallyManaged(obj) {
+		if err := controllerutil.SetControllerReference(cluster, obj, r.scheme); err != nil {
+			return external.ReconcileOutput{}, err
+		}
 	}
 
 	// Set the Cluster label.
@@ -95,6 +104,9 @@ func (r *ClusterReconciler) reconcileExternal(ctx context.Context, cluster *clus
 		labels = make(map[string]string)
 	}
 	labels[clusterv1.ClusterLabelName] = cluster.Name
+	for key, value := range util.GetPropagatedLabels(cluster) {
+		labels[key] = value
+	}
 	obj.SetLabels(labels)
 
 	// Always attempt to Patch the external object.
@@ -158,12 +170,25 @@ func (r *ClusterReconciler) reconcileInfrastructure(ctx context.Context, cluster
 	cluster.Status.InfrastructureReady = ready
 	if !ready {
 		logger.V(3).Info("Infrastructure provider is not ready yet")
+		conditions.MarkFalse(cluster, clusterv1.InfrastructureReadyCondition, clusterv1.WaitingForInfrastructureReason, clusterv1.ConditionSeverityInfo,
+			"Waiting for %s %q to be ready", infraConfig.GetKind(), infraConfig.GetName())
+
+		// Externally managed infrastructure isn't owned by the Cluster, so changes to its status don't trigger a reconcile.
+		if util.IsExternallyManaged(infraConfig) {
+			return errors.Wrapf(&capierrors.RequeueAfterError{RequeueAfter: 30 * time.Second},
+				"waiting for externally managed %v %q for Cluster %q in namespace %q to be ready",
+				infraConfig.GroupVersionKind(), infraConfig.GetName(), cluster.Name, cluster.Namespace)
+		}
 		return nil
 	}
+	conditions.MarkTrue(cluster, clusterv1.InfrastructureReadyCondition)
 
 	// Get and parse Spec.ControlPlaneEndpoint field from the infrastructure provider.
+	// When using a control plane provider, the endpoint can be provided by the control plane provider instead,
+	// e.g. for externally managed control planes.
 	if cluster.Spec.ControlPlaneEndpoint.IsZero() {
-		if err := util.UnstructuredUnmarshalField(infraConfig, &cluster.Spec.ControlPlaneEndpoint, "spec", "controlPlaneEndpoint"); err != nil {
+		err := util.UnstructuredUnmarshalField(infraConfig, &cluster.Spec.ControlPlaneEndpoint, "spec", "controlPlaneEndpoint")
+		if err != nil && !(err == util.ErrUnstructuredFieldNotFound && cluster.Spec.ControlPlaneRef != nil) {
 			return errors.Wrapf(err, "failed to retrieve Spec.ControlPlaneEndpoint from infrastructure provider for Cluster %q in namespace %q",
 				cluster.Name, cluster.Namespace)
 		}
@@ -216,6 +241,32 @@ func (r *ClusterReconciler) reconcileControlPlane(ctx context.Context, cluster *
 		return err
 	}
 	cluster.Status.ControlPlaneReady = ready
+	if ready {
+		conditions.MarkTrue(cluster, clusterv1.ControlPlaneReadyCondition)
+	} else {
+		conditions.MarkFalse(cluster, clusterv1.ControlPlaneReadyCondition, clusterv1.WaitingForControlPlaneReason, clusterv1.ConditionSeverityInfo,
+			"Waiting for %s %q to be ready", controlPlaneConfig.GetKind(), controlPlaneConfig.GetName())
+	}
+
+	// Get and parse Spec.ControlPlaneEndpoint field from the control plane provider, if it is not provided by the
+	// infrastructure provider. Externally managed control planes are not backed by Machines and must provide it.
+	if cluster.Spec.ControlPlaneEndpoint.IsZero() {
+		err := util.UnstructuredUnmarshalField(controlPlaneConfig, &cluster.Spec.ControlPlaneEndpoint, "spec", "controlPlaneEndpoint")
+		switch {
+		case err == util.ErrUnstructuredFieldNotFound:
+			externalManaged, err := external.IsExternalManagedControlPlane(controlPlaneConfig)
+			if err != nil {
+				return err
+			}
+			if externalManaged && ready {
+				return errors.Errorf("externally managed control plane %v %q is ready but does not provide Spec.ControlPlaneEndpoint for Cluster %q in namespace %q",
+					controlPlaneConfig.GroupVersionKind(), controlPlaneConfig.GetName(), cluster.Name, cluster.Namespace)
+			}
+		case err != nil:
+			return errors.Wrapf(err, "failed to retrieve Spec.ControlPlaneEndpoint from control plane provider for Cluster %q in namespace %q",
+				cluster.Name, cluster.Namespace)
+		}
+	}
 
 	return nil
 }
@@ -147,6 +147,126 @@ func TestClusterReconcilePhases(t *testing.T) {
 
 	})
 
+	t.Run("reconcile control plane", func(t *testing.T) {
+		cluster := &clusterv1.Cluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "test-cluster",
+				Namespace: "test-namespace",
+			},
+			Spec: clusterv1.ClusterSpec{
+				ControlPlaneRef: &corev1.ObjectReference{
+					APIVersion: "controlplane.cluster.x-k8s.io/v1alpha3",
+					Kind:       "ControlPlaneConfig",
+					Name:       "test",
+				},
+			},
+		}
+		controlPlane := func(spec, status map[string]interface{}) map[string]interface{} {
+			return map[string]interface{}{
+				"kind":       "ControlPlaneConfig",
+				"apiVersion": "controlplane.cluster.x-k8s.io/v1alpha3",
+				"metadata": map[string]interface{}{
+					"name":      "test",
+					"namespace": "test-namespace",
+				},
+				"spec":   spec,
+				"status": status,
+			}
+		}
+
+		tests := []struct {
+			name              string
+			controlPlaneRef   map[string]interface{}
+			expectErr         bool
+			expectEndpoint    clusterv1.APIEndpoint
+			expectReady       bool
+			expectInitialized bool
+		}{
+			{
+				name: "reads the control plane endpoint from an externally managed control plane",
+				controlPlaneRef: controlPlane(
+					map[string]interface{}{
+						"controlPlaneEndpoint": map[string]interface{}{
+							"host": "example.com",
+							"port": int64(443),
+						},
+					},
+					map[string]interface{}{
+						"externalManagedControlPlane": true,
+						"initialized":                 true,
+						"ready":                       true,
+					},
+				),
+				expectErr:         false,
+				expectEndpoint:    clusterv1.APIEndpoint{Host: "example.com", Port: 443},
+				expectReady:       true,
+				expectInitialized: true,
+			},
+			{
+				name: "returns no error if a control plane does not provide the control plane endpoint",
+				controlPlaneRef: controlPlane(
+					map[string]interface{}{},
+					map[string]interface{}{
+						"initialized": true,
+						"ready":       true,
+					},
+				),
+				expectErr:         false,
+				expectReady:       true,
+				expectInitialized: true,
+			},
+			{
+				name: "returns no error if an externally managed control plane not ready yet does not provide the control plane endpoint",
+				controlPlaneRef: controlPlane(
+					map[string]interface{}{},
+					map[string]interface{}{
+						"externalManagedControlPlane": true,
+					},
+				),
+				expectErr: false,
+			},
+			{
+				name: "returns error if an externally managed control plane is ready but does not provide the control plane endpoint",
+				controlPlaneRef: controlPlane(
+					map[string]interface{}{},
+					map[string]interface{}{
+						"externalManagedControlPlane": true,
+						"initialized":                 true,
+						"ready":                       true,
+					},
+				),
+				expectErr:         true,
+				expectReady:       true,
+				expectInitialized: true,
+			},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				g := NewWithT(t)
+				g.Expect(clusterv1.AddToScheme(scheme.Scheme)).To(Succeed())
+
+				cluster := cluster.DeepCopy()
+				controlPlaneConfig := &unstructured.Unstructured{Object: tt.controlPlaneRef}
+				r := &ClusterReconciler{
+					Client: fake.NewFakeClientWithScheme(scheme.Scheme, cluster, controlPlaneConfig),
+					Log:    log.Log,
+					scheme: scheme.Scheme,
+				}
+
+				err := r.reconcileControlPlane(context.Background(), cluster)
+				if tt.expectErr {
+					g.Expect(err).To(HaveOccurred())
+				} else {
+					g.Expect(err).NotTo(HaveOccurred())
+				}
+				g.Expect(cluster.Spec.ControlPlaneEndpoint).To(Equal(tt.expectEndpoint))
+				g.Expect(cluster.Status.ControlPlaneReady).To(Equal(tt.expectReady))
+				g.Expect(cluster.Status.ControlPlaneInitialized).To(Equal(tt.expectInitialized))
+			})
+		}
+	})
+
 	t.Run("reconcile kubeconfig", func(t *testing.T) {
 		cluster := &clusterv1.Cluster{
 			ObjectMeta: metav1.ObjectMeta{
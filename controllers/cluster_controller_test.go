@@ -26,6 +26,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/utils/pointer"
@@ -38,6 +39,7 @@ import (
 
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
 	capierrors "sigs.k8s.io/cluster-api/errors"
+	"sigs.k8s.io/cluster-api/util/conditions"
 	"sigs.k8s.io/cluster-api/util/kubeconfig"
 	"sigs.k8s.io/cluster-api/util/patch"
 )
@@ -777,6 +779,161 @@ func TestFilterOwnedDescendants(t *testing.T) {
 	g.Expect(actual).To(Equal(expected))
 }
 
+func TestClusterReconcilerDeletionOrder(t *testing.T) {
+	g := NewWithT(t)
+	g.Expect(clusterv1.AddToScheme(scheme.Scheme)).To(Succeed())
+
+	cluster := &clusterv1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       "test-cluster",
+			Namespace:  "test-namespace",
+			Finalizers: []string{clusterv1.ClusterFinalizer},
+		},
+		Spec: clusterv1.ClusterSpec{
+			ControlPlaneRef: &corev1.ObjectReference{
+				APIVersion: "controlplane.cluster.x-k8s.io/v1alpha3",
+				Kind:       "ControlPlaneConfig",
+				Name:       "test-control-plane",
+			},
+			InfrastructureRef: &corev1.ObjectReference{
+				APIVersion: "infrastructure.cluster.x-k8s.io/v1alpha3",
+				Kind:       "InfrastructureConfig",
+				Name:       "test-infrastructure",
+			},
+		},
+	}
+	external := func(apiVersion, kind, name string) *unstructured.Unstructured {
+		return &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": apiVersion,
+			"kind":       kind,
+			"metadata": map[string]interface{}{
+				"name":      name,
+				"namespace": "test-namespace",
+			},
+		}}
+	}
+	controlPlane := external("controlplane.cluster.x-k8s.io/v1alpha3", "ControlPlaneConfig", "test-control-plane")
+	infrastructure := external("infrastructure.cluster.x-k8s.io/v1alpha3", "InfrastructureConfig", "test-infrastructure")
+
+	md := newMachineDeploymentBuilder().named("test-md").ownedBy(cluster).build()
+	md.Namespace = "test-namespace"
+	md.Labels = map[string]string{clusterv1.ClusterLabelName: cluster.Name}
+
+	c := fake.NewFakeClientWithScheme(scheme.Scheme, cluster, controlPlane, infrastructure, &md)
+	r := &ClusterReconciler{
+		Client: c,
+		Log:    log.Log,
+	}
+	exists := func(obj runtime.Object, name string) bool {
+		return c.Get(ctx, client.ObjectKey{Namespace: "test-namespace", Name: name}, obj) == nil
+	}
+
+	// The workers are deleted first, while the control plane and the infrastructure are still there.
+	result, err := r.reconcileDelete(ctx, cluster)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(result.RequeueAfter).To(Equal(deleteRequeueAfter))
+	g.Expect(exists(&clusterv1.MachineDeployment{}, "test-md")).To(BeFalse())
+	g.Expect(exists(external("controlplane.cluster.x-k8s.io/v1alpha3", "ControlPlaneConfig", ""), "test-control-plane")).To(BeTrue())
+	g.Expect(exists(external("infrastructure.cluster.x-k8s.io/v1alpha3", "InfrastructureConfig", ""), "test-infrastructure")).To(BeTrue())
+
+	// Then the control plane.
+	result, err = r.reconcileDelete(ctx, cluster)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(result.RequeueAfter).To(Equal(deleteRequeueAfter))
+	g.Expect(exists(external("controlplane.cluster.x-k8s.io/v1alpha3", "ControlPlaneConfig", ""), "test-control-plane")).To(BeFalse())
+	g.Expect(exists(external("infrastructure.cluster.x-k8s.io/v1alpha3", "InfrastructureConfig", ""), "test-infrastructure")).To(BeTrue())
+
+	// Then the infrastructure.
+	_, err = r.reconcileDelete(ctx, cluster)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(exists(external("infrastructure.cluster.x-k8s.io/v1alpha3", "InfrastructureConfig", ""), "test-infrastructure")).To(BeFalse())
+	g.Expect(cluster.Finalizers).To(ContainElement(clusterv1.ClusterFinalizer))
+
+	// Finally the finalizer is removed.
+	_, err = r.reconcileDelete(ctx, cluster)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(cluster.Finalizers).NotTo(ContainElement(clusterv1.ClusterFinalizer))
+}
+
+func TestClusterReconcilerDeleteExternallyManagedInfrastructure(t *testing.T) {
+	g := NewWithT(t)
+	g.Expect(clusterv1.AddToScheme(scheme.Scheme)).To(Succeed())
+
+	cluster := &clusterv1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       "test-cluster",
+			Namespace:  "test-namespace",
+			Finalizers: []string{clusterv1.ClusterFinalizer},
+		},
+		Spec: clusterv1.ClusterSpec{
+			InfrastructureRef: &corev1.ObjectReference{
+				APIVersion: "infrastructure.cluster.x-k8s.io/v1alpha3",
+				Kind:       "InfrastructureConfig",
+				Name:       "test-infrastructure",
+			},
+		},
+	}
+	infrastructure := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "infrastructure.cluster.x-k8s.io/v1alpha3",
+		"kind":       "InfrastructureConfig",
+		"metadata": map[string]interface{}{
+			"name":      "test-infrastructure",
+			"namespace": "test-namespace",
+			"annotations": map[string]interface{}{
+				clusterv1.ManagedByAnnotation: "test",
+			},
+		},
+	}}
+
+	c := fake.NewFakeClientWithScheme(scheme.Scheme, cluster, infrastructure)
+	r := &ClusterReconciler{
+		Client: c,
+		Log:    log.Log,
+	}
+
+	_, err := r.reconcileDelete(ctx, cluster)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(cluster.Finalizers).NotTo(ContainElement(clusterv1.ClusterFinalizer))
+
+	// The externally managed infrastructure is not deleted.
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion("infrastructure.cluster.x-k8s.io/v1alpha3")
+	obj.SetKind("InfrastructureConfig")
+	g.Expect(c.Get(ctx, client.ObjectKey{Namespace: "test-namespace", Name: "test-infrastructure"}, obj)).To(Succeed())
+}
+
+func TestClusterReconcilerReconcileReadyTime(t *testing.T) {
+	g := NewWithT(t)
+
+	r := &ClusterReconciler{
+		Log: log.Log,
+	}
+	cluster := &clusterv1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-cluster",
+			Namespace: "test-namespace",
+		},
+	}
+
+	// The ready time is not set while the Cluster is not Ready.
+	conditions.MarkFalse(cluster, clusterv1.ReadyCondition, "Reason", clusterv1.ConditionSeverityInfo, "")
+	r.reconcileReadyTime(ctx, cluster)
+	g.Expect(cluster.Status.ReadyTime).To(BeNil())
+
+	// The ready time is set to the time the Cluster became Ready.
+	conditions.MarkTrue(cluster, clusterv1.ReadyCondition)
+	r.reconcileReadyTime(ctx, cluster)
+	g.Expect(cluster.Status.ReadyTime).NotTo(BeNil())
+	g.Expect(*cluster.Status.ReadyTime).To(Equal(conditions.Get(cluster, clusterv1.ReadyCondition).LastTransitionTime))
+
+	// The ready time is not changed when the Cluster becomes Ready again.
+	readyTime := *cluster.Status.ReadyTime
+	conditions.MarkFalse(cluster, clusterv1.ReadyCondition, "Reason", clusterv1.ConditionSeverityInfo, "")
+	conditions.MarkTrue(cluster, clusterv1.ReadyCondition)
+	r.reconcileReadyTime(ctx, cluster)
+	g.Expect(*cluster.Status.ReadyTime).To(Equal(readyTime))
+}
+
 func TestReconcileControlPlaneInitializedControlPlaneRef(t *testing.T) {
 	g := NewWithT(t)
 
@@ -0,0 +1,543 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/go-logr/logr"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	kerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/client-go/util/retry"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/controllers/remote"
+	"sigs.k8s.io/cluster-api/util"
+	"sigs.k8s.io/cluster-api/util/patch"
+	utilyaml "sigs.k8s.io/cluster-api/util/yaml"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+// +kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;watch
+// +kubebuilder:rbac:groups=core,resources=configmaps,verbs=get;list;watch
+// +kubebuilder:rbac:groups=cluster.x-k8s.io,resources=clusterresourcesets;clusterresourcesets/status,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=cluster.x-k8s.io,resources=clusterresourcesetbindings,verbs=get;list;watch;create;update;patch;delete
+
+// ClusterResourceSetReconciler reconciles a ClusterResourceSet object
+type ClusterResourceSetReconciler struct {
+	Client client.Client
+	Log    logr.Logger
+
+	scheme             *runtime.Scheme
+	remoteClientGetter remote.ClusterClientGetter
+}
+
+func (r *ClusterResourceSetReconciler) SetupWithManager(mgr ctrl.Manager, options controller.Options) error {
+	err := ctrl.NewControllerManagedBy(mgr).
+		For(&clusterv1.ClusterResourceSet{}).
+		Watches(
+			&source.Kind{Type: &clusterv1.Cluster{}},
+			&handler.EnqueueRequestsFromMapFunc{ToRequests: handler.ToRequestsFunc(r.clusterToClusterResourceSet)},
+		).
+		Watches(
+			&source.Kind{Type: &corev1.ConfigMap{}},
+			&handler.EnqueueRequestsFromMapFunc{ToRequests: handler.ToRequestsFunc(r.resourceToClusterResourceSet)},
+		).
+		Watches(
+			&source.Kind{Type: &corev1.Secret{}},
+			&handler.EnqueueRequestsFromMapFunc{ToRequests: handler.ToRequestsFunc(r.resourceToClusterResourceSet)},
+		).
+		WithOptions(options).
+		Complete(r)
+
+	if err != nil {
+		return errors.Wrap(err, "failed setting up with a controller manager")
+	}
+
+	r.scheme = mgr.GetScheme()
+	if r.remoteClientGetter == nil {
+		r.remoteClientGetter = remote.NewClusterClient
+	}
+	return nil
+}
+
+func (r *ClusterResourceSetReconciler) Reconcile(req ctrl.Request) (_ ctrl.Result, reterr error) {
+	ctx := context.Background()
+	logger := r.Log.WithValues("clusterresourceset", req.Name, "namespace", req.Namespace)
+
+	// Fetch the ClusterResourceSet instance.
+	clusterResourceSet := &clusterv1.ClusterResourceSet{}
+	if err := r.Client.Get(ctx, req.NamespacedName, clusterResourceSet); err != nil {
+		if apierrors.IsNotFound(err) {
+			// Object not found, return. Created objects are automatically garbage collected.
+			// For additional cleanup logic use finalizers.
+			return ctrl.Result{}, nil
+		}
+		// Error reading the object - requeue the request.
+		return ctrl.Result{}, err
+	}
+
+	// Initialize the patch helper.
+	patchHelper, err := patch.NewHelper(clusterResourceSet, r.Client)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	defer func() {
+		// Always attempt to Patch the ClusterResourceSet object and status after each reconciliation.
+		clusterResourceSet.Status.ObservedGeneration = clusterResourceSet.Generation
+		if err := patchHelper.Patch(ctx, clusterResourceSet); err != nil {
+			reterr = kerrors.NewAggregate([]error{reterr, err})
+		}
+	}()
+
+	// Handle deletion reconciliation loop.
+	if !clusterResourceSet.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, r.reconcileDelete(ctx, clusterResourceSet)
+	}
+
+	// Add the finalizer first if not exist to avoid the race condition between init and delete.
+	if !util.Contains(clusterResourceSet.Finalizers, clusterv1.ClusterResourceSetFinalizer) {
+		controllerutil.AddFinalizer(clusterResourceSet, clusterv1.ClusterResourceSetFinalizer)
+		return ctrl.Result{}, nil
+	}
+
+	clusters, err := r.getClustersByClusterResourceSetSelector(ctx, clusterResourceSet)
+	if err != nil {
+		logger.Error(err, "Failed fetching clusters that matches ClusterResourceSet labels", "ClusterResourceSet", clusterResourceSet.Name)
+		return ctrl.Result{}, err
+	}
+
+	var errs []error
+	for _, cluster := range clusters {
+		if err := r.ApplyClusterResourceSet(ctx, cluster, clusterResourceSet); err != nil {
+			logger.Error(err, "Failed applying ClusterResourceSet to cluster", "cluster", cluster.Name)
+			errs = append(errs, err)
+		}
+	}
+
+	return ctrl.Result{}, kerrors.NewAggregate(errs)
+}
+
+// reconcileDelete removes the deleted ClusterResourceSet from all the ClusterResourceSetBindings it is added to.
+// The resources that were applied to the workload clusters are not deleted.
+func (r *ClusterResourceSetReconciler) reconcileDelete(ctx context.Context, crs *clusterv1.ClusterResourceSet) error {
+	bindings := &clusterv1.ClusterResourceSetBindingList{}
+	if err := r.Client.List(ctx, bindings, client.InNamespace(crs.Namespace)); err != nil {
+		return errors.Wrapf(err, "failed to list ClusterResourceSetBindings in namespace %q", crs.Namespace)
+	}
+
+	var errs []error
+	for i := range bindings.Items {
+		binding := &bindings.Items[i]
+
+		found := false
+		for _, b := range binding.Spec.Bindings {
+			if b.ClusterResourceSetName == crs.Name {
+				found = true
+				break
+			}
+		}
+		if !found {
+			continue
+		}
+
+		key := client.ObjectKey{Namespace: binding.Namespace, Name: binding.Name}
+		err := retry.RetryOnConflict(retry.DefaultBackoff, func() error {
+			binding := &clusterv1.ClusterResourceSetBinding{}
+			if err := r.Client.Get(ctx, key, binding); err != nil {
+				return err
+			}
+			base := binding.DeepCopy()
+			binding.DeleteBinding(crs)
+
+			// If the binding is not tracking any ClusterResourceSet anymore, delete it, unless it was changed in the meantime.
+			if len(binding.Spec.Bindings) == 0 {
+				return r.Client.Delete(ctx, binding, client.Preconditions{ResourceVersion: &base.ResourceVersion})
+			}
+			return patchClusterResourceSetBinding(ctx, r.Client, base, binding)
+		})
+		if err != nil && !apierrors.IsNotFound(err) {
+			errs = append(errs, errors.Wrapf(err, "failed to remove ClusterResourceSet %q from ClusterResourceSetBinding %q", crs.Name, binding.Name))
+		}
+	}
+
+	if len(errs) > 0 {
+		return kerrors.NewAggregate(errs)
+	}
+
+	controllerutil.RemoveFinalizer(crs, clusterv1.ClusterResourceSetFinalizer)
+	return nil
+}
+
+// getClustersByClusterResourceSetSelector fetches Clusters matched by the ClusterResourceSet's label selector that are in the same namespace as the ClusterResourceSet object.
+func (r *ClusterResourceSetReconciler) getClustersByClusterResourceSetSelector(ctx context.Context, clusterResourceSet *clusterv1.ClusterResourceSet) ([]*clusterv1.Cluster, error) {
+	selector, err := metav1.LabelSelectorAsSelector(&clusterResourceSet.Spec.ClusterSelector)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to build selector")
+	}
+
+	// If a ClusterResourceSet has a nil or empty selector, it should match nothing, not everything.
+	if selector.Empty() {
+		return nil, nil
+	}
+
+	clusterList := &clusterv1.ClusterList{}
+	if err := r.Client.List(ctx, clusterList, client.InNamespace(clusterResourceSet.Namespace), client.MatchingLabelsSelector{Selector: selector}); err != nil {
+		return nil, errors.Wrap(err, "failed to list clusters")
+	}
+
+	clusters := []*clusterv1.Cluster{}
+	for i := range clusterList.Items {
+		c := &clusterList.Items[i]
+		if c.DeletionTimestamp.IsZero() {
+			clusters = append(clusters, c)
+		}
+	}
+	return clusters, nil
+}
+
+// ApplyClusterResourceSet applies resources in a ClusterResourceSet to a Cluster. Once applied, a record will be added to the
+// cluster's ClusterResourceSetBinding.
+// In ApplyOnce strategy, resources are applied only once to a particular cluster. ClusterResourceSetBinding is used to check if a resource is applied before.
+// In Reconcile strategy, resources are re-applied to a particular cluster when their hash changes.
+func (r *ClusterResourceSetReconciler) ApplyClusterResourceSet(ctx context.Context, cluster *clusterv1.Cluster, clusterResourceSet *clusterv1.ClusterResourceSet) error {
+	logger := r.Log.WithValues("clusterresourceset", clusterResourceSet.Name, "namespace", clusterResourceSet.Namespace, "cluster", cluster.Name)
+
+	// Return early if the object or Cluster is paused.
+	if util.IsPaused(cluster, clusterResourceSet) {
+		logger.V(3).Info("reconciliation is paused for this cluster")
+		return nil
+	}
+
+	// Resources can be applied only once the control plane of the workload cluster is reachable.
+	if !cluster.Status.ControlPlaneInitialized {
+		logger.V(4).Info("Cluster control plane is not initialized yet, skipping")
+		return nil
+	}
+
+	clusterResourceSetBinding, err := r.getOrNewClusterResourceSetBinding(ctx, cluster)
+	if err != nil {
+		return err
+	}
+	resourceSetBinding := clusterResourceSetBinding.GetOrCreateBinding(clusterResourceSet)
+
+	var remoteClient client.Client
+	var resourceBindings []clusterv1.ResourceBinding
+	var errs []error
+	for _, resource := range clusterResourceSet.Spec.Resources {
+		// In ApplyOnce strategy, resources that are already applied are never updated.
+		if clusterResourceSet.Spec.Strategy != string(clusterv1.ClusterResourceSetStrategyReconcile) && resourceSetBinding.IsApplied(resource) {
+			continue
+		}
+
+		data, err := r.getResourceData(ctx, clusterResourceSet.Namespace, resource)
+		if err != nil {
+			if apierrors.IsNotFound(errors.Cause(err)) {
+				// The ClusterResourceSet is requeued as soon as the resource is created.
+				logger.Info("Resource referenced by the ClusterResourceSet does not exist yet", "kind", resource.Kind, "name", resource.Name)
+				continue
+			}
+			errs = append(errs, err)
+			continue
+		}
+
+		hash := computeHash(data)
+		if current := resourceSetBinding.GetResource(resource); current != nil && current.Applied && current.Hash == hash {
+			continue
+		}
+
+		if remoteClient == nil {
+			remoteClient, err = r.remoteClientGetter(ctx, r.Client, cluster, r.scheme)
+			if err != nil {
+				errs = append(errs, errors.Wrapf(err, "failed to create remote cluster client"))
+				break
+			}
+		}
+
+		applyErr := applyResourceData(ctx, remoteClient, data, clusterResourceSet.Spec.Strategy == string(clusterv1.ClusterResourceSetStrategyReconcile))
+		if applyErr != nil {
+			errs = append(errs, errors.Wrapf(applyErr, "failed to apply %s %q to cluster %q", resource.Kind, resource.Name, cluster.Name))
+		} else {
+			logger.Info("Applied resource to cluster", "kind", resource.Kind, "name", resource.Name)
+		}
+
+		now := metav1.Now()
+		resourceBindings = append(resourceBindings, clusterv1.ResourceBinding{
+			ResourceRef:     resource,
+			Hash:            hash,
+			LastAppliedTime: &now,
+			Applied:         applyErr == nil,
+		})
+	}
+
+	// Records the applied resources in the latest version of the ClusterResourceSetBinding, given that other
+	// ClusterResourceSets could have changed it while applying the resources.
+	err = retry.OnError(retry.DefaultBackoff, isConflictOrAlreadyExists, func() error {
+		clusterResourceSetBinding, err := r.getOrNewClusterResourceSetBinding(ctx, cluster)
+		if err != nil {
+			return err
+		}
+		base := clusterResourceSetBinding.DeepCopy()
+
+		resourceSetBinding := clusterResourceSetBinding.GetOrCreateBinding(clusterResourceSet)
+		for _, resourceBinding := range resourceBindings {
+			resourceSetBinding.SetBinding(resourceBinding)
+		}
+
+		if clusterResourceSetBinding.ResourceVersion == "" {
+			return r.Client.Create(ctx, clusterResourceSetBinding)
+		}
+		return patchClusterResourceSetBinding(ctx, r.Client, base, clusterResourceSetBinding)
+	})
+	if err != nil {
+		errs = append(errs, errors.Wrapf(err, "failed to update ClusterResourceSetBinding for cluster %q", cluster.Name))
+	}
+
+	return kerrors.NewAggregate(errs)
+}
+
+// isConflictOrAlreadyExists returns true if the ClusterResourceSetBinding was changed or created by someone else
+// while trying to update or create it.
+func isConflictOrAlreadyExists(err error) bool {
+	return apierrors.IsConflict(err) || apierrors.IsAlreadyExists(err)
+}
+
+// patchClusterResourceSetBinding patches a ClusterResourceSetBinding with the changes from base, unless it was changed
+// in the meantime: many ClusterResourceSets can update the same ClusterResourceSetBinding concurrently, and a merge
+// patch replaces the whole list of bindings, so the patch includes the resourceVersion of base as a precondition
+// (optimistic lock) and it fails with a conflict if the ClusterResourceSetBinding is not at that version anymore.
+func patchClusterResourceSetBinding(ctx context.Context, c client.Client, base, binding *clusterv1.ClusterResourceSetBinding) error {
+	if reflect.DeepEqual(base.Spec, binding.Spec) {
+		return nil
+	}
+
+	// Clearing the resourceVersion in the base object includes the current resourceVersion in the patch.
+	lockedBase := base.DeepCopy()
+	lockedBase.ResourceVersion = ""
+	return c.Patch(ctx, binding, client.MergeFrom(lockedBase))
+}
+
+// getOrNewClusterResourceSetBinding retrieves the ClusterResourceSetBinding of the cluster, if any;
+// otherwise it returns a new ClusterResourceSetBinding, without resourceVersion, that should be created.
+func (r *ClusterResourceSetReconciler) getOrNewClusterResourceSetBinding(ctx context.Context, cluster *clusterv1.Cluster) (*clusterv1.ClusterResourceSetBinding, error) {
+	clusterResourceSetBinding := &clusterv1.ClusterResourceSetBinding{}
+	key := client.ObjectKey{Namespace: cluster.Namespace, Name: cluster.Name}
+	if err := r.Client.Get(ctx, key, clusterResourceSetBinding); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return nil, errors.Wrapf(err, "failed to get ClusterResourceSetBinding for cluster %q", cluster.Name)
+		}
+
+		clusterResourceSetBinding = &clusterv1.ClusterResourceSetBinding{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      cluster.Name,
+				Namespace: cluster.Namespace,
+				OwnerReferences: []metav1.OwnerReference{
+					{
+						APIVersion: clusterv1.GroupVersion.String(),
+						Kind:       "Cluster",
+						Name:       cluster.Name,
+						UID:        cluster.UID,
+					},
+				},
+			},
+		}
+		return clusterResourceSetBinding, nil
+	}
+	return clusterResourceSetBinding, nil
+}
+
+// getResourceData returns the manifests stored in a ClusterResourceSet resource, sorted by key.
+func (r *ClusterResourceSetReconciler) getResourceData(ctx context.Context, namespace string, resource clusterv1.ResourceRef) ([][]byte, error) {
+	key := client.ObjectKey{Namespace: namespace, Name: resource.Name}
+	data := map[string][]byte{}
+
+	switch resource.Kind {
+	case clusterv1.ConfigMapClusterResourceSetResourceKind:
+		configMap := &corev1.ConfigMap{}
+		if err := r.Client.Get(ctx, key, configMap); err != nil {
+			return nil, errors.Wrapf(err, "failed to get ConfigMap %q", resource.Name)
+		}
+		for k, v := range configMap.Data {
+			data[k] = []byte(v)
+		}
+	case clusterv1.SecretClusterResourceSetResourceKind:
+		secret := &corev1.Secret{}
+		if err := r.Client.Get(ctx, key, secret); err != nil {
+			return nil, errors.Wrapf(err, "failed to get Secret %q", resource.Name)
+		}
+		if secret.Type != clusterv1.ClusterResourceSetSecretType {
+			return nil, errors.Errorf("invalid type for Secret %q: only %q is supported", resource.Name, clusterv1.ClusterResourceSetSecretType)
+		}
+		data = secret.Data
+	default:
+		return nil, errors.Errorf("unsupported resource kind %q", resource.Kind)
+	}
+
+	keys := make([]string, 0, len(data))
+	for k := range data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	ret := make([][]byte, 0, len(keys))
+	for _, k := range keys {
+		ret = append(ret, data[k])
+	}
+	return ret, nil
+}
+
+// computeHash returns the hash of the manifests stored in a ClusterResourceSet resource.
+func computeHash(data [][]byte) string {
+	hash := sha256.New()
+	for _, d := range data {
+		_, _ = hash.Write(d)
+	}
+	return fmt.Sprintf("sha256:%x", hash.Sum(nil))
+}
+
+// applyResourceData creates the objects defined in the manifests into the workload cluster;
+// if update is true, objects that already exist are updated too.
+func applyResourceData(ctx context.Context, c client.Client, data [][]byte, update bool) error {
+	var errs []error
+	for _, d := range data {
+		objs, err := utilyaml.ToUnstructured(d)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		for i := range objs {
+			if err := applyUnstructured(ctx, c, &objs[i], update); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return kerrors.NewAggregate(errs)
+}
+
+func applyUnstructured(ctx context.Context, c client.Client, obj *unstructured.Unstructured, update bool) error {
+	if err := c.Create(ctx, obj.DeepCopy()); err != nil {
+		if !apierrors.IsAlreadyExists(err) {
+			return errors.Wrapf(err, "failed to create %s %q", obj.GroupVersionKind(), obj.GetName())
+		}
+		if !update {
+			return nil
+		}
+
+		current := &unstructured.Unstructured{}
+		current.SetGroupVersionKind(obj.GroupVersionKind())
+		if err := c.Get(ctx, client.ObjectKey{Namespace: obj.GetNamespace(), Name: obj.GetName()}, current); err != nil {
+			return errors.Wrapf(err, "failed to get %s %q", obj.GroupVersionKind(), obj.GetName())
+		}
+		obj.SetResourceVersion(current.GetResourceVersion())
+		if err := c.Update(ctx, obj); err != nil {
+			return errors.Wrapf(err, "failed to update %s %q", obj.GroupVersionKind(), obj.GetName())
+		}
+	}
+	return nil
+}
+
+// clusterToClusterResourceSet is mapper function that maps clusters to ClusterResourceSet
+func (r *ClusterResourceSetReconciler) clusterToClusterResourceSet(o handler.MapObject) []ctrl.Request {
+	result := []ctrl.Request{}
+
+	cluster, ok := o.Object.(*clusterv1.Cluster)
+	if !ok {
+		r.Log.Error(errors.Errorf("expected a Cluster but got a %T", o.Object), "failed to get ClusterResourceSet for Cluster")
+		return nil
+	}
+
+	resourceList := &clusterv1.ClusterResourceSetList{}
+	if err := r.Client.List(context.Background(), resourceList, client.InNamespace(cluster.Namespace)); err != nil {
+		r.Log.Error(err, "failed to list ClusterResourceSet")
+		return nil
+	}
+
+	clusterLabels := labels.Set(cluster.GetLabels())
+	for i := range resourceList.Items {
+		rs := &resourceList.Items[i]
+
+		selector, err := metav1.LabelSelectorAsSelector(&rs.Spec.ClusterSelector)
+		if err != nil {
+			r.Log.Error(err, "unable to convert ClusterSelector to selector")
+			return nil
+		}
+
+		// If a ClusterResourceSet with a nil or empty selector creeps in, it should match nothing, not everything.
+		if selector.Empty() {
+			continue
+		}
+
+		if !selector.Matches(clusterLabels) {
+			continue
+		}
+
+		name := client.ObjectKey{Namespace: rs.Namespace, Name: rs.Name}
+		result = append(result, ctrl.Request{NamespacedName: name})
+	}
+
+	return result
+}
+
+// resourceToClusterResourceSet is mapper function that maps ConfigMaps and Secrets to the ClusterResourceSets referencing them.
+func (r *ClusterResourceSetReconciler) resourceToClusterResourceSet(o handler.MapObject) []ctrl.Request {
+	result := []ctrl.Request{}
+
+	var kind clusterv1.ClusterResourceSetResourceKind
+	switch o.Object.(type) {
+	case *corev1.ConfigMap:
+		kind = clusterv1.ConfigMapClusterResourceSetResourceKind
+	case *corev1.Secret:
+		kind = clusterv1.SecretClusterResourceSetResourceKind
+	default:
+		return nil
+	}
+
+	resourceList := &clusterv1.ClusterResourceSetList{}
+	if err := r.Client.List(context.Background(), resourceList, client.InNamespace(o.Meta.GetNamespace())); err != nil {
+		r.Log.Error(err, "failed to list ClusterResourceSet")
+		return nil
+	}
+
+	for i := range resourceList.Items {
+		rs := &resourceList.Items[i]
+		for _, resource := range rs.Spec.Resources {
+			if resource.Kind == kind && resource.Name == o.Meta.GetName() {
+				name := client.ObjectKey{Namespace: rs.Namespace, Name: rs.Name}
+				result = append(result, ctrl.Request{NamespacedName: name})
+				break
+			}
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,328 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	fakeremote "sigs.k8s.io/cluster-api/controllers/remote/fake"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const addonConfigMap = `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: %s
+  namespace: default
+data:
+  key: %s
+`
+
+func newClusterResourceSetTestObjects(strategy clusterv1.ClusterResourceSetStrategy) (*clusterv1.Cluster, *clusterv1.ClusterResourceSet, *corev1.ConfigMap, *corev1.Secret) {
+	cluster := &clusterv1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-cluster",
+			Namespace: "default",
+			Labels:    map[string]string{"cni": "calico"},
+		},
+		Status: clusterv1.ClusterStatus{
+			ControlPlaneInitialized: true,
+		},
+	}
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "addon-configmap",
+			Namespace: "default",
+		},
+		Data: map[string]string{
+			"addon.yaml": fmt.Sprintf(addonConfigMap, "applied-from-configmap", "v1"),
+		},
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "addon-secret",
+			Namespace: "default",
+		},
+		Type: clusterv1.ClusterResourceSetSecretType,
+		Data: map[string][]byte{
+			"addon.yaml": []byte(fmt.Sprintf(addonConfigMap, "applied-from-secret", "v1")),
+		},
+	}
+	crs := &clusterv1.ClusterResourceSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       "test-crs",
+			Namespace:  "default",
+			Finalizers: []string{clusterv1.ClusterResourceSetFinalizer},
+		},
+		Spec: clusterv1.ClusterResourceSetSpec{
+			ClusterSelector: metav1.LabelSelector{MatchLabels: map[string]string{"cni": "calico"}},
+			Resources: []clusterv1.ResourceRef{
+				{Name: configMap.Name, Kind: clusterv1.ConfigMapClusterResourceSetResourceKind},
+				{Name: secret.Name, Kind: clusterv1.SecretClusterResourceSetResourceKind},
+			},
+			Strategy: string(strategy),
+		},
+	}
+	return cluster, crs, configMap, secret
+}
+
+func newClusterResourceSetReconciler(objs ...runtime.Object) *ClusterResourceSetReconciler {
+	return &ClusterResourceSetReconciler{
+		Client:             fake.NewFakeClientWithScheme(scheme.Scheme, objs...),
+		Log:                log.Log,
+		scheme:             scheme.Scheme,
+		remoteClientGetter: fakeremote.NewClusterClient,
+	}
+}
+
+func TestClusterResourceSetReconcile(t *testing.T) {
+	tests := []struct {
+		name          string
+		strategy      clusterv1.ClusterResourceSetStrategy
+		expectUpdated bool
+	}{
+		{
+			name:          "ApplyOnce does not update resources already applied",
+			strategy:      clusterv1.ClusterResourceSetStrategyApplyOnce,
+			expectUpdated: false,
+		},
+		{
+			name:          "Reconcile updates resources when their content changes",
+			strategy:      clusterv1.ClusterResourceSetStrategyReconcile,
+			expectUpdated: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+			g.Expect(clusterv1.AddToScheme(scheme.Scheme)).To(Succeed())
+
+			cluster, crs, configMap, secret := newClusterResourceSetTestObjects(tt.strategy)
+			r := newClusterResourceSetReconciler(cluster, crs, configMap, secret)
+			request := ctrl.Request{NamespacedName: client.ObjectKey{Namespace: crs.Namespace, Name: crs.Name}}
+
+			_, err := r.Reconcile(request)
+			g.Expect(err).NotTo(HaveOccurred())
+
+			// The resources are applied to the cluster.
+			applied := &corev1.ConfigMap{}
+			g.Expect(r.Client.Get(context.Background(), client.ObjectKey{Namespace: "default", Name: "applied-from-configmap"}, applied)).To(Succeed())
+			g.Expect(applied.Data["key"]).To(Equal("v1"))
+			g.Expect(r.Client.Get(context.Background(), client.ObjectKey{Namespace: "default", Name: "applied-from-secret"}, &corev1.ConfigMap{})).To(Succeed())
+
+			// The binding tracks the applied resources.
+			binding := &clusterv1.ClusterResourceSetBinding{}
+			g.Expect(r.Client.Get(context.Background(), client.ObjectKey{Namespace: cluster.Namespace, Name: cluster.Name}, binding)).To(Succeed())
+			g.Expect(binding.Spec.Bindings).To(HaveLen(1))
+			g.Expect(binding.Spec.Bindings[0].ClusterResourceSetName).To(Equal(crs.Name))
+			g.Expect(binding.Spec.Bindings[0].Resources).To(HaveLen(2))
+			for _, resource := range binding.Spec.Bindings[0].Resources {
+				g.Expect(resource.Applied).To(BeTrue())
+				g.Expect(resource.Hash).NotTo(BeEmpty())
+			}
+
+			// Change the content of the ConfigMap referenced by the ClusterResourceSet.
+			g.Expect(r.Client.Get(context.Background(), client.ObjectKey{Namespace: configMap.Namespace, Name: configMap.Name}, configMap)).To(Succeed())
+			configMap.Data["addon.yaml"] = fmt.Sprintf(addonConfigMap, "applied-from-configmap", "v2")
+			g.Expect(r.Client.Update(context.Background(), configMap)).To(Succeed())
+
+			_, err = r.Reconcile(request)
+			g.Expect(err).NotTo(HaveOccurred())
+
+			g.Expect(r.Client.Get(context.Background(), client.ObjectKey{Namespace: "default", Name: "applied-from-configmap"}, applied)).To(Succeed())
+			if tt.expectUpdated {
+				g.Expect(applied.Data["key"]).To(Equal("v2"))
+			} else {
+				g.Expect(applied.Data["key"]).To(Equal("v1"))
+			}
+		})
+	}
+}
+
+func TestClusterResourceSetReconcileSkipsClustersNotInitialized(t *testing.T) {
+	g := NewWithT(t)
+	g.Expect(clusterv1.AddToScheme(scheme.Scheme)).To(Succeed())
+
+	cluster, crs, configMap, secret := newClusterResourceSetTestObjects(clusterv1.ClusterResourceSetStrategyApplyOnce)
+	cluster.Status.ControlPlaneInitialized = false
+	r := newClusterResourceSetReconciler(cluster, crs, configMap, secret)
+
+	_, err := r.Reconcile(ctrl.Request{NamespacedName: client.ObjectKey{Namespace: crs.Namespace, Name: crs.Name}})
+	g.Expect(err).NotTo(HaveOccurred())
+
+	err = r.Client.Get(context.Background(), client.ObjectKey{Namespace: cluster.Namespace, Name: cluster.Name}, &clusterv1.ClusterResourceSetBinding{})
+	g.Expect(apierrors.IsNotFound(err)).To(BeTrue())
+}
+
+func TestClusterResourceSetReconcileInvalidSecretType(t *testing.T) {
+	g := NewWithT(t)
+	g.Expect(clusterv1.AddToScheme(scheme.Scheme)).To(Succeed())
+
+	cluster, crs, configMap, secret := newClusterResourceSetTestObjects(clusterv1.ClusterResourceSetStrategyApplyOnce)
+	secret.Type = corev1.SecretTypeOpaque
+	r := newClusterResourceSetReconciler(cluster, crs, configMap, secret)
+
+	_, err := r.Reconcile(ctrl.Request{NamespacedName: client.ObjectKey{Namespace: crs.Namespace, Name: crs.Name}})
+	g.Expect(err).To(HaveOccurred())
+
+	// Resources from valid sources are applied anyway.
+	g.Expect(r.Client.Get(context.Background(), client.ObjectKey{Namespace: "default", Name: "applied-from-configmap"}, &corev1.ConfigMap{})).To(Succeed())
+	err = r.Client.Get(context.Background(), client.ObjectKey{Namespace: "default", Name: "applied-from-secret"}, &corev1.ConfigMap{})
+	g.Expect(apierrors.IsNotFound(err)).To(BeTrue())
+}
+
+func TestClusterResourceSetReconcileDelete(t *testing.T) {
+	g := NewWithT(t)
+	g.Expect(clusterv1.AddToScheme(scheme.Scheme)).To(Succeed())
+
+	cluster, crs, _, _ := newClusterResourceSetTestObjects(clusterv1.ClusterResourceSetStrategyApplyOnce)
+	now := metav1.Now()
+	crs.DeletionTimestamp = &now
+
+	otherBinding := clusterv1.ResourceSetBinding{ClusterResourceSetName: "other-crs"}
+	binding := &clusterv1.ClusterResourceSetBinding{
+		ObjectMeta: metav1.ObjectMeta{Name: cluster.Name, Namespace: cluster.Namespace},
+		Spec: clusterv1.ClusterResourceSetBindingSpec{
+			Bindings: []clusterv1.ResourceSetBinding{{ClusterResourceSetName: crs.Name}, otherBinding},
+		},
+	}
+	onlyBinding := &clusterv1.ClusterResourceSetBinding{
+		ObjectMeta: metav1.ObjectMeta{Name: "other-cluster", Namespace: cluster.Namespace},
+		Spec: clusterv1.ClusterResourceSetBindingSpec{
+			Bindings: []clusterv1.ResourceSetBinding{{ClusterResourceSetName: crs.Name}},
+		},
+	}
+	r := newClusterResourceSetReconciler(cluster, crs, binding, onlyBinding)
+
+	_, err := r.Reconcile(ctrl.Request{NamespacedName: client.ObjectKey{Namespace: crs.Namespace, Name: crs.Name}})
+	g.Expect(err).NotTo(HaveOccurred())
+
+	// The ClusterResourceSet is removed from the bindings, and empty bindings are deleted.
+	g.Expect(r.Client.Get(context.Background(), client.ObjectKey{Namespace: binding.Namespace, Name: binding.Name}, binding)).To(Succeed())
+	g.Expect(binding.Spec.Bindings).To(ConsistOf(otherBinding))
+	err = r.Client.Get(context.Background(), client.ObjectKey{Namespace: onlyBinding.Namespace, Name: onlyBinding.Name}, onlyBinding)
+	g.Expect(apierrors.IsNotFound(err)).To(BeTrue())
+
+	g.Expect(r.Client.Get(context.Background(), client.ObjectKey{Namespace: crs.Namespace, Name: crs.Name}, crs)).To(Succeed())
+	g.Expect(crs.Finalizers).NotTo(ContainElement(clusterv1.ClusterResourceSetFinalizer))
+}
+
+// conflictingBindingClient simulates another ClusterResourceSet updating the ClusterResourceSetBinding while it is
+// being patched: the first patch of a ClusterResourceSetBinding fails with a conflict after the binding is changed.
+type conflictingBindingClient struct {
+	client.Client
+	conflicts int
+	patches   []string
+}
+
+func (c *conflictingBindingClient) Patch(ctx context.Context, obj runtime.Object, patch client.Patch, opts ...client.PatchOption) error {
+	binding, ok := obj.(*clusterv1.ClusterResourceSetBinding)
+	if !ok {
+		return c.Client.Patch(ctx, obj, patch, opts...)
+	}
+
+	data, err := patch.Data(obj)
+	if err != nil {
+		return err
+	}
+	c.patches = append(c.patches, string(data))
+
+	if c.conflicts == 0 {
+		c.conflicts++
+		current := &clusterv1.ClusterResourceSetBinding{}
+		if err := c.Client.Get(ctx, client.ObjectKey{Namespace: binding.Namespace, Name: binding.Name}, current); err != nil {
+			return err
+		}
+		current.Spec.Bindings = append(current.Spec.Bindings, clusterv1.ResourceSetBinding{ClusterResourceSetName: "concurrent-crs"})
+		if err := c.Client.Update(ctx, current); err != nil {
+			return err
+		}
+		return apierrors.NewConflict(clusterv1.GroupVersion.WithResource("clusterresourcesetbindings").GroupResource(), binding.Name, errors.New("the object has been modified"))
+	}
+	return c.Client.Patch(ctx, obj, patch, opts...)
+}
+
+func TestClusterResourceSetReconcileRetriesOnBindingConflict(t *testing.T) {
+	g := NewWithT(t)
+	g.Expect(clusterv1.AddToScheme(scheme.Scheme)).To(Succeed())
+
+	cluster, crs, configMap, secret := newClusterResourceSetTestObjects(clusterv1.ClusterResourceSetStrategyApplyOnce)
+	binding := &clusterv1.ClusterResourceSetBinding{
+		ObjectMeta: metav1.ObjectMeta{Name: cluster.Name, Namespace: cluster.Namespace, ResourceVersion: "1"},
+		Spec: clusterv1.ClusterResourceSetBindingSpec{
+			Bindings: []clusterv1.ResourceSetBinding{{ClusterResourceSetName: "other-crs"}},
+		},
+	}
+	r := newClusterResourceSetReconciler(cluster, crs, configMap, secret, binding)
+	c := &conflictingBindingClient{Client: r.Client}
+	r.Client = c
+
+	_, err := r.Reconcile(ctrl.Request{NamespacedName: client.ObjectKey{Namespace: crs.Namespace, Name: crs.Name}})
+	g.Expect(err).NotTo(HaveOccurred())
+
+	// The patches are applied only to the expected version of the binding.
+	g.Expect(c.patches).To(HaveLen(2))
+	for _, p := range c.patches {
+		g.Expect(p).To(ContainSubstring(`"resourceVersion"`))
+	}
+
+	// The change done concurrently is preserved.
+	g.Expect(r.Client.Get(context.Background(), client.ObjectKey{Namespace: binding.Namespace, Name: binding.Name}, binding)).To(Succeed())
+	var names []string
+	for _, b := range binding.Spec.Bindings {
+		names = append(names, b.ClusterResourceSetName)
+	}
+	g.Expect(names).To(ConsistOf("other-crs", "concurrent-crs", crs.Name))
+}
+
+func TestClusterResourceSetMapFuncs(t *testing.T) {
+	g := NewWithT(t)
+	g.Expect(clusterv1.AddToScheme(scheme.Scheme)).To(Succeed())
+
+	cluster, crs, configMap, secret := newClusterResourceSetTestObjects(clusterv1.ClusterResourceSetStrategyApplyOnce)
+	otherCluster := cluster.DeepCopy()
+	otherCluster.Name = "other-cluster"
+	otherCluster.Labels = map[string]string{"cni": "flannel"}
+	otherConfigMap := configMap.DeepCopy()
+	otherConfigMap.Name = "other-configmap"
+
+	r := newClusterResourceSetReconciler(crs)
+	expected := []ctrl.Request{{NamespacedName: client.ObjectKey{Namespace: crs.Namespace, Name: crs.Name}}}
+
+	g.Expect(r.clusterToClusterResourceSet(handler.MapObject{Meta: cluster, Object: cluster})).To(Equal(expected))
+	g.Expect(r.clusterToClusterResourceSet(handler.MapObject{Meta: otherCluster, Object: otherCluster})).To(BeEmpty())
+
+	g.Expect(r.resourceToClusterResourceSet(handler.MapObject{Meta: configMap, Object: configMap})).To(Equal(expected))
+	g.Expect(r.resourceToClusterResourceSet(handler.MapObject{Meta: secret, Object: secret})).To(Equal(expected))
+	g.Expect(r.resourceToClusterResourceSet(handler.MapObject{Meta: otherConfigMap, Object: otherConfigMap})).To(BeEmpty())
+}
@@ -0,0 +1,588 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+
+	"github.com/go-logr/logr"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	kerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apiserver/pkg/storage/names"
+	"k8s.io/client-go/tools/record"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/controllers/external"
+	"sigs.k8s.io/cluster-api/util"
+	"sigs.k8s.io/cluster-api/util/patch"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+// topologyVariableRegex matches the occurrences of ${NAME} in the string values of the templates of a ClusterClass.
+var topologyVariableRegex = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// +kubebuilder:rbac:groups=cluster.x-k8s.io,resources=clusterclasses,verbs=get;list;watch
+// +kubebuilder:rbac:groups=cluster.x-k8s.io,resources=clusters;clusters/status,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=cluster.x-k8s.io,resources=machinedeployments;machinedeployments/status,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io;bootstrap.cluster.x-k8s.io;controlplane.cluster.x-k8s.io,resources=*,verbs=get;list;watch;create;update;patch;delete
+
+// ClusterTopologyReconciler reconciles the managed topology of a Cluster, creating and updating the infrastructure
+// cluster, the control plane and the MachineDeployments from the templates of the referenced ClusterClass.
+type ClusterTopologyReconciler struct {
+	Client client.Client
+	Log    logr.Logger
+
+	recorder record.EventRecorder
+}
+
+func (r *ClusterTopologyReconciler) SetupWithManager(mgr ctrl.Manager, options controller.Options) error {
+	err := ctrl.NewControllerManagedBy(mgr).
+		For(&clusterv1.Cluster{}).
+		Named("topology").
+		Watches(
+			&source.Kind{Type: &clusterv1.ClusterClass{}},
+			&handler.EnqueueRequestsFromMapFunc{ToRequests: handler.ToRequestsFunc(r.clusterClassToCluster)},
+		).
+		Watches(
+			&source.Kind{Type: &clusterv1.MachineDeployment{}},
+			&handler.EnqueueRequestsFromMapFunc{ToRequests: handler.ToRequestsFunc(r.machineDeploymentToCluster)},
+		).
+		WithOptions(options).
+		Complete(r)
+
+	if err != nil {
+		return errors.Wrap(err, "failed setting up with a controller manager")
+	}
+
+	r.recorder = mgr.GetEventRecorderFor("topology-controller")
+	return nil
+}
+
+func (r *ClusterTopologyReconciler) Reconcile(req ctrl.Request) (_ ctrl.Result, reterr error) {
+	ctx := context.Background()
+	logger := r.Log.WithValues("cluster", req.Name, "namespace", req.Namespace)
+
+	// Fetch the Cluster instance.
+	cluster := &clusterv1.Cluster{}
+	if err := r.Client.Get(ctx, req.NamespacedName, cluster); err != nil {
+		if apierrors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	// Only Clusters with a managed topology are reconciled; the objects of a deleted Cluster are deleted
+	// by the Cluster controller.
+	if cluster.Spec.Topology == nil || !cluster.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, nil
+	}
+
+	// Return early if the Cluster is paused.
+	if util.IsPaused(cluster, cluster) {
+		logger.V(3).Info("reconciliation is paused for this object")
+		return ctrl.Result{}, nil
+	}
+
+	// Initialize the patch helper.
+	patchHelper, err := patch.NewHelper(cluster, r.Client)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	defer func() {
+		// Always attempt to Patch the Cluster object, persisting the references to the objects created from the ClusterClass.
+		if err := patchHelper.Patch(ctx, cluster); err != nil {
+			reterr = kerrors.NewAggregate([]error{reterr, err})
+		}
+	}()
+
+	return ctrl.Result{}, r.reconcileTopology(ctx, cluster)
+}
+
+// ReconcileTopology reconciles the managed topology of a Cluster using the given client, without patching the Cluster.
+// It allows to compute the changes to the managed topology without applying them, e.g. by using a client backed by
+// a copy of the objects of the management cluster.
+func ReconcileTopology(ctx context.Context, c client.Client, cluster *clusterv1.Cluster) error {
+	r := &ClusterTopologyReconciler{Client: c, recorder: &record.FakeRecorder{}}
+	return r.reconcileTopology(ctx, cluster)
+}
+
+// reconcileTopology creates and updates the objects of the managed topology of the Cluster.
+func (r *ClusterTopologyReconciler) reconcileTopology(ctx context.Context, cluster *clusterv1.Cluster) error {
+	class := &clusterv1.ClusterClass{}
+	key := client.ObjectKey{Namespace: cluster.Namespace, Name: cluster.Spec.Topology.Class}
+	if err := r.Client.Get(ctx, key, class); err != nil {
+		return errors.Wrapf(err, "failed to get ClusterClass %q for Cluster %q", key.Name, cluster.Name)
+	}
+
+	variables, err := topologyVariables(class, cluster.Spec.Topology)
+	if err != nil {
+		return errors.Wrapf(err, "failed to resolve the variables of ClusterClass %q for Cluster %q", class.Name, cluster.Name)
+	}
+
+	errs := []error{}
+	if err := r.reconcileInfrastructureCluster(ctx, cluster, class, variables); err != nil {
+		errs = append(errs, err)
+	}
+	if err := r.reconcileControlPlane(ctx, cluster, class, variables); err != nil {
+		errs = append(errs, err)
+	}
+	if err := r.reconcileMachineDeployments(ctx, cluster, class, variables); err != nil {
+		errs = append(errs, err)
+	}
+	return kerrors.NewAggregate(errs)
+}
+
+// reconcileInfrastructureCluster creates the infrastructure cluster from the template of the ClusterClass.
+// The infrastructure cluster is not updated once created, because its spec is usually immutable or
+// set by the infrastructure provider (e.g. the control plane endpoint).
+func (r *ClusterTopologyReconciler) reconcileInfrastructureCluster(ctx context.Context, cluster *clusterv1.Cluster, class *clusterv1.ClusterClass, variables map[string]string) error {
+	if class.Spec.Infrastructure.Ref == nil || cluster.Spec.InfrastructureRef != nil {
+		return nil
+	}
+
+	desired, err := external.GenerateTemplate(ctx, &external.CloneTemplateInput{
+		Client:      r.Client,
+		TemplateRef: class.Spec.Infrastructure.Ref,
+		Namespace:   cluster.Namespace,
+		ClusterName: cluster.Name,
+		Labels:      topologyLabels(cluster),
+		Name:        cluster.Name,
+	})
+	if err != nil {
+		return errors.Wrapf(err, "failed to generate the infrastructure cluster for Cluster %q", cluster.Name)
+	}
+	substituteVariables(desired.Object, variables)
+
+	ref, err := r.createObject(ctx, cluster, desired)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create the infrastructure cluster for Cluster %q", cluster.Name)
+	}
+	cluster.Spec.InfrastructureRef = ref
+	return nil
+}
+
+// reconcileControlPlane creates the control plane from the template of the ClusterClass, and keeps its spec,
+// version and replicas in sync with the ClusterClass and the topology of the Cluster.
+func (r *ClusterTopologyReconciler) reconcileControlPlane(ctx context.Context, cluster *clusterv1.Cluster, class *clusterv1.ClusterClass, variables map[string]string) error {
+	topology := cluster.Spec.Topology
+
+	var current *unstructured.Unstructured
+	if cluster.Spec.ControlPlaneRef != nil {
+		var err error
+		current, err = external.Get(ctx, r.Client, cluster.Spec.ControlPlaneRef, cluster.Namespace)
+		if err != nil {
+			return errors.Wrapf(err, "failed to get the control plane for Cluster %q", cluster.Name)
+		}
+	}
+
+	desired, err := external.GenerateTemplate(ctx, &external.CloneTemplateInput{
+		Client:      r.Client,
+		TemplateRef: class.Spec.ControlPlane.Ref,
+		Namespace:   cluster.Namespace,
+		ClusterName: cluster.Name,
+		Labels:      mergeMaps(class.Spec.ControlPlane.Metadata.Labels, topology.ControlPlane.Metadata.Labels, topologyLabels(cluster)),
+		Name:        fmt.Sprintf("%s-control-plane", cluster.Name),
+	})
+	if err != nil {
+		return errors.Wrapf(err, "failed to generate the control plane for Cluster %q", cluster.Name)
+	}
+	substituteVariables(desired.Object, variables)
+	desired.SetAnnotations(mergeMaps(desired.GetAnnotations(), class.Spec.ControlPlane.Metadata.Annotations, topology.ControlPlane.Metadata.Annotations))
+
+	if err := unstructured.SetNestedField(desired.Object, topology.Version, "spec", "version"); err != nil {
+		return errors.Wrapf(err, "failed to set the version of the control plane for Cluster %q", cluster.Name)
+	}
+	if topology.ControlPlane.Replicas != nil {
+		if err := unstructured.SetNestedField(desired.Object, int64(*topology.ControlPlane.Replicas), "spec", "replicas"); err != nil {
+			return errors.Wrapf(err, "failed to set the replicas of the control plane for Cluster %q", cluster.Name)
+		}
+	}
+
+	if class.Spec.ControlPlane.MachineInfrastructure != nil {
+		var currentRef *corev1.ObjectReference
+		if current != nil {
+			currentRef = &corev1.ObjectReference{}
+			if err := util.UnstructuredUnmarshalField(current, currentRef, "spec", "infrastructureTemplate"); err != nil && err != util.ErrUnstructuredFieldNotFound {
+				return errors.Wrapf(err, "failed to get the infrastructure template of the control plane for Cluster %q", cluster.Name)
+			}
+		}
+		infrastructureRef, err := r.reconcileTemplate(ctx, cluster, class.Spec.ControlPlane.MachineInfrastructure.Ref, currentRef, desired.GetName(), topologyLabels(cluster), variables)
+		if err != nil {
+			return errors.Wrapf(err, "failed to reconcile the infrastructure template of the control plane for Cluster %q", cluster.Name)
+		}
+		if err := unstructured.SetNestedMap(desired.Object, map[string]interface{}{
+			"apiVersion": infrastructureRef.APIVersion,
+			"kind":       infrastructureRef.Kind,
+			"name":       infrastructureRef.Name,
+			"namespace":  infrastructureRef.Namespace,
+		}, "spec", "infrastructureTemplate"); err != nil {
+			return errors.Wrapf(err, "failed to set the infrastructure template of the control plane for Cluster %q", cluster.Name)
+		}
+	}
+
+	if current == nil {
+		ref, err := r.createObject(ctx, cluster, desired)
+		if err != nil {
+			return errors.Wrapf(err, "failed to create the control plane for Cluster %q", cluster.Name)
+		}
+		cluster.Spec.ControlPlaneRef = ref
+		return nil
+	}
+
+	patchHelper, err := patch.NewHelper(current, r.Client)
+	if err != nil {
+		return err
+	}
+	// Only the fields defined in the template (and the ones set from the topology) are updated, leaving
+	// any other field to the control plane provider.
+	desiredSpec, _, _ := unstructured.NestedMap(desired.Object, "spec")
+	for field, value := range desiredSpec {
+		if err := unstructured.SetNestedField(current.Object, value, "spec", field); err != nil {
+			return errors.Wrapf(err, "failed to set spec.%s of the control plane for Cluster %q", field, cluster.Name)
+		}
+	}
+	current.SetLabels(mergeMaps(current.GetLabels(), desired.GetLabels()))
+	current.SetAnnotations(mergeMaps(current.GetAnnotations(), desired.GetAnnotations()))
+	if err := patchHelper.Patch(ctx, current); err != nil {
+		return errors.Wrapf(err, "failed to update the control plane for Cluster %q", cluster.Name)
+	}
+	return nil
+}
+
+// reconcileMachineDeployments creates, updates and deletes the MachineDeployments of the Cluster, so they match the
+// worker pools of the topology.
+func (r *ClusterTopologyReconciler) reconcileMachineDeployments(ctx context.Context, cluster *clusterv1.Cluster, class *clusterv1.ClusterClass, variables map[string]string) error {
+	mdList := &clusterv1.MachineDeploymentList{}
+	if err := r.Client.List(ctx, mdList,
+		client.InNamespace(cluster.Namespace),
+		client.MatchingLabels{clusterv1.ClusterLabelName: cluster.Name},
+		client.HasLabels{clusterv1.ClusterTopologyOwnedLabel},
+	); err != nil {
+		return errors.Wrapf(err, "failed to list MachineDeployments for Cluster %q", cluster.Name)
+	}
+	current := map[string]*clusterv1.MachineDeployment{}
+	for i := range mdList.Items {
+		md := &mdList.Items[i]
+		current[md.Labels[clusterv1.ClusterTopologyMachineDeploymentLabelName]] = md
+	}
+
+	classes := map[string]*clusterv1.MachineDeploymentClass{}
+	for i := range class.Spec.Workers.MachineDeployments {
+		mdClass := &class.Spec.Workers.MachineDeployments[i]
+		classes[mdClass.Class] = mdClass
+	}
+
+	errs := []error{}
+	desired := map[string]bool{}
+	if cluster.Spec.Topology.Workers != nil {
+		for _, mdTopology := range cluster.Spec.Topology.Workers.MachineDeployments {
+			desired[mdTopology.Name] = true
+
+			mdClass, ok := classes[mdTopology.Class]
+			if !ok {
+				errs = append(errs, errors.Errorf("MachineDeploymentClass %q of %q not found in ClusterClass %q", mdTopology.Class, mdTopology.Name, class.Name))
+				continue
+			}
+			if err := r.reconcileMachineDeployment(ctx, cluster, mdClass, mdTopology, current[mdTopology.Name], variables); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+
+	// Delete the MachineDeployments of the worker pools removed from the topology.
+	for name, md := range current {
+		if desired[name] || !md.DeletionTimestamp.IsZero() {
+			continue
+		}
+		if err := r.Client.Delete(ctx, md); err != nil && !apierrors.IsNotFound(err) {
+			errs = append(errs, errors.Wrapf(err, "failed to delete MachineDeployment %q", md.Name))
+			continue
+		}
+		r.recorder.Eventf(cluster, corev1.EventTypeNormal, "TopologyDelete", "Deleted MachineDeployment %q", md.Name)
+	}
+
+	return kerrors.NewAggregate(errs)
+}
+
+// reconcileMachineDeployment creates or updates the MachineDeployment of a worker pool of the topology.
+func (r *ClusterTopologyReconciler) reconcileMachineDeployment(ctx context.Context, cluster *clusterv1.Cluster, mdClass *clusterv1.MachineDeploymentClass, mdTopology clusterv1.MachineDeploymentTopology, md *clusterv1.MachineDeployment, variables map[string]string) error {
+	name := fmt.Sprintf("%s-%s", cluster.Name, mdTopology.Name)
+	labels := topologyLabels(cluster)
+	labels[clusterv1.ClusterTopologyMachineDeploymentLabelName] = mdTopology.Name
+
+	var currentBootstrapRef, currentInfrastructureRef *corev1.ObjectReference
+	if md != nil {
+		currentBootstrapRef = md.Spec.Template.Spec.Bootstrap.ConfigRef
+		currentInfrastructureRef = &md.Spec.Template.Spec.InfrastructureRef
+	}
+	bootstrapRef, err := r.reconcileTemplate(ctx, cluster, mdClass.Template.Bootstrap.Ref, currentBootstrapRef, name, labels, variables)
+	if err != nil {
+		return errors.Wrapf(err, "failed to reconcile the bootstrap template of MachineDeployment %q", name)
+	}
+	infrastructureRef, err := r.reconcileTemplate(ctx, cluster, mdClass.Template.Infrastructure.Ref, currentInfrastructureRef, name, labels, variables)
+	if err != nil {
+		return errors.Wrapf(err, "failed to reconcile the infrastructure template of MachineDeployment %q", name)
+	}
+
+	if md == nil {
+		md = &clusterv1.MachineDeployment{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            name,
+				Namespace:       cluster.Namespace,
+				Labels:          labels,
+				OwnerReferences: []metav1.OwnerReference{clusterOwnerRef(cluster)},
+			},
+			Spec: clusterv1.MachineDeploymentSpec{
+				ClusterName: cluster.Name,
+				Selector: metav1.LabelSelector{
+					MatchLabels: map[string]string{
+						clusterv1.ClusterLabelName:                          cluster.Name,
+						clusterv1.ClusterTopologyMachineDeploymentLabelName: mdTopology.Name,
+					},
+				},
+			},
+		}
+		setMachineDeploymentTopology(md, mdClass, mdTopology, cluster.Spec.Topology.Version, bootstrapRef, infrastructureRef)
+		if err := r.Client.Create(ctx, md); err != nil {
+			return errors.Wrapf(err, "failed to create MachineDeployment %q", name)
+		}
+		r.recorder.Eventf(cluster, corev1.EventTypeNormal, "TopologyCreate", "Created MachineDeployment %q", name)
+		return nil
+	}
+
+	patchHelper, err := patch.NewHelper(md, r.Client)
+	if err != nil {
+		return err
+	}
+	setMachineDeploymentTopology(md, mdClass, mdTopology, cluster.Spec.Topology.Version, bootstrapRef, infrastructureRef)
+	if err := patchHelper.Patch(ctx, md); err != nil {
+		return errors.Wrapf(err, "failed to update MachineDeployment %q", name)
+	}
+	return nil
+}
+
+// setMachineDeploymentTopology sets the fields of a MachineDeployment managed by the topology.
+func setMachineDeploymentTopology(md *clusterv1.MachineDeployment, mdClass *clusterv1.MachineDeploymentClass, mdTopology clusterv1.MachineDeploymentTopology, version string, bootstrapRef, infrastructureRef *corev1.ObjectReference) {
+	if mdTopology.Replicas != nil {
+		md.Spec.Replicas = mdTopology.Replicas
+	}
+
+	template := &md.Spec.Template
+	template.Labels = mergeMaps(template.Labels, mdClass.Template.Metadata.Labels, mdTopology.Metadata.Labels, md.Spec.Selector.MatchLabels)
+	template.Annotations = mergeMaps(template.Annotations, mdClass.Template.Metadata.Annotations, mdTopology.Metadata.Annotations)
+	template.Spec.ClusterName = md.Spec.ClusterName
+	template.Spec.Version = &version
+	template.Spec.Bootstrap.ConfigRef = bootstrapRef
+	template.Spec.InfrastructureRef = *infrastructureRef
+}
+
+// reconcileTemplate returns a reference to a copy of the template of the ClusterClass, with the variables substituted.
+// The current copy is returned if its spec matches the template; otherwise a new copy is created, so the objects using
+// it are rolled out.
+func (r *ClusterTopologyReconciler) reconcileTemplate(ctx context.Context, cluster *clusterv1.Cluster, classRef, currentRef *corev1.ObjectReference, namePrefix string, labels map[string]string, variables map[string]string) (*corev1.ObjectReference, error) {
+	template, err := external.Get(ctx, r.Client, classRef, cluster.Namespace)
+	if err != nil {
+		return nil, err
+	}
+	substituteVariables(template.Object, variables)
+
+	if currentRef != nil && currentRef.Name != "" && currentRef.Kind == template.GetKind() && currentRef.APIVersion == template.GetAPIVersion() {
+		current, err := external.Get(ctx, r.Client, currentRef, cluster.Namespace)
+		switch {
+		case err == nil && reflect.DeepEqual(current.Object["spec"], template.Object["spec"]):
+			return currentRef, nil
+		case err != nil && !apierrors.IsNotFound(errors.Cause(err)):
+			return nil, err
+		}
+	}
+
+	desired := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": template.GetAPIVersion(),
+		"kind":       template.GetKind(),
+		"spec":       template.Object["spec"],
+	}}
+	desired.SetName(names.SimpleNameGenerator.GenerateName(namePrefix + "-"))
+	desired.SetNamespace(cluster.Namespace)
+	desired.SetLabels(labels)
+	desired.SetOwnerReferences([]metav1.OwnerReference{clusterOwnerRef(cluster)})
+	return r.createObject(ctx, cluster, desired)
+}
+
+// createObject creates an object of the topology, returning a reference to it. An object left behind by a previous
+// reconciliation that failed to persist the reference is reused.
+func (r *ClusterTopologyReconciler) createObject(ctx context.Context, cluster *clusterv1.Cluster, obj *unstructured.Unstructured) (*corev1.ObjectReference, error) {
+	if err := r.Client.Create(ctx, obj); err != nil {
+		if !apierrors.IsAlreadyExists(err) {
+			return nil, err
+		}
+	} else {
+		r.recorder.Eventf(cluster, corev1.EventTypeNormal, "TopologyCreate", "Created %s %q", obj.GetKind(), obj.GetName())
+	}
+
+	return &corev1.ObjectReference{
+		APIVersion: obj.GetAPIVersion(),
+		Kind:       obj.GetKind(),
+		Name:       obj.GetName(),
+		Namespace:  obj.GetNamespace(),
+	}, nil
+}
+
+// clusterClassToCluster is a handler.ToRequestsFunc to be used to enqueue requests for reconciliation
+// of the Clusters using a ClusterClass.
+func (r *ClusterTopologyReconciler) clusterClassToCluster(o handler.MapObject) []reconcile.Request {
+	class, ok := o.Object.(*clusterv1.ClusterClass)
+	if !ok {
+		r.Log.Error(errors.Errorf("expected a ClusterClass, got %T", o.Object), "failed to get Clusters for ClusterClass")
+		return nil
+	}
+
+	clusterList := &clusterv1.ClusterList{}
+	if err := r.Client.List(context.Background(), clusterList, client.InNamespace(class.Namespace)); err != nil {
+		r.Log.Error(err, "failed to list Clusters for ClusterClass", "clusterclass", class.Name, "namespace", class.Namespace)
+		return nil
+	}
+
+	var requests []reconcile.Request
+	for _, cluster := range clusterList.Items {
+		if cluster.Spec.Topology != nil && cluster.Spec.Topology.Class == class.Name {
+			requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKey{Namespace: cluster.Namespace, Name: cluster.Name}})
+		}
+	}
+	return requests
+}
+
+// machineDeploymentToCluster is a handler.ToRequestsFunc to be used to enqueue requests for reconciliation
+// of the Cluster owning a MachineDeployment of a managed topology.
+func (r *ClusterTopologyReconciler) machineDeploymentToCluster(o handler.MapObject) []reconcile.Request {
+	md, ok := o.Object.(*clusterv1.MachineDeployment)
+	if !ok {
+		r.Log.Error(errors.Errorf("expected a MachineDeployment, got %T", o.Object), "failed to get Cluster for MachineDeployment")
+		return nil
+	}
+
+	if _, ok := md.Labels[clusterv1.ClusterTopologyOwnedLabel]; !ok {
+		return nil
+	}
+
+	return []reconcile.Request{
+		{NamespacedName: client.ObjectKey{Namespace: md.Namespace, Name: md.Spec.ClusterName}},
+	}
+}
+
+// topologyVariables returns the values of the variables of the ClusterClass for the topology, using the
+// default values for the variables not set in the topology.
+func topologyVariables(class *clusterv1.ClusterClass, topology *clusterv1.Topology) (map[string]string, error) {
+	values := map[string]string{}
+	for _, variable := range topology.Variables {
+		values[variable.Name] = variable.Value
+	}
+
+	errs := []error{}
+	variables := map[string]string{}
+	for _, variable := range class.Spec.Variables {
+		value, ok := values[variable.Name]
+		switch {
+		case ok:
+			variables[variable.Name] = value
+		case variable.Required:
+			errs = append(errs, errors.Errorf("variable %q is required", variable.Name))
+		default:
+			variables[variable.Name] = variable.Default
+		}
+		delete(values, variable.Name)
+	}
+
+	undefined := make([]string, 0, len(values))
+	for name := range values {
+		undefined = append(undefined, name)
+	}
+	sort.Strings(undefined)
+	for _, name := range undefined {
+		errs = append(errs, errors.Errorf("variable %q is not defined", name))
+	}
+
+	if len(errs) > 0 {
+		return nil, kerrors.NewAggregate(errs)
+	}
+	return variables, nil
+}
+
+// substituteVariables replaces the occurrences of ${NAME} in the string values of obj with the value of the
+// variable NAME; occurrences of undefined variables are left unchanged.
+func substituteVariables(obj interface{}, variables map[string]string) interface{} {
+	switch v := obj.(type) {
+	case string:
+		return topologyVariableRegex.ReplaceAllStringFunc(v, func(s string) string {
+			if value, ok := variables[topologyVariableRegex.FindStringSubmatch(s)[1]]; ok {
+				return value
+			}
+			return s
+		})
+	case map[string]interface{}:
+		for key, value := range v {
+			v[key] = substituteVariables(value, variables)
+		}
+	case []interface{}:
+		for i, value := range v {
+			v[i] = substituteVariables(value, variables)
+		}
+	}
+	return obj
+}
+
+// topologyLabels returns the labels set on all the objects of the managed topology of a Cluster.
+func topologyLabels(cluster *clusterv1.Cluster) map[string]string {
+	return map[string]string{
+		clusterv1.ClusterLabelName:          cluster.Name,
+		clusterv1.ClusterTopologyOwnedLabel: "",
+	}
+}
+
+// clusterOwnerRef returns an OwnerReference to the Cluster, so the objects of the managed topology
+// are garbage collected when the Cluster is deleted.
+func clusterOwnerRef(cluster *clusterv1.Cluster) metav1.OwnerReference {
+	return metav1.OwnerReference{
+		APIVersion: clusterv1.GroupVersion.String(),
+		Kind:       "Cluster",
+		Name:       cluster.Name,
+		UID:        cluster.UID,
+	}
+}
+
+// mergeMaps returns a new map with the entries of all the maps; later maps take precedence.
+func mergeMaps(maps ...map[string]string) map[string]string {
+	merged := map[string]string{}
+	for _, m := range maps {
+		for key, value := range m {
+			merged[key] = value
+		}
+	}
+	return merged
+}
@@ -0,0 +1,261 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/pointer"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/controllers/external"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func TestTopologyVariables(t *testing.T) {
+	class := &clusterv1.ClusterClass{
+		Spec: clusterv1.ClusterClassSpec{
+			Variables: []clusterv1.ClusterClassVariable{
+				{Name: "IMAGE_ID", Required: true},
+				{Name: "FLAVOR", Default: "small"},
+			},
+		},
+	}
+
+	tests := []struct {
+		name      string
+		variables []clusterv1.ClusterVariable
+		expected  map[string]string
+		expectErr bool
+	}{
+		{
+			name:      "should use the default values of the variables not set",
+			variables: []clusterv1.ClusterVariable{{Name: "IMAGE_ID", Value: "image"}},
+			expected:  map[string]string{"IMAGE_ID": "image", "FLAVOR": "small"},
+		},
+		{
+			name:      "should override the default values",
+			variables: []clusterv1.ClusterVariable{{Name: "IMAGE_ID", Value: "image"}, {Name: "FLAVOR", Value: "large"}},
+			expected:  map[string]string{"IMAGE_ID": "image", "FLAVOR": "large"},
+		},
+		{
+			name:      "should return error when a required variable is not set",
+			variables: []clusterv1.ClusterVariable{{Name: "FLAVOR", Value: "large"}},
+			expectErr: true,
+		},
+		{
+			name:      "should return error when a variable is not defined in the class",
+			variables: []clusterv1.ClusterVariable{{Name: "IMAGE_ID", Value: "image"}, {Name: "REGION", Value: "eu"}},
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			variables, err := topologyVariables(class, &clusterv1.Topology{Variables: tt.variables})
+			if tt.expectErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(variables).To(Equal(tt.expected))
+		})
+	}
+}
+
+func TestSubstituteVariables(t *testing.T) {
+	g := NewWithT(t)
+
+	obj := map[string]interface{}{
+		"image": "${IMAGE_ID}",
+		"files": []interface{}{
+			map[string]interface{}{"content": "region=${REGION} home=${HOME} user=$USER"},
+		},
+		"replicas": int64(3),
+	}
+	substituteVariables(obj, map[string]string{"IMAGE_ID": "image", "REGION": "eu"})
+
+	g.Expect(obj).To(Equal(map[string]interface{}{
+		"image": "image",
+		"files": []interface{}{
+			map[string]interface{}{"content": "region=eu home=${HOME} user=$USER"},
+		},
+		"replicas": int64(3),
+	}))
+}
+
+func TestClusterTopologyReconcilerReconcileTopology(t *testing.T) {
+	g := NewWithT(t)
+	g.Expect(clusterv1.AddToScheme(scheme.Scheme)).To(Succeed())
+
+	template := func(apiVersion, kind, name string, spec map[string]interface{}) *unstructured.Unstructured {
+		return &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": apiVersion,
+			"kind":       kind,
+			"metadata": map[string]interface{}{
+				"name":      name,
+				"namespace": "test-namespace",
+			},
+			"spec": map[string]interface{}{
+				"template": map[string]interface{}{
+					"spec": spec,
+				},
+			},
+		}}
+	}
+	ref := func(obj *unstructured.Unstructured) *corev1.ObjectReference {
+		return &corev1.ObjectReference{APIVersion: obj.GetAPIVersion(), Kind: obj.GetKind(), Name: obj.GetName()}
+	}
+	infrastructureTemplate := template("infrastructure.cluster.x-k8s.io/v1alpha3", "InfrastructureClusterTemplate", "infra", map[string]interface{}{"region": "${REGION}"})
+	controlPlaneTemplate := template("controlplane.cluster.x-k8s.io/v1alpha3", "ControlPlaneTemplate", "cp", map[string]interface{}{"config": "cp-config"})
+	controlPlaneMachineTemplate := template("infrastructure.cluster.x-k8s.io/v1alpha3", "InfrastructureMachineTemplate", "cp-machine", map[string]interface{}{"image": "${IMAGE_ID}"})
+	workerMachineTemplate := template("infrastructure.cluster.x-k8s.io/v1alpha3", "InfrastructureMachineTemplate", "worker-machine", map[string]interface{}{"image": "${IMAGE_ID}"})
+	workerBootstrapTemplate := template("bootstrap.cluster.x-k8s.io/v1alpha3", "BootstrapConfigTemplate", "worker-bootstrap", map[string]interface{}{})
+
+	class := &clusterv1.ClusterClass{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-class",
+			Namespace: "test-namespace",
+		},
+		Spec: clusterv1.ClusterClassSpec{
+			Infrastructure: clusterv1.LocalObjectTemplate{Ref: ref(infrastructureTemplate)},
+			ControlPlane: clusterv1.ControlPlaneClass{
+				LocalObjectTemplate:   clusterv1.LocalObjectTemplate{Ref: ref(controlPlaneTemplate)},
+				MachineInfrastructure: &clusterv1.LocalObjectTemplate{Ref: ref(controlPlaneMachineTemplate)},
+			},
+			Workers: clusterv1.WorkersClass{
+				MachineDeployments: []clusterv1.MachineDeploymentClass{
+					{
+						Class: "default-worker",
+						Template: clusterv1.MachineDeploymentClassTemplate{
+							Bootstrap:      clusterv1.LocalObjectTemplate{Ref: ref(workerBootstrapTemplate)},
+							Infrastructure: clusterv1.LocalObjectTemplate{Ref: ref(workerMachineTemplate)},
+						},
+					},
+				},
+			},
+			Variables: []clusterv1.ClusterClassVariable{
+				{Name: "IMAGE_ID", Required: true},
+				{Name: "REGION", Default: "eu"},
+			},
+		},
+	}
+
+	cluster := &clusterv1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-cluster",
+			Namespace: "test-namespace",
+		},
+		Spec: clusterv1.ClusterSpec{
+			Topology: &clusterv1.Topology{
+				Class:   "test-class",
+				Version: "v1.18.2",
+				ControlPlane: clusterv1.ControlPlaneTopology{
+					Replicas: pointer.Int32Ptr(3),
+				},
+				Workers: &clusterv1.WorkersTopology{
+					MachineDeployments: []clusterv1.MachineDeploymentTopology{
+						{Class: "default-worker", Name: "md-0", Replicas: pointer.Int32Ptr(2)},
+					},
+				},
+				Variables: []clusterv1.ClusterVariable{
+					{Name: "IMAGE_ID", Value: "image-1"},
+				},
+			},
+		},
+	}
+
+	c := fake.NewFakeClientWithScheme(scheme.Scheme, class, infrastructureTemplate, controlPlaneTemplate, controlPlaneMachineTemplate, workerMachineTemplate, workerBootstrapTemplate)
+	r := &ClusterTopologyReconciler{
+		Client:   c,
+		Log:      log.Log,
+		recorder: record.NewFakeRecorder(32),
+	}
+	nestedField := func(obj *unstructured.Unstructured, fields ...string) interface{} {
+		value, _, _ := unstructured.NestedFieldCopy(obj.Object, fields...)
+		return value
+	}
+	getMachineDeployment := func() *clusterv1.MachineDeployment {
+		md := &clusterv1.MachineDeployment{}
+		g.Expect(c.Get(ctx, client.ObjectKey{Namespace: "test-namespace", Name: "test-cluster-md-0"}, md)).To(Succeed())
+		return md
+	}
+
+	// The objects of the topology are created from the templates of the class.
+	g.Expect(r.reconcileTopology(ctx, cluster)).To(Succeed())
+
+	g.Expect(cluster.Spec.InfrastructureRef).NotTo(BeNil())
+	infrastructure, err := external.Get(ctx, c, cluster.Spec.InfrastructureRef, "test-namespace")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(infrastructure.GetKind()).To(Equal("InfrastructureCluster"))
+	g.Expect(infrastructure.Object["spec"]).To(HaveKeyWithValue("region", "eu"))
+
+	g.Expect(cluster.Spec.ControlPlaneRef).NotTo(BeNil())
+	controlPlane, err := external.Get(ctx, c, cluster.Spec.ControlPlaneRef, "test-namespace")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(controlPlane.GetKind()).To(Equal("ControlPlane"))
+	g.Expect(controlPlane.GetName()).To(Equal("test-cluster-control-plane"))
+	g.Expect(nestedField(controlPlane, "spec", "version")).To(Equal("v1.18.2"))
+	g.Expect(nestedField(controlPlane, "spec", "replicas")).To(Equal(int64(3)))
+	g.Expect(nestedField(controlPlane, "spec", "config")).To(Equal("cp-config"))
+	controlPlaneMachineTemplateName, _ := nestedField(controlPlane, "spec", "infrastructureTemplate", "name").(string)
+	controlPlaneMachine, err := external.Get(ctx, c, &corev1.ObjectReference{
+		APIVersion: "infrastructure.cluster.x-k8s.io/v1alpha3",
+		Kind:       "InfrastructureMachineTemplate",
+		Name:       controlPlaneMachineTemplateName,
+	}, "test-namespace")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(nestedField(controlPlaneMachine, "spec", "template", "spec", "image")).To(Equal("image-1"))
+
+	md := getMachineDeployment()
+	g.Expect(md.Labels).To(HaveKeyWithValue(clusterv1.ClusterTopologyMachineDeploymentLabelName, "md-0"))
+	g.Expect(*md.Spec.Replicas).To(Equal(int32(2)))
+	g.Expect(*md.Spec.Template.Spec.Version).To(Equal("v1.18.2"))
+	g.Expect(md.Spec.Template.Spec.Bootstrap.ConfigRef.Kind).To(Equal("BootstrapConfigTemplate"))
+	g.Expect(md.Spec.Template.Spec.InfrastructureRef.Kind).To(Equal("InfrastructureMachineTemplate"))
+	workerMachineTemplateName := md.Spec.Template.Spec.InfrastructureRef.Name
+
+	// Changes to the version, the replicas and the variables of the topology are rolled out.
+	cluster.Spec.Topology.Version = "v1.19.1"
+	cluster.Spec.Topology.Workers.MachineDeployments[0].Replicas = pointer.Int32Ptr(5)
+	cluster.Spec.Topology.Variables[0].Value = "image-2"
+	g.Expect(r.reconcileTopology(ctx, cluster)).To(Succeed())
+
+	controlPlane, err = external.Get(ctx, c, cluster.Spec.ControlPlaneRef, "test-namespace")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(nestedField(controlPlane, "spec", "version")).To(Equal("v1.19.1"))
+	g.Expect(nestedField(controlPlane, "spec", "infrastructureTemplate", "name")).NotTo(Equal(controlPlaneMachineTemplateName))
+
+	md = getMachineDeployment()
+	g.Expect(*md.Spec.Replicas).To(Equal(int32(5)))
+	g.Expect(*md.Spec.Template.Spec.Version).To(Equal("v1.19.1"))
+	g.Expect(md.Spec.Template.Spec.InfrastructureRef.Name).NotTo(Equal(workerMachineTemplateName))
+
+	// The MachineDeployments of the worker pools removed from the topology are deleted.
+	cluster.Spec.Topology.Workers.MachineDeployments = nil
+	g.Expect(r.reconcileTopology(ctx, cluster)).To(Succeed())
+	g.Expect(c.Get(ctx, client.ObjectKey{Namespace: "test-namespace", Name: "test-cluster-md-0"}, &clusterv1.MachineDeployment{})).NotTo(Succeed())
+}
@@ -0,0 +1,86 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package external
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+)
+
+// ContractLabelName is the label set on the CustomResourceDefinitions of the provider types implementing the
+// Cluster API contract; the value is the list of the versions of the type implementing the contract, separated
+// by underscores, e.g. "cluster.x-k8s.io/v1alpha3: v1alpha2_v1alpha3".
+var ContractLabelName = clusterv1.GroupVersion.String()
+
+// ValidateReference checks that the kind of an object reference is served by the API server and, if it is defined
+// by a CustomResourceDefinition, that the CustomResourceDefinition implements the Cluster API contract for the
+// referenced version, so references with a mistyped apiVersion or kind are detected before being reconciled.
+// The returned field errors refer to the given path; an error is returned when the CustomResourceDefinition
+// can't be read.
+func ValidateReference(ctx context.Context, c client.Reader, mapper meta.RESTMapper, ref *corev1.ObjectReference, fldPath *field.Path) (field.ErrorList, error) {
+	gv, err := schema.ParseGroupVersion(ref.APIVersion)
+	if err != nil {
+		return field.ErrorList{field.Invalid(fldPath.Child("apiVersion"), ref.APIVersion, err.Error())}, nil
+	}
+	gk := schema.GroupKind{Group: gv.Group, Kind: ref.Kind}
+
+	// Uses discovery for getting the resource serving the kind, if any.
+	mapping, err := mapper.RESTMapping(gk, gv.Version)
+	if err != nil {
+		if meta.IsNoMatchError(err) {
+			return field.ErrorList{field.Invalid(fldPath, fmt.Sprintf("%s, Kind=%s", ref.APIVersion, ref.Kind), "the referenced kind is not served by the API server")}, nil
+		}
+		return nil, err
+	}
+
+	crd := &apiextensionsv1.CustomResourceDefinition{}
+	crdName := fmt.Sprintf("%s.%s", mapping.Resource.Resource, mapping.Resource.Group)
+	if err := c.Get(ctx, client.ObjectKey{Name: crdName}, crd); err != nil {
+		// The kind is served by an aggregated apiserver, which can't be labeled with the contract.
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	if !implementsContract(crd, gv.Version) {
+		return field.ErrorList{field.Invalid(fldPath.Child("apiVersion"), ref.APIVersion,
+			fmt.Sprintf("the %s CustomResourceDefinition does not implement the Cluster API contract for this version, it must have the %q label listing it", crdName, ContractLabelName))}, nil
+	}
+	return nil, nil
+}
+
+// implementsContract returns true if the contract label of the CustomResourceDefinition lists the given version.
+func implementsContract(crd *apiextensionsv1.CustomResourceDefinition, version string) bool {
+	for _, v := range strings.Split(crd.Labels[ContractLabelName], "_") {
+		if v == version {
+			return true
+		}
+	}
+	return false
+}
@@ -30,6 +30,9 @@ var (
 		},
 		ObjectMeta: metav1.ObjectMeta{
 			Name: "genericmachines.bootstrap.cluster.x-k8s.io",
+			Labels: map[string]string{
+				ContractLabelName: "v1alpha3",
+			},
 		},
 		Spec: apiextensionsv1.CustomResourceDefinitionSpec{
 			Group: "bootstrap.cluster.x-k8s.io",
@@ -69,6 +72,9 @@ var (
 		},
 		ObjectMeta: metav1.ObjectMeta{
 			Name: "genericmachinetemplates.bootstrap.cluster.x-k8s.io",
+			Labels: map[string]string{
+				ContractLabelName: "v1alpha3",
+			},
 		},
 		Spec: apiextensionsv1.CustomResourceDefinitionSpec{
 			Group: "bootstrap.cluster.x-k8s.io",
@@ -108,6 +114,9 @@ var (
 		},
 		ObjectMeta: metav1.ObjectMeta{
 			Name: "genericmachines.infrastructure.cluster.x-k8s.io",
+			Labels: map[string]string{
+				ContractLabelName: "v1alpha3",
+			},
 		},
 		Spec: apiextensionsv1.CustomResourceDefinitionSpec{
 			Group: "infrastructure.cluster.x-k8s.io",
@@ -147,6 +156,9 @@ var (
 		},
 		ObjectMeta: metav1.ObjectMeta{
 			Name: "genericmachinetemplates.infrastructure.cluster.x-k8s.io",
+			Labels: map[string]string{
+				ContractLabelName: "v1alpha3",
+			},
 		},
 		Spec: apiextensionsv1.CustomResourceDefinitionSpec{
 			Group: "infrastructure.cluster.x-k8s.io",
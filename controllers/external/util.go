@@ -22,6 +22,7 @@ import (
 
 	"github.com/pkg/errors"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apiserver/pkg/storage/names"
@@ -73,10 +74,36 @@ type CloneTemplateInput struct {
 	// Labels is an optional map of labels to be added to the object.
 	// +optional
 	Labels map[string]string
+
+	// Name is an optional name for the cloned object.
+	// If empty, a name is generated from the template name.
+	// +optional
+	Name string
 }
 
 // CloneTemplate uses the client and the reference to create a new object from the template.
 func CloneTemplate(ctx context.Context, in *CloneTemplateInput) (*corev1.ObjectReference, error) {
+	to, err := GenerateTemplate(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create the external clone.
+	if err := in.Client.Create(context.Background(), to); err != nil {
+		return nil, err
+	}
+
+	return &corev1.ObjectReference{
+		APIVersion: to.GetAPIVersion(),
+		Kind:       to.GetKind(),
+		Name:       to.GetName(),
+		Namespace:  to.GetNamespace(),
+		UID:        to.GetUID(),
+	}, nil
+}
+
+// GenerateTemplate uses the client and the reference to generate a new object from the template, without creating it.
+func GenerateTemplate(ctx context.Context, in *CloneTemplateInput) (*unstructured.Unstructured, error) {
 	from, err := Get(ctx, in.Client, in.TemplateRef, in.Namespace)
 	if err != nil {
 		return nil, err
@@ -94,9 +121,22 @@ func CloneTemplate(ctx context.Context, in *CloneTemplateInput) (*corev1.ObjectR
 	to.SetFinalizers(nil)
 	to.SetUID("")
 	to.SetSelfLink("")
-	to.SetName(names.SimpleNameGenerator.GenerateName(from.GetName() + "-"))
+	to.SetName(in.Name)
+	if to.GetName() == "" {
+		to.SetName(names.SimpleNameGenerator.GenerateName(from.GetName() + "-"))
+	}
 	to.SetNamespace(in.Namespace)
 
+	// Never copy a pause propagated from a Cluster, because it is removed only from the objects the pause was
+	// propagated to, and the clone would stay paused after the Cluster is resumed.
+	if annotations := to.GetAnnotations(); annotations != nil {
+		if _, ok := annotations[clusterv1.PausePropagatedAnnotation]; ok {
+			delete(annotations, clusterv1.PausedAnnotation)
+			delete(annotations, clusterv1.PausePropagatedAnnotation)
+			to.SetAnnotations(annotations)
+		}
+	}
+
 	// Set labels.
 	labels := to.GetLabels()
 	if labels == nil {
@@ -123,18 +163,7 @@ func CloneTemplate(ctx context.Context, in *CloneTemplateInput) (*corev1.ObjectR
 		to.SetKind(strings.TrimSuffix(in.TemplateRef.Kind, TemplateSuffix))
 	}
 
-	// Create the external clone.
-	if err := in.Client.Create(context.Background(), to); err != nil {
-		return nil, err
-	}
-
-	return &corev1.ObjectReference{
-		APIVersion: to.GetAPIVersion(),
-		Kind:       to.GetKind(),
-		Name:       to.GetName(),
-		Namespace:  to.GetNamespace(),
-		UID:        to.GetUID(),
-	}, nil
+	return to, nil
 }
 
 // FailuresFrom returns the FailureReason and FailureMessage fields from the external object status.
@@ -162,6 +191,29 @@ func IsReady(obj *unstructured.Unstructured) (bool, error) {
 	return ready && found, nil
 }
 
+// IsReleased returns true if the Status.Released field on an external object is true; supported is false if the
+// field is not set at all, i.e. the provider does not support releasing the machine before deleting it.
+func IsReleased(obj *unstructured.Unstructured) (released bool, supported bool, err error) {
+	released, found, err := unstructured.NestedBool(obj.Object, "status", "released")
+	if err != nil {
+		return false, false, errors.Wrapf(err, "failed to determine if %v %q is released",
+			obj.GroupVersionKind(), obj.GetName())
+	}
+	return released && found, found, nil
+}
+
+// IsExternalManagedControlPlane returns true if the Status.ExternalManagedControlPlane field on a control plane
+// object is true, i.e. the control plane is managed by a service outside of the cluster (e.g. EKS, AKS or GKE) and
+// it is not backed by Machines.
+func IsExternalManagedControlPlane(obj *unstructured.Unstructured) (bool, error) {
+	managed, found, err := unstructured.NestedBool(obj.Object, "status", "externalManagedControlPlane")
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to determine if %v %q is an externally managed control plane",
+			obj.GroupVersionKind(), obj.GetName())
+	}
+	return managed && found, nil
+}
+
 // IsInitialized returns true if the Status.Initialized field on an external object is true.
 func IsInitialized(obj *unstructured.Unstructured) (bool, error) {
 	initialized, found, err := unstructured.NestedBool(obj.Object, "status", "initialized")
@@ -171,3 +223,38 @@ func IsInitialized(obj *unstructured.Unstructured) (bool, error) {
 	}
 	return initialized && found, nil
 }
+
+// ImageChannelFrom returns the Spec.ImageChannel field of an infrastructure machine template, i.e. the channel of
+// the OS images used by the template; it returns an empty string if the template does not reference an image channel.
+func ImageChannelFrom(obj *unstructured.Unstructured) (string, error) {
+	channel, _, err := unstructured.NestedString(obj.Object, "spec", "imageChannel")
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to determine the image channel of %v %q",
+			obj.GroupVersionKind(), obj.GetName())
+	}
+	return channel, nil
+}
+
+// CapacityFrom returns the Status.Capacity field of an infrastructure machine template, i.e. the resources of the
+// Nodes of the machines created from the template; it returns nil if the template does not report its capacity.
+func CapacityFrom(obj *unstructured.Unstructured) (corev1.ResourceList, error) {
+	capacity, found, err := unstructured.NestedStringMap(obj.Object, "status", "capacity")
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to determine the capacity of %v %q",
+			obj.GroupVersionKind(), obj.GetName())
+	}
+	if !found {
+		return nil, nil
+	}
+
+	ret := corev1.ResourceList{}
+	for name, value := range capacity {
+		quantity, err := resource.ParseQuantity(value)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to parse the %q capacity of %v %q",
+				name, obj.GroupVersionKind(), obj.GetName())
+		}
+		ret[corev1.ResourceName(name)] = quantity
+	}
+	return ret, nil
+}
@@ -24,6 +24,7 @@ import (
 	"github.com/pkg/errors"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -261,6 +262,116 @@ func TestCloneTemplateResourceFoundNoOwner(t *testing.T) {
 	g.Expect(cloneSpec).To(Equal(expectedSpec))
 }
 
+func TestCloneTemplateResourceFoundWithName(t *testing.T) {
+	g := NewWithT(t)
+
+	namespace := "test"
+	testClusterName := "test-cluster"
+
+	templateName := "greenTemplate"
+	templateKind := "GreenTemplate"
+	templateAPIVersion := "green.io/v1"
+
+	template := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"kind":       templateKind,
+			"apiVersion": templateAPIVersion,
+			"metadata": map[string]interface{}{
+				"name":      templateName,
+				"namespace": namespace,
+			},
+			"spec": map[string]interface{}{
+				"template": map[string]interface{}{
+					"spec": map[string]interface{}{
+						"hello": "world",
+					},
+				},
+			},
+		},
+	}
+
+	templateRef := &corev1.ObjectReference{
+		Kind:       templateKind,
+		APIVersion: templateAPIVersion,
+		Name:       templateName,
+		Namespace:  namespace,
+	}
+
+	fakeClient := fake.NewFakeClientWithScheme(runtime.NewScheme(), template.DeepCopy())
+
+	ref, err := CloneTemplate(context.Background(), &CloneTemplateInput{
+		Client:      fakeClient,
+		TemplateRef: templateRef,
+		Namespace:   namespace,
+		ClusterName: testClusterName,
+		Name:        "green-machine",
+	})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(ref).NotTo(BeNil())
+	g.Expect(ref.Kind).To(Equal("Green"))
+	g.Expect(ref.Name).To(Equal("green-machine"))
+
+	clone := &unstructured.Unstructured{}
+	clone.SetKind(ref.Kind)
+	clone.SetAPIVersion(ref.APIVersion)
+	key := client.ObjectKey{Name: ref.Name, Namespace: ref.Namespace}
+	g.Expect(fakeClient.Get(context.Background(), key, clone)).To(Succeed())
+}
+
+func TestGenerateTemplateDropsPropagatedPause(t *testing.T) {
+	g := NewWithT(t)
+
+	namespace := "test"
+	testClusterName := "test-cluster"
+
+	templateName := "purpleTemplate"
+	templateKind := "PurpleTemplate"
+	templateAPIVersion := "purple.io/v1"
+
+	template := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"kind":       templateKind,
+			"apiVersion": templateAPIVersion,
+			"metadata": map[string]interface{}{
+				"name":      templateName,
+				"namespace": namespace,
+			},
+			"spec": map[string]interface{}{
+				"template": map[string]interface{}{
+					"metadata": map[string]interface{}{
+						"annotations": map[string]interface{}{
+							clusterv1.PausedAnnotation:          "true",
+							clusterv1.PausePropagatedAnnotation: testClusterName,
+							"color":                             "purple",
+						},
+					},
+					"spec": map[string]interface{}{
+						"hello": "world",
+					},
+				},
+			},
+		},
+	}
+
+	templateRef := &corev1.ObjectReference{
+		Kind:       templateKind,
+		APIVersion: templateAPIVersion,
+		Name:       templateName,
+		Namespace:  namespace,
+	}
+
+	fakeClient := fake.NewFakeClientWithScheme(runtime.NewScheme(), template.DeepCopy())
+
+	obj, err := GenerateTemplate(context.Background(), &CloneTemplateInput{
+		Client:      fakeClient,
+		TemplateRef: templateRef,
+		Namespace:   namespace,
+		ClusterName: testClusterName,
+	})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(obj.GetAnnotations()).To(Equal(map[string]string{"color": "purple"}))
+}
+
 func TestCloneTemplateMissingSpecTemplate(t *testing.T) {
 	g := NewWithT(t)
 
@@ -300,3 +411,85 @@ func TestCloneTemplateMissingSpecTemplate(t *testing.T) {
 	})
 	g.Expect(err).To(HaveOccurred())
 }
+
+func TestIsExternalManagedControlPlane(t *testing.T) {
+	g := NewWithT(t)
+
+	controlPlane := &unstructured.Unstructured{Object: map[string]interface{}{}}
+	managed, err := IsExternalManagedControlPlane(controlPlane)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(managed).To(BeFalse())
+
+	g.Expect(unstructured.SetNestedField(controlPlane.Object, true, "status", "externalManagedControlPlane")).To(Succeed())
+	managed, err = IsExternalManagedControlPlane(controlPlane)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(managed).To(BeTrue())
+
+	g.Expect(unstructured.SetNestedField(controlPlane.Object, "yes", "status", "externalManagedControlPlane")).To(Succeed())
+	_, err = IsExternalManagedControlPlane(controlPlane)
+	g.Expect(err).To(HaveOccurred())
+}
+
+func TestIsReleased(t *testing.T) {
+	g := NewWithT(t)
+
+	infraMachine := &unstructured.Unstructured{Object: map[string]interface{}{}}
+	released, supported, err := IsReleased(infraMachine)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(released).To(BeFalse())
+	g.Expect(supported).To(BeFalse())
+
+	g.Expect(unstructured.SetNestedField(infraMachine.Object, false, "status", "released")).To(Succeed())
+	released, supported, err = IsReleased(infraMachine)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(released).To(BeFalse())
+	g.Expect(supported).To(BeTrue())
+
+	g.Expect(unstructured.SetNestedField(infraMachine.Object, true, "status", "released")).To(Succeed())
+	released, supported, err = IsReleased(infraMachine)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(released).To(BeTrue())
+	g.Expect(supported).To(BeTrue())
+
+	g.Expect(unstructured.SetNestedField(infraMachine.Object, "yes", "status", "released")).To(Succeed())
+	_, _, err = IsReleased(infraMachine)
+	g.Expect(err).To(HaveOccurred())
+}
+
+func TestCapacityFrom(t *testing.T) {
+	g := NewWithT(t)
+
+	template := &unstructured.Unstructured{Object: map[string]interface{}{}}
+	capacity, err := CapacityFrom(template)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(capacity).To(BeNil())
+
+	g.Expect(unstructured.SetNestedStringMap(template.Object, map[string]string{"cpu": "2", "memory": "8Gi"}, "status", "capacity")).To(Succeed())
+	capacity, err = CapacityFrom(template)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(capacity).To(HaveLen(2))
+	g.Expect(capacity[corev1.ResourceCPU]).To(Equal(resource.MustParse("2")))
+	g.Expect(capacity[corev1.ResourceMemory]).To(Equal(resource.MustParse("8Gi")))
+
+	g.Expect(unstructured.SetNestedStringMap(template.Object, map[string]string{"cpu": "two"}, "status", "capacity")).To(Succeed())
+	_, err = CapacityFrom(template)
+	g.Expect(err).To(HaveOccurred())
+}
+
+func TestImageChannelFrom(t *testing.T) {
+	g := NewWithT(t)
+
+	template := &unstructured.Unstructured{Object: map[string]interface{}{}}
+	channel, err := ImageChannelFrom(template)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(channel).To(BeEmpty())
+
+	g.Expect(unstructured.SetNestedField(template.Object, "ubuntu-1804-k8s-1.17", "spec", "imageChannel")).To(Succeed())
+	channel, err = ImageChannelFrom(template)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(channel).To(Equal("ubuntu-1804-k8s-1.17"))
+
+	g.Expect(unstructured.SetNestedField(template.Object, int64(1), "spec", "imageChannel")).To(Succeed())
+	_, err = ImageChannelFrom(template)
+	g.Expect(err).To(HaveOccurred())
+}
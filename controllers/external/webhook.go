@@ -0,0 +1,140 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package external
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// MachineTemplateReferences are the fields of the core objects stamping Machines holding the references to
+// the bootstrap and infrastructure templates.
+var MachineTemplateReferences = map[string][][]string{
+	"MachineDeployment": {
+		{"spec", "template", "spec", "bootstrap", "configRef"},
+		{"spec", "template", "spec", "infrastructureRef"},
+	},
+	"MachineSet": {
+		{"spec", "template", "spec", "bootstrap", "configRef"},
+		{"spec", "template", "spec", "infrastructureRef"},
+	},
+}
+
+// +kubebuilder:webhook:verbs=create;update,path=/validate-cluster-x-k8s-io-v1alpha3-template-references,mutating=false,failurePolicy=fail,groups=cluster.x-k8s.io,resources=machinedeployments;machinesets,versions=v1alpha3,name=validation-references.machinetemplate.cluster.x-k8s.io
+// +kubebuilder:rbac:groups=apiextensions.k8s.io,resources=customresourcedefinitions,verbs=get;list;watch
+
+// ReferenceValidator is an admission webhook rejecting the objects referencing kinds which are not served by
+// a CustomResourceDefinition implementing the Cluster API contract, e.g. because of a mistyped apiVersion or kind,
+// instead of failing at the first reconciliation. See ValidateReference.
+type ReferenceValidator struct {
+	// Client is used for reading the CustomResourceDefinitions; an uncached client is recommended, so the
+	// CustomResourceDefinitions are not watched.
+	Client client.Reader
+
+	// RESTMapper is used for discovering the resources serving the referenced kinds.
+	RESTMapper meta.RESTMapper
+
+	// References are the fields holding the references to validate, for each kind of object handled by the webhook.
+	References map[string][][]string
+}
+
+var _ admission.Handler = &ReferenceValidator{}
+
+// SetupWebhookWithManager registers the webhook with the manager webhook server at the given path.
+func (v *ReferenceValidator) SetupWebhookWithManager(mgr ctrl.Manager, path string) error {
+	mgr.GetWebhookServer().Register(path, &webhook.Admission{Handler: v})
+	return nil
+}
+
+// Handle implements admission.Handler; references are validated only on create and when changed on update, so
+// existing objects can still be updated, e.g. scaled, if the referenced kinds stop implementing the contract.
+func (v *ReferenceValidator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	paths, ok := v.References[req.Kind.Kind]
+	if !ok || (req.Operation != admissionv1beta1.Create && req.Operation != admissionv1beta1.Update) {
+		return admission.Allowed("")
+	}
+
+	obj := &unstructured.Unstructured{}
+	if err := json.Unmarshal(req.Object.Raw, &obj.Object); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+	var oldObj *unstructured.Unstructured
+	if req.Operation == admissionv1beta1.Update {
+		oldObj = &unstructured.Unstructured{}
+		if err := json.Unmarshal(req.OldObject.Raw, &oldObj.Object); err != nil {
+			return admission.Errored(http.StatusBadRequest, err)
+		}
+	}
+
+	var allErrs field.ErrorList
+	for _, path := range paths {
+		fldPath := field.NewPath(path[0], path[1:]...)
+		ref, err := referenceAt(obj, path)
+		if err != nil {
+			allErrs = append(allErrs, field.Invalid(fldPath, nil, err.Error()))
+			continue
+		}
+		if ref == nil {
+			continue
+		}
+		if oldObj != nil {
+			if oldRef, err := referenceAt(oldObj, path); err == nil && oldRef != nil &&
+				oldRef.APIVersion == ref.APIVersion && oldRef.Kind == ref.Kind {
+				continue
+			}
+		}
+
+		errs, err := ValidateReference(ctx, v.Client, v.RESTMapper, ref, fldPath)
+		if err != nil {
+			return admission.Errored(http.StatusInternalServerError, err)
+		}
+		allErrs = append(allErrs, errs...)
+	}
+
+	if len(allErrs) > 0 {
+		gk := schema.GroupKind{Group: req.Kind.Group, Kind: req.Kind.Kind}
+		return admission.Denied(apierrors.NewInvalid(gk, obj.GetName(), allErrs).Error())
+	}
+	return admission.Allowed("")
+}
+
+// referenceAt returns the object reference at the given path of an object, or nil if the field is not set.
+func referenceAt(obj *unstructured.Unstructured, path []string) (*corev1.ObjectReference, error) {
+	m, found, err := unstructured.NestedMap(obj.Object, path...)
+	if err != nil || !found {
+		return nil, err
+	}
+	ref := &corev1.ObjectReference{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(m, ref); err != nil {
+		return nil, err
+	}
+	return ref, nil
+}
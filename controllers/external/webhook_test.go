@@ -0,0 +1,146 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package external
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+)
+
+func TestReferenceValidator(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := apiextensionsv1.AddToScheme(scheme); err != nil {
+		t.Fatal("failed to register apiextensions objects to scheme")
+	}
+
+	// A CRD implementing the contract and one not implementing it.
+	unlabeledCRD := TestGenericInfrastructureTemplateCRD.DeepCopy()
+	unlabeledCRD.Name = "unlabeledmachinetemplates.infrastructure.cluster.x-k8s.io"
+	unlabeledCRD.Labels = nil
+	c := fake.NewFakeClientWithScheme(scheme, TestGenericBootstrapTemplateCRD.DeepCopy(), TestGenericInfrastructureTemplateCRD.DeepCopy(), unlabeledCRD)
+
+	mapper := meta.NewDefaultRESTMapper(nil)
+	for kind, plural := range map[schema.GroupVersionKind]string{
+		{Group: "bootstrap.cluster.x-k8s.io", Version: "v1alpha3", Kind: "BootstrapMachineTemplate"}:           "genericmachinetemplates",
+		{Group: "infrastructure.cluster.x-k8s.io", Version: "v1alpha3", Kind: "InfrastructureMachineTemplate"}: "genericmachinetemplates",
+		{Group: "infrastructure.cluster.x-k8s.io", Version: "v1alpha3", Kind: "UnlabeledMachineTemplate"}:      "unlabeledmachinetemplates",
+		{Group: "infrastructure.cluster.x-k8s.io", Version: "v1alpha3", Kind: "AggregatedMachineTemplate"}:     "aggregatedmachinetemplates",
+	} {
+		mapper.AddSpecific(kind, kind.GroupVersion().WithResource(plural), kind.GroupVersion().WithResource(plural), meta.RESTScopeNamespace)
+	}
+
+	v := &ReferenceValidator{
+		Client:     c,
+		RESTMapper: mapper,
+		References: MachineTemplateReferences,
+	}
+
+	machineDeployment := func(infrastructureAPIVersion, infrastructureKind string) *clusterv1.MachineDeployment {
+		return &clusterv1.MachineDeployment{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "md"},
+			Spec: clusterv1.MachineDeploymentSpec{
+				Template: clusterv1.MachineTemplateSpec{
+					Spec: clusterv1.MachineSpec{
+						Bootstrap: clusterv1.Bootstrap{
+							ConfigRef: &corev1.ObjectReference{APIVersion: "bootstrap.cluster.x-k8s.io/v1alpha3", Kind: "BootstrapMachineTemplate", Name: "bootstrap"},
+						},
+						InfrastructureRef: corev1.ObjectReference{APIVersion: infrastructureAPIVersion, Kind: infrastructureKind, Name: "infra"},
+					},
+				},
+			},
+		}
+	}
+	request := func(operation admissionv1beta1.Operation, obj, oldObj *clusterv1.MachineDeployment) admission.Request {
+		req := admission.Request{AdmissionRequest: admissionv1beta1.AdmissionRequest{
+			Kind:      metav1.GroupVersionKind{Group: clusterv1.GroupVersion.Group, Version: clusterv1.GroupVersion.Version, Kind: "MachineDeployment"},
+			Operation: operation,
+		}}
+		req.Object.Raw, _ = json.Marshal(obj)
+		if oldObj != nil {
+			req.OldObject.Raw, _ = json.Marshal(oldObj)
+		}
+		return req
+	}
+
+	tests := []struct {
+		name    string
+		req     admission.Request
+		allowed bool
+	}{
+		{
+			name:    "references to kinds implementing the contract are allowed",
+			req:     request(admissionv1beta1.Create, machineDeployment("infrastructure.cluster.x-k8s.io/v1alpha3", "InfrastructureMachineTemplate"), nil),
+			allowed: true,
+		},
+		{
+			name:    "references to a mistyped kind are rejected",
+			req:     request(admissionv1beta1.Create, machineDeployment("infrastructure.cluster.x-k8s.io/v1alpha3", "InfrastructureMachineTemplat"), nil),
+			allowed: false,
+		},
+		{
+			name:    "references to a mistyped apiVersion are rejected",
+			req:     request(admissionv1beta1.Create, machineDeployment("infrastructure.cluster.x-k8s.io/v1alpha1", "InfrastructureMachineTemplate"), nil),
+			allowed: false,
+		},
+		{
+			name:    "references to kinds not implementing the contract are rejected",
+			req:     request(admissionv1beta1.Create, machineDeployment("infrastructure.cluster.x-k8s.io/v1alpha3", "UnlabeledMachineTemplate"), nil),
+			allowed: false,
+		},
+		{
+			name:    "references to kinds served by an aggregated apiserver are allowed",
+			req:     request(admissionv1beta1.Create, machineDeployment("infrastructure.cluster.x-k8s.io/v1alpha3", "AggregatedMachineTemplate"), nil),
+			allowed: true,
+		},
+		{
+			name: "unchanged references are not validated on update",
+			req: request(admissionv1beta1.Update,
+				machineDeployment("infrastructure.cluster.x-k8s.io/v1alpha3", "UnlabeledMachineTemplate"),
+				machineDeployment("infrastructure.cluster.x-k8s.io/v1alpha3", "UnlabeledMachineTemplate")),
+			allowed: true,
+		},
+		{
+			name: "changed references are validated on update",
+			req: request(admissionv1beta1.Update,
+				machineDeployment("infrastructure.cluster.x-k8s.io/v1alpha3", "UnlabeledMachineTemplate"),
+				machineDeployment("infrastructure.cluster.x-k8s.io/v1alpha3", "InfrastructureMachineTemplate")),
+			allowed: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			resp := v.Handle(context.Background(), tt.req)
+			g.Expect(resp.Allowed).To(Equal(tt.allowed), "response: %v", resp.Result)
+		})
+	}
+}
@@ -0,0 +1,197 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	kerrors "k8s.io/apimachinery/pkg/util/errors"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/util"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/cluster-api/util/patch"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+const (
+	// ipAddressReleaseRequeueAfter is how long to wait before checking again whether the IPAddresses
+	// allocated for a deleted claim have been released.
+	ipAddressReleaseRequeueAfter = 5 * time.Second
+)
+
+// +kubebuilder:rbac:groups=cluster.x-k8s.io,resources=ipaddressclaims;ipaddressclaims/status,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=cluster.x-k8s.io,resources=ipaddresses,verbs=get;list;watch;create;update;patch;delete
+
+// IPAddressClaimReconciler reconciles an IPAddressClaim object.
+// Addresses are allocated by IPAM providers; the core controller only reports the allocation status
+// and releases the allocated IPAddresses when the claim is deleted.
+type IPAddressClaimReconciler struct {
+	Client client.Client
+	Log    logr.Logger
+}
+
+func (r *IPAddressClaimReconciler) SetupWithManager(mgr ctrl.Manager, options controller.Options) error {
+	err := ctrl.NewControllerManagedBy(mgr).
+		For(&clusterv1.IPAddressClaim{}).
+		Watches(
+			&source.Kind{Type: &clusterv1.IPAddress{}},
+			&handler.EnqueueRequestsFromMapFunc{ToRequests: handler.ToRequestsFunc(r.ipAddressToIPAddressClaim)},
+		).
+		WithOptions(options).
+		Complete(r)
+
+	if err != nil {
+		return errors.Wrap(err, "failed setting up with a controller manager")
+	}
+	return nil
+}
+
+func (r *IPAddressClaimReconciler) Reconcile(req ctrl.Request) (_ ctrl.Result, reterr error) {
+	ctx := context.Background()
+	logger := r.Log.WithValues("ipaddressclaim", req.Name, "namespace", req.Namespace)
+
+	// Fetch the IPAddressClaim instance.
+	claim := &clusterv1.IPAddressClaim{}
+	if err := r.Client.Get(ctx, req.NamespacedName, claim); err != nil {
+		if apierrors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	// Initialize the patch helper.
+	patchHelper, err := patch.NewHelper(claim, r.Client)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	defer func() {
+		// Always attempt to Patch the IPAddressClaim object and status after each reconciliation.
+		if err := patchHelper.Patch(ctx, claim); err != nil {
+			reterr = kerrors.NewAggregate([]error{reterr, err})
+		}
+	}()
+
+	// Handle deletion reconciliation loop.
+	if !claim.DeletionTimestamp.IsZero() {
+		return r.reconcileDelete(ctx, logger, claim)
+	}
+
+	// Add the finalizer first if not exist to avoid the race condition between init and delete.
+	if !util.Contains(claim.Finalizers, clusterv1.IPAddressClaimFinalizer) {
+		controllerutil.AddFinalizer(claim, clusterv1.IPAddressClaimFinalizer)
+		return ctrl.Result{}, nil
+	}
+
+	return ctrl.Result{}, r.reconcileNormal(ctx, claim)
+}
+
+// reconcileNormal reports whether an IPAddress has been allocated for the claim by the IPAM provider.
+func (r *IPAddressClaimReconciler) reconcileNormal(ctx context.Context, claim *clusterv1.IPAddressClaim) error {
+	if claim.Status.AddressRef.Name == "" {
+		conditions.MarkFalse(claim, clusterv1.AddressAllocatedCondition, clusterv1.WaitingForIPAddressReason, clusterv1.ConditionSeverityInfo, "")
+		return nil
+	}
+
+	address := &clusterv1.IPAddress{}
+	key := client.ObjectKey{Namespace: claim.Namespace, Name: claim.Status.AddressRef.Name}
+	if err := r.Client.Get(ctx, key, address); err != nil {
+		if apierrors.IsNotFound(err) {
+			conditions.MarkFalse(claim, clusterv1.AddressAllocatedCondition, clusterv1.WaitingForIPAddressReason, clusterv1.ConditionSeverityInfo, "IPAddress %s does not exist", key.Name)
+			return nil
+		}
+		return errors.Wrapf(err, "failed to get IPAddress %s for IPAddressClaim %s", key.Name, claim.Name)
+	}
+
+	conditions.MarkTrue(claim, clusterv1.AddressAllocatedCondition)
+	return nil
+}
+
+// reconcileDelete releases the IPAddresses allocated for the claim, and removes the finalizer once they are gone.
+func (r *IPAddressClaimReconciler) reconcileDelete(ctx context.Context, logger logr.Logger, claim *clusterv1.IPAddressClaim) (ctrl.Result, error) {
+	addresses, err := r.getAddressesForClaim(ctx, claim)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	if len(addresses) == 0 {
+		controllerutil.RemoveFinalizer(claim, clusterv1.IPAddressClaimFinalizer)
+		return ctrl.Result{}, nil
+	}
+
+	var errs []error
+	for i := range addresses {
+		address := &addresses[i]
+		if !address.DeletionTimestamp.IsZero() {
+			continue
+		}
+		logger.Info("Releasing IPAddress", "ipaddress", address.Name)
+		if err := r.Client.Delete(ctx, address); err != nil && !apierrors.IsNotFound(err) {
+			errs = append(errs, errors.Wrapf(err, "failed to delete IPAddress %s", address.Name))
+		}
+	}
+	if len(errs) > 0 {
+		return ctrl.Result{}, kerrors.NewAggregate(errs)
+	}
+
+	// Wait for the IPAM provider to release the addresses before removing the finalizer.
+	return ctrl.Result{RequeueAfter: ipAddressReleaseRequeueAfter}, nil
+}
+
+// getAddressesForClaim returns the IPAddresses referencing the claim.
+func (r *IPAddressClaimReconciler) getAddressesForClaim(ctx context.Context, claim *clusterv1.IPAddressClaim) ([]clusterv1.IPAddress, error) {
+	addressList := &clusterv1.IPAddressList{}
+	if err := r.Client.List(ctx, addressList, client.InNamespace(claim.Namespace)); err != nil {
+		return nil, errors.Wrapf(err, "failed to list IPAddresses for IPAddressClaim %s", claim.Name)
+	}
+
+	addresses := []clusterv1.IPAddress{}
+	for _, address := range addressList.Items {
+		if address.Spec.ClaimRef.Name == claim.Name {
+			addresses = append(addresses, address)
+		}
+	}
+	return addresses, nil
+}
+
+// ipAddressToIPAddressClaim is a handler.ToRequestsFunc to be used to enqueue requests for reconciliation
+// of the IPAddressClaim referenced by an IPAddress.
+func (r *IPAddressClaimReconciler) ipAddressToIPAddressClaim(o handler.MapObject) []reconcile.Request {
+	address, ok := o.Object.(*clusterv1.IPAddress)
+	if !ok {
+		r.Log.Error(errors.Errorf("expected an IPAddress, got %T", o.Object), "failed to get IPAddressClaim for IPAddress")
+		return nil
+	}
+
+	if address.Spec.ClaimRef.Name == "" {
+		return nil
+	}
+
+	return []reconcile.Request{
+		{NamespacedName: client.ObjectKey{Namespace: address.Namespace, Name: address.Spec.ClaimRef.Name}},
+	}
+}
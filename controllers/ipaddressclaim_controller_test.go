@@ -0,0 +1,138 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/utils/pointer"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func newTestIPAddressClaim(name string) *clusterv1.IPAddressClaim {
+	return &clusterv1.IPAddressClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       name,
+			Namespace:  "default",
+			Finalizers: []string{clusterv1.IPAddressClaimFinalizer},
+		},
+		Spec: clusterv1.IPAddressClaimSpec{
+			PoolRef: corev1.TypedLocalObjectReference{APIGroup: pointer.StringPtr("ipam.example.com"), Kind: "IPPool", Name: "pool"},
+		},
+	}
+}
+
+func newTestIPAddress(name, claimName string) *clusterv1.IPAddress {
+	return &clusterv1.IPAddress{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Spec: clusterv1.IPAddressSpec{
+			ClaimRef: corev1.LocalObjectReference{Name: claimName},
+			PoolRef:  corev1.TypedLocalObjectReference{APIGroup: pointer.StringPtr("ipam.example.com"), Kind: "IPPool", Name: "pool"},
+			Address:  "10.0.0.10",
+			Prefix:   24,
+		},
+	}
+}
+
+func newTestIPAddressClaimReconciler(objs ...runtime.Object) *IPAddressClaimReconciler {
+	return &IPAddressClaimReconciler{
+		Client: fake.NewFakeClientWithScheme(scheme.Scheme, objs...),
+		Log:    log.Log,
+	}
+}
+
+func TestIPAddressClaimReconcileNormal(t *testing.T) {
+	t.Run("waits for the IPAM provider to allocate an address", func(t *testing.T) {
+		g := NewWithT(t)
+		g.Expect(clusterv1.AddToScheme(scheme.Scheme)).To(Succeed())
+
+		claim := newTestIPAddressClaim("claim")
+		r := newTestIPAddressClaimReconciler(claim)
+
+		g.Expect(r.reconcileNormal(context.Background(), claim)).To(Succeed())
+		g.Expect(conditions.IsFalse(claim, clusterv1.AddressAllocatedCondition)).To(BeTrue())
+		g.Expect(conditions.GetReason(claim, clusterv1.AddressAllocatedCondition)).To(Equal(clusterv1.WaitingForIPAddressReason))
+	})
+
+	t.Run("waits for the referenced address to exist", func(t *testing.T) {
+		g := NewWithT(t)
+		g.Expect(clusterv1.AddToScheme(scheme.Scheme)).To(Succeed())
+
+		claim := newTestIPAddressClaim("claim")
+		claim.Status.AddressRef = corev1.LocalObjectReference{Name: "address"}
+		r := newTestIPAddressClaimReconciler(claim)
+
+		g.Expect(r.reconcileNormal(context.Background(), claim)).To(Succeed())
+		g.Expect(conditions.IsFalse(claim, clusterv1.AddressAllocatedCondition)).To(BeTrue())
+	})
+
+	t.Run("reports the allocated address", func(t *testing.T) {
+		g := NewWithT(t)
+		g.Expect(clusterv1.AddToScheme(scheme.Scheme)).To(Succeed())
+
+		claim := newTestIPAddressClaim("claim")
+		claim.Status.AddressRef = corev1.LocalObjectReference{Name: "address"}
+		r := newTestIPAddressClaimReconciler(claim, newTestIPAddress("address", "claim"))
+
+		g.Expect(r.reconcileNormal(context.Background(), claim)).To(Succeed())
+		g.Expect(conditions.IsTrue(claim, clusterv1.AddressAllocatedCondition)).To(BeTrue())
+	})
+}
+
+func TestIPAddressClaimReconcileDelete(t *testing.T) {
+	t.Run("releases the addresses allocated for the claim", func(t *testing.T) {
+		g := NewWithT(t)
+		g.Expect(clusterv1.AddToScheme(scheme.Scheme)).To(Succeed())
+
+		claim := newTestIPAddressClaim("claim")
+		r := newTestIPAddressClaimReconciler(claim, newTestIPAddress("address", "claim"), newTestIPAddress("other-address", "other-claim"))
+
+		result, err := r.reconcileDelete(context.Background(), r.Log, claim)
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(result.RequeueAfter).To(Equal(ipAddressReleaseRequeueAfter))
+		g.Expect(claim.Finalizers).To(ContainElement(clusterv1.IPAddressClaimFinalizer))
+
+		err = r.Client.Get(context.Background(), client.ObjectKey{Namespace: "default", Name: "address"}, &clusterv1.IPAddress{})
+		g.Expect(apierrors.IsNotFound(err)).To(BeTrue())
+		g.Expect(r.Client.Get(context.Background(), client.ObjectKey{Namespace: "default", Name: "other-address"}, &clusterv1.IPAddress{})).To(Succeed())
+	})
+
+	t.Run("removes the finalizer once the addresses are released", func(t *testing.T) {
+		g := NewWithT(t)
+		g.Expect(clusterv1.AddToScheme(scheme.Scheme)).To(Succeed())
+
+		claim := newTestIPAddressClaim("claim")
+		r := newTestIPAddressClaimReconciler(claim, newTestIPAddress("other-address", "other-claim"))
+
+		result, err := r.reconcileDelete(context.Background(), r.Log, claim)
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(result).To(Equal(ctrl.Result{}))
+		g.Expect(claim.Finalizers).NotTo(ContainElement(clusterv1.IPAddressClaimFinalizer))
+	})
+}
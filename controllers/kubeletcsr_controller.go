@@ -0,0 +1,403 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/pkg/errors"
+	certificatesv1beta1 "k8s.io/api/certificates/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/kubernetes"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/controllers/noderefutil"
+	"sigs.k8s.io/cluster-api/controllers/remote"
+	"sigs.k8s.io/cluster-api/util"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+const (
+	// defaultKubeletCSRCheckInterval is the interval the pending CSRs of a workload cluster are checked at, when the
+	// KubeletCSRApproverReconciler does not define it.
+	defaultKubeletCSRCheckInterval = 30 * time.Second
+
+	// kubeletCSRApprovedReason is the reason of the Approved condition set on the CSRs approved by Cluster API.
+	kubeletCSRApprovedReason = "ClusterAPIApproved"
+
+	nodeUserPrefix      = "system:node:"
+	nodesGroup          = "system:nodes"
+	bootstrapUserPrefix = "system:bootstrap:"
+)
+
+var (
+	kubeletClientUsages = []certificatesv1beta1.KeyUsage{
+		certificatesv1beta1.UsageDigitalSignature,
+		certificatesv1beta1.UsageKeyEncipherment,
+		certificatesv1beta1.UsageClientAuth,
+	}
+	kubeletServingUsages = []certificatesv1beta1.KeyUsage{
+		certificatesv1beta1.UsageDigitalSignature,
+		certificatesv1beta1.UsageKeyEncipherment,
+		certificatesv1beta1.UsageServerAuth,
+	}
+)
+
+// kubeClientGetter returns a Kubernetes clientset for the workload cluster.
+type kubeClientGetter func(ctx context.Context, c client.Client, cluster *clusterv1.Cluster) (kubernetes.Interface, error)
+
+// +kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;watch
+// +kubebuilder:rbac:groups=cluster.x-k8s.io,resources=clusters;machines,verbs=get;list;watch
+
+// KubeletCSRApproverReconciler approves the kubelet CSRs of the workload clusters that are requested by the Nodes of
+// the Machines managed by Cluster API, so the workload clusters don't need to approve all the kubelet CSRs.
+type KubeletCSRApproverReconciler struct {
+	Client client.Client
+	Log    logr.Logger
+
+	// CheckInterval is the interval the pending CSRs of a workload cluster are checked at.
+	CheckInterval time.Duration
+
+	kubeClientGetter kubeClientGetter
+}
+
+func (r *KubeletCSRApproverReconciler) SetupWithManager(mgr ctrl.Manager, options controller.Options) error {
+	err := ctrl.NewControllerManagedBy(mgr).
+		For(&clusterv1.Cluster{}).
+		Named("kubeletcsrapprover").
+		Watches(
+			&source.Kind{Type: &clusterv1.Machine{}},
+			&handler.EnqueueRequestsFromMapFunc{ToRequests: handler.ToRequestsFunc(r.machineToCluster)},
+		).
+		WithOptions(options).
+		Complete(r)
+
+	if err != nil {
+		return errors.Wrap(err, "failed setting up with a controller manager")
+	}
+
+	if r.kubeClientGetter == nil {
+		r.kubeClientGetter = newKubeClient
+	}
+	return nil
+}
+
+func (r *KubeletCSRApproverReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
+	ctx := context.Background()
+	logger := r.Log.WithValues("cluster", req.Name, "namespace", req.Namespace)
+
+	// Fetch the Cluster instance.
+	cluster := &clusterv1.Cluster{}
+	if err := r.Client.Get(ctx, req.NamespacedName, cluster); err != nil {
+		if apierrors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	if !cluster.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, nil
+	}
+
+	// Return early if the Cluster is paused.
+	if util.IsPaused(cluster, cluster) {
+		logger.V(3).Info("reconciliation is paused for this object")
+		return ctrl.Result{}, nil
+	}
+
+	// CSRs can't be checked until the workload cluster API server is reachable.
+	if !cluster.Status.ControlPlaneInitialized {
+		logger.V(3).Info("Cluster control plane is not initialized yet, skipping kubelet CSRs approval")
+		return ctrl.Result{}, nil
+	}
+
+	kubeClient, err := r.kubeClientGetter(ctx, r.Client, cluster)
+	if err != nil {
+		return ctrl.Result{}, errors.Wrapf(err, "error creating a remote client for cluster %q", cluster.Name)
+	}
+
+	if err := r.reconcile(ctx, cluster, kubeClient); err != nil {
+		logger.Error(err, "Failed to approve kubelet CSRs")
+		return ctrl.Result{}, err
+	}
+
+	// CSRs are not watched, so they are checked again after the check interval.
+	interval := r.CheckInterval
+	if interval <= 0 {
+		interval = defaultKubeletCSRCheckInterval
+	}
+	return ctrl.Result{RequeueAfter: interval}, nil
+}
+
+// reconcile approves the pending kubelet CSRs of the workload cluster that correspond to a Machine of the Cluster.
+// The CSRs that don't correspond to a Machine are left pending, so they can be approved or denied by others.
+func (r *KubeletCSRApproverReconciler) reconcile(ctx context.Context, cluster *clusterv1.Cluster, kubeClient kubernetes.Interface) error {
+	logger := r.Log.WithValues("cluster", cluster.Name, "namespace", cluster.Namespace)
+
+	machines, err := util.GetMachinesForCluster(ctx, r.Client, cluster)
+	if err != nil {
+		return errors.Wrapf(err, "failed to list Machines for Cluster %q", cluster.Name)
+	}
+
+	csrs, err := kubeClient.CertificatesV1beta1().CertificateSigningRequests().List(metav1.ListOptions{})
+	if err != nil {
+		return errors.Wrapf(err, "failed to list CSRs of Cluster %q", cluster.Name)
+	}
+
+	errs := []error{}
+	for i := range csrs.Items {
+		csr := &csrs.Items[i]
+		if !isPendingCSR(csr) {
+			continue
+		}
+
+		machine, err := machineForKubeletCSR(kubeClient, csr, machines.Items)
+		if err != nil {
+			logger.V(3).Info("Not approving CSR", "csr", csr.Name, "reason", err.Error())
+			continue
+		}
+
+		csr.Status.Conditions = append(csr.Status.Conditions, certificatesv1beta1.CertificateSigningRequestCondition{
+			Type:           certificatesv1beta1.CertificateApproved,
+			Reason:         kubeletCSRApprovedReason,
+			Message:        "The CSR has been requested by the Node of Machine " + machine.Name,
+			LastUpdateTime: metav1.Now(),
+		})
+		if _, err := kubeClient.CertificatesV1beta1().CertificateSigningRequests().UpdateApproval(csr); err != nil {
+			errs = append(errs, errors.Wrapf(err, "failed to approve CSR %q", csr.Name))
+			continue
+		}
+		logger.Info("Approved kubelet CSR", "csr", csr.Name, "machine", machine.Name)
+	}
+	return kerrors.NewAggregate(errs)
+}
+
+// machineForKubeletCSR returns the Machine whose Node requested the given kubelet client or serving CSR, or an error
+// explaining why the CSR does not correspond to any Machine.
+func machineForKubeletCSR(kubeClient kubernetes.Interface, csr *certificatesv1beta1.CertificateSigningRequest, machines []clusterv1.Machine) (*clusterv1.Machine, error) {
+	x509cr, err := parseCSR(csr)
+	if err != nil {
+		return nil, err
+	}
+
+	if !strings.HasPrefix(x509cr.Subject.CommonName, nodeUserPrefix) || !reflect.DeepEqual(x509cr.Subject.Organization, []string{nodesGroup}) {
+		return nil, errors.New("the CSR is not requested for a Node")
+	}
+	nodeName := strings.TrimPrefix(x509cr.Subject.CommonName, nodeUserPrefix)
+	if len(x509cr.EmailAddresses) > 0 || len(x509cr.URIs) > 0 {
+		return nil, errors.New("the CSR has email or URI SANs")
+	}
+
+	node, err := kubeClient.CoreV1().Nodes().Get(nodeName, metav1.GetOptions{})
+	if err != nil {
+		if !apierrors.IsNotFound(err) {
+			return nil, errors.Wrapf(err, "failed to get Node %q", nodeName)
+		}
+		node = nil
+	}
+
+	switch usages := csr.Spec.Usages; {
+	case hasExactUsages(usages, kubeletClientUsages):
+		if len(x509cr.DNSNames) > 0 || len(x509cr.IPAddresses) > 0 {
+			return nil, errors.New("the client CSR has DNS or IP SANs")
+		}
+		switch {
+		case csr.Spec.Username == nodeUserPrefix+nodeName:
+			// The Node renews its own client certificate.
+			return machineForNode(node, machines)
+		case strings.HasPrefix(csr.Spec.Username, bootstrapUserPrefix):
+			// A bootstrap token can't be used to request the credentials of an existing Node.
+			if node != nil {
+				return nil, errors.Errorf("the client CSR is requested with a bootstrap token for the existing Node %q", nodeName)
+			}
+			return machineForJoiningNode(nodeName, machines)
+		}
+		return nil, errors.Errorf("the client CSR is requested by %q, neither the Node nor a bootstrap token", csr.Spec.Username)
+	case hasExactUsages(usages, kubeletServingUsages):
+		if csr.Spec.Username != nodeUserPrefix+nodeName {
+			return nil, errors.Errorf("the serving CSR is requested by %q, not by the Node", csr.Spec.Username)
+		}
+		machine, err := machineForNode(node, machines)
+		if err != nil {
+			return nil, err
+		}
+		if err := checkServingSANs(x509cr, nodeName, machine); err != nil {
+			return nil, err
+		}
+		return machine, nil
+	}
+	return nil, errors.New("the CSR usages are not the ones of a kubelet client or serving certificate")
+}
+
+// machineForNode returns the Machine referencing the Node, whose provider ID must match the one of the Node.
+func machineForNode(node *corev1.Node, machines []clusterv1.Machine) (*clusterv1.Machine, error) {
+	if node == nil {
+		return nil, errors.New("the Node does not exist")
+	}
+	for i := range machines {
+		machine := &machines[i]
+		if machine.Status.NodeRef == nil || machine.Status.NodeRef.Name != node.Name || !machine.DeletionTimestamp.IsZero() {
+			continue
+		}
+		if machine.Spec.ProviderID == nil {
+			return nil, errors.Errorf("Machine %q does not have a provider ID", machine.Name)
+		}
+		machineProviderID, err := noderefutil.NewProviderID(*machine.Spec.ProviderID)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to parse the provider ID of Machine %q", machine.Name)
+		}
+		nodeProviderID, err := noderefutil.NewProviderID(node.Spec.ProviderID)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to parse the provider ID of Node %q", node.Name)
+		}
+		if !machineProviderID.Equals(nodeProviderID) {
+			return nil, errors.Errorf("the provider ID of Node %q does not match the one of Machine %q", node.Name, machine.Name)
+		}
+		return machine, nil
+	}
+	return nil, errors.Errorf("no Machine references Node %q", node.Name)
+}
+
+// machineForJoiningNode returns the Machine without a Node yet, whose host name is the name of the joining Node.
+func machineForJoiningNode(nodeName string, machines []clusterv1.Machine) (*clusterv1.Machine, error) {
+	for i := range machines {
+		machine := &machines[i]
+		if machine.Status.NodeRef != nil || !machine.DeletionTimestamp.IsZero() {
+			continue
+		}
+		if machineDNSNames(machine).Has(nodeName) {
+			return machine, nil
+		}
+	}
+	return nil, errors.Errorf("no Machine without a Node has the address %q", nodeName)
+}
+
+// checkServingSANs checks that the DNS names and IP addresses of a serving CSR are the addresses of the Machine.
+func checkServingSANs(x509cr *x509.CertificateRequest, nodeName string, machine *clusterv1.Machine) error {
+	if len(x509cr.DNSNames) == 0 && len(x509cr.IPAddresses) == 0 {
+		return errors.New("the serving CSR has no DNS or IP SANs")
+	}
+
+	dnsNames := machineDNSNames(machine).Insert(nodeName)
+	for _, name := range x509cr.DNSNames {
+		if !dnsNames.Has(name) {
+			return errors.Errorf("the DNS name %q is not an address of Machine %q", name, machine.Name)
+		}
+	}
+
+	ips := sets.NewString()
+	for _, address := range machine.Status.Addresses {
+		if address.Type == clusterv1.MachineInternalIP || address.Type == clusterv1.MachineExternalIP {
+			ips.Insert(address.Address)
+		}
+	}
+	for _, ip := range x509cr.IPAddresses {
+		if !ips.Has(ip.String()) {
+			return errors.Errorf("the IP address %q is not an address of Machine %q", ip, machine.Name)
+		}
+	}
+	return nil
+}
+
+func machineDNSNames(machine *clusterv1.Machine) sets.String {
+	names := sets.NewString()
+	for _, address := range machine.Status.Addresses {
+		switch address.Type {
+		case clusterv1.MachineHostName, clusterv1.MachineInternalDNS, clusterv1.MachineExternalDNS:
+			names.Insert(address.Address)
+		}
+	}
+	return names
+}
+
+// parseCSR decodes the PEM encoded certificate request of the CSR and checks its signature.
+func parseCSR(csr *certificatesv1beta1.CertificateSigningRequest) (*x509.CertificateRequest, error) {
+	block, _ := pem.Decode(csr.Spec.Request)
+	if block == nil || block.Type != "CERTIFICATE REQUEST" {
+		return nil, errors.New("the CSR does not contain a PEM encoded certificate request")
+	}
+	x509cr, err := x509.ParseCertificateRequest(block.Bytes)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse the certificate request")
+	}
+	if err := x509cr.CheckSignature(); err != nil {
+		return nil, errors.Wrap(err, "invalid signature of the certificate request")
+	}
+	return x509cr, nil
+}
+
+func isPendingCSR(csr *certificatesv1beta1.CertificateSigningRequest) bool {
+	for _, c := range csr.Status.Conditions {
+		if c.Type == certificatesv1beta1.CertificateApproved || c.Type == certificatesv1beta1.CertificateDenied {
+			return false
+		}
+	}
+	return true
+}
+
+func hasExactUsages(usages, expected []certificatesv1beta1.KeyUsage) bool {
+	if len(usages) != len(expected) {
+		return false
+	}
+	set := map[certificatesv1beta1.KeyUsage]bool{}
+	for _, u := range usages {
+		set[u] = true
+	}
+	for _, u := range expected {
+		if !set[u] {
+			return false
+		}
+	}
+	return true
+}
+
+// machineToCluster maps a Machine to its Cluster, so the CSRs of joining Nodes are checked as soon as the
+// addresses of the Machine are known.
+func (r *KubeletCSRApproverReconciler) machineToCluster(o handler.MapObject) []reconcile.Request {
+	m, ok := o.Object.(*clusterv1.Machine)
+	if !ok {
+		r.Log.Error(errors.Errorf("expected a Machine, got %T", o.Object), "failed to get Cluster for Machine")
+		return nil
+	}
+
+	return []reconcile.Request{
+		{NamespacedName: client.ObjectKey{Namespace: m.Namespace, Name: m.Spec.ClusterName}},
+	}
+}
+
+// newKubeClient returns a Kubernetes clientset for the workload cluster, required for approving CSRs.
+func newKubeClient(ctx context.Context, c client.Client, cluster *clusterv1.Cluster) (kubernetes.Interface, error) {
+	restConfig, err := remote.RESTConfig(ctx, c, cluster)
+	if err != nil {
+		return nil, err
+	}
+	return kubernetes.NewForConfig(restConfig)
+}
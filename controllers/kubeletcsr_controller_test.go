@@ -0,0 +1,136 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"net"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	certificatesv1beta1 "k8s.io/api/certificates/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/klog/klogr"
+	"k8s.io/utils/pointer"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func newKubeletCSR(g *WithT, name, username, nodeName string, usages []certificatesv1beta1.KeyUsage, dnsNames []string, ips []net.IP) *certificatesv1beta1.CertificateSigningRequest {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	g.Expect(err).NotTo(HaveOccurred())
+	der, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		Subject:     pkix.Name{CommonName: nodeUserPrefix + nodeName, Organization: []string{nodesGroup}},
+		DNSNames:    dnsNames,
+		IPAddresses: ips,
+	}, key)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	return &certificatesv1beta1.CertificateSigningRequest{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec: certificatesv1beta1.CertificateSigningRequestSpec{
+			Request:  pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: der}),
+			Usages:   usages,
+			Username: username,
+		},
+	}
+}
+
+func TestKubeletCSRApproverReconcile(t *testing.T) {
+	g := NewWithT(t)
+
+	cluster := &clusterv1.Cluster{ObjectMeta: metav1.ObjectMeta{Name: "test-cluster", Namespace: "default"}}
+	machine := func(name string, nodeName string, addresses ...clusterv1.MachineAddress) *clusterv1.Machine {
+		m := &clusterv1.Machine{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: cluster.Namespace,
+				Labels:    map[string]string{clusterv1.ClusterLabelName: cluster.Name},
+			},
+			Spec: clusterv1.MachineSpec{
+				ClusterName: cluster.Name,
+				ProviderID:  pointer.StringPtr("aws:///us-east-1/" + name),
+			},
+			Status: clusterv1.MachineStatus{Addresses: addresses},
+		}
+		if nodeName != "" {
+			m.Status.NodeRef = &corev1.ObjectReference{Kind: "Node", Name: nodeName}
+		}
+		return m
+	}
+	node := func(name, providerID string) *corev1.Node {
+		return &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: name}, Spec: corev1.NodeSpec{ProviderID: providerID}}
+	}
+
+	g.Expect(clusterv1.AddToScheme(scheme.Scheme)).To(Succeed())
+	c := fake.NewFakeClientWithScheme(scheme.Scheme,
+		machine("m1", "node-1",
+			clusterv1.MachineAddress{Type: clusterv1.MachineHostName, Address: "node-1"},
+			clusterv1.MachineAddress{Type: clusterv1.MachineInternalIP, Address: "10.0.0.1"}),
+		machine("m2", "", clusterv1.MachineAddress{Type: clusterv1.MachineHostName, Address: "node-2"}),
+		machine("m3", "node-3"),
+	)
+
+	csrs := []*certificatesv1beta1.CertificateSigningRequest{
+		newKubeletCSR(g, "serving", "system:node:node-1", "node-1", kubeletServingUsages, []string{"node-1"}, []net.IP{net.ParseIP("10.0.0.1")}),
+		newKubeletCSR(g, "serving-unknown-ip", "system:node:node-1", "node-1", kubeletServingUsages, []string{"node-1"}, []net.IP{net.ParseIP("10.0.0.2")}),
+		newKubeletCSR(g, "serving-other-user", "system:node:node-3", "node-1", kubeletServingUsages, []string{"node-1"}, nil),
+		newKubeletCSR(g, "client-renewal", "system:node:node-1", "node-1", kubeletClientUsages, nil, nil),
+		newKubeletCSR(g, "client-bootstrap", "system:bootstrap:abcdef", "node-2", kubeletClientUsages, nil, nil),
+		newKubeletCSR(g, "client-bootstrap-existing-node", "system:bootstrap:abcdef", "node-1", kubeletClientUsages, nil, nil),
+		newKubeletCSR(g, "client-unknown-node", "system:bootstrap:abcdef", "node-4", kubeletClientUsages, nil, nil),
+		newKubeletCSR(g, "client-provider-id-mismatch", "system:node:node-3", "node-3", kubeletClientUsages, nil, nil),
+	}
+	objs := []runtime.Object{
+		node("node-1", "aws:///us-east-1/m1"),
+		node("node-3", "aws:///us-east-1/other"),
+	}
+	for _, csr := range csrs {
+		objs = append(objs, csr)
+	}
+	kubeClient := k8sfake.NewSimpleClientset(objs...)
+
+	r := &KubeletCSRApproverReconciler{Client: c, Log: klogr.New()}
+	g.Expect(r.reconcile(context.Background(), cluster, kubeClient)).To(Succeed())
+
+	approved := map[string]bool{}
+	list, err := kubeClient.CertificatesV1beta1().CertificateSigningRequests().List(metav1.ListOptions{})
+	g.Expect(err).NotTo(HaveOccurred())
+	for _, csr := range list.Items {
+		approved[csr.Name] = !isPendingCSR(&csr)
+	}
+	g.Expect(approved).To(Equal(map[string]bool{
+		"serving":                        true,
+		"serving-unknown-ip":             false,
+		"serving-other-user":             false,
+		"client-renewal":                 true,
+		"client-bootstrap":               true,
+		"client-bootstrap-existing-node": false,
+		"client-unknown-node":            false,
+		"client-provider-id-mismatch":    false,
+	}))
+}
@@ -0,0 +1,110 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/controllers/external"
+	"sigs.k8s.io/cluster-api/util"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// autoscalerCapacityAnnotations maps the resources reported in the capacity of the infrastructure machine templates
+// to the cluster-autoscaler annotations.
+var autoscalerCapacityAnnotations = map[corev1.ResourceName]string{
+	corev1.ResourceCPU:              clusterv1.AutoscalerCPUCapacityAnnotation,
+	corev1.ResourceMemory:           clusterv1.AutoscalerMemoryCapacityAnnotation,
+	corev1.ResourceEphemeralStorage: clusterv1.AutoscalerEphemeralDiskCapacityAnnotation,
+	corev1.ResourcePods:             clusterv1.AutoscalerMaxPodsCapacityAnnotation,
+}
+
+// getAutoscalerAnnotations returns the cluster-autoscaler annotations describing the Nodes of the Machines created from
+// a machine template: the capacity reported by the infrastructure machine template, the labels synced to the Nodes and
+// the Node taints.
+func getAutoscalerAnnotations(ctx context.Context, c client.Client, namespace string, template *clusterv1.MachineTemplateSpec) (map[string]string, error) {
+	annotations := map[string]string{}
+
+	if strings.HasSuffix(template.Spec.InfrastructureRef.Kind, external.TemplateSuffix) {
+		infraTemplate, err := external.Get(ctx, c, &template.Spec.InfrastructureRef, namespace)
+		if err != nil {
+			return nil, err
+		}
+		capacity, err := external.CapacityFrom(infraTemplate)
+		if err != nil {
+			return nil, err
+		}
+		for name, quantity := range capacity {
+			if annotation, ok := autoscalerCapacityAnnotations[name]; ok {
+				annotations[annotation] = quantity.String()
+				continue
+			}
+			// GPUs are extended resources named after their vendor, e.g. nvidia.com/gpu.
+			if strings.HasSuffix(string(name), "/gpu") {
+				annotations[clusterv1.AutoscalerGPUTypeAnnotation] = string(name)
+				annotations[clusterv1.AutoscalerGPUCountAnnotation] = quantity.String()
+			}
+		}
+	}
+
+	var labels []string
+	for key, value := range util.GetNodeMetadata(template.Labels) {
+		labels = append(labels, fmt.Sprintf("%s=%s", key, value))
+	}
+	if len(labels) > 0 {
+		sort.Strings(labels)
+		annotations[clusterv1.AutoscalerLabelsAnnotation] = strings.Join(labels, ",")
+	}
+
+	var taints []string
+	for _, taint := range template.Spec.NodeTaints {
+		taints = append(taints, fmt.Sprintf("%s=%s:%s", taint.Key, taint.Value, taint.Effect))
+	}
+	if len(taints) > 0 {
+		annotations[clusterv1.AutoscalerTaintsAnnotation] = strings.Join(taints, ",")
+	}
+
+	return annotations, nil
+}
+
+// setAutoscalerAnnotations sets the cluster-autoscaler annotations on a MachineDeployment or a MachineSet, and returns
+// true if they were changed. The annotations that can not be computed, e.g. because the infrastructure machine template
+// does not report its capacity, are left unchanged, so they can be set manually.
+func setAutoscalerAnnotations(obj metav1.Object, annotations map[string]string) bool {
+	current := obj.GetAnnotations()
+	changed := false
+	for key, value := range annotations {
+		if v, ok := current[key]; ok && v == value {
+			continue
+		}
+		if current == nil {
+			current = map[string]string{}
+		}
+		current[key] = value
+		changed = true
+	}
+	if changed {
+		obj.SetAnnotations(current)
+	}
+	return changed
+}
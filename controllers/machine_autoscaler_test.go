@@ -0,0 +1,107 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/kubernetes/scheme"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestGetAutoscalerAnnotations(t *testing.T) {
+	g := NewWithT(t)
+
+	infraTemplate := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"spec": map[string]interface{}{},
+			"status": map[string]interface{}{
+				"capacity": map[string]interface{}{
+					"cpu":               "4",
+					"memory":            "16Gi",
+					"ephemeral-storage": "100Gi",
+					"pods":              "110",
+					"nvidia.com/gpu":    "2",
+				},
+			},
+		},
+	}
+	infraTemplate.SetKind("InfrastructureMachineTemplate")
+	infraTemplate.SetAPIVersion("infrastructure.cluster.x-k8s.io/v1alpha3")
+	infraTemplate.SetName("template")
+	infraTemplate.SetNamespace("default")
+
+	template := &clusterv1.MachineTemplateSpec{
+		ObjectMeta: clusterv1.ObjectMeta{
+			Labels: map[string]string{
+				"env":                           "prod",
+				"node.cluster.x-k8s.io/gpu":     "true",
+				"node-role.kubernetes.io/infra": "",
+			},
+		},
+		Spec: clusterv1.MachineSpec{
+			InfrastructureRef: corev1.ObjectReference{
+				APIVersion: "infrastructure.cluster.x-k8s.io/v1alpha3",
+				Kind:       "InfrastructureMachineTemplate",
+				Name:       "template",
+			},
+			NodeTaints: []corev1.Taint{{Key: "dedicated", Value: "gpu", Effect: corev1.TaintEffectNoSchedule}},
+		},
+	}
+
+	c := fake.NewFakeClientWithScheme(scheme.Scheme, infraTemplate)
+	annotations, err := getAutoscalerAnnotations(context.Background(), c, "default", template)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(annotations).To(Equal(map[string]string{
+		clusterv1.AutoscalerCPUCapacityAnnotation:           "4",
+		clusterv1.AutoscalerMemoryCapacityAnnotation:        "16Gi",
+		clusterv1.AutoscalerEphemeralDiskCapacityAnnotation: "100Gi",
+		clusterv1.AutoscalerMaxPodsCapacityAnnotation:       "110",
+		clusterv1.AutoscalerGPUTypeAnnotation:               "nvidia.com/gpu",
+		clusterv1.AutoscalerGPUCountAnnotation:              "2",
+		clusterv1.AutoscalerLabelsAnnotation:                "node-role.kubernetes.io/infra=,node.cluster.x-k8s.io/gpu=true",
+		clusterv1.AutoscalerTaintsAnnotation:                "dedicated=gpu:NoSchedule",
+	}))
+}
+
+func TestSetAutoscalerAnnotations(t *testing.T) {
+	g := NewWithT(t)
+
+	ms := &clusterv1.MachineSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Annotations: map[string]string{
+				// Set manually, and not reported by the infrastructure machine template.
+				clusterv1.AutoscalerGPUCountAnnotation:    "1",
+				clusterv1.AutoscalerCPUCapacityAnnotation: "2",
+			},
+		},
+	}
+
+	g.Expect(setAutoscalerAnnotations(ms, map[string]string{clusterv1.AutoscalerCPUCapacityAnnotation: "4"})).To(BeTrue())
+	g.Expect(ms.Annotations).To(Equal(map[string]string{
+		clusterv1.AutoscalerGPUCountAnnotation:    "1",
+		clusterv1.AutoscalerCPUCapacityAnnotation: "4",
+	}))
+
+	g.Expect(setAutoscalerAnnotations(ms, map[string]string{clusterv1.AutoscalerCPUCapacityAnnotation: "4"})).To(BeFalse())
+}
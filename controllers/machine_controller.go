@@ -19,11 +19,14 @@ package controllers
 import (
 	"context"
 	"fmt"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/go-logr/logr"
 	"github.com/pkg/errors"
 	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
@@ -40,13 +43,19 @@ import (
 	"sigs.k8s.io/cluster-api/controllers/noderefutil"
 	"sigs.k8s.io/cluster-api/controllers/remote"
 	capierrors "sigs.k8s.io/cluster-api/errors"
+	"sigs.k8s.io/cluster-api/feature"
 	kubedrain "sigs.k8s.io/cluster-api/third_party/kubernetes-drain"
 	"sigs.k8s.io/cluster-api/util"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/cluster-api/util/fairness"
 	"sigs.k8s.io/cluster-api/util/patch"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 )
 
 var (
@@ -56,7 +65,7 @@ var (
 )
 
 // +kubebuilder:rbac:groups=core,resources=events,verbs=get;list;watch;create;patch
-// +kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;watch
+// +kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;watch;create
 // +kubebuilder:rbac:groups=core,resources=nodes,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io;bootstrap.cluster.x-k8s.io,resources=*,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=cluster.x-k8s.io,resources=machines;machines/status,verbs=get;list;watch;create;update;patch;delete
@@ -66,22 +75,40 @@ type MachineReconciler struct {
 	Client client.Client
 	Log    logr.Logger
 
+	// ClusterLimiter, if set, rate limits the reconciliations of the Machines of each cluster.
+	ClusterLimiter *fairness.ClusterLimiter
+
 	config          *rest.Config
 	scheme          *runtime.Scheme
 	recorder        record.EventRecorder
 	externalTracker external.ObjectTracker
+	nodeRefs        nodeRefCache
 }
 
 func (r *MachineReconciler) SetupWithManager(mgr ctrl.Manager, options controller.Options) error {
 	controller, err := ctrl.NewControllerManagedBy(mgr).
 		For(&clusterv1.Machine{}).
 		WithOptions(options).
-		Build(r)
+		Build(fairness.NewReconciler(mgr.GetClient(), &clusterv1.Machine{}, "machine", r.ClusterLimiter, r))
 
 	if err != nil {
 		return errors.Wrap(err, "failed setting up with a controller manager")
 	}
 
+	// Watch the Clusters being paused or resumed, for propagating the pause to the provider objects of the Machines.
+	err = controller.Watch(
+		&source.Kind{Type: &clusterv1.Cluster{}},
+		&handler.EnqueueRequestsFromMapFunc{ToRequests: handler.ToRequestsFunc(r.clusterToMachines)},
+		clusterPauseChanged,
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed adding Watch for Clusters to controller manager")
+	}
+
+	if err := r.addMachineIndexes(mgr); err != nil {
+		return err
+	}
+
 	r.recorder = mgr.GetEventRecorderFor("machine-controller")
 	r.config = mgr.GetConfig()
 	r.scheme = mgr.GetScheme()
@@ -114,8 +141,11 @@ func (r *MachineReconciler) Reconcile(req ctrl.Request) (_ ctrl.Result, reterr e
 			m.Spec.ClusterName, m.Name, m.Namespace)
 	}
 
-	// Return early if the object or Cluster is paused.
+	// Return early if the object or Cluster is paused, after propagating the pause to the referenced provider objects.
 	if util.IsPaused(cluster, m) {
+		if err := reconcilePausePropagation(ctx, r.Client, cluster, m.Namespace, m.Spec.Bootstrap.ConfigRef, &m.Spec.InfrastructureRef); err != nil {
+			return ctrl.Result{}, err
+		}
 		logger.V(3).Info("reconciliation is paused for this object")
 		return ctrl.Result{}, nil
 	}
@@ -130,6 +160,17 @@ func (r *MachineReconciler) Reconcile(req ctrl.Request) (_ ctrl.Result, reterr e
 		r.reconcilePhase(ctx, m)
 		r.reconcileMetrics(ctx, m)
 
+		// Always update the Ready condition with the summary of the Machine conditions; while the Machine
+		// is being deleted, the Ready condition is set by reconcileDelete.
+		if m.ObjectMeta.DeletionTimestamp.IsZero() {
+			conditions.SetSummary(m,
+				conditions.WithConditions(
+					clusterv1.BootstrapReadyCondition,
+					clusterv1.InfrastructureReadyCondition,
+				),
+			)
+		}
+
 		// Always attempt to patch the object and status after each reconciliation.
 		if err := patchHelper.Patch(ctx, m); err != nil {
 			reterr = kerrors.NewAggregate([]error{reterr, err})
@@ -141,6 +182,9 @@ func (r *MachineReconciler) Reconcile(req ctrl.Request) (_ ctrl.Result, reterr e
 		m.Labels = make(map[string]string)
 	}
 	m.Labels[clusterv1.ClusterLabelName] = m.Spec.ClusterName
+	for key, value := range util.GetPropagatedLabels(cluster) {
+		m.Labels[key] = value
+	}
 
 	// Handle deletion reconciliation loop.
 	if !m.ObjectMeta.DeletionTimestamp.IsZero() {
@@ -173,6 +217,9 @@ func (r *MachineReconciler) reconcile(ctx context.Context, cluster *clusterv1.Cl
 		r.reconcileBootstrap(ctx, cluster, m),
 		r.reconcileInfrastructure(ctx, cluster, m),
 		r.reconcileNodeRef(ctx, cluster, m),
+		r.reconcileNodeMetadata(ctx, cluster, m),
+		r.reconcileNodeReadyTime(ctx, cluster, m),
+		r.reconcilePhaseTimeout(ctx, cluster, m),
 	}
 
 	// Parse the errors, making sure we record if there is a RequeueAfterError.
@@ -210,13 +257,48 @@ func (r *MachineReconciler) reconcileMetrics(_ context.Context, m *clusterv1.Mac
 	} else {
 		metrics.MachineNodeReady.WithLabelValues(m.Name, m.Namespace, m.Spec.ClusterName).Set(0)
 	}
+	if conditions.GetReason(m, clusterv1.InfrastructureReadyCondition) == clusterv1.InfrastructureNotFoundReason {
+		metrics.MachineReferenceNotFound.WithLabelValues(m.Name, m.Namespace, m.Spec.ClusterName, "infrastructure").Set(1)
+	} else {
+		metrics.MachineReferenceNotFound.WithLabelValues(m.Name, m.Namespace, m.Spec.ClusterName, "infrastructure").Set(0)
+	}
+	if conditions.GetReason(m, clusterv1.BootstrapReadyCondition) == clusterv1.BootstrapConfigNotFoundReason {
+		metrics.MachineReferenceNotFound.WithLabelValues(m.Name, m.Namespace, m.Spec.ClusterName, "bootstrap").Set(1)
+	} else {
+		metrics.MachineReferenceNotFound.WithLabelValues(m.Name, m.Namespace, m.Spec.ClusterName, "bootstrap").Set(0)
+	}
+	for _, phase := range []clusterv1.MachinePhase{
+		clusterv1.MachinePhasePending,
+		clusterv1.MachinePhaseProvisioning,
+		clusterv1.MachinePhaseProvisioned,
+		clusterv1.MachinePhaseRunning,
+		clusterv1.MachinePhaseDeleting,
+		clusterv1.MachinePhaseDeleted,
+		clusterv1.MachinePhaseFailed,
+		clusterv1.MachinePhaseUnknown,
+	} {
+		if m.Status.GetTypedPhase() == phase {
+			metrics.MachinePhase.WithLabelValues(m.Name, m.Namespace, m.Spec.ClusterName, string(phase)).Set(1)
+		} else {
+			metrics.MachinePhase.WithLabelValues(m.Name, m.Namespace, m.Spec.ClusterName, string(phase)).Set(0)
+		}
+	}
 }
 
 func (r *MachineReconciler) reconcileDelete(ctx context.Context, cluster *clusterv1.Cluster, m *clusterv1.Machine) (ctrl.Result, error) {
 	logger := r.Log.WithValues("machine", m.Name, "namespace", m.Namespace)
 	logger = logger.WithValues("cluster", cluster.Name)
 
-	if err := r.isDeleteNodeAllowed(ctx, m); err != nil {
+	conditions.MarkFalse(m, clusterv1.ReadyCondition, clusterv1.DeletingReason, clusterv1.ConditionSeverityInfo, "")
+
+	// Wait for the pre-drain delete hooks to be removed before draining the node.
+	if feature.Gates.Enabled(feature.LifecycleHooks) {
+		if result, blocked := r.waitForDeleteHooks(logger, m, clusterv1.PreDrainDeleteHookAnnotationPrefix, clusterv1.PreDrainDeleteHookSucceededCondition, m.Spec.PreDrainHookTimeout); blocked {
+			return result, nil
+		}
+	}
+
+	if err := r.isDeleteNodeAllowed(ctx, cluster, m); err != nil {
 		switch err {
 		case errNilNodeRef:
 			logger.Error(err, "Deleting node is not allowed")
@@ -228,13 +310,17 @@ func (r *MachineReconciler) reconcileDelete(ctx context.Context, cluster *cluste
 		}
 	} else {
 		// Drain node before deletion
-		if _, exists := m.ObjectMeta.Annotations[clusterv1.ExcludeNodeDrainingAnnotation]; !exists {
-			logger.Info("Draining node", "node", m.Status.NodeRef.Name)
-			if err := r.drainNode(ctx, cluster, m.Status.NodeRef.Name, m.Name); err != nil {
-				r.recorder.Eventf(m, corev1.EventTypeWarning, "FailedDrainNode", "error draining Machine's node %q: %v", m.Status.NodeRef.Name, err)
+		if _, exists := m.ObjectMeta.Annotations[clusterv1.ExcludeNodeDrainingAnnotation]; !exists && !m.Spec.SkipNodeDrain {
+			if err := r.reconcileDrainNode(ctx, cluster, m); err != nil {
 				return ctrl.Result{}, err
 			}
-			r.recorder.Eventf(m, corev1.EventTypeNormal, "SuccessfulDrainNode", "success draining Machine's node %q", m.Status.NodeRef.Name)
+		}
+
+		// Wait for the volumes attached to the node to be detached, if required.
+		if m.Spec.NodeVolumeDetachTimeout != nil {
+			if result, err := r.waitForVolumeDetach(ctx, cluster, m); err != nil || result != (ctrl.Result{}) {
+				return result, err
+			}
 		}
 		logger.Info("Deleting node", "node", m.Status.NodeRef.Name)
 
@@ -251,6 +337,18 @@ func (r *MachineReconciler) reconcileDelete(ctx context.Context, cluster *cluste
 		}
 	}
 
+	// Wait for the pre-terminate delete hooks to be removed before deleting the infrastructure.
+	if feature.Gates.Enabled(feature.LifecycleHooks) {
+		if result, blocked := r.waitForDeleteHooks(logger, m, clusterv1.PreTerminateDeleteHookAnnotationPrefix, clusterv1.PreTerminateDeleteHookSucceededCondition, m.Spec.PreTerminateHookTimeout); blocked {
+			return result, nil
+		}
+	}
+
+	// Wait for the infrastructure provider to release the machine before deleting the infrastructure.
+	if released, err := r.reconcileInfrastructureRelease(ctx, m); !released || err != nil {
+		return ctrl.Result{}, err
+	}
+
 	if ok, err := r.reconcileDeleteExternal(ctx, m); !ok || err != nil {
 		// Return early and don't remove the finalizer if we got an error or
 		// the external reconciliation deletion isn't ready.
@@ -263,7 +361,8 @@ func (r *MachineReconciler) reconcileDelete(ctx context.Context, cluster *cluste
 
 // isDeleteNodeAllowed returns nil only if the Machine's NodeRef is not nil
 // and if the Machine is not the last control plane node in the cluster.
-func (r *MachineReconciler) isDeleteNodeAllowed(ctx context.Context, machine *clusterv1.Machine) error {
+// Clusters with an externally managed control plane have no control plane Machines, so their nodes can be deleted.
+func (r *MachineReconciler) isDeleteNodeAllowed(ctx context.Context, cluster *clusterv1.Cluster, machine *clusterv1.Machine) error {
 	// Cannot delete something that doesn't exist.
 	if machine.Status.NodeRef == nil {
 		return errNilNodeRef
@@ -281,7 +380,14 @@ func (r *MachineReconciler) isDeleteNodeAllowed(ctx context.Context, machine *cl
 	switch numControlPlaneMachines := len(util.GetControlPlaneMachines(machines)); {
 	case numControlPlaneMachines == 0:
 		// Do not delete the NodeRef if there are no remaining members of
-		// the control plane.
+		// the control plane, unless the control plane is not backed by Machines.
+		externalManaged, err := r.isExternalManagedControlPlane(ctx, cluster)
+		if err != nil {
+			return err
+		}
+		if externalManaged {
+			return nil
+		}
 		return errNoControlPlaneNodes
 	case numControlPlaneMachines == 1 && util.IsControlPlaneMachine(machine):
 		// Do not delete the NodeRef if this is the last member of the
@@ -293,8 +399,52 @@ func (r *MachineReconciler) isDeleteNodeAllowed(ctx context.Context, machine *cl
 	}
 }
 
-func (r *MachineReconciler) drainNode(ctx context.Context, cluster *clusterv1.Cluster, nodeName string, machineName string) error {
-	logger := r.Log.WithValues("machine", machineName, "node", nodeName, "cluster", cluster.Name, "namespace", cluster.Namespace)
+// reconcileDrainNode drains the Machine's node, unless the Machine's NodeDrainTimeout is expired, and records
+// the progress of the drain in the DrainingSucceeded condition.
+func (r *MachineReconciler) reconcileDrainNode(ctx context.Context, cluster *clusterv1.Cluster, m *clusterv1.Machine) error {
+	logger := r.Log.WithValues("machine", m.Name, "namespace", m.Namespace, "node", m.Status.NodeRef.Name)
+
+	if isNodeDrainTimeoutExpired(m) {
+		logger.Info("Timed out draining node, moving on")
+		r.recorder.Eventf(m, corev1.EventTypeWarning, "FailedDrainNode", "timed out draining Machine's node %q", m.Status.NodeRef.Name)
+		conditions.MarkFalse(m, clusterv1.DrainingSucceededCondition, clusterv1.DrainingTimedOutReason, clusterv1.ConditionSeverityWarning, "Timed out draining node %q", m.Status.NodeRef.Name)
+		return nil
+	}
+
+	logger.Info("Draining node")
+	conditions.MarkFalse(m, clusterv1.DrainingSucceededCondition, clusterv1.DrainingReason, clusterv1.ConditionSeverityInfo, "Draining node %q", m.Status.NodeRef.Name)
+	if err := r.drainNode(ctx, cluster, m); err != nil {
+		r.recorder.Eventf(m, corev1.EventTypeWarning, "FailedDrainNode", "error draining Machine's node %q: %v", m.Status.NodeRef.Name, err)
+		conditions.MarkFalse(m, clusterv1.DrainingSucceededCondition, clusterv1.DrainingFailedReason, clusterv1.ConditionSeverityWarning, err.Error())
+		return err
+	}
+
+	r.recorder.Eventf(m, corev1.EventTypeNormal, "SuccessfulDrainNode", "success draining Machine's node %q", m.Status.NodeRef.Name)
+	conditions.MarkTrue(m, clusterv1.DrainingSucceededCondition)
+	return nil
+}
+
+// isExternalManagedControlPlane returns true if the Cluster references a control plane that is externally managed,
+// e.g. by a cloud provider, and thus not backed by Machines.
+func (r *MachineReconciler) isExternalManagedControlPlane(ctx context.Context, cluster *clusterv1.Cluster) (bool, error) {
+	if cluster.Spec.ControlPlaneRef == nil {
+		return false, nil
+	}
+
+	controlPlane, err := external.Get(ctx, r.Client, cluster.Spec.ControlPlaneRef, cluster.Namespace)
+	if err != nil {
+		// The control plane could be already deleted, e.g. when deleting the Cluster.
+		if apierrors.IsNotFound(errors.Cause(err)) {
+			return false, nil
+		}
+		return false, err
+	}
+	return external.IsExternalManagedControlPlane(controlPlane)
+}
+
+func (r *MachineReconciler) drainNode(ctx context.Context, cluster *clusterv1.Cluster, m *clusterv1.Machine) error {
+	nodeName := m.Status.NodeRef.Name
+	logger := r.Log.WithValues("machine", m.Name, "node", nodeName, "cluster", cluster.Name, "namespace", cluster.Namespace)
 	var kubeClient kubernetes.Interface
 	if cluster == nil {
 		var err error
@@ -331,7 +481,7 @@ func (r *MachineReconciler) drainNode(ctx context.Context, cluster *clusterv1.Cl
 		Force:               true,
 		IgnoreAllDaemonSets: true,
 		DeleteLocalData:     true,
-		GracePeriodSeconds:  -1,
+		GracePeriodSeconds:  nodeDrainGracePeriodSeconds(m),
 		// If a pod is not evicted in 20 seconds, retry the eviction next time the
 		// machine gets reconciled again (to allow other machines to be reconciled).
 		Timeout: 20 * time.Second,
@@ -369,6 +519,133 @@ func (r *MachineReconciler) drainNode(ctx context.Context, cluster *clusterv1.Cl
 	return nil
 }
 
+// waitForVolumeDetach returns a result requeuing the Machine until all the volumes attached to the Machine's node
+// are detached, or until the Machine's NodeVolumeDetachTimeout expires.
+func (r *MachineReconciler) waitForVolumeDetach(ctx context.Context, cluster *clusterv1.Cluster, m *clusterv1.Machine) (ctrl.Result, error) {
+	logger := r.Log.WithValues("machine", m.Name, "node", m.Status.NodeRef.Name, "cluster", cluster.Name, "namespace", cluster.Namespace)
+
+	if isNodeVolumeDetachTimeoutExpired(m) {
+		logger.Info("Timed out waiting for volumes to be detached from node, moving on")
+		r.recorder.Eventf(m, corev1.EventTypeWarning, "FailedWaitForVolumeDetach", "timed out waiting for volumes to be detached from Machine's node %q", m.Status.NodeRef.Name)
+		return ctrl.Result{}, nil
+	}
+
+	c, err := remote.NewClusterClient(ctx, r.Client, cluster, r.scheme)
+	if err != nil {
+		return ctrl.Result{}, errors.Wrapf(err, "error creating a remote client for cluster %q", cluster.Name)
+	}
+
+	volumes, err := getAttachedVolumes(ctx, c, m.Status.NodeRef.Name)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	if len(volumes) > 0 {
+		logger.Info("Waiting for volumes to be detached from node", "volumes", volumes)
+		return ctrl.Result{RequeueAfter: 5 * time.Second}, nil
+	}
+
+	r.recorder.Eventf(m, corev1.EventTypeNormal, "SuccessfulWaitForVolumeDetach", "all volumes detached from Machine's node %q", m.Status.NodeRef.Name)
+	return ctrl.Result{}, nil
+}
+
+// isNodeVolumeDetachTimeoutExpired returns true if the time elapsed since the Machine deletion started
+// exceeds the Machine's NodeVolumeDetachTimeout.
+func isNodeVolumeDetachTimeoutExpired(m *clusterv1.Machine) bool {
+	if m.Spec.NodeVolumeDetachTimeout == nil || m.DeletionTimestamp.IsZero() {
+		return false
+	}
+	return time.Since(m.DeletionTimestamp.Time) > m.Spec.NodeVolumeDetachTimeout.Duration
+}
+
+// isNodeDrainTimeoutExpired returns true if the time elapsed since the Machine deletion started
+// exceeds the Machine's NodeDrainTimeout.
+func isNodeDrainTimeoutExpired(m *clusterv1.Machine) bool {
+	if m.Spec.NodeDrainTimeout == nil || m.DeletionTimestamp.IsZero() {
+		return false
+	}
+	return time.Since(m.DeletionTimestamp.Time) > m.Spec.NodeDrainTimeout.Duration
+}
+
+// nodeDrainGracePeriodSeconds returns the grace period given to each Pod to terminate when draining the Machine's Node;
+// a negative value means the default value specified in the Pod is used.
+func nodeDrainGracePeriodSeconds(m *clusterv1.Machine) int {
+	if m.Spec.NodeDrainGracePeriodSeconds == nil {
+		return -1
+	}
+	return int(*m.Spec.NodeDrainGracePeriodSeconds)
+}
+
+// waitForDeleteHooks returns true if the deletion of the Machine is blocked by delete hooks, i.e. annotations
+// with the given prefix, and the timeout for waiting for them to be removed has not expired.
+// The wait is recorded by the given condition, whose last transition time is the moment the controller started
+// waiting for the hooks, and so the moment the timeout is measured from.
+// Removing a hook triggers a new reconciliation, so a requeue is only needed for the timeout to be enforced.
+func (r *MachineReconciler) waitForDeleteHooks(logger logr.Logger, m *clusterv1.Machine, prefix string, condition clusterv1.ConditionType, timeout *metav1.Duration) (ctrl.Result, bool) {
+	hooks := getDeleteHooks(m, prefix)
+	if len(hooks) == 0 {
+		if conditions.Has(m, condition) {
+			conditions.MarkTrue(m, condition)
+		}
+		return ctrl.Result{}, false
+	}
+
+	// Once the timeout is expired, the remaining hooks are ignored.
+	if conditions.GetReason(m, condition) == clusterv1.DeleteHookTimedOutReason {
+		return ctrl.Result{}, false
+	}
+
+	conditions.MarkFalse(m, condition, clusterv1.WaitingExternalHookReason, clusterv1.ConditionSeverityInfo, "Waiting for delete hooks %v to be removed", hooks)
+	waitingSince := conditions.GetLastTransitionTime(m, condition).Time
+
+	if timeout != nil && time.Since(waitingSince) > timeout.Duration {
+		logger.Info("Timed out waiting for delete hooks to be removed, moving on", "hooks", hooks)
+		r.recorder.Eventf(m, corev1.EventTypeWarning, "FailedWaitForDeleteHooks", "timed out waiting for delete hooks %v to be removed", hooks)
+		conditions.MarkFalse(m, condition, clusterv1.DeleteHookTimedOutReason, clusterv1.ConditionSeverityWarning, "Timed out waiting for delete hooks %v to be removed", hooks)
+		return ctrl.Result{}, false
+	}
+
+	logger.Info("Waiting for delete hooks to be removed", "hooks", hooks)
+	if timeout == nil {
+		return ctrl.Result{}, true
+	}
+	return ctrl.Result{RequeueAfter: time.Until(waitingSince.Add(timeout.Duration)) + time.Second}, true
+}
+
+// getDeleteHooks returns the sorted names of the Machine annotations with the given delete hook prefix.
+func getDeleteHooks(m *clusterv1.Machine, prefix string) []string {
+	var hooks []string
+	for name := range m.Annotations {
+		if strings.HasPrefix(name, prefix+"/") {
+			hooks = append(hooks, name)
+		}
+	}
+	sort.Strings(hooks)
+	return hooks
+}
+
+// getAttachedVolumes returns the names of the persistent volumes attached, or being attached/detached, to a node,
+// according to the VolumeAttachment objects existing in the cluster.
+func getAttachedVolumes(ctx context.Context, c client.Client, nodeName string) ([]string, error) {
+	volumeAttachments := &storagev1.VolumeAttachmentList{}
+	if err := c.List(ctx, volumeAttachments); err != nil {
+		return nil, errors.Wrap(err, "failed to list VolumeAttachments")
+	}
+
+	var volumes []string
+	for _, va := range volumeAttachments.Items {
+		if va.Spec.NodeName != nodeName {
+			continue
+		}
+		if va.Spec.Source.PersistentVolumeName != nil {
+			volumes = append(volumes, *va.Spec.Source.PersistentVolumeName)
+			continue
+		}
+		volumes = append(volumes, va.Name)
+	}
+	return volumes, nil
+}
+
 func (r *MachineReconciler) deleteNode(ctx context.Context, cluster *clusterv1.Cluster, name string) error {
 	logger := r.Log.WithValues("machine", name, "cluster", cluster.Name, "namespace", cluster.Namespace)
 
@@ -391,6 +668,62 @@ func (r *MachineReconciler) deleteNode(ctx context.Context, cluster *clusterv1.C
 	return nil
 }
 
+// reconcileInfrastructureRelease requests the infrastructure provider to release the machine of a deleted Machine,
+// and returns true once the machine has been released. InfrastructureMachines without a status.released field are
+// considered released, as their provider does not support the release step.
+func (r *MachineReconciler) reconcileInfrastructureRelease(ctx context.Context, m *clusterv1.Machine) (bool, error) {
+	infraConfig, err := external.Get(ctx, r.Client, &m.Spec.InfrastructureRef, m.Namespace)
+	if err != nil {
+		if apierrors.IsNotFound(errors.Cause(err)) {
+			return true, nil
+		}
+		return false, errors.Wrapf(err, "failed to get %s %q for Machine %q in namespace %q",
+			m.Spec.InfrastructureRef.GroupVersionKind(), m.Spec.InfrastructureRef.Name, m.Name, m.Namespace)
+	}
+
+	// The InfrastructureMachine has already been deleted, e.g. by the user.
+	if !infraConfig.GetDeletionTimestamp().IsZero() {
+		return true, nil
+	}
+
+	released, supported, err := external.IsReleased(infraConfig)
+	if err != nil {
+		return false, err
+	}
+	if released || !supported {
+		return true, nil
+	}
+
+	// Ensure changes to the InfrastructureMachine trigger a new reconcile, e.g. after a restart of the controller.
+	if err := r.externalTracker.Watch(r.Log, infraConfig, &handler.EnqueueRequestForOwner{OwnerType: &clusterv1.Machine{}}); err != nil {
+		return false, err
+	}
+
+	conditions.MarkFalse(m, clusterv1.InfrastructureReadyCondition, clusterv1.WaitingForInfrastructureReleaseReason, clusterv1.ConditionSeverityInfo, "")
+
+	annotations := infraConfig.GetAnnotations()
+	if annotations[clusterv1.ActionAnnotation] == clusterv1.ReleaseAction {
+		// The release has already been requested.
+		return false, nil
+	}
+
+	patchHelper, err := patch.NewHelper(infraConfig, r.Client)
+	if err != nil {
+		return false, err
+	}
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+	annotations[clusterv1.ActionAnnotation] = clusterv1.ReleaseAction
+	infraConfig.SetAnnotations(annotations)
+	if err := patchHelper.Patch(ctx, infraConfig); err != nil {
+		return false, errors.Wrapf(err, "failed to request the release of %v %q for Machine %q in namespace %q",
+			infraConfig.GroupVersionKind(), infraConfig.GetName(), m.Name, m.Namespace)
+	}
+	r.recorder.Eventf(m, corev1.EventTypeNormal, "InfrastructureReleaseRequested", "Requested infrastructure provider to release the machine")
+	return false, nil
+}
+
 // reconcileDeleteExternal tries to delete external references, returning true if it cannot find any.
 func (r *MachineReconciler) reconcileDeleteExternal(ctx context.Context, m *clusterv1.Machine) (bool, error) {
 	objects := []*unstructured.Unstructured{}
@@ -442,3 +775,27 @@ func (w writer) Write(p []byte) (n int, err error) {
 	w.logFunc(string(p))
 	return len(p), nil
 }
+
+// clusterToMachines maps Cluster events to reconcile requests for all the Machines belonging to the Cluster.
+func (r *MachineReconciler) clusterToMachines(o handler.MapObject) []reconcile.Request {
+	c, ok := o.Object.(*clusterv1.Cluster)
+	if !ok {
+		r.Log.Error(errors.New("incorrect type"), "expected a Cluster", "type", fmt.Sprintf("%T", o))
+		return nil
+	}
+
+	machineList := &clusterv1.MachineList{}
+	if err := r.Client.List(context.TODO(), machineList, client.InNamespace(c.Namespace), client.MatchingFields{machineClusterNameIndex: c.Name}); err != nil {
+		r.Log.Error(err, "Unable to list Machines", "cluster", c.Name, "namespace", c.Namespace)
+		return nil
+	}
+
+	requests := []reconcile.Request{}
+	for _, m := range machineList.Items {
+		if m.Spec.ClusterName != c.Name {
+			continue
+		}
+		requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKey{Namespace: m.Namespace, Name: m.Name}})
+	}
+	return requests
+}
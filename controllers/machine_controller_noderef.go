@@ -18,14 +18,20 @@ package controllers
 
 import (
 	"context"
+	"sort"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/pkg/errors"
 	apicorev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/controllers/metrics"
 	"sigs.k8s.io/cluster-api/controllers/noderefutil"
 	"sigs.k8s.io/cluster-api/controllers/remote"
 	capierrors "sigs.k8s.io/cluster-api/errors"
+	"sigs.k8s.io/cluster-api/util"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
@@ -64,13 +70,26 @@ func (r *MachineReconciler) reconcileNodeRef(ctx context.Context, cluster *clust
 		return err
 	}
 
+	// Check that the ProviderID is not shared with other Machines, otherwise they would all reference the same Node.
+	machines, err := getMachinesByProviderID(ctx, r.Client, machine.Namespace, machine.Spec.ClusterName, providerID)
+	if err != nil {
+		return err
+	}
+	for _, m := range machines {
+		if m.Name != machine.Name && m.DeletionTimestamp.IsZero() {
+			err := errors.Errorf("cannot assign NodeRef to Machine %q in namespace %q, Machine %q has the same ProviderID %q", machine.Name, machine.Namespace, m.Name, providerID)
+			r.recorder.Event(machine, apicorev1.EventTypeWarning, "FailedSetNodeRef", err.Error())
+			return err
+		}
+	}
+
 	clusterClient, err := remote.NewClusterClient(ctx, r.Client, cluster, r.scheme)
 	if err != nil {
 		return err
 	}
 
 	// Get the Node reference.
-	nodeRef, err := r.getNodeReference(clusterClient, providerID)
+	nodeRef, err := r.getNodeReference(client.ObjectKey{Namespace: cluster.Namespace, Name: cluster.Name}, clusterClient, providerID)
 	if err != nil {
 		if err == ErrNodeNotFound {
 			return errors.Wrapf(&capierrors.RequeueAfterError{RequeueAfter: 10 * time.Second},
@@ -81,6 +100,17 @@ func (r *MachineReconciler) reconcileNodeRef(ctx context.Context, cluster *clust
 		return err
 	}
 
+	// Check that the Node is not already referenced by another Machine, e.g. one created out of band for the same host.
+	owner, err := getMachineByNodeName(ctx, r.Client, machine.Namespace, machine.Spec.ClusterName, nodeRef.Name)
+	if err != nil {
+		return err
+	}
+	if owner != nil && owner.Name != machine.Name {
+		err := errors.Errorf("cannot assign NodeRef to Machine %q in namespace %q, Node %q is already referenced by Machine %q", machine.Name, machine.Namespace, nodeRef.Name, owner.Name)
+		r.recorder.Event(machine, apicorev1.EventTypeWarning, "FailedSetNodeRef", err.Error())
+		return err
+	}
+
 	// Set the Machine NodeRef.
 	machine.Status.NodeRef = nodeRef
 	logger.Info("Set Machine's NodeRef", "noderef", machine.Status.NodeRef.Name)
@@ -88,8 +118,27 @@ func (r *MachineReconciler) reconcileNodeRef(ctx context.Context, cluster *clust
 	return nil
 }
 
-func (r *MachineReconciler) getNodeReference(c client.Client, providerID *noderefutil.ProviderID) (*apicorev1.ObjectReference, error) {
-	logger := r.Log.WithValues("providerID", providerID)
+// getNodeReference returns the reference to the Node of a workload cluster with the given ProviderID.
+// The Nodes are listed at most once per nodeRefCacheTTL for each cluster, and shared by all its Machines; this avoids
+// listing all the Nodes for each Machine waiting for its Node, e.g. when scaling up thousands of Machines.
+func (r *MachineReconciler) getNodeReference(cluster client.ObjectKey, c client.Client, providerID *noderefutil.ProviderID) (*apicorev1.ObjectReference, error) {
+	nodeRefs, err := r.nodeRefs.get(cluster, func() (map[string]*apicorev1.ObjectReference, error) {
+		return r.listNodeReferences(c)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	nodeRef, ok := nodeRefs[providerIDIndexKey(providerID)]
+	if !ok {
+		return nil, ErrNodeNotFound
+	}
+	return nodeRef.DeepCopy(), nil
+}
+
+// listNodeReferences returns the references to all the Nodes of a workload cluster, indexed by ProviderID.
+func (r *MachineReconciler) listNodeReferences(c client.Client) (map[string]*apicorev1.ObjectReference, error) {
+	nodeRefs := map[string]*apicorev1.ObjectReference{}
 
 	nodeList := apicorev1.NodeList{}
 	for {
@@ -100,17 +149,15 @@ func (r *MachineReconciler) getNodeReference(c client.Client, providerID *nodere
 		for _, node := range nodeList.Items {
 			nodeProviderID, err := noderefutil.NewProviderID(node.Spec.ProviderID)
 			if err != nil {
-				logger.Error(err, "Failed to parse ProviderID", "node", node.Name)
+				r.Log.Error(err, "Failed to parse ProviderID", "node", node.Name)
 				continue
 			}
 
-			if providerID.Equals(nodeProviderID) {
-				return &apicorev1.ObjectReference{
-					Kind:       node.Kind,
-					APIVersion: node.APIVersion,
-					Name:       node.Name,
-					UID:        node.UID,
-				}, nil
+			nodeRefs[providerIDIndexKey(nodeProviderID)] = &apicorev1.ObjectReference{
+				Kind:       node.Kind,
+				APIVersion: node.APIVersion,
+				Name:       node.Name,
+				UID:        node.UID,
 			}
 		}
 
@@ -119,5 +166,287 @@ func (r *MachineReconciler) getNodeReference(c client.Client, providerID *nodere
 		}
 	}
 
-	return nil, ErrNodeNotFound
+	return nodeRefs, nil
+}
+
+// nodeRefCacheTTL is how long the Nodes listed from a workload cluster are reused for assigning the NodeRefs;
+// it matches the delay the Machines without a matching Node are requeued after.
+const nodeRefCacheTTL = 10 * time.Second
+
+// nodeRefCache caches the references to the Nodes of the workload clusters, indexed by ProviderID.
+// The zero value is ready to use.
+type nodeRefCache struct {
+	lock    sync.Mutex
+	now     func() time.Time
+	entries map[client.ObjectKey]nodeRefCacheEntry
+}
+
+type nodeRefCacheEntry struct {
+	listed   time.Time
+	nodeRefs map[string]*apicorev1.ObjectReference
+}
+
+// get returns the references to the Nodes of a cluster, listing them only if they were not listed in the last
+// nodeRefCacheTTL; the expired entries of the other clusters are dropped, so deleted clusters are not retained.
+// NB. The lock is not held while listing, so an unreachable cluster does not block the lookups for the other ones.
+func (c *nodeRefCache) get(cluster client.ObjectKey, list func() (map[string]*apicorev1.ObjectReference, error)) (map[string]*apicorev1.ObjectReference, error) {
+	if nodeRefs, ok := c.lookup(cluster); ok {
+		return nodeRefs, nil
+	}
+
+	nodeRefs, err := list()
+	if err != nil {
+		return nil, err
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	now := c.clock()
+	if c.entries == nil {
+		c.entries = map[client.ObjectKey]nodeRefCacheEntry{}
+	}
+	for key, entry := range c.entries {
+		if now.Sub(entry.listed) >= nodeRefCacheTTL {
+			delete(c.entries, key)
+		}
+	}
+	c.entries[cluster] = nodeRefCacheEntry{listed: now, nodeRefs: nodeRefs}
+	return nodeRefs, nil
+}
+
+// lookup returns the references to the Nodes of a cluster, if they have been listed in the last nodeRefCacheTTL.
+func (c *nodeRefCache) lookup(cluster client.ObjectKey) (map[string]*apicorev1.ObjectReference, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	entry, ok := c.entries[cluster]
+	if !ok || c.clock().Sub(entry.listed) >= nodeRefCacheTTL {
+		return nil, false
+	}
+	return entry.nodeRefs, true
+}
+
+func (c *nodeRefCache) clock() time.Time {
+	if c.now != nil {
+		return c.now()
+	}
+	return time.Now()
+}
+
+// reconcileNodeReadyTime records the time the Node of the Machine became Ready for the first time, observing the
+// time elapsed since the creation of the Machine, e.g. by scaling up its MachineSet.
+func (r *MachineReconciler) reconcileNodeReadyTime(ctx context.Context, cluster *clusterv1.Cluster, machine *clusterv1.Machine) error {
+	if !machine.DeletionTimestamp.IsZero() || machine.Status.NodeRef == nil || machine.Status.NodeReadyTime != nil || cluster == nil {
+		return nil
+	}
+
+	clusterClient, err := remote.NewClusterClient(ctx, r.Client, cluster, r.scheme)
+	if err != nil {
+		return err
+	}
+
+	node := &apicorev1.Node{}
+	if err := clusterClient.Get(ctx, client.ObjectKey{Name: machine.Status.NodeRef.Name}, node); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return errors.Wrapf(err, "failed to get Node %q", machine.Status.NodeRef.Name)
+	}
+
+	for _, condition := range node.Status.Conditions {
+		if condition.Type == apicorev1.NodeReady && condition.Status == apicorev1.ConditionTrue {
+			readyTime := condition.LastTransitionTime
+			machine.Status.NodeReadyTime = &readyTime
+			metrics.ObserveDuration(metrics.MachineNodeReadyDuration.WithLabelValues(machine.Namespace, machine.Spec.ClusterName, machinePoolName(machine)),
+				machine.CreationTimestamp.Time, readyTime.Time)
+			return nil
+		}
+	}
+
+	// Nodes are not watched, so check again later.
+	return errors.Wrapf(&capierrors.RequeueAfterError{RequeueAfter: 10 * time.Second},
+		"Node %q of Machine %q in namespace %q is not Ready yet", node.Name, machine.Name, machine.Namespace)
+}
+
+// machinePoolName returns the name of the pool of a Machine used in the metrics, i.e. the name of its
+// MachineDeployment or MachineSet, or "control-plane" for control plane Machines.
+func machinePoolName(machine *clusterv1.Machine) string {
+	if util.IsControlPlaneMachine(machine) {
+		return "control-plane"
+	}
+	if name, ok := machine.Labels[clusterv1.MachineDeploymentLabelName]; ok {
+		return name
+	}
+	return machine.Labels[clusterv1.MachineSetLabelName]
+}
+
+// reconcileNodeMetadata syncs to the Node of the Machine the Machine labels and annotations accepted by
+// util.IsNodeMetadataKey and the Machine Node taints. The label keys, the annotation keys and the taints synced
+// are recorded in annotations on the Node, so the ones removed from the Machine are removed from the Node.
+func (r *MachineReconciler) reconcileNodeMetadata(ctx context.Context, cluster *clusterv1.Cluster, machine *clusterv1.Machine) error {
+	if !machine.DeletionTimestamp.IsZero() || machine.Status.NodeRef == nil || cluster == nil {
+		return nil
+	}
+
+	// Avoid connecting to the workload cluster for Machines without anything to sync, unless something was
+	// synced before and has to be removed from the Node.
+	hasNodeMetadata := len(util.GetNodeMetadata(machine.Labels)) > 0 ||
+		len(util.GetNodeMetadata(machine.Annotations)) > 0 ||
+		len(machine.Spec.NodeTaints) > 0
+	if _, synced := machine.Annotations[clusterv1.NodeMetadataSyncedAnnotation]; !hasNodeMetadata && !synced {
+		return nil
+	}
+
+	clusterClient, err := remote.NewClusterClient(ctx, r.Client, cluster, r.scheme)
+	if err != nil {
+		return err
+	}
+
+	node := &apicorev1.Node{}
+	if err := clusterClient.Get(ctx, client.ObjectKey{Name: machine.Status.NodeRef.Name}, node); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return errors.Wrapf(err, "failed to get Node %q", machine.Status.NodeRef.Name)
+	}
+
+	patch := client.MergeFrom(node.DeepCopy())
+	if syncNodeMetadata(node, machine) {
+		if err := clusterClient.Patch(ctx, node, patch); err != nil {
+			return errors.Wrapf(err, "failed to sync labels, annotations and taints to Node %q", node.Name)
+		}
+	}
+
+	if hasNodeMetadata {
+		if machine.Annotations == nil {
+			machine.Annotations = map[string]string{}
+		}
+		machine.Annotations[clusterv1.NodeMetadataSyncedAnnotation] = ""
+	} else {
+		delete(machine.Annotations, clusterv1.NodeMetadataSyncedAnnotation)
+	}
+	return nil
+}
+
+// syncNodeMetadata sets the node metadata labels and annotations and the taints of the Machine on the Node,
+// and returns true if the Node was changed.
+func syncNodeMetadata(node *apicorev1.Node, machine *clusterv1.Machine) bool {
+	if node.Annotations == nil {
+		node.Annotations = map[string]string{}
+	}
+
+	labelsChanged := syncManagedKeys(node, &node.Labels, util.GetNodeMetadata(machine.Labels), clusterv1.ManagedNodeLabelsAnnotation)
+	annotationsChanged := syncManagedKeys(node, &node.Annotations, util.GetNodeMetadata(machine.Annotations), clusterv1.ManagedNodeAnnotationsAnnotation)
+	taintsChanged := syncManagedTaints(node, machine.Spec.NodeTaints)
+	return labelsChanged || annotationsChanged || taintsChanged
+}
+
+// syncManagedKeys sets the desired keys in m, removes the keys previously recorded in the managedAnnotation of
+// the Node that are no longer desired, and records the desired keys in the managedAnnotation.
+func syncManagedKeys(node *apicorev1.Node, m *map[string]string, desired map[string]string, managedAnnotation string) bool {
+	changed := false
+	for _, key := range splitManaged(node.Annotations[managedAnnotation]) {
+		if _, ok := desired[key]; ok {
+			continue
+		}
+		if _, ok := (*m)[key]; ok {
+			delete(*m, key)
+			changed = true
+		}
+	}
+
+	keys := make([]string, 0, len(desired))
+	for key, value := range desired {
+		keys = append(keys, key)
+		if current, ok := (*m)[key]; ok && current == value {
+			continue
+		}
+		if *m == nil {
+			*m = map[string]string{}
+		}
+		(*m)[key] = value
+		changed = true
+	}
+
+	return setManaged(node, managedAnnotation, keys) || changed
+}
+
+// syncManagedTaints sets the desired taints on the Node, replacing the taints with the same key and effect,
+// removes the taints previously recorded in the ManagedNodeTaintsAnnotation that are no longer desired, and
+// records the desired taints in the annotation.
+func syncManagedTaints(node *apicorev1.Node, desired []apicorev1.Taint) bool {
+	taintID := func(t apicorev1.Taint) string {
+		return t.Key + ":" + string(t.Effect)
+	}
+
+	desiredIDs := map[string]apicorev1.Taint{}
+	ids := make([]string, 0, len(desired))
+	for _, t := range desired {
+		desiredIDs[taintID(t)] = t
+		ids = append(ids, taintID(t))
+	}
+	managed := map[string]bool{}
+	for _, id := range splitManaged(node.Annotations[clusterv1.ManagedNodeTaintsAnnotation]) {
+		managed[id] = true
+	}
+
+	changed := false
+	taints := []apicorev1.Taint{}
+	for _, t := range node.Spec.Taints {
+		id := taintID(t)
+		if d, ok := desiredIDs[id]; ok {
+			if t.Value != d.Value {
+				changed = true
+			}
+			continue
+		}
+		if managed[id] {
+			changed = true
+			continue
+		}
+		taints = append(taints, t)
+	}
+	for _, id := range ids {
+		t := desiredIDs[id]
+		if !changed && !hasTaint(node.Spec.Taints, t) {
+			changed = true
+		}
+		taints = append(taints, t)
+	}
+	if changed {
+		node.Spec.Taints = taints
+	}
+
+	return setManaged(node, clusterv1.ManagedNodeTaintsAnnotation, ids) || changed
+}
+
+func hasTaint(taints []apicorev1.Taint, taint apicorev1.Taint) bool {
+	for _, t := range taints {
+		if t.Key == taint.Key && t.Effect == taint.Effect && t.Value == taint.Value {
+			return true
+		}
+	}
+	return false
+}
+
+func splitManaged(value string) []string {
+	if value == "" {
+		return nil
+	}
+	return strings.Split(value, ",")
+}
+
+// setManaged records the managed keys in an annotation on the Node, removing the annotation if there are none.
+func setManaged(node *apicorev1.Node, annotation string, keys []string) bool {
+	sort.Strings(keys)
+	value := strings.Join(keys, ",")
+
+	current, ok := node.Annotations[annotation]
+	if value == "" {
+		delete(node.Annotations, annotation)
+		return ok
+	}
+	node.Annotations[annotation] = value
+	return !ok || current != value
 }
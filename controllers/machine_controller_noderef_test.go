@@ -17,14 +17,19 @@ limitations under the License.
 package controllers
 
 import (
+	"context"
+	"errors"
 	"testing"
+	"time"
 
 	. "github.com/onsi/gomega"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/pointer"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
@@ -107,7 +112,7 @@ func TestGetNodeReference(t *testing.T) {
 			providerID, err := noderefutil.NewProviderID(test.providerID)
 			gt.Expect(err).NotTo(HaveOccurred(), "Expected no error parsing provider id %q, got %v", test.providerID, err)
 
-			reference, err := r.getNodeReference(client, providerID)
+			reference, err := r.getNodeReference(types.NamespacedName{Namespace: "default", Name: "cluster"}, client, providerID)
 			if test.err == nil {
 				g.Expect(err).To(BeNil())
 			} else {
@@ -125,3 +130,155 @@ func TestGetNodeReference(t *testing.T) {
 
 	}
 }
+
+func TestSyncNodeMetadata(t *testing.T) {
+	g := NewWithT(t)
+
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "node-1",
+			Labels: map[string]string{
+				"kubernetes.io/hostname":         "node-1",
+				"node-role.kubernetes.io/master": "",
+				"node.cluster.x-k8s.io/removed":  "true",
+			},
+			Annotations: map[string]string{
+				clusterv1.ManagedNodeLabelsAnnotation: "node.cluster.x-k8s.io/removed",
+				clusterv1.ManagedNodeTaintsAnnotation: "removed:NoSchedule",
+			},
+		},
+		Spec: corev1.NodeSpec{
+			Taints: []corev1.Taint{
+				{Key: "node-role.kubernetes.io/master", Effect: corev1.TaintEffectNoSchedule},
+				{Key: "removed", Effect: corev1.TaintEffectNoSchedule},
+				{Key: "dedicated", Value: "old", Effect: corev1.TaintEffectNoSchedule},
+			},
+		},
+	}
+	machine := &clusterv1.Machine{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "machine-1",
+			Labels: map[string]string{
+				clusterv1.ClusterLabelName:  "cluster-1",
+				"node.cluster.x-k8s.io/gpu": "true",
+			},
+			Annotations: map[string]string{
+				"node.cluster.x-k8s.io/owner": "team-a",
+			},
+		},
+		Spec: clusterv1.MachineSpec{
+			NodeTaints: []corev1.Taint{
+				{Key: "dedicated", Value: "gpu", Effect: corev1.TaintEffectNoSchedule},
+			},
+		},
+	}
+
+	g.Expect(syncNodeMetadata(node, machine)).To(BeTrue())
+
+	// Labels, annotations and taints not managed by the Machine are preserved.
+	g.Expect(node.Labels).To(Equal(map[string]string{
+		"kubernetes.io/hostname":         "node-1",
+		"node-role.kubernetes.io/master": "",
+		"node.cluster.x-k8s.io/gpu":      "true",
+	}))
+	g.Expect(node.Annotations).To(Equal(map[string]string{
+		"node.cluster.x-k8s.io/owner":              "team-a",
+		clusterv1.ManagedNodeLabelsAnnotation:      "node.cluster.x-k8s.io/gpu",
+		clusterv1.ManagedNodeAnnotationsAnnotation: "node.cluster.x-k8s.io/owner",
+		clusterv1.ManagedNodeTaintsAnnotation:      "dedicated:NoSchedule",
+	}))
+	g.Expect(node.Spec.Taints).To(ConsistOf(
+		corev1.Taint{Key: "node-role.kubernetes.io/master", Effect: corev1.TaintEffectNoSchedule},
+		corev1.Taint{Key: "dedicated", Value: "gpu", Effect: corev1.TaintEffectNoSchedule},
+	))
+
+	// A second sync does not change the Node.
+	g.Expect(syncNodeMetadata(node, machine)).To(BeFalse())
+
+	// Removing everything from the Machine removes it from the Node.
+	machine.Labels = map[string]string{clusterv1.ClusterLabelName: "cluster-1"}
+	machine.Annotations = nil
+	machine.Spec.NodeTaints = nil
+	g.Expect(syncNodeMetadata(node, machine)).To(BeTrue())
+	g.Expect(node.Labels).To(Equal(map[string]string{
+		"kubernetes.io/hostname":         "node-1",
+		"node-role.kubernetes.io/master": "",
+	}))
+	g.Expect(node.Annotations).To(BeEmpty())
+	g.Expect(node.Spec.Taints).To(ConsistOf(
+		corev1.Taint{Key: "node-role.kubernetes.io/master", Effect: corev1.TaintEffectNoSchedule},
+	))
+}
+
+func TestNodeRefCache(t *testing.T) {
+	g := NewWithT(t)
+
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := &nodeRefCache{now: func() time.Time { return now }}
+
+	lists := 0
+	list := func() (map[string]*corev1.ObjectReference, error) {
+		lists++
+		return map[string]*corev1.ObjectReference{"aws://id-node-1": {Name: "node-1"}}, nil
+	}
+	cluster1 := types.NamespacedName{Namespace: "default", Name: "cluster-1"}
+	cluster2 := types.NamespacedName{Namespace: "default", Name: "cluster-2"}
+
+	// The Nodes of a cluster are listed once, and shared by the following lookups.
+	for i := 0; i < 10; i++ {
+		nodeRefs, err := c.get(cluster1, list)
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(nodeRefs).To(HaveKey("aws://id-node-1"))
+	}
+	g.Expect(lists).To(Equal(1))
+
+	// The Nodes of each cluster are listed separately.
+	_, err := c.get(cluster2, list)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(lists).To(Equal(2))
+
+	// The Nodes are listed again once expired, and the other expired clusters are dropped.
+	now = now.Add(nodeRefCacheTTL)
+	_, err = c.get(cluster1, list)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(lists).To(Equal(3))
+	g.Expect(c.entries).To(HaveLen(1))
+
+	// Listing errors are not cached.
+	now = now.Add(nodeRefCacheTTL)
+	_, err = c.get(cluster1, func() (map[string]*corev1.ObjectReference, error) { return nil, errors.New("unreachable") })
+	g.Expect(err).To(HaveOccurred())
+	_, err = c.get(cluster1, list)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(lists).To(Equal(4))
+}
+
+func TestReconcileNodeRefDuplicateProviderID(t *testing.T) {
+	g := NewWithT(t)
+
+	g.Expect(clusterv1.AddToScheme(scheme.Scheme)).To(Succeed())
+
+	cluster := &clusterv1.Cluster{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "cluster"}}
+	machine := func(name, providerID string) *clusterv1.Machine {
+		return &clusterv1.Machine{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name},
+			Spec: clusterv1.MachineSpec{
+				ClusterName: "cluster",
+				ProviderID:  pointer.StringPtr(providerID),
+			},
+		}
+	}
+	m1 := machine("machine-1", "aws:///us-east-1/id-node-1")
+	m2 := machine("machine-2", "aws:///us-west-2/id-node-1")
+
+	r := &MachineReconciler{
+		Client:   fake.NewFakeClientWithScheme(scheme.Scheme, cluster, m1, m2),
+		Log:      log.Log,
+		recorder: record.NewFakeRecorder(32),
+	}
+
+	err := r.reconcileNodeRef(context.Background(), cluster, m1)
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(err.Error()).To(ContainSubstring(`Machine "machine-2" has the same ProviderID`))
+	g.Expect(m1.Status.NodeRef).To(BeNil())
+}
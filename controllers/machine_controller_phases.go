@@ -18,6 +18,7 @@ package controllers
 
 import (
 	"context"
+	"encoding/base64"
 	"fmt"
 	"strings"
 	"time"
@@ -25,6 +26,7 @@ import (
 	"github.com/pkg/errors"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/utils/pointer"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
@@ -32,16 +34,23 @@ import (
 
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
 	"sigs.k8s.io/cluster-api/controllers/external"
+	"sigs.k8s.io/cluster-api/controllers/metrics"
 	capierrors "sigs.k8s.io/cluster-api/errors"
 	"sigs.k8s.io/cluster-api/util"
+	"sigs.k8s.io/cluster-api/util/conditions"
 	"sigs.k8s.io/cluster-api/util/patch"
+	"sigs.k8s.io/cluster-api/util/secret"
 )
 
 var (
 	externalReadyWait = 30 * time.Second
+
+	machineKind = clusterv1.GroupVersion.WithKind("Machine")
 )
 
 func (r *MachineReconciler) reconcilePhase(_ context.Context, m *clusterv1.Machine) {
+	previousPhase := m.Status.GetTypedPhase()
+
 	// Set the phase to "pending" if nil.
 	if m.Status.Phase == "" {
 		m.Status.SetTypedPhase(clusterv1.MachinePhasePending)
@@ -71,6 +80,64 @@ func (r *MachineReconciler) reconcilePhase(_ context.Context, m *clusterv1.Machi
 	if !m.DeletionTimestamp.IsZero() {
 		m.Status.SetTypedPhase(clusterv1.MachinePhaseDeleting)
 	}
+
+	// Observe the provisioning duration the first time the Machine is running.
+	if m.Status.GetTypedPhase() == clusterv1.MachinePhaseRunning && previousPhase != clusterv1.MachinePhaseRunning {
+		metrics.MachineProvisioningDuration.WithLabelValues(m.Namespace, m.Spec.ClusterName).Observe(time.Since(m.CreationTimestamp.Time).Seconds())
+	}
+}
+
+// reconcilePhaseTimeout marks the Machine as failed if it stays in the Pending or Provisioning phase for longer than
+// its PendingTimeout or ProvisioningTimeout, and deletes it if ReplaceOnPhaseTimeout is set and the Machine is
+// controlled by a MachineSet, so that it gets replaced.
+func (r *MachineReconciler) reconcilePhaseTimeout(ctx context.Context, _ *clusterv1.Cluster, m *clusterv1.Machine) error {
+	if m.Status.FailureReason == nil && m.Status.FailureMessage == nil {
+		phase, timeout, started := phaseTimeout(m)
+		if timeout == nil {
+			return nil
+		}
+
+		if remaining := time.Until(started.Add(timeout.Duration)); remaining > 0 {
+			return errors.Wrapf(&capierrors.RequeueAfterError{RequeueAfter: remaining + time.Second},
+				"Machine %q in namespace %q is in the %s phase, requeuing until its timeout expires", m.Name, m.Namespace, phase)
+		}
+
+		m.Status.FailureReason = capierrors.MachineStatusErrorPtr(capierrors.PhaseTimeoutMachineError)
+		m.Status.FailureMessage = pointer.StringPtr(fmt.Sprintf("Machine has been in the %s phase for more than %s", phase, timeout.Duration))
+		r.recorder.Eventf(m, corev1.EventTypeWarning, "PhaseTimeout", "Machine has been in the %s phase for more than %s", phase, timeout.Duration)
+	}
+
+	if !m.Spec.ReplaceOnPhaseTimeout || m.Status.FailureReason == nil || *m.Status.FailureReason != capierrors.PhaseTimeoutMachineError {
+		return nil
+	}
+	if owner := metav1.GetControllerOf(m); owner == nil || owner.Kind != "MachineSet" {
+		return nil
+	}
+
+	if err := r.Client.Delete(ctx, m); err != nil && !apierrors.IsNotFound(err) {
+		return errors.Wrapf(err, "failed to delete Machine %q in namespace %q after its phase timeout expired", m.Name, m.Namespace)
+	}
+	r.recorder.Eventf(m, corev1.EventTypeNormal, "ReplacingMachine", "Deleted Machine after its phase timeout expired, so that it gets replaced")
+	return nil
+}
+
+// phaseTimeout returns the phase of the Machine subject to a timeout, if any, together with the timeout and the time
+// the Machine entered the phase.
+func phaseTimeout(m *clusterv1.Machine) (clusterv1.MachinePhase, *metav1.Duration, time.Time) {
+	switch {
+	case m.Status.NodeRef != nil || m.Status.InfrastructureReady:
+		return "", nil, time.Time{}
+	case !m.Status.BootstrapReady:
+		return clusterv1.MachinePhasePending, m.Spec.PendingTimeout, m.CreationTimestamp.Time
+	}
+
+	// The Provisioning phase starts when the bootstrap data is ready; Machines created before the BootstrapReady
+	// condition was introduced fall back to the creation time.
+	started := m.CreationTimestamp.Time
+	if c := conditions.Get(m, clusterv1.BootstrapReadyCondition); c != nil && c.Status == corev1.ConditionTrue {
+		started = c.LastTransitionTime.Time
+	}
+	return clusterv1.MachinePhaseProvisioning, m.Spec.ProvisioningTimeout, started
 }
 
 // reconcileExternal handles generic unstructured objects referenced by a Machine.
@@ -87,6 +154,11 @@ func (r *MachineReconciler) reconcileExternal(ctx context.Context, cluster *clus
 		return external.ReconcileOutput{}, err
 	}
 
+	// Remove the pause propagated to the external object, if the Cluster is not paused anymore.
+	if err := propagatePause(ctx, r.Client, cluster, obj); err != nil {
+		return external.ReconcileOutput{}, err
+	}
+
 	// if external ref is paused, return error.
 	if util.IsPaused(cluster, obj) {
 		logger.V(3).Info("External object referenced is paused")
@@ -110,6 +182,9 @@ func (r *MachineReconciler) reconcileExternal(ctx context.Context, cluster *clus
 		labels = make(map[string]string)
 	}
 	labels[clusterv1.ClusterLabelName] = m.Spec.ClusterName
+	for key, value := range util.GetPropagatedLabels(cluster) {
+		labels[key] = value
+	}
 	obj.SetLabels(labels)
 
 	// Always attempt to Patch the external object.
@@ -148,6 +223,10 @@ func (r *MachineReconciler) reconcileBootstrap(ctx context.Context, cluster *clu
 	if m.Spec.Bootstrap.ConfigRef != nil {
 		bootstrapReconcileResult, err := r.reconcileExternal(ctx, cluster, m, m.Spec.Bootstrap.ConfigRef)
 		if err != nil {
+			if m.Spec.Bootstrap.Data == nil && m.Spec.Bootstrap.DataSecretName == nil && strings.Contains(err.Error(), "could not find") {
+				conditions.MarkFalse(m, clusterv1.BootstrapReadyCondition, clusterv1.BootstrapConfigNotFoundReason, clusterv1.ConditionSeverityWarning,
+					"%s %q does not exist", m.Spec.Bootstrap.ConfigRef.Kind, m.Spec.Bootstrap.ConfigRef.Name)
+			}
 			return err
 		}
 		// if the external object is paused, return without any further processing
@@ -157,9 +236,17 @@ func (r *MachineReconciler) reconcileBootstrap(ctx context.Context, cluster *clu
 		bootstrapConfig = bootstrapReconcileResult.Result
 	}
 
+	// Move the deprecated plaintext bootstrap data to a secret.
+	if m.Spec.Bootstrap.Data != nil && m.Spec.Bootstrap.DataSecretName == nil {
+		if err := r.storeBootstrapData(ctx, cluster, m); err != nil {
+			return err
+		}
+	}
+
 	// If the bootstrap data is populated, set ready and return.
 	if m.Spec.Bootstrap.Data != nil || m.Spec.Bootstrap.DataSecretName != nil {
 		m.Status.BootstrapReady = true
+		conditions.MarkTrue(m, clusterv1.BootstrapReadyCondition)
 		return nil
 	}
 
@@ -173,6 +260,8 @@ func (r *MachineReconciler) reconcileBootstrap(ctx context.Context, cluster *clu
 	if err != nil {
 		return err
 	} else if !ready {
+		conditions.MarkFalse(m, clusterv1.BootstrapReadyCondition, clusterv1.WaitingForDataSecretReason, clusterv1.ConditionSeverityInfo,
+			"Waiting for %s %q to be ready", bootstrapConfig.GetKind(), bootstrapConfig.GetName())
 		return errors.Wrapf(&capierrors.RequeueAfterError{RequeueAfter: externalReadyWait},
 			"Bootstrap provider for Machine %q in namespace %q is not ready, requeuing", m.Name, m.Namespace)
 	}
@@ -187,6 +276,38 @@ func (r *MachineReconciler) reconcileBootstrap(ctx context.Context, cluster *clu
 
 	m.Spec.Bootstrap.DataSecretName = pointer.StringPtr(secretName)
 	m.Status.BootstrapReady = true
+	conditions.MarkTrue(m, clusterv1.BootstrapReadyCondition)
+	return nil
+}
+
+// storeBootstrapData moves the deprecated plaintext bootstrap data of a Machine to a bootstrap data secret
+// owned by the Machine, so large bootstrap data does not bloat the Machine object.
+func (r *MachineReconciler) storeBootstrapData(ctx context.Context, cluster *clusterv1.Cluster, m *clusterv1.Machine) error {
+	// The plaintext bootstrap data was copied base64 encoded from the status of the bootstrap providers by the
+	// v1alpha2 controllers; the data set by users which is not base64 encoded is stored as is.
+	data, err := base64.StdEncoding.DecodeString(*m.Spec.Bootstrap.Data)
+	if err != nil {
+		data = []byte(*m.Spec.Bootstrap.Data)
+	}
+
+	dataSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%s-bootstrap-data", m.Name),
+			Namespace: m.Namespace,
+			Labels: map[string]string{
+				clusterv1.ClusterLabelName: cluster.Name,
+			},
+			OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(m, machineKind)},
+		},
+	}
+	secret.SetBootstrapData(dataSecret, data, clusterv1.BootstrapDataFormatCloudConfig)
+
+	if err := r.Client.Create(ctx, dataSecret); err != nil && !apierrors.IsAlreadyExists(err) {
+		return errors.Wrapf(err, "failed to create bootstrap data secret for Machine %q in namespace %q", m.Name, m.Namespace)
+	}
+
+	m.Spec.Bootstrap.DataSecretName = pointer.StringPtr(dataSecret.Name)
+	m.Spec.Bootstrap.Data = nil
 	return nil
 }
 
@@ -195,12 +316,18 @@ func (r *MachineReconciler) reconcileInfrastructure(ctx context.Context, cluster
 	// Call generic external reconciler.
 	infraReconcileResult, err := r.reconcileExternal(ctx, cluster, m, &m.Spec.InfrastructureRef)
 	if err != nil {
-		if m.Status.InfrastructureReady && strings.Contains(err.Error(), "could not find") {
-			// Infra object went missing after the machine was up and running
-			r.Log.Error(err, "Machine infrastructure reference has been deleted after being ready, setting failure state")
-			m.Status.FailureReason = capierrors.MachineStatusErrorPtr(capierrors.InvalidConfigurationMachineError)
-			m.Status.FailureMessage = pointer.StringPtr(fmt.Sprintf("Machine infrastructure resource %v with name %q has been deleted after being ready",
-				m.Spec.InfrastructureRef.GroupVersionKind(), m.Spec.InfrastructureRef.Name))
+		if strings.Contains(err.Error(), "could not find") {
+			severity := clusterv1.ConditionSeverityWarning
+			if m.Status.InfrastructureReady {
+				// Infra object went missing after the machine was up and running
+				r.Log.Error(err, "Machine infrastructure reference has been deleted after being ready, setting failure state")
+				m.Status.FailureReason = capierrors.MachineStatusErrorPtr(capierrors.InvalidConfigurationMachineError)
+				m.Status.FailureMessage = pointer.StringPtr(fmt.Sprintf("Machine infrastructure resource %v with name %q has been deleted after being ready",
+					m.Spec.InfrastructureRef.GroupVersionKind(), m.Spec.InfrastructureRef.Name))
+				severity = clusterv1.ConditionSeverityError
+			}
+			conditions.MarkFalse(m, clusterv1.InfrastructureReadyCondition, clusterv1.InfrastructureNotFoundReason, severity,
+				"%s %q does not exist", m.Spec.InfrastructureRef.Kind, m.Spec.InfrastructureRef.Name)
 		}
 		return err
 	}
@@ -214,6 +341,11 @@ func (r *MachineReconciler) reconcileInfrastructure(ctx context.Context, cluster
 		return nil
 	}
 
+	// Hand over any action requested on the Machine to the infrastructure provider.
+	if err := r.reconcileInfrastructureAction(ctx, m, infraConfig); err != nil {
+		return err
+	}
+
 	// Determine if the infrastructure provider is ready.
 	ready, err := external.IsReady(infraConfig)
 	if err != nil {
@@ -221,11 +353,15 @@ func (r *MachineReconciler) reconcileInfrastructure(ctx context.Context, cluster
 	}
 	m.Status.InfrastructureReady = ready
 	if !ready {
+		conditions.MarkFalse(m, clusterv1.InfrastructureReadyCondition, clusterv1.WaitingForInfrastructureReason, clusterv1.ConditionSeverityInfo,
+			"Waiting for %s %q to be ready", infraConfig.GetKind(), infraConfig.GetName())
 		return errors.Wrapf(&capierrors.RequeueAfterError{RequeueAfter: externalReadyWait},
 			"Infrastructure provider for Machine %q in namespace %q is not ready, requeuing", m.Name, m.Namespace,
 		)
 	}
 
+	conditions.MarkTrue(m, clusterv1.InfrastructureReadyCondition)
+
 	// Get Spec.ProviderID from the infrastructure provider.
 	var providerID string
 	if err := util.UnstructuredUnmarshalField(infraConfig, &providerID, "spec", "providerID"); err != nil {
@@ -254,3 +390,33 @@ func (r *MachineReconciler) reconcileInfrastructure(ctx context.Context, cluster
 	m.Spec.ProviderID = pointer.StringPtr(providerID)
 	return nil
 }
+
+// reconcileInfrastructureAction moves the action annotation from the Machine to the InfrastructureMachine;
+// the infrastructure provider is responsible for performing the action and for removing the annotation afterwards.
+func (r *MachineReconciler) reconcileInfrastructureAction(ctx context.Context, m *clusterv1.Machine, infraConfig *unstructured.Unstructured) error {
+	action, ok := m.Annotations[clusterv1.ActionAnnotation]
+	if !ok {
+		return nil
+	}
+
+	patchHelper, err := patch.NewHelper(infraConfig, r.Client)
+	if err != nil {
+		return err
+	}
+
+	annotations := infraConfig.GetAnnotations()
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+	annotations[clusterv1.ActionAnnotation] = action
+	infraConfig.SetAnnotations(annotations)
+
+	if err := patchHelper.Patch(ctx, infraConfig); err != nil {
+		return errors.Wrapf(err, "failed to request action %q to infrastructure provider for Machine %q in namespace %q", action, m.Name, m.Namespace)
+	}
+
+	// The annotation is removed from the Machine by the patch at the end of the reconcile loop.
+	delete(m.Annotations, clusterv1.ActionAnnotation)
+	r.recorder.Eventf(m, corev1.EventTypeNormal, "ActionRequested", "Requested action %q to infrastructure provider", action)
+	return nil
+}
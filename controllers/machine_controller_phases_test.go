@@ -18,20 +18,28 @@ package controllers
 
 import (
 	"context"
+	"encoding/base64"
 	"testing"
 	"time"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
 	dto "github.com/prometheus/client_model/go"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/utils/pointer"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	capierrors "sigs.k8s.io/cluster-api/errors"
+	"sigs.k8s.io/cluster-api/util/conditions"
 	"sigs.k8s.io/cluster-api/util/kubeconfig"
+	"sigs.k8s.io/cluster-api/util/secret"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 )
@@ -506,6 +514,7 @@ func TestReconcileBootstrap(t *testing.T) {
 			expectError: true,
 			expected: func(g *WithT, m *clusterv1.Machine) {
 				g.Expect(m.Status.BootstrapReady).To(BeFalse())
+				g.Expect(conditions.GetReason(m, clusterv1.BootstrapReadyCondition)).To(Equal(clusterv1.BootstrapConfigNotFoundReason))
 			},
 		},
 		{
@@ -523,7 +532,7 @@ func TestReconcileBootstrap(t *testing.T) {
 			expectError: true,
 		},
 		{
-			name: "existing machine, bootstrap data should not change",
+			name: "existing machine, plaintext bootstrap data is moved to a secret",
 			bootstrapConfig: map[string]interface{}{
 				"kind":       "BootstrapConfig",
 				"apiVersion": "bootstrap.cluster.x-k8s.io/v1alpha3",
@@ -559,7 +568,8 @@ func TestReconcileBootstrap(t *testing.T) {
 			expectError: false,
 			expected: func(g *WithT, m *clusterv1.Machine) {
 				g.Expect(m.Status.BootstrapReady).To(BeTrue())
-				g.Expect(*m.Spec.Bootstrap.Data).To(Equal("#!/bin/bash ... data"))
+				g.Expect(m.Spec.Bootstrap.Data).To(BeNil())
+				g.Expect(m.Spec.Bootstrap.DataSecretName).To(Equal(pointer.StringPtr("bootstrap-test-existing-bootstrap-data")))
 			},
 		},
 		{
@@ -634,6 +644,52 @@ func TestReconcileBootstrap(t *testing.T) {
 	}
 }
 
+func TestReconcileBootstrapStoresPlaintextData(t *testing.T) {
+	g := NewWithT(t)
+
+	g.Expect(clusterv1.AddToScheme(scheme.Scheme)).To(Succeed())
+
+	cluster := &clusterv1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-cluster",
+			Namespace: "default",
+		},
+	}
+	machine := &clusterv1.Machine{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "machine-test",
+			Namespace: "default",
+		},
+		Spec: clusterv1.MachineSpec{
+			ClusterName: cluster.Name,
+			Bootstrap: clusterv1.Bootstrap{
+				Data: pointer.StringPtr(base64.StdEncoding.EncodeToString([]byte("#cloud-config"))),
+			},
+		},
+	}
+
+	r := &MachineReconciler{
+		Client: fake.NewFakeClientWithScheme(scheme.Scheme, machine),
+		Log:    log.Log,
+		scheme: scheme.Scheme,
+	}
+	g.Expect(r.reconcileBootstrap(context.Background(), cluster, machine)).To(Succeed())
+	g.Expect(machine.Status.BootstrapReady).To(BeTrue())
+	g.Expect(machine.Spec.Bootstrap.Data).To(BeNil())
+	g.Expect(machine.Spec.Bootstrap.DataSecretName).ToNot(BeNil())
+
+	dataSecret := &corev1.Secret{}
+	g.Expect(r.Client.Get(context.Background(), client.ObjectKey{Namespace: machine.Namespace, Name: *machine.Spec.Bootstrap.DataSecretName}, dataSecret)).To(Succeed())
+	g.Expect(dataSecret.Labels).To(HaveKeyWithValue(clusterv1.ClusterLabelName, cluster.Name))
+	g.Expect(dataSecret.OwnerReferences).To(HaveLen(1))
+	g.Expect(dataSecret.OwnerReferences[0].Kind).To(Equal("Machine"))
+
+	data, format, err := secret.BootstrapDataFromSecret(dataSecret)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(string(data)).To(Equal("#cloud-config"))
+	g.Expect(format).To(Equal(clusterv1.BootstrapDataFormatCloudConfig))
+}
+
 func TestReconcileInfrastructure(t *testing.T) {
 	defaultMachine := clusterv1.Machine{
 		ObjectMeta: metav1.ObjectMeta{
@@ -760,6 +816,8 @@ func TestReconcileInfrastructure(t *testing.T) {
 				g.Expect(m.Status.FailureMessage).ToNot(BeNil())
 				g.Expect(m.Status.FailureReason).ToNot(BeNil())
 				g.Expect(m.Status.GetTypedPhase()).To(Equal(clusterv1.MachinePhaseFailed))
+				g.Expect(conditions.GetReason(m, clusterv1.InfrastructureReadyCondition)).To(Equal(clusterv1.InfrastructureNotFoundReason))
+				g.Expect(*conditions.GetSeverity(m, clusterv1.InfrastructureReadyCondition)).To(Equal(clusterv1.ConditionSeverityError))
 			},
 		},
 		{
@@ -831,6 +889,191 @@ func TestReconcileInfrastructure(t *testing.T) {
 	}
 }
 
+func TestReconcileInfrastructureAction(t *testing.T) {
+	g := NewWithT(t)
+
+	g.Expect(clusterv1.AddToScheme(scheme.Scheme)).To(Succeed())
+
+	machine := &clusterv1.Machine{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "machine-test",
+			Namespace: "default",
+			Annotations: map[string]string{
+				clusterv1.ActionAnnotation: clusterv1.RebootAction,
+			},
+		},
+	}
+	infraConfig := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"kind":       "InfrastructureConfig",
+			"apiVersion": "infrastructure.cluster.x-k8s.io/v1alpha3",
+			"metadata": map[string]interface{}{
+				"name":      "infra-config1",
+				"namespace": "default",
+			},
+		},
+	}
+
+	r := &MachineReconciler{
+		Client:   fake.NewFakeClientWithScheme(scheme.Scheme, machine, infraConfig.DeepCopy()),
+		Log:      log.Log,
+		scheme:   scheme.Scheme,
+		recorder: record.NewFakeRecorder(32),
+	}
+
+	g.Expect(r.reconcileInfrastructureAction(context.Background(), machine, infraConfig)).To(Succeed())
+	g.Expect(machine.Annotations).ToNot(HaveKey(clusterv1.ActionAnnotation))
+
+	updatedInfraConfig := &unstructured.Unstructured{}
+	updatedInfraConfig.SetAPIVersion("infrastructure.cluster.x-k8s.io/v1alpha3")
+	updatedInfraConfig.SetKind("InfrastructureConfig")
+	g.Expect(r.Client.Get(context.Background(), types.NamespacedName{Namespace: "default", Name: "infra-config1"}, updatedInfraConfig)).To(Succeed())
+	g.Expect(updatedInfraConfig.GetAnnotations()).To(HaveKeyWithValue(clusterv1.ActionAnnotation, clusterv1.RebootAction))
+
+	// Without the annotation, the InfrastructureMachine is left untouched.
+	g.Expect(r.reconcileInfrastructureAction(context.Background(), machine, updatedInfraConfig)).To(Succeed())
+}
+
+func TestReconcilePhaseTimeout(t *testing.T) {
+	now := time.Now()
+	machineSetOwner := metav1.OwnerReference{
+		APIVersion: clusterv1.GroupVersion.String(),
+		Kind:       "MachineSet",
+		Name:       "ms1",
+		Controller: pointer.BoolPtr(true),
+	}
+
+	testCases := []struct {
+		name          string
+		machine       *clusterv1.Machine
+		expectFailed  bool
+		expectRequeue bool
+		expectDeleted bool
+	}{
+		{
+			name: "pending Machine without timeout",
+			machine: &clusterv1.Machine{
+				ObjectMeta: metav1.ObjectMeta{CreationTimestamp: metav1.NewTime(now.Add(-time.Hour))},
+			},
+		},
+		{
+			name: "pending Machine before its timeout",
+			machine: &clusterv1.Machine{
+				ObjectMeta: metav1.ObjectMeta{CreationTimestamp: metav1.NewTime(now.Add(-time.Minute))},
+				Spec:       clusterv1.MachineSpec{PendingTimeout: &metav1.Duration{Duration: 10 * time.Minute}},
+			},
+			expectRequeue: true,
+		},
+		{
+			name: "pending Machine after its timeout",
+			machine: &clusterv1.Machine{
+				ObjectMeta: metav1.ObjectMeta{CreationTimestamp: metav1.NewTime(now.Add(-time.Hour))},
+				Spec:       clusterv1.MachineSpec{PendingTimeout: &metav1.Duration{Duration: 10 * time.Minute}},
+			},
+			expectFailed: true,
+		},
+		{
+			name: "provisioning Machine measured from the moment the bootstrap data was ready",
+			machine: &clusterv1.Machine{
+				ObjectMeta: metav1.ObjectMeta{CreationTimestamp: metav1.NewTime(now.Add(-time.Hour))},
+				Spec:       clusterv1.MachineSpec{ProvisioningTimeout: &metav1.Duration{Duration: 10 * time.Minute}},
+				Status: clusterv1.MachineStatus{
+					BootstrapReady: true,
+					Conditions: clusterv1.Conditions{{
+						Type:               clusterv1.BootstrapReadyCondition,
+						Status:             corev1.ConditionTrue,
+						LastTransitionTime: metav1.NewTime(now.Add(-time.Minute)),
+					}},
+				},
+			},
+			expectRequeue: true,
+		},
+		{
+			name: "provisioning Machine after its timeout",
+			machine: &clusterv1.Machine{
+				ObjectMeta: metav1.ObjectMeta{CreationTimestamp: metav1.NewTime(now.Add(-time.Hour))},
+				Spec:       clusterv1.MachineSpec{ProvisioningTimeout: &metav1.Duration{Duration: 10 * time.Minute}},
+				Status:     clusterv1.MachineStatus{BootstrapReady: true},
+			},
+			expectFailed: true,
+		},
+		{
+			name: "provisioned Machine is not subject to the timeouts",
+			machine: &clusterv1.Machine{
+				ObjectMeta: metav1.ObjectMeta{CreationTimestamp: metav1.NewTime(now.Add(-time.Hour))},
+				Spec:       clusterv1.MachineSpec{ProvisioningTimeout: &metav1.Duration{Duration: 10 * time.Minute}},
+				Status:     clusterv1.MachineStatus{BootstrapReady: true, InfrastructureReady: true},
+			},
+		},
+		{
+			name: "Machine controlled by a MachineSet is replaced after its timeout",
+			machine: &clusterv1.Machine{
+				ObjectMeta: metav1.ObjectMeta{
+					CreationTimestamp: metav1.NewTime(now.Add(-time.Hour)),
+					OwnerReferences:   []metav1.OwnerReference{machineSetOwner},
+				},
+				Spec: clusterv1.MachineSpec{
+					PendingTimeout:        &metav1.Duration{Duration: 10 * time.Minute},
+					ReplaceOnPhaseTimeout: true,
+				},
+			},
+			expectFailed:  true,
+			expectDeleted: true,
+		},
+		{
+			name: "Machine not controlled by a MachineSet is not replaced after its timeout",
+			machine: &clusterv1.Machine{
+				ObjectMeta: metav1.ObjectMeta{CreationTimestamp: metav1.NewTime(now.Add(-time.Hour))},
+				Spec: clusterv1.MachineSpec{
+					PendingTimeout:        &metav1.Duration{Duration: 10 * time.Minute},
+					ReplaceOnPhaseTimeout: true,
+				},
+			},
+			expectFailed: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			g.Expect(clusterv1.AddToScheme(scheme.Scheme)).To(Succeed())
+
+			tc.machine.Name = "machine-test"
+			tc.machine.Namespace = "default"
+			r := &MachineReconciler{
+				Client:   fake.NewFakeClientWithScheme(scheme.Scheme, tc.machine.DeepCopy()),
+				Log:      log.Log,
+				scheme:   scheme.Scheme,
+				recorder: record.NewFakeRecorder(32),
+			}
+
+			err := r.reconcilePhaseTimeout(context.Background(), nil, tc.machine)
+			if tc.expectRequeue {
+				g.Expect(err).To(HaveOccurred())
+				_, ok := errors.Cause(err).(capierrors.HasRequeueAfterError)
+				g.Expect(ok).To(BeTrue())
+			} else {
+				g.Expect(err).NotTo(HaveOccurred())
+			}
+
+			if tc.expectFailed {
+				g.Expect(tc.machine.Status.FailureReason).To(Equal(capierrors.MachineStatusErrorPtr(capierrors.PhaseTimeoutMachineError)))
+				g.Expect(tc.machine.Status.FailureMessage).NotTo(BeNil())
+			} else {
+				g.Expect(tc.machine.Status.FailureReason).To(BeNil())
+			}
+
+			err = r.Client.Get(context.Background(), types.NamespacedName{Namespace: "default", Name: "machine-test"}, &clusterv1.Machine{})
+			if tc.expectDeleted {
+				g.Expect(apierrors.IsNotFound(err)).To(BeTrue())
+			} else {
+				g.Expect(err).NotTo(HaveOccurred())
+			}
+		})
+	}
+}
+
 func getMetricFamily(list []*dto.MetricFamily, metricName string) *dto.MetricFamily {
 	for _, mf := range list {
 		if mf.GetName() == metricName {
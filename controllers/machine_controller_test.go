@@ -19,14 +19,17 @@ package controllers
 import (
 	"context"
 	"testing"
+	"time"
 
 	. "github.com/onsi/gomega"
 	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/utils/pointer"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
@@ -35,6 +38,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/util/conditions"
 )
 
 func TestMachineFinalizer(t *testing.T) {
@@ -536,6 +540,108 @@ func TestReconcileDeleteExternal(t *testing.T) {
 	}
 }
 
+func TestReconcileInfrastructureRelease(t *testing.T) {
+	machine := &clusterv1.Machine{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "delete",
+			Namespace: "default",
+		},
+		Spec: clusterv1.MachineSpec{
+			ClusterName: "test-cluster",
+			InfrastructureRef: corev1.ObjectReference{
+				APIVersion: "infrastructure.cluster.x-k8s.io/v1alpha3",
+				Kind:       "InfrastructureConfig",
+				Name:       "delete-infra",
+			},
+		},
+	}
+	infraConfig := func(status map[string]interface{}) *unstructured.Unstructured {
+		obj := &unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"kind":       "InfrastructureConfig",
+				"apiVersion": "infrastructure.cluster.x-k8s.io/v1alpha3",
+				"metadata": map[string]interface{}{
+					"name":      "delete-infra",
+					"namespace": "default",
+				},
+			},
+		}
+		if status != nil {
+			obj.Object["status"] = status
+		}
+		return obj
+	}
+
+	testCases := []struct {
+		name              string
+		infraConfig       *unstructured.Unstructured
+		expected          bool
+		expectReleaseSent bool
+	}{
+		{
+			name:        "should be released if the infrastructure does not exist",
+			infraConfig: nil,
+			expected:    true,
+		},
+		{
+			name:        "should be released if the provider does not support the release",
+			infraConfig: infraConfig(nil),
+			expected:    true,
+		},
+		{
+			name:              "should request the release",
+			infraConfig:       infraConfig(map[string]interface{}{"released": false}),
+			expected:          false,
+			expectReleaseSent: true,
+		},
+		{
+			name:        "should be released once the provider has released the machine",
+			infraConfig: infraConfig(map[string]interface{}{"released": true}),
+			expected:    true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			g.Expect(clusterv1.AddToScheme(scheme.Scheme)).To(Succeed())
+
+			objs := []runtime.Object{machine}
+			if tc.infraConfig != nil {
+				objs = append(objs, tc.infraConfig)
+			}
+
+			r := &MachineReconciler{
+				Client:   fake.NewFakeClientWithScheme(scheme.Scheme, objs...),
+				Log:      log.Log,
+				scheme:   scheme.Scheme,
+				recorder: record.NewFakeRecorder(32),
+			}
+
+			m := machine.DeepCopy()
+			released, err := r.reconcileInfrastructureRelease(ctx, m)
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(released).To(Equal(tc.expected))
+			if tc.infraConfig == nil {
+				return
+			}
+
+			updatedInfraConfig := &unstructured.Unstructured{}
+			updatedInfraConfig.SetAPIVersion("infrastructure.cluster.x-k8s.io/v1alpha3")
+			updatedInfraConfig.SetKind("InfrastructureConfig")
+			g.Expect(r.Client.Get(ctx, types.NamespacedName{Namespace: "default", Name: "delete-infra"}, updatedInfraConfig)).To(Succeed())
+			if tc.expectReleaseSent {
+				g.Expect(updatedInfraConfig.GetAnnotations()).To(HaveKeyWithValue(clusterv1.ActionAnnotation, clusterv1.ReleaseAction))
+				g.Expect(conditions.IsFalse(m, clusterv1.InfrastructureReadyCondition)).To(BeTrue())
+				g.Expect(conditions.GetReason(m, clusterv1.InfrastructureReadyCondition)).To(Equal(clusterv1.WaitingForInfrastructureReleaseReason))
+			} else {
+				g.Expect(updatedInfraConfig.GetAnnotations()).NotTo(HaveKey(clusterv1.ActionAnnotation))
+			}
+		})
+	}
+}
+
 func TestRemoveMachineFinalizerAfterDeleteReconcile(t *testing.T) {
 	g := NewWithT(t)
 
@@ -668,3 +774,533 @@ func TestReconcileMetrics(t *testing.T) {
 		})
 	}
 }
+
+func TestReconcileMetricsPhase(t *testing.T) {
+	g := NewWithT(t)
+
+	machine := &clusterv1.Machine{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "test-machine-phase",
+		},
+		Status: clusterv1.MachineStatus{
+			Phase: string(clusterv1.MachinePhaseRunning),
+		},
+	}
+
+	r := &MachineReconciler{
+		Client: fake.NewFakeClientWithScheme(scheme.Scheme, machine),
+		Log:    log.Log,
+		scheme: scheme.Scheme,
+	}
+
+	r.reconcileMetrics(context.TODO(), machine)
+
+	mr, err := metrics.Registry.Gather()
+	g.Expect(err).ToNot(HaveOccurred())
+	mf := getMetricFamily(mr, "capi_machine_phase")
+	g.Expect(mf).ToNot(BeNil())
+
+	phases := map[string]float64{}
+	for _, m := range mf.GetMetric() {
+		var name, phase string
+		for _, l := range m.GetLabel() {
+			switch l.GetName() {
+			case "machine":
+				name = l.GetValue()
+			case "phase":
+				phase = l.GetValue()
+			}
+		}
+		if name == machine.Name {
+			phases[phase] = m.GetGauge().GetValue()
+		}
+	}
+	g.Expect(phases).To(HaveKeyWithValue(string(clusterv1.MachinePhaseRunning), float64(1)))
+	g.Expect(phases).To(HaveKeyWithValue(string(clusterv1.MachinePhasePending), float64(0)))
+	g.Expect(phases).To(HaveKeyWithValue(string(clusterv1.MachinePhaseDeleting), float64(0)))
+}
+
+func TestReconcileMetricsReferenceNotFound(t *testing.T) {
+	g := NewWithT(t)
+
+	machine := &clusterv1.Machine{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "test-machine-reference-not-found",
+		},
+		Status: clusterv1.MachineStatus{
+			Conditions: clusterv1.Conditions{
+				*conditions.FalseCondition(clusterv1.InfrastructureReadyCondition, clusterv1.InfrastructureNotFoundReason, clusterv1.ConditionSeverityError, ""),
+				*conditions.TrueCondition(clusterv1.BootstrapReadyCondition),
+			},
+		},
+	}
+
+	r := &MachineReconciler{
+		Client: fake.NewFakeClientWithScheme(scheme.Scheme, machine),
+		Log:    log.Log,
+		scheme: scheme.Scheme,
+	}
+
+	r.reconcileMetrics(context.TODO(), machine)
+
+	mr, err := metrics.Registry.Gather()
+	g.Expect(err).ToNot(HaveOccurred())
+	mf := getMetricFamily(mr, "capi_machine_reference_not_found")
+	g.Expect(mf).ToNot(BeNil())
+
+	references := map[string]float64{}
+	for _, m := range mf.GetMetric() {
+		var name, reference string
+		for _, l := range m.GetLabel() {
+			switch l.GetName() {
+			case "machine":
+				name = l.GetValue()
+			case "reference":
+				reference = l.GetValue()
+			}
+		}
+		if name == machine.Name {
+			references[reference] = m.GetGauge().GetValue()
+		}
+	}
+	g.Expect(references).To(HaveKeyWithValue("infrastructure", float64(1)))
+	g.Expect(references).To(HaveKeyWithValue("bootstrap", float64(0)))
+}
+
+func TestIsDeleteNodeAllowed(t *testing.T) {
+	// Returns a control plane object, externally managed or not.
+	controlPlane := func(externalManaged bool) *unstructured.Unstructured {
+		return &unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"kind":       "ControlPlane",
+				"apiVersion": "controlplane.cluster.x-k8s.io/v1alpha3",
+				"metadata": map[string]interface{}{
+					"name":      "test-control-plane",
+					"namespace": "default",
+				},
+				"status": map[string]interface{}{
+					"externalManagedControlPlane": externalManaged,
+				},
+			},
+		}
+	}
+
+	controlPlaneRef := &corev1.ObjectReference{
+		APIVersion: "controlplane.cluster.x-k8s.io/v1alpha3",
+		Kind:       "ControlPlane",
+		Name:       "test-control-plane",
+	}
+
+	controlPlaneMachine := &clusterv1.Machine{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "control-plane",
+			Namespace: "default",
+			Labels: map[string]string{
+				clusterv1.ClusterLabelName:             "test-cluster",
+				clusterv1.MachineControlPlaneLabelName: "",
+			},
+		},
+	}
+
+	tests := []struct {
+		name            string
+		nodeRef         *corev1.ObjectReference
+		controlPlaneRef *corev1.ObjectReference
+		objs            []runtime.Object
+		expectedError   error
+	}{
+		{
+			name:          "machine without a node",
+			expectedError: errNilNodeRef,
+		},
+		{
+			name:          "cluster without control plane machines",
+			nodeRef:       &corev1.ObjectReference{Name: "test-node"},
+			expectedError: errNoControlPlaneNodes,
+		},
+		{
+			name:            "cluster with a control plane not externally managed, without control plane machines",
+			nodeRef:         &corev1.ObjectReference{Name: "test-node"},
+			controlPlaneRef: controlPlaneRef,
+			objs:            []runtime.Object{controlPlane(false)},
+			expectedError:   errNoControlPlaneNodes,
+		},
+		{
+			name:            "cluster with an externally managed control plane",
+			nodeRef:         &corev1.ObjectReference{Name: "test-node"},
+			controlPlaneRef: controlPlaneRef,
+			objs:            []runtime.Object{controlPlane(true)},
+			expectedError:   nil,
+		},
+		{
+			name:          "cluster with control plane machines",
+			nodeRef:       &corev1.ObjectReference{Name: "test-node"},
+			objs:          []runtime.Object{controlPlaneMachine},
+			expectedError: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			g.Expect(clusterv1.AddToScheme(scheme.Scheme)).To(Succeed())
+
+			cluster := &clusterv1.Cluster{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-cluster", Namespace: "default"},
+				Spec:       clusterv1.ClusterSpec{ControlPlaneRef: tt.controlPlaneRef},
+			}
+			m := &clusterv1.Machine{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "worker",
+					Namespace: "default",
+					Labels:    map[string]string{clusterv1.ClusterLabelName: "test-cluster"},
+				},
+				Status: clusterv1.MachineStatus{NodeRef: tt.nodeRef},
+			}
+			r := &MachineReconciler{
+				Client: fake.NewFakeClientWithScheme(scheme.Scheme, append(tt.objs, cluster)...),
+				Log:    log.Log,
+				scheme: scheme.Scheme,
+			}
+
+			err := r.isDeleteNodeAllowed(context.Background(), cluster, m)
+			if tt.expectedError == nil {
+				g.Expect(err).NotTo(HaveOccurred())
+				return
+			}
+			g.Expect(err).To(Equal(tt.expectedError))
+		})
+	}
+}
+
+func TestIsNodeVolumeDetachTimeoutExpired(t *testing.T) {
+	tests := []struct {
+		name     string
+		machine  *clusterv1.Machine
+		expected bool
+	}{
+		{
+			name: "timeout not set",
+			machine: &clusterv1.Machine{
+				ObjectMeta: metav1.ObjectMeta{DeletionTimestamp: &metav1.Time{Time: time.Now().Add(-time.Hour)}},
+			},
+			expected: false,
+		},
+		{
+			name: "machine not being deleted",
+			machine: &clusterv1.Machine{
+				Spec: clusterv1.MachineSpec{NodeVolumeDetachTimeout: &metav1.Duration{Duration: time.Minute}},
+			},
+			expected: false,
+		},
+		{
+			name: "timeout not expired",
+			machine: &clusterv1.Machine{
+				ObjectMeta: metav1.ObjectMeta{DeletionTimestamp: &metav1.Time{Time: time.Now().Add(-time.Second)}},
+				Spec:       clusterv1.MachineSpec{NodeVolumeDetachTimeout: &metav1.Duration{Duration: time.Minute}},
+			},
+			expected: false,
+		},
+		{
+			name: "timeout expired",
+			machine: &clusterv1.Machine{
+				ObjectMeta: metav1.ObjectMeta{DeletionTimestamp: &metav1.Time{Time: time.Now().Add(-time.Hour)}},
+				Spec:       clusterv1.MachineSpec{NodeVolumeDetachTimeout: &metav1.Duration{Duration: time.Minute}},
+			},
+			expected: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			g.Expect(isNodeVolumeDetachTimeoutExpired(tt.machine)).To(Equal(tt.expected))
+		})
+	}
+}
+
+func TestGetAttachedVolumes(t *testing.T) {
+	g := NewWithT(t)
+
+	volumeAttachment := func(name, nodeName string, pvName *string) *storagev1.VolumeAttachment {
+		return &storagev1.VolumeAttachment{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec: storagev1.VolumeAttachmentSpec{
+				Attacher: "csi.example.com",
+				NodeName: nodeName,
+				Source:   storagev1.VolumeAttachmentSource{PersistentVolumeName: pvName},
+			},
+		}
+	}
+
+	c := fake.NewFakeClientWithScheme(scheme.Scheme,
+		volumeAttachment("va1", "node1", pointer.StringPtr("pv1")),
+		volumeAttachment("va2", "node1", nil),
+		volumeAttachment("va3", "node2", pointer.StringPtr("pv3")),
+	)
+
+	volumes, err := getAttachedVolumes(context.TODO(), c, "node1")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(volumes).To(ConsistOf("pv1", "va2"))
+
+	volumes, err = getAttachedVolumes(context.TODO(), c, "node3")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(volumes).To(BeEmpty())
+}
+
+func TestIsNodeDrainTimeoutExpired(t *testing.T) {
+	tests := []struct {
+		name     string
+		machine  *clusterv1.Machine
+		expected bool
+	}{
+		{
+			name: "timeout not set",
+			machine: &clusterv1.Machine{
+				ObjectMeta: metav1.ObjectMeta{DeletionTimestamp: &metav1.Time{Time: time.Now().Add(-time.Hour)}},
+			},
+			expected: false,
+		},
+		{
+			name: "machine not being deleted",
+			machine: &clusterv1.Machine{
+				Spec: clusterv1.MachineSpec{NodeDrainTimeout: &metav1.Duration{Duration: time.Minute}},
+			},
+			expected: false,
+		},
+		{
+			name: "timeout not expired",
+			machine: &clusterv1.Machine{
+				ObjectMeta: metav1.ObjectMeta{DeletionTimestamp: &metav1.Time{Time: time.Now().Add(-time.Second)}},
+				Spec:       clusterv1.MachineSpec{NodeDrainTimeout: &metav1.Duration{Duration: time.Minute}},
+			},
+			expected: false,
+		},
+		{
+			name: "timeout expired",
+			machine: &clusterv1.Machine{
+				ObjectMeta: metav1.ObjectMeta{DeletionTimestamp: &metav1.Time{Time: time.Now().Add(-time.Hour)}},
+				Spec:       clusterv1.MachineSpec{NodeDrainTimeout: &metav1.Duration{Duration: time.Minute}},
+			},
+			expected: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			g.Expect(isNodeDrainTimeoutExpired(tt.machine)).To(Equal(tt.expected))
+		})
+	}
+}
+
+func TestNodeDrainGracePeriodSeconds(t *testing.T) {
+	g := NewWithT(t)
+
+	g.Expect(nodeDrainGracePeriodSeconds(&clusterv1.Machine{})).To(Equal(-1))
+	g.Expect(nodeDrainGracePeriodSeconds(&clusterv1.Machine{
+		Spec: clusterv1.MachineSpec{NodeDrainGracePeriodSeconds: pointer.Int32Ptr(30)},
+	})).To(Equal(30))
+}
+
+func TestReconcileDrainNode(t *testing.T) {
+	tests := []struct {
+		name           string
+		drainTimeout   *metav1.Duration
+		expectedStatus corev1.ConditionStatus
+		expectedReason string
+	}{
+		{
+			name:           "drain timeout expired",
+			drainTimeout:   &metav1.Duration{Duration: time.Minute},
+			expectedStatus: corev1.ConditionFalse,
+			expectedReason: clusterv1.DrainingTimedOutReason,
+		},
+		{
+			// The kubeconfig of the workload cluster does not exist, so the node is not drained and the deletion continues.
+			name:           "drain completed",
+			expectedStatus: corev1.ConditionTrue,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			g.Expect(clusterv1.AddToScheme(scheme.Scheme)).To(Succeed())
+
+			cluster := &clusterv1.Cluster{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-cluster", Namespace: "default"},
+			}
+			m := &clusterv1.Machine{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:              "test-machine",
+					Namespace:         "default",
+					DeletionTimestamp: &metav1.Time{Time: time.Now().Add(-time.Hour)},
+				},
+				Spec: clusterv1.MachineSpec{
+					NodeDrainTimeout: tt.drainTimeout,
+				},
+				Status: clusterv1.MachineStatus{
+					NodeRef: &corev1.ObjectReference{Name: "test-node"},
+				},
+			}
+			r := &MachineReconciler{
+				Client:   fake.NewFakeClientWithScheme(scheme.Scheme, cluster, m),
+				Log:      log.Log,
+				scheme:   scheme.Scheme,
+				recorder: record.NewFakeRecorder(32),
+			}
+
+			g.Expect(r.reconcileDrainNode(context.Background(), cluster, m)).To(Succeed())
+
+			condition := conditions.Get(m, clusterv1.DrainingSucceededCondition)
+			g.Expect(condition).NotTo(BeNil())
+			g.Expect(condition.Status).To(Equal(tt.expectedStatus))
+			g.Expect(condition.Reason).To(Equal(tt.expectedReason))
+		})
+	}
+}
+
+func TestWaitForDeleteHooks(t *testing.T) {
+	preDrainHook := clusterv1.PreDrainDeleteHookAnnotationPrefix + "/detach-storage"
+	preTerminateHook := clusterv1.PreTerminateDeleteHookAnnotationPrefix + "/update-cmdb"
+
+	// Returns the condition of a Machine waiting for the pre-drain delete hooks since the given time.
+	waitingSince := func(d time.Duration, reason string) *clusterv1.Condition {
+		return &clusterv1.Condition{
+			Type:               clusterv1.PreDrainDeleteHookSucceededCondition,
+			Status:             corev1.ConditionFalse,
+			Severity:           clusterv1.ConditionSeverityInfo,
+			Reason:             reason,
+			LastTransitionTime: metav1.NewTime(time.Now().Add(-d)),
+		}
+	}
+
+	tests := []struct {
+		name              string
+		annotations       map[string]string
+		condition         *clusterv1.Condition
+		timeout           *metav1.Duration
+		expectedBlocked   bool
+		expectedRequeue   bool
+		expectedCondition *clusterv1.Condition
+	}{
+		{
+			name:              "no hooks",
+			annotations:       map[string]string{preTerminateHook: "cmdb-controller"},
+			expectedBlocked:   false,
+			expectedCondition: nil,
+		},
+		{
+			name:              "hooks removed",
+			condition:         waitingSince(time.Minute, clusterv1.WaitingExternalHookReason),
+			expectedBlocked:   false,
+			expectedCondition: conditions.TrueCondition(clusterv1.PreDrainDeleteHookSucceededCondition),
+		},
+		{
+			name:              "hook without timeout",
+			annotations:       map[string]string{preDrainHook: "storage-controller"},
+			condition:         waitingSince(time.Hour, clusterv1.WaitingExternalHookReason),
+			expectedBlocked:   true,
+			expectedCondition: conditions.FalseCondition(clusterv1.PreDrainDeleteHookSucceededCondition, clusterv1.WaitingExternalHookReason, clusterv1.ConditionSeverityInfo, ""),
+		},
+		{
+			name:              "hook found for the first time, with timeout",
+			annotations:       map[string]string{preDrainHook: "storage-controller"},
+			timeout:           &metav1.Duration{Duration: time.Minute},
+			expectedBlocked:   true,
+			expectedRequeue:   true,
+			expectedCondition: conditions.FalseCondition(clusterv1.PreDrainDeleteHookSucceededCondition, clusterv1.WaitingExternalHookReason, clusterv1.ConditionSeverityInfo, ""),
+		},
+		{
+			name:              "hook with timeout not expired",
+			annotations:       map[string]string{preDrainHook: "storage-controller"},
+			condition:         waitingSince(time.Second, clusterv1.WaitingExternalHookReason),
+			timeout:           &metav1.Duration{Duration: time.Minute},
+			expectedBlocked:   true,
+			expectedRequeue:   true,
+			expectedCondition: conditions.FalseCondition(clusterv1.PreDrainDeleteHookSucceededCondition, clusterv1.WaitingExternalHookReason, clusterv1.ConditionSeverityInfo, ""),
+		},
+		{
+			name:              "hook with timeout expired",
+			annotations:       map[string]string{preDrainHook: "storage-controller"},
+			condition:         waitingSince(time.Hour, clusterv1.WaitingExternalHookReason),
+			timeout:           &metav1.Duration{Duration: time.Minute},
+			expectedBlocked:   false,
+			expectedCondition: conditions.FalseCondition(clusterv1.PreDrainDeleteHookSucceededCondition, clusterv1.DeleteHookTimedOutReason, clusterv1.ConditionSeverityWarning, ""),
+		},
+		{
+			name:              "hook already timed out",
+			annotations:       map[string]string{preDrainHook: "storage-controller"},
+			condition:         waitingSince(time.Second, clusterv1.DeleteHookTimedOutReason),
+			timeout:           &metav1.Duration{Duration: time.Minute},
+			expectedBlocked:   false,
+			expectedCondition: conditions.FalseCondition(clusterv1.PreDrainDeleteHookSucceededCondition, clusterv1.DeleteHookTimedOutReason, clusterv1.ConditionSeverityWarning, ""),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			// The Machine deletion started long before, but the timeout is measured from the moment
+			// the controller started waiting for the hooks.
+			m := &clusterv1.Machine{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:              "test-machine",
+					Namespace:         "default",
+					Annotations:       tt.annotations,
+					DeletionTimestamp: &metav1.Time{Time: time.Now().Add(-24 * time.Hour)},
+				},
+			}
+			if tt.condition != nil {
+				conditions.Set(m, tt.condition)
+			}
+			r := &MachineReconciler{
+				Log:      log.Log,
+				recorder: record.NewFakeRecorder(32),
+			}
+
+			result, blocked := r.waitForDeleteHooks(r.Log, m, clusterv1.PreDrainDeleteHookAnnotationPrefix, clusterv1.PreDrainDeleteHookSucceededCondition, tt.timeout)
+			g.Expect(blocked).To(Equal(tt.expectedBlocked))
+			g.Expect(result.RequeueAfter > 0).To(Equal(tt.expectedRequeue))
+
+			condition := conditions.Get(m, clusterv1.PreDrainDeleteHookSucceededCondition)
+			if tt.expectedCondition == nil {
+				g.Expect(condition).To(BeNil())
+				return
+			}
+			g.Expect(condition).NotTo(BeNil())
+			g.Expect(condition.Status).To(Equal(tt.expectedCondition.Status))
+			g.Expect(condition.Reason).To(Equal(tt.expectedCondition.Reason))
+		})
+	}
+}
+
+func TestGetDeleteHooks(t *testing.T) {
+	g := NewWithT(t)
+
+	m := &clusterv1.Machine{
+		ObjectMeta: metav1.ObjectMeta{
+			Annotations: map[string]string{
+				clusterv1.PreDrainDeleteHookAnnotationPrefix + "/b":          "",
+				clusterv1.PreDrainDeleteHookAnnotationPrefix + "/a":          "",
+				clusterv1.PreTerminateDeleteHookAnnotationPrefix + "/c":      "",
+				clusterv1.PreDrainDeleteHookAnnotationPrefix + "-not-a-hook": "",
+			},
+		},
+	}
+
+	g.Expect(getDeleteHooks(m, clusterv1.PreDrainDeleteHookAnnotationPrefix)).To(Equal([]string{
+		clusterv1.PreDrainDeleteHookAnnotationPrefix + "/a",
+		clusterv1.PreDrainDeleteHookAnnotationPrefix + "/b",
+	}))
+	g.Expect(getDeleteHooks(m, clusterv1.PreTerminateDeleteHookAnnotationPrefix)).To(Equal([]string{
+		clusterv1.PreTerminateDeleteHookAnnotationPrefix + "/c",
+	}))
+	g.Expect(getDeleteHooks(&clusterv1.Machine{}, clusterv1.PreTerminateDeleteHookAnnotationPrefix)).To(BeEmpty())
+}
@@ -0,0 +1,143 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/controllers/noderefutil"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// machineClusterNameIndex indexes the Machines by the name of their Cluster.
+	machineClusterNameIndex = "spec.clusterName"
+
+	// machineNodeNameIndex indexes the Machines by the name of their Node.
+	machineNodeNameIndex = "status.nodeRef.name"
+
+	// machineProviderIDIndex indexes the Machines by their provider ID, normalized as done by noderefutil.ProviderID
+	// when comparing provider IDs.
+	machineProviderIDIndex = "spec.providerID"
+)
+
+// addMachineIndexes adds to the manager cache the indexes used for looking up Machines, so the lookups don't have
+// to list and scan all the Machines, which does not scale with large fleets.
+func (r *MachineReconciler) addMachineIndexes(mgr ctrl.Manager) error {
+	indexes := map[string]func(runtime.Object) []string{
+		machineClusterNameIndex: r.indexMachineByClusterName,
+		machineNodeNameIndex:    r.indexMachineByNodeName,
+		machineProviderIDIndex:  r.indexMachineByProviderID,
+	}
+	for field, extractValue := range indexes {
+		if err := mgr.GetCache().IndexField(&clusterv1.Machine{}, field, extractValue); err != nil {
+			return errors.Wrapf(err, "error setting index field %q", field)
+		}
+	}
+	return nil
+}
+
+func (r *MachineReconciler) indexMachineByClusterName(object runtime.Object) []string {
+	machine, ok := object.(*clusterv1.Machine)
+	if !ok {
+		r.Log.Error(errors.New("incorrect type"), "expected a Machine", "type", fmt.Sprintf("%T", object))
+		return nil
+	}
+
+	return []string{machine.Spec.ClusterName}
+}
+
+func (r *MachineReconciler) indexMachineByNodeName(object runtime.Object) []string {
+	machine, ok := object.(*clusterv1.Machine)
+	if !ok {
+		r.Log.Error(errors.New("incorrect type"), "expected a Machine", "type", fmt.Sprintf("%T", object))
+		return nil
+	}
+
+	if machine.Status.NodeRef == nil || machine.Status.NodeRef.Name == "" {
+		return nil
+	}
+	return []string{machine.Status.NodeRef.Name}
+}
+
+func (r *MachineReconciler) indexMachineByProviderID(object runtime.Object) []string {
+	machine, ok := object.(*clusterv1.Machine)
+	if !ok {
+		r.Log.Error(errors.New("incorrect type"), "expected a Machine", "type", fmt.Sprintf("%T", object))
+		return nil
+	}
+
+	if machine.Spec.ProviderID == nil {
+		return nil
+	}
+	providerID, err := noderefutil.NewProviderID(*machine.Spec.ProviderID)
+	if err != nil {
+		return nil
+	}
+	return []string{providerIDIndexKey(providerID)}
+}
+
+// providerIDIndexKey returns the key of a provider ID in the indexes; provider IDs are equal if their cloud provider
+// and ID match, regardless of the optional segments in between.
+func providerIDIndexKey(providerID *noderefutil.ProviderID) string {
+	return providerID.CloudProvider() + "://" + providerID.ID()
+}
+
+// getMachineByNodeName returns the Machine of a Cluster referencing the Node with the given name, if any.
+func getMachineByNodeName(ctx context.Context, c client.Client, namespace, clusterName, nodeName string) (*clusterv1.Machine, error) {
+	machineList := &clusterv1.MachineList{}
+	if err := c.List(ctx, machineList, client.InNamespace(namespace), client.MatchingFields{machineNodeNameIndex: nodeName}); err != nil {
+		return nil, errors.Wrapf(err, "failed to list Machines referencing Node %q", nodeName)
+	}
+
+	// NB. The index is checked again, because not all the clients support field indexes.
+	for i := range machineList.Items {
+		machine := &machineList.Items[i]
+		if machine.Spec.ClusterName == clusterName && machine.Status.NodeRef != nil && machine.Status.NodeRef.Name == nodeName {
+			return machine, nil
+		}
+	}
+	return nil, nil
+}
+
+// getMachinesByProviderID returns the Machines of a Cluster with the given provider ID.
+func getMachinesByProviderID(ctx context.Context, c client.Client, namespace, clusterName string, providerID *noderefutil.ProviderID) ([]*clusterv1.Machine, error) {
+	machineList := &clusterv1.MachineList{}
+	if err := c.List(ctx, machineList, client.InNamespace(namespace), client.MatchingFields{machineProviderIDIndex: providerIDIndexKey(providerID)}); err != nil {
+		return nil, errors.Wrapf(err, "failed to list Machines with provider ID %q", providerID)
+	}
+
+	// NB. The index is checked again, because not all the clients support field indexes.
+	machines := []*clusterv1.Machine{}
+	for i := range machineList.Items {
+		machine := &machineList.Items[i]
+		if machine.Spec.ClusterName != clusterName || machine.Spec.ProviderID == nil {
+			continue
+		}
+		machineProviderID, err := noderefutil.NewProviderID(*machine.Spec.ProviderID)
+		if err != nil || !machineProviderID.Equals(providerID) {
+			continue
+		}
+		machines = append(machines, machine)
+	}
+	return machines, nil
+}
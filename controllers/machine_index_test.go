@@ -0,0 +1,124 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/controllers/noderefutil"
+)
+
+func TestMachineIndexes(t *testing.T) {
+	g := NewWithT(t)
+
+	r := &MachineReconciler{Log: log.Log}
+	machine := &clusterv1.Machine{
+		Spec: clusterv1.MachineSpec{
+			ClusterName: "cluster",
+			ProviderID:  pointer.StringPtr("aws:///us-east-1/id-node-1"),
+		},
+		Status: clusterv1.MachineStatus{
+			NodeRef: &corev1.ObjectReference{Name: "node-1"},
+		},
+	}
+
+	g.Expect(r.indexMachineByClusterName(machine)).To(ConsistOf("cluster"))
+	g.Expect(r.indexMachineByNodeName(machine)).To(ConsistOf("node-1"))
+	g.Expect(r.indexMachineByProviderID(machine)).To(ConsistOf("aws://id-node-1"))
+
+	// Machines without a Node or a ProviderID yet are not indexed.
+	g.Expect(r.indexMachineByNodeName(&clusterv1.Machine{})).To(BeEmpty())
+	g.Expect(r.indexMachineByProviderID(&clusterv1.Machine{})).To(BeEmpty())
+	g.Expect(r.indexMachineByProviderID(&clusterv1.Machine{Spec: clusterv1.MachineSpec{ProviderID: pointer.StringPtr("invalid")}})).To(BeEmpty())
+
+	// Other objects are not indexed.
+	g.Expect(r.indexMachineByClusterName(&clusterv1.Cluster{})).To(BeNil())
+}
+
+func TestGetMachineByNodeName(t *testing.T) {
+	g := NewWithT(t)
+
+	g.Expect(clusterv1.AddToScheme(scheme.Scheme)).To(Succeed())
+
+	machine := func(name, clusterName, nodeName string) *clusterv1.Machine {
+		m := &clusterv1.Machine{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name},
+			Spec:       clusterv1.MachineSpec{ClusterName: clusterName},
+		}
+		if nodeName != "" {
+			m.Status.NodeRef = &corev1.ObjectReference{Name: nodeName}
+		}
+		return m
+	}
+	c := fake.NewFakeClientWithScheme(scheme.Scheme,
+		machine("machine-1", "cluster-1", "node-1"),
+		machine("machine-2", "cluster-2", "node-1"),
+		machine("machine-3", "cluster-1", ""),
+	)
+
+	got, err := getMachineByNodeName(context.Background(), c, "default", "cluster-2", "node-1")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(got).NotTo(BeNil())
+	g.Expect(got.Name).To(Equal("machine-2"))
+
+	got, err = getMachineByNodeName(context.Background(), c, "default", "cluster-1", "node-2")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(got).To(BeNil())
+}
+
+func TestGetMachinesByProviderID(t *testing.T) {
+	g := NewWithT(t)
+
+	g.Expect(clusterv1.AddToScheme(scheme.Scheme)).To(Succeed())
+
+	machine := func(name, clusterName, providerID string) *clusterv1.Machine {
+		return &clusterv1.Machine{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name},
+			Spec: clusterv1.MachineSpec{
+				ClusterName: clusterName,
+				ProviderID:  pointer.StringPtr(providerID),
+			},
+		}
+	}
+	c := fake.NewFakeClientWithScheme(scheme.Scheme,
+		machine("machine-1", "cluster-1", "aws:///us-east-1/id-node-1"),
+		machine("machine-2", "cluster-1", "aws:///us-west-2/id-node-1"),
+		machine("machine-3", "cluster-1", "aws:///us-east-1/id-node-2"),
+		machine("machine-4", "cluster-2", "aws:///us-east-1/id-node-1"),
+	)
+
+	providerID, err := noderefutil.NewProviderID("aws:///id-node-1")
+	g.Expect(err).NotTo(HaveOccurred())
+
+	got, err := getMachinesByProviderID(context.Background(), c, "default", "cluster-1", providerID)
+	g.Expect(err).NotTo(HaveOccurred())
+	names := []string{}
+	for _, m := range got {
+		names = append(names, m.Name)
+	}
+	g.Expect(names).To(ConsistOf("machine-1", "machine-2"))
+}
@@ -19,6 +19,8 @@ package controllers
 import (
 	"context"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/go-logr/logr"
 	"github.com/pkg/errors"
@@ -26,10 +28,16 @@ import (
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
 	kerrors "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/client-go/tools/record"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/controllers/mdutil"
+	"sigs.k8s.io/cluster-api/controllers/preflight"
+	"sigs.k8s.io/cluster-api/controllers/remote"
 	"sigs.k8s.io/cluster-api/util"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/cluster-api/util/fairness"
 	"sigs.k8s.io/cluster-api/util/patch"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -43,6 +51,12 @@ var (
 	machineDeploymentKind = clusterv1.GroupVersion.WithKind("MachineDeployment")
 )
 
+const (
+	// preflightChecksRequeueAfter is how long to wait before running again the preflight checks
+	// that are blocking a version rollout.
+	preflightChecksRequeueAfter = 1 * time.Minute
+)
+
 // +kubebuilder:rbac:groups=core,resources=events,verbs=get;list;watch;create;patch
 // +kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;watch
 // +kubebuilder:rbac:groups=core,resources=nodes,verbs=get;list;watch;create;update;patch;delete
@@ -54,7 +68,12 @@ type MachineDeploymentReconciler struct {
 	Client client.Client
 	Log    logr.Logger
 
-	recorder record.EventRecorder
+	// ClusterLimiter, if set, rate limits the reconciliations of the MachineDeployments of each cluster.
+	ClusterLimiter *fairness.ClusterLimiter
+
+	recorder           record.EventRecorder
+	scheme             *runtime.Scheme
+	remoteClientGetter remote.ClusterClientGetter
 }
 
 func (r *MachineDeploymentReconciler) SetupWithManager(mgr ctrl.Manager, options controller.Options) error {
@@ -66,13 +85,17 @@ func (r *MachineDeploymentReconciler) SetupWithManager(mgr ctrl.Manager, options
 			&handler.EnqueueRequestsFromMapFunc{ToRequests: handler.ToRequestsFunc(r.MachineSetToDeployments)},
 		).
 		WithOptions(options).
-		Complete(r)
+		Complete(fairness.NewReconciler(mgr.GetClient(), &clusterv1.MachineDeployment{}, "machinedeployment", r.ClusterLimiter, r))
 
 	if err != nil {
 		return errors.Wrap(err, "failed setting up with a controller manager")
 	}
 
 	r.recorder = mgr.GetEventRecorderFor("machinedeployment-controller")
+	r.scheme = mgr.GetScheme()
+	if r.remoteClientGetter == nil {
+		r.remoteClientGetter = remote.NewClusterClient
+	}
 	return nil
 }
 
@@ -110,6 +133,13 @@ func (r *MachineDeploymentReconciler) Reconcile(req ctrl.Request) (_ ctrl.Result
 	}
 
 	defer func() {
+		// Always update the Ready condition with the summary of the MachineDeployment conditions.
+		conditions.SetSummary(deployment,
+			conditions.WithConditions(
+				clusterv1.MachineDeploymentAvailableCondition,
+			),
+		)
+
 		// Always attempt to patch the object and status after each reconciliation.
 		if err := patchHelper.Patch(ctx, deployment); err != nil {
 			reterr = kerrors.NewAggregate([]error{reterr, err})
@@ -131,7 +161,7 @@ func (r *MachineDeploymentReconciler) Reconcile(req ctrl.Request) (_ ctrl.Result
 	return result, nil
 }
 
-func (r *MachineDeploymentReconciler) reconcile(_ context.Context, cluster *clusterv1.Cluster, d *clusterv1.MachineDeployment) (ctrl.Result, error) {
+func (r *MachineDeploymentReconciler) reconcile(ctx context.Context, cluster *clusterv1.Cluster, d *clusterv1.MachineDeployment) (ctrl.Result, error) {
 	logger := r.Log.WithValues("machinedeployment", d.Name, "namespace", d.Namespace)
 	logger.V(4).Info("Reconcile MachineDeployment")
 
@@ -147,6 +177,9 @@ func (r *MachineDeploymentReconciler) reconcile(_ context.Context, cluster *clus
 	}
 
 	d.Labels[clusterv1.ClusterLabelName] = d.Spec.ClusterName
+	for key, value := range util.GetPropagatedLabels(cluster) {
+		d.Labels[key] = value
+	}
 
 	// Make sure selector and template to be in the same cluster.
 	d.Spec.Selector.MatchLabels[clusterv1.ClusterLabelName] = d.Spec.ClusterName
@@ -162,22 +195,111 @@ func (r *MachineDeploymentReconciler) reconcile(_ context.Context, cluster *clus
 		return ctrl.Result{}, nil
 	}
 
+	// Describe the Nodes of the MachineDeployment to the cluster-autoscaler, so it can scale the MachineDeployment
+	// from zero replicas; the annotations are persisted when patching the MachineDeployment.
+	autoscalerAnnotations, err := getAutoscalerAnnotations(ctx, r.Client, d.Namespace, &d.Spec.Template)
+	if err != nil {
+		return ctrl.Result{}, errors.Wrapf(err, "failed to compute the autoscaler annotations of MachineDeployment %q", d.Name)
+	}
+	setAutoscalerAnnotations(d, autoscalerAnnotations)
+
 	msList, err := r.getMachineSetsForDeployment(d)
 	if err != nil {
 		return ctrl.Result{}, err
 	}
 
+	if err := r.reconcileSummary(ctx, d); err != nil {
+		return ctrl.Result{}, err
+	}
+
 	if d.Spec.Paused {
 		return ctrl.Result{}, r.sync(d, msList)
 	}
 
 	if d.Spec.Strategy.Type == clusterv1.RollingUpdateMachineDeploymentStrategyType {
-		return ctrl.Result{}, r.rolloutRolling(d, msList)
+		// Follow the image channel of the infrastructure template, possibly rolling out a new OS image.
+		imageRequeueAfter, err := r.reconcileImageChannel(ctx, d)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+
+		blocked, err := r.reconcilePreflightChecks(ctx, cluster, d, msList)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		if blocked {
+			return ctrl.Result{RequeueAfter: preflightChecksRequeueAfter}, nil
+		}
+		if err := r.rolloutRolling(d, msList); err != nil {
+			return ctrl.Result{}, err
+		}
+		// Requeue for starting the rollout once the rolloutAfter time or the next maintenance window is reached.
+		result := ctrl.Result{RequeueAfter: imageRequeueAfter}
+		if d.Spec.RolloutAfter != nil && time.Now().Before(d.Spec.RolloutAfter.Time) {
+			if until := time.Until(d.Spec.RolloutAfter.Time); result.RequeueAfter == 0 || until < result.RequeueAfter {
+				result.RequeueAfter = until
+			}
+		}
+		return result, nil
+	}
+
+	if d.Spec.Strategy.Type == clusterv1.OnDeleteMachineDeploymentStrategyType {
+		return ctrl.Result{}, r.rolloutOnDelete(d, msList)
 	}
 
 	return ctrl.Result{}, errors.Errorf("unexpected deployment strategy type: %s", d.Spec.Strategy.Type)
 }
 
+// reconcilePreflightChecks runs the preflight checks against the workload cluster before starting the rollout of
+// a new Kubernetes version, unless they are skipped with the SkipUpgradePreflightChecksAnnotation; it returns true
+// if the rollout is blocked by failing checks.
+func (r *MachineDeploymentReconciler) reconcilePreflightChecks(ctx context.Context, cluster *clusterv1.Cluster, d *clusterv1.MachineDeployment, msList []*clusterv1.MachineSet) (bool, error) {
+	logger := r.Log.WithValues("machinedeployment", d.Name, "namespace", d.Namespace)
+
+	d.Status.PreflightCheckFailures = nil
+	if preflight.IsSkipped(d) || !isVersionRolloutStarting(d, msList) {
+		return false, nil
+	}
+
+	clusterClient, err := r.remoteClientGetter(ctx, r.Client, cluster, r.scheme)
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to create client for Cluster %s/%s", cluster.Namespace, cluster.Name)
+	}
+
+	failures, err := preflight.Run(ctx, clusterClient,
+		preflight.NodesReady,
+		preflight.ControlPlanePodsNotCrashLooping,
+		preflight.PodDisruptionBudgetsAllowDisruption,
+	)
+	if err != nil {
+		return false, errors.Wrap(err, "failed to run upgrade preflight checks")
+	}
+	if len(failures) == 0 {
+		return false, nil
+	}
+
+	d.Status.PreflightCheckFailures = failures
+	logger.Info("Rollout blocked by failing preflight checks", "failures", failures)
+	r.recorder.Eventf(d, corev1.EventTypeWarning, "UpgradeBlocked", "Rollout of version %s blocked by failing preflight checks: %s", *d.Spec.Template.Spec.Version, strings.Join(failures, "; "))
+	return true, nil
+}
+
+// isVersionRolloutStarting returns true if a new MachineSet has to be created for rolling out a new Kubernetes
+// version to existing Machines.
+func isVersionRolloutStarting(d *clusterv1.MachineDeployment, msList []*clusterv1.MachineSet) bool {
+	if d.Spec.Template.Spec.Version == nil || mdutil.FindNewMachineSet(d, msList) != nil {
+		return false
+	}
+
+	oldMSs, _ := mdutil.FindOldMachineSets(d, msList)
+	for _, ms := range oldMSs {
+		if ms.Spec.Template.Spec.Version != nil && *ms.Spec.Template.Spec.Version != *d.Spec.Template.Spec.Version {
+			return true
+		}
+	}
+	return false
+}
+
 // getMachineSetsForDeployment returns a list of MachineSets associated with a MachineDeployment.
 func (r *MachineDeploymentReconciler) getMachineSetsForDeployment(d *clusterv1.MachineDeployment) ([]*clusterv1.MachineSet, error) {
 	logger := r.Log.WithValues("machinedeployemnt", d.Name, "namespace", d.Namespace)
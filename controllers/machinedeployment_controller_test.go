@@ -35,6 +35,7 @@ import (
 
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
 	"sigs.k8s.io/cluster-api/controllers/external"
+	fakeremote "sigs.k8s.io/cluster-api/controllers/remote/fake"
 	"sigs.k8s.io/cluster-api/util"
 	"sigs.k8s.io/cluster-api/util/kubeconfig"
 )
@@ -724,3 +725,101 @@ func TestGetMachineSetsForDeployment(t *testing.T) {
 		})
 	}
 }
+
+func TestReconcilePreflightChecks(t *testing.T) {
+	cluster := &clusterv1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-cluster",
+			Namespace: "default",
+		},
+	}
+
+	md := &clusterv1.MachineDeployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "md",
+			Namespace: "default",
+		},
+		Spec: clusterv1.MachineDeploymentSpec{
+			ClusterName: cluster.Name,
+			Template: clusterv1.MachineTemplateSpec{
+				Spec: clusterv1.MachineSpec{
+					ClusterName: cluster.Name,
+					Version:     pointer.StringPtr("v1.17.3"),
+				},
+			},
+		},
+	}
+
+	currentMS := &clusterv1.MachineSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "current",
+			Namespace: "default",
+			UID:       "current",
+		},
+		Spec: clusterv1.MachineSetSpec{
+			Replicas: pointer.Int32Ptr(3),
+			Template: *md.Spec.Template.DeepCopy(),
+		},
+	}
+
+	oldVersionMS := currentMS.DeepCopy()
+	oldVersionMS.Name = "old-version"
+	oldVersionMS.UID = "old-version"
+	oldVersionMS.Spec.Template.Spec.Version = pointer.StringPtr("v1.16.7")
+
+	skippedMD := md.DeepCopy()
+	skippedMD.Annotations = map[string]string{clusterv1.SkipUpgradePreflightChecksAnnotation: ""}
+
+	notReadyNode := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "not-ready"},
+		Status: corev1.NodeStatus{
+			Conditions: []corev1.NodeCondition{{Type: corev1.NodeReady, Status: corev1.ConditionFalse}},
+		},
+	}
+
+	testCases := []struct {
+		name             string
+		md               *clusterv1.MachineDeployment
+		msList           []*clusterv1.MachineSet
+		expectedBlocked  bool
+		expectedFailures []string
+	}{
+		{
+			name:   "should not run the checks if there is no version rollout",
+			md:     md,
+			msList: []*clusterv1.MachineSet{currentMS},
+		},
+		{
+			name:             "should block a version rollout if the checks fail",
+			md:               md,
+			msList:           []*clusterv1.MachineSet{oldVersionMS},
+			expectedBlocked:  true,
+			expectedFailures: []string{`node "not-ready" is not ready`},
+		},
+		{
+			name:   "should not run the checks if they are skipped",
+			md:     skippedMD,
+			msList: []*clusterv1.MachineSet{oldVersionMS},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			r := &MachineDeploymentReconciler{
+				Client:             fake.NewFakeClientWithScheme(scheme.Scheme, notReadyNode),
+				Log:                log.Log,
+				recorder:           record.NewFakeRecorder(32),
+				scheme:             scheme.Scheme,
+				remoteClientGetter: fakeremote.NewClusterClient,
+			}
+
+			d := tc.md.DeepCopy()
+			blocked, err := r.reconcilePreflightChecks(ctx, cluster, d, tc.msList)
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(blocked).To(Equal(tc.expectedBlocked))
+			g.Expect(d.Status.PreflightCheckFailures).To(Equal(tc.expectedFailures))
+		})
+	}
+}
@@ -0,0 +1,221 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/controllers/external"
+)
+
+// maxImageSupersededHops is the maximum number of superseding infrastructure templates followed when looking for
+// the latest OS image of an image channel, which protects from cycles.
+const maxImageSupersededHops = 10
+
+// reconcileImageChannel follows the image channel of the infrastructure template of a MachineDeployment: when the
+// template is superseded by a template using a new OS image, the new template is rolled out if the MachineDeployment
+// has the ImageAutoRolloutAnnotation and it is inside its maintenance window, otherwise the new template is reported
+// with the ImageUpdateAvailableAnnotation. It returns the time to wait for the next maintenance window, if any.
+func (r *MachineDeploymentReconciler) reconcileImageChannel(ctx context.Context, d *clusterv1.MachineDeployment) (time.Duration, error) {
+	logger := r.Log.WithValues("machinedeployment", d.Name, "namespace", d.Namespace)
+
+	ref := &d.Spec.Template.Spec.InfrastructureRef
+	latest, err := r.latestImageTemplate(ctx, d.Namespace, ref)
+	if err != nil {
+		return 0, err
+	}
+	if latest == "" {
+		delete(d.Annotations, clusterv1.ImageUpdateAvailableAnnotation)
+		return 0, nil
+	}
+
+	var wait time.Duration
+	if d.Annotations[clusterv1.ImageAutoRolloutAnnotation] == "true" {
+		if value, ok := d.Annotations[clusterv1.MaintenanceWindowAnnotation]; ok {
+			window, err := parseMaintenanceWindow(value)
+			if err != nil {
+				return 0, errors.Wrapf(err, "invalid %s annotation on MachineDeployment %q", clusterv1.MaintenanceWindowAnnotation, d.Name)
+			}
+			if now := time.Now(); !window.contains(now) {
+				wait = window.next(now).Sub(now)
+			}
+		}
+
+		if wait == 0 {
+			logger.Info("Rolling out a new OS image", "from", ref.Name, "to", latest)
+			r.recorder.Eventf(d, corev1.EventTypeNormal, "ImageRollout", "Rolling out %s %q with a new OS image, superseding %q", ref.Kind, latest, ref.Name)
+			ref.Name = latest
+			delete(d.Annotations, clusterv1.ImageUpdateAvailableAnnotation)
+			return 0, nil
+		}
+	}
+
+	if d.Annotations == nil {
+		d.Annotations = map[string]string{}
+	}
+	if d.Annotations[clusterv1.ImageUpdateAvailableAnnotation] != latest {
+		r.recorder.Eventf(d, corev1.EventTypeNormal, "ImageUpdateAvailable", "A new OS image is available with %s %q", ref.Kind, latest)
+	}
+	d.Annotations[clusterv1.ImageUpdateAvailableAnnotation] = latest
+	return wait, nil
+}
+
+// latestImageTemplate returns the name of the infrastructure template with the latest OS image of the image channel
+// of the referenced template, following the ImageSupersededByAnnotation; it returns an empty string if the template
+// does not reference an image channel or if it is not superseded.
+func (r *MachineDeploymentReconciler) latestImageTemplate(ctx context.Context, namespace string, ref *corev1.ObjectReference) (string, error) {
+	template, err := external.Get(ctx, r.Client, ref, namespace)
+	if err != nil {
+		return "", err
+	}
+	channel, err := external.ImageChannelFrom(template)
+	if err != nil || channel == "" {
+		return "", err
+	}
+
+	latest := ""
+	for i := 0; i < maxImageSupersededHops; i++ {
+		next := template.GetAnnotations()[clusterv1.ImageSupersededByAnnotation]
+		if next == "" {
+			break
+		}
+
+		nextRef := ref.DeepCopy()
+		nextRef.Name = next
+		nextTemplate, err := external.Get(ctx, r.Client, nextRef, namespace)
+		if err != nil {
+			return "", errors.Wrapf(err, "failed to get %s %q superseding %q", ref.Kind, next, template.GetName())
+		}
+		nextChannel, err := external.ImageChannelFrom(nextTemplate)
+		if err != nil {
+			return "", err
+		}
+		if nextChannel != channel {
+			return "", errors.Errorf("%s %q is superseded by %q, which has a different image channel (%q instead of %q)",
+				ref.Kind, template.GetName(), next, nextChannel, channel)
+		}
+		latest, template = next, nextTemplate
+	}
+
+	if latest == ref.Name {
+		return "", nil
+	}
+	return latest, nil
+}
+
+// maintenanceWindow is a daily time window, in UTC, optionally restricted to some days of the week.
+type maintenanceWindow struct {
+	// days of the week the window starts on; if empty, the window starts every day.
+	days map[time.Weekday]bool
+
+	// start and end of the window, as offsets from midnight; if end is before start, the window ends the next day.
+	start, end time.Duration
+}
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// parseMaintenanceWindow parses a maintenance window in the "[days ]HH:MM-HH:MM" format, e.g. "Sat,Sun 02:00-06:00".
+func parseMaintenanceWindow(value string) (*maintenanceWindow, error) {
+	window := &maintenanceWindow{}
+
+	fields := strings.Fields(value)
+	switch len(fields) {
+	case 1:
+	case 2:
+		window.days = map[time.Weekday]bool{}
+		for _, day := range strings.Split(fields[0], ",") {
+			weekday, ok := weekdays[strings.ToLower(strings.TrimSpace(day))]
+			if !ok {
+				return nil, errors.Errorf("invalid day %q, valid days are Mon, Tue, Wed, Thu, Fri, Sat and Sun", day)
+			}
+			window.days[weekday] = true
+		}
+		fields = fields[1:]
+	default:
+		return nil, errors.Errorf("invalid maintenance window %q, the format is \"[days ]HH:MM-HH:MM\"", value)
+	}
+
+	times := strings.Split(fields[0], "-")
+	if len(times) != 2 {
+		return nil, errors.Errorf("invalid maintenance window %q, the format is \"[days ]HH:MM-HH:MM\"", value)
+	}
+	for i, t := range []*time.Duration{&window.start, &window.end} {
+		parsed, err := time.Parse("15:04", times[i])
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid time %q in maintenance window %q", times[i], value)
+		}
+		*t = time.Duration(parsed.Hour())*time.Hour + time.Duration(parsed.Minute())*time.Minute
+	}
+	if window.start == window.end {
+		return nil, errors.Errorf("invalid maintenance window %q, the start and the end of the window are the same", value)
+	}
+	return window, nil
+}
+
+// length returns the duration of the window.
+func (w *maintenanceWindow) length() time.Duration {
+	if w.end < w.start {
+		return w.end + 24*time.Hour - w.start
+	}
+	return w.end - w.start
+}
+
+func (w *maintenanceWindow) startsOn(day time.Weekday) bool {
+	return len(w.days) == 0 || w.days[day]
+}
+
+// contains returns true if the given time is inside the window.
+func (w *maintenanceWindow) contains(t time.Time) bool {
+	t = t.UTC()
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+
+	// Check both the window starting today and the one started yesterday, which can end today.
+	for _, days := range []int{0, -1} {
+		start := midnight.AddDate(0, 0, days).Add(w.start)
+		if w.startsOn(start.Weekday()) && !t.Before(start) && t.Before(start.Add(w.length())) {
+			return true
+		}
+	}
+	return false
+}
+
+// next returns the start of the next window after the given time.
+func (w *maintenanceWindow) next(t time.Time) time.Time {
+	t = t.UTC()
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+
+	for days := 0; days <= 7; days++ {
+		start := midnight.AddDate(0, 0, days).Add(w.start)
+		if start.After(t) && w.startsOn(start.Weekday()) {
+			return start
+		}
+	}
+	return t
+}
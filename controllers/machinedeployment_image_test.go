@@ -0,0 +1,207 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func newImageTemplate(name, channel, supersededBy string) *unstructured.Unstructured {
+	template := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "infrastructure.cluster.x-k8s.io/v1alpha3",
+		"kind":       "InfrastructureMachineTemplate",
+		"metadata": map[string]interface{}{
+			"name":      name,
+			"namespace": "default",
+		},
+		"spec": map[string]interface{}{
+			"imageChannel": channel,
+		},
+	}}
+	if supersededBy != "" {
+		template.SetAnnotations(map[string]string{clusterv1.ImageSupersededByAnnotation: supersededBy})
+	}
+	return template
+}
+
+func TestMachineDeploymentReconciler_reconcileImageChannel(t *testing.T) {
+	newMachineDeployment := func(annotations map[string]string) *clusterv1.MachineDeployment {
+		return &clusterv1.MachineDeployment{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "md",
+				Namespace:   "default",
+				Annotations: annotations,
+			},
+			Spec: clusterv1.MachineDeploymentSpec{
+				Template: clusterv1.MachineTemplateSpec{
+					Spec: clusterv1.MachineSpec{
+						InfrastructureRef: corev1.ObjectReference{
+							APIVersion: "infrastructure.cluster.x-k8s.io/v1alpha3",
+							Kind:       "InfrastructureMachineTemplate",
+							Name:       "template-1",
+						},
+					},
+				},
+			},
+		}
+	}
+	now := time.Now().UTC()
+	closedWindow := now.Add(2*time.Hour).Format("15:04") + "-" + now.Add(3*time.Hour).Format("15:04")
+
+	tests := []struct {
+		name             string
+		templates        []runtime.Object
+		annotations      map[string]string
+		wantTemplate     string
+		wantAvailable    string
+		wantRequeueAfter bool
+		wantErr          bool
+	}{
+		{
+			name:         "template without an image channel",
+			templates:    []runtime.Object{newImageTemplate("template-1", "", "template-2")},
+			wantTemplate: "template-1",
+		},
+		{
+			name:         "template not superseded",
+			templates:    []runtime.Object{newImageTemplate("template-1", "channel", "")},
+			annotations:  map[string]string{clusterv1.ImageUpdateAvailableAnnotation: "template-0"},
+			wantTemplate: "template-1",
+		},
+		{
+			name: "new image reported without auto rollout",
+			templates: []runtime.Object{
+				newImageTemplate("template-1", "channel", "template-2"),
+				newImageTemplate("template-2", "channel", ""),
+			},
+			wantTemplate:  "template-1",
+			wantAvailable: "template-2",
+		},
+		{
+			name: "latest image rolled out with auto rollout",
+			templates: []runtime.Object{
+				newImageTemplate("template-1", "channel", "template-2"),
+				newImageTemplate("template-2", "channel", "template-3"),
+				newImageTemplate("template-3", "channel", ""),
+			},
+			annotations:  map[string]string{clusterv1.ImageAutoRolloutAnnotation: "true"},
+			wantTemplate: "template-3",
+		},
+		{
+			name: "new image reported outside of the maintenance window",
+			templates: []runtime.Object{
+				newImageTemplate("template-1", "channel", "template-2"),
+				newImageTemplate("template-2", "channel", ""),
+			},
+			annotations: map[string]string{
+				clusterv1.ImageAutoRolloutAnnotation:  "true",
+				clusterv1.MaintenanceWindowAnnotation: closedWindow,
+			},
+			wantTemplate:     "template-1",
+			wantAvailable:    "template-2",
+			wantRequeueAfter: true,
+		},
+		{
+			name: "superseding template with a different image channel",
+			templates: []runtime.Object{
+				newImageTemplate("template-1", "channel", "template-2"),
+				newImageTemplate("template-2", "other-channel", ""),
+			},
+			annotations: map[string]string{clusterv1.ImageAutoRolloutAnnotation: "true"},
+			wantErr:     true,
+		},
+		{
+			name:        "missing superseding template",
+			templates:   []runtime.Object{newImageTemplate("template-1", "channel", "template-2")},
+			annotations: map[string]string{clusterv1.ImageAutoRolloutAnnotation: "true"},
+			wantErr:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+			g.Expect(clusterv1.AddToScheme(scheme.Scheme)).To(Succeed())
+
+			r := &MachineDeploymentReconciler{
+				Client:   fake.NewFakeClientWithScheme(scheme.Scheme, tt.templates...),
+				Log:      log.Log,
+				recorder: record.NewFakeRecorder(32),
+			}
+			d := newMachineDeployment(tt.annotations)
+
+			requeueAfter, err := r.reconcileImageChannel(context.Background(), d)
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(d.Spec.Template.Spec.InfrastructureRef.Name).To(Equal(tt.wantTemplate))
+			g.Expect(d.Annotations[clusterv1.ImageUpdateAvailableAnnotation]).To(Equal(tt.wantAvailable))
+			g.Expect(requeueAfter > 0).To(Equal(tt.wantRequeueAfter))
+		})
+	}
+}
+
+func TestParseMaintenanceWindow(t *testing.T) {
+	g := NewWithT(t)
+
+	for _, value := range []string{"", "02:00", "02:00-02:00", "Xyz 02:00-06:00", "Sat 02:00-25:00", "Sat Sun 02:00-06:00"} {
+		_, err := parseMaintenanceWindow(value)
+		g.Expect(err).To(HaveOccurred(), "expected %q to be invalid", value)
+	}
+
+	// 2020-06-06 is a Saturday.
+	saturday := func(hour, minute int) time.Time {
+		return time.Date(2020, 6, 6, hour, minute, 0, 0, time.UTC)
+	}
+
+	window, err := parseMaintenanceWindow("Sat,Sun 02:00-06:00")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(window.contains(saturday(1, 59))).To(BeFalse())
+	g.Expect(window.contains(saturday(2, 0))).To(BeTrue())
+	g.Expect(window.contains(saturday(5, 59))).To(BeTrue())
+	g.Expect(window.contains(saturday(6, 0))).To(BeFalse())
+	g.Expect(window.contains(saturday(2, 0).AddDate(0, 0, 2))).To(BeFalse())
+	g.Expect(window.next(saturday(1, 0))).To(Equal(saturday(2, 0)))
+	g.Expect(window.next(saturday(3, 0))).To(Equal(saturday(2, 0).AddDate(0, 0, 1)))
+	g.Expect(window.next(saturday(2, 0).AddDate(0, 0, 1))).To(Equal(saturday(2, 0).AddDate(0, 0, 7)))
+
+	// A window across midnight started on Friday ends on Saturday.
+	window, err = parseMaintenanceWindow("fri 22:00-02:00")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(window.contains(saturday(1, 0))).To(BeTrue())
+	g.Expect(window.contains(saturday(23, 0))).To(BeFalse())
+	g.Expect(window.next(saturday(1, 0))).To(Equal(saturday(22, 0).AddDate(0, 0, 6)))
+
+	window, err = parseMaintenanceWindow("22:00-02:00")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(window.contains(saturday(23, 0))).To(BeTrue())
+	g.Expect(window.next(saturday(3, 0))).To(Equal(saturday(22, 0)))
+}
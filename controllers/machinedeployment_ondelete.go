@@ -0,0 +1,150 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"sort"
+
+	"github.com/pkg/errors"
+	"k8s.io/utils/integer"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/controllers/mdutil"
+	"sigs.k8s.io/cluster-api/util/patch"
+)
+
+// rolloutOnDelete implements the logic for the OnDelete strategy: a new machine set is created on changes,
+// but the machines of the old machine sets are only replaced once they are deleted.
+func (r *MachineDeploymentReconciler) rolloutOnDelete(d *clusterv1.MachineDeployment, msList []*clusterv1.MachineSet) error {
+	newMS, oldMSs, err := r.getAllMachineSetsAndSyncRevision(d, msList, true)
+	if err != nil {
+		return err
+	}
+
+	// newMS can be nil in case there is already a MachineSet associated with this deployment,
+	// but there are only either changes in annotations or MinReadySeconds.
+	if newMS == nil {
+		return nil
+	}
+
+	allMSs := append(oldMSs, newMS)
+
+	// Scale down the old machine sets by the machines which were deleted, or for scaling down the deployment.
+	if err := r.reconcileOldMachineSetsOnDelete(oldMSs, allMSs, d); err != nil {
+		return err
+	}
+
+	if err := r.syncDeploymentStatus(allMSs, newMS, d); err != nil {
+		return err
+	}
+
+	// Scale up to replace the deleted machines.
+	if err := r.reconcileNewMachineSet(allMSs, newMS, d); err != nil {
+		return err
+	}
+
+	if err := r.syncDeploymentStatus(allMSs, newMS, d); err != nil {
+		return err
+	}
+
+	if mdutil.DeploymentComplete(d, &d.Status) {
+		if err := r.cleanupDeployment(oldMSs, d); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// reconcileOldMachineSetsOnDelete prevents the old machine sets from replacing the deleted machines, and scales
+// them down by the number of machines which were deleted; if the deployment has more replicas than desired,
+// the oldest machine sets are scaled down further.
+func (r *MachineDeploymentReconciler) reconcileOldMachineSetsOnDelete(oldMSs []*clusterv1.MachineSet, allMSs []*clusterv1.MachineSet, deployment *clusterv1.MachineDeployment) error {
+	logger := r.Log.WithValues("machinedeployment", deployment.Name, "namespace", deployment.Namespace)
+
+	if deployment.Spec.Replicas == nil {
+		return errors.Errorf("spec replicas for MachineDeployment %q/%q is nil, this is unexpected",
+			deployment.Namespace, deployment.Name)
+	}
+
+	sort.Sort(mdutil.MachineSetsByCreationTimestamp(oldMSs))
+
+	scaleDownCount := mdutil.GetReplicaCountForMachineSets(allMSs) - *(deployment.Spec.Replicas)
+	for _, oldMS := range oldMSs {
+		if oldMS.Spec.Replicas == nil {
+			return errors.Errorf("spec replicas for machine set %v is nil, this is unexpected", oldMS.Name)
+		}
+		if *(oldMS.Spec.Replicas) == 0 {
+			continue
+		}
+
+		if err := r.disableMachineCreate(oldMS); err != nil {
+			return err
+		}
+
+		// The machine set is not able to create machines anymore, so the machines it is missing were deleted.
+		deletedCount := integer.Int32Max(*(oldMS.Spec.Replicas)-oldMS.Status.Replicas, 0)
+		newReplicasCount := *(oldMS.Spec.Replicas) - deletedCount
+		if scaleDownCount > deletedCount {
+			newReplicasCount -= integer.Int32Min(newReplicasCount, scaleDownCount-deletedCount)
+		}
+		if newReplicasCount == *(oldMS.Spec.Replicas) {
+			continue
+		}
+
+		logger.V(4).Info("Scaling down old MachineSet", "machineset", oldMS.Name, "deleted", deletedCount, "replicas", newReplicasCount)
+		scaleDownCount -= *(oldMS.Spec.Replicas) - newReplicasCount
+		if err := r.scaleMachineSet(oldMS, newReplicasCount, deployment); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// disableMachineCreate sets the DisableMachineCreateAnnotation on a machine set, so it does not replace
+// its deleted machines.
+func (r *MachineDeploymentReconciler) disableMachineCreate(ms *clusterv1.MachineSet) error {
+	if _, ok := ms.Annotations[clusterv1.DisableMachineCreateAnnotation]; ok {
+		return nil
+	}
+
+	patchHelper, err := patch.NewHelper(ms, r.Client)
+	if err != nil {
+		return err
+	}
+	if ms.Annotations == nil {
+		ms.Annotations = map[string]string{}
+	}
+	ms.Annotations[clusterv1.DisableMachineCreateAnnotation] = "true"
+	return patchHelper.Patch(context.Background(), ms)
+}
+
+// enableMachineCreate removes the DisableMachineCreateAnnotation from a machine set, e.g. when a deployment
+// is rolled back to it or when switching the deployment to the RollingUpdate strategy.
+func (r *MachineDeploymentReconciler) enableMachineCreate(ms *clusterv1.MachineSet) error {
+	if _, ok := ms.Annotations[clusterv1.DisableMachineCreateAnnotation]; !ok {
+		return nil
+	}
+
+	patchHelper, err := patch.NewHelper(ms, r.Client)
+	if err != nil {
+		return err
+	}
+	delete(ms.Annotations, clusterv1.DisableMachineCreateAnnotation)
+	return patchHelper.Patch(context.Background(), ms)
+}
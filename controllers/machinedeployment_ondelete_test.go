@@ -0,0 +1,166 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+)
+
+func TestMachineDeploymentOnDelete(t *testing.T) {
+	g := NewWithT(t)
+	g.Expect(clusterv1.AddToScheme(scheme.Scheme)).To(Succeed())
+
+	deployment := func(replicas int32) *clusterv1.MachineDeployment {
+		return &clusterv1.MachineDeployment{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "md"},
+			Spec: clusterv1.MachineDeploymentSpec{
+				Replicas: pointer.Int32Ptr(replicas),
+				Strategy: &clusterv1.MachineDeploymentStrategy{Type: clusterv1.OnDeleteMachineDeploymentStrategyType},
+			},
+		}
+	}
+	machineSet := func(name string, replicas, currentReplicas int32, createdAt int64) *clusterv1.MachineSet {
+		return &clusterv1.MachineSet{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace:         "default",
+				Name:              name,
+				CreationTimestamp: metav1.Unix(createdAt, 0),
+			},
+			Spec:   clusterv1.MachineSetSpec{Replicas: pointer.Int32Ptr(replicas)},
+			Status: clusterv1.MachineSetStatus{Replicas: currentReplicas},
+		}
+	}
+
+	testCases := []struct {
+		name                string
+		md                  *clusterv1.MachineDeployment
+		oldMSs              []*clusterv1.MachineSet
+		newMS               *clusterv1.MachineSet
+		expectedOldReplicas []int32
+		expectedNewReplicas int32
+	}{
+		{
+			name:                "should not replace machines which were not deleted",
+			md:                  deployment(3),
+			oldMSs:              []*clusterv1.MachineSet{machineSet("old", 3, 3, 0)},
+			newMS:               machineSet("new", 0, 0, 10),
+			expectedOldReplicas: []int32{3},
+			expectedNewReplicas: 0,
+		},
+		{
+			name:                "should replace the deleted machines",
+			md:                  deployment(3),
+			oldMSs:              []*clusterv1.MachineSet{machineSet("old", 3, 1, 0)},
+			newMS:               machineSet("new", 0, 0, 10),
+			expectedOldReplicas: []int32{1},
+			expectedNewReplicas: 2,
+		},
+		{
+			name:                "should scale down the oldest machine sets when the deployment is scaled down",
+			md:                  deployment(3),
+			oldMSs:              []*clusterv1.MachineSet{machineSet("older", 2, 2, 0), machineSet("old", 2, 2, 5)},
+			newMS:               machineSet("new", 1, 1, 10),
+			expectedOldReplicas: []int32{0, 2},
+			expectedNewReplicas: 1,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			objs := []runtime.Object{tc.newMS}
+			for _, ms := range tc.oldMSs {
+				objs = append(objs, ms)
+			}
+			c := fake.NewFakeClientWithScheme(scheme.Scheme, objs...)
+			r := &MachineDeploymentReconciler{
+				Client:   c,
+				Log:      log.Log,
+				recorder: record.NewFakeRecorder(32),
+			}
+
+			allMSs := append(append([]*clusterv1.MachineSet{}, tc.oldMSs...), tc.newMS)
+			g.Expect(r.reconcileOldMachineSetsOnDelete(tc.oldMSs, allMSs, tc.md)).To(Succeed())
+			g.Expect(r.reconcileNewMachineSet(allMSs, tc.newMS, tc.md)).To(Succeed())
+
+			for i, ms := range tc.oldMSs {
+				got := &clusterv1.MachineSet{}
+				g.Expect(c.Get(context.Background(), client.ObjectKey{Namespace: ms.Namespace, Name: ms.Name}, got)).To(Succeed())
+				g.Expect(*got.Spec.Replicas).To(Equal(tc.expectedOldReplicas[i]))
+				if tc.expectedOldReplicas[i] > 0 {
+					// The old machine sets do not replace the deleted machines.
+					g.Expect(got.Annotations).To(HaveKey(clusterv1.DisableMachineCreateAnnotation))
+				}
+			}
+
+			got := &clusterv1.MachineSet{}
+			g.Expect(c.Get(context.Background(), client.ObjectKey{Namespace: tc.newMS.Namespace, Name: tc.newMS.Name}, got)).To(Succeed())
+			g.Expect(*got.Spec.Replicas).To(Equal(tc.expectedNewReplicas))
+			g.Expect(got.Annotations).NotTo(HaveKey(clusterv1.DisableMachineCreateAnnotation))
+		})
+	}
+}
+
+func TestMachineDeploymentOnDeleteRollback(t *testing.T) {
+	g := NewWithT(t)
+	g.Expect(clusterv1.AddToScheme(scheme.Scheme)).To(Succeed())
+
+	md := &clusterv1.MachineDeployment{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "md"},
+		Spec: clusterv1.MachineDeploymentSpec{
+			Replicas: pointer.Int32Ptr(2),
+			Strategy: &clusterv1.MachineDeploymentStrategy{Type: clusterv1.OnDeleteMachineDeploymentStrategyType},
+		},
+	}
+	// A machine set which was an old one before the deployment was rolled back to it.
+	newMS := &clusterv1.MachineSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:   "default",
+			Name:        "rolled-back",
+			Annotations: map[string]string{clusterv1.DisableMachineCreateAnnotation: "true"},
+		},
+		Spec:   clusterv1.MachineSetSpec{Replicas: pointer.Int32Ptr(1)},
+		Status: clusterv1.MachineSetStatus{Replicas: 1},
+	}
+	c := fake.NewFakeClientWithScheme(scheme.Scheme, newMS)
+	r := &MachineDeploymentReconciler{
+		Client:   c,
+		Log:      log.Log,
+		recorder: record.NewFakeRecorder(32),
+	}
+
+	g.Expect(r.reconcileNewMachineSet([]*clusterv1.MachineSet{newMS}, newMS, md)).To(Succeed())
+
+	got := &clusterv1.MachineSet{}
+	g.Expect(c.Get(context.Background(), client.ObjectKey{Namespace: newMS.Namespace, Name: newMS.Name}, got)).To(Succeed())
+	g.Expect(got.Annotations).NotTo(HaveKey(clusterv1.DisableMachineCreateAnnotation))
+	g.Expect(*got.Spec.Replicas).To(Equal(int32(2)))
+}
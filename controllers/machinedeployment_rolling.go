@@ -77,6 +77,11 @@ func (r *MachineDeploymentReconciler) reconcileNewMachineSet(allMSs []*clusterv1
 		return errors.Errorf("spec replicas for machine set %v is nil, this is unexpected", newMS.Name)
 	}
 
+	// The new machine set must be able to create machines, even if it was an old one of a deployment using the OnDelete strategy.
+	if err := r.enableMachineCreate(newMS); err != nil {
+		return err
+	}
+
 	if *(newMS.Spec.Replicas) == *(deployment.Spec.Replicas) {
 		// Scaling not required.
 		return nil
@@ -0,0 +1,80 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// reconcileSummary lists the Machines targeted by a MachineDeployment and aggregates them in its status summary.
+func (r *MachineDeploymentReconciler) reconcileSummary(ctx context.Context, d *clusterv1.MachineDeployment) error {
+	selector, err := metav1.LabelSelectorAsSelector(&d.Spec.Selector)
+	if err != nil {
+		return errors.Wrapf(err, "failed to convert the label selector of MachineDeployment %q", d.Name)
+	}
+
+	machines := &clusterv1.MachineList{}
+	// A MachineDeployment with an empty selector matches no Machine.
+	if !selector.Empty() {
+		if err := r.Client.List(ctx, machines, client.InNamespace(d.Namespace), client.MatchingLabelsSelector{Selector: selector}); err != nil {
+			return errors.Wrapf(err, "failed to list Machines for MachineDeployment %q", d.Name)
+		}
+	}
+
+	d.Status.Summary = calculateSummary(d, machines.Items)
+	return nil
+}
+
+// calculateSummary aggregates the given Machines by failure domain and phase, and records the age of the oldest and
+// newest Machines, together with the templates new Machines are created from.
+func calculateSummary(d *clusterv1.MachineDeployment, machines []clusterv1.Machine) *clusterv1.MachineDeploymentSummary {
+	summary := &clusterv1.MachineDeploymentSummary{
+		InfrastructureRef:  d.Spec.Template.Spec.InfrastructureRef.DeepCopy(),
+		BootstrapConfigRef: d.Spec.Template.Spec.Bootstrap.ConfigRef.DeepCopy(),
+	}
+
+	for i := range machines {
+		m := &machines[i]
+
+		if m.Spec.FailureDomain != nil && *m.Spec.FailureDomain != "" {
+			if summary.FailureDomains == nil {
+				summary.FailureDomains = map[string]int32{}
+			}
+			summary.FailureDomains[*m.Spec.FailureDomain]++
+		}
+
+		if summary.Phases == nil {
+			summary.Phases = map[string]int32{}
+		}
+		summary.Phases[string(m.Status.GetTypedPhase())]++
+
+		created := m.CreationTimestamp
+		if summary.OldestMachineCreationTimestamp == nil || created.Before(summary.OldestMachineCreationTimestamp) {
+			summary.OldestMachineCreationTimestamp = created.DeepCopy()
+		}
+		if summary.NewestMachineCreationTimestamp == nil || summary.NewestMachineCreationTimestamp.Before(&created) {
+			summary.NewestMachineCreationTimestamp = created.DeepCopy()
+		}
+	}
+
+	return summary
+}
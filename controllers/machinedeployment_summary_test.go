@@ -0,0 +1,75 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+)
+
+func TestCalculateSummary(t *testing.T) {
+	g := NewWithT(t)
+
+	infraRef := corev1.ObjectReference{Kind: "InfrastructureMachineTemplate", Name: "infra-2"}
+	d := &clusterv1.MachineDeployment{
+		Spec: clusterv1.MachineDeploymentSpec{
+			Template: clusterv1.MachineTemplateSpec{
+				Spec: clusterv1.MachineSpec{
+					InfrastructureRef: infraRef,
+				},
+			},
+		},
+	}
+
+	now := time.Now().Truncate(time.Second)
+	machine := func(name string, age time.Duration, failureDomain *string, phase clusterv1.MachinePhase) clusterv1.Machine {
+		m := clusterv1.Machine{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              name,
+				CreationTimestamp: metav1.NewTime(now.Add(-age)),
+			},
+			Spec: clusterv1.MachineSpec{FailureDomain: failureDomain},
+		}
+		m.Status.SetTypedPhase(phase)
+		return m
+	}
+	machines := []clusterv1.Machine{
+		machine("m1", time.Hour, pointer.StringPtr("fd1"), clusterv1.MachinePhaseRunning),
+		machine("m2", 3*time.Hour, pointer.StringPtr("fd2"), clusterv1.MachinePhaseRunning),
+		machine("m3", 2*time.Hour, pointer.StringPtr("fd1"), clusterv1.MachinePhaseDeleting),
+		machine("m4", time.Minute, nil, clusterv1.MachinePhaseProvisioning),
+	}
+
+	summary := calculateSummary(d, machines)
+	g.Expect(summary.FailureDomains).To(Equal(map[string]int32{"fd1": 2, "fd2": 1}))
+	g.Expect(summary.Phases).To(Equal(map[string]int32{"Running": 2, "Deleting": 1, "Provisioning": 1}))
+	g.Expect(summary.OldestMachineCreationTimestamp.Time).To(Equal(now.Add(-3 * time.Hour)))
+	g.Expect(summary.NewestMachineCreationTimestamp.Time).To(Equal(now.Add(-time.Minute)))
+	g.Expect(summary.InfrastructureRef).To(Equal(&infraRef))
+	g.Expect(summary.BootstrapConfigRef).To(BeNil())
+
+	empty := calculateSummary(d, nil)
+	g.Expect(empty.Phases).To(BeNil())
+	g.Expect(empty.OldestMachineCreationTimestamp).To(BeNil())
+	g.Expect(empty.InfrastructureRef).To(Equal(&infraRef))
+}
@@ -19,18 +19,25 @@ package controllers
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/go-logr/logr"
 	"github.com/pkg/errors"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	kerrors "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/client-go/tools/record"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/controllers/external"
+	"sigs.k8s.io/cluster-api/controllers/metrics"
+	"sigs.k8s.io/cluster-api/controllers/remote"
 	"sigs.k8s.io/cluster-api/util"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/cluster-api/util/fairness"
 	"sigs.k8s.io/cluster-api/util/patch"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -44,13 +51,26 @@ const (
 	mhcClusterNameIndex = "spec.clusterName"
 )
 
+// +kubebuilder:rbac:groups=core,resources=events,verbs=get;list;watch;create;patch
+// +kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;watch
+// +kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=*,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=cluster.x-k8s.io,resources=machines;machines/status,verbs=get;list;watch;delete
+// +kubebuilder:rbac:groups=cluster.x-k8s.io,resources=machinesets;machinesets/status,verbs=get;list;watch;patch
+// +kubebuilder:rbac:groups=cluster.x-k8s.io,resources=machinehealthchecks;machinehealthchecks/status,verbs=get;list;watch;update;patch
+
 // MachineHealthCheckReconciler reconciles a MachineHealthCheck object
 type MachineHealthCheckReconciler struct {
 	Client client.Client
 	Log    logr.Logger
 
-	controller controller.Controller
-	recorder   record.EventRecorder
+	// ClusterLimiter, if set, rate limits the reconciliations of the MachineHealthChecks of each cluster.
+	ClusterLimiter *fairness.ClusterLimiter
+
+	controller         controller.Controller
+	recorder           record.EventRecorder
+	scheme             *runtime.Scheme
+	externalTracker    external.ObjectTracker
+	remoteClientGetter remote.ClusterClientGetter
 }
 
 func (r *MachineHealthCheckReconciler) SetupWithManager(mgr ctrl.Manager, options controller.Options) error {
@@ -60,8 +80,12 @@ func (r *MachineHealthCheckReconciler) SetupWithManager(mgr ctrl.Manager, option
 			&source.Kind{Type: &clusterv1.Cluster{}},
 			&handler.EnqueueRequestsFromMapFunc{ToRequests: handler.ToRequestsFunc(r.clusterToMachineHealthCheck)},
 		).
+		Watches(
+			&source.Kind{Type: &clusterv1.Machine{}},
+			&handler.EnqueueRequestsFromMapFunc{ToRequests: handler.ToRequestsFunc(r.machineToMachineHealthChecks)},
+		).
 		WithOptions(options).
-		Build(r)
+		Build(fairness.NewReconciler(mgr.GetClient(), &clusterv1.MachineHealthCheck{}, "machinehealthcheck", r.ClusterLimiter, r))
 
 	if err != nil {
 		return errors.Wrap(err, "failed setting up with a controller manager")
@@ -77,6 +101,13 @@ func (r *MachineHealthCheckReconciler) SetupWithManager(mgr ctrl.Manager, option
 
 	r.controller = controller
 	r.recorder = mgr.GetEventRecorderFor("machinehealthcheck-controller")
+	r.scheme = mgr.GetScheme()
+	r.externalTracker = external.ObjectTracker{
+		Controller: controller,
+	}
+	if r.remoteClientGetter == nil {
+		r.remoteClientGetter = remote.NewClusterClient
+	}
 	return nil
 }
 
@@ -135,14 +166,13 @@ func (r *MachineHealthCheckReconciler) Reconcile(req ctrl.Request) (_ ctrl.Resul
 	if err != nil {
 		logger.Error(err, "Failed to reconcile MachineHealthCheck")
 		r.recorder.Eventf(m, corev1.EventTypeWarning, "ReconcileError", "%v", err)
-
-		//TODO(JoelSpeed): Determine how/when to requeue requests if errors occur within r.reconcile
+		return ctrl.Result{}, err
 	}
 
 	return result, nil
 }
 
-func (r *MachineHealthCheckReconciler) reconcile(_ context.Context, cluster *clusterv1.Cluster, m *clusterv1.MachineHealthCheck) (ctrl.Result, error) {
+func (r *MachineHealthCheckReconciler) reconcile(ctx context.Context, cluster *clusterv1.Cluster, m *clusterv1.MachineHealthCheck) (ctrl.Result, error) {
 	// Ensure the MachineHealthCheck is owned by the Cluster it belongs to
 	m.OwnerReferences = util.EnsureOwnerRef(m.OwnerReferences, metav1.OwnerReference{
 		APIVersion: clusterv1.GroupVersion.String(),
@@ -151,7 +181,122 @@ func (r *MachineHealthCheckReconciler) reconcile(_ context.Context, cluster *clu
 		UID:        cluster.UID,
 	})
 
-	return ctrl.Result{}, fmt.Errorf("controller not yet implemented")
+	logger := r.Log.WithValues("machinehealthcheck", m.Name, "namespace", m.Namespace, "cluster", cluster.Name)
+
+	// Nodes can't be checked until the workload cluster API server is reachable.
+	if !cluster.Status.ControlPlaneInitialized {
+		logger.V(3).Info("Cluster control plane is not initialized yet, skipping health checks")
+		return ctrl.Result{}, nil
+	}
+
+	clusterClient, err := r.remoteClientGetter(ctx, r.Client, cluster, r.scheme)
+	if err != nil {
+		return ctrl.Result{}, errors.Wrapf(err, "error creating a remote client for cluster %q", cluster.Name)
+	}
+
+	// fetch all targets
+	logger.V(3).Info("Finding targets")
+	targets, err := r.getTargetsFromMHC(ctx, clusterClient, m)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	m.Status.ExpectedMachines = int32(len(targets))
+
+	var timeoutForMachineToHaveNode time.Duration
+	if m.Spec.NodeStartupTimeout != nil {
+		timeoutForMachineToHaveNode = m.Spec.NodeStartupTimeout.Duration
+	}
+
+	// health check all targets and reconcile mhc status
+	healthy, unhealthy, nextCheckTimes := r.healthCheckTargets(targets, logger, timeoutForMachineToHaveNode)
+	m.Status.CurrentHealthy = int32(len(healthy))
+	m.Status.RemediationsInProgress = 0
+	reconcileRemediationTime(m, len(unhealthy))
+
+	errList := []error{}
+
+	// Remediation requests and Remediating conditions of Machines which recovered are no longer needed.
+	for _, t := range healthy {
+		if err := r.deleteRemediationRequest(ctx, logger, t); err != nil {
+			errList = append(errList, err)
+		}
+		if err := r.clearRemediating(ctx, t); err != nil {
+			errList = append(errList, err)
+		}
+	}
+
+	// check MHC current health against UnhealthyRange or MaxUnhealthy
+	if !isAllowedRemediation(m) {
+		logger.V(3).Info(
+			"Short-circuiting remediation",
+			"totalTargets", len(targets),
+			"maxUnhealthy", m.Spec.MaxUnhealthy,
+			"unhealthyRange", m.Spec.UnhealthyRange,
+			"unhealthyTargets", len(unhealthy),
+		)
+		conditions.MarkFalse(
+			m,
+			clusterv1.RemediationAllowedCondition,
+			clusterv1.TooManyUnhealthyReason,
+			clusterv1.ConditionSeverityWarning,
+			"Remediation is not allowed, the number of unhealthy machines is out of bounds (total: %v, unhealthy: %v, %s)",
+			len(targets),
+			len(unhealthy),
+			remediationLimit(m),
+		)
+		r.recorder.Eventf(
+			m,
+			corev1.EventTypeWarning,
+			EventRemediationRestricted,
+			"Remediation restricted due to the number of unhealthy machines being out of bounds (total: %v, unhealthy: %v, %s)",
+			len(targets),
+			len(unhealthy),
+			remediationLimit(m),
+		)
+		if len(errList) > 0 {
+			return ctrl.Result{}, kerrors.NewAggregate(errList)
+		}
+		return ctrl.Result{Requeue: true}, nil
+	}
+	conditions.MarkTrue(m, clusterv1.RemediationAllowedCondition)
+
+	for _, t := range unhealthy {
+		nextCheck, inProgress, err := r.remediate(ctx, logger, t)
+		if err != nil {
+			errList = append(errList, errors.Wrapf(err, "failed to remediate target %q", t.string()))
+			continue
+		}
+		if inProgress {
+			m.Status.RemediationsInProgress++
+		}
+		if nextCheck > 0 {
+			nextCheckTimes = append(nextCheckTimes, nextCheck)
+		}
+	}
+
+	if len(errList) > 0 {
+		return ctrl.Result{}, kerrors.NewAggregate(errList)
+	}
+
+	if minNextCheck := minDuration(nextCheckTimes); minNextCheck > 0 {
+		logger.V(3).Info("Some targets might go unhealthy. Ensuring a requeue happens", "requeueIn", minNextCheck.Truncate(time.Second).String())
+		return ctrl.Result{RequeueAfter: minNextCheck}, nil
+	}
+
+	return ctrl.Result{}, nil
+}
+
+// reconcileRemediationTime records the time unhealthy machines are found and, once all the machines are healthy
+// again, it observes the time to recovery.
+func reconcileRemediationTime(m *clusterv1.MachineHealthCheck, unhealthy int) {
+	switch {
+	case unhealthy > 0 && m.Status.RemediationStartTime == nil:
+		now := metav1.Now()
+		m.Status.RemediationStartTime = &now
+	case m.Status.RemediationStartTime != nil && m.Status.CurrentHealthy == m.Status.ExpectedMachines:
+		metrics.MachineHealthCheckRemediationDuration.WithLabelValues(m.Name, m.Namespace, m.Spec.ClusterName).Observe(time.Since(m.Status.RemediationStartTime.Time).Seconds())
+		m.Status.RemediationStartTime = nil
+	}
 }
 
 func (r *MachineHealthCheckReconciler) indexMachineHealthCheckByClusterName(object runtime.Object) []string {
@@ -192,3 +337,67 @@ func (r *MachineHealthCheckReconciler) clusterToMachineHealthCheck(o handler.Map
 	}
 	return requests
 }
+
+// machineToMachineHealthChecks maps events from Machine objects to
+// MachineHealthCheck objects that select the Machine
+func (r *MachineHealthCheckReconciler) machineToMachineHealthChecks(o handler.MapObject) []reconcile.Request {
+	m, ok := o.Object.(*clusterv1.Machine)
+	if !ok {
+		r.Log.Error(errors.New("incorrect type"), "expected a Machine", "type", fmt.Sprintf("%T", o))
+		return nil
+	}
+
+	mhcList := &clusterv1.MachineHealthCheckList{}
+	if err := r.Client.List(
+		context.TODO(),
+		mhcList,
+		client.InNamespace(m.Namespace),
+		client.MatchingFields{mhcClusterNameIndex: m.Spec.ClusterName},
+	); err != nil {
+		r.Log.Error(err, "Unable to list MachineHealthChecks", "machine", m.Name, "namespace", m.Namespace)
+		return nil
+	}
+
+	var requests []reconcile.Request
+	for k := range mhcList.Items {
+		mhc := &mhcList.Items[k]
+		if hasMatchingLabels(mhc.Spec.Selector, m.Labels) {
+			key := types.NamespacedName{Namespace: mhc.Namespace, Name: mhc.Name}
+			requests = append(requests, reconcile.Request{NamespacedName: key})
+		}
+	}
+	return requests
+}
+
+// remediationRequestToMachineHealthChecks maps events from external remediation
+// requests to the MachineHealthCheck objects selecting the Machine they remediate
+func (r *MachineHealthCheckReconciler) remediationRequestToMachineHealthChecks(o handler.MapObject) []reconcile.Request {
+	for _, ref := range o.Meta.GetOwnerReferences() {
+		if ref.Kind != "Machine" || ref.APIVersion != clusterv1.GroupVersion.String() {
+			continue
+		}
+
+		m := &clusterv1.Machine{}
+		key := client.ObjectKey{Namespace: o.Meta.GetNamespace(), Name: ref.Name}
+		if err := r.Client.Get(context.TODO(), key, m); err != nil {
+			if !apierrors.IsNotFound(err) {
+				r.Log.Error(err, "Unable to get Machine", "machine", ref.Name, "namespace", o.Meta.GetNamespace())
+			}
+			return nil
+		}
+		return r.machineToMachineHealthChecks(handler.MapObject{Meta: m, Object: m})
+	}
+	return nil
+}
+
+// hasMatchingLabels returns true if the selector is not empty and matches the given labels
+func hasMatchingLabels(matchSelector metav1.LabelSelector, matchLabels map[string]string) bool {
+	if isEmptySelector(&matchSelector) {
+		return false
+	}
+	selector, err := metav1.LabelSelectorAsSelector(&matchSelector)
+	if err != nil {
+		return false
+	}
+	return selector.Matches(labels.Set(matchLabels))
+}
@@ -394,6 +394,33 @@ func TestIndexMachineHealthCheckByClusterName(t *testing.T) {
 	}
 }
 
+func TestReconcileRemediationTime(t *testing.T) {
+	g := NewWithT(t)
+
+	m := newTestMachineHealthCheck("test-mhc", "default", "test-cluster", nil)
+	m.Status.ExpectedMachines = 3
+	m.Status.CurrentHealthy = 3
+
+	// All the machines are healthy.
+	reconcileRemediationTime(m, 0)
+	g.Expect(m.Status.RemediationStartTime).To(BeNil())
+
+	// Unhealthy machines are found.
+	m.Status.CurrentHealthy = 2
+	reconcileRemediationTime(m, 1)
+	g.Expect(m.Status.RemediationStartTime).NotTo(BeNil())
+	start := m.Status.RemediationStartTime
+
+	// The unhealthy machine is replaced by a machine not healthy yet.
+	reconcileRemediationTime(m, 0)
+	g.Expect(m.Status.RemediationStartTime).To(Equal(start))
+
+	// All the machines are healthy again.
+	m.Status.CurrentHealthy = 3
+	reconcileRemediationTime(m, 0)
+	g.Expect(m.Status.RemediationStartTime).To(BeNil())
+}
+
 func newTestMachineHealthCheck(name, namespace, cluster string, labels map[string]string) *clusterv1.MachineHealthCheck {
 	return &clusterv1.MachineHealthCheck{
 		ObjectMeta: metav1.ObjectMeta{
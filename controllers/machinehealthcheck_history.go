@@ -0,0 +1,204 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/cluster-api/util/patch"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// EventRemediationRetriesExhausted is emitted when an unhealthy machine is
+// not remediated because the remediation retry limit has been reached
+const EventRemediationRetriesExhausted string = "RemediationRetriesExhausted"
+
+// remediationHistory is the remediation history of a target. It is recorded on the MachineSet owning the
+// Machine, which outlives the Machines it replaces, or on the Machine itself.
+type remediationHistory struct {
+	obj     runtime.Object
+	records *[]clusterv1.RemediationRecord
+}
+
+// getRemediationHistory returns the remediation history of a target, or nil if the MachineSet owning the
+// Machine does not exist.
+func (r *MachineHealthCheckReconciler) getRemediationHistory(ctx context.Context, t healthCheckTarget) (*remediationHistory, error) {
+	if !t.hasMachineSetOwner() {
+		return &remediationHistory{obj: t.Machine, records: &t.Machine.Status.RemediationHistory}, nil
+	}
+
+	ms := &clusterv1.MachineSet{}
+	key := client.ObjectKey{Namespace: t.Machine.Namespace, Name: metav1.GetControllerOf(t.Machine).Name}
+	if err := r.Client.Get(ctx, key, ms); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, errors.Wrapf(err, "failed to get MachineSet %q", key.Name)
+	}
+	return &remediationHistory{obj: ms, records: &ms.Status.RemediationHistory}, nil
+}
+
+// recordRemediation appends a remediation of the target to its remediation history, dropping the oldest
+// records beyond MaxRemediationHistory.
+func (r *MachineHealthCheckReconciler) recordRemediation(ctx context.Context, t healthCheckTarget, remediationType clusterv1.RemediationType, outcome clusterv1.RemediationOutcome) error {
+	history, err := r.getRemediationHistory(ctx, t)
+	if err != nil || history == nil {
+		return err
+	}
+
+	return r.patchRemediationHistory(ctx, history, func(records []clusterv1.RemediationRecord) []clusterv1.RemediationRecord {
+		records = append(records, clusterv1.RemediationRecord{
+			MachineName: t.Machine.Name,
+			Timestamp:   metav1.Now(),
+			Cause:       t.unhealthyReason,
+			Type:        remediationType,
+			Outcome:     outcome,
+		})
+		if len(records) > clusterv1.MaxRemediationHistory {
+			records = records[len(records)-clusterv1.MaxRemediationHistory:]
+		}
+		return records
+	})
+}
+
+// setRemediationOutcome sets the outcome of the started remediations of the target of the given types.
+func (r *MachineHealthCheckReconciler) setRemediationOutcome(ctx context.Context, t healthCheckTarget, outcome clusterv1.RemediationOutcome, remediationTypes ...clusterv1.RemediationType) error {
+	history, err := r.getRemediationHistory(ctx, t)
+	if err != nil || history == nil {
+		return err
+	}
+
+	started := func(record clusterv1.RemediationRecord) bool {
+		if record.MachineName != t.Machine.Name || record.Outcome != clusterv1.RemediationStarted {
+			return false
+		}
+		for _, remediationType := range remediationTypes {
+			if record.Type == remediationType {
+				return true
+			}
+		}
+		return false
+	}
+
+	found := false
+	for _, record := range *history.records {
+		if started(record) {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return nil
+	}
+
+	return r.patchRemediationHistory(ctx, history, func(records []clusterv1.RemediationRecord) []clusterv1.RemediationRecord {
+		for i := range records {
+			if started(records[i]) {
+				records[i].Outcome = outcome
+			}
+		}
+		return records
+	})
+}
+
+func (r *MachineHealthCheckReconciler) patchRemediationHistory(ctx context.Context, history *remediationHistory, update func([]clusterv1.RemediationRecord) []clusterv1.RemediationRecord) error {
+	patchHelper, err := patch.NewHelper(history.obj, r.Client)
+	if err != nil {
+		return err
+	}
+	*history.records = update(*history.records)
+	if err := patchHelper.Patch(ctx, history.obj); err != nil {
+		return errors.Wrap(err, "failed to patch the remediation history")
+	}
+	return nil
+}
+
+// remediationRetriesExhausted returns the number of remediations counted against the MaxRemediationRetries
+// of the MachineHealthCheck if it has been reached, along with the duration after which remediations are
+// allowed again, zero meaning never. Remediations already started for the target are not limited.
+func remediationRetriesExhausted(mhc *clusterv1.MachineHealthCheck, history *remediationHistory, machineName string, now time.Time) (int, time.Duration) {
+	if mhc.Spec.MaxRemediationRetries == nil || history == nil {
+		return 0, 0
+	}
+
+	var counted []clusterv1.RemediationRecord
+	for _, record := range *history.records {
+		if record.MachineName == machineName && record.Outcome == clusterv1.RemediationStarted {
+			return 0, 0
+		}
+		if mhc.Spec.RemediationRetryPeriod == nil || now.Sub(record.Timestamp.Time) < mhc.Spec.RemediationRetryPeriod.Duration {
+			counted = append(counted, record)
+		}
+	}
+	if len(counted) < int(*mhc.Spec.MaxRemediationRetries) {
+		return 0, 0
+	}
+
+	// Records are kept oldest first, so remediations are allowed again once enough of the oldest
+	// records have left the retry period.
+	if mhc.Spec.RemediationRetryPeriod == nil {
+		return len(counted), 0
+	}
+	expiring := counted[len(counted)-int(*mhc.Spec.MaxRemediationRetries)]
+	return len(counted), expiring.Timestamp.Add(mhc.Spec.RemediationRetryPeriod.Duration).Sub(now)
+}
+
+// markRemediationRetriesExhausted sets the Remediating condition of the target Machine to False, flagging
+// that the Machine is no longer remediated and requires human intervention.
+func (r *MachineHealthCheckReconciler) markRemediationRetriesExhausted(ctx context.Context, logger logr.Logger, t healthCheckTarget, remediations int) error {
+	if conditions.IsFalse(t.Machine, clusterv1.RemediatingCondition) && conditions.GetReason(t.Machine, clusterv1.RemediatingCondition) == clusterv1.RemediationRetriesExhaustedReason {
+		return nil
+	}
+
+	patchHelper, err := patch.NewHelper(t.Machine, r.Client)
+	if err != nil {
+		return err
+	}
+	conditions.MarkFalse(
+		t.Machine,
+		clusterv1.RemediatingCondition,
+		clusterv1.RemediationRetriesExhaustedReason,
+		clusterv1.ConditionSeverityError,
+		"Remediation stopped after %d remediations, human intervention is required: %s",
+		remediations,
+		t.unhealthyReason,
+	)
+	if err := patchHelper.Patch(ctx, t.Machine); err != nil {
+		return errors.Wrapf(err, "failed to set the %s condition on Machine %q", clusterv1.RemediatingCondition, t.Machine.Name)
+	}
+
+	logger.Info("Remediation retries exhausted, skipping remediation", "target", t.string(), "remediations", remediations)
+	r.recorder.Eventf(
+		t.MHC,
+		corev1.EventTypeWarning,
+		EventRemediationRetriesExhausted,
+		"Remediation of unhealthy Machine %q stopped after %d remediations, human intervention is required: %s",
+		t.Machine.Name,
+		remediations,
+		t.unhealthyReason,
+	)
+	return nil
+}
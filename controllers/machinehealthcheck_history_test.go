@@ -0,0 +1,217 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/utils/pointer"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestMachineHealthCheckRemediationHistory(t *testing.T) {
+	g := NewWithT(t)
+	g.Expect(clusterv1.AddToScheme(scheme.Scheme)).To(Succeed())
+
+	mhc := newTestMachineHealthCheck("test-mhc", "default", "test-cluster", map[string]string{"foo": "bar"})
+	mhc.Spec.MaxRemediationRetries = pointer.Int32Ptr(2)
+	ms := &clusterv1.MachineSet{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "machineset1"}}
+	var machines []*clusterv1.Machine
+	for _, name := range []string{"machine1", "machine2", "machine3"} {
+		machine := newTestMachine(name, "default", "test-cluster", "node1", map[string]string{"foo": "bar"})
+		machine.OwnerReferences = []metav1.OwnerReference{newTestMachineSetOwnerRef()}
+		machines = append(machines, machine)
+	}
+
+	r := newTestMachineHealthCheckReconciler(mhc, ms, machines[0], machines[1], machines[2])
+
+	// The first remediations are recorded on the MachineSet.
+	for _, machine := range machines[:2] {
+		target := healthCheckTarget{MHC: mhc, Machine: machine, nodeMissing: true, unhealthyReason: "Node has been deleted"}
+		_, _, err := r.remediate(context.Background(), r.Log, target)
+		g.Expect(err).NotTo(HaveOccurred())
+
+		err = r.Client.Get(context.Background(), client.ObjectKey{Namespace: machine.Namespace, Name: machine.Name}, &clusterv1.Machine{})
+		g.Expect(apierrors.IsNotFound(err)).To(BeTrue())
+	}
+
+	got := &clusterv1.MachineSet{}
+	g.Expect(r.Client.Get(context.Background(), client.ObjectKey{Namespace: ms.Namespace, Name: ms.Name}, got)).To(Succeed())
+	g.Expect(got.Status.RemediationHistory).To(HaveLen(2))
+	for i, record := range got.Status.RemediationHistory {
+		g.Expect(record.MachineName).To(Equal(machines[i].Name))
+		g.Expect(record.Cause).To(Equal("Node has been deleted"))
+		g.Expect(record.Type).To(Equal(clusterv1.DeleteRemediationType))
+		g.Expect(record.Outcome).To(Equal(clusterv1.RemediationSucceeded))
+	}
+
+	// Once the retry limit is reached, the Machine is no longer remediated and flagged for human intervention.
+	target := healthCheckTarget{MHC: mhc, Machine: machines[2], nodeMissing: true, unhealthyReason: "Node has been deleted"}
+	nextCheck, inProgress, err := r.remediate(context.Background(), r.Log, target)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(nextCheck).To(BeZero())
+	g.Expect(inProgress).To(BeFalse())
+
+	machine := &clusterv1.Machine{}
+	g.Expect(r.Client.Get(context.Background(), client.ObjectKey{Namespace: machines[2].Namespace, Name: machines[2].Name}, machine)).To(Succeed())
+	g.Expect(conditions.IsFalse(machine, clusterv1.RemediatingCondition)).To(BeTrue())
+	g.Expect(conditions.GetReason(machine, clusterv1.RemediatingCondition)).To(Equal(clusterv1.RemediationRetriesExhaustedReason))
+}
+
+func TestMachineHealthCheckRemediationHistoryOutcome(t *testing.T) {
+	g := NewWithT(t)
+	g.Expect(clusterv1.AddToScheme(scheme.Scheme)).To(Succeed())
+
+	mhc := newTestMachineHealthCheck("test-mhc", "default", "test-cluster", map[string]string{"foo": "bar"})
+	machine := newTestMachine("machine1", "default", "test-cluster", "node1", map[string]string{"foo": "bar"})
+	machine.OwnerReferences = []metav1.OwnerReference{{
+		APIVersion: "controlplane.cluster.x-k8s.io/v1alpha3",
+		Kind:       "KubeadmControlPlane",
+		Name:       "test-kcp",
+		UID:        "test-kcp-uid",
+		Controller: pointer.BoolPtr(true),
+	}}
+
+	r := newTestMachineHealthCheckReconciler(mhc, machine)
+	target := healthCheckTarget{MHC: mhc, Machine: machine, nodeMissing: true, unhealthyReason: "Node has been deleted"}
+
+	// The Machine is not owned by a MachineSet, so the remediation is recorded on the Machine.
+	_, _, err := r.remediate(context.Background(), r.Log, target)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	got := &clusterv1.Machine{}
+	g.Expect(r.Client.Get(context.Background(), client.ObjectKey{Namespace: machine.Namespace, Name: machine.Name}, got)).To(Succeed())
+	g.Expect(got.Status.RemediationHistory).To(HaveLen(1))
+	g.Expect(got.Status.RemediationHistory[0].Type).To(Equal(clusterv1.OwnerRemediationType))
+	g.Expect(got.Status.RemediationHistory[0].Outcome).To(Equal(clusterv1.RemediationStarted))
+
+	// Once the Machine is healthy again, the remediation is recorded as succeeded.
+	target.Machine = got
+	g.Expect(r.clearRemediating(context.Background(), target)).To(Succeed())
+
+	got = &clusterv1.Machine{}
+	g.Expect(r.Client.Get(context.Background(), client.ObjectKey{Namespace: machine.Namespace, Name: machine.Name}, got)).To(Succeed())
+	g.Expect(got.Status.RemediationHistory).To(HaveLen(1))
+	g.Expect(got.Status.RemediationHistory[0].Outcome).To(Equal(clusterv1.RemediationSucceeded))
+}
+
+func TestRemediationRetriesExhausted(t *testing.T) {
+	now := time.Now()
+	record := func(machineName string, age time.Duration, outcome clusterv1.RemediationOutcome) clusterv1.RemediationRecord {
+		return clusterv1.RemediationRecord{
+			MachineName: machineName,
+			Timestamp:   metav1.NewTime(now.Add(-age)),
+			Type:        clusterv1.DeleteRemediationType,
+			Outcome:     outcome,
+		}
+	}
+
+	tests := []struct {
+		name             string
+		maxRetries       *int32
+		period           *metav1.Duration
+		records          []clusterv1.RemediationRecord
+		expectExhausted  int
+		expectRetryAfter time.Duration
+	}{
+		{
+			name: "without a retry limit",
+			records: []clusterv1.RemediationRecord{
+				record("machine1", time.Minute, clusterv1.RemediationSucceeded),
+				record("machine2", time.Minute, clusterv1.RemediationSucceeded),
+			},
+			expectExhausted: 0,
+		},
+		{
+			name:       "below the retry limit",
+			maxRetries: pointer.Int32Ptr(3),
+			records: []clusterv1.RemediationRecord{
+				record("machine1", time.Minute, clusterv1.RemediationSucceeded),
+				record("machine2", time.Minute, clusterv1.RemediationSucceeded),
+			},
+			expectExhausted: 0,
+		},
+		{
+			name:       "at the retry limit without a retry period",
+			maxRetries: pointer.Int32Ptr(2),
+			records: []clusterv1.RemediationRecord{
+				record("machine1", 48*time.Hour, clusterv1.RemediationSucceeded),
+				record("machine2", time.Minute, clusterv1.RemediationSucceeded),
+			},
+			expectExhausted: 2,
+		},
+		{
+			name:       "at the retry limit with old remediations outside of the retry period",
+			maxRetries: pointer.Int32Ptr(2),
+			period:     &metav1.Duration{Duration: time.Hour},
+			records: []clusterv1.RemediationRecord{
+				record("machine1", 2*time.Hour, clusterv1.RemediationSucceeded),
+				record("machine2", time.Minute, clusterv1.RemediationSucceeded),
+			},
+			expectExhausted: 0,
+		},
+		{
+			name:       "at the retry limit within the retry period",
+			maxRetries: pointer.Int32Ptr(2),
+			period:     &metav1.Duration{Duration: time.Hour},
+			records: []clusterv1.RemediationRecord{
+				record("machine1", 40*time.Minute, clusterv1.RemediationSucceeded),
+				record("machine2", time.Minute, clusterv1.RemediationSucceeded),
+			},
+			expectExhausted:  2,
+			expectRetryAfter: 20 * time.Minute,
+		},
+		{
+			name:       "with a remediation of the target in progress",
+			maxRetries: pointer.Int32Ptr(2),
+			records: []clusterv1.RemediationRecord{
+				record("machine1", time.Minute, clusterv1.RemediationSucceeded),
+				record("target", time.Minute, clusterv1.RemediationStarted),
+			},
+			expectExhausted: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			mhc := &clusterv1.MachineHealthCheck{
+				Spec: clusterv1.MachineHealthCheckSpec{
+					MaxRemediationRetries:  tt.maxRetries,
+					RemediationRetryPeriod: tt.period,
+				},
+			}
+			records := tt.records
+			remediations, retryAfter := remediationRetriesExhausted(mhc, &remediationHistory{records: &records}, "target", now)
+			g.Expect(remediations).To(Equal(tt.expectExhausted))
+			if tt.expectRetryAfter > 0 {
+				g.Expect(retryAfter).To(BeNumerically("~", tt.expectRetryAfter, time.Second))
+			} else {
+				g.Expect(retryAfter).To(BeZero())
+			}
+		})
+	}
+}
@@ -0,0 +1,360 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/controllers/external"
+	"sigs.k8s.io/cluster-api/controllers/metrics"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/cluster-api/util/patch"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+)
+
+const (
+	// EventRemediationRestricted is emitted in case when machine remediation
+	// is restricted by remediation circuit shorting logic
+	EventRemediationRestricted string = "RemediationRestricted"
+
+	// EventMachineDeleted is emitted when an unhealthy machine is deleted
+	// so that its MachineSet can replace it
+	EventMachineDeleted string = "MachineDeleted"
+
+	// EventRemediationRequestCreated is emitted when an external remediation
+	// request is created for an unhealthy machine
+	EventRemediationRequestCreated string = "RemediationRequestCreated"
+
+	// EventRemediationRequestFailed is emitted when an external remediation
+	// request reports a failure or does not complete in time
+	EventRemediationRequestFailed string = "RemediationRequestFailed"
+
+	// EventRemediationRequestDeleted is emitted when an external remediation
+	// request is removed after its machine has become healthy again
+	EventRemediationRequestDeleted string = "RemediationRequestDeleted"
+
+	// EventRemediationSkipped is emitted when an unhealthy machine is not
+	// remediated because it has the skip remediation annotation
+	EventRemediationSkipped string = "RemediationSkipped"
+
+	// EventOwnerRemediationRequested is emitted when the remediation of an
+	// unhealthy machine is handed off to the controller owning it
+	EventOwnerRemediationRequested string = "OwnerRemediationRequested"
+)
+
+// remediate hands an unhealthy target off to the remediation strategy configured
+// on its MachineHealthCheck. It returns the duration after which the target should
+// be checked again, if any, and whether an external remediation is in progress.
+func (r *MachineHealthCheckReconciler) remediate(ctx context.Context, logger logr.Logger, t healthCheckTarget) (time.Duration, bool, error) {
+	if _, ok := t.Machine.Annotations[clusterv1.SkipRemediationAnnotation]; ok {
+		logger.Info("Machine has the skip remediation annotation, skipping remediation", "target", t.string())
+		r.recorder.Eventf(
+			t.Machine,
+			corev1.EventTypeNormal,
+			EventRemediationSkipped,
+			"Remediation of unhealthy Machine %v skipped because of the %s annotation",
+			t.string(),
+			clusterv1.SkipRemediationAnnotation,
+		)
+		return 0, false, nil
+	}
+
+	history, err := r.getRemediationHistory(ctx, t)
+	if err != nil {
+		return 0, false, err
+	}
+	if remediations, retryAfter := remediationRetriesExhausted(t.MHC, history, t.Machine.Name, time.Now()); remediations > 0 {
+		return retryAfter, false, r.markRemediationRetriesExhausted(ctx, logger, t, remediations)
+	}
+
+	if err := r.markRemediating(ctx, t); err != nil {
+		return 0, false, err
+	}
+
+	if t.MHC.Spec.RemediationTemplate == nil {
+		return 0, false, r.deleteMachine(ctx, logger, t, t.unhealthyReason)
+	}
+	return r.remediateExternally(ctx, logger, t)
+}
+
+// remediateExternally makes sure a remediation request exists for the target and falls
+// back to deleting the Machine once the request has failed or timed out.
+func (r *MachineHealthCheckReconciler) remediateExternally(ctx context.Context, logger logr.Logger, t healthCheckTarget) (time.Duration, bool, error) {
+	ref := remediationRequestRef(t)
+
+	// Watch remediation requests so that the MachineHealthCheck is notified of their progress.
+	if err := r.externalTracker.Watch(logger, newUnstructuredFromRef(ref), &handler.EnqueueRequestsFromMapFunc{
+		ToRequests: handler.ToRequestsFunc(r.remediationRequestToMachineHealthChecks),
+	}); err != nil {
+		return 0, false, err
+	}
+
+	obj, err := external.Get(ctx, r.Client, ref, t.Machine.Namespace)
+	if apierrors.IsNotFound(errors.Cause(err)) {
+		if _, err := external.CloneTemplate(ctx, &external.CloneTemplateInput{
+			Client:      r.Client,
+			TemplateRef: t.MHC.Spec.RemediationTemplate,
+			Namespace:   t.Machine.Namespace,
+			ClusterName: t.MHC.Spec.ClusterName,
+			Name:        t.Machine.Name,
+			OwnerRef: &metav1.OwnerReference{
+				APIVersion: clusterv1.GroupVersion.String(),
+				Kind:       "Machine",
+				Name:       t.Machine.Name,
+				UID:        t.Machine.UID,
+			},
+		}); err != nil {
+			return 0, false, errors.Wrapf(err, "failed to create %s for Machine %q", ref.Kind, t.Machine.Name)
+		}
+		if err := r.recordRemediation(ctx, t, clusterv1.ExternalRemediationType, clusterv1.RemediationStarted); err != nil {
+			return 0, false, err
+		}
+
+		logger.Info("Created remediation request for unhealthy target", "target", t.string(), "kind", ref.Kind)
+		r.recorder.Eventf(
+			t.MHC,
+			corev1.EventTypeNormal,
+			EventRemediationRequestCreated,
+			"Created %s %q to remediate unhealthy Machine: %s",
+			ref.Kind,
+			ref.Name,
+			t.unhealthyReason,
+		)
+		if timeout := remediationTimeout(t.MHC); timeout > 0 {
+			return timeout, true, nil
+		}
+		return 0, true, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+
+	failureReason, failureMessage, err := external.FailuresFrom(obj)
+	if err != nil {
+		return 0, false, err
+	}
+	if failureReason != "" || failureMessage != "" {
+		reason := fmt.Sprintf("%s %q failed: %s", ref.Kind, ref.Name, strings.TrimSpace(failureReason+" "+failureMessage))
+		r.recorder.Event(t.MHC, corev1.EventTypeWarning, EventRemediationRequestFailed, reason)
+		if err := r.setRemediationOutcome(ctx, t, clusterv1.RemediationFailed, clusterv1.ExternalRemediationType); err != nil {
+			return 0, false, err
+		}
+		return 0, false, r.deleteMachine(ctx, logger, t, reason)
+	}
+
+	timeout := remediationTimeout(t.MHC)
+	if timeout <= 0 {
+		return 0, true, nil
+	}
+	deadline := obj.GetCreationTimestamp().Add(timeout)
+	if now := time.Now(); now.Before(deadline) {
+		return deadline.Sub(now), true, nil
+	}
+
+	reason := fmt.Sprintf("%s %q did not remediate the Machine within %v", ref.Kind, ref.Name, timeout)
+	r.recorder.Event(t.MHC, corev1.EventTypeWarning, EventRemediationRequestFailed, reason)
+	if err := r.setRemediationOutcome(ctx, t, clusterv1.RemediationFailed, clusterv1.ExternalRemediationType); err != nil {
+		return 0, false, err
+	}
+	return 0, false, r.deleteMachine(ctx, logger, t, reason)
+}
+
+// deleteRemediationRequest removes the remediation request created for a target,
+// if any, once the target has been found healthy again.
+func (r *MachineHealthCheckReconciler) deleteRemediationRequest(ctx context.Context, logger logr.Logger, t healthCheckTarget) error {
+	if t.MHC.Spec.RemediationTemplate == nil {
+		return nil
+	}
+
+	ref := remediationRequestRef(t)
+	obj, err := external.Get(ctx, r.Client, ref, t.Machine.Namespace)
+	if err != nil {
+		if apierrors.IsNotFound(errors.Cause(err)) {
+			return nil
+		}
+		return err
+	}
+	if !obj.GetDeletionTimestamp().IsZero() {
+		return nil
+	}
+
+	if err := r.Client.Delete(ctx, obj); err != nil && !apierrors.IsNotFound(err) {
+		return errors.Wrapf(err, "failed to delete %s %q", ref.Kind, ref.Name)
+	}
+
+	logger.Info("Deleted remediation request for healthy target", "target", t.string(), "kind", ref.Kind)
+	r.recorder.Eventf(
+		t.MHC,
+		corev1.EventTypeNormal,
+		EventRemediationRequestDeleted,
+		"Deleted %s %q, Machine is healthy again",
+		ref.Kind,
+		ref.Name,
+	)
+	return nil
+}
+
+// deleteMachine deletes the target Machine so that it gets replaced by its MachineSet.
+// The remediation of Machines owned by another controller, e.g. control plane Machines,
+// is handed off to their owner; Machines without a controller are left untouched.
+func (r *MachineHealthCheckReconciler) deleteMachine(ctx context.Context, logger logr.Logger, t healthCheckTarget, reason string) error {
+	if !t.hasMachineSetOwner() {
+		if metav1.GetControllerOf(t.Machine) == nil {
+			logger.Info("Target is not owned by a controller, skipping deletion", "target", t.string())
+			return nil
+		}
+		return r.markOwnerRemediation(ctx, logger, t, reason)
+	}
+
+	if err := r.Client.Delete(ctx, t.Machine); err != nil && !apierrors.IsNotFound(err) {
+		return errors.Wrapf(err, "failed to delete Machine %q", t.Machine.Name)
+	}
+	if err := r.recordRemediation(ctx, t, clusterv1.DeleteRemediationType, clusterv1.RemediationSucceeded); err != nil {
+		return err
+	}
+
+	logger.Info("Deleted unhealthy target", "target", t.string(), "reason", reason)
+	r.recorder.Eventf(
+		t.MHC,
+		corev1.EventTypeNormal,
+		EventMachineDeleted,
+		"Deleted unhealthy Machine %q: %s",
+		t.Machine.Name,
+		reason,
+	)
+	return nil
+}
+
+// markOwnerRemediation sets the OwnerRemediated condition to False on the target Machine,
+// so that the controller owning it remediates the Machine.
+func (r *MachineHealthCheckReconciler) markOwnerRemediation(ctx context.Context, logger logr.Logger, t healthCheckTarget, reason string) error {
+	if conditions.IsFalse(t.Machine, clusterv1.MachineOwnerRemediatedCondition) {
+		return nil
+	}
+
+	patchHelper, err := patch.NewHelper(t.Machine, r.Client)
+	if err != nil {
+		return err
+	}
+	conditions.MarkFalse(t.Machine, clusterv1.MachineOwnerRemediatedCondition, clusterv1.WaitingForRemediationReason, clusterv1.ConditionSeverityWarning, "%s", reason)
+	if err := patchHelper.Patch(ctx, t.Machine); err != nil {
+		return errors.Wrapf(err, "failed to set the %s condition on Machine %q", clusterv1.MachineOwnerRemediatedCondition, t.Machine.Name)
+	}
+	if err := r.recordRemediation(ctx, t, clusterv1.OwnerRemediationType, clusterv1.RemediationStarted); err != nil {
+		return err
+	}
+
+	owner := metav1.GetControllerOf(t.Machine)
+	logger.Info("Handed off remediation of unhealthy target to its owner", "target", t.string(), "owner", owner.Kind+"/"+owner.Name, "reason", reason)
+	r.recorder.Eventf(
+		t.MHC,
+		corev1.EventTypeNormal,
+		EventOwnerRemediationRequested,
+		"Requested %s %q to remediate unhealthy Machine %q: %s",
+		owner.Kind,
+		owner.Name,
+		t.Machine.Name,
+		reason,
+	)
+	return nil
+}
+
+// markRemediating sets the Remediating condition on the target Machine, documenting
+// why the Machine is being remediated.
+func (r *MachineHealthCheckReconciler) markRemediating(ctx context.Context, t healthCheckTarget) error {
+	if conditions.IsTrue(t.Machine, clusterv1.RemediatingCondition) && conditions.GetMessage(t.Machine, clusterv1.RemediatingCondition) == t.unhealthyReason {
+		return nil
+	}
+
+	wasRemediating := conditions.IsTrue(t.Machine, clusterv1.RemediatingCondition)
+
+	patchHelper, err := patch.NewHelper(t.Machine, r.Client)
+	if err != nil {
+		return err
+	}
+	conditions.Set(t.Machine, &clusterv1.Condition{
+		Type:    clusterv1.RemediatingCondition,
+		Status:  corev1.ConditionTrue,
+		Reason:  clusterv1.UnhealthyReason,
+		Message: t.unhealthyReason,
+	})
+	if err := patchHelper.Patch(ctx, t.Machine); err != nil {
+		return errors.Wrapf(err, "failed to set the %s condition on Machine %q", clusterv1.RemediatingCondition, t.Machine.Name)
+	}
+
+	if !wasRemediating {
+		metrics.MachineHealthCheckRemediations.WithLabelValues(t.MHC.Name, t.MHC.Namespace, t.MHC.Spec.ClusterName).Inc()
+	}
+	return nil
+}
+
+// clearRemediating removes the Remediating and OwnerRemediated conditions from the target
+// Machine, if any, once the target has been found healthy again, recording its started
+// remediations as succeeded.
+func (r *MachineHealthCheckReconciler) clearRemediating(ctx context.Context, t healthCheckTarget) error {
+	if !conditions.Has(t.Machine, clusterv1.RemediatingCondition) && !conditions.Has(t.Machine, clusterv1.MachineOwnerRemediatedCondition) {
+		return nil
+	}
+
+	patchHelper, err := patch.NewHelper(t.Machine, r.Client)
+	if err != nil {
+		return err
+	}
+	conditions.Delete(t.Machine, clusterv1.RemediatingCondition)
+	conditions.Delete(t.Machine, clusterv1.MachineOwnerRemediatedCondition)
+	if err := patchHelper.Patch(ctx, t.Machine); err != nil {
+		return errors.Wrapf(err, "failed to remove the %s condition from Machine %q", clusterv1.RemediatingCondition, t.Machine.Name)
+	}
+	return r.setRemediationOutcome(ctx, t, clusterv1.RemediationSucceeded, clusterv1.ExternalRemediationType, clusterv1.OwnerRemediationType)
+}
+
+// remediationRequestRef returns a reference to the remediation request of a target.
+// Remediation requests are named after the Machine they remediate and their kind is
+// the one of the template, with the "Template" suffix stripped.
+func remediationRequestRef(t healthCheckTarget) *corev1.ObjectReference {
+	template := t.MHC.Spec.RemediationTemplate
+	return &corev1.ObjectReference{
+		APIVersion: template.APIVersion,
+		Kind:       strings.TrimSuffix(template.Kind, external.TemplateSuffix),
+		Name:       t.Machine.Name,
+		Namespace:  t.Machine.Namespace,
+	}
+}
+
+func remediationTimeout(mhc *clusterv1.MachineHealthCheck) time.Duration {
+	if mhc.Spec.RemediationTimeout == nil {
+		return 0
+	}
+	return mhc.Spec.RemediationTimeout.Duration
+}
+
+func newUnstructuredFromRef(ref *corev1.ObjectReference) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion(ref.APIVersion)
+	obj.SetKind(ref.Kind)
+	return obj
+}
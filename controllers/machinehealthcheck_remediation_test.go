@@ -0,0 +1,397 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/pointer"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func TestMachineHealthCheckRemediateByDeletion(t *testing.T) {
+	testCases := []struct {
+		name          string
+		owned         bool
+		expectDeleted bool
+	}{
+		{
+			name:          "when the Machine is owned by a MachineSet",
+			owned:         true,
+			expectDeleted: true,
+		},
+		{
+			name:          "when the Machine is not owned by a MachineSet",
+			owned:         false,
+			expectDeleted: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			g.Expect(clusterv1.AddToScheme(scheme.Scheme)).To(Succeed())
+
+			mhc := newTestMachineHealthCheck("test-mhc", "default", "test-cluster", map[string]string{"foo": "bar"})
+			machine := newTestMachine("machine1", "default", "test-cluster", "node1", map[string]string{"foo": "bar"})
+			if tc.owned {
+				machine.OwnerReferences = []metav1.OwnerReference{newTestMachineSetOwnerRef()}
+			}
+
+			r := newTestMachineHealthCheckReconciler(mhc, machine)
+			target := healthCheckTarget{MHC: mhc, Machine: machine, nodeMissing: true, unhealthyReason: "Node has been deleted"}
+
+			nextCheck, inProgress, err := r.remediate(context.Background(), r.Log, target)
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(nextCheck).To(BeZero())
+			g.Expect(inProgress).To(BeFalse())
+
+			err = r.Client.Get(context.Background(), client.ObjectKey{Namespace: machine.Namespace, Name: machine.Name}, &clusterv1.Machine{})
+			if tc.expectDeleted {
+				g.Expect(apierrors.IsNotFound(err)).To(BeTrue())
+			} else {
+				g.Expect(err).NotTo(HaveOccurred())
+			}
+		})
+	}
+}
+
+func TestMachineHealthCheckRemediatingCondition(t *testing.T) {
+	g := NewWithT(t)
+	g.Expect(clusterv1.AddToScheme(scheme.Scheme)).To(Succeed())
+
+	mhc := newTestMachineHealthCheck("test-mhc", "default", "test-cluster", map[string]string{"foo": "bar"})
+	machine := newTestMachine("machine1", "default", "test-cluster", "node1", map[string]string{"foo": "bar"})
+
+	r := newTestMachineHealthCheckReconciler(mhc, machine)
+	target := healthCheckTarget{MHC: mhc, Machine: machine, nodeMissing: true, unhealthyReason: "Node has been deleted"}
+
+	// The Machine is not owned by a MachineSet, so it is not deleted but it is marked as remediating.
+	_, _, err := r.remediate(context.Background(), r.Log, target)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	got := &clusterv1.Machine{}
+	g.Expect(r.Client.Get(context.Background(), client.ObjectKey{Namespace: machine.Namespace, Name: machine.Name}, got)).To(Succeed())
+	g.Expect(conditions.IsTrue(got, clusterv1.RemediatingCondition)).To(BeTrue())
+	g.Expect(conditions.GetReason(got, clusterv1.RemediatingCondition)).To(Equal(clusterv1.UnhealthyReason))
+	g.Expect(conditions.GetMessage(got, clusterv1.RemediatingCondition)).To(Equal("Node has been deleted"))
+
+	// Once the Machine is healthy again, the condition is removed.
+	target.Machine = got
+	g.Expect(r.clearRemediating(context.Background(), target)).To(Succeed())
+
+	got = &clusterv1.Machine{}
+	g.Expect(r.Client.Get(context.Background(), client.ObjectKey{Namespace: machine.Namespace, Name: machine.Name}, got)).To(Succeed())
+	g.Expect(conditions.Has(got, clusterv1.RemediatingCondition)).To(BeFalse())
+}
+
+func TestMachineHealthCheckRemediateByOwner(t *testing.T) {
+	g := NewWithT(t)
+	g.Expect(clusterv1.AddToScheme(scheme.Scheme)).To(Succeed())
+
+	mhc := newTestMachineHealthCheck("test-mhc", "default", "test-cluster", map[string]string{"foo": "bar"})
+	machine := newTestMachine("machine1", "default", "test-cluster", "node1", map[string]string{"foo": "bar"})
+	machine.OwnerReferences = []metav1.OwnerReference{{
+		APIVersion: "controlplane.cluster.x-k8s.io/v1alpha3",
+		Kind:       "KubeadmControlPlane",
+		Name:       "test-kcp",
+		UID:        "test-kcp-uid",
+		Controller: pointer.BoolPtr(true),
+	}}
+
+	r := newTestMachineHealthCheckReconciler(mhc, machine)
+	target := healthCheckTarget{MHC: mhc, Machine: machine, nodeMissing: true, unhealthyReason: "Node has been deleted"}
+
+	// The Machine is owned by a controller other than a MachineSet, so its remediation is handed off to the owner.
+	_, _, err := r.remediate(context.Background(), r.Log, target)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	got := &clusterv1.Machine{}
+	g.Expect(r.Client.Get(context.Background(), client.ObjectKey{Namespace: machine.Namespace, Name: machine.Name}, got)).To(Succeed())
+	g.Expect(conditions.IsFalse(got, clusterv1.MachineOwnerRemediatedCondition)).To(BeTrue())
+	g.Expect(conditions.GetReason(got, clusterv1.MachineOwnerRemediatedCondition)).To(Equal(clusterv1.WaitingForRemediationReason))
+	g.Expect(conditions.GetMessage(got, clusterv1.MachineOwnerRemediatedCondition)).To(Equal("Node has been deleted"))
+
+	// Once the Machine is healthy again, the condition is removed.
+	target.Machine = got
+	g.Expect(r.clearRemediating(context.Background(), target)).To(Succeed())
+
+	got = &clusterv1.Machine{}
+	g.Expect(r.Client.Get(context.Background(), client.ObjectKey{Namespace: machine.Namespace, Name: machine.Name}, got)).To(Succeed())
+	g.Expect(conditions.Has(got, clusterv1.MachineOwnerRemediatedCondition)).To(BeFalse())
+}
+
+func TestMachineHealthCheckRemediateSkipped(t *testing.T) {
+	testCases := []struct {
+		name                string
+		remediationTemplate *corev1.ObjectReference
+	}{
+		{
+			name: "when remediating by deletion",
+		},
+		{
+			name:                "when remediating externally",
+			remediationTemplate: newTestRemediationTemplateRef(),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			g.Expect(clusterv1.AddToScheme(scheme.Scheme)).To(Succeed())
+
+			mhc := newTestMachineHealthCheck("test-mhc", "default", "test-cluster", map[string]string{"foo": "bar"})
+			mhc.Spec.RemediationTemplate = tc.remediationTemplate
+			machine := newTestMachine("machine1", "default", "test-cluster", "node1", map[string]string{"foo": "bar"})
+			machine.OwnerReferences = []metav1.OwnerReference{newTestMachineSetOwnerRef()}
+			machine.Annotations = map[string]string{clusterv1.SkipRemediationAnnotation: ""}
+
+			r := newTestMachineHealthCheckReconciler(mhc, machine, newTestRemediationTemplate())
+			target := healthCheckTarget{MHC: mhc, Machine: machine, nodeMissing: true, unhealthyReason: "Node has been deleted"}
+
+			nextCheck, inProgress, err := r.remediate(context.Background(), r.Log, target)
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(nextCheck).To(BeZero())
+			g.Expect(inProgress).To(BeFalse())
+
+			// The Machine is neither deleted nor handed off to an external remediation
+			g.Expect(r.Client.Get(context.Background(), client.ObjectKey{Namespace: machine.Namespace, Name: machine.Name}, &clusterv1.Machine{})).To(Succeed())
+			request := &unstructured.Unstructured{}
+			request.SetAPIVersion("infrastructure.cluster.x-k8s.io/v1alpha3")
+			request.SetKind("GenericRemediation")
+			err = r.Client.Get(context.Background(), client.ObjectKey{Namespace: machine.Namespace, Name: machine.Name}, request)
+			g.Expect(apierrors.IsNotFound(err)).To(BeTrue())
+		})
+	}
+}
+
+func TestMachineHealthCheckRemediateExternally(t *testing.T) {
+	t.Run("creates a remediation request for an unhealthy Machine", func(t *testing.T) {
+		g := NewWithT(t)
+		g.Expect(clusterv1.AddToScheme(scheme.Scheme)).To(Succeed())
+
+		mhc := newTestMachineHealthCheck("test-mhc", "default", "test-cluster", map[string]string{"foo": "bar"})
+		mhc.Spec.RemediationTemplate = newTestRemediationTemplateRef()
+		machine := newTestMachine("machine1", "default", "test-cluster", "node1", map[string]string{"foo": "bar"})
+		machine.UID = "machine1-uid"
+		machine.OwnerReferences = []metav1.OwnerReference{newTestMachineSetOwnerRef()}
+
+		r := newTestMachineHealthCheckReconciler(mhc, machine, newTestRemediationTemplate())
+		target := healthCheckTarget{MHC: mhc, Machine: machine, nodeMissing: true, unhealthyReason: "Node has been deleted"}
+
+		_, inProgress, err := r.remediate(context.Background(), r.Log, target)
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(inProgress).To(BeTrue())
+
+		request := getTestRemediationRequest(g, r.Client, machine)
+		g.Expect(request.GetLabels()).To(HaveKeyWithValue(clusterv1.ClusterLabelName, "test-cluster"))
+		g.Expect(request.GetOwnerReferences()).To(ConsistOf(metav1.OwnerReference{
+			APIVersion: clusterv1.GroupVersion.String(),
+			Kind:       "Machine",
+			Name:       machine.Name,
+			UID:        machine.UID,
+		}))
+		action, _, err := unstructured.NestedString(request.Object, "spec", "action")
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(action).To(Equal("reboot"))
+
+		// The Machine is left to the external remediation
+		g.Expect(r.Client.Get(context.Background(), client.ObjectKey{Namespace: machine.Namespace, Name: machine.Name}, &clusterv1.Machine{})).To(Succeed())
+
+		// Checking again does not create another request nor delete the Machine
+		_, inProgress, err = r.remediate(context.Background(), r.Log, target)
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(inProgress).To(BeTrue())
+		g.Expect(r.Client.Get(context.Background(), client.ObjectKey{Namespace: machine.Namespace, Name: machine.Name}, &clusterv1.Machine{})).To(Succeed())
+	})
+
+	t.Run("deletes the Machine when the remediation request failed", func(t *testing.T) {
+		g := NewWithT(t)
+		g.Expect(clusterv1.AddToScheme(scheme.Scheme)).To(Succeed())
+
+		mhc := newTestMachineHealthCheck("test-mhc", "default", "test-cluster", map[string]string{"foo": "bar"})
+		mhc.Spec.RemediationTemplate = newTestRemediationTemplateRef()
+		machine := newTestMachine("machine1", "default", "test-cluster", "node1", map[string]string{"foo": "bar"})
+		machine.OwnerReferences = []metav1.OwnerReference{newTestMachineSetOwnerRef()}
+
+		request := newTestRemediationRequest(machine, metav1.Now())
+		g.Expect(unstructured.SetNestedField(request.Object, "RebootFailed", "status", "failureReason")).To(Succeed())
+
+		r := newTestMachineHealthCheckReconciler(mhc, machine, newTestRemediationTemplate(), request)
+		target := healthCheckTarget{MHC: mhc, Machine: machine, nodeMissing: true, unhealthyReason: "Node has been deleted"}
+
+		_, inProgress, err := r.remediate(context.Background(), r.Log, target)
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(inProgress).To(BeFalse())
+
+		err = r.Client.Get(context.Background(), client.ObjectKey{Namespace: machine.Namespace, Name: machine.Name}, &clusterv1.Machine{})
+		g.Expect(apierrors.IsNotFound(err)).To(BeTrue())
+	})
+
+	t.Run("waits for the remediation request until the remediation timeout", func(t *testing.T) {
+		g := NewWithT(t)
+		g.Expect(clusterv1.AddToScheme(scheme.Scheme)).To(Succeed())
+
+		mhc := newTestMachineHealthCheck("test-mhc", "default", "test-cluster", map[string]string{"foo": "bar"})
+		mhc.Spec.RemediationTemplate = newTestRemediationTemplateRef()
+		mhc.Spec.RemediationTimeout = &metav1.Duration{Duration: 30 * time.Minute}
+		machine := newTestMachine("machine1", "default", "test-cluster", "node1", map[string]string{"foo": "bar"})
+		machine.OwnerReferences = []metav1.OwnerReference{newTestMachineSetOwnerRef()}
+
+		request := newTestRemediationRequest(machine, metav1.NewTime(time.Now().Add(-20*time.Minute)))
+
+		r := newTestMachineHealthCheckReconciler(mhc, machine, newTestRemediationTemplate(), request)
+		target := healthCheckTarget{MHC: mhc, Machine: machine, nodeMissing: true, unhealthyReason: "Node has been deleted"}
+
+		nextCheck, inProgress, err := r.remediate(context.Background(), r.Log, target)
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(inProgress).To(BeTrue())
+		g.Expect(nextCheck).To(BeNumerically("<=", 10*time.Minute))
+		g.Expect(nextCheck).To(BeNumerically(">", 0))
+		g.Expect(r.Client.Get(context.Background(), client.ObjectKey{Namespace: machine.Namespace, Name: machine.Name}, &clusterv1.Machine{})).To(Succeed())
+	})
+
+	t.Run("deletes the Machine when the remediation timeout has expired", func(t *testing.T) {
+		g := NewWithT(t)
+		g.Expect(clusterv1.AddToScheme(scheme.Scheme)).To(Succeed())
+
+		mhc := newTestMachineHealthCheck("test-mhc", "default", "test-cluster", map[string]string{"foo": "bar"})
+		mhc.Spec.RemediationTemplate = newTestRemediationTemplateRef()
+		mhc.Spec.RemediationTimeout = &metav1.Duration{Duration: 30 * time.Minute}
+		machine := newTestMachine("machine1", "default", "test-cluster", "node1", map[string]string{"foo": "bar"})
+		machine.OwnerReferences = []metav1.OwnerReference{newTestMachineSetOwnerRef()}
+
+		request := newTestRemediationRequest(machine, metav1.NewTime(time.Now().Add(-40*time.Minute)))
+
+		r := newTestMachineHealthCheckReconciler(mhc, machine, newTestRemediationTemplate(), request)
+		target := healthCheckTarget{MHC: mhc, Machine: machine, nodeMissing: true, unhealthyReason: "Node has been deleted"}
+
+		_, inProgress, err := r.remediate(context.Background(), r.Log, target)
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(inProgress).To(BeFalse())
+
+		err = r.Client.Get(context.Background(), client.ObjectKey{Namespace: machine.Namespace, Name: machine.Name}, &clusterv1.Machine{})
+		g.Expect(apierrors.IsNotFound(err)).To(BeTrue())
+	})
+}
+
+func TestMachineHealthCheckDeleteRemediationRequest(t *testing.T) {
+	g := NewWithT(t)
+	g.Expect(clusterv1.AddToScheme(scheme.Scheme)).To(Succeed())
+
+	mhc := newTestMachineHealthCheck("test-mhc", "default", "test-cluster", map[string]string{"foo": "bar"})
+	mhc.Spec.RemediationTemplate = newTestRemediationTemplateRef()
+	machine := newTestMachine("machine1", "default", "test-cluster", "node1", map[string]string{"foo": "bar"})
+	request := newTestRemediationRequest(machine, metav1.Now())
+
+	r := newTestMachineHealthCheckReconciler(mhc, machine, newTestRemediationTemplate(), request)
+	target := healthCheckTarget{MHC: mhc, Machine: machine, Node: newTestNode("node1")}
+
+	g.Expect(r.deleteRemediationRequest(context.Background(), r.Log, target)).To(Succeed())
+
+	obj := newUnstructuredFromRef(remediationRequestRef(target))
+	err := r.Client.Get(context.Background(), client.ObjectKey{Namespace: machine.Namespace, Name: machine.Name}, obj)
+	g.Expect(apierrors.IsNotFound(err)).To(BeTrue())
+
+	// Deleting a remediation request which doesn't exist is a no-op
+	g.Expect(r.deleteRemediationRequest(context.Background(), r.Log, target)).To(Succeed())
+}
+
+func newTestMachineHealthCheckReconciler(objs ...runtime.Object) *MachineHealthCheckReconciler {
+	return &MachineHealthCheckReconciler{
+		Client:   fake.NewFakeClientWithScheme(scheme.Scheme, objs...),
+		Log:      log.Log,
+		recorder: record.NewFakeRecorder(32),
+	}
+}
+
+func newTestMachineSetOwnerRef() metav1.OwnerReference {
+	return metav1.OwnerReference{
+		APIVersion: clusterv1.GroupVersion.String(),
+		Kind:       "MachineSet",
+		Name:       "machineset1",
+		UID:        "machineset1-uid",
+		Controller: pointer.BoolPtr(true),
+	}
+}
+
+func newTestRemediationTemplateRef() *corev1.ObjectReference {
+	return &corev1.ObjectReference{
+		APIVersion: "infrastructure.cluster.x-k8s.io/v1alpha3",
+		Kind:       "GenericRemediationTemplate",
+		Name:       "remediation-template",
+		Namespace:  "default",
+	}
+}
+
+func newTestRemediationTemplate() *unstructured.Unstructured {
+	return &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "infrastructure.cluster.x-k8s.io/v1alpha3",
+			"kind":       "GenericRemediationTemplate",
+			"metadata": map[string]interface{}{
+				"name":      "remediation-template",
+				"namespace": "default",
+			},
+			"spec": map[string]interface{}{
+				"template": map[string]interface{}{
+					"spec": map[string]interface{}{
+						"action": "reboot",
+					},
+				},
+			},
+		},
+	}
+}
+
+func newTestRemediationRequest(machine *clusterv1.Machine, created metav1.Time) *unstructured.Unstructured {
+	request := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "infrastructure.cluster.x-k8s.io/v1alpha3",
+			"kind":       "GenericRemediation",
+			"spec": map[string]interface{}{
+				"action": "reboot",
+			},
+		},
+	}
+	request.SetName(machine.Name)
+	request.SetNamespace(machine.Namespace)
+	request.SetCreationTimestamp(created)
+	return request
+}
+
+func getTestRemediationRequest(g *WithT, c client.Client, machine *clusterv1.Machine) *unstructured.Unstructured {
+	request := &unstructured.Unstructured{}
+	request.SetAPIVersion("infrastructure.cluster.x-k8s.io/v1alpha3")
+	request.SetKind("GenericRemediation")
+	g.Expect(c.Get(context.Background(), client.ObjectKey{Namespace: machine.Namespace, Name: machine.Name}, request)).To(Succeed())
+	return request
+}
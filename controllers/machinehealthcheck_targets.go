@@ -0,0 +1,327 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// EventDetectedUnhealthy is emitted in case a node associated with a
+	// machine was detected unhealthy
+	EventDetectedUnhealthy string = "DetectedUnhealthy"
+)
+
+// healthCheckTarget contains the information required to perform a health check
+// on the node to determine if any remediation is required.
+type healthCheckTarget struct {
+	Machine     *clusterv1.Machine
+	Node        *corev1.Node
+	MHC         *clusterv1.MachineHealthCheck
+	nodeMissing bool
+
+	// unhealthyReason is set once the target has been found to need remediation.
+	unhealthyReason string
+}
+
+func (t *healthCheckTarget) string() string {
+	return fmt.Sprintf("%s/%s/%s/%s",
+		t.MHC.GetNamespace(),
+		t.MHC.GetName(),
+		t.Machine.GetName(),
+		t.nodeName(),
+	)
+}
+
+// Get the node name if the target has a node
+func (t *healthCheckTarget) nodeName() string {
+	if t.Node != nil {
+		return t.Node.GetName()
+	}
+	return ""
+}
+
+// hasMachineSetOwner returns true if the Machine is controlled by a MachineSet,
+// which will replace the Machine once it has been deleted.
+func (t *healthCheckTarget) hasMachineSetOwner() bool {
+	ref := metav1.GetControllerOf(t.Machine)
+	return ref != nil && ref.Kind == "MachineSet"
+}
+
+// needsRemediation determines whether the Machine or its Node is unhealthy.
+// If it is, the reason is returned. If it isn't, the returned duration is the
+// time after which the target should be checked again, zero meaning there is
+// nothing to wait for.
+func (t *healthCheckTarget) needsRemediation(timeoutForMachineToHaveNode time.Duration) (bool, string, time.Duration) {
+	var nextCheckTimes []time.Duration
+	now := time.Now()
+
+	// A terminal failure has been reported on the machine
+	if t.Machine.Status.FailureReason != nil {
+		return true, fmt.Sprintf("FailureReason: %v", *t.Machine.Status.FailureReason), 0
+	}
+	if t.Machine.Status.FailureMessage != nil {
+		return true, fmt.Sprintf("FailureMessage: %v", *t.Machine.Status.FailureMessage), 0
+	}
+
+	// the node does not exist
+	if t.nodeMissing {
+		return true, "Node has been deleted", 0
+	}
+
+	// the node has not been set yet
+	if t.Node == nil {
+		if timeoutForMachineToHaveNode <= 0 {
+			return false, "", 0
+		}
+		nodeStartupDeadline := t.Machine.CreationTimestamp.Add(timeoutForMachineToHaveNode)
+		if nodeStartupDeadline.Before(now) {
+			return true, fmt.Sprintf("Node failed to start up within %v", timeoutForMachineToHaveNode), 0
+		}
+		return false, "", nodeStartupDeadline.Sub(now)
+	}
+
+	// check conditions
+	for _, c := range t.MHC.Spec.UnhealthyConditions {
+		nodeCondition := getNodeCondition(t.Node, c.Type)
+
+		// Skip when current node condition is different from the one reported
+		// in the MachineHealthCheck.
+		if nodeCondition == nil || nodeCondition.Status != c.Status {
+			continue
+		}
+
+		// If the condition has been in the unhealthy state for longer than the
+		// timeout, return true with no requeue time.
+		timeoutDeadline := nodeCondition.LastTransitionTime.Add(c.Timeout.Duration)
+		if timeoutDeadline.Before(now) {
+			return true, fmt.Sprintf("Condition %s on Node is reporting status %s for more than %v", c.Type, c.Status, c.Timeout.Duration), 0
+		}
+
+		nextCheckTimes = append(nextCheckTimes, timeoutDeadline.Sub(now))
+	}
+	return false, "", minDuration(nextCheckTimes)
+}
+
+// getTargetsFromMHC uses the MachineHealthCheck's selector to fetch machines
+// and their nodes targeted by the health check, ready for health checking.
+func (r *MachineHealthCheckReconciler) getTargetsFromMHC(ctx context.Context, clusterClient client.Client, mhc *clusterv1.MachineHealthCheck) ([]healthCheckTarget, error) {
+	machines, err := r.getMachinesFromMHC(ctx, mhc)
+	if err != nil {
+		return nil, errors.Wrap(err, "error getting machines from MachineHealthCheck")
+	}
+	if len(machines) == 0 {
+		return nil, nil
+	}
+
+	targets := []healthCheckTarget{}
+	for k := range machines {
+		// Machines being deleted are already on their way out, skip them.
+		if !machines[k].DeletionTimestamp.IsZero() {
+			continue
+		}
+
+		target := healthCheckTarget{
+			MHC:     mhc,
+			Machine: &machines[k],
+		}
+		node, err := r.getNodeFromMachine(ctx, clusterClient, target.Machine)
+		if err != nil {
+			if !apierrors.IsNotFound(errors.Cause(err)) {
+				return nil, errors.Wrap(err, "error getting node")
+			}
+
+			// A node has been seen for this machine, but it no longer exists
+			target.nodeMissing = true
+		}
+		target.Node = node
+		targets = append(targets, target)
+	}
+	return targets, nil
+}
+
+// getMachinesFromMHC fetches Machines matched by the MachineHealthCheck's
+// label selector, restricted to the Cluster the MachineHealthCheck belongs to.
+func (r *MachineHealthCheckReconciler) getMachinesFromMHC(ctx context.Context, mhc *clusterv1.MachineHealthCheck) ([]clusterv1.Machine, error) {
+	if isEmptySelector(&mhc.Spec.Selector) {
+		return nil, nil
+	}
+
+	selector := mhc.Spec.Selector.DeepCopy()
+	if selector.MatchLabels == nil {
+		selector.MatchLabels = map[string]string{}
+	}
+	selector.MatchLabels[clusterv1.ClusterLabelName] = mhc.Spec.ClusterName
+
+	labelSelector, err := metav1.LabelSelectorAsSelector(selector)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to build selector")
+	}
+
+	machineList := &clusterv1.MachineList{}
+	if err := r.Client.List(
+		ctx,
+		machineList,
+		client.InNamespace(mhc.Namespace),
+		client.MatchingLabelsSelector{Selector: labelSelector},
+	); err != nil {
+		return nil, errors.Wrap(err, "failed to list machines")
+	}
+	return machineList.Items, nil
+}
+
+// getNodeFromMachine fetches the node from a local or remote cluster for a
+// given machine.
+func (r *MachineHealthCheckReconciler) getNodeFromMachine(ctx context.Context, clusterClient client.Client, machine *clusterv1.Machine) (*corev1.Node, error) {
+	if machine.Status.NodeRef == nil {
+		return nil, nil
+	}
+
+	node := &corev1.Node{}
+	nodeKey := client.ObjectKey{
+		Name: machine.Status.NodeRef.Name,
+	}
+	if err := clusterClient.Get(ctx, nodeKey, node); err != nil {
+		return nil, err
+	}
+	return node, nil
+}
+
+// healthCheckTargets health checks a slice of targets
+// and gives a data to measure the average health.
+func (r *MachineHealthCheckReconciler) healthCheckTargets(targets []healthCheckTarget, logger logr.Logger, timeoutForMachineToHaveNode time.Duration) ([]healthCheckTarget, []healthCheckTarget, []time.Duration) {
+	var nextCheckTimes []time.Duration
+	var healthy []healthCheckTarget
+	var unhealthy []healthCheckTarget
+
+	for _, t := range targets {
+		log := logger.WithValues("target", t.string())
+		log.V(3).Info("Health checking target")
+		needsRemediation, reason, nextCheck := t.needsRemediation(timeoutForMachineToHaveNode)
+
+		if needsRemediation {
+			log.Info("Target has failed health check, marking for remediation", "reason", reason)
+			r.recorder.Eventf(
+				t.Machine,
+				corev1.EventTypeNormal,
+				EventDetectedUnhealthy,
+				"Machine %v has unhealthy node %v: %s",
+				t.string(),
+				t.nodeName(),
+				reason,
+			)
+			t.unhealthyReason = reason
+			unhealthy = append(unhealthy, t)
+			continue
+		}
+
+		if nextCheck > 0 {
+			log.V(3).Info("Target is likely to go unhealthy", "timeUntilUnhealthy", nextCheck.Truncate(time.Second).String())
+			nextCheckTimes = append(nextCheckTimes, nextCheck)
+		}
+		healthy = append(healthy, t)
+	}
+	return healthy, unhealthy, nextCheckTimes
+}
+
+// isAllowedRemediation checks the value of the UnhealthyRange field, or of the MaxUnhealthy one if not set, to determine
+// whether remediation should be allowed or not
+func isAllowedRemediation(mhc *clusterv1.MachineHealthCheck) bool {
+	unhealthy := int(mhc.Status.ExpectedMachines - mhc.Status.CurrentHealthy)
+
+	// If unhealthy is outside of the range, short circuit any further remediation
+	if mhc.Spec.UnhealthyRange != nil {
+		min, max, err := getUnhealthyRange(mhc)
+		if err != nil {
+			return false
+		}
+		return unhealthy == 0 || (unhealthy >= min && unhealthy <= max)
+	}
+
+	if mhc.Spec.MaxUnhealthy == nil {
+		return true
+	}
+	maxUnhealthy, err := intstr.GetValueFromIntOrPercent(mhc.Spec.MaxUnhealthy, int(mhc.Status.ExpectedMachines), false)
+	if err != nil {
+		return false
+	}
+
+	// If unhealthy is above maxUnhealthy, short circuit any further remediation
+	return unhealthy <= maxUnhealthy
+}
+
+// getUnhealthyRange parses the UnhealthyRange field, e.g. "[3-5]", and returns its bounds.
+func getUnhealthyRange(mhc *clusterv1.MachineHealthCheck) (int, int, error) {
+	var min, max int
+	if _, err := fmt.Sscanf(*mhc.Spec.UnhealthyRange, "[%d-%d]", &min, &max); err != nil {
+		return 0, 0, errors.Wrapf(err, "invalid unhealthyRange %q", *mhc.Spec.UnhealthyRange)
+	}
+	if min > max {
+		return 0, 0, errors.Errorf("invalid unhealthyRange %q, the lower bound is greater than the upper bound", *mhc.Spec.UnhealthyRange)
+	}
+	return min, max, nil
+}
+
+// remediationLimit describes the field limiting remediation, for events and conditions.
+func remediationLimit(mhc *clusterv1.MachineHealthCheck) string {
+	if mhc.Spec.UnhealthyRange != nil {
+		return fmt.Sprintf("unhealthyRange: %s", *mhc.Spec.UnhealthyRange)
+	}
+	return fmt.Sprintf("maxUnhealthy: %v", mhc.Spec.MaxUnhealthy)
+}
+
+// isEmptySelector returns true if the selector has neither labels nor expressions,
+// in which case no Machine is considered to be matched.
+func isEmptySelector(selector *metav1.LabelSelector) bool {
+	return len(selector.MatchLabels) == 0 && len(selector.MatchExpressions) == 0
+}
+
+func minDuration(durations []time.Duration) time.Duration {
+	if len(durations) == 0 {
+		return time.Duration(0)
+	}
+
+	minDuration := durations[0]
+	// Ignore first element as that is already minDuration
+	for _, nc := range durations[1:] {
+		if nc < minDuration {
+			minDuration = nc
+		}
+	}
+	return minDuration
+}
+
+// getNodeCondition returns node condition by type
+func getNodeCondition(node *corev1.Node, conditionType corev1.NodeConditionType) *corev1.NodeCondition {
+	for _, cond := range node.Status.Conditions {
+		if cond.Type == conditionType {
+			return &cond
+		}
+	}
+	return nil
+}
@@ -0,0 +1,518 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/pointer"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	capierrors "sigs.k8s.io/cluster-api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func TestGetTargetsFromMHC(t *testing.T) {
+	g := NewWithT(t)
+	g.Expect(clusterv1.AddToScheme(scheme.Scheme)).To(Succeed())
+
+	mhc := newTestMachineHealthCheck("test-mhc", "default", "test-cluster", map[string]string{"foo": "bar"})
+
+	testNode1 := newTestNode("node1")
+	testMachine1 := newTestMachine("machine1", "default", "test-cluster", testNode1.Name, map[string]string{"foo": "bar"})
+	testNode2 := newTestNode("node2")
+	testMachine2 := newTestMachine("machine2", "default", "test-cluster", testNode2.Name, map[string]string{"foo": "bar"})
+	// Machine without a node yet
+	testMachine3 := newTestMachine("machine3", "default", "test-cluster", "", map[string]string{"foo": "bar"})
+	// Machine with a node which no longer exists
+	testMachine4 := newTestMachine("machine4", "default", "test-cluster", "node4", map[string]string{"foo": "bar"})
+	// Machines not selected by the MachineHealthCheck
+	testMachineOtherLabels := newTestMachine("machine5", "default", "test-cluster", "", map[string]string{"foo": "baz"})
+	testMachineOtherCluster := newTestMachine("machine6", "default", "other-cluster", "", map[string]string{"foo": "bar"})
+	testMachineOtherNamespace := newTestMachine("machine7", "other", "test-cluster", "", map[string]string{"foo": "bar"})
+
+	c := fake.NewFakeClientWithScheme(scheme.Scheme,
+		mhc,
+		testNode1,
+		testMachine1,
+		testNode2,
+		testMachine2,
+		testMachine3,
+		testMachine4,
+		testMachineOtherLabels,
+		testMachineOtherCluster,
+		testMachineOtherNamespace,
+	)
+	r := &MachineHealthCheckReconciler{
+		Client:   c,
+		Log:      log.Log,
+		recorder: record.NewFakeRecorder(32),
+	}
+
+	targets, err := r.getTargetsFromMHC(context.Background(), c, mhc)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(targets).To(HaveLen(4))
+
+	byName := map[string]healthCheckTarget{}
+	for _, target := range targets {
+		g.Expect(target.MHC).To(Equal(mhc))
+		byName[target.Machine.Name] = target
+	}
+	g.Expect(byName).To(HaveKey("machine1"))
+	g.Expect(byName["machine1"].Node).NotTo(BeNil())
+	g.Expect(byName["machine1"].Node.Name).To(Equal("node1"))
+	g.Expect(byName).To(HaveKey("machine2"))
+	g.Expect(byName["machine2"].Node).NotTo(BeNil())
+	g.Expect(byName).To(HaveKey("machine3"))
+	g.Expect(byName["machine3"].Node).To(BeNil())
+	g.Expect(byName["machine3"].nodeMissing).To(BeFalse())
+	g.Expect(byName).To(HaveKey("machine4"))
+	g.Expect(byName["machine4"].Node).To(BeNil())
+	g.Expect(byName["machine4"].nodeMissing).To(BeTrue())
+}
+
+func TestGetTargetsFromMHCWithEmptySelector(t *testing.T) {
+	g := NewWithT(t)
+	g.Expect(clusterv1.AddToScheme(scheme.Scheme)).To(Succeed())
+
+	mhc := newTestMachineHealthCheck("test-mhc", "default", "test-cluster", nil)
+	testMachine := newTestMachine("machine1", "default", "test-cluster", "", map[string]string{"foo": "bar"})
+
+	c := fake.NewFakeClientWithScheme(scheme.Scheme, mhc, testMachine)
+	r := &MachineHealthCheckReconciler{
+		Client:   c,
+		Log:      log.Log,
+		recorder: record.NewFakeRecorder(32),
+	}
+
+	targets, err := r.getTargetsFromMHC(context.Background(), c, mhc)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(targets).To(BeEmpty())
+}
+
+func TestHealthCheckTargets(t *testing.T) {
+	mhc := newTestMachineHealthCheck("test-mhc", "default", "test-cluster", map[string]string{"foo": "bar"})
+	mhc.Spec.UnhealthyConditions = []clusterv1.UnhealthyCondition{
+		{
+			Type:    corev1.NodeReady,
+			Status:  corev1.ConditionUnknown,
+			Timeout: metav1.Duration{Duration: 5 * time.Minute},
+		},
+		{
+			Type:    corev1.NodeReady,
+			Status:  corev1.ConditionFalse,
+			Timeout: metav1.Duration{Duration: 5 * time.Minute},
+		},
+	}
+	timeoutForMachineToHaveNode := 10 * time.Minute
+
+	testMachine := newTestMachine("machine1", "default", "test-cluster", "node1", map[string]string{"foo": "bar"})
+
+	// Target for when the node has not yet been seen by the Machine controller
+	testMachineLastUpdated400s := testMachine.DeepCopy()
+	testMachineLastUpdated400s.CreationTimestamp = metav1.NewTime(time.Now().Add(-400 * time.Second))
+	nodeNotYetStartedTarget := healthCheckTarget{
+		MHC:     mhc,
+		Machine: testMachineLastUpdated400s,
+	}
+
+	// Target for when the Node has been seen, but has now gone
+	nodeGoneAway := healthCheckTarget{
+		MHC:         mhc,
+		Machine:     testMachine,
+		nodeMissing: true,
+	}
+
+	// Target for when the node has not come up within the startup timeout
+	testMachineLastUpdated1200s := testMachine.DeepCopy()
+	testMachineLastUpdated1200s.CreationTimestamp = metav1.NewTime(time.Now().Add(-1200 * time.Second))
+	nodeNeverStartedTarget := healthCheckTarget{
+		MHC:     mhc,
+		Machine: testMachineLastUpdated1200s,
+	}
+
+	// Target for when the node is healthy
+	testNodeHealthy := newTestNode("node1")
+	testNodeHealthy.Status.Conditions = []corev1.NodeCondition{
+		{
+			Type:               corev1.NodeReady,
+			Status:             corev1.ConditionTrue,
+			LastTransitionTime: metav1.NewTime(time.Now().Add(-24 * time.Hour)),
+		},
+	}
+	nodeHealthy := healthCheckTarget{
+		MHC:     mhc,
+		Machine: testMachine,
+		Node:    testNodeHealthy,
+	}
+
+	// Target for when the node has been unknown for a short while
+	testNodeUnknown200 := newTestNode("node1")
+	testNodeUnknown200.Status.Conditions = []corev1.NodeCondition{
+		{
+			Type:               corev1.NodeReady,
+			Status:             corev1.ConditionUnknown,
+			LastTransitionTime: metav1.NewTime(time.Now().Add(-200 * time.Second)),
+		},
+	}
+	nodeUnknown200 := healthCheckTarget{
+		MHC:     mhc,
+		Machine: testMachine,
+		Node:    testNodeUnknown200,
+	}
+
+	// Target for when the node has been not ready for longer than the timeout
+	testNodeNotReady400 := newTestNode("node1")
+	testNodeNotReady400.Status.Conditions = []corev1.NodeCondition{
+		{
+			Type:               corev1.NodeReady,
+			Status:             corev1.ConditionFalse,
+			LastTransitionTime: metav1.NewTime(time.Now().Add(-400 * time.Second)),
+		},
+	}
+	nodeNotReady400 := healthCheckTarget{
+		MHC:     mhc,
+		Machine: testMachine,
+		Node:    testNodeNotReady400,
+	}
+
+	// Target for when the machine reports a terminal failure
+	testMachineFailed := testMachine.DeepCopy()
+	failureReason := capierrors.UpdateMachineError
+	testMachineFailed.Status.FailureReason = &failureReason
+	machineFailed := healthCheckTarget{
+		MHC:     mhc,
+		Machine: testMachineFailed,
+		Node:    testNodeHealthy,
+	}
+
+	testCases := []struct {
+		desc                              string
+		targets                           []healthCheckTarget
+		expectedHealthy                   []string
+		expectedNeedsRemediation          []string
+		expectedNextCheckTimesLessOrEqual []time.Duration
+	}{
+		{
+			desc:                              "when the node has not yet started",
+			targets:                           []healthCheckTarget{nodeNotYetStartedTarget},
+			expectedHealthy:                   []string{"machine1"},
+			expectedNextCheckTimesLessOrEqual: []time.Duration{200 * time.Second},
+		},
+		{
+			desc:                     "when the node has gone away",
+			targets:                  []healthCheckTarget{nodeGoneAway},
+			expectedNeedsRemediation: []string{"machine1"},
+		},
+		{
+			desc:                     "when the node never started",
+			targets:                  []healthCheckTarget{nodeNeverStartedTarget},
+			expectedNeedsRemediation: []string{"machine1"},
+		},
+		{
+			desc:            "when the node is healthy",
+			targets:         []healthCheckTarget{nodeHealthy},
+			expectedHealthy: []string{"machine1"},
+		},
+		{
+			desc:                              "when the node has been unknown for less than the timeout",
+			targets:                           []healthCheckTarget{nodeUnknown200},
+			expectedHealthy:                   []string{"machine1"},
+			expectedNextCheckTimesLessOrEqual: []time.Duration{100 * time.Second},
+		},
+		{
+			desc:                     "when the node has been not ready for longer than the timeout",
+			targets:                  []healthCheckTarget{nodeNotReady400},
+			expectedNeedsRemediation: []string{"machine1"},
+		},
+		{
+			desc:                     "when the machine has failed",
+			targets:                  []healthCheckTarget{machineFailed},
+			expectedNeedsRemediation: []string{"machine1"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			g := NewWithT(t)
+
+			r := &MachineHealthCheckReconciler{
+				Log:      log.Log,
+				recorder: record.NewFakeRecorder(32),
+			}
+
+			healthy, unhealthy, nextCheckTimes := r.healthCheckTargets(tc.targets, r.Log, timeoutForMachineToHaveNode)
+
+			g.Expect(machineNames(healthy)).To(ConsistOf(tc.expectedHealthy))
+			g.Expect(machineNames(unhealthy)).To(ConsistOf(tc.expectedNeedsRemediation))
+			for _, target := range unhealthy {
+				g.Expect(target.unhealthyReason).NotTo(BeEmpty())
+			}
+			g.Expect(nextCheckTimes).To(HaveLen(len(tc.expectedNextCheckTimesLessOrEqual)))
+			for i, nextCheckTime := range nextCheckTimes {
+				g.Expect(nextCheckTime).To(BeNumerically("<=", tc.expectedNextCheckTimesLessOrEqual[i]))
+			}
+		})
+	}
+}
+
+func TestIsAllowedRemediation(t *testing.T) {
+	testCases := []struct {
+		name             string
+		maxUnhealthy     *intstr.IntOrString
+		unhealthyRange   *string
+		expectedMachines int32
+		currentHealthy   int32
+		allowed          bool
+	}{
+		{
+			name:             "when maxUnhealthy is not set",
+			maxUnhealthy:     nil,
+			expectedMachines: int32(3),
+			currentHealthy:   int32(0),
+			allowed:          true,
+		},
+		{
+			name:             "when maxUnhealthy is not an int or percentage",
+			maxUnhealthy:     &intstr.IntOrString{Type: intstr.String, StrVal: "abcdef"},
+			expectedMachines: int32(5),
+			currentHealthy:   int32(2),
+			allowed:          false,
+		},
+		{
+			name:             "when maxUnhealthy is an int less than current unhealthy",
+			maxUnhealthy:     &intstr.IntOrString{Type: intstr.Int, IntVal: int32(1)},
+			expectedMachines: int32(3),
+			currentHealthy:   int32(1),
+			allowed:          false,
+		},
+		{
+			name:             "when maxUnhealthy is an int equal to current unhealthy",
+			maxUnhealthy:     &intstr.IntOrString{Type: intstr.Int, IntVal: int32(2)},
+			expectedMachines: int32(3),
+			currentHealthy:   int32(1),
+			allowed:          true,
+		},
+		{
+			name:             "when maxUnhealthy is a percentage less than current unhealthy",
+			maxUnhealthy:     &intstr.IntOrString{Type: intstr.String, StrVal: "50%"},
+			expectedMachines: int32(5),
+			currentHealthy:   int32(2),
+			allowed:          false,
+		},
+		{
+			name:             "when maxUnhealthy is a percentage greater than current unhealthy",
+			maxUnhealthy:     &intstr.IntOrString{Type: intstr.String, StrVal: "50%"},
+			expectedMachines: int32(5),
+			currentHealthy:   int32(3),
+			allowed:          true,
+		},
+		{
+			name:             "when unhealthyRange contains the current unhealthy",
+			maxUnhealthy:     &intstr.IntOrString{Type: intstr.Int, IntVal: int32(1)},
+			unhealthyRange:   pointer.StringPtr("[2-3]"),
+			expectedMachines: int32(5),
+			currentHealthy:   int32(3),
+			allowed:          true,
+		},
+		{
+			name:             "when unhealthyRange is below the current unhealthy",
+			unhealthyRange:   pointer.StringPtr("[1-2]"),
+			expectedMachines: int32(5),
+			currentHealthy:   int32(2),
+			allowed:          false,
+		},
+		{
+			name:             "when unhealthyRange is above the current unhealthy",
+			unhealthyRange:   pointer.StringPtr("[3-5]"),
+			expectedMachines: int32(5),
+			currentHealthy:   int32(3),
+			allowed:          false,
+		},
+		{
+			name:             "when unhealthyRange is above and there are no unhealthy machines",
+			unhealthyRange:   pointer.StringPtr("[3-5]"),
+			expectedMachines: int32(5),
+			currentHealthy:   int32(5),
+			allowed:          true,
+		},
+		{
+			name:             "when unhealthyRange is not valid",
+			unhealthyRange:   pointer.StringPtr("[5-3]"),
+			expectedMachines: int32(5),
+			currentHealthy:   int32(1),
+			allowed:          false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			mhc := &clusterv1.MachineHealthCheck{
+				Spec: clusterv1.MachineHealthCheckSpec{
+					MaxUnhealthy:   tc.maxUnhealthy,
+					UnhealthyRange: tc.unhealthyRange,
+				},
+				Status: clusterv1.MachineHealthCheckStatus{
+					ExpectedMachines: tc.expectedMachines,
+					CurrentHealthy:   tc.currentHealthy,
+				},
+			}
+
+			g.Expect(isAllowedRemediation(mhc)).To(Equal(tc.allowed))
+		})
+	}
+}
+
+func TestMachineToMachineHealthChecks(t *testing.T) {
+	g := NewWithT(t)
+	g.Expect(clusterv1.AddToScheme(scheme.Scheme)).To(Succeed())
+
+	mhc1 := newTestMachineHealthCheck("mhc1", "default", "test-cluster", map[string]string{"foo": "bar"})
+	mhc2 := newTestMachineHealthCheck("mhc2", "default", "test-cluster", map[string]string{"foo": "baz"})
+	mhc3 := newTestMachineHealthCheck("mhc3", "default", "other-cluster", map[string]string{"foo": "bar"})
+	machine := newTestMachine("machine1", "default", "test-cluster", "", map[string]string{"foo": "bar"})
+
+	r := &MachineHealthCheckReconciler{
+		Client: fake.NewFakeClientWithScheme(scheme.Scheme, mhc1, mhc2, mhc3),
+		Log:    log.Log,
+	}
+
+	requests := r.machineToMachineHealthChecks(handlerMapObject(machine))
+	g.Expect(requests).To(HaveLen(1))
+	g.Expect(requests[0].Name).To(Equal("mhc1"))
+}
+
+func TestMachineHealthCheckHasMatchingLabels(t *testing.T) {
+	testCases := []struct {
+		name     string
+		selector metav1.LabelSelector
+		labels   map[string]string
+		expected bool
+	}{
+		{
+			name: "selector matches labels",
+			selector: metav1.LabelSelector{
+				MatchLabels: map[string]string{"foo": "bar"},
+			},
+			labels:   map[string]string{"foo": "bar", "more": "labels"},
+			expected: true,
+		},
+		{
+			name: "selector does not match labels",
+			selector: metav1.LabelSelector{
+				MatchLabels: map[string]string{"foo": "bar"},
+			},
+			labels:   map[string]string{"no": "match"},
+			expected: false,
+		},
+		{
+			name:     "selector is empty",
+			selector: metav1.LabelSelector{},
+			labels:   map[string]string{"foo": "bar"},
+			expected: false,
+		},
+		{
+			name: "selector is invalid",
+			selector: metav1.LabelSelector{
+				MatchExpressions: []metav1.LabelSelectorRequirement{
+					{Key: "foo", Operator: "bad-operator"},
+				},
+			},
+			labels:   map[string]string{"foo": "bar"},
+			expected: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			g.Expect(hasMatchingLabels(tc.selector, tc.labels)).To(Equal(tc.expected))
+		})
+	}
+}
+
+func machineNames(targets []healthCheckTarget) []string {
+	names := []string{}
+	for _, t := range targets {
+		names = append(names, t.Machine.Name)
+	}
+	return names
+}
+
+func newTestMachine(name, namespace, clusterName, nodeName string, labels map[string]string) *clusterv1.Machine {
+	bootstrap := "bootstrap"
+	machineLabels := map[string]string{clusterv1.ClusterLabelName: clusterName}
+	for k, v := range labels {
+		machineLabels[k] = v
+	}
+
+	machine := &clusterv1.Machine{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: clusterv1.GroupVersion.String(),
+			Kind:       "Machine",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              name,
+			Namespace:         namespace,
+			Labels:            machineLabels,
+			CreationTimestamp: metav1.Now(),
+		},
+		Spec: clusterv1.MachineSpec{
+			ClusterName: clusterName,
+			Bootstrap: clusterv1.Bootstrap{
+				Data: &bootstrap,
+			},
+		},
+	}
+	if nodeName != "" {
+		machine.Status.NodeRef = &corev1.ObjectReference{
+			Name: nodeName,
+		}
+	}
+	return machine
+}
+
+func newTestNode(name string) *corev1.Node {
+	return &corev1.Node{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "Node",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+		},
+	}
+}
+
+func handlerMapObject(obj runtime.Object) handler.MapObject {
+	return handler.MapObject{
+		Meta:   obj.(metav1.Object),
+		Object: obj,
+	}
+}
@@ -0,0 +1,194 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+)
+
+func machinePoolTestNodes() []runtime.Object {
+	readyCondition := corev1.NodeCondition{
+		Type:               corev1.NodeReady,
+		Status:             corev1.ConditionTrue,
+		LastTransitionTime: metav1.Now(),
+	}
+	return []runtime.Object{
+		&corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: "node-1"},
+			Spec:       corev1.NodeSpec{ProviderID: "aws://us-east-1/id-node-1"},
+			Status:     corev1.NodeStatus{Conditions: []corev1.NodeCondition{readyCondition}},
+		},
+		&corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: "node-2"},
+			Spec:       corev1.NodeSpec{ProviderID: "aws://us-west-2/id-node-2"},
+		},
+		&corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: "gce-node-2"},
+			Spec:       corev1.NodeSpec{ProviderID: "gce://us-central1/id-node-2"},
+			Status:     corev1.NodeStatus{Conditions: []corev1.NodeCondition{readyCondition}},
+		},
+	}
+}
+
+func TestMachinePoolGetNodeReferences(t *testing.T) {
+	g := NewWithT(t)
+
+	g.Expect(clusterv1.AddToScheme(scheme.Scheme)).To(Succeed())
+
+	r := &MachinePoolReconciler{
+		Client:   fake.NewFakeClientWithScheme(scheme.Scheme),
+		Log:      log.Log,
+		recorder: record.NewFakeRecorder(32),
+	}
+
+	c := fake.NewFakeClientWithScheme(scheme.Scheme, machinePoolTestNodes()...)
+
+	testCases := []struct {
+		name           string
+		providerIDList []string
+		expected       []string
+		ready          int
+		available      int
+		err            error
+	}{
+		{
+			name:           "all the provider ids with a node",
+			providerIDList: []string{"aws:///id-node-1", "aws:///id-node-2", "gce:///id-node-2"},
+			expected:       []string{"node-1", "node-2", "gce-node-2"},
+			ready:          2,
+			available:      2,
+		},
+		{
+			name:           "some provider ids without a node",
+			providerIDList: []string{"aws:///id-node-1", "aws:///id-node-100"},
+			expected:       []string{"node-1"},
+			ready:          1,
+			available:      1,
+		},
+		{
+			name:           "no provider id with a node",
+			providerIDList: []string{"aws:///id-node-100"},
+			err:            ErrNoAvailableNodes,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			gt := NewWithT(t)
+
+			result, err := r.getNodeReferences(context.Background(), c, test.providerIDList, pointer.Int32Ptr(0))
+			if test.err != nil {
+				gt.Expect(err).To(Equal(test.err))
+				return
+			}
+			gt.Expect(err).NotTo(HaveOccurred())
+
+			var names []string
+			for _, ref := range result.references {
+				names = append(names, ref.Name)
+			}
+			gt.Expect(names).To(Equal(test.expected))
+			gt.Expect(result.ready).To(Equal(test.ready))
+			gt.Expect(result.available).To(Equal(test.available))
+		})
+	}
+}
+
+func TestMachinePoolDeleteRetiredNodes(t *testing.T) {
+	g := NewWithT(t)
+
+	g.Expect(clusterv1.AddToScheme(scheme.Scheme)).To(Succeed())
+
+	r := &MachinePoolReconciler{
+		Client:   fake.NewFakeClientWithScheme(scheme.Scheme),
+		Log:      log.Log,
+		recorder: record.NewFakeRecorder(32),
+	}
+
+	c := fake.NewFakeClientWithScheme(scheme.Scheme, machinePoolTestNodes()...)
+
+	nodeRefs := []corev1.ObjectReference{{Name: "node-1"}, {Name: "node-2"}, {Name: "node-missing"}}
+	g.Expect(r.deleteRetiredNodes(context.Background(), c, nodeRefs, []string{"aws:///id-node-1"})).To(Succeed())
+
+	// node-1 is still in the provider id list, node-2 has been removed from the group, while gce-node-2 is not
+	// part of the MachinePool.
+	g.Expect(c.Get(context.Background(), client.ObjectKey{Name: "node-1"}, &corev1.Node{})).To(Succeed())
+	g.Expect(apierrors.IsNotFound(c.Get(context.Background(), client.ObjectKey{Name: "node-2"}, &corev1.Node{}))).To(BeTrue())
+	g.Expect(c.Get(context.Background(), client.ObjectKey{Name: "gce-node-2"}, &corev1.Node{})).To(Succeed())
+}
+
+func TestMachinePoolReconcileDeleteNodes(t *testing.T) {
+	g := NewWithT(t)
+
+	g.Expect(clusterv1.AddToScheme(scheme.Scheme)).To(Succeed())
+
+	mp := &clusterv1.MachinePool{
+		ObjectMeta: metav1.ObjectMeta{Name: "machinepool-test", Namespace: "default"},
+		Status: clusterv1.MachinePoolStatus{
+			NodeRefs: []corev1.ObjectReference{{Name: "node-1"}},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		cluster *clusterv1.Cluster
+	}{
+		{
+			name: "skips the nodes if the cluster is being deleted",
+			cluster: &clusterv1.Cluster{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-cluster", Namespace: "default", DeletionTimestamp: &metav1.Time{Time: time.Now()}},
+			},
+		},
+		{
+			name: "skips the nodes if the remote client can't be created",
+			cluster: &clusterv1.Cluster{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-cluster", Namespace: "default"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			// The kubeconfig secret of the cluster does not exist, so the remote client can't be created.
+			r := &MachinePoolReconciler{
+				Client:   fake.NewFakeClientWithScheme(scheme.Scheme, tt.cluster, mp),
+				Log:      log.Log,
+				scheme:   scheme.Scheme,
+				recorder: record.NewFakeRecorder(32),
+			}
+			g.Expect(r.reconcileDeleteNodes(context.Background(), tt.cluster, mp)).To(Succeed())
+		})
+	}
+}
@@ -0,0 +1,268 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/utils/pointer"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/controllers/external"
+	capierrors "sigs.k8s.io/cluster-api/errors"
+	"sigs.k8s.io/cluster-api/util"
+	"sigs.k8s.io/cluster-api/util/patch"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+)
+
+func (r *MachinePoolReconciler) reconcilePhase(_ context.Context, mp *clusterv1.MachinePool) {
+	// Set the phase to "pending" if nil.
+	if mp.Status.Phase == "" {
+		mp.Status.SetTypedPhase(clusterv1.MachinePoolPhasePending)
+	}
+
+	// Set the phase to "provisioning" if bootstrap is ready and the infrastructure isn't.
+	if mp.Status.BootstrapReady && !mp.Status.InfrastructureReady {
+		mp.Status.SetTypedPhase(clusterv1.MachinePoolPhaseProvisioning)
+	}
+
+	// Set the phase to "provisioned" if there are NodeRefs.
+	if len(mp.Status.NodeRefs) != 0 {
+		mp.Status.SetTypedPhase(clusterv1.MachinePoolPhaseProvisioned)
+	}
+
+	// Set the phase to "running" if the infrastructure is ready and the number of ready replicas is equal to the desired replicas.
+	if mp.Status.InfrastructureReady && len(mp.Status.NodeRefs) != 0 && machinePoolReplicas(mp) == mp.Status.ReadyReplicas {
+		mp.Status.SetTypedPhase(clusterv1.MachinePoolPhaseRunning)
+	}
+
+	// Set the phase to "failed" if any of Status.FailureReason or Status.FailureMessage is not-nil.
+	if mp.Status.FailureReason != nil || mp.Status.FailureMessage != nil {
+		mp.Status.SetTypedPhase(clusterv1.MachinePoolPhaseFailed)
+	}
+
+	// Set the phase to "deleting" if the deletion timestamp is set.
+	if !mp.DeletionTimestamp.IsZero() {
+		mp.Status.SetTypedPhase(clusterv1.MachinePoolPhaseDeleting)
+	}
+}
+
+// machinePoolReplicas returns the desired number of replicas of a MachinePool, that defaults to 1.
+func machinePoolReplicas(mp *clusterv1.MachinePool) int32 {
+	if mp.Spec.Replicas == nil {
+		return 1
+	}
+	return *mp.Spec.Replicas
+}
+
+// reconcileExternal handles generic unstructured objects referenced by a MachinePool.
+func (r *MachinePoolReconciler) reconcileExternal(ctx context.Context, cluster *clusterv1.Cluster, mp *clusterv1.MachinePool, ref *corev1.ObjectReference) (external.ReconcileOutput, error) {
+	logger := r.Log.WithValues("machinepool", mp.Name, "namespace", mp.Namespace)
+
+	obj, err := external.Get(ctx, r.Client, ref, mp.Namespace)
+	if err != nil {
+		if apierrors.IsNotFound(errors.Cause(err)) {
+			return external.ReconcileOutput{}, errors.Wrapf(&capierrors.RequeueAfterError{RequeueAfter: externalReadyWait},
+				"could not find %v %q for MachinePool %q in namespace %q, requeuing",
+				ref.GroupVersionKind(), ref.Name, mp.Name, mp.Namespace)
+		}
+		return external.ReconcileOutput{}, err
+	}
+
+	// Remove the pause propagated to the external object, if the Cluster is not paused anymore.
+	if err := propagatePause(ctx, r.Client, cluster, obj); err != nil {
+		return external.ReconcileOutput{}, err
+	}
+
+	// if external ref is paused, return error.
+	if util.IsPaused(cluster, obj) {
+		logger.V(3).Info("External object referenced is paused")
+		return external.ReconcileOutput{Paused: true}, nil
+	}
+
+	// Initialize the patch helper.
+	patchHelper, err := patch.NewHelper(obj, r.Client)
+	if err != nil {
+		return external.ReconcileOutput{}, err
+	}
+
+	// Set external object ControllerReference to the MachinePool.
+	if err := controllerutil.SetControllerReference(mp, obj, r.scheme); err != nil {
+		return external.ReconcileOutput{}, err
+	}
+
+	// Set the Cluster label.
+	labels := obj.GetLabels()
+	if labels == nil {
+		labels = make(map[string]string)
+	}
+	labels[clusterv1.ClusterLabelName] = mp.Spec.ClusterName
+	for key, value := range util.GetPropagatedLabels(cluster) {
+		labels[key] = value
+	}
+	obj.SetLabels(labels)
+
+	// Always attempt to Patch the external object.
+	if err := patchHelper.Patch(ctx, obj); err != nil {
+		return external.ReconcileOutput{}, err
+	}
+
+	// Ensure we add a watcher to the external object.
+	if err := r.externalTracker.Watch(logger, obj, &handler.EnqueueRequestForOwner{OwnerType: &clusterv1.MachinePool{}}); err != nil {
+		return external.ReconcileOutput{}, err
+	}
+
+	// Set failure reason and message, if any.
+	failureReason, failureMessage, err := external.FailuresFrom(obj)
+	if err != nil {
+		return external.ReconcileOutput{}, err
+	}
+	if failureReason != "" {
+		machinePoolStatusError := capierrors.MachinePoolStatusError(failureReason)
+		mp.Status.FailureReason = &machinePoolStatusError
+	}
+	if failureMessage != "" {
+		mp.Status.FailureMessage = pointer.StringPtr(
+			fmt.Sprintf("Failure detected from referenced resource %v with name %q: %s",
+				obj.GroupVersionKind(), obj.GetName(), failureMessage),
+		)
+	}
+
+	return external.ReconcileOutput{Result: obj}, nil
+}
+
+// reconcileBootstrap reconciles the Spec.Template.Spec.Bootstrap.ConfigRef object on a MachinePool.
+func (r *MachinePoolReconciler) reconcileBootstrap(ctx context.Context, cluster *clusterv1.Cluster, mp *clusterv1.MachinePool) error {
+	// Call generic external reconciler if we have an external reference.
+	var bootstrapConfig *unstructured.Unstructured
+	if mp.Spec.Template.Spec.Bootstrap.ConfigRef != nil {
+		bootstrapReconcileResult, err := r.reconcileExternal(ctx, cluster, mp, mp.Spec.Template.Spec.Bootstrap.ConfigRef)
+		if err != nil {
+			return err
+		}
+		// if the external object is paused, return without any further processing
+		if bootstrapReconcileResult.Paused {
+			return nil
+		}
+		bootstrapConfig = bootstrapReconcileResult.Result
+	}
+
+	// If the bootstrap data is populated, set ready and return.
+	if mp.Spec.Template.Spec.Bootstrap.Data != nil || mp.Spec.Template.Spec.Bootstrap.DataSecretName != nil {
+		mp.Status.BootstrapReady = true
+		return nil
+	}
+
+	// If the bootstrap config is being deleted, return early.
+	if !bootstrapConfig.GetDeletionTimestamp().IsZero() {
+		return nil
+	}
+
+	// Determine if the bootstrap provider is ready.
+	ready, err := external.IsReady(bootstrapConfig)
+	if err != nil {
+		return err
+	} else if !ready {
+		return errors.Wrapf(&capierrors.RequeueAfterError{RequeueAfter: externalReadyWait},
+			"Bootstrap provider for MachinePool %q in namespace %q is not ready, requeuing", mp.Name, mp.Namespace)
+	}
+
+	// Get and set the name of the secret containing the bootstrap data.
+	secretName, _, err := unstructured.NestedString(bootstrapConfig.Object, "status", "dataSecretName")
+	if err != nil {
+		return errors.Wrapf(err, "failed to retrieve dataSecretName from bootstrap provider for MachinePool %q in namespace %q", mp.Name, mp.Namespace)
+	} else if secretName == "" {
+		return errors.Errorf("retrieved empty dataSecretName from bootstrap provider for MachinePool %q in namespace %q", mp.Name, mp.Namespace)
+	}
+
+	mp.Spec.Template.Spec.Bootstrap.DataSecretName = pointer.StringPtr(secretName)
+	mp.Status.BootstrapReady = true
+	return nil
+}
+
+// reconcileInfrastructure reconciles the Spec.Template.Spec.InfrastructureRef object on a MachinePool.
+// Scaling is delegated to the infrastructure provider, that reports the provider IDs of the instances in the group
+// in spec.providerIDList and the number of instances in status.replicas.
+func (r *MachinePoolReconciler) reconcileInfrastructure(ctx context.Context, cluster *clusterv1.Cluster, mp *clusterv1.MachinePool) error {
+	// Call generic external reconciler.
+	infraReconcileResult, err := r.reconcileExternal(ctx, cluster, mp, &mp.Spec.Template.Spec.InfrastructureRef)
+	if err != nil {
+		if mp.Status.InfrastructureReady && strings.Contains(err.Error(), "could not find") {
+			// Infra object went missing after the machine pool was up and running
+			r.Log.Error(err, "MachinePool infrastructure reference has been deleted after being ready, setting failure state")
+			mp.Status.FailureReason = capierrors.MachinePoolStatusErrorPtr(capierrors.InvalidConfigurationMachinePoolError)
+			mp.Status.FailureMessage = pointer.StringPtr(fmt.Sprintf("MachinePool infrastructure resource %v with name %q has been deleted after being ready",
+				mp.Spec.Template.Spec.InfrastructureRef.GroupVersionKind(), mp.Spec.Template.Spec.InfrastructureRef.Name))
+		}
+		return err
+	}
+	// if the external object is paused, return without any further processing
+	if infraReconcileResult.Paused {
+		return nil
+	}
+	infraConfig := infraReconcileResult.Result
+
+	if !infraConfig.GetDeletionTimestamp().IsZero() {
+		return nil
+	}
+
+	// Determine if the infrastructure provider is ready.
+	ready, err := external.IsReady(infraConfig)
+	if err != nil {
+		return err
+	}
+	mp.Status.InfrastructureReady = ready
+	if !ready {
+		return errors.Wrapf(&capierrors.RequeueAfterError{RequeueAfter: externalReadyWait},
+			"Infrastructure provider for MachinePool %q in namespace %q is not ready, requeuing", mp.Name, mp.Namespace,
+		)
+	}
+
+	// Get Spec.ProviderIDList from the infrastructure provider.
+	var providerIDList []string
+	if err := util.UnstructuredUnmarshalField(infraConfig, &providerIDList, "spec", "providerIDList"); err != nil && err != util.ErrUnstructuredFieldNotFound {
+		return errors.Wrapf(err, "failed to retrieve Spec.ProviderIDList from infrastructure provider for MachinePool %q in namespace %q", mp.Name, mp.Namespace)
+	}
+
+	// Get and set Status.Replicas from the infrastructure provider.
+	err = util.UnstructuredUnmarshalField(infraConfig, &mp.Status.Replicas, "status", "replicas")
+	if err != nil && err != util.ErrUnstructuredFieldNotFound {
+		return errors.Wrapf(err, "failed to retrieve Status.Replicas from infrastructure provider for MachinePool %q in namespace %q", mp.Name, mp.Namespace)
+	}
+
+	// When the instances in the group change, the replicas counters are reset and then computed again by the
+	// NodeRefs reconciliation.
+	if !reflect.DeepEqual(mp.Spec.ProviderIDs, providerIDList) {
+		mp.Spec.ProviderIDs = providerIDList
+		mp.Status.ReadyReplicas = 0
+		mp.Status.AvailableReplicas = 0
+		mp.Status.UnavailableReplicas = mp.Status.Replicas
+	}
+
+	if len(providerIDList) == 0 && machinePoolReplicas(mp) > 0 {
+		return errors.Wrapf(&capierrors.RequeueAfterError{RequeueAfter: externalReadyWait},
+			"retrieved empty Spec.ProviderIDList from infrastructure provider for MachinePool %q in namespace %q, requeuing", mp.Name, mp.Namespace)
+	}
+	return nil
+}
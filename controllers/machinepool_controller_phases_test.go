@@ -0,0 +1,204 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	capierrors "sigs.k8s.io/cluster-api/errors"
+)
+
+func TestMachinePoolReconcilePhase(t *testing.T) {
+	deletionTimestamp := metav1.Now()
+
+	testCases := []struct {
+		name     string
+		mp       *clusterv1.MachinePool
+		expected clusterv1.MachinePoolPhase
+	}{
+		{
+			name:     "pending with a new MachinePool",
+			mp:       &clusterv1.MachinePool{},
+			expected: clusterv1.MachinePoolPhasePending,
+		},
+		{
+			name: "provisioning when bootstrap is ready",
+			mp: &clusterv1.MachinePool{
+				Status: clusterv1.MachinePoolStatus{BootstrapReady: true},
+			},
+			expected: clusterv1.MachinePoolPhaseProvisioning,
+		},
+		{
+			name: "provisioned when there are NodeRefs, but not all the replicas are ready",
+			mp: &clusterv1.MachinePool{
+				Spec: clusterv1.MachinePoolSpec{Replicas: pointer.Int32Ptr(2)},
+				Status: clusterv1.MachinePoolStatus{
+					BootstrapReady:      true,
+					InfrastructureReady: true,
+					NodeRefs:            []corev1.ObjectReference{{Name: "node-1"}},
+					ReadyReplicas:       1,
+				},
+			},
+			expected: clusterv1.MachinePoolPhaseProvisioned,
+		},
+		{
+			name: "running when all the replicas are ready",
+			mp: &clusterv1.MachinePool{
+				Spec: clusterv1.MachinePoolSpec{Replicas: pointer.Int32Ptr(2)},
+				Status: clusterv1.MachinePoolStatus{
+					BootstrapReady:      true,
+					InfrastructureReady: true,
+					NodeRefs:            []corev1.ObjectReference{{Name: "node-1"}, {Name: "node-2"}},
+					ReadyReplicas:       2,
+				},
+			},
+			expected: clusterv1.MachinePoolPhaseRunning,
+		},
+		{
+			name: "failed when there is a failure reason",
+			mp: &clusterv1.MachinePool{
+				Status: clusterv1.MachinePoolStatus{
+					FailureReason: capierrors.MachinePoolStatusErrorPtr(capierrors.InvalidConfigurationMachinePoolError),
+				},
+			},
+			expected: clusterv1.MachinePoolPhaseFailed,
+		},
+		{
+			name: "deleting when the deletion timestamp is set",
+			mp: &clusterv1.MachinePool{
+				ObjectMeta: metav1.ObjectMeta{DeletionTimestamp: &deletionTimestamp},
+			},
+			expected: clusterv1.MachinePoolPhaseDeleting,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			r := &MachinePoolReconciler{}
+			r.reconcilePhase(context.Background(), tc.mp)
+			g.Expect(tc.mp.Status.GetTypedPhase()).To(Equal(tc.expected))
+		})
+	}
+}
+
+func TestMachinePoolReconcileBootstrapAndInfrastructure(t *testing.T) {
+	g := NewWithT(t)
+
+	g.Expect(clusterv1.AddToScheme(scheme.Scheme)).To(Succeed())
+
+	cluster := &clusterv1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-cluster", Namespace: "default"},
+	}
+
+	mp := &clusterv1.MachinePool{
+		ObjectMeta: metav1.ObjectMeta{Name: "machinepool-test", Namespace: "default"},
+		Spec: clusterv1.MachinePoolSpec{
+			ClusterName: cluster.Name,
+			Replicas:    pointer.Int32Ptr(2),
+			Template: clusterv1.MachineTemplateSpec{
+				Spec: clusterv1.MachineSpec{
+					ClusterName: cluster.Name,
+					Bootstrap: clusterv1.Bootstrap{
+						ConfigRef: &corev1.ObjectReference{
+							APIVersion: "bootstrap.cluster.x-k8s.io/v1alpha3",
+							Kind:       "BootstrapConfig",
+							Name:       "bootstrap-config1",
+						},
+					},
+					InfrastructureRef: corev1.ObjectReference{
+						APIVersion: "infrastructure.cluster.x-k8s.io/v1alpha3",
+						Kind:       "InfrastructureConfig",
+						Name:       "infra-config1",
+					},
+				},
+			},
+		},
+	}
+
+	bootstrapConfig := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"kind":       "BootstrapConfig",
+			"apiVersion": "bootstrap.cluster.x-k8s.io/v1alpha3",
+			"metadata": map[string]interface{}{
+				"name":      "bootstrap-config1",
+				"namespace": "default",
+			},
+			"spec": map[string]interface{}{},
+			"status": map[string]interface{}{
+				"ready":          true,
+				"dataSecretName": "secret-data",
+			},
+		},
+	}
+
+	infraConfig := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"kind":       "InfrastructureConfig",
+			"apiVersion": "infrastructure.cluster.x-k8s.io/v1alpha3",
+			"metadata": map[string]interface{}{
+				"name":      "infra-config1",
+				"namespace": "default",
+			},
+			"spec": map[string]interface{}{
+				"providerIDList": []interface{}{"aws:///id-node-1", "aws:///id-node-2"},
+			},
+			"status": map[string]interface{}{
+				"ready":    true,
+				"replicas": int64(2),
+			},
+		},
+	}
+
+	r := &MachinePoolReconciler{
+		Client:   fake.NewFakeClientWithScheme(scheme.Scheme, cluster, mp, bootstrapConfig, infraConfig),
+		Log:      log.Log,
+		recorder: record.NewFakeRecorder(32),
+		scheme:   scheme.Scheme,
+	}
+
+	g.Expect(r.reconcileBootstrap(context.Background(), cluster, mp)).To(Succeed())
+	g.Expect(mp.Status.BootstrapReady).To(BeTrue())
+	g.Expect(mp.Spec.Template.Spec.Bootstrap.DataSecretName).To(Equal(pointer.StringPtr("secret-data")))
+
+	g.Expect(r.reconcileInfrastructure(context.Background(), cluster, mp)).To(Succeed())
+	g.Expect(mp.Status.InfrastructureReady).To(BeTrue())
+	g.Expect(mp.Status.Replicas).To(BeEquivalentTo(2))
+	g.Expect(mp.Spec.ProviderIDs).To(Equal([]string{"aws:///id-node-1", "aws:///id-node-2"}))
+	g.Expect(mp.Status.UnavailableReplicas).To(BeEquivalentTo(2))
+
+	// The external objects are owned by the MachinePool and have the cluster name label.
+	for _, obj := range []*unstructured.Unstructured{bootstrapConfig, infraConfig} {
+		g.Expect(r.Client.Get(context.Background(), types.NamespacedName{Name: obj.GetName(), Namespace: obj.GetNamespace()}, obj)).To(Succeed())
+		g.Expect(obj.GetOwnerReferences()).To(HaveLen(1))
+		g.Expect(obj.GetLabels()[clusterv1.ClusterLabelName]).To(Equal(cluster.Name))
+	}
+}
@@ -35,9 +35,12 @@ import (
 	"k8s.io/client-go/tools/record"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
 	"sigs.k8s.io/cluster-api/controllers/external"
+	"sigs.k8s.io/cluster-api/controllers/mdutil"
 	"sigs.k8s.io/cluster-api/controllers/noderefutil"
 	"sigs.k8s.io/cluster-api/controllers/remote"
 	"sigs.k8s.io/cluster-api/util"
+	"sigs.k8s.io/cluster-api/util/fairness"
+	"sigs.k8s.io/cluster-api/util/guardrails"
 	"sigs.k8s.io/cluster-api/util/patch"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -69,8 +72,16 @@ type MachineSetReconciler struct {
 	Client client.Client
 	Log    logr.Logger
 
-	recorder record.EventRecorder
-	scheme   *runtime.Scheme
+	// ClusterLimiter, if set, rate limits the reconciliations of the MachineSets of each cluster.
+	ClusterLimiter *fairness.ClusterLimiter
+
+	// CreationLimiter, if set, caps the number of Machines a single MachineSet is allowed to create
+	// within a time window.
+	CreationLimiter *guardrails.CreationLimiter
+
+	recorder           record.EventRecorder
+	scheme             *runtime.Scheme
+	remoteClientGetter remote.ClusterClientGetter
 }
 
 func (r *MachineSetReconciler) SetupWithManager(mgr ctrl.Manager, options controller.Options) error {
@@ -82,7 +93,7 @@ func (r *MachineSetReconciler) SetupWithManager(mgr ctrl.Manager, options contro
 			&handler.EnqueueRequestsFromMapFunc{ToRequests: handler.ToRequestsFunc(r.MachineToMachineSets)},
 		).
 		WithOptions(options).
-		Complete(r)
+		Complete(fairness.NewReconciler(mgr.GetClient(), &clusterv1.MachineSet{}, "machineset", r.ClusterLimiter, r))
 
 	if err != nil {
 		return errors.Wrap(err, "failed setting up with a controller manager")
@@ -90,6 +101,9 @@ func (r *MachineSetReconciler) SetupWithManager(mgr ctrl.Manager, options contro
 
 	r.recorder = mgr.GetEventRecorderFor("machineset-controller")
 	r.scheme = mgr.GetScheme()
+	if r.remoteClientGetter == nil {
+		r.remoteClientGetter = remote.NewClusterClient
+	}
 	return nil
 }
 
@@ -142,6 +156,9 @@ func (r *MachineSetReconciler) reconcile(ctx context.Context, cluster *clusterv1
 		machineSet.Labels = make(map[string]string)
 	}
 	machineSet.Labels[clusterv1.ClusterLabelName] = machineSet.Spec.ClusterName
+	for key, value := range util.GetPropagatedLabels(cluster) {
+		machineSet.Labels[key] = value
+	}
 
 	if r.shouldAdopt(machineSet) {
 		patch := client.MergeFrom(machineSet.DeepCopy())
@@ -173,6 +190,19 @@ func (r *MachineSetReconciler) reconcile(ctx context.Context, cluster *clusterv1
 		}
 	}
 
+	// Describe the Nodes of the MachineSet to the cluster-autoscaler, so it can scale the MachineSet from zero replicas.
+	autoscalerAnnotations, err := getAutoscalerAnnotations(ctx, r.Client, machineSet.Namespace, &machineSet.Spec.Template)
+	if err != nil {
+		return ctrl.Result{}, errors.Wrapf(err, "failed to compute the autoscaler annotations of MachineSet %q", machineSet.Name)
+	}
+	annotationsPatch := client.MergeFrom(machineSet.DeepCopy())
+	if setAutoscalerAnnotations(machineSet, autoscalerAnnotations) {
+		// Patch using a deep copy to avoid overwriting any unexpected Status changes from the returned result
+		if err := r.Client.Patch(ctx, machineSet.DeepCopy(), annotationsPatch); err != nil {
+			return ctrl.Result{}, errors.Wrapf(err, "failed to set the autoscaler annotations of MachineSet %q", machineSet.Name)
+		}
+	}
+
 	// Make sure selector and template to be in the same cluster.
 	machineSet.Spec.Selector.MatchLabels[clusterv1.ClusterLabelName] = machineSet.Spec.ClusterName
 	machineSet.Spec.Template.Labels[clusterv1.ClusterLabelName] = machineSet.Spec.ClusterName
@@ -219,7 +249,12 @@ func (r *MachineSetReconciler) reconcile(ctx context.Context, cluster *clusterv1
 		filteredMachines = append(filteredMachines, machine)
 	}
 
-	syncErr := r.syncReplicas(ctx, machineSet, filteredMachines)
+	// Propagate the changes to the template fields updated in place to the existing Machines.
+	if err := r.syncMachinesInPlace(ctx, machineSet, filteredMachines); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	syncErr := r.syncReplicas(ctx, cluster, machineSet, filteredMachines)
 
 	ms := machineSet.DeepCopy()
 	newStatus, err := r.calculateStatus(ctx, cluster, ms, filteredMachines)
@@ -297,7 +332,7 @@ func (r *MachineSetReconciler) reconcileExternalReference(ctx context.Context, c
 }
 
 // syncReplicas scales Machine resources up or down.
-func (r *MachineSetReconciler) syncReplicas(ctx context.Context, ms *clusterv1.MachineSet, machines []*clusterv1.Machine) error {
+func (r *MachineSetReconciler) syncReplicas(ctx context.Context, cluster *clusterv1.Cluster, ms *clusterv1.MachineSet, machines []*clusterv1.Machine) error {
 	logger := r.Log.WithValues("machineset", ms.Name, "namespace", ms.Namespace)
 	if ms.Spec.Replicas == nil {
 		return errors.Errorf("the Replicas field in Spec for machineset %v is nil, this should not be allowed", ms.Name)
@@ -307,8 +342,21 @@ func (r *MachineSetReconciler) syncReplicas(ctx context.Context, ms *clusterv1.M
 
 	if diff < 0 {
 		diff *= -1
+		if _, ok := ms.Annotations[clusterv1.DisableMachineCreateAnnotation]; ok {
+			logger.V(2).Info("Too few replicas, but machine creation is disabled", "need", *(ms.Spec.Replicas), "missing", diff)
+			return nil
+		}
 		logger.Info("Too few replicas", "need", *(ms.Spec.Replicas), "creating", diff)
 
+		if allowed := r.CreationLimiter.Allow(ms.UID, diff); allowed < diff {
+			logger.Info("Machine creation limit exceeded, throttling scale up",
+				"limit", r.CreationLimiter.Limit(), "window", r.CreationLimiter.Window(), "requested", diff, "allowed", allowed)
+			r.recorder.Eventf(ms, corev1.EventTypeWarning, "CreationLimitExceeded",
+				"Requested %d new machines, but only %d machines can be created every %s; creating %d machines",
+				diff, r.CreationLimiter.Limit(), r.CreationLimiter.Window(), allowed)
+			diff = allowed
+		}
+
 		var machineList []*clusterv1.Machine
 		var errstrings []string
 		for i := 0; i < diff; i++ {
@@ -373,6 +421,7 @@ func (r *MachineSetReconciler) syncReplicas(ctx context.Context, ms *clusterv1.M
 				}
 				continue
 			}
+			r.CreationLimiter.Record(ms.UID)
 			logger.Info(fmt.Sprintf("Created machine %d of %d with name %q", i+1, diff, machine.Name))
 			r.recorder.Eventf(ms, corev1.EventTypeNormal, "SuccessfulCreate", "Created machine %q", machine.Name)
 
@@ -391,6 +440,14 @@ func (r *MachineSetReconciler) syncReplicas(ctx context.Context, ms *clusterv1.M
 		if err != nil {
 			return err
 		}
+		if clusterv1.MachineSetDeletePolicy(ms.Spec.DeletePolicy) == clusterv1.LeastUtilizedMachineSetDeletePolicy {
+			utilization, err := r.getNodeUtilization(ctx, cluster)
+			if err != nil {
+				logger.Error(err, "Failed to retrieve the Node utilization, falling back to the Random delete policy")
+			} else {
+				deletePriorityFunc = leastUtilizedDeletePriority(utilization)
+			}
+		}
 		logger.Info("Found delete policy", "delete-policy", ms.Spec.DeletePolicy)
 		// Choose which Machines to delete.
 		machinesToDelete := getMachinesToDeletePrioritized(machines, diff, deletePriorityFunc)
@@ -453,6 +510,22 @@ func (r *MachineSetReconciler) getNewMachine(machineSet *clusterv1.MachineSet) *
 	return machine
 }
 
+// syncMachinesInPlace updates the fields of the Machines that can be changed without replacing them, i.e. the
+// labels and annotations synced to the Nodes and the Node taints, to the ones of the MachineSet template.
+func (r *MachineSetReconciler) syncMachinesInPlace(ctx context.Context, machineSet *clusterv1.MachineSet, machines []*clusterv1.Machine) error {
+	var errs []error
+	for _, machine := range machines {
+		patch := client.MergeFrom(machine.DeepCopy())
+		if !mdutil.SyncMachineInPlaceMutableFields(machine, &machineSet.Spec.Template) {
+			continue
+		}
+		if err := r.Client.Patch(ctx, machine, patch); err != nil {
+			errs = append(errs, errors.Wrapf(err, "failed to update Machine %q in place", machine.Name))
+		}
+	}
+	return kerrors.NewAggregate(errs)
+}
+
 // shouldExcludeMachine returns true if the machine should be filtered out, false otherwise.
 func shouldExcludeMachine(machineSet *clusterv1.MachineSet, machine *clusterv1.Machine, logger logr.Logger) bool {
 	if metav1.GetControllerOf(machine) != nil && !metav1.IsControlledBy(machine, machineSet) {
@@ -708,6 +781,26 @@ func (r *MachineSetReconciler) patchMachineSetStatus(ctx context.Context, ms *cl
 	return ms, nil
 }
 
+// getNodeUtilization lists the Nodes and the Pods in the workload cluster and computes the utilization of each Node.
+func (r *MachineSetReconciler) getNodeUtilization(ctx context.Context, cluster *clusterv1.Cluster) (map[string]nodeUtilization, error) {
+	if cluster == nil {
+		return nil, errors.New("cluster is required to compute the Node utilization")
+	}
+	c, err := r.remoteClientGetter(ctx, r.Client, cluster, r.scheme)
+	if err != nil {
+		return nil, err
+	}
+	nodes := &corev1.NodeList{}
+	if err := c.List(ctx, nodes); err != nil {
+		return nil, errors.Wrapf(err, "error listing nodes in cluster %s/%s", cluster.Namespace, cluster.Name)
+	}
+	pods := &corev1.PodList{}
+	if err := c.List(ctx, pods); err != nil {
+		return nil, errors.Wrapf(err, "error listing pods in cluster %s/%s", cluster.Namespace, cluster.Name)
+	}
+	return calculateNodeUtilization(nodes.Items, pods.Items), nil
+}
+
 func (r *MachineSetReconciler) getMachineNode(ctx context.Context, cluster *clusterv1.Cluster, machine *clusterv1.Machine) (*corev1.Node, error) {
 	c, err := remote.NewClusterClient(ctx, r.Client, cluster, r.scheme)
 	if err != nil {
@@ -27,10 +27,12 @@ import (
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/tools/record"
 	"k8s.io/klog/klogr"
+	"k8s.io/utils/pointer"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
@@ -39,7 +41,9 @@ import (
 
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
 	"sigs.k8s.io/cluster-api/controllers/external"
+	fakeremote "sigs.k8s.io/cluster-api/controllers/remote/fake"
 	"sigs.k8s.io/cluster-api/util"
+	"sigs.k8s.io/cluster-api/util/guardrails"
 	"sigs.k8s.io/cluster-api/util/kubeconfig"
 )
 
@@ -426,6 +430,102 @@ func TestMachineSetReconcile(t *testing.T) {
 	})
 }
 
+func TestMachineSetSyncReplicasCreationLimitExceeded(t *testing.T) {
+	g := NewWithT(t)
+
+	ms := newMachineSet("machineset1", "test-cluster")
+	ms.UID = "machineset1-uid"
+	ms.Spec.Replicas = pointer.Int32Ptr(3)
+
+	g.Expect(clusterv1.AddToScheme(scheme.Scheme)).To(Succeed())
+
+	c := fake.NewFakeClientWithScheme(scheme.Scheme, ms)
+	limiter := guardrails.NewCreationLimiter(2, time.Hour)
+	limiter.Record(ms.UID)
+	limiter.Record(ms.UID)
+
+	rec := record.NewFakeRecorder(32)
+	msr := &MachineSetReconciler{
+		Client:          c,
+		Log:             log.Log,
+		CreationLimiter: limiter,
+		recorder:        rec,
+	}
+	g.Expect(msr.syncReplicas(context.Background(), nil, ms, nil)).To(Succeed())
+
+	machines := &clusterv1.MachineList{}
+	g.Expect(c.List(context.Background(), machines, client.InNamespace(ms.Namespace))).To(Succeed())
+	g.Expect(machines.Items).To(BeEmpty())
+	g.Expect(rec.Events).To(Receive(ContainSubstring("CreationLimitExceeded")))
+}
+
+func TestMachineSetSyncReplicasMachineCreateDisabled(t *testing.T) {
+	g := NewWithT(t)
+
+	ms := newMachineSet("machineset1", "test-cluster")
+	ms.Spec.Replicas = pointer.Int32Ptr(3)
+	ms.Annotations = map[string]string{clusterv1.DisableMachineCreateAnnotation: "true"}
+
+	g.Expect(clusterv1.AddToScheme(scheme.Scheme)).To(Succeed())
+
+	c := fake.NewFakeClientWithScheme(scheme.Scheme, ms)
+	msr := &MachineSetReconciler{
+		Client:   c,
+		Log:      log.Log,
+		recorder: record.NewFakeRecorder(32),
+	}
+	g.Expect(msr.syncReplicas(context.Background(), nil, ms, nil)).To(Succeed())
+
+	machines := &clusterv1.MachineList{}
+	g.Expect(c.List(context.Background(), machines, client.InNamespace(ms.Namespace))).To(Succeed())
+	g.Expect(machines.Items).To(BeEmpty())
+}
+
+func TestMachineSetSyncReplicasLeastUtilized(t *testing.T) {
+	g := NewWithT(t)
+
+	g.Expect(clusterv1.AddToScheme(scheme.Scheme)).To(Succeed())
+
+	cluster := &clusterv1.Cluster{ObjectMeta: metav1.ObjectMeta{Name: "test-cluster", Namespace: "default"}}
+	ms := newMachineSet("machineset1", cluster.Name)
+	ms.Spec.Replicas = pointer.Int32Ptr(1)
+	ms.Spec.DeletePolicy = string(clusterv1.LeastUtilizedMachineSetDeletePolicy)
+
+	machineOnNode := func(name, nodeName string) *clusterv1.Machine {
+		return &clusterv1.Machine{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: ms.Namespace},
+			Status:     clusterv1.MachineStatus{NodeRef: &corev1.ObjectReference{Name: nodeName}},
+		}
+	}
+	busyMachine := machineOnNode("busy-machine", "busy-node")
+	emptyMachine := machineOnNode("empty-machine", "empty-node")
+
+	objs := []runtime.Object{
+		ms,
+		busyMachine,
+		emptyMachine,
+		&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "busy-node"}},
+		&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "empty-node"}},
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "workload", Namespace: "default"},
+			Spec:       corev1.PodSpec{NodeName: "busy-node"},
+		},
+	}
+
+	c := fake.NewFakeClientWithScheme(scheme.Scheme, objs...)
+	msr := &MachineSetReconciler{
+		Client:             c,
+		Log:                log.Log,
+		recorder:           record.NewFakeRecorder(32),
+		scheme:             scheme.Scheme,
+		remoteClientGetter: fakeremote.NewClusterClient,
+	}
+	g.Expect(msr.syncReplicas(context.Background(), cluster, ms, []*clusterv1.Machine{busyMachine, emptyMachine})).To(Succeed())
+
+	g.Expect(c.Get(context.Background(), client.ObjectKey{Namespace: ms.Namespace, Name: emptyMachine.Name}, &clusterv1.Machine{})).ToNot(Succeed())
+	g.Expect(c.Get(context.Background(), client.ObjectKey{Namespace: ms.Namespace, Name: busyMachine.Name}, &clusterv1.Machine{})).To(Succeed())
+}
+
 func TestMachineSetToMachines(t *testing.T) {
 	g := NewWithT(t)
 
@@ -682,6 +782,49 @@ func TestAdoptOrphan(t *testing.T) {
 	}
 }
 
+func TestSyncMachinesInPlace(t *testing.T) {
+	g := NewWithT(t)
+
+	ctx := context.Background()
+	m := &clusterv1.Machine{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   "machine",
+			Labels: map[string]string{"env": "prod", "node.cluster.x-k8s.io/gpu": "true"},
+		},
+	}
+	ms := &clusterv1.MachineSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "machineset",
+		},
+		Spec: clusterv1.MachineSetSpec{
+			Template: clusterv1.MachineTemplateSpec{
+				ObjectMeta: clusterv1.ObjectMeta{
+					Labels:      map[string]string{"env": "dev", "node-role.kubernetes.io/infra": ""},
+					Annotations: map[string]string{"node.cluster.x-k8s.io/owner": "team-a"},
+				},
+				Spec: clusterv1.MachineSpec{
+					NodeTaints: []corev1.Taint{{Key: "dedicated", Value: "infra", Effect: corev1.TaintEffectNoSchedule}},
+				},
+			},
+		},
+	}
+
+	g.Expect(clusterv1.AddToScheme(scheme.Scheme)).To(Succeed())
+
+	r := &MachineSetReconciler{
+		Client: fake.NewFakeClientWithScheme(scheme.Scheme, m),
+		Log:    log.Log,
+	}
+	g.Expect(r.syncMachinesInPlace(ctx, ms, []*clusterv1.Machine{m.DeepCopy()})).To(Succeed())
+
+	got := &clusterv1.Machine{}
+	g.Expect(r.Client.Get(ctx, client.ObjectKey{Name: "machine"}, got)).To(Succeed())
+	// Only the labels and annotations synced to the Nodes are updated in place.
+	g.Expect(got.Labels).To(Equal(map[string]string{"env": "prod", "node-role.kubernetes.io/infra": ""}))
+	g.Expect(got.Annotations).To(Equal(map[string]string{"node.cluster.x-k8s.io/owner": "team-a"}))
+	g.Expect(got.Spec.NodeTaints).To(Equal(ms.Spec.Template.Spec.NodeTaints))
+}
+
 func TestHasMatchingLabels(t *testing.T) {
 	r := &MachineSetReconciler{
 		Log: klogr.New(),
@@ -21,6 +21,7 @@ import (
 	"sort"
 
 	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
 )
@@ -33,6 +34,9 @@ type (
 const (
 	// DeleteNodeAnnotation marks nodes that will be given priority for deletion
 	// when a machineset scales down. This annotation is given top priority on all delete policies.
+	//
+	// Deprecated: use clusterv1.DeleteMachineAnnotation; this annotation is still honored for compatibility
+	// with the cluster-autoscaler versions using it.
 	DeleteNodeAnnotation = "cluster.k8s.io/delete-machine"
 
 	mustDelete    deletePriority = 100.0
@@ -43,12 +47,18 @@ const (
 	secondsPerTenDays float64 = 864000
 )
 
+// isDeleteMachineAnnotated returns true if the machine has been marked for deletion by an operator or by the
+// cluster-autoscaler, using either clusterv1.DeleteMachineAnnotation or the deprecated DeleteNodeAnnotation.
+func isDeleteMachineAnnotated(machine *clusterv1.Machine) bool {
+	return machine.Annotations[clusterv1.DeleteMachineAnnotation] != "" || machine.Annotations[DeleteNodeAnnotation] != ""
+}
+
 // maps the creation timestamp onto the 0-100 priority range
 func oldestDeletePriority(machine *clusterv1.Machine) deletePriority {
 	if !machine.DeletionTimestamp.IsZero() {
 		return mustDelete
 	}
-	if machine.ObjectMeta.Annotations != nil && machine.ObjectMeta.Annotations[DeleteNodeAnnotation] != "" {
+	if isDeleteMachineAnnotated(machine) {
 		return mustDelete
 	}
 	if machine.Status.FailureReason != nil || machine.Status.FailureMessage != nil {
@@ -68,7 +78,7 @@ func newestDeletePriority(machine *clusterv1.Machine) deletePriority {
 	if !machine.DeletionTimestamp.IsZero() {
 		return mustDelete
 	}
-	if machine.ObjectMeta.Annotations != nil && machine.ObjectMeta.Annotations[DeleteNodeAnnotation] != "" {
+	if isDeleteMachineAnnotated(machine) {
 		return mustDelete
 	}
 	if machine.Status.FailureReason != nil || machine.Status.FailureMessage != nil {
@@ -81,8 +91,8 @@ func randomDeletePolicy(machine *clusterv1.Machine) deletePriority {
 	if !machine.DeletionTimestamp.IsZero() {
 		return mustDelete
 	}
-	if machine.ObjectMeta.Annotations != nil && machine.ObjectMeta.Annotations[DeleteNodeAnnotation] != "" {
-		return betterDelete
+	if isDeleteMachineAnnotated(machine) {
+		return mustDelete
 	}
 	if machine.Status.FailureReason != nil || machine.Status.FailureMessage != nil {
 		return betterDelete
@@ -90,6 +100,100 @@ func randomDeletePolicy(machine *clusterv1.Machine) deletePriority {
 	return couldDelete
 }
 
+// nodeUtilization describes how busy the Node of a Machine is, as observed in the workload cluster.
+type nodeUtilization struct {
+	// pods is the number of Pods running on the Node, not counting DaemonSet and mirror Pods,
+	// which do not need to be rescheduled when the Node goes away.
+	pods int
+	// requests is the largest of the CPU and memory requests of those Pods, as a fraction
+	// of the Node's allocatable resources; it ranges from 0 to 1.
+	requests float64
+}
+
+// leastUtilizedDeletePriority returns a delete priority function preferring the Machines whose Nodes are running
+// the fewest Pods, using the resources requested on the Nodes to break ties.
+// Machines without a Node, or whose Node is unknown, are considered empty.
+func leastUtilizedDeletePriority(utilization map[string]nodeUtilization) deletePriorityFunc {
+	return func(machine *clusterv1.Machine) deletePriority {
+		if !machine.DeletionTimestamp.IsZero() {
+			return mustDelete
+		}
+		if isDeleteMachineAnnotated(machine) {
+			return mustDelete
+		}
+		if machine.Status.FailureReason != nil || machine.Status.FailureMessage != nil {
+			return betterDelete
+		}
+		if machine.Status.NodeRef == nil {
+			return couldDelete
+		}
+		u, ok := utilization[machine.Status.NodeRef.Name]
+		if !ok {
+			return couldDelete
+		}
+		return deletePriority(float64(couldDelete) / (1.0 + float64(u.pods) + u.requests))
+	}
+}
+
+// calculateNodeUtilization computes the utilization of each Node from the Pods scheduled on it.
+func calculateNodeUtilization(nodes []corev1.Node, pods []corev1.Pod) map[string]nodeUtilization {
+	cpuRequests := map[string]int64{}
+	memoryRequests := map[string]int64{}
+	utilization := make(map[string]nodeUtilization, len(nodes))
+	for i := range nodes {
+		utilization[nodes[i].Name] = nodeUtilization{}
+	}
+
+	for i := range pods {
+		pod := &pods[i]
+		u, ok := utilization[pod.Spec.NodeName]
+		if !ok || isTerminalOrNodeBoundPod(pod) {
+			continue
+		}
+		u.pods++
+		utilization[pod.Spec.NodeName] = u
+		for _, container := range pod.Spec.Containers {
+			cpuRequests[pod.Spec.NodeName] += container.Resources.Requests.Cpu().MilliValue()
+			memoryRequests[pod.Spec.NodeName] += container.Resources.Requests.Memory().Value()
+		}
+	}
+
+	for i := range nodes {
+		node := &nodes[i]
+		u := utilization[node.Name]
+		u.requests = math.Max(
+			requestsFraction(cpuRequests[node.Name], node.Status.Allocatable.Cpu().MilliValue()),
+			requestsFraction(memoryRequests[node.Name], node.Status.Allocatable.Memory().Value()),
+		)
+		utilization[node.Name] = u
+	}
+	return utilization
+}
+
+// isTerminalOrNodeBoundPod returns true for the Pods that don't have to be moved elsewhere when their Node is deleted:
+// completed Pods, mirror Pods and Pods managed by a DaemonSet.
+func isTerminalOrNodeBoundPod(pod *corev1.Pod) bool {
+	if pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+		return true
+	}
+	if _, ok := pod.Annotations[corev1.MirrorPodAnnotationKey]; ok {
+		return true
+	}
+	for _, ref := range pod.OwnerReferences {
+		if ref.Controller != nil && *ref.Controller && ref.Kind == "DaemonSet" {
+			return true
+		}
+	}
+	return false
+}
+
+func requestsFraction(requested, allocatable int64) float64 {
+	if allocatable <= 0 {
+		return 0
+	}
+	return math.Min(float64(requested)/float64(allocatable), 1.0)
+}
+
 type sortableMachines struct {
 	machines []*clusterv1.Machine
 	priority deletePriorityFunc
@@ -126,9 +230,13 @@ func getDeletePriorityFunc(ms *clusterv1.MachineSet) (deletePriorityFunc, error)
 		return newestDeletePriority, nil
 	case clusterv1.OldestMachineSetDeletePolicy:
 		return oldestDeletePriority, nil
+	case clusterv1.LeastUtilizedMachineSetDeletePolicy:
+		// The Node utilization is only known to the reconciler, which replaces this function
+		// with leastUtilizedDeletePriority when the workload cluster can be queried.
+		return randomDeletePolicy, nil
 	case "":
 		return randomDeletePolicy, nil
 	default:
-		return nil, errors.Errorf("Unsupported delete policy %s. Must be one of 'Random', 'Newest', or 'Oldest'", msdp)
+		return nil, errors.Errorf("Unsupported delete policy %s. Must be one of 'Random', 'Newest', 'Oldest', or 'LeastUtilized'", msdp)
 	}
 }
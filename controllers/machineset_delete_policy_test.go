@@ -20,7 +20,10 @@ import (
 	"testing"
 
 	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
 
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
 	capierrors "sigs.k8s.io/cluster-api/errors"
@@ -32,6 +35,7 @@ func TestMachineToDelete(t *testing.T) {
 	mustDeleteMachine := &clusterv1.Machine{ObjectMeta: metav1.ObjectMeta{DeletionTimestamp: &now}}
 	betterDeleteMachine := &clusterv1.Machine{Status: clusterv1.MachineStatus{FailureMessage: &msg}}
 	deleteMeMachine := &clusterv1.Machine{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{DeleteNodeAnnotation: "yes"}}}
+	deleteMachineAnnotatedMachine := &clusterv1.Machine{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{clusterv1.DeleteMachineAnnotation: "yes"}}}
 
 	tests := []struct {
 		desc     string
@@ -149,6 +153,30 @@ func TestMachineToDelete(t *testing.T) {
 			expect: []*clusterv1.Machine{
 				deleteMeMachine,
 			},
+		},
+		{
+			desc: "func=randomDeletePolicy, delete-machine annotated, diff=1",
+			diff: 1,
+			machines: []*clusterv1.Machine{
+				{},
+				deleteMachineAnnotatedMachine,
+				{},
+			},
+			expect: []*clusterv1.Machine{
+				deleteMachineAnnotatedMachine,
+			},
+		},
+		{
+			desc: "func=randomDeletePolicy, annotated preferred to unhealthy, diff=1",
+			diff: 1,
+			machines: []*clusterv1.Machine{
+				betterDeleteMachine,
+				deleteMachineAnnotatedMachine,
+				betterDeleteMachine,
+			},
+			expect: []*clusterv1.Machine{
+				deleteMachineAnnotatedMachine,
+			},
 		}}
 
 	for _, test := range tests {
@@ -302,3 +330,144 @@ func TestMachineOldestDelete(t *testing.T) {
 		g.Expect(result).To(Equal(test.expect))
 	}
 }
+
+func TestMachineLeastUtilizedDelete(t *testing.T) {
+	statusError := capierrors.MachineStatusError("I'm unhealthy!")
+	withNode := func(name string) *clusterv1.Machine {
+		return &clusterv1.Machine{Status: clusterv1.MachineStatus{NodeRef: &corev1.ObjectReference{Name: name}}}
+	}
+	noNode := &clusterv1.Machine{}
+	empty := withNode("empty")
+	light := withNode("light")
+	busy := withNode("busy")
+	busier := withNode("busier")
+	unknown := withNode("unknown")
+	annotatedMachine := &clusterv1.Machine{
+		ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{clusterv1.DeleteMachineAnnotation: "yes"}},
+		Status:     clusterv1.MachineStatus{NodeRef: &corev1.ObjectReference{Name: "busier"}},
+	}
+	unhealthyMachine := &clusterv1.Machine{
+		Status: clusterv1.MachineStatus{FailureReason: &statusError, NodeRef: &corev1.ObjectReference{Name: "busier"}},
+	}
+
+	utilization := map[string]nodeUtilization{
+		"empty":  {},
+		"light":  {pods: 2, requests: 0.1},
+		"busy":   {pods: 2, requests: 0.8},
+		"busier": {pods: 10, requests: 0.2},
+	}
+
+	tests := []struct {
+		desc     string
+		machines []*clusterv1.Machine
+		diff     int
+		expect   []*clusterv1.Machine
+	}{
+		{
+			desc:     "func=leastUtilizedDeletePriority, diff=1",
+			diff:     1,
+			machines: []*clusterv1.Machine{busier, busy, empty, light},
+			expect:   []*clusterv1.Machine{empty},
+		},
+		{
+			desc:     "func=leastUtilizedDeletePriority, diff=2 (requests break ties)",
+			diff:     2,
+			machines: []*clusterv1.Machine{busier, busy, empty, light},
+			expect:   []*clusterv1.Machine{empty, light},
+		},
+		{
+			desc:     "func=leastUtilizedDeletePriority, diff=3",
+			diff:     3,
+			machines: []*clusterv1.Machine{busier, busy, light, empty},
+			expect:   []*clusterv1.Machine{empty, light, busy},
+		},
+		{
+			desc:     "func=leastUtilizedDeletePriority, diff=1 (machine without a node)",
+			diff:     1,
+			machines: []*clusterv1.Machine{busier, noNode, light},
+			expect:   []*clusterv1.Machine{noNode},
+		},
+		{
+			desc:     "func=leastUtilizedDeletePriority, diff=1 (machine with an unknown node)",
+			diff:     1,
+			machines: []*clusterv1.Machine{busier, light, unknown},
+			expect:   []*clusterv1.Machine{unknown},
+		},
+		{
+			desc:     "func=leastUtilizedDeletePriority, diff=1 (annotated)",
+			diff:     1,
+			machines: []*clusterv1.Machine{empty, light, busy, annotatedMachine},
+			expect:   []*clusterv1.Machine{annotatedMachine},
+		},
+		{
+			desc:     "func=leastUtilizedDeletePriority, diff=1 (unhealthy)",
+			diff:     1,
+			machines: []*clusterv1.Machine{empty, light, busy, unhealthyMachine},
+			expect:   []*clusterv1.Machine{unhealthyMachine},
+		},
+	}
+
+	for _, test := range tests {
+		g := NewWithT(t)
+
+		result := getMachinesToDeletePrioritized(test.machines, test.diff, leastUtilizedDeletePriority(utilization))
+		g.Expect(result).To(Equal(test.expect), test.desc)
+	}
+}
+
+func TestCalculateNodeUtilization(t *testing.T) {
+	g := NewWithT(t)
+
+	node := func(name string) corev1.Node {
+		return corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Status: corev1.NodeStatus{
+				Allocatable: corev1.ResourceList{
+					corev1.ResourceCPU:    resource.MustParse("4"),
+					corev1.ResourceMemory: resource.MustParse("8Gi"),
+				},
+			},
+		}
+	}
+	pod := func(nodeName, cpu, memory string) corev1.Pod {
+		return corev1.Pod{
+			Spec: corev1.PodSpec{
+				NodeName: nodeName,
+				Containers: []corev1.Container{{
+					Resources: corev1.ResourceRequirements{
+						Requests: corev1.ResourceList{
+							corev1.ResourceCPU:    resource.MustParse(cpu),
+							corev1.ResourceMemory: resource.MustParse(memory),
+						},
+					},
+				}},
+			},
+			Status: corev1.PodStatus{Phase: corev1.PodRunning},
+		}
+	}
+
+	completed := pod("node-1", "1", "1Gi")
+	completed.Status.Phase = corev1.PodSucceeded
+	mirror := pod("node-1", "1", "1Gi")
+	mirror.Annotations = map[string]string{corev1.MirrorPodAnnotationKey: "mirror"}
+	daemon := pod("node-1", "1", "1Gi")
+	daemon.OwnerReferences = []metav1.OwnerReference{{Kind: "DaemonSet", Name: "ds", Controller: pointer.BoolPtr(true)}}
+
+	utilization := calculateNodeUtilization(
+		[]corev1.Node{node("node-1"), node("node-2"), node("node-3")},
+		[]corev1.Pod{
+			pod("node-1", "1", "1Gi"),
+			pod("node-1", "500m", "3Gi"),
+			completed,
+			mirror,
+			daemon,
+			pod("node-2", "8", "1Gi"),
+			pod("unscheduled", "1", "1Gi"),
+		},
+	)
+
+	g.Expect(utilization).To(HaveLen(3))
+	g.Expect(utilization["node-1"]).To(Equal(nodeUtilization{pods: 2, requests: 0.5}))
+	g.Expect(utilization["node-2"]).To(Equal(nodeUtilization{pods: 1, requests: 1.0}))
+	g.Expect(utilization["node-3"]).To(Equal(nodeUtilization{}))
+}
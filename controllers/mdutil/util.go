@@ -23,6 +23,7 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/davecgh/go-spew/spew"
 	"github.com/go-logr/logr"
@@ -34,6 +35,7 @@ import (
 	intstrutil "k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/utils/integer"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/util"
 )
 
 const (
@@ -392,11 +394,16 @@ func getMachineSetFraction(ms clusterv1.MachineSet, d clusterv1.MachineDeploymen
 }
 
 // EqualMachineTemplate returns true if two given machineTemplateSpec are equal,
-// ignoring the diff in value of Labels["machine-template-hash"], and the version from external references.
+// ignoring the diff in value of Labels["machine-template-hash"], the version from external references,
+// and the fields that are updated in place (see SyncInPlaceMutableFields).
 func EqualMachineTemplate(template1, template2 *clusterv1.MachineTemplateSpec) bool {
 	t1Copy := template1.DeepCopy()
 	t2Copy := template2.DeepCopy()
 
+	// Remove the fields updated in place from the comparison, so changing them does not trigger a rollout.
+	dropInPlaceMutableFields(t1Copy)
+	dropInPlaceMutableFields(t2Copy)
+
 	// Remove `machine-template-hash` from the comparison:
 	// 1. The hash result would be different upon machineTemplateSpec API changes
 	//    (e.g. the addition of a new field will cause the hash code to change)
@@ -418,11 +425,58 @@ func EqualMachineTemplate(template1, template2 *clusterv1.MachineTemplateSpec) b
 	return apiequality.Semantic.DeepEqual(t1Copy, t2Copy)
 }
 
+// dropInPlaceMutableFields removes from a machine template the fields updated in place.
+func dropInPlaceMutableFields(template *clusterv1.MachineTemplateSpec) {
+	for key := range util.GetNodeMetadata(template.Labels) {
+		delete(template.Labels, key)
+	}
+	for key := range util.GetNodeMetadata(template.Annotations) {
+		delete(template.Annotations, key)
+	}
+	template.Spec.NodeTaints = nil
+}
+
+// SyncInPlaceMutableFields sets the fields of the dst machine template that can be changed without replacing the
+// Machines to the ones of the src template, and returns true if dst was changed. The fields are the labels and
+// annotations synced to the Nodes, see util.IsNodeMetadataKey, and the Node taints.
+func SyncInPlaceMutableFields(dst, src *clusterv1.MachineTemplateSpec) bool {
+	var labelsChanged, annotationsChanged bool
+	dst.Labels, labelsChanged = util.SyncNodeMetadata(dst.Labels, src.Labels)
+	dst.Annotations, annotationsChanged = util.SyncNodeMetadata(dst.Annotations, src.Annotations)
+
+	taintsChanged := !apiequality.Semantic.DeepEqual(dst.Spec.NodeTaints, src.Spec.NodeTaints)
+	if taintsChanged {
+		dst.Spec.NodeTaints = append([]v1.Taint(nil), src.Spec.NodeTaints...)
+	}
+	return labelsChanged || annotationsChanged || taintsChanged
+}
+
+// SyncMachineInPlaceMutableFields sets the fields of the Machine that can be changed without replacing it to the ones
+// of the machine template, and returns true if the Machine was changed.
+func SyncMachineInPlaceMutableFields(machine *clusterv1.Machine, template *clusterv1.MachineTemplateSpec) bool {
+	machineTemplate := &clusterv1.MachineTemplateSpec{
+		ObjectMeta: clusterv1.ObjectMeta{
+			Labels:      machine.Labels,
+			Annotations: machine.Annotations,
+		},
+		Spec: clusterv1.MachineSpec{
+			NodeTaints: machine.Spec.NodeTaints,
+		},
+	}
+	if !SyncInPlaceMutableFields(machineTemplate, template) {
+		return false
+	}
+	machine.Labels = machineTemplate.Labels
+	machine.Annotations = machineTemplate.Annotations
+	machine.Spec.NodeTaints = machineTemplate.Spec.NodeTaints
+	return true
+}
+
 // FindNewMachineSet returns the new MS this given deployment targets (the one with the same machine template).
 func FindNewMachineSet(deployment *clusterv1.MachineDeployment, msList []*clusterv1.MachineSet) *clusterv1.MachineSet {
 	sort.Sort(MachineSetsByCreationTimestamp(msList))
 	for i := range msList {
-		if EqualMachineTemplate(&msList[i].Spec.Template, &deployment.Spec.Template) {
+		if EqualMachineTemplate(&msList[i].Spec.Template, &deployment.Spec.Template) && !ShouldRolloutAfter(deployment, msList[i], time.Now()) {
 			// In rare cases, such as after cluster upgrades, Deployment may end up with
 			// having more than one new MachineSets that have the same template,
 			// see https://github.com/kubernetes/kubernetes/issues/40415
@@ -434,6 +488,15 @@ func FindNewMachineSet(deployment *clusterv1.MachineDeployment, msList []*cluste
 	return nil
 }
 
+// ShouldRolloutAfter returns true if the rolloutAfter time of the deployment has been reached at the given time,
+// and the MachineSet was created before it and therefore has to be replaced.
+func ShouldRolloutAfter(deployment *clusterv1.MachineDeployment, ms *clusterv1.MachineSet, now time.Time) bool {
+	if deployment.Spec.RolloutAfter == nil || now.Before(deployment.Spec.RolloutAfter.Time) {
+		return false
+	}
+	return ms.CreationTimestamp.Before(deployment.Spec.RolloutAfter)
+}
+
 // FindOldMachineSets returns the old machine sets targeted by the given Deployment, with the given slice of MSes.
 // Returns two list of machine sets
 //  - the first contains all old machine sets with all non-zero replicas
@@ -537,6 +600,15 @@ func NewMSNewReplicas(deployment *clusterv1.MachineDeployment, allMSs []*cluster
 		// Do not exceed the number of desired replicas.
 		scaleUpCount = integer.Int32Min(scaleUpCount, *(deployment.Spec.Replicas)-*(newMS.Spec.Replicas))
 		return *(newMS.Spec.Replicas) + scaleUpCount, nil
+	case clusterv1.OnDeleteMachineDeploymentStrategyType:
+		// Only replace the machines of the old machine sets which were deleted, without surge.
+		currentMachineCount := GetReplicaCountForMachineSets(allMSs)
+		if currentMachineCount >= *(deployment.Spec.Replicas) {
+			// Cannot scale up.
+			return *(newMS.Spec.Replicas), nil
+		}
+		scaleUpCount := *(deployment.Spec.Replicas) - currentMachineCount
+		return *(newMS.Spec.Replicas) + scaleUpCount, nil
 	default:
 		// Check if we can scale up.
 		maxSurge, err := intstrutil.GetValueFromIntOrPercent(deployment.Spec.Strategy.RollingUpdate.MaxSurge, int(*(deployment.Spec.Replicas)), true)
@@ -703,3 +775,15 @@ func ComputeHash(template *clusterv1.MachineTemplateSpec) uint32 {
 	DeepHashObject(machineTemplateSpecHasher, *template)
 	return machineTemplateSpecHasher.Sum32()
 }
+
+// ComputeRolloutHash returns the hash of the deployment's machine template, salted with its rolloutAfter time
+// if set, so the MachineSet created for a rollout triggered by rolloutAfter does not collide with the existing one.
+func ComputeRolloutHash(deployment *clusterv1.MachineDeployment) uint32 {
+	if deployment.Spec.RolloutAfter == nil {
+		return ComputeHash(&deployment.Spec.Template)
+	}
+	machineTemplateSpecHasher := fnv.New32a()
+	DeepHashObject(machineTemplateSpecHasher, deployment.Spec.Template)
+	fmt.Fprint(machineTemplateSpecHasher, deployment.Spec.RolloutAfter.UTC().Format(time.RFC3339))
+	return machineTemplateSpecHasher.Sum32()
+}
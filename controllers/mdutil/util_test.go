@@ -274,6 +274,42 @@ func TestEqualMachineTemplate(t *testing.T) {
 	}
 }
 
+func TestEqualMachineTemplateIgnoresInPlaceMutableFields(t *testing.T) {
+	former := generateMachineTemplateSpec("foo", map[string]string{"node.cluster.x-k8s.io/owner": "a"}, map[string]string{"something": "else", "node-role.kubernetes.io/infra": ""})
+	latter := generateMachineTemplateSpec("foo", map[string]string{}, map[string]string{"something": "else", "node.cluster.x-k8s.io/gpu": "true"})
+	latter.Spec.NodeTaints = []corev1.Taint{{Key: "dedicated", Value: "gpu", Effect: corev1.TaintEffectNoSchedule}}
+
+	if !EqualMachineTemplate(&former, &latter) {
+		t.Errorf("expected templates differing only in node metadata and taints to be equal")
+	}
+}
+
+func TestSyncInPlaceMutableFields(t *testing.T) {
+	dst := generateMachineTemplateSpec("foo", map[string]string{"note": "kept"}, map[string]string{DefaultMachineDeploymentUniqueLabelKey: "hash", "node-role.kubernetes.io/infra": ""})
+	src := generateMachineTemplateSpec("foo", map[string]string{"node.cluster.x-k8s.io/owner": "a"}, map[string]string{"something": "else", "node.cluster.x-k8s.io/gpu": "true"})
+	src.Spec.NodeTaints = []corev1.Taint{{Key: "dedicated", Value: "gpu", Effect: corev1.TaintEffectNoSchedule}}
+
+	if !SyncInPlaceMutableFields(&dst, &src) {
+		t.Fatalf("expected the template to be changed")
+	}
+
+	expectedLabels := map[string]string{DefaultMachineDeploymentUniqueLabelKey: "hash", "node.cluster.x-k8s.io/gpu": "true"}
+	if !reflect.DeepEqual(dst.Labels, expectedLabels) {
+		t.Errorf("expected labels %v, got %v", expectedLabels, dst.Labels)
+	}
+	expectedAnnotations := map[string]string{"note": "kept", "node.cluster.x-k8s.io/owner": "a"}
+	if !reflect.DeepEqual(dst.Annotations, expectedAnnotations) {
+		t.Errorf("expected annotations %v, got %v", expectedAnnotations, dst.Annotations)
+	}
+	if !reflect.DeepEqual(dst.Spec.NodeTaints, src.Spec.NodeTaints) {
+		t.Errorf("expected taints %v, got %v", src.Spec.NodeTaints, dst.Spec.NodeTaints)
+	}
+
+	if SyncInPlaceMutableFields(&dst, &src) {
+		t.Errorf("expected the template not to be changed when already in sync")
+	}
+}
+
 func TestFindNewMachineSet(t *testing.T) {
 	now := metav1.Now()
 	later := metav1.Time{Time: now.Add(time.Minute)}
@@ -327,6 +363,73 @@ func TestFindNewMachineSet(t *testing.T) {
 	}
 }
 
+func TestFindNewMachineSetWithRolloutAfter(t *testing.T) {
+	now := metav1.Now()
+	before := metav1.Time{Time: now.Add(-2 * time.Minute)}
+	after := metav1.Time{Time: now.Add(-time.Minute)}
+
+	deployment := generateDeployment("nginx")
+	oldMS := generateMS(deployment)
+	oldMS.CreationTimestamp = before
+
+	newMS := generateMS(deployment)
+	newMS.CreationTimestamp = now
+
+	tests := []struct {
+		Name         string
+		rolloutAfter *metav1.Time
+		msList       []*clusterv1.MachineSet
+		expected     *clusterv1.MachineSet
+	}{
+		{
+			Name:         "Get the MachineSet created before rolloutAfter if rolloutAfter is not reached",
+			rolloutAfter: &metav1.Time{Time: now.Add(time.Hour)},
+			msList:       []*clusterv1.MachineSet{&oldMS},
+			expected:     &oldMS,
+		},
+		{
+			Name:         "Get nil new MachineSet if all MachineSets were created before the reached rolloutAfter",
+			rolloutAfter: &after,
+			msList:       []*clusterv1.MachineSet{&oldMS},
+			expected:     nil,
+		},
+		{
+			Name:         "Get the MachineSet created after the reached rolloutAfter",
+			rolloutAfter: &after,
+			msList:       []*clusterv1.MachineSet{&oldMS, &newMS},
+			expected:     &newMS,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			d := deployment.DeepCopy()
+			d.Spec.RolloutAfter = test.rolloutAfter
+			if ms := FindNewMachineSet(d, test.msList); !reflect.DeepEqual(ms, test.expected) {
+				t.Errorf("In test case %q, expected %#v, got %#v", test.Name, test.expected, ms)
+			}
+		})
+	}
+}
+
+func TestComputeRolloutHash(t *testing.T) {
+	deployment := generateDeployment("nginx")
+	if ComputeRolloutHash(&deployment) != ComputeHash(&deployment.Spec.Template) {
+		t.Errorf("expected the rollout hash to match the template hash when rolloutAfter is not set")
+	}
+
+	first := deployment.DeepCopy()
+	first.Spec.RolloutAfter = &metav1.Time{Time: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)}
+	second := deployment.DeepCopy()
+	second.Spec.RolloutAfter = &metav1.Time{Time: time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC)}
+	if ComputeRolloutHash(first) == ComputeRolloutHash(second) {
+		t.Errorf("expected different rollout hashes for different rolloutAfter times")
+	}
+	if ComputeRolloutHash(first) != ComputeRolloutHash(first.DeepCopy()) {
+		t.Errorf("expected the rollout hash to be stable")
+	}
+}
+
 func TestFindOldMachineSets(t *testing.T) {
 	now := metav1.Now()
 	later := metav1.Time{Time: now.Add(time.Minute)}
@@ -537,6 +640,16 @@ func TestNewMSNewReplicas(t *testing.T) {
 			clusterv1.RollingUpdateMachineDeploymentStrategyType,
 			6, 2, 10, 6,
 		},
+		{
+			"on delete - scale up to replace the deleted machines only",
+			clusterv1.OnDeleteMachineDeploymentStrategyType,
+			6, 0, 10, 1,
+		},
+		{
+			"on delete - can not scale up",
+			clusterv1.OnDeleteMachineDeploymentStrategyType,
+			5, 0, 10, 0,
+		},
 	}
 	newDeployment := generateDeployment("nginx")
 	newRC := generateMS(newDeployment)
@@ -19,11 +19,17 @@ limitations under the License.
 package metrics
 
 import (
+	"time"
+
 	"github.com/prometheus/client_golang/prometheus"
 	"sigs.k8s.io/controller-runtime/pkg/metrics"
 )
 
 var (
+	// processStartTime is used for ignoring the durations of the events which happened before the controllers
+	// started, e.g. when upgrading from a version not persisting the corresponding timestamps.
+	processStartTime = time.Now()
+
 	// ClusterControlPlaneReady is a metric that is set to 1 if the cluster
 	// control plane is ready and 0 if it is not.
 	ClusterControlPlaneReady = prometheus.NewGaugeVec(
@@ -93,8 +99,103 @@ var (
 		},
 		[]string{"machine", "namespace", "cluster"},
 	)
+
+	// MachineReferenceNotFound is a metric that is set to 1 if the infrastructure
+	// or bootstrap object referenced by a machine does not exist and 0 if it does.
+	MachineReferenceNotFound = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "capi_machine_reference_not_found",
+			Help: "The infrastructure or bootstrap object referenced by the Machine does not exist if set to 1.",
+		},
+		[]string{"machine", "namespace", "cluster", "reference"},
+	)
+
+	// MachinePhase is a metric that is set to 1 for the current phase of the
+	// machine and 0 for the other phases.
+	MachinePhase = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "capi_machine_phase",
+			Help: "Machine is in the phase if set to 1 and not if 0.",
+		},
+		[]string{"machine", "namespace", "cluster", "phase"},
+	)
+
+	// MachineProvisioningDuration is a metric observing the time elapsed between the
+	// creation of a machine and the moment it reaches the running phase.
+	MachineProvisioningDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "capi_machine_provisioning_duration_seconds",
+			Help:    "Time elapsed between the creation of a Machine and the moment it is running.",
+			Buckets: prometheus.ExponentialBuckets(30, 2, 8),
+		},
+		[]string{"namespace", "cluster"},
+	)
+
+	// MachineHealthCheckRemediations is a metric counting the unhealthy machines
+	// for which a machine health check triggered a remediation.
+	MachineHealthCheckRemediations = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "capi_machinehealthcheck_remediations_total",
+			Help: "Number of unhealthy Machines for which the MachineHealthCheck triggered a remediation.",
+		},
+		[]string{"machinehealthcheck", "namespace", "cluster"},
+	)
+
+	// MachineDeploymentRolloutDuration is a metric observing the time elapsed between the
+	// creation of the new machine set of a machine deployment and the completion of the rollout.
+	MachineDeploymentRolloutDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "capi_machinedeployment_rollout_duration_seconds",
+			Help:    "Time elapsed between the creation of the new MachineSet of a MachineDeployment and the completion of the rollout.",
+			Buckets: prometheus.ExponentialBuckets(60, 2, 8),
+		},
+		[]string{"namespace", "cluster"},
+	)
+
+	// ClusterReadyDuration is a metric observing the time elapsed between the
+	// creation of a cluster and the moment it is ready for the first time.
+	ClusterReadyDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "capi_cluster_ready_duration_seconds",
+			Help:    "Time elapsed between the creation of a Cluster and the moment it is Ready for the first time.",
+			Buckets: prometheus.ExponentialBuckets(60, 2, 8),
+		},
+		[]string{"namespace"},
+	)
+
+	// MachineNodeReadyDuration is a metric observing the time elapsed between the
+	// creation of a machine, e.g. by scaling up its pool, and the moment its node is ready.
+	MachineNodeReadyDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "capi_machine_node_ready_duration_seconds",
+			Help:    "Time elapsed between the creation of a Machine and the moment its Node is Ready for the first time.",
+			Buckets: prometheus.ExponentialBuckets(30, 2, 8),
+		},
+		[]string{"namespace", "cluster", "pool"},
+	)
+
+	// MachineHealthCheckRemediationDuration is a metric observing the time elapsed between
+	// the moment a machine health check finds unhealthy machines and the moment all the
+	// machines are healthy again, i.e. the time to recovery.
+	MachineHealthCheckRemediationDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "capi_machinehealthcheck_remediation_duration_seconds",
+			Help:    "Time elapsed between the moment a MachineHealthCheck finds unhealthy Machines and the moment all the Machines are healthy again.",
+			Buckets: prometheus.ExponentialBuckets(60, 2, 8),
+		},
+		[]string{"machinehealthcheck", "namespace", "cluster"},
+	)
 )
 
+// ObserveDuration observes the time elapsed between start and end, unless end is
+// before the controllers started.
+func ObserveDuration(o prometheus.Observer, start, end time.Time) {
+	if end.Before(processStartTime) {
+		return
+	}
+	o.Observe(end.Sub(start).Seconds())
+}
+
 func init() {
 	metrics.Registry.MustRegister(
 		ClusterControlPlaneReady,
@@ -104,5 +205,13 @@ func init() {
 		MachineBootstrapReady,
 		MachineInfrastructureReady,
 		MachineNodeReady,
+		MachineReferenceNotFound,
+		MachinePhase,
+		MachineProvisioningDuration,
+		MachineHealthCheckRemediations,
+		MachineDeploymentRolloutDuration,
+		ClusterReadyDuration,
+		MachineNodeReadyDuration,
+		MachineHealthCheckRemediationDuration,
 	)
 }
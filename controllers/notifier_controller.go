@@ -0,0 +1,457 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/go-logr/logr"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	kerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/utils/pointer"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/util"
+	"sigs.k8s.io/cluster-api/util/patch"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+// +kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;watch
+// +kubebuilder:rbac:groups=cluster.x-k8s.io,resources=notifiers;notifiers/status,verbs=get;list;watch;update;patch
+// +kubebuilder:rbac:groups=cluster.x-k8s.io,resources=clusters;machines;machinehealthchecks,verbs=get;list;watch
+
+// NotifierReconciler reconciles a Notifier object
+type NotifierReconciler struct {
+	Client client.Client
+	Log    logr.Logger
+
+	sender notificationSender
+}
+
+func (r *NotifierReconciler) SetupWithManager(mgr ctrl.Manager, options controller.Options) error {
+	err := ctrl.NewControllerManagedBy(mgr).
+		For(&clusterv1.Notifier{}).
+		Watches(
+			&source.Kind{Type: &clusterv1.Cluster{}},
+			&handler.EnqueueRequestsFromMapFunc{ToRequests: handler.ToRequestsFunc(r.clusterToNotifiers)},
+		).
+		Watches(
+			&source.Kind{Type: &clusterv1.Machine{}},
+			&handler.EnqueueRequestsFromMapFunc{ToRequests: handler.ToRequestsFunc(r.machineToNotifiers)},
+		).
+		Watches(
+			&source.Kind{Type: &clusterv1.MachineHealthCheck{}},
+			&handler.EnqueueRequestsFromMapFunc{ToRequests: handler.ToRequestsFunc(r.machineHealthCheckToNotifiers)},
+		).
+		WithOptions(options).
+		Complete(r)
+
+	if err != nil {
+		return errors.Wrap(err, "failed setting up with a controller manager")
+	}
+
+	if r.sender == nil {
+		r.sender = newHTTPNotificationSender()
+	}
+	return nil
+}
+
+func (r *NotifierReconciler) Reconcile(req ctrl.Request) (_ ctrl.Result, reterr error) {
+	ctx := context.Background()
+	logger := r.Log.WithValues("notifier", req.Name, "namespace", req.Namespace)
+
+	// Fetch the Notifier instance.
+	notifier := &clusterv1.Notifier{}
+	if err := r.Client.Get(ctx, req.NamespacedName, notifier); err != nil {
+		if apierrors.IsNotFound(err) {
+			// Object not found, return. Created objects are automatically garbage collected.
+			// For additional cleanup logic use finalizers.
+			return ctrl.Result{}, nil
+		}
+		// Error reading the object - requeue the request.
+		return ctrl.Result{}, err
+	}
+
+	// Return early if the object is being deleted.
+	if !notifier.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, nil
+	}
+
+	// Initialize the patch helper.
+	patchHelper, err := patch.NewHelper(notifier, r.Client)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	defer func() {
+		// Always attempt to Patch the Notifier object and status after each reconciliation.
+		notifier.Status.ObservedGeneration = notifier.Generation
+		if err := patchHelper.Patch(ctx, notifier); err != nil {
+			reterr = kerrors.NewAggregate([]error{reterr, err})
+		}
+	}()
+
+	clusters, err := r.getClustersByNotifierSelector(ctx, notifier)
+	if err != nil {
+		logger.Error(err, "Failed fetching clusters that matches Notifier labels")
+		return ctrl.Result{}, err
+	}
+
+	return ctrl.Result{}, r.reconcileNotifications(ctx, logger, notifier, clusters)
+}
+
+// reconcileNotifications compares the observed lifecycle state of each Cluster with the state last notified,
+// posts a notification for each transition and records the notified state in the Notifier status.
+// The notified state of a Cluster is only advanced once the corresponding notification has been posted,
+// so that failed notifications are retried on the next reconciliation.
+func (r *NotifierReconciler) reconcileNotifications(ctx context.Context, logger logr.Logger, notifier *clusterv1.Notifier, clusters []*clusterv1.Cluster) error {
+	var (
+		url      string
+		statuses []clusterv1.NotifierClusterStatus
+		sent     bool
+		errs     []error
+	)
+
+	for _, cluster := range clusters {
+		logger := logger.WithValues("cluster", cluster.Name)
+
+		previous := notifier.GetClusterStatus(cluster.Name)
+
+		// Notifications are held back while the Cluster or the Notifier is paused.
+		if util.IsPaused(cluster, notifier) {
+			logger.V(3).Info("reconciliation is paused for this cluster")
+			if previous != nil {
+				statuses = append(statuses, *previous)
+			}
+			continue
+		}
+
+		observed, err := r.observeCluster(ctx, cluster)
+		if err != nil {
+			errs = append(errs, err)
+			if previous != nil {
+				statuses = append(statuses, *previous)
+			}
+			continue
+		}
+
+		var events []clusterv1.NotifierEvent
+		var notified clusterv1.NotifierClusterStatus
+		switch {
+		case previous != nil:
+			notified = *previous
+		case cluster.CreationTimestamp.Before(&notifier.CreationTimestamp):
+			// Clusters existing before the Notifier are not notified about their past.
+			statuses = append(statuses, *observed)
+			continue
+		default:
+			notified = clusterv1.NotifierClusterStatus{Name: cluster.Name}
+			events = append(events, clusterv1.NotifierEventClusterCreated)
+		}
+		events = append(events, clusterLifecycleEvents(&notified, observed)...)
+
+		for _, event := range events {
+			if notifier.NotifiesEvent(event) {
+				if url == "" {
+					if url, err = r.getWebhookURL(ctx, notifier); err != nil {
+						errs = append(errs, err)
+						break
+					}
+				}
+
+				n := notification{
+					Event:     event,
+					Cluster:   cluster.Name,
+					Namespace: cluster.Namespace,
+					Message:   notificationMessage(event, observed),
+					Timestamp: metav1.Now(),
+				}
+				if err := r.sender.Send(ctx, url, notifier.Spec.Webhook.Format, n); err != nil {
+					logger.Error(err, "Failed to post notification", "event", event)
+					errs = append(errs, errors.Wrapf(err, "failed to post %s notification for Cluster %q", event, cluster.Name))
+					break
+				}
+				logger.V(4).Info("Posted notification", "event", event)
+				sent = true
+			}
+			applyLifecycleEvent(&notified, observed, event)
+		}
+
+		// The version is recorded as soon as the Machines are first seen running the same version.
+		if notified.Version == "" && !notified.Upgrading && !observed.Upgrading {
+			notified.Version = observed.Version
+		}
+		statuses = append(statuses, notified)
+	}
+
+	// Clusters that are gone or no longer selected are dropped from the status.
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Name < statuses[j].Name
+	})
+	notifier.Status.Clusters = statuses
+
+	if sent {
+		now := metav1.Now()
+		notifier.Status.LastNotificationTime = &now
+	}
+
+	if len(errs) > 0 {
+		notifier.Status.FailureMessage = pointer.StringPtr(kerrors.NewAggregate(errs).Error())
+		return kerrors.NewAggregate(errs)
+	}
+	notifier.Status.FailureMessage = nil
+	return nil
+}
+
+// observeCluster returns the current lifecycle state of a Cluster.
+func (r *NotifierReconciler) observeCluster(ctx context.Context, cluster *clusterv1.Cluster) (*clusterv1.NotifierClusterStatus, error) {
+	observed := &clusterv1.NotifierClusterStatus{
+		Name:  cluster.Name,
+		Ready: cluster.Status.GetTypedPhase() == clusterv1.ClusterPhaseProvisioned && cluster.Status.ControlPlaneInitialized,
+	}
+
+	machines := &clusterv1.MachineList{}
+	if err := r.Client.List(ctx, machines, client.InNamespace(cluster.Namespace), client.MatchingLabels{clusterv1.ClusterLabelName: cluster.Name}); err != nil {
+		return nil, errors.Wrapf(err, "failed to list Machines for Cluster %q", cluster.Name)
+	}
+
+	// A Cluster is being upgraded while its Machines are not all running the same version.
+	versions := sets.NewString()
+	for i := range machines.Items {
+		m := &machines.Items[i]
+		if m.DeletionTimestamp.IsZero() && m.Spec.Version != nil {
+			versions.Insert(*m.Spec.Version)
+		}
+	}
+	switch versions.Len() {
+	case 0:
+	case 1:
+		observed.Version = versions.List()[0]
+	default:
+		observed.Upgrading = true
+	}
+
+	mhcs := &clusterv1.MachineHealthCheckList{}
+	if err := r.Client.List(ctx, mhcs, client.InNamespace(cluster.Namespace)); err != nil {
+		return nil, errors.Wrapf(err, "failed to list MachineHealthChecks for Cluster %q", cluster.Name)
+	}
+
+	// A Cluster is being remediated while any of its MachineHealthChecks reports unhealthy Machines.
+	for i := range mhcs.Items {
+		mhc := &mhcs.Items[i]
+		if mhc.Spec.ClusterName == cluster.Name && mhc.Status.ExpectedMachines > mhc.Status.CurrentHealthy {
+			observed.Remediating = true
+			break
+		}
+	}
+
+	return observed, nil
+}
+
+// clusterLifecycleEvents returns the events to notify for a Cluster moving from the notified to the observed state.
+func clusterLifecycleEvents(notified, observed *clusterv1.NotifierClusterStatus) []clusterv1.NotifierEvent {
+	var events []clusterv1.NotifierEvent
+
+	if observed.Ready && !notified.Ready {
+		events = append(events, clusterv1.NotifierEventClusterReady)
+	}
+
+	// An upgrade completing between two reconciliations is still notified as started and finished.
+	versionChanged := notified.Version != "" && observed.Version != "" && notified.Version != observed.Version
+	if !notified.Upgrading && (observed.Upgrading || versionChanged) {
+		events = append(events, clusterv1.NotifierEventUpgradeStarted)
+	}
+	if !observed.Upgrading && (notified.Upgrading || versionChanged) {
+		events = append(events, clusterv1.NotifierEventUpgradeFinished)
+	}
+
+	if observed.Remediating && !notified.Remediating {
+		events = append(events, clusterv1.NotifierEventRemediationStarted)
+	}
+	if !observed.Remediating && notified.Remediating {
+		events = append(events, clusterv1.NotifierEventRemediationFinished)
+	}
+
+	return events
+}
+
+// applyLifecycleEvent records a notified event in the notified state of a Cluster.
+func applyLifecycleEvent(notified, observed *clusterv1.NotifierClusterStatus, event clusterv1.NotifierEvent) {
+	switch event {
+	case clusterv1.NotifierEventClusterReady:
+		notified.Ready = true
+	case clusterv1.NotifierEventUpgradeStarted:
+		notified.Upgrading = true
+	case clusterv1.NotifierEventUpgradeFinished:
+		notified.Upgrading = false
+		notified.Version = observed.Version
+	case clusterv1.NotifierEventRemediationStarted:
+		notified.Remediating = true
+	case clusterv1.NotifierEventRemediationFinished:
+		notified.Remediating = false
+	}
+}
+
+// notificationMessage returns a human readable description of an event.
+func notificationMessage(event clusterv1.NotifierEvent, observed *clusterv1.NotifierClusterStatus) string {
+	switch event {
+	case clusterv1.NotifierEventClusterCreated:
+		return "Cluster has been created"
+	case clusterv1.NotifierEventClusterReady:
+		return "Cluster is ready"
+	case clusterv1.NotifierEventUpgradeStarted:
+		return "Cluster upgrade has started"
+	case clusterv1.NotifierEventUpgradeFinished:
+		return fmt.Sprintf("Cluster has been upgraded to version %s", observed.Version)
+	case clusterv1.NotifierEventRemediationStarted:
+		return "Cluster has unhealthy Machines being remediated"
+	case clusterv1.NotifierEventRemediationFinished:
+		return "Cluster has no unhealthy Machines anymore"
+	}
+	return string(event)
+}
+
+// getWebhookURL returns the URL stored in the Secret referenced by the Notifier.
+func (r *NotifierReconciler) getWebhookURL(ctx context.Context, notifier *clusterv1.Notifier) (string, error) {
+	secret := &corev1.Secret{}
+	key := client.ObjectKey{Namespace: notifier.Namespace, Name: notifier.Spec.Webhook.SecretName}
+	if err := r.Client.Get(ctx, key, secret); err != nil {
+		return "", errors.Wrapf(err, "failed to get webhook Secret %q", key.Name)
+	}
+
+	url, ok := secret.Data[clusterv1.NotifierURLSecretKey]
+	if !ok || len(url) == 0 {
+		return "", errors.Errorf("webhook Secret %q has no %q key", key.Name, clusterv1.NotifierURLSecretKey)
+	}
+	return string(url), nil
+}
+
+// getClustersByNotifierSelector fetches Clusters matched by the Notifier's label selector that are in the same namespace as the Notifier object.
+func (r *NotifierReconciler) getClustersByNotifierSelector(ctx context.Context, notifier *clusterv1.Notifier) ([]*clusterv1.Cluster, error) {
+	selector, err := metav1.LabelSelectorAsSelector(&notifier.Spec.ClusterSelector)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to build selector")
+	}
+
+	// If a Notifier has a nil or empty selector, it should match nothing, not everything.
+	if selector.Empty() {
+		return nil, nil
+	}
+
+	clusterList := &clusterv1.ClusterList{}
+	if err := r.Client.List(ctx, clusterList, client.InNamespace(notifier.Namespace), client.MatchingLabelsSelector{Selector: selector}); err != nil {
+		return nil, errors.Wrap(err, "failed to list clusters")
+	}
+
+	clusters := []*clusterv1.Cluster{}
+	for i := range clusterList.Items {
+		c := &clusterList.Items[i]
+		if c.DeletionTimestamp.IsZero() {
+			clusters = append(clusters, c)
+		}
+	}
+	return clusters, nil
+}
+
+// clusterToNotifiers is mapper function that maps clusters to Notifiers
+func (r *NotifierReconciler) clusterToNotifiers(o handler.MapObject) []ctrl.Request {
+	cluster, ok := o.Object.(*clusterv1.Cluster)
+	if !ok {
+		r.Log.Error(errors.Errorf("expected a Cluster but got a %T", o.Object), "failed to get Notifiers for Cluster")
+		return nil
+	}
+
+	return r.notifiersForCluster(cluster)
+}
+
+// machineToNotifiers is mapper function that maps machines to the Notifiers of their Cluster
+func (r *NotifierReconciler) machineToNotifiers(o handler.MapObject) []ctrl.Request {
+	m, ok := o.Object.(*clusterv1.Machine)
+	if !ok {
+		r.Log.Error(errors.Errorf("expected a Machine but got a %T", o.Object), "failed to get Notifiers for Machine")
+		return nil
+	}
+
+	cluster, err := util.GetClusterByName(context.Background(), r.Client, m.Namespace, m.Spec.ClusterName)
+	if err != nil {
+		return nil
+	}
+
+	return r.notifiersForCluster(cluster)
+}
+
+// machineHealthCheckToNotifiers is mapper function that maps machinehealthchecks to the Notifiers of their Cluster
+func (r *NotifierReconciler) machineHealthCheckToNotifiers(o handler.MapObject) []ctrl.Request {
+	mhc, ok := o.Object.(*clusterv1.MachineHealthCheck)
+	if !ok {
+		r.Log.Error(errors.Errorf("expected a MachineHealthCheck but got a %T", o.Object), "failed to get Notifiers for MachineHealthCheck")
+		return nil
+	}
+
+	cluster, err := util.GetClusterByName(context.Background(), r.Client, mhc.Namespace, mhc.Spec.ClusterName)
+	if err != nil {
+		return nil
+	}
+
+	return r.notifiersForCluster(cluster)
+}
+
+// notifiersForCluster returns a request for each Notifier selecting the Cluster.
+func (r *NotifierReconciler) notifiersForCluster(cluster *clusterv1.Cluster) []ctrl.Request {
+	result := []ctrl.Request{}
+
+	notifierList := &clusterv1.NotifierList{}
+	if err := r.Client.List(context.Background(), notifierList, client.InNamespace(cluster.Namespace)); err != nil {
+		r.Log.Error(err, "failed to list Notifiers")
+		return nil
+	}
+
+	clusterLabels := labels.Set(cluster.GetLabels())
+	for i := range notifierList.Items {
+		n := &notifierList.Items[i]
+
+		selector, err := metav1.LabelSelectorAsSelector(&n.Spec.ClusterSelector)
+		if err != nil {
+			r.Log.Error(err, "unable to convert ClusterSelector to selector")
+			return nil
+		}
+
+		// If a Notifier with a nil or empty selector creeps in, it should match nothing, not everything.
+		if selector.Empty() {
+			continue
+		}
+
+		if !selector.Matches(clusterLabels) {
+			continue
+		}
+
+		name := client.ObjectKey{Namespace: n.Namespace, Name: n.Name}
+		result = append(result, ctrl.Request{NamespacedName: name})
+	}
+
+	return result
+}
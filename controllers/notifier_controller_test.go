@@ -0,0 +1,343 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/utils/pointer"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+type fakeNotificationSender struct {
+	sent []notification
+	err  error
+}
+
+func (s *fakeNotificationSender) Send(_ context.Context, _ string, _ clusterv1.NotifierFormat, n notification) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.sent = append(s.sent, n)
+	return nil
+}
+
+func (s *fakeNotificationSender) events() []clusterv1.NotifierEvent {
+	events := []clusterv1.NotifierEvent{}
+	for _, n := range s.sent {
+		events = append(events, n.Event)
+	}
+	return events
+}
+
+func newTestNotifier(created time.Time) *clusterv1.Notifier {
+	return &clusterv1.Notifier{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "test-notifier",
+			Namespace:         "default",
+			CreationTimestamp: metav1.NewTime(created),
+		},
+		Spec: clusterv1.NotifierSpec{
+			ClusterSelector: metav1.LabelSelector{MatchLabels: map[string]string{"foo": "bar"}},
+			Webhook: clusterv1.NotifierWebhook{
+				SecretName: "test-webhook",
+				Format:     clusterv1.NotifierFormatJSON,
+			},
+		},
+	}
+}
+
+func newTestNotifierCluster(created time.Time) *clusterv1.Cluster {
+	return &clusterv1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "test-cluster",
+			Namespace:         "default",
+			Labels:            map[string]string{"foo": "bar"},
+			CreationTimestamp: metav1.NewTime(created),
+		},
+		Status: clusterv1.ClusterStatus{
+			Phase:                   string(clusterv1.ClusterPhaseProvisioned),
+			ControlPlaneInitialized: true,
+		},
+	}
+}
+
+func newTestNotifierMachine(name, version string) *clusterv1.Machine {
+	return &clusterv1.Machine{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "default",
+			Labels:    map[string]string{clusterv1.ClusterLabelName: "test-cluster"},
+		},
+		Spec: clusterv1.MachineSpec{
+			ClusterName: "test-cluster",
+			Version:     pointer.StringPtr(version),
+		},
+	}
+}
+
+func newTestNotifierReconciler(sender notificationSender, objs ...runtime.Object) *NotifierReconciler {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-webhook", Namespace: "default"},
+		Data:       map[string][]byte{clusterv1.NotifierURLSecretKey: []byte("http://example.com/hook")},
+	}
+	return &NotifierReconciler{
+		Client: fake.NewFakeClientWithScheme(scheme.Scheme, append(objs, secret)...),
+		Log:    log.Log,
+		sender: sender,
+	}
+}
+
+func TestNotifierReconcileNotifications(t *testing.T) {
+	now := time.Now()
+
+	t.Run("notifies the creation of a new Cluster", func(t *testing.T) {
+		g := NewWithT(t)
+		g.Expect(clusterv1.AddToScheme(scheme.Scheme)).To(Succeed())
+
+		notifier := newTestNotifier(now.Add(-time.Hour))
+		cluster := newTestNotifierCluster(now)
+		sender := &fakeNotificationSender{}
+		r := newTestNotifierReconciler(sender, notifier, cluster, newTestNotifierMachine("m1", "v1.17.0"))
+
+		g.Expect(r.reconcileNotifications(context.Background(), r.Log, notifier, []*clusterv1.Cluster{cluster})).To(Succeed())
+		g.Expect(sender.events()).To(Equal([]clusterv1.NotifierEvent{
+			clusterv1.NotifierEventClusterCreated,
+			clusterv1.NotifierEventClusterReady,
+		}))
+		g.Expect(notifier.Status.Clusters).To(Equal([]clusterv1.NotifierClusterStatus{
+			{Name: "test-cluster", Ready: true, Version: "v1.17.0"},
+		}))
+		g.Expect(notifier.Status.LastNotificationTime).NotTo(BeNil())
+		g.Expect(notifier.Status.FailureMessage).To(BeNil())
+
+		// Nothing changed, nothing is notified again.
+		sender.sent = nil
+		g.Expect(r.reconcileNotifications(context.Background(), r.Log, notifier, []*clusterv1.Cluster{cluster})).To(Succeed())
+		g.Expect(sender.sent).To(BeEmpty())
+	})
+
+	t.Run("does not notify the past of a Cluster existing before the Notifier", func(t *testing.T) {
+		g := NewWithT(t)
+		g.Expect(clusterv1.AddToScheme(scheme.Scheme)).To(Succeed())
+
+		notifier := newTestNotifier(now)
+		cluster := newTestNotifierCluster(now.Add(-time.Hour))
+		sender := &fakeNotificationSender{}
+		r := newTestNotifierReconciler(sender, notifier, cluster, newTestNotifierMachine("m1", "v1.17.0"))
+
+		g.Expect(r.reconcileNotifications(context.Background(), r.Log, notifier, []*clusterv1.Cluster{cluster})).To(Succeed())
+		g.Expect(sender.sent).To(BeEmpty())
+		g.Expect(notifier.Status.Clusters).To(Equal([]clusterv1.NotifierClusterStatus{
+			{Name: "test-cluster", Ready: true, Version: "v1.17.0"},
+		}))
+		g.Expect(notifier.Status.LastNotificationTime).To(BeNil())
+	})
+
+	t.Run("notifies upgrades and remediations", func(t *testing.T) {
+		g := NewWithT(t)
+		g.Expect(clusterv1.AddToScheme(scheme.Scheme)).To(Succeed())
+
+		notifier := newTestNotifier(now)
+		notifier.Status.Clusters = []clusterv1.NotifierClusterStatus{
+			{Name: "test-cluster", Ready: true, Version: "v1.17.0"},
+		}
+		cluster := newTestNotifierCluster(now.Add(-time.Hour))
+		mhc := newTestMachineHealthCheck("test-mhc", "default", "test-cluster", map[string]string{})
+		mhc.Status.ExpectedMachines = 2
+		mhc.Status.CurrentHealthy = 1
+		sender := &fakeNotificationSender{}
+		r := newTestNotifierReconciler(sender, notifier, cluster, mhc,
+			newTestNotifierMachine("m1", "v1.17.0"),
+			newTestNotifierMachine("m2", "v1.18.0"),
+		)
+
+		g.Expect(r.reconcileNotifications(context.Background(), r.Log, notifier, []*clusterv1.Cluster{cluster})).To(Succeed())
+		g.Expect(sender.events()).To(Equal([]clusterv1.NotifierEvent{
+			clusterv1.NotifierEventUpgradeStarted,
+			clusterv1.NotifierEventRemediationStarted,
+		}))
+		g.Expect(notifier.Status.Clusters).To(Equal([]clusterv1.NotifierClusterStatus{
+			{Name: "test-cluster", Ready: true, Version: "v1.17.0", Upgrading: true, Remediating: true},
+		}))
+	})
+
+	t.Run("only notifies the selected events", func(t *testing.T) {
+		g := NewWithT(t)
+		g.Expect(clusterv1.AddToScheme(scheme.Scheme)).To(Succeed())
+
+		notifier := newTestNotifier(now.Add(-time.Hour))
+		notifier.Spec.Events = []clusterv1.NotifierEvent{clusterv1.NotifierEventClusterReady}
+		cluster := newTestNotifierCluster(now)
+		sender := &fakeNotificationSender{}
+		r := newTestNotifierReconciler(sender, notifier, cluster)
+
+		g.Expect(r.reconcileNotifications(context.Background(), r.Log, notifier, []*clusterv1.Cluster{cluster})).To(Succeed())
+		g.Expect(sender.events()).To(Equal([]clusterv1.NotifierEvent{clusterv1.NotifierEventClusterReady}))
+	})
+
+	t.Run("does not record the notified state when posting fails", func(t *testing.T) {
+		g := NewWithT(t)
+		g.Expect(clusterv1.AddToScheme(scheme.Scheme)).To(Succeed())
+
+		notifier := newTestNotifier(now)
+		notifier.Status.Clusters = []clusterv1.NotifierClusterStatus{
+			{Name: "test-cluster", Version: "v1.17.0"},
+		}
+		cluster := newTestNotifierCluster(now.Add(-time.Hour))
+		sender := &fakeNotificationSender{err: errors.New("connection refused")}
+		r := newTestNotifierReconciler(sender, notifier, cluster, newTestNotifierMachine("m1", "v1.17.0"))
+
+		g.Expect(r.reconcileNotifications(context.Background(), r.Log, notifier, []*clusterv1.Cluster{cluster})).NotTo(Succeed())
+		g.Expect(notifier.Status.Clusters).To(Equal([]clusterv1.NotifierClusterStatus{
+			{Name: "test-cluster", Version: "v1.17.0"},
+		}))
+		g.Expect(notifier.Status.FailureMessage).NotTo(BeNil())
+	})
+
+	t.Run("drops Clusters that are gone", func(t *testing.T) {
+		g := NewWithT(t)
+		g.Expect(clusterv1.AddToScheme(scheme.Scheme)).To(Succeed())
+
+		notifier := newTestNotifier(now)
+		notifier.Status.Clusters = []clusterv1.NotifierClusterStatus{
+			{Name: "deleted-cluster", Ready: true},
+		}
+		sender := &fakeNotificationSender{}
+		r := newTestNotifierReconciler(sender, notifier)
+
+		g.Expect(r.reconcileNotifications(context.Background(), r.Log, notifier, nil)).To(Succeed())
+		g.Expect(notifier.Status.Clusters).To(BeEmpty())
+	})
+}
+
+func TestClusterLifecycleEvents(t *testing.T) {
+	testCases := []struct {
+		name     string
+		notified clusterv1.NotifierClusterStatus
+		observed clusterv1.NotifierClusterStatus
+		expected []clusterv1.NotifierEvent
+	}{
+		{
+			name:     "no change",
+			notified: clusterv1.NotifierClusterStatus{Ready: true, Version: "v1.17.0"},
+			observed: clusterv1.NotifierClusterStatus{Ready: true, Version: "v1.17.0"},
+		},
+		{
+			name:     "cluster becomes ready",
+			notified: clusterv1.NotifierClusterStatus{},
+			observed: clusterv1.NotifierClusterStatus{Ready: true},
+			expected: []clusterv1.NotifierEvent{clusterv1.NotifierEventClusterReady},
+		},
+		{
+			name:     "upgrade finishes",
+			notified: clusterv1.NotifierClusterStatus{Version: "v1.17.0", Upgrading: true},
+			observed: clusterv1.NotifierClusterStatus{Version: "v1.18.0"},
+			expected: []clusterv1.NotifierEvent{clusterv1.NotifierEventUpgradeFinished},
+		},
+		{
+			name:     "upgrade completes between two observations",
+			notified: clusterv1.NotifierClusterStatus{Version: "v1.17.0"},
+			observed: clusterv1.NotifierClusterStatus{Version: "v1.18.0"},
+			expected: []clusterv1.NotifierEvent{clusterv1.NotifierEventUpgradeStarted, clusterv1.NotifierEventUpgradeFinished},
+		},
+		{
+			name:     "first version observed",
+			notified: clusterv1.NotifierClusterStatus{},
+			observed: clusterv1.NotifierClusterStatus{Version: "v1.17.0"},
+		},
+		{
+			name:     "remediation finishes",
+			notified: clusterv1.NotifierClusterStatus{Remediating: true},
+			observed: clusterv1.NotifierClusterStatus{},
+			expected: []clusterv1.NotifierEvent{clusterv1.NotifierEventRemediationFinished},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			g.Expect(clusterLifecycleEvents(&tc.notified, &tc.observed)).To(Equal(tc.expected))
+		})
+	}
+}
+
+func TestHTTPNotificationSender(t *testing.T) {
+	n := notification{
+		Event:     clusterv1.NotifierEventClusterReady,
+		Cluster:   "test-cluster",
+		Namespace: "default",
+		Message:   "Cluster is ready",
+		Timestamp: metav1.Now(),
+	}
+
+	t.Run("posts JSON notifications", func(t *testing.T) {
+		g := NewWithT(t)
+
+		var received map[string]interface{}
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			body, _ := ioutil.ReadAll(req.Body)
+			_ = json.Unmarshal(body, &received)
+		}))
+		defer server.Close()
+
+		g.Expect(newHTTPNotificationSender().Send(context.Background(), server.URL, clusterv1.NotifierFormatJSON, n)).To(Succeed())
+		g.Expect(received).To(HaveKeyWithValue("event", "ClusterReady"))
+		g.Expect(received).To(HaveKeyWithValue("cluster", "test-cluster"))
+		g.Expect(received).To(HaveKeyWithValue("namespace", "default"))
+	})
+
+	t.Run("posts Slack notifications", func(t *testing.T) {
+		g := NewWithT(t)
+
+		var received map[string]interface{}
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			body, _ := ioutil.ReadAll(req.Body)
+			_ = json.Unmarshal(body, &received)
+		}))
+		defer server.Close()
+
+		g.Expect(newHTTPNotificationSender().Send(context.Background(), server.URL, clusterv1.NotifierFormatSlack, n)).To(Succeed())
+		g.Expect(received).To(Equal(map[string]interface{}{"text": "[default/test-cluster] ClusterReady: Cluster is ready"}))
+	})
+
+	t.Run("fails when the webhook responds with an error", func(t *testing.T) {
+		g := NewWithT(t)
+
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+		defer server.Close()
+
+		g.Expect(newHTTPNotificationSender().Send(context.Background(), server.URL, clusterv1.NotifierFormatJSON, n)).NotTo(Succeed())
+	})
+}
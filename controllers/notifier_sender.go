@@ -0,0 +1,108 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+)
+
+const notificationTimeout = 10 * time.Second
+
+// notification is a Cluster lifecycle event posted to a webhook.
+type notification struct {
+	Event     clusterv1.NotifierEvent `json:"event"`
+	Cluster   string                  `json:"cluster"`
+	Namespace string                  `json:"namespace"`
+	Message   string                  `json:"message"`
+	Timestamp metav1.Time             `json:"timestamp"`
+}
+
+// slackMessage is the payload accepted by Slack incoming webhooks.
+type slackMessage struct {
+	Text string `json:"text"`
+}
+
+// notificationSender posts notifications to webhooks.
+type notificationSender interface {
+	Send(ctx context.Context, url string, format clusterv1.NotifierFormat, n notification) error
+}
+
+// httpNotificationSender posts notifications to webhooks over HTTP.
+type httpNotificationSender struct {
+	client *http.Client
+}
+
+func newHTTPNotificationSender() *httpNotificationSender {
+	return &httpNotificationSender{
+		client: &http.Client{Timeout: notificationTimeout},
+	}
+}
+
+// Send posts a notification to the given URL in the given format.
+// The URL is never included in the returned errors, as webhook URLs usually embed credentials.
+func (s *httpNotificationSender) Send(ctx context.Context, url string, format clusterv1.NotifierFormat, n notification) error {
+	body, err := notificationPayload(format, n)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return errors.New("failed to create webhook request")
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return errors.New("failed to post to webhook")
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(ioutil.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return errors.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// notificationPayload returns the body posted to a webhook for a notification.
+func notificationPayload(format clusterv1.NotifierFormat, n notification) ([]byte, error) {
+	var payload interface{} = n
+	if format == clusterv1.NotifierFormatSlack {
+		payload = slackMessage{
+			Text: fmt.Sprintf("[%s/%s] %s: %s", n.Namespace, n.Cluster, n.Event, n.Message),
+		}
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal notification")
+	}
+	return body, nil
+}
@@ -0,0 +1,91 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/controllers/external"
+	"sigs.k8s.io/cluster-api/util"
+	"sigs.k8s.io/cluster-api/util/patch"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+// reconcilePausePropagation propagates the pause of a Cluster to the provider objects referenced by an object
+// belonging to the Cluster; it is called before returning early for a paused object, because otherwise providers
+// would keep reconciling the referenced objects. References to objects not existing yet are ignored.
+func reconcilePausePropagation(ctx context.Context, c client.Client, cluster *clusterv1.Cluster, namespace string, refs ...*corev1.ObjectReference) error {
+	for _, ref := range refs {
+		if ref == nil {
+			continue
+		}
+		obj, err := external.Get(ctx, c, ref, namespace)
+		if err != nil {
+			if apierrors.IsNotFound(errors.Cause(err)) {
+				continue
+			}
+			return err
+		}
+		if err := propagatePause(ctx, c, cluster, obj); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// propagatePause adds or removes the paused annotation to a provider object according to the pause of the Cluster,
+// and patches the object if required.
+func propagatePause(ctx context.Context, c client.Client, cluster *clusterv1.Cluster, obj *unstructured.Unstructured) error {
+	patchHelper, err := patch.NewHelper(obj, c)
+	if err != nil {
+		return err
+	}
+	if !util.PropagatePause(cluster, obj) {
+		return nil
+	}
+	if err := patchHelper.Patch(ctx, obj); err != nil {
+		return errors.Wrapf(err, "failed to propagate the pause of Cluster %q to %v %q",
+			cluster.Name, obj.GroupVersionKind(), obj.GetName())
+	}
+	return nil
+}
+
+// clusterPauseChanged is a predicate selecting only the Cluster updates changing Spec.Paused; it is used by the
+// controllers watching Clusters only for propagating their pause.
+var clusterPauseChanged = predicate.Funcs{
+	CreateFunc:  func(event.CreateEvent) bool { return false },
+	DeleteFunc:  func(event.DeleteEvent) bool { return false },
+	GenericFunc: func(event.GenericEvent) bool { return false },
+	UpdateFunc: func(e event.UpdateEvent) bool {
+		oldCluster, ok := e.ObjectOld.(*clusterv1.Cluster)
+		if !ok {
+			return false
+		}
+		newCluster, ok := e.ObjectNew.(*clusterv1.Cluster)
+		if !ok {
+			return false
+		}
+		return oldCluster.Spec.Paused != newCluster.Spec.Paused
+	},
+}
@@ -0,0 +1,100 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/kubernetes/scheme"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+)
+
+func TestReconcilePausePropagation(t *testing.T) {
+	g := NewWithT(t)
+	g.Expect(clusterv1.AddToScheme(scheme.Scheme)).To(Succeed())
+
+	infraRef := &corev1.ObjectReference{
+		APIVersion: "infrastructure.cluster.x-k8s.io/v1alpha3",
+		Kind:       "InfrastructureConfig",
+		Name:       "test",
+	}
+	missingRef := &corev1.ObjectReference{
+		APIVersion: "bootstrap.cluster.x-k8s.io/v1alpha3",
+		Kind:       "BootstrapConfig",
+		Name:       "missing",
+	}
+	infraConfig := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"kind":       infraRef.Kind,
+			"apiVersion": infraRef.APIVersion,
+			"metadata": map[string]interface{}{
+				"name":      infraRef.Name,
+				"namespace": "test-namespace",
+			},
+		},
+	}
+	cluster := &clusterv1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-cluster",
+			Namespace: "test-namespace",
+		},
+		Spec: clusterv1.ClusterSpec{
+			Paused: true,
+		},
+	}
+
+	c := fake.NewFakeClientWithScheme(scheme.Scheme, cluster, infraConfig)
+	getAnnotations := func() map[string]string {
+		obj := &unstructured.Unstructured{}
+		obj.SetAPIVersion(infraRef.APIVersion)
+		obj.SetKind(infraRef.Kind)
+		g.Expect(c.Get(context.Background(), client.ObjectKey{Namespace: "test-namespace", Name: infraRef.Name}, obj)).To(Succeed())
+		return obj.GetAnnotations()
+	}
+
+	// The pause of the Cluster is propagated to the existing objects, while missing and nil references are ignored.
+	g.Expect(reconcilePausePropagation(context.Background(), c, cluster, "test-namespace", infraRef, missingRef, nil)).To(Succeed())
+	g.Expect(getAnnotations()).To(HaveKeyWithValue(clusterv1.PausedAnnotation, "true"))
+	g.Expect(getAnnotations()).To(HaveKeyWithValue(clusterv1.PausePropagatedAnnotation, "test-cluster"))
+
+	// The propagated pause is removed when the Cluster is resumed.
+	cluster.Spec.Paused = false
+	g.Expect(reconcilePausePropagation(context.Background(), c, cluster, "test-namespace", infraRef)).To(Succeed())
+	g.Expect(getAnnotations()).NotTo(HaveKey(clusterv1.PausedAnnotation))
+	g.Expect(getAnnotations()).NotTo(HaveKey(clusterv1.PausePropagatedAnnotation))
+}
+
+func TestClusterPauseChanged(t *testing.T) {
+	g := NewWithT(t)
+
+	running := &clusterv1.Cluster{ObjectMeta: metav1.ObjectMeta{Name: "test-cluster", Generation: 1}}
+	runningChanged := &clusterv1.Cluster{ObjectMeta: metav1.ObjectMeta{Name: "test-cluster", Generation: 2}}
+	paused := &clusterv1.Cluster{ObjectMeta: metav1.ObjectMeta{Name: "test-cluster"}, Spec: clusterv1.ClusterSpec{Paused: true}}
+
+	g.Expect(clusterPauseChanged.Update(event.UpdateEvent{ObjectOld: running, ObjectNew: paused})).To(BeTrue())
+	g.Expect(clusterPauseChanged.Update(event.UpdateEvent{ObjectOld: paused, ObjectNew: running})).To(BeTrue())
+	g.Expect(clusterPauseChanged.Update(event.UpdateEvent{ObjectOld: running, ObjectNew: runningChanged})).To(BeFalse())
+	g.Expect(clusterPauseChanged.Create(event.CreateEvent{Object: paused})).To(BeFalse())
+}
@@ -0,0 +1,111 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package preflight implements the checks run against a workload cluster before starting a rollout.
+package preflight
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kerrors "k8s.io/apimachinery/pkg/util/errors"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/controllers/noderefutil"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const crashLoopBackOffReason = "CrashLoopBackOff"
+
+// Check inspects the state of a workload cluster and returns a description of every failure found.
+type Check func(ctx context.Context, c client.Client) ([]string, error)
+
+// Run runs the given checks against a workload cluster and returns the failures found by all of them.
+func Run(ctx context.Context, c client.Client, checks ...Check) ([]string, error) {
+	var failures []string
+	var errs []error
+	for _, check := range checks {
+		checkFailures, err := check(ctx, c)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		failures = append(failures, checkFailures...)
+	}
+	return failures, kerrors.NewAggregate(errs)
+}
+
+// IsSkipped returns true if the object has the annotation for skipping the preflight checks.
+func IsSkipped(o metav1.Object) bool {
+	_, ok := o.GetAnnotations()[clusterv1.SkipUpgradePreflightChecksAnnotation]
+	return ok
+}
+
+// NodesReady checks that all the Nodes in the workload cluster are Ready.
+func NodesReady(ctx context.Context, c client.Client) ([]string, error) {
+	nodes := &corev1.NodeList{}
+	if err := c.List(ctx, nodes); err != nil {
+		return nil, errors.Wrap(err, "failed to list nodes")
+	}
+
+	var failures []string
+	for i := range nodes.Items {
+		if !noderefutil.IsNodeReady(&nodes.Items[i]) {
+			failures = append(failures, fmt.Sprintf("node %q is not ready", nodes.Items[i].Name))
+		}
+	}
+	return failures, nil
+}
+
+// ControlPlanePodsNotCrashLooping checks that none of the control plane Pods in the workload cluster
+// has a container in CrashLoopBackOff.
+func ControlPlanePodsNotCrashLooping(ctx context.Context, c client.Client) ([]string, error) {
+	pods := &corev1.PodList{}
+	if err := c.List(ctx, pods, client.InNamespace(metav1.NamespaceSystem), client.MatchingLabels{"tier": "control-plane"}); err != nil {
+		return nil, errors.Wrap(err, "failed to list control plane pods")
+	}
+
+	var failures []string
+	for _, pod := range pods.Items {
+		for _, status := range pod.Status.ContainerStatuses {
+			if status.State.Waiting != nil && status.State.Waiting.Reason == crashLoopBackOffReason {
+				failures = append(failures, fmt.Sprintf("pod %s/%s is in %s", pod.Namespace, pod.Name, crashLoopBackOffReason))
+				break
+			}
+		}
+	}
+	return failures, nil
+}
+
+// PodDisruptionBudgetsAllowDisruption checks that all the PodDisruptionBudgets in the workload cluster allow
+// at least one disruption, so Machines can be drained during the rollout.
+func PodDisruptionBudgetsAllowDisruption(ctx context.Context, c client.Client) ([]string, error) {
+	pdbs := &policyv1beta1.PodDisruptionBudgetList{}
+	if err := c.List(ctx, pdbs); err != nil {
+		return nil, errors.Wrap(err, "failed to list pod disruption budgets")
+	}
+
+	var failures []string
+	for _, pdb := range pdbs.Items {
+		if pdb.Status.ExpectedPods > 0 && pdb.Status.PodDisruptionsAllowed == 0 {
+			failures = append(failures, fmt.Sprintf("pod disruption budget %s/%s does not allow any disruption", pdb.Namespace, pdb.Name))
+		}
+	}
+	return failures, nil
+}
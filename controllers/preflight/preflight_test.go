@@ -0,0 +1,135 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package preflight
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestRun(t *testing.T) {
+	readyNode := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "ready"},
+		Status: corev1.NodeStatus{
+			Conditions: []corev1.NodeCondition{{Type: corev1.NodeReady, Status: corev1.ConditionTrue}},
+		},
+	}
+	notReadyNode := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "not-ready"},
+		Status: corev1.NodeStatus{
+			Conditions: []corev1.NodeCondition{{Type: corev1.NodeReady, Status: corev1.ConditionFalse}},
+		},
+	}
+	runningPod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "kube-apiserver-ready",
+			Namespace: metav1.NamespaceSystem,
+			Labels:    map[string]string{"tier": "control-plane"},
+		},
+		Status: corev1.PodStatus{
+			ContainerStatuses: []corev1.ContainerStatus{{State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}}}},
+		},
+	}
+	crashLoopingPod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "kube-apiserver-crashing",
+			Namespace: metav1.NamespaceSystem,
+			Labels:    map[string]string{"tier": "control-plane"},
+		},
+		Status: corev1.PodStatus{
+			ContainerStatuses: []corev1.ContainerStatus{{State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: crashLoopBackOffReason}}}},
+		},
+	}
+	crashLoopingWorkloadPod := crashLoopingPod.DeepCopy()
+	crashLoopingWorkloadPod.Name = "workload"
+	crashLoopingWorkloadPod.Labels = nil
+	allowingPDB := &policyv1beta1.PodDisruptionBudget{
+		ObjectMeta: metav1.ObjectMeta{Name: "allowing", Namespace: "default"},
+		Status:     policyv1beta1.PodDisruptionBudgetStatus{ExpectedPods: 2, PodDisruptionsAllowed: 1},
+	}
+	blockingPDB := &policyv1beta1.PodDisruptionBudget{
+		ObjectMeta: metav1.ObjectMeta{Name: "blocking", Namespace: "default"},
+		Status:     policyv1beta1.PodDisruptionBudgetStatus{ExpectedPods: 1, PodDisruptionsAllowed: 0},
+	}
+
+	tests := []struct {
+		name             string
+		objs             []runtime.Object
+		expectedFailures []string
+	}{
+		{
+			name: "should pass when the workload cluster is healthy",
+			objs: []runtime.Object{readyNode, runningPod, crashLoopingWorkloadPod, allowingPDB},
+		},
+		{
+			name: "should report all the failures",
+			objs: []runtime.Object{readyNode, notReadyNode, runningPod, crashLoopingPod, allowingPDB, blockingPDB},
+			expectedFailures: []string{
+				`node "not-ready" is not ready`,
+				"pod kube-system/kube-apiserver-crashing is in CrashLoopBackOff",
+				"pod disruption budget default/blocking does not allow any disruption",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			c := fake.NewFakeClientWithScheme(scheme.Scheme, tt.objs...)
+			failures, err := Run(context.Background(), c, NodesReady, ControlPlanePodsNotCrashLooping, PodDisruptionBudgetsAllowDisruption)
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(failures).To(Equal(tt.expectedFailures))
+		})
+	}
+}
+
+func TestRunAggregatesErrors(t *testing.T) {
+	g := NewWithT(t)
+
+	failingCheck := func(_ context.Context, _ client.Client) ([]string, error) {
+		return nil, errors.New("failed")
+	}
+	failingCheckWithFailures := func(_ context.Context, _ client.Client) ([]string, error) {
+		return []string{"failure"}, nil
+	}
+
+	failures, err := Run(context.Background(), fake.NewFakeClientWithScheme(scheme.Scheme), failingCheck, failingCheckWithFailures)
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(failures).To(Equal([]string{"failure"}))
+}
+
+func TestIsSkipped(t *testing.T) {
+	g := NewWithT(t)
+
+	o := &metav1.ObjectMeta{}
+	g.Expect(IsSkipped(o)).To(BeFalse())
+
+	o.Annotations = map[string]string{clusterv1.SkipUpgradePreflightChecksAnnotation: ""}
+	g.Expect(IsSkipped(o)).To(BeTrue())
+}
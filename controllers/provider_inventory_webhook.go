@@ -0,0 +1,169 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/apimachinery/pkg/util/version"
+	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// +kubebuilder:webhook:verbs=create;update,path=/validate-clusterctl-cluster-x-k8s-io-v1alpha3-provider,mutating=false,failurePolicy=ignore,groups=clusterctl.cluster.x-k8s.io,resources=providers,versions=v1alpha3,name=validation.provider.clusterctl.cluster.x-k8s.io
+// +kubebuilder:rbac:groups=clusterctl.cluster.x-k8s.io,resources=providers,verbs=get;list;watch
+// +kubebuilder:rbac:groups=core,resources=namespaces,verbs=get;list;watch
+
+// ProviderInventoryValidator is an admission webhook rejecting the changes to the clusterctl inventory which would
+// corrupt the management groups, e.g. a manual edit making two instances of the same provider watch the same
+// namespaces, or moving a provider into a management group supporting a different API Version of Cluster API
+// (contract); without the webhook, these changes are discovered only by the next clusterctl operation.
+// NB. The webhook fails open, because the inventory items are written by clusterctl also while upgrading the core
+// provider serving the webhook.
+type ProviderInventoryValidator struct {
+	// Client is used for reading the inventory items and the namespaces; an uncached client is recommended, so
+	// the inventory items are not watched.
+	Client client.Reader
+}
+
+var _ admission.Handler = &ProviderInventoryValidator{}
+
+// SetupWebhookWithManager registers the webhook with the manager webhook server at the given path.
+func (v *ProviderInventoryValidator) SetupWebhookWithManager(mgr ctrl.Manager, path string) error {
+	mgr.GetWebhookServer().Register(path, &webhook.Admission{Handler: v})
+	return nil
+}
+
+// Handle implements admission.Handler.
+func (v *ProviderInventoryValidator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	if req.Operation != admissionv1beta1.Create && req.Operation != admissionv1beta1.Update {
+		return admission.Allowed("")
+	}
+
+	provider := &clusterctlv1.Provider{}
+	if err := json.Unmarshal(req.Object.Raw, provider); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+	var oldProvider *clusterctlv1.Provider
+	if req.Operation == admissionv1beta1.Update {
+		oldProvider = &clusterctlv1.Provider{}
+		if err := json.Unmarshal(req.OldObject.Raw, oldProvider); err != nil {
+			return admission.Errored(http.StatusBadRequest, err)
+		}
+	}
+
+	allErrs := validateProvider(provider, oldProvider)
+	if len(allErrs) == 0 {
+		errs, err := v.validateManagementGroup(ctx, provider)
+		if err != nil {
+			return admission.Errored(http.StatusInternalServerError, err)
+		}
+		allErrs = append(allErrs, errs...)
+	}
+
+	if len(allErrs) > 0 {
+		gk := schema.GroupKind{Group: req.Kind.Group, Kind: req.Kind.Kind}
+		return admission.Denied(apierrors.NewInvalid(gk, provider.Name, allErrs).Error())
+	}
+	return admission.Allowed("")
+}
+
+// validateProvider validates the fields of an inventory item.
+func validateProvider(provider, oldProvider *clusterctlv1.Provider) field.ErrorList {
+	var allErrs field.ErrorList
+
+	if provider.GetProviderType() == clusterctlv1.ProviderTypeUnknown {
+		allErrs = append(allErrs, field.NotSupported(field.NewPath("type"), provider.Type, []string{
+			string(clusterctlv1.CoreProviderType),
+			string(clusterctlv1.BootstrapProviderType),
+			string(clusterctlv1.InfrastructureProviderType),
+			string(clusterctlv1.ControlPlaneProviderType),
+		}))
+	}
+	if oldProvider != nil && provider.Type != oldProvider.Type {
+		allErrs = append(allErrs, field.Forbidden(field.NewPath("type"), "the provider type cannot be changed"))
+	}
+
+	if provider.Version != "" {
+		if _, err := version.ParseSemantic(provider.Version); err != nil {
+			allErrs = append(allErrs, field.Invalid(field.NewPath("version"), provider.Version, err.Error()))
+		}
+	}
+
+	if provider.WatchedNamespaceSelector != nil {
+		if _, err := metav1.LabelSelectorAsSelector(provider.WatchedNamespaceSelector); err != nil {
+			allErrs = append(allErrs, field.Invalid(field.NewPath("watchedNamespaceSelector"), provider.WatchedNamespaceSelector, err.Error()))
+		}
+	}
+	return allErrs
+}
+
+// validateManagementGroup validates an inventory item against the other ones, checking that:
+// - instances of the same provider are not watching the same namespaces;
+// - the provider supports the same contract of the core providers whose management group it belongs to.
+// NB. The status of the inventory items is written separately by clusterctl, so the contract is checked only when
+// it is already recorded.
+func (v *ProviderInventoryValidator) validateManagementGroup(ctx context.Context, provider *clusterctlv1.Provider) (field.ErrorList, error) {
+	providerList := &clusterctlv1.ProviderList{}
+	if err := v.Client.List(ctx, providerList); err != nil {
+		return nil, err
+	}
+	namespaceList := &corev1.NamespaceList{}
+	if err := v.Client.List(ctx, namespaceList); err != nil {
+		return nil, err
+	}
+
+	var allErrs field.ErrorList
+	for i := range providerList.Items {
+		other := providerList.Items[i]
+		if other.Namespace == provider.Namespace && other.Name == provider.Name {
+			continue
+		}
+		if !provider.HasWatchingOverlapWith(other, namespaceList.Items) {
+			continue
+		}
+
+		if other.Name == provider.Name && other.Type == provider.Type {
+			allErrs = append(allErrs, field.Invalid(field.NewPath("watchedNamespace"), provider.WatchedNamespace,
+				fmt.Sprintf("the %s instance of the same provider is watching the same namespaces", other.InstanceName())))
+			continue
+		}
+
+		isCore := provider.GetProviderType() == clusterctlv1.CoreProviderType
+		if isCore == (other.GetProviderType() == clusterctlv1.CoreProviderType) {
+			continue
+		}
+		if provider.Status.Contract == "" || other.Status.Contract == "" || provider.Status.Contract == other.Status.Contract {
+			continue
+		}
+		allErrs = append(allErrs, field.Invalid(field.NewPath("status", "contract"), provider.Status.Contract,
+			fmt.Sprintf("the provider belongs to the same management group of %s, which supports the %s contract", other.InstanceName(), other.Status.Contract)))
+	}
+	return allErrs, nil
+}
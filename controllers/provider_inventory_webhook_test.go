@@ -0,0 +1,138 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"encoding/json"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+func TestProviderInventoryValidator(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := clusterctlv1.AddToScheme(scheme); err != nil {
+		t.Fatal("failed to register clusterctl objects to scheme")
+	}
+	if err := corev1.AddToScheme(scheme); err != nil {
+		t.Fatal("failed to register core objects to scheme")
+	}
+
+	provider := func(namespace, name string, providerType clusterctlv1.ProviderType, watchedNamespace, contract string) *clusterctlv1.Provider {
+		return &clusterctlv1.Provider{
+			ObjectMeta:       metav1.ObjectMeta{Namespace: namespace, Name: name},
+			Type:             string(providerType),
+			Version:          "v0.3.0",
+			WatchedNamespace: watchedNamespace,
+			Status:           clusterctlv1.ProviderStatus{Contract: contract},
+		}
+	}
+	c := fake.NewFakeClientWithScheme(scheme,
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "ns1"}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "ns2"}},
+		provider("capi-system", "cluster-api", clusterctlv1.CoreProviderType, "ns1", "v1alpha3"),
+		provider("capa-system", "infrastructure-aws", clusterctlv1.InfrastructureProviderType, "ns1", "v1alpha3"),
+	)
+	v := &ProviderInventoryValidator{Client: c}
+
+	request := func(operation admissionv1beta1.Operation, obj, oldObj *clusterctlv1.Provider) admission.Request {
+		req := admission.Request{AdmissionRequest: admissionv1beta1.AdmissionRequest{
+			Kind:      metav1.GroupVersionKind{Group: clusterctlv1.GroupVersion.Group, Version: clusterctlv1.GroupVersion.Version, Kind: "Provider"},
+			Operation: operation,
+		}}
+		req.Object.Raw, _ = json.Marshal(obj)
+		if oldObj != nil {
+			req.OldObject.Raw, _ = json.Marshal(oldObj)
+		}
+		return req
+	}
+	withVersion := func(p *clusterctlv1.Provider, version string) *clusterctlv1.Provider {
+		p.Version = version
+		return p
+	}
+
+	tests := []struct {
+		name    string
+		req     admission.Request
+		allowed bool
+	}{
+		{
+			name:    "a provider in a management group with the same contract is allowed",
+			req:     request(admissionv1beta1.Create, provider("cabpk-system", "bootstrap-kubeadm", clusterctlv1.BootstrapProviderType, "ns1", "v1alpha3"), nil),
+			allowed: true,
+		},
+		{
+			name:    "a provider without a recorded contract is allowed",
+			req:     request(admissionv1beta1.Create, provider("cabpk-system", "bootstrap-kubeadm", clusterctlv1.BootstrapProviderType, "ns1", ""), nil),
+			allowed: true,
+		},
+		{
+			name:    "an unknown provider type is rejected",
+			req:     request(admissionv1beta1.Create, provider("cabpk-system", "bootstrap-kubeadm", "Bootstrap", "ns1", ""), nil),
+			allowed: false,
+		},
+		{
+			name:    "an invalid version is rejected",
+			req:     request(admissionv1beta1.Create, withVersion(provider("cabpk-system", "bootstrap-kubeadm", clusterctlv1.BootstrapProviderType, "ns1", ""), "latest"), nil),
+			allowed: false,
+		},
+		{
+			name:    "an instance of the same provider watching the same namespaces is rejected",
+			req:     request(admissionv1beta1.Create, provider("capa-system-2", "infrastructure-aws", clusterctlv1.InfrastructureProviderType, "ns1", ""), nil),
+			allowed: false,
+		},
+		{
+			name:    "an instance of the same provider watching other namespaces is allowed",
+			req:     request(admissionv1beta1.Create, provider("capa-system-2", "infrastructure-aws", clusterctlv1.InfrastructureProviderType, "ns2", ""), nil),
+			allowed: true,
+		},
+		{
+			name: "moving a provider into a management group with a different contract is rejected",
+			req: request(admissionv1beta1.Update,
+				provider("capa-system", "infrastructure-aws", clusterctlv1.InfrastructureProviderType, "ns1", "v1alpha4"),
+				provider("capa-system", "infrastructure-aws", clusterctlv1.InfrastructureProviderType, "ns2", "v1alpha4")),
+			allowed: false,
+		},
+		{
+			name: "changing the provider type is rejected",
+			req: request(admissionv1beta1.Update,
+				provider("capa-system", "infrastructure-aws", clusterctlv1.ControlPlaneProviderType, "ns1", "v1alpha3"),
+				provider("capa-system", "infrastructure-aws", clusterctlv1.InfrastructureProviderType, "ns1", "v1alpha3")),
+			allowed: false,
+		},
+		{
+			name:    "deletions are allowed",
+			req:     request(admissionv1beta1.Delete, provider("capa-system", "infrastructure-aws", "", "ns1", ""), nil),
+			allowed: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			resp := v.Handle(ctx, tt.req)
+			g.Expect(resp.Allowed).To(Equal(tt.allowed), "response: %v", resp.Result)
+		})
+	}
+}
@@ -0,0 +1,127 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	utilversion "sigs.k8s.io/cluster-api/util/version"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// +kubebuilder:webhook:verbs=create;update,path=/validate-cluster-x-k8s-io-v1alpha3-version-skew,mutating=false,failurePolicy=fail,groups=cluster.x-k8s.io,resources=machinedeployments,versions=v1alpha3,name=validation-version-skew.machinedeployment.cluster.x-k8s.io
+
+// VersionSkewValidator is an admission webhook rejecting the MachineDeployments whose Kubernetes version is newer
+// than the version of the control plane of their Cluster, as read from the spec.version field of the control plane
+// object, because the kubelet must not be newer than the API server; without the webhook, the new Machines
+// fail to join the cluster.
+// The version is validated only on create and when changed on update, so existing MachineDeployments can still be
+// updated, e.g. scaled.
+type VersionSkewValidator struct {
+	// Client is used for reading the Clusters and their control plane objects.
+	Client client.Reader
+}
+
+var _ admission.Handler = &VersionSkewValidator{}
+
+// SetupWebhookWithManager registers the webhook with the manager webhook server at the given path.
+func (v *VersionSkewValidator) SetupWebhookWithManager(mgr ctrl.Manager, path string) error {
+	mgr.GetWebhookServer().Register(path, &webhook.Admission{Handler: v})
+	return nil
+}
+
+// Handle implements admission.Handler.
+func (v *VersionSkewValidator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	if req.Operation != admissionv1beta1.Create && req.Operation != admissionv1beta1.Update {
+		return admission.Allowed("")
+	}
+
+	md := &clusterv1.MachineDeployment{}
+	if err := json.Unmarshal(req.Object.Raw, md); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+	workerVersion := md.Spec.Template.Spec.Version
+	if workerVersion == nil || *workerVersion == "" {
+		return admission.Allowed("")
+	}
+	if req.Operation == admissionv1beta1.Update {
+		oldMD := &clusterv1.MachineDeployment{}
+		if err := json.Unmarshal(req.OldObject.Raw, oldMD); err != nil {
+			return admission.Errored(http.StatusBadRequest, err)
+		}
+		if oldVersion := oldMD.Spec.Template.Spec.Version; oldVersion != nil && *oldVersion == *workerVersion {
+			return admission.Allowed("")
+		}
+	}
+
+	controlPlaneVersion, err := v.controlPlaneVersion(ctx, md.Namespace, md.Spec.ClusterName)
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	if controlPlaneVersion == "" {
+		return admission.Allowed("")
+	}
+
+	if err := utilversion.ValidateWorkerSkew(controlPlaneVersion, *workerVersion); err != nil {
+		gk := schema.GroupKind{Group: req.Kind.Group, Kind: req.Kind.Kind}
+		allErrs := field.ErrorList{field.Forbidden(field.NewPath("spec", "template", "spec", "version"), err.Error())}
+		return admission.Denied(apierrors.NewInvalid(gk, md.Name, allErrs).Error())
+	}
+	return admission.Allowed("")
+}
+
+// controlPlaneVersion returns the version of the control plane of a Cluster; it returns an empty string if the
+// Cluster or its control plane object do not exist yet, or if the control plane object has no version.
+func (v *VersionSkewValidator) controlPlaneVersion(ctx context.Context, namespace, clusterName string) (string, error) {
+	cluster := &clusterv1.Cluster{}
+	if err := v.Client.Get(ctx, client.ObjectKey{Namespace: namespace, Name: clusterName}, cluster); err != nil {
+		if apierrors.IsNotFound(err) {
+			return "", nil
+		}
+		return "", err
+	}
+
+	ref := cluster.Spec.ControlPlaneRef
+	if ref == nil {
+		return "", nil
+	}
+	controlPlane := &unstructured.Unstructured{}
+	controlPlane.SetAPIVersion(ref.APIVersion)
+	controlPlane.SetKind(ref.Kind)
+	if err := v.Client.Get(ctx, client.ObjectKey{Namespace: namespace, Name: ref.Name}, controlPlane); err != nil {
+		if apierrors.IsNotFound(err) {
+			return "", nil
+		}
+		return "", err
+	}
+	version, _, err := unstructured.NestedString(controlPlane.Object, "spec", "version")
+	if err != nil {
+		return "", err
+	}
+	return version, nil
+}
@@ -0,0 +1,139 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"encoding/json"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/utils/pointer"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+func TestVersionSkewValidator(t *testing.T) {
+	g := NewWithT(t)
+	g.Expect(clusterv1.AddToScheme(scheme.Scheme)).To(Succeed())
+
+	cluster := func(name string, controlPlaneRef *corev1.ObjectReference) *clusterv1.Cluster {
+		return &clusterv1.Cluster{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name},
+			Spec:       clusterv1.ClusterSpec{ControlPlaneRef: controlPlaneRef},
+		}
+	}
+	controlPlane := &unstructured.Unstructured{Object: map[string]interface{}{
+		"kind":       "ControlPlaneConfig",
+		"apiVersion": "controlplane.cluster.x-k8s.io/v1alpha3",
+		"metadata": map[string]interface{}{
+			"name":      "control-plane",
+			"namespace": "default",
+		},
+		"spec": map[string]interface{}{
+			"version": "v1.17.3",
+		},
+	}}
+	c := fake.NewFakeClientWithScheme(scheme.Scheme,
+		cluster("cluster", &corev1.ObjectReference{
+			APIVersion: "controlplane.cluster.x-k8s.io/v1alpha3",
+			Kind:       "ControlPlaneConfig",
+			Name:       "control-plane",
+		}),
+		cluster("cluster-without-control-plane", nil),
+		controlPlane,
+	)
+	v := &VersionSkewValidator{Client: c}
+
+	md := func(clusterName, version string) *clusterv1.MachineDeployment {
+		return &clusterv1.MachineDeployment{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "md"},
+			Spec: clusterv1.MachineDeploymentSpec{
+				ClusterName: clusterName,
+				Template: clusterv1.MachineTemplateSpec{
+					Spec: clusterv1.MachineSpec{ClusterName: clusterName, Version: pointer.StringPtr(version)},
+				},
+			},
+		}
+	}
+	request := func(operation admissionv1beta1.Operation, obj, oldObj *clusterv1.MachineDeployment) admission.Request {
+		req := admission.Request{AdmissionRequest: admissionv1beta1.AdmissionRequest{
+			Kind:      metav1.GroupVersionKind{Group: clusterv1.GroupVersion.Group, Version: clusterv1.GroupVersion.Version, Kind: "MachineDeployment"},
+			Operation: operation,
+		}}
+		req.Object.Raw, _ = json.Marshal(obj)
+		if oldObj != nil {
+			req.OldObject.Raw, _ = json.Marshal(oldObj)
+		}
+		return req
+	}
+
+	tests := []struct {
+		name    string
+		req     admission.Request
+		allowed bool
+	}{
+		{
+			name:    "workers with the version of the control plane are allowed",
+			req:     request(admissionv1beta1.Create, md("cluster", "v1.17.3"), nil),
+			allowed: true,
+		},
+		{
+			name:    "workers older than the control plane are allowed",
+			req:     request(admissionv1beta1.Create, md("cluster", "v1.16.2"), nil),
+			allowed: true,
+		},
+		{
+			name:    "workers newer than the control plane are rejected",
+			req:     request(admissionv1beta1.Create, md("cluster", "v1.18.0"), nil),
+			allowed: false,
+		},
+		{
+			name:    "upgrading the workers before the control plane is rejected",
+			req:     request(admissionv1beta1.Update, md("cluster", "v1.18.0"), md("cluster", "v1.17.3")),
+			allowed: false,
+		},
+		{
+			name:    "updating workers already newer than the control plane without changing the version is allowed",
+			req:     request(admissionv1beta1.Update, md("cluster", "v1.18.0"), md("cluster", "v1.18.0")),
+			allowed: true,
+		},
+		{
+			name:    "workers of a cluster without a control plane object are allowed",
+			req:     request(admissionv1beta1.Create, md("cluster-without-control-plane", "v1.18.0"), nil),
+			allowed: true,
+		},
+		{
+			name:    "workers of a cluster not created yet are allowed",
+			req:     request(admissionv1beta1.Create, md("missing", "v1.18.0"), nil),
+			allowed: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			resp := v.Handle(ctx, tt.req)
+			g.Expect(resp.Allowed).To(Equal(tt.allowed), "response: %v", resp.Result)
+		})
+	}
+}
@@ -0,0 +1,99 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha3
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	cabpkv1 "sigs.k8s.io/cluster-api/bootstrap/kubeadm/api/v1alpha3"
+)
+
+// KubeadmControlPlaneTemplateSpec defines the desired state of KubeadmControlPlaneTemplate.
+type KubeadmControlPlaneTemplateSpec struct {
+	Template KubeadmControlPlaneTemplateResource `json:"template"`
+}
+
+// KubeadmControlPlaneTemplateResource describes the data needed to create a KubeadmControlPlane from a template.
+type KubeadmControlPlaneTemplateResource struct {
+	Spec KubeadmControlPlaneTemplateResourceSpec `json:"spec"`
+}
+
+// KubeadmControlPlaneTemplateResourceSpec defines the desired state of the KubeadmControlPlanes created from the template;
+// the replicas, the version and the infrastructure template are set by the Cluster topology.
+type KubeadmControlPlaneTemplateResourceSpec struct {
+	// KubeadmConfigSpec is a KubeadmConfigSpec
+	// to use for initializing and joining machines to the control plane.
+	KubeadmConfigSpec cabpkv1.KubeadmConfigSpec `json:"kubeadmConfigSpec"`
+
+	// RolloutStrategy is the strategy used to replace control plane Machines
+	// that no longer match the desired configuration.
+	// Defaults to a RollingUpdate with MaxSurge 1.
+	// +optional
+	RolloutStrategy *RolloutStrategy `json:"rolloutStrategy,omitempty"`
+
+	// ScaleUpStrategy is the strategy used to add control plane Machines
+	// when scaling up. Defaults to joining one Machine at a time.
+	// +optional
+	ScaleUpStrategy *ScaleUpStrategy `json:"scaleUpStrategy,omitempty"`
+
+	// RemediationStrategy is the strategy used to remediate the control plane
+	// Machines marked as unhealthy by a MachineHealthCheck.
+	// +optional
+	RemediationStrategy *RemediationStrategy `json:"remediationStrategy,omitempty"`
+
+	// EtcdBackup enables periodic snapshots of the managed etcd cluster,
+	// taken by Jobs created in the kube-system namespace of the workload cluster.
+	// It can not be used with an external etcd cluster.
+	// +optional
+	EtcdBackup *EtcdBackup `json:"etcdBackup,omitempty"`
+
+	// RolloutBefore is a field to indicate a rollout should be performed
+	// if the specified criteria is met, e.g. to renew the certificates of
+	// the control plane Machines before they expire.
+	// +optional
+	RolloutBefore *RolloutBefore `json:"rolloutBefore,omitempty"`
+
+	// NodeMetadata are the labels, annotations and taints set on the control
+	// plane Machines and synced to their Nodes. Changes are applied in place
+	// to the existing Machines and Nodes, without triggering a rollout.
+	// +optional
+	NodeMetadata *NodeMetadata `json:"nodeMetadata,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:path=kubeadmcontrolplanetemplates,scope=Namespaced,categories=cluster-api
+
+// KubeadmControlPlaneTemplate is the Schema for the kubeadmcontrolplanetemplates API.
+type KubeadmControlPlaneTemplate struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec KubeadmControlPlaneTemplateSpec `json:"spec,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// KubeadmControlPlaneTemplateList contains a list of KubeadmControlPlaneTemplate.
+type KubeadmControlPlaneTemplateList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []KubeadmControlPlaneTemplate `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&KubeadmControlPlaneTemplate{}, &KubeadmControlPlaneTemplateList{})
+}
@@ -19,7 +19,9 @@ package v1alpha3
 import (
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
 	cabpkv1 "sigs.k8s.io/cluster-api/bootstrap/kubeadm/api/v1alpha3"
 	"sigs.k8s.io/cluster-api/errors"
 )
@@ -27,6 +29,26 @@ import (
 const (
 	KubeadmControlPlaneFinalizer    = "kubeadm.controlplane.cluster.x-k8s.io"
 	KubeadmControlPlaneHashLabelKey = "kubeadm.controlplane.cluster.x-k8s.io/hash"
+
+	// CertificatesExpiryAnnotation is set on the control plane Machines to the expiry date of
+	// the certificates of their Node, in RFC3339 format.
+	CertificatesExpiryAnnotation = "controlplane.cluster.x-k8s.io/certificates-expiry"
+
+	// DefaultEtcdSnapshotHostPath is the default directory on the control plane Nodes where etcd snapshots are saved.
+	DefaultEtcdSnapshotHostPath = "/var/lib/etcd-snapshots"
+
+	// MinimumCertificatesExpiryDays is the minimum value of RolloutBefore.CertificatesExpiryDays,
+	// leaving time for the rollout to complete before the certificates expire.
+	MinimumCertificatesExpiryDays = 7
+)
+
+// RolloutStrategyType defines the rollout strategies for a KubeadmControlPlane.
+type RolloutStrategyType string
+
+const (
+	// RollingUpdateStrategyType replaces the old control plane Machines by new ones one at a time,
+	// using at most MaxSurge Machines above the desired number of replicas.
+	RollingUpdateStrategyType RolloutStrategyType = "RollingUpdate"
 )
 
 // KubeadmControlPlaneSpec defines the desired state of KubeadmControlPlane.
@@ -54,6 +76,185 @@ type KubeadmControlPlaneSpec struct {
 	// KubeadmControlPlane
 	// +optional
 	UpgradeAfter *metav1.Time `json:"upgradeAfter,omitempty"`
+
+	// RolloutStrategy is the strategy used to replace control plane Machines
+	// that no longer match the desired configuration.
+	// Defaults to a RollingUpdate with MaxSurge 1.
+	// +optional
+	RolloutStrategy *RolloutStrategy `json:"rolloutStrategy,omitempty"`
+
+	// ScaleUpStrategy is the strategy used to add control plane Machines
+	// when scaling up. Defaults to joining one Machine at a time.
+	// +optional
+	ScaleUpStrategy *ScaleUpStrategy `json:"scaleUpStrategy,omitempty"`
+
+	// RemediationStrategy is the strategy used to remediate the control plane
+	// Machines marked as unhealthy by a MachineHealthCheck.
+	// +optional
+	RemediationStrategy *RemediationStrategy `json:"remediationStrategy,omitempty"`
+
+	// EtcdBackup enables periodic snapshots of the managed etcd cluster,
+	// taken by Jobs created in the kube-system namespace of the workload cluster.
+	// It can not be used with an external etcd cluster.
+	// +optional
+	EtcdBackup *EtcdBackup `json:"etcdBackup,omitempty"`
+
+	// RolloutBefore is a field to indicate a rollout should be performed
+	// if the specified criteria is met, e.g. to renew the certificates of
+	// the control plane Machines before they expire.
+	// +optional
+	RolloutBefore *RolloutBefore `json:"rolloutBefore,omitempty"`
+
+	// NodeMetadata are the labels, annotations and taints set on the control
+	// plane Machines and synced to their Nodes. Changes are applied in place
+	// to the existing Machines and Nodes, without triggering a rollout.
+	// +optional
+	NodeMetadata *NodeMetadata `json:"nodeMetadata,omitempty"`
+}
+
+// NodeMetadata defines the metadata of the control plane Machines that is synced to their Nodes.
+type NodeMetadata struct {
+	// Labels are set on the control plane Machines and Nodes. Only keys in the
+	// node.cluster.x-k8s.io and node-role.kubernetes.io domains are allowed.
+	// +optional
+	Labels map[string]string `json:"labels,omitempty"`
+
+	// Annotations are set on the control plane Machines and Nodes. Only keys in
+	// the node.cluster.x-k8s.io and node-role.kubernetes.io domains are allowed.
+	// +optional
+	Annotations map[string]string `json:"annotations,omitempty"`
+
+	// Taints are set on the control plane Nodes, in addition to the ones set
+	// at bootstrap.
+	// +optional
+	Taints []corev1.Taint `json:"taints,omitempty"`
+}
+
+// RolloutBefore describes when a rollout should be performed on the control plane Machines.
+type RolloutBefore struct {
+	// CertificatesExpiryDays indicates a rollout needs to be performed if the
+	// certificates of the control plane Machines will expire within the
+	// specified days. Replacing a Machine creates a Node with new certificates.
+	// Must be at least 7.
+	// +optional
+	CertificatesExpiryDays *int32 `json:"certificatesExpiryDays,omitempty"`
+}
+
+// EtcdBackup configures periodic snapshots of the managed etcd cluster.
+type EtcdBackup struct {
+	// Interval is the time between two snapshots.
+	Interval metav1.Duration `json:"interval"`
+
+	// Image is the container image used for taking the snapshots; it must
+	// provide the etcdctl binary and any tool used by Command.
+	Image string `json:"image"`
+
+	// Command overrides the command taking the snapshot, e.g. for uploading it
+	// to an external storage. etcdctl is configured through the ETCDCTL_*
+	// environment variables, and the snapshot must be saved to the file
+	// defined by the SNAPSHOT_FILE environment variable.
+	// Defaults to `etcdctl snapshot save $(SNAPSHOT_FILE)`.
+	// +optional
+	Command []string `json:"command,omitempty"`
+
+	// HostPath is the directory on the control plane Node where snapshots are saved.
+	// Defaults to /var/lib/etcd-snapshots.
+	// +optional
+	HostPath string `json:"hostPath,omitempty"`
+}
+
+// RolloutStrategy describes how to replace existing control plane Machines
+// with new ones.
+type RolloutStrategy struct {
+	// Type of rollout. Currently the only supported strategy is "RollingUpdate".
+	// Default is RollingUpdate.
+	// +optional
+	Type RolloutStrategyType `json:"type,omitempty"`
+
+	// Rolling update config params. Present only if
+	// RolloutStrategyType = RollingUpdate.
+	// +optional
+	RollingUpdate *RollingUpdate `json:"rollingUpdate,omitempty"`
+}
+
+// RollingUpdate is used to control the desired behavior of rolling update.
+type RollingUpdate struct {
+	// The maximum number of control plane Machines that can be scheduled above
+	// the desired number of replicas during a rollout.
+	// Value can only be an absolute number, either 1 or 0.
+	// Defaults to 1.
+	// When this is set to 0, an old Machine is deleted before its replacement
+	// is created, which is useful in environments with hard capacity limits.
+	// A MaxSurge of 0 requires at least 3 replicas when using managed etcd, so
+	// that the etcd cluster keeps quorum while a member is being replaced.
+	// +optional
+	MaxSurge *intstr.IntOrString `json:"maxSurge,omitempty"`
+}
+
+// ScaleUpStrategy describes how new control plane Machines are added when
+// scaling up.
+type ScaleUpStrategy struct {
+	// MaxParallelJoins is the maximum number of control plane Machines that
+	// can be joining the cluster at the same time when scaling up.
+	// Parallel joins are further limited so that the etcd quorum never
+	// requires more members than the joined ones, or than a single join
+	// would; e.g. scaling from 1 to 3 or from 3 to 5 replicas can be done
+	// in one step with a value of 2.
+	// Must be at least 1. Defaults to 1.
+	// +optional
+	MaxParallelJoins *int32 `json:"maxParallelJoins,omitempty"`
+}
+
+// RemediationStrategy describes how unhealthy control plane Machines are
+// remediated.
+type RemediationStrategy struct {
+	// MaxRetry is the maximum number of retries while attempting to remediate
+	// unhealthy Machines. A retry happens when a Machine fails within
+	// MinHealthyPeriod from the previous remediation, e.g. when the Machine
+	// created as a replacement of an unhealthy one fails too.
+	// If not set, remediation is retried forever.
+	// +optional
+	MaxRetry *int32 `json:"maxRetry,omitempty"`
+
+	// RetryPeriod is the duration to wait before remediating a Machine in
+	// case of a retry. Defaults to 0, i.e. retries happen immediately.
+	// +optional
+	RetryPeriod metav1.Duration `json:"retryPeriod,omitempty"`
+
+	// MinHealthyPeriod is the duration after a remediation after which a
+	// failing Machine is not considered a retry anymore, and the retry count
+	// is reset. Defaults to 1h.
+	// +optional
+	MinHealthyPeriod *metav1.Duration `json:"minHealthyPeriod,omitempty"`
+}
+
+// LastRemediationStatus describes the last remediation of a control plane Machine.
+type LastRemediationStatus struct {
+	// Machine is the name of the last remediated Machine.
+	Machine string `json:"machine"`
+
+	// Timestamp is the time of the last remediation.
+	Timestamp metav1.Time `json:"timestamp"`
+
+	// RetryCount is the number of consecutive remediations happened within
+	// MinHealthyPeriod from each other.
+	RetryCount int32 `json:"retryCount"`
+}
+
+// EtcdSnapshot references a snapshot of the managed etcd cluster saved on a control plane Node.
+type EtcdSnapshot struct {
+	// JobName is the name of the Job that took the snapshot, in the kube-system
+	// namespace of the workload cluster.
+	JobName string `json:"jobName"`
+
+	// NodeName is the name of the Node the snapshot is saved on.
+	NodeName string `json:"nodeName"`
+
+	// Path is the path of the snapshot file on the Node.
+	Path string `json:"path"`
+
+	// CompletionTime is the time the snapshot was completed.
+	CompletionTime metav1.Time `json:"completionTime"`
 }
 
 // KubeadmControlPlaneStatus defines the observed state of KubeadmControlPlane.
@@ -93,6 +294,35 @@ type KubeadmControlPlaneStatus struct {
 	// +optional
 	Initialized bool `json:"initialized"`
 
+	// OutdatedMachines lists the names of the machines targeted by this
+	// control plane that were created with a configuration that does not
+	// match the current spec; it is updated also when reconciliation is paused,
+	// so configuration drift can be detected before rolling out changes.
+	// +optional
+	OutdatedMachines []string `json:"outdatedMachines,omitempty"`
+
+	// LastEtcdSnapshot references the latest successful snapshot of the
+	// managed etcd cluster.
+	// +optional
+	LastEtcdSnapshot *EtcdSnapshot `json:"lastEtcdSnapshot,omitempty"`
+
+	// CertificatesExpiryDate is the earliest expiry date of the certificates
+	// of the control plane Machines; if the certificates expire the workload
+	// cluster stops working.
+	// +optional
+	CertificatesExpiryDate *metav1.Time `json:"certificatesExpiryDate,omitempty"`
+
+	// PreflightCheckFailures lists the failed checks against the workload
+	// cluster that are blocking the start of a rollout; the checks can be
+	// skipped with the cluster.x-k8s.io/skip-upgrade-preflight-checks annotation.
+	// +optional
+	PreflightCheckFailures []string `json:"preflightCheckFailures,omitempty"`
+
+	// LastRemediation describes the last remediation of an unhealthy control
+	// plane Machine, used to limit the retries of failing remediations.
+	// +optional
+	LastRemediation *LastRemediationStatus `json:"lastRemediation,omitempty"`
+
 	// Ready denotes that the KubeadmControlPlane API Server is ready to
 	// receive requests.
 	// +optional
@@ -108,6 +338,10 @@ type KubeadmControlPlaneStatus struct {
 	// state, and will be set to a descriptive error message.
 	// +optional
 	FailureMessage *string `json:"failureMessage,omitempty"`
+
+	// Conditions defines current service state of the KubeadmControlPlane.
+	// +optional
+	Conditions clusterv1.Conditions `json:"conditions,omitempty"`
 }
 
 // +kubebuilder:object:root=true
@@ -131,6 +365,14 @@ type KubeadmControlPlane struct {
 	Status KubeadmControlPlaneStatus `json:"status,omitempty"`
 }
 
+func (in *KubeadmControlPlane) GetConditions() clusterv1.Conditions {
+	return in.Status.Conditions
+}
+
+func (in *KubeadmControlPlane) SetConditions(conditions clusterv1.Conditions) {
+	in.Status.Conditions = conditions
+}
+
 // +kubebuilder:object:root=true
 
 // KubeadmControlPlaneList contains a list of KubeadmControlPlane.
@@ -17,11 +17,16 @@ limitations under the License.
 package v1alpha3
 
 import (
+	"fmt"
 	"reflect"
 
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/apimachinery/pkg/util/validation/field"
+	cabpkv1 "sigs.k8s.io/cluster-api/bootstrap/kubeadm/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/util"
+	utilversion "sigs.k8s.io/cluster-api/util/version"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
 )
@@ -48,6 +53,26 @@ func (r *KubeadmControlPlane) Default() {
 	if r.Spec.InfrastructureTemplate.Namespace == "" {
 		r.Spec.InfrastructureTemplate.Namespace = r.Namespace
 	}
+
+	if r.Spec.RolloutStrategy == nil {
+		r.Spec.RolloutStrategy = &RolloutStrategy{}
+	}
+	if r.Spec.RolloutStrategy.Type == "" {
+		r.Spec.RolloutStrategy.Type = RollingUpdateStrategyType
+	}
+	if r.Spec.RolloutStrategy.Type == RollingUpdateStrategyType {
+		if r.Spec.RolloutStrategy.RollingUpdate == nil {
+			r.Spec.RolloutStrategy.RollingUpdate = &RollingUpdate{}
+		}
+		if r.Spec.RolloutStrategy.RollingUpdate.MaxSurge == nil {
+			maxSurge := intstr.FromInt(1)
+			r.Spec.RolloutStrategy.RollingUpdate.MaxSurge = &maxSurge
+		}
+	}
+
+	if r.Spec.EtcdBackup != nil && r.Spec.EtcdBackup.HostPath == "" {
+		r.Spec.EtcdBackup.HostPath = DefaultEtcdSnapshotHostPath
+	}
 }
 
 // ValidateCreate implements webhook.Validator so a webhook will be registered for the type
@@ -75,14 +100,7 @@ func (r *KubeadmControlPlane) ValidateCreate() error {
 		)
 	}
 
-	externalEtcd := false
-	if r.Spec.KubeadmConfigSpec.InitConfiguration != nil {
-		if r.Spec.KubeadmConfigSpec.InitConfiguration.Etcd.External != nil {
-			externalEtcd = true
-		}
-	}
-
-	if !externalEtcd {
+	if !r.usesExternalEtcd() {
 		if r.Spec.Replicas != nil && *r.Spec.Replicas%2 == 0 {
 			allErrs = append(
 				allErrs,
@@ -105,6 +123,23 @@ func (r *KubeadmControlPlane) ValidateCreate() error {
 		)
 	}
 
+	if r.Spec.KubeadmConfigSpec.OS == cabpkv1.Windows {
+		allErrs = append(
+			allErrs,
+			field.Forbidden(
+				field.NewPath("spec", "kubeadmConfigSpec", "os"),
+				"control plane machines cannot run Windows",
+			),
+		)
+	}
+
+	allErrs = append(allErrs, r.validateRolloutStrategy()...)
+	allErrs = append(allErrs, r.validateScaleUpStrategy()...)
+	allErrs = append(allErrs, r.validateRemediationStrategy()...)
+	allErrs = append(allErrs, r.validateEtcdBackup()...)
+	allErrs = append(allErrs, r.validateRolloutBefore()...)
+	allErrs = append(allErrs, r.validateNodeMetadata()...)
+
 	if len(allErrs) == 0 {
 		return nil
 	}
@@ -117,7 +152,13 @@ func (r *KubeadmControlPlane) ValidateUpdate(old runtime.Object) error {
 	var allErrs field.ErrorList
 
 	oldKubeadmControlPlane := old.(*KubeadmControlPlane)
-	if !reflect.DeepEqual(r.Spec.KubeadmConfigSpec, oldKubeadmControlPlane.Spec.KubeadmConfigSpec) {
+
+	// The kubelet configuration can be changed, the control plane Machines are rolled out to apply it.
+	newConfigSpec := r.Spec.KubeadmConfigSpec.DeepCopy()
+	newConfigSpec.Kubelet = nil
+	oldConfigSpec := oldKubeadmControlPlane.Spec.KubeadmConfigSpec.DeepCopy()
+	oldConfigSpec.Kubelet = nil
+	if !reflect.DeepEqual(newConfigSpec, oldConfigSpec) {
 		allErrs = append(
 			allErrs,
 			field.Forbidden(
@@ -127,6 +168,20 @@ func (r *KubeadmControlPlane) ValidateUpdate(old runtime.Object) error {
 		)
 	}
 
+	if err := utilversion.ValidateUpgrade(oldKubeadmControlPlane.Spec.Version, r.Spec.Version); err != nil {
+		allErrs = append(
+			allErrs,
+			field.Forbidden(field.NewPath("spec", "version"), err.Error()),
+		)
+	}
+
+	allErrs = append(allErrs, r.validateRolloutStrategy()...)
+	allErrs = append(allErrs, r.validateScaleUpStrategy()...)
+	allErrs = append(allErrs, r.validateRemediationStrategy()...)
+	allErrs = append(allErrs, r.validateEtcdBackup()...)
+	allErrs = append(allErrs, r.validateRolloutBefore()...)
+	allErrs = append(allErrs, r.validateNodeMetadata()...)
+
 	if len(allErrs) == 0 {
 		return nil
 	}
@@ -134,6 +189,236 @@ func (r *KubeadmControlPlane) ValidateUpdate(old runtime.Object) error {
 	return apierrors.NewInvalid(GroupVersion.WithKind("KubeadmControlPlane").GroupKind(), r.Name, allErrs)
 }
 
+// validateRolloutStrategy checks that the rollout strategy is supported and
+// that a MaxSurge of 0 cannot break etcd quorum while a member is replaced.
+func (r *KubeadmControlPlane) validateRolloutStrategy() field.ErrorList {
+	var allErrs field.ErrorList
+
+	if r.Spec.RolloutStrategy == nil {
+		return allErrs
+	}
+
+	fldPath := field.NewPath("spec", "rolloutStrategy")
+	if r.Spec.RolloutStrategy.Type != "" && r.Spec.RolloutStrategy.Type != RollingUpdateStrategyType {
+		allErrs = append(
+			allErrs,
+			field.NotSupported(
+				fldPath.Child("type"),
+				r.Spec.RolloutStrategy.Type,
+				[]string{string(RollingUpdateStrategyType)},
+			),
+		)
+	}
+
+	if r.Spec.RolloutStrategy.RollingUpdate == nil || r.Spec.RolloutStrategy.RollingUpdate.MaxSurge == nil {
+		return allErrs
+	}
+
+	maxSurge := r.Spec.RolloutStrategy.RollingUpdate.MaxSurge
+	maxSurgePath := fldPath.Child("rollingUpdate", "maxSurge")
+	if maxSurge.Type != intstr.Int || (maxSurge.IntVal != 0 && maxSurge.IntVal != 1) {
+		allErrs = append(
+			allErrs,
+			field.Invalid(
+				maxSurgePath,
+				maxSurge.String(),
+				"must be either 0 or 1",
+			),
+		)
+		return allErrs
+	}
+
+	if maxSurge.IntVal == 0 && r.Spec.Replicas != nil {
+		minReplicas := int32(3)
+		if r.usesExternalEtcd() {
+			minReplicas = 2
+		}
+		if *r.Spec.Replicas < minReplicas {
+			allErrs = append(
+				allErrs,
+				field.Forbidden(
+					maxSurgePath,
+					fmt.Sprintf("cannot be 0 with less than %d replicas, the control plane would not survive the removal of a Machine", minReplicas),
+				),
+			)
+		}
+	}
+
+	return allErrs
+}
+
+// validateScaleUpStrategy checks that at least one control plane Machine can join the cluster at a time.
+func (r *KubeadmControlPlane) validateScaleUpStrategy() field.ErrorList {
+	var allErrs field.ErrorList
+
+	if r.Spec.ScaleUpStrategy == nil || r.Spec.ScaleUpStrategy.MaxParallelJoins == nil {
+		return allErrs
+	}
+
+	if joins := *r.Spec.ScaleUpStrategy.MaxParallelJoins; joins < 1 {
+		allErrs = append(
+			allErrs,
+			field.Invalid(
+				field.NewPath("spec", "scaleUpStrategy", "maxParallelJoins"),
+				joins,
+				"must be greater than or equal to 1",
+			),
+		)
+	}
+
+	return allErrs
+}
+
+// validateRemediationStrategy checks that the retries of the remediation of unhealthy Machines are configured
+// with non negative values.
+func (r *KubeadmControlPlane) validateRemediationStrategy() field.ErrorList {
+	var allErrs field.ErrorList
+
+	if r.Spec.RemediationStrategy == nil {
+		return allErrs
+	}
+
+	fldPath := field.NewPath("spec", "remediationStrategy")
+	if r.Spec.RemediationStrategy.MaxRetry != nil && *r.Spec.RemediationStrategy.MaxRetry < 0 {
+		allErrs = append(
+			allErrs,
+			field.Invalid(
+				fldPath.Child("maxRetry"),
+				*r.Spec.RemediationStrategy.MaxRetry,
+				"must be greater than or equal to 0",
+			),
+		)
+	}
+	if r.Spec.RemediationStrategy.RetryPeriod.Duration < 0 {
+		allErrs = append(
+			allErrs,
+			field.Invalid(
+				fldPath.Child("retryPeriod"),
+				r.Spec.RemediationStrategy.RetryPeriod.Duration.String(),
+				"must be greater than or equal to 0",
+			),
+		)
+	}
+	if r.Spec.RemediationStrategy.MinHealthyPeriod != nil && r.Spec.RemediationStrategy.MinHealthyPeriod.Duration <= 0 {
+		allErrs = append(
+			allErrs,
+			field.Invalid(
+				fldPath.Child("minHealthyPeriod"),
+				r.Spec.RemediationStrategy.MinHealthyPeriod.Duration.String(),
+				"must be greater than 0",
+			),
+		)
+	}
+
+	return allErrs
+}
+
+// validateEtcdBackup checks that etcd snapshots are configured only for a managed etcd cluster.
+func (r *KubeadmControlPlane) validateEtcdBackup() field.ErrorList {
+	var allErrs field.ErrorList
+
+	if r.Spec.EtcdBackup == nil {
+		return allErrs
+	}
+
+	fldPath := field.NewPath("spec", "etcdBackup")
+	if r.usesExternalEtcd() {
+		allErrs = append(
+			allErrs,
+			field.Forbidden(
+				fldPath,
+				"cannot be used with an external etcd cluster",
+			),
+		)
+	}
+	if r.Spec.EtcdBackup.Interval.Duration <= 0 {
+		allErrs = append(
+			allErrs,
+			field.Invalid(
+				fldPath.Child("interval"),
+				r.Spec.EtcdBackup.Interval.Duration.String(),
+				"must be greater than 0",
+			),
+		)
+	}
+	if r.Spec.EtcdBackup.Image == "" {
+		allErrs = append(
+			allErrs,
+			field.Required(
+				fldPath.Child("image"),
+				"is required",
+			),
+		)
+	}
+
+	return allErrs
+}
+
+// validateRolloutBefore checks that the certificates of the control plane Machines are renewed early enough
+// for the rollout to complete before they expire.
+func (r *KubeadmControlPlane) validateRolloutBefore() field.ErrorList {
+	var allErrs field.ErrorList
+
+	if r.Spec.RolloutBefore == nil || r.Spec.RolloutBefore.CertificatesExpiryDays == nil {
+		return allErrs
+	}
+
+	if days := *r.Spec.RolloutBefore.CertificatesExpiryDays; days < MinimumCertificatesExpiryDays {
+		allErrs = append(
+			allErrs,
+			field.Invalid(
+				field.NewPath("spec", "rolloutBefore", "certificatesExpiryDays"),
+				days,
+				fmt.Sprintf("must be greater than or equal to %d", MinimumCertificatesExpiryDays),
+			),
+		)
+	}
+
+	return allErrs
+}
+
+// validateNodeMetadata checks that only the labels and annotations synced to the Nodes are set in the node metadata.
+func (r *KubeadmControlPlane) validateNodeMetadata() field.ErrorList {
+	var allErrs field.ErrorList
+
+	if r.Spec.NodeMetadata == nil {
+		return allErrs
+	}
+
+	fldPath := field.NewPath("spec", "nodeMetadata")
+	for key := range r.Spec.NodeMetadata.Labels {
+		if !util.IsNodeMetadataKey(key) {
+			allErrs = append(
+				allErrs,
+				field.Invalid(
+					fldPath.Child("labels"),
+					key,
+					"must be in the node.cluster.x-k8s.io or node-role.kubernetes.io domain",
+				),
+			)
+		}
+	}
+	for key := range r.Spec.NodeMetadata.Annotations {
+		if !util.IsNodeMetadataKey(key) {
+			allErrs = append(
+				allErrs,
+				field.Invalid(
+					fldPath.Child("annotations"),
+					key,
+					"must be in the node.cluster.x-k8s.io or node-role.kubernetes.io domain",
+				),
+			)
+		}
+	}
+
+	return allErrs
+}
+
+// usesExternalEtcd returns true if the control plane is configured with an external etcd cluster.
+func (r *KubeadmControlPlane) usesExternalEtcd() bool {
+	return r.Spec.KubeadmConfigSpec.InitConfiguration != nil && r.Spec.KubeadmConfigSpec.InitConfiguration.Etcd.External != nil
+}
+
 // ValidateDelete implements webhook.Validator so a webhook will be registered for the type
 func (r *KubeadmControlPlane) ValidateDelete() error {
 	return nil
@@ -18,10 +18,12 @@ package v1alpha3
 
 import (
 	"testing"
+	"time"
 
 	. "github.com/onsi/gomega"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/utils/pointer"
 
 	bootstrapv1 "sigs.k8s.io/cluster-api/bootstrap/kubeadm/api/v1alpha3"
@@ -42,6 +44,14 @@ func TestKubeadmControlPlaneDefault(t *testing.T) {
 	kcp.Default()
 
 	g.Expect(kcp.Spec.InfrastructureTemplate.Namespace).To(Equal(kcp.Namespace))
+	g.Expect(kcp.Spec.RolloutStrategy.Type).To(Equal(RollingUpdateStrategyType))
+	g.Expect(kcp.Spec.RolloutStrategy.RollingUpdate.MaxSurge.IntValue()).To(Equal(1))
+	g.Expect(kcp.Spec.EtcdBackup).To(BeNil())
+
+	kcp.Spec.EtcdBackup = &EtcdBackup{}
+	kcp.Default()
+
+	g.Expect(kcp.Spec.EtcdBackup.HostPath).To(Equal(DefaultEtcdSnapshotHostPath))
 }
 
 func TestKubeadmControlPlaneValidateCreate(t *testing.T) {
@@ -81,6 +91,81 @@ func TestKubeadmControlPlaneValidateCreate(t *testing.T) {
 		},
 	}
 
+	maxSurgeZero := intstr.FromInt(0)
+	scaleInRollout := valid.DeepCopy()
+	scaleInRollout.Spec.Replicas = pointer.Int32Ptr(3)
+	scaleInRollout.Spec.RolloutStrategy = &RolloutStrategy{
+		Type:          RollingUpdateStrategyType,
+		RollingUpdate: &RollingUpdate{MaxSurge: &maxSurgeZero},
+	}
+
+	scaleInRolloutSingleReplica := scaleInRollout.DeepCopy()
+	scaleInRolloutSingleReplica.Spec.Replicas = pointer.Int32Ptr(1)
+
+	maxSurgeTwo := intstr.FromInt(2)
+	invalidMaxSurge := scaleInRollout.DeepCopy()
+	invalidMaxSurge.Spec.RolloutStrategy.RollingUpdate.MaxSurge = &maxSurgeTwo
+
+	maxSurgePercent := intstr.FromString("10%")
+	percentMaxSurge := scaleInRollout.DeepCopy()
+	percentMaxSurge.Spec.RolloutStrategy.RollingUpdate.MaxSurge = &maxSurgePercent
+
+	unknownStrategy := valid.DeepCopy()
+	unknownStrategy.Spec.RolloutStrategy = &RolloutStrategy{Type: "Recreate"}
+
+	parallelJoins := valid.DeepCopy()
+	parallelJoins.Spec.ScaleUpStrategy = &ScaleUpStrategy{MaxParallelJoins: pointer.Int32Ptr(2)}
+
+	noParallelJoins := valid.DeepCopy()
+	noParallelJoins.Spec.ScaleUpStrategy = &ScaleUpStrategy{MaxParallelJoins: pointer.Int32Ptr(0)}
+
+	remediationStrategy := valid.DeepCopy()
+	remediationStrategy.Spec.RemediationStrategy = &RemediationStrategy{
+		MaxRetry:         pointer.Int32Ptr(5),
+		RetryPeriod:      metav1.Duration{Duration: 10 * time.Minute},
+		MinHealthyPeriod: &metav1.Duration{Duration: 2 * time.Hour},
+	}
+
+	negativeRemediationRetries := valid.DeepCopy()
+	negativeRemediationRetries.Spec.RemediationStrategy = &RemediationStrategy{MaxRetry: pointer.Int32Ptr(-1)}
+
+	zeroMinHealthyPeriod := valid.DeepCopy()
+	zeroMinHealthyPeriod.Spec.RemediationStrategy = &RemediationStrategy{MinHealthyPeriod: &metav1.Duration{}}
+
+	etcdBackup := valid.DeepCopy()
+	etcdBackup.Spec.EtcdBackup = &EtcdBackup{
+		Interval: metav1.Duration{Duration: time.Hour},
+		Image:    "k8s.gcr.io/etcd:3.4.3-0",
+	}
+
+	etcdBackupExternalEtcd := evenReplicasExternalEtcd.DeepCopy()
+	etcdBackupExternalEtcd.Spec.EtcdBackup = etcdBackup.Spec.EtcdBackup.DeepCopy()
+
+	etcdBackupMissingImage := etcdBackup.DeepCopy()
+	etcdBackupMissingImage.Spec.EtcdBackup.Image = ""
+
+	etcdBackupZeroInterval := etcdBackup.DeepCopy()
+	etcdBackupZeroInterval.Spec.EtcdBackup.Interval = metav1.Duration{}
+
+	rolloutBefore := valid.DeepCopy()
+	rolloutBefore.Spec.RolloutBefore = &RolloutBefore{CertificatesExpiryDays: pointer.Int32Ptr(21)}
+
+	rolloutBeforeTooLate := valid.DeepCopy()
+	rolloutBeforeTooLate.Spec.RolloutBefore = &RolloutBefore{CertificatesExpiryDays: pointer.Int32Ptr(1)}
+
+	nodeMetadata := valid.DeepCopy()
+	nodeMetadata.Spec.NodeMetadata = &NodeMetadata{
+		Labels:      map[string]string{"node-role.kubernetes.io/infra": ""},
+		Annotations: map[string]string{"node.cluster.x-k8s.io/owner": "team-a"},
+		Taints:      []corev1.Taint{{Key: "dedicated", Value: "infra", Effect: corev1.TaintEffectNoSchedule}},
+	}
+
+	invalidNodeMetadataLabel := nodeMetadata.DeepCopy()
+	invalidNodeMetadataLabel.Spec.NodeMetadata.Labels["env"] = "prod"
+
+	windows := valid.DeepCopy()
+	windows.Spec.KubeadmConfigSpec.OS = bootstrapv1.Windows
+
 	tests := []struct {
 		name      string
 		expectErr bool
@@ -91,6 +176,101 @@ func TestKubeadmControlPlaneValidateCreate(t *testing.T) {
 			expectErr: false,
 			kcp:       valid,
 		},
+		{
+			name:      "should succeed when maxSurge is 0 with 3 replicas",
+			expectErr: false,
+			kcp:       scaleInRollout,
+		},
+		{
+			name:      "should return error when maxSurge is 0 with a single replica",
+			expectErr: true,
+			kcp:       scaleInRolloutSingleReplica,
+		},
+		{
+			name:      "should return error when maxSurge is greater than 1",
+			expectErr: true,
+			kcp:       invalidMaxSurge,
+		},
+		{
+			name:      "should return error when maxSurge is a percentage",
+			expectErr: true,
+			kcp:       percentMaxSurge,
+		},
+		{
+			name:      "should return error when the rollout strategy type is unknown",
+			expectErr: true,
+			kcp:       unknownStrategy,
+		},
+		{
+			name:      "should succeed when two Machines can join in parallel",
+			expectErr: false,
+			kcp:       parallelJoins,
+		},
+		{
+			name:      "should return error when no Machine can join",
+			expectErr: true,
+			kcp:       noParallelJoins,
+		},
+		{
+			name:      "should succeed when a remediation strategy is configured",
+			expectErr: false,
+			kcp:       remediationStrategy,
+		},
+		{
+			name:      "should return error when the remediation retries are negative",
+			expectErr: true,
+			kcp:       negativeRemediationRetries,
+		},
+		{
+			name:      "should return error when the remediation min healthy period is zero",
+			expectErr: true,
+			kcp:       zeroMinHealthyPeriod,
+		},
+		{
+			name:      "should succeed when etcd backups are configured",
+			expectErr: false,
+			kcp:       etcdBackup,
+		},
+		{
+			name:      "should return error when etcd backups are configured with external etcd",
+			expectErr: true,
+			kcp:       etcdBackupExternalEtcd,
+		},
+		{
+			name:      "should return error when the etcd backup image is missing",
+			expectErr: true,
+			kcp:       etcdBackupMissingImage,
+		},
+		{
+			name:      "should return error when the etcd backup interval is zero",
+			expectErr: true,
+			kcp:       etcdBackupZeroInterval,
+		},
+		{
+			name:      "should succeed when rolling out 21 days before the certificates expiry",
+			expectErr: false,
+			kcp:       rolloutBefore,
+		},
+		{
+			name:      "should return error when rolling out less than 7 days before the certificates expiry",
+			expectErr: true,
+			kcp:       rolloutBeforeTooLate,
+		},
+		{
+			name:      "should succeed when the node metadata keys are synced to the Nodes",
+			expectErr: false,
+			kcp:       nodeMetadata,
+		},
+		{
+			name:      "should return error when a node metadata label is not synced to the Nodes",
+			expectErr: true,
+			kcp:       invalidNodeMetadataLabel,
+		},
+		{
+			name:      "should return error when the control plane machines run Windows",
+			expectErr: true,
+			kcp:       windows,
+		},
 		{
 			name:      "should return error when kubeadmControlPlane namespace and infrastructureTemplate  namespace mismatch",
 			expectErr: true,
@@ -143,6 +323,7 @@ func TestKubeadmControlPlaneValidateUpdate(t *testing.T) {
 				Name:      "infraTemplate",
 			},
 			Replicas:          pointer.Int32Ptr(1),
+			Version:           "v1.17.3",
 			KubeadmConfigSpec: bootstrapv1.KubeadmConfigSpec{},
 		},
 	}
@@ -155,6 +336,32 @@ func TestKubeadmControlPlaneValidateUpdate(t *testing.T) {
 	validUpdate.Spec.InfrastructureTemplate.Name = "orange"
 	validUpdate.Spec.Replicas = pointer.Int32Ptr(5)
 
+	kubeletUpdate := before.DeepCopy()
+	kubeletUpdate.Spec.KubeadmConfigSpec.Kubelet = &bootstrapv1.KubeletConfiguration{
+		MaxPods:      pointer.Int32Ptr(250),
+		CgroupDriver: "systemd",
+	}
+
+	kubeletAndInitUpdate := kubeletUpdate.DeepCopy()
+	kubeletAndInitUpdate.Spec.KubeadmConfigSpec.InitConfiguration = &kubeadmv1beta1.InitConfiguration{}
+
+	maxSurgeZero := intstr.FromInt(0)
+	scaleInRollout := before.DeepCopy()
+	scaleInRollout.Spec.Replicas = pointer.Int32Ptr(3)
+	scaleInRollout.Spec.RolloutStrategy = &RolloutStrategy{
+		Type:          RollingUpdateStrategyType,
+		RollingUpdate: &RollingUpdate{MaxSurge: &maxSurgeZero},
+	}
+
+	scaleInRolloutSingleReplica := scaleInRollout.DeepCopy()
+	scaleInRolloutSingleReplica.Spec.Replicas = pointer.Int32Ptr(1)
+
+	minorUpgrade := before.DeepCopy()
+	minorUpgrade.Spec.Version = "v1.18.2"
+
+	skippedMinorUpgrade := before.DeepCopy()
+	skippedMinorUpgrade.Spec.Version = "v1.19.0"
+
 	tests := []struct {
 		name      string
 		expectErr bool
@@ -170,6 +377,36 @@ func TestKubeadmControlPlaneValidateUpdate(t *testing.T) {
 			expectErr: true,
 			kcp:       invalidUpdate,
 		},
+		{
+			name:      "should succeed when changing the kubelet configuration",
+			expectErr: false,
+			kcp:       kubeletUpdate,
+		},
+		{
+			name:      "should return error when mutating the kubeadmconfigspec along with the kubelet configuration",
+			expectErr: true,
+			kcp:       kubeletAndInitUpdate,
+		},
+		{
+			name:      "should succeed when changing the rollout strategy",
+			expectErr: false,
+			kcp:       scaleInRollout,
+		},
+		{
+			name:      "should return error when setting maxSurge to 0 with a single replica",
+			expectErr: true,
+			kcp:       scaleInRolloutSingleReplica,
+		},
+		{
+			name:      "should succeed when upgrading to the next minor version",
+			expectErr: false,
+			kcp:       minorUpgrade,
+		},
+		{
+			name:      "should return error when skipping a minor version",
+			expectErr: true,
+			kcp:       skippedMinorUpgrade,
+		},
 	}
 
 	for _, tt := range tests {
@@ -0,0 +1,121 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kerrors "k8s.io/apimachinery/pkg/util/errors"
+
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	controlplanev1 "sigs.k8s.io/cluster-api/controlplane/kubeadm/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/controlplane/kubeadm/internal"
+	"sigs.k8s.io/cluster-api/util/patch"
+)
+
+const (
+	// certificatesExpiryWarningPeriod is how long before the certificates expiry a warning is reported,
+	// when the KubeadmControlPlane is not configured to renew them.
+	certificatesExpiryWarningPeriod = 30 * 24 * time.Hour
+
+	// defaultAPIServerBindPort is the port the kube-apiserver listens on when it is not set in the kubeadm configuration.
+	defaultAPIServerBindPort = 6443
+)
+
+// reconcileCertificateExpiries records the certificates expiry date on the control plane Machines with a Node, and
+// warns when the certificates are about to expire and will not be renewed by a rollout.
+// The expiry date is read only once per Machine, as the certificates are not renewed during the Machine lifetime.
+func (r *KubeadmControlPlaneReconciler) reconcileCertificateExpiries(ctx context.Context, cluster *clusterv1.Cluster, kcp *controlplanev1.KubeadmControlPlane, machines []clusterv1.Machine) error {
+	var errs []error
+	port := apiServerBindPort(kcp)
+	for i := range machines {
+		m := &machines[i]
+		if m.Status.NodeRef == nil || isDeleting(*m) {
+			continue
+		}
+		if _, ok := internal.CertificatesExpiry(*m); ok {
+			continue
+		}
+
+		expiry, err := r.managementCluster.GetAPIServerCertificateExpiry(ctx, clusterKey(cluster), m.Status.NodeRef.Name, port)
+		if err != nil {
+			errs = append(errs, errors.Wrapf(err, "failed to get the certificates expiry of machine %q", m.Name))
+			continue
+		}
+
+		patchHelper, err := patch.NewHelper(m, r.Client)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if m.Annotations == nil {
+			m.Annotations = map[string]string{}
+		}
+		m.Annotations[controlplanev1.CertificatesExpiryAnnotation] = expiry.UTC().Format(time.RFC3339)
+		if err := patchHelper.Patch(ctx, m); err != nil {
+			errs = append(errs, errors.Wrapf(err, "failed to patch machine %q", m.Name))
+		}
+	}
+
+	if kcp.Spec.RolloutBefore == nil || kcp.Spec.RolloutBefore.CertificatesExpiryDays == nil {
+		if expiry := certificatesExpiryDate(machines); expiry != nil && time.Until(expiry.Time) < certificatesExpiryWarningPeriod {
+			r.recorder.Eventf(kcp, corev1.EventTypeWarning, "CertificatesExpiring",
+				"The certificates of the control plane machines expire on %s; set spec.rolloutBefore.certificatesExpiryDays to renew them", expiry.UTC().Format(time.RFC3339))
+		}
+	}
+
+	return kerrors.NewAggregate(errs)
+}
+
+// certificatesExpiryDate returns the earliest certificates expiry date recorded on the given machines,
+// or nil if none is recorded.
+func certificatesExpiryDate(machines []clusterv1.Machine) *metav1.Time {
+	var earliest *metav1.Time
+	for _, m := range machines {
+		expiry, ok := internal.CertificatesExpiry(m)
+		if !ok {
+			continue
+		}
+		if earliest == nil || expiry.Before(earliest.Time) {
+			t := metav1.NewTime(expiry)
+			earliest = &t
+		}
+	}
+	return earliest
+}
+
+// certificatesRolloutDeadline returns the time before which the certificates of the control plane Machines must not
+// expire, or nil if the KubeadmControlPlane does not roll out Machines before their certificates expire.
+func certificatesRolloutDeadline(kcp *controlplanev1.KubeadmControlPlane, now time.Time) *metav1.Time {
+	if kcp.Spec.RolloutBefore == nil || kcp.Spec.RolloutBefore.CertificatesExpiryDays == nil {
+		return nil
+	}
+	deadline := metav1.NewTime(now.Add(time.Duration(*kcp.Spec.RolloutBefore.CertificatesExpiryDays) * 24 * time.Hour))
+	return &deadline
+}
+
+// apiServerBindPort returns the port the kube-apiserver listens on, on the control plane Nodes.
+func apiServerBindPort(kcp *controlplanev1.KubeadmControlPlane) int {
+	if initConfiguration := kcp.Spec.KubeadmConfigSpec.InitConfiguration; initConfiguration != nil && initConfiguration.LocalAPIEndpoint.BindPort != 0 {
+		return int(initConfiguration.LocalAPIEndpoint.BindPort)
+	}
+	return defaultAPIServerBindPort
+}
@@ -0,0 +1,136 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/pointer"
+
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	controlplanev1 "sigs.k8s.io/cluster-api/controlplane/kubeadm/api/v1alpha3"
+)
+
+func machineWithCertificatesExpiry(name string, expiry time.Time) clusterv1.Machine {
+	return clusterv1.Machine{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+			Annotations: map[string]string{
+				controlplanev1.CertificatesExpiryAnnotation: expiry.UTC().Format(time.RFC3339),
+			},
+		},
+	}
+}
+
+func TestCertificatesExpiryDate(t *testing.T) {
+	g := NewWithT(t)
+
+	g.Expect(certificatesExpiryDate(nil)).To(BeNil())
+	g.Expect(certificatesExpiryDate([]clusterv1.Machine{{}})).To(BeNil())
+
+	earliest := time.Date(2021, time.March, 1, 0, 0, 0, 0, time.UTC)
+	machines := []clusterv1.Machine{
+		machineWithCertificatesExpiry("m1", earliest.Add(48*time.Hour)),
+		{},
+		machineWithCertificatesExpiry("m2", earliest),
+	}
+	expiry := certificatesExpiryDate(machines)
+	g.Expect(expiry).NotTo(BeNil())
+	g.Expect(expiry.Time.Equal(earliest)).To(BeTrue())
+}
+
+func TestCertificatesRolloutDeadline(t *testing.T) {
+	g := NewWithT(t)
+
+	now := time.Now()
+	kcp := &controlplanev1.KubeadmControlPlane{}
+	g.Expect(certificatesRolloutDeadline(kcp, now)).To(BeNil())
+
+	kcp.Spec.RolloutBefore = &controlplanev1.RolloutBefore{}
+	g.Expect(certificatesRolloutDeadline(kcp, now)).To(BeNil())
+
+	kcp.Spec.RolloutBefore.CertificatesExpiryDays = pointer.Int32Ptr(21)
+	deadline := certificatesRolloutDeadline(kcp, now)
+	g.Expect(deadline).NotTo(BeNil())
+	g.Expect(deadline.Time.Equal(now.Add(21 * 24 * time.Hour))).To(BeTrue())
+}
+
+func TestKubeadmControlPlaneReconciler_reconcileCertificateExpiries(t *testing.T) {
+	cluster := &clusterv1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "foo",
+			Namespace: "test",
+		},
+	}
+
+	tests := []struct {
+		name          string
+		rolloutBefore *controlplanev1.RolloutBefore
+		expiry        time.Time
+		expectEvent   bool
+	}{
+		{
+			name:        "should warn when the certificates are about to expire",
+			expiry:      time.Now().Add(10 * 24 * time.Hour),
+			expectEvent: true,
+		},
+		{
+			name:        "should not warn when the certificates expire later",
+			expiry:      time.Now().Add(300 * 24 * time.Hour),
+			expectEvent: false,
+		},
+		{
+			name:          "should not warn when the certificates are renewed by a rollout",
+			rolloutBefore: &controlplanev1.RolloutBefore{CertificatesExpiryDays: pointer.Int32Ptr(21)},
+			expiry:        time.Now().Add(10 * 24 * time.Hour),
+			expectEvent:   false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			kcp := &controlplanev1.KubeadmControlPlane{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "kcp-foo",
+					Namespace: cluster.Namespace,
+				},
+				Spec: controlplanev1.KubeadmControlPlaneSpec{
+					RolloutBefore: tt.rolloutBefore,
+				},
+			}
+			recorder := record.NewFakeRecorder(32)
+			r := &KubeadmControlPlaneReconciler{
+				recorder: recorder,
+			}
+
+			machines := []clusterv1.Machine{machineWithCertificatesExpiry("m1", tt.expiry)}
+			g.Expect(r.reconcileCertificateExpiries(context.Background(), cluster, kcp, machines)).To(Succeed())
+			if tt.expectEvent {
+				g.Expect(recorder.Events).To(Receive(ContainSubstring("CertificatesExpiring")))
+			} else {
+				g.Expect(recorder.Events).NotTo(Receive())
+			}
+		})
+	}
+}
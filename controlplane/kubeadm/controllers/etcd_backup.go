@@ -0,0 +1,249 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sort"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/pkg/errors"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	controlplanev1 "sigs.k8s.io/cluster-api/controlplane/kubeadm/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/controlplane/kubeadm/internal"
+)
+
+const (
+	// EtcdSnapshotRequeueAfter is how long to wait before checking again
+	// the progress of an etcd snapshot Job.
+	EtcdSnapshotRequeueAfter = 1 * time.Minute
+
+	// etcdSnapshotLabel is the label set on the etcd snapshot Jobs, with the name of the KubeadmControlPlane as value.
+	etcdSnapshotLabel = "controlplane.cluster.x-k8s.io/etcd-snapshot"
+
+	// etcdSnapshotNodeAnnotation and etcdSnapshotPathAnnotation record on the etcd snapshot Jobs where the snapshot is saved.
+	etcdSnapshotNodeAnnotation = "controlplane.cluster.x-k8s.io/etcd-snapshot-node"
+	etcdSnapshotPathAnnotation = "controlplane.cluster.x-k8s.io/etcd-snapshot-path"
+
+	etcdPKIDir = "/etc/kubernetes/pki/etcd"
+)
+
+// reconcileEtcdBackup takes periodic snapshots of the managed etcd cluster by creating Jobs in the workload cluster,
+// and records the latest successful snapshot in the KubeadmControlPlane status.
+func (r *KubeadmControlPlaneReconciler) reconcileEtcdBackup(ctx context.Context, cluster *clusterv1.Cluster, kcp *controlplanev1.KubeadmControlPlane, logger logr.Logger) (ctrl.Result, error) {
+	if kcp.Spec.EtcdBackup == nil || !cluster.Status.ControlPlaneInitialized {
+		return ctrl.Result{}, nil
+	}
+
+	remoteClient, err := r.remoteClientGetter(ctx, r.Client, cluster, r.scheme)
+	if err != nil {
+		return ctrl.Result{}, errors.Wrap(err, "failed to create remote cluster client")
+	}
+
+	jobList := &batchv1.JobList{}
+	if err := remoteClient.List(ctx, jobList, client.InNamespace(metav1.NamespaceSystem), client.MatchingLabels{etcdSnapshotLabel: kcp.Name}); err != nil {
+		return ctrl.Result{}, errors.Wrap(err, "failed to list etcd snapshot jobs")
+	}
+	jobs := jobList.Items
+	sort.Slice(jobs, func(i, j int) bool {
+		return jobs[i].CreationTimestamp.Before(&jobs[j].CreationTimestamp)
+	})
+
+	var latest, lastSucceeded *batchv1.Job
+	for i := range jobs {
+		latest = &jobs[i]
+		if jobs[i].Status.Succeeded > 0 {
+			lastSucceeded = &jobs[i]
+		}
+	}
+	if lastSucceeded != nil {
+		kcp.Status.LastEtcdSnapshot = etcdSnapshotFromJob(lastSucceeded)
+	}
+
+	// Only the latest Job and the one holding the latest successful snapshot are kept.
+	for i := range jobs {
+		job := &jobs[i]
+		if job == latest || job == lastSucceeded {
+			continue
+		}
+		if err := remoteClient.Delete(ctx, job, client.PropagationPolicy(metav1.DeletePropagationBackground)); err != nil && !apierrors.IsNotFound(err) {
+			return ctrl.Result{}, errors.Wrapf(err, "failed to delete etcd snapshot job %q", job.Name)
+		}
+	}
+
+	if latest != nil {
+		if isJobFailed(latest) && latest != lastSucceeded {
+			r.recorder.Eventf(kcp, corev1.EventTypeWarning, "FailedEtcdSnapshot", "Etcd snapshot job %s failed", latest.Name)
+		}
+		if !isJobFinished(latest) {
+			return ctrl.Result{RequeueAfter: EtcdSnapshotRequeueAfter}, nil
+		}
+		if next := latest.CreationTimestamp.Add(kcp.Spec.EtcdBackup.Interval.Duration); time.Now().Before(next) {
+			return ctrl.Result{RequeueAfter: time.Until(next)}, nil
+		}
+	}
+
+	ownedMachines, err := r.managementCluster.GetMachinesForCluster(ctx, clusterKey(cluster), internal.OwnedControlPlaneMachines(kcp.Name))
+	if err != nil {
+		return ctrl.Result{}, errors.Wrap(err, "failed to get list of owned machines")
+	}
+	nodeName := etcdSnapshotNodeName(ownedMachines)
+	if nodeName == "" {
+		logger.Info("Waiting for a control plane Node to take the etcd snapshot on")
+		return ctrl.Result{RequeueAfter: EtcdSnapshotRequeueAfter}, nil
+	}
+
+	job := newEtcdSnapshotJob(kcp, nodeName, time.Now())
+	logger.Info("Taking etcd snapshot", "job", job.Name, "node", nodeName)
+	if err := remoteClient.Create(ctx, job); err != nil {
+		return ctrl.Result{}, errors.Wrap(err, "failed to create etcd snapshot job")
+	}
+	return ctrl.Result{RequeueAfter: EtcdSnapshotRequeueAfter}, nil
+}
+
+// etcdSnapshotNodeName returns the name of the Node of the oldest control plane Machine that is not being deleted.
+func etcdSnapshotNodeName(machines []clusterv1.Machine) string {
+	var oldest *clusterv1.Machine
+	for i := range machines {
+		m := &machines[i]
+		if m.Status.NodeRef == nil || isDeleting(*m) {
+			continue
+		}
+		if oldest == nil || m.CreationTimestamp.Before(&oldest.CreationTimestamp) {
+			oldest = m
+		}
+	}
+	if oldest == nil {
+		return ""
+	}
+	return oldest.Status.NodeRef.Name
+}
+
+// newEtcdSnapshotJob returns a Job taking a snapshot of the etcd member running on the given Node.
+func newEtcdSnapshotJob(kcp *controlplanev1.KubeadmControlPlane, nodeName string, now time.Time) *batchv1.Job {
+	backup := kcp.Spec.EtcdBackup
+	hostPath := backup.HostPath
+	if hostPath == "" {
+		hostPath = controlplanev1.DefaultEtcdSnapshotHostPath
+	}
+
+	name := fmt.Sprintf("%s-etcd-snapshot-%d", kcp.Name, now.Unix())
+	snapshotFile := path.Join(hostPath, name+".db")
+
+	command := backup.Command
+	if len(command) == 0 {
+		command = []string{"etcdctl", "snapshot", "save", "$(SNAPSHOT_FILE)"}
+	}
+
+	hostPathDirectoryOrCreate := corev1.HostPathDirectoryOrCreate
+	return &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: metav1.NamespaceSystem,
+			Labels: map[string]string{
+				etcdSnapshotLabel: kcp.Name,
+			},
+			Annotations: map[string]string{
+				etcdSnapshotNodeAnnotation: nodeName,
+				etcdSnapshotPathAnnotation: snapshotFile,
+			},
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit: pointer.Int32Ptr(2),
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					NodeName:      nodeName,
+					HostNetwork:   true,
+					RestartPolicy: corev1.RestartPolicyNever,
+					Tolerations: []corev1.Toleration{
+						{Operator: corev1.TolerationOpExists},
+					},
+					Containers: []corev1.Container{
+						{
+							Name:    "etcd-snapshot",
+							Image:   backup.Image,
+							Command: command,
+							Env: []corev1.EnvVar{
+								{Name: "ETCDCTL_API", Value: "3"},
+								{Name: "ETCDCTL_ENDPOINTS", Value: "https://127.0.0.1:2379"},
+								{Name: "ETCDCTL_CACERT", Value: path.Join(etcdPKIDir, "ca.crt")},
+								{Name: "ETCDCTL_CERT", Value: path.Join(etcdPKIDir, "healthcheck-client.crt")},
+								{Name: "ETCDCTL_KEY", Value: path.Join(etcdPKIDir, "healthcheck-client.key")},
+								{Name: "SNAPSHOT_FILE", Value: snapshotFile},
+							},
+							VolumeMounts: []corev1.VolumeMount{
+								{Name: "etcd-certs", MountPath: etcdPKIDir, ReadOnly: true},
+								{Name: "etcd-snapshots", MountPath: hostPath},
+							},
+						},
+					},
+					Volumes: []corev1.Volume{
+						{
+							Name: "etcd-certs",
+							VolumeSource: corev1.VolumeSource{
+								HostPath: &corev1.HostPathVolumeSource{Path: etcdPKIDir},
+							},
+						},
+						{
+							Name: "etcd-snapshots",
+							VolumeSource: corev1.VolumeSource{
+								HostPath: &corev1.HostPathVolumeSource{Path: hostPath, Type: &hostPathDirectoryOrCreate},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// etcdSnapshotFromJob returns the reference to the etcd snapshot taken by a successful Job.
+func etcdSnapshotFromJob(job *batchv1.Job) *controlplanev1.EtcdSnapshot {
+	snapshot := &controlplanev1.EtcdSnapshot{
+		JobName:  job.Name,
+		NodeName: job.Annotations[etcdSnapshotNodeAnnotation],
+		Path:     job.Annotations[etcdSnapshotPathAnnotation],
+	}
+	if job.Status.CompletionTime != nil {
+		snapshot.CompletionTime = *job.Status.CompletionTime
+	}
+	return snapshot
+}
+
+func isJobFinished(job *batchv1.Job) bool {
+	return job.Status.Succeeded > 0 || isJobFailed(job)
+}
+
+func isJobFailed(job *batchv1.Job) bool {
+	for _, c := range job.Status.Conditions {
+		if c.Type == batchv1.JobFailed && c.Status == corev1.ConditionTrue {
+			return true
+		}
+	}
+	return false
+}
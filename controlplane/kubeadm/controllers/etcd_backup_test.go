@@ -0,0 +1,217 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	fakeremote "sigs.k8s.io/cluster-api/controllers/remote/fake"
+	controlplanev1 "sigs.k8s.io/cluster-api/controlplane/kubeadm/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/controlplane/kubeadm/internal"
+)
+
+func TestKubeadmControlPlaneReconciler_reconcileEtcdBackup(t *testing.T) {
+	cluster := &clusterv1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "foo",
+			Namespace: "test",
+		},
+		Status: clusterv1.ClusterStatus{
+			ControlPlaneInitialized: true,
+		},
+	}
+
+	kcp := &controlplanev1.KubeadmControlPlane{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "kcp-foo",
+			Namespace: cluster.Namespace,
+		},
+		Spec: controlplanev1.KubeadmControlPlaneSpec{
+			EtcdBackup: &controlplanev1.EtcdBackup{
+				Interval: metav1.Duration{Duration: time.Hour},
+				Image:    "k8s.gcr.io/etcd:3.4.3-0",
+			},
+		},
+	}
+
+	machine := &clusterv1.Machine{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "machine",
+			Namespace: cluster.Namespace,
+			Labels:    internal.ControlPlaneLabelsForCluster(cluster.Name),
+			OwnerReferences: []metav1.OwnerReference{
+				*metav1.NewControllerRef(kcp, controlplanev1.GroupVersion.WithKind("KubeadmControlPlane")),
+			},
+		},
+		Status: clusterv1.MachineStatus{
+			NodeRef: &corev1.ObjectReference{Kind: "Node", Name: "node"},
+		},
+	}
+
+	completionTime := metav1.NewTime(time.Now().Add(-5 * time.Minute))
+	succeededJob := newEtcdSnapshotJob(kcp, "node", time.Now().Add(-10*time.Minute))
+	succeededJob.CreationTimestamp = metav1.NewTime(time.Now().Add(-10 * time.Minute))
+	succeededJob.Status.Succeeded = 1
+	succeededJob.Status.CompletionTime = &completionTime
+
+	runningJob := newEtcdSnapshotJob(kcp, "node", time.Now().Add(-time.Minute))
+	runningJob.CreationTimestamp = metav1.NewTime(time.Now().Add(-time.Minute))
+	runningJob.Status.Active = 1
+
+	t.Run("should create a snapshot job on a control plane node", func(t *testing.T) {
+		g := NewWithT(t)
+
+		r, fakeClient := newEtcdBackupTestReconciler(g, cluster, kcp, machine)
+		k := kcp.DeepCopy()
+
+		result, err := r.reconcileEtcdBackup(context.Background(), cluster, k, r.Log)
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(result).To(Equal(ctrl.Result{RequeueAfter: EtcdSnapshotRequeueAfter}))
+
+		jobs := &batchv1.JobList{}
+		g.Expect(fakeClient.List(context.Background(), jobs, client.InNamespace(metav1.NamespaceSystem))).To(Succeed())
+		g.Expect(jobs.Items).To(HaveLen(1))
+		g.Expect(jobs.Items[0].Labels).To(HaveKeyWithValue(etcdSnapshotLabel, kcp.Name))
+		g.Expect(jobs.Items[0].Spec.Template.Spec.NodeName).To(Equal("node"))
+		g.Expect(jobs.Items[0].Spec.Template.Spec.Containers[0].Image).To(Equal(kcp.Spec.EtcdBackup.Image))
+		g.Expect(k.Status.LastEtcdSnapshot).To(BeNil())
+	})
+
+	t.Run("should record the last snapshot and wait for the next interval", func(t *testing.T) {
+		g := NewWithT(t)
+
+		r, fakeClient := newEtcdBackupTestReconciler(g, cluster, kcp, machine, succeededJob)
+		k := kcp.DeepCopy()
+
+		result, err := r.reconcileEtcdBackup(context.Background(), cluster, k, r.Log)
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(result.RequeueAfter).To(BeNumerically(">", 45*time.Minute))
+		g.Expect(result.RequeueAfter).To(BeNumerically("<=", 50*time.Minute))
+
+		g.Expect(k.Status.LastEtcdSnapshot).NotTo(BeNil())
+		g.Expect(k.Status.LastEtcdSnapshot.JobName).To(Equal(succeededJob.Name))
+		g.Expect(k.Status.LastEtcdSnapshot.NodeName).To(Equal("node"))
+		g.Expect(k.Status.LastEtcdSnapshot.Path).To(Equal(succeededJob.Annotations[etcdSnapshotPathAnnotation]))
+
+		jobs := &batchv1.JobList{}
+		g.Expect(fakeClient.List(context.Background(), jobs, client.InNamespace(metav1.NamespaceSystem))).To(Succeed())
+		g.Expect(jobs.Items).To(HaveLen(1))
+	})
+
+	t.Run("should wait for the running snapshot job", func(t *testing.T) {
+		g := NewWithT(t)
+
+		r, fakeClient := newEtcdBackupTestReconciler(g, cluster, kcp, machine, succeededJob, runningJob)
+		k := kcp.DeepCopy()
+		k.Spec.EtcdBackup.Interval = metav1.Duration{Duration: 30 * time.Second}
+
+		result, err := r.reconcileEtcdBackup(context.Background(), cluster, k, r.Log)
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(result).To(Equal(ctrl.Result{RequeueAfter: EtcdSnapshotRequeueAfter}))
+		g.Expect(k.Status.LastEtcdSnapshot.JobName).To(Equal(succeededJob.Name))
+
+		jobs := &batchv1.JobList{}
+		g.Expect(fakeClient.List(context.Background(), jobs, client.InNamespace(metav1.NamespaceSystem))).To(Succeed())
+		g.Expect(jobs.Items).To(HaveLen(2))
+	})
+
+	t.Run("should do nothing if backups are not enabled", func(t *testing.T) {
+		g := NewWithT(t)
+
+		r, fakeClient := newEtcdBackupTestReconciler(g, cluster, kcp, machine)
+		k := kcp.DeepCopy()
+		k.Spec.EtcdBackup = nil
+
+		result, err := r.reconcileEtcdBackup(context.Background(), cluster, k, r.Log)
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(result).To(Equal(ctrl.Result{}))
+
+		jobs := &batchv1.JobList{}
+		g.Expect(fakeClient.List(context.Background(), jobs, client.InNamespace(metav1.NamespaceSystem))).To(Succeed())
+		g.Expect(jobs.Items).To(BeEmpty())
+	})
+}
+
+func TestLowestNonZeroResult(t *testing.T) {
+	tests := []struct {
+		name     string
+		i, j     ctrl.Result
+		expected ctrl.Result
+	}{
+		{
+			name:     "should return the non zero result",
+			i:        ctrl.Result{},
+			j:        ctrl.Result{RequeueAfter: time.Minute},
+			expected: ctrl.Result{RequeueAfter: time.Minute},
+		},
+		{
+			name:     "should return the immediate requeue",
+			i:        ctrl.Result{RequeueAfter: time.Minute},
+			j:        ctrl.Result{Requeue: true},
+			expected: ctrl.Result{Requeue: true},
+		},
+		{
+			name:     "should return the lowest requeue after",
+			i:        ctrl.Result{RequeueAfter: time.Hour},
+			j:        ctrl.Result{RequeueAfter: time.Minute},
+			expected: ctrl.Result{RequeueAfter: time.Minute},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			g.Expect(lowestNonZeroResult(tt.i, tt.j)).To(Equal(tt.expected))
+			g.Expect(lowestNonZeroResult(tt.j, tt.i)).To(Equal(tt.expected))
+		})
+	}
+}
+
+func newEtcdBackupTestReconciler(g *WithT, objs ...runtime.Object) (*KubeadmControlPlaneReconciler, client.Client) {
+	g.Expect(clusterv1.AddToScheme(scheme.Scheme)).To(Succeed())
+	g.Expect(controlplanev1.AddToScheme(scheme.Scheme)).To(Succeed())
+
+	for i := range objs {
+		objs[i] = objs[i].DeepCopyObject()
+	}
+	fakeClient := fake.NewFakeClientWithScheme(scheme.Scheme, objs...)
+
+	return &KubeadmControlPlaneReconciler{
+		Client:             fakeClient,
+		Log:                log.Log,
+		recorder:           record.NewFakeRecorder(32),
+		scheme:             scheme.Scheme,
+		remoteClientGetter: fakeremote.NewClusterClient,
+		managementCluster:  &internal.ManagementCluster{Client: fakeClient},
+	}, fakeClient
+}
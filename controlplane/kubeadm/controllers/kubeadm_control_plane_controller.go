@@ -19,6 +19,7 @@ package controllers
 import (
 	"context"
 	"fmt"
+	"sort"
 	"strings"
 	"time"
 
@@ -44,12 +45,16 @@ import (
 	bootstrapv1 "sigs.k8s.io/cluster-api/bootstrap/kubeadm/api/v1alpha3"
 	kubeadmv1 "sigs.k8s.io/cluster-api/bootstrap/kubeadm/types/v1beta1"
 	"sigs.k8s.io/cluster-api/controllers/external"
+	"sigs.k8s.io/cluster-api/controllers/mdutil"
+	"sigs.k8s.io/cluster-api/controllers/preflight"
 	"sigs.k8s.io/cluster-api/controllers/remote"
 	controlplanev1 "sigs.k8s.io/cluster-api/controlplane/kubeadm/api/v1alpha3"
 	"sigs.k8s.io/cluster-api/controlplane/kubeadm/internal"
 	"sigs.k8s.io/cluster-api/controlplane/kubeadm/internal/hash"
 	capierrors "sigs.k8s.io/cluster-api/errors"
 	"sigs.k8s.io/cluster-api/util"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/cluster-api/util/guardrails"
 	"sigs.k8s.io/cluster-api/util/kubeconfig"
 	"sigs.k8s.io/cluster-api/util/patch"
 	"sigs.k8s.io/cluster-api/util/secret"
@@ -59,6 +64,18 @@ const (
 	// DeleteRequeueAfter is how long to wait before checking again to see if
 	// all control plane machines have been deleted.
 	DeleteRequeueAfter = 30 * time.Second
+
+	// UpgradeRequeueAfter is how long to wait before checking again to see if
+	// a replacement control plane machine has joined the cluster.
+	UpgradeRequeueAfter = 20 * time.Second
+
+	// ScaleUpRequeueAfter is how long to wait before checking again to see if
+	// the control plane machines created when scaling up have joined the cluster.
+	ScaleUpRequeueAfter = 20 * time.Second
+
+	// PreflightChecksRequeueAfter is how long to wait before running again
+	// the preflight checks that are blocking an upgrade.
+	PreflightChecksRequeueAfter = 1 * time.Minute
 )
 
 // +kubebuilder:rbac:groups=core,resources=events,verbs=get;list;watch;create;patch
@@ -75,6 +92,14 @@ type KubeadmControlPlaneReconciler struct {
 	controller controller.Controller
 	recorder   record.EventRecorder
 
+	// CreationLimiter, if set, caps the number of Machines a single KubeadmControlPlane is allowed
+	// to create within a time window.
+	CreationLimiter *guardrails.CreationLimiter
+
+	// DriftCheckInterval, if set, is the interval at which machines created with an outdated configuration
+	// are reported while reconciliation is paused.
+	DriftCheckInterval time.Duration
+
 	remoteClientGetter remote.ClusterClientGetter
 
 	managementCluster *internal.ManagementCluster
@@ -135,11 +160,12 @@ func (r *KubeadmControlPlaneReconciler) Reconcile(req ctrl.Request) (res ctrl.Re
 	}
 	logger = logger.WithValues("cluster", cluster.Name)
 
+	r.managementCluster = &internal.ManagementCluster{Client: r.Client}
+
 	if util.IsPaused(cluster, kcp) {
 		logger.Info("Reconciliation is paused")
-		return ctrl.Result{}, nil
+		return r.reconcileDrift(ctx, cluster, kcp, logger)
 	}
-	r.managementCluster = &internal.ManagementCluster{Client: r.Client}
 
 	// Wait for the cluster infrastructure to be ready before creating machines
 	if !cluster.Status.InfrastructureReady {
@@ -160,6 +186,18 @@ func (r *KubeadmControlPlaneReconciler) Reconcile(req ctrl.Request) (res ctrl.Re
 			reterr = kerrors.NewAggregate([]error{reterr, err})
 		}
 
+		// Always update the readyCondition by summarizing the state of other conditions.
+		if kcp.ObjectMeta.DeletionTimestamp.IsZero() {
+			conditions.SetSummary(kcp,
+				conditions.WithConditions(
+					controlplanev1.MachinesSpecUpToDateCondition,
+					controlplanev1.ResizedCondition,
+					controlplanev1.MachinesReadyCondition,
+					controlplanev1.AvailableCondition,
+				),
+			)
+		}
+
 		// Always attempt to Patch the KubeadmControlPlane object and status after each reconciliation.
 		if err := patchHelper.Patch(ctx, kcp); err != nil {
 			logger.Error(err, "Failed to patch KubeadmControlPlane")
@@ -173,7 +211,37 @@ func (r *KubeadmControlPlaneReconciler) Reconcile(req ctrl.Request) (res ctrl.Re
 	}
 
 	// Handle normal reconciliation loop.
-	return r.reconcile(ctx, cluster, kcp, logger)
+	result, err := r.reconcile(ctx, cluster, kcp, logger)
+	if err != nil {
+		return result, err
+	}
+
+	// Etcd snapshots are taken independently of the other operations on the control plane.
+	backupResult, err := r.reconcileEtcdBackup(ctx, cluster, kcp, logger)
+	if err != nil {
+		logger.Error(err, "Failed to reconcile etcd backup")
+		r.recorder.Eventf(kcp, corev1.EventTypeWarning, "FailedEtcdBackup", "Failed to reconcile etcd backup: %v", err)
+		return result, err
+	}
+	return lowestNonZeroResult(result, backupResult), nil
+}
+
+// lowestNonZeroResult returns the result requeueing first.
+func lowestNonZeroResult(i, j ctrl.Result) ctrl.Result {
+	switch {
+	case i == (ctrl.Result{}):
+		return j
+	case j == (ctrl.Result{}):
+		return i
+	case i.Requeue && i.RequeueAfter == 0:
+		return i
+	case j.Requeue && j.RequeueAfter == 0:
+		return j
+	case i.RequeueAfter < j.RequeueAfter:
+		return i
+	default:
+		return j
+	}
 }
 
 // reconcile handles KubeadmControlPlane reconciliation.
@@ -224,30 +292,73 @@ func (r *KubeadmControlPlaneReconciler) reconcile(ctx context.Context, cluster *
 		return ctrl.Result{}, err
 	}
 
+	if err := r.reconcileCertificateExpiries(ctx, cluster, kcp, ownedMachines); err != nil {
+		// The certificates expiry is only used to schedule rollouts, so failing to read it must not block other operations.
+		logger.Error(err, "Failed to reconcile the certificates expiry of the control plane Machines")
+	}
+
+	// Changes to the node metadata are applied in place, without replacing the control plane Machines.
+	if err := r.reconcileNodeMetadata(ctx, kcp, ownedMachines); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	// Unhealthy Machines are remediated before other operations, as they are putting the control plane at risk.
+	if result, err := r.reconcileUnhealthyMachines(ctx, cluster, kcp, ownedMachines, logger); err != nil || result != (ctrl.Result{}) {
+		if err != nil {
+			logger.Error(err, "Failed to remediate unhealthy control plane Machines")
+			r.recorder.Eventf(kcp, corev1.EventTypeWarning, "FailedRemediation", "Failed to remediate unhealthy control plane Machines: %v", err)
+		}
+		return result, err
+	}
+
+	// Machines are replaced if their configuration is outdated, if they were created before UpgradeAfter once that time
+	// has passed, or if their certificates expire within the RolloutBefore period.
+	now := time.Now()
+	var upgradeAfter *metav1.Time
+	if kcp.Spec.UpgradeAfter != nil && kcp.Spec.UpgradeAfter.Time.Before(now) {
+		upgradeAfter = kcp.Spec.UpgradeAfter
+	}
 	currentConfigurationHash := hash.Compute(&kcp.Spec)
 	requireUpgrade := internal.FilterMachines(
 		ownedMachines,
-		internal.HasOutdatedConfiguration(currentConfigurationHash),
-		internal.OlderThan(kcp.Spec.UpgradeAfter),
+		internal.Or(
+			internal.HasOutdatedConfiguration(currentConfigurationHash),
+			internal.OlderThan(upgradeAfter),
+			internal.HasCertificatesExpiringBefore(certificatesRolloutDeadline(kcp, now)),
+		),
 	)
 
+	// Preflight check failures are reported only while they are blocking the start of an upgrade.
+	kcp.Status.PreflightCheckFailures = nil
+
 	// Upgrade takes precedence over other operations
 	if len(requireUpgrade) > 0 {
 		logger.Info("Upgrading Control Plane")
-		if err := r.upgradeControlPlane(ctx, cluster, kcp, requireUpgrade); err != nil {
+		conditions.MarkFalse(kcp, controlplanev1.MachinesSpecUpToDateCondition, controlplanev1.RollingUpdateInProgressReason, clusterv1.ConditionSeverityWarning, "Rolling %d replicas with outdated spec", len(requireUpgrade))
+		result, err := r.upgradeControlPlane(ctx, cluster, kcp, ownedMachines, requireUpgrade)
+		if err != nil {
 			logger.Error(err, "Failed to upgrade the Control Plane")
 			r.recorder.Eventf(kcp, corev1.EventTypeWarning, "FailedUpgrade", "Failed to upgrade the control plane: %v", err)
-			return ctrl.Result{}, err
 		}
-		// TODO: need to requeue if there are additional operations to perform
-		return ctrl.Result{}, nil
+		return result, err
 	}
 
 	// If we've made it this far, we don't need to worry about Machines that are older than kcp.Spec.UpgradeAfter
+	conditions.MarkTrue(kcp, controlplanev1.MachinesSpecUpToDateCondition)
+
 	currentMachines := internal.FilterMachines(ownedMachines, internal.MatchesConfigurationHash(currentConfigurationHash))
 	numMachines := len(currentMachines)
 	desiredReplicas := int(*kcp.Spec.Replicas)
 
+	switch {
+	case numMachines < desiredReplicas:
+		conditions.MarkFalse(kcp, controlplanev1.ResizedCondition, controlplanev1.ScalingUpReason, clusterv1.ConditionSeverityInfo, "Scaling up to %d replicas (actual %d)", desiredReplicas, numMachines)
+	case numMachines > desiredReplicas:
+		conditions.MarkFalse(kcp, controlplanev1.ResizedCondition, controlplanev1.ScalingDownReason, clusterv1.ConditionSeverityInfo, "Scaling down to %d replicas (actual %d)", desiredReplicas, numMachines)
+	default:
+		conditions.MarkTrue(kcp, controlplanev1.ResizedCondition)
+	}
+
 	switch {
 	// We are creating the first replica
 	case numMachines < desiredReplicas && numMachines == 0:
@@ -263,6 +374,26 @@ func (r *KubeadmControlPlaneReconciler) reconcile(ctx context.Context, cluster *
 		// create a new Machine w/ join
 		logger.Info("Scaling up", "Desired Replicas", desiredReplicas, "Existing Replicas", numMachines)
 		wantMachines := desiredReplicas - numMachines
+		joining := len(internal.FilterMachines(ownedMachines, isJoining))
+		allowedJoins := maxParallelJoins(kcp, len(ownedMachines)-joining) - joining
+		if allowedJoins <= 0 {
+			logger.Info("Waiting for control plane Machines to join the cluster", "Joining Replicas", joining)
+			return ctrl.Result{RequeueAfter: ScaleUpRequeueAfter}, nil
+		}
+		if wantMachines > allowedJoins {
+			wantMachines = allowedJoins
+		}
+		if allowed := r.CreationLimiter.Allow(kcp.UID, wantMachines); allowed < wantMachines {
+			logger.Info("Machine creation limit exceeded, throttling scale up",
+				"limit", r.CreationLimiter.Limit(), "window", r.CreationLimiter.Window(), "requested", wantMachines, "allowed", allowed)
+			r.recorder.Eventf(kcp, corev1.EventTypeWarning, "CreationLimitExceeded",
+				"Requested %d new machines, but only %d machines can be created every %s; creating %d machines",
+				wantMachines, r.CreationLimiter.Limit(), r.CreationLimiter.Window(), allowed)
+			if allowed == 0 {
+				return ctrl.Result{RequeueAfter: r.CreationLimiter.RetryAfter(kcp.UID)}, nil
+			}
+			wantMachines = allowed
+		}
 		if err := r.scaleUpControlPlane(ctx, cluster, kcp, wantMachines); err != nil {
 			logger.Error(err, "Failed to scale up the Control Plane")
 			r.recorder.Eventf(kcp, corev1.EventTypeWarning, "FailedScaleUp", "Failed to scale up the control plane: %v", err)
@@ -271,9 +402,12 @@ func (r *KubeadmControlPlaneReconciler) reconcile(ctx context.Context, cluster *
 	// scaling down
 	case numMachines > desiredReplicas:
 		logger.Info("Scaling down", "Desired Replicas", desiredReplicas, "Existing Replicas", numMachines)
-		err := errors.New("Not Implemented")
-		logger.Error(err, "Should delete the appropriate Machine here.")
-		return ctrl.Result{}, err
+		result, err := r.scaleDownControlPlane(ctx, cluster, kcp, ownedMachines, currentMachines)
+		if err != nil {
+			logger.Error(err, "Failed to scale down the Control Plane")
+			r.recorder.Eventf(kcp, corev1.EventTypeWarning, "FailedScaleDown", "Failed to scale down the control plane: %v", err)
+		}
+		return result, err
 	}
 
 	return ctrl.Result{}, nil
@@ -297,6 +431,8 @@ func (r *KubeadmControlPlaneReconciler) updateStatus(ctx context.Context, kcp *c
 
 	currentMachines := internal.FilterMachines(ownedMachines, internal.MatchesConfigurationHash(hash.Compute(&kcp.Spec)))
 	kcp.Status.UpdatedReplicas = int32(len(currentMachines))
+	updateOutdatedMachines(kcp, cluster, ownedMachines)
+	kcp.Status.CertificatesExpiryDate = certificatesExpiryDate(ownedMachines)
 
 	replicas := int32(len(ownedMachines))
 	kcp.Status.Replicas = replicas
@@ -323,28 +459,175 @@ func (r *KubeadmControlPlaneReconciler) updateStatus(ctx context.Context, kcp *c
 	kcp.Status.ReadyReplicas = readyMachines
 	kcp.Status.UnavailableReplicas = replicas - readyMachines
 
+	// Aggregate the Ready condition of the owned machines into the MachinesReady condition.
+	machines := make([]conditions.Getter, 0, len(ownedMachines))
+	for i := range ownedMachines {
+		machines = append(machines, &ownedMachines[i])
+	}
+	conditions.SetAggregate(kcp, controlplanev1.MachinesReadyCondition, machines)
+
 	if !kcp.Status.Initialized {
 		if kcp.Status.ReadyReplicas > 0 {
 			kcp.Status.Initialized = true
 		}
 	}
+	if kcp.Status.Initialized {
+		conditions.MarkTrue(kcp, controlplanev1.AvailableCondition)
+	} else {
+		conditions.MarkFalse(kcp, controlplanev1.AvailableCondition, controlplanev1.WaitingForKubeadmInitReason, clusterv1.ConditionSeverityInfo, "")
+	}
 	return nil
 }
 
-func (r *KubeadmControlPlaneReconciler) upgradeControlPlane(ctx context.Context, cluster *clusterv1.Cluster, kcp *controlplanev1.KubeadmControlPlane, requireUpgrade []clusterv1.Machine) error {
+// reconcileDrift reports the machines created with an outdated configuration while reconciliation is paused,
+// so configuration drift can be detected before rolling out changes; no other changes are applied.
+func (r *KubeadmControlPlaneReconciler) reconcileDrift(ctx context.Context, cluster *clusterv1.Cluster, kcp *controlplanev1.KubeadmControlPlane, logger logr.Logger) (ctrl.Result, error) {
+	patchHelper, err := patch.NewHelper(kcp, r.Client)
+	if err != nil {
+		logger.Error(err, "Failed to configure the patch helper")
+		return ctrl.Result{Requeue: true}, nil
+	}
 
-	// TODO: verify health for each existing replica
-	// TODO: mark an old Machine via the label kubeadm.controlplane.cluster.x-k8s.io/selected-for-upgrade
-	// TODO: check full cluster health
-	// TODO: provision new Machine to replace marked Machine
-	// TODO: wait for health of all existing replicas, ideally in a re-entrant way to avoid waiting in process
-	// TODO: wait for full cluster health, ideally in a re-entrant way to avoid waiting in process
-	// TODO: Remove etcd membership for old Machine instance
-	// TODO: Update the kubeadm configmap
-	// TODO: Delete the Marked ControlPlane machine
-	// TODO: Continue with next OldMachine
+	ownedMachines, err := r.managementCluster.GetMachinesForCluster(ctx, clusterKey(cluster), internal.OwnedControlPlaneMachines(kcp.Name))
+	if err != nil {
+		return ctrl.Result{}, errors.Wrap(err, "failed to get list of owned machines")
+	}
+	updateOutdatedMachines(kcp, cluster, ownedMachines)
 
-	return nil
+	if err := patchHelper.Patch(ctx, kcp); err != nil {
+		logger.Error(err, "Failed to patch KubeadmControlPlane")
+		return ctrl.Result{}, err
+	}
+
+	return ctrl.Result{RequeueAfter: r.DriftCheckInterval}, nil
+}
+
+// updateOutdatedMachines reports, in the KubeadmControlPlane status and in the outdated machines metric, the machines
+// created with a configuration hash that does not match the current spec.
+func updateOutdatedMachines(kcp *controlplanev1.KubeadmControlPlane, cluster *clusterv1.Cluster, machines []clusterv1.Machine) {
+	outdatedMachines := internal.FilterMachines(machines, internal.HasOutdatedConfiguration(hash.Compute(&kcp.Spec)))
+
+	kcp.Status.OutdatedMachines = nil
+	for _, m := range outdatedMachines {
+		kcp.Status.OutdatedMachines = append(kcp.Status.OutdatedMachines, m.Name)
+	}
+	sort.Strings(kcp.Status.OutdatedMachines)
+
+	controlPlaneOutdatedMachines.WithLabelValues(cluster.Name, cluster.Namespace).Set(float64(len(outdatedMachines)))
+}
+
+// upgradeControlPlane replaces one outdated control plane Machine at a time, following the rollout strategy:
+// with a MaxSurge of 1 a new Machine is created before an outdated one is deleted, with a MaxSurge of 0 an
+// outdated Machine is deleted before its replacement is created. The rollout does not start until the preflight checks
+// against the workload cluster pass, unless they are skipped with the SkipUpgradePreflightChecksAnnotation.
+func (r *KubeadmControlPlaneReconciler) upgradeControlPlane(ctx context.Context, cluster *clusterv1.Cluster, kcp *controlplanev1.KubeadmControlPlane, ownedMachines, requireUpgrade []clusterv1.Machine) (ctrl.Result, error) {
+	logger := r.Log.WithValues("namespace", kcp.Namespace, "kubeadmControlPlane", kcp.Name, "cluster", cluster.Name)
+
+	// Wait for Machines being deleted or joining the cluster, so only one Machine is replaced at a time.
+	if len(internal.FilterMachines(ownedMachines, isDeleting)) > 0 {
+		logger.Info("Waiting for control plane Machines being deleted")
+		return ctrl.Result{RequeueAfter: DeleteRequeueAfter}, nil
+	}
+	if len(internal.FilterMachines(ownedMachines, isJoining)) > 0 {
+		logger.Info("Waiting for control plane Machines to join the cluster")
+		return ctrl.Result{RequeueAfter: UpgradeRequeueAfter}, nil
+	}
+
+	desiredReplicas := int(*kcp.Spec.Replicas)
+
+	// The preflight checks are run only before starting the rollout, when no outdated Machine has been replaced yet.
+	if len(ownedMachines) == desiredReplicas && len(requireUpgrade) == len(ownedMachines) && !preflight.IsSkipped(kcp) {
+		failures, err := r.managementCluster.TargetClusterUpgradePreflightChecks(ctx, clusterKey(cluster), kcp.Name)
+		if err != nil {
+			return ctrl.Result{}, errors.Wrap(err, "failed to run upgrade preflight checks")
+		}
+		if len(failures) > 0 {
+			kcp.Status.PreflightCheckFailures = failures
+			logger.Info("Upgrade blocked by failing preflight checks", "failures", failures)
+			r.recorder.Eventf(kcp, corev1.EventTypeWarning, "UpgradeBlocked", "Upgrade blocked by failing preflight checks: %s", strings.Join(failures, "; "))
+			return ctrl.Result{RequeueAfter: PreflightChecksRequeueAfter}, nil
+		}
+	}
+
+	if len(ownedMachines) < desiredReplicas+rolloutMaxSurge(kcp) {
+		logger.Info("Creating a replacement control plane Machine", "Desired Replicas", desiredReplicas, "Existing Replicas", len(ownedMachines))
+		if allowed := r.CreationLimiter.Allow(kcp.UID, 1); allowed == 0 {
+			logger.Info("Machine creation limit exceeded, throttling upgrade",
+				"limit", r.CreationLimiter.Limit(), "window", r.CreationLimiter.Window())
+			return ctrl.Result{RequeueAfter: r.CreationLimiter.RetryAfter(kcp.UID)}, nil
+		}
+		if err := r.scaleUpControlPlane(ctx, cluster, kcp, 1); err != nil {
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{RequeueAfter: UpgradeRequeueAfter}, nil
+	}
+
+	logger.Info("Deleting an outdated control plane Machine", "Outdated Replicas", len(requireUpgrade))
+	return r.scaleDownControlPlane(ctx, cluster, kcp, ownedMachines, requireUpgrade)
+}
+
+// reconcileNodeMetadata updates the labels, annotations and Node taints of the control plane Machines to the node
+// metadata of the KubeadmControlPlane; the Machine controller syncs them to the Nodes.
+func (r *KubeadmControlPlaneReconciler) reconcileNodeMetadata(ctx context.Context, kcp *controlplanev1.KubeadmControlPlane, machines []clusterv1.Machine) error {
+	template := nodeMetadataTemplate(kcp)
+
+	var errs []error
+	for i := range machines {
+		machine := &machines[i]
+		if isDeleting(*machine) {
+			continue
+		}
+		patch := client.MergeFrom(machine.DeepCopy())
+		if !mdutil.SyncMachineInPlaceMutableFields(machine, template) {
+			continue
+		}
+		if err := r.Client.Patch(ctx, machine, patch); err != nil {
+			errs = append(errs, errors.Wrapf(err, "failed to update control plane Machine %q in place", machine.Name))
+		}
+	}
+	return kerrors.NewAggregate(errs)
+}
+
+// nodeMetadataTemplate returns a machine template with the node metadata of the KubeadmControlPlane.
+func nodeMetadataTemplate(kcp *controlplanev1.KubeadmControlPlane) *clusterv1.MachineTemplateSpec {
+	template := &clusterv1.MachineTemplateSpec{}
+	if kcp.Spec.NodeMetadata != nil {
+		template.Labels = kcp.Spec.NodeMetadata.Labels
+		template.Annotations = kcp.Spec.NodeMetadata.Annotations
+		template.Spec.NodeTaints = kcp.Spec.NodeMetadata.Taints
+	}
+	return template
+}
+
+// rolloutMaxSurge returns the maximum number of control plane Machines that can be created above the desired number
+// of replicas while replacing outdated Machines.
+func rolloutMaxSurge(kcp *controlplanev1.KubeadmControlPlane) int {
+	if kcp.Spec.RolloutStrategy == nil || kcp.Spec.RolloutStrategy.RollingUpdate == nil || kcp.Spec.RolloutStrategy.RollingUpdate.MaxSurge == nil {
+		return 1
+	}
+	return kcp.Spec.RolloutStrategy.RollingUpdate.MaxSurge.IntValue()
+}
+
+// maxParallelJoins returns how many control plane Machines can be joining the cluster at the same time, given the
+// number of Machines that have already joined. It is the MaxParallelJoins of the scale up strategy, limited so that the
+// etcd quorum of the resulting cluster does not require more members than the joined ones, or than a single join
+// would: a member added by kubeadm counts toward the quorum before its etcd instance is running.
+func maxParallelJoins(kcp *controlplanev1.KubeadmControlPlane, joined int) int {
+	maxJoins := 1
+	if kcp.Spec.ScaleUpStrategy != nil && kcp.Spec.ScaleUpStrategy.MaxParallelJoins != nil {
+		maxJoins = int(*kcp.Spec.ScaleUpStrategy.MaxParallelJoins)
+	}
+
+	quorum := func(members int) int { return members/2 + 1 }
+	maxQuorum := joined
+	if q := quorum(joined + 1); q > maxQuorum {
+		maxQuorum = q
+	}
+	joins := 1
+	for joins < maxJoins && quorum(joined+joins+1) <= maxQuorum {
+		joins++
+	}
+	return joins
 }
 
 func (r *KubeadmControlPlaneReconciler) scaleUpControlPlane(ctx context.Context, cluster *clusterv1.Cluster, kcp *controlplanev1.KubeadmControlPlane, numMachines int) error {
@@ -355,23 +638,84 @@ func (r *KubeadmControlPlaneReconciler) scaleUpControlPlane(ctx context.Context,
 	bootstrapSpec.InitConfiguration = nil
 	bootstrapSpec.ClusterConfiguration = nil
 
+	// Machines are required for picking failure domains only if there are control plane failure domains defined on the cluster.
+	var ownedMachines []clusterv1.Machine
+	if len(cluster.Status.FailureDomains.FilterControlPlane()) > 0 {
+		var err error
+		ownedMachines, err = r.managementCluster.GetMachinesForCluster(ctx, clusterKey(cluster), internal.OwnedControlPlaneMachines(kcp.Name))
+		if err != nil {
+			return err
+		}
+	}
+
 	for i := 0; i < numMachines; i++ {
-		err := r.cloneConfigsAndGenerateMachine(ctx, cluster, kcp, bootstrapSpec)
+		// The failure domain is picked taking into account the Machines created in the previous iterations,
+		// that might not be visible yet when reading Machines.
+		fd := failureDomainForScaleUp(cluster, ownedMachines)
+		err := r.cloneConfigsAndGenerateMachine(ctx, cluster, kcp, bootstrapSpec, fd)
 		if err != nil {
 			errs = append(errs, errors.Wrap(err, "failed to clone and create an additional control plane Machine"))
+			continue
 		}
+		r.CreationLimiter.Record(kcp.UID)
+		ownedMachines = append(ownedMachines, clusterv1.Machine{Spec: clusterv1.MachineSpec{FailureDomain: fd}})
 	}
 
 	return kerrors.NewAggregate(errs)
 }
 
+// scaleDownControlPlane deletes one of the candidate control plane Machines, picking the oldest Machine in the failure
+// domain with the most candidates, so the remaining Machines are spread across failure domains; the etcd member running
+// on the Machine is removed before deleting it.
+func (r *KubeadmControlPlaneReconciler) scaleDownControlPlane(ctx context.Context, cluster *clusterv1.Cluster, kcp *controlplanev1.KubeadmControlPlane, ownedMachines, candidates []clusterv1.Machine) (ctrl.Result, error) {
+	logger := r.Log.WithValues("namespace", kcp.Namespace, "kubeadmControlPlane", kcp.Name, "cluster", cluster.Name)
+
+	// Wait for Machines being deleted, so only one Machine is deleted at a time.
+	if len(internal.FilterMachines(ownedMachines, isDeleting)) > 0 {
+		logger.Info("Waiting for control plane Machines being deleted")
+		return ctrl.Result{RequeueAfter: DeleteRequeueAfter}, nil
+	}
+
+	// Removing the last etcd member would destroy the cluster.
+	if len(ownedMachines) <= 1 {
+		return ctrl.Result{}, errors.New("cannot delete the last control plane Machine")
+	}
+
+	if err := r.managementCluster.TargetClusterControlPlaneIsHealthy(ctx, clusterKey(cluster), kcp.Name); err != nil {
+		return ctrl.Result{}, errors.Wrap(err, "control plane is not healthy")
+	}
+	if err := r.managementCluster.TargetClusterEtcdIsHealthy(ctx, clusterKey(cluster), kcp.Name); err != nil {
+		return ctrl.Result{}, errors.Wrap(err, "etcd cluster is not healthy")
+	}
+
+	machineToDelete := selectMachineForScaleDown(cluster, candidates)
+	if machineToDelete == nil {
+		return ctrl.Result{}, errors.New("failed to pick a control plane Machine to delete")
+	}
+
+	if err := r.managementCluster.RemoveEtcdMemberForMachine(ctx, clusterKey(cluster), machineToDelete); err != nil {
+		return ctrl.Result{}, errors.Wrapf(err, "failed to remove etcd member for Machine %q", machineToDelete.Name)
+	}
+
+	logger.Info("Deleting control plane Machine", "machine", machineToDelete.Name, "failure-domain", machineToDelete.Spec.FailureDomain)
+	if err := r.Client.Delete(ctx, machineToDelete); err != nil && !apierrors.IsNotFound(err) {
+		return ctrl.Result{}, errors.Wrapf(err, "failed to delete control plane Machine %q", machineToDelete.Name)
+	}
+
+	return ctrl.Result{RequeueAfter: DeleteRequeueAfter}, nil
+}
+
 func (r *KubeadmControlPlaneReconciler) initializeControlPlane(ctx context.Context, cluster *clusterv1.Cluster, kcp *controlplanev1.KubeadmControlPlane) error {
 	bootstrapSpec := kcp.Spec.KubeadmConfigSpec.DeepCopy()
 	bootstrapSpec.JoinConfiguration = nil
-	return r.cloneConfigsAndGenerateMachine(ctx, cluster, kcp, bootstrapSpec)
+	if err := r.cloneConfigsAndGenerateMachine(ctx, cluster, kcp, bootstrapSpec, failureDomainForScaleUp(cluster, nil)); err != nil {
+		return err
+	}
+	r.CreationLimiter.Record(kcp.UID)
+	return nil
 }
 
-func (r *KubeadmControlPlaneReconciler) cloneConfigsAndGenerateMachine(ctx context.Context, cluster *clusterv1.Cluster, kcp *controlplanev1.KubeadmControlPlane, bootstrapSpec *bootstrapv1.KubeadmConfigSpec) error {
+func (r *KubeadmControlPlaneReconciler) cloneConfigsAndGenerateMachine(ctx context.Context, cluster *clusterv1.Cluster, kcp *controlplanev1.KubeadmControlPlane, bootstrapSpec *bootstrapv1.KubeadmConfigSpec, failureDomain *string) error {
 	var errs []error
 
 	// Since the cloned resource should eventually have a controller ref for the Machine, we create an
@@ -405,7 +749,7 @@ func (r *KubeadmControlPlaneReconciler) cloneConfigsAndGenerateMachine(ctx conte
 
 	// Only proceed to generating the Machine if we haven't encountered an error
 	if len(errs) == 0 {
-		if err := r.generateMachine(ctx, kcp, cluster, infraRef, bootstrapRef); err != nil {
+		if err := r.generateMachine(ctx, kcp, cluster, infraRef, bootstrapRef, failureDomain); err != nil {
 			errs = append(errs, errors.Wrap(err, "failed to create Machine"))
 		}
 	}
@@ -477,25 +821,44 @@ func (r *KubeadmControlPlaneReconciler) generateKubeadmConfig(ctx context.Contex
 	return bootstrapRef, nil
 }
 
-func (r *KubeadmControlPlaneReconciler) failureDomainForScaleUp(ctx context.Context, kcp *controlplanev1.KubeadmControlPlane, cluster *clusterv1.Cluster) (*string, error) {
-	// Don't do anything if there are no failure domains defined on the cluster.
-	if len(cluster.Status.FailureDomains) == 0 {
-		return nil, nil
-	}
-	ownedMachines, err := r.managementCluster.GetMachinesForCluster(ctx, clusterKey(cluster), internal.OwnedControlPlaneMachines(kcp.Name))
-	if err != nil {
-		return nil, err
+// failureDomainForScaleUp returns the control plane failure domain with the fewest Machines, or nil if there are
+// no control plane failure domains defined on the cluster.
+func failureDomainForScaleUp(cluster *clusterv1.Cluster, machines []clusterv1.Machine) *string {
+	failureDomain := internal.PickFewest(cluster.Status.FailureDomains.FilterControlPlane(), machines)
+	if failureDomain == "" {
+		return nil
 	}
-	failureDomain := internal.PickFewest(cluster.Status.FailureDomains.FilterControlPlane(), ownedMachines)
-	return &failureDomain, nil
+	return &failureDomain
 }
 
-func (r *KubeadmControlPlaneReconciler) generateMachine(ctx context.Context, kcp *controlplanev1.KubeadmControlPlane, cluster *clusterv1.Cluster, infraRef, bootstrapRef *corev1.ObjectReference) error {
-	fd, err := r.failureDomainForScaleUp(ctx, kcp, cluster)
-	if err != nil {
-		return err
+// selectMachineForScaleDown returns the oldest Machine in the control plane failure domain with the most Machines;
+// if there are no control plane failure domains defined on the cluster, the oldest Machine is returned.
+func selectMachineForScaleDown(cluster *clusterv1.Cluster, machines []clusterv1.Machine) *clusterv1.Machine {
+	candidates := machines
+	if failureDomain := internal.PickMost(cluster.Status.FailureDomains.FilterControlPlane(), machines); failureDomain != "" {
+		if inFailureDomain := internal.FilterMachines(machines, internal.InFailureDomain(&failureDomain)); len(inFailureDomain) > 0 {
+			candidates = inFailureDomain
+		}
 	}
 
+	var oldest *clusterv1.Machine
+	for i := range candidates {
+		if oldest == nil || candidates[i].CreationTimestamp.Before(&oldest.CreationTimestamp) {
+			oldest = &candidates[i]
+		}
+	}
+	return oldest
+}
+
+func isDeleting(machine clusterv1.Machine) bool {
+	return !machine.DeletionTimestamp.IsZero()
+}
+
+func isJoining(machine clusterv1.Machine) bool {
+	return machine.Status.NodeRef == nil
+}
+
+func (r *KubeadmControlPlaneReconciler) generateMachine(ctx context.Context, kcp *controlplanev1.KubeadmControlPlane, cluster *clusterv1.Cluster, infraRef, bootstrapRef *corev1.ObjectReference, failureDomain *string) error {
 	machine := &clusterv1.Machine{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      names.SimpleNameGenerator.GenerateName(kcp.Name + "-"),
@@ -512,9 +875,10 @@ func (r *KubeadmControlPlaneReconciler) generateMachine(ctx context.Context, kcp
 			Bootstrap: clusterv1.Bootstrap{
 				ConfigRef: bootstrapRef,
 			},
-			FailureDomain: fd,
+			FailureDomain: failureDomain,
 		},
 	}
+	mdutil.SyncMachineInPlaceMutableFields(machine, nodeMetadataTemplate(kcp))
 
 	if err := r.Client.Create(ctx, machine); err != nil {
 		return errors.Wrap(err, "Failed to create machine")
@@ -528,6 +892,8 @@ func (r *KubeadmControlPlaneReconciler) generateMachine(ctx context.Context, kcp
 // consideration. This may or may not change in the future. Please see
 // https://github.com/kubernetes-sigs/cluster-api/issues/2064
 func (r *KubeadmControlPlaneReconciler) reconcileDelete(ctx context.Context, cluster *clusterv1.Cluster, kcp *controlplanev1.KubeadmControlPlane, logger logr.Logger) (_ ctrl.Result, reterr error) {
+	conditions.MarkFalse(kcp, clusterv1.ReadyCondition, clusterv1.DeletingReason, clusterv1.ConditionSeverityInfo, "")
+
 	allMachines, err := r.managementCluster.GetMachinesForCluster(ctx, clusterKey(cluster))
 	if err != nil {
 		return ctrl.Result{}, err
@@ -20,6 +20,7 @@ import (
 	"context"
 	"fmt"
 	"testing"
+	"time"
 
 	. "github.com/onsi/gomega"
 	corev1 "k8s.io/api/core/v1"
@@ -27,6 +28,7 @@ import (
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/tools/record"
 	"k8s.io/klog/klogr"
@@ -44,6 +46,7 @@ import (
 	controlplanev1 "sigs.k8s.io/cluster-api/controlplane/kubeadm/api/v1alpha3"
 	"sigs.k8s.io/cluster-api/controlplane/kubeadm/internal"
 	"sigs.k8s.io/cluster-api/controlplane/kubeadm/internal/hash"
+	"sigs.k8s.io/cluster-api/util/guardrails"
 	"sigs.k8s.io/cluster-api/util/kubeconfig"
 	"sigs.k8s.io/cluster-api/util/secret"
 )
@@ -699,7 +702,7 @@ func TestKubeadmControlPlaneReconciler_generateMachine(t *testing.T) {
 		Log:               log.Log,
 		managementCluster: &internal.ManagementCluster{Client: fakeClient},
 	}
-	g.Expect(r.generateMachine(context.Background(), kcp, cluster, infraRef, bootstrapRef)).To(Succeed())
+	g.Expect(r.generateMachine(context.Background(), kcp, cluster, infraRef, bootstrapRef, nil)).To(Succeed())
 
 	machineList := &clusterv1.MachineList{}
 	g.Expect(fakeClient.List(context.Background(), machineList, client.InNamespace(cluster.Namespace))).To(Succeed())
@@ -862,6 +865,102 @@ func TestKubeadmControlPlaneReconciler_updateStatusNoMachines(t *testing.T) {
 	g.Expect(kcp.Status.FailureReason).To(BeEquivalentTo(""))
 }
 
+func TestKubeadmControlPlaneReconciler_reconcileDrift(t *testing.T) {
+	g := NewWithT(t)
+
+	cluster := &clusterv1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "foo",
+			Namespace: "test",
+		},
+	}
+
+	kcp := &controlplanev1.KubeadmControlPlane{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: cluster.Namespace,
+			Name:      "foo",
+			Annotations: map[string]string{
+				clusterv1.PausedAnnotation: "true",
+			},
+		},
+	}
+	kcp.Default()
+	g.Expect(kcp.ValidateCreate()).To(Succeed())
+
+	updated, _ := createMachineNodePair("updated", cluster, kcp, true)
+	updated.Labels = internal.ControlPlaneLabelsForClusterWithHash(cluster.Name, hash.Compute(&kcp.Spec))
+	outdated, _ := createMachineNodePair("outdated", cluster, kcp, true)
+	outdated.Labels = internal.ControlPlaneLabelsForClusterWithHash(cluster.Name, "outdated-hash")
+
+	g.Expect(clusterv1.AddToScheme(scheme.Scheme)).To(Succeed())
+	g.Expect(bootstrapv1.AddToScheme(scheme.Scheme)).To(Succeed())
+	g.Expect(controlplanev1.AddToScheme(scheme.Scheme)).To(Succeed())
+	fakeClient := fake.NewFakeClientWithScheme(scheme.Scheme, kcp.DeepCopy(), cluster, updated, outdated)
+
+	r := &KubeadmControlPlaneReconciler{
+		Client:             fakeClient,
+		Log:                log.Log,
+		scheme:             scheme.Scheme,
+		managementCluster:  &internal.ManagementCluster{Client: fakeClient},
+		DriftCheckInterval: 5 * time.Minute,
+	}
+
+	result, err := r.reconcileDrift(context.Background(), cluster, kcp, r.Log)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(result).To(Equal(ctrl.Result{RequeueAfter: 5 * time.Minute}))
+	g.Expect(kcp.Status.OutdatedMachines).To(ConsistOf("outdated"))
+
+	// The status is persisted even if reconciliation is paused.
+	updatedKCP := &controlplanev1.KubeadmControlPlane{}
+	g.Expect(fakeClient.Get(context.Background(), client.ObjectKey{Namespace: kcp.Namespace, Name: kcp.Name}, updatedKCP)).To(Succeed())
+	g.Expect(updatedKCP.Status.OutdatedMachines).To(ConsistOf("outdated"))
+}
+
+func TestKubeadmControlPlaneReconciler_reconcileNodeMetadata(t *testing.T) {
+	g := NewWithT(t)
+
+	cluster := &clusterv1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "foo",
+			Namespace: "test",
+		},
+	}
+
+	kcp := &controlplanev1.KubeadmControlPlane{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: cluster.Namespace,
+			Name:      "foo",
+		},
+		Spec: controlplanev1.KubeadmControlPlaneSpec{
+			NodeMetadata: &controlplanev1.NodeMetadata{
+				Labels: map[string]string{"node.cluster.x-k8s.io/tier": "control-plane"},
+				Taints: []corev1.Taint{{Key: "dedicated", Value: "control-plane", Effect: corev1.TaintEffectNoSchedule}},
+			},
+		},
+	}
+
+	machine, _ := createMachineNodePair("machine", cluster, kcp, true)
+	machine.Labels["node.cluster.x-k8s.io/removed"] = "true"
+	hashLabel := machine.Labels[controlplanev1.KubeadmControlPlaneHashLabelKey]
+
+	g.Expect(clusterv1.AddToScheme(scheme.Scheme)).To(Succeed())
+	fakeClient := fake.NewFakeClientWithScheme(scheme.Scheme, machine.DeepCopy())
+
+	r := &KubeadmControlPlaneReconciler{
+		Client: fakeClient,
+		Log:    log.Log,
+	}
+	g.Expect(r.reconcileNodeMetadata(context.Background(), kcp, []clusterv1.Machine{*machine})).To(Succeed())
+
+	// The node metadata is updated in place, without changing the configuration hash.
+	updated := &clusterv1.Machine{}
+	g.Expect(fakeClient.Get(context.Background(), client.ObjectKey{Namespace: machine.Namespace, Name: machine.Name}, updated)).To(Succeed())
+	g.Expect(updated.Labels).To(HaveKeyWithValue("node.cluster.x-k8s.io/tier", "control-plane"))
+	g.Expect(updated.Labels).NotTo(HaveKey("node.cluster.x-k8s.io/removed"))
+	g.Expect(updated.Labels[controlplanev1.KubeadmControlPlaneHashLabelKey]).To(Equal(hashLabel))
+	g.Expect(updated.Spec.NodeTaints).To(Equal(kcp.Spec.NodeMetadata.Taints))
+}
+
 func createMachineNodePair(name string, cluster *clusterv1.Cluster, kcp *controlplanev1.KubeadmControlPlane, ready bool) (*clusterv1.Machine, *corev1.Node) {
 	machine := &clusterv1.Machine{
 		ObjectMeta: metav1.ObjectMeta{
@@ -1126,7 +1225,8 @@ func TestReconcileControlPlaneScaleUp(t *testing.T) {
 				InitConfiguration:    &kubeadmv1.InitConfiguration{},
 				JoinConfiguration:    &kubeadmv1.JoinConfiguration{},
 			},
-			Replicas: utilpointer.Int32Ptr(3),
+			Replicas:        utilpointer.Int32Ptr(3),
+			ScaleUpStrategy: &controlplanev1.ScaleUpStrategy{MaxParallelJoins: utilpointer.Int32Ptr(2)},
 		},
 	}
 
@@ -1164,14 +1264,26 @@ func TestReconcileControlPlaneScaleUp(t *testing.T) {
 		scheme:             scheme.Scheme,
 	}
 
+	// No Machine joins until the first one has joined the cluster.
 	result, err := r.Reconcile(ctrl.Request{NamespacedName: types.NamespacedName{Name: kcp.Name, Namespace: kcp.Namespace}})
 	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(result).To(Equal(ctrl.Result{RequeueAfter: ScaleUpRequeueAfter}))
+
+	machineList := &clusterv1.MachineList{}
+	g.Expect(fakeClient.List(context.Background(), machineList, client.InNamespace("test"))).To(Succeed())
+	g.Expect(machineList.Items).To(HaveLen(1))
+
+	// Scaling from 1 to 3 replicas keeps the etcd quorum of a single join, so both Machines join in parallel.
+	machine.Status.NodeRef = &corev1.ObjectReference{Kind: "Node", Name: machine.Name}
+	g.Expect(fakeClient.Update(context.Background(), machine)).To(Succeed())
+
+	result, err = r.Reconcile(ctrl.Request{NamespacedName: types.NamespacedName{Name: kcp.Name, Namespace: kcp.Namespace}})
+	g.Expect(err).NotTo(HaveOccurred())
 	g.Expect(result).To(Equal(ctrl.Result{}))
 	g.Expect(r.Client.Get(context.Background(), types.NamespacedName{Name: kcp.Name, Namespace: kcp.Namespace}, kcp)).To(Succeed())
 
 	g.Expect(kcp.Status.Replicas).To(BeEquivalentTo(3))
 
-	machineList := &clusterv1.MachineList{}
 	g.Expect(fakeClient.List(context.Background(), machineList, client.InNamespace("test"))).To(Succeed())
 	g.Expect(machineList.Items).To(HaveLen(3))
 	for _, m := range machineList.Items {
@@ -1179,6 +1291,135 @@ func TestReconcileControlPlaneScaleUp(t *testing.T) {
 	}
 }
 
+func TestReconcileControlPlaneScaleUpCreationLimitExceeded(t *testing.T) {
+	g := NewWithT(t)
+
+	cluster := &clusterv1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "foo",
+			Namespace: "test",
+		},
+		Spec: clusterv1.ClusterSpec{
+			ControlPlaneEndpoint: clusterv1.APIEndpoint{
+				Host: "test.local",
+				Port: 9999,
+			},
+			ControlPlaneRef: &corev1.ObjectReference{
+				Kind:       "KubeadmControlPlane",
+				Namespace:  "test",
+				Name:       "kcp-foo",
+				APIVersion: controlplanev1.GroupVersion.String(),
+			},
+		},
+		Status: clusterv1.ClusterStatus{
+			InfrastructureReady: true,
+		},
+	}
+
+	genericMachineTemplate := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"kind":       "GenericMachineTemplate",
+			"apiVersion": "generic.io/v1",
+			"metadata": map[string]interface{}{
+				"name":      "infra-foo",
+				"namespace": cluster.Namespace,
+			},
+			"spec": map[string]interface{}{
+				"template": map[string]interface{}{
+					"spec": map[string]interface{}{
+						"hello": "world",
+					},
+				},
+			},
+		},
+	}
+
+	kcp := &controlplanev1.KubeadmControlPlane{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: cluster.Namespace,
+			Name:      "foo",
+			OwnerReferences: []metav1.OwnerReference{
+				{
+					Kind:       "Cluster",
+					APIVersion: clusterv1.GroupVersion.String(),
+					Name:       cluster.Name,
+				},
+			},
+		},
+		Spec: controlplanev1.KubeadmControlPlaneSpec{
+			InfrastructureTemplate: corev1.ObjectReference{
+				Kind:       genericMachineTemplate.GetKind(),
+				Namespace:  genericMachineTemplate.GetNamespace(),
+				Name:       genericMachineTemplate.GetName(),
+				APIVersion: genericMachineTemplate.GetAPIVersion(),
+			},
+			KubeadmConfigSpec: bootstrapv1.KubeadmConfigSpec{
+				ClusterConfiguration: &kubeadmv1.ClusterConfiguration{},
+				InitConfiguration:    &kubeadmv1.InitConfiguration{},
+				JoinConfiguration:    &kubeadmv1.JoinConfiguration{},
+			},
+			Replicas:        utilpointer.Int32Ptr(3),
+			ScaleUpStrategy: &controlplanev1.ScaleUpStrategy{MaxParallelJoins: utilpointer.Int32Ptr(2)},
+		},
+	}
+
+	machine := &clusterv1.Machine{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "foo-0",
+			Namespace: cluster.Namespace,
+			Labels:    internal.ControlPlaneLabelsForClusterWithHash(cluster.Name, hash.Compute(&kcp.Spec)),
+			OwnerReferences: []metav1.OwnerReference{
+				*metav1.NewControllerRef(kcp, controlplanev1.GroupVersion.WithKind("KubeadmControlPlane")),
+			},
+		},
+		Status: clusterv1.MachineStatus{
+			NodeRef: &corev1.ObjectReference{Kind: "Node", Name: "foo-0"},
+		},
+	}
+
+	kcp.Default()
+	g.Expect(kcp.ValidateCreate()).To(Succeed())
+
+	g.Expect(clusterv1.AddToScheme(scheme.Scheme)).To(Succeed())
+	g.Expect(bootstrapv1.AddToScheme(scheme.Scheme)).To(Succeed())
+	g.Expect(controlplanev1.AddToScheme(scheme.Scheme)).To(Succeed())
+	fakeClient := fake.NewFakeClientWithScheme(
+		scheme.Scheme,
+		kcp.DeepCopy(),
+		cluster.DeepCopy(),
+		machine.DeepCopy(),
+		genericMachineTemplate.DeepCopy(),
+	)
+	log.SetLogger(klogr.New())
+
+	r := &KubeadmControlPlaneReconciler{
+		Client:             fakeClient,
+		Log:                log.Log,
+		CreationLimiter:    guardrails.NewCreationLimiter(1, time.Hour),
+		remoteClientGetter: fakeremote.NewClusterClient,
+		recorder:           record.NewFakeRecorder(32),
+		scheme:             scheme.Scheme,
+	}
+
+	// Only one of the two missing machines is created within the window.
+	result, err := r.Reconcile(ctrl.Request{NamespacedName: types.NamespacedName{Name: kcp.Name, Namespace: kcp.Namespace}})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(result).To(Equal(ctrl.Result{}))
+
+	machineList := &clusterv1.MachineList{}
+	g.Expect(fakeClient.List(context.Background(), machineList, client.InNamespace("test"))).To(Succeed())
+	g.Expect(machineList.Items).To(HaveLen(2))
+
+	// Once the limit is reached, no machines are created and the reconcile is requeued at the end of the window.
+	result, err = r.Reconcile(ctrl.Request{NamespacedName: types.NamespacedName{Name: kcp.Name, Namespace: kcp.Namespace}})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(result.RequeueAfter).To(BeNumerically(">", 0))
+	g.Expect(result.RequeueAfter).To(BeNumerically("<=", time.Hour))
+
+	g.Expect(fakeClient.List(context.Background(), machineList, client.InNamespace("test"))).To(Succeed())
+	g.Expect(machineList.Items).To(HaveLen(2))
+}
+
 func TestScaleUpControlPlaneAddsANewMachine(t *testing.T) {
 	g := NewWithT(t)
 
@@ -1255,6 +1496,370 @@ func TestScaleUpControlPlaneAddsANewMachine(t *testing.T) {
 	}
 }
 
+func TestScaleUpControlPlaneSpreadsAcrossFailureDomains(t *testing.T) {
+	g := NewWithT(t)
+
+	cluster := &clusterv1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "foo",
+			Namespace: "test",
+		},
+		Status: clusterv1.ClusterStatus{
+			FailureDomains: clusterv1.FailureDomains{
+				"one":   clusterv1.FailureDomainSpec{ControlPlane: true},
+				"two":   clusterv1.FailureDomainSpec{ControlPlane: true},
+				"three": clusterv1.FailureDomainSpec{ControlPlane: true},
+				"four":  clusterv1.FailureDomainSpec{ControlPlane: false},
+			},
+		},
+	}
+
+	genericMachineTemplate := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"kind":       "GenericMachineTemplate",
+			"apiVersion": "generic.io/v1",
+			"metadata": map[string]interface{}{
+				"name":      "infra-foo",
+				"namespace": cluster.Namespace,
+			},
+			"spec": map[string]interface{}{
+				"template": map[string]interface{}{
+					"spec": map[string]interface{}{
+						"hello": "world",
+					},
+				},
+			},
+		},
+	}
+
+	kcp := &controlplanev1.KubeadmControlPlane{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "kcp-foo",
+			Namespace: cluster.Namespace,
+		},
+		Spec: controlplanev1.KubeadmControlPlaneSpec{
+			InfrastructureTemplate: corev1.ObjectReference{
+				Kind:       genericMachineTemplate.GetKind(),
+				Namespace:  genericMachineTemplate.GetNamespace(),
+				Name:       genericMachineTemplate.GetName(),
+				APIVersion: genericMachineTemplate.GetAPIVersion(),
+			},
+			KubeadmConfigSpec: bootstrapv1.KubeadmConfigSpec{
+				ClusterConfiguration: &kubeadmv1.ClusterConfiguration{},
+				InitConfiguration:    &kubeadmv1.InitConfiguration{},
+				JoinConfiguration:    &kubeadmv1.JoinConfiguration{},
+			},
+		},
+	}
+
+	g.Expect(clusterv1.AddToScheme(scheme.Scheme)).To(Succeed())
+	g.Expect(bootstrapv1.AddToScheme(scheme.Scheme)).To(Succeed())
+	g.Expect(controlplanev1.AddToScheme(scheme.Scheme)).To(Succeed())
+	fakeClient := fake.NewFakeClientWithScheme(
+		scheme.Scheme,
+		cluster.DeepCopy(),
+		kcp.DeepCopy(),
+		genericMachineTemplate.DeepCopy(),
+	)
+
+	r := &KubeadmControlPlaneReconciler{
+		Client:            fakeClient,
+		Log:               log.Log,
+		recorder:          record.NewFakeRecorder(32),
+		scheme:            scheme.Scheme,
+		managementCluster: &internal.ManagementCluster{Client: fakeClient},
+	}
+
+	g.Expect(r.scaleUpControlPlane(context.Background(), cluster, kcp, 3)).To(Succeed())
+
+	machineList := &clusterv1.MachineList{}
+	g.Expect(fakeClient.List(context.Background(), machineList, client.InNamespace(cluster.Namespace))).To(Succeed())
+	g.Expect(machineList.Items).To(HaveLen(3))
+
+	failureDomains := []string{}
+	for _, m := range machineList.Items {
+		g.Expect(m.Spec.FailureDomain).ToNot(BeNil())
+		failureDomains = append(failureDomains, *m.Spec.FailureDomain)
+	}
+	g.Expect(failureDomains).To(ConsistOf("one", "two", "three"))
+}
+
+func TestSelectMachineForScaleDown(t *testing.T) {
+	one := "one"
+	two := "two"
+	now := time.Now()
+
+	machine := func(name string, failureDomain *string, age time.Duration) clusterv1.Machine {
+		return clusterv1.Machine{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              name,
+				CreationTimestamp: metav1.NewTime(now.Add(-age)),
+			},
+			Spec: clusterv1.MachineSpec{FailureDomain: failureDomain},
+		}
+	}
+
+	testCases := []struct {
+		name           string
+		failureDomains clusterv1.FailureDomains
+		machines       []clusterv1.Machine
+		expected       string
+	}{
+		{
+			name:     "no failure domains, picks the oldest machine",
+			machines: []clusterv1.Machine{machine("a", nil, time.Hour), machine("b", nil, 2*time.Hour), machine("c", nil, 3*time.Minute)},
+			expected: "b",
+		},
+		{
+			name: "picks the oldest machine in the failure domain with the most machines",
+			failureDomains: clusterv1.FailureDomains{
+				one: clusterv1.FailureDomainSpec{ControlPlane: true},
+				two: clusterv1.FailureDomainSpec{ControlPlane: true},
+			},
+			machines: []clusterv1.Machine{
+				machine("a", &one, 3*time.Hour),
+				machine("b", &two, time.Hour),
+				machine("c", &two, 2*time.Hour),
+			},
+			expected: "c",
+		},
+		{
+			name: "falls back to all the machines if no machines are in a known failure domain",
+			failureDomains: clusterv1.FailureDomains{
+				one: clusterv1.FailureDomainSpec{ControlPlane: true},
+			},
+			machines: []clusterv1.Machine{machine("a", nil, time.Hour), machine("b", &two, 2*time.Hour)},
+			expected: "b",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			cluster := &clusterv1.Cluster{Status: clusterv1.ClusterStatus{FailureDomains: tc.failureDomains}}
+			selected := selectMachineForScaleDown(cluster, tc.machines)
+			g.Expect(selected).ToNot(BeNil())
+			g.Expect(selected.Name).To(Equal(tc.expected))
+		})
+	}
+}
+
+func TestKubeadmControlPlaneReconciler_upgradeControlPlane(t *testing.T) {
+	cluster := &clusterv1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "foo",
+			Namespace: "test",
+		},
+	}
+
+	genericMachineTemplate := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"kind":       "GenericMachineTemplate",
+			"apiVersion": "generic.io/v1",
+			"metadata": map[string]interface{}{
+				"name":      "infra-foo",
+				"namespace": cluster.Namespace,
+			},
+			"spec": map[string]interface{}{
+				"template": map[string]interface{}{
+					"spec": map[string]interface{}{
+						"hello": "world",
+					},
+				},
+			},
+		},
+	}
+
+	maxSurgeZero := intstr.FromInt(0)
+	kcp := &controlplanev1.KubeadmControlPlane{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "kcp-foo",
+			Namespace: cluster.Namespace,
+		},
+		Spec: controlplanev1.KubeadmControlPlaneSpec{
+			Replicas: utilpointer.Int32Ptr(3),
+			InfrastructureTemplate: corev1.ObjectReference{
+				Kind:       genericMachineTemplate.GetKind(),
+				Namespace:  genericMachineTemplate.GetNamespace(),
+				Name:       genericMachineTemplate.GetName(),
+				APIVersion: genericMachineTemplate.GetAPIVersion(),
+			},
+			KubeadmConfigSpec: bootstrapv1.KubeadmConfigSpec{
+				JoinConfiguration: &kubeadmv1.JoinConfiguration{},
+			},
+			RolloutStrategy: &controlplanev1.RolloutStrategy{
+				Type:          controlplanev1.RollingUpdateStrategyType,
+				RollingUpdate: &controlplanev1.RollingUpdate{MaxSurge: &maxSurgeZero},
+			},
+		},
+	}
+
+	machine := func(name string, joined bool) clusterv1.Machine {
+		m := clusterv1.Machine{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: cluster.Namespace,
+			},
+		}
+		if joined {
+			m.Status.NodeRef = &corev1.ObjectReference{Kind: "Node", Name: name}
+		}
+		return m
+	}
+
+	tests := []struct {
+		name             string
+		ownedMachines    []clusterv1.Machine
+		expectResult     ctrl.Result
+		expectNewMachine bool
+	}{
+		{
+			name:          "should wait for Machines joining the cluster",
+			ownedMachines: []clusterv1.Machine{machine("m1", true), machine("m2", true), machine("m3", false)},
+			expectResult:  ctrl.Result{RequeueAfter: UpgradeRequeueAfter},
+		},
+		{
+			name:             "should create a replacement Machine after an outdated one has been deleted",
+			ownedMachines:    []clusterv1.Machine{machine("m1", true), machine("m2", true)},
+			expectResult:     ctrl.Result{RequeueAfter: UpgradeRequeueAfter},
+			expectNewMachine: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			g.Expect(clusterv1.AddToScheme(scheme.Scheme)).To(Succeed())
+			g.Expect(bootstrapv1.AddToScheme(scheme.Scheme)).To(Succeed())
+			g.Expect(controlplanev1.AddToScheme(scheme.Scheme)).To(Succeed())
+			fakeClient := fake.NewFakeClientWithScheme(
+				scheme.Scheme,
+				cluster.DeepCopy(),
+				kcp.DeepCopy(),
+				genericMachineTemplate.DeepCopy(),
+			)
+
+			r := &KubeadmControlPlaneReconciler{
+				Client:   fakeClient,
+				Log:      log.Log,
+				recorder: record.NewFakeRecorder(32),
+				scheme:   scheme.Scheme,
+			}
+
+			result, err := r.upgradeControlPlane(context.Background(), cluster, kcp, tt.ownedMachines, tt.ownedMachines)
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(result).To(Equal(tt.expectResult))
+
+			machineList := &clusterv1.MachineList{}
+			g.Expect(fakeClient.List(context.Background(), machineList, client.InNamespace(cluster.Namespace))).To(Succeed())
+			if tt.expectNewMachine {
+				g.Expect(machineList.Items).To(HaveLen(1))
+			} else {
+				g.Expect(machineList.Items).To(BeEmpty())
+			}
+		})
+	}
+}
+
+func TestRolloutMaxSurge(t *testing.T) {
+	maxSurgeZero := intstr.FromInt(0)
+
+	tests := []struct {
+		name     string
+		strategy *controlplanev1.RolloutStrategy
+		expected int
+	}{
+		{
+			name:     "should default to 1 when no rollout strategy is set",
+			expected: 1,
+		},
+		{
+			name:     "should default to 1 when maxSurge is not set",
+			strategy: &controlplanev1.RolloutStrategy{Type: controlplanev1.RollingUpdateStrategyType},
+			expected: 1,
+		},
+		{
+			name: "should return maxSurge when set",
+			strategy: &controlplanev1.RolloutStrategy{
+				Type:          controlplanev1.RollingUpdateStrategyType,
+				RollingUpdate: &controlplanev1.RollingUpdate{MaxSurge: &maxSurgeZero},
+			},
+			expected: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			kcp := &controlplanev1.KubeadmControlPlane{
+				Spec: controlplanev1.KubeadmControlPlaneSpec{RolloutStrategy: tt.strategy},
+			}
+			g.Expect(rolloutMaxSurge(kcp)).To(Equal(tt.expected))
+		})
+	}
+}
+
+func TestMaxParallelJoins(t *testing.T) {
+	tests := []struct {
+		name             string
+		maxParallelJoins *int32
+		joined           int
+		expected         int
+	}{
+		{
+			name:     "should default to 1",
+			joined:   3,
+			expected: 1,
+		},
+		{
+			name:             "should allow 2 joins when scaling from 1 replica",
+			maxParallelJoins: utilpointer.Int32Ptr(5),
+			joined:           1,
+			expected:         2,
+		},
+		{
+			name:             "should allow 2 joins when scaling from 3 replicas",
+			maxParallelJoins: utilpointer.Int32Ptr(5),
+			joined:           3,
+			expected:         2,
+		},
+		{
+			name:             "should allow 4 joins when scaling from 5 replicas",
+			maxParallelJoins: utilpointer.Int32Ptr(5),
+			joined:           5,
+			expected:         4,
+		},
+		{
+			name:             "should be limited by maxParallelJoins",
+			maxParallelJoins: utilpointer.Int32Ptr(3),
+			joined:           5,
+			expected:         3,
+		},
+		{
+			name:             "should allow a single join before the first Machine has joined",
+			maxParallelJoins: utilpointer.Int32Ptr(5),
+			joined:           0,
+			expected:         1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			kcp := &controlplanev1.KubeadmControlPlane{
+				Spec: controlplanev1.KubeadmControlPlaneSpec{
+					ScaleUpStrategy: &controlplanev1.ScaleUpStrategy{MaxParallelJoins: tt.maxParallelJoins},
+				},
+			}
+			g.Expect(maxParallelJoins(kcp, tt.joined)).To(Equal(tt.expected))
+		})
+	}
+}
+
 func TestCloneConfigsAndGenerateMachine(t *testing.T) {
 	g := NewWithT(t)
 
@@ -1318,7 +1923,7 @@ func TestCloneConfigsAndGenerateMachine(t *testing.T) {
 	bootstrapSpec := &bootstrapv1.KubeadmConfigSpec{
 		JoinConfiguration: &kubeadmv1.JoinConfiguration{},
 	}
-	g.Expect(r.cloneConfigsAndGenerateMachine(context.Background(), cluster, kcp, bootstrapSpec)).To(Succeed())
+	g.Expect(r.cloneConfigsAndGenerateMachine(context.Background(), cluster, kcp, bootstrapSpec, nil)).To(Succeed())
 
 	machineList := &clusterv1.MachineList{}
 	g.Expect(fakeClient.List(context.Background(), machineList, client.InNamespace(cluster.Namespace))).To(Succeed())
@@ -1409,7 +2014,8 @@ func TestReconcileControlPlaneDelete(t *testing.T) {
 					InitConfiguration:    &kubeadmv1.InitConfiguration{},
 					JoinConfiguration:    &kubeadmv1.JoinConfiguration{},
 				},
-				Replicas: utilpointer.Int32Ptr(3),
+				Replicas:        utilpointer.Int32Ptr(3),
+				ScaleUpStrategy: &controlplanev1.ScaleUpStrategy{MaxParallelJoins: utilpointer.Int32Ptr(2)},
 			},
 		}
 
@@ -1422,6 +2028,9 @@ func TestReconcileControlPlaneDelete(t *testing.T) {
 					*metav1.NewControllerRef(kcp, controlplanev1.GroupVersion.WithKind("KubeadmControlPlane")),
 				},
 			},
+			Status: clusterv1.MachineStatus{
+				NodeRef: &corev1.ObjectReference{Kind: "Node", Name: "foo-0"},
+			},
 		}
 
 		kcp.Default()
@@ -1500,7 +2109,8 @@ func TestReconcileControlPlaneDelete(t *testing.T) {
 					InitConfiguration:    &kubeadmv1.InitConfiguration{},
 					JoinConfiguration:    &kubeadmv1.JoinConfiguration{},
 				},
-				Replicas: utilpointer.Int32Ptr(3),
+				Replicas:        utilpointer.Int32Ptr(3),
+				ScaleUpStrategy: &controlplanev1.ScaleUpStrategy{MaxParallelJoins: utilpointer.Int32Ptr(2)},
 			},
 		}
 
@@ -1513,6 +2123,9 @@ func TestReconcileControlPlaneDelete(t *testing.T) {
 					*metav1.NewControllerRef(kcp, controlplanev1.GroupVersion.WithKind("KubeadmControlPlane")),
 				},
 			},
+			Status: clusterv1.MachineStatus{
+				NodeRef: &corev1.ObjectReference{Kind: "Node", Name: "foo-0"},
+			},
 		}
 
 		workerMachine := &clusterv1.Machine{
@@ -0,0 +1,40 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+var (
+	// controlPlaneOutdatedMachines is a metric that is set to the number of control plane
+	// machines created with a configuration that does not match the current KubeadmControlPlane spec.
+	controlPlaneOutdatedMachines = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "capi_kubeadm_control_plane_outdated_machines",
+			Help: "Number of control plane machines created with a configuration that does not match the current KubeadmControlPlane spec.",
+		},
+		[]string{"cluster", "namespace"},
+	)
+)
+
+func init() {
+	metrics.Registry.MustRegister(
+		controlPlaneOutdatedMachines,
+	)
+}
@@ -0,0 +1,160 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	controlplanev1 "sigs.k8s.io/cluster-api/controlplane/kubeadm/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/controlplane/kubeadm/internal"
+	"sigs.k8s.io/cluster-api/util/conditions"
+)
+
+// defaultRemediationMinHealthyPeriod is the MinHealthyPeriod used when the RemediationStrategy does not define it.
+const defaultRemediationMinHealthyPeriod = 1 * time.Hour
+
+// reconcileUnhealthyMachines remediates the control plane Machines a MachineHealthCheck marked as unhealthy, one at a
+// time: the etcd member running on the Machine is removed and the Machine is deleted, so it is recreated by scaling up.
+// Remediation is skipped if the etcd cluster would lose quorum, and delayed according to the RemediationStrategy if
+// the previous remediation happened less than MinHealthyPeriod ago.
+func (r *KubeadmControlPlaneReconciler) reconcileUnhealthyMachines(ctx context.Context, cluster *clusterv1.Cluster, kcp *controlplanev1.KubeadmControlPlane, ownedMachines []clusterv1.Machine, logger logr.Logger) (ctrl.Result, error) {
+	unhealthyMachines := internal.FilterMachines(ownedMachines, needsRemediation)
+	if len(unhealthyMachines) == 0 {
+		conditions.Delete(kcp, controlplanev1.RemediationAllowedCondition)
+		return ctrl.Result{}, nil
+	}
+
+	// Wait for Machines being deleted, so only one etcd member is removed at a time.
+	if len(internal.FilterMachines(ownedMachines, isDeleting)) > 0 {
+		logger.Info("Waiting for control plane Machines being deleted before remediating unhealthy Machines")
+		return ctrl.Result{RequeueAfter: DeleteRequeueAfter}, nil
+	}
+
+	// Remediate the oldest unhealthy Machine first.
+	sort.Slice(unhealthyMachines, func(i, j int) bool {
+		return unhealthyMachines[i].CreationTimestamp.Before(&unhealthyMachines[j].CreationTimestamp)
+	})
+	machineToRemediate := &unhealthyMachines[0]
+
+	if !canSafelyRemoveEtcdMember(ownedMachines, machineToRemediate) {
+		logger.Info("Skipping remediation of unhealthy control plane Machine, the etcd cluster would lose quorum", "machine", machineToRemediate.Name)
+		conditions.MarkFalse(kcp, controlplanev1.RemediationAllowedCondition, controlplanev1.EtcdQuorumAtRiskReason, clusterv1.ConditionSeverityWarning,
+			"Removing the etcd member of Machine %s would cause the etcd cluster to lose quorum", machineToRemediate.Name)
+		return ctrl.Result{}, nil
+	}
+
+	now := time.Now()
+	retryCount, retryAfter, maxRetryReached := remediationRetry(kcp, now)
+	if maxRetryReached {
+		if conditions.GetReason(kcp, controlplanev1.RemediationAllowedCondition) != controlplanev1.MaxRemediationRetryReachedReason {
+			r.recorder.Eventf(kcp, corev1.EventTypeWarning, "MaxRemediationRetryReached",
+				"Not remediating unhealthy control plane Machine %s, the maximum number of retries has been reached", machineToRemediate.Name)
+		}
+		conditions.MarkFalse(kcp, controlplanev1.RemediationAllowedCondition, controlplanev1.MaxRemediationRetryReachedReason, clusterv1.ConditionSeverityWarning,
+			"The maximum number of %d retries has been reached", *kcp.Spec.RemediationStrategy.MaxRetry)
+		return ctrl.Result{}, nil
+	}
+	if retryAfter > 0 {
+		logger.Info("Waiting for the retry period before remediating unhealthy control plane Machine", "machine", machineToRemediate.Name, "retryAfter", retryAfter)
+		conditions.MarkFalse(kcp, controlplanev1.RemediationAllowedCondition, controlplanev1.WaitingForRemediationRetryReason, clusterv1.ConditionSeverityInfo,
+			"Waiting %s before remediating Machine %s", retryAfter.Round(time.Second), machineToRemediate.Name)
+		return ctrl.Result{RequeueAfter: retryAfter}, nil
+	}
+
+	if err := r.managementCluster.RemoveEtcdMemberForMachine(ctx, clusterKey(cluster), machineToRemediate); err != nil {
+		return ctrl.Result{}, errors.Wrapf(err, "failed to remove etcd member for Machine %q", machineToRemediate.Name)
+	}
+
+	logger.Info("Deleting unhealthy control plane Machine", "machine", machineToRemediate.Name, "retryCount", retryCount)
+	if err := r.Client.Delete(ctx, machineToRemediate); err != nil && !apierrors.IsNotFound(err) {
+		return ctrl.Result{}, errors.Wrapf(err, "failed to delete unhealthy control plane Machine %q", machineToRemediate.Name)
+	}
+
+	kcp.Status.LastRemediation = &controlplanev1.LastRemediationStatus{
+		Machine:    machineToRemediate.Name,
+		Timestamp:  metav1.NewTime(now),
+		RetryCount: retryCount,
+	}
+	conditions.MarkTrue(kcp, controlplanev1.RemediationAllowedCondition)
+	r.recorder.Eventf(kcp, corev1.EventTypeNormal, "MachineRemediated", "Deleted unhealthy control plane Machine %s", machineToRemediate.Name)
+
+	return ctrl.Result{RequeueAfter: DeleteRequeueAfter}, nil
+}
+
+// needsRemediation returns true for the Machines a MachineHealthCheck asked the owner to remediate.
+func needsRemediation(machine clusterv1.Machine) bool {
+	return !isDeleting(machine) && conditions.IsFalse(&machine, clusterv1.MachineOwnerRemediatedCondition)
+}
+
+// canSafelyRemoveEtcdMember returns true if the etcd cluster keeps quorum once the member running on the given
+// Machine is removed, i.e. if the healthy members left are a majority of the members left; the Machines that have
+// joined the cluster are assumed to run an etcd member.
+func canSafelyRemoveEtcdMember(machines []clusterv1.Machine, machineToRemove *clusterv1.Machine) bool {
+	members, healthyMembers := 0, 0
+	for i := range machines {
+		machine := machines[i]
+		if machine.Name == machineToRemove.Name || isJoining(machine) {
+			continue
+		}
+		members++
+		if !needsRemediation(machine) {
+			healthyMembers++
+		}
+	}
+	return members > 0 && healthyMembers >= members/2+1
+}
+
+// remediationRetry returns the retry count of a remediation happening at the given time, how long the remediation
+// should be delayed to honor the RetryPeriod, and whether the maximum number of retries has been reached.
+// A remediation is considered a retry if the previous one happened less than MinHealthyPeriod ago.
+func remediationRetry(kcp *controlplanev1.KubeadmControlPlane, now time.Time) (int32, time.Duration, bool) {
+	last := kcp.Status.LastRemediation
+	if last == nil {
+		return 0, 0, false
+	}
+
+	strategy := kcp.Spec.RemediationStrategy
+	if strategy == nil {
+		strategy = &controlplanev1.RemediationStrategy{}
+	}
+	minHealthyPeriod := defaultRemediationMinHealthyPeriod
+	if strategy.MinHealthyPeriod != nil {
+		minHealthyPeriod = strategy.MinHealthyPeriod.Duration
+	}
+	if !now.Before(last.Timestamp.Add(minHealthyPeriod)) {
+		return 0, 0, false
+	}
+
+	retryCount := last.RetryCount + 1
+	if strategy.MaxRetry != nil && retryCount > *strategy.MaxRetry {
+		return retryCount, 0, true
+	}
+	if retryAt := last.Timestamp.Add(strategy.RetryPeriod.Duration); now.Before(retryAt) {
+		return retryCount, retryAt.Sub(now), false
+	}
+	return retryCount, 0, false
+}
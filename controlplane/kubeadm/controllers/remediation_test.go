@@ -0,0 +1,226 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/klog/klogr"
+	"k8s.io/utils/pointer"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	controlplanev1 "sigs.k8s.io/cluster-api/controlplane/kubeadm/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/controlplane/kubeadm/internal"
+	"sigs.k8s.io/cluster-api/util/conditions"
+)
+
+func unhealthyMachine(name string, joined bool) clusterv1.Machine {
+	machine := clusterv1.Machine{ObjectMeta: metav1.ObjectMeta{Name: name}}
+	if joined {
+		machine.Status.NodeRef = &corev1.ObjectReference{Kind: "Node", Name: name}
+	}
+	conditions.MarkFalse(&machine, clusterv1.MachineOwnerRemediatedCondition, clusterv1.WaitingForRemediationReason, clusterv1.ConditionSeverityWarning, "")
+	return machine
+}
+
+func TestCanSafelyRemoveEtcdMember(t *testing.T) {
+	healthy := func(name string) clusterv1.Machine {
+		return clusterv1.Machine{ObjectMeta: metav1.ObjectMeta{Name: name}, Status: clusterv1.MachineStatus{NodeRef: &corev1.ObjectReference{Kind: "Node", Name: name}}}
+	}
+
+	tests := []struct {
+		name     string
+		machines []clusterv1.Machine
+		want     bool
+	}{
+		{
+			name:     "the last member cannot be removed",
+			machines: []clusterv1.Machine{unhealthyMachine("m1", true)},
+			want:     false,
+		},
+		{
+			name:     "one of three members can be removed if the others are healthy",
+			machines: []clusterv1.Machine{unhealthyMachine("m1", true), healthy("m2"), healthy("m3")},
+			want:     true,
+		},
+		{
+			name:     "one of three members cannot be removed if another one is unhealthy",
+			machines: []clusterv1.Machine{unhealthyMachine("m1", true), unhealthyMachine("m2", true), healthy("m3")},
+			want:     false,
+		},
+		{
+			name:     "one of five members can be removed if another one is unhealthy",
+			machines: []clusterv1.Machine{unhealthyMachine("m1", true), unhealthyMachine("m2", true), healthy("m3"), healthy("m4"), healthy("m5")},
+			want:     true,
+		},
+		{
+			name:     "a Machine that has not joined can be removed if the members are healthy",
+			machines: []clusterv1.Machine{unhealthyMachine("m1", false), healthy("m2")},
+			want:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			g.Expect(canSafelyRemoveEtcdMember(tt.machines, &tt.machines[0])).To(Equal(tt.want))
+		})
+	}
+}
+
+func TestRemediationRetry(t *testing.T) {
+	now := time.Date(2020, time.June, 1, 12, 0, 0, 0, time.UTC)
+	lastRemediation := func(ago time.Duration, retryCount int32) *controlplanev1.LastRemediationStatus {
+		return &controlplanev1.LastRemediationStatus{Machine: "m1", Timestamp: metav1.NewTime(now.Add(-ago)), RetryCount: retryCount}
+	}
+
+	tests := []struct {
+		name            string
+		strategy        *controlplanev1.RemediationStrategy
+		lastRemediation *controlplanev1.LastRemediationStatus
+		wantRetryCount  int32
+		wantRetryAfter  time.Duration
+		wantMaxReached  bool
+	}{
+		{
+			name: "first remediation",
+		},
+		{
+			name:            "remediation after the default MinHealthyPeriod is not a retry",
+			lastRemediation: lastRemediation(2*time.Hour, 3),
+		},
+		{
+			name:            "remediation within the default MinHealthyPeriod is a retry",
+			lastRemediation: lastRemediation(30*time.Minute, 0),
+			wantRetryCount:  1,
+		},
+		{
+			name:            "remediation after a custom MinHealthyPeriod is not a retry",
+			strategy:        &controlplanev1.RemediationStrategy{MinHealthyPeriod: &metav1.Duration{Duration: 10 * time.Minute}},
+			lastRemediation: lastRemediation(30*time.Minute, 1),
+		},
+		{
+			name:            "retry is delayed until the RetryPeriod has passed",
+			strategy:        &controlplanev1.RemediationStrategy{RetryPeriod: metav1.Duration{Duration: 20 * time.Minute}},
+			lastRemediation: lastRemediation(5*time.Minute, 0),
+			wantRetryCount:  1,
+			wantRetryAfter:  15 * time.Minute,
+		},
+		{
+			name:            "retry is not allowed once MaxRetry has been reached",
+			strategy:        &controlplanev1.RemediationStrategy{MaxRetry: pointer.Int32Ptr(2)},
+			lastRemediation: lastRemediation(5*time.Minute, 2),
+			wantRetryCount:  3,
+			wantMaxReached:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			kcp := &controlplanev1.KubeadmControlPlane{
+				Spec:   controlplanev1.KubeadmControlPlaneSpec{RemediationStrategy: tt.strategy},
+				Status: controlplanev1.KubeadmControlPlaneStatus{LastRemediation: tt.lastRemediation},
+			}
+			retryCount, retryAfter, maxReached := remediationRetry(kcp, now)
+			g.Expect(retryCount).To(Equal(tt.wantRetryCount))
+			g.Expect(retryAfter).To(Equal(tt.wantRetryAfter))
+			g.Expect(maxReached).To(Equal(tt.wantMaxReached))
+		})
+	}
+}
+
+func TestReconcileUnhealthyMachines(t *testing.T) {
+	g := NewWithT(t)
+
+	cluster := &clusterv1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "foo",
+			Namespace: "test",
+		},
+	}
+	kcp := &controlplanev1.KubeadmControlPlane{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: cluster.Namespace,
+			Name:      "foo",
+		},
+	}
+
+	var ownedMachines []clusterv1.Machine
+	objs := []runtime.Object{}
+	for _, name := range []string{"m1", "m2", "m3"} {
+		machine, _ := createMachineNodePair(name, cluster, kcp, true)
+		ownedMachines = append(ownedMachines, *machine)
+		objs = append(objs, machine)
+	}
+	// The etcd member of a Machine that has not joined the cluster does not need to be removed.
+	unhealthy := ownedMachines[0].DeepCopy()
+	unhealthy.Status.NodeRef = nil
+	conditions.MarkFalse(unhealthy, clusterv1.MachineOwnerRemediatedCondition, clusterv1.WaitingForRemediationReason, clusterv1.ConditionSeverityWarning, "")
+	ownedMachines[0] = *unhealthy
+
+	g.Expect(clusterv1.AddToScheme(scheme.Scheme)).To(Succeed())
+	g.Expect(controlplanev1.AddToScheme(scheme.Scheme)).To(Succeed())
+	fakeClient := fake.NewFakeClientWithScheme(scheme.Scheme, objs...)
+	recorder := record.NewFakeRecorder(32)
+
+	r := &KubeadmControlPlaneReconciler{
+		Client:            fakeClient,
+		Log:               klogr.New(),
+		recorder:          recorder,
+		managementCluster: &internal.ManagementCluster{Client: fakeClient},
+	}
+
+	// Remediation is delayed while retrying within the retry period.
+	kcp.Spec.RemediationStrategy = &controlplanev1.RemediationStrategy{RetryPeriod: metav1.Duration{Duration: time.Hour}}
+	kcp.Status.LastRemediation = &controlplanev1.LastRemediationStatus{Machine: "m0", Timestamp: metav1.Now()}
+	result, err := r.reconcileUnhealthyMachines(context.Background(), cluster, kcp, ownedMachines, r.Log)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(result.RequeueAfter).To(BeNumerically(">", 0))
+	g.Expect(conditions.GetReason(kcp, controlplanev1.RemediationAllowedCondition)).To(Equal(controlplanev1.WaitingForRemediationRetryReason))
+	g.Expect(fakeClient.Get(context.Background(), client.ObjectKey{Namespace: cluster.Namespace, Name: "m1"}, &clusterv1.Machine{})).To(Succeed())
+
+	// The unhealthy Machine is deleted once the retry period has passed.
+	kcp.Status.LastRemediation.Timestamp = metav1.NewTime(time.Now().Add(-2 * time.Hour))
+	result, err = r.reconcileUnhealthyMachines(context.Background(), cluster, kcp, ownedMachines, r.Log)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(result).To(Equal(ctrl.Result{RequeueAfter: DeleteRequeueAfter}))
+	g.Expect(conditions.IsTrue(kcp, controlplanev1.RemediationAllowedCondition)).To(BeTrue())
+	g.Expect(kcp.Status.LastRemediation.Machine).To(Equal("m1"))
+	g.Expect(kcp.Status.LastRemediation.RetryCount).To(BeEquivalentTo(0))
+	g.Expect(recorder.Events).To(Receive(ContainSubstring("MachineRemediated")))
+	err = fakeClient.Get(context.Background(), client.ObjectKey{Namespace: cluster.Namespace, Name: "m1"}, &clusterv1.Machine{})
+	g.Expect(apierrors.IsNotFound(err)).To(BeTrue())
+
+	// Without unhealthy Machines the condition is removed.
+	result, err = r.reconcileUnhealthyMachines(context.Background(), cluster, kcp, ownedMachines[1:], r.Log)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(result).To(Equal(ctrl.Result{}))
+	g.Expect(conditions.Has(kcp, controlplanev1.RemediationAllowedCondition)).To(BeFalse())
+}
@@ -0,0 +1,115 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	controlplanev1 "sigs.k8s.io/cluster-api/controlplane/kubeadm/api/v1alpha3"
+	utilversion "sigs.k8s.io/cluster-api/util/version"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// +kubebuilder:webhook:verbs=create;update,path=/validate-controlplane-cluster-x-k8s-io-v1alpha3-version-skew,mutating=false,failurePolicy=fail,groups=controlplane.cluster.x-k8s.io,resources=kubeadmcontrolplanes,versions=v1alpha3,name=validation-version-skew.kubeadmcontrolplane.controlplane.cluster.x-k8s.io
+// +kubebuilder:rbac:groups=cluster.x-k8s.io,resources=machinedeployments,verbs=get;list;watch
+
+// VersionSkewValidator is an admission webhook rejecting the KubeadmControlPlanes whose Kubernetes version is older
+// than the version of the MachineDeployments of their Cluster, e.g. because of a downgrade, because the kubelet must
+// not be newer than the API server.
+// The version is validated only on create and when changed on update, so existing KubeadmControlPlanes can still be
+// updated, e.g. scaled.
+type VersionSkewValidator struct {
+	// Client is used for reading the MachineDeployments.
+	Client client.Reader
+}
+
+var _ admission.Handler = &VersionSkewValidator{}
+
+// SetupWebhookWithManager registers the webhook with the manager webhook server at the given path.
+func (v *VersionSkewValidator) SetupWebhookWithManager(mgr ctrl.Manager, path string) error {
+	mgr.GetWebhookServer().Register(path, &webhook.Admission{Handler: v})
+	return nil
+}
+
+// Handle implements admission.Handler.
+func (v *VersionSkewValidator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	if req.Operation != admissionv1beta1.Create && req.Operation != admissionv1beta1.Update {
+		return admission.Allowed("")
+	}
+
+	kcp := &controlplanev1.KubeadmControlPlane{}
+	if err := json.Unmarshal(req.Object.Raw, kcp); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+	if req.Operation == admissionv1beta1.Update {
+		oldKCP := &controlplanev1.KubeadmControlPlane{}
+		if err := json.Unmarshal(req.OldObject.Raw, oldKCP); err != nil {
+			return admission.Errored(http.StatusBadRequest, err)
+		}
+		if oldKCP.Spec.Version == kcp.Spec.Version {
+			return admission.Allowed("")
+		}
+	}
+
+	clusterName := ownerClusterName(kcp)
+	if clusterName == "" {
+		return admission.Allowed("")
+	}
+
+	mdList := &clusterv1.MachineDeploymentList{}
+	if err := v.Client.List(ctx, mdList, client.InNamespace(kcp.Namespace)); err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+
+	var allErrs field.ErrorList
+	for _, md := range mdList.Items {
+		if md.Spec.ClusterName != clusterName || md.Spec.Template.Spec.Version == nil {
+			continue
+		}
+		if err := utilversion.ValidateWorkerSkew(kcp.Spec.Version, *md.Spec.Template.Spec.Version); err != nil {
+			allErrs = append(allErrs, field.Forbidden(field.NewPath("spec", "version"), fmt.Sprintf("MachineDeployment %s: %v", md.Name, err)))
+		}
+	}
+
+	if len(allErrs) > 0 {
+		gk := schema.GroupKind{Group: req.Kind.Group, Kind: req.Kind.Kind}
+		return admission.Denied(apierrors.NewInvalid(gk, kcp.Name, allErrs).Error())
+	}
+	return admission.Allowed("")
+}
+
+// ownerClusterName returns the name of the Cluster owning a KubeadmControlPlane, read from the owner references or,
+// if the owner reference is not set yet, from the cluster name label; it returns an empty string if it is unknown.
+func ownerClusterName(kcp *controlplanev1.KubeadmControlPlane) string {
+	for _, ref := range kcp.OwnerReferences {
+		if ref.Kind == "Cluster" && ref.APIVersion == clusterv1.GroupVersion.String() {
+			return ref.Name
+		}
+	}
+	return kcp.Labels[clusterv1.ClusterLabelName]
+}
@@ -0,0 +1,124 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	controlplanev1 "sigs.k8s.io/cluster-api/controlplane/kubeadm/api/v1alpha3"
+)
+
+func TestVersionSkewValidator(t *testing.T) {
+	g := NewWithT(t)
+
+	scheme := runtime.NewScheme()
+	g.Expect(clusterv1.AddToScheme(scheme)).To(Succeed())
+	g.Expect(controlplanev1.AddToScheme(scheme)).To(Succeed())
+
+	md := func(name, clusterName, version string) *clusterv1.MachineDeployment {
+		return &clusterv1.MachineDeployment{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name},
+			Spec: clusterv1.MachineDeploymentSpec{
+				ClusterName: clusterName,
+				Template: clusterv1.MachineTemplateSpec{
+					Spec: clusterv1.MachineSpec{ClusterName: clusterName, Version: pointer.StringPtr(version)},
+				},
+			},
+		}
+	}
+	c := fake.NewFakeClientWithScheme(scheme,
+		md("md-0", "cluster", "v1.17.3"),
+		md("md-1", "cluster", "v1.16.2"),
+		md("other-md", "other-cluster", "v1.18.0"),
+	)
+	v := &VersionSkewValidator{Client: c}
+
+	kcp := func(version string) *controlplanev1.KubeadmControlPlane {
+		return &controlplanev1.KubeadmControlPlane{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "default",
+				Name:      "kcp",
+				OwnerReferences: []metav1.OwnerReference{
+					{APIVersion: clusterv1.GroupVersion.String(), Kind: "Cluster", Name: "cluster"},
+				},
+			},
+			Spec: controlplanev1.KubeadmControlPlaneSpec{Version: version},
+		}
+	}
+	request := func(operation admissionv1beta1.Operation, obj, oldObj *controlplanev1.KubeadmControlPlane) admission.Request {
+		req := admission.Request{AdmissionRequest: admissionv1beta1.AdmissionRequest{
+			Kind:      metav1.GroupVersionKind{Group: controlplanev1.GroupVersion.Group, Version: controlplanev1.GroupVersion.Version, Kind: "KubeadmControlPlane"},
+			Operation: operation,
+		}}
+		req.Object.Raw, _ = json.Marshal(obj)
+		if oldObj != nil {
+			req.OldObject.Raw, _ = json.Marshal(oldObj)
+		}
+		return req
+	}
+
+	tests := []struct {
+		name    string
+		req     admission.Request
+		allowed bool
+	}{
+		{
+			name:    "upgrading the control plane is allowed",
+			req:     request(admissionv1beta1.Update, kcp("v1.18.0"), kcp("v1.17.3")),
+			allowed: true,
+		},
+		{
+			name:    "downgrading the control plane below the workers is rejected",
+			req:     request(admissionv1beta1.Update, kcp("v1.16.8"), kcp("v1.17.3")),
+			allowed: false,
+		},
+		{
+			name:    "creating a control plane older than the workers is rejected",
+			req:     request(admissionv1beta1.Create, kcp("v1.16.8"), nil),
+			allowed: false,
+		},
+		{
+			name:    "updating a control plane without changing the version is allowed",
+			req:     request(admissionv1beta1.Update, kcp("v1.16.8"), kcp("v1.16.8")),
+			allowed: true,
+		},
+		{
+			name:    "a control plane without an owner cluster is allowed",
+			req:     request(admissionv1beta1.Create, &controlplanev1.KubeadmControlPlane{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "kcp"}, Spec: controlplanev1.KubeadmControlPlaneSpec{Version: "v1.15.0"}}, nil),
+			allowed: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			resp := v.Handle(context.Background(), tt.req)
+			g.Expect(resp.Allowed).To(Equal(tt.allowed), "response: %v", resp.Result)
+		})
+	}
+}
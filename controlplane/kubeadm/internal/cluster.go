@@ -35,6 +35,7 @@ import (
 	"k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/rest"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/controllers/preflight"
 	"sigs.k8s.io/cluster-api/controllers/remote"
 	controlplanev1 "sigs.k8s.io/cluster-api/controlplane/kubeadm/api/v1alpha3"
 	"sigs.k8s.io/cluster-api/controlplane/kubeadm/internal/etcd"
@@ -90,6 +91,58 @@ func OlderThan(t *metav1.Time) func(machine clusterv1.Machine) bool {
 	}
 }
 
+// HasCertificatesExpiringBefore returns a MachineFilter function to find all machines
+// whose certificates, as recorded in the certificates expiry annotation, expire before the given time.
+// Machines without the annotation never match.
+func HasCertificatesExpiringBefore(t *metav1.Time) func(machine clusterv1.Machine) bool {
+	return func(machine clusterv1.Machine) bool {
+		if t == nil {
+			return false
+		}
+		expiry, ok := CertificatesExpiry(machine)
+		if !ok {
+			return false
+		}
+		return expiry.Before(t.Time)
+	}
+}
+
+// CertificatesExpiry returns the certificates expiry date recorded on the given machine, if any.
+func CertificatesExpiry(machine clusterv1.Machine) (time.Time, bool) {
+	value, ok := machine.Annotations[controlplanev1.CertificatesExpiryAnnotation]
+	if !ok {
+		return time.Time{}, false
+	}
+	expiry, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return expiry, true
+}
+
+// Or returns a MachineFilter function that matches the machines matching any of the given filters.
+func Or(filters ...func(machine clusterv1.Machine) bool) func(machine clusterv1.Machine) bool {
+	return func(machine clusterv1.Machine) bool {
+		for _, filter := range filters {
+			if filter(machine) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// InFailureDomain returns a MachineFilter function to find all machines
+// in the given failure domain.
+func InFailureDomain(failureDomain *string) func(machine clusterv1.Machine) bool {
+	return func(machine clusterv1.Machine) bool {
+		if failureDomain == nil || machine.Spec.FailureDomain == nil {
+			return failureDomain == machine.Spec.FailureDomain
+		}
+		return *failureDomain == *machine.Spec.FailureDomain
+	}
+}
+
 // FilterMachines returns a filtered list of machines
 func FilterMachines(machines []clusterv1.Machine, filters ...func(machine clusterv1.Machine) bool) []clusterv1.Machine {
 	if len(filters) == 0 {
@@ -242,6 +295,86 @@ func (m *ManagementCluster) TargetClusterEtcdIsHealthy(ctx context.Context, clus
 	return m.healthCheck(ctx, cluster.etcdIsHealthy, clusterKey, controlPlaneName)
 }
 
+// TargetClusterUpgradePreflightChecks runs the checks that must pass before starting a control plane rollout:
+// all Nodes are Ready, no control plane Pod is crash looping, PodDisruptionBudgets allow disruption and etcd is healthy.
+// It returns a description of every failure found.
+func (m *ManagementCluster) TargetClusterUpgradePreflightChecks(ctx context.Context, clusterKey types.NamespacedName, controlPlaneName string) ([]string, error) {
+	cluster, err := m.getCluster(ctx, clusterKey)
+	if err != nil {
+		return nil, err
+	}
+
+	failures, err := preflight.Run(ctx, cluster.client,
+		preflight.NodesReady,
+		preflight.ControlPlanePodsNotCrashLooping,
+		preflight.PodDisruptionBudgetsAllowDisruption,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := m.healthCheck(ctx, cluster.etcdIsHealthy, clusterKey, controlPlaneName); err != nil {
+		failures = append(failures, fmt.Sprintf("etcd cluster is not healthy: %v", err))
+	}
+	return failures, nil
+}
+
+// GetAPIServerCertificateExpiry returns the expiry date of the serving certificate of the kube-apiserver running on the given Node.
+// kubeadm generates all the control plane certificates of a Node at the same time, so this is used as the expiry date of
+// the Node certificates.
+func (m *ManagementCluster) GetAPIServerCertificateExpiry(ctx context.Context, clusterKey types.NamespacedName, nodeName string, port int) (time.Time, error) {
+	restConfig, err := remote.RESTConfig(ctx, m.Client, &clusterv1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: clusterKey.Namespace,
+			Name:      clusterKey.Name,
+		},
+	})
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	dialer, err := proxy.NewDialer(proxy.Proxy{
+		Kind:         "pods",
+		Namespace:    "kube-system",
+		ResourceName: staticPodName("kube-apiserver", nodeName),
+		KubeConfig:   restConfig,
+		Port:         port,
+	})
+	if err != nil {
+		return time.Time{}, err
+	}
+	conn, err := dialer.DialContext(ctx, "tcp", "")
+	if err != nil {
+		return time.Time{}, errors.Wrapf(err, "failed to connect to the kube-apiserver on node %q", nodeName)
+	}
+
+	// The certificate is only inspected and no data is exchanged on the connection, so it does not need to be verified.
+	tlsConn := tls.Client(conn, &tls.Config{InsecureSkipVerify: true}) //nolint:gosec
+	defer tlsConn.Close()
+	if err := tlsConn.Handshake(); err != nil {
+		return time.Time{}, errors.Wrapf(err, "failed TLS handshake with the kube-apiserver on node %q", nodeName)
+	}
+
+	peerCertificates := tlsConn.ConnectionState().PeerCertificates
+	if len(peerCertificates) == 0 {
+		return time.Time{}, errors.Errorf("the kube-apiserver on node %q did not present any certificate", nodeName)
+	}
+	return peerCertificates[0].NotAfter, nil
+}
+
+// RemoveEtcdMemberForMachine removes the etcd member running on the Node of the given Machine from the etcd cluster;
+// it is a no-op if the Machine has no Node or if the etcd member has already been removed.
+func (m *ManagementCluster) RemoveEtcdMemberForMachine(ctx context.Context, clusterKey types.NamespacedName, machine *clusterv1.Machine) error {
+	if machine.Status.NodeRef == nil {
+		return nil
+	}
+	cluster, err := m.getCluster(ctx, clusterKey)
+	if err != nil {
+		return err
+	}
+	return cluster.removeEtcdMember(ctx, machine.Status.NodeRef.Name)
+}
+
 // cluster are operations on target clusters.
 type cluster struct {
 	client ctrlclient.Client
@@ -399,6 +532,54 @@ func (c *cluster) etcdIsHealthy(ctx context.Context) (healthCheckResult, error)
 	return response, nil
 }
 
+// removeEtcdMember removes the etcd member with the given name using the etcd member running on another control plane
+// node, given that the member being removed is going away.
+func (c *cluster) removeEtcdMember(ctx context.Context, name string) error {
+	controlPlaneNodes, err := c.getControlPlaneNodes(ctx)
+	if err != nil {
+		return err
+	}
+
+	tlsConfig, err := c.generateEtcdTLSClientBundle()
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, node := range controlPlaneNodes.Items {
+		if node.Name == name {
+			continue
+		}
+
+		etcdClient, err := c.getEtcdClientForNode(node.Name, tlsConfig)
+		if err != nil {
+			errs = append(errs, errors.Wrapf(err, "failed to create etcd client for node %q", node.Name))
+			continue
+		}
+		defer etcdClient.Close()
+
+		members, err := etcdClient.Members(ctx)
+		if err != nil {
+			errs = append(errs, errors.Wrapf(err, "failed to list etcd members using etcd client for node %q", node.Name))
+			continue
+		}
+
+		member := etcdutil.MemberForName(members, name)
+		if member == nil {
+			return nil
+		}
+		if err := etcdClient.RemoveMember(ctx, member.ID); err != nil {
+			return errors.Wrapf(err, "failed to remove etcd member %q", name)
+		}
+		return nil
+	}
+
+	if len(errs) > 0 {
+		return kerrors.NewAggregate(errs)
+	}
+	return errors.Errorf("failed to remove etcd member %q: no other control plane nodes found", name)
+}
+
 // getEtcdClientForNode returns a client that talks directly to an etcd instance living on a particular node.
 func (c *cluster) getEtcdClientForNode(nodeName string, tlsConfig *tls.Config) (*etcd.Client, error) {
 	// This does not support external etcd.
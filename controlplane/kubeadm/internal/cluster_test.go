@@ -20,12 +20,14 @@ import (
 	"context"
 	"fmt"
 	"testing"
+	"time"
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	controlplanev1 "sigs.k8s.io/cluster-api/controlplane/kubeadm/api/v1alpha3"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
@@ -180,6 +182,77 @@ func TestGetMachinesForCluster(t *testing.T) {
 	}
 }
 
+func TestHasCertificatesExpiringBefore(t *testing.T) {
+	now := time.Now()
+	deadline := metav1.NewTime(now.Add(7 * 24 * time.Hour))
+	machine := func(expiry string) clusterv1.Machine {
+		return clusterv1.Machine{
+			ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{controlplanev1.CertificatesExpiryAnnotation: expiry},
+			},
+		}
+	}
+
+	tests := []struct {
+		name     string
+		deadline *metav1.Time
+		machine  clusterv1.Machine
+		expected bool
+	}{
+		{
+			name:     "certificates expiring before the deadline",
+			deadline: &deadline,
+			machine:  machine(now.Add(24 * time.Hour).Format(time.RFC3339)),
+			expected: true,
+		},
+		{
+			name:     "certificates expiring after the deadline",
+			deadline: &deadline,
+			machine:  machine(now.Add(30 * 24 * time.Hour).Format(time.RFC3339)),
+			expected: false,
+		},
+		{
+			name:     "no deadline",
+			machine:  machine(now.Add(24 * time.Hour).Format(time.RFC3339)),
+			expected: false,
+		},
+		{
+			name:     "no recorded expiry",
+			deadline: &deadline,
+			machine:  clusterv1.Machine{},
+			expected: false,
+		},
+		{
+			name:     "invalid recorded expiry",
+			deadline: &deadline,
+			machine:  machine("tomorrow"),
+			expected: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if actual := HasCertificatesExpiringBefore(tt.deadline)(tt.machine); actual != tt.expected {
+				t.Fatalf("expected %t but got %t", tt.expected, actual)
+			}
+		})
+	}
+}
+
+func TestOr(t *testing.T) {
+	match := func(clusterv1.Machine) bool { return true }
+	noMatch := func(clusterv1.Machine) bool { return false }
+
+	if Or()(clusterv1.Machine{}) {
+		t.Fatal("expected no filter to not match")
+	}
+	if Or(noMatch, noMatch)(clusterv1.Machine{}) {
+		t.Fatal("expected non matching filters to not match")
+	}
+	if !Or(noMatch, match)(clusterv1.Machine{}) {
+		t.Fatal("expected a matching filter to match")
+	}
+}
+
 func machineListForTestGetMachinesForCluster() *clusterv1.MachineList {
 	owned := true
 	ownedRef := []metav1.OwnerReference{
@@ -22,6 +22,7 @@ import (
 
 	corev1 "k8s.io/api/core/v1"
 
+	cabpkv1 "sigs.k8s.io/cluster-api/bootstrap/kubeadm/api/v1alpha3"
 	"sigs.k8s.io/cluster-api/controllers/mdutil"
 	controlplanev1 "sigs.k8s.io/cluster-api/controlplane/kubeadm/api/v1alpha3"
 )
@@ -31,8 +32,15 @@ type fieldsToHash struct {
 	infrastructureTemplate corev1.ObjectReference
 }
 
+// fieldsToHashWithKubelet is used instead of fieldsToHash when a kubelet configuration is set,
+// so the hash of the control planes not using it is unchanged.
+type fieldsToHashWithKubelet struct {
+	fieldsToHash
+	kubelet cabpkv1.KubeletConfiguration
+}
+
 // Compute will generate a 32-bit FNV-1a Hash of the Version and InfrastructureTemplate
-// fields for the given KubeadmControlPlaneSpec
+// fields, and of the kubelet configuration if any, for the given KubeadmControlPlaneSpec
 func Compute(spec *controlplanev1.KubeadmControlPlaneSpec) string {
 	// since we only care about spec.Version and spec.InfrastructureTemplate
 	// and to avoid changing the hash if additional fields are added, we copy
@@ -43,7 +51,14 @@ func Compute(spec *controlplanev1.KubeadmControlPlaneSpec) string {
 	}
 
 	hasher := fnv.New32a()
-	mdutil.DeepHashObject(hasher, specToHash)
+	if spec.KubeadmConfigSpec.Kubelet != nil {
+		mdutil.DeepHashObject(hasher, fieldsToHashWithKubelet{
+			fieldsToHash: specToHash,
+			kubelet:      *spec.KubeadmConfigSpec.Kubelet,
+		})
+	} else {
+		mdutil.DeepHashObject(hasher, specToHash)
+	}
 
 	return fmt.Sprintf("%d", hasher.Sum32())
 }
@@ -0,0 +1,56 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hash
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/utils/pointer"
+
+	cabpkv1 "sigs.k8s.io/cluster-api/bootstrap/kubeadm/api/v1alpha3"
+	controlplanev1 "sigs.k8s.io/cluster-api/controlplane/kubeadm/api/v1alpha3"
+)
+
+func TestCompute(t *testing.T) {
+	g := NewWithT(t)
+
+	spec := &controlplanev1.KubeadmControlPlaneSpec{
+		Version: "v1.17.3",
+		InfrastructureTemplate: corev1.ObjectReference{
+			Kind: "InfrastructureMachineTemplate",
+			Name: "infra",
+		},
+	}
+	hash := Compute(spec)
+
+	// Changes to the fields which are not hashed don't trigger a rollout.
+	other := spec.DeepCopy()
+	other.Replicas = pointer.Int32Ptr(3)
+	g.Expect(Compute(other)).To(Equal(hash))
+
+	// Setting a kubelet configuration triggers a rollout, changing it triggers another one.
+	withKubelet := spec.DeepCopy()
+	withKubelet.KubeadmConfigSpec.Kubelet = &cabpkv1.KubeletConfiguration{MaxPods: pointer.Int32Ptr(110)}
+	kubeletHash := Compute(withKubelet)
+	g.Expect(kubeletHash).NotTo(Equal(hash))
+
+	withKubelet.KubeadmConfigSpec.Kubelet.MaxPods = pointer.Int32Ptr(250)
+	g.Expect(Compute(withKubelet)).NotTo(Equal(kubeletHash))
+}
@@ -23,6 +23,7 @@ import (
 	"os"
 	"time"
 
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/rest"
@@ -30,8 +31,10 @@ import (
 	"k8s.io/klog/klogr"
 	clusterv1alpha3 "sigs.k8s.io/cluster-api/api/v1alpha3"
 	kubeadmbootstrapv1alpha3 "sigs.k8s.io/cluster-api/bootstrap/kubeadm/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/controllers/external"
 	kubeadmcontrolplanev1alpha3 "sigs.k8s.io/cluster-api/controlplane/kubeadm/api/v1alpha3"
 	kubeadmcontrolplanecontrollers "sigs.k8s.io/cluster-api/controlplane/kubeadm/controllers"
+	"sigs.k8s.io/cluster-api/util/guardrails"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -48,6 +51,7 @@ func init() {
 	klog.InitFlags(nil)
 
 	_ = clientgoscheme.AddToScheme(scheme)
+	_ = apiextensionsv1.AddToScheme(scheme)
 	_ = clusterv1alpha3.AddToScheme(scheme)
 	_ = kubeadmcontrolplanev1alpha3.AddToScheme(scheme)
 	_ = kubeadmbootstrapv1alpha3.AddToScheme(scheme)
@@ -63,6 +67,9 @@ func main() {
 		profilerAddress                string
 		kubeadmControlPlaneConcurrency int
 		syncPeriod                     time.Duration
+		machineCreationLimit           int
+		machineCreationWindow          time.Duration
+		driftCheckInterval             time.Duration
 		webhookPort                    int
 	)
 
@@ -81,6 +88,15 @@ func main() {
 	flag.IntVar(&kubeadmControlPlaneConcurrency, "kubeadmcontrolplane-concurrency", 1,
 		"Number of kubeadm control planes to process simultaneously")
 
+	flag.IntVar(&machineCreationLimit, "machine-creation-limit", 0,
+		"Maximum number of Machines a single KubeadmControlPlane can create within the machine creation window; once exceeded, scale up is throttled and a warning event is recorded (set to 0 to disable)")
+
+	flag.DurationVar(&machineCreationWindow, "machine-creation-window", 10*time.Minute,
+		"The time window the machine creation limit applies to (e.g. 10m)")
+
+	flag.DurationVar(&driftCheckInterval, "drift-check-interval", 10*time.Minute,
+		"The interval at which control plane machines created with an outdated configuration are reported while reconciliation is paused (e.g. 10m)")
+
 	flag.DurationVar(&syncPeriod, "sync-period", 10*time.Minute,
 		"The minimum interval at which watched resources are reconciled (e.g. 15m)")
 
@@ -115,8 +131,10 @@ func main() {
 
 	// KubeadmControlPlane controllers.
 	if err = (&kubeadmcontrolplanecontrollers.KubeadmControlPlaneReconciler{
-		Client: mgr.GetClient(),
-		Log:    ctrl.Log.WithName("controllers").WithName("KubeadmControlPlane"),
+		Client:             mgr.GetClient(),
+		Log:                ctrl.Log.WithName("controllers").WithName("KubeadmControlPlane"),
+		CreationLimiter:    guardrails.NewCreationLimiter(machineCreationLimit, machineCreationWindow),
+		DriftCheckInterval: driftCheckInterval,
 	}).SetupWithManager(mgr, concurrency(kubeadmControlPlaneConcurrency)); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "KubeadmControlPlane")
 		os.Exit(1)
@@ -127,6 +145,22 @@ func main() {
 			setupLog.Error(err, "unable to create webhook", "webhook", "KubeadmControlPlane")
 			os.Exit(1)
 		}
+
+		if err = (&external.ReferenceValidator{
+			Client:     mgr.GetAPIReader(),
+			RESTMapper: mgr.GetRESTMapper(),
+			References: kubeadmcontrolplanecontrollers.TemplateReferences,
+		}).SetupWebhookWithManager(mgr, "/validate-controlplane-cluster-x-k8s-io-v1alpha3-template-references"); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "TemplateReferences")
+			os.Exit(1)
+		}
+
+		if err = (&kubeadmcontrolplanecontrollers.VersionSkewValidator{
+			Client: mgr.GetAPIReader(),
+		}).SetupWebhookWithManager(mgr, "/validate-controlplane-cluster-x-k8s-io-v1alpha3-version-skew"); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "VersionSkew")
+			os.Exit(1)
+		}
 	}
 
 	// +kubebuilder:scaffold:builder
@@ -74,6 +74,11 @@ const (
 	// not result in a Node joining the cluster within a given timeout
 	// and that are managed by a MachineSet
 	JoinClusterTimeoutMachineError = "JoinClusterTimeoutError"
+
+	// This error indicates that the machine stayed in the Pending or
+	// Provisioning phase for longer than the timeout set in its spec,
+	// e.g. because the cloud instance never came up.
+	PhaseTimeoutMachineError MachineStatusError = "PhaseTimeoutError"
 )
 
 type ClusterStatusError string
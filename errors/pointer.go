@@ -25,3 +25,8 @@ func MachineStatusErrorPtr(v MachineStatusError) *MachineStatusError {
 func ClusterStatusErrorPtr(v ClusterStatusError) *ClusterStatusError {
 	return &v
 }
+
+// MachinePoolStatusErrorPtr converts a MachinePoolStatusError to a pointer.
+func MachinePoolStatusErrorPtr(v MachinePoolStatusError) *MachinePoolStatusError {
+	return &v
+}
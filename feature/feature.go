@@ -0,0 +1,51 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package feature defines the feature gates of the Cluster API manager, allowing experimental subsystems to ship
+// disabled by default and to be enabled per management cluster with the --feature-gates flag.
+package feature
+
+const (
+	// Every feature gate should add a constant here, following this template:
+	//
+	//	// MyFeature enables ...
+	//	// owner: @username
+	//	// alpha: v0.3
+	//	MyFeature Feature = "MyFeature"
+
+	// MachinePool enables the MachinePool controller and webhook.
+	// alpha: v0.3
+	MachinePool Feature = "MachinePool"
+
+	// ClusterResourceSet enables the ClusterResourceSet controller and webhook.
+	// alpha: v0.3
+	ClusterResourceSet Feature = "ClusterResourceSet"
+
+	// LifecycleHooks enables the pre-drain and pre-terminate delete hooks of the Machines.
+	// beta: v0.3
+	LifecycleHooks Feature = "LifecycleHooks"
+)
+
+// defaultFeatureGates consists of all the known feature gates of the Cluster API manager.
+// To add a new feature, define a key for it above and add it here.
+var defaultFeatureGates = map[Feature]Spec{
+	MachinePool:        {Default: false, PreRelease: Alpha},
+	ClusterResourceSet: {Default: false, PreRelease: Alpha},
+	LifecycleHooks:     {Default: true, PreRelease: Beta},
+}
+
+// Gates is the feature gate of the Cluster API manager, set with the --feature-gates flag.
+var Gates = NewGate(defaultFeatureGates)
@@ -0,0 +1,157 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package feature
+
+import (
+	"flag"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// Feature is the name of a feature gate.
+type Feature string
+
+// PreRelease is the maturity of a feature.
+type PreRelease string
+
+const (
+	// Alpha features are disabled by default, and might change or be removed in future releases.
+	Alpha = PreRelease("ALPHA")
+
+	// Beta features are enabled by default, and can be disabled if they cause problems.
+	Beta = PreRelease("BETA")
+
+	// GA features are always enabled; the gate is kept for one release to avoid breaking the flags.
+	GA = PreRelease("")
+)
+
+// Spec is the default value and maturity of a feature.
+type Spec struct {
+	// Default is the value of the feature when it is not set with the flag.
+	Default bool
+
+	// PreRelease is the maturity of the feature.
+	PreRelease PreRelease
+}
+
+// Gate records the known features and whether they are enabled. It implements flag.Value, parsing a comma
+// separated list of Feature=true|false pairs.
+type Gate struct {
+	lock    sync.RWMutex
+	known   map[Feature]Spec
+	enabled map[Feature]bool
+}
+
+// ensure Gate implements flag.Value.
+var _ flag.Value = &Gate{}
+
+// NewGate returns a Gate for the given features, with their default values.
+func NewGate(known map[Feature]Spec) *Gate {
+	g := &Gate{
+		known:   known,
+		enabled: map[Feature]bool{},
+	}
+	g.recordMetrics()
+	return g
+}
+
+// Enabled returns true if the feature is enabled; unknown features are never enabled.
+func (g *Gate) Enabled(f Feature) bool {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+
+	if enabled, ok := g.enabled[f]; ok {
+		return enabled
+	}
+	return g.known[f].Default
+}
+
+// Set parses a comma separated list of Feature=true|false pairs, e.g. "MachinePool=true,LifecycleHooks=false",
+// and enables or disables the features accordingly. It fails on unknown features, or if a GA feature is disabled.
+func (g *Gate) Set(value string) error {
+	enabled := map[Feature]bool{}
+	for _, pair := range strings.Split(value, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 {
+			return errors.Errorf("invalid feature gate %q, it must be in the Feature=true|false format", pair)
+		}
+		f := Feature(strings.TrimSpace(parts[0]))
+		spec, ok := g.known[f]
+		if !ok {
+			return errors.Errorf("unknown feature gate %q, the known feature gates are: %s", f, strings.Join(g.KnownFeatures(), ", "))
+		}
+		v, err := strconv.ParseBool(strings.TrimSpace(parts[1]))
+		if err != nil {
+			return errors.Wrapf(err, "invalid value for feature gate %q", f)
+		}
+		if spec.PreRelease == GA && !v {
+			return errors.Errorf("feature gate %q is GA and can't be disabled", f)
+		}
+		enabled[f] = v
+	}
+
+	g.lock.Lock()
+	for f, v := range enabled {
+		g.enabled[f] = v
+	}
+	g.lock.Unlock()
+
+	g.recordMetrics()
+	return nil
+}
+
+// String returns the features set with Set, in the format accepted by Set.
+func (g *Gate) String() string {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+
+	pairs := make([]string, 0, len(g.enabled))
+	for f, v := range g.enabled {
+		pairs = append(pairs, fmt.Sprintf("%s=%t", f, v))
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+// KnownFeatures returns a sorted description of the known features, e.g. "MachinePool=true|false (ALPHA - default=false)".
+func (g *Gate) KnownFeatures() []string {
+	features := make([]string, 0, len(g.known))
+	for f, spec := range g.known {
+		if spec.PreRelease == GA {
+			features = append(features, fmt.Sprintf("%s=true|false (default=%t)", f, spec.Default))
+			continue
+		}
+		features = append(features, fmt.Sprintf("%s=true|false (%s - default=%t)", f, spec.PreRelease, spec.Default))
+	}
+	sort.Strings(features)
+	return features
+}
+
+// AddFlag adds the --feature-gates flag to the given flag set.
+func (g *Gate) AddFlag(fs *flag.FlagSet) {
+	fs.Var(g, "feature-gates", "A set of key=value pairs that describe feature gates for alpha/experimental features. "+
+		"Options are:\n"+strings.Join(g.KnownFeatures(), "\n"))
+}
@@ -0,0 +1,126 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package feature
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+const (
+	alphaFeature Feature = "AlphaFeature"
+	betaFeature  Feature = "BetaFeature"
+	gaFeature    Feature = "GAFeature"
+)
+
+func newTestGate() *Gate {
+	return NewGate(map[Feature]Spec{
+		alphaFeature: {Default: false, PreRelease: Alpha},
+		betaFeature:  {Default: true, PreRelease: Beta},
+		gaFeature:    {Default: true, PreRelease: GA},
+	})
+}
+
+func TestGateDefaults(t *testing.T) {
+	g := NewWithT(t)
+
+	gate := newTestGate()
+	g.Expect(gate.Enabled(alphaFeature)).To(BeFalse())
+	g.Expect(gate.Enabled(betaFeature)).To(BeTrue())
+	g.Expect(gate.Enabled(gaFeature)).To(BeTrue())
+	g.Expect(gate.Enabled("UnknownFeature")).To(BeFalse())
+	g.Expect(gate.String()).To(BeEmpty())
+}
+
+func TestGateSet(t *testing.T) {
+	tests := []struct {
+		name        string
+		value       string
+		wantEnabled map[Feature]bool
+		wantString  string
+		wantErr     bool
+	}{
+		{
+			name:  "empty value keeps the defaults",
+			value: "",
+			wantEnabled: map[Feature]bool{
+				alphaFeature: false,
+				betaFeature:  true,
+				gaFeature:    true,
+			},
+			wantString: "",
+		},
+		{
+			name:  "enable an alpha feature and disable a beta feature",
+			value: "AlphaFeature=true, BetaFeature=false",
+			wantEnabled: map[Feature]bool{
+				alphaFeature: true,
+				betaFeature:  false,
+				gaFeature:    true,
+			},
+			wantString: "AlphaFeature=true,BetaFeature=false",
+		},
+		{
+			name:    "fails for unknown features",
+			value:   "UnknownFeature=true",
+			wantErr: true,
+		},
+		{
+			name:    "fails for invalid values",
+			value:   "AlphaFeature=maybe",
+			wantErr: true,
+		},
+		{
+			name:    "fails without a value",
+			value:   "AlphaFeature",
+			wantErr: true,
+		},
+		{
+			name:    "fails to disable a GA feature",
+			value:   "GAFeature=false",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			gate := newTestGate()
+			err := gate.Set(tt.value)
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+			for f, enabled := range tt.wantEnabled {
+				g.Expect(gate.Enabled(f)).To(Equal(enabled), "feature %s", f)
+			}
+			g.Expect(gate.String()).To(Equal(tt.wantString))
+		})
+	}
+}
+
+func TestGateKnownFeatures(t *testing.T) {
+	g := NewWithT(t)
+
+	g.Expect(newTestGate().KnownFeatures()).To(Equal([]string{
+		"AlphaFeature=true|false (ALPHA - default=false)",
+		"BetaFeature=true|false (BETA - default=true)",
+		"GAFeature=true|false (default=true)",
+	}))
+}
@@ -0,0 +1,46 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package feature
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+// featureEnabled is a metric that is set to 1 if a feature is enabled and 0 if it is not.
+var featureEnabled = prometheus.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Name: "capi_feature_enabled",
+		Help: "Feature is enabled if set to 1 and not if 0.",
+	},
+	[]string{"name", "stage"},
+)
+
+func init() {
+	metrics.Registry.MustRegister(featureEnabled)
+}
+
+// recordMetrics sets the featureEnabled metric for all the known features.
+func (g *Gate) recordMetrics() {
+	for f, spec := range g.known {
+		v := 0.0
+		if g.Enabled(f) {
+			v = 1
+		}
+		featureEnabled.WithLabelValues(string(f), string(spec.PreRelease)).Set(v)
+	}
+}
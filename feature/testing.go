@@ -0,0 +1,38 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package feature
+
+// SetFeatureGateDuringTest enables or disables a feature for the duration of a test, and returns a function
+// restoring its previous value, meant to be deferred, e.g.
+//
+//	defer feature.SetFeatureGateDuringTest(feature.Gates, feature.MachinePool, true)()
+func SetFeatureGateDuringTest(g *Gate, f Feature, enabled bool) func() {
+	g.lock.Lock()
+	previous, wasSet := g.enabled[f]
+	g.enabled[f] = enabled
+	g.lock.Unlock()
+
+	return func() {
+		g.lock.Lock()
+		if wasSet {
+			g.enabled[f] = previous
+		} else {
+			delete(g.enabled, f)
+		}
+		g.lock.Unlock()
+	}
+}
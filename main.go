@@ -22,6 +22,7 @@ import (
 	"os"
 	"time"
 
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/rest"
@@ -34,7 +35,12 @@ import (
 
 	clusterv1alpha2 "sigs.k8s.io/cluster-api/api/v1alpha2"
 	clusterv1alpha3 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
 	"sigs.k8s.io/cluster-api/controllers"
+	"sigs.k8s.io/cluster-api/controllers/external"
+	"sigs.k8s.io/cluster-api/feature"
+	"sigs.k8s.io/cluster-api/util/fairness"
+	"sigs.k8s.io/cluster-api/util/guardrails"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	// +kubebuilder:scaffold:imports
 )
@@ -48,25 +54,38 @@ func init() {
 	klog.InitFlags(nil)
 
 	_ = clientgoscheme.AddToScheme(scheme)
+	_ = apiextensionsv1.AddToScheme(scheme)
 	_ = clusterv1alpha2.AddToScheme(scheme)
 	_ = clusterv1alpha3.AddToScheme(scheme)
+	_ = clusterctlv1.AddToScheme(scheme)
 	// +kubebuilder:scaffold:scheme
 }
 
 func main() {
 	var (
-		metricsAddr                  string
-		enableLeaderElection         bool
-		watchNamespace               string
-		profilerAddress              string
-		clusterConcurrency           int
-		machineConcurrency           int
-		machineSetConcurrency        int
-		machineDeploymentConcurrency int
-		machinePoolConcurrency       int
-		syncPeriod                   time.Duration
-		webhookPort                  int
-		healthAddr                   string
+		metricsAddr                   string
+		enableLeaderElection          bool
+		watchNamespace                string
+		profilerAddress               string
+		clusterConcurrency            int
+		machineConcurrency            int
+		machineSetConcurrency         int
+		machineDeploymentConcurrency  int
+		machinePoolConcurrency        int
+		machineHealthCheckConcurrency int
+		clusterResourceSetConcurrency int
+		notifierConcurrency           int
+		ipAddressClaimConcurrency     int
+		clusterTopologyConcurrency    int
+		enableKubeletCSRApproval      bool
+		kubeletCSRCheckInterval       time.Duration
+		syncPeriod                    time.Duration
+		machineCreationLimit          int
+		machineCreationWindow         time.Duration
+		clusterReconcileQPS           float64
+		clusterReconcileBurst         int
+		webhookPort                   int
+		healthAddr                    string
 	)
 
 	flag.StringVar(&metricsAddr, "metrics-addr", ":8080",
@@ -96,6 +115,39 @@ func main() {
 	flag.IntVar(&machinePoolConcurrency, "machinepool-concurrency", 10,
 		"Number of machine pools to process simultaneously")
 
+	flag.IntVar(&machineHealthCheckConcurrency, "machinehealthcheck-concurrency", 10,
+		"Number of machine health checks to process simultaneously")
+
+	flag.IntVar(&clusterResourceSetConcurrency, "clusterresourceset-concurrency", 10,
+		"Number of cluster resource sets to process simultaneously")
+
+	flag.IntVar(&notifierConcurrency, "notifier-concurrency", 10,
+		"Number of notifiers to process simultaneously")
+
+	flag.IntVar(&ipAddressClaimConcurrency, "ipaddressclaim-concurrency", 10,
+		"Number of ipaddressclaims to process simultaneously")
+
+	flag.IntVar(&clusterTopologyConcurrency, "clustertopology-concurrency", 10,
+		"Number of clusters with a managed topology to process simultaneously")
+
+	flag.BoolVar(&enableKubeletCSRApproval, "enable-kubelet-csr-approval", false,
+		"Approve the kubelet client and serving CSRs of the workload clusters that are requested by the Nodes of known Machines, matching node names, provider IDs and addresses")
+
+	flag.DurationVar(&kubeletCSRCheckInterval, "kubelet-csr-check-interval", 30*time.Second,
+		"The interval at which the pending CSRs of the workload clusters are checked, when kubelet CSR approval is enabled (e.g. 30s)")
+
+	flag.IntVar(&machineCreationLimit, "machine-creation-limit", 0,
+		"Maximum number of Machines a single MachineSet can create within the machine creation window; once exceeded, scale up is throttled and a warning event is recorded (set to 0 to disable)")
+
+	flag.DurationVar(&machineCreationWindow, "machine-creation-window", 10*time.Minute,
+		"The time window the machine creation limit applies to (e.g. 10m)")
+
+	flag.Float64Var(&clusterReconcileQPS, "cluster-reconcile-qps", 0,
+		"Maximum number of reconciliations per second of the Machines, MachineSets, MachineDeployments, MachinePools and MachineHealthChecks of a single cluster, so a cluster generating a storm of events cannot starve the others; exceeding reconciliations are requeued (set to 0 to disable)")
+
+	flag.IntVar(&clusterReconcileBurst, "cluster-reconcile-burst", 20,
+		"Maximum number of reconciliations of the objects of a single cluster allowed in a burst, when the cluster reconcile QPS is set")
+
 	flag.DurationVar(&syncPeriod, "sync-period", 10*time.Minute,
 		"The minimum interval at which watched resources are reconciled (e.g. 15m)")
 
@@ -105,6 +157,8 @@ func main() {
 	flag.StringVar(&healthAddr, "health-addr", ":9440",
 		"The address the health endpoint binds to.")
 
+	feature.Gates.AddFlag(flag.CommandLine)
+
 	flag.Parse()
 
 	ctrl.SetLogger(klogr.New())
@@ -140,33 +194,88 @@ func main() {
 		os.Exit(1)
 	}
 	if err = (&controllers.MachineReconciler{
-		Client: mgr.GetClient(),
-		Log:    ctrl.Log.WithName("controllers").WithName("Machine"),
+		Client:         mgr.GetClient(),
+		Log:            ctrl.Log.WithName("controllers").WithName("Machine"),
+		ClusterLimiter: fairness.NewClusterLimiter(clusterReconcileQPS, clusterReconcileBurst),
 	}).SetupWithManager(mgr, concurrency(machineConcurrency)); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "Machine")
 		os.Exit(1)
 	}
 	if err = (&controllers.MachineSetReconciler{
-		Client: mgr.GetClient(),
-		Log:    ctrl.Log.WithName("controllers").WithName("MachineSet"),
+		Client:          mgr.GetClient(),
+		Log:             ctrl.Log.WithName("controllers").WithName("MachineSet"),
+		ClusterLimiter:  fairness.NewClusterLimiter(clusterReconcileQPS, clusterReconcileBurst),
+		CreationLimiter: guardrails.NewCreationLimiter(machineCreationLimit, machineCreationWindow),
 	}).SetupWithManager(mgr, concurrency(machineSetConcurrency)); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "MachineSet")
 		os.Exit(1)
 	}
 	if err = (&controllers.MachineDeploymentReconciler{
-		Client: mgr.GetClient(),
-		Log:    ctrl.Log.WithName("controllers").WithName("MachineDeployment"),
+		Client:         mgr.GetClient(),
+		Log:            ctrl.Log.WithName("controllers").WithName("MachineDeployment"),
+		ClusterLimiter: fairness.NewClusterLimiter(clusterReconcileQPS, clusterReconcileBurst),
 	}).SetupWithManager(mgr, concurrency(machineDeploymentConcurrency)); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "MachineDeployment")
 		os.Exit(1)
 	}
-	if err = (&controllers.MachinePoolReconciler{
+	if feature.Gates.Enabled(feature.MachinePool) {
+		if err = (&controllers.MachinePoolReconciler{
+			Client:         mgr.GetClient(),
+			Log:            ctrl.Log.WithName("controllers").WithName("MachinePool"),
+			ClusterLimiter: fairness.NewClusterLimiter(clusterReconcileQPS, clusterReconcileBurst),
+		}).SetupWithManager(mgr, concurrency(machinePoolConcurrency)); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "MachinePool")
+			os.Exit(1)
+		}
+	}
+	if err = (&controllers.MachineHealthCheckReconciler{
+		Client:         mgr.GetClient(),
+		Log:            ctrl.Log.WithName("controllers").WithName("MachineHealthCheck"),
+		ClusterLimiter: fairness.NewClusterLimiter(clusterReconcileQPS, clusterReconcileBurst),
+	}).SetupWithManager(mgr, concurrency(machineHealthCheckConcurrency)); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "MachineHealthCheck")
+		os.Exit(1)
+	}
+	if feature.Gates.Enabled(feature.ClusterResourceSet) {
+		if err = (&controllers.ClusterResourceSetReconciler{
+			Client: mgr.GetClient(),
+			Log:    ctrl.Log.WithName("controllers").WithName("ClusterResourceSet"),
+		}).SetupWithManager(mgr, concurrency(clusterResourceSetConcurrency)); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "ClusterResourceSet")
+			os.Exit(1)
+		}
+	}
+	if err = (&controllers.NotifierReconciler{
 		Client: mgr.GetClient(),
-		Log:    ctrl.Log.WithName("controllers").WithName("MachinePool"),
-	}).SetupWithManager(mgr, concurrency(machinePoolConcurrency)); err != nil {
-		setupLog.Error(err, "unable to create controller", "controller", "MachinePool")
+		Log:    ctrl.Log.WithName("controllers").WithName("Notifier"),
+	}).SetupWithManager(mgr, concurrency(notifierConcurrency)); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "Notifier")
 		os.Exit(1)
 	}
+	if err = (&controllers.IPAddressClaimReconciler{
+		Client: mgr.GetClient(),
+		Log:    ctrl.Log.WithName("controllers").WithName("IPAddressClaim"),
+	}).SetupWithManager(mgr, concurrency(ipAddressClaimConcurrency)); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "IPAddressClaim")
+		os.Exit(1)
+	}
+	if err = (&controllers.ClusterTopologyReconciler{
+		Client: mgr.GetClient(),
+		Log:    ctrl.Log.WithName("controllers").WithName("ClusterTopology"),
+	}).SetupWithManager(mgr, concurrency(clusterTopologyConcurrency)); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "ClusterTopology")
+		os.Exit(1)
+	}
+	if enableKubeletCSRApproval {
+		if err = (&controllers.KubeletCSRApproverReconciler{
+			Client:        mgr.GetClient(),
+			Log:           ctrl.Log.WithName("controllers").WithName("KubeletCSRApprover"),
+			CheckInterval: kubeletCSRCheckInterval,
+		}).SetupWithManager(mgr, concurrency(clusterConcurrency)); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "KubeletCSRApprover")
+			os.Exit(1)
+		}
+	}
 
 	if webhookPort != 0 {
 		if err = (&clusterv1alpha2.Cluster{}).SetupWebhookWithManager(mgr); err != nil {
@@ -229,6 +338,59 @@ func main() {
 			setupLog.Error(err, "unable to create webhook", "webhook", "MachinePool")
 			os.Exit(1)
 		}
+
+		if err = (&clusterv1alpha3.MachineHealthCheck{}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "MachineHealthCheck")
+			os.Exit(1)
+		}
+
+		if err = (&clusterv1alpha3.ClusterResourceSet{}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "ClusterResourceSet")
+			os.Exit(1)
+		}
+
+		if err = (&clusterv1alpha3.Notifier{}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "Notifier")
+			os.Exit(1)
+		}
+
+		if err = (&clusterv1alpha3.IPAddressClaim{}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "IPAddressClaim")
+			os.Exit(1)
+		}
+
+		if err = (&clusterv1alpha3.IPAddress{}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "IPAddress")
+			os.Exit(1)
+		}
+
+		if err = (&clusterv1alpha3.ClusterClass{}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "ClusterClass")
+			os.Exit(1)
+		}
+
+		if err = (&external.ReferenceValidator{
+			Client:     mgr.GetAPIReader(),
+			RESTMapper: mgr.GetRESTMapper(),
+			References: external.MachineTemplateReferences,
+		}).SetupWebhookWithManager(mgr, "/validate-cluster-x-k8s-io-v1alpha3-template-references"); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "TemplateReferences")
+			os.Exit(1)
+		}
+
+		if err = (&controllers.ProviderInventoryValidator{
+			Client: mgr.GetAPIReader(),
+		}).SetupWebhookWithManager(mgr, "/validate-clusterctl-cluster-x-k8s-io-v1alpha3-provider"); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "ProviderInventory")
+			os.Exit(1)
+		}
+
+		if err = (&controllers.VersionSkewValidator{
+			Client: mgr.GetAPIReader(),
+		}).SetupWebhookWithManager(mgr, "/validate-cluster-x-k8s-io-v1alpha3-version-skew"); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "VersionSkew")
+			os.Exit(1)
+		}
 	}
 
 	if err := mgr.AddReadyzCheck("ping", healthz.Ping); err != nil {
@@ -0,0 +1,209 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package clusterctl provides helpers for running the clusterctl flows, e.g. init, config cluster, move and
+// upgrade, in the e2e tests of the providers.
+package clusterctl
+
+import (
+	"context"
+	"fmt"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	clusterctlclient "sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/client"
+	"sigs.k8s.io/cluster-api/test/framework"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// InitInput is the input for Init.
+type InitInput struct {
+	// KubeconfigPath is the kubeconfig of the management cluster to initialize.
+	KubeconfigPath string
+	// ClusterctlConfigPath is the clusterctl configuration file, defining e.g. the provider repositories under test.
+	// Defaults to $HOME/.cluster-api/clusterctl.yaml.
+	ClusterctlConfigPath string
+
+	CoreProvider            string
+	BootstrapProviders      []string
+	ControlPlaneProviders   []string
+	InfrastructureProviders []string
+}
+
+func (input InitInput) initOptions() clusterctlclient.InitOptions {
+	return clusterctlclient.InitOptions{
+		Kubeconfig:              input.KubeconfigPath,
+		CoreProvider:            input.CoreProvider,
+		BootstrapProviders:      input.BootstrapProviders,
+		ControlPlaneProviders:   input.ControlPlaneProviders,
+		InfrastructureProviders: input.InfrastructureProviders,
+	}
+}
+
+// Init runs clusterctl init, installing the providers into a management cluster.
+func Init(ctx context.Context, input InitInput) {
+	By(fmt.Sprintf("running clusterctl init --core %s --bootstrap %v --control-plane %v --infrastructure %v",
+		input.CoreProvider, input.BootstrapProviders, input.ControlPlaneProviders, input.InfrastructureProviders))
+
+	c := newClient(input.ClusterctlConfigPath)
+	_, err := c.Init(ctx, input.initOptions())
+	Expect(err).ToNot(HaveOccurred(), "failed to run clusterctl init")
+}
+
+// ConfigClusterInput is the input for ConfigCluster.
+type ConfigClusterInput struct {
+	// KubeconfigPath is the kubeconfig of the management cluster the workload cluster is created in.
+	KubeconfigPath string
+	// ClusterctlConfigPath is the clusterctl configuration file, defining e.g. the variables of the template.
+	ClusterctlConfigPath string
+
+	InfrastructureProvider   string
+	Flavor                   string
+	Namespace                string
+	ClusterName              string
+	KubernetesVersion        string
+	ControlPlaneMachineCount int
+	WorkerMachineCount       int
+}
+
+func (input ConfigClusterInput) getClusterTemplateOptions() clusterctlclient.GetClusterTemplateOptions {
+	return clusterctlclient.GetClusterTemplateOptions{
+		Kubeconfig: input.KubeconfigPath,
+		ProviderRepositorySource: &clusterctlclient.ProviderRepositorySourceOptions{
+			InfrastructureProvider: input.InfrastructureProvider,
+			Flavor:                 input.Flavor,
+		},
+		TargetNamespace:          input.Namespace,
+		ClusterName:              input.ClusterName,
+		KubernetesVersion:        input.KubernetesVersion,
+		ControlPlaneMachineCount: input.ControlPlaneMachineCount,
+		WorkerMachineCount:       input.WorkerMachineCount,
+	}
+}
+
+// ConfigCluster runs clusterctl config cluster, returning the YAML of the workload cluster template.
+func ConfigCluster(ctx context.Context, input ConfigClusterInput) []byte {
+	By(fmt.Sprintf("running clusterctl config cluster %s --infrastructure %s --flavor %q --target-namespace %s --kubernetes-version %s",
+		input.ClusterName, input.InfrastructureProvider, input.Flavor, input.Namespace, input.KubernetesVersion))
+
+	c := newClient(input.ClusterctlConfigPath)
+	template, err := c.GetClusterTemplate(ctx, input.getClusterTemplateOptions())
+	Expect(err).ToNot(HaveOccurred(), "failed to run clusterctl config cluster")
+
+	yaml, err := template.Yaml()
+	Expect(err).ToNot(HaveOccurred(), "failed to convert the cluster template to yaml")
+	return yaml
+}
+
+// ApplyClusterTemplateAndWaitInput is the input for ApplyClusterTemplateAndWait.
+type ApplyClusterTemplateAndWaitInput struct {
+	ManagementCluster framework.ManagementCluster
+	ConfigCluster     ConfigClusterInput
+
+	WaitForClusterIntervals  []interface{}
+	WaitForMachinesIntervals []interface{}
+}
+
+// ApplyClusterTemplateAndWait applies the workload cluster template generated by clusterctl config cluster to the
+// management cluster, then waits for the cluster to be provisioned and for all its machines to be running.
+func ApplyClusterTemplateAndWait(ctx context.Context, input ApplyClusterTemplateAndWaitInput) *clusterv1.Cluster {
+	Expect(input.ManagementCluster).ToNot(BeNil(), "the management cluster is required for ApplyClusterTemplateAndWait")
+
+	yaml := ConfigCluster(ctx, input.ConfigCluster)
+
+	By(fmt.Sprintf("applying the cluster template for cluster %s", input.ConfigCluster.ClusterName))
+	Expect(input.ManagementCluster.Apply(ctx, yaml)).To(Succeed())
+
+	mgmtClient, err := input.ManagementCluster.GetClient()
+	Expect(err).ToNot(HaveOccurred(), "failed to get the client of the management cluster")
+
+	cluster := &clusterv1.Cluster{}
+	key := client.ObjectKey{Namespace: input.ConfigCluster.Namespace, Name: input.ConfigCluster.ClusterName}
+	Eventually(func() error {
+		return mgmtClient.Get(ctx, key, cluster)
+	}, input.WaitForClusterIntervals...).Should(Succeed())
+
+	framework.WaitForClusterToProvision(ctx, framework.WaitForClusterToProvisionInput{
+		Getter:  mgmtClient,
+		Cluster: cluster,
+	}, input.WaitForClusterIntervals...)
+
+	framework.WaitForClusterMachinesToBeRunning(ctx, framework.WaitForClusterMachinesToBeRunningInput{
+		Lister:  mgmtClient,
+		Cluster: cluster,
+		Count:   input.ConfigCluster.ControlPlaneMachineCount + input.ConfigCluster.WorkerMachineCount,
+	}, input.WaitForMachinesIntervals...)
+
+	return cluster
+}
+
+// MoveInput is the input for Move.
+type MoveInput struct {
+	FromKubeconfigPath   string
+	ToKubeconfigPath     string
+	ClusterctlConfigPath string
+	Namespace            string
+}
+
+// Move runs clusterctl move, moving the Cluster API objects of a namespace to another management cluster.
+func Move(ctx context.Context, input MoveInput) {
+	By(fmt.Sprintf("running clusterctl move --namespace %s", input.Namespace))
+
+	c := newClient(input.ClusterctlConfigPath)
+	Expect(c.Move(ctx, clusterctlclient.MoveOptions{
+		FromKubeconfig: input.FromKubeconfigPath,
+		ToKubeconfig:   input.ToKubeconfigPath,
+		Namespace:      input.Namespace,
+	})).To(Succeed(), "failed to run clusterctl move")
+}
+
+// UpgradeInput is the input for Upgrade.
+type UpgradeInput struct {
+	KubeconfigPath       string
+	ClusterctlConfigPath string
+	ManagementGroup      string
+	Contract             string
+}
+
+// Upgrade runs clusterctl upgrade apply, upgrading the providers of a management group to the latest version
+// of a contract, then checks the providers are working after the upgrade.
+func Upgrade(ctx context.Context, input UpgradeInput) {
+	By(fmt.Sprintf("running clusterctl upgrade apply --management-group %s --contract %s", input.ManagementGroup, input.Contract))
+
+	c := newClient(input.ClusterctlConfigPath)
+	Expect(c.ApplyUpgrade(ctx, clusterctlclient.ApplyUpgradeOptions{
+		Kubeconfig:      input.KubeconfigPath,
+		ManagementGroup: input.ManagementGroup,
+		Contract:        input.Contract,
+	})).To(Succeed(), "failed to run clusterctl upgrade apply")
+
+	By("verifying the providers after the upgrade")
+	checks, err := c.VerifyUpgrade(ctx, clusterctlclient.VerifyUpgradeOptions{
+		Kubeconfig:      input.KubeconfigPath,
+		ManagementGroup: input.ManagementGroup,
+	})
+	Expect(err).ToNot(HaveOccurred(), "failed to verify the upgrade")
+	for _, check := range checks {
+		Expect(check.Error).ToNot(HaveOccurred(), "upgrade check %s failed", check.Name)
+	}
+}
+
+func newClient(configPath string) clusterctlclient.Client {
+	c, err := clusterctlclient.New(configPath)
+	Expect(err).ToNot(HaveOccurred(), "failed to create the clusterctl client")
+	return c
+}
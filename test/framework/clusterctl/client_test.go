@@ -0,0 +1,69 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package clusterctl
+
+import (
+	"testing"
+
+	"github.com/onsi/gomega"
+	clusterctlclient "sigs.k8s.io/cluster-api/cmd/clusterctl/pkg/client"
+)
+
+func TestInitInput_initOptions(t *testing.T) {
+	g := gomega.NewWithT(t)
+	input := InitInput{
+		KubeconfigPath:          "/tmp/kubeconfig",
+		ClusterctlConfigPath:    "/tmp/clusterctl.yaml",
+		CoreProvider:            "cluster-api:v0.3.0",
+		BootstrapProviders:      []string{"kubeadm-bootstrap:v0.3.0"},
+		ControlPlaneProviders:   []string{"kubeadm-control-plane:v0.3.0"},
+		InfrastructureProviders: []string{"docker:v0.3.0"},
+	}
+	g.Expect(input.initOptions()).To(gomega.Equal(clusterctlclient.InitOptions{
+		Kubeconfig:              "/tmp/kubeconfig",
+		CoreProvider:            "cluster-api:v0.3.0",
+		BootstrapProviders:      []string{"kubeadm-bootstrap:v0.3.0"},
+		ControlPlaneProviders:   []string{"kubeadm-control-plane:v0.3.0"},
+		InfrastructureProviders: []string{"docker:v0.3.0"},
+	}))
+}
+
+func TestConfigClusterInput_getClusterTemplateOptions(t *testing.T) {
+	g := gomega.NewWithT(t)
+	input := ConfigClusterInput{
+		KubeconfigPath:           "/tmp/kubeconfig",
+		InfrastructureProvider:   "docker",
+		Flavor:                   "ha",
+		Namespace:                "e2e",
+		ClusterName:              "e2e-cluster",
+		KubernetesVersion:        "v1.17.0",
+		ControlPlaneMachineCount: 3,
+		WorkerMachineCount:       2,
+	}
+	g.Expect(input.getClusterTemplateOptions()).To(gomega.Equal(clusterctlclient.GetClusterTemplateOptions{
+		Kubeconfig: "/tmp/kubeconfig",
+		ProviderRepositorySource: &clusterctlclient.ProviderRepositorySourceOptions{
+			InfrastructureProvider: "docker",
+			Flavor:                 "ha",
+		},
+		TargetNamespace:          "e2e",
+		ClusterName:              "e2e-cluster",
+		KubernetesVersion:        "v1.17.0",
+		ControlPlaneMachineCount: 3,
+		WorkerMachineCount:       2,
+	}))
+}
@@ -237,6 +237,33 @@ func WaitForKubeadmControlPlaneMachinesToExist(ctx context.Context, input WaitFo
 	}, intervals...).Should(Equal(int(*input.ControlPlane.Spec.Replicas)))
 }
 
+// WaitForClusterMachinesToBeRunningInput is the input for WaitForClusterMachinesToBeRunning.
+type WaitForClusterMachinesToBeRunningInput struct {
+	Lister  Lister
+	Cluster *clusterv1.Cluster
+	// Count is the number of machines, control plane and workers, expected for the cluster.
+	Count int
+}
+
+// WaitForClusterMachinesToBeRunning will wait until the expected number of machines of a cluster, regardless of
+// the object owning them, are in the running phase and have a node ref.
+func WaitForClusterMachinesToBeRunning(ctx context.Context, input WaitForClusterMachinesToBeRunningInput, intervals ...interface{}) {
+	By(fmt.Sprintf("waiting for %d machines of cluster %s to be running", input.Count, input.Cluster.GetName()))
+	Eventually(func() (int, error) {
+		machineList := &clusterv1.MachineList{}
+		if err := input.Lister.List(ctx, machineList, client.InNamespace(input.Cluster.Namespace), client.MatchingLabels{clusterv1.ClusterLabelName: input.Cluster.Name}); err != nil {
+			return 0, err
+		}
+		count := 0
+		for _, machine := range machineList.Items {
+			if machine.Status.NodeRef != nil && machine.Status.GetTypedPhase() == clusterv1.MachinePhaseRunning {
+				count++
+			}
+		}
+		return count, nil
+	}, intervals...).Should(Equal(input.Count))
+}
+
 // WaitForControlPlaneToBeReadyInput is the input for WaitForControlPlaneToBeReady.
 type WaitForControlPlaneToBeReadyInput struct {
 	Getter       Getter
@@ -14,3 +14,5 @@ require (
 	sigs.k8s.io/kind v0.7.0
 	sigs.k8s.io/yaml v1.1.0
 )
+
+replace sigs.k8s.io/cluster-api => ../..
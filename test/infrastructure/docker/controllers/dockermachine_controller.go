@@ -23,7 +23,6 @@ import (
 
 	"github.com/go-logr/logr"
 	"github.com/pkg/errors"
-	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/types"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
@@ -31,6 +30,7 @@ import (
 	"sigs.k8s.io/cluster-api/test/infrastructure/docker/docker"
 	"sigs.k8s.io/cluster-api/util"
 	"sigs.k8s.io/cluster-api/util/patch"
+	"sigs.k8s.io/cluster-api/util/secret"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
@@ -307,15 +307,12 @@ func (r *DockerMachineReconciler) getBootstrapData(ctx context.Context, machine
 		return "", errors.New("error retrieving bootstrap data: linked Machine's bootstrap.dataSecretName is nil")
 	}
 
-	s := &corev1.Secret{}
-	key := types.NamespacedName{Namespace: machine.GetNamespace(), Name: *machine.Spec.Bootstrap.DataSecretName}
-	if err := r.Client.Get(ctx, key, s); err != nil {
-		return "", errors.Wrapf(err, "failed to retrieve bootstrap data secret for DockerMachine %s/%s", machine.GetNamespace(), machine.GetName())
+	value, format, err := secret.GetBootstrapData(ctx, r.Client, machine)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to retrieve bootstrap data for DockerMachine %s/%s", machine.GetNamespace(), machine.GetName())
 	}
-
-	value, ok := s.Data["value"]
-	if !ok {
-		return "", errors.New("error retrieving bootstrap data: secret value key is missing")
+	if format != clusterv1.BootstrapDataFormatCloudConfig {
+		return "", errors.Errorf("error retrieving bootstrap data: unsupported format %q, only %q is supported", format, clusterv1.BootstrapDataFormatCloudConfig)
 	}
 
 	return base64.StdEncoding.EncodeToString(value), nil
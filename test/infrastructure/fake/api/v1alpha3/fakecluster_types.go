@@ -0,0 +1,72 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha3
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+)
+
+// FakeClusterSpec defines the desired state of FakeCluster.
+type FakeClusterSpec struct {
+	// ControlPlaneEndpoint represents the endpoint used to communicate with the control plane.
+	// If not set, the fake cluster controller sets it to a fake endpoint, as there is no real control plane.
+	// +optional
+	ControlPlaneEndpoint clusterv1.APIEndpoint `json:"controlPlaneEndpoint"`
+
+	// FailureDomains are copied into the status by the fake cluster controller, so the Cluster API
+	// controllers spreading the machines across failure domains can be tested.
+	// +optional
+	FailureDomains clusterv1.FailureDomains `json:"failureDomains,omitempty"`
+}
+
+// FakeClusterStatus defines the observed state of FakeCluster.
+type FakeClusterStatus struct {
+	// Ready denotes that the fake cluster (infrastructure) is ready.
+	Ready bool `json:"ready"`
+
+	// FailureDomains is the list of failure domains copied from the spec.
+	// +optional
+	FailureDomains clusterv1.FailureDomains `json:"failureDomains,omitempty"`
+}
+
+// +kubebuilder:resource:path=fakeclusters,scope=Namespaced,categories=cluster-api
+// +kubebuilder:subresource:status
+// +kubebuilder:storageversion
+// +kubebuilder:object:root=true
+
+// FakeCluster is the Schema for the fakeclusters API
+type FakeCluster struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   FakeClusterSpec   `json:"spec,omitempty"`
+	Status FakeClusterStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// FakeClusterList contains a list of FakeCluster
+type FakeClusterList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []FakeCluster `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&FakeCluster{}, &FakeClusterList{})
+}
@@ -0,0 +1,57 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha3
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// FakeClusterTemplateSpec defines the desired state of FakeClusterTemplate
+type FakeClusterTemplateSpec struct {
+	Template FakeClusterTemplateResource `json:"template"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:path=fakeclustertemplates,scope=Namespaced,categories=cluster-api
+// +kubebuilder:storageversion
+
+// FakeClusterTemplate is the Schema for the fakeclustertemplates API
+type FakeClusterTemplate struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec FakeClusterTemplateSpec `json:"spec,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// FakeClusterTemplateList contains a list of FakeClusterTemplate
+type FakeClusterTemplateList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []FakeClusterTemplate `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&FakeClusterTemplate{}, &FakeClusterTemplateList{})
+}
+
+// FakeClusterTemplateResource describes the data needed to create a FakeCluster from a template
+type FakeClusterTemplateResource struct {
+	// Spec is the specification of the desired behavior of the cluster.
+	Spec FakeClusterSpec `json:"spec"`
+}
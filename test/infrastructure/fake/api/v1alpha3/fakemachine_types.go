@@ -0,0 +1,89 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha3
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	capierrors "sigs.k8s.io/cluster-api/errors"
+)
+
+// FakeMachineSpec defines the desired state of FakeMachine
+type FakeMachineSpec struct {
+	// ProviderID is set by the fake machine controller in the fake:///<namespace>/<name> format.
+	// +optional
+	ProviderID *string `json:"providerID,omitempty"`
+
+	// ProvisioningDelay is how long the fake machine takes to be provisioned, counted from its creation,
+	// so the intermediate phases of the Machine can be observed. Defaults to no delay.
+	// +optional
+	ProvisioningDelay *metav1.Duration `json:"provisioningDelay,omitempty"`
+
+	// FailureReason, if set, makes the fake machine fail with this reason instead of being provisioned,
+	// e.g. for testing the remediation of failed machines.
+	// +optional
+	FailureReason *capierrors.MachineStatusError `json:"failureReason,omitempty"`
+
+	// FailureMessage is the message reported together with FailureReason.
+	// +optional
+	FailureMessage *string `json:"failureMessage,omitempty"`
+}
+
+// FakeMachineStatus defines the observed state of FakeMachine
+type FakeMachineStatus struct {
+	// Ready denotes that the fake machine is provisioned.
+	Ready bool `json:"ready"`
+
+	// Addresses contains the fake addresses of the machine.
+	// +optional
+	Addresses []clusterv1.MachineAddress `json:"addresses,omitempty"`
+
+	// FailureReason is copied from the spec when the fake machine fails.
+	// +optional
+	FailureReason *capierrors.MachineStatusError `json:"failureReason,omitempty"`
+
+	// FailureMessage is copied from the spec when the fake machine fails.
+	// +optional
+	FailureMessage *string `json:"failureMessage,omitempty"`
+}
+
+// +kubebuilder:resource:path=fakemachines,scope=Namespaced,categories=cluster-api
+// +kubebuilder:object:root=true
+// +kubebuilder:storageversion
+// +kubebuilder:subresource:status
+
+// FakeMachine is the Schema for the fakemachines API
+type FakeMachine struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   FakeMachineSpec   `json:"spec,omitempty"`
+	Status FakeMachineStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// FakeMachineList contains a list of FakeMachine
+type FakeMachineList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []FakeMachine `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&FakeMachine{}, &FakeMachineList{})
+}
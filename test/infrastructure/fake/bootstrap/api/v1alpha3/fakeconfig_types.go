@@ -0,0 +1,66 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha3
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// FakeConfigSpec defines the desired state of FakeConfig
+type FakeConfigSpec struct {
+	// Data is the bootstrap data stored in the bootstrap data secret; it is not executed by anything, so
+	// defaults to a placeholder.
+	// +optional
+	Data string `json:"data,omitempty"`
+}
+
+// FakeConfigStatus defines the observed state of FakeConfig
+type FakeConfigStatus struct {
+	// Ready indicates the bootstrap data secret has been created.
+	Ready bool `json:"ready"`
+
+	// DataSecretName is the name of the secret that stores the bootstrap data.
+	// +optional
+	DataSecretName *string `json:"dataSecretName,omitempty"`
+}
+
+// +kubebuilder:resource:path=fakeconfigs,scope=Namespaced,categories=cluster-api
+// +kubebuilder:object:root=true
+// +kubebuilder:storageversion
+// +kubebuilder:subresource:status
+
+// FakeConfig is the Schema for the fakeconfigs API
+type FakeConfig struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   FakeConfigSpec   `json:"spec,omitempty"`
+	Status FakeConfigStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// FakeConfigList contains a list of FakeConfig
+type FakeConfigList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []FakeConfig `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&FakeConfig{}, &FakeConfigList{})
+}
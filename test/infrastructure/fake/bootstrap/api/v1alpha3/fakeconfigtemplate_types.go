@@ -0,0 +1,57 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha3
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// FakeConfigTemplateSpec defines the desired state of FakeConfigTemplate
+type FakeConfigTemplateSpec struct {
+	Template FakeConfigTemplateResource `json:"template"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:path=fakeconfigtemplates,scope=Namespaced,categories=cluster-api
+// +kubebuilder:storageversion
+
+// FakeConfigTemplate is the Schema for the fakeconfigtemplates API
+type FakeConfigTemplate struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec FakeConfigTemplateSpec `json:"spec,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// FakeConfigTemplateList contains a list of FakeConfigTemplate
+type FakeConfigTemplateList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []FakeConfigTemplate `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&FakeConfigTemplate{}, &FakeConfigTemplateList{})
+}
+
+// FakeConfigTemplateResource describes the data needed to create a FakeConfig from a template
+type FakeConfigTemplateResource struct {
+	// Spec is the specification of the desired behavior of the bootstrap config.
+	Spec FakeConfigSpec `json:"spec"`
+}
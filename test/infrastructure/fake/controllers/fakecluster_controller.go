@@ -0,0 +1,123 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-logr/logr"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	infrav1 "sigs.k8s.io/cluster-api/test/infrastructure/fake/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/util"
+	"sigs.k8s.io/cluster-api/util/patch"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+// fakeControlPlanePort is the port of the fake control plane endpoints.
+const fakeControlPlanePort = 6443
+
+// FakeClusterReconciler reconciles a FakeCluster object
+type FakeClusterReconciler struct {
+	client.Client
+	Log logr.Logger
+}
+
+// +kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=fakeclusters,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=fakeclusters/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=cluster.x-k8s.io,resources=clusters,verbs=get;list;watch
+
+// Reconcile marks the FakeCluster as ready, there is no infrastructure to provision.
+func (r *FakeClusterReconciler) Reconcile(req ctrl.Request) (_ ctrl.Result, rerr error) {
+	ctx := context.Background()
+	log := r.Log.WithValues("fake-cluster", req.NamespacedName)
+
+	// Fetch the FakeCluster instance
+	fakeCluster := &infrav1.FakeCluster{}
+	if err := r.Client.Get(ctx, req.NamespacedName, fakeCluster); err != nil {
+		if apierrors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	// Fetch the Cluster.
+	cluster, err := util.GetOwnerCluster(ctx, r.Client, fakeCluster.ObjectMeta)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if cluster == nil {
+		log.Info("Waiting for Cluster Controller to set OwnerRef on FakeCluster")
+		return ctrl.Result{}, nil
+	}
+
+	// There is nothing to clean up for deleted clusters.
+	if !fakeCluster.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, nil
+	}
+
+	// Initialize the patch helper
+	patchHelper, err := patch.NewHelper(fakeCluster, r)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	// Always attempt to Patch the FakeCluster object and status after each reconciliation.
+	defer func() {
+		if err := patchHelper.Patch(ctx, fakeCluster); err != nil {
+			log.Error(err, "failed to patch FakeCluster")
+			if rerr == nil {
+				rerr = err
+			}
+		}
+	}()
+
+	reconcileFakeCluster(cluster, fakeCluster)
+	return ctrl.Result{}, nil
+}
+
+func reconcileFakeCluster(cluster *clusterv1.Cluster, fakeCluster *infrav1.FakeCluster) {
+	fakeCluster.Status.FailureDomains = fakeCluster.Spec.FailureDomains
+
+	// There is no load balancer in front of the control plane, so a fake endpoint is used.
+	if fakeCluster.Spec.ControlPlaneEndpoint.IsZero() {
+		fakeCluster.Spec.ControlPlaneEndpoint = clusterv1.APIEndpoint{
+			Host: fmt.Sprintf("%s.%s.fake.local", cluster.Name, cluster.Namespace),
+			Port: fakeControlPlanePort,
+		}
+	}
+
+	fakeCluster.Status.Ready = true
+}
+
+// SetupWithManager will add watches for this controller
+func (r *FakeClusterReconciler) SetupWithManager(mgr ctrl.Manager, options controller.Options) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&infrav1.FakeCluster{}).
+		Watches(
+			&source.Kind{Type: &clusterv1.Cluster{}},
+			&handler.EnqueueRequestsFromMapFunc{
+				ToRequests: util.ClusterToInfrastructureMapFunc(infrav1.GroupVersion.WithKind("FakeCluster")),
+			},
+		).
+		WithOptions(options).
+		Complete(r)
+}
@@ -0,0 +1,72 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	infrav1 "sigs.k8s.io/cluster-api/test/infrastructure/fake/api/v1alpha3"
+	bootstrapv1 "sigs.k8s.io/cluster-api/test/infrastructure/fake/bootstrap/api/v1alpha3"
+)
+
+func setupScheme() *runtime.Scheme {
+	s := runtime.NewScheme()
+	if err := clusterv1.AddToScheme(s); err != nil {
+		panic(err)
+	}
+	if err := infrav1.AddToScheme(s); err != nil {
+		panic(err)
+	}
+	if err := bootstrapv1.AddToScheme(s); err != nil {
+		panic(err)
+	}
+	return s
+}
+
+func TestReconcileFakeCluster(t *testing.T) {
+	cluster := &clusterv1.Cluster{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "test"}}
+
+	t.Run("sets a fake control plane endpoint and marks the cluster ready", func(t *testing.T) {
+		g := NewWithT(t)
+
+		fakeCluster := &infrav1.FakeCluster{
+			Spec: infrav1.FakeClusterSpec{
+				FailureDomains: clusterv1.FailureDomains{"fd1": clusterv1.FailureDomainSpec{ControlPlane: true}},
+			},
+		}
+		reconcileFakeCluster(cluster, fakeCluster)
+
+		g.Expect(fakeCluster.Spec.ControlPlaneEndpoint).To(Equal(clusterv1.APIEndpoint{Host: "test.default.fake.local", Port: 6443}))
+		g.Expect(fakeCluster.Status.FailureDomains).To(Equal(fakeCluster.Spec.FailureDomains))
+		g.Expect(fakeCluster.Status.Ready).To(BeTrue())
+	})
+
+	t.Run("keeps the control plane endpoint set in the spec", func(t *testing.T) {
+		g := NewWithT(t)
+
+		endpoint := clusterv1.APIEndpoint{Host: "example.com", Port: 443}
+		fakeCluster := &infrav1.FakeCluster{Spec: infrav1.FakeClusterSpec{ControlPlaneEndpoint: endpoint}}
+		reconcileFakeCluster(cluster, fakeCluster)
+
+		g.Expect(fakeCluster.Spec.ControlPlaneEndpoint).To(Equal(endpoint))
+		g.Expect(fakeCluster.Status.Ready).To(BeTrue())
+	})
+}
@@ -0,0 +1,143 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	bsutil "sigs.k8s.io/cluster-api/bootstrap/util"
+	bootstrapv1 "sigs.k8s.io/cluster-api/test/infrastructure/fake/bootstrap/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/util/patch"
+	"sigs.k8s.io/cluster-api/util/secret"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+)
+
+// defaultFakeBootstrapData is stored in the bootstrap data secrets of the FakeConfigs without data.
+const defaultFakeBootstrapData = "#!/bin/sh\n# bootstrap data generated by the fake bootstrap provider\n"
+
+// FakeConfigReconciler reconciles a FakeConfig object
+type FakeConfigReconciler struct {
+	client.Client
+	Log logr.Logger
+}
+
+// +kubebuilder:rbac:groups=bootstrap.cluster.x-k8s.io,resources=fakeconfigs,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=bootstrap.cluster.x-k8s.io,resources=fakeconfigs/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=cluster.x-k8s.io,resources=machines,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch;create
+
+// Reconcile stores the data of the FakeConfig in the bootstrap data secret, once the FakeConfig is owned by a Machine.
+func (r *FakeConfigReconciler) Reconcile(req ctrl.Request) (_ ctrl.Result, rerr error) {
+	ctx := context.Background()
+	log := r.Log.WithValues("fake-config", req.NamespacedName)
+
+	// Fetch the FakeConfig instance.
+	config := &bootstrapv1.FakeConfig{}
+	if err := r.Client.Get(ctx, req.NamespacedName, config); err != nil {
+		if apierrors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	if config.Status.Ready || !config.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, nil
+	}
+
+	// Fetch the owner of the FakeConfig.
+	owner, err := bsutil.GetConfigOwner(ctx, r.Client, config.ObjectMeta)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if owner == nil {
+		log.Info("Waiting for Machine Controller to set OwnerRef on FakeConfig")
+		return ctrl.Result{}, nil
+	}
+
+	// Initialize the patch helper
+	patchHelper, err := patch.NewHelper(config, r)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	// Always attempt to Patch the FakeConfig object and status after each reconciliation.
+	defer func() {
+		if err := patchHelper.Patch(ctx, config); err != nil {
+			log.Error(err, "failed to patch FakeConfig")
+			if rerr == nil {
+				rerr = err
+			}
+		}
+	}()
+
+	if err := r.storeBootstrapData(ctx, config, owner.ClusterName()); err != nil {
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{}, nil
+}
+
+// storeBootstrapData creates the bootstrap data secret, sets the reference in the FakeConfig status and ready to true.
+func (r *FakeConfigReconciler) storeBootstrapData(ctx context.Context, config *bootstrapv1.FakeConfig, clusterName string) error {
+	data := config.Spec.Data
+	if data == "" {
+		data = defaultFakeBootstrapData
+	}
+
+	dataSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      config.Name,
+			Namespace: config.Namespace,
+			Labels: map[string]string{
+				clusterv1.ClusterLabelName: clusterName,
+			},
+			OwnerReferences: []metav1.OwnerReference{
+				{
+					APIVersion: bootstrapv1.GroupVersion.String(),
+					Kind:       "FakeConfig",
+					Name:       config.Name,
+					UID:        config.UID,
+					Controller: pointer.BoolPtr(true),
+				},
+			},
+		},
+	}
+	secret.SetBootstrapData(dataSecret, []byte(data), clusterv1.BootstrapDataFormatCloudConfig)
+
+	if err := r.Client.Create(ctx, dataSecret); err != nil && !apierrors.IsAlreadyExists(err) {
+		return errors.Wrapf(err, "failed to create bootstrap data secret for FakeConfig %s/%s", config.Namespace, config.Name)
+	}
+
+	config.Status.DataSecretName = pointer.StringPtr(dataSecret.Name)
+	config.Status.Ready = true
+	return nil
+}
+
+// SetupWithManager will add watches for this controller
+func (r *FakeConfigReconciler) SetupWithManager(mgr ctrl.Manager, options controller.Options) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&bootstrapv1.FakeConfig{}).
+		WithOptions(options).
+		Complete(r)
+}
@@ -0,0 +1,102 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/klogr"
+	"k8s.io/utils/pointer"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	bootstrapv1 "sigs.k8s.io/cluster-api/test/infrastructure/fake/bootstrap/api/v1alpha3"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestFakeConfigReconciler_Reconcile(t *testing.T) {
+	machine := &clusterv1.Machine{
+		TypeMeta:   metav1.TypeMeta{APIVersion: clusterv1.GroupVersion.String(), Kind: "Machine"},
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "machine"},
+		Spec:       clusterv1.MachineSpec{ClusterName: "test"},
+	}
+	newConfig := func(name, data string, owned bool) *bootstrapv1.FakeConfig {
+		config := &bootstrapv1.FakeConfig{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name},
+			Spec:       bootstrapv1.FakeConfigSpec{Data: data},
+		}
+		if owned {
+			config.OwnerReferences = []metav1.OwnerReference{{APIVersion: clusterv1.GroupVersion.String(), Kind: "Machine", Name: machine.Name}}
+		}
+		return config
+	}
+
+	tests := []struct {
+		name       string
+		config     *bootstrapv1.FakeConfig
+		wantReady  bool
+		wantSecret string
+	}{
+		{
+			name:       "stores the data of the config",
+			config:     newConfig("with-data", "#cloud-config", true),
+			wantReady:  true,
+			wantSecret: "#cloud-config",
+		},
+		{
+			name:       "stores placeholder data for a config without data",
+			config:     newConfig("without-data", "", true),
+			wantReady:  true,
+			wantSecret: defaultFakeBootstrapData,
+		},
+		{
+			name:      "waits for the config to be owned",
+			config:    newConfig("not-owned", "#cloud-config", false),
+			wantReady: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			c := fake.NewFakeClientWithScheme(setupScheme(), machine, tt.config)
+			r := &FakeConfigReconciler{Client: c, Log: klogr.New()}
+
+			key := types.NamespacedName{Namespace: tt.config.Namespace, Name: tt.config.Name}
+			_, err := r.Reconcile(ctrl.Request{NamespacedName: key})
+			g.Expect(err).ToNot(HaveOccurred())
+
+			config := &bootstrapv1.FakeConfig{}
+			g.Expect(c.Get(context.Background(), key, config)).To(Succeed())
+			g.Expect(config.Status.Ready).To(Equal(tt.wantReady))
+			if !tt.wantReady {
+				g.Expect(config.Status.DataSecretName).To(BeNil())
+				return
+			}
+			g.Expect(config.Status.DataSecretName).To(Equal(pointer.StringPtr(tt.config.Name)))
+
+			secret := &corev1.Secret{}
+			g.Expect(c.Get(context.Background(), key, secret)).To(Succeed())
+			g.Expect(string(secret.Data["value"])).To(Equal(tt.wantSecret))
+			g.Expect(secret.Labels).To(HaveKeyWithValue(clusterv1.ClusterLabelName, "test"))
+		})
+	}
+}
@@ -0,0 +1,193 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	infrav1 "sigs.k8s.io/cluster-api/test/infrastructure/fake/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/util"
+	"sigs.k8s.io/cluster-api/util/patch"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+// FakeMachineReconciler reconciles a FakeMachine object
+type FakeMachineReconciler struct {
+	client.Client
+	Log logr.Logger
+}
+
+// +kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=fakemachines,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=fakemachines/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=cluster.x-k8s.io,resources=clusters;machines,verbs=get;list;watch
+
+// Reconcile provisions the FakeMachine, i.e. marks it as ready, once the cluster infrastructure is ready and the
+// bootstrap data is available, after the provisioning delay; if a failure is set in the spec, the FakeMachine fails
+// instead.
+func (r *FakeMachineReconciler) Reconcile(req ctrl.Request) (_ ctrl.Result, rerr error) {
+	ctx := context.Background()
+	log := r.Log.WithValues("fake-machine", req.NamespacedName)
+
+	// Fetch the FakeMachine instance.
+	fakeMachine := &infrav1.FakeMachine{}
+	if err := r.Client.Get(ctx, req.NamespacedName, fakeMachine); err != nil {
+		if apierrors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	// There is nothing to clean up for deleted machines.
+	if !fakeMachine.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, nil
+	}
+
+	// Fetch the Machine.
+	machine, err := util.GetOwnerMachine(ctx, r.Client, fakeMachine.ObjectMeta)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if machine == nil {
+		log.Info("Waiting for Machine Controller to set OwnerRef on FakeMachine")
+		return ctrl.Result{}, nil
+	}
+
+	log = log.WithValues("machine", machine.Name)
+
+	// Fetch the Cluster.
+	cluster, err := util.GetClusterFromMetadata(ctx, r.Client, machine.ObjectMeta)
+	if err != nil {
+		log.Info("FakeMachine owner Machine is missing cluster label or cluster does not exist")
+		return ctrl.Result{}, err
+	}
+
+	log = log.WithValues("cluster", cluster.Name)
+
+	// Make sure infrastructure is ready
+	if !cluster.Status.InfrastructureReady {
+		log.Info("Waiting for FakeCluster Controller to create cluster infrastructure")
+		return ctrl.Result{}, nil
+	}
+
+	// Make sure bootstrap data is available and populated.
+	if machine.Spec.Bootstrap.DataSecretName == nil {
+		log.Info("Waiting for the Bootstrap provider controller to set bootstrap data")
+		return ctrl.Result{}, nil
+	}
+
+	// Initialize the patch helper
+	patchHelper, err := patch.NewHelper(fakeMachine, r)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	// Always attempt to Patch the FakeMachine object and status after each reconciliation.
+	defer func() {
+		if err := patchHelper.Patch(ctx, fakeMachine); err != nil {
+			log.Error(err, "failed to patch FakeMachine")
+			if rerr == nil {
+				rerr = err
+			}
+		}
+	}()
+
+	return reconcileFakeMachine(machine, fakeMachine, time.Now()), nil
+}
+
+func reconcileFakeMachine(machine *clusterv1.Machine, fakeMachine *infrav1.FakeMachine, now time.Time) ctrl.Result {
+	if fakeMachine.Status.Ready || fakeMachine.Status.FailureReason != nil {
+		return ctrl.Result{}
+	}
+
+	if fakeMachine.Spec.ProvisioningDelay != nil {
+		provisionedAt := fakeMachine.CreationTimestamp.Add(fakeMachine.Spec.ProvisioningDelay.Duration)
+		if remaining := provisionedAt.Sub(now); remaining > 0 {
+			return ctrl.Result{RequeueAfter: remaining}
+		}
+	}
+
+	if fakeMachine.Spec.FailureReason != nil {
+		fakeMachine.Status.FailureReason = fakeMachine.Spec.FailureReason
+		fakeMachine.Status.FailureMessage = fakeMachine.Spec.FailureMessage
+		return ctrl.Result{}
+	}
+
+	providerID := fmt.Sprintf("fake:///%s/%s", fakeMachine.Namespace, fakeMachine.Name)
+	fakeMachine.Spec.ProviderID = &providerID
+	fakeMachine.Status.Addresses = []clusterv1.MachineAddress{
+		{
+			Type:    clusterv1.MachineHostName,
+			Address: machine.Name,
+		},
+	}
+	fakeMachine.Status.Ready = true
+	return ctrl.Result{}
+}
+
+// SetupWithManager will add watches for this controller
+func (r *FakeMachineReconciler) SetupWithManager(mgr ctrl.Manager, options controller.Options) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&infrav1.FakeMachine{}).
+		Watches(
+			&source.Kind{Type: &clusterv1.Machine{}},
+			&handler.EnqueueRequestsFromMapFunc{
+				ToRequests: util.MachineToInfrastructureMapFunc(infrav1.GroupVersion.WithKind("FakeMachine")),
+			},
+		).
+		Watches(
+			&source.Kind{Type: &clusterv1.Cluster{}},
+			&handler.EnqueueRequestsFromMapFunc{
+				ToRequests: handler.ToRequestsFunc(r.ClusterToFakeMachines),
+			},
+		).
+		WithOptions(options).
+		Complete(r)
+}
+
+// ClusterToFakeMachines is a handler.ToRequestsFunc to be used to enqueue requests for the FakeMachines of a Cluster,
+// e.g. when its infrastructure becomes ready.
+func (r *FakeMachineReconciler) ClusterToFakeMachines(o handler.MapObject) []ctrl.Request {
+	c, ok := o.Object.(*clusterv1.Cluster)
+	if !ok {
+		r.Log.Error(fmt.Errorf("expected a Cluster but got a %T", o.Object), "failed to get FakeMachines for Cluster")
+		return nil
+	}
+
+	machineList := &clusterv1.MachineList{}
+	if err := r.Client.List(context.TODO(), machineList, client.InNamespace(c.Namespace), client.MatchingLabels{clusterv1.ClusterLabelName: c.Name}); err != nil {
+		r.Log.Error(err, "failed to list Machines", "cluster", c.Name)
+		return nil
+	}
+
+	result := []ctrl.Request{}
+	for _, m := range machineList.Items {
+		if m.Spec.InfrastructureRef.GroupVersionKind() != infrav1.GroupVersion.WithKind("FakeMachine") || m.Spec.InfrastructureRef.Name == "" {
+			continue
+		}
+		name := client.ObjectKey{Namespace: m.Namespace, Name: m.Spec.InfrastructureRef.Name}
+		result = append(result, ctrl.Request{NamespacedName: name})
+	}
+	return result
+}
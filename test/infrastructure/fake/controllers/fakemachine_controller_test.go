@@ -0,0 +1,119 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/klogr"
+	"k8s.io/utils/pointer"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	capierrors "sigs.k8s.io/cluster-api/errors"
+	infrav1 "sigs.k8s.io/cluster-api/test/infrastructure/fake/api/v1alpha3"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+)
+
+func TestReconcileFakeMachine(t *testing.T) {
+	created := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	machine := &clusterv1.Machine{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "machine"}}
+	newFakeMachine := func(spec infrav1.FakeMachineSpec) *infrav1.FakeMachine {
+		return &infrav1.FakeMachine{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "fake-machine", CreationTimestamp: metav1.NewTime(created)},
+			Spec:       spec,
+		}
+	}
+
+	t.Run("provisions the machine", func(t *testing.T) {
+		g := NewWithT(t)
+
+		fakeMachine := newFakeMachine(infrav1.FakeMachineSpec{})
+		g.Expect(reconcileFakeMachine(machine, fakeMachine, created)).To(Equal(ctrl.Result{}))
+
+		g.Expect(fakeMachine.Spec.ProviderID).To(Equal(pointer.StringPtr("fake:///default/fake-machine")))
+		g.Expect(fakeMachine.Status.Addresses).To(ConsistOf(clusterv1.MachineAddress{Type: clusterv1.MachineHostName, Address: "machine"}))
+		g.Expect(fakeMachine.Status.Ready).To(BeTrue())
+	})
+
+	t.Run("waits for the provisioning delay", func(t *testing.T) {
+		g := NewWithT(t)
+
+		fakeMachine := newFakeMachine(infrav1.FakeMachineSpec{ProvisioningDelay: &metav1.Duration{Duration: time.Minute}})
+		g.Expect(reconcileFakeMachine(machine, fakeMachine, created.Add(20*time.Second))).To(Equal(ctrl.Result{RequeueAfter: 40 * time.Second}))
+		g.Expect(fakeMachine.Status.Ready).To(BeFalse())
+
+		g.Expect(reconcileFakeMachine(machine, fakeMachine, created.Add(time.Minute))).To(Equal(ctrl.Result{}))
+		g.Expect(fakeMachine.Status.Ready).To(BeTrue())
+	})
+
+	t.Run("fails the machine", func(t *testing.T) {
+		g := NewWithT(t)
+
+		reason := capierrors.CreateMachineError
+		fakeMachine := newFakeMachine(infrav1.FakeMachineSpec{FailureReason: &reason, FailureMessage: pointer.StringPtr("boom")})
+		g.Expect(reconcileFakeMachine(machine, fakeMachine, created)).To(Equal(ctrl.Result{}))
+
+		g.Expect(fakeMachine.Status.FailureReason).To(Equal(&reason))
+		g.Expect(fakeMachine.Status.FailureMessage).To(Equal(pointer.StringPtr("boom")))
+		g.Expect(fakeMachine.Status.Ready).To(BeFalse())
+		g.Expect(fakeMachine.Spec.ProviderID).To(BeNil())
+	})
+}
+
+func TestFakeMachineReconciler_ClusterToFakeMachines(t *testing.T) {
+	g := NewWithT(t)
+
+	cluster := &clusterv1.Cluster{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "test"}}
+	newMachine := func(name, clusterName, infraKind string) *clusterv1.Machine {
+		return &clusterv1.Machine{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "default",
+				Name:      name,
+				Labels:    map[string]string{clusterv1.ClusterLabelName: clusterName},
+			},
+			Spec: clusterv1.MachineSpec{
+				ClusterName: clusterName,
+				InfrastructureRef: corev1.ObjectReference{
+					APIVersion: infrav1.GroupVersion.String(),
+					Kind:       infraKind,
+					Name:       "infra-" + name,
+				},
+			},
+		}
+	}
+
+	c := fake.NewFakeClientWithScheme(setupScheme(),
+		cluster,
+		newMachine("m1", "test", "FakeMachine"),
+		newMachine("m2", "test", "FakeMachine"),
+		newMachine("m3", "test", "OtherMachine"),
+		newMachine("m4", "other", "FakeMachine"),
+	)
+	r := &FakeMachineReconciler{Client: c, Log: klogr.New()}
+
+	out := r.ClusterToFakeMachines(handler.MapObject{Object: cluster})
+	names := []string{}
+	for _, req := range out {
+		names = append(names, req.Name)
+	}
+	g.Expect(names).To(ConsistOf("infra-m1", "infra-m2"))
+}
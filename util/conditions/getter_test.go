@@ -0,0 +1,220 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package conditions
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+)
+
+var (
+	nil1          *clusterv1.Condition
+	true1         = TrueCondition("true1")
+	unknown1      = UnknownCondition("unknown1", "reason unknown1", "message unknown1")
+	falseInfo1    = FalseCondition("falseInfo1", "reason falseInfo1", clusterv1.ConditionSeverityInfo, "message falseInfo1")
+	falseWarning1 = FalseCondition("falseWarning1", "reason falseWarning1", clusterv1.ConditionSeverityWarning, "message falseWarning1")
+	falseError1   = FalseCondition("falseError1", "reason falseError1", clusterv1.ConditionSeverityError, "message falseError1")
+)
+
+func TestGetAndHas(t *testing.T) {
+	g := NewWithT(t)
+
+	cluster := &clusterv1.Cluster{}
+
+	g.Expect(Has(cluster, "conditionBaz")).To(BeFalse())
+	g.Expect(Get(cluster, "conditionBaz")).To(BeNil())
+
+	cluster.SetConditions(conditionList(TrueCondition("conditionBaz")))
+
+	g.Expect(Has(cluster, "conditionBaz")).To(BeTrue())
+	g.Expect(Get(cluster, "conditionBaz")).To(haveSameStateOf(TrueCondition("conditionBaz")))
+}
+
+func TestIsMethods(t *testing.T) {
+	g := NewWithT(t)
+
+	obj := getterWithConditions(nil1, true1, unknown1, falseInfo1, falseWarning1, falseError1)
+
+	// test isTrue
+	g.Expect(IsTrue(obj, "nil1")).To(BeFalse())
+	g.Expect(IsTrue(obj, "true1")).To(BeTrue())
+	g.Expect(IsTrue(obj, "falseInfo1")).To(BeFalse())
+	g.Expect(IsTrue(obj, "unknown1")).To(BeFalse())
+
+	// test isFalse
+	g.Expect(IsFalse(obj, "nil1")).To(BeFalse())
+	g.Expect(IsFalse(obj, "true1")).To(BeFalse())
+	g.Expect(IsFalse(obj, "falseInfo1")).To(BeTrue())
+	g.Expect(IsFalse(obj, "unknown1")).To(BeFalse())
+
+	// test isUnknown
+	g.Expect(IsUnknown(obj, "nil1")).To(BeTrue())
+	g.Expect(IsUnknown(obj, "true1")).To(BeFalse())
+	g.Expect(IsUnknown(obj, "falseInfo1")).To(BeFalse())
+	g.Expect(IsUnknown(obj, "unknown1")).To(BeTrue())
+
+	// test GetReason
+	g.Expect(GetReason(obj, "nil1")).To(Equal(""))
+	g.Expect(GetReason(obj, "falseInfo1")).To(Equal("reason falseInfo1"))
+
+	// test GetMessage
+	g.Expect(GetMessage(obj, "nil1")).To(Equal(""))
+	g.Expect(GetMessage(obj, "falseInfo1")).To(Equal("message falseInfo1"))
+
+	// test GetSeverity
+	g.Expect(GetSeverity(obj, "nil1")).To(BeNil())
+	severity := GetSeverity(obj, "falseInfo1")
+	expectedSeverity := clusterv1.ConditionSeverityInfo
+	g.Expect(severity).To(Equal(&expectedSeverity))
+
+	// test GetLastTransitionTime
+	g.Expect(GetLastTransitionTime(obj, "nil1")).To(BeNil())
+	g.Expect(GetLastTransitionTime(obj, "falseInfo1")).ToNot(BeNil())
+}
+
+func TestSummary(t *testing.T) {
+	foo := TrueCondition("foo")
+	bar := FalseCondition("bar", "reason falseInfo1", clusterv1.ConditionSeverityInfo, "message falseInfo1")
+	baz := FalseCondition("baz", "reason falseInfo2", clusterv1.ConditionSeverityInfo, "message falseInfo2")
+	existingReady := FalseCondition(clusterv1.ReadyCondition, "reason falseError1", clusterv1.ConditionSeverityError, "message falseError1") // NB. existing ready has higher priority than other conditions
+
+	tests := []struct {
+		name    string
+		from    Getter
+		options []MergeOption
+		want    *clusterv1.Condition
+	}{
+		{
+			name: "Returns nil if there are no conditions to summarize",
+			from: getterWithConditions(),
+			want: nil,
+		},
+		{
+			name: "Returns ready condition with the summary of existing conditions (with default options)",
+			from: getterWithConditions(foo, bar),
+			want: FalseCondition(clusterv1.ReadyCondition, "reason falseInfo1", clusterv1.ConditionSeverityInfo, "message falseInfo1"),
+		},
+		{
+			name:    "Ignores existing Ready condition when computing the summary",
+			from:    getterWithConditions(existingReady, foo, bar),
+			want:    FalseCondition(clusterv1.ReadyCondition, "reason falseInfo1", clusterv1.ConditionSeverityInfo, "message falseInfo1"),
+			options: []MergeOption{WithConditions("foo", "bar")},
+		},
+		{
+			name:    "Returns ready condition with the summary of selected conditions",
+			from:    getterWithConditions(foo, bar),
+			options: []MergeOption{WithConditions("foo")}, // bar should be ignored
+			want:    TrueCondition(clusterv1.ReadyCondition),
+		},
+		{
+			name:    "Returns ready condition using the order of the selected conditions for picking reason and message",
+			from:    getterWithConditions(foo, bar, baz),
+			options: []MergeOption{WithConditions("baz", "bar")},
+			want:    FalseCondition(clusterv1.ReadyCondition, "reason falseInfo2", clusterv1.ConditionSeverityInfo, "message falseInfo2"),
+		},
+		{
+			name: "Returns ready condition with the status of the condition with the highest priority",
+			from: getterWithConditions(foo, falseInfo1, unknown1, falseError1, falseWarning1),
+			want: FalseCondition(clusterv1.ReadyCondition, "reason falseError1", clusterv1.ConditionSeverityError, "message falseError1"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			got := summary(tt.from, tt.options...)
+			g.Expect(got).To(haveSameStateOf(tt.want))
+		})
+	}
+}
+
+func TestAggregate(t *testing.T) {
+	ready1 := TrueCondition(clusterv1.ReadyCondition)
+	ready2 := FalseCondition(clusterv1.ReadyCondition, "reason falseInfo1", clusterv1.ConditionSeverityInfo, "message falseInfo1")
+
+	tests := []struct {
+		name string
+		from []Getter
+		t    clusterv1.ConditionType
+		want *clusterv1.Condition
+	}{
+		{
+			name: "Returns nil if there are no objects with a Ready condition",
+			from: []Getter{getterWithConditions(), getterWithConditions(true1)},
+			t:    "foo",
+			want: nil,
+		},
+		{
+			name: "Returns foo condition with Status=True if all the objects are Ready",
+			from: []Getter{getterWithConditions(ready1), getterWithConditions(ready1)},
+			t:    "foo",
+			want: TrueCondition("foo"),
+		},
+		{
+			name: "Returns foo condition with the summary of the Ready conditions",
+			from: []Getter{getterWithConditions(ready1), getterWithConditions(ready2)},
+			t:    "foo",
+			want: FalseCondition("foo", "reason falseInfo1", clusterv1.ConditionSeverityInfo, "message falseInfo1"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			got := aggregate(tt.from, tt.t)
+			g.Expect(got).To(haveSameStateOf(tt.want))
+		})
+	}
+}
+
+func getterWithConditions(conditions ...*clusterv1.Condition) Getter {
+	obj := &clusterv1.Cluster{}
+	obj.SetConditions(conditionList(conditions...))
+	return obj
+}
+
+func conditionList(conditions ...*clusterv1.Condition) clusterv1.Conditions {
+	cs := clusterv1.Conditions{}
+	for _, x := range conditions {
+		if x != nil {
+			cs = append(cs, *x)
+		}
+	}
+	return cs
+}
+
+// haveSameStateOf matches a condition to have the same state of another, ignoring the LastTransitionTime.
+func haveSameStateOf(expected *clusterv1.Condition) OmegaMatcher {
+	if expected == nil {
+		return BeNil()
+	}
+	return And(
+		Not(BeNil()),
+		WithTransform(func(c *clusterv1.Condition) clusterv1.Condition {
+			if c == nil {
+				return clusterv1.Condition{}
+			}
+			ret := *c
+			ret.LastTransitionTime = expected.LastTransitionTime
+			return ret
+		}, Equal(*expected)),
+	)
+}
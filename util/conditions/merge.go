@@ -0,0 +1,97 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package conditions
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+)
+
+// MergeOption defines an option for computing a summary of conditions.
+type MergeOption func(*mergeOptions)
+
+// mergeOptions allows to set options for the merge operation.
+type mergeOptions struct {
+	conditionTypes []clusterv1.ConditionType
+}
+
+// WithConditions instructs merge about the condition types to consider when doing a merge operation;
+// if this option is not specified, all the conditions (except Ready) will be considered. This is required
+// so we can provide some guarantees about the semantic of the target condition without worrying about
+// side effects if someone or something adds custom conditions to the objects.
+//
+// NOTE: The order of conditions types defines the priority for determining the Reason and Message for the
+// target condition.
+func WithConditions(t ...clusterv1.ConditionType) MergeOption {
+	return func(c *mergeOptions) {
+		c.conditionTypes = t
+	}
+}
+
+// merge a list of conditions into a single one.
+// This operation is designed to ensure visibility of the most relevant conditions for defining the
+// operational state of a component. E.g. If there is one error in the condition list, this one takes
+// priority over the other conditions and it should be reflected in the target condition.
+//
+// More specifically, the resulting condition has the status and severity of the condition with the highest
+// priority, that is False/Error, False/Warning, False/Info, Unknown, True; the reason and the message
+// of the resulting condition are copied from the first condition with the highest priority.
+//
+// If the list of conditions is empty, no condition is returned.
+func merge(conditions []clusterv1.Condition, targetCondition clusterv1.ConditionType) *clusterv1.Condition {
+	if len(conditions) == 0 {
+		return nil
+	}
+
+	var top *clusterv1.Condition
+	for i := range conditions {
+		if top == nil || priority(&conditions[i]) < priority(top) {
+			top = &conditions[i]
+		}
+	}
+
+	if top.Status == corev1.ConditionTrue {
+		return TrueCondition(targetCondition)
+	}
+
+	return &clusterv1.Condition{
+		Type:     targetCondition,
+		Status:   top.Status,
+		Severity: top.Severity,
+		Reason:   top.Reason,
+		Message:  top.Message,
+	}
+}
+
+// priority returns the merge priority of a condition, where lower values take precedence.
+func priority(c *clusterv1.Condition) int {
+	switch c.Status {
+	case corev1.ConditionFalse:
+		switch c.Severity {
+		case clusterv1.ConditionSeverityError:
+			return 0
+		case clusterv1.ConditionSeverityWarning:
+			return 1
+		default:
+			return 2
+		}
+	case corev1.ConditionTrue:
+		return 4
+	default:
+		return 3
+	}
+}
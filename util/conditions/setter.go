@@ -0,0 +1,164 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package conditions
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+)
+
+// Setter interface defines methods that a Cluster API object should implement in order to
+// use the conditions package for setting conditions.
+type Setter interface {
+	Getter
+	SetConditions(clusterv1.Conditions)
+}
+
+// Set sets the given condition.
+//
+// NOTE: If a condition already exists, the LastTransitionTime is updated only if a change is detected
+// in the condition status; conditions are kept ordered with Ready first and then the other
+// conditions in alphabetical order.
+func Set(to Setter, condition *clusterv1.Condition) {
+	if to == nil || condition == nil {
+		return
+	}
+
+	// Check if the new conditions already exists, and change it only if there is a status
+	// transition (otherwise we should preserve the current last transition time).
+	conditions := to.GetConditions()
+	exists := false
+	for i := range conditions {
+		existingCondition := conditions[i]
+		if existingCondition.Type == condition.Type {
+			exists = true
+			if existingCondition.Status == condition.Status {
+				condition.LastTransitionTime = existingCondition.LastTransitionTime
+			} else {
+				condition.LastTransitionTime = now()
+			}
+			conditions[i] = *condition
+			break
+		}
+	}
+
+	// If the condition does not exist, add it, setting the transition time only if not already set
+	if !exists {
+		if condition.LastTransitionTime.IsZero() {
+			condition.LastTransitionTime = now()
+		}
+		conditions = append(conditions, *condition)
+	}
+
+	// Sorts conditions for convenience of the consumer, i.e. kubectl.
+	sort.Slice(conditions, func(i, j int) bool {
+		return lexicographicLess(&conditions[i], &conditions[j])
+	})
+
+	to.SetConditions(conditions)
+}
+
+// TrueCondition returns a condition with Status=True and the given type.
+func TrueCondition(t clusterv1.ConditionType) *clusterv1.Condition {
+	return &clusterv1.Condition{
+		Type:   t,
+		Status: corev1.ConditionTrue,
+	}
+}
+
+// FalseCondition returns a condition with Status=False and the given type.
+func FalseCondition(t clusterv1.ConditionType, reason string, severity clusterv1.ConditionSeverity, messageFormat string, messageArgs ...interface{}) *clusterv1.Condition {
+	return &clusterv1.Condition{
+		Type:     t,
+		Status:   corev1.ConditionFalse,
+		Reason:   reason,
+		Severity: severity,
+		Message:  fmt.Sprintf(messageFormat, messageArgs...),
+	}
+}
+
+// UnknownCondition returns a condition with Status=Unknown and the given type.
+func UnknownCondition(t clusterv1.ConditionType, reason string, messageFormat string, messageArgs ...interface{}) *clusterv1.Condition {
+	return &clusterv1.Condition{
+		Type:    t,
+		Status:  corev1.ConditionUnknown,
+		Reason:  reason,
+		Message: fmt.Sprintf(messageFormat, messageArgs...),
+	}
+}
+
+// MarkTrue sets Status=True for the condition with the given type.
+func MarkTrue(to Setter, t clusterv1.ConditionType) {
+	Set(to, TrueCondition(t))
+}
+
+// MarkUnknown sets Status=Unknown for the condition with the given type.
+func MarkUnknown(to Setter, t clusterv1.ConditionType, reason, messageFormat string, messageArgs ...interface{}) {
+	Set(to, UnknownCondition(t, reason, messageFormat, messageArgs...))
+}
+
+// MarkFalse sets Status=False for the condition with the given type.
+func MarkFalse(to Setter, t clusterv1.ConditionType, reason string, severity clusterv1.ConditionSeverity, messageFormat string, messageArgs ...interface{}) {
+	Set(to, FalseCondition(t, reason, severity, messageFormat, messageArgs...))
+}
+
+// SetSummary sets a Ready condition with the summary of all the conditions existing on an object.
+// If the object does not have other conditions, no summary condition is generated.
+func SetSummary(to Setter, options ...MergeOption) {
+	Set(to, summary(to, options...))
+}
+
+// SetAggregate sets a condition with the given type, summarizing the Ready condition of a list of objects,
+// e.g. the Machines belonging to a control plane. If none of the objects has a Ready condition, the condition is not set.
+func SetAggregate(to Setter, t clusterv1.ConditionType, from []Getter) {
+	Set(to, aggregate(from, t))
+}
+
+// Delete deletes the condition with the given type.
+func Delete(to Setter, t clusterv1.ConditionType) {
+	if to == nil {
+		return
+	}
+
+	conditions := to.GetConditions()
+	newConditions := make(clusterv1.Conditions, 0, len(conditions))
+	for _, condition := range conditions {
+		if condition.Type != t {
+			newConditions = append(newConditions, condition)
+		}
+	}
+	to.SetConditions(newConditions)
+}
+
+// now returns the current time with the precision supported by the API server, so
+// lastTransitionTime values survive a round trip to the API server unchanged.
+func now() metav1.Time {
+	return metav1.NewTime(time.Now().UTC().Truncate(time.Second))
+}
+
+// lexicographicLess returns true if a condition is less than another with regards to the
+// to order of conditions designed for convenience of the consumer, i.e. kubectl.
+// According to this order the Ready condition always goes first, followed by all the other
+// conditions sorted by Type.
+func lexicographicLess(i, j *clusterv1.Condition) bool {
+	return (i.Type == clusterv1.ReadyCondition || i.Type < j.Type) && j.Type != clusterv1.ReadyCondition
+}
@@ -0,0 +1,139 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package fairness implements the per-cluster rate limiting the controllers apply to reconciliations,
+// so a single cluster generating a storm of events cannot starve the reconciliation of the other clusters
+// sharing the same controller.
+package fairness
+
+import (
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// idleBucketTTL is how long the bucket of a cluster is retained after being refilled, before being dropped.
+const idleBucketTTL = 10 * time.Minute
+
+// ClusterLimiter rate limits the reconciliations of each cluster with a token bucket: every cluster can
+// burst up to burst reconciliations, then it is allowed qps reconciliations per second.
+// A nil ClusterLimiter, or one with a non positive qps, never limits reconciliations.
+type ClusterLimiter struct {
+	qps   float64
+	burst int
+
+	lock      sync.Mutex
+	buckets   map[types.NamespacedName]*bucket
+	lastSweep time.Time
+
+	// now is used for testing purposes.
+	now func() time.Time
+}
+
+// bucket tracks the tokens available to a cluster as of the last time it was updated.
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// NewClusterLimiter returns a ClusterLimiter that allows each cluster qps reconciliations per second,
+// with bursts of up to burst reconciliations; burst is at least 1.
+func NewClusterLimiter(qps float64, burst int) *ClusterLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &ClusterLimiter{
+		qps:     qps,
+		burst:   burst,
+		buckets: map[types.NamespacedName]*bucket{},
+		now:     time.Now,
+	}
+}
+
+// Take consumes a token from the bucket of the cluster and returns 0 if one is available; otherwise it
+// returns the time until the next token is available, without consuming any.
+func (l *ClusterLimiter) Take(cluster types.NamespacedName) time.Duration {
+	if !l.enabled() {
+		return 0
+	}
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+
+	b, ok := l.buckets[cluster]
+	if !ok {
+		b = &bucket{tokens: float64(l.burst), last: now}
+		l.buckets[cluster] = b
+	}
+	l.refill(b, now)
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return 0
+	}
+	return time.Duration((1 - b.tokens) / l.qps * float64(time.Second))
+}
+
+// QPS returns the number of reconciliations per second each cluster is allowed.
+func (l *ClusterLimiter) QPS() float64 {
+	if l == nil {
+		return 0
+	}
+	return l.qps
+}
+
+// Burst returns the number of reconciliations each cluster is allowed in a burst.
+func (l *ClusterLimiter) Burst() int {
+	if l == nil {
+		return 0
+	}
+	return l.burst
+}
+
+func (l *ClusterLimiter) enabled() bool {
+	return l != nil && l.qps > 0
+}
+
+// refill adds the tokens accrued since the last update of the bucket; it must be called with the lock held.
+func (l *ClusterLimiter) refill(b *bucket, now time.Time) {
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens += elapsed.Seconds() * l.qps
+		if b.tokens > float64(l.burst) {
+			b.tokens = float64(l.burst)
+		}
+	}
+	b.last = now
+}
+
+// sweep drops the buckets which have been full for a while, e.g. the ones of deleted clusters, given that
+// a full bucket is equivalent to a missing one; it must be called with the lock held.
+func (l *ClusterLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < idleBucketTTL {
+		return
+	}
+	l.lastSweep = now
+
+	fullAfter := time.Duration(float64(l.burst) / l.qps * float64(time.Second))
+	for cluster, b := range l.buckets {
+		if now.Sub(b.last) > fullAfter+idleBucketTTL {
+			delete(l.buckets, cluster)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fairness
+
+import (
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestClusterLimiter(t *testing.T) {
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	l := NewClusterLimiter(2, 3)
+	l.now = func() time.Time { return now }
+
+	noisy := types.NamespacedName{Namespace: "default", Name: "noisy"}
+	quiet := types.NamespacedName{Namespace: "default", Name: "quiet"}
+
+	for i := 0; i < 3; i++ {
+		if got := l.Take(noisy); got != 0 {
+			t.Fatalf("Take() = %v, want 0 within the burst", got)
+		}
+	}
+	if got := l.Take(noisy); got != 500*time.Millisecond {
+		t.Fatalf("Take() = %v, want 500ms after exhausting the burst", got)
+	}
+	// A throttled cluster does not consume tokens, so it is not pushed further back by retrying.
+	if got := l.Take(noisy); got != 500*time.Millisecond {
+		t.Fatalf("Take() = %v, want 500ms when retrying before the next token", got)
+	}
+
+	// The other clusters are not affected by the noisy one.
+	if got := l.Take(quiet); got != 0 {
+		t.Fatalf("Take() = %v, want 0 for another cluster", got)
+	}
+
+	now = now.Add(500 * time.Millisecond)
+	if got := l.Take(noisy); got != 0 {
+		t.Fatalf("Take() = %v, want 0 after a token is refilled", got)
+	}
+	if got := l.Take(noisy); got != 500*time.Millisecond {
+		t.Fatalf("Take() = %v, want 500ms after consuming the refilled token", got)
+	}
+
+	// Tokens are refilled up to the burst.
+	now = now.Add(time.Hour)
+	for i := 0; i < 3; i++ {
+		if got := l.Take(noisy); got != 0 {
+			t.Fatalf("Take() = %v, want 0 within the burst after being idle", got)
+		}
+	}
+	if got := l.Take(noisy); got == 0 {
+		t.Fatalf("Take() = 0, want a delay after exhausting the refilled burst")
+	}
+}
+
+func TestClusterLimiter_DropsIdleBuckets(t *testing.T) {
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	l := NewClusterLimiter(1, 1)
+	l.now = func() time.Time { return now }
+
+	for i := 0; i < 5; i++ {
+		l.Take(types.NamespacedName{Namespace: "default", Name: string(rune('a' + i))})
+	}
+	if got := len(l.buckets); got != 5 {
+		t.Fatalf("got %d buckets, want 5", got)
+	}
+
+	now = now.Add(2 * idleBucketTTL)
+	l.Take(types.NamespacedName{Namespace: "default", Name: "z"})
+	if got := len(l.buckets); got != 1 {
+		t.Fatalf("got %d buckets, want 1 after the idle buckets are dropped", got)
+	}
+}
+
+func TestClusterLimiter_Disabled(t *testing.T) {
+	cluster := types.NamespacedName{Namespace: "default", Name: "cluster"}
+
+	var l *ClusterLimiter
+	for i := 0; i < 100; i++ {
+		if got := l.Take(cluster); got != 0 {
+			t.Fatalf("Take() = %v, want 0 for a nil limiter", got)
+		}
+	}
+
+	l = NewClusterLimiter(0, 1)
+	for i := 0; i < 100; i++ {
+		if got := l.Take(cluster); got != 0 {
+			t.Fatalf("Take() = %v, want 0 for a limiter with no qps", got)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fairness
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+var (
+	// reconcileAdmitted is a metric counting the reconciliations of each cluster which were run.
+	reconcileAdmitted = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "capi_cluster_reconcile_admitted_total",
+			Help: "Number of reconciliations of the objects of a cluster which were run by a controller.",
+		},
+		[]string{"controller", "namespace", "cluster"},
+	)
+
+	// reconcileThrottled is a metric counting the reconciliations of each cluster which were delayed
+	// because the cluster exceeded its rate.
+	reconcileThrottled = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "capi_cluster_reconcile_throttled_total",
+			Help: "Number of reconciliations of the objects of a cluster which were delayed by a controller because the cluster exceeded its rate.",
+		},
+		[]string{"controller", "namespace", "cluster"},
+	)
+
+	// reconcileThrottleDelay is a metric observing how long the throttled reconciliations were delayed.
+	reconcileThrottleDelay = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "capi_cluster_reconcile_throttle_delay_seconds",
+			Help:    "Delay applied by a controller to the reconciliations of the clusters exceeding their rate.",
+			Buckets: prometheus.ExponentialBuckets(0.01, 2, 12),
+		},
+		[]string{"controller"},
+	)
+)
+
+func init() {
+	metrics.Registry.MustRegister(
+		reconcileAdmitted,
+		reconcileThrottled,
+		reconcileThrottleDelay,
+	)
+}
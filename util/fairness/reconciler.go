@@ -0,0 +1,100 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fairness
+
+import (
+	"context"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// reconciler wraps a Reconciler, delaying the reconciliation of the objects of the clusters exceeding their rate.
+type reconciler struct {
+	client     client.Client
+	objType    runtime.Object
+	controller string
+	limiter    *ClusterLimiter
+	reconciler reconcile.Reconciler
+}
+
+// NewReconciler wraps the Reconciler of a controller for objects of the given type, so the reconciliations of
+// the objects belonging to a cluster are rate limited by the limiter; the reconciliations exceeding the rate
+// are requeued, freeing the controller workers for the objects of the other clusters.
+// If the limiter is not enabled, the Reconciler is returned unchanged.
+func NewReconciler(c client.Client, objType runtime.Object, controller string, limiter *ClusterLimiter, r reconcile.Reconciler) reconcile.Reconciler {
+	if !limiter.enabled() {
+		return r
+	}
+	return &reconciler{
+		client:     c,
+		objType:    objType,
+		controller: controller,
+		limiter:    limiter,
+		reconciler: r,
+	}
+}
+
+func (r *reconciler) Reconcile(req reconcile.Request) (reconcile.Result, error) {
+	obj := r.objType.DeepCopyObject()
+	if err := r.client.Get(context.Background(), req.NamespacedName, obj); err != nil {
+		// Let the wrapped Reconciler deal with missing objects and errors.
+		return r.reconciler.Reconcile(req)
+	}
+
+	clusterName := clusterNameForObject(obj)
+	if clusterName == "" {
+		return r.reconciler.Reconcile(req)
+	}
+
+	cluster := types.NamespacedName{Namespace: req.Namespace, Name: clusterName}
+	if delay := r.limiter.Take(cluster); delay > 0 {
+		reconcileThrottled.WithLabelValues(r.controller, cluster.Namespace, cluster.Name).Inc()
+		reconcileThrottleDelay.WithLabelValues(r.controller).Observe(delay.Seconds())
+		return reconcile.Result{RequeueAfter: delay}, nil
+	}
+
+	reconcileAdmitted.WithLabelValues(r.controller, cluster.Namespace, cluster.Name).Inc()
+	return r.reconciler.Reconcile(req)
+}
+
+// clusterNameForObject returns the name of the cluster an object belongs to, read from the spec of the
+// Cluster API types or from the cluster name label; it returns an empty string if it is unknown.
+func clusterNameForObject(obj runtime.Object) string {
+	switch o := obj.(type) {
+	case *clusterv1.Machine:
+		return o.Spec.ClusterName
+	case *clusterv1.MachineSet:
+		return o.Spec.ClusterName
+	case *clusterv1.MachineDeployment:
+		return o.Spec.ClusterName
+	case *clusterv1.MachinePool:
+		return o.Spec.ClusterName
+	case *clusterv1.MachineHealthCheck:
+		return o.Spec.ClusterName
+	}
+
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return ""
+	}
+	return accessor.GetLabels()[clusterv1.ClusterLabelName]
+}
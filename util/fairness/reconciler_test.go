@@ -0,0 +1,116 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fairness
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// countingReconciler counts the reconciliations of each object.
+type countingReconciler map[types.NamespacedName]int
+
+func (r countingReconciler) Reconcile(req reconcile.Request) (reconcile.Result, error) {
+	r[req.NamespacedName]++
+	return reconcile.Result{}, nil
+}
+
+func TestReconciler(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := clusterv1.AddToScheme(scheme); err != nil {
+		t.Fatal("failed to register cluster api objects to scheme")
+	}
+
+	machine := func(name, cluster string) *clusterv1.Machine {
+		return &clusterv1.Machine{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name},
+			Spec:       clusterv1.MachineSpec{ClusterName: cluster},
+		}
+	}
+	objs := []runtime.Object{machine("quiet-machine", "quiet")}
+	for i := 0; i < 10; i++ {
+		objs = append(objs, machine(fmt.Sprintf("noisy-machine-%d", i), "noisy"))
+	}
+	c := fake.NewFakeClientWithScheme(scheme, objs...)
+
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	limiter := NewClusterLimiter(1, 5)
+	limiter.now = func() time.Time { return now }
+
+	inner := countingReconciler{}
+	r := NewReconciler(c, &clusterv1.Machine{}, "test", limiter, inner)
+
+	// A storm of events for the noisy cluster: only the burst is reconciled, the rest is requeued.
+	throttled := 0
+	for i := 0; i < 10; i++ {
+		res, err := r.Reconcile(reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: fmt.Sprintf("noisy-machine-%d", i)}})
+		if err != nil {
+			t.Fatalf("Reconcile() error = %v", err)
+		}
+		if res.RequeueAfter > 0 {
+			throttled++
+		}
+	}
+	if throttled != 5 {
+		t.Errorf("got %d throttled reconciliations, want 5", throttled)
+	}
+
+	// The quiet cluster is reconciled immediately, despite the storm.
+	quiet := types.NamespacedName{Namespace: "default", Name: "quiet-machine"}
+	res, err := r.Reconcile(reconcile.Request{NamespacedName: quiet})
+	if err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+	if res.RequeueAfter != 0 || inner[quiet] != 1 {
+		t.Errorf("got RequeueAfter = %v and %d reconciliations for the quiet cluster, want it reconciled immediately", res.RequeueAfter, inner[quiet])
+	}
+
+	if got := testutil.ToFloat64(reconcileAdmitted.WithLabelValues("test", "default", "noisy")); got != 5 {
+		t.Errorf("got %v admitted reconciliations for the noisy cluster, want 5", got)
+	}
+	if got := testutil.ToFloat64(reconcileThrottled.WithLabelValues("test", "default", "noisy")); got != 5 {
+		t.Errorf("got %v throttled reconciliations for the noisy cluster, want 5", got)
+	}
+	if got := testutil.ToFloat64(reconcileThrottled.WithLabelValues("test", "default", "quiet")); got != 0 {
+		t.Errorf("got %v throttled reconciliations for the quiet cluster, want 0", got)
+	}
+
+	// Missing objects are passed through to the wrapped reconciler.
+	missing := types.NamespacedName{Namespace: "default", Name: "missing"}
+	if _, err := r.Reconcile(reconcile.Request{NamespacedName: missing}); err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+	if inner[missing] != 1 {
+		t.Errorf("got %d reconciliations for a missing object, want 1", inner[missing])
+	}
+}
+
+func TestNewReconciler_Disabled(t *testing.T) {
+	inner := countingReconciler{}
+	if _, ok := NewReconciler(nil, &clusterv1.Machine{}, "test", nil, inner).(countingReconciler); !ok {
+		t.Errorf("NewReconciler() returned a wrapper, want the reconciler unchanged when the limiter is disabled")
+	}
+}
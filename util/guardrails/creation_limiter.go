@@ -0,0 +1,140 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package guardrails implements safety limits the controllers apply before creating
+// infrastructure, protecting cloud accounts from runaway replica values.
+package guardrails
+
+import (
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// CreationLimiter caps the number of objects a single owner is allowed to create within a
+// sliding time window. A nil CreationLimiter, or one with a non positive limit, never limits creations.
+type CreationLimiter struct {
+	limit  int
+	window time.Duration
+
+	lock    sync.Mutex
+	created map[types.UID][]time.Time
+
+	// now is used for testing purposes.
+	now func() time.Time
+}
+
+// NewCreationLimiter returns a CreationLimiter that allows each owner to create at most limit
+// objects within window.
+func NewCreationLimiter(limit int, window time.Duration) *CreationLimiter {
+	return &CreationLimiter{
+		limit:   limit,
+		window:  window,
+		created: map[types.UID][]time.Time{},
+		now:     time.Now,
+	}
+}
+
+// Allow returns how many of the requested creations the owner can perform without exceeding
+// the limit for the current window.
+func (l *CreationLimiter) Allow(owner types.UID, requested int) int {
+	if !l.enabled() {
+		return requested
+	}
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	remaining := l.limit - len(l.prune(owner))
+	if remaining < 0 {
+		remaining = 0
+	}
+	if requested < remaining {
+		return requested
+	}
+	return remaining
+}
+
+// Record registers that the owner created an object.
+func (l *CreationLimiter) Record(owner types.UID) {
+	if !l.enabled() {
+		return
+	}
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	l.created[owner] = append(l.prune(owner), l.now())
+}
+
+// RetryAfter returns the time until the owner will be allowed to create another object;
+// it returns 0 if the owner is not limited.
+func (l *CreationLimiter) RetryAfter(owner types.UID) time.Duration {
+	if !l.enabled() {
+		return 0
+	}
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	created := l.prune(owner)
+	if len(created) < l.limit {
+		return 0
+	}
+	// The oldest creations are at the beginning of the list; the owner is allowed to create again
+	// as soon as enough of them fall out of the window.
+	return created[len(created)-l.limit].Add(l.window).Sub(l.now())
+}
+
+// Limit returns the maximum number of objects an owner is allowed to create within the window.
+func (l *CreationLimiter) Limit() int {
+	if l == nil {
+		return 0
+	}
+	return l.limit
+}
+
+// Window returns the duration of the window the limit applies to.
+func (l *CreationLimiter) Window() time.Duration {
+	if l == nil {
+		return 0
+	}
+	return l.window
+}
+
+func (l *CreationLimiter) enabled() bool {
+	return l != nil && l.limit > 0
+}
+
+// prune drops the creations that are outside of the current window; it must be called with the lock held.
+func (l *CreationLimiter) prune(owner types.UID) []time.Time {
+	created := l.created[owner]
+	windowStart := l.now().Add(-l.window)
+
+	i := 0
+	for i < len(created) && !created[i].After(windowStart) {
+		i++
+	}
+	created = created[i:]
+
+	if len(created) == 0 {
+		delete(l.created, owner)
+		return nil
+	}
+	l.created[owner] = created
+	return created
+}
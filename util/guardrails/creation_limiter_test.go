@@ -0,0 +1,99 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package guardrails
+
+import (
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestCreationLimiter(t *testing.T) {
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	l := NewCreationLimiter(3, time.Minute)
+	l.now = func() time.Time { return now }
+
+	owner := types.UID("owner")
+	other := types.UID("other")
+
+	if got := l.Allow(owner, 5); got != 3 {
+		t.Fatalf("Allow() = %d, want 3", got)
+	}
+	for i := 0; i < 3; i++ {
+		l.Record(owner)
+		now = now.Add(10 * time.Second)
+	}
+
+	if got := l.Allow(owner, 1); got != 0 {
+		t.Fatalf("Allow() = %d, want 0 after reaching the limit", got)
+	}
+	if got := l.RetryAfter(owner); got != 30*time.Second {
+		t.Fatalf("RetryAfter() = %v, want 30s", got)
+	}
+	if got := l.Allow(other, 2); got != 2 {
+		t.Fatalf("Allow() = %d, want 2 for an owner without creations", got)
+	}
+
+	// Move past the first creation, which frees a slot.
+	now = now.Add(31 * time.Second)
+	if got := l.Allow(owner, 5); got != 1 {
+		t.Fatalf("Allow() = %d, want 1 after the oldest creation left the window", got)
+	}
+	if got := l.RetryAfter(owner); got != 0 {
+		t.Fatalf("RetryAfter() = %v, want 0", got)
+	}
+
+	// Move past all the creations.
+	now = now.Add(time.Minute)
+	if got := l.Allow(owner, 5); got != 3 {
+		t.Fatalf("Allow() = %d, want 3 after all the creations left the window", got)
+	}
+	if len(l.created) != 0 {
+		t.Fatalf("expected expired creations to be pruned, got %v", l.created)
+	}
+}
+
+func TestCreationLimiter_Disabled(t *testing.T) {
+	tests := []struct {
+		name string
+		l    *CreationLimiter
+	}{
+		{
+			name: "nil limiter",
+			l:    nil,
+		},
+		{
+			name: "zero limit",
+			l:    NewCreationLimiter(0, time.Minute),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			owner := types.UID("owner")
+			for i := 0; i < 10; i++ {
+				tt.l.Record(owner)
+			}
+			if got := tt.l.Allow(owner, 10); got != 10 {
+				t.Errorf("Allow() = %d, want 10", got)
+			}
+			if got := tt.l.RetryAfter(owner); got != 0 {
+				t.Errorf("RetryAfter() = %v, want 0", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,98 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package ipam implements helpers for infrastructure providers requesting IP addresses from IPAM providers
+// through IPAddressClaims.
+package ipam
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/util"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ClaimName returns the name of the IPAddressClaim for the address with the given index of an infrastructure machine.
+func ClaimName(ownerName string, index int) string {
+	return fmt.Sprintf("%s-%d", ownerName, index)
+}
+
+// EnsureClaim returns the IPAddressClaim with the given name, creating it if it does not exist. A created claim
+// references the given pool, is owned by the given owner, usually an infrastructure machine, so it is garbage collected
+// with it, and is labeled with the Cluster name.
+func EnsureClaim(ctx context.Context, c client.Client, namespace, name string, owner metav1.OwnerReference, clusterName string, poolRef corev1.TypedLocalObjectReference) (*clusterv1.IPAddressClaim, error) {
+	claim := &clusterv1.IPAddressClaim{}
+	key := client.ObjectKey{Namespace: namespace, Name: name}
+	if err := c.Get(ctx, key, claim); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return nil, errors.Wrapf(err, "failed to get IPAddressClaim %s", name)
+		}
+
+		claim = &clusterv1.IPAddressClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            name,
+				Namespace:       namespace,
+				Labels:          map[string]string{clusterv1.ClusterLabelName: clusterName},
+				OwnerReferences: []metav1.OwnerReference{owner},
+			},
+			Spec: clusterv1.IPAddressClaimSpec{
+				PoolRef: poolRef,
+			},
+		}
+		if err := c.Create(ctx, claim); err != nil {
+			return nil, errors.Wrapf(err, "failed to create IPAddressClaim %s", name)
+		}
+		return claim, nil
+	}
+
+	if !util.HasOwnerRef(claim.OwnerReferences, owner) {
+		return nil, errors.Errorf("IPAddressClaim %s is not owned by %s %s", name, owner.Kind, owner.Name)
+	}
+	if !reflect.DeepEqual(claim.Spec.PoolRef, poolRef) {
+		return nil, errors.Errorf("IPAddressClaim %s references the pool %s %s instead of %s %s", name,
+			claim.Spec.PoolRef.Kind, claim.Spec.PoolRef.Name, poolRef.Kind, poolRef.Name)
+	}
+	return claim, nil
+}
+
+// GetAddress returns the IPAddress allocated for the claim, or nil if the IPAM provider has not allocated it yet.
+// The IPAddress must reference the claim and its pool.
+func GetAddress(ctx context.Context, c client.Client, claim *clusterv1.IPAddressClaim) (*clusterv1.IPAddress, error) {
+	if claim.Status.AddressRef.Name == "" {
+		return nil, nil
+	}
+
+	address := &clusterv1.IPAddress{}
+	key := client.ObjectKey{Namespace: claim.Namespace, Name: claim.Status.AddressRef.Name}
+	if err := c.Get(ctx, key, address); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, errors.Wrapf(err, "failed to get IPAddress %s for IPAddressClaim %s", key.Name, claim.Name)
+	}
+
+	if address.Spec.ClaimRef.Name != claim.Name || !reflect.DeepEqual(address.Spec.PoolRef, claim.Spec.PoolRef) {
+		return nil, errors.Errorf("IPAddress %s was not allocated for IPAddressClaim %s", address.Name, claim.Name)
+	}
+	return address, nil
+}
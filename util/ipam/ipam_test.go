@@ -0,0 +1,100 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ipam
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/utils/pointer"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestEnsureClaimAndGetAddress(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	g.Expect(clusterv1.AddToScheme(scheme.Scheme)).To(Succeed())
+	c := fake.NewFakeClientWithScheme(scheme.Scheme)
+
+	owner := metav1.OwnerReference{
+		APIVersion: "infrastructure.cluster.x-k8s.io/v1alpha3",
+		Kind:       "VSphereMachine",
+		Name:       "machine-1",
+		UID:        "uid",
+	}
+	poolRef := corev1.TypedLocalObjectReference{APIGroup: pointer.StringPtr("ipam.example.com"), Kind: "Pool", Name: "pool"}
+	name := ClaimName(owner.Name, 0)
+	g.Expect(name).To(Equal("machine-1-0"))
+
+	claim, err := EnsureClaim(ctx, c, "default", name, owner, "cluster", poolRef)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(claim.Labels).To(HaveKeyWithValue(clusterv1.ClusterLabelName, "cluster"))
+	g.Expect(claim.OwnerReferences).To(ConsistOf(owner))
+
+	// The claim is returned as is if it already exists.
+	claim, err = EnsureClaim(ctx, c, "default", name, owner, "cluster", poolRef)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	// Claims referencing another pool or owned by another object can't be reused.
+	otherPool := poolRef
+	otherPool.Name = "other"
+	_, err = EnsureClaim(ctx, c, "default", name, owner, "cluster", otherPool)
+	g.Expect(err).To(HaveOccurred())
+	otherOwner := owner
+	otherOwner.Name = "machine-2"
+	_, err = EnsureClaim(ctx, c, "default", name, otherOwner, "cluster", poolRef)
+	g.Expect(err).To(HaveOccurred())
+
+	// No address is returned until it is allocated.
+	address, err := GetAddress(ctx, c, claim)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(address).To(BeNil())
+
+	g.Expect(c.Create(ctx, &clusterv1.IPAddress{
+		ObjectMeta: metav1.ObjectMeta{Name: "address", Namespace: "default"},
+		Spec: clusterv1.IPAddressSpec{
+			ClaimRef: corev1.LocalObjectReference{Name: name},
+			PoolRef:  poolRef,
+			Address:  "10.0.0.10",
+			Prefix:   24,
+		},
+	})).To(Succeed())
+	claim.Status.AddressRef.Name = "address"
+	address, err = GetAddress(ctx, c, claim)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(address.Spec.Address).To(Equal("10.0.0.10"))
+
+	// Addresses allocated for other claims are rejected.
+	g.Expect(c.Create(ctx, &clusterv1.IPAddress{
+		ObjectMeta: metav1.ObjectMeta{Name: "other-address", Namespace: "default"},
+		Spec: clusterv1.IPAddressSpec{
+			ClaimRef: corev1.LocalObjectReference{Name: "other-claim"},
+			PoolRef:  poolRef,
+			Address:  "10.0.0.11",
+			Prefix:   24,
+		},
+	})).To(Succeed())
+	claim.Status.AddressRef.Name = "other-address"
+	_, err = GetAddress(ctx, c, claim)
+	g.Expect(err).To(HaveOccurred())
+}
@@ -0,0 +1,78 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package secret
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+var (
+	// ErrMissingBootstrapData occurs when a bootstrap data secret has no bootstrap data.
+	ErrMissingBootstrapData = errors.New("missing bootstrap data")
+)
+
+// GetBootstrapData retrieves the bootstrap data of a Machine, and its format, from the secret
+// referenced by the Machine's spec.bootstrap.dataSecretName.
+func GetBootstrapData(ctx context.Context, c client.Client, machine *clusterv1.Machine) ([]byte, clusterv1.BootstrapDataFormat, error) {
+	if machine.Spec.Bootstrap.DataSecretName == nil {
+		return nil, "", errors.Errorf("bootstrap data secret name of Machine %s/%s is not set", machine.Namespace, machine.Name)
+	}
+
+	s := &corev1.Secret{}
+	key := client.ObjectKey{Namespace: machine.Namespace, Name: *machine.Spec.Bootstrap.DataSecretName}
+	if err := c.Get(ctx, key, s); err != nil {
+		return nil, "", errors.Wrapf(err, "failed to get bootstrap data secret %s for Machine %s/%s", key.Name, machine.Namespace, machine.Name)
+	}
+
+	return BootstrapDataFromSecret(s)
+}
+
+// BootstrapDataFromSecret returns the bootstrap data stored in a bootstrap data secret, and its format.
+// The secrets without a format, e.g. the ones created by bootstrap providers implementing the previous
+// version of the contract, hold cloud-config bootstrap data.
+func BootstrapDataFromSecret(s *corev1.Secret) ([]byte, clusterv1.BootstrapDataFormat, error) {
+	value, ok := s.Data[clusterv1.BootstrapDataSecretValueKey]
+	if !ok {
+		return nil, "", errors.Wrapf(ErrMissingBootstrapData, "secret %s/%s has no %q key", s.Namespace, s.Name, clusterv1.BootstrapDataSecretValueKey)
+	}
+
+	format := clusterv1.BootstrapDataFormatCloudConfig
+	if f := s.Data[clusterv1.BootstrapDataSecretFormatKey]; len(f) > 0 {
+		format = clusterv1.BootstrapDataFormat(f)
+	}
+	return value, format, nil
+}
+
+// SetBootstrapData stores the bootstrap data and its format in a bootstrap data secret, and marks the secret
+// with the version of the bootstrap data contract.
+func SetBootstrapData(s *corev1.Secret, data []byte, format clusterv1.BootstrapDataFormat) {
+	if s.Data == nil {
+		s.Data = map[string][]byte{}
+	}
+	s.Data[clusterv1.BootstrapDataSecretValueKey] = data
+	s.Data[clusterv1.BootstrapDataSecretFormatKey] = []byte(format)
+
+	if s.Annotations == nil {
+		s.Annotations = map[string]string{}
+	}
+	s.Annotations[clusterv1.BootstrapDataContractVersionAnnotation] = clusterv1.BootstrapDataContractVersion
+}
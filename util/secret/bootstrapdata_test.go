@@ -0,0 +1,91 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package secret_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/pointer"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/util/secret"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestSetBootstrapData(t *testing.T) {
+	s := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "bootstrap-data"}}
+	secret.SetBootstrapData(s, []byte("{}"), clusterv1.BootstrapDataFormatIgnition)
+
+	if got := s.Annotations[clusterv1.BootstrapDataContractVersionAnnotation]; got != clusterv1.BootstrapDataContractVersion {
+		t.Errorf("got contract version %q, want %q", got, clusterv1.BootstrapDataContractVersion)
+	}
+	data, format, err := secret.BootstrapDataFromSecret(s)
+	if err != nil {
+		t.Fatalf("BootstrapDataFromSecret() error = %v", err)
+	}
+	if !bytes.Equal(data, []byte("{}")) || format != clusterv1.BootstrapDataFormatIgnition {
+		t.Errorf("BootstrapDataFromSecret() = %q, %q, want %q, %q", data, format, "{}", clusterv1.BootstrapDataFormatIgnition)
+	}
+}
+
+func TestBootstrapDataFromSecret_PreviousContract(t *testing.T) {
+	s := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "bootstrap-data"},
+		Data:       map[string][]byte{"value": []byte("#cloud-config")},
+	}
+	data, format, err := secret.BootstrapDataFromSecret(s)
+	if err != nil {
+		t.Fatalf("BootstrapDataFromSecret() error = %v", err)
+	}
+	if !bytes.Equal(data, []byte("#cloud-config")) || format != clusterv1.BootstrapDataFormatCloudConfig {
+		t.Errorf("BootstrapDataFromSecret() = %q, %q, want the data in the cloud-config format", data, format)
+	}
+
+	s.Data = nil
+	if _, _, err := secret.BootstrapDataFromSecret(s); errors.Cause(err) != secret.ErrMissingBootstrapData {
+		t.Errorf("BootstrapDataFromSecret() error = %v, want %v", err, secret.ErrMissingBootstrapData)
+	}
+}
+
+func TestGetBootstrapData(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := corev1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	s := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "bootstrap-data"}}
+	secret.SetBootstrapData(s, []byte("#cloud-config"), clusterv1.BootstrapDataFormatCloudConfig)
+	c := fake.NewFakeClientWithScheme(scheme, s)
+
+	machine := &clusterv1.Machine{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "machine"}}
+	if _, _, err := secret.GetBootstrapData(context.Background(), c, machine); err == nil {
+		t.Error("GetBootstrapData() succeeded, want an error for a Machine without bootstrap data secret")
+	}
+
+	machine.Spec.Bootstrap.DataSecretName = pointer.StringPtr("bootstrap-data")
+	data, format, err := secret.GetBootstrapData(context.Background(), c, machine)
+	if err != nil {
+		t.Fatalf("GetBootstrapData() error = %v", err)
+	}
+	if !bytes.Equal(data, []byte("#cloud-config")) || format != clusterv1.BootstrapDataFormatCloudConfig {
+		t.Errorf("GetBootstrapData() = %q, %q, want the data in the cloud-config format", data, format)
+	}
+}
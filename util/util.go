@@ -397,6 +397,84 @@ func HasOwner(refList []metav1.OwnerReference, apiVersion string, kinds []string
 	return false
 }
 
+// GetPropagatedLabels returns the Cluster labels selected for propagation to all the objects belonging to the Cluster
+// using the `propagate-labels` annotation.
+func GetPropagatedLabels(cluster *clusterv1.Cluster) map[string]string {
+	labels := map[string]string{}
+
+	keys, ok := cluster.GetAnnotations()[clusterv1.PropagateLabelsAnnotation]
+	if !ok {
+		return labels
+	}
+
+	for _, key := range strings.Split(keys, ",") {
+		key = strings.TrimSpace(key)
+		if key == "" {
+			continue
+		}
+		if value, ok := cluster.Labels[key]; ok {
+			labels[key] = value
+		}
+	}
+	return labels
+}
+
+// IsNodeMetadataKey returns true if the label or annotation with the given key is in the NodeMetadataDomain, or in one
+// of its subdomains, or in the NodeRoleLabelDomain; such labels and annotations are synced from the Machines to their
+// Nodes, and their changes on the templates of MachineDeployments and control planes are applied in place.
+func IsNodeMetadataKey(key string) bool {
+	i := strings.Index(key, "/")
+	if i < 0 {
+		return false
+	}
+	domain := key[:i]
+	return domain == clusterv1.NodeMetadataDomain ||
+		strings.HasSuffix(domain, "."+clusterv1.NodeMetadataDomain) ||
+		domain == clusterv1.NodeRoleLabelDomain
+}
+
+// GetNodeMetadata returns the labels or annotations with a key accepted by IsNodeMetadataKey.
+func GetNodeMetadata(m map[string]string) map[string]string {
+	ret := map[string]string{}
+	for key, value := range m {
+		if IsNodeMetadataKey(key) {
+			ret[key] = value
+		}
+	}
+	return ret
+}
+
+// SyncNodeMetadata sets the labels or annotations with a key accepted by IsNodeMetadataKey in dst to the ones in src,
+// removing the ones missing in src; all the other keys in dst are preserved. It returns the updated map, allocated if
+// dst is nil, and true if it was changed.
+func SyncNodeMetadata(dst, src map[string]string) (map[string]string, bool) {
+	changed := false
+	for key := range dst {
+		if _, ok := src[key]; !ok && IsNodeMetadataKey(key) {
+			delete(dst, key)
+			changed = true
+		}
+	}
+	for key, value := range GetNodeMetadata(src) {
+		if current, ok := dst[key]; ok && current == value {
+			continue
+		}
+		if dst == nil {
+			dst = map[string]string{}
+		}
+		dst[key] = value
+		changed = true
+	}
+	return dst, changed
+}
+
+// IsExternallyManaged returns true if the object has the `managed-by` annotation, i.e. it is managed by an external
+// system instead of a Cluster API provider.
+func IsExternallyManaged(o metav1.Object) bool {
+	_, ok := o.GetAnnotations()[clusterv1.ManagedByAnnotation]
+	return ok
+}
+
 // IsPaused returns true if the Cluster is paused or the object has the `paused` annotation.
 func IsPaused(cluster *clusterv1.Cluster, v metav1.Object) bool {
 	if cluster.Spec.Paused {
@@ -410,3 +488,28 @@ func IsPaused(cluster *clusterv1.Cluster, v metav1.Object) bool {
 	_, ok := annotations[clusterv1.PausedAnnotation]
 	return ok
 }
+
+// PropagatePause propagates the pause of a Cluster to an object belonging to it: if the Cluster is paused the `paused`
+// annotation is added to the object, if the Cluster is not paused the `paused` annotation is removed, but only if it
+// was added by PropagatePause. It returns true if the annotations of the object are changed.
+func PropagatePause(cluster *clusterv1.Cluster, o metav1.Object) bool {
+	annotations := o.GetAnnotations()
+	_, paused := annotations[clusterv1.PausedAnnotation]
+	_, propagated := annotations[clusterv1.PausePropagatedAnnotation]
+
+	switch {
+	case cluster.Spec.Paused && !paused:
+		if annotations == nil {
+			annotations = map[string]string{}
+		}
+		annotations[clusterv1.PausedAnnotation] = "true"
+		annotations[clusterv1.PausePropagatedAnnotation] = cluster.Name
+	case !cluster.Spec.Paused && propagated:
+		delete(annotations, clusterv1.PausedAnnotation)
+		delete(annotations, clusterv1.PausePropagatedAnnotation)
+	default:
+		return false
+	}
+	o.SetAnnotations(annotations)
+	return true
+}
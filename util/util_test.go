@@ -260,6 +260,174 @@ func TestHasOwner(t *testing.T) {
 	}
 }
 
+func TestGetPropagatedLabels(t *testing.T) {
+	tests := []struct {
+		name        string
+		labels      map[string]string
+		annotations map[string]string
+		expected    map[string]string
+	}{
+		{
+			name:     "no propagate-labels annotation",
+			labels:   map[string]string{"env": "prod"},
+			expected: map[string]string{},
+		},
+		{
+			name:        "propagates only the selected labels",
+			labels:      map[string]string{"env": "prod", "team": "a", "cost-center": "42"},
+			annotations: map[string]string{clusterv1.PropagateLabelsAnnotation: "env, team"},
+			expected:    map[string]string{"env": "prod", "team": "a"},
+		},
+		{
+			name:        "ignores selected labels missing on the cluster",
+			labels:      map[string]string{"env": "prod"},
+			annotations: map[string]string{clusterv1.PropagateLabelsAnnotation: "env,,team"},
+			expected:    map[string]string{"env": "prod"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cluster := &clusterv1.Cluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "test-cluster",
+					Labels:      test.labels,
+					Annotations: test.annotations,
+				},
+			}
+			result := GetPropagatedLabels(cluster)
+			if !reflect.DeepEqual(test.expected, result) {
+				t.Errorf("expected propagated labels to be %v, got %v", test.expected, result)
+			}
+		})
+	}
+}
+
+func TestPropagatePause(t *testing.T) {
+	propagated := map[string]string{clusterv1.PausedAnnotation: "true", clusterv1.PausePropagatedAnnotation: "test-cluster"}
+
+	tests := []struct {
+		name            string
+		paused          bool
+		annotations     map[string]string
+		expected        map[string]string
+		expectedChanged bool
+	}{
+		{
+			name:            "adds the paused annotation when the cluster is paused",
+			paused:          true,
+			annotations:     nil,
+			expected:        propagated,
+			expectedChanged: true,
+		},
+		{
+			name:            "does not change an object already paused by the user when the cluster is paused",
+			paused:          true,
+			annotations:     map[string]string{clusterv1.PausedAnnotation: ""},
+			expected:        map[string]string{clusterv1.PausedAnnotation: ""},
+			expectedChanged: false,
+		},
+		{
+			name:            "removes the propagated paused annotation when the cluster is resumed",
+			paused:          false,
+			annotations:     map[string]string{clusterv1.PausedAnnotation: "true", clusterv1.PausePropagatedAnnotation: "test-cluster", "foo": "bar"},
+			expected:        map[string]string{"foo": "bar"},
+			expectedChanged: true,
+		},
+		{
+			name:            "does not remove the paused annotation set by the user when the cluster is resumed",
+			paused:          false,
+			annotations:     map[string]string{clusterv1.PausedAnnotation: ""},
+			expected:        map[string]string{clusterv1.PausedAnnotation: ""},
+			expectedChanged: false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cluster := &clusterv1.Cluster{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-cluster"},
+				Spec:       clusterv1.ClusterSpec{Paused: test.paused},
+			}
+			obj := &metav1.ObjectMeta{Annotations: test.annotations}
+
+			changed := PropagatePause(cluster, obj)
+			if changed != test.expectedChanged {
+				t.Errorf("expected PropagatePause to return %v, got %v", test.expectedChanged, changed)
+			}
+			if !reflect.DeepEqual(test.expected, obj.Annotations) {
+				t.Errorf("expected annotations to be %v, got %v", test.expected, obj.Annotations)
+			}
+		})
+	}
+}
+
+func TestIsNodeMetadataKey(t *testing.T) {
+	tests := []struct {
+		key      string
+		expected bool
+	}{
+		{key: "node.cluster.x-k8s.io/gpu", expected: true},
+		{key: "team.node.cluster.x-k8s.io/owner", expected: true},
+		{key: "node-role.kubernetes.io/worker", expected: true},
+		{key: "cluster.x-k8s.io/cluster-name", expected: false},
+		{key: "evilnode.cluster.x-k8s.io/gpu", expected: false},
+		{key: "node.cluster.x-k8s.io", expected: false},
+		{key: "env", expected: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.key, func(t *testing.T) {
+			if result := IsNodeMetadataKey(test.key); result != test.expected {
+				t.Errorf("expected IsNodeMetadataKey(%q) to be %v, got %v", test.key, test.expected, result)
+			}
+		})
+	}
+}
+
+func TestSyncNodeMetadata(t *testing.T) {
+	tests := []struct {
+		name            string
+		dst             map[string]string
+		src             map[string]string
+		expected        map[string]string
+		expectedChanged bool
+	}{
+		{
+			name:            "adds node metadata to a nil map",
+			src:             map[string]string{"node.cluster.x-k8s.io/gpu": "true", "env": "prod"},
+			expected:        map[string]string{"node.cluster.x-k8s.io/gpu": "true"},
+			expectedChanged: true,
+		},
+		{
+			name:            "updates and removes node metadata, preserving other keys",
+			dst:             map[string]string{"node.cluster.x-k8s.io/gpu": "true", "node-role.kubernetes.io/infra": "", "env": "prod"},
+			src:             map[string]string{"node.cluster.x-k8s.io/gpu": "false"},
+			expected:        map[string]string{"node.cluster.x-k8s.io/gpu": "false", "env": "prod"},
+			expectedChanged: true,
+		},
+		{
+			name:            "does not change node metadata already in sync",
+			dst:             map[string]string{"node.cluster.x-k8s.io/gpu": "true", "env": "prod"},
+			src:             map[string]string{"node.cluster.x-k8s.io/gpu": "true", "env": "dev"},
+			expected:        map[string]string{"node.cluster.x-k8s.io/gpu": "true", "env": "prod"},
+			expectedChanged: false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result, changed := SyncNodeMetadata(test.dst, test.src)
+			if !reflect.DeepEqual(test.expected, result) {
+				t.Errorf("expected %v, got %v", test.expected, result)
+			}
+			if changed != test.expectedChanged {
+				t.Errorf("expected changed to be %v, got %v", test.expectedChanged, changed)
+			}
+		})
+	}
+}
+
 func TestPointsTo(t *testing.T) {
 	targetID := "fri3ndsh1p"
 
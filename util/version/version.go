@@ -0,0 +1,75 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package version implements the Kubernetes version skew rules enforced when upgrading workload clusters.
+package version
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/util/version"
+)
+
+// ValidateUpgrade returns an error if the upgrade from a Kubernetes version to another skips a minor version,
+// e.g. from v1.16.x to v1.18.y, because kubeadm supports upgrading one minor version at a time.
+// Downgrades are not checked, as well as versions which can't be parsed, which are reported by other validations.
+func ValidateUpgrade(from, to string) error {
+	fromVersion, err := version.ParseGeneric(from)
+	if err != nil {
+		return nil
+	}
+	toVersion, err := version.ParseGeneric(to)
+	if err != nil {
+		return nil
+	}
+
+	if toVersion.Major() != fromVersion.Major() {
+		if toVersion.Major() > fromVersion.Major() {
+			return errors.Errorf("upgrading from %s to %s changes the major version, which is not supported", from, to)
+		}
+		return nil
+	}
+	if toVersion.Minor() > fromVersion.Minor()+1 {
+		return errors.Errorf("upgrading from %s to %s skips the %s minor version, upgrade one minor version at a time",
+			from, to, majorMinor(fromVersion.Major(), fromVersion.Minor()+1))
+	}
+	return nil
+}
+
+// ValidateWorkerSkew returns an error if the Kubernetes version of the workers is newer than the version of the
+// control plane, because the kubelet must not be newer than the API server; only major and minor versions are
+// compared. Versions which can't be parsed are not checked.
+func ValidateWorkerSkew(controlPlane, worker string) error {
+	controlPlaneVersion, err := version.ParseGeneric(controlPlane)
+	if err != nil {
+		return nil
+	}
+	workerVersion, err := version.ParseGeneric(worker)
+	if err != nil {
+		return nil
+	}
+
+	if workerVersion.Major() > controlPlaneVersion.Major() ||
+		(workerVersion.Major() == controlPlaneVersion.Major() && workerVersion.Minor() > controlPlaneVersion.Minor()) {
+		return errors.Errorf("the workers version %s is newer than the control plane version %s, the workers must not be newer than the control plane", worker, controlPlane)
+	}
+	return nil
+}
+
+func majorMinor(major, minor uint) string {
+	return fmt.Sprintf("v%d.%d", major, minor)
+}
@@ -0,0 +1,67 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package version
+
+import (
+	"testing"
+)
+
+func TestValidateUpgrade(t *testing.T) {
+	tests := []struct {
+		from    string
+		to      string
+		wantErr bool
+	}{
+		{from: "v1.16.2", to: "v1.16.8"},
+		{from: "v1.16.2", to: "v1.17.0"},
+		{from: "1.16.2", to: "v1.17.3"},
+		{from: "v1.17.3", to: "v1.16.2"},
+		{from: "v1.16.2", to: "v1.18.0", wantErr: true},
+		{from: "v1.16.2", to: "v2.0.0", wantErr: true},
+		{from: "foo", to: "v1.18.0"},
+		{from: "v1.16.2", to: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.from+"->"+tt.to, func(t *testing.T) {
+			if err := ValidateUpgrade(tt.from, tt.to); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateUpgrade() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateWorkerSkew(t *testing.T) {
+	tests := []struct {
+		controlPlane string
+		worker       string
+		wantErr      bool
+	}{
+		{controlPlane: "v1.17.3", worker: "v1.17.3"},
+		{controlPlane: "v1.17.3", worker: "v1.17.8"},
+		{controlPlane: "v1.17.3", worker: "v1.16.2"},
+		{controlPlane: "v1.17.3", worker: "v1.18.0", wantErr: true},
+		{controlPlane: "v1.17.3", worker: "v2.0.0", wantErr: true},
+		{controlPlane: "", worker: "v1.18.0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.controlPlane+"/"+tt.worker, func(t *testing.T) {
+			if err := ValidateWorkerSkew(tt.controlPlane, tt.worker); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateWorkerSkew() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
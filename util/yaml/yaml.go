@@ -19,6 +19,7 @@ package yaml
 import (
 	"bufio"
 	"bytes"
+	"fmt"
 	"io"
 	"os"
 
@@ -177,6 +178,61 @@ func (d *yamlDecoder) Close() error {
 	return d.close()
 }
 
+// ToUnstructured takes a YAML and converts it to a list of Unstructured objects.
+// Unlike Parse, objects whose type is not registered in the scheme are retained.
+func ToUnstructured(rawyaml []byte) ([]unstructured.Unstructured, error) {
+	var ret []unstructured.Unstructured
+
+	reader := yaml.NewYAMLReader(bufio.NewReader(bytes.NewReader(rawyaml)))
+	count := 1
+	for {
+		// Read one YAML document at a time, until io.EOF is returned
+		b, err := reader.Read()
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, errors.Wrapf(err, "failed to read yaml")
+		}
+		if len(bytes.TrimSpace(b)) == 0 {
+			continue
+		}
+
+		var m map[string]interface{}
+		if err := yaml.Unmarshal(b, &m); err != nil {
+			return nil, errors.Wrapf(err, "failed to unmarshal the %s yaml document: %q", ordinal(count), string(b))
+		}
+		// Skip documents containing only comments.
+		if len(m) == 0 {
+			count++
+			continue
+		}
+
+		var u unstructured.Unstructured
+		u.SetUnstructuredContent(m)
+		ret = append(ret, u)
+		count++
+	}
+
+	return ret, nil
+}
+
+func ordinal(x int) string {
+	suffix := "th"
+	switch x % 10 {
+	case 1:
+		suffix = "st"
+	case 2:
+		suffix = "nd"
+	case 3:
+		suffix = "rd"
+	}
+	if x%100 >= 11 && x%100 <= 13 {
+		suffix = "th"
+	}
+	return fmt.Sprintf("%d%s", x, suffix)
+}
+
 func NewYAMLDecoder(r io.ReadCloser) streaming.Decoder {
 	return &yamlDecoder{
 		reader:  yaml.NewYAMLReader(bufio.NewReader(r)),
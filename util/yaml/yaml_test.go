@@ -321,3 +321,61 @@ func createTempFile(contents string) (filename string, reterr error) {
 	_, _ = f.WriteString(contents)
 	return f.Name(), nil
 }
+
+func TestToUnstructured(t *testing.T) {
+	var testcases = []struct {
+		name          string
+		contents      string
+		expectedKinds []string
+		expectErr     bool
+	}{
+		{
+			name: "multiple documents including types not registered in the scheme",
+			contents: `
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: cm1
+---
+# only comments
+---
+apiVersion: crd.projectcalico.org/v1
+kind: IPPool
+metadata:
+  name: default-ipv4-ippool
+spec:
+  blockSize: 26
+`,
+			expectedKinds: []string{"ConfigMap", "IPPool"},
+		},
+		{
+			name:      "invalid yaml",
+			contents:  "apiVersion: v1\nkind: [ConfigMap",
+			expectErr: true,
+		},
+	}
+
+	for _, testcase := range testcases {
+		t.Run(testcase.name, func(t *testing.T) {
+			objs, err := ToUnstructured([]byte(testcase.contents))
+			if testcase.expectErr {
+				if err == nil {
+					t.Fatal("expected an error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(objs) != len(testcase.expectedKinds) {
+				t.Fatalf("expected %d objects, got %d", len(testcase.expectedKinds), len(objs))
+			}
+			for i, kind := range testcase.expectedKinds {
+				if objs[i].GetKind() != kind {
+					t.Errorf("expected object %d to be a %s, got %s", i, kind, objs[i].GetKind())
+				}
+			}
+		})
+	}
+}